@@ -19,7 +19,7 @@ import os
 import sys
 import time
 import uuid
-from collections import defaultdict
+from collections import defaultdict, deque
 from datetime import datetime, timedelta
 from typing import Dict, List, Optional, Any, Callable, Set
 from dataclasses import dataclass, asdict, field
@@ -56,6 +56,15 @@ class Config:
     CREDIT_EARNING_RATE = float(os.getenv("DEPARROW_CREDIT_RATE", "0.1"))  # credits per CPU-hour
     CREDIT_SUBMISSION_COST = float(os.getenv("DEPARROW_SUBMISSION_COST", "1.0"))
     MIN_CREDIT_BALANCE = float(os.getenv("DEPARROW_MIN_BALANCE", "10.0"))
+    BALANCE_SNAPSHOT_INTERVAL = int(os.getenv("DEPARROW_BALANCE_SNAPSHOT_INTERVAL", "3600"))  # seconds
+    BALANCE_SNAPSHOT_RETENTION_DAYS = int(os.getenv("DEPARROW_BALANCE_SNAPSHOT_RETENTION_DAYS", "400"))
+    MAX_BALANCE_HISTORY_POINTS = 1000
+
+    # Real-time events kept for resuming clients, and how long an event
+    # poll may be held open (seconds)
+    EVENT_RETENTION = int(os.getenv("DEPARROW_EVENT_RETENTION", "1000"))
+    EVENT_POLL_WAIT = 25
+    EVENT_POLL_MAX_WAIT = 60
     
     # Network
     ORCHESTRATOR_PORT = 4222
@@ -86,6 +95,7 @@ class NodeRegistration(BaseModel):
     resources: Dict[str, Any]
     arch: NodeArchitecture
     labels: Dict[str, str] = Field(default_factory=dict)
+    agent_version: str = ""
 
 class JobSubmission(BaseModel):
     """Job submission request"""
@@ -190,6 +200,8 @@ class Node:
     memory_gb: float = 0.0
     live_gflops: float = 0.0
     location: Dict[str, float] = None  # {"lat": 0, "lng": 0}
+    # Node-agent software version reported at registration
+    agent_version: str = ""
     
     def __post_init__(self):
         if self.labels is None:
@@ -210,6 +222,61 @@ class Node:
             return ContributionTier.SILVER
         return ContributionTier.BRONZE
 
+# Node-agent release history per architecture, newest first.
+# The first entry is the recommended version on the stable channel.
+NODE_AGENT_RELEASES: Dict[str, List[Dict[str, Any]]] = {
+    arch.value: [
+        {
+            'version': '1.6.0',
+            'released_at': '2026-10-01T00:00:00Z',
+            'security_fix': False,
+            'earnings_multiplier': 1.10,
+            'min_supported': '1.5.0',
+            'notes': '10% earnings bonus for nodes with verified uptime'
+        },
+        {
+            'version': '1.5.2',
+            'released_at': '2026-09-01T00:00:00Z',
+            'security_fix': True,
+            'earnings_multiplier': 1.0,
+            'min_supported': '1.5.0',
+            'notes': 'Fixes a container escape in job isolation'
+        },
+        {
+            'version': '1.5.0',
+            'released_at': '2026-07-15T00:00:00Z',
+            'security_fix': False,
+            'earnings_multiplier': 1.0,
+            'min_supported': '1.4.0',
+            'notes': 'Faster job start-up and GPU telemetry'
+        },
+        {
+            'version': '1.4.0',
+            'released_at': '2026-04-15T00:00:00Z',
+            'security_fix': False,
+            'earnings_multiplier': 1.0,
+            'min_supported': '1.3.0',
+            'notes': ''
+        },
+    ]
+    for arch in NodeArchitecture
+}
+
+
+def version_tuple(version: str) -> tuple:
+    """Parse a dotted version such as 'v1.5.2' for comparison"""
+    version = version.strip().lstrip('v').split('-')[0].split('+')[0]
+    parts = []
+    for part in version.split('.'):
+        try:
+            parts.append(int(part))
+        except ValueError:
+            parts.append(0)
+    while parts and parts[-1] == 0:
+        parts.pop()
+    return tuple(parts)
+
+
 @dataclass
 class User:
     user_id: str
@@ -219,6 +286,59 @@ class User:
     last_active: datetime
 
 
+@dataclass
+class BalanceSnapshot:
+    timestamp: datetime
+    balance: float
+
+
+def balance_history(snapshots: List[BalanceSnapshot], end: datetime,
+                    period: timedelta, resolution: timedelta) -> Dict[str, Any]:
+    """Bucket snapshots, oldest first, into one point per resolution over the
+    period ending at end. Intervals are aligned to multiples of the resolution
+    since the epoch, so daily points start at midnight UTC. Intervals before the
+    first snapshot have no point; later ones without a snapshot carry the
+    previous balance forward."""
+    epoch = datetime(1970, 1, 1)
+    start = end - period
+    start -= (start - epoch) % resolution
+
+    current = 0.0
+    started = False
+    i = 0
+    while i < len(snapshots) and snapshots[i].timestamp <= start:
+        current = snapshots[i].balance
+        started = True
+        i += 1
+    start_balance = current
+    previous = current
+
+    points = []
+    t = start
+    while t < end:
+        next_t = t + resolution
+        while i < len(snapshots) and snapshots[i].timestamp < next_t:
+            if not started:
+                start_balance = previous = snapshots[i].balance
+                started = True
+            current = snapshots[i].balance
+            i += 1
+        if started:
+            points.append({
+                'timestamp': t.isoformat() + 'Z',
+                'balance': current,
+                'change': current - previous
+            })
+            previous = current
+        t = next_t
+
+    return {
+        'start_balance': start_balance,
+        'end_balance': current,
+        'points': points
+    }
+
+
 @dataclass
 class Orchestrator:
     orchestrator_id: str
@@ -227,6 +347,10 @@ class Orchestrator:
     node_count: int
     status: NodeStatus
     registered_at: datetime
+    region: str = 'global'
+    version: str = ''
+    features: List[str] = field(default_factory=list)
+    max_jobs: int = 100
 
 
 @dataclass
@@ -279,6 +403,11 @@ class WebSocketManager:
         self.agent_connections: Dict[str, Set[str]] = defaultdict(set)  # agent_id -> set of client_ids
         self.subscriptions: Dict[str, Set[str]] = defaultdict(set)  # client_id -> set of channels
         self._lock = asyncio.Lock()
+        # Recent broadcasts as (event_id, channel, message), for clients
+        # that long-poll or resume after a dropped connection
+        self.events: deque = deque(maxlen=Config.EVENT_RETENTION)
+        self.last_event_id = 0
+        self._event_posted = asyncio.Condition()
     
     async def connect(self, client_id: str, ws: web.WebSocketResponse):
         """Register a new WebSocket connection"""
@@ -315,7 +444,15 @@ class WebSocketManager:
             self.agent_connections[agent_id].add(client_id)
     
     async def broadcast(self, channel: str, message: Dict[str, Any]):
-        """Broadcast message to all subscribers of a channel"""
+        """Broadcast message to all subscribers of a channel.
+
+        Every message gets an event_id, the cursor clients resume after."""
+        async with self._event_posted:
+            self.last_event_id += 1
+            message = {**message, 'event_id': self.last_event_id, 'channel': channel}
+            self.events.append((self.last_event_id, channel, message))
+            self._event_posted.notify_all()
+
         message_json = json.dumps(message)
         disconnected = []
         
@@ -358,6 +495,35 @@ class WebSocketManager:
                 await self.disconnect(client_id)
         return False
     
+    def _events_after(self, cursor: int, channels: Set[str]) -> List[Dict[str, Any]]:
+        """Retained messages on channels with an event_id above cursor"""
+        return [message for event_id, channel, message in self.events
+                if event_id > cursor and channel in channels]
+
+    async def poll_events(self, channels: Set[str], cursor: Optional[int], wait: float):
+        """Return the messages on channels after cursor, waiting up to wait
+        seconds for the first one. A cursor of None starts after the latest
+        event. reset is set when events after cursor were not retained, or
+        the cursor is from before a restart, so the client must refetch its
+        state. Returns (messages, next cursor, reset)."""
+        async with self._event_posted:
+            reset = False
+            if cursor is None or cursor > self.last_event_id:
+                reset = cursor is not None
+                cursor = self.last_event_id
+            elif self.events and self.events[0][0] > cursor + 1:
+                reset = True
+
+            if not reset and wait > 0:
+                try:
+                    await asyncio.wait_for(
+                        self._event_posted.wait_for(lambda: self._events_after(cursor, channels)),
+                        timeout=wait
+                    )
+                except asyncio.TimeoutError:
+                    pass
+            return self._events_after(cursor, channels), self.last_event_id, reset
+
     def get_connection_count(self) -> int:
         """Get total number of connections"""
         return len(self.connections)
@@ -554,6 +720,7 @@ class DEparrowBootstrapServer:
         self.orchestrators: Dict[str, Orchestrator] = {}
         self.users: Dict[str, User] = {}
         self.jobs: Dict[str, JobSubmission] = {}
+        self.balance_snapshots: Dict[str, List[BalanceSnapshot]] = defaultdict(list)
         
         # PicoClaw Agents
         self.agents: Dict[str, Agent] = {}
@@ -571,7 +738,9 @@ class DEparrowBootstrapServer:
             port=4222,
             node_count=0,
             status=NodeStatus.ONLINE,
-            registered_at=datetime.utcnow()
+            registered_at=datetime.utcnow(),
+            version='1.5.0',
+            features=['docker', 'wasm', 'gpu']
         )
         
         # Sample user
@@ -603,6 +772,10 @@ class DEparrowBootstrapServer:
         self.app.router.add_get('/api/v1/nodes/{node_id}', self.get_node)
         self.app.router.add_post('/api/v1/nodes/{node_id}/heartbeat', self.node_heartbeat)
         
+        # Node software update routes
+        self.app.router.add_get('/api/v1/node-agent/releases', self.list_node_agent_releases)
+        self.app.router.add_get('/api/v1/nodes/{node_id}/update-advisory', self.get_update_advisory)
+        
         # Contribution tracking routes
         self.app.router.add_get('/api/v1/nodes/{node_id}/contribution', self.get_node_contribution)
         self.app.router.add_get('/api/v1/network/contribution', self.get_network_contribution)
@@ -622,6 +795,8 @@ class DEparrowBootstrapServer:
         self.app.router.add_post('/api/v1/credits/check', self.check_credits)
         self.app.router.add_post('/api/v1/credits/transfer', self.transfer_credits)
         self.app.router.add_get('/api/v1/credits/balance/{user_id}', self.get_credit_balance)
+        self.app.router.add_get('/api/v1/credits/balance/{user_id}/history', self.get_balance_history)
+        self.app.router.add_get('/api/v1/credits/history', self.get_balance_history)
         
         # ============ PicoClaw Agent Routes ============
         # Agent management
@@ -639,6 +814,7 @@ class DEparrowBootstrapServer:
         
         # WebSocket endpoint
         self.app.router.add_get('/api/v1/ws', self.websocket_handler)
+        self.app.router.add_get('/api/v1/events/poll', self.poll_events)
         
         # Health and metrics
         self.app.router.add_get('/api/v1/health', self.health_check)
@@ -691,6 +867,7 @@ class DEparrowBootstrapServer:
                 node = self.nodes[registration.node_id]
                 node.last_seen = datetime.utcnow()
                 node.status = NodeStatus.ONLINE
+                node.agent_version = registration.agent_version or node.agent_version
                 logger.info(f"Node re-registered: {registration.node_id}")
             else:
                 # Create new node
@@ -701,7 +878,8 @@ class DEparrowBootstrapServer:
                     resources=registration.resources,
                     status=NodeStatus.ONLINE,
                     last_seen=datetime.utcnow(),
-                    labels=registration.labels
+                    labels=registration.labels,
+                    agent_version=registration.agent_version
                 )
                 self.nodes[registration.node_id] = node
                 logger.info(f"New node registered: {registration.node_id}")
@@ -818,7 +996,11 @@ class DEparrowBootstrapServer:
                 port=port,
                 node_count=0,
                 status=NodeStatus.ONLINE,
-                registered_at=datetime.utcnow()
+                registered_at=datetime.utcnow(),
+                region=data.get('region', 'global'),
+                version=data.get('version', ''),
+                features=data.get('features', []),
+                max_jobs=data.get('max_jobs', 100)
             )
             
             self.orchestrators[orchestrator_id] = orchestrator
@@ -859,7 +1041,11 @@ class DEparrowBootstrapServer:
                 'port': orchestrator.port,
                 'status': orchestrator.status,
                 'node_count': orchestrator.node_count,
-                'registered_at': orchestrator.registered_at.isoformat()
+                'registered_at': orchestrator.registered_at.isoformat(),
+                'region': orchestrator.region,
+                'version': orchestrator.version,
+                'features': orchestrator.features,
+                'load': self._orchestrator_load(orch_id)
             })
         
         return web.json_response({
@@ -867,6 +1053,19 @@ class DEparrowBootstrapServer:
             'total': len(orchestrators_list)
         })
     
+    def _orchestrator_load(self, orchestrator_id: str) -> float:
+        """Fraction of an orchestrator's job capacity in use"""
+        orchestrator = self.orchestrators[orchestrator_id]
+        assigned = len([j for j in self.jobs.values() if j.orchestrator == orchestrator_id])
+        return round(min(assigned / max(orchestrator.max_jobs, 1), 1.0), 2)
+    
+    def _pick_orchestrator(self) -> Optional[str]:
+        """Least loaded online orchestrator"""
+        online = [o for o in self.orchestrators if self.orchestrators[o].status == NodeStatus.ONLINE]
+        if not online:
+            return None
+        return min(online, key=self._orchestrator_load)
+    
     # Job Management with Credit System
     async def submit_job(self, request: web.Request):
         """Submit a job with credit payment verification"""
@@ -881,6 +1080,14 @@ class DEparrowBootstrapServer:
             user = self.users[user_id]
             credit_cost = data.get('credit_cost', Config.CREDIT_SUBMISSION_COST)
             
+            # Route to the requested orchestrator, or the least loaded one
+            orchestrator_id = data.get('orchestrator') or self._pick_orchestrator()
+            if orchestrator_id not in self.orchestrators:
+                return web.json_response(
+                    {'error': f'Orchestrator not found: {orchestrator_id}'},
+                    status=404
+                )
+            
             if user.credit_balance < credit_cost:
                 return web.json_response({
                     'error': 'Insufficient credits',
@@ -899,7 +1106,7 @@ class DEparrowBootstrapServer:
                 user_id=user_id,
                 spec=data.get('spec', {}),
                 credit_cost=credit_cost,
-                orchestrator=data.get('orchestrator')
+                orchestrator=orchestrator_id
             )
             
             self.jobs[job_id] = job
@@ -913,6 +1120,7 @@ class DEparrowBootstrapServer:
                 'job_id': job_id,
                 'credit_deducted': credit_cost,
                 'remaining_balance': user.credit_balance,
+                'orchestrator': orchestrator_id,
                 'message': 'Job submitted successfully. Credits deducted.'
             })
             
@@ -1062,6 +1270,37 @@ class DEparrowBootstrapServer:
             'last_active': user.last_active.isoformat()
         })
     
+    async def get_balance_history(self, request: web.Request):
+        """Get a user's balance over a period, one point per resolution.
+
+        Query parameters are in seconds: period (default 30 days) and
+        resolution (default 1 day)."""
+        user_id = request.match_info.get('user_id', request.get('user_id'))
+
+        if user_id not in self.users:
+            return web.json_response({'error': 'User not found'}, status=404)
+
+        try:
+            period = int(request.query.get('period', 30 * 86400))
+            resolution = int(request.query.get('resolution', 86400))
+        except ValueError:
+            return web.json_response({'error': 'period and resolution must be whole seconds'}, status=400)
+        if resolution < 1 or period < resolution:
+            return web.json_response({'error': 'resolution must be at least a second and no longer than the period'}, status=400)
+        if period // resolution > Config.MAX_BALANCE_HISTORY_POINTS:
+            return web.json_response({
+                'error': f'period is more than {Config.MAX_BALANCE_HISTORY_POINTS} points at this resolution'
+            }, status=400)
+
+        history = balance_history(
+            self.balance_snapshots.get(user_id, []),
+            datetime.utcnow(),
+            timedelta(seconds=period),
+            timedelta(seconds=resolution)
+        )
+        history['user_id'] = user_id
+        return web.json_response(history)
+    
     # Health and Metrics
     async def health_check(self, request: web.Request):
         """Health check endpoint"""
@@ -1135,6 +1374,65 @@ class DEparrowBootstrapServer:
             'timestamp': datetime.utcnow().isoformat()
         })
     
+    # ============ Node Software Updates ============
+    
+    async def list_node_agent_releases(self, request: web.Request):
+        """Get the recommended node-agent version for each architecture"""
+        releases = [
+            {**history[0], 'arch': arch, 'channel': 'stable'}
+            for arch, history in NODE_AGENT_RELEASES.items()
+        ]
+        return web.json_response({
+            'channel': 'stable',
+            'releases': releases
+        })
+    
+    async def get_update_advisory(self, request: web.Request):
+        """Tell an operator whether a node runs outdated software and what it is missing"""
+        node_id = request.match_info['node_id']
+        
+        if node_id not in self.nodes:
+            return web.json_response({'error': 'Node not found'}, status=404)
+        
+        node = self.nodes[node_id]
+        arch = node.arch.value if isinstance(node.arch, NodeArchitecture) else str(node.arch)
+        history = NODE_AGENT_RELEASES.get(arch)
+        if not history:
+            return web.json_response({'error': f'No releases for architecture {arch}'}, status=404)
+        
+        latest = {**history[0], 'arch': arch, 'channel': 'stable'}
+        current = node.agent_version
+        missing = []
+        current_multiplier = 0.0
+        if current:
+            for release in history:
+                if version_tuple(release['version']) > version_tuple(current):
+                    missing.append({**release, 'arch': arch, 'channel': 'stable'})
+                elif version_tuple(release['version']) == version_tuple(current):
+                    current_multiplier = release['earnings_multiplier']
+        
+        if not current:
+            severity = 'unknown'
+        elif version_tuple(current) < version_tuple(latest['min_supported']):
+            severity = 'unsupported'
+        elif any(r['security_fix'] for r in missing):
+            severity = 'security'
+        elif missing:
+            severity = 'recommended'
+        else:
+            severity = 'current'
+        
+        return web.json_response({
+            'node_id': node_id,
+            'arch': arch,
+            'current_version': current,
+            'latest': latest,
+            'outdated': len(missing) > 0,
+            'severity': severity,
+            'missing': missing,
+            'current_multiplier': current_multiplier
+        })
+    
     # ============ Contribution Tracking ============
     
     async def get_node_contribution(self, request: web.Request):
@@ -2008,6 +2306,7 @@ class DEparrowBootstrapServer:
                 'type': 'connected',
                 'client_id': client_id,
                 'channels': channels,
+                'cursor': self.ws_manager.last_event_id,
                 'timestamp': datetime.utcnow().isoformat()
             })
             
@@ -2038,6 +2337,31 @@ class DEparrowBootstrapServer:
         
         return ws
     
+    async def poll_events(self, request: web.Request):
+        """Long-poll for the messages the WebSocket pushes, for clients that
+        cannot hold one open.
+
+        Query parameters: channels (comma-separated, as for the WebSocket),
+        cursor (the event_id to resume after; omit it to start with new
+        events) and wait (seconds to hold the request while nothing is new,
+        at most EVENT_POLL_MAX_WAIT)."""
+        channels = {c.strip() for c in request.query.get('channels', 'jobs,nodes,agents').split(',') if c.strip()}
+        try:
+            cursor = int(request.query['cursor']) if 'cursor' in request.query else None
+            wait = float(request.query.get('wait', Config.EVENT_POLL_WAIT))
+        except ValueError:
+            return web.json_response({'error': 'cursor must be an event ID and wait a number of seconds'}, status=400)
+        if cursor is not None and cursor < 0:
+            return web.json_response({'error': 'cursor must be an event ID'}, status=400)
+        wait = min(max(wait, 0.0), Config.EVENT_POLL_MAX_WAIT)
+
+        events, next_cursor, reset = await self.ws_manager.poll_events(channels, cursor, wait)
+        return web.json_response({
+            'events': events,
+            'cursor': next_cursor,
+            'reset': reset
+        })
+    
     async def _handle_ws_message(self, client_id: str, data: Dict[str, Any]):
         """Handle incoming WebSocket message"""
         msg_type = data.get('type')
@@ -2090,6 +2414,7 @@ class DEparrowBootstrapServer:
         # Start background tasks
         asyncio.create_task(self._cleanup_task())
         asyncio.create_task(self._metrics_task())
+        asyncio.create_task(self._balance_snapshot_task())
         
         # Start web server
         runner = web.AppRunner(self.app)
@@ -2163,6 +2488,28 @@ class DEparrowBootstrapServer:
             
             await asyncio.sleep(30)  # Run every 30 seconds
     
+    def _snapshot_balances(self, now: datetime):
+        """Record every user's balance and drop snapshots past retention"""
+        cutoff = now - timedelta(days=Config.BALANCE_SNAPSHOT_RETENTION_DAYS)
+        for user_id, user in self.users.items():
+            snapshots = self.balance_snapshots[user_id]
+            snapshots.append(BalanceSnapshot(timestamp=now, balance=user.credit_balance))
+            # Keep the last snapshot before the cutoff as the baseline of long periods
+            expired = 0
+            while expired + 1 < len(snapshots) and snapshots[expired + 1].timestamp < cutoff:
+                expired += 1
+            del snapshots[:expired]
+    
+    async def _balance_snapshot_task(self):
+        """Background task to snapshot user balances for balance history"""
+        while True:
+            try:
+                self._snapshot_balances(datetime.utcnow())
+            except Exception as e:
+                logger.error(f"Balance snapshot task error: {str(e)}")
+            
+            await asyncio.sleep(Config.BALANCE_SNAPSHOT_INTERVAL)
+    
     async def stop(self):
         """Stop the bootstrap server"""
         if self.session:
@@ -3,9 +3,14 @@
 package integration
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -262,6 +267,50 @@ func (s *APICompatibilitySuite) TestJobEndpoints() {
 		testutil.ReadJSON(resp, &result)
 
 		assert.Contains(t, result, "jobs", "Should return jobs array")
+		assert.Contains(t, result, "counts", "Should return counts by status")
+	})
+
+	s.T().Run("GET /api/v1/jobs sorted by cost", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		s.mockServer.SetCredits("test-user", 100.0)
+		for _, cost := range []float64{3.0, 7.0} {
+			resp, err := s.client.Post(ctx, "/api/v1/jobs/submit", map[string]interface{}{
+				"spec":        map[string]interface{}{"image": "ubuntu:latest"},
+				"credit_cost": cost,
+			})
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		resp, err := s.client.Get(ctx, "/api/v1/jobs?sort=cost&order=asc")
+		require.NoError(t, err, "Sorted job list should succeed")
+		defer resp.Body.Close()
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+
+		var result struct {
+			Jobs []struct {
+				CreditCost float64 `json:"credit_cost"`
+			} `json:"jobs"`
+			Counts map[string]int `json:"counts"`
+		}
+		testutil.ReadJSON(resp, &result)
+
+		require.GreaterOrEqual(t, len(result.Jobs), 2)
+		for i := 1; i < len(result.Jobs); i++ {
+			assert.LessOrEqual(t, result.Jobs[i-1].CreditCost, result.Jobs[i].CreditCost, "Jobs should be sorted by ascending cost")
+		}
+		total := 0
+		for _, n := range result.Counts {
+			total += n
+		}
+		assert.Equal(t, len(result.Jobs), total, "Counts should cover every job")
+
+		invalid, err := s.client.Get(ctx, "/api/v1/jobs?sort=name")
+		require.NoError(t, err)
+		defer invalid.Body.Close()
+		assert.Equal(t, 400, invalid.StatusCode, "Unknown sort field should be rejected")
 	})
 
 	s.T().Run("job submission with insufficient credits", func(t *testing.T) {
@@ -284,6 +333,10 @@ func (s *APICompatibilitySuite) TestJobEndpoints() {
 		defer resp.Body.Close()
 
 		assert.Equal(t, 400, resp.StatusCode, "Should return 400 Bad Request")
+
+		var result map[string]interface{}
+		require.NoError(t, testutil.ReadJSON(resp, &result))
+		assert.Equal(t, "insufficient_credits", result["error_code"], "Error should carry a machine-readable code")
 	})
 }
 
@@ -355,6 +408,549 @@ func (s *APICompatibilitySuite) TestCreditEndpoints() {
 	})
 }
 
+// TestPaymentEndpoints tests funding sources, top-ups and the payment webhook.
+func (s *APICompatibilitySuite) TestPaymentEndpoints() {
+	var topUpID string
+
+	s.T().Run("GET /api/v1/credits/funding-sources", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Get(ctx, "/api/v1/credits/funding-sources")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+
+		var result struct {
+			Sources []map[string]interface{} `json:"funding_sources"`
+		}
+		testutil.ReadJSON(resp, &result)
+
+		require.NotEmpty(t, result.Sources, "Should list funding sources")
+		assert.Equal(t, true, result.Sources[0]["default"], "First source should be the default")
+	})
+
+	s.T().Run("POST /api/v1/credits/top-ups", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		s.mockServer.SetCredits("test-user", 5.0)
+
+		resp, err := s.client.Post(ctx, "/api/v1/credits/top-ups", map[string]interface{}{"amount": 100.0})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+
+		var result map[string]interface{}
+		testutil.ReadJSON(resp, &result)
+
+		topUpID, _ = result["top_up_id"].(string)
+		assert.NotEmpty(t, topUpID, "Should return top-up ID")
+		assert.Equal(t, "pending", result["status"], "Top-up should wait for payment")
+		assert.NotEmpty(t, result["checkout_url"], "Should return a checkout URL")
+		assert.Equal(t, 10.0, result["price"], "Price should follow the source's rate")
+		assert.Equal(t, 5.0, s.mockServer.GetCredits("test-user"), "Credits should not arrive before payment")
+	})
+
+	s.T().Run("top-up outside the source's limits", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Post(ctx, "/api/v1/credits/top-ups", map[string]interface{}{"amount": 50.0, "source_id": "bank-transfer"})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 400, resp.StatusCode, "Should return 400 Bad Request")
+	})
+
+	s.T().Run("webhook with a bad signature", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Post(ctx, testutil.MockPaymentWebhookPath, testutil.MockPaymentEvent{
+			Type:    testutil.PaymentEventSucceeded,
+			TopUpID: topUpID,
+		})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 401, resp.StatusCode, "Unsigned events should be rejected")
+		assert.Equal(t, 5.0, s.mockServer.GetCredits("test-user"))
+	})
+
+	s.T().Run("POST /api/v1/payments/webhook", func(t *testing.T) {
+		require.NotEmpty(t, topUpID)
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		event := testutil.MockPaymentEvent{ID: "evt-1", Type: testutil.PaymentEventSucceeded, TopUpID: topUpID}
+		for i := 0; i < 2; i++ {
+			resp, err := s.mockServer.DeliverPaymentEvent(ctx, event)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, 200, resp.StatusCode, "Webhook should be accepted")
+		}
+
+		assert.Equal(t, 105.0, s.mockServer.GetCredits("test-user"), "Credits should be added once")
+
+		resp, err := s.client.Get(ctx, "/api/v1/credits/top-ups/"+topUpID)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		testutil.ReadJSON(resp, &result)
+		assert.Equal(t, "completed", result["status"])
+		assert.Empty(t, result["checkout_url"], "Checkout should be closed")
+	})
+
+	s.T().Run("failed payment", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Post(ctx, "/api/v1/credits/top-ups", map[string]interface{}{"amount": 20.0})
+		require.NoError(t, err)
+		var created map[string]interface{}
+		testutil.ReadJSON(resp, &created)
+		resp.Body.Close()
+
+		id, _ := created["top_up_id"].(string)
+		resp, err = s.mockServer.DeliverPaymentEvent(ctx, testutil.MockPaymentEvent{
+			Type:    testutil.PaymentEventFailed,
+			TopUpID: id,
+			Reason:  "card_declined",
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		topUp, ok := s.mockServer.TopUp(id)
+		require.True(t, ok)
+		assert.EqualValues(t, "failed", topUp.Status)
+		assert.Equal(t, "card_declined", topUp.FailureReason)
+		assert.Equal(t, 105.0, s.mockServer.GetCredits("test-user"), "Failed payments should add nothing")
+	})
+}
+
+// TestNodeDecommissionEndpoints tests draining, paying out and retiring a node.
+func (s *APICompatibilitySuite) TestNodeDecommissionEndpoints() {
+	const nodeID = "decommission-test-node"
+	path := "/api/v1/nodes/" + nodeID + "/decommission"
+	node := s.mockServer.AddTestNode(nodeID)
+	s.mockServer.UpdateNode(nodeID, func(n *testutil.MockNode) {
+		n.PublicKey = "node-public-key"
+		n.PendingEarnings = 25.0
+	})
+	s.mockServer.SetCredits("test-user", 100.0)
+
+	var runningJob, queuedJob string
+	for _, id := range []*string{&runningJob, &queuedJob} {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		resp, err := s.client.Post(ctx, "/api/v1/jobs/submit", map[string]interface{}{
+			"spec":    map[string]interface{}{"image": "alpine"},
+			"node_id": node.ID,
+		})
+		cancel()
+		s.Require().NoError(err)
+		var result map[string]interface{}
+		testutil.ReadJSON(resp, &result)
+		resp.Body.Close()
+		*id, _ = result["job_id"].(string)
+	}
+	s.Require().True(s.mockServer.UpdateJobStatus(runningJob, "running"))
+
+	s.T().Run("cancel while draining", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		// A node with a running job stays draining
+		other := s.mockServer.AddTestNode("decommission-cancel-node")
+		resp, err := s.client.Post(ctx, "/api/v1/jobs/submit", map[string]interface{}{
+			"spec":    map[string]interface{}{"image": "alpine"},
+			"node_id": other.ID,
+		})
+		require.NoError(t, err)
+		var job map[string]interface{}
+		testutil.ReadJSON(resp, &job)
+		resp.Body.Close()
+		jobID, _ := job["job_id"].(string)
+		require.True(t, s.mockServer.UpdateJobStatus(jobID, "running"))
+
+		resp, err = s.client.Post(ctx, "/api/v1/nodes/"+other.ID+"/decommission", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		resp, err = s.client.Delete(ctx, "/api/v1/nodes/"+other.ID+"/decommission")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+		s.mockServer.UpdateNode(other.ID, func(n *testutil.MockNode) {
+			assert.EqualValues(t, "online", n.Status, "Node should be back online")
+		})
+	})
+
+	var credits float64
+	s.T().Run("POST "+path, func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		credits = s.mockServer.GetCredits("test-user")
+
+		resp, err := s.client.Post(ctx, path, nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+
+		var result testutil.MockDecommission
+		testutil.ReadJSON(resp, &result)
+
+		assert.Equal(t, testutil.DecommissionDraining, result.Stage, "Node should drain while a job runs")
+		assert.Equal(t, 1, result.JobsRescheduled, "Queued job should be rescheduled")
+		assert.Equal(t, 1, result.JobsRemaining, "Running job should be left to finish")
+		assert.Equal(t, credits, s.mockServer.GetCredits("test-user"), "Earnings should wait for the drain")
+	})
+
+	s.T().Run("POST "+path+" twice", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Post(ctx, path, nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 409, resp.StatusCode, "Should return 409 Conflict")
+	})
+
+	s.T().Run("GET "+path, func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		require.True(t, s.mockServer.UpdateJobStatus(runningJob, "completed"))
+
+		resp, err := s.client.Get(ctx, path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+
+		var result testutil.MockDecommission
+		testutil.ReadJSON(resp, &result)
+
+		assert.Equal(t, testutil.DecommissionRetired, result.Stage, "Node should retire once drained")
+		assert.Equal(t, 25.0, result.FinalEarnings)
+		assert.NotNil(t, result.KeyRevokedAt, "Key should be revoked")
+		assert.Equal(t, credits+25.0, s.mockServer.GetCredits("test-user"), "Pending earnings should be paid out")
+		s.mockServer.UpdateNode(nodeID, func(n *testutil.MockNode) {
+			assert.EqualValues(t, "retired", n.Status)
+			assert.Empty(t, n.PublicKey, "Key should be cleared")
+		})
+	})
+
+	s.T().Run("DELETE "+path+" after retiring", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Delete(ctx, path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 409, resp.StatusCode, "Should return 409 Conflict")
+	})
+
+	s.T().Run("re-register a retired node", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Post(ctx, "/api/v1/nodes/register", map[string]interface{}{
+			"node_id":    nodeID,
+			"public_key": "new-key",
+		})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 409, resp.StatusCode, "Should return 409 Conflict")
+	})
+}
+
+// TestStandingOrderEndpoints tests recurring transfer endpoints and their scheduler.
+func (s *APICompatibilitySuite) TestStandingOrderEndpoints() {
+	var orderID string
+
+	s.T().Run("POST /api/v1/credits/standing-orders", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		s.mockServer.SetCredits("test-user", 500.0)
+		s.mockServer.AddTestUser("allowance-user", "allowance@test.com", "pass")
+
+		// Backdated so two weekly runs have already fallen due
+		orderReq := map[string]interface{}{
+			"to_user_id":  "allowance-user",
+			"amount":      25.0,
+			"interval":    "weekly",
+			"description": "Weekly allowance",
+			"start_at":    time.Now().Add(-8 * 24 * time.Hour),
+		}
+
+		resp, err := s.client.Post(ctx, "/api/v1/credits/standing-orders", orderReq)
+		require.NoError(t, err, "Creating a standing order should succeed")
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+
+		var result map[string]interface{}
+		testutil.ReadJSON(resp, &result)
+
+		orderID, _ = result["order_id"].(string)
+		assert.NotEmpty(t, orderID, "Should return order ID")
+		assert.Equal(t, "active", result["status"], "Order should be active")
+		assert.Equal(t, 2.0, result["runs"], "Due runs should be executed")
+		assert.Equal(t, 450.0, s.mockServer.GetCredits("test-user"), "Sender should be debited")
+		assert.Equal(t, 50.0, s.mockServer.GetCredits("allowance-user"), "Recipient should be credited")
+	})
+
+	s.T().Run("scheduler executes due orders", func(t *testing.T) {
+		require.NotEmpty(t, orderID)
+
+		order, ok := s.mockServer.StandingOrder(orderID)
+		require.True(t, ok)
+
+		assert.Equal(t, 0, s.mockServer.RunStandingOrders(time.Now()), "Nothing should be due yet")
+		assert.Equal(t, 1, s.mockServer.RunStandingOrders(order.NextRun), "Next run should execute")
+		assert.Equal(t, 425.0, s.mockServer.GetCredits("test-user"))
+	})
+
+	s.T().Run("GET /api/v1/credits/standing-orders", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Get(ctx, "/api/v1/credits/standing-orders")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result struct {
+			Orders []map[string]interface{} `json:"standing_orders"`
+		}
+		testutil.ReadJSON(resp, &result)
+
+		require.Len(t, result.Orders, 1, "Should list the standing order")
+		assert.Equal(t, 3.0, result.Orders[0]["runs"])
+	})
+
+	s.T().Run("PUT /api/v1/credits/standing-orders/{id}", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Put(ctx, "/api/v1/credits/standing-orders/"+orderID, map[string]interface{}{"amount": 30.0})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		testutil.ReadJSON(resp, &result)
+
+		assert.Equal(t, 30.0, result["amount"], "Amount should be updated")
+	})
+
+	s.T().Run("DELETE /api/v1/credits/standing-orders/{id}", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Delete(ctx, "/api/v1/credits/standing-orders/"+orderID)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Should return 200 OK")
+
+		order, _ := s.mockServer.StandingOrder(orderID)
+		assert.EqualValues(t, "cancelled", order.Status)
+		assert.Equal(t, 0, s.mockServer.RunStandingOrders(order.NextRun), "Cancelled orders should not run")
+
+		resp, err = s.client.Delete(ctx, "/api/v1/credits/standing-orders/"+orderID)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 400, resp.StatusCode, "Cancelling twice should fail")
+	})
+}
+
+// TestAPIKeyEndpoints tests API key issuance, scopes, rotation and revocation.
+func (s *APICompatibilitySuite) TestAPIKeyEndpoints() {
+	user := testutil.NewHTTPClient(s.mockServer.URL, "test-jwt-token-test-user")
+	var keyID, secret string
+
+	s.T().Run("POST /api/v1/auth/api-keys", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := user.Post(ctx, "/api/v1/auth/api-keys", map[string]interface{}{
+			"name":  "ci",
+			"scope": testutil.APIKeyScopeSubmitOnly,
+		})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var result map[string]interface{}
+		testutil.ReadJSON(resp, &result)
+		keyID, _ = result["key_id"].(string)
+		secret, _ = result["secret"].(string)
+		assert.NotEmpty(t, keyID, "Should return key ID")
+		assert.True(t, strings.HasPrefix(secret, result["prefix"].(string)), "Prefix should identify the secret")
+
+		// Keys can only be managed with a user token
+		resp, err = s.client.Post(ctx, "/api/v1/auth/api-keys", map[string]interface{}{"name": "anonymous"})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	s.T().Run("submit-only scope", func(t *testing.T) {
+		require.NotEmpty(t, secret)
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		ci := testutil.NewHTTPClient(s.mockServer.URL, "")
+		ci.APIKey = secret
+
+		resp, err := ci.Post(ctx, "/api/v1/jobs/submit", map[string]interface{}{
+			"spec": map[string]interface{}{"image": "alpine"},
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "Should submit jobs")
+
+		resp, err = ci.Get(ctx, "/api/v1/jobs")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "Should list jobs")
+
+		resp, err = ci.Post(ctx, "/api/v1/credits/transfer", map[string]interface{}{
+			"from_user": "test-user", "to_user": "other", "amount": 1.0,
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Should not transfer credits")
+
+		resp, err = ci.Post(ctx, "/api/v1/auth/api-keys", map[string]interface{}{"name": "escalate"})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Should not issue more keys")
+
+		key, ok := s.mockServer.APIKey(keyID)
+		require.True(t, ok)
+		assert.NotNil(t, key.LastUsedAt, "Use should be recorded")
+	})
+
+	s.T().Run("read-only scope", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		_, readOnly := s.mockServer.AddTestAPIKey("test-user", "dashboard", testutil.APIKeyScopeReadOnly)
+		ci := testutil.NewHTTPClient(s.mockServer.URL, "")
+		ci.APIKey = readOnly
+
+		resp, err := ci.Get(ctx, "/api/v1/credits/balance")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = ci.Post(ctx, "/api/v1/jobs/submit", map[string]interface{}{"spec": map[string]interface{}{}})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	s.T().Run("rotate and revoke", func(t *testing.T) {
+		require.NotEmpty(t, keyID)
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := user.Post(ctx, "/api/v1/auth/api-keys/"+keyID+"/rotate", nil)
+		require.NoError(t, err)
+		var rotated map[string]interface{}
+		testutil.ReadJSON(resp, &rotated)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		newSecret, _ := rotated["secret"].(string)
+		assert.NotEqual(t, secret, newSecret)
+
+		ci := testutil.NewHTTPClient(s.mockServer.URL, "")
+		ci.APIKey = secret
+		resp, err = ci.Get(ctx, "/api/v1/jobs")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Old secret should stop working")
+
+		ci.APIKey = newSecret
+		resp, err = ci.Get(ctx, "/api/v1/jobs")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = user.Delete(ctx, "/api/v1/auth/api-keys/"+keyID)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, err = ci.Get(ctx, "/api/v1/jobs")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Revoked key should be rejected")
+
+		resp, err = user.Get(ctx, "/api/v1/auth/api-keys")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var list struct {
+			Keys []map[string]interface{} `json:"api_keys"`
+		}
+		testutil.ReadJSON(resp, &list)
+		require.Len(t, list.Keys, 2)
+		for _, key := range list.Keys {
+			assert.NotContains(t, key, "secret", "Listings should never include secrets")
+		}
+	})
+
+	s.T().Run("/api/v1/auth/apikeys alias", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := user.Post(ctx, "/api/v1/auth/apikeys", map[string]interface{}{"name": "alias"})
+		require.NoError(t, err)
+		var created map[string]interface{}
+		testutil.ReadJSON(resp, &created)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		aliasID, _ := created["key_id"].(string)
+		require.NotEmpty(t, aliasID)
+
+		resp, err = user.Get(ctx, "/api/v1/auth/apikeys")
+		require.NoError(t, err)
+		var list struct {
+			Keys []map[string]interface{} `json:"api_keys"`
+		}
+		testutil.ReadJSON(resp, &list)
+		resp.Body.Close()
+		assert.Len(t, list.Keys, 3, "Should list the same keys as /api/v1/auth/api-keys")
+
+		// API keys cannot manage keys through the alias either
+		ci := testutil.NewHTTPClient(s.mockServer.URL, "")
+		ci.APIKey, _ = created["secret"].(string)
+		resp, err = ci.Post(ctx, "/api/v1/auth/apikeys", map[string]interface{}{"name": "escalate"})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, err = user.Delete(ctx, "/api/v1/auth/apikeys/"+aliasID)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		key, ok := s.mockServer.APIKey(aliasID)
+		require.True(t, ok)
+		assert.NotNil(t, key.RevokedAt, "Key should be revoked")
+	})
+}
+
 // TestAgentEndpoints tests agent-related endpoints.
 func (s *APICompatibilitySuite) TestAgentEndpoints() {
 	s.T().Run("GET /api/v1/agent/status", func(t *testing.T) {
@@ -579,6 +1175,205 @@ func (s *APICompatibilitySuite) TestResponseContentType() {
 	})
 }
 
+// TestCorrelationHeaders tests that request metadata is echoed back.
+func (s *APICompatibilitySuite) TestCorrelationHeaders() {
+	s.T().Run("request and session IDs are echoed", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.mockServer.URL+"/api/v1/health", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Request-ID", "req-123")
+		req.Header.Set("X-Session-ID", "telegram:42")
+		req.Header.Set("User-Agent", "picoclaw-deparrow/test")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "Health request should succeed")
+		resp.Body.Close()
+
+		assert.Equal(t, "req-123", resp.Header.Get("X-Request-ID"))
+		assert.Equal(t, "telegram:42", resp.Header.Get("X-Session-ID"))
+
+		requests := s.mockServer.Requests()
+		last := requests[len(requests)-1]
+		assert.Equal(t, "req-123", last.RequestID)
+		assert.Equal(t, "picoclaw-deparrow/test", last.UserAgent)
+	})
+
+	s.T().Run("request ID is generated when missing", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, err := s.client.Get(ctx, "/api/v1/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+	})
+}
+
+// openEventStream subscribes to /api/v1/events with the given query and resume token.
+func openEventStream(ctx context.Context, t *testing.T, baseURL, query, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/v1/events"+query, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "Event stream request should succeed")
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// nextSSEEvent reads frames until the next event, skipping retry hints and heartbeats.
+func nextSSEEvent(t *testing.T, r *bufio.Reader) testutil.SSEFrame {
+	t.Helper()
+	for {
+		frame, err := testutil.ReadSSEFrame(r)
+		require.NoError(t, err, "Should read an event frame")
+		if frame.Event != "" {
+			return frame
+		}
+	}
+}
+
+// TestEventStream tests the Server-Sent Events bridge used by the GUI.
+func (s *APICompatibilitySuite) TestEventStream() {
+	var firstID string
+
+	s.T().Run("GET /api/v1/events streams job and balance events", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, events := openEventStream(ctx, t, s.mockServer.URL, "?types=job_created,balance_update", "")
+		defer resp.Body.Close()
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		frame, err := testutil.ReadSSEFrame(events)
+		require.NoError(t, err)
+		assert.Equal(t, "3000", frame.Retry, "Should suggest a reconnect delay")
+
+		s.mockServer.SetCredits("test-user", 500.0)
+		submit, err := s.client.Post(ctx, "/api/v1/jobs/submit", map[string]interface{}{
+			"spec":        map[string]interface{}{"image": "alpine"},
+			"credit_cost": 10.0,
+		})
+		require.NoError(t, err)
+		submit.Body.Close()
+
+		balance := nextSSEEvent(t, events)
+		assert.Equal(t, "balance_update", balance.Event)
+		assert.JSONEq(t, `{"user_id": "test-user", "credit_balance": 500}`, balance.Data)
+		firstID = balance.ID
+
+		created := nextSSEEvent(t, events)
+		assert.Equal(t, "job_created", created.Event, "transaction events are filtered out")
+		var payload struct {
+			Job map[string]interface{} `json:"job"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(created.Data), &payload))
+		assert.Equal(t, "pending", payload.Job["status"])
+
+		charged := nextSSEEvent(t, events)
+		assert.Equal(t, "balance_update", charged.Event)
+		assert.JSONEq(t, `{"user_id": "test-user", "credit_balance": 490}`, charged.Data)
+	})
+
+	s.T().Run("Last-Event-ID resumes after the given event", func(t *testing.T) {
+		require.NotEmpty(t, firstID)
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, events := openEventStream(ctx, t, s.mockServer.URL, "?types=job_created,transaction", firstID)
+		defer resp.Body.Close()
+
+		assert.Equal(t, "job_created", nextSSEEvent(t, events).Event)
+		assert.Equal(t, "transaction", nextSSEEvent(t, events).Event)
+	})
+
+	s.T().Run("invalid resume token", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, _ := openEventStream(ctx, t, s.mockServer.URL, "?last_event_id=abc", "")
+		resp.Body.Close()
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	s.T().Run("job updates carry progress and only move forward", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		s.mockServer.SetCredits("test-user", 100.0)
+		submit, err := s.client.Post(ctx, "/api/v1/jobs/submit", map[string]interface{}{
+			"spec":        map[string]interface{}{"image": "alpine"},
+			"credit_cost": 10.0,
+		})
+		require.NoError(t, err)
+		var submitted map[string]interface{}
+		testutil.ReadJSON(submit, &submitted)
+		jobID, _ := submitted["job_id"].(string)
+		require.NotEmpty(t, jobID)
+
+		resp, events := openEventStream(ctx, t, s.mockServer.URL, "?types=job_update", "")
+		defer resp.Body.Close()
+		testutil.ReadSSEFrame(events) // retry hint
+
+		require.True(t, s.mockServer.UpdateJobStatus(jobID, "running"))
+		assert.False(t, s.mockServer.UpdateJobStatus(jobID, "pending"), "jobs do not move backwards")
+		require.True(t, s.mockServer.UpdateJobStatus(jobID, "completed"))
+		assert.False(t, s.mockServer.UpdateJobStatus(jobID, "failed"), "completed jobs are final")
+
+		for _, want := range []struct {
+			status   string
+			progress float64
+		}{{"running", 50}, {"completed", 100}} {
+			update := nextSSEEvent(t, events)
+			var payload struct {
+				Job map[string]interface{} `json:"job"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(update.Data), &payload))
+			assert.Equal(t, want.status, payload.Job["status"])
+			assert.Equal(t, want.progress, payload.Job["progress"])
+		}
+	})
+
+	s.T().Run("idle streams send heartbeats", func(t *testing.T) {
+		server := testutil.NewMockMetaOSServer()
+		server.SSEHeartbeat = 20 * time.Millisecond
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, events := openEventStream(ctx, t, server.URL, "", "")
+		defer resp.Body.Close()
+
+		testutil.ReadSSEFrame(events) // retry hint
+		frame, err := testutil.ReadSSEFrame(events)
+		require.NoError(t, err)
+		assert.Equal(t, "heartbeat", frame.Comment)
+	})
+
+	s.T().Run("expired resume token resets the client", func(t *testing.T) {
+		server := testutil.NewMockMetaOSServer()
+		defer server.Close()
+		for i := 0; i < 300; i++ {
+			server.SetCredits("test-user", float64(i))
+		}
+
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		resp, events := openEventStream(ctx, t, server.URL, "?last_event_id=1", "")
+		defer resp.Body.Close()
+
+		assert.Equal(t, "reset", nextSSEEvent(t, events).Event, "Client must refetch its state")
+		assert.Equal(t, "balance_update", nextSSEEvent(t, events).Event)
+	})
+}
+
 // TestAPICompatibilitySuite runs the test suite.
 func TestAPICompatibilitySuite(t *testing.T) {
 	suite.Run(t, new(APICompatibilitySuite))
@@ -0,0 +1,204 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/deparrow/test-integration/testutil"
+)
+
+// contractTargets are the server implementations the deparrow client talks
+// to, each seeded with the fixture network. Every contract test drives the
+// real deparrow client against each of them and expects the same behavior,
+// so they cannot drift apart from the client or from each other.
+//
+// The globalvm HTTP bridge joins this list once it serves the Meta-OS API.
+var contractTargets = []struct {
+	name  string
+	start func(t *testing.T) *deparrow.Client
+}{
+	{"mock", startMockContractTarget},
+	{"sandbox", startSandboxContractTarget},
+}
+
+func startMockContractTarget(t *testing.T) *deparrow.Client {
+	server := testutil.NewMockMetaOSServer()
+	t.Cleanup(server.Close)
+	server.LoadFixtures()
+	return deparrow.NewClient(server.URL, testutil.Fixtures.DefaultUser().Token)
+}
+
+func startSandboxContractTarget(t *testing.T) *deparrow.Client {
+	return deparrow.NewSandboxClient()
+}
+
+// runContract runs test against every contract target.
+func runContract(t *testing.T, test func(t *testing.T, ctx context.Context, client *deparrow.Client)) {
+	for _, target := range contractTargets {
+		t.Run(target.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.DefaultTimeout)
+			defer cancel()
+			test(t, ctx, target.start(t))
+		})
+	}
+}
+
+func TestContract_Health(t *testing.T) {
+	runContract(t, func(t *testing.T, ctx context.Context, client *deparrow.Client) {
+		health, err := client.Health(ctx)
+		require.NoError(t, err)
+		assert.NotEmpty(t, health["status"])
+		// The client gates optional request fields on these
+		assert.NotEmpty(t, health["api_version"])
+		assert.IsType(t, []interface{}{}, health["features"])
+	})
+}
+
+func TestContract_Jobs(t *testing.T) {
+	runContract(t, func(t *testing.T, ctx context.Context, client *deparrow.Client) {
+		before, err := client.GetCredits(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, deparrow.SandboxStartingBalance, before.Balance)
+
+		submitted, err := client.SubmitJob(ctx, &deparrow.JobSpec{
+			Image:     "alpine",
+			Resources: &deparrow.ResourceSpec{CPU: "1"},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, submitted.ID)
+		assert.Positive(t, submitted.CreditCost)
+
+		after, err := client.GetCredits(ctx)
+		require.NoError(t, err)
+		assert.InDelta(t, before.Balance-submitted.CreditCost, after.Balance, 1e-9)
+
+		// Job status. The sandbox moves a job on each time it is read, so
+		// only the start of the lifecycle is common to every target.
+		job, err := client.GetJob(ctx, submitted.ID)
+		require.NoError(t, err)
+		assert.Equal(t, submitted.ID, job.ID)
+		assert.Contains(t, []deparrow.JobStatus{deparrow.JobStatusPending, deparrow.JobStatusRunning}, job.Status)
+		assert.Equal(t, submitted.CreditCost, job.CreditCost)
+		assert.WithinDuration(t, time.Now(), job.SubmittedAt, time.Minute)
+
+		// Job list
+		page, err := client.ListJobsPage(ctx, deparrow.JobPageOptions{})
+		require.NoError(t, err)
+		require.Len(t, page.Jobs, 1)
+		assert.Equal(t, submitted.ID, page.Jobs[0].ID)
+		assert.Equal(t, map[deparrow.JobStatus]int{page.Jobs[0].Status: 1}, page.Counts)
+	})
+}
+
+func TestContract_JobErrors(t *testing.T) {
+	runContract(t, func(t *testing.T, ctx context.Context, client *deparrow.Client) {
+		_, err := client.GetJob(ctx, "no-such-job")
+		assert.ErrorIs(t, err, deparrow.ErrJobNotFound)
+
+		// A week-long high-priority GPU job takes most of the starting
+		// balance, so a second one no longer fits
+		expensive := &deparrow.JobSpec{
+			Image:     "alpine",
+			Resources: &deparrow.ResourceSpec{GPU: "1", Memory: "1Gi"},
+			Timeout:   7 * 24 * 3600,
+			Priority:  100,
+		}
+		job, err := client.SubmitJob(ctx, expensive)
+		require.NoError(t, err)
+		require.Greater(t, 2*job.CreditCost, deparrow.SandboxStartingBalance)
+		_, err = client.SubmitJob(ctx, expensive)
+		assert.ErrorIs(t, err, deparrow.ErrInsufficientCredits)
+	})
+}
+
+func TestContract_Nodes(t *testing.T) {
+	runContract(t, func(t *testing.T, ctx context.Context, client *deparrow.Client) {
+		nodes, err := client.ListNodes(ctx)
+		require.NoError(t, err)
+
+		fixtures := deparrow.FixtureNodes(time.Now())
+		require.Len(t, nodes, len(fixtures))
+		listed := make(map[string]deparrow.Node)
+		for _, node := range nodes {
+			listed[node.ID] = node
+		}
+		for _, f := range fixtures {
+			node, ok := listed[f.ID]
+			if !assert.True(t, ok, "node %s", f.ID) {
+				continue
+			}
+			assert.Equal(t, f.Status, node.Status, f.ID)
+			assert.Equal(t, f.Arch, node.Arch, f.ID)
+			assert.Equal(t, f.Region, node.Labels["region"], f.ID)
+			require.NotNil(t, node.Resources, f.ID)
+			assert.Equal(t, *f.Resources, *node.Resources, f.ID)
+		}
+	})
+}
+
+func TestContract_Capacity(t *testing.T) {
+	runContract(t, func(t *testing.T, ctx context.Context, client *deparrow.Client) {
+		capacity, err := client.GetNetworkCapacity(ctx)
+		require.NoError(t, err)
+		assert.False(t, capacity.Timestamp.IsZero(), "timestamp")
+
+		// What the online fixture nodes add up to
+		want := make(map[string]*deparrow.RegionCapacity)
+		for _, f := range deparrow.FixtureNodes(time.Now()) {
+			if f.Status != deparrow.NodeStatusOnline {
+				continue
+			}
+			region, ok := want[f.Region]
+			if !ok {
+				region = &deparrow.RegionCapacity{Region: f.Region}
+				want[f.Region] = region
+			}
+			region.OnlineNodes++
+			region.AvailableCPU += f.Resources.CPU
+			region.AvailableMemoryGB += f.MemoryGB
+			region.AvailableStorageGB += f.DiskGB
+			if f.Resources.GPU > 0 {
+				region.GPUs = append(region.GPUs, deparrow.GPUCapacity{
+					Model: f.Resources.GPUModel, Available: f.Resources.GPU, MaxPerNode: f.Resources.GPU,
+				})
+			}
+		}
+
+		require.Len(t, capacity.Regions, len(want))
+		for _, region := range capacity.Regions {
+			expected, ok := want[region.Region]
+			if !assert.True(t, ok, "region %s", region.Region) {
+				continue
+			}
+			assert.Equal(t, expected.OnlineNodes, region.OnlineNodes, region.Region)
+			assert.Equal(t, expected.AvailableCPU, region.AvailableCPU, region.Region)
+			assert.Equal(t, expected.AvailableMemoryGB, region.AvailableMemoryGB, region.Region)
+			assert.Equal(t, expected.AvailableStorageGB, region.AvailableStorageGB, region.Region)
+			assert.ElementsMatch(t, mergeGPUs(expected.GPUs), region.GPUs, region.Region)
+		}
+	})
+}
+
+// mergeGPUs totals the GPUs of each model, as a region reports them.
+func mergeGPUs(gpus []deparrow.GPUCapacity) []deparrow.GPUCapacity {
+	var merged []deparrow.GPUCapacity
+	index := make(map[string]int)
+	for _, gpu := range gpus {
+		i, ok := index[gpu.Model]
+		if !ok {
+			index[gpu.Model] = len(merged)
+			merged = append(merged, gpu)
+			continue
+		}
+		merged[i].Available += gpu.Available
+		merged[i].MaxPerNode = max(merged[i].MaxPerNode, gpu.MaxPerNode)
+	}
+	return merged
+}
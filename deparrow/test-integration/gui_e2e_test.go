@@ -242,9 +242,37 @@ func (s *GUIE2ESuite) TestJobsPage() {
 	})
 
 	s.T().Run("job estimation before submit", func(t *testing.T) {
-		// GUI would call this to show estimated cost before submission
-		// This is a placeholder for the estimate endpoint
-		t.Skip("Estimate endpoint not yet implemented in mock server")
+		ctx, cancel := context.WithTimeout(s.ctx, testutil.DefaultTimeout)
+		defer cancel()
+
+		s.mockServer.SetCredits("test-user", 50.0)
+
+		// GUI calls this to show the estimated cost before submission
+		estimateReq := map[string]interface{}{
+			"spec": map[string]interface{}{
+				"image": "ubuntu:latest",
+				"resources": map[string]interface{}{
+					"cpu":    "500m",
+					"memory": "256Mi",
+				},
+			},
+			"credit_cost": 12.5,
+		}
+
+		resp, err := s.client.Post(ctx, "/api/v1/jobs/estimate", estimateReq)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode, "Estimate should succeed")
+
+		var result map[string]interface{}
+		testutil.ReadJSON(resp, &result)
+
+		// Estimate panel needs these fields
+		assert.Equal(t, 12.5, result["credit_cost"], "Need credit_cost")
+		assert.Contains(t, result, "eligible_nodes", "Need eligible_nodes")
+		assert.Contains(t, result, "expected_queue_seconds", "Need expected_queue_seconds")
+		assert.Equal(t, 50.0, s.mockServer.GetCredits("test-user"), "Estimating must not spend credits")
 	})
 }
 
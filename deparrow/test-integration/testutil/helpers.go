@@ -2,6 +2,7 @@
 package testutil
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -22,6 +23,8 @@ type HTTPClient struct {
 	Token     string
 	Client    *http.Client
 	UserAgent string
+	// APIKey is sent as X-API-Key when set.
+	APIKey string
 }
 
 // NewHTTPClient creates a new HTTP client for testing.
@@ -100,6 +103,9 @@ func (c *HTTPClient) setHeaders(req *http.Request) {
 	if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
 	if c.UserAgent != "" {
 		req.Header.Set("User-Agent", c.UserAgent)
 	}
@@ -121,6 +127,47 @@ func ReadString(resp *http.Response) (string, error) {
 	return string(body), nil
 }
 
+// SSEFrame is one frame of a Server-Sent Events stream. Heartbeats are
+// comment-only frames.
+type SSEFrame struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+	Retry   string
+}
+
+// ReadSSEFrame reads the next frame from an event stream.
+func ReadSSEFrame(r *bufio.Reader) (SSEFrame, error) {
+	var frame SSEFrame
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return frame, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return frame, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			frame.Comment = strings.TrimSpace(line[1:])
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			frame.ID = value
+		case "event":
+			frame.Event = value
+		case "data":
+			frame.Data += value
+		case "retry":
+			frame.Retry = value
+		}
+	}
+}
+
 // AssertSuccessfulResponse asserts that the response is successful (2xx).
 func AssertSuccessfulResponse(t *testing.T, resp *http.Response) {
 	t.Helper()
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+func (c *cli) creditsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "credits",
+		Short: "Check and transfer credits",
+	}
+	cmd.AddCommand(c.creditsBalanceCommand(), c.creditsTransferCommand())
+	return cmd
+}
+
+func (c *cli) creditsBalanceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "balance",
+		Short: "Show your credit balance and where it came from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			balance, err := client.GetCredits(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return c.print(balance, func(w io.Writer) {
+				fmt.Fprintf(w, "Balance: %.2f credits\n", balance.Balance)
+				if len(balance.Buckets) == 0 {
+					return
+				}
+				fmt.Fprintln(w)
+				row(w, "SOURCE", "AMOUNT", "GRANTED", "EXPIRES", "DESCRIPTION")
+				for _, b := range balance.Buckets {
+					expires := "never"
+					if b.ExpiresAt != nil {
+						expires = timestamp(*b.ExpiresAt)
+					}
+					row(w, b.Source, fmt.Sprintf("%.2f", b.Amount), timestamp(b.GrantedAt), expires, orDash(b.Description))
+				}
+			})
+		},
+	}
+}
+
+// transferResult is what credits transfer prints.
+type transferResult struct {
+	ToUserID string  `json:"to_user_id"`
+	Amount   float64 `json:"amount"`
+	// "completed", or "pending_approval" with the transfer awaiting approval
+	Status     string `json:"status"`
+	TransferID string `json:"transfer_id,omitempty"`
+}
+
+func (c *cli) creditsTransferCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "transfer USER_ID AMOUNT",
+		Short: "Transfer credits to another user",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			amount, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || amount <= 0 {
+				return fmt.Errorf("invalid amount %q; give a positive number of credits", args[1])
+			}
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+
+			result := transferResult{ToUserID: args[0], Amount: amount, Status: "completed"}
+			var approval *deparrow.ApprovalRequiredError
+			switch err := client.TransferCredits(cmd.Context(), args[0], amount); {
+			case errors.As(err, &approval):
+				result.Status = string(deparrow.TransferPendingApproval)
+				result.TransferID = approval.Transfer.ID
+			case err != nil:
+				return err
+			}
+			return c.print(result, func(w io.Writer) {
+				if result.TransferID != "" {
+					fmt.Fprintf(w, "Transfer %s of %.2f credits to %s is waiting for approval\n",
+						result.TransferID, result.Amount, result.ToUserID)
+					return
+				}
+				fmt.Fprintf(w, "Transferred %.2f credits to %s\n", result.Amount, result.ToUserID)
+			})
+		},
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+func (c *cli) jobCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "job",
+		Aliases: []string{"jobs"},
+		Short:   "Submit, inspect and cancel jobs",
+	}
+	cmd.AddCommand(
+		c.jobSubmitCommand(),
+		c.jobGetCommand(),
+		c.jobListCommand(),
+		c.jobCancelCommand(),
+		c.jobLogsCommand(),
+	)
+	return cmd
+}
+
+func (c *cli) jobSubmitCommand() *cobra.Command {
+	var (
+		spec         deparrow.JobSpec
+		resources    deparrow.ResourceSpec
+		orchestrator string
+		wait         bool
+	)
+	cmd := &cobra.Command{
+		Use:   "submit --image IMAGE [flags] [-- COMMAND [ARG...]]",
+		Short: "Submit a job",
+		Example: `  deparrow job submit --image python:3.12 --cpu 1 --memory 512Mi -- python -c 'print(42)'
+  deparrow job submit --image alpine --wait -- echo hello`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			spec.Command = args
+			if resources != (deparrow.ResourceSpec{}) {
+				spec.Resources = &resources
+			}
+
+			job, err := client.SubmitJobTo(cmd.Context(), &spec, orchestrator)
+			if err != nil {
+				return err
+			}
+			if wait {
+				if job, err = client.WaitForJobCompletion(cmd.Context(), job.ID, deparrow.WaitOptions{}); err != nil {
+					return err
+				}
+			}
+			return c.print(job, func(w io.Writer) { jobDetails(w, job) })
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&spec.Image, "image", "", "container image to run")
+	flags.StringToStringVar(&spec.Env, "env", nil, "environment variables, e.g. KEY=value")
+	flags.StringToStringVar(&spec.Labels, "label", nil, "job labels, e.g. team=ml")
+	flags.StringVar(&resources.CPU, "cpu", "", "CPU to request, e.g. 500m")
+	flags.StringVar(&resources.Memory, "memory", "", "memory to request, e.g. 1Gi")
+	flags.StringVar(&resources.GPU, "gpu", "", "GPUs to request, e.g. 1")
+	flags.StringVar(&resources.Storage, "storage", "", "storage to request, e.g. 10Gi")
+	flags.IntVar(&spec.Timeout, "timeout", 0, "job timeout in seconds; 0 for the default")
+	flags.IntVar(&spec.Priority, "priority", 0, "priority from 0 to 100")
+	flags.StringSliceVar(&spec.Regions, "region", nil, "regions the job may run in")
+	flags.StringVar(&orchestrator, "orchestrator", "", "orchestrator to submit through")
+	flags.BoolVar(&wait, "wait", false, "wait for the job to finish")
+	cmd.MarkFlagRequired("image")
+	return cmd
+}
+
+func (c *cli) jobGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get JOB_ID",
+		Short: "Show a job and its results",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			job, err := client.GetJob(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return c.print(job, func(w io.Writer) { jobDetails(w, job) })
+		},
+	}
+}
+
+func (c *cli) jobListCommand() *cobra.Command {
+	var (
+		statuses []string
+		opts     deparrow.JobPageOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List your jobs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				opts.Status = append(opts.Status, deparrow.JobStatus(s))
+			}
+			page, err := client.ListJobsPage(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return c.print(page.Jobs, func(w io.Writer) {
+				row(w, "JOB ID", "STATUS", "CREDITS", "SUBMITTED")
+				for _, job := range page.Jobs {
+					row(w, job.ID, job.Status, fmt.Sprintf("%.2f", job.CreditCost), timestamp(job.SubmittedAt))
+				}
+				if page.Total > len(page.Jobs) {
+					fmt.Fprintf(w, "\nShowing %d of %d jobs\n", len(page.Jobs), page.Total)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringSliceVar(&statuses, "status", nil, "only jobs in these statuses")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 20, "most jobs to list")
+	cmd.Flags().IntVar(&opts.Offset, "offset", 0, "jobs to skip")
+	return cmd
+}
+
+// cancelResult is what job cancel prints.
+type cancelResult struct {
+	JobID  string  `json:"job_id"`
+	Refund float64 `json:"refund_amount"`
+}
+
+func (c *cli) jobCancelCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel JOB_ID",
+		Short: "Cancel a job and get back the unused credits",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			refund, err := client.CancelJob(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			result := cancelResult{JobID: args[0], Refund: refund}
+			return c.print(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Cancelled job %s; %.2f credits refunded\n", result.JobID, result.Refund)
+			})
+		},
+	}
+}
+
+func (c *cli) jobLogsCommand() *cobra.Command {
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "logs JOB_ID",
+		Short: "Print a job's output",
+		Long: `Print the output of a finished job, or with --follow, stream the log of a
+running one until it finishes. With -o json or yaml each line is printed as
+a record.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			if follow {
+				return c.followLogs(cmd, client, args[0])
+			}
+
+			job, err := client.GetJob(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if job.Results == nil {
+				return fmt.Errorf("job %s has no output yet (%s); use --follow to stream it", job.ID, job.Status)
+			}
+			if format(c.output) != formatTable {
+				return c.print(job.Results, nil)
+			}
+			// Output goes out as it is, not through the table writer
+			fmt.Fprint(c.out, job.Results.Stdout)
+			fmt.Fprint(cmd.ErrOrStderr(), job.Results.Stderr)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "stream the log until the job finishes")
+	return cmd
+}
+
+// followLogs streams a job's log, stdout lines to out and stderr lines to
+// the command's error output.
+func (c *cli) followLogs(cmd *cobra.Command, client *deparrow.Client, jobID string) error {
+	stream, err := client.StreamJobLogs(cmd.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	for line := range stream.Lines() {
+		if format(c.output) != formatTable {
+			if err := c.print(line, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		w := c.out
+		if line.Stream == deparrow.LogStreamStderr {
+			w = cmd.ErrOrStderr()
+		}
+		fmt.Fprintln(w, line.Text)
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	if end := stream.End(); end != nil && end.Status != deparrow.JobStatusCompleted {
+		return fmt.Errorf("job %s %s with exit code %d", jobID, end.Status, end.ExitCode)
+	}
+	return nil
+}
+
+// jobDetails draws one job as a two-column table.
+func jobDetails(w io.Writer, job *deparrow.Job) {
+	row(w, "Job ID", job.ID)
+	row(w, "Status", job.Status)
+	row(w, "Credits", fmt.Sprintf("%.2f", job.CreditCost))
+	row(w, "Submitted", timestamp(job.SubmittedAt))
+	if job.Orchestrator != "" {
+		row(w, "Orchestrator", job.Orchestrator)
+	}
+	if job.Error != "" {
+		row(w, "Error", job.Error)
+	}
+	for _, e := range job.Evictions {
+		row(w, "Evicted", timestamp(e.At)+" "+e.Describe())
+	}
+	if r := job.Results; r != nil {
+		row(w, "Node", orDash(r.NodeID))
+		row(w, "Exit code", r.ExitCode)
+		row(w, "Duration", fmt.Sprintf("%.1fs", r.Duration))
+		if r.OutputCID != "" {
+			row(w, "Output CID", r.OutputCID)
+		}
+		if out := strings.TrimSpace(r.Stdout); out != "" {
+			row(w, "Stdout", firstLine(out))
+		}
+	}
+}
+
+// firstLine returns the first line of s, marked when more follow.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " …"
+	}
+	return s
+}
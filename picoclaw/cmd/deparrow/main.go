@@ -0,0 +1,29 @@
+// Command deparrow is a command-line client for the DEparrow Meta-OS API,
+// built on the same client package as the agent's DEparrow tools.
+//
+// Credentials come from ~/.deparrow/config.yaml, DEPARROW_* environment
+// variables or flags, in increasing order of precedence:
+//
+//	deparrow job submit --image alpine -- echo hello
+//	deparrow job logs job-123 --follow
+//	deparrow credits balance -o json
+//	deparrow leaderboard --window 7d --region eu-west
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCommand(os.Stdout).ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,139 @@
+//go:build unit
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// run executes the CLI with args and returns what it printed.
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	root := newRootCommand(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	err := root.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestCLI_SandboxJob(t *testing.T) {
+	out, err := run(t, "--sandbox", "-o", "json", "job", "submit", "--image", "alpine", "--cpu", "500m", "--wait", "--", "echo", "hi")
+	if err != nil {
+		t.Fatalf("job submit error = %v", err)
+	}
+	var job struct {
+		ID      string `json:"job_id"`
+		Status  string `json:"status"`
+		Results struct {
+			Stdout string `json:"stdout"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &job); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if job.ID == "" || job.Status != "completed" || job.Results.Stdout == "" {
+		t.Errorf("job = %+v", job)
+	}
+
+	out, err = run(t, "--sandbox", "job", "get", "missing")
+	if err == nil {
+		t.Errorf("job get of an unknown job printed %s", out)
+	}
+	if _, err := run(t, "--sandbox", "job", "submit"); err == nil || !strings.Contains(err.Error(), "image") {
+		t.Errorf("job submit without --image error = %v", err)
+	}
+}
+
+func TestCLI_OutputFormats(t *testing.T) {
+	out, err := run(t, "--sandbox", "credits", "balance")
+	if err != nil || !strings.Contains(out, "Balance: 1000.00 credits") || !strings.Contains(out, "SOURCE") {
+		t.Errorf("table output = %s, %v", out, err)
+	}
+
+	out, err = run(t, "--sandbox", "-o", "yaml", "network", "stats")
+	if err != nil {
+		t.Fatalf("network stats error = %v", err)
+	}
+	var stats map[string]interface{}
+	if err := yaml.Unmarshal([]byte(out), &stats); err != nil || stats["total_nodes"] == nil {
+		t.Errorf("yaml output = %s, %v; want the API's field names", out, err)
+	}
+
+	out, err = run(t, "--sandbox", "leaderboard", "--window", "7d", "--limit", "2")
+	if err != nil || !strings.Contains(out, "node-euw-h100-01") || !strings.Contains(out, "+2") {
+		t.Errorf("leaderboard = %s, %v", out, err)
+	}
+
+	if _, err := run(t, "--sandbox", "-o", "xml", "nodes", "list"); err == nil {
+		t.Error("expected error for an unsupported output format")
+	}
+}
+
+func TestCLI_Transfer(t *testing.T) {
+	out, err := run(t, "--sandbox", "credits", "transfer", "user-2", "12.5")
+	if err != nil || !strings.Contains(out, "Transferred 12.50 credits to user-2") {
+		t.Errorf("transfer = %s, %v", out, err)
+	}
+	if _, err := run(t, "--sandbox", "credits", "transfer", "user-2", "-1"); err == nil {
+		t.Error("expected error for a negative amount")
+	}
+}
+
+func TestCLI_ConfigAndEnvironment(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "status": "running"})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := "api_url: " + server.URL + "\ntoken: file-token\nprofiles:\n  staging:\n    token: staging-token\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEPARROW_API_URL", "")
+	t.Setenv("DEPARROW_TOKEN", "")
+	t.Setenv("DEPARROW_PROFILE", "")
+
+	if out, err := run(t, "--config", path, "job", "get", "job-1"); err != nil || !strings.Contains(out, "running") {
+		t.Fatalf("job get = %s, %v", out, err)
+	}
+	if auth != "Bearer file-token" {
+		t.Errorf("Authorization = %q, want the config file's token", auth)
+	}
+
+	run(t, "--config", path, "--profile", "staging", "job", "get", "job-1")
+	if auth != "Bearer staging-token" {
+		t.Errorf("Authorization = %q, want the staging profile's token", auth)
+	}
+	if _, err := run(t, "--config", path, "--profile", "prod", "job", "get", "job-1"); err == nil {
+		t.Error("expected error for a profile the config file does not have")
+	}
+
+	t.Setenv("DEPARROW_TOKEN", "env-token")
+	run(t, "--config", path, "job", "get", "job-1")
+	if auth != "Bearer env-token" {
+		t.Errorf("Authorization = %q, want the environment's token", auth)
+	}
+
+	run(t, "--config", path, "--token", "flag-token", "job", "get", "job-1")
+	if auth != "Bearer flag-token" {
+		t.Errorf("Authorization = %q, want the flag's token", auth)
+	}
+
+	if _, err := run(t, "--config", filepath.Join(t.TempDir(), "missing.yaml"), "job", "list"); err == nil {
+		t.Error("expected error for a missing config file named by --config")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+func (c *cli) networkCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "Show the state of the network",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Show network-wide node, capacity and tier statistics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			stats, err := client.GetNetworkStats(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return c.print(stats, func(w io.Writer) {
+				row(w, "Nodes", fmt.Sprintf("%d (%d online)", stats.TotalNodes, stats.OnlineNodes))
+				row(w, "CPU cores", stats.TotalCPU)
+				row(w, "GPUs", stats.TotalGPU)
+				row(w, "Memory", fmt.Sprintf("%.0f GB", stats.TotalMemory))
+				row(w, "Live compute", fmt.Sprintf("%.2f TFLOPS", stats.LiveTFlops))
+				tiers := make([]string, 0, len(stats.TierDistribution))
+				for tier := range stats.TierDistribution {
+					tiers = append(tiers, tier)
+				}
+				slices.Sort(tiers)
+				for _, tier := range tiers {
+					row(w, "Tier "+tier, stats.TierDistribution[tier])
+				}
+				row(w, "As of", timestamp(stats.Timestamp))
+			})
+		},
+	})
+	return cmd
+}
+
+func (c *cli) leaderboardCommand() *cobra.Command {
+	var (
+		window string
+		opts   deparrow.LeaderboardOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "leaderboard",
+		Short: "Show the nodes contributing the most",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			opts.Window = deparrow.LeaderboardWindow(window)
+			page, err := client.GetLeaderboardPage(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return c.print(page.Entries, func(w io.Writer) {
+				row(w, "RANK", "NODE ID", "TIER", "CREDITS", "HOURS", "CHANGE")
+				for _, e := range page.Entries {
+					change := "-"
+					if delta, ok := e.RankChange(); ok {
+						change = "="
+						if delta != 0 {
+							change = fmt.Sprintf("%+d", delta)
+						}
+					}
+					row(w, e.Rank, e.NodeID, e.Tier, fmt.Sprintf("%.2f", e.CreditsEarned), fmt.Sprintf("%.1f", e.TotalHours), change)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVar(&window, "window", "", "period to rank over: 24h, 7d, 30d or all")
+	cmd.Flags().StringVar(&opts.Region, "region", "", "rank only the nodes of one region")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 10, "most nodes to show")
+	cmd.Flags().IntVar(&opts.Offset, "offset", 0, "ranks to skip")
+	return cmd
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+func (c *cli) nodesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "nodes",
+		Aliases: []string{"node"},
+		Short:   "Look up compute nodes",
+	}
+	cmd.AddCommand(c.nodesListCommand(), c.nodesGetCommand())
+	return cmd
+}
+
+func (c *cli) nodesListCommand() *cobra.Command {
+	var (
+		statuses []string
+		opts     deparrow.NodePageOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the network's nodes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				opts.Status = append(opts.Status, deparrow.NodeStatus(s))
+			}
+			page, err := client.ListNodesPage(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return c.print(page.Nodes, func(w io.Writer) {
+				row(w, "NODE ID", "STATUS", "ARCH", "TIER", "CPU", "GPU", "LAST SEEN")
+				for _, n := range page.Nodes {
+					cpu, gpu := "-", "-"
+					if r := n.Resources; r != nil {
+						cpu, gpu = fmt.Sprint(r.CPU), gpuSummary(r)
+					}
+					row(w, n.ID, n.Status, orDash(string(n.Arch)), orDash(string(n.Tier)), cpu, gpu, timestamp(n.LastSeen))
+				}
+				if page.Total > len(page.Nodes) {
+					fmt.Fprintf(w, "\nShowing %d of %d nodes\n", len(page.Nodes), page.Total)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringSliceVar(&statuses, "status", nil, "only nodes in these statuses")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 20, "most nodes to list")
+	cmd.Flags().IntVar(&opts.Offset, "offset", 0, "nodes to skip")
+	return cmd
+}
+
+func (c *cli) nodesGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get NODE_ID",
+		Short: "Show a node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.api()
+			if err != nil {
+				return err
+			}
+			node, err := client.GetNode(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return c.print(node, func(w io.Writer) { nodeDetails(w, node) })
+		},
+	}
+}
+
+// nodeDetails draws one node as a two-column table.
+func nodeDetails(w io.Writer, n *deparrow.Node) {
+	row(w, "Node ID", n.ID)
+	row(w, "Status", n.Status)
+	row(w, "Arch", orDash(string(n.Arch)))
+	row(w, "Tier", orDash(string(n.Tier)))
+	if r := n.Resources; r != nil {
+		row(w, "CPU cores", r.CPU)
+		row(w, "Memory", orDash(r.Memory))
+		row(w, "GPU", gpuSummary(r))
+	}
+	if l := n.Location; l != nil && (l.City != "" || l.Country != "") {
+		row(w, "Location", strings.Trim(l.City+", "+l.Country, ", "))
+	}
+	row(w, "Credits earned", fmt.Sprintf("%.2f", n.CreditsEarned))
+	if n.AgentVersion != "" {
+		row(w, "Agent version", n.AgentVersion)
+	}
+	row(w, "Running jobs", n.RunningJobs)
+	row(w, "Last seen", timestamp(n.LastSeen))
+}
+
+// gpuSummary describes a node's GPUs, e.g. "2 × A100".
+func gpuSummary(r *deparrow.NodeResources) string {
+	switch {
+	case r.GPU == 0:
+		return "-"
+	case r.GPUModel == "":
+		return fmt.Sprint(r.GPU)
+	}
+	return fmt.Sprintf("%d × %s", r.GPU, r.GPUModel)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+// cli holds the global flags and the client the commands share.
+type cli struct {
+	out io.Writer
+
+	configPath string
+	profile    string
+	flags      deparrow.Config
+	output     string
+
+	cmd    *cobra.Command
+	client *deparrow.Client
+}
+
+// newRootCommand builds the deparrow command tree writing to out.
+func newRootCommand(out io.Writer) *cobra.Command {
+	c := &cli{out: out}
+	root := &cobra.Command{
+		Use:   "deparrow",
+		Short: "Command-line client for the DEparrow network",
+		Long: `deparrow submits and follows jobs, moves credits and looks up nodes and
+network statistics on DEparrow.
+
+Settings are read from a profile of ~/.deparrow/config.yaml (api_url, token,
+api_key, user_id, sandbox, timeout, retry), then from DEPARROW_API_URL,
+DEPARROW_TOKEN, DEPARROW_API_KEY, DEPARROW_USER_ID, DEPARROW_SANDBOX and the
+other DEPARROW_* variables, then from flags. --profile or DEPARROW_PROFILE
+picks the profile, e.g. staging or prod.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			c.cmd = cmd
+			return validateFormat(c.output)
+		},
+	}
+	root.SetOut(out)
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&c.configPath, "config", "", "config file (default ~/.deparrow/config.yaml)")
+	flags.StringVar(&c.profile, "profile", "", "config profile to use (default "+deparrow.DefaultProfile+")")
+	flags.StringVar(&c.flags.APIURL, "api-url", "", "Meta-OS API URL")
+	flags.StringVar(&c.flags.Token, "token", "", "API token")
+	flags.StringVar(&c.flags.APIKey, "api-key", "", "API key, used instead of the token")
+	flags.StringVar(&c.flags.UserID, "user-id", "", "user whose credits are shown")
+	flags.BoolVar(&c.flags.Sandbox, "sandbox", false, "use the simulated network instead of the API")
+	flags.StringVarP(&c.output, "output", "o", string(formatTable), "output format: table, json or yaml")
+
+	root.AddCommand(
+		c.jobCommand(),
+		c.creditsCommand(),
+		c.nodesCommand(),
+		c.networkCommand(),
+		c.leaderboardCommand(),
+	)
+	return root
+}
+
+// api returns the client, creating it from the config file, the
+// environment and the flags on first use.
+func (c *cli) api() (*deparrow.Client, error) {
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	root := c.cmd.Root().PersistentFlags()
+	cfg, err := deparrow.LoadConfigFile(c.configPath, c.profile)
+	if err != nil {
+		return nil, err
+	}
+	if root.Changed("api-url") {
+		cfg.APIURL = c.flags.APIURL
+	}
+	if root.Changed("token") {
+		cfg.Token = c.flags.Token
+	}
+	if root.Changed("api-key") {
+		cfg.APIKey = c.flags.APIKey
+	}
+	if root.Changed("user-id") {
+		cfg.UserID = c.flags.UserID
+	}
+	if root.Changed("sandbox") {
+		cfg.Sandbox = c.flags.Sandbox
+	}
+	if !cfg.Sandbox && cfg.APIURL == "" {
+		return nil, fmt.Errorf("no API URL configured for profile %q; set api_url in the config file or DEPARROW_API_URL, or pass --api-url or --sandbox", cfg.Profile)
+	}
+
+	c.client, err = deparrow.NewClientFromConfig(cfg)
+	return c.client, err
+}
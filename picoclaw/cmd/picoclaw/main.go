@@ -417,8 +417,13 @@ func agentCmd() {
 			"skills_available": startupInfo["skills"].(map[string]interface{})["available"],
 		})
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := agentLoop.StartDeparrow(ctx); err != nil {
+		fmt.Printf("Error starting DEparrow: %v\n", err)
+	}
+
 	if message != "" {
-		ctx := context.Background()
 		response, err := agentLoop.ProcessDirect(ctx, message, sessionKey)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
@@ -671,6 +676,9 @@ func gatewayCmd() {
 	}()
 	fmt.Printf("✓ Health endpoints available at http://%s:%d/health and /ready\n", cfg.Gateway.Host, cfg.Gateway.Port)
 
+	if err := agentLoop.StartDeparrow(ctx); err != nil {
+		fmt.Printf("Error starting DEparrow: %v\n", err)
+	}
 	go agentLoop.Run(ctx)
 
 	sigChan := make(chan os.Signal, 1)
@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -45,6 +46,7 @@ type AgentLoop struct {
 	running        atomic.Bool
 	summarizing    sync.Map // Tracks which sessions are currently being summarized
 	channelManager *channels.Manager
+	deparrow       *deparrowTools
 }
 
 // processOptions configures how a message is processed
@@ -61,7 +63,7 @@ type processOptions struct {
 
 // createToolRegistry creates a tool registry with common tools.
 // This is shared between main agent and subagents.
-func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msgBus *bus.MessageBus) *tools.ToolRegistry {
+func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msgBus *bus.MessageBus, dep *deparrowTools) *tools.ToolRegistry {
 	registry := tools.NewToolRegistry()
 
 	// File system tools
@@ -103,21 +105,141 @@ func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msg
 	registry.Register(messageTool)
 
 	// DEparrow tools (if configured) - enables AI agents to buy compute
-	if cfg.Deparrow.Enabled {
-		deparrowClient := deparrow.NewClient(cfg.Deparrow.APIURL, cfg.Deparrow.JWTToken)
+	if dep != nil {
+		dep.provider.RegisterAll(registry)
+	}
+
+	return registry
+}
+
+// deparrowTools is the DEparrow client the main agent and its subagents
+// share, with the metrics it records.
+type deparrowTools struct {
+	provider    *deparrow.ToolsProvider
+	prefs       *deparrow.PreferencesStore
+	metrics     *deparrow.PrometheusMetrics
+	metricsAddr string
+}
+
+// newDeparrowTools builds the DEparrow tools, or returns nil when they are
+// not enabled.
+func newDeparrowTools(workspace string, cfg *config.Config) *deparrowTools {
+	if !cfg.Deparrow.Enabled {
+		return nil
+	}
+	deparrowOpts := []deparrow.ClientOption{deparrow.WithRetryPolicy(deparrow.DefaultRetryPolicy())}
+	if cfg.Deparrow.UserAgent != "" {
+		deparrowOpts = append(deparrowOpts, deparrow.WithUserAgent(cfg.Deparrow.UserAgent))
+	}
+	if cfg.Deparrow.APIKey != "" {
+		deparrowOpts = append(deparrowOpts, deparrow.WithAPIKey(cfg.Deparrow.APIKey))
+	}
+	if len(cfg.Deparrow.ReadURLs) > 0 {
+		deparrowOpts = append(deparrowOpts, deparrow.WithReadReplicas(cfg.Deparrow.ReadURLs...))
+	}
+	if cfg.Deparrow.LongPoll {
+		deparrowOpts = append(deparrowOpts, deparrow.WithLongPolling())
+	}
+	if cfg.Deparrow.TransferApprovalAbove > 0 {
+		deparrowOpts = append(deparrowOpts, deparrow.WithTransferApprovalThreshold(cfg.Deparrow.TransferApprovalAbove))
+	}
+	var deparrowMetrics *deparrow.PrometheusMetrics
+	if cfg.Deparrow.MetricsAddr != "" {
+		deparrowMetrics = deparrow.NewPrometheusMetrics("")
+		deparrowOpts = append(deparrowOpts, deparrow.WithMetrics(deparrowMetrics))
+	}
+	var deparrowClient *deparrow.Client
+	prefsPath := filepath.Join(workspace, "state", "deparrow_preferences.json")
+	earningsPath := filepath.Join(workspace, "state", "deparrow_earnings.json")
+	templatesPath := filepath.Join(workspace, "state", "deparrow_templates.json")
+	if cfg.Deparrow.Sandbox {
+		// Keep practice preferences and readings apart from the real ones
+		deparrowClient = deparrow.NewSandboxClient(deparrowOpts...)
+		prefsPath = filepath.Join(workspace, "state", "deparrow_preferences_sandbox.json")
+		earningsPath = filepath.Join(workspace, "state", "deparrow_earnings_sandbox.json")
+		templatesPath = filepath.Join(workspace, "state", "deparrow_templates_sandbox.json")
+	} else {
+		deparrowClient = deparrow.NewClient(cfg.Deparrow.APIURL, cfg.Deparrow.JWTToken, deparrowOpts...)
 		if cfg.Deparrow.UserID != "" {
 			deparrowClient.SetUserID(cfg.Deparrow.UserID)
 		}
-		deparrowProvider := deparrow.NewToolsProvider(deparrowClient)
-		deparrowProvider.RegisterAll(registry)
-		logger.InfoCF("agent", "DEparrow tools registered",
-			map[string]interface{}{
-				"api_url": cfg.Deparrow.APIURL,
-				"enabled": true,
-			})
 	}
+	prefs, err := deparrow.NewPreferencesStore(prefsPath, deparrowClient)
+	if err != nil {
+		logger.WarnCF("agent", "Failed to load DEparrow preferences",
+			map[string]interface{}{"error": err.Error()})
+	} else {
+		deparrowClient.SetPreferences(prefs)
+	}
+	if ledger, err := deparrow.NewEarningsLedger(earningsPath); err != nil {
+		logger.WarnCF("agent", "Failed to load DEparrow earnings ledger",
+			map[string]interface{}{"error": err.Error()})
+	} else {
+		deparrowClient.SetEarningsLedger(ledger)
+	}
+	if templates, err := deparrow.NewTemplateStore(templatesPath); err != nil {
+		logger.WarnCF("agent", "Failed to load DEparrow job templates",
+			map[string]interface{}{"error": err.Error()})
+	} else {
+		deparrowClient.SetTemplates(templates)
+	}
+	deparrowProvider := deparrow.NewToolsProvider(deparrowClient)
+	if cfg.Deparrow.SessionBudget > 0 || cfg.Deparrow.ConfirmSpendAbove > 0 {
+		deparrowProvider.SetBudgetGuard(deparrow.NewBudgetGuard(deparrow.BudgetConfig{
+			SessionCeiling: cfg.Deparrow.SessionBudget,
+			ConfirmAbove:   cfg.Deparrow.ConfirmSpendAbove,
+		}))
+	}
+	if deparrowMetrics != nil {
+		deparrowProvider.SetMetrics(deparrowMetrics)
+	}
+	logger.InfoCF("agent", "DEparrow tools registered",
+		map[string]interface{}{
+			"api_url": cfg.Deparrow.APIURL,
+			"enabled": true,
+			"sandbox": cfg.Deparrow.Sandbox,
+		})
+	return &deparrowTools{provider: deparrowProvider, prefs: prefs, metrics: deparrowMetrics, metricsAddr: cfg.Deparrow.MetricsAddr}
+}
 
-	return registry
+// start serves the metrics endpoint, if configured, until ctx is done,
+// and syncs the preferences and discovers the server's capabilities in the
+// background. Until discovery finishes the tools offer every action.
+// The metrics address is bound before start returns, so a bad or busy
+// address is reported to the caller.
+func (d *deparrowTools) start(ctx context.Context) error {
+	if d.metrics != nil {
+		ln, err := net.Listen("tcp", d.metricsAddr)
+		if err != nil {
+			return fmt.Errorf("DEparrow metrics endpoint: %w", err)
+		}
+		go func() {
+			if err := d.metrics.Serve(ctx, ln); err != nil {
+				logger.WarnCF("agent", "DEparrow metrics endpoint stopped",
+					map[string]interface{}{"addr": d.metricsAddr, "error": err.Error()})
+			}
+		}()
+	}
+
+	if d.prefs != nil {
+		go func() {
+			syncCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if err := d.prefs.Sync(syncCtx); err != nil {
+				logger.DebugCF("agent", "DEparrow preferences not synced",
+					map[string]interface{}{"error": err.Error()})
+			}
+		}()
+	}
+	go func() {
+		capsCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := d.provider.DiscoverCapabilities(capsCtx); err != nil {
+			logger.WarnCF("agent", "DEparrow capabilities not discovered; offering every tool action",
+				map[string]interface{}{"error": err.Error()})
+		}
+	}()
+	return nil
 }
 
 func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers.LLMProvider) *AgentLoop {
@@ -126,12 +248,15 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 
 	restrict := cfg.Agents.Defaults.RestrictToWorkspace
 
+	// DEparrow tools are shared by the main agent and its subagents
+	dep := newDeparrowTools(workspace, cfg)
+
 	// Create tool registry for main agent
-	toolsRegistry := createToolRegistry(workspace, restrict, cfg, msgBus)
+	toolsRegistry := createToolRegistry(workspace, restrict, cfg, msgBus, dep)
 
 	// Create subagent manager with its own tool registry
 	subagentManager := tools.NewSubagentManager(provider, cfg.Agents.Defaults.Model, workspace, msgBus)
-	subagentTools := createToolRegistry(workspace, restrict, cfg, msgBus)
+	subagentTools := createToolRegistry(workspace, restrict, cfg, msgBus, dep)
 	// Subagent doesn't need spawn/subagent tools to avoid recursion
 	subagentManager.SetTools(subagentTools)
 
@@ -164,7 +289,18 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 		contextBuilder: contextBuilder,
 		tools:          toolsRegistry,
 		summarizing:    sync.Map{},
+		deparrow:       dep,
+	}
+}
+
+// StartDeparrow starts the DEparrow metrics endpoint, if configured, for as
+// long as ctx lives, and syncs the DEparrow preferences and capabilities in
+// the background. It returns an error if the endpoint cannot be bound.
+func (al *AgentLoop) StartDeparrow(ctx context.Context) error {
+	if al.deparrow == nil {
+		return nil
 	}
+	return al.deparrow.start(ctx)
 }
 
 func (al *AgentLoop) Run(ctx context.Context) error {
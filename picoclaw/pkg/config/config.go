@@ -63,12 +63,41 @@ type DeparrowConfig struct {
 	Enabled bool `json:"enabled" env:"PICOCLAW_DEPARROW_ENABLED"`
 	// APIURL is the base URL for the DEparrow Meta-OS API (e.g., "http://localhost:8080").
 	APIURL string `json:"api_url" env:"PICOCLAW_DEPARROW_API_URL"`
+	// ReadURLs are base URLs of read replicas serving stats, the leaderboard
+	// and job and node lists, keeping that read load off the primary API.
+	ReadURLs FlexibleStringSlice `json:"read_urls" env:"PICOCLAW_DEPARROW_READ_URLS"`
 	// JWTToken is the authentication token for the DEparrow API.
 	// If empty, unauthenticated endpoints will still work.
 	JWTToken string `json:"jwt_token" env:"PICOCLAW_DEPARROW_JWT_TOKEN"`
+	// APIKey authenticates with a scoped API key instead of a user token,
+	// for unattended use such as CI. Takes precedence over JWTToken.
+	APIKey string `json:"api_key" env:"PICOCLAW_DEPARROW_API_KEY"`
 	// UserID is the user identifier extracted from JWT or set manually.
 	// Used for credit balance queries and job ownership.
 	UserID string `json:"user_id" env:"PICOCLAW_DEPARROW_USER_ID"`
+	// Sandbox routes every DEparrow call to a simulated network so the tools
+	// can be practiced or demoed without spending real credits.
+	Sandbox bool `json:"sandbox" env:"PICOCLAW_DEPARROW_SANDBOX"`
+	// UserAgent overrides the User-Agent sent to the DEparrow API.
+	// Defaults to "picoclaw-deparrow/<agent version>".
+	UserAgent string `json:"user_agent" env:"PICOCLAW_DEPARROW_USER_AGENT"`
+	// LongPoll makes real-time features long-poll instead of holding
+	// WebSockets open, for devices and networks that cannot keep them.
+	LongPoll bool `json:"long_poll" env:"PICOCLAW_DEPARROW_LONG_POLL"`
+	// SessionBudget is the most credits the tools may spend in one agent
+	// session. Zero means no ceiling.
+	SessionBudget float64 `json:"session_budget" env:"PICOCLAW_DEPARROW_SESSION_BUDGET"`
+	// ConfirmSpendAbove is the cost of a single job or transfer above which
+	// the agent must get the user's confirmation first. Zero never asks.
+	ConfirmSpendAbove float64 `json:"confirm_spend_above" env:"PICOCLAW_DEPARROW_CONFIRM_SPEND_ABOVE"`
+	// TransferApprovalAbove is the transfer amount above which a second
+	// user of the organization must approve the transfer. Zero leaves it
+	// to the server.
+	TransferApprovalAbove float64 `json:"transfer_approval_above" env:"PICOCLAW_DEPARROW_TRANSFER_APPROVAL_ABOVE"`
+	// MetricsAddr is the address (e.g. ":9464") to serve Prometheus metrics
+	// on: the tools' invocations, errors, latency and credits spent, and
+	// the API requests behind them. Empty serves none.
+	MetricsAddr string `json:"metrics_addr" env:"PICOCLAW_DEPARROW_METRICS_ADDR"`
 }
 
 type AgentsConfig struct {
@@ -353,6 +382,7 @@ func DefaultConfig() *Config {
 			APIURL:   "http://localhost:8080",
 			JWTToken: "",
 			UserID:   "",
+			Sandbox:  false,
 		},
 	}
 }
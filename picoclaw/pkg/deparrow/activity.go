@@ -0,0 +1,175 @@
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// activityPath is the feed of recent account activity.
+const activityPath = "/api/v1/activity"
+
+// ActivityKind is the kind of an activity feed entry.
+type ActivityKind string
+
+// Activity kinds.
+const (
+	ActivityJobCompleted     ActivityKind = "job_completed"
+	ActivityJobFailed        ActivityKind = "job_failed"
+	ActivityTransferReceived ActivityKind = "transfer_received"
+	ActivityNodeTierChanged  ActivityKind = "node_tier_changed"
+)
+
+// Valid reports whether the kind is known.
+func (k ActivityKind) Valid() bool {
+	switch k {
+	case ActivityJobCompleted, ActivityJobFailed, ActivityTransferReceived, ActivityNodeTierChanged:
+		return true
+	}
+	return false
+}
+
+// Activity is an entry of the activity feed: something that happened to
+// the user's jobs, wallet or nodes, or to their organization's.
+type Activity struct {
+	ID        string       `json:"activity_id"`
+	Kind      ActivityKind `json:"kind"`
+	Timestamp time.Time    `json:"timestamp"`
+	// Summary is a one-line description written by the server
+	Summary string `json:"summary,omitempty"`
+	// UserID is whose activity it is, in an organization's feed
+	UserID string `json:"user_id,omitempty"`
+
+	// For jobs
+	JobID      string  `json:"job_id,omitempty"`
+	CreditCost float64 `json:"credit_cost,omitempty"`
+	// For transfers
+	FromUser string  `json:"from_user,omitempty"`
+	Amount   float64 `json:"amount,omitempty"`
+	// For tier changes
+	NodeID   string           `json:"node_id,omitempty"`
+	FromTier ContributionTier `json:"from_tier,omitempty"`
+	ToTier   ContributionTier `json:"to_tier,omitempty"`
+}
+
+// ActivityFilter selects activity feed entries.
+type ActivityFilter struct {
+	PageOptions
+	// Since keeps only what happened at or after it; zero keeps all the
+	// server holds
+	Since time.Time
+	// Kinds keeps only entries of these kinds; empty keeps all
+	Kinds []ActivityKind
+	// Organization widens the feed to the user's whole organization
+	Organization bool
+}
+
+// validate checks the filter's kinds.
+func (f ActivityFilter) validate() error {
+	for _, kind := range f.Kinds {
+		if !kind.Valid() {
+			return fmt.Errorf("unknown activity kind %q", kind)
+		}
+	}
+	return nil
+}
+
+// query encodes the filter as list parameters.
+func (f ActivityFilter) query() url.Values {
+	query := url.Values{}
+	f.encode(query)
+	if !f.Since.IsZero() {
+		query.Set("since", f.Since.UTC().Format(time.RFC3339))
+	}
+	if len(f.Kinds) > 0 {
+		kinds := make([]string, len(f.Kinds))
+		for i, kind := range f.Kinds {
+			kinds[i] = string(kind)
+		}
+		query.Set("kind", strings.Join(kinds, ","))
+	}
+	if f.Organization {
+		query.Set("scope", "org")
+	}
+	return query
+}
+
+// ActivityPage is one page of the activity feed.
+type ActivityPage struct {
+	Activities []Activity
+	// Total matching entries, or -1 when the server does not report it
+	Total int
+
+	filter  ActivityFilter
+	next    PageOptions
+	hasNext bool
+}
+
+// Next returns the filter for the page after this one, or false on the
+// last page.
+func (p *ActivityPage) Next() (ActivityFilter, bool) {
+	filter := p.filter
+	filter.PageOptions = p.next
+	return filter, p.hasNext
+}
+
+// GetActivity fetches one page of the activity feed matching filter,
+// oldest first.
+//
+// Example:
+//
+//	// What happened since yesterday
+//	page, err := client.GetActivity(ctx, deparrow.ActivityFilter{
+//	    Since: time.Now().AddDate(0, 0, -1),
+//	})
+func (c *Client) GetActivity(ctx context.Context, filter ActivityFilter) (*ActivityPage, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	page := &ActivityPage{filter: filter}
+	state, err := c.streamPage(ctx, activityPath, "activities", filter.query(), nil, func(dec *json.Decoder) error {
+		var activity Activity
+		if err := dec.Decode(&activity); err != nil {
+			return &malformedResponseError{err}
+		}
+		page.Activities = append(page.Activities, activity)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = state.Total
+	page.next, page.hasNext = filter.PageOptions.next(state)
+	return page, nil
+}
+
+// AllActivity iterates over every activity feed entry matching filter,
+// fetching the following pages as the loop reaches them. An error ends the
+// iteration.
+func (c *Client) AllActivity(ctx context.Context, filter ActivityFilter) iter.Seq2[Activity, error] {
+	return func(yield func(Activity, error) bool) {
+		for {
+			page, err := c.GetActivity(ctx, filter)
+			if err != nil {
+				yield(Activity{}, err)
+				return
+			}
+			for _, activity := range page.Activities {
+				if !yield(activity, nil) {
+					return
+				}
+			}
+			next, ok := page.Next()
+			if !ok {
+				return
+			}
+			filter = next
+		}
+	}
+}
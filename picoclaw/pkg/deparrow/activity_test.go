@@ -0,0 +1,110 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandbox_ActivityFeed(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	job, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		client.GetJob(ctx, job.ID)
+	}
+	client.sandbox.transactions = append(client.sandbox.transactions, Transaction{
+		ID: "tx-gift", Type: TransactionTransfer, Amount: 15, Timestamp: time.Now(), FromUser: "user-2", ToUser: SandboxUserID,
+	})
+
+	page, err := client.GetActivity(ctx, ActivityFilter{PageOptions: PageOptions{Limit: 2}})
+	if err != nil {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	if page.Total != 3 || len(page.Activities) != 2 || page.Activities[0].Kind != ActivityNodeTierChanged {
+		t.Fatalf("page = %+v", page)
+	}
+	if _, ok := page.Next(); !ok {
+		t.Error("Next() = false on the first of two pages")
+	}
+
+	var kinds []string
+	for activity, err := range client.AllActivity(ctx, ActivityFilter{PageOptions: PageOptions{Limit: 1}, Organization: true}) {
+		if err != nil {
+			t.Fatalf("AllActivity() error = %v", err)
+		}
+		kinds = append(kinds, string(activity.Kind))
+	}
+	if strings.Join(kinds, ",") != "node_tier_changed,job_completed,transfer_received" {
+		t.Errorf("AllActivity() kinds = %v", kinds)
+	}
+
+	page, err = client.GetActivity(ctx, ActivityFilter{
+		Since: time.Now().Add(-time.Hour),
+		Kinds: []ActivityKind{ActivityJobCompleted, ActivityNodeTierChanged},
+	})
+	if err != nil || len(page.Activities) != 1 || page.Activities[0].JobID != job.ID {
+		t.Errorf("GetActivity(since an hour ago, jobs and tiers) = %+v, %v", page, err)
+	}
+
+	if _, err := client.GetActivity(ctx, ActivityFilter{Kinds: []ActivityKind{"login"}}); err == nil {
+		t.Error("expected error for an unknown activity kind")
+	}
+}
+
+func TestActivityTool_Briefing(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		json.NewEncoder(w).Encode(map[string]interface{}{"activities": []Activity{
+			{Kind: ActivityJobCompleted, Timestamp: now.Add(-20 * time.Hour), JobID: "job-1", CreditCost: 2.5, UserID: "ana"},
+			{Kind: ActivityJobFailed, Timestamp: now.Add(-10 * time.Hour), JobID: "job-2", CreditCost: 1, UserID: "ana"},
+			{Kind: ActivityTransferReceived, Timestamp: now.Add(-5 * time.Hour), FromUser: "bo", Amount: 15, UserID: "cy"},
+			{Kind: ActivityNodeTierChanged, Timestamp: now.Add(-time.Hour), Summary: "Node n-1 reached gold", UserID: "cy"},
+		}})
+	}))
+	defer server.Close()
+
+	tool := NewActivityTool(NewClient(server.URL, "test-token"))
+	tool.now = func() time.Time { return now }
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"organization": true})
+	if result.IsError {
+		t.Fatalf("Execute() error = %s", result.ForLLM)
+	}
+	if !strings.Contains(query, "scope=org") || !strings.Contains(query, "since=2026-03-09T09%3A00%3A00Z") {
+		t.Errorf("query = %s, want the organization's last day", query)
+	}
+	for _, want := range []string{
+		"last day", "1 job completed", "1 job failed", "3.50 credits spent",
+		"1 transfer received (+15.00 credits)", "1 node tier change",
+		"ana: Job job-2 failed", "cy: Received 15.00 credits from bo", "cy: Node n-1 reached gold",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"since_hours": -1.0})
+	if !result.IsError {
+		t.Error("expected error for negative since_hours")
+	}
+}
+
+func TestActivityTool_Quiet(t *testing.T) {
+	result := NewActivityTool(NewSandboxClient()).Execute(context.Background(), map[string]interface{}{"since_hours": 1.0})
+	if result.IsError || !strings.Contains(result.ForLLM, "Nothing happened") {
+		t.Errorf("Execute() = %s, want nothing in the last hour", result.ForLLM)
+	}
+}
@@ -0,0 +1,195 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+const (
+	// activityToolShown is how many of the latest entries the activity
+	// tool lists after its summary.
+	activityToolShown = 20
+
+	// activityToolScanned bounds how many entries the activity tool reads,
+	// so an organization's busy week can't page on forever.
+	activityToolScanned = 1000
+)
+
+// ActivityTool summarizes recent activity for a daily briefing.
+type ActivityTool struct {
+	client *Client
+	now    func() time.Time
+}
+
+// NewActivityTool creates a new activity tool.
+func NewActivityTool(client *Client) *ActivityTool {
+	return &ActivityTool{client: client, now: time.Now}
+}
+
+// Name returns the tool name.
+func (t *ActivityTool) Name() string {
+	return "deparrow_activity"
+}
+
+// Description returns the tool description.
+func (t *ActivityTool) Description() string {
+	return `Summarize what happened on DEparrow recently: jobs that completed or
+failed, credit transfers received, and nodes whose contribution tier changed.
+
+By default covers the last 24 hours of your own activity, which suits a
+daily briefing ("what happened since yesterday"). Set since_hours to look
+further back, and organization to include everyone in your organization.`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *ActivityTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"since_hours": map[string]interface{}{
+				"type":        "number",
+				"description": "How many hours back to summarize",
+				"default":     24,
+			},
+			"organization": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include the activity of everyone in your organization",
+				"default":     false,
+			},
+		},
+	}
+}
+
+// Execute runs the activity tool.
+func (t *ActivityTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	hours := 24.0
+	if v, ok := args["since_hours"].(float64); ok {
+		if v <= 0 {
+			return tools.ErrorResult("since_hours must be positive")
+		}
+		hours = v
+	}
+	organization, _ := args["organization"].(bool)
+
+	period := time.Duration(hours * float64(time.Hour))
+	since := t.now().Add(-period)
+	filter := ActivityFilter{Since: since, Organization: organization}
+
+	var (
+		activities []Activity
+		truncated  bool
+	)
+	for activity, err := range t.client.AllActivity(ctx, filter) {
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Failed to get activity: %v", err))
+		}
+		if len(activities) == activityToolScanned {
+			truncated = true
+			break
+		}
+		activities = append(activities, activity)
+	}
+
+	return tools.UserResult(formatActivity(activities, since, period, organization, truncated))
+}
+
+// formatActivity renders the summary and the latest entries.
+func formatActivity(activities []Activity, since time.Time, period time.Duration, organization, truncated bool) string {
+	var b strings.Builder
+	scope := "Your"
+	if organization {
+		scope = "Organization"
+	}
+	b.WriteString(fmt.Sprintf("📰 %s Activity — last %s\n", scope, formatPeriod(period)))
+	b.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	if len(activities) == 0 {
+		b.WriteString(fmt.Sprintf("Nothing happened since %s.", since.Format("2006-01-02 15:04")))
+		return b.String()
+	}
+
+	var (
+		completed, failed, received, tiers int
+		spent, receivedAmount              float64
+	)
+	for _, a := range activities {
+		switch a.Kind {
+		case ActivityJobCompleted:
+			completed++
+			spent += a.CreditCost
+		case ActivityJobFailed:
+			failed++
+			spent += a.CreditCost
+		case ActivityTransferReceived:
+			received++
+			receivedAmount += a.Amount
+		case ActivityNodeTierChanged:
+			tiers++
+		}
+	}
+
+	if completed > 0 {
+		b.WriteString(fmt.Sprintf("  ✅ %s completed\n", plural(completed, "job")))
+	}
+	if failed > 0 {
+		b.WriteString(fmt.Sprintf("  ❌ %s failed\n", plural(failed, "job")))
+	}
+	if spent > 0 {
+		b.WriteString(fmt.Sprintf("  💳 %.2f credits spent on finished jobs\n", spent))
+	}
+	if received > 0 {
+		b.WriteString(fmt.Sprintf("  💸 %s received (+%.2f credits)\n", plural(received, "transfer"), receivedAmount))
+	}
+	if tiers > 0 {
+		b.WriteString(fmt.Sprintf("  🏅 %s\n", plural(tiers, "node tier change")))
+	}
+	if truncated {
+		b.WriteString(fmt.Sprintf("  (only the first %d entries were read; narrow since_hours for the rest)\n", activityToolScanned))
+	}
+
+	latest := activities
+	if len(latest) > activityToolShown {
+		latest = latest[len(latest)-activityToolShown:]
+		b.WriteString(fmt.Sprintf("\nLatest %d:\n", activityToolShown))
+	} else {
+		b.WriteString("\nDetails:\n")
+	}
+	for _, a := range latest {
+		line := activityLine(a)
+		if organization && a.UserID != "" {
+			line = a.UserID + ": " + line
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n", a.Timestamp.Format("Jan 02 15:04"), line))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// activityLine describes an entry, in the server's words when it gives them.
+func activityLine(a Activity) string {
+	if a.Summary != "" {
+		return a.Summary
+	}
+	switch a.Kind {
+	case ActivityJobCompleted:
+		return fmt.Sprintf("Job %s completed (%.2f credits)", a.JobID, a.CreditCost)
+	case ActivityJobFailed:
+		return fmt.Sprintf("Job %s failed (%.2f credits)", a.JobID, a.CreditCost)
+	case ActivityTransferReceived:
+		return fmt.Sprintf("Received %.2f credits from %s", a.Amount, a.FromUser)
+	case ActivityNodeTierChanged:
+		return fmt.Sprintf("Node %s moved from %s to %s", a.NodeID, a.FromTier, a.ToTier)
+	}
+	return string(a.Kind)
+}
+
+// plural formats a count of things, e.g. "1 job" or "3 jobs".
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
@@ -0,0 +1,180 @@
+package deparrow
+
+import (
+	"fmt"
+	"time"
+)
+
+// SpendAnomalyKind identifies what looks wrong with recent spending.
+type SpendAnomalyKind string
+
+const (
+	// AnomalyBurnRate is spending far above the user's normal hourly rate.
+	AnomalyBurnRate SpendAnomalyKind = "burn_rate"
+	// AnomalyChargedFailures is a run of failed jobs that were still charged.
+	AnomalyChargedFailures SpendAnomalyKind = "charged_failures"
+)
+
+// SpendAnomaly is one finding of the spend anomaly detector.
+type SpendAnomaly struct {
+	Kind    SpendAnomalyKind `json:"kind"`
+	Message string           `json:"message"`
+}
+
+// AnomalyConfig tunes the spend anomaly detector.
+type AnomalyConfig struct {
+	// Window is the recent period whose spend rate is checked.
+	Window time.Duration
+	// BaselinePeriod is the period before Window the normal rate is taken from.
+	BaselinePeriod time.Duration
+	// BurnMultiplier flags a recent rate this many times the baseline rate.
+	BurnMultiplier float64
+	// MinBurn ignores recent spend below this many credits, so small
+	// absolute amounts are never flagged.
+	MinBurn float64
+	// FailureWindow is the period failed jobs are counted over.
+	FailureWindow time.Duration
+	// MaxChargedFailures flags this many charged failed jobs within FailureWindow.
+	MaxChargedFailures int
+}
+
+// DefaultAnomalyConfig flags an hour spending ten times the hourly rate of
+// the previous week, or three charged job failures in a day.
+func DefaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{
+		Window:             time.Hour,
+		BaselinePeriod:     7 * 24 * time.Hour,
+		BurnMultiplier:     10,
+		MinBurn:            5,
+		FailureWindow:      24 * time.Hour,
+		MaxChargedFailures: 3,
+	}
+}
+
+// SpendReport is the result of checking a wallet's recent spending.
+type SpendReport struct {
+	At time.Time
+	// Credits spent within the window and the resulting hourly rate
+	RecentSpend float64
+	RecentRate  float64
+	// Hourly rate over the baseline period
+	BaselineRate float64
+	// HasBaseline is false until there is spending before the window
+	HasBaseline bool
+	// Failed jobs within the failure window that were charged
+	ChargedFailures []Job
+	Anomalies       []SpendAnomaly
+}
+
+// Anomalous reports whether any anomaly was found.
+func (r *SpendReport) Anomalous() bool {
+	return len(r.Anomalies) > 0
+}
+
+// DetectSpendAnomalies compares the spend rate over the config's window
+// with the rate over the baseline period before it, and counts failed jobs
+// that were charged. A wallet younger than the baseline period is measured
+// from its first spend, so new users are not compared against idle days
+// they never had.
+func DetectSpendAnomalies(transactions []Transaction, jobs []Job, now time.Time, cfg AnomalyConfig) *SpendReport {
+	report := &SpendReport{At: now}
+
+	windowStart := now.Add(-cfg.Window)
+	baselineStart := windowStart.Add(-cfg.BaselinePeriod)
+	var baselineSpend float64
+	firstSpend := windowStart
+	for _, tx := range transactions {
+		if tx.Type != "spend" || tx.Timestamp.After(now) {
+			continue
+		}
+		switch {
+		case !tx.Timestamp.Before(windowStart):
+			report.RecentSpend += tx.Amount
+		case !tx.Timestamp.Before(baselineStart):
+			baselineSpend += tx.Amount
+			if tx.Timestamp.Before(firstSpend) {
+				firstSpend = tx.Timestamp
+			}
+		}
+	}
+
+	report.RecentRate = report.RecentSpend / cfg.Window.Hours()
+	if baselineSpend > 0 {
+		report.HasBaseline = true
+		period := max(windowStart.Sub(firstSpend), cfg.Window)
+		report.BaselineRate = baselineSpend / period.Hours()
+	}
+
+	if report.HasBaseline && report.RecentSpend >= cfg.MinBurn &&
+		report.RecentRate >= cfg.BurnMultiplier*report.BaselineRate {
+		report.Anomalies = append(report.Anomalies, SpendAnomaly{
+			Kind: AnomalyBurnRate,
+			Message: fmt.Sprintf("Spending %.2f credits/hour, %.0fx your normal %.2f credits/hour",
+				report.RecentRate, report.RecentRate/report.BaselineRate, report.BaselineRate),
+		})
+	}
+
+	failureStart := now.Add(-cfg.FailureWindow)
+	var charged float64
+	for _, job := range jobs {
+		if job.Status == JobStatusFailed && job.CreditCost > 0 && !job.SubmittedAt.Before(failureStart) {
+			report.ChargedFailures = append(report.ChargedFailures, job)
+			charged += job.CreditCost
+		}
+	}
+	if cfg.MaxChargedFailures > 0 && len(report.ChargedFailures) >= cfg.MaxChargedFailures {
+		report.Anomalies = append(report.Anomalies, SpendAnomaly{
+			Kind: AnomalyChargedFailures,
+			Message: fmt.Sprintf("%d failed jobs were charged %.2f credits in the last %s",
+				len(report.ChargedFailures), charged, formatPeriod(cfg.FailureWindow)),
+		})
+	}
+
+	return report
+}
+
+// formatPeriod renders whole days as days and anything shorter as hours.
+func formatPeriod(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		if d == 24*time.Hour {
+			return "day"
+		}
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+	if d == time.Hour {
+		return "hour"
+	}
+	return d.String()
+}
+
+// SpendingPause records why tools stopped spending credits.
+type SpendingPause struct {
+	Reason string
+	Since  time.Time
+}
+
+// PauseSpending stops tools using this client from submitting jobs or
+// transferring credits until ResumeSpending is called. Direct API calls
+// are not affected.
+func (c *Client) PauseSpending(reason string) {
+	c.spendingPause.Store(&SpendingPause{Reason: reason, Since: time.Now()})
+}
+
+// ResumeSpending lets tools spend credits again.
+func (c *Client) ResumeSpending() {
+	c.spendingPause.Store(nil)
+}
+
+// SpendingPaused returns the active pause, or nil when spending is allowed.
+func (c *Client) SpendingPaused() *SpendingPause {
+	return c.spendingPause.Load()
+}
+
+// spendingPausedMessage explains a refused spend to the agent.
+func spendingPausedMessage(pause *SpendingPause) string {
+	return fmt.Sprintf(
+		"Spending is paused since %s: %s. Ask the user to review recent spending, "+
+			"then resume it with 'deparrow_spend_check' action='resume'.",
+		pause.Since.Format("2006-01-02 15:04"), pause.Reason,
+	)
+}
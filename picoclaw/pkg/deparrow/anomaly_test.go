@@ -0,0 +1,162 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var anomalyNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// steadySpend returns a week of 1 credit spent every hour before the last hour.
+func steadySpend() []Transaction {
+	var txs []Transaction
+	for h := 2; h <= 7*24; h++ {
+		txs = append(txs, Transaction{Type: "spend", Amount: 1, Timestamp: anomalyNow.Add(-time.Duration(h) * time.Hour)})
+	}
+	return txs
+}
+
+func TestDetectSpendAnomalies_BurnRate(t *testing.T) {
+	cfg := DefaultAnomalyConfig()
+
+	txs := append(steadySpend(), Transaction{Type: "spend", Amount: 2, Timestamp: anomalyNow.Add(-10 * time.Minute)})
+	report := DetectSpendAnomalies(txs, nil, anomalyNow, cfg)
+	if report.Anomalous() {
+		t.Errorf("2x normal should not be flagged: %+v", report.Anomalies)
+	}
+	if !report.HasBaseline || report.BaselineRate < 0.95 || report.BaselineRate > 1.05 {
+		t.Errorf("BaselineRate = %.3f, want about 1", report.BaselineRate)
+	}
+
+	txs = append(steadySpend(),
+		Transaction{Type: "spend", Amount: 8, Timestamp: anomalyNow.Add(-40 * time.Minute)},
+		Transaction{Type: "spend", Amount: 8, Timestamp: anomalyNow.Add(-5 * time.Minute)},
+		Transaction{Type: "earn", Amount: 100, Timestamp: anomalyNow.Add(-5 * time.Minute)},
+	)
+	report = DetectSpendAnomalies(txs, nil, anomalyNow, cfg)
+	if len(report.Anomalies) != 1 || report.Anomalies[0].Kind != AnomalyBurnRate {
+		t.Fatalf("Anomalies = %+v, want a burn rate anomaly", report.Anomalies)
+	}
+	if report.RecentSpend != 16 {
+		t.Errorf("RecentSpend = %.2f, want 16 (earnings are not spend)", report.RecentSpend)
+	}
+}
+
+func TestDetectSpendAnomalies_NewWallet(t *testing.T) {
+	cfg := DefaultAnomalyConfig()
+
+	// One day of history at 2 credits/hour, then an hour at 15
+	var txs []Transaction
+	for h := 2; h <= 24; h++ {
+		txs = append(txs, Transaction{Type: "spend", Amount: 2, Timestamp: anomalyNow.Add(-time.Duration(h) * time.Hour)})
+	}
+	txs = append(txs, Transaction{Type: "spend", Amount: 15, Timestamp: anomalyNow.Add(-time.Minute)})
+
+	report := DetectSpendAnomalies(txs, nil, anomalyNow, cfg)
+	if report.Anomalous() {
+		t.Errorf("7.5x the rate since the first spend should not be flagged: %+v", report.Anomalies)
+	}
+
+	// Without any history there is nothing to compare with
+	report = DetectSpendAnomalies(txs[len(txs)-1:], nil, anomalyNow, cfg)
+	if report.HasBaseline || report.Anomalous() {
+		t.Errorf("report = %+v, want no baseline and no anomaly", report)
+	}
+}
+
+func TestDetectSpendAnomalies_ChargedFailures(t *testing.T) {
+	cfg := DefaultAnomalyConfig()
+	jobs := []Job{
+		{ID: "job-1", Status: JobStatusFailed, CreditCost: 2, SubmittedAt: anomalyNow.Add(-time.Hour)},
+		{ID: "job-2", Status: JobStatusFailed, CreditCost: 2, SubmittedAt: anomalyNow.Add(-2 * time.Hour)},
+		{ID: "job-3", Status: JobStatusFailed, CreditCost: 0, SubmittedAt: anomalyNow.Add(-3 * time.Hour)},
+		{ID: "job-4", Status: JobStatusFailed, CreditCost: 2, SubmittedAt: anomalyNow.Add(-48 * time.Hour)},
+		{ID: "job-5", Status: JobStatusCompleted, CreditCost: 2, SubmittedAt: anomalyNow.Add(-time.Hour)},
+	}
+
+	report := DetectSpendAnomalies(nil, jobs, anomalyNow, cfg)
+	if report.Anomalous() || len(report.ChargedFailures) != 2 {
+		t.Errorf("report = %+v, want 2 charged failures and no anomaly", report)
+	}
+
+	jobs = append(jobs, Job{ID: "job-6", Status: JobStatusFailed, CreditCost: 3, SubmittedAt: anomalyNow.Add(-time.Minute)})
+	report = DetectSpendAnomalies(nil, jobs, anomalyNow, cfg)
+	if len(report.Anomalies) != 1 || report.Anomalies[0].Kind != AnomalyChargedFailures {
+		t.Fatalf("Anomalies = %+v, want charged failures", report.Anomalies)
+	}
+	if want := "3 failed jobs were charged 7.00 credits in the last day"; report.Anomalies[0].Message != want {
+		t.Errorf("Message = %q, want %q", report.Anomalies[0].Message, want)
+	}
+}
+
+// newSpendServer serves a burst of spending on top of a steady week.
+func newSpendServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	txs := append(steadySpend(), Transaction{Type: "spend", Amount: 50, Timestamp: anomalyNow.Add(-time.Minute)})
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/credits/transactions":
+			json.NewEncoder(w).Encode(map[string]interface{}{"transactions": txs})
+		case "/api/v1/jobs":
+			json.NewEncoder(w).Encode(map[string]interface{}{"jobs": []Job{}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestSpendCheckTool_PauseAndResume(t *testing.T) {
+	server := newSpendServer(t)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	tool := NewSpendCheckTool(client)
+	tool.now = func() time.Time { return anomalyNow }
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{})
+	if result.IsError || !strings.Contains(result.ForLLM, "Unusual spending") {
+		t.Fatalf("result should warn about the burst:\n%s", result.ForLLM)
+	}
+	if client.SpendingPaused() != nil {
+		t.Fatal("spending should not be paused without pause_on_anomaly")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"pause_on_anomaly": true})
+	if !strings.Contains(result.ForLLM, "paused") || client.SpendingPaused() == nil {
+		t.Fatalf("spending should be paused:\n%s", result.ForLLM)
+	}
+
+	// Tools refuse to spend while paused, before contacting the server
+	submit := NewJobTool(client).Execute(ctx, map[string]interface{}{"image": "alpine"})
+	if !submit.IsError || !strings.Contains(submit.ForLLM, "Spending is paused") {
+		t.Errorf("submit should be refused while paused:\n%s", submit.ForLLM)
+	}
+	transfer := NewTransferTool(client).Execute(ctx, map[string]interface{}{"to_user_id": "user-2", "amount": 1.0})
+	if !transfer.IsError || !strings.Contains(transfer.ForLLM, "Spending is paused") {
+		t.Errorf("transfer should be refused while paused:\n%s", transfer.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "resume"})
+	if result.IsError || client.SpendingPaused() != nil {
+		t.Errorf("resume failed:\n%s", result.ForLLM)
+	}
+}
+
+func TestSpendCheckTool_Sandbox(t *testing.T) {
+	result := NewSpendCheckTool(NewSandboxClient()).Execute(context.Background(), map[string]interface{}{})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Normal rate") {
+		t.Errorf("result missing the rate summary:\n%s", result.ForLLM)
+	}
+}
@@ -0,0 +1,152 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// SpendCheckTool watches the wallet for unusual spending and can pause
+// the agent's own spending until the user has had a look.
+type SpendCheckTool struct {
+	client *Client
+	config AnomalyConfig
+	now    func() time.Time
+}
+
+// NewSpendCheckTool creates a new spend check tool with the default thresholds.
+func NewSpendCheckTool(client *Client) *SpendCheckTool {
+	return &SpendCheckTool{client: client, config: DefaultAnomalyConfig(), now: time.Now}
+}
+
+// Name returns the tool name.
+func (t *SpendCheckTool) Name() string {
+	return "deparrow_spend_check"
+}
+
+// Description returns the tool description.
+func (t *SpendCheckTool) Description() string {
+	return `Check recent credit spending for anomalies.
+
+Compares the last hour's spend rate with your normal hourly rate over the
+previous week and flags bursts of ten times normal or more, and flags
+repeated failed jobs that were still charged.
+
+With pause_on_anomaly, an anomaly pauses job submissions and transfers made
+through these tools until spending is resumed with action='resume'. Run a
+check after submitting many jobs in a row or when jobs keep failing.`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *SpendCheckTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"check", "resume"},
+				"description": "Action to perform: 'check' to look for anomalies, 'resume' to allow spending again after a pause",
+				"default":     "check",
+			},
+			"pause_on_anomaly": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Pause job submissions and transfers when an anomaly is found",
+				"default":     false,
+			},
+		},
+	}
+}
+
+// Execute runs the spend check tool.
+func (t *SpendCheckTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	action, _ := args["action"].(string)
+	switch action {
+	case "", "check":
+		pause, _ := args["pause_on_anomaly"].(bool)
+		return t.check(ctx, pause)
+	case "resume":
+		return t.resume()
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
+	}
+}
+
+// check runs the detector over the wallet and job history.
+func (t *SpendCheckTool) check(ctx context.Context, pauseOnAnomaly bool) *tools.ToolResult {
+	now := t.now()
+	since := now.Add(-t.config.Window - t.config.BaselinePeriod)
+	transactions, err := t.client.ListTransactions(ctx, since)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get transactions: %v", err))
+	}
+	jobs, err := t.client.ListJobs(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to list jobs: %v", err))
+	}
+
+	report := DetectSpendAnomalies(transactions, jobs, now, t.config)
+
+	var result strings.Builder
+	result.WriteString("🔎 Spend Check\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(fmt.Sprintf("  Last %s:  %.2f credits (%.2f/hour)\n",
+		formatPeriod(t.config.Window), report.RecentSpend, report.RecentRate))
+	if report.HasBaseline {
+		result.WriteString(fmt.Sprintf("  Normal rate: %.2f credits/hour\n", report.BaselineRate))
+	} else {
+		result.WriteString("  Normal rate: not enough spending history yet\n")
+	}
+	result.WriteString(fmt.Sprintf("  Charged failures in the last %s: %d\n",
+		formatPeriod(t.config.FailureWindow), len(report.ChargedFailures)))
+
+	if !report.Anomalous() {
+		result.WriteString("\n✅ Spending looks normal.")
+		if pause := t.client.SpendingPaused(); pause != nil {
+			result.WriteString(fmt.Sprintf("\n\n⏸️  Spending is still paused: %s. Use action='resume' to allow it again.", pause.Reason))
+		}
+		return tools.UserResult(result.String())
+	}
+
+	result.WriteString("\n⚠️  Unusual spending:\n")
+	reasons := make([]string, len(report.Anomalies))
+	for i, anomaly := range report.Anomalies {
+		result.WriteString(fmt.Sprintf("  • %s\n", anomaly.Message))
+		reasons[i] = anomaly.Message
+	}
+	if len(report.ChargedFailures) > 0 && len(report.ChargedFailures) <= 10 {
+		result.WriteString("\n  Charged failures:\n")
+		for _, job := range report.ChargedFailures {
+			result.WriteString(fmt.Sprintf("    %s  %.2f credits  %s\n",
+				job.ID, job.CreditCost, job.SubmittedAt.Format("2006-01-02 15:04")))
+		}
+	}
+
+	if pauseOnAnomaly {
+		t.client.PauseSpending(strings.Join(reasons, "; "))
+		result.WriteString("\n⏸️  Job submissions and transfers are paused. Review the spending with the user, " +
+			"then use action='resume' to allow them again.")
+	} else {
+		result.WriteString("\n💡 Review recent jobs with 'deparrow_list_jobs' before submitting more.")
+	}
+
+	return tools.UserResult(result.String())
+}
+
+// resume lifts a spending pause.
+func (t *SpendCheckTool) resume() *tools.ToolResult {
+	pause := t.client.SpendingPaused()
+	if pause == nil {
+		return tools.UserResult("Spending is not paused.")
+	}
+	t.client.ResumeSpending()
+	return tools.UserResult(fmt.Sprintf(
+		"▶️  Spending resumed. It had been paused since %s: %s",
+		pause.Since.Format("2006-01-02 15:04"), pause.Reason,
+	))
+}
+
+// Ensure tool implements the Tool interface
+var _ tools.Tool = (*SpendCheckTool)(nil)
@@ -0,0 +1,105 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_APIKeyHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(HeaderAPIKey); got != "dpk_ci_secret" {
+			t.Errorf("%s = %q, want dpk_ci_secret", HeaderAPIKey, got)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization should not be sent with an API key, got %s", auth)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user-token", WithAPIKey("dpk_ci_secret"))
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+}
+
+func TestClient_APIKeyLifecycle(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	key := APIKey{ID: "key-1", Name: "ci", Scope: APIKeyScopeSubmitOnly, Prefix: "dpk_abcd", CreatedAt: now}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == apiKeysPath:
+			var req APIKeyRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Name != "ci" || req.Scope != APIKeyScopeSubmitOnly {
+				t.Errorf("create request = %+v", req)
+			}
+			issued := key
+			issued.Secret = "dpk_abcd_first"
+			json.NewEncoder(w).Encode(issued)
+		case r.Method == http.MethodGet && r.URL.Path == apiKeysPath:
+			json.NewEncoder(w).Encode(map[string]interface{}{"api_keys": []APIKey{key}})
+		case r.Method == http.MethodPost && r.URL.Path == apiKeysPath+"/key-1/rotate":
+			rotated := key
+			rotated.RotatedAt = &now
+			rotated.Secret = "dpk_efgh_second"
+			json.NewEncoder(w).Encode(rotated)
+		case r.Method == http.MethodDelete && r.URL.Path == apiKeysPath+"/key-1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user-token")
+	ctx := context.Background()
+
+	created, err := client.CreateAPIKey(ctx, &APIKeyRequest{Name: "ci", Scope: APIKeyScopeSubmitOnly})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if created.Secret != "dpk_abcd_first" {
+		t.Errorf("Secret = %q, want dpk_abcd_first", created.Secret)
+	}
+
+	keys, err := client.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListAPIKeys() error = %v", err)
+	}
+	if len(keys) != 1 || keys[0].Secret != "" || keys[0].Revoked() {
+		t.Errorf("ListAPIKeys() = %+v, want one active key without its secret", keys)
+	}
+
+	rotated, err := client.RotateAPIKey(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("RotateAPIKey() error = %v", err)
+	}
+	if rotated.Secret != "dpk_efgh_second" || rotated.RotatedAt == nil {
+		t.Errorf("RotateAPIKey() = %+v", rotated)
+	}
+
+	if err := client.RevokeAPIKey(ctx, "key-1"); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+}
+
+func TestClient_CreateAPIKey_Validation(t *testing.T) {
+	client := NewClient("http://localhost:0", "user-token")
+	ctx := context.Background()
+
+	if _, err := client.CreateAPIKey(ctx, &APIKeyRequest{Scope: APIKeyScopeReadOnly}); err == nil {
+		t.Error("expected error without a name")
+	}
+	if _, err := client.CreateAPIKey(ctx, &APIKeyRequest{Name: "ci", Scope: "admin"}); err == nil {
+		t.Error("expected error for an unsupported scope")
+	}
+}
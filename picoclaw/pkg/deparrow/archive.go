@@ -0,0 +1,269 @@
+package deparrow
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// jobArchivePath is the collection endpoint for archived jobs.
+const jobArchivePath = "/api/v1/jobs/archive"
+
+// ExportFormat selects the encoding of a job export.
+type ExportFormat string
+
+const (
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatCSV  ExportFormat = "csv"
+	ExportFormatTar  ExportFormat = "tar"
+)
+
+// Valid reports whether the format is supported.
+func (f ExportFormat) Valid() bool {
+	switch f {
+	case ExportFormatJSON, ExportFormatCSV, ExportFormatTar:
+		return true
+	}
+	return false
+}
+
+// JobRecord is a job as kept in the archive or written to an export.
+// ArchivedAt is nil for jobs that are still on the active list.
+type JobRecord struct {
+	Job
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// JobExport is a portable snapshot of an account's jobs.
+type JobExport struct {
+	ExportedAt time.Time   `json:"exported_at"`
+	Since      *time.Time  `json:"since,omitempty"`
+	TotalCost  float64     `json:"total_cost"`
+	Jobs       []JobRecord `json:"jobs"`
+}
+
+// jobExportColumns is the header row of the CSV export.
+var jobExportColumns = []string{
+	"job_id", "status", "image", "submitted_at", "completed_at", "duration_seconds",
+	"credit_cost", "exit_code", "node_id", "output_cid", "archived_at",
+}
+
+// ArchiveJob moves a finished job from the active list to the archive.
+// Pending and running jobs cannot be archived.
+func (c *Client) ArchiveJob(ctx context.Context, jobID string) (*JobRecord, error) {
+	var result JobRecord
+	if err := c.doRequest(ctx, http.MethodPost, "/api/v1/jobs/"+url.PathEscape(jobID)+"/archive", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ArchiveFinishedJobs archives every completed, failed or cancelled job
+// submitted more than olderThan ago, and returns the archived jobs.
+func (c *Client) ArchiveFinishedJobs(ctx context.Context, olderThan time.Duration) ([]JobRecord, error) {
+	cutoff := time.Now().Add(-olderThan)
+	var ids []string
+	err := c.EachJob(ctx, func(job Job) error {
+		if job.Status.IsTerminal() && job.SubmittedAt.Before(cutoff) {
+			ids = append(ids, job.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	archived := make([]JobRecord, 0, len(ids))
+	for _, id := range ids {
+		record, err := c.ArchiveJob(ctx, id)
+		if err != nil {
+			return archived, fmt.Errorf("failed to archive job %s: %w", id, err)
+		}
+		archived = append(archived, *record)
+	}
+	return archived, nil
+}
+
+// ListArchivedJobs retrieves the archived jobs submitted at or after since.
+// A zero since returns the whole archive.
+func (c *Client) ListArchivedJobs(ctx context.Context, since time.Time) ([]JobRecord, error) {
+	path := jobArchivePath
+	if !since.IsZero() {
+		path += "?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	}
+
+	var result struct {
+		Jobs []JobRecord `json:"jobs"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Jobs == nil {
+		result.Jobs = []JobRecord{}
+	}
+	return result.Jobs, nil
+}
+
+// ExportJobs collects the active and archived jobs submitted within period
+// and encodes them in the given format. A zero period exports every job.
+//
+// JSON and CSV produce a single document. Tar produces an archive holding
+// manifest.json, jobs.csv and one jobs/<job_id>.json per job with its full
+// spec and results metadata.
+func (c *Client) ExportJobs(ctx context.Context, period time.Duration, format ExportFormat) ([]byte, error) {
+	if !format.Valid() {
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	now := time.Now()
+	export := JobExport{ExportedAt: now, Jobs: []JobRecord{}}
+	var since time.Time
+	if period > 0 {
+		since = now.Add(-period)
+		export.Since = &since
+	}
+
+	err := c.EachJob(ctx, func(job Job) error {
+		if !job.SubmittedAt.Before(since) {
+			export.Jobs = append(export.Jobs, JobRecord{Job: job})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	archived, err := c.ListArchivedJobs(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	export.Jobs = append(export.Jobs, archived...)
+
+	sort.SliceStable(export.Jobs, func(i, j int) bool {
+		return export.Jobs[i].SubmittedAt.Before(export.Jobs[j].SubmittedAt)
+	})
+	for _, job := range export.Jobs {
+		export.TotalCost += job.CreditCost
+	}
+
+	switch format {
+	case ExportFormatCSV:
+		return encodeJobsCSV(export.Jobs)
+	case ExportFormatTar:
+		return encodeJobsTar(export)
+	default:
+		return json.MarshalIndent(export, "", "  ")
+	}
+}
+
+// encodeJobsCSV writes one row per job with its costs and results metadata.
+func encodeJobsCSV(jobs []JobRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(jobExportColumns); err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		var image, exitCode, nodeID, outputCID, completedAt, archivedAt string
+		if job.Spec != nil {
+			image = job.Spec.Image
+		}
+		if job.Results != nil {
+			exitCode = strconv.Itoa(job.Results.ExitCode)
+			nodeID = job.Results.NodeID
+			outputCID = job.Results.OutputCID
+		}
+		if job.CompletedAt != nil {
+			completedAt = job.CompletedAt.UTC().Format(time.RFC3339)
+		}
+		if job.ArchivedAt != nil {
+			archivedAt = job.ArchivedAt.UTC().Format(time.RFC3339)
+		}
+
+		err := w.Write([]string{
+			job.ID,
+			string(job.Status),
+			image,
+			job.SubmittedAt.UTC().Format(time.RFC3339),
+			completedAt,
+			// Unfinished jobs have no duration yet
+			strconv.FormatFloat(job.Duration(job.SubmittedAt).Seconds(), 'f', 0, 64),
+			strconv.FormatFloat(job.CreditCost, 'f', 2, 64),
+			exitCode,
+			nodeID,
+			outputCID,
+			archivedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeJobsTar bundles the manifest, the CSV summary and one file per job.
+func encodeJobsTar(export JobExport) ([]byte, error) {
+	manifest, err := json.MarshalIndent(struct {
+		ExportedAt time.Time  `json:"exported_at"`
+		Since      *time.Time `json:"since,omitempty"`
+		TotalCost  float64    `json:"total_cost"`
+		JobCount   int        `json:"job_count"`
+	}{export.ExportedAt, export.Since, export.TotalCost, len(export.Jobs)}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	summary, err := encodeJobsCSV(export.Jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	add := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: export.ExportedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := add("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := add("jobs.csv", summary); err != nil {
+		return nil, err
+	}
+	for _, job := range export.Jobs {
+		data, err := json.MarshalIndent(job, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := add("jobs/"+url.PathEscape(job.ID)+".json", data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,170 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+// completedSandboxJob submits a job and polls it until the sandbox completes it.
+func completedSandboxJob(t *testing.T, client *Client, image string) *Job {
+	t.Helper()
+	ctx := context.Background()
+
+	job, err := client.SubmitJob(ctx, &JobSpec{Image: image})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+	for job.Status != JobStatusCompleted {
+		if job, err = client.GetJob(ctx, job.ID); err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+	}
+	return job
+}
+
+func TestClient_ArchiveJob(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	done := completedSandboxJob(t, client, "alpine")
+	pending, _ := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+
+	if _, err := client.ArchiveJob(ctx, pending.ID); err == nil {
+		t.Error("expected error archiving a pending job")
+	}
+
+	record, err := client.ArchiveJob(ctx, done.ID)
+	if err != nil {
+		t.Fatalf("ArchiveJob() error = %v", err)
+	}
+	if record.ID != done.ID || record.ArchivedAt == nil {
+		t.Errorf("ArchiveJob() = %+v, want %s with an archive time", record, done.ID)
+	}
+
+	jobs, _ := client.ListJobs(ctx)
+	if len(jobs) != 1 || jobs[0].ID != pending.ID {
+		t.Errorf("ListJobs() = %+v, want only the pending job", jobs)
+	}
+
+	archived, err := client.ListArchivedJobs(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("ListArchivedJobs() error = %v", err)
+	}
+	if len(archived) != 1 || archived[0].Results == nil {
+		t.Errorf("ListArchivedJobs() = %+v, want the completed job with its results", archived)
+	}
+
+	archived, _ = client.ListArchivedJobs(ctx, time.Now().Add(time.Hour))
+	if len(archived) != 0 {
+		t.Errorf("ListArchivedJobs(future) = %d jobs, want 0", len(archived))
+	}
+}
+
+func TestClient_ArchiveFinishedJobs(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	completedSandboxJob(t, client, "alpine")
+	cancelled, _ := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	client.CancelJob(ctx, cancelled.ID)
+	client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+
+	archived, err := client.ArchiveFinishedJobs(ctx, 0)
+	if err != nil {
+		t.Fatalf("ArchiveFinishedJobs() error = %v", err)
+	}
+	if len(archived) != 2 {
+		t.Errorf("archived %d jobs, want 2", len(archived))
+	}
+
+	jobs, _ := client.ListJobs(ctx)
+	if len(jobs) != 1 || jobs[0].Status != JobStatusPending {
+		t.Errorf("ListJobs() = %+v, want only the pending job", jobs)
+	}
+}
+
+func TestClient_ExportJobs(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	done := completedSandboxJob(t, client, "alpine")
+	client.ArchiveJob(ctx, done.ID)
+	active, _ := client.SubmitJob(ctx, &JobSpec{Image: "python:3.11"})
+
+	t.Run("json", func(t *testing.T) {
+		data, err := client.ExportJobs(ctx, 24*time.Hour, ExportFormatJSON)
+		if err != nil {
+			t.Fatalf("ExportJobs() error = %v", err)
+		}
+		var export JobExport
+		if err := json.Unmarshal(data, &export); err != nil {
+			t.Fatalf("invalid JSON export: %v", err)
+		}
+		if len(export.Jobs) != 2 {
+			t.Fatalf("exported %d jobs, want 2", len(export.Jobs))
+		}
+		if export.Jobs[0].ArchivedAt == nil || export.Jobs[1].ArchivedAt != nil {
+			t.Errorf("archive times = %v, %v, want only the first job archived", export.Jobs[0].ArchivedAt, export.Jobs[1].ArchivedAt)
+		}
+		if want := done.CreditCost + active.CreditCost; export.TotalCost != want {
+			t.Errorf("TotalCost = %.2f, want %.2f", export.TotalCost, want)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		data, err := client.ExportJobs(ctx, 0, ExportFormatCSV)
+		if err != nil {
+			t.Fatalf("ExportJobs() error = %v", err)
+		}
+		rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV export: %v", err)
+		}
+		if len(rows) != 3 || rows[0][0] != "job_id" {
+			t.Fatalf("rows = %v, want a header and 2 jobs", rows)
+		}
+		if rows[1][0] != done.ID || rows[1][2] != "alpine" || rows[2][0] != active.ID {
+			t.Errorf("rows = %v, want jobs in submission order", rows[1:])
+		}
+	})
+
+	t.Run("tar", func(t *testing.T) {
+		data, err := client.ExportJobs(ctx, 0, ExportFormatTar)
+		if err != nil {
+			t.Fatalf("ExportJobs() error = %v", err)
+		}
+		var names []string
+		tr := tar.NewReader(bytes.NewReader(data))
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("invalid tar export: %v", err)
+			}
+			names = append(names, header.Name)
+		}
+		want := []string{"manifest.json", "jobs.csv", "jobs/" + done.ID + ".json", "jobs/" + active.ID + ".json"}
+		if len(names) != len(want) {
+			t.Fatalf("entries = %v, want %v", names, want)
+		}
+		for i := range want {
+			if names[i] != want[i] {
+				t.Errorf("entry %d = %s, want %s", i, names[i], want[i])
+			}
+		}
+	})
+
+	if _, err := client.ExportJobs(ctx, 0, "xml"); err == nil {
+		t.Error("expected error for an unsupported format")
+	}
+}
@@ -0,0 +1,472 @@
+package deparrow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// authRefreshPath exchanges a refresh token for a new access token.
+	authRefreshPath = "/api/v1/auth/refresh"
+
+	// deviceCodePath and deviceTokenPath implement the OAuth2 device
+	// authorization grant (RFC 8628).
+	deviceCodePath  = "/api/v1/auth/device/code"
+	deviceTokenPath = "/api/v1/auth/device/token"
+
+	// deviceCodeGrantType is the grant type of device token requests.
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// defaultDevicePollInterval is how often the device token endpoint is
+	// polled when the server suggests no interval.
+	defaultDevicePollInterval = 5 * time.Second
+
+	// tokenExpiryLeeway renews access tokens this long before they expire,
+	// so requests in flight do not carry a token that lapses on the way.
+	tokenExpiryLeeway = 30 * time.Second
+)
+
+var (
+	// ErrTokenNotRefreshable is returned by token providers that cannot
+	// obtain a new access token, such as a static token.
+	ErrTokenNotRefreshable = errors.New("token cannot be refreshed")
+
+	// ErrDeviceAccessDenied is returned when the user declines a device login.
+	ErrDeviceAccessDenied = errors.New("device login was denied")
+
+	// ErrDeviceCodeExpired is returned when a device login is not approved
+	// before its code expires.
+	ErrDeviceCodeExpired = errors.New("device code expired before the login was approved")
+)
+
+// TokenProvider supplies the bearer token requests are authenticated with.
+// A request rejected with 401 is sent once more after Refresh.
+type TokenProvider interface {
+	// Token returns the current access token, obtaining one first if needed.
+	Token(ctx context.Context) (string, error)
+
+	// Refresh replaces the access token the server rejected. When another
+	// request already replaced it, the current token is returned as is.
+	Refresh(ctx context.Context, rejected string) (string, error)
+}
+
+// WithTokenProvider authenticates requests with tokens from provider
+// instead of the static JWT token. Providers that talk to the auth
+// endpoints use the client's API URL and HTTP client.
+//
+// Example:
+//
+//	provider := deparrow.NewRefreshTokenProvider(saved, func(t deparrow.AuthToken) { save(t) })
+//	client := deparrow.NewClient("https://api.deparrow.net", "", deparrow.WithTokenProvider(provider))
+func WithTokenProvider(provider TokenProvider) ClientOption {
+	return func(c *Client) {
+		c.tokens = provider
+	}
+}
+
+// AuthToken is a token set issued by the auth endpoints.
+type AuthToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// When the access token expires (zero if it does not)
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+// expired reports whether the access token is missing or about to expire.
+func (t AuthToken) expired(now time.Time) bool {
+	return t.AccessToken == "" || (!t.Expiry.IsZero() && !now.Add(tokenExpiryLeeway).Before(t.Expiry))
+}
+
+// StaticToken is a TokenProvider for a fixed token, such as a JWT issued
+// out of band. It cannot be refreshed.
+type StaticToken string
+
+// Token returns the token.
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// Refresh returns ErrTokenNotRefreshable.
+func (t StaticToken) Refresh(ctx context.Context, rejected string) (string, error) {
+	return "", ErrTokenNotRefreshable
+}
+
+// clientBinder is implemented by token providers that call the auth
+// endpoints of the client they are given to.
+type clientBinder interface {
+	bindClient(c *Client)
+}
+
+// tokenResponse is the body of the OAuth2 token endpoints.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	// Set on failure, e.g. "authorization_pending" while a device login waits
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// authToken converts a successful response, keeping refreshToken when the
+// server did not rotate it.
+func (r *tokenResponse) authToken(now time.Time, refreshToken string) AuthToken {
+	token := AuthToken{AccessToken: r.AccessToken, RefreshToken: r.RefreshToken}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	if r.ExpiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return token
+}
+
+// apiError converts a failed response to *APIError.
+func (r *tokenResponse) apiError(status int) *APIError {
+	return &APIError{Code: status, Message: r.Error, Details: r.ErrorDescription}
+}
+
+// tokenCache holds a token set and renews it under a lock, so requests
+// rejected together renew it only once.
+type tokenCache struct {
+	mu      sync.Mutex
+	client  *Client
+	token   AuthToken
+	onToken func(AuthToken)
+	now     func() time.Time
+}
+
+func (tc *tokenCache) bindClient(c *Client) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.client == nil {
+		tc.client = c
+	}
+}
+
+// get returns the access token, renewing it first when it is missing or
+// about to expire.
+func (tc *tokenCache) get(ctx context.Context, renew func(ctx context.Context) (AuthToken, error)) (string, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if !tc.token.expired(tc.now()) {
+		return tc.token.AccessToken, nil
+	}
+	return tc.renewLocked(ctx, renew)
+}
+
+// replace renews the access token unless rejected is no longer current.
+func (tc *tokenCache) replace(ctx context.Context, rejected string, renew func(ctx context.Context) (AuthToken, error)) (string, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.token.AccessToken != "" && tc.token.AccessToken != rejected {
+		return tc.token.AccessToken, nil
+	}
+	return tc.renewLocked(ctx, renew)
+}
+
+func (tc *tokenCache) renewLocked(ctx context.Context, renew func(ctx context.Context) (AuthToken, error)) (string, error) {
+	if tc.client == nil {
+		return "", errors.New("token provider is not attached to a client")
+	}
+	token, err := renew(ctx)
+	if err != nil {
+		return "", err
+	}
+	tc.token = token
+	if tc.onToken != nil {
+		tc.onToken(token)
+	}
+	return token.AccessToken, nil
+}
+
+// current returns the token set held.
+func (tc *tokenCache) current() AuthToken {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.token
+}
+
+// postAuth posts body to an unauthenticated auth endpoint and decodes the
+// token response, reporting the status code.
+func (c *Client) postAuth(ctx context.Context, path string, body interface{}, result interface{}) (int, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setMetadataHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer closeBody(resp.Body)
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil && err != io.EOF && resp.StatusCode < 400 {
+		return resp.StatusCode, &malformedResponseError{err}
+	}
+	return resp.StatusCode, nil
+}
+
+// exchangeRefreshToken obtains a new token set for refreshToken.
+func (c *Client) exchangeRefreshToken(ctx context.Context, refreshToken string, now time.Time) (AuthToken, error) {
+	var result tokenResponse
+	status, err := c.postAuth(ctx, authRefreshPath, map[string]string{"refresh_token": refreshToken}, &result)
+	if err != nil {
+		return AuthToken{}, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	if status >= 400 || result.AccessToken == "" {
+		return AuthToken{}, fmt.Errorf("failed to refresh token: %w", result.apiError(status))
+	}
+	return result.authToken(now, refreshToken), nil
+}
+
+// RefreshTokenProvider keeps an access token fresh by exchanging its
+// refresh token at /api/v1/auth/refresh before the access token expires
+// and after the server rejects it.
+type RefreshTokenProvider struct {
+	cache tokenCache
+}
+
+// NewRefreshTokenProvider creates a provider starting from token. The
+// access token may be empty, in which case the first request refreshes.
+// onRefresh, if not nil, is called with every new token set, so the
+// rotated refresh token can be saved.
+func NewRefreshTokenProvider(token AuthToken, onRefresh func(AuthToken)) *RefreshTokenProvider {
+	return &RefreshTokenProvider{cache: tokenCache{token: token, onToken: onRefresh, now: time.Now}}
+}
+
+// Token returns the access token, refreshing it when it is about to expire.
+func (p *RefreshTokenProvider) Token(ctx context.Context) (string, error) {
+	return p.cache.get(ctx, p.exchange)
+}
+
+// Refresh exchanges the refresh token for a new access token.
+func (p *RefreshTokenProvider) Refresh(ctx context.Context, rejected string) (string, error) {
+	return p.cache.replace(ctx, rejected, p.exchange)
+}
+
+// Current returns the token set held by the provider.
+func (p *RefreshTokenProvider) Current() AuthToken {
+	return p.cache.current()
+}
+
+func (p *RefreshTokenProvider) bindClient(c *Client) {
+	p.cache.bindClient(c)
+}
+
+// exchange runs with the cache locked.
+func (p *RefreshTokenProvider) exchange(ctx context.Context) (AuthToken, error) {
+	if p.cache.token.RefreshToken == "" {
+		return AuthToken{}, ErrTokenNotRefreshable
+	}
+	return p.cache.client.exchangeRefreshToken(ctx, p.cache.token.RefreshToken, p.cache.now())
+}
+
+// DeviceAuthorization is what the user needs to approve a device login.
+type DeviceAuthorization struct {
+	// Code the user enters at VerificationURI
+	UserCode string `json:"user_code"`
+	// Page where the user approves the login
+	VerificationURI string `json:"verification_uri"`
+	// VerificationURI with the code filled in, if the server offers one
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	// When the code stops being accepted
+	ExpiresAt time.Time `json:"-"`
+
+	deviceCode string
+	interval   time.Duration
+}
+
+// DeviceFlowProvider logs in with the OAuth2 device authorization grant:
+// the user approves the login on another device while the provider polls
+// for the token. Once logged in it refreshes like RefreshTokenProvider,
+// and starts a new login only when the refresh token is rejected.
+type DeviceFlowProvider struct {
+	cache    tokenCache
+	clientID string
+	prompt   func(DeviceAuthorization)
+	// Overrides the poll interval the server suggests (tests only)
+	pollInterval time.Duration
+}
+
+// NewDeviceFlowProvider creates a provider that logs in as the OAuth2
+// client clientID on the first request. prompt is called with the code
+// the user has to enter and must show it to them; onToken, if not nil, is
+// called with every new token set.
+//
+// Example:
+//
+//	provider := deparrow.NewDeviceFlowProvider("picoclaw", func(a deparrow.DeviceAuthorization) {
+//	    fmt.Printf("Open %s and enter %s\n", a.VerificationURI, a.UserCode)
+//	}, nil)
+func NewDeviceFlowProvider(clientID string, prompt func(DeviceAuthorization), onToken func(AuthToken)) *DeviceFlowProvider {
+	return &DeviceFlowProvider{
+		cache:    tokenCache{onToken: onToken, now: time.Now},
+		clientID: clientID,
+		prompt:   prompt,
+	}
+}
+
+// Token returns the access token, logging in first if there is none.
+func (p *DeviceFlowProvider) Token(ctx context.Context) (string, error) {
+	return p.cache.get(ctx, p.renew)
+}
+
+// Refresh obtains a new access token with the refresh token, or a new
+// login when that fails.
+func (p *DeviceFlowProvider) Refresh(ctx context.Context, rejected string) (string, error) {
+	return p.cache.replace(ctx, rejected, p.renew)
+}
+
+// Current returns the token set held by the provider.
+func (p *DeviceFlowProvider) Current() AuthToken {
+	return p.cache.current()
+}
+
+func (p *DeviceFlowProvider) bindClient(c *Client) {
+	p.cache.bindClient(c)
+}
+
+// renew runs with the cache locked.
+func (p *DeviceFlowProvider) renew(ctx context.Context) (AuthToken, error) {
+	client, now := p.cache.client, p.cache.now
+	if refreshToken := p.cache.token.RefreshToken; refreshToken != "" {
+		token, err := client.exchangeRefreshToken(ctx, refreshToken, now())
+		if err == nil || ctx.Err() != nil {
+			return token, err
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code < 400 || apiErr.Code >= 500 {
+			// Only a rejected refresh token is worth bothering the user over
+			return AuthToken{}, err
+		}
+	}
+
+	auth, err := client.requestDeviceCode(ctx, p.clientID, now())
+	if err != nil {
+		return AuthToken{}, err
+	}
+	if p.pollInterval > 0 {
+		auth.interval = p.pollInterval
+	}
+	p.prompt(auth)
+	return client.pollDeviceToken(ctx, p.clientID, auth, now)
+}
+
+// requestDeviceCode starts a device login.
+func (c *Client) requestDeviceCode(ctx context.Context, clientID string, now time.Time) (DeviceAuthorization, error) {
+	var result struct {
+		tokenResponse
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		Interval                int64  `json:"interval"`
+	}
+	status, err := c.postAuth(ctx, deviceCodePath, map[string]string{"client_id": clientID}, &result)
+	if err != nil {
+		return DeviceAuthorization{}, fmt.Errorf("failed to start device login: %w", err)
+	}
+	if status >= 400 || result.DeviceCode == "" {
+		return DeviceAuthorization{}, fmt.Errorf("failed to start device login: %w", result.apiError(status))
+	}
+
+	auth := DeviceAuthorization{
+		UserCode:                result.UserCode,
+		VerificationURI:         result.VerificationURI,
+		VerificationURIComplete: result.VerificationURIComplete,
+		deviceCode:              result.DeviceCode,
+		interval:                time.Duration(result.Interval) * time.Second,
+	}
+	if result.ExpiresIn > 0 {
+		auth.ExpiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	if auth.interval <= 0 {
+		auth.interval = defaultDevicePollInterval
+	}
+	return auth, nil
+}
+
+// pollDeviceToken waits for the user to approve a device login.
+func (c *Client) pollDeviceToken(ctx context.Context, clientID string, auth DeviceAuthorization, now func() time.Time) (AuthToken, error) {
+	interval := auth.interval
+	req := map[string]string{"client_id": clientID, "device_code": auth.deviceCode, "grant_type": deviceCodeGrantType}
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return AuthToken{}, ctx.Err()
+		case <-timer.C:
+		}
+
+		var result tokenResponse
+		status, err := c.postAuth(ctx, deviceTokenPath, req, &result)
+		if err != nil {
+			return AuthToken{}, fmt.Errorf("failed to poll device login: %w", err)
+		}
+		switch {
+		case status < 400 && result.AccessToken != "":
+			return result.authToken(now(), ""), nil
+		case result.Error == "authorization_pending":
+		case result.Error == "slow_down":
+			interval += 5 * time.Second
+		case result.Error == "access_denied":
+			return AuthToken{}, ErrDeviceAccessDenied
+		case result.Error == "expired_token":
+			return AuthToken{}, ErrDeviceCodeExpired
+		default:
+			return AuthToken{}, fmt.Errorf("failed to poll device login: %w", result.apiError(status))
+		}
+		if !auth.ExpiresAt.IsZero() && !now().Before(auth.ExpiresAt) {
+			return AuthToken{}, ErrDeviceCodeExpired
+		}
+	}
+}
+
+// reauthenticate renews the token req was sent with after the server
+// answered it with status, reporting whether req is worth sending again.
+// Requests with an API key or without a token are never retried.
+func (c *Client) reauthenticate(req *http.Request, status int) bool {
+	if status != http.StatusUnauthorized || c.apiKey != "" || c.tokens == nil {
+		return false
+	}
+	rejected, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	_, err := c.tokens.Refresh(req.Context(), rejected)
+	return err == nil
+}
+
+// doAuthenticated sends the request built by newReq with httpClient like
+// do. When the server rejects the token with 401 and the token provider
+// renews it, the request is built again and sent once more.
+func (c *Client) doAuthenticated(httpClient *http.Client, newReq func() (*http.Request, error)) (*http.Response, error) {
+	req, err := newReq()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(httpClient, req)
+	var apiErr *APIError
+	if err == nil || !errors.As(err, &apiErr) || !c.reauthenticate(req, apiErr.Code) {
+		return resp, err
+	}
+	if req, err = newReq(); err != nil {
+		return nil, err
+	}
+	return c.do(httpClient, req)
+}
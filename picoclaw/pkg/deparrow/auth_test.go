@@ -0,0 +1,247 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authServer accepts requests carrying Bearer valid and rejects others with
+// 401. refresh handles /api/v1/auth/refresh.
+func authServer(t *testing.T, valid *atomic.Value, refresh http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var rejected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authRefreshPath {
+			refresh(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+valid.Load().(string) {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "token expired"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy"})
+	}))
+	t.Cleanup(server.Close)
+	return server, &rejected
+}
+
+func TestRefreshTokenProvider_RetriesAfter401(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("access-2")
+	var refreshes atomic.Int32
+	server, rejected := authServer(t, &valid, func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["refresh_token"] != "refresh-1" || r.Header.Get("Authorization") != "" {
+			t.Errorf("refresh request = %v, Authorization = %q", req, r.Header.Get("Authorization"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-2", "refresh_token": "refresh-2", "expires_in": 3600,
+		})
+	})
+
+	var saved []AuthToken
+	provider := NewRefreshTokenProvider(AuthToken{AccessToken: "access-1", RefreshToken: "refresh-1"}, func(token AuthToken) {
+		saved = append(saved, token)
+	})
+	client := NewClient(server.URL, "", WithTokenProvider(provider))
+
+	// Concurrent requests rejected together refresh once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Health(context.Background()); err != nil {
+				t.Errorf("Health() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if refreshes.Load() != 1 {
+		t.Errorf("refreshed %d times, want once", refreshes.Load())
+	}
+	if rejected.Load() == 0 {
+		t.Error("no request was sent with the expired token")
+	}
+	current := provider.Current()
+	if len(saved) != 1 || current.RefreshToken != "refresh-2" || current.Expiry.IsZero() {
+		t.Errorf("saved = %+v, current = %+v", saved, current)
+	}
+}
+
+func TestRefreshTokenProvider_RefreshesBeforeExpiry(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("access-2")
+	server, rejected := authServer(t, &valid, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-2", "expires_in": 3600})
+	})
+
+	provider := NewRefreshTokenProvider(AuthToken{
+		AccessToken: "access-1", RefreshToken: "refresh-1", Expiry: time.Now().Add(time.Second),
+	}, nil)
+	client := NewClient(server.URL, "", WithTokenProvider(provider))
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if rejected.Load() != 0 {
+		t.Errorf("%d requests sent with a token about to expire", rejected.Load())
+	}
+	if got := provider.Current().RefreshToken; got != "refresh-1" {
+		t.Errorf("RefreshToken = %q, want the unrotated refresh-1", got)
+	}
+}
+
+func TestClient_401NotRetriedWithoutRefresh(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("other")
+	var refreshes atomic.Int32
+	refresh := func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "refresh token revoked"})
+	}
+
+	for name, client := range map[string]func(url string) *Client{
+		"static token": func(url string) *Client { return NewClient(url, "static") },
+		"API key":      func(url string) *Client { return NewClient(url, "static", WithAPIKey("dpk_key")) },
+		"revoked refresh token": func(url string) *Client {
+			return NewClient(url, "", WithTokenProvider(NewRefreshTokenProvider(AuthToken{AccessToken: "a", RefreshToken: "r"}, nil)))
+		},
+	} {
+		server, rejected := authServer(t, &valid, refresh)
+		_, err := client(server.URL).Health(context.Background())
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusUnauthorized {
+			t.Errorf("%s: Health() error = %v, want 401", name, err)
+		}
+		if rejected.Load() != 1 {
+			t.Errorf("%s: sent %d times, want once", name, rejected.Load())
+		}
+	}
+	if refreshes.Load() != 1 {
+		t.Errorf("refresh endpoint called %d times, want once", refreshes.Load())
+	}
+}
+
+// deviceServer serves the device login endpoints. The login is approved
+// after pending polls; every login issues access-<n> and refresh-<n>.
+func deviceServer(t *testing.T, pending int, denied bool) (*httptest.Server, *atomic.Value) {
+	t.Helper()
+	var mu sync.Mutex
+	logins, polls := 0, 0
+	var valid atomic.Value
+	valid.Store("")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case deviceCodePath:
+			if req["client_id"] != "picoclaw" {
+				t.Errorf("device code request = %v", req)
+			}
+			logins++
+			polls = 0
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"device_code": "device-code", "user_code": "ABCD-1234", "verification_uri": "https://deparrow.net/device",
+				"expires_in": 600, "interval": 5,
+			})
+		case deviceTokenPath:
+			if req["device_code"] != "device-code" || req["grant_type"] != deviceCodeGrantType {
+				t.Errorf("device token request = %v", req)
+			}
+			polls++
+			switch {
+			case denied:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "access_denied"})
+			case polls <= pending:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+			default:
+				token := "access-" + string(rune('0'+logins))
+				valid.Store(token)
+				json.NewEncoder(w).Encode(map[string]interface{}{"access_token": token, "refresh_token": "refresh-" + string(rune('0'+logins))})
+			}
+		case authRefreshPath:
+			// Refresh tokens are revoked, so only a new login helps
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		default:
+			if r.Header.Get("Authorization") != "Bearer "+valid.Load().(string) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &valid
+}
+
+func TestDeviceFlowProvider(t *testing.T) {
+	server, valid := deviceServer(t, 2, false)
+	var prompts []DeviceAuthorization
+	provider := NewDeviceFlowProvider("picoclaw", func(auth DeviceAuthorization) {
+		prompts = append(prompts, auth)
+	}, nil)
+	provider.pollInterval = time.Millisecond
+	client := NewClient(server.URL, "", WithTokenProvider(provider))
+
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].UserCode != "ABCD-1234" || prompts[0].VerificationURI != "https://deparrow.net/device" ||
+		prompts[0].ExpiresAt.IsZero() {
+		t.Fatalf("prompts = %+v", prompts)
+	}
+	if got := provider.Current(); got.AccessToken != "access-1" || got.RefreshToken != "refresh-1" {
+		t.Errorf("token = %+v", got)
+	}
+
+	// Once the session is revoked, the rejected refresh token starts a new login
+	valid.Store("revoked")
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health() after revocation error = %v", err)
+	}
+	if len(prompts) != 2 || provider.Current().AccessToken != "access-2" {
+		t.Errorf("prompts = %d, token = %+v; want a second login", len(prompts), provider.Current())
+	}
+}
+
+func TestDeviceFlowProvider_Denied(t *testing.T) {
+	server, _ := deviceServer(t, 0, true)
+	provider := NewDeviceFlowProvider("picoclaw", func(DeviceAuthorization) {}, nil)
+	provider.pollInterval = time.Millisecond
+	client := NewClient(server.URL, "", WithTokenProvider(provider))
+
+	if _, err := client.Health(context.Background()); !errors.Is(err, ErrDeviceAccessDenied) {
+		t.Errorf("Health() error = %v, want ErrDeviceAccessDenied", err)
+	}
+}
+
+func TestDeviceFlowProvider_Cancelled(t *testing.T) {
+	server, _ := deviceServer(t, 1000, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	provider := NewDeviceFlowProvider("picoclaw", func(DeviceAuthorization) { cancel() }, nil)
+	client := NewClient(server.URL, "", WithTokenProvider(provider))
+
+	if _, err := client.Health(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Health() error = %v, want context.Canceled", err)
+	}
+}
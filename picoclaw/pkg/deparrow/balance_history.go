@@ -0,0 +1,134 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxBalanceHistoryPoints is the most intervals a balance history request
+// may ask for.
+const MaxBalanceHistoryPoints = 1000
+
+// BalanceSnapshot is a user's balance as the network recorded it at a
+// point in time. The Meta-OS takes one for every user periodically.
+type BalanceSnapshot struct {
+	At      time.Time `json:"timestamp"`
+	Balance float64   `json:"balance"`
+}
+
+// BalancePoint is the balance at the end of one interval of a
+// BalanceHistory.
+type BalancePoint struct {
+	// Start of the interval
+	Time time.Time `json:"timestamp"`
+	// Balance at the last snapshot taken by the end of the interval
+	Balance float64 `json:"balance"`
+	// Change since the end of the previous interval
+	Change float64 `json:"change"`
+}
+
+// BalanceHistory is a user's balance over a period, one point per
+// interval of the requested resolution. Intervals before the user's first
+// snapshot have no point; later intervals without a snapshot carry the
+// previous balance forward.
+type BalanceHistory struct {
+	UserID     string        `json:"user_id"`
+	Period     time.Duration `json:"-"`
+	Resolution time.Duration `json:"-"`
+	// Balance when the period started, or at the first snapshot when the
+	// user has none from before it
+	StartBalance float64        `json:"start_balance"`
+	EndBalance   float64        `json:"end_balance"`
+	Points       []BalancePoint `json:"points"`
+}
+
+// NetChange returns how much the balance grew over the period; it is
+// negative for a net spender.
+func (h *BalanceHistory) NetChange() float64 {
+	return h.EndBalance - h.StartBalance
+}
+
+// GetBalanceHistory returns the authenticated user's balance over the
+// period ending now, one point per resolution, from the snapshots the
+// network takes of it.
+//
+// Example:
+//
+//	// Daily balance over the last 30 days
+//	history, err := client.GetBalanceHistory(ctx, 30*24*time.Hour, 24*time.Hour)
+func (c *Client) GetBalanceHistory(ctx context.Context, period, resolution time.Duration) (*BalanceHistory, error) {
+	if err := validateBalanceHistory(period, resolution); err != nil {
+		return nil, err
+	}
+
+	path := "/api/v1/credits/balance/" + url.PathEscape(c.userID) + "/history"
+	if c.userID == "" {
+		path = "/api/v1/credits/history"
+	}
+	query := url.Values{
+		"period":     {strconv.FormatInt(int64(period/time.Second), 10)},
+		"resolution": {strconv.FormatInt(int64(resolution/time.Second), 10)},
+	}
+
+	var history BalanceHistory
+	if err := c.doRequest(ctx, http.MethodGet, path+"?"+query.Encode(), nil, &history); err != nil {
+		return nil, err
+	}
+	history.Period = period
+	history.Resolution = resolution
+	return &history, nil
+}
+
+// validateBalanceHistory checks the period and resolution of a balance
+// history request.
+func validateBalanceHistory(period, resolution time.Duration) error {
+	switch {
+	case resolution < time.Second:
+		return fmt.Errorf("resolution must be at least a second, got %s", resolution)
+	case period < resolution:
+		return fmt.Errorf("period %s is shorter than the resolution %s", period, resolution)
+	case period/resolution > MaxBalanceHistoryPoints:
+		return fmt.Errorf("period %s at resolution %s is more than %d points", period, resolution, MaxBalanceHistoryPoints)
+	}
+	return nil
+}
+
+// balanceHistory buckets snapshots, oldest first, into the history of the
+// period ending at end. Intervals are aligned to multiples of the
+// resolution, so daily points start at midnight UTC.
+func balanceHistory(snapshots []BalanceSnapshot, end time.Time, period, resolution time.Duration) BalanceHistory {
+	start := end.Add(-period).Truncate(resolution)
+	history := BalanceHistory{Period: period, Resolution: resolution, Points: []BalancePoint{}}
+
+	var current float64
+	started := false
+	i := 0
+	for ; i < len(snapshots) && !snapshots[i].At.After(start); i++ {
+		current = snapshots[i].Balance
+		started = true
+	}
+	history.StartBalance = current
+	previous := current
+
+	for t := start; t.Before(end); t = t.Add(resolution) {
+		next := t.Add(resolution)
+		for ; i < len(snapshots) && snapshots[i].At.Before(next); i++ {
+			if !started {
+				history.StartBalance, previous = snapshots[i].Balance, snapshots[i].Balance
+				started = true
+			}
+			current = snapshots[i].Balance
+		}
+		if !started {
+			continue
+		}
+		history.Points = append(history.Points, BalancePoint{Time: t, Balance: current, Change: current - previous})
+		previous = current
+	}
+	history.EndBalance = current
+	return history
+}
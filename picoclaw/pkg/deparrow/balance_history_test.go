@@ -0,0 +1,120 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBalanceHistory_Buckets(t *testing.T) {
+	day := 24 * time.Hour
+	end := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	snapshots := []BalanceSnapshot{
+		{At: end.Add(-10 * day), Balance: 50},
+		{At: end.Add(-4*day - time.Hour), Balance: 70},
+		{At: end.Add(-4 * day), Balance: 80},
+		{At: end.Add(-2 * day), Balance: 40},
+		{At: end.Add(-time.Hour), Balance: 45},
+	}
+
+	history := balanceHistory(snapshots, end, 5*day, day)
+	if history.StartBalance != 50 || history.EndBalance != 45 || history.NetChange() != -5 {
+		t.Fatalf("history = %+v", history)
+	}
+	// Daily points from midnight five days back up to today
+	want := []BalancePoint{
+		{Time: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), Balance: 50},
+		{Time: time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC), Balance: 80, Change: 30},
+		{Time: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC), Balance: 80},
+		{Time: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), Balance: 40, Change: -40},
+		{Time: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), Balance: 40},
+		{Time: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), Balance: 45, Change: 5},
+	}
+	if len(history.Points) != len(want) {
+		t.Fatalf("Points = %+v", history.Points)
+	}
+	for i, p := range history.Points {
+		if !p.Time.Equal(want[i].Time) || p.Balance != want[i].Balance || p.Change != want[i].Change {
+			t.Errorf("point %d = %+v, want %+v", i, p, want[i])
+		}
+	}
+
+	// A user newer than the period has no points before the first snapshot
+	history = balanceHistory(snapshots[3:], end, 5*day, day)
+	if history.StartBalance != 40 || len(history.Points) != 3 || history.Points[0].Change != 0 {
+		t.Errorf("new user history = %+v", history)
+	}
+
+	if history := balanceHistory(nil, end, 5*day, day); len(history.Points) != 0 || history.NetChange() != 0 {
+		t.Errorf("empty history = %+v", history)
+	}
+}
+
+func TestGetBalanceHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/credits/balance/user-1/history" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if q := r.URL.Query(); q.Get("period") != "604800" || q.Get("resolution") != "86400" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"user_id": "user-1", "start_balance": 10.0, "end_balance": 25.5,
+			"points": []map[string]interface{}{{"timestamp": "2026-03-10T00:00:00Z", "balance": 25.5, "change": 15.5}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	client.SetUserID("user-1")
+	history, err := client.GetBalanceHistory(context.Background(), 7*24*time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetBalanceHistory() error = %v", err)
+	}
+	if history.NetChange() != 15.5 || len(history.Points) != 1 || history.Resolution != 24*time.Hour {
+		t.Errorf("history = %+v", history)
+	}
+
+	for _, tt := range []struct{ period, resolution time.Duration }{
+		{24 * time.Hour, 0},
+		{time.Hour, 24 * time.Hour},
+		{(MaxBalanceHistoryPoints + 1) * time.Hour, time.Hour},
+	} {
+		if _, err := client.GetBalanceHistory(context.Background(), tt.period, tt.resolution); err == nil {
+			t.Errorf("GetBalanceHistory(%s, %s) should fail", tt.period, tt.resolution)
+		}
+	}
+}
+
+func TestWalletTool_Trend(t *testing.T) {
+	client := NewSandboxClient()
+	day := 24 * time.Hour
+	now := time.Now()
+	client.sandbox.snapshots = []BalanceSnapshot{
+		{At: now.Add(-6 * day), Balance: 100},
+		{At: now.Add(-3 * day), Balance: 60},
+		// Recent enough that the sandbox takes no snapshot of its own
+		{At: now, Balance: 75},
+	}
+
+	result := NewWalletTool(client).Execute(context.Background(), map[string]interface{}{"action": "trend", "days": 5.0})
+	if result.IsError {
+		t.Fatalf("trend failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{"last 5 days", "-40.00", "+15.00", "Net spender: -25.00", "deparrow_how_to_earn"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("trend should mention %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = NewWalletTool(client).Execute(context.Background(), map[string]interface{}{"action": "trend", "days": 0.0})
+	if !result.IsError {
+		t.Error("trend with 0 days should fail")
+	}
+}
@@ -0,0 +1,218 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is how many jobs are submitted at once when the
+// server has no batch endpoint.
+const DefaultBatchConcurrency = 8
+
+// maxBatchSize is the most jobs sent in one batch request.
+const maxBatchSize = 100
+
+const batchSubmitPath = "/api/v1/jobs/submit/batch"
+
+// WithBatchConcurrency sets how many jobs SubmitJobs submits at once when
+// it falls back to individual submissions.
+func WithBatchConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.batchConcurrency = n
+	}
+}
+
+// BatchJobResult is the outcome of submitting one job of a batch.
+type BatchJobResult struct {
+	// Index of the spec in the submitted slice
+	Index int
+	// Submitted job, nil when Err is set
+	Job *Job
+	Err error
+}
+
+// BatchSubmission is the outcome of submitting a batch of jobs.
+type BatchSubmission struct {
+	// One result per spec, in the order the specs were given
+	Results []BatchJobResult
+	// Credits deducted for the accepted jobs
+	TotalCost float64
+	Submitted int
+	Failed    int
+}
+
+// Err joins the errors of the jobs that were not submitted, or returns nil.
+func (b *BatchSubmission) Err() error {
+	var errs []error
+	for _, r := range b.Results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("job %d: %w", r.Index, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SubmitJobs submits many jobs at once, such as the points of a parameter
+// sweep. Jobs are sent to the batch endpoint in chunks of up to 100; when
+// the server has no batch endpoint they are submitted individually, a few
+// at a time (see WithBatchConcurrency).
+//
+// The submission is always returned with a result per spec. The error is
+// non-nil when any job was not submitted and joins the individual errors.
+//
+// Example:
+//
+//	var specs []*deparrow.JobSpec
+//	for _, lr := range []string{"0.1", "0.01", "0.001"} {
+//	    specs = append(specs, &deparrow.JobSpec{
+//	        Image: "python:3.11-slim",
+//	        Command: []string{"python", "train.py", "--lr", lr},
+//	    })
+//	}
+//	batch, err := client.SubmitJobs(ctx, specs)
+func (c *Client) SubmitJobs(ctx context.Context, specs []*JobSpec) (*BatchSubmission, error) {
+	batch := &BatchSubmission{Results: make([]BatchJobResult, len(specs))}
+	for i := range batch.Results {
+		batch.Results[i].Index = i
+		batch.Results[i].Err = specs[i].Validate()
+		if batch.Results[i].Err == nil {
+			batch.Results[i].Err = c.requireFeatures(ctx, specs[i].features())
+		}
+	}
+
+	for start := 0; start < len(specs); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(specs))
+		chunk, results := specs[start:end], batch.Results[start:end]
+
+		if !c.batchUnsupported.Load() {
+			err := c.submitBatch(ctx, chunk, results)
+			if err == nil {
+				continue
+			}
+			if !endpointUnavailable(err) {
+				for i := range results {
+					if results[i].Err == nil {
+						results[i].Err = err
+					}
+				}
+				continue
+			}
+			c.batchUnsupported.Store(true)
+		}
+		c.submitEach(ctx, chunk, results)
+	}
+
+	for _, r := range batch.Results {
+		if r.Err != nil {
+			batch.Failed++
+			continue
+		}
+		batch.Submitted++
+		batch.TotalCost += r.Job.CreditCost
+	}
+	return batch, batch.Err()
+}
+
+// endpointUnavailable reports whether err means the server has no such
+// endpoint, as with an older Meta-OS.
+func endpointUnavailable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound ||
+		apiErr.Code == http.StatusMethodNotAllowed || apiErr.Code == http.StatusNotImplemented)
+}
+
+// batchItem is one job of a batch request.
+type batchItem struct {
+	Spec       *JobSpec `json:"spec"`
+	CreditCost float64  `json:"credit_cost"`
+}
+
+// batchItemResult is the server's answer for one job of a batch request.
+type batchItemResult struct {
+	JobID          string  `json:"job_id"`
+	CreditDeducted float64 `json:"credit_deducted"`
+	Orchestrator   string  `json:"orchestrator"`
+	Error          string  `json:"error,omitempty"`
+	ErrorCode      string  `json:"error_code,omitempty"`
+	Code           int     `json:"code,omitempty"`
+}
+
+// submitBatch sends specs in one batch request and fills in results.
+// Specs whose result already holds an error are left out.
+func (c *Client) submitBatch(ctx context.Context, specs []*JobSpec, results []BatchJobResult) error {
+	var (
+		items   []batchItem
+		indexes []int
+	)
+	for i, spec := range specs {
+		if results[i].Err == nil {
+			items = append(items, batchItem{Spec: spec, CreditCost: calculateCreditCost(spec)})
+			indexes = append(indexes, i)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	var resp struct {
+		Results []batchItemResult `json:"results"`
+	}
+	if err := c.doRequest(ctx, http.MethodPost, batchSubmitPath, map[string]interface{}{"jobs": items}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Results) != len(items) {
+		return fmt.Errorf("batch response has %d results for %d jobs", len(resp.Results), len(items))
+	}
+
+	for n, item := range resp.Results {
+		i := indexes[n]
+		if item.Error != "" || item.JobID == "" {
+			results[i].Err = &APIError{Code: item.Code, ErrorCode: item.ErrorCode, Message: item.Error}
+			continue
+		}
+		c.observeCredits(ctx, item.CreditDeducted)
+		results[i].Job = &Job{
+			ID:           item.JobID,
+			Status:       JobStatusPending,
+			Spec:         specs[i],
+			CreditCost:   item.CreditDeducted,
+			SubmittedAt:  time.Now(),
+			Orchestrator: item.Orchestrator,
+		}
+	}
+	return nil
+}
+
+// submitEach submits specs one request at a time, running up to the
+// client's batch concurrency at once, and fills in results.
+func (c *Client) submitEach(ctx context.Context, specs []*JobSpec, results []BatchJobResult) {
+	concurrency := c.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, spec := range specs {
+		if results[i].Err != nil {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Job, results[i].Err = c.SubmitJob(ctx, spec)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,136 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func sweepSpecs(n int) []*JobSpec {
+	specs := make([]*JobSpec, n)
+	for i := range specs {
+		specs[i] = &JobSpec{Image: "python:3.11-slim", Command: []string{"python", "train.py", "--seed", fmt.Sprint(i)}}
+	}
+	return specs
+}
+
+func TestSubmitJobs_BatchEndpoint(t *testing.T) {
+	var batches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != batchSubmitPath {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		batches.Add(1)
+		var req struct {
+			Jobs []batchItem `json:"jobs"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		results := make([]batchItemResult, len(req.Jobs))
+		for i, job := range req.Jobs {
+			if job.Spec.Command[3] == "2" {
+				results[i] = batchItemResult{Error: "Insufficient credits", Code: http.StatusPaymentRequired}
+				continue
+			}
+			results[i] = batchItemResult{JobID: fmt.Sprintf("job-%d", i), CreditDeducted: 1.5}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	batch, err := client.SubmitJobs(context.Background(), sweepSpecs(150))
+	if err == nil {
+		t.Fatal("SubmitJobs() should report the failed job")
+	}
+	if batches.Load() != 2 {
+		t.Errorf("batch requests = %d, want 2 chunks", batches.Load())
+	}
+	if batch.Submitted != 149 || batch.Failed != 1 || batch.TotalCost != 149*1.5 {
+		t.Errorf("batch = %d submitted, %d failed, %.2f credits; want 149, 1, %.2f",
+			batch.Submitted, batch.Failed, batch.TotalCost, 149*1.5)
+	}
+	if r := batch.Results[2]; r.Job != nil || r.Err == nil || r.Err.Error() != "Insufficient credits" {
+		t.Errorf("Results[2] = %+v, want the insufficient credits error", r)
+	}
+	if r := batch.Results[120]; r.Index != 120 || r.Job == nil || r.Job.ID != "job-20" {
+		t.Errorf("Results[120] = %+v, want job-20 of the second chunk", r)
+	}
+}
+
+func TestSubmitJobs_FallbackBoundsConcurrency(t *testing.T) {
+	var (
+		mu                 sync.Mutex
+		inFlight, maxSeen  int
+		batchCalls, single atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == batchSubmitPath {
+			batchCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := single.Add(1)
+		mu.Lock()
+		inFlight++
+		maxSeen = max(maxSeen, inFlight)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": fmt.Sprintf("job-%d", n), "credit_deducted": 2.0})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithBatchConcurrency(3))
+	batch, err := client.SubmitJobs(context.Background(), sweepSpecs(12))
+	if err != nil {
+		t.Fatalf("SubmitJobs() error = %v", err)
+	}
+	if batch.Submitted != 12 || batch.TotalCost != 24 {
+		t.Errorf("batch = %d submitted for %.2f credits, want 12 for 24", batch.Submitted, batch.TotalCost)
+	}
+	if maxSeen > 3 {
+		t.Errorf("%d submissions in flight, want at most 3", maxSeen)
+	}
+
+	// The missing endpoint is remembered
+	if _, err := client.SubmitJobs(context.Background(), sweepSpecs(2)); err != nil {
+		t.Fatalf("SubmitJobs() error = %v", err)
+	}
+	if batchCalls.Load() != 1 || single.Load() != 14 {
+		t.Errorf("batch calls = %d, single calls = %d; want 1 and 14", batchCalls.Load(), single.Load())
+	}
+}
+
+func TestSubmitJobs_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	specs := sweepSpecs(3)
+	specs = append(specs, nil)
+	batch, err := client.SubmitJobs(ctx, specs)
+	if err == nil || batch.Submitted != 3 || batch.Failed != 1 {
+		t.Fatalf("SubmitJobs() = %+v, %v; want 3 submitted and the nil spec failed", batch, err)
+	}
+
+	balance, _ := client.GetCredits(ctx)
+	if balance.Balance != SandboxStartingBalance-batch.TotalCost {
+		t.Errorf("Balance = %.2f, want %.2f", balance.Balance, SandboxStartingBalance-batch.TotalCost)
+	}
+	jobs, _ := client.ListJobs(ctx)
+	if len(jobs) != 3 {
+		t.Errorf("ListJobs() = %d jobs, want 3", len(jobs))
+	}
+}
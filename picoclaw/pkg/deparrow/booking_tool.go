@@ -0,0 +1,336 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// bookingTimeLayout renders booking windows. Calendars are in UTC.
+const bookingTimeLayout = "Mon 2006-01-02 15:04"
+
+// maxCalendarSlots caps the slots shown per node.
+const maxCalendarSlots = 8
+
+// BookingTool shows provider availability calendars and books future GPU
+// capacity against them.
+type BookingTool struct {
+	client *Client
+	now    func() time.Time
+}
+
+// NewBookingTool creates a new capacity booking tool.
+func NewBookingTool(client *Client) *BookingTool {
+	return &BookingTool{client: client, now: time.Now}
+}
+
+// Name returns the tool name.
+func (t *BookingTool) Name() string {
+	return "deparrow_book_capacity"
+}
+
+// Description returns the tool description.
+func (t *BookingTool) Description() string {
+	return `View when providers offer their GPUs and book future capacity.
+
+Providers publish availability calendars for their nodes, e.g. an A100 rig
+that is free over the weekend. Use 'calendar' to see the free slots,
+filtered by GPU model, region or date, and 'book' to reserve GPUs for a
+day: the cheapest slot covering the window is booked and its cost is held
+from your wallet. 'bookings' lists your bookings and 'cancel' releases the
+hold of a booking that has not started.
+
+Dates are YYYY-MM-DD, 'today', 'tomorrow', a weekday ('saturday') or
+'next saturday'; a weekday means the next one after today. Times are UTC.
+Without start_time and hours the booking covers the whole day.
+
+Example: to reserve next Saturday's A100 time, use action='book',
+gpu_model='A100', date='next saturday'.
+`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *BookingTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"calendar", "book", "bookings", "cancel"},
+				"description": "Action to perform: 'calendar' to view free slots, 'book' to reserve capacity, 'bookings' to list your bookings, 'cancel' to release one",
+				"default":     "calendar",
+			},
+			"gpu_model": map[string]interface{}{
+				"type":        "string",
+				"description": "GPU model to look for, e.g. A100 or H100",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Region to look in, e.g. us-east",
+			},
+			"node_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Book this node rather than the cheapest one",
+			},
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "Day to view or book: YYYY-MM-DD, today, tomorrow, a weekday or 'next <weekday>' (required for book)",
+			},
+			"start_time": map[string]interface{}{
+				"type":        "string",
+				"description": "Start of the booking as HH:MM UTC (default 00:00)",
+			},
+			"hours": map[string]interface{}{
+				"type":        "number",
+				"description": "Length of the booking in hours (default: until the end of the day)",
+			},
+			"gpus": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of GPUs to book (default 1)",
+				"default":     1,
+			},
+			"booking_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Booking to cancel (for cancel)",
+			},
+		},
+	}
+}
+
+// Execute runs the capacity booking tool.
+func (t *BookingTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	action, _ := args["action"].(string)
+	switch action {
+	case "", "calendar":
+		return t.calendar(ctx, args)
+	case "book":
+		return t.book(ctx, args)
+	case "bookings":
+		return t.listBookings(ctx)
+	case "cancel":
+		bookingID, _ := args["booking_id"].(string)
+		return t.cancel(ctx, bookingID)
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
+	}
+}
+
+// calendar shows the free slots on the given day, or over the next week.
+func (t *BookingTool) calendar(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	now := t.now()
+	query := CalendarQuery{From: now, To: now.Add(7 * 24 * time.Hour)}
+	query.GPUModel, _ = args["gpu_model"].(string)
+	query.Region, _ = args["region"].(string)
+	if date, _ := args["date"].(string); date != "" {
+		day, err := resolveDate(date, now)
+		if err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		query.From, query.To = day, day.AddDate(0, 0, 1)
+	}
+
+	calendars, err := t.client.ListCalendars(ctx, query)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get calendars: %v", err))
+	}
+
+	var result strings.Builder
+	result.WriteString("📅 DEparrow Capacity Calendar\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(fmt.Sprintf("%s – %s UTC\n", query.From.UTC().Format(bookingTimeLayout), query.To.UTC().Format(bookingTimeLayout)))
+
+	shown := 0
+	for _, cal := range calendars {
+		if len(cal.Slots) == 0 {
+			continue
+		}
+		shown++
+		result.WriteString(fmt.Sprintf("\n🖥️  %s (%d×%s, %s)\n", cal.NodeID, cal.GPUCount, cal.GPUModel, cal.Region))
+		for i, slot := range cal.Slots {
+			if i == maxCalendarSlots {
+				result.WriteString(fmt.Sprintf("  … and %d more slots\n", len(cal.Slots)-i))
+				break
+			}
+			result.WriteString(fmt.Sprintf("  %s – %s  %d/%d GPUs free  %.2f credits/GPU-hour\n",
+				slot.Start.UTC().Format(bookingTimeLayout), slot.End.UTC().Format(bookingTimeLayout),
+				slot.AvailableGPUs, cal.GPUCount, slot.CreditsPerGPUHour))
+		}
+	}
+	if shown == 0 {
+		result.WriteString("\nNo capacity is offered in this period. Try another date, GPU model or region.")
+		return tools.UserResult(result.String())
+	}
+
+	result.WriteString("\nUse action='book' with a date to reserve capacity.")
+	return tools.UserResult(result.String())
+}
+
+// book reserves the cheapest slot covering the requested window.
+func (t *BookingTool) book(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	now := t.now()
+	date, _ := args["date"].(string)
+	if date == "" {
+		return tools.ErrorResult("date is required to book capacity")
+	}
+	day, err := resolveDate(date, now)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+
+	start := day
+	if v, _ := args["start_time"].(string); v != "" {
+		clock, err := time.Parse("15:04", v)
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("start_time must be HH:MM, got %q", v))
+		}
+		start = day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+	}
+	end := day.AddDate(0, 0, 1)
+	if hours, ok := args["hours"].(float64); ok {
+		if hours <= 0 {
+			return tools.ErrorResult("hours must be positive")
+		}
+		end = start.Add(time.Duration(hours * float64(time.Hour)))
+	}
+	if !start.After(now) {
+		return tools.ErrorResult(fmt.Sprintf("%s UTC has already passed; pick a later date or start_time", start.Format(bookingTimeLayout)))
+	}
+	gpus := 1
+	if v, ok := args["gpus"].(float64); ok {
+		gpus = int(v)
+	}
+	if gpus <= 0 {
+		return tools.ErrorResult("gpus must be positive")
+	}
+
+	// Nothing is held while spending is paused
+	if pause := t.client.SpendingPaused(); pause != nil {
+		return tools.ErrorResult(spendingPausedMessage(pause))
+	}
+
+	query := CalendarQuery{From: start, To: end}
+	query.GPUModel, _ = args["gpu_model"].(string)
+	query.Region, _ = args["region"].(string)
+	var calendars []CapacityCalendar
+	if nodeID, _ := args["node_id"].(string); nodeID != "" {
+		cal, err := t.client.GetNodeCalendar(ctx, nodeID, start, end)
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Failed to get calendar for %s: %v", nodeID, err))
+		}
+		calendars = []CapacityCalendar{*cal}
+	} else if calendars, err = t.client.ListCalendars(ctx, query); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get calendars: %v", err))
+	}
+
+	cal, slot, ok := cheapestSlot(calendars, query.GPUModel, start, end, gpus)
+	if !ok {
+		return tools.ErrorResult(fmt.Sprintf(
+			"No %s slot has %d GPUs free for %s – %s UTC. Use action='calendar' with this date to see what is offered, "+
+				"then book with start_time and hours inside a slot.",
+			describeModel(query.GPUModel), gpus, start.Format(bookingTimeLayout), end.Format(bookingTimeLayout)))
+	}
+
+	booking, err := t.client.BookCapacity(ctx, &BookingRequest{NodeID: cal.NodeID, GPUs: gpus, Start: start, End: end})
+	if err != nil {
+		return failureResult("book capacity", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("✅ Capacity Booked\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(formatBooking(booking))
+	result.WriteString(fmt.Sprintf("  Region:   %s\n", cal.Region))
+	result.WriteString(fmt.Sprintf("  Rate:     %.2f credits/GPU-hour\n", slot.CreditsPerGPUHour))
+	result.WriteString(fmt.Sprintf("\n%.2f credits are held from your wallet. Cancel with action='cancel' and booking_id='%s' before the booking starts to release them.",
+		booking.HoldAmount, booking.ID))
+	return tools.UserResult(result.String())
+}
+
+// listBookings shows the user's bookings.
+func (t *BookingTool) listBookings(ctx context.Context) *tools.ToolResult {
+	bookings, err := t.client.ListBookings(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to list bookings: %v", err))
+	}
+
+	var result strings.Builder
+	result.WriteString("📅 Your Capacity Bookings\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	if len(bookings) == 0 {
+		result.WriteString("\nNo bookings yet. Use action='book' to reserve capacity.")
+		return tools.UserResult(result.String())
+	}
+
+	held := 0.0
+	for i := range bookings {
+		result.WriteString("\n")
+		result.WriteString(formatBooking(&bookings[i]))
+		if bookings[i].Status == BookingHeld {
+			held += bookings[i].HoldAmount
+		}
+	}
+	result.WriteString(fmt.Sprintf("\nCredits held for upcoming bookings: %.2f", held))
+	return tools.UserResult(result.String())
+}
+
+// cancel cancels a booking and releases its hold.
+func (t *BookingTool) cancel(ctx context.Context, bookingID string) *tools.ToolResult {
+	if bookingID == "" {
+		return tools.ErrorResult("booking_id is required to cancel a booking")
+	}
+	if err := t.client.CancelBooking(ctx, bookingID); err != nil {
+		return failureResult("cancel booking", err)
+	}
+	return tools.UserResult(fmt.Sprintf("🛑 Booking %s cancelled. The credits held for it have been released.", bookingID))
+}
+
+// formatBooking renders a booking's details.
+func formatBooking(b *Booking) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("  Booking:  %s (%s)\n", b.ID, b.Status))
+	result.WriteString(fmt.Sprintf("  Node:     %s\n", b.NodeID))
+	result.WriteString(fmt.Sprintf("  GPUs:     %d×%s\n", b.GPUs, describeModel(b.GPUModel)))
+	result.WriteString(fmt.Sprintf("  Window:   %s – %s UTC\n", b.Start.UTC().Format(bookingTimeLayout), b.End.UTC().Format(bookingTimeLayout)))
+	result.WriteString(fmt.Sprintf("  Held:     %.2f credits\n", b.HoldAmount))
+	return result.String()
+}
+
+// describeModel names a GPU model, or any GPU when none is given.
+func describeModel(model string) string {
+	if model == "" {
+		return "GPU"
+	}
+	return model
+}
+
+// resolveDate returns midnight UTC of the day a date refers to, relative to now.
+func resolveDate(date string, now time.Time) (time.Time, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	date = strings.ToLower(strings.TrimSpace(date))
+	switch date {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	name := strings.TrimPrefix(date, "next ")
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.ToLower(day.String()) == name {
+			ahead := (int(day)-int(today.Weekday())+6)%7 + 1
+			return today.AddDate(0, 0, ahead), nil
+		}
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognised date %q: use YYYY-MM-DD, today, tomorrow or a weekday", date)
+	}
+	return day, nil
+}
+
+// Ensure tool implements the Tool interface
+var _ tools.Tool = (*BookingTool)(nil)
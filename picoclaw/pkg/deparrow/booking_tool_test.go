@@ -0,0 +1,87 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBookingTool_BookNextSaturday(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewBookingTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "calendar", "gpu_model": "A100", "date": "next saturday"})
+	if result.IsError {
+		t.Fatalf("calendar failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "node-use-a100-01") || !strings.Contains(result.ForLLM, "8/8 GPUs free") {
+		t.Errorf("calendar output missing the free A100 weekend:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "book", "gpu_model": "A100", "date": "next saturday", "gpus": float64(2)})
+	if result.IsError {
+		t.Fatalf("book failed: %s", result.ForLLM)
+	}
+	bookings, err := client.ListBookings(ctx)
+	if err != nil || len(bookings) != 1 {
+		t.Fatalf("ListBookings() = %+v, %v", bookings, err)
+	}
+	booking := bookings[0]
+	saturday, _ := resolveDate("next saturday", time.Now())
+	if !booking.Start.Equal(saturday) || !booking.End.Equal(saturday.AddDate(0, 0, 1)) || booking.GPUs != 2 || booking.GPUModel != "A100" {
+		t.Errorf("booking = %+v, want 2 A100s for all of %s", booking, saturday.Format("2006-01-02"))
+	}
+	if !strings.Contains(result.ForLLM, booking.ID) || !strings.Contains(result.ForLLM, "held from your wallet") {
+		t.Errorf("book output missing booking details:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "cancel", "booking_id": booking.ID})
+	if result.IsError {
+		t.Fatalf("cancel failed: %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "bookings"})
+	if !strings.Contains(result.ForLLM, "(cancelled)") || !strings.Contains(result.ForLLM, "held for upcoming bookings: 0.00") {
+		t.Errorf("bookings output:\n%s", result.ForLLM)
+	}
+}
+
+func TestBookingTool_Errors(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewBookingTool(client)
+	ctx := context.Background()
+
+	tests := map[string]map[string]interface{}{
+		"no date":         {"action": "book", "gpu_model": "A100"},
+		"bad date":        {"action": "book", "date": "someday"},
+		"bad start time":  {"action": "book", "date": "tomorrow", "start_time": "noon"},
+		"in the past":     {"action": "book", "date": "today"},
+		"no slot":         {"action": "book", "gpu_model": "A100", "date": "next wednesday"},
+		"too many GPUs":   {"action": "book", "gpu_model": "A100", "date": "next saturday", "gpus": float64(9)},
+		"no booking id":   {"action": "cancel"},
+		"unknown action":  {"action": "rebook"},
+		"unknown booking": {"action": "cancel", "booking_id": "missing"},
+	}
+	for name, args := range tests {
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("%s: expected an error, got:\n%s", name, result.ForLLM)
+		}
+	}
+
+	// A short night slot on an H100 is bookable with a start time and hours
+	result := tool.Execute(ctx, map[string]interface{}{
+		"action": "book", "gpu_model": "H100", "date": "tomorrow", "start_time": "01:00", "hours": float64(4),
+	})
+	if result.IsError {
+		t.Fatalf("booking an H100 night failed: %s", result.ForLLM)
+	}
+
+	// Nothing is held while spending is paused
+	client.PauseSpending("test")
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "book", "gpu_model": "A100", "date": "next saturday"}); !result.IsError {
+		t.Error("booking while spending is paused should fail")
+	}
+}
@@ -0,0 +1,281 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// BudgetConfig limits what the tools may spend on the agent's own
+// initiative.
+type BudgetConfig struct {
+	// SessionCeiling is the most credits the tools may spend in one agent
+	// session. Zero means no ceiling.
+	SessionCeiling float64
+	// ConfirmAbove is the cost of a single call above which the agent has
+	// to confirm the spend with the user first. Zero never asks.
+	ConfirmAbove float64
+}
+
+// budgetedTool is a tool whose calls spend credits.
+type budgetedTool interface {
+	tools.Tool
+	// estimateSpend returns the credits a call with args would spend, or
+	// unlimitedSpend for a call that commits to spending without a limit.
+	// It reports false for calls that spend nothing or whose arguments
+	// the tool will reject anyway.
+	estimateSpend(args map[string]interface{}) (float64, bool)
+}
+
+// unlimitedSpend is the estimate of a call that commits to spending with
+// no limit, such as creating a schedule without a budget.
+var unlimitedSpend = math.Inf(1)
+
+// BudgetGuard tracks the credits the tools spend per agent session, as
+// identified by the session ID on the call's context, refuses calls that
+// would take a session over its ceiling and holds back costly calls until
+// the agent confirms them.
+//
+// Each call's estimated cost is reserved while it runs. A call that submits
+// jobs is then charged the credits the server deducted for them, even when
+// it goes on to report the jobs failed; any other call keeps its estimate
+// unless it fails. Job submissions, template submissions and transfers, escrowed
+// ones included, are counted, as are the budgets of the worker pools and
+// job schedules the session creates, one window's worth for a schedule.
+// A schedule without a budget is refused while the session has a ceiling.
+// Capacity bookings hold rather than spend credits.
+type BudgetGuard struct {
+	config BudgetConfig
+
+	mu    sync.Mutex
+	spent map[string]float64 // session -> credits spent or reserved
+}
+
+// NewBudgetGuard creates a budget guard.
+func NewBudgetGuard(config BudgetConfig) *BudgetGuard {
+	return &BudgetGuard{config: config, spent: make(map[string]float64)}
+}
+
+// Spent returns the credits spent in the session so far.
+func (g *BudgetGuard) Spent(session string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.spent[session]
+}
+
+// Remaining returns the credits left in the session's budget. It reports
+// false when there is no ceiling.
+func (g *BudgetGuard) Remaining(session string) (float64, bool) {
+	if g.config.SessionCeiling <= 0 {
+		return 0, false
+	}
+	return max(g.config.SessionCeiling-g.Spent(session), 0), true
+}
+
+// Reset forgets what the session has spent.
+func (g *BudgetGuard) Reset(session string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.spent, session)
+}
+
+// reserve counts cost against the session's budget, failing when it would
+// go over the ceiling. The reservation keeps concurrent calls from
+// overspending together.
+func (g *BudgetGuard) reserve(session string, cost float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	spent := g.spent[session]
+	if ceiling := g.config.SessionCeiling; ceiling > 0 && spent+cost > ceiling {
+		return fmt.Errorf(
+			"estimated cost %.2f credits exceeds the %.2f credits left of this session's budget of %.2f credits. "+
+				"Ask the user whether to raise the budget or spend less",
+			cost, max(ceiling-spent, 0), ceiling,
+		)
+	}
+	g.spent[session] = spent + cost
+	return nil
+}
+
+// settle replaces a reservation with what the call actually spent: zero
+// releases it.
+func (g *BudgetGuard) settle(session string, reserved, spent float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.spent[session] = max(g.spent[session]-reserved+spent, 0)
+}
+
+// describe renders the session's budget for the agent.
+func (g *BudgetGuard) describe(session string) string {
+	remaining, ok := g.Remaining(session)
+	if !ok {
+		return fmt.Sprintf("💰 Session spend: %.2f credits (no budget ceiling)", g.Spent(session))
+	}
+	return fmt.Sprintf("💰 Session budget: %.2f of %.2f credits left", remaining, g.config.SessionCeiling)
+}
+
+// wrap guards the tool when its calls spend credits.
+func (g *BudgetGuard) wrap(tool tools.Tool, spender budgetedTool) tools.Tool {
+	return &budgetTool{Tool: tool, spender: spender, guard: g}
+}
+
+// budgetTool enforces a BudgetGuard on a tool that spends credits.
+type budgetTool struct {
+	tools.Tool
+	spender budgetedTool
+	guard   *BudgetGuard
+}
+
+// Parameters adds the confirmation flag to the wrapped tool's parameters.
+func (t *budgetTool) Parameters() map[string]interface{} {
+	params := t.Tool.Parameters()
+	if t.guard.config.ConfirmAbove <= 0 {
+		return params
+	}
+
+	properties := make(map[string]interface{})
+	if existing, ok := params["properties"].(map[string]interface{}); ok {
+		for name, schema := range existing {
+			properties[name] = schema
+		}
+	}
+	properties["confirm_spend"] = map[string]interface{}{
+		"type": "boolean",
+		"description": fmt.Sprintf("Set after the user has agreed to a call costing more than %.2f credits",
+			t.guard.config.ConfirmAbove),
+		"default": false,
+	}
+
+	wrapped := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		wrapped[key] = value
+	}
+	wrapped["properties"] = properties
+	return wrapped
+}
+
+// Execute runs the wrapped tool when the call fits the session's budget,
+// and reports what is left of it.
+func (t *budgetTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	cost, spends := t.spender.estimateSpend(args)
+	if !spends {
+		return t.Tool.Execute(ctx, args)
+	}
+	session := SessionIDFromContext(ctx)
+	unlimited := math.IsInf(cost, 1)
+
+	if unlimited && t.guard.config.SessionCeiling > 0 {
+		return t.withBudget(session, tools.ErrorResult(
+			"This call would commit to spending credits without a limit, which this session's budget cannot cover. "+
+				"Give it a budget and call again.",
+		))
+	}
+	if confirmed, _ := args["confirm_spend"].(bool); !confirmed && t.guard.config.ConfirmAbove > 0 && cost > t.guard.config.ConfirmAbove {
+		estimate := fmt.Sprintf("an estimated %.2f credits", cost)
+		if unlimited {
+			estimate = "credits without a limit"
+		}
+		return t.withBudget(session, tools.ErrorResult(fmt.Sprintf(
+			"This call would spend %s, more than the %.2f credits that need the user's confirmation. "+
+				"Tell the user the cost and, once they agree, call again with confirm_spend=true.",
+			estimate, t.guard.config.ConfirmAbove,
+		)))
+	}
+	if unlimited {
+		// Without a ceiling there is nothing to count it against
+		return t.withBudget(session, t.Tool.Execute(ctx, args))
+	}
+	if err := t.guard.reserve(session, cost); err != nil {
+		return t.withBudget(session, tools.ErrorResult(err.Error()+"."))
+	}
+
+	ctx, tally := withCreditTally(ctx)
+	result := t.Tool.Execute(ctx, args)
+	if deducted := tally.total(); deducted > 0 {
+		t.guard.settle(session, cost, deducted)
+	} else if result == nil || result.IsError {
+		t.guard.settle(session, cost, 0)
+	}
+	return t.withBudget(session, result)
+}
+
+// withBudget appends the session's budget to the result for the agent.
+func (t *budgetTool) withBudget(session string, result *tools.ToolResult) *tools.ToolResult {
+	if result != nil {
+		result.ForLLM += "\n\n" + t.guard.describe(session)
+	}
+	return result
+}
+
+// estimateSpend returns the estimated cost of the job the call submits.
+func (t *JobTool) estimateSpend(args map[string]interface{}) (float64, bool) {
+	spec, err := buildJobSpec(t.client, args)
+	if err != nil {
+		return 0, false
+	}
+	return calculateCreditCost(spec), true
+}
+
+// estimateSpend returns the estimated cost of the job a submit action
+// submits from its template.
+func (t *JobTemplateTool) estimateSpend(args map[string]interface{}) (float64, bool) {
+	if action, _ := args["action"].(string); action != "submit" || t.client.templates == nil {
+		return 0, false
+	}
+	name, _ := args["name"].(string)
+	tmpl, ok := t.client.templates.Get(name)
+	if !ok {
+		return 0, false
+	}
+	params, err := stringMap(args["params"])
+	if err != nil {
+		return 0, false
+	}
+	spec, err := tmpl.Render(params)
+	if err != nil {
+		return 0, false
+	}
+	return calculateCreditCost(spec), true
+}
+
+// estimateSpend returns the budget of the pool a create action starts: the
+// most its workers may cost, however many are replaced.
+func (t *WorkerPoolTool) estimateSpend(args map[string]interface{}) (float64, bool) {
+	if action, _ := args["action"].(string); action != "create" {
+		return 0, false
+	}
+	budget, _ := args["budget"].(float64)
+	return budget, budget > 0
+}
+
+// estimateSpend returns the budget a create action gives the schedule for
+// one window, or unlimitedSpend for a schedule without a budget.
+func (t *ScheduleTool) estimateSpend(args map[string]interface{}) (float64, bool) {
+	if action, _ := args["action"].(string); action != "create" {
+		return 0, false
+	}
+	if budget, _ := args["budget"].(float64); budget > 0 {
+		return budget, true
+	}
+	return unlimitedSpend, true
+}
+
+// estimateSpend returns the amount the call transfers or puts in escrow.
+// A transfer that waits for approval is counted when it is asked for, so
+// approving it, like answering an offer, counts nothing.
+func (t *TransferTool) estimateSpend(args map[string]interface{}) (float64, bool) {
+	if action, _ := args["action"].(string); action != "" && action != "send" && action != "offer" {
+		return 0, false
+	}
+	amount, ok := args["amount"].(float64)
+	if !ok {
+		if amountInt, ok := args["amount"].(int); ok {
+			amount = float64(amountInt)
+		}
+	}
+	return amount, amount > 0
+}
@@ -0,0 +1,195 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// budgetTools returns the provider's tools by name with the guard set.
+func budgetTools(client *Client, guard *BudgetGuard) map[string]tools.Tool {
+	provider := NewToolsProvider(client)
+	provider.SetBudgetGuard(guard)
+	byName := make(map[string]tools.Tool)
+	for _, tool := range provider.GetAllTools() {
+		byName[tool.Name()] = tool
+	}
+	return byName
+}
+
+func TestBudgetGuard_SessionCeiling(t *testing.T) {
+	client := NewSandboxClient()
+	guard := NewBudgetGuard(BudgetConfig{SessionCeiling: 5})
+	submit := budgetTools(client, guard)["deparrow_submit_job"]
+	args := map[string]interface{}{"image": "alpine"}
+	cost, _ := NewJobTool(client).estimateSpend(args)
+
+	ctx := WithSessionID(context.Background(), "cli:one")
+	result := submit.Execute(ctx, args)
+	if result.IsError || !strings.Contains(result.ForLLM, "Session budget: ") {
+		t.Fatalf("first submission:\n%s", result.ForLLM)
+	}
+	if got := guard.Spent("cli:one"); got != cost {
+		t.Errorf("Spent() = %v, want %v", got, cost)
+	}
+
+	guard.reserve("cli:one", 5-cost)
+	result = submit.Execute(ctx, args)
+	if !result.IsError || !strings.Contains(result.ForLLM, "exceeds the 0.00 credits left") {
+		t.Errorf("over-budget submission:\n%s", result.ForLLM)
+	}
+	if remaining, _ := guard.Remaining("cli:one"); remaining != 0 {
+		t.Errorf("Remaining() = %v after a refused call", remaining)
+	}
+
+	// Other sessions have their own budget
+	if result := submit.Execute(WithSessionID(context.Background(), "cli:two"), args); result.IsError {
+		t.Errorf("other session:\n%s", result.ForLLM)
+	}
+	guard.Reset("cli:one")
+	if result := submit.Execute(ctx, args); result.IsError {
+		t.Errorf("after reset:\n%s", result.ForLLM)
+	}
+}
+
+func TestBudgetGuard_FailedJobsStillSpend(t *testing.T) {
+	// Every job is charged 2 credits and then fails
+	server := newFailingJobServer(1, 1, 1, 1, 1, 1)
+	defer server.Close()
+	client := NewClient(server.URL, "test-token", WithLongPolling())
+	guard := NewBudgetGuard(BudgetConfig{SessionCeiling: 5})
+	submit := budgetTools(client, guard)["deparrow_submit_job"]
+	args := map[string]interface{}{"image": "alpine", "wait": true}
+
+	ctx := WithSessionID(context.Background(), "cli:one")
+	result := submit.Execute(ctx, args)
+	if !result.IsError || !strings.Contains(result.ForLLM, "failed") {
+		t.Fatalf("failed job:\n%s", result.ForLLM)
+	}
+	if got := guard.Spent("cli:one"); got != 2 {
+		t.Errorf("Spent() = %v after a failed job, want the 2 credits deducted", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		submit.Execute(ctx, args)
+	}
+	if n := server.submissions(); n != 3 {
+		t.Errorf("%d jobs submitted, want the budget to stop them after 3", n)
+	}
+	if got := guard.Spent("cli:one"); got != 6 {
+		t.Errorf("Spent() = %v, want the 6 credits deducted", got)
+	}
+}
+
+func TestBudgetGuard_Confirmation(t *testing.T) {
+	client := NewSandboxClient()
+	guard := NewBudgetGuard(BudgetConfig{ConfirmAbove: 10})
+	transfer := budgetTools(client, guard)["deparrow_transfer"]
+
+	props := transfer.Parameters()["properties"].(map[string]interface{})
+	if _, ok := props["confirm_spend"]; !ok {
+		t.Error("confirm_spend should be a parameter")
+	}
+	if _, ok := NewTransferTool(client).Parameters()["properties"].(map[string]interface{})["confirm_spend"]; ok {
+		t.Error("the wrapped tool's parameters should not change")
+	}
+
+	ctx := context.Background()
+	args := map[string]interface{}{"to_user_id": "user-0123456789abcdef", "amount": 25.0}
+	result := transfer.Execute(ctx, args)
+	if !result.IsError || !strings.Contains(result.ForLLM, "confirm_spend=true") {
+		t.Fatalf("unconfirmed transfer:\n%s", result.ForLLM)
+	}
+	if guard.Spent("") != 0 {
+		t.Error("an unconfirmed call should spend nothing")
+	}
+
+	args["confirm_spend"] = true
+	result = transfer.Execute(ctx, args)
+	if result.IsError || !strings.Contains(result.ForLLM, "Session spend: 25.00 credits") {
+		t.Errorf("confirmed transfer:\n%s", result.ForLLM)
+	}
+
+	// Small calls and failed calls are not held back or counted
+	result = transfer.Execute(ctx, map[string]interface{}{"amount": 5.0})
+	if !result.IsError || guard.Spent("") != 25 {
+		t.Errorf("failed transfer counted: spent %v\n%s", guard.Spent(""), result.ForLLM)
+	}
+}
+
+func TestBudgetGuard_ConcurrentReservations(t *testing.T) {
+	guard := NewBudgetGuard(BudgetConfig{SessionCeiling: 10})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if guard.reserve("s", 1) == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 10 || guard.Spent("s") != 10 {
+		t.Errorf("accepted %d reservations, spent %v", accepted, guard.Spent("s"))
+	}
+}
+
+func TestBudgetGuard_OnlySpendingTools(t *testing.T) {
+	guarded := budgetTools(NewSandboxClient(), NewBudgetGuard(BudgetConfig{SessionCeiling: 1}))
+	for name, tool := range guarded {
+		_, isBudgeted := tool.(*sessionTool).Tool.(*budgetTool)
+		want := name == "deparrow_submit_job" || name == "deparrow_job_template" || name == "deparrow_transfer" ||
+			name == "deparrow_worker_pool" || name == "deparrow_schedule"
+		if isBudgeted != want {
+			t.Errorf("%s guarded = %v, want %v", name, isBudgeted, want)
+		}
+	}
+}
+
+func TestBudgetGuard_Schedules(t *testing.T) {
+	client := NewSandboxClient()
+	guard := NewBudgetGuard(BudgetConfig{SessionCeiling: 20})
+	schedule := budgetTools(client, guard)["deparrow_schedule"]
+	ctx := WithSessionID(context.Background(), "cli:one")
+	args := map[string]interface{}{"action": "create", "image": "alpine", "cron": "0 2 * * *"}
+
+	result := schedule.Execute(ctx, args)
+	if !result.IsError || !strings.Contains(result.ForLLM, "without a limit") {
+		t.Fatalf("schedule without a budget:\n%s", result.ForLLM)
+	}
+
+	args["budget"] = 15.0
+	if result := schedule.Execute(ctx, args); result.IsError || guard.Spent("cli:one") != 15 {
+		t.Fatalf("schedule with a budget: spent %v\n%s", guard.Spent("cli:one"), result.ForLLM)
+	}
+	if result := schedule.Execute(ctx, args); !result.IsError || !strings.Contains(result.ForLLM, "exceeds") {
+		t.Errorf("schedule over the session's budget:\n%s", result.ForLLM)
+	}
+
+	// Without a ceiling an unlimited schedule needs the user's confirmation
+	schedule = budgetTools(client, NewBudgetGuard(BudgetConfig{ConfirmAbove: 10}))["deparrow_schedule"]
+	delete(args, "budget")
+	if result := schedule.Execute(ctx, args); !result.IsError || !strings.Contains(result.ForLLM, "confirm_spend=true") {
+		t.Errorf("unconfirmed schedule without a budget:\n%s", result.ForLLM)
+	}
+	args["confirm_spend"] = true
+	if result := schedule.Execute(ctx, args); result.IsError {
+		t.Errorf("confirmed schedule without a budget:\n%s", result.ForLLM)
+	}
+
+	// Nothing is scheduled while spending is paused
+	client.PauseSpending("test")
+	if result := NewScheduleTool(client).Execute(ctx, args); !result.IsError || !strings.Contains(result.ForLLM, "paused") {
+		t.Errorf("schedule while spending is paused:\n%s", result.ForLLM)
+	}
+}
@@ -0,0 +1,155 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// calendarsPath is the collection endpoint for provider calendars.
+	calendarsPath = "/api/v1/calendars"
+
+	// bookingsPath is the collection endpoint for capacity bookings.
+	bookingsPath = "/api/v1/bookings"
+)
+
+// CalendarQuery filters the calendars returned by ListCalendars. Zero
+// fields don't filter.
+type CalendarQuery struct {
+	GPUModel string
+	Region   string
+	// Only slots overlapping [From, To) are returned
+	From time.Time
+	To   time.Time
+}
+
+// values encodes the query as URL parameters.
+func (q CalendarQuery) values() url.Values {
+	v := url.Values{}
+	if q.GPUModel != "" {
+		v.Set("gpu_model", q.GPUModel)
+	}
+	if q.Region != "" {
+		v.Set("region", q.Region)
+	}
+	if !q.From.IsZero() {
+		v.Set("from", q.From.UTC().Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		v.Set("to", q.To.UTC().Format(time.RFC3339))
+	}
+	return v
+}
+
+// ListCalendars retrieves the availability calendars providers publish for
+// their nodes.
+func (c *Client) ListCalendars(ctx context.Context, query CalendarQuery) ([]CapacityCalendar, error) {
+	var result struct {
+		Calendars []CapacityCalendar `json:"calendars"`
+	}
+	path := calendarsPath
+	if params := query.values(); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Calendars == nil {
+		result.Calendars = []CapacityCalendar{}
+	}
+	return result.Calendars, nil
+}
+
+// GetNodeCalendar retrieves the calendar of a single node, limited to slots
+// overlapping [from, to) when they are set.
+func (c *Client) GetNodeCalendar(ctx context.Context, nodeID string, from, to time.Time) (*CapacityCalendar, error) {
+	path := calendarsPath + "/" + url.PathEscape(nodeID)
+	if params := (CalendarQuery{From: from, To: to}).values(); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var result CapacityCalendar
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BookCapacity reserves GPUs on a node for a future window. The booking's
+// cost is held from the wallet until the window has passed or the booking
+// is cancelled.
+func (c *Client) BookCapacity(ctx context.Context, req *BookingRequest) (*Booking, error) {
+	if req.NodeID == "" {
+		return nil, fmt.Errorf("node is required")
+	}
+	if req.GPUs <= 0 {
+		return nil, fmt.Errorf("gpus must be positive")
+	}
+	if !req.End.After(req.Start) {
+		return nil, fmt.Errorf("booking must end after it starts")
+	}
+
+	var result Booking
+	if err := c.doRequest(ctx, http.MethodPost, bookingsPath, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListBookings retrieves the authenticated user's bookings, including
+// cancelled ones.
+func (c *Client) ListBookings(ctx context.Context) ([]Booking, error) {
+	var result struct {
+		Bookings []Booking `json:"bookings"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, bookingsPath, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Bookings == nil {
+		result.Bookings = []Booking{}
+	}
+	return result.Bookings, nil
+}
+
+// CancelBooking cancels a booking that has not started and releases the
+// credits held for it.
+func (c *Client) CancelBooking(ctx context.Context, bookingID string) error {
+	return c.doRequest(ctx, http.MethodDelete, bookingPath(bookingID), nil, nil)
+}
+
+func bookingPath(bookingID string) string {
+	return bookingsPath + "/" + url.PathEscape(bookingID)
+}
+
+// Covers reports whether the slot spans [start, end) with gpus free.
+func (s CalendarSlot) Covers(start, end time.Time, gpus int) bool {
+	return !start.Before(s.Start) && !end.After(s.End) && gpus <= s.AvailableGPUs
+}
+
+// Cost returns what booking gpus of the slot for [start, end) costs.
+func (s CalendarSlot) Cost(start, end time.Time, gpus int) float64 {
+	return float64(gpus) * end.Sub(start).Hours() * s.CreditsPerGPUHour
+}
+
+// cheapestSlot returns the calendar and slot that can take a booking of
+// gpus for [start, end) at the lowest price. The GPU model is matched
+// when it is set.
+func cheapestSlot(calendars []CapacityCalendar, gpuModel string, start, end time.Time, gpus int) (CapacityCalendar, CalendarSlot, bool) {
+	var bestCal CapacityCalendar
+	var best CalendarSlot
+	found := false
+	for _, cal := range calendars {
+		if gpuModel != "" && !strings.EqualFold(cal.GPUModel, gpuModel) {
+			continue
+		}
+		for _, slot := range cal.Slots {
+			if slot.Covers(start, end, gpus) && (!found || slot.CreditsPerGPUHour < best.CreditsPerGPUHour) {
+				bestCal, best, found = cal, slot, true
+			}
+		}
+	}
+	return bestCal, best, found
+}
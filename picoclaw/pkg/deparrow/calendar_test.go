@@ -0,0 +1,188 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_CalendarsAndBookings(t *testing.T) {
+	saturday := time.Date(2026, time.October, 24, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/calendars":
+			q := r.URL.Query()
+			if q.Get("gpu_model") != "A100" || q.Get("from") != "2026-10-24T00:00:00Z" || q.Get("to") != "2026-10-25T00:00:00Z" {
+				t.Errorf("unexpected calendar query %v", q)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"calendars": []CapacityCalendar{{NodeID: "node-1", GPUModel: "A100", GPUCount: 8}},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/calendars/node-1":
+			if r.URL.Query().Get("from") != "" {
+				t.Errorf("unexpected range %v", r.URL.Query())
+			}
+			json.NewEncoder(w).Encode(CapacityCalendar{NodeID: "node-1", Slots: []CalendarSlot{{Start: saturday, AvailableGPUs: 8}}})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/bookings":
+			var req BookingRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.NodeID != "node-1" || req.GPUs != 2 || !req.Start.Equal(saturday) {
+				t.Errorf("unexpected booking request %+v", req)
+			}
+			json.NewEncoder(w).Encode(Booking{ID: "booking-1", NodeID: req.NodeID, GPUs: req.GPUs, Status: BookingHeld, HoldAmount: 240})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/bookings":
+			json.NewEncoder(w).Encode(map[string]interface{}{})
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/bookings/booking-1":
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	ctx := context.Background()
+
+	calendars, err := client.ListCalendars(ctx, CalendarQuery{GPUModel: "A100", From: saturday, To: saturday.AddDate(0, 0, 1)})
+	if err != nil || len(calendars) != 1 || calendars[0].GPUCount != 8 {
+		t.Fatalf("ListCalendars() = %+v, %v", calendars, err)
+	}
+
+	cal, err := client.GetNodeCalendar(ctx, "node-1", time.Time{}, time.Time{})
+	if err != nil || len(cal.Slots) != 1 {
+		t.Errorf("GetNodeCalendar() = %+v, %v", cal, err)
+	}
+
+	booking, err := client.BookCapacity(ctx, &BookingRequest{NodeID: "node-1", GPUs: 2, Start: saturday, End: saturday.Add(12 * time.Hour)})
+	if err != nil || booking.ID != "booking-1" || booking.HoldAmount != 240 {
+		t.Fatalf("BookCapacity() = %+v, %v", booking, err)
+	}
+
+	bookings, err := client.ListBookings(ctx)
+	if err != nil || bookings == nil || len(bookings) != 0 {
+		t.Errorf("ListBookings() = %#v, %v; want an empty list", bookings, err)
+	}
+
+	if err := client.CancelBooking(ctx, "booking-1"); err != nil {
+		t.Errorf("CancelBooking() error = %v", err)
+	}
+}
+
+func TestClient_BookCapacityValidation(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	start := time.Now().Add(24 * time.Hour)
+
+	invalid := []*BookingRequest{
+		{GPUs: 1, Start: start, End: start.Add(time.Hour)},
+		{NodeID: "node-1", Start: start, End: start.Add(time.Hour)},
+		{NodeID: "node-1", GPUs: 1, Start: start, End: start},
+	}
+	for _, req := range invalid {
+		if _, err := client.BookCapacity(context.Background(), req); err == nil {
+			t.Errorf("BookCapacity(%+v) expected error", req)
+		}
+	}
+}
+
+func TestResolveDate(t *testing.T) {
+	// A Saturday
+	now := time.Date(2026, time.October, 17, 15, 30, 0, 0, time.UTC)
+
+	tests := map[string]time.Time{
+		"today":         time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC),
+		"Tomorrow":      time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+		"monday":        time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		"next Saturday": time.Date(2026, time.October, 24, 0, 0, 0, 0, time.UTC),
+		"friday":        time.Date(2026, time.October, 23, 0, 0, 0, 0, time.UTC),
+		"2026-11-02":    time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC),
+	}
+	for date, want := range tests {
+		if got, err := resolveDate(date, now); err != nil || !got.Equal(want) {
+			t.Errorf("resolveDate(%q) = %s, %v; want %s", date, got, err, want)
+		}
+	}
+
+	if _, err := resolveDate("someday", now); err == nil {
+		t.Error("resolveDate(someday) expected error")
+	}
+}
+
+func TestSandbox_Bookings(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	balanceNow := func() float64 {
+		credits, err := client.GetCredits(ctx)
+		if err != nil {
+			t.Fatalf("GetCredits() error = %v", err)
+		}
+		return credits.Balance
+	}
+	balance := balanceNow()
+
+	calendars, err := client.ListCalendars(ctx, CalendarQuery{GPUModel: "a100"})
+	if err != nil {
+		t.Fatalf("ListCalendars() error = %v", err)
+	}
+	if len(calendars) != 2 {
+		t.Fatalf("ListCalendars() returned %d calendars, want the two A100 nodes", len(calendars))
+	}
+	cal := calendars[0]
+	if len(cal.Slots) == 0 {
+		t.Fatalf("calendar %s has no slots", cal.NodeID)
+	}
+	// Weekend slots start on a Saturday; skip one already under way
+	slot := cal.Slots[len(cal.Slots)-1]
+	if slot.Start.Weekday() != time.Saturday || slot.AvailableGPUs != 8 {
+		t.Fatalf("slot = %+v, want a free weekend", slot)
+	}
+
+	start, end := slot.Start.Add(8*time.Hour), slot.Start.Add(18*time.Hour)
+	booking, err := client.BookCapacity(ctx, &BookingRequest{NodeID: cal.NodeID, GPUs: 6, Start: start, End: end})
+	if err != nil {
+		t.Fatalf("BookCapacity() error = %v", err)
+	}
+	if want := slot.Cost(start, end, 6); booking.Status != BookingHeld || booking.HoldAmount != want {
+		t.Errorf("booking = %+v, want a hold of %.2f", booking, want)
+	}
+	if after := balanceNow(); math.Abs(after-(balance-booking.HoldAmount)) > 1e-9 {
+		t.Errorf("balance after booking = %.2f, want %.2f", after, balance-booking.HoldAmount)
+	}
+
+	// The booked GPUs are no longer offered
+	updated, err := client.GetNodeCalendar(ctx, cal.NodeID, slot.Start, slot.End)
+	if err != nil || len(updated.Slots) != 1 || updated.Slots[0].AvailableGPUs != 2 {
+		t.Errorf("GetNodeCalendar() = %+v, %v; want 2 GPUs left", updated, err)
+	}
+	var apiErr *APIError
+	if _, err := client.BookCapacity(ctx, &BookingRequest{NodeID: cal.NodeID, GPUs: 4, Start: start, End: end}); !errors.As(err, &apiErr) || apiErr.Code != http.StatusConflict {
+		t.Errorf("overbooking error = %v, want 409", err)
+	}
+	// Outside the weekend the node is not offered
+	weekday := slot.Start.AddDate(0, 0, 3)
+	if _, err := client.BookCapacity(ctx, &BookingRequest{NodeID: cal.NodeID, GPUs: 1, Start: weekday, End: weekday.Add(time.Hour)}); err == nil {
+		t.Error("booking outside the calendar should fail")
+	}
+
+	if err := client.CancelBooking(ctx, booking.ID); err != nil {
+		t.Fatalf("CancelBooking() error = %v", err)
+	}
+	if after := balanceNow(); math.Abs(after-balance) > 1e-9 {
+		t.Errorf("balance after cancelling = %.2f, want the hold released to %.2f", after, balance)
+	}
+	bookings, err := client.ListBookings(ctx)
+	if err != nil || len(bookings) != 1 || bookings[0].Status != BookingCancelled {
+		t.Errorf("ListBookings() = %+v, %v", bookings, err)
+	}
+	if err := client.CancelBooking(ctx, booking.ID); err == nil {
+		t.Error("cancelling twice should fail")
+	}
+}
@@ -0,0 +1,166 @@
+package deparrow
+
+import (
+	"context"
+	"net/http"
+)
+
+// Optional features a Meta-OS advertises on its health endpoint. Requests
+// using a field that needs one are refused with ErrUnsupportedFeature
+// before they are sent to a server that doesn't advertise it, rather than
+// failing with a bare 400 or having the field silently ignored, and tools
+// leave out the parameters and actions that need one, so the model isn't
+// offered requests that would fail.
+const (
+	// FeatureVerifiedJobs covers the verified and verification fields of
+	// a job spec.
+	FeatureVerifiedJobs = "verified_jobs"
+	// FeatureOrchestratorRouting covers submitting a job through a chosen
+	// orchestrator.
+	FeatureOrchestratorRouting = "orchestrator_routing"
+	// FeatureSchedulingConstraints covers the node selector, regions,
+	// architectures and excluded nodes of a job spec.
+	FeatureSchedulingConstraints = "scheduling_constraints"
+	// FeatureWorkflows covers submitting a workflow in one request, for
+	// the server to run its steps in dependency order.
+	FeatureWorkflows = "workflows"
+	// FeatureSpotPricing covers the spot pricing class and the bid of a
+	// job spec.
+	FeatureSpotPricing = "spot_pricing"
+	// FeatureEscrow covers transfer offers, whose credits are held in
+	// escrow until the recipient accepts.
+	FeatureEscrow = "escrow"
+	// FeatureWebSocket covers real-time events pushed over a WebSocket;
+	// without it events are long-polled.
+	FeatureWebSocket = "websocket"
+	// FeatureEstimates covers the server's job estimates; without it
+	// costs are estimated locally.
+	FeatureEstimates = "estimates"
+)
+
+// ServerInfo is what the Meta-OS advertises about itself on its health
+// endpoint.
+type ServerInfo struct {
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+	// API version, e.g. "1.2"; servers that predate version negotiation
+	// leave it out and support none of the optional features
+	APIVersion string   `json:"api_version,omitempty"`
+	Features   []string `json:"features,omitempty"`
+}
+
+// Supports reports whether the server advertises a feature, e.g.
+// FeatureVerifiedJobs.
+func (s *ServerInfo) Supports(feature string) bool {
+	for _, f := range s.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerInfo returns what the server advertises about itself. It is asked
+// on first use and remembered for the life of the client.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	c.discoverMu.Lock()
+	defer c.discoverMu.Unlock()
+	if info := c.knownServerInfo(); info != nil {
+		return info, nil
+	}
+
+	var info ServerInfo
+	if err := c.doRequest(ctx, http.MethodGet, "/api/v1/health", nil, &info); err != nil {
+		return nil, err
+	}
+	c.serverInfoMu.Lock()
+	c.serverInfo = &info
+	c.serverInfoMu.Unlock()
+	return &info, nil
+}
+
+// knownServerInfo returns what the server advertised, or nil when it
+// hasn't been asked yet. It never waits on a request.
+func (c *Client) knownServerInfo() *ServerInfo {
+	c.serverInfoMu.Lock()
+	defer c.serverInfoMu.Unlock()
+	return c.serverInfo
+}
+
+// offers reports whether tools should offer what needs a feature. Until
+// the server has said what it supports, everything is offered and the
+// server judges each request.
+func (c *Client) offers(feature string) bool {
+	info := c.knownServerInfo()
+	return info == nil || info.Supports(feature)
+}
+
+// requestFeature is an optional feature a field of a request needs.
+type requestFeature struct {
+	field   string
+	feature string
+}
+
+// features returns the optional features the fields of the spec need.
+func (s *JobSpec) features() []requestFeature {
+	var features []requestFeature
+	switch {
+	case s.Verification != nil:
+		features = append(features, requestFeature{"spec.verification", FeatureVerifiedJobs})
+	case s.Verified:
+		features = append(features, requestFeature{"spec.verified", FeatureVerifiedJobs})
+	}
+	if field := s.constraintField(); field != "" {
+		features = append(features, requestFeature{field, FeatureSchedulingConstraints})
+	}
+	switch {
+	case s.IsSpot():
+		features = append(features, requestFeature{"spec.pricing_class", FeatureSpotPricing})
+	case s.MaxCreditBid != 0:
+		features = append(features, requestFeature{"spec.max_credit_bid", FeatureSpotPricing})
+	}
+	return features
+}
+
+// requireFeatures returns an *UnsupportedFeatureError for the first
+// feature the server doesn't support. The server is only asked when a
+// request uses optional features; when it can't be asked the request goes
+// ahead and the server judges it.
+func (c *Client) requireFeatures(ctx context.Context, features []requestFeature) error {
+	if len(features) == 0 {
+		return nil
+	}
+	info, err := c.ServerInfo(ctx)
+	if err != nil {
+		return nil
+	}
+	for _, f := range features {
+		if !info.Supports(f.feature) {
+			return &UnsupportedFeatureError{Feature: f.feature, Field: f.field, APIVersion: info.APIVersion}
+		}
+	}
+	return nil
+}
+
+// advertises reports whether the server is known to support a feature.
+// Unlike offers it is false until the server has said so, for what the
+// client adds to requests on its own account.
+func (c *Client) advertises(feature string) bool {
+	info := c.knownServerInfo()
+	return info != nil && info.Supports(feature)
+}
+
+// offersFeatures returns an *UnsupportedFeatureError for the first feature
+// tools don't offer, without asking the server.
+func (c *Client) offersFeatures(features []requestFeature) error {
+	info := c.knownServerInfo()
+	if info == nil {
+		return nil
+	}
+	for _, f := range features {
+		if !info.Supports(f.feature) {
+			return &UnsupportedFeatureError{Feature: f.feature, Field: f.field, APIVersion: info.APIVersion}
+		}
+	}
+	return nil
+}
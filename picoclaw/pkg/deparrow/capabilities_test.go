@@ -0,0 +1,296 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// featureServer answers the health endpoint with health and counts the
+// job submissions it gets. It has no batch endpoint.
+func featureServer(t *testing.T, health map[string]interface{}) (*httptest.Server, *atomic.Int32) {
+	var submits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" {
+			json.NewEncoder(w).Encode(health)
+			return
+		}
+		if r.URL.Path == batchSubmitPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		submits.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "credit_deducted": 1.0})
+	}))
+	t.Cleanup(server.Close)
+	return server, &submits
+}
+
+func TestClient_RequireFeatures(t *testing.T) {
+	ctx := context.Background()
+	verified := &JobSpec{Image: "alpine", Verified: true}
+
+	// Servers that predate negotiation support no optional fields
+	server, submits := featureServer(t, map[string]interface{}{"status": "healthy", "version": "1.1.0"})
+	client := NewClient(server.URL, "test-token")
+	_, err := client.SubmitJob(ctx, verified)
+	var unsupported *UnsupportedFeatureError
+	if !errors.Is(err, ErrUnsupportedFeature) || !errors.As(err, &unsupported) {
+		t.Fatalf("SubmitJob() error = %v, want ErrUnsupportedFeature", err)
+	}
+	if unsupported.Field != "spec.verified" || unsupported.Feature != FeatureVerifiedJobs || unsupported.APIVersion != "" {
+		t.Errorf("error = %+v", unsupported)
+	}
+	if _, err := client.SubmitJobTo(ctx, &JobSpec{Image: "alpine"}, "orch-eu"); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("SubmitJobTo() error = %v, want ErrUnsupportedFeature", err)
+	}
+	// but plain submissions go through
+	if _, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"}); err != nil {
+		t.Errorf("SubmitJob() error = %v", err)
+	}
+	if submits.Load() != 1 {
+		t.Errorf("server got %d submissions, want only the plain one", submits.Load())
+	}
+
+	// A newer server is held to the features it advertises
+	server, submits = featureServer(t, map[string]interface{}{
+		"status": "healthy", "api_version": "1.2", "features": []string{FeatureVerifiedJobs},
+	})
+	client = NewClient(server.URL, "test-token")
+	if _, err := client.SubmitJob(ctx, verified); err != nil {
+		t.Errorf("SubmitJob() error = %v", err)
+	}
+	_, err = client.SubmitJobTo(ctx, &JobSpec{Image: "alpine", Verified: true, Verification: &VerificationSpec{Mode: VerifyTEE}}, "orch-eu")
+	if !errors.As(err, &unsupported) || unsupported.Field != "orchestrator" || !strings.Contains(err.Error(), "API 1.2") {
+		t.Errorf("SubmitJobTo() error = %v, want orchestrator unsupported by API 1.2", err)
+	}
+	batch, _ := client.SubmitJobs(ctx, []*JobSpec{verified, {Image: "alpine"}})
+	if batch.Submitted != 2 {
+		t.Errorf("SubmitJobs() submitted %d, want 2: %v", batch.Submitted, batch.Err())
+	}
+
+	info, err := client.ServerInfo(ctx)
+	if err != nil || info.APIVersion != "1.2" || !info.Supports(FeatureVerifiedJobs) || info.Supports(FeatureOrchestratorRouting) {
+		t.Errorf("ServerInfo() = %+v, %v", info, err)
+	}
+}
+
+func TestClient_RequireFeaturesWithoutHealth(t *testing.T) {
+	// When the server can't say what it supports, it judges the request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	if _, err := client.SubmitJob(context.Background(), &JobSpec{Image: "alpine", Verified: true}); err != nil {
+		t.Errorf("SubmitJob() error = %v", err)
+	}
+}
+
+func TestSandbox_AdvertisesFeatures(t *testing.T) {
+	info, err := NewSandboxClient().ServerInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerInfo() error = %v", err)
+	}
+	if info.APIVersion == "" || !info.Supports(FeatureVerifiedJobs) || !info.Supports(FeatureOrchestratorRouting) {
+		t.Errorf("sandbox advertises %+v, want every feature", info)
+	}
+}
+
+// healthFeatureServer advertises features on the health endpoint and
+// counts the requests each path gets.
+func healthFeatureServer(t *testing.T, features []string) (*httptest.Server, map[string]*atomic.Int32) {
+	requests := map[string]*atomic.Int32{
+		"/api/v1/health": {},
+		jobEstimatePath:  {},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count, ok := requests[r.URL.Path]; ok {
+			count.Add(1)
+		}
+		if r.URL.Path == "/api/v1/health" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy", "api_version": "1.3", "features": features})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"credit_cost": 99.0})
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestClient_ServerInfoAskedOnce(t *testing.T) {
+	ctx := context.Background()
+	server, requests := healthFeatureServer(t, []string{FeatureWebSocket})
+	client := NewClient(server.URL, "test-token")
+
+	for i := 0; i < 2; i++ {
+		info, err := client.ServerInfo(ctx)
+		if err != nil {
+			t.Fatalf("ServerInfo() error = %v", err)
+		}
+		if info.APIVersion != "1.3" || !info.Supports(FeatureWebSocket) || info.Supports(FeatureEscrow) {
+			t.Errorf("ServerInfo() = %+v", info)
+		}
+	}
+	if n := requests["/api/v1/health"].Load(); n != 1 {
+		t.Errorf("health asked %d times, want once per client", n)
+	}
+
+	// Estimates aren't advertised, so the endpoint isn't tried
+	estimate, err := client.EstimateJob(ctx, &JobSpec{Image: "alpine"})
+	if err != nil || !estimate.Local {
+		t.Errorf("EstimateJob() = %+v, %v, want a local estimate", estimate, err)
+	}
+	if n := requests[jobEstimatePath].Load(); n != 0 {
+		t.Errorf("estimate endpoint got %d requests, want none", n)
+	}
+}
+
+func TestClient_OffersDuringDiscovery(t *testing.T) {
+	// Tools keep offering everything while discovery waits on the server
+	asked := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(asked)
+		<-release
+		json.NewEncoder(w).Encode(ServerInfo{Status: "healthy", APIVersion: "1.3"})
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "test-token")
+
+	discovered := make(chan error, 1)
+	go func() {
+		_, err := client.ServerInfo(context.Background())
+		discovered <- err
+	}()
+	<-asked
+
+	offered := make(chan bool, 1)
+	go func() { offered <- client.offers(FeatureEscrow) }()
+	select {
+	case ok := <-offered:
+		if !ok {
+			t.Error("offers(escrow) = false before discovery finished")
+		}
+	case <-time.After(time.Second):
+		t.Error("offers() waited on discovery")
+	}
+
+	close(release)
+	if err := <-discovered; err != nil {
+		t.Fatalf("ServerInfo() error = %v", err)
+	}
+	if client.offers(FeatureEscrow) {
+		t.Error("offers(escrow) = true after the server left it out")
+	}
+}
+
+func TestTransferTool_WithoutEscrow(t *testing.T) {
+	ctx := context.Background()
+	server, _ := healthFeatureServer(t, []string{FeatureEstimates})
+	client := NewClient(server.URL, "test-token")
+	tool := NewTransferTool(client)
+
+	// Everything is offered until the capabilities are discovered
+	if !strings.Contains(tool.Description(), "offer:") {
+		t.Error("offers hidden before discovery")
+	}
+	if err := NewToolsProvider(client).DiscoverCapabilities(ctx); err != nil {
+		t.Fatalf("DiscoverCapabilities() error = %v", err)
+	}
+
+	props := tool.Parameters()["properties"].(map[string]interface{})
+	actions := props["action"].(map[string]interface{})["enum"].([]string)
+	if strings.Join(actions, ",") != "send,pending,approve" {
+		t.Errorf("actions = %v, want send,pending,approve", actions)
+	}
+	if strings.Contains(tool.Description(), "offer:") {
+		t.Error("description still describes offers")
+	}
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "offer", "to_user_id": "bob", "amount": 5.0})
+	if !result.IsError || !strings.Contains(result.ForLLM, "use action 'send'") {
+		t.Errorf("Execute(offer) = %+v, want it refused", result)
+	}
+}
+
+func TestJobTools_FollowFeatures(t *testing.T) {
+	ctx := context.Background()
+	server, _ := healthFeatureServer(t, []string{FeatureSpotPricing})
+	client := NewClient(server.URL, "test-token")
+	store, _ := NewTemplateStore("")
+	client.SetTemplates(store)
+	job := NewJobTool(client)
+	template := NewJobTemplateTool(client)
+
+	// Everything is offered until the capabilities are discovered
+	gated := []string{"verified", "replicas", "pricing_class", "orchestrator", "regions", "node_selector"}
+	props := job.Parameters()["properties"].(map[string]interface{})
+	for _, name := range gated {
+		if _, ok := props[name]; !ok {
+			t.Errorf("%s hidden before discovery", name)
+		}
+	}
+	if err := NewToolsProvider(client).DiscoverCapabilities(ctx); err != nil {
+		t.Fatalf("DiscoverCapabilities() error = %v", err)
+	}
+
+	for name, tool := range map[string]tools.Tool{
+		"job": job, "estimate": NewEstimateTool(client), "schedule": NewScheduleTool(client), "pool": NewWorkerPoolTool(client),
+	} {
+		props := tool.Parameters()["properties"].(map[string]interface{})
+		for _, hidden := range []string{"verified", "verification_mode", "replicas", "orchestrator", "architectures", "regions", "node_selector", "exclude_node_ids"} {
+			if _, ok := props[hidden]; ok {
+				t.Errorf("%s tool offers %s, which the server doesn't support", name, hidden)
+			}
+		}
+		if _, ok := props["pricing_class"]; !ok {
+			t.Errorf("%s tool hides pricing_class, which the server supports", name)
+		}
+	}
+	description := job.Description()
+	if strings.Contains(description, "verified=true") || strings.Contains(description, "node_selector") ||
+		!strings.Contains(description, "pricing_class='spot'") {
+		t.Errorf("job description doesn't follow the features:\n%s", description)
+	}
+
+	spec := template.Parameters()["properties"].(map[string]interface{})["spec"].(map[string]interface{})["description"].(string)
+	if strings.Contains(spec, "verified") || strings.Contains(spec, "regions") || !strings.Contains(spec, "pricing_class") {
+		t.Errorf("template spec description = %q", spec)
+	}
+	result := template.Execute(ctx, map[string]interface{}{
+		"action": "save", "name": "placed", "spec": map[string]interface{}{"image": "alpine", "regions": []string{"eu-west"}},
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, FeatureSchedulingConstraints) {
+		t.Errorf("saving a template with regions = %+v, want it refused", result)
+	}
+}
+
+func TestSandbox_Capabilities(t *testing.T) {
+	client := NewSandboxClient()
+	info, err := client.ServerInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerInfo() error = %v", err)
+	}
+	if !info.Supports(FeatureEscrow) || !info.Supports(FeatureEstimates) {
+		t.Errorf("sandbox advertises %+v", info)
+	}
+	if client.offers(FeatureWebSocket) {
+		t.Error("sandbox offers websocket events it doesn't serve")
+	}
+}
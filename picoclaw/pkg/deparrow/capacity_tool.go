@@ -0,0 +1,449 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// CapacityRequest is a parsed, plain-language resource description.
+type CapacityRequest struct {
+	CPU       int
+	MemoryGB  float64
+	StorageGB float64
+	GPUCount  int
+	// GPUModel is empty when any GPU model is acceptable
+	GPUModel string
+	Duration time.Duration
+}
+
+var (
+	capacityPartPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(?:x|×|\*)?\s*(.*)$`)
+	sizeUnitPattern     = regexp.MustCompile(`^(kb|mb|gb|tb|kib|mib|gib|tib)\b\s*(.*)$`)
+)
+
+// ParseCapacityRequest parses descriptions such as "8×A100, 2TB disk, 3 days".
+// Parts are separated by commas or "and". Sizes without a "disk" or "storage"
+// qualifier are treated as memory; the duration defaults to one hour.
+func ParseCapacityRequest(desc string) (*CapacityRequest, error) {
+	req := &CapacityRequest{Duration: time.Hour}
+
+	desc = strings.ReplaceAll(strings.ToLower(desc), " and ", ",")
+	for _, part := range strings.FieldsFunc(desc, func(r rune) bool { return r == ',' || r == ';' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if err := req.parsePart(part); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.CPU == 0 && req.MemoryGB == 0 && req.StorageGB == 0 && req.GPUCount == 0 {
+		return nil, fmt.Errorf("no resources found in %q", desc)
+	}
+	return req, nil
+}
+
+// parsePart parses one "<quantity> <unit or model>" fragment.
+func (r *CapacityRequest) parsePart(part string) error {
+	m := capacityPartPattern.FindStringSubmatch(part)
+	if m == nil {
+		// A bare GPU model such as "a100" means one GPU
+		if isGPUModel(part) {
+			r.GPUCount++
+			r.GPUModel = strings.ToUpper(part)
+			return nil
+		}
+		return fmt.Errorf("cannot parse %q: expected a quantity such as '4 cpu' or '2TB disk'", part)
+	}
+
+	qty, _ := strconv.ParseFloat(m[1], 64)
+	rest := strings.TrimSpace(m[2])
+
+	if sm := sizeUnitPattern.FindStringSubmatch(rest); sm != nil {
+		gb := qty * sizeUnitToGB(sm[1])
+		qualifier := sm[2]
+		if strings.Contains(qualifier, "disk") || strings.Contains(qualifier, "storage") || strings.Contains(qualifier, "ssd") {
+			r.StorageGB += gb
+		} else {
+			r.MemoryGB += gb
+		}
+		return nil
+	}
+
+	word := strings.Fields(rest)
+	if len(word) == 0 {
+		return fmt.Errorf("cannot parse %q: missing unit", part)
+	}
+
+	switch unit := word[0]; {
+	case unit == "cpu" || unit == "cpus" || unit == "core" || unit == "cores" || unit == "vcpu" || unit == "vcpus":
+		r.CPU += int(qty)
+	case unit == "gpu" || unit == "gpus":
+		r.GPUCount += int(qty)
+	case strings.HasPrefix(unit, "min"):
+		r.Duration = time.Duration(qty * float64(time.Minute))
+	case unit == "h" || unit == "hr" || unit == "hrs" || strings.HasPrefix(unit, "hour"):
+		r.Duration = time.Duration(qty * float64(time.Hour))
+	case unit == "d" || strings.HasPrefix(unit, "day"):
+		r.Duration = time.Duration(qty * 24 * float64(time.Hour))
+	case unit == "w" || strings.HasPrefix(unit, "week"):
+		r.Duration = time.Duration(qty * 7 * 24 * float64(time.Hour))
+	case isGPUModel(unit):
+		r.GPUCount += int(qty)
+		r.GPUModel = strings.ToUpper(unit)
+	default:
+		return fmt.Errorf("cannot parse %q: unknown unit %q", part, unit)
+	}
+	return nil
+}
+
+// sizeUnitToGB converts a size unit into a multiplier in gigabytes.
+func sizeUnitToGB(unit string) float64 {
+	switch unit {
+	case "kb", "kib":
+		return 1.0 / (1024 * 1024)
+	case "mb", "mib":
+		return 1.0 / 1024
+	case "tb", "tib":
+		return 1024
+	default:
+		return 1
+	}
+}
+
+// otherGPUModels lists GPU models recognised in resource descriptions besides
+// the classes in gpuClasses.
+var otherGPUModels = []string{"A30", "A6000", "RTX3090", "RTX6000", "GTX1080", "MI100", "MI210", "MI250", "MI250X", "MI300X"}
+
+// isGPUModel reports whether a token names a known GPU model (e.g. "a100", "rtx4090", "mi250").
+func isGPUModel(token string) bool {
+	for _, class := range gpuClasses {
+		if strings.EqualFold(class.Model, token) {
+			return true
+		}
+	}
+	for _, model := range otherGPUModels {
+		if strings.EqualFold(model, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hours returns the requested duration in hours.
+func (r *CapacityRequest) Hours() float64 {
+	return r.Duration.Hours()
+}
+
+// EstimateCost returns the estimated credit cost of the request, priced like
+// a job asking for its resources for its duration.
+func (r *CapacityRequest) EstimateCost() float64 {
+	return calculateCreditCost(r.jobSpec())
+}
+
+// jobSpec returns a job spec asking for the request's resources with its
+// duration as the timeout.
+func (r *CapacityRequest) jobSpec() *JobSpec {
+	resources := &ResourceSpec{}
+	if r.CPU > 0 {
+		resources.CPU = strconv.Itoa(r.CPU)
+	}
+	if r.MemoryGB > 0 {
+		resources.Memory = trimFloat(r.MemoryGB) + "Gi"
+	}
+	if r.GPUCount > 0 {
+		resources.GPU = strconv.Itoa(r.GPUCount)
+	}
+	if r.StorageGB > 0 {
+		resources.Storage = trimFloat(r.StorageGB) + "Gi"
+	}
+	return &JobSpec{Resources: resources, Timeout: int(math.Ceil(r.Duration.Seconds()))}
+}
+
+// String renders the request in the same compact form it is written in.
+func (r *CapacityRequest) String() string {
+	var parts []string
+	if r.GPUCount > 0 {
+		model := r.GPUModel
+		if model == "" {
+			model = "GPU"
+		}
+		parts = append(parts, fmt.Sprintf("%d×%s", r.GPUCount, model))
+	}
+	if r.CPU > 0 {
+		parts = append(parts, fmt.Sprintf("%d CPU", r.CPU))
+	}
+	if r.MemoryGB > 0 {
+		parts = append(parts, fmt.Sprintf("%.0fGB RAM", r.MemoryGB))
+	}
+	if r.StorageGB > 0 {
+		parts = append(parts, fmt.Sprintf("%.0fGB disk", r.StorageGB))
+	}
+	parts = append(parts, formatDuration(r.Duration))
+	return strings.Join(parts, ", ")
+}
+
+// formatDuration renders a duration in the largest whole unit that fits.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%.1f hours", d.Hours())
+	default:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	}
+}
+
+// regionFit is the outcome of matching a request against the nodes of one region.
+type regionFit struct {
+	region   RegionCapacity
+	feasible bool
+	// node is the online node in the region that can satisfy the most of the
+	// request, nil when the region has none
+	node *Node
+	// score is the average fraction of each requested dimension node can satisfy
+	score float64
+	// gpuModel is the GPU model that would be used in this region
+	gpuModel   string
+	shortfalls []string
+}
+
+// fitRegion checks whether one online node of a region can run the request,
+// since a job runs on a single node however much the region has free in total.
+func fitRegion(req *CapacityRequest, region RegionCapacity, nodes []Node) regionFit {
+	best := regionFit{region: region}
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Status != NodeStatusOnline || node.Resources == nil ||
+			!strings.EqualFold(node.Labels[NodeRegionLabel], region.Region) {
+			continue
+		}
+		if fit := fitNode(req, region, node); best.node == nil || fit.score > best.score {
+			best = fit
+		}
+	}
+	if best.node == nil {
+		best.shortfalls = []string{"no online nodes"}
+	}
+	return best
+}
+
+// fitNode checks whether a node can run the request.
+func fitNode(req *CapacityRequest, region RegionCapacity, node *Node) regionFit {
+	fit := regionFit{region: region, node: node, feasible: true}
+	var fractions []float64
+
+	check := func(name string, need, have float64) {
+		if need <= 0 {
+			return
+		}
+		if have >= need {
+			fractions = append(fractions, 1)
+			return
+		}
+		fit.feasible = false
+		fractions = append(fractions, have/need)
+		fit.shortfalls = append(fit.shortfalls, fmt.Sprintf("%s per node: need %s, have %s", name, trimFloat(need), trimFloat(have)))
+	}
+
+	res := node.Resources
+	check("CPU cores", float64(req.CPU), float64(res.CPU))
+	check("memory GB", req.MemoryGB, quantityGB(res.Memory))
+	check("storage GB", req.StorageGB, quantityGB(res.Storage))
+
+	if req.GPUCount > 0 {
+		name, gpus := "GPUs", res.GPU
+		if req.GPUModel != "" {
+			name = req.GPUModel + " GPUs"
+			if !strings.EqualFold(res.GPUModel, req.GPUModel) {
+				gpus = 0
+			}
+		}
+		if gpus > 0 {
+			fit.gpuModel = res.GPUModel
+		}
+		check(name, float64(req.GPUCount), float64(gpus))
+	}
+
+	for _, f := range fractions {
+		fit.score += f
+	}
+	if len(fractions) > 0 {
+		fit.score /= float64(len(fractions))
+	}
+	return fit
+}
+
+// closestAlternative scales the request down to what the fit's node can offer.
+func closestAlternative(req *CapacityRequest, fit regionFit) *CapacityRequest {
+	alt := *req
+	res := fit.node.Resources
+	alt.CPU = min(alt.CPU, res.CPU)
+	alt.MemoryGB = min(alt.MemoryGB, quantityGB(res.Memory))
+	alt.StorageGB = min(alt.StorageGB, quantityGB(res.Storage))
+	if alt.GPUCount > 0 {
+		// Offer the node's GPU model when the requested one is not available
+		alt.GPUModel = res.GPUModel
+		alt.GPUCount = min(alt.GPUCount, res.GPU)
+	}
+	return &alt
+}
+
+// trimFloat formats a float without trailing zeros.
+func trimFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// CanRunTool answers whether the network can run a workload right now.
+type CanRunTool struct {
+	client *Client
+}
+
+// NewCanRunTool creates a new capacity feasibility tool.
+func NewCanRunTool(client *Client) *CanRunTool {
+	return &CanRunTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *CanRunTool) Name() string {
+	return "deparrow_can_run"
+}
+
+// Description returns the tool description.
+func (t *CanRunTool) Description() string {
+	return `Check whether the DEparrow network can run a workload right now.
+
+Describe the resources in plain language, for example:
+  "8×A100, 2TB disk, 3 days"
+  "16 cpu, 64GB RAM, 2 hours"
+
+The answer includes which regions can run it, the estimated credit cost,
+and the closest feasible alternative when nothing fits.
+`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *CanRunTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"resources": map[string]interface{}{
+				"type":        "string",
+				"description": "Plain resource description (e.g., '8×A100, 2TB disk, 3 days')",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Only consider this region (optional)",
+			},
+		},
+		"required": []string{"resources"},
+	}
+}
+
+// Execute runs the capacity feasibility tool.
+func (t *CanRunTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	desc, ok := args["resources"].(string)
+	if !ok || strings.TrimSpace(desc) == "" {
+		return tools.ErrorResult("resources parameter is required")
+	}
+
+	req, err := ParseCapacityRequest(desc)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to understand resources: %v", err))
+	}
+
+	capacity, err := t.client.GetNetworkCapacity(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get network capacity: %v", err))
+	}
+
+	nodes, err := t.client.ListNodes(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to list nodes: %v", err))
+	}
+
+	regionFilter, _ := args["region"].(string)
+
+	var fits []regionFit
+	for _, region := range capacity.Regions {
+		if regionFilter != "" && !strings.EqualFold(region.Region, regionFilter) {
+			continue
+		}
+		fits = append(fits, fitRegion(req, region, nodes))
+	}
+
+	if len(fits) == 0 {
+		if regionFilter != "" {
+			return tools.UserResult(fmt.Sprintf("No capacity data for region %s.", regionFilter))
+		}
+		return tools.UserResult("No capacity data is available for the network right now.")
+	}
+
+	// Rank by how much of the request each region can satisfy, preferred region first on ties
+	preferred := t.client.Preferences().DefaultRegion
+	sort.SliceStable(fits, func(i, j int) bool {
+		if fits[i].score != fits[j].score {
+			return fits[i].score > fits[j].score
+		}
+		return strings.EqualFold(fits[i].region.Region, preferred)
+	})
+
+	var result strings.Builder
+	result.WriteString("🔎 DEparrow Capacity Check\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(fmt.Sprintf("Requested: %s\n", req))
+	result.WriteString(fmt.Sprintf("Estimated Cost: %.2f credits\n\n", req.EstimateCost()))
+
+	var feasible []regionFit
+	for _, fit := range fits {
+		if fit.feasible {
+			feasible = append(feasible, fit)
+		}
+	}
+
+	if len(feasible) > 0 {
+		result.WriteString(fmt.Sprintf("✅ Yes, this can run now in %d region(s):\n", len(feasible)))
+		for _, fit := range feasible {
+			result.WriteString(fmt.Sprintf("  • %s (%d nodes online", fit.region.Region, fit.region.OnlineNodes))
+			if fit.gpuModel != "" {
+				result.WriteString(fmt.Sprintf(", GPU: %s", fit.gpuModel))
+			}
+			result.WriteString(")")
+			if preferred != "" && strings.EqualFold(fit.region.Region, preferred) {
+				result.WriteString(" ⭐ your default region")
+			}
+			result.WriteString("\n")
+		}
+		return tools.UserResult(result.String())
+	}
+
+	best := fits[0]
+	result.WriteString("❌ The network cannot run this right now.\n\n")
+	result.WriteString(fmt.Sprintf("Closest region: %s\n", best.region.Region))
+	for _, s := range best.shortfalls {
+		result.WriteString(fmt.Sprintf("  • %s\n", s))
+	}
+	if best.node == nil {
+		return tools.UserResult(result.String())
+	}
+
+	alt := closestAlternative(req, best)
+	result.WriteString("\n💡 Closest feasible alternative:\n")
+	result.WriteString(fmt.Sprintf("  %s in %s\n", alt, best.region.Region))
+	result.WriteString(fmt.Sprintf("  Estimated Cost: %.2f credits\n", alt.EstimateCost()))
+
+	return tools.UserResult(result.String())
+}
+
+// Ensure tools implement the Tool interface
+var _ tools.Tool = (*CanRunTool)(nil)
@@ -0,0 +1,278 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseCapacityRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		desc    string
+		want    CapacityRequest
+		wantErr bool
+	}{
+		{
+			name: "gpu disk and days",
+			desc: "8×A100, 2TB disk, 3 days",
+			want: CapacityRequest{GPUCount: 8, GPUModel: "A100", StorageGB: 2048, Duration: 72 * time.Hour},
+		},
+		{
+			name: "cpu and memory",
+			desc: "16 cpu, 64GB RAM, 2 hours",
+			want: CapacityRequest{CPU: 16, MemoryGB: 64, Duration: 2 * time.Hour},
+		},
+		{
+			name: "generic gpu with and",
+			desc: "2 gpus and 30 minutes",
+			want: CapacityRequest{GPUCount: 2, Duration: 30 * time.Minute},
+		},
+		{
+			name: "default duration",
+			desc: "4 x h100",
+			want: CapacityRequest{GPUCount: 4, GPUModel: "H100", Duration: time.Hour},
+		},
+		{
+			name:    "unknown unit",
+			desc:    "3 bananas",
+			wantErr: true,
+		},
+		{
+			name:    "word starting like a gpu model",
+			desc:    "2 arcs",
+			wantErr: true,
+		},
+		{
+			name:    "no resources",
+			desc:    "5 days",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCapacityRequest(tt.desc)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseCapacityRequest(%q) expected error, got %+v", tt.desc, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCapacityRequest(%q) unexpected error: %v", tt.desc, err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseCapacityRequest(%q) = %+v, want %+v", tt.desc, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsGPUModel(t *testing.T) {
+	for _, token := range []string{"a100", "H100", "rtx4090", "mi250", "mi300x", "t4"} {
+		if !isGPUModel(token) {
+			t.Errorf("isGPUModel(%q) = false, want true", token)
+		}
+	}
+	for _, token := range []string{"mi", "arc", "miles", "archives", "a1000", "t4s"} {
+		if isGPUModel(token) {
+			t.Errorf("isGPUModel(%q) = true, want false", token)
+		}
+	}
+}
+
+func TestCapacityRequest_EstimateCost(t *testing.T) {
+	req := &CapacityRequest{CPU: 2, GPUCount: 1, MemoryGB: 10, Duration: 2 * time.Hour}
+
+	// Priced like the job it describes
+	spec := &JobSpec{Resources: &ResourceSpec{CPU: "2", Memory: "10Gi", GPU: "1"}, Timeout: 7200}
+	if got, want := req.EstimateCost(), calculateCreditCost(spec); got != want {
+		t.Errorf("EstimateCost() = %v, want %v", got, want)
+	}
+}
+
+func newCapacityServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/nodes" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"nodes": capacityNodes()})
+			return
+		}
+		if r.URL.Path != "/api/v1/network/capacity" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"regions": []map[string]interface{}{
+				{
+					"region":               "us-east",
+					"online_nodes":         12,
+					"available_cpu_cores":  256,
+					"available_memory_gb":  1024,
+					"available_storage_gb": 8192,
+					"gpus": []map[string]interface{}{
+						{"model": "A100", "available": 16, "max_per_node": 8},
+					},
+				},
+				{
+					"region":               "eu-west",
+					"online_nodes":         4,
+					"available_cpu_cores":  64,
+					"available_memory_gb":  256,
+					"available_storage_gb": 1024,
+					"gpus": []map[string]interface{}{
+						{"model": "A100", "available": 4, "max_per_node": 4},
+					},
+				},
+			},
+		})
+	}))
+}
+
+// capacityNodes returns the nodes behind newCapacityServer's regions: the
+// us-east GPUs are split over two nodes and its CPUs over four, eu-west has
+// one node with four GPUs.
+func capacityNodes() []Node {
+	node := func(id, region string, cpu int, memory, storage string, gpus int) Node {
+		n := Node{
+			ID:        id,
+			Status:    NodeStatusOnline,
+			Resources: &NodeResources{CPU: cpu, Memory: memory, Storage: storage, GPU: gpus},
+			Labels:    map[string]string{NodeRegionLabel: region},
+		}
+		if gpus > 0 {
+			n.Resources.GPUModel = "A100"
+		}
+		return n
+	}
+	return []Node{
+		node("use-1", "us-east", 64, "256Gi", "4Ti", 8),
+		node("use-2", "us-east", 64, "256Gi", "2Ti", 8),
+		node("use-3", "us-east", 64, "256Gi", "1Ti", 0),
+		node("use-4", "us-east", 64, "256Gi", "1Ti", 0),
+		node("euw-1", "eu-west", 64, "256Gi", "1Ti", 4),
+	}
+}
+
+func TestClient_GetNetworkCapacity(t *testing.T) {
+	server := newCapacityServer(t)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	capacity, err := client.GetNetworkCapacity(context.Background())
+	if err != nil {
+		t.Fatalf("GetNetworkCapacity() error = %v", err)
+	}
+	if len(capacity.Regions) != 2 {
+		t.Fatalf("Regions = %d, want 2", len(capacity.Regions))
+	}
+	if capacity.Regions[0].GPUs[0].MaxPerNode != 8 {
+		t.Errorf("MaxPerNode = %d, want 8", capacity.Regions[0].GPUs[0].MaxPerNode)
+	}
+}
+
+func TestCanRunTool_Name(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	tool := NewCanRunTool(client)
+
+	if tool.Name() != "deparrow_can_run" {
+		t.Errorf("Name() = %s, want deparrow_can_run", tool.Name())
+	}
+}
+
+func TestCanRunTool_Execute_Feasible(t *testing.T) {
+	server := newCapacityServer(t)
+	defer server.Close()
+
+	tool := NewCanRunTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"resources": "8×A100, 2TB disk, 3 days",
+	})
+
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	if !contains(result.ForLLM, "Yes, this can run now in 1 region") {
+		t.Errorf("expected feasible answer, got:\n%s", result.ForLLM)
+	}
+	if !contains(result.ForLLM, "us-east") {
+		t.Error("expected us-east in result")
+	}
+	if contains(result.ForLLM, "eu-west") {
+		t.Error("eu-west cannot fit 8 GPUs on one node and should not be listed")
+	}
+}
+
+func TestCanRunTool_Execute_Alternative(t *testing.T) {
+	server := newCapacityServer(t)
+	defer server.Close()
+
+	tool := NewCanRunTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"resources": "8×A100, 1 day",
+		"region":    "eu-west",
+	})
+
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	if !contains(result.ForLLM, "cannot run this right now") {
+		t.Errorf("expected infeasible answer, got:\n%s", result.ForLLM)
+	}
+	if !contains(result.ForLLM, "4×A100") {
+		t.Errorf("expected 4×A100 alternative, got:\n%s", result.ForLLM)
+	}
+}
+
+func TestCanRunTool_Execute_PerNode(t *testing.T) {
+	server := newCapacityServer(t)
+	defer server.Close()
+
+	// us-east has 256 free CPU cores, but no more than 64 on one node
+	tool := NewCanRunTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"resources": "200 cpu, 1 hour",
+		"region":    "us-east",
+	})
+
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	for _, want := range []string{"cannot run this right now", "CPU cores per node: need 200, have 64", "64 CPU, 1.0 hours in us-east"} {
+		if !contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestCanRunTool_Execute_MissingResources(t *testing.T) {
+	tool := NewCanRunTool(NewClient("http://localhost:8080", "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{})
+
+	if !result.IsError {
+		t.Error("expected error for missing resources")
+	}
+}
+
+func TestCanRunTool_Execute_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "capacity unavailable"})
+	}))
+	defer server.Close()
+
+	tool := NewCanRunTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"resources": "4 cpu",
+	})
+
+	if !result.IsError {
+		t.Error("expected error result on API failure")
+	}
+}
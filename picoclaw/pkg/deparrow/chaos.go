@@ -0,0 +1,462 @@
+package deparrow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// FaultKind is the kind of failure a Fault injects.
+type FaultKind string
+
+const (
+	// FaultStatus answers with an error status without reaching the server
+	FaultStatus FaultKind = "status"
+	// FaultTimeout holds the request for the fault's Delay, then fails it
+	// as timed out
+	FaultTimeout FaultKind = "timeout"
+	// FaultPartialJSON lets the request through and cuts the response body
+	// off halfway
+	FaultPartialJSON FaultKind = "partial_json"
+)
+
+// Fault is a failure a ChaosTransport injects into the requests it
+// matches.
+type Fault struct {
+	Kind FaultKind
+	// Method the request must have; empty matches any
+	Method string
+	// Start of the request path, e.g. "/api/v1/jobs"; empty matches any
+	PathPrefix string
+	// Matching requests let through before the fault starts
+	After int
+	// Matching requests failed once it has started; 0 fails all of them
+	Times int
+	// Status a FaultStatus answers with; defaults to 500
+	Status int
+	// Body a FaultStatus answers with, e.g. an error payload with an
+	// error_code; defaults to one naming the status
+	Body string
+	// How long a FaultTimeout holds the request
+	Delay time.Duration
+}
+
+// matches reports whether the fault applies to the request.
+func (f *Fault) matches(req *http.Request) bool {
+	return (f.Method == "" || strings.EqualFold(f.Method, req.Method)) &&
+		strings.HasPrefix(req.URL.Path, f.PathPrefix)
+}
+
+// chaosFault is a fault with the requests it has seen and failed.
+type chaosFault struct {
+	Fault
+	seen   int
+	failed int
+}
+
+// chaosTimeoutError is the error of a request failed by a FaultTimeout. It
+// is a net.Error, like the timeouts of a real transport.
+type chaosTimeoutError struct{}
+
+func (chaosTimeoutError) Error() string   { return "chaos: request timed out" }
+func (chaosTimeoutError) Timeout() bool   { return true }
+func (chaosTimeoutError) Temporary() bool { return true }
+
+// ChaosTransport is an http.RoundTripper that injects faults into the
+// requests it passes on to another transport, to test how clients and
+// agents cope with a failing API.
+type ChaosTransport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	faults   []*chaosFault
+	injected int
+}
+
+// NewChaosTransport creates a transport injecting the faults into the
+// requests it passes on to base; nil uses http.DefaultTransport.
+func NewChaosTransport(base http.RoundTripper, faults ...Fault) *ChaosTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &ChaosTransport{base: base}
+	t.SetFaults(faults...)
+	return t
+}
+
+// SetFaults replaces the faults in force; none lets every request
+// through. The first fault matching a request is the one applied.
+func (t *ChaosTransport) SetFaults(faults ...Fault) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.faults = make([]*chaosFault, len(faults))
+	for i, f := range faults {
+		t.faults[i] = &chaosFault{Fault: f}
+	}
+}
+
+// Injected returns how many requests the faults have failed.
+func (t *ChaosTransport) Injected() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.injected
+}
+
+// next returns the fault to inject into the request, if any.
+func (t *ChaosTransport) next(req *http.Request) *Fault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, f := range t.faults {
+		if !f.matches(req) {
+			continue
+		}
+		f.seen++
+		if f.seen <= f.After || f.Times > 0 && f.failed >= f.Times {
+			return nil
+		}
+		f.failed++
+		t.injected++
+		fault := f.Fault
+		return &fault
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault := t.next(req)
+	if fault == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	switch fault.Kind {
+	case FaultPartialJSON:
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		closeBody(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body[:len(body)/2]))
+		resp.ContentLength = -1
+		resp.Header.Del("Content-Length")
+		return resp, nil
+
+	case FaultTimeout:
+		if req.Body != nil {
+			closeBody(req.Body)
+		}
+		if err := sleepContext(req.Context(), fault.Delay); err != nil {
+			return nil, err
+		}
+		return nil, chaosTimeoutError{}
+
+	default:
+		if req.Body != nil {
+			closeBody(req.Body)
+		}
+		status := fault.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		body := fault.Body
+		if body == "" {
+			body = fmt.Sprintf(`{"error": %q}`, http.StatusText(status))
+		}
+		return &http.Response{
+			StatusCode:    status,
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+}
+
+// ChaosStep is one tool call of a ChaosScenario.
+type ChaosStep struct {
+	// Name of the deparrow tool to call, e.g. "deparrow_submit_job"
+	Tool string
+	Args map[string]interface{}
+	// Faults in force while the step runs; none lets every request through
+	Faults []Fault
+	// WantError expects the call to fail with an actionable message
+	WantError bool
+	// Text the result must contain, such as the tool a hint points to
+	WantContains []string
+}
+
+// ChaosScenario is a sequence of tool calls an agent might make while the
+// API fails under it.
+type ChaosScenario struct {
+	Name        string
+	Description string
+	Steps       []ChaosStep
+}
+
+// ChaosStepResult is the outcome of one step of a scenario.
+type ChaosStepResult struct {
+	Step   ChaosStep
+	Result *tools.ToolResult
+	// Requests the faults failed during the step
+	Injected int
+	// What is wrong with the result; empty when it is as expected
+	Problems []string
+}
+
+// ChaosReport is the outcome of running a scenario.
+type ChaosReport struct {
+	Scenario string
+	Steps    []ChaosStepResult
+}
+
+// Passed reports whether every step turned out as expected.
+func (r *ChaosReport) Passed() bool {
+	for _, step := range r.Steps {
+		if len(step.Problems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String lists the problems of each failed step.
+func (r *ChaosReport) String() string {
+	if r.Passed() {
+		return fmt.Sprintf("scenario %s passed", r.Scenario)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "scenario %s failed:", r.Scenario)
+	for i, step := range r.Steps {
+		for _, problem := range step.Problems {
+			fmt.Fprintf(&b, "\n  step %d (%s): %s", i+1, step.Step.Tool, problem)
+		}
+	}
+	return b.String()
+}
+
+// ChaosHarness runs the deparrow tools of a client through a
+// ChaosTransport, so downstream agents can check in CI that what the tools
+// tell them while the API fails is something they can act on.
+//
+// Example:
+//
+//	for _, scenario := range deparrow.ChaosScenarios() {
+//	    harness := deparrow.NewChaosHarness(deparrow.NewSandboxClient())
+//	    if report := harness.Run(ctx, scenario); !report.Passed() {
+//	        t.Error(report)
+//	    }
+//	}
+type ChaosHarness struct {
+	transport *ChaosTransport
+	tools     map[string]tools.Tool
+}
+
+// NewChaosHarness routes the client's requests through a ChaosTransport.
+// The client's http.Client is replaced by a copy, so a shared one is left
+// alone.
+func NewChaosHarness(client *Client) *ChaosHarness {
+	transport := NewChaosTransport(client.httpClient.Transport)
+	client.httpClient = &http.Client{
+		Timeout:   client.httpClient.Timeout,
+		Transport: transport,
+	}
+
+	h := &ChaosHarness{transport: transport, tools: make(map[string]tools.Tool)}
+	for _, tool := range NewToolsProvider(client).GetAllTools() {
+		h.tools[tool.Name()] = tool
+	}
+	return h
+}
+
+// Transport returns the transport the client's requests go through, for
+// injecting faults around calls made outside a scenario.
+func (h *ChaosHarness) Transport() *ChaosTransport {
+	return h.transport
+}
+
+// Run runs the scenario's steps in order, each with its own faults in
+// force, and reports how each one turned out. A step that panics is
+// reported rather than stopping the run.
+func (h *ChaosHarness) Run(ctx context.Context, scenario ChaosScenario) *ChaosReport {
+	report := &ChaosReport{Scenario: scenario.Name}
+	for _, step := range scenario.Steps {
+		report.Steps = append(report.Steps, h.runStep(ctx, step))
+	}
+	h.transport.SetFaults()
+	return report
+}
+
+// runStep calls the step's tool with its faults in force.
+func (h *ChaosHarness) runStep(ctx context.Context, step ChaosStep) (result ChaosStepResult) {
+	result.Step = step
+	tool, ok := h.tools[step.Tool]
+	if !ok {
+		result.Problems = []string{fmt.Sprintf("unknown tool %q", step.Tool)}
+		return result
+	}
+
+	h.transport.SetFaults(step.Faults...)
+	before := h.transport.Injected()
+	defer func() {
+		result.Injected = h.transport.Injected() - before
+		if r := recover(); r != nil {
+			result.Problems = []string{fmt.Sprintf("the tool panicked: %v", r)}
+			return
+		}
+		result.Problems = step.problems(result.Result, result.Injected)
+	}()
+
+	args := step.Args
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	result.Result = tool.Execute(ctx, args)
+	return result
+}
+
+// problems returns what is wrong with the result of the step.
+func (s *ChaosStep) problems(result *tools.ToolResult, injected int) []string {
+	if result == nil {
+		return []string{"the tool returned no result"}
+	}
+
+	var problems []string
+	switch {
+	case s.WantError:
+		if len(s.Faults) > 0 && injected == 0 {
+			problems = append(problems, "no fault was injected; the step never reached the API")
+		}
+		problems = append(problems, ActionableProblems(result)...)
+	case result.IsError:
+		problems = append(problems, fmt.Sprintf("the tool failed: %s", chaosMessage(result)))
+	}
+	for _, want := range s.WantContains {
+		if !strings.Contains(result.ForLLM, want) {
+			problems = append(problems, fmt.Sprintf("the result does not mention %q: %s", want, chaosMessage(result)))
+		}
+	}
+	return problems
+}
+
+// chaosLeaks are fragments that only show up in messages nobody wrote for
+// an agent to read.
+var chaosLeaks = []string{"%!", "<nil>", "panic:", "goroutine "}
+
+// ActionableProblems returns what keeps a failed tool result from being
+// something an agent can act on: it must be reported as an error, say
+// what failed and follow that with a hint on what to do next, and must
+// not show formatting mistakes or Go internals. None are returned for an
+// actionable result.
+func ActionableProblems(result *tools.ToolResult) []string {
+	if result == nil {
+		return []string{"the tool returned no result"}
+	}
+	msg := chaosMessage(result)
+
+	var problems []string
+	if !result.IsError {
+		problems = append(problems, "the failure was reported as a success")
+	}
+	if msg == "" {
+		return append(problems, "the message is empty")
+	}
+	if !strings.Contains(msg, "\n") {
+		problems = append(problems, fmt.Sprintf("the message gives no hint on what to do next: %q", msg))
+	}
+	for _, leak := range chaosLeaks {
+		if strings.Contains(msg, leak) {
+			problems = append(problems, fmt.Sprintf("the message shows %q: %q", leak, msg))
+		}
+	}
+	return problems
+}
+
+// chaosMessage returns the message of a result without the sandbox
+// watermark.
+func chaosMessage(result *tools.ToolResult) string {
+	msg := strings.TrimPrefix(result.ForLLM, SandboxWatermark)
+	return strings.TrimSpace(msg)
+}
+
+// insufficientCreditsBody is the error payload of a Meta-OS turning a
+// submission down for lack of credits.
+const insufficientCreditsBody = `{"error": "insufficient credits", "error_code": "` + ErrorCodeInsufficientCredits + `"}`
+
+// ScenarioAPIOutageMidPipeline submits a job, then loses the API while
+// checking on it and submitting the next one: first to server errors, then
+// to cut-off responses and timeouts, before it recovers.
+func ScenarioAPIOutageMidPipeline() ChaosScenario {
+	outage := []Fault{{Kind: FaultStatus, Status: http.StatusInternalServerError}}
+	job := map[string]interface{}{"image": "alpine"}
+	return ChaosScenario{
+		Name:        "api_outage_mid_pipeline",
+		Description: "The API goes down after the first job of a pipeline is submitted",
+		Steps: []ChaosStep{
+			{Tool: "deparrow_submit_job", Args: job},
+			{Tool: "deparrow_list_jobs", Faults: outage, WantError: true, WantContains: []string{"try again"}},
+			{Tool: "deparrow_submit_job", Args: job, Faults: outage, WantError: true, WantContains: []string{"deparrow_list_jobs"}},
+			{
+				Tool:         "deparrow_list_jobs",
+				Faults:       []Fault{{Kind: FaultPartialJSON, PathPrefix: "/api/v1/jobs"}},
+				WantError:    true,
+				WantContains: []string{"try again"},
+			},
+			{
+				Tool:         "deparrow_list_jobs",
+				Faults:       []Fault{{Kind: FaultTimeout, PathPrefix: "/api/v1/jobs"}},
+				WantError:    true,
+				WantContains: []string{"deparrow_health"},
+			},
+			{Tool: "deparrow_list_jobs"},
+		},
+	}
+}
+
+// ScenarioCreditExhaustionMidSweep submits a parameter sweep whose credits
+// run out part of the way through.
+func ScenarioCreditExhaustionMidSweep() ChaosScenario {
+	exhausted := []Fault{{
+		Kind:       FaultStatus,
+		Method:     http.MethodPost,
+		PathPrefix: "/api/v1/jobs/submit",
+		Status:     http.StatusPaymentRequired,
+		Body:       insufficientCreditsBody,
+	}}
+	sweep := func(cpu string) map[string]interface{} {
+		return map[string]interface{}{"image": "alpine", "cpu": cpu}
+	}
+	return ChaosScenario{
+		Name:        "credit_exhaustion_mid_sweep",
+		Description: "Credits run out while the jobs of a sweep are being submitted",
+		Steps: []ChaosStep{
+			{Tool: "deparrow_submit_job", Args: sweep("1")},
+			{Tool: "deparrow_submit_job", Args: sweep("2")},
+			{Tool: "deparrow_submit_job", Args: sweep("4"), Faults: exhausted, WantError: true, WantContains: []string{"deparrow_credits", "deparrow_wallet"}},
+			{Tool: "deparrow_submit_job", Args: sweep("8"), Faults: exhausted, WantError: true, WantContains: []string{"deparrow_credits"}},
+			{Tool: "deparrow_credits", Args: map[string]interface{}{"action": "balance"}},
+		},
+	}
+}
+
+// ChaosScenarios returns the canned scenarios.
+func ChaosScenarios() []ChaosScenario {
+	return []ChaosScenario{
+		ScenarioAPIOutageMidPipeline(),
+		ScenarioCreditExhaustionMidSweep(),
+	}
+}
@@ -0,0 +1,104 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func TestChaosScenarios_Sandbox(t *testing.T) {
+	for _, scenario := range ChaosScenarios() {
+		t.Run(scenario.Name, func(t *testing.T) {
+			client := NewSandboxClient(WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+			report := NewChaosHarness(client).Run(context.Background(), scenario)
+			if !report.Passed() {
+				t.Fatal(report)
+			}
+			if len(report.Steps) != len(scenario.Steps) {
+				t.Errorf("%d steps reported, want %d", len(report.Steps), len(scenario.Steps))
+			}
+		})
+	}
+}
+
+func TestChaosTransport_AfterAndTimes(t *testing.T) {
+	client := NewSandboxClient(WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	harness := NewChaosHarness(client)
+	harness.Transport().SetFaults(Fault{Kind: FaultStatus, Method: http.MethodGet, PathPrefix: "/api/v1/credits", After: 1, Times: 1})
+
+	ctx := context.Background()
+	var errs []error
+	for range 3 {
+		_, err := client.GetCredits(ctx)
+		errs = append(errs, err)
+	}
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("errors = %v, want only the second request failed", errs)
+	}
+	var apiErr *APIError
+	if !errors.As(errs[1], &apiErr) || apiErr.Code != http.StatusInternalServerError {
+		t.Errorf("second error = %v, want a 500", errs[1])
+	}
+	if n := harness.Transport().Injected(); n != 1 {
+		t.Errorf("Injected() = %d, want 1", n)
+	}
+}
+
+func TestChaosTransport_PartialJSON(t *testing.T) {
+	client := NewSandboxClient()
+	harness := NewChaosHarness(client)
+	harness.Transport().SetFaults(Fault{Kind: FaultPartialJSON})
+
+	if _, err := client.GetCredits(context.Background()); !errors.Is(err, ErrMalformedResponse) {
+		t.Errorf("GetCredits() error = %v, want ErrMalformedResponse", err)
+	}
+}
+
+func TestChaosHarness_ReportsProblems(t *testing.T) {
+	harness := NewChaosHarness(NewSandboxClient())
+	report := harness.Run(context.Background(), ChaosScenario{Name: "broken", Steps: []ChaosStep{
+		{Tool: "deparrow_missing"},
+		// A fault the call never reaches
+		{Tool: "deparrow_list_jobs", Faults: []Fault{{Kind: FaultStatus, PathPrefix: "/api/v1/nodes"}}, WantError: true},
+		{Tool: "deparrow_list_jobs", Faults: []Fault{{Kind: FaultStatus}}},
+	}})
+	if report.Passed() {
+		t.Fatal("report should fail")
+	}
+	for i, want := range []string{"unknown tool", "no fault was injected", "the tool failed"} {
+		if problems := strings.Join(report.Steps[i].Problems, "; "); !strings.Contains(problems, want) {
+			t.Errorf("step %d problems = %q, want %q", i+1, problems, want)
+		}
+	}
+	if !strings.Contains(report.String(), "step 3 (deparrow_list_jobs)") {
+		t.Errorf("String() = %s", report)
+	}
+}
+
+func TestActionableProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *tools.ToolResult
+		want   string
+	}{
+		{"actionable", tools.ErrorResult("Failed to list jobs: boom\nTry again shortly."), ""},
+		{"success", tools.NewToolResult("Jobs: none"), "reported as a success"},
+		{"no hint", tools.ErrorResult("Failed to list jobs: boom"), "no hint"},
+		{"formatting", tools.ErrorResult("Failed to list jobs: %!v(MISSING)\nTry again."), `"%!"`},
+		{"nil", nil, "no result"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := strings.Join(ActionableProblems(tt.result), "; ")
+			if tt.want == "" && problems != "" || !strings.Contains(problems, tt.want) {
+				t.Errorf("ActionableProblems() = %q, want %q", problems, tt.want)
+			}
+		})
+	}
+}
@@ -8,7 +8,17 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow/deparrowpb"
 )
 
 // Client is the HTTP client for the DEparrow Meta-OS API.
@@ -16,12 +26,83 @@ import (
 type Client struct {
 	// Base URL for the Meta-OS API (e.g., "http://localhost:8080")
 	baseURL string
-	// JWT token for authentication
-	jwtToken string
+	// Base URLs of read replicas serving heavy read-only requests
+	readURLs []string
+	// Index of the read replica the next replica-served read goes to
+	nextReplica atomic.Uint64
+	// Supplies the bearer token for authentication (nil for none)
+	tokens TokenProvider
+	// API key sent instead of the JWT token when set
+	apiKey string
 	// HTTP client with configurable timeout
 	httpClient *http.Client
 	// User ID extracted from JWT (set after authentication)
 	userID string
+	// Preferences store consulted by tools for default values
+	preferences *PreferencesStore
+	// Ledger of node earnings readings used for reconciliation
+	earnings *EarningsLedger
+	// Saved job templates
+	templates *TemplateStore
+	// Simulated Meta-OS serving requests in sandbox mode (nil otherwise)
+	sandbox *MockMetaOSServer
+	// User-Agent sent with every request
+	userAgent string
+	// Set while tools must not spend credits
+	spendingPause atomic.Pointer[SpendingPause]
+	// Retry policy for requests whose context does not override it
+	retryPolicy RetryPolicy
+	// Jobs submitted at once when SubmitJobs falls back to single submissions
+	batchConcurrency int
+	// Set once the server turned out to have no batch endpoint
+	batchUnsupported atomic.Bool
+	// Set once the server turned out to have no estimate endpoint
+	estimateUnsupported atomic.Bool
+	// IPFS gateway job outputs are fetched from by CID ("" for none)
+	ipfsGateway string
+	// IPFS RPC API staged inputs are added through
+	ipfsAPI string
+	// Bytes of a job's stdout or stderr the tools show (0 for all)
+	outputLimit int
+	// Directory the full output of truncated streams is saved in
+	artifactDir string
+	// Smooths bursts of requests (nil for no limit)
+	limiter *tokenBucket
+	// Requests are held back until then (Unix nanoseconds) after a 429
+	pausedUntil atomic.Int64
+	// Concurrency slots of capped endpoints, by path prefix
+	endpointSlots map[string]chan struct{}
+	// What the server advertised about itself, once asked. Asking holds
+	// discoverMu, so tools reading it don't wait on the request.
+	discoverMu   sync.Mutex
+	serverInfoMu sync.Mutex
+	serverInfo   *ServerInfo
+	// Told about every request and credit spent (nil for none)
+	metrics MetricsHook
+	// Starts request spans (nil for the global tracer provider)
+	tracer trace.Tracer
+	// Told about every request and response (nil for none)
+	logger Logger
+	// Body bytes the logger is shown (0 for none)
+	debugBodyLimit int
+	// Real-time features long-poll instead of using WebSockets
+	longPoll bool
+	// Transfers above this many credits wait for approval (0 for the
+	// server's threshold only)
+	approvalThreshold float64
+	// Transport the endpoints the gRPC API mirrors are called over
+	transport Transport
+	// Address of the gRPC API ("" for the base URL's host) and extra
+	// options for dialling it
+	grpcAddress     string
+	grpcDialOptions []grpc.DialOption
+	// Connection to the gRPC API, made on first use
+	grpcOnce sync.Once
+	grpcConn *grpc.ClientConn
+	grpcErr  error
+	// Worker pools the worker pool tool keeps, by name
+	workerPoolsMu sync.Mutex
+	workerPools   map[string]*WorkerPool
 }
 
 // ClientOption is a functional option for configuring the Client.
@@ -42,7 +123,8 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 }
 
 // NewClient creates a new DEparrow API client.
-// The jwtToken is required for authenticated endpoints.
+// The jwtToken is required for authenticated endpoints, unless
+// WithTokenProvider or WithAPIKey supplies the credentials.
 //
 // Example:
 //
@@ -50,15 +132,26 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 func NewClient(apiURL, jwtToken string, opts ...ClientOption) *Client {
 	c := &Client{
 		baseURL:   apiURL,
-		jwtToken:  jwtToken,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		userAgent:   DefaultUserAgent(),
+		ipfsGateway: DefaultIPFSGateway,
+		ipfsAPI:     DefaultIPFSAPI,
+		outputLimit: DefaultOutputLimit,
+		artifactDir: filepath.Join(os.TempDir(), "deparrow-artifacts"),
+	}
+
+	if jwtToken != "" {
+		c.tokens = StaticToken(jwtToken)
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
+	if binder, ok := c.tokens.(clientBinder); ok {
+		binder.bindClient(c)
+	}
 
 	return c
 }
@@ -70,62 +163,165 @@ func (c *Client) SetUserID(userID string) {
 }
 
 // doRequest performs an HTTP request with authentication.
+// The response is decoded straight from the (decompressed) body stream.
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+
+	// Parse successful response; an empty body leaves result untouched
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil && err != io.EOF {
+			return &malformedResponseError{err}
+		}
+	}
+
+	return nil
+}
+
+// send performs an HTTP request with authentication and compression
+// negotiation. On success the returned response body is already
+// decompressed and must be closed by the caller; error statuses are
+// converted to *APIError. Failed requests are retried as the retry policy
+// allows, with the same request ID on every attempt; a request turned away
+// with 429 is sent again once the server's Retry-After has passed; a request whose
+// token is rejected is sent once more with a refreshed one. Reads a replica may
+// serve go to the read replicas when the client has any.
+func (c *Client) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	policy := c.retryPolicyFor(ctx)
+	attempts := policy.attempts(method)
+	if attempts > 1 && RequestIDFromContext(ctx) == "" {
+		ctx = WithRequestID(ctx, uuid.New().String())
+	}
+
+	resent := 0
+	for attempt := 1; ; attempt++ {
+		base := c.baseURLFor(ctx, method, path)
+		resp, err := c.doAuthenticated(c.httpClient, func() (*http.Request, error) {
+			return c.newRequestTo(ctx, base, method, path, body)
+		})
+		if err != nil && base != c.baseURL && ctx.Err() == nil && replicaFailed(err) {
+			// A broken replica must not fail reads the primary can serve
+			resp, err = c.doAuthenticated(c.httpClient, func() (*http.Request, error) {
+				return c.newRequest(ctx, method, path, body)
+			})
+		}
+		if err != nil && ctx.Err() == nil && resendAfterRateLimit(err, resent) {
+			// The pause is waited out before the request goes again
+			resent++
+			attempt--
+			continue
+		}
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !policy.retries(err) {
+			return resp, err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// newRequest builds an authenticated API request to the primary with a
+// JSON body.
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	return c.newRequestTo(ctx, c.baseURL, method, path, body)
+}
+
+// newRequestTo builds an authenticated API request to baseURL with a JSON body.
+func (c *Client) newRequestTo(ctx context.Context, baseURL, method, path string, body interface{}) (*http.Request, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		reqBody = bytes.NewReader(jsonBody)
 	}
 
-	fullURL := c.baseURL + path
+	fullURL := baseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	if c.jwtToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.jwtToken)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	if err := c.setAuthHeaders(req); err != nil {
+		return nil, err
+	}
+	c.setMetadataHeaders(req)
+
+	return req, nil
+}
+
+// do executes req with httpClient, decompresses the response body and
+// converts error statuses to *APIError.
+func (c *Client) do(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	release, err := c.throttle(req)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
-	// Execute request
-	resp, err := c.httpClient.Do(req)
+	span := c.startRequestSpan(req)
+	c.logRequest(req)
+	start := time.Now()
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		c.observeRequest(req, 0, start, err)
+		endRequestSpan(span, 0, err)
+		c.logResponse(req, nil, start, err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
+	c.observeRequest(req, resp.StatusCode, start, nil)
+	endRequestSpan(span, resp.StatusCode, nil)
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+	decoded, err := decodeBody(resp)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		resp.Body.Close()
+		c.logResponse(req, nil, start, err)
+		return nil, err
 	}
+	resp.Body = decoded
+	c.logResponse(req, resp, start, nil)
 
 	// Check for error status codes
 	if resp.StatusCode >= 400 {
-		var apiErr APIError
-		if jsonErr := json.Unmarshal(respBody, &apiErr); jsonErr == nil {
-			apiErr.Code = resp.StatusCode
-			return &apiErr
-		}
-		return &APIError{
-			Code:    resp.StatusCode,
-			Message: string(respBody),
-		}
+		defer closeBody(resp.Body)
+		err := responseError(resp)
+		c.noteRateLimit(err)
+		return nil, err
 	}
 
-	// Parse successful response
-	if result != nil && len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
-		}
+	return resp, nil
+}
+
+// responseError converts an error response to *APIError.
+func responseError(resp *http.Response) error {
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return nil
+	var apiErr APIError
+	if jsonErr := json.Unmarshal(respBody, &apiErr); jsonErr != nil {
+		apiErr = APIError{Message: string(respBody)}
+	}
+	apiErr.Code = resp.StatusCode
+	apiErr.RequestID = resp.Header.Get(HeaderRequestID)
+	apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if apiErr.ErrorCode == "" && resp.Request != nil {
+		apiErr.ErrorCode = inferErrorCode(resp.StatusCode, resp.Request.URL.Path)
+	}
+	return &apiErr
 }
 
 // Health checks the API health status.
@@ -149,74 +345,139 @@ func (c *Client) Health(ctx context.Context) (map[string]interface{}, error) {
 //	    },
 //	})
 func (c *Client) SubmitJob(ctx context.Context, spec *JobSpec) (*Job, error) {
+	return c.SubmitJobTo(ctx, spec, "")
+}
+
+// SubmitJobTo submits a job through a specific orchestrator. An empty
+// orchestratorID lets the network pick the least loaded one.
+func (c *Client) SubmitJobTo(ctx context.Context, spec *JobSpec, orchestratorID string) (*Job, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	features := spec.features()
+	if orchestratorID != "" {
+		features = append(features, requestFeature{"orchestrator", FeatureOrchestratorRouting})
+	}
+	if err := c.requireFeatures(ctx, features); err != nil {
+		return nil, err
+	}
+
 	// Calculate credit cost based on resources
 	creditCost := calculateCreditCost(spec)
+	annotateSpan(ctx, AttrCreditCost.Float64(creditCost))
 
 	req := map[string]interface{}{
 		"spec":         spec,
 		"credit_cost":  creditCost,
 	}
+	if orchestratorID != "" {
+		req["orchestrator"] = orchestratorID
+	}
 
 	var result struct {
 		Status          string    `json:"status"`
 		JobID           string    `json:"job_id"`
 		CreditDeducted  float64   `json:"credit_deducted"`
 		RemainingBalance float64  `json:"remaining_balance"`
+		Orchestrator    string    `json:"orchestrator"`
 		Message         string    `json:"message"`
 	}
 
-	err := c.doRequest(ctx, http.MethodPost, "/api/v1/jobs/submit", req, &result)
+	var err error
+	if c.usesGRPC() {
+		var resp *deparrowpb.SubmitJobResponse
+		resp, err = c.grpcSubmitJob(ctx, spec, creditCost, orchestratorID)
+		result.JobID, result.CreditDeducted, result.Orchestrator = resp.GetJobId(), resp.GetCreditDeducted(), resp.GetOrchestrator()
+	} else {
+		err = c.doRequest(ctx, http.MethodPost, "/api/v1/jobs/submit", req, &result)
+	}
 	if err != nil {
 		return nil, err
 	}
+	c.observeCredits(ctx, result.CreditDeducted)
+	annotateSpan(ctx, AttrJobID.String(result.JobID), AttrCreditCost.Float64(result.CreditDeducted))
 
 	return &Job{
-		ID:          result.JobID,
-		Status:      JobStatusPending,
-		Spec:        spec,
-		CreditCost:  result.CreditDeducted,
-		SubmittedAt: time.Now(),
+		ID:           result.JobID,
+		Status:       JobStatusPending,
+		Spec:         spec,
+		CreditCost:   result.CreditDeducted,
+		SubmittedAt:  time.Now(),
+		Orchestrator: result.Orchestrator,
 	}, nil
 }
 
-// GetJob retrieves the status of a job by ID.
-func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
+// ListOrchestrators lists the orchestrators jobs can be submitted through.
+func (c *Client) ListOrchestrators(ctx context.Context) ([]Orchestrator, error) {
 	var result struct {
-		JobID       string     `json:"job_id"`
-		Status      JobStatus  `json:"status"`
-		UserID      string     `json:"user_id"`
-		CreditCost  float64    `json:"credit_cost"`
-		SubmittedAt time.Time  `json:"submitted_at"`
-		Results     *JobResults `json:"results,omitempty"`
+		Orchestrators []Orchestrator `json:"orchestrators"`
 	}
+	if err := c.doRequest(ctx, http.MethodGet, "/api/v1/orchestrators", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Orchestrators, nil
+}
 
-	err := c.doRequest(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(jobID), nil, &result)
+// GetJob retrieves the status of a job by ID.
+func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	var result jobResponse
+	var err error
+	if c.usesGRPC() {
+		result, err = c.grpcGetJob(ctx, jobID)
+	} else {
+		err = c.doRequest(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(jobID), nil, &result)
+	}
 	if err != nil {
 		return nil, err
 	}
+	annotateSpan(ctx, AttrJobID.String(result.JobID))
+	if result.Results != nil && result.Results.NodeID != "" {
+		annotateSpan(ctx, AttrNodeID.String(result.Results.NodeID))
+	}
+	return result.job(), nil
+}
+
+// jobResponse is a job as the API returns it.
+type jobResponse struct {
+	JobID       string     `json:"job_id"`
+	Status      JobStatus  `json:"status"`
+	UserID      string     `json:"user_id"`
+	CreditCost  float64    `json:"credit_cost"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	Results     *JobResults `json:"results,omitempty"`
+	Evictions   []JobEviction `json:"evictions,omitempty"`
+}
 
+// job converts the response to a Job.
+func (r *jobResponse) job() *Job {
 	return &Job{
-		ID:          result.JobID,
-		UserID:      result.UserID,
-		Status:      result.Status,
-		CreditCost:  result.CreditCost,
-		SubmittedAt: result.SubmittedAt,
-		Results:     result.Results,
-	}, nil
+		ID:          r.JobID,
+		UserID:      r.UserID,
+		Status:      r.Status,
+		CreditCost:  r.CreditCost,
+		SubmittedAt: r.SubmittedAt,
+		Results:     r.Results,
+		Evictions:   r.Evictions,
+	}
 }
 
 // ListJobs lists all jobs for the authenticated user.
+// Jobs are decoded one at a time as the response streams in.
 func (c *Client) ListJobs(ctx context.Context) ([]Job, error) {
-	var result struct {
-		Jobs []Job `json:"jobs"`
-	}
-
-	err := c.doRequest(ctx, http.MethodGet, "/api/v1/jobs", nil, &result)
-	return result.Jobs, err
+	var jobs []Job
+	err := c.EachJob(ctx, func(job Job) error {
+		jobs = append(jobs, job)
+		return nil
+	})
+	return jobs, err
 }
 
 // CancelJob cancels a running job and returns partial credit refund.
 func (c *Client) CancelJob(ctx context.Context, jobID string) (refund float64, err error) {
+	if c.usesGRPC() {
+		return c.grpcCancelJob(ctx, jobID)
+	}
+
 	var result struct {
 		Status           string  `json:"status"`
 		JobID            string  `json:"job_id"`
@@ -234,12 +495,16 @@ func (c *Client) GetCredits(ctx context.Context) (*CreditBalance, error) {
 		UserID       string    `json:"user_id"`
 		Balance      float64   `json:"credit_balance"`
 		LastActive   time.Time `json:"last_active"`
+		Buckets      []CreditBucket `json:"buckets"`
 	}
 
 	path := "/api/v1/credits/balance/" + c.userID
 	if c.userID == "" {
 		path = "/api/v1/credits"
 	}
+	if c.usesGRPC() {
+		return c.grpcGetCredits(ctx, path)
+	}
 
 	err := c.doRequest(ctx, http.MethodGet, path, nil, &result)
 	if err != nil {
@@ -249,6 +514,7 @@ func (c *Client) GetCredits(ctx context.Context) (*CreditBalance, error) {
 	return &CreditBalance{
 		Balance:     result.Balance,
 		LastUpdated: result.LastActive,
+		Buckets:     result.Buckets,
 	}, nil
 }
 
@@ -269,50 +535,42 @@ func (c *Client) CheckCredits(ctx context.Context, required float64) (bool, erro
 	return result.HasSufficient, err
 }
 
-// TransferCredits transfers credits to another user.
+// TransferCredits transfers credits to another user. A transfer above the
+// approval threshold is not made but returned as an *ApprovalRequiredError
+// until a second approver approves it.
 func (c *Client) TransferCredits(ctx context.Context, toUserID string, amount float64) error {
 	req := map[string]interface{}{
 		"to_user_id": toUserID,
 		"amount":     amount,
 	}
+	if c.approvalThreshold > 0 && amount > c.approvalThreshold {
+		req["require_approval"] = true
+	}
 
-	return c.doRequest(ctx, http.MethodPost, "/api/v1/credits/transfer", req, nil)
+	var result transferResult
+	if err := c.doRequest(ctx, http.MethodPost, transfersPath, req, &result); err != nil {
+		return err
+	}
+	if result.Status == TransferPendingApproval {
+		return &ApprovalRequiredError{Transfer: result.PendingTransfer}
+	}
+	return nil
 }
 
 // ListNodes retrieves all registered compute nodes.
+// Nodes are decoded one at a time as the response streams in.
 func (c *Client) ListNodes(ctx context.Context) ([]Node, error) {
-	var result struct {
-		Nodes []struct {
-			NodeID        string            `json:"node_id"`
-			Arch          Architecture      `json:"arch"`
-			Status        NodeStatus        `json:"status"`
-			LastSeen      time.Time         `json:"last_seen"`
-			Resources     *NodeResources    `json:"resources"`
-			CreditsEarned float64           `json:"credits_earned"`
-			Labels        map[string]string `json:"labels"`
-		} `json:"nodes"`
-		Total  int `json:"total"`
-		Online int `json:"online"`
-	}
-
-	err := c.doRequest(ctx, http.MethodGet, "/api/v1/nodes", nil, &result)
+	var nodes []Node
+	err := c.EachNode(ctx, func(node Node) error {
+		nodes = append(nodes, node)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	nodes := make([]Node, len(result.Nodes))
-	for i, n := range result.Nodes {
-		nodes[i] = Node{
-			ID:            n.NodeID,
-			Arch:          n.Arch,
-			Status:        n.Status,
-			LastSeen:      n.LastSeen,
-			Resources:     n.Resources,
-			CreditsEarned: n.CreditsEarned,
-			Labels:        n.Labels,
-		}
+	if nodes == nil {
+		nodes = []Node{}
 	}
-
 	return nodes, nil
 }
 
@@ -356,11 +614,29 @@ func (c *Client) GetWallet(ctx context.Context) (*Wallet, error) {
 	return &Wallet{
 		Address: c.userID,
 		Balance: credits.Balance,
+		Buckets: credits.Buckets,
 	}, nil
 }
 
+// ListTransactions retrieves the wallet transactions recorded since the given time.
+func (c *Client) ListTransactions(ctx context.Context, since time.Time) ([]Transaction, error) {
+	var result struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+
+	path := transactionsPath + "?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Transactions, nil
+}
+
 // GetNetworkStats retrieves overall network statistics.
 func (c *Client) GetNetworkStats(ctx context.Context) (*NetworkStats, error) {
+	if c.usesGRPC() {
+		return c.grpcGetNetworkStats(ctx)
+	}
+
 	var result struct {
 		Network struct {
 			TotalNodes    int            `json:"total_nodes"`
@@ -393,19 +669,24 @@ func (c *Client) GetNetworkStats(ctx context.Context) (*NetworkStats, error) {
 	}, nil
 }
 
-// GetLeaderboard retrieves the contribution leaderboard.
-func (c *Client) GetLeaderboard(ctx context.Context, limit int) ([]LeaderboardEntry, error) {
-	path := "/api/v1/network/leaderboard"
-	if limit > 0 {
-		path = fmt.Sprintf("%s?limit=%d", path, limit)
+// GetNetworkCapacity retrieves the free capacity of the network broken down by region.
+func (c *Client) GetNetworkCapacity(ctx context.Context) (*NetworkCapacity, error) {
+	var result NetworkCapacity
+	err := c.doRequest(ctx, http.MethodGet, "/api/v1/network/capacity", nil, &result)
+	if err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
-	var result struct {
-		Leaderboard []LeaderboardEntry `json:"leaderboard"`
+// GetLeaderboard retrieves the top of the contribution leaderboard. Use
+// GetLeaderboardPage for other windows, a region or later pages.
+func (c *Client) GetLeaderboard(ctx context.Context, limit int) ([]LeaderboardEntry, error) {
+	page, err := c.GetLeaderboardPage(ctx, LeaderboardOptions{PageOptions: PageOptions{Limit: limit}})
+	if err != nil {
+		return nil, err
 	}
-
-	err := c.doRequest(ctx, http.MethodGet, path, nil, &result)
-	return result.Leaderboard, err
+	return page.Entries, nil
 }
 
 // GetMetrics retrieves system metrics.
@@ -415,8 +696,15 @@ func (c *Client) GetMetrics(ctx context.Context) (map[string]interface{}, error)
 	return result, err
 }
 
-// calculateCreditCost estimates the credit cost for a job based on resources.
+// calculateCreditCost estimates the credit cost for a job based on
+// resources, including the premium for a verified result.
 func calculateCreditCost(spec *JobSpec) float64 {
+	base := baseCreditCost(spec)
+	return spotPrice(spec, base+verificationPremium(spec, base))
+}
+
+// baseCreditCost estimates the cost of running a job once.
+func baseCreditCost(spec *JobSpec) float64 {
 	baseCost := 1.0 // Base cost per job
 
 	if spec.Resources == nil {
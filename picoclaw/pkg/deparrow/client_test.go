@@ -45,8 +45,12 @@ func TestNewClient(t *testing.T) {
 			if client.baseURL != tt.apiURL {
 				t.Errorf("baseURL = %s, want %s", client.baseURL, tt.apiURL)
 			}
-			if client.jwtToken != tt.jwtToken {
-				t.Errorf("jwtToken = %s, want %s", client.jwtToken, tt.jwtToken)
+			if tt.jwtToken == "" {
+				if client.tokens != nil {
+					t.Errorf("tokens = %v, want none without a token", client.tokens)
+				}
+			} else if token, _ := client.tokens.Token(context.Background()); token != tt.jwtToken {
+				t.Errorf("token = %s, want %s", token, tt.jwtToken)
 			}
 			if client.httpClient == nil {
 				t.Error("httpClient is nil")
@@ -221,6 +225,73 @@ func TestClient_SubmitJob_Error(t *testing.T) {
 	}
 }
 
+func TestClient_SubmitJobTo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" {
+			json.NewEncoder(w).Encode(ServerInfo{Status: "healthy", APIVersion: "1.2", Features: []string{FeatureOrchestratorRouting}})
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["orchestrator"] != "orch-eu" {
+			t.Errorf("orchestrator = %v, want orch-eu", body["orchestrator"])
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"job_id":          "job-1",
+			"credit_deducted": 1.0,
+			"orchestrator":    "orch-eu",
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	job, err := client.SubmitJobTo(context.Background(), &JobSpec{Image: "alpine"}, "orch-eu")
+	if err != nil {
+		t.Fatalf("SubmitJobTo() error = %v", err)
+	}
+	if job.Orchestrator != "orch-eu" {
+		t.Errorf("Orchestrator = %s, want orch-eu", job.Orchestrator)
+	}
+}
+
+func TestClient_ListOrchestrators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/orchestrators" {
+			t.Errorf("Path = %s, want /api/v1/orchestrators", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"orchestrators": []map[string]interface{}{
+				{
+					"orchestrator_id": "orch-1",
+					"host":            "orchestrator.deparrow.net",
+					"port":            4222,
+					"region":          "global",
+					"status":          "online",
+					"load":            0.25,
+					"version":         "1.5.0",
+					"features":        []string{"docker", "gpu"},
+				},
+			},
+			"total": 1,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	orchestrators, err := client.ListOrchestrators(context.Background())
+	if err != nil {
+		t.Fatalf("ListOrchestrators() error = %v", err)
+	}
+	if len(orchestrators) != 1 {
+		t.Fatalf("Orchestrators count = %d, want 1", len(orchestrators))
+	}
+	o := orchestrators[0]
+	if o.ID != "orch-1" || o.Load != 0.25 || !o.Supports("gpu") || o.Supports("wasm") {
+		t.Errorf("Orchestrator = %+v", o)
+	}
+}
+
 func TestClient_GetJob(t *testing.T) {
 	now := time.Now()
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,247 @@
+package deparrow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the profile made of a config file's top-level settings.
+const DefaultProfile = "default"
+
+// Config is how a client reaches the DEparrow API, as read by LoadConfig.
+//
+// The config file holds the default profile's settings at the top level
+// and other profiles, e.g. staging or prod, under profiles; a profile
+// inherits every setting it does not set from the top level:
+//
+//	api_url: https://api.deparrow.net
+//	token: eyJhbGciOi...
+//	timeout: 30s
+//	retry:
+//	  max_attempts: 5
+//	profile: prod # used when DEPARROW_PROFILE is not set
+//	profiles:
+//	  staging:
+//	    api_url: https://staging.deparrow.net
+//	    token: eyJzdGFnaW5n...
+type Config struct {
+	// Profile the settings were read from
+	Profile string `yaml:"-"`
+	// Base URL of the Meta-OS API
+	APIURL string `yaml:"api_url"`
+	// Bearer token; APIKey is sent instead when both are set
+	Token  string `yaml:"token"`
+	APIKey string `yaml:"api_key"`
+	// User whose credits are shown; the token's user when empty
+	UserID string `yaml:"user_id"`
+	// Use the simulated network instead of APIURL
+	Sandbox bool `yaml:"sandbox"`
+	// HTTP request timeout; 0 keeps the client's default
+	Timeout time.Duration `yaml:"timeout"`
+	// Retry policy; zero fields take DefaultRetryPolicy's values
+	Retry RetryConfig `yaml:"retry"`
+}
+
+// RetryConfig is the part of a RetryPolicy a config file can set.
+type RetryConfig struct {
+	// Attempts including the first; 1 disables retries
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	// Also retry submits and transfers, which may charge twice
+	RetryNonIdempotent bool `yaml:"retry_non_idempotent"`
+}
+
+// Policy returns the retry policy the config describes.
+func (r RetryConfig) Policy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if r.MaxAttempts > 0 {
+		policy.MaxAttempts = r.MaxAttempts
+	}
+	if r.InitialBackoff > 0 {
+		policy.InitialBackoff = r.InitialBackoff
+	}
+	if r.MaxBackoff > 0 {
+		policy.MaxBackoff = r.MaxBackoff
+	}
+	policy.RetryNonIdempotent = r.RetryNonIdempotent
+	return policy.withDefaults()
+}
+
+// configFile is the layout of the config file.
+type configFile struct {
+	Config   `yaml:",inline"`
+	Default  string               `yaml:"profile"`
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+// DefaultConfigPath returns the config file LoadConfig reads: the file
+// DEPARROW_CONFIG names, or ~/.deparrow/config.yaml.
+func DefaultConfigPath() string {
+	if path := os.Getenv("DEPARROW_CONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".deparrow", "config.yaml")
+}
+
+// LoadConfig reads the client configuration from DefaultConfigPath and
+// the DEPARROW_* environment variables; see LoadConfigFile.
+func LoadConfig() (*Config, error) {
+	return LoadConfigFile("", "")
+}
+
+// LoadConfigFile reads the settings of a profile from the config file at
+// path, then lets the DEPARROW_* environment variables that are set and
+// not empty override them: DEPARROW_API_URL, DEPARROW_TOKEN,
+// DEPARROW_API_KEY, DEPARROW_USER_ID, DEPARROW_SANDBOX, DEPARROW_TIMEOUT,
+// DEPARROW_RETRY_MAX_ATTEMPTS, DEPARROW_RETRY_INITIAL_BACKOFF and
+// DEPARROW_RETRY_MAX_BACKOFF.
+//
+// An empty path reads DefaultConfigPath, which need not exist. An empty
+// profile uses DEPARROW_PROFILE, else the file's profile setting, else
+// DefaultProfile. Naming a profile the file does not have is an error.
+func LoadConfigFile(path, profile string) (*Config, error) {
+	required := path != ""
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+
+	var file configFile
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &file); err != nil {
+				return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+			}
+		case !errors.Is(err, os.ErrNotExist) || required:
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	if profile == "" {
+		profile = os.Getenv("DEPARROW_PROFILE")
+	}
+	if profile == "" {
+		profile = file.Default
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	cfg := file.Config
+	if node, ok := file.Profiles[profile]; ok {
+		if err := node.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("invalid profile %q in %s: %w", profile, path, err)
+		}
+	} else if profile != DefaultProfile {
+		names := make([]string, 0, len(file.Profiles))
+		for name := range file.Profiles {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("no profile %q in %s (profiles: %v)", profile, path, names)
+	}
+	cfg.Profile = profile
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// applyEnv overrides the settings whose environment variables are set.
+func (cfg *Config) applyEnv() error {
+	for name, field := range map[string]*string{
+		"DEPARROW_API_URL": &cfg.APIURL,
+		"DEPARROW_TOKEN":   &cfg.Token,
+		"DEPARROW_API_KEY": &cfg.APIKey,
+		"DEPARROW_USER_ID": &cfg.UserID,
+	} {
+		if v := os.Getenv(name); v != "" {
+			*field = v
+		}
+	}
+	if v := os.Getenv("DEPARROW_SANDBOX"); v != "" {
+		sandbox, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid DEPARROW_SANDBOX %q: %w", v, err)
+		}
+		cfg.Sandbox = sandbox
+	}
+	if v := os.Getenv("DEPARROW_RETRY_MAX_ATTEMPTS"); v != "" {
+		attempts, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid DEPARROW_RETRY_MAX_ATTEMPTS %q: %w", v, err)
+		}
+		cfg.Retry.MaxAttempts = attempts
+	}
+	for name, field := range map[string]*time.Duration{
+		"DEPARROW_TIMEOUT":               &cfg.Timeout,
+		"DEPARROW_RETRY_INITIAL_BACKOFF": &cfg.Retry.InitialBackoff,
+		"DEPARROW_RETRY_MAX_BACKOFF":     &cfg.Retry.MaxBackoff,
+	} {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %w", name, v, err)
+			}
+			*field = d
+		}
+	}
+	return nil
+}
+
+// NewClientFromConfig creates a client as cfg describes: a sandbox client
+// when cfg.Sandbox is set, otherwise one for cfg.APIURL, with cfg's
+// credentials, user ID, timeout and retry policy. opts are applied after
+// the config's settings, so they take precedence.
+//
+// Example:
+//
+//	cfg, err := deparrow.LoadConfig()
+//	if err != nil {
+//		return err
+//	}
+//	client, err := deparrow.NewClientFromConfig(cfg)
+func NewClientFromConfig(cfg *Config, opts ...ClientOption) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	if !cfg.Sandbox && cfg.APIURL == "" {
+		return nil, fmt.Errorf("no API URL configured for profile %q; set api_url or DEPARROW_API_URL", cfg.Profile)
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+
+	configured := []ClientOption{WithRetryPolicy(cfg.Retry.Policy())}
+	if cfg.Timeout > 0 {
+		configured = append(configured, WithTimeout(cfg.Timeout))
+	}
+	if cfg.APIKey != "" {
+		configured = append(configured, WithAPIKey(cfg.APIKey))
+	}
+	opts = append(configured, opts...)
+
+	var client *Client
+	if cfg.Sandbox {
+		client = NewSandboxClient(opts...)
+	} else {
+		client = NewClient(cfg.APIURL, cfg.Token, opts...)
+	}
+	if cfg.UserID != "" {
+		client.SetUserID(cfg.UserID)
+	}
+	return client, nil
+}
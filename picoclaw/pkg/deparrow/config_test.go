@@ -0,0 +1,132 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearConfigEnv unsets the DEPARROW_* variables LoadConfigFile reads.
+func clearConfigEnv(t *testing.T) {
+	for _, name := range []string{
+		"DEPARROW_CONFIG", "DEPARROW_PROFILE", "DEPARROW_API_URL", "DEPARROW_TOKEN",
+		"DEPARROW_API_KEY", "DEPARROW_USER_ID", "DEPARROW_SANDBOX", "DEPARROW_TIMEOUT",
+		"DEPARROW_RETRY_MAX_ATTEMPTS", "DEPARROW_RETRY_INITIAL_BACKOFF", "DEPARROW_RETRY_MAX_BACKOFF",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testConfig = `
+api_url: https://api.example.com
+token: prod-token
+timeout: 10s
+retry:
+  max_attempts: 5
+profiles:
+  staging:
+    api_url: https://staging.example.com
+    token: staging-token
+    retry:
+      initial_backoff: 2s
+`
+
+func TestLoadConfigFile_Profiles(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeConfig(t, testConfig)
+
+	cfg, err := LoadConfigFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadConfigFile() error = %v", err)
+	}
+	if cfg.Profile != DefaultProfile || cfg.APIURL != "https://api.example.com" || cfg.Timeout != 10*time.Second {
+		t.Errorf("default profile = %+v", cfg)
+	}
+
+	cfg, err = LoadConfigFile(path, "staging")
+	if err != nil {
+		t.Fatalf("LoadConfigFile(staging) error = %v", err)
+	}
+	if cfg.APIURL != "https://staging.example.com" || cfg.Token != "staging-token" {
+		t.Errorf("staging profile = %+v", cfg)
+	}
+	if cfg.Timeout != 10*time.Second || cfg.Retry.MaxAttempts != 5 || cfg.Retry.InitialBackoff != 2*time.Second {
+		t.Errorf("staging profile = %+v, want the top-level settings it does not override", cfg)
+	}
+
+	t.Setenv("DEPARROW_PROFILE", "staging")
+	if cfg, _ := LoadConfigFile(path, ""); cfg == nil || cfg.Profile != "staging" {
+		t.Errorf("DEPARROW_PROFILE did not pick the profile: %+v", cfg)
+	}
+
+	if _, err := LoadConfigFile(path, "prod"); err == nil || !strings.Contains(err.Error(), "staging") {
+		t.Errorf("LoadConfigFile(prod) error = %v, want the profiles listed", err)
+	}
+	if _, err := LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"), ""); err == nil {
+		t.Error("LoadConfigFile() of a missing file should fail")
+	}
+}
+
+func TestLoadConfig_Environment(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DEPARROW_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv("DEPARROW_API_URL", "https://env.example.com")
+	t.Setenv("DEPARROW_TIMEOUT", "45s")
+	t.Setenv("DEPARROW_RETRY_MAX_ATTEMPTS", "1")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() without a config file error = %v", err)
+	}
+	if cfg.APIURL != "https://env.example.com" || cfg.Timeout != 45*time.Second || cfg.Retry.Policy().MaxAttempts != 1 {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	t.Setenv("DEPARROW_TIMEOUT", "soon")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig() with an invalid DEPARROW_TIMEOUT should fail")
+	}
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"job_id":"job-1","status":"running"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientFromConfig(&Config{APIURL: server.URL, Token: "t", UserID: "user-1", Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() error = %v", err)
+	}
+	if _, err := client.GetJob(context.Background(), "job-1"); err != nil || auth != "Bearer t" {
+		t.Errorf("GetJob() = %v with Authorization %q", err, auth)
+	}
+	if client.userID != "user-1" || client.httpClient.Timeout != time.Minute || client.retryPolicy.MaxAttempts != DefaultRetryMaxAttempts {
+		t.Errorf("client user %q, timeout %s, retry %+v", client.userID, client.httpClient.Timeout, client.retryPolicy)
+	}
+
+	if client, err := NewClientFromConfig(&Config{Sandbox: true}); err != nil || !client.IsSandbox() {
+		t.Errorf("sandbox config = %v, %v", client, err)
+	}
+	if _, err := NewClientFromConfig(&Config{Profile: "prod"}); err == nil || !strings.Contains(err.Error(), "prod") {
+		t.Errorf("config without an API URL error = %v", err)
+	}
+}
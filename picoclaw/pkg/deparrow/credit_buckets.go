@@ -0,0 +1,94 @@
+package deparrow
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CreditExpiryWarning is how far ahead the wallet warns about expiring credits.
+const CreditExpiryWarning = 7 * 24 * time.Hour
+
+// Expired reports whether the bucket's credits can no longer be spent.
+func (b CreditBucket) Expired(now time.Time) bool {
+	return b.ExpiresAt != nil && !now.Before(*b.ExpiresAt)
+}
+
+// ExpiresWithin reports whether the bucket expires in the window after now.
+func (b CreditBucket) ExpiresWithin(now time.Time, window time.Duration) bool {
+	return b.ExpiresAt != nil && !b.Expired(now) && b.ExpiresAt.Before(now.Add(window))
+}
+
+// AvailableCredits sums the credits that can still be spent.
+func AvailableCredits(buckets []CreditBucket, now time.Time) float64 {
+	total := 0.0
+	for _, b := range buckets {
+		if !b.Expired(now) {
+			total += b.Amount
+		}
+	}
+	return total
+}
+
+// ExpiringCredits returns the non-empty buckets that expire within the
+// window, soonest first.
+func ExpiringCredits(buckets []CreditBucket, now time.Time, window time.Duration) []CreditBucket {
+	var out []CreditBucket
+	for _, b := range buckets {
+		if b.Amount > 0 && b.ExpiresWithin(now, window) {
+			out = append(out, b)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].ExpiresAt.Before(*out[j].ExpiresAt)
+	})
+	return out
+}
+
+// SpendCredits takes amount from the buckets, consuming the soonest-expiring
+// credits first and never-expiring ones last, so promotional credits are
+// used before they lapse. It returns the remaining buckets, with emptied
+// ones removed, and leaves the input untouched. An error is returned when
+// the unexpired credits don't cover the amount.
+func SpendCredits(buckets []CreditBucket, amount float64, now time.Time) ([]CreditBucket, error) {
+	if available := AvailableCredits(buckets, now); amount > available {
+		return nil, fmt.Errorf("insufficient credits: %.2f required, %.2f available", amount, available)
+	}
+
+	order := make([]int, len(buckets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := buckets[order[i]].ExpiresAt, buckets[order[j]].ExpiresAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+
+	remaining := make([]CreditBucket, len(buckets))
+	copy(remaining, buckets)
+	for _, i := range order {
+		if amount <= 0 {
+			break
+		}
+		if remaining[i].Expired(now) {
+			continue
+		}
+		take := remaining[i].Amount
+		if take > amount {
+			take = amount
+		}
+		remaining[i].Amount -= take
+		amount -= take
+	}
+
+	out := remaining[:0]
+	for _, b := range remaining {
+		if b.Amount > 0 {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func expiringBucket(id string, amount float64, expires time.Time) CreditBucket {
+	return CreditBucket{ID: id, Source: CreditSourcePromo, Amount: amount, ExpiresAt: &expires}
+}
+
+func TestSpendCredits_ExpiringFirst(t *testing.T) {
+	now := time.Now()
+	buckets := []CreditBucket{
+		{ID: "purchased", Source: CreditSourcePurchased, Amount: 100},
+		expiringBucket("grant-later", 50, now.Add(30*24*time.Hour)),
+		expiringBucket("promo-soon", 20, now.Add(2*24*time.Hour)),
+		expiringBucket("expired", 500, now.Add(-time.Hour)),
+	}
+
+	got, err := SpendCredits(buckets, 60, now)
+	if err != nil {
+		t.Fatalf("SpendCredits() error = %v", err)
+	}
+
+	remaining := make(map[string]float64)
+	for _, b := range got {
+		remaining[b.ID] = b.Amount
+	}
+	if _, ok := remaining["promo-soon"]; ok {
+		t.Error("soonest-expiring bucket should be used up and removed")
+	}
+	if remaining["grant-later"] != 10 {
+		t.Errorf("grant-later = %v, want 10", remaining["grant-later"])
+	}
+	if remaining["purchased"] != 100 {
+		t.Errorf("purchased = %v, want untouched 100", remaining["purchased"])
+	}
+	if remaining["expired"] != 500 {
+		t.Errorf("expired bucket should not be spent, got %v", remaining["expired"])
+	}
+	if buckets[2].Amount != 20 {
+		t.Error("SpendCredits() modified its input")
+	}
+
+	if _, err := SpendCredits(buckets, 171, now); err == nil {
+		t.Error("expected error when only 170 unexpired credits are available")
+	}
+}
+
+func TestExpiringCredits(t *testing.T) {
+	now := time.Now()
+	buckets := []CreditBucket{
+		{ID: "purchased", Amount: 100},
+		expiringBucket("later", 5, now.Add(6*24*time.Hour)),
+		expiringBucket("soon", 5, now.Add(time.Hour)),
+		expiringBucket("far", 5, now.Add(60*24*time.Hour)),
+		expiringBucket("gone", 5, now.Add(-time.Hour)),
+	}
+
+	got := ExpiringCredits(buckets, now, CreditExpiryWarning)
+	if len(got) != 2 || got[0].ID != "soon" || got[1].ID != "later" {
+		t.Errorf("ExpiringCredits() = %+v, want soon then later", got)
+	}
+	if total := AvailableCredits(buckets, now); total != 115 {
+		t.Errorf("AvailableCredits() = %v, want 115", total)
+	}
+}
+
+func TestSandbox_SpendsPromoCreditsFirst(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	if err := client.TransferCredits(ctx, "friend", 100); err != nil {
+		t.Fatalf("TransferCredits() error = %v", err)
+	}
+
+	balance, err := client.GetCredits(ctx)
+	if err != nil {
+		t.Fatalf("GetCredits() error = %v", err)
+	}
+	if balance.Balance != SandboxStartingBalance-100 {
+		t.Errorf("Balance = %.2f, want %.2f", balance.Balance, SandboxStartingBalance-100)
+	}
+	for _, b := range balance.Buckets {
+		if b.Source == CreditSourcePromo && b.Amount != sandboxPromoCredits-100 {
+			t.Errorf("promo bucket = %.2f, want %.2f", b.Amount, sandboxPromoCredits-100)
+		}
+		if b.Source == CreditSourcePurchased && b.Amount != SandboxStartingBalance-sandboxPromoCredits {
+			t.Errorf("purchased bucket = %.2f, want untouched", b.Amount)
+		}
+	}
+}
+
+func TestWalletTool_Execute_ExpiryWarning(t *testing.T) {
+	expires := time.Now().Add(36 * time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"user_id":        "user-wallet-expiry01",
+			"credit_balance": 300.0,
+			"buckets": []CreditBucket{
+				{ID: "b1", Source: CreditSourcePurchased, Amount: 200},
+				{ID: "b2", Source: CreditSourcePromo, Amount: 100, Description: "Launch promo", ExpiresAt: &expires},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	client.SetUserID("user-wallet-expiry01")
+	result := NewWalletTool(client).Execute(context.Background(), map[string]interface{}{"action": "balance"})
+
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	for _, want := range []string{"Launch promo", "never expires", "100.00 credits expire within 7 days", "tomorrow"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("Result should contain %q: %s", want, result.ForLLM)
+		}
+	}
+}
@@ -2,8 +2,12 @@ package deparrow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
@@ -86,7 +90,7 @@ func (t *CreditTool) Execute(ctx context.Context, args map[string]interface{}) *
 func (t *CreditTool) getBalance(ctx context.Context) *tools.ToolResult {
 	balance, err := t.client.GetCredits(ctx)
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to get credit balance: %v", err))
+		return failureResult("get credit balance", err)
 	}
 
 	var result strings.Builder
@@ -103,8 +107,8 @@ func (t *CreditTool) getBalance(ctx context.Context) *tools.ToolResult {
 
 	// Provide guidance on usage
 	result.WriteString("\n💡 Tips:\n")
-	if balance.Balance < 10 {
-		result.WriteString("  • Balance is low. Contribute compute to earn more credits.\n")
+	if balance.Balance < t.client.LowBalanceThreshold() {
+		result.WriteString("  • Balance is low. Top up with 'deparrow_wallet' (action 'top_up') or contribute compute to earn more credits.\n")
 	} else if balance.Balance >= 100 {
 		result.WriteString("  • You have plenty of credits for compute jobs!\n")
 	} else {
@@ -163,8 +167,12 @@ func (t *CreditTool) transferCredits(ctx context.Context, args map[string]interf
 	}
 
 	err := t.client.TransferCredits(ctx, toUser, amount)
+	var pending *ApprovalRequiredError
+	if errors.As(err, &pending) {
+		return approvalRequiredResult(pending)
+	}
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to transfer credits: %v", err))
+		return failureResult("transfer credits", err)
 	}
 
 	return tools.UserResult(fmt.Sprintf(
@@ -245,19 +253,35 @@ func (t *NetworkStatsTool) Name() string {
 
 // Description returns the tool description.
 func (t *NetworkStatsTool) Description() string {
-	return "View DEparrow network statistics including total nodes, compute power, and tier distribution."
+	return `View DEparrow network statistics including total nodes, compute power, and tier distribution.
+
+Set trend_days to also chart how online nodes, compute power and earnings
+changed over that many days, to answer whether the network is growing.`
 }
 
 // Parameters returns the JSON schema for tool parameters.
 func (t *NetworkStatsTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
-		"type":       "object",
-		"properties": map[string]interface{}{},
+		"type": "object",
+		"properties": map[string]interface{}{
+			"trend_days": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Days of history to chart, up to %d; omit for the current snapshot only", maxNetworkTrendDays),
+			},
+		},
 	}
 }
 
 // Execute runs the network stats tool.
 func (t *NetworkStatsTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	days := 0
+	if d, ok := args["trend_days"].(float64); ok {
+		days = int(d)
+		if days < 1 || days > maxNetworkTrendDays {
+			return tools.ErrorResult(fmt.Sprintf("trend_days must be between 1 and %d", maxNetworkTrendDays))
+		}
+	}
+
 	stats, err := t.client.GetNetworkStats(ctx)
 	if err != nil {
 		return tools.ErrorResult(fmt.Sprintf("Failed to get network stats: %v", err))
@@ -280,9 +304,106 @@ func (t *NetworkStatsTool) Execute(ctx context.Context, args map[string]interfac
 		}
 	}
 
+	if days > 0 {
+		result.WriteString("\n")
+		result.WriteString(t.trend(ctx, days))
+	}
+
 	return tools.UserResult(result.String())
 }
 
+// maxNetworkTrendDays is the longest network trend the tool charts.
+const maxNetworkTrendDays = 90
+
+// trend charts the network over the last days: hourly for up to two days,
+// daily beyond. A server without network history gets a note rather than
+// failing the snapshot.
+func (t *NetworkStatsTool) trend(ctx context.Context, days int) string {
+	resolution := 24 * time.Hour
+	if days <= 2 {
+		resolution = time.Hour
+	}
+	history, err := t.client.GetNetworkStatsHistory(ctx, time.Duration(days)*24*time.Hour, resolution)
+	if err != nil {
+		if endpointUnavailable(err) {
+			return "📈 This DEparrow server does not record network history, so there is no trend to show.\n"
+		}
+		return fmt.Sprintf("📈 Could not get the network trend: %v\n", err)
+	}
+	return formatNetworkTrend(history)
+}
+
+// formatNetworkTrend renders a network history as a sparkline per measure
+// and tells whether the network grew over it.
+func formatNetworkTrend(history *NetworkStatsHistory) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📈 Trend (last %s):\n", formatPeriod(history.Window)))
+	growth, ok := history.Growth()
+	if !ok {
+		result.WriteString("  Not enough history yet to show a trend.\n")
+		return result.String()
+	}
+
+	online := make([]float64, len(history.Points))
+	gflops := make([]float64, len(history.Points))
+	credits := make([]float64, len(history.Points))
+	for i, p := range history.Points {
+		online[i] = float64(p.OnlineNodes)
+		gflops[i] = p.LiveGFlops
+		credits[i] = p.CreditsEarned
+	}
+	first, last := history.Points[0], history.Points[len(history.Points)-1]
+	result.WriteString(fmt.Sprintf("  Online nodes  %s  %d → %d (%+.1f%%)\n",
+		sparkline(online), first.OnlineNodes, last.OnlineNodes, growth.OnlineNodes*100))
+	result.WriteString(fmt.Sprintf("  GFLOPS        %s  %.1f → %.1f (%+.1f%%)\n",
+		sparkline(gflops), first.LiveGFlops, last.LiveGFlops, growth.LiveGFlops*100))
+	result.WriteString(fmt.Sprintf("  Credits/%-5s %s  %.2f earned\n",
+		formatPeriod(history.Resolution), sparkline(credits), history.CreditsEarned()))
+	result.WriteString("\n" + networkVerdict(growth) + "\n")
+	return result.String()
+}
+
+// networkSteady is the relative change below which the network is taken
+// to be holding steady.
+const networkSteady = 0.01
+
+// networkVerdict sums up the growth of online nodes and compute power.
+func networkVerdict(growth NetworkGrowth) string {
+	nodes, gflops := growth.OnlineNodes, growth.LiveGFlops
+	switch {
+	case math.Abs(nodes) < networkSteady && math.Abs(gflops) < networkSteady:
+		return "➡️ The network is holding steady."
+	case nodes > -networkSteady && gflops > -networkSteady:
+		return "🌱 The network is growing."
+	case nodes < networkSteady && gflops < networkSteady:
+		return "🔻 The network is shrinking."
+	case gflops > 0:
+		return "🔀 Mixed: fewer nodes are online, but compute power grew."
+	}
+	return "🔀 Mixed: more nodes are online, but compute power fell."
+}
+
+// sparklineTicks are the bars of a sparkline, lowest first.
+var sparklineTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as one bar each, scaled between their minimum
+// and maximum. A flat series is drawn at mid height.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := slices.Min(values), slices.Max(values)
+	line := make([]rune, len(values))
+	for i, v := range values {
+		tick := len(sparklineTicks) / 2
+		if high > low {
+			tick = int(math.Round((v - low) / (high - low) * float64(len(sparklineTicks)-1)))
+		}
+		line[i] = sparklineTicks[tick]
+	}
+	return string(line)
+}
+
 // LeaderboardTool shows the top contributing nodes.
 type LeaderboardTool struct {
 	client *Client
@@ -300,7 +421,12 @@ func (t *LeaderboardTool) Name() string {
 
 // Description returns the tool description.
 func (t *LeaderboardTool) Description() string {
-	return "View the DEparrow contribution leaderboard showing top nodes."
+	return `View the DEparrow contribution leaderboard showing top nodes.
+
+Ranks all-time contributions by default; set window to 24h, 7d or 30d to
+rank recent ones, which also shows how each node moved since the previous
+period. Set region to rank one region's nodes, and offset to page further
+down the list.`
 }
 
 // Parameters returns the JSON schema for tool parameters.
@@ -313,6 +439,21 @@ func (t *LeaderboardTool) Parameters() map[string]interface{} {
 				"description": "Number of entries to show (default: 10)",
 				"default":     10,
 			},
+			"window": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{string(LeaderboardDay), string(LeaderboardWeek), string(LeaderboardMonth), string(LeaderboardAllTime)},
+				"description": "Period to rank contributions over",
+				"default":     string(LeaderboardAllTime),
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Rank only the nodes of this region, e.g. 'eu-west'",
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of top entries to skip, to see further down the list",
+				"default":     0,
+			},
 		},
 	}
 }
@@ -325,18 +466,48 @@ func (t *LeaderboardTool) Execute(ctx context.Context, args map[string]interface
 	} else if l, ok := args["limit"].(int); ok {
 		limit = l
 	}
-
-	entries, err := t.client.GetLeaderboard(ctx, limit)
+	offset := 0
+	if o, ok := args["offset"].(float64); ok {
+		offset = int(o)
+	}
+	if offset < 0 {
+		return tools.ErrorResult("offset can't be negative")
+	}
+	window, _ := args["window"].(string)
+	region, _ := args["region"].(string)
+
+	page, err := t.client.GetLeaderboardPage(ctx, LeaderboardOptions{
+		PageOptions: PageOptions{Limit: limit, Offset: offset},
+		Window:      LeaderboardWindow(window),
+		Region:      region,
+	})
 	if err != nil {
 		return tools.ErrorResult(fmt.Sprintf("Failed to get leaderboard: %v", err))
 	}
+	entries := page.Entries
 
 	if len(entries) == 0 {
+		if offset > 0 || region != "" {
+			return tools.UserResult("No leaderboard entries match. Try a smaller offset or another region.")
+		}
 		return tools.UserResult("No entries on the leaderboard yet. Be the first to contribute!")
 	}
 
 	var result strings.Builder
 	result.WriteString("🏆 DEparrow Contribution Leaderboard\n")
+	var scope []string
+	if page.Window != "" {
+		window = string(page.Window)
+	}
+	if label := leaderboardWindowLabel(LeaderboardWindow(window)); label != "" {
+		scope = append(scope, label)
+	}
+	if region != "" {
+		scope = append(scope, region)
+	}
+	if len(scope) > 0 {
+		result.WriteString(strings.Join(scope, " · ") + "\n")
+	}
 	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	tierIcons := map[ContributionTier]string{
@@ -349,16 +520,61 @@ func (t *LeaderboardTool) Execute(ctx context.Context, args map[string]interface
 
 	for _, entry := range entries {
 		icon := tierIcons[entry.Tier]
-		result.WriteString(fmt.Sprintf("%2d. %s %s\n", entry.Rank, icon, entry.NodeID[:12]+"..."))
+		nodeID := entry.NodeID
+		if len(nodeID) > 12 {
+			nodeID = nodeID[:12] + "..."
+		}
+		result.WriteString(fmt.Sprintf("%2d. %s %s%s\n", entry.Rank, icon, nodeID, rankDelta(entry)))
 		result.WriteString(fmt.Sprintf("    %.0f credits | %.1f CPUh + %.1f GPUh\n",
 			entry.CreditsEarned, entry.CPUHours, entry.GPUHours))
 	}
 
+	if page.Total > 0 {
+		last := entries[len(entries)-1].Rank
+		result.WriteString(fmt.Sprintf("\nShowing %d of %d ranked nodes.", len(entries), page.Total))
+		if _, more := page.Next(); more || offset+len(entries) < page.Total {
+			result.WriteString(fmt.Sprintf(" Use offset=%d for the next entries after rank %d.", offset+len(entries), last))
+		}
+		result.WriteString("\n")
+	}
 	result.WriteString("\nUse 'deparrow_nodes' to see your own contribution.")
 
 	return tools.UserResult(result.String())
 }
 
+// leaderboardWindowLabel names a window for the leaderboard heading.
+func leaderboardWindowLabel(window LeaderboardWindow) string {
+	switch window {
+	case LeaderboardDay:
+		return "Last 24 hours"
+	case LeaderboardWeek:
+		return "Last 7 days"
+	case LeaderboardMonth:
+		return "Last 30 days"
+	case LeaderboardAllTime:
+		return "All time"
+	}
+	return ""
+}
+
+// rankDelta shows how an entry moved since the previous period, when the
+// server reports it.
+func rankDelta(entry LeaderboardEntry) string {
+	if entry.PreviousRank == nil {
+		return ""
+	}
+	change, ok := entry.RankChange()
+	switch {
+	case !ok:
+		return "  🆕 new"
+	case change > 0:
+		return fmt.Sprintf("  ▲%d", change)
+	case change < 0:
+		return fmt.Sprintf("  ▼%d", -change)
+	}
+	return "  ="
+}
+
 // Ensure tools implement the Tool interface
 var _ tools.Tool = (*CreditTool)(nil)
 var _ tools.Tool = (*CreditEarnTool)(nil)
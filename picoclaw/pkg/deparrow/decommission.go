@@ -0,0 +1,40 @@
+package deparrow
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// decommissionPath is the endpoint of a node's decommissioning.
+func decommissionPath(nodeID string) string {
+	return "/api/v1/nodes/" + url.PathEscape(nodeID) + "/decommission"
+}
+
+// RequestDecommission starts removing a node from the network for good.
+// The server drains the node's jobs, settles its unpaid earnings, revokes
+// its key and marks it retired, in that order; follow the progress with
+// GetDecommission. Until the node is drained it can be called off with
+// CancelDecommission.
+func (c *Client) RequestDecommission(ctx context.Context, nodeID string) (*Decommission, error) {
+	var result Decommission
+	if err := c.doRequest(ctx, http.MethodPost, decommissionPath(nodeID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetDecommission retrieves the progress of a node's decommissioning.
+func (c *Client) GetDecommission(ctx context.Context, nodeID string) (*Decommission, error) {
+	var result Decommission
+	if err := c.doRequest(ctx, http.MethodGet, decommissionPath(nodeID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelDecommission calls off a decommissioning that is still draining
+// and puts the node back online. Later stages can't be undone.
+func (c *Client) CancelDecommission(ctx context.Context, nodeID string) error {
+	return c.doRequest(ctx, http.MethodDelete, decommissionPath(nodeID), nil, nil)
+}
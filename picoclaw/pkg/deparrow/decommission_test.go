@@ -0,0 +1,119 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNodeTool_Decommission_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewNodeTool(client)
+	ctx := context.Background()
+
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		t.Fatalf("ListNodes() error = %v", err)
+	}
+	nodeID := nodes[len(nodes)-1].ID
+	wallet, err := client.GetWallet(ctx)
+	if err != nil {
+		t.Fatalf("GetWallet() error = %v", err)
+	}
+
+	// Without confirmation nothing happens
+	result := tool.Execute(ctx, map[string]interface{}{"action": "decommission", "node_id": nodeID})
+	if result.IsError {
+		t.Fatalf("decommission preview failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "confirm=true") {
+		t.Errorf("preview should ask for confirmation:\n%s", result.ForLLM)
+	}
+	if _, err := client.GetDecommission(ctx, nodeID); err == nil {
+		t.Fatal("decommissioning started without confirmation")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "decommission", "node_id": nodeID, "confirm": true})
+	if result.IsError {
+		t.Fatalf("decommission failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "⏳ Drain jobs") {
+		t.Errorf("decommissioning should start draining:\n%s", result.ForLLM)
+	}
+	if node, _ := client.GetNode(ctx, nodeID); node.Status != NodeStatusDraining {
+		t.Errorf("Status = %s, want draining", node.Status)
+	}
+
+	// Each status check moves the sandbox one stage on
+	var d *Decommission
+	for _, want := range []DecommissionStage{DecommissionFinalizing, DecommissionRevokingKey, DecommissionRetired} {
+		d, err = client.GetDecommission(ctx, nodeID)
+		if err != nil {
+			t.Fatalf("GetDecommission() error = %v", err)
+		}
+		if d.Stage != want {
+			t.Fatalf("Stage = %s, want %s", d.Stage, want)
+		}
+	}
+	if !d.Done() || d.KeyRevokedAt == nil || d.FinalEarnings != sandboxUnpaidEarnings {
+		t.Errorf("decommission = %+v", d)
+	}
+	if node, _ := client.GetNode(ctx, nodeID); node.Status != NodeStatusRetired {
+		t.Errorf("Status = %s, want retired", node.Status)
+	}
+	after, _ := client.GetWallet(ctx)
+	if after.Balance != wallet.Balance+sandboxUnpaidEarnings {
+		t.Errorf("Balance = %.2f, want the final earnings paid out", after.Balance)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "decommission_status", "node_id": nodeID})
+	if !strings.Contains(result.ForLLM, "Retired") {
+		t.Errorf("status should show the node retired:\n%s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "decommission", "node_id": nodeID, "confirm": true})
+	if !result.IsError {
+		t.Errorf("decommissioning a retired node should fail:\n%s", result.ForLLM)
+	}
+}
+
+func TestNodeTool_CancelDecommission_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewNodeTool(client)
+	ctx := context.Background()
+
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		t.Fatalf("ListNodes() error = %v", err)
+	}
+	nodeID := nodes[0].ID
+
+	if _, err := client.RequestDecommission(ctx, nodeID); err != nil {
+		t.Fatalf("RequestDecommission() error = %v", err)
+	}
+	result := tool.Execute(ctx, map[string]interface{}{"action": "cancel_decommission", "node_id": nodeID})
+	if result.IsError {
+		t.Fatalf("cancel failed: %s", result.ForLLM)
+	}
+	if node, _ := client.GetNode(ctx, nodeID); node.Status != NodeStatusOnline {
+		t.Errorf("Status = %s, want online", node.Status)
+	}
+
+	// Once past draining it can't be called off
+	client.RequestDecommission(ctx, nodeID)
+	client.GetDecommission(ctx, nodeID)
+	if err := client.CancelDecommission(ctx, nodeID); err == nil {
+		t.Error("CancelDecommission() after draining should fail")
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "decommission"},
+		{"action": "decommission_status", "node_id": "missing"},
+		{"action": "retire"},
+	} {
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("Execute(%v) should fail:\n%s", args, result.ForLLM)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: deparrow.proto
+
+package deparrowpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Deparrow_SubmitJob_FullMethodName       = "/deparrow.v1.Deparrow/SubmitJob"
+	Deparrow_GetJob_FullMethodName          = "/deparrow.v1.Deparrow/GetJob"
+	Deparrow_CancelJob_FullMethodName       = "/deparrow.v1.Deparrow/CancelJob"
+	Deparrow_WatchJob_FullMethodName        = "/deparrow.v1.Deparrow/WatchJob"
+	Deparrow_GetCredits_FullMethodName      = "/deparrow.v1.Deparrow/GetCredits"
+	Deparrow_GetNetworkStats_FullMethodName = "/deparrow.v1.Deparrow/GetNetworkStats"
+)
+
+// DeparrowClient is the client API for Deparrow service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Deparrow mirrors the Meta-OS REST endpoints agents call most often, for
+// clients that poll or submit at a rate where JSON over HTTP costs too
+// much. Requests authenticate with the same bearer token or API key as
+// the REST API, sent as "authorization" or "x-api-key" metadata.
+type DeparrowClient interface {
+	// SubmitJob mirrors POST /api/v1/jobs/submit.
+	SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error)
+	// GetJob mirrors GET /api/v1/jobs/{job_id}.
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error)
+	// CancelJob mirrors POST /api/v1/jobs/{job_id}/cancel.
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	// WatchJob streams the job's status, starting with its current one,
+	// until it finishes. It replaces the WebSocket at
+	// /api/v1/jobs/{job_id}/watch.
+	WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobStatusUpdate], error)
+	// GetCredits mirrors GET /api/v1/credits/balance/{user_id}.
+	GetCredits(ctx context.Context, in *GetCreditsRequest, opts ...grpc.CallOption) (*CreditBalance, error)
+	// GetNetworkStats mirrors GET /api/v1/network/contribution.
+	GetNetworkStats(ctx context.Context, in *GetNetworkStatsRequest, opts ...grpc.CallOption) (*NetworkStats, error)
+}
+
+type deparrowClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeparrowClient(cc grpc.ClientConnInterface) DeparrowClient {
+	return &deparrowClient{cc}
+}
+
+func (c *deparrowClient) SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitJobResponse)
+	err := c.cc.Invoke(ctx, Deparrow_SubmitJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deparrowClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, Deparrow_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deparrowClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, Deparrow_CancelJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deparrowClient) WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobStatusUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Deparrow_ServiceDesc.Streams[0], Deparrow_WatchJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobRequest, JobStatusUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Deparrow_WatchJobClient = grpc.ServerStreamingClient[JobStatusUpdate]
+
+func (c *deparrowClient) GetCredits(ctx context.Context, in *GetCreditsRequest, opts ...grpc.CallOption) (*CreditBalance, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreditBalance)
+	err := c.cc.Invoke(ctx, Deparrow_GetCredits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deparrowClient) GetNetworkStats(ctx context.Context, in *GetNetworkStatsRequest, opts ...grpc.CallOption) (*NetworkStats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NetworkStats)
+	err := c.cc.Invoke(ctx, Deparrow_GetNetworkStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeparrowServer is the server API for Deparrow service.
+// All implementations must embed UnimplementedDeparrowServer
+// for forward compatibility.
+//
+// Deparrow mirrors the Meta-OS REST endpoints agents call most often, for
+// clients that poll or submit at a rate where JSON over HTTP costs too
+// much. Requests authenticate with the same bearer token or API key as
+// the REST API, sent as "authorization" or "x-api-key" metadata.
+type DeparrowServer interface {
+	// SubmitJob mirrors POST /api/v1/jobs/submit.
+	SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error)
+	// GetJob mirrors GET /api/v1/jobs/{job_id}.
+	GetJob(context.Context, *GetJobRequest) (*Job, error)
+	// CancelJob mirrors POST /api/v1/jobs/{job_id}/cancel.
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	// WatchJob streams the job's status, starting with its current one,
+	// until it finishes. It replaces the WebSocket at
+	// /api/v1/jobs/{job_id}/watch.
+	WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobStatusUpdate]) error
+	// GetCredits mirrors GET /api/v1/credits/balance/{user_id}.
+	GetCredits(context.Context, *GetCreditsRequest) (*CreditBalance, error)
+	// GetNetworkStats mirrors GET /api/v1/network/contribution.
+	GetNetworkStats(context.Context, *GetNetworkStatsRequest) (*NetworkStats, error)
+	mustEmbedUnimplementedDeparrowServer()
+}
+
+// UnimplementedDeparrowServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDeparrowServer struct{}
+
+func (UnimplementedDeparrowServer) SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedDeparrowServer) GetJob(context.Context, *GetJobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedDeparrowServer) CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedDeparrowServer) WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobStatusUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchJob not implemented")
+}
+func (UnimplementedDeparrowServer) GetCredits(context.Context, *GetCreditsRequest) (*CreditBalance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCredits not implemented")
+}
+func (UnimplementedDeparrowServer) GetNetworkStats(context.Context, *GetNetworkStatsRequest) (*NetworkStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNetworkStats not implemented")
+}
+func (UnimplementedDeparrowServer) mustEmbedUnimplementedDeparrowServer() {}
+func (UnimplementedDeparrowServer) testEmbeddedByValue()                  {}
+
+// UnsafeDeparrowServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeparrowServer will
+// result in compilation errors.
+type UnsafeDeparrowServer interface {
+	mustEmbedUnimplementedDeparrowServer()
+}
+
+func RegisterDeparrowServer(s grpc.ServiceRegistrar, srv DeparrowServer) {
+	// If the following call pancis, it indicates UnimplementedDeparrowServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Deparrow_ServiceDesc, srv)
+}
+
+func _Deparrow_SubmitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeparrowServer).SubmitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deparrow_SubmitJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeparrowServer).SubmitJob(ctx, req.(*SubmitJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deparrow_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeparrowServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deparrow_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeparrowServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deparrow_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeparrowServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deparrow_CancelJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeparrowServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deparrow_WatchJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeparrowServer).WatchJob(m, &grpc.GenericServerStream[WatchJobRequest, JobStatusUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Deparrow_WatchJobServer = grpc.ServerStreamingServer[JobStatusUpdate]
+
+func _Deparrow_GetCredits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCreditsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeparrowServer).GetCredits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deparrow_GetCredits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeparrowServer).GetCredits(ctx, req.(*GetCreditsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deparrow_GetNetworkStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNetworkStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeparrowServer).GetNetworkStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deparrow_GetNetworkStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeparrowServer).GetNetworkStats(ctx, req.(*GetNetworkStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Deparrow_ServiceDesc is the grpc.ServiceDesc for Deparrow service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Deparrow_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "deparrow.v1.Deparrow",
+	HandlerType: (*DeparrowServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitJob",
+			Handler:    _Deparrow_SubmitJob_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _Deparrow_GetJob_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _Deparrow_CancelJob_Handler,
+		},
+		{
+			MethodName: "GetCredits",
+			Handler:    _Deparrow_GetCredits_Handler,
+		},
+		{
+			MethodName: "GetNetworkStats",
+			Handler:    _Deparrow_GetNetworkStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchJob",
+			Handler:       _Deparrow_WatchJob_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "deparrow.proto",
+}
@@ -0,0 +1,6 @@
+// Package deparrowpb holds the protobuf definition of the DEparrow gRPC API
+// and the code generated from it. Regenerate after editing deparrow.proto
+// with protoc, protoc-gen-go and protoc-gen-go-grpc on the PATH.
+package deparrowpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative deparrow.proto
@@ -0,0 +1,356 @@
+package deparrow
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultIPFSGateway serves job outputs stored on IPFS that the API lists
+// no download URL for.
+const DefaultIPFSGateway = "https://ipfs.io"
+
+// partSuffix marks a download in progress. A later download resumes it.
+const partSuffix = ".part"
+
+// ErrChecksumMismatch is returned when a downloaded file does not match
+// the checksum the API listed for it.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// WithIPFSGateway sets the HTTP gateway job outputs are fetched from by
+// CID. An empty URL turns fetching by CID off.
+func WithIPFSGateway(gatewayURL string) ClientOption {
+	return func(c *Client) {
+		c.ipfsGateway = strings.TrimRight(strings.TrimSpace(gatewayURL), "/")
+	}
+}
+
+// DownloadProgress reports how far the download of one result file has got.
+type DownloadProgress struct {
+	// Name of the result, e.g. "stdout"
+	Name string
+	// Bytes on disk so far, including a resumed part
+	Written int64
+	// Full size in bytes, or -1 when unknown
+	Total int64
+}
+
+// DownloadedFile is a job result saved to disk.
+type DownloadedFile struct {
+	// Name of the result, e.g. "stdout"
+	Name string
+	// Where the file was saved
+	Path string
+	// Size in bytes
+	Size int64
+	// Set when the API listed a checksum and the file matched it
+	Verified bool
+}
+
+// resultSource is one result file to save and where its content comes from.
+type resultSource struct {
+	name   string
+	file   string
+	url    string
+	inline *string
+}
+
+// DownloadResults saves the results of a finished job to destDir: every
+// file the API lists a download URL for, stdout and stderr, and the output
+// CID through the IPFS gateway when no URL covers it. Files are checked
+// against the checksums the API lists. An interrupted download leaves a
+// .part file that the next call resumes, and files already saved are not
+// fetched again. onProgress, if not nil, is called as data arrives.
+//
+// On error the files saved so far are returned with it.
+//
+// Example:
+//
+//	files, err := client.DownloadResults(ctx, job.ID, "./results", func(p deparrow.DownloadProgress) {
+//	    fmt.Printf("%s: %d/%d bytes\n", p.Name, p.Written, p.Total)
+//	})
+func (c *Client) DownloadResults(ctx context.Context, jobID, destDir string, onProgress func(DownloadProgress)) ([]DownloadedFile, error) {
+	job, err := c.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Results == nil {
+		return nil, fmt.Errorf("job %s has no results (status %s)", jobID, job.Status)
+	}
+
+	sources, err := c.resultSources(job.Results)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", destDir, err)
+	}
+
+	files := make([]DownloadedFile, 0, len(sources))
+	for _, src := range sources {
+		file, err := c.saveResult(ctx, src, filepath.Join(destDir, src.file), job.Results.Checksums[src.name], onProgress)
+		if err != nil {
+			return files, fmt.Errorf("failed to download %s: %w", src.name, err)
+		}
+		files = append(files, *file)
+	}
+	return files, nil
+}
+
+// resultSources lists the files a job's results are saved as, in name
+// order with the IPFS outputs last.
+func (c *Client) resultSources(results *JobResults) ([]resultSource, error) {
+	var sources []resultSource
+	for name, u := range results.DownloadURLs {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("invalid result name %q", name)
+		}
+		sources = append(sources, resultSource{name: name, file: name, url: u})
+	}
+	for _, inline := range []struct {
+		name string
+		text *string
+	}{{LogStreamStdout, &results.Stdout}, {LogStreamStderr, &results.Stderr}} {
+		if _, listed := results.DownloadURLs[inline.name]; !listed && *inline.text != "" {
+			sources = append(sources, resultSource{name: inline.name, file: inline.name, inline: inline.text})
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].name < sources[j].name })
+
+	if _, listed := results.DownloadURLs["outputs"]; !listed && results.OutputCID != "" && c.ipfsGateway != "" && c.sandbox == nil {
+		sources = append(sources, resultSource{
+			name: "outputs",
+			file: "outputs.tar",
+			url:  c.ipfsGateway + "/ipfs/" + url.PathEscape(results.OutputCID) + "?format=tar",
+		})
+	}
+	return sources, nil
+}
+
+// saveResult saves one result file to path, resuming a partial download.
+func (c *Client) saveResult(ctx context.Context, src resultSource, path, checksum string, onProgress func(DownloadProgress)) (*DownloadedFile, error) {
+	checksum = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(checksum), "sha256:"))
+	report := func(written, total int64) {
+		if onProgress != nil {
+			onProgress(DownloadProgress{Name: src.name, Written: written, Total: total})
+		}
+	}
+
+	// A file from an earlier call is complete, it was only renamed into
+	// place once it was
+	if info, err := os.Stat(path); err == nil {
+		verified, err := verifyChecksum(path, checksum)
+		if err == nil {
+			report(info.Size(), info.Size())
+			return &DownloadedFile{Name: src.name, Path: path, Size: info.Size(), Verified: verified}, nil
+		}
+		if !errors.Is(err, ErrChecksumMismatch) {
+			return nil, err
+		}
+	}
+
+	part := path + partSuffix
+	if src.inline != nil {
+		if err := os.WriteFile(part, []byte(*src.inline), 0o644); err != nil {
+			return nil, err
+		}
+		report(int64(len(*src.inline)), int64(len(*src.inline)))
+	} else if err := c.fetchResult(ctx, src, part, report); err != nil {
+		return nil, err
+	}
+
+	verified, err := verifyChecksum(part, checksum)
+	if err != nil {
+		// A corrupt part cannot be resumed
+		os.Remove(part)
+		return nil, err
+	}
+	info, err := os.Stat(part)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(part, path); err != nil {
+		return nil, err
+	}
+	return &DownloadedFile{Name: src.name, Path: path, Size: info.Size(), Verified: verified}, nil
+}
+
+// fetchResult downloads src.url into part, continuing from what part holds.
+func (c *Client) fetchResult(ctx context.Context, src resultSource, part string, report func(written, total int64)) error {
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.getResult(ctx, src.url, offset)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return fmt.Errorf("unexpected Content-Range %q resuming at byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		total = size
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The part already holds the whole file
+		report(offset, offset)
+		return nil
+	case resp.StatusCode >= 400:
+		return responseError(resp)
+	default:
+		// The server ignored the range and sent the whole file
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		offset = 0
+	}
+
+	written := offset
+	report(written, total)
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				return err
+			}
+			written += int64(n)
+			report(written, total)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if total >= 0 && written != total {
+		return fmt.Errorf("download ended after %d of %d bytes", written, total)
+	}
+	return f.Close()
+}
+
+// getResult requests a result URL from byte offset on. A request to the
+// API whose token is rejected is sent once more with a refreshed one.
+func (c *Client) getResult(ctx context.Context, rawURL string, offset int64) (*http.Response, error) {
+	for retried := false; ; retried = true {
+		req, err := c.newDownloadRequest(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if offset > 0 {
+			req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		}
+
+		resp, err := c.transferClient().Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		if retried || !c.reauthenticate(req, resp.StatusCode) {
+			return resp, nil
+		}
+		closeBody(resp.Body)
+	}
+}
+
+// transferClient returns the HTTP client for uploads and downloads. They
+// may take longer than the API timeout, so only their context bounds them.
+func (c *Client) transferClient() *http.Client {
+	return &http.Client{
+		Transport:     c.httpClient.Transport,
+		CheckRedirect: c.httpClient.CheckRedirect,
+		Jar:           c.httpClient.Jar,
+	}
+}
+
+// newDownloadRequest builds a GET request for a result URL. Paths are
+// relative to the API, and requests to the API are authenticated; other
+// hosts, such as signed storage URLs and gateways, get no credentials.
+func (c *Client) newDownloadRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	if strings.HasPrefix(rawURL, "/") {
+		rawURL = c.baseURL + rawURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if api, err := url.Parse(c.baseURL); err == nil && api.Host == req.URL.Host {
+		if err := c.setAuthHeaders(req); err != nil {
+			return nil, err
+		}
+		c.setMetadataHeaders(req)
+	}
+	return req, nil
+}
+
+// parseContentRange parses a "bytes start-end/size" Content-Range header.
+// size is -1 when the server does not know it.
+func parseContentRange(header string) (start, size int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, sizeStr, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startStr, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if sizeStr == "*" {
+		return start, -1, true
+	}
+	if size, err = strconv.ParseInt(sizeStr, 10, 64); err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+// verifyChecksum checks the file at path against a hex SHA-256 checksum,
+// reporting whether there was one to check.
+func verifyChecksum(path, checksum string) (bool, error) {
+	if checksum == "" {
+		return false, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != checksum {
+		return false, fmt.Errorf("%w: got sha256 %s, want %s", ErrChecksumMismatch, got, checksum)
+	}
+	return true, nil
+}
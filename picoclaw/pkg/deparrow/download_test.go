@@ -0,0 +1,217 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resultsServer serves job-1 with the given results, and files under
+// /files/ with range support. It records the Range and Authorization
+// headers of file requests.
+func resultsServer(t *testing.T, results *JobResults, files map[string][]byte) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/jobs/job-1" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"job_id": "job-1", "status": "completed", "results": results,
+			})
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/files/")
+		data, ok := files[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		seen = append(seen, name+" range="+r.Header.Get("Range")+" auth="+r.Header.Get("Authorization"))
+		mu.Unlock()
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestDownloadResults(t *testing.T) {
+	stdout := []byte("hello from the job\n")
+	model := bytes.Repeat([]byte("weights"), 10000)
+	storage, storageRequests := resultsServer(t, nil, map[string][]byte{"model.bin": model})
+	results := &JobResults{
+		Stderr: "a warning\n",
+		DownloadURLs: map[string]string{
+			"stdout":    "/files/stdout",
+			"model.bin": storage.URL + "/files/model.bin?signature=abc",
+		},
+		Checksums: map[string]string{"model.bin": "sha256:" + sha256Hex(model)},
+	}
+	api, apiRequests := resultsServer(t, results, map[string][]byte{"stdout": stdout})
+	client := NewClient(api.URL, "test-token")
+	dir := t.TempDir()
+
+	var progress []DownloadProgress
+	files, err := client.DownloadResults(context.Background(), "job-1", dir, func(p DownloadProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("DownloadResults() error = %v", err)
+	}
+
+	want := map[string][]byte{"model.bin": model, "stderr": []byte(results.Stderr), "stdout": stdout}
+	if len(files) != len(want) {
+		t.Fatalf("files = %+v, want %d", files, len(want))
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil || !bytes.Equal(data, want[f.Name]) || f.Size != int64(len(want[f.Name])) {
+			t.Errorf("%s saved as %d bytes (%v), want %d", f.Name, len(data), err, len(want[f.Name]))
+		}
+		if f.Verified != (f.Name == "model.bin") {
+			t.Errorf("%s Verified = %v", f.Name, f.Verified)
+		}
+	}
+
+	// Credentials go to the API only, never to signed storage URLs
+	if got := apiRequests(); len(got) != 1 || got[0] != "stdout range= auth=Bearer test-token" {
+		t.Errorf("API file requests = %v", got)
+	}
+	if got := storageRequests(); len(got) != 1 || got[0] != "model.bin range= auth=" {
+		t.Errorf("storage requests = %v", got)
+	}
+
+	last := progress[len(progress)-1]
+	if last.Name != "stdout" || last.Written != int64(len(stdout)) || last.Total != int64(len(stdout)) {
+		t.Errorf("last progress = %+v", last)
+	}
+
+	// Files already saved are not fetched again
+	if _, err := client.DownloadResults(context.Background(), "job-1", dir, nil); err != nil {
+		t.Fatalf("DownloadResults() again error = %v", err)
+	}
+	if len(apiRequests()) != 1 || len(storageRequests()) != 1 {
+		t.Errorf("saved files were downloaded again")
+	}
+}
+
+func TestDownloadResults_Resume(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	results := &JobResults{
+		DownloadURLs: map[string]string{"data.csv": "/files/data.csv"},
+		Checksums:    map[string]string{"data.csv": sha256Hex(data)},
+	}
+	api, requests := resultsServer(t, results, map[string][]byte{"data.csv": data})
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.csv.part"), data[:4000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := NewClient(api.URL, "test-token").DownloadResults(context.Background(), "job-1", dir, nil)
+	if err != nil {
+		t.Fatalf("DownloadResults() error = %v", err)
+	}
+	if got := requests(); len(got) != 1 || !strings.HasPrefix(got[0], "data.csv range=bytes=4000-") {
+		t.Errorf("requests = %v, want a resume from byte 4000", got)
+	}
+	if len(files) != 1 || !files[0].Verified || files[0].Size != int64(len(data)) {
+		t.Errorf("files = %+v", files)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data.csv.part")); !os.IsNotExist(err) {
+		t.Errorf("part file left behind: %v", err)
+	}
+}
+
+func TestDownloadResults_ChecksumMismatch(t *testing.T) {
+	results := &JobResults{
+		DownloadURLs: map[string]string{"out.txt": "/files/out.txt"},
+		Checksums:    map[string]string{"out.txt": sha256Hex([]byte("expected"))},
+	}
+	api, _ := resultsServer(t, results, map[string][]byte{"out.txt": []byte("tampered")})
+	dir := t.TempDir()
+
+	_, err := NewClient(api.URL, "test-token").DownloadResults(context.Background(), "job-1", dir, nil)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("DownloadResults() error = %v, want ErrChecksumMismatch", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("left %d files behind, want none", len(entries))
+	}
+}
+
+func TestDownloadResults_IPFSGateway(t *testing.T) {
+	archive := []byte("tar archive bytes")
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ipfs/bafyoutputs" || r.URL.Query().Get("format") != "tar" {
+			t.Errorf("gateway request = %s", r.URL)
+		}
+		w.Write(archive)
+	}))
+	defer gateway.Close()
+	api, _ := resultsServer(t, &JobResults{OutputCID: "bafyoutputs"}, nil)
+	dir := t.TempDir()
+
+	client := NewClient(api.URL, "test-token", WithIPFSGateway(gateway.URL+"/"))
+	files, err := client.DownloadResults(context.Background(), "job-1", dir, nil)
+	if err != nil {
+		t.Fatalf("DownloadResults() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "outputs" || files[0].Path != filepath.Join(dir, "outputs.tar") {
+		t.Fatalf("files = %+v", files)
+	}
+	if data, _ := os.ReadFile(files[0].Path); !bytes.Equal(data, archive) {
+		t.Errorf("outputs.tar = %q", data)
+	}
+}
+
+func TestDownloadResults_Errors(t *testing.T) {
+	api, _ := resultsServer(t, &JobResults{DownloadURLs: map[string]string{"../escape": "/files/x"}}, nil)
+	if _, err := NewClient(api.URL, "test-token").DownloadResults(context.Background(), "job-1", t.TempDir(), nil); err == nil {
+		t.Error("DownloadResults() should reject names that leave the directory")
+	}
+
+	client := NewSandboxClient()
+	job, _ := client.SubmitJob(context.Background(), &JobSpec{Image: "alpine"})
+	if _, err := client.DownloadResults(context.Background(), job.ID, t.TempDir(), nil); err == nil {
+		t.Error("DownloadResults() should fail for a job without results")
+	}
+}
+
+func TestDownloadResults_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+	job, _ := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	if _, err := client.WaitForJobCompletion(ctx, job.ID, WaitOptions{PollInterval: time.Millisecond}); err != nil {
+		t.Fatalf("WaitForJobCompletion() error = %v", err)
+	}
+
+	files, err := client.DownloadResults(ctx, job.ID, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("DownloadResults() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "stdout" {
+		t.Errorf("files = %+v, want the inline stdout only", files)
+	}
+}
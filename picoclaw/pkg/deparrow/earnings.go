@@ -0,0 +1,210 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// earningsRetention is how long node earnings readings are kept.
+const earningsRetention = 90 * 24 * time.Hour
+
+// earningsTolerance absorbs rounding differences between the node counter
+// and the wallet ledger.
+const earningsTolerance = 0.01
+
+// EarningsReading is a node's cumulative credits_earned at a point in time.
+type EarningsReading struct {
+	At            time.Time `json:"at"`
+	CreditsEarned float64   `json:"credits_earned"`
+}
+
+// SetEarningsLedger attaches the ledger used to remember node earnings between reconciliations.
+func (c *Client) SetEarningsLedger(ledger *EarningsLedger) {
+	c.earnings = ledger
+}
+
+// EarningsLedger remembers each node's credits_earned over time so that
+// the earnings over a period can be computed from the cumulative counter.
+// Readings are kept in a local JSON file.
+type EarningsLedger struct {
+	path     string
+	mu       sync.Mutex
+	readings map[string][]EarningsReading
+}
+
+// NewEarningsLedger creates a ledger backed by the file at path.
+// A missing file is not an error. An empty path keeps readings in memory only.
+func NewEarningsLedger(path string) (*EarningsLedger, error) {
+	l := &EarningsLedger{
+		path:     path,
+		readings: make(map[string][]EarningsReading),
+	}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, fmt.Errorf("failed to read earnings ledger: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &l.readings); err != nil {
+		return nil, fmt.Errorf("failed to parse earnings ledger: %w", err)
+	}
+	return l, nil
+}
+
+// Baseline returns the reading to measure earnings since the given time from:
+// the latest reading taken at or before it, or failing that the earliest
+// reading after it. It returns false when the node has no readings.
+func (l *EarningsLedger) Baseline(nodeID string, since time.Time) (EarningsReading, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	readings := l.readings[nodeID]
+	if len(readings) == 0 {
+		return EarningsReading{}, false
+	}
+
+	// Readings are sorted by time
+	i := sort.Search(len(readings), func(i int) bool {
+		return readings[i].At.After(since)
+	})
+	if i == 0 {
+		return readings[0], true
+	}
+	return readings[i-1], true
+}
+
+// Record adds a reading for a node and saves the ledger.
+// Readings older than the retention period are dropped, but the latest one
+// before the cut-off is kept so long periods still have a baseline.
+func (l *EarningsLedger) Record(nodeID string, reading EarningsReading) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	readings := append(l.readings[nodeID], reading)
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readings[i].At.Before(readings[j].At)
+	})
+
+	cutoff := reading.At.Add(-earningsRetention)
+	first := 0
+	for first < len(readings)-1 && readings[first+1].At.Before(cutoff) {
+		first++
+	}
+	l.readings[nodeID] = readings[first:]
+
+	return l.saveLocked()
+}
+
+// saveLocked writes the ledger to disk. The caller must hold the lock.
+func (l *EarningsLedger) saveLocked() error {
+	if l.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create earnings ledger directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(l.readings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal earnings ledger: %w", err)
+	}
+
+	tempFile := l.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tempFile, l.path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
+
+// NodeReconciliation compares what a node reports it earned with what
+// reached the wallet over the same window.
+type NodeReconciliation struct {
+	NodeID string
+	// Start of the compared window (the baseline reading)
+	Since time.Time
+	// Increase of the node's credits_earned counter since the baseline
+	Reported float64
+	// Sum of wallet earn transactions attributed to the node since the baseline
+	Credited float64
+	// HasBaseline is false on the first reconciliation of a node
+	HasBaseline bool
+}
+
+// Difference returns the reported earnings that did not reach the wallet.
+// A negative value means the wallet received more than the node reported.
+func (r NodeReconciliation) Difference() float64 {
+	return r.Reported - r.Credited
+}
+
+// Matched reports whether the reported and credited earnings agree.
+func (r NodeReconciliation) Matched() bool {
+	return math.Abs(r.Difference()) <= earningsTolerance
+}
+
+// EarningsReconciliation is the result of reconciling node earnings with the wallet.
+type EarningsReconciliation struct {
+	Since time.Time
+	Until time.Time
+	Nodes []NodeReconciliation
+	// Earn transactions in the period that name no node
+	Unattributed float64
+}
+
+// Discrepancies returns the nodes whose earnings do not match the wallet.
+func (r *EarningsReconciliation) Discrepancies() []NodeReconciliation {
+	var out []NodeReconciliation
+	for _, node := range r.Nodes {
+		if node.HasBaseline && !node.Matched() {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+// ReconcileEarnings matches the credits_earned increase of each node since
+// its baseline reading against the wallet earn transactions attributed to it.
+// Nodes without a baseline are listed but not compared.
+func ReconcileEarnings(
+	nodes []Node, baselines map[string]EarningsReading, transactions []Transaction, since, until time.Time,
+) *EarningsReconciliation {
+	report := &EarningsReconciliation{Since: since, Until: until}
+
+	for _, node := range nodes {
+		rec := NodeReconciliation{NodeID: node.ID, Since: since}
+		if baseline, ok := baselines[node.ID]; ok {
+			rec.HasBaseline = true
+			rec.Since = baseline.At
+			rec.Reported = node.CreditsEarned - baseline.CreditsEarned
+			for _, tx := range transactions {
+				if tx.Type == "earn" && tx.NodeID == node.ID && tx.Timestamp.After(baseline.At) && !tx.Timestamp.After(until) {
+					rec.Credited += tx.Amount
+				}
+			}
+		}
+		report.Nodes = append(report.Nodes, rec)
+	}
+
+	for _, tx := range transactions {
+		if tx.Type == "earn" && tx.NodeID == "" && tx.Timestamp.After(since) && !tx.Timestamp.After(until) {
+			report.Unattributed += tx.Amount
+		}
+	}
+
+	return report
+}
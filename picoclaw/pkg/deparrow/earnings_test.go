@@ -0,0 +1,133 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEarningsLedger_Baseline(t *testing.T) {
+	ledger, err := NewEarningsLedger("")
+	if err != nil {
+		t.Fatalf("NewEarningsLedger() error = %v", err)
+	}
+
+	now := time.Now()
+	if _, ok := ledger.Baseline("node-1", now); ok {
+		t.Fatal("Baseline() on empty ledger should report no reading")
+	}
+
+	for i, credits := range []float64{10, 20, 30} {
+		at := now.Add(time.Duration(i-3) * 24 * time.Hour)
+		if err := ledger.Record("node-1", EarningsReading{At: at, CreditsEarned: credits}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	// Latest reading at or before the start of the period
+	got, _ := ledger.Baseline("node-1", now.Add(-36*time.Hour))
+	if got.CreditsEarned != 20 {
+		t.Errorf("Baseline(-36h) = %v, want 20", got.CreditsEarned)
+	}
+
+	// No reading that old, fall back to the earliest
+	got, _ = ledger.Baseline("node-1", now.Add(-30*24*time.Hour))
+	if got.CreditsEarned != 10 {
+		t.Errorf("Baseline(-30d) = %v, want 10", got.CreditsEarned)
+	}
+}
+
+func TestEarningsLedger_Retention(t *testing.T) {
+	ledger, _ := NewEarningsLedger("")
+	now := time.Now()
+
+	ledger.Record("node-1", EarningsReading{At: now.Add(-200 * 24 * time.Hour), CreditsEarned: 1})
+	ledger.Record("node-1", EarningsReading{At: now.Add(-100 * 24 * time.Hour), CreditsEarned: 2})
+	ledger.Record("node-1", EarningsReading{At: now.Add(-10 * 24 * time.Hour), CreditsEarned: 3})
+	ledger.Record("node-1", EarningsReading{At: now, CreditsEarned: 4})
+
+	readings := ledger.readings["node-1"]
+	if len(readings) != 3 {
+		t.Fatalf("readings = %d, want 3", len(readings))
+	}
+	// The latest reading before the cut-off is kept as a baseline
+	if readings[0].CreditsEarned != 2 {
+		t.Errorf("oldest kept reading = %v, want 2", readings[0].CreditsEarned)
+	}
+}
+
+func TestEarningsLedger_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "earnings.json")
+
+	ledger, err := NewEarningsLedger(path)
+	if err != nil {
+		t.Fatalf("NewEarningsLedger() error = %v", err)
+	}
+	at := time.Now().Add(-time.Hour).UTC()
+	if err := ledger.Record("node-1", EarningsReading{At: at, CreditsEarned: 42}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reloaded, err := NewEarningsLedger(path)
+	if err != nil {
+		t.Fatalf("NewEarningsLedger() reload error = %v", err)
+	}
+	got, ok := reloaded.Baseline("node-1", time.Now())
+	if !ok || got.CreditsEarned != 42 || !got.At.Equal(at) {
+		t.Errorf("Baseline() after reload = %+v, %v", got, ok)
+	}
+}
+
+func TestReconcileEarnings(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-7 * 24 * time.Hour)
+	baselineAt := now.Add(-3 * 24 * time.Hour)
+
+	nodes := []Node{
+		{ID: "node-ok", CreditsEarned: 150},
+		{ID: "node-short", CreditsEarned: 80},
+		{ID: "node-new", CreditsEarned: 10},
+	}
+	baselines := map[string]EarningsReading{
+		"node-ok":    {At: baselineAt, CreditsEarned: 100},
+		"node-short": {At: baselineAt, CreditsEarned: 50},
+	}
+	transactions := []Transaction{
+		{Type: "earn", NodeID: "node-ok", Amount: 30, Timestamp: now.Add(-2 * 24 * time.Hour)},
+		{Type: "earn", NodeID: "node-ok", Amount: 20, Timestamp: now.Add(-time.Hour)},
+		{Type: "earn", NodeID: "node-short", Amount: 25, Timestamp: now.Add(-time.Hour)},
+		// Before the baseline, not part of the compared window
+		{Type: "earn", NodeID: "node-short", Amount: 5, Timestamp: now.Add(-4 * 24 * time.Hour)},
+		{Type: "spend", NodeID: "node-short", Amount: 5, Timestamp: now.Add(-time.Hour)},
+		{Type: "earn", Amount: 7, Timestamp: now.Add(-time.Hour)},
+	}
+
+	report := ReconcileEarnings(nodes, baselines, transactions, since, now)
+	if len(report.Nodes) != 3 {
+		t.Fatalf("Nodes = %d, want 3", len(report.Nodes))
+	}
+
+	ok := report.Nodes[0]
+	if !ok.HasBaseline || !ok.Matched() || ok.Reported != 50 || ok.Credited != 50 {
+		t.Errorf("node-ok = %+v, want matched 50/50", ok)
+	}
+
+	short := report.Nodes[1]
+	if short.Matched() || short.Difference() != 5 {
+		t.Errorf("node-short difference = %v, want 5", short.Difference())
+	}
+
+	if report.Nodes[2].HasBaseline {
+		t.Error("node-new should have no baseline")
+	}
+
+	discrepancies := report.Discrepancies()
+	if len(discrepancies) != 1 || discrepancies[0].NodeID != "node-short" {
+		t.Errorf("Discrepancies() = %+v, want node-short", discrepancies)
+	}
+	if report.Unattributed != 7 {
+		t.Errorf("Unattributed = %v, want 7", report.Unattributed)
+	}
+}
@@ -0,0 +1,231 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// Errors an *APIError matches with errors.Is, so callers can branch on the
+// kind of failure instead of matching messages:
+//
+//	if errors.Is(err, deparrow.ErrInsufficientCredits) { ... }
+//
+// They are matched by the error_code field of the error payload and, when
+// the server leaves it out, by the response status and the requested path.
+var (
+	ErrInsufficientCredits = errors.New("insufficient credits")
+	ErrJobNotFound         = errors.New("job not found")
+	ErrNodeNotFound        = errors.New("node not found")
+	ErrNodeOffline         = errors.New("node offline")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrUnauthorized        = errors.New("unauthorized")
+)
+
+// ErrUnsupportedFeature is matched by an *UnsupportedFeatureError.
+var ErrUnsupportedFeature = errors.New("unsupported feature")
+
+// UnsupportedFeatureError is returned, without sending the request, for a
+// request using a field the server does not support.
+type UnsupportedFeatureError struct {
+	// Feature the field needs, e.g. FeatureVerifiedJobs
+	Feature string
+	// Field of the request, e.g. "spec.verification"
+	Field string
+	// API version the server advertised; empty for servers that predate
+	// version negotiation
+	APIVersion string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedFeatureError) Error() string {
+	server := "the server"
+	if e.APIVersion != "" {
+		server += " (API " + e.APIVersion + ")"
+	}
+	return fmt.Sprintf("%s needs the %s feature, which %s does not support", e.Field, e.Feature, server)
+}
+
+// Is makes errors.Is(err, ErrUnsupportedFeature) match.
+func (e *UnsupportedFeatureError) Is(target error) bool {
+	return target == ErrUnsupportedFeature
+}
+
+// ErrMalformedResponse is matched by the error for a response body that
+// could not be parsed, such as one cut off mid-way.
+var ErrMalformedResponse = errors.New("malformed response")
+
+// malformedResponseError wraps the error from decoding a response body.
+type malformedResponseError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *malformedResponseError) Error() string {
+	return "failed to parse response: " + e.err.Error()
+}
+
+// Unwrap returns the decoding error.
+func (e *malformedResponseError) Unwrap() error {
+	return e.err
+}
+
+// Is makes errors.Is(err, ErrMalformedResponse) match.
+func (e *malformedResponseError) Is(target error) bool {
+	return target == ErrMalformedResponse
+}
+
+// Error codes the Meta-OS sends in the error_code field of an error payload.
+const (
+	ErrorCodeInsufficientCredits = "insufficient_credits"
+	ErrorCodeJobNotFound         = "job_not_found"
+	ErrorCodeNodeNotFound        = "node_not_found"
+	ErrorCodeNodeOffline         = "node_offline"
+	ErrorCodeRateLimited         = "rate_limited"
+	ErrorCodeUnauthorized        = "unauthorized"
+)
+
+// errorCodes maps error codes to the errors they match.
+var errorCodes = map[string]error{
+	ErrorCodeInsufficientCredits: ErrInsufficientCredits,
+	ErrorCodeJobNotFound:         ErrJobNotFound,
+	ErrorCodeNodeNotFound:        ErrNodeNotFound,
+	ErrorCodeNodeOffline:         ErrNodeOffline,
+	ErrorCodeRateLimited:         ErrRateLimited,
+	ErrorCodeUnauthorized:        ErrUnauthorized,
+}
+
+// Is reports whether the API error is of the kind target names, so that
+// errors.Is(err, ErrJobNotFound) works on wrapped API errors.
+func (e *APIError) Is(target error) bool {
+	if e.ErrorCode != "" {
+		return errorCodes[e.ErrorCode] == target
+	}
+	switch e.Code {
+	case http.StatusPaymentRequired:
+		return target == ErrInsufficientCredits
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	}
+	return false
+}
+
+// inferErrorCode returns the error code of a failure the server sent
+// without one, from its status and the path of the request. Only codes
+// the status alone can't tell apart are inferred.
+func inferErrorCode(status int, path string) string {
+	if status != http.StatusNotFound {
+		return ""
+	}
+	for prefix, code := range map[string]string{
+		"/api/v1/jobs/":  ErrorCodeJobNotFound,
+		"/api/v1/nodes/": ErrorCodeNodeNotFound,
+	} {
+		i := strings.Index(path, prefix)
+		if i < 0 {
+			continue
+		}
+		id, _, _ := strings.Cut(path[i+len(prefix):], "/")
+		// A missing collection endpoint means an older server, not a missing resource
+		if id != "" && !collectionEndpoints[prefix+id] {
+			return code
+		}
+	}
+	return ""
+}
+
+// collectionEndpoints are the paths under /jobs/ and /nodes/ that don't
+// name a single resource.
+var collectionEndpoints = map[string]bool{
+	"/api/v1/jobs/submit": true,
+	jobEstimatePath:       true,
+	jobArchivePath:        true,
+	nodeRegisterPath:      true,
+}
+
+// parseRetryAfter reads a Retry-After header, given in seconds or as an
+// HTTP date. It returns zero when the header is absent or malformed.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// RetryAfter returns how long the server asked to wait before retrying a
+// request that failed with err, and whether it asked at all.
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// failureResult reports a failed API call to the agent, with a hint on
+// what to do next for the failures it can act on.
+func failureResult(action string, err error) *tools.ToolResult {
+	msg := fmt.Sprintf("Failed to %s: %v", action, err)
+	switch {
+	case errors.Is(err, ErrInsufficientCredits):
+		msg += "\nCheck your balance with 'deparrow_credits', buy credits with 'deparrow_wallet' (action 'top_up') or earn more with 'deparrow_how_to_earn'."
+	case errors.Is(err, ErrRateLimited):
+		if wait, ok := RetryAfter(err); ok {
+			msg += fmt.Sprintf("\nThe network is limiting requests; try again in %s.", formatDuration(wait.Round(time.Minute)))
+		} else {
+			msg += "\nThe network is limiting requests; try again shortly."
+		}
+	case errors.Is(err, ErrJobNotFound):
+		msg += "\nCheck the job ID with 'deparrow_list_jobs'."
+	case errors.Is(err, ErrNodeNotFound):
+		msg += "\nCheck the node ID with 'deparrow_nodes'."
+	case errors.Is(err, ErrNodeOffline):
+		msg += "\nThe node is offline; pick an online one with 'deparrow_nodes'."
+	case errors.Is(err, ErrUnauthorized):
+		msg += "\nThe DEparrow credentials were rejected; ask the user to log in again."
+	case errors.Is(err, ErrUnsupportedFeature):
+		var unsupported *UnsupportedFeatureError
+		errors.As(err, &unsupported)
+		msg += fmt.Sprintf("\nThis DEparrow server is too old for %s; try again without it.", unsupported.Field)
+	case isServerError(err):
+		var apiErr *APIError
+		errors.As(err, &apiErr)
+		msg += fmt.Sprintf("\nThe DEparrow API is failing (HTTP %d); try again shortly.", apiErr.Code) + appliedHint
+	case errors.Is(err, ErrMalformedResponse):
+		msg += "\nThe DEparrow API sent a response that was cut off or garbled; try again." + appliedHint
+	case isTimeout(err):
+		msg += "\nThe DEparrow API did not answer in time; check it with 'deparrow_health' and try again." + appliedHint
+	}
+	return tools.ErrorResult(msg)
+}
+
+// appliedHint warns that a request whose outcome is unknown may still
+// have been carried out.
+const appliedHint = "\nIf this was a submission, check 'deparrow_list_jobs' before submitting again; it may have gone through."
+
+// isServerError reports whether err is a 5xx response from the API.
+func isServerError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code >= http.StatusInternalServerError
+}
+
+// isTimeout reports whether err is a request that timed out.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,162 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/jobs/submit":
+			// Older servers reject with 400 and only the payload tells why
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Insufficient credits", "error_code": ErrorCodeInsufficientCredits})
+		case "/api/v1/nodes/node-1":
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Node is offline", "error_code": ErrorCodeNodeOffline})
+		case "/api/v1/credits/transfer":
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Not enough credits"})
+		case "/api/v1/credits":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, "slow down")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Not found"})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	ctx := context.Background()
+
+	_, err := client.SubmitJob(ctx, &JobSpec{Image: "ubuntu:22.04"})
+	if !errors.Is(err, ErrInsufficientCredits) || errors.Is(err, ErrJobNotFound) {
+		t.Errorf("SubmitJob() error = %v, want ErrInsufficientCredits from the payload", err)
+	}
+	if _, err := client.GetNode(ctx, "node-1"); !errors.Is(err, ErrNodeOffline) {
+		t.Errorf("GetNode() error = %v, want ErrNodeOffline", err)
+	}
+	if err := client.TransferCredits(ctx, "teammate", 10); !errors.Is(err, ErrInsufficientCredits) {
+		t.Errorf("TransferCredits() error = %v, want ErrInsufficientCredits from the status", err)
+	}
+
+	// A 404 names the missing resource from the path
+	if _, err := client.GetJob(ctx, "job-404"); !errors.Is(err, ErrJobNotFound) || errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("GetJob() error = %v, want ErrJobNotFound", err)
+	}
+	if _, err := client.GetNode(ctx, "node-404"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("GetNode() error = %v, want ErrNodeNotFound", err)
+	}
+	// but a missing endpoint is not a missing job
+	if _, err := client.ListArchivedJobs(ctx, time.Time{}); errors.Is(err, ErrJobNotFound) {
+		t.Errorf("ListArchivedJobs() error = %v, should not be ErrJobNotFound", err)
+	}
+
+	// Last, as the client holds back further requests for the 30s asked
+	_, err = client.GetCredits(ctx)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("GetCredits() error = %v, want ErrRateLimited", err)
+	}
+	if wait, ok := RetryAfter(err); !ok || wait != 30*time.Second {
+		t.Errorf("RetryAfter() = %s, %v; want 30s", wait, ok)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-5":                            0,
+		"Fri, 16 Oct 2026 12:01:30 GMT": 90 * time.Second,
+		"Fri, 16 Oct 2026 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for header, want := range tests {
+		if got := parseRetryAfter(header, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestClient_RetryHonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var retryAfter atomic.Value
+	retryAfter.Store("1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter.Load().(string))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		RetryOnStatus:  []int{http.StatusTooManyRequests},
+	}))
+
+	start := time.Now()
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the 1s the server asked for", elapsed)
+	}
+
+	// A wait beyond MaxBackoff is left to the caller
+	calls.Store(0)
+	retryAfter.Store("60")
+	_, err := client.Health(context.Background())
+	if !errors.Is(err, ErrRateLimited) || calls.Load() != 1 {
+		t.Errorf("Health() error = %v after %d calls, want ErrRateLimited without a retry", err, calls.Load())
+	}
+}
+
+func TestFailureResult_Hints(t *testing.T) {
+	client := NewSandboxClient()
+	result := NewJobStatusTool(client).Execute(context.Background(), map[string]interface{}{"job_id": "missing"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "Failed to get job") || !strings.Contains(result.ForLLM, "deparrow_list_jobs") {
+		t.Errorf("unknown job result:\n%s", result.ForLLM)
+	}
+
+	tests := map[string]struct {
+		err  error
+		hint string
+	}{
+		"credits":   {&APIError{Code: http.StatusPaymentRequired, Message: "Insufficient credits"}, "deparrow_credits"},
+		"limited":   {&APIError{Code: http.StatusTooManyRequests, RetryAfter: 90 * time.Second}, "try again in 2 minutes"},
+		"offline":   {fmt.Errorf("booking: %w", &APIError{Code: http.StatusConflict, ErrorCode: ErrorCodeNodeOffline}), "offline"},
+		"outage":    {&APIError{Code: http.StatusInternalServerError}, "failing (HTTP 500)"},
+		"garbled":   {&malformedResponseError{io.ErrUnexpectedEOF}, "cut off"},
+		"timeout":   {fmt.Errorf("get: %w", context.DeadlineExceeded), "deparrow_health"},
+		"no advice": {errors.New("boom"), ""},
+	}
+	for name, tt := range tests {
+		msg := failureResult("do it", tt.err).ForLLM
+		if !strings.HasPrefix(msg, "Failed to do it: ") {
+			t.Errorf("%s: message = %q", name, msg)
+		}
+		if tt.hint == "" && strings.Contains(msg, "\n") || !strings.Contains(msg, tt.hint) {
+			t.Errorf("%s: message = %q, want hint %q", name, msg, tt.hint)
+		}
+	}
+}
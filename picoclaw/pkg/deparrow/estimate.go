@@ -0,0 +1,69 @@
+package deparrow
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const jobEstimatePath = "/api/v1/jobs/estimate"
+
+// JobEstimate is what submitting a job is expected to cost and how soon it
+// would start, worked out without submitting it.
+type JobEstimate struct {
+	// Projected credit cost, verification premium included
+	CreditCost float64
+	// Online nodes able to run the job, or -1 when unknown
+	EligibleNodes int
+	// Expected wait before the job starts, or -1 when unknown
+	QueueTime time.Duration
+	// Set when the server could not estimate and the cost was computed by
+	// the client; node count and queue time are then unknown
+	Local bool
+}
+
+// EstimateJob asks the server what submitting spec would cost, how many
+// nodes could run it and how long it would queue, without submitting it
+// or spending credits. When the server has no estimate endpoint the cost
+// is estimated locally.
+func (c *Client) EstimateJob(ctx context.Context, spec *JobSpec) (*JobEstimate, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	local := &JobEstimate{
+		CreditCost:    calculateCreditCost(spec),
+		EligibleNodes: -1,
+		QueueTime:     -1,
+		Local:         true,
+	}
+	if c.estimateUnsupported.Load() || !c.offers(FeatureEstimates) {
+		return local, nil
+	}
+
+	var result struct {
+		CreditCost    *float64 `json:"credit_cost"`
+		EligibleNodes *int     `json:"eligible_nodes"`
+		QueueSeconds  *float64 `json:"expected_queue_seconds"`
+	}
+	req := map[string]interface{}{"spec": spec, "credit_cost": local.CreditCost}
+	if err := c.doRequest(ctx, http.MethodPost, jobEstimatePath, req, &result); err != nil {
+		if !endpointUnavailable(err) {
+			return nil, err
+		}
+		c.estimateUnsupported.Store(true)
+		return local, nil
+	}
+
+	// Fields the server leaves out keep the local values
+	estimate := &JobEstimate{CreditCost: local.CreditCost, EligibleNodes: -1, QueueTime: -1}
+	if result.CreditCost != nil {
+		estimate.CreditCost = *result.CreditCost
+	}
+	if result.EligibleNodes != nil {
+		estimate.EligibleNodes = *result.EligibleNodes
+	}
+	if result.QueueSeconds != nil {
+		estimate.QueueTime = time.Duration(*result.QueueSeconds * float64(time.Second))
+	}
+	return estimate, nil
+}
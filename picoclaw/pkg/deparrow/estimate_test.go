@@ -0,0 +1,122 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEstimateJob_Server(t *testing.T) {
+	var sentCost float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != jobEstimatePath {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		var req struct {
+			Spec       *JobSpec `json:"spec"`
+			CreditCost float64  `json:"credit_cost"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		sentCost = req.CreditCost
+		w.Write([]byte(`{"credit_cost": 4.5, "eligible_nodes": 12, "expected_queue_seconds": 90}`))
+	}))
+	defer server.Close()
+
+	spec := &JobSpec{Image: "alpine", Resources: &ResourceSpec{CPU: "1", Memory: "1Gi"}}
+	estimate, err := NewClient(server.URL, "test-token").EstimateJob(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("EstimateJob() error = %v", err)
+	}
+	if sentCost != calculateCreditCost(spec) {
+		t.Errorf("sent credit_cost = %v, want the local estimate", sentCost)
+	}
+	if estimate.CreditCost != 4.5 || estimate.EligibleNodes != 12 || estimate.QueueTime != 90*time.Second || estimate.Local {
+		t.Errorf("estimate = %+v", estimate)
+	}
+}
+
+func TestEstimateJob_LocalFallback(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "not found"}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "test-token")
+
+	spec := &JobSpec{Image: "alpine", Resources: &ResourceSpec{GPU: "1"}, Timeout: 7200}
+	for i := 0; i < 2; i++ {
+		estimate, err := client.EstimateJob(context.Background(), spec)
+		if err != nil {
+			t.Fatalf("EstimateJob() error = %v", err)
+		}
+		if !estimate.Local || estimate.CreditCost != calculateCreditCost(spec) ||
+			estimate.EligibleNodes != -1 || estimate.QueueTime != -1 {
+			t.Errorf("estimate = %+v, want the local estimate", estimate)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want the missing endpoint asked once", calls.Load())
+	}
+
+	if _, err := client.EstimateJob(context.Background(), &JobSpec{Image: "alpine", Timeout: -1}); err == nil {
+		t.Error("EstimateJob() should reject an invalid spec")
+	}
+}
+
+func TestEstimateJob_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	estimate, err := client.EstimateJob(ctx, &JobSpec{Image: "alpine", Resources: &ResourceSpec{CPU: "64", GPU: "8"}})
+	if err != nil {
+		t.Fatalf("EstimateJob() error = %v", err)
+	}
+	if estimate.EligibleNodes != 2 || estimate.QueueTime != 0 {
+		t.Errorf("estimate = %+v, want the two 8-GPU nodes free now", estimate)
+	}
+
+	estimate, _ = client.EstimateJob(ctx, &JobSpec{Image: "alpine", Resources: &ResourceSpec{Memory: "2Ti"}})
+	if estimate.EligibleNodes != 0 {
+		t.Errorf("EligibleNodes = %d, want no node with 2Ti of memory", estimate.EligibleNodes)
+	}
+
+	if jobs, _ := client.ListJobs(ctx); len(jobs) != 0 {
+		t.Errorf("estimating submitted %d jobs", len(jobs))
+	}
+}
+
+func TestEstimateTool(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewEstimateTool(client)
+
+	if _, ok := tool.Parameters()["properties"].(map[string]interface{})["wait"]; ok {
+		t.Error("the estimate tool should not offer 'wait'")
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"image":  "python:3.11-slim",
+		"cpu":    "2",
+		"memory": "4Gi",
+	})
+	if result.IsError {
+		t.Fatalf("Execute() error = %s", result.ForLLM)
+	}
+	for _, want := range []string{"Estimated Cost:", "Eligible Nodes: 6", "starts right away", "Nothing was submitted"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError {
+		t.Error("Execute() without an image should fail")
+	}
+}
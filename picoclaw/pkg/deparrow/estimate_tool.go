@@ -0,0 +1,130 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// EstimateTool shows what a job would cost and how soon it would start,
+// without submitting it.
+type EstimateTool struct {
+	client *Client
+}
+
+// NewEstimateTool creates a new job estimate tool.
+func NewEstimateTool(client *Client) *EstimateTool {
+	return &EstimateTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *EstimateTool) Name() string {
+	return "deparrow_estimate"
+}
+
+// Description returns the tool description.
+func (t *EstimateTool) Description() string {
+	return `Estimate a DEparrow job before submitting it (a dry run).
+
+Takes the same parameters as 'deparrow_submit_job' and reports the
+projected credit cost, how many online nodes can run the job and how long
+it is expected to wait in the queue. Nothing is submitted and no credits
+are spent.
+
+Use this to show the user the cost of a job before committing to it, or
+to compare variants of a job (e.g. with and without a GPU).
+`
+}
+
+// Parameters returns the JSON schema for tool parameters: those of the job
+// submission tool that shape the job.
+func (t *EstimateTool) Parameters() map[string]interface{} {
+	params := NewJobTool(t.client).Parameters()
+	properties := params["properties"].(map[string]interface{})
+	delete(properties, "orchestrator")
+	delete(properties, "wait")
+	return params
+}
+
+// Execute runs the job estimate tool.
+func (t *EstimateTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	spec, err := buildJobSpec(t.client, args)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+
+	estimate, err := t.client.EstimateJob(ctx, spec)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to estimate job: %v", err))
+	}
+
+	var result strings.Builder
+	result.WriteString("🧮 DEparrow Job Estimate\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(fmt.Sprintf("Image: %s\n", spec.Image))
+	result.WriteString(fmt.Sprintf("Resources: %s\n\n", formatSpecResources(spec)))
+
+	result.WriteString(fmt.Sprintf("Estimated Cost: %.2f credits\n", estimate.CreditCost))
+	if estimate.EligibleNodes >= 0 {
+		result.WriteString(fmt.Sprintf("Eligible Nodes: %d\n", estimate.EligibleNodes))
+	} else {
+		result.WriteString("Eligible Nodes: unknown\n")
+	}
+	result.WriteString(fmt.Sprintf("Expected Queue Time: %s\n", formatQueueTime(estimate.QueueTime)))
+
+	if estimate.Local {
+		result.WriteString("\nℹ️  The network could not estimate this job; the cost was estimated locally.\n")
+	}
+	if estimate.EligibleNodes == 0 {
+		result.WriteString("\n⚠️  No online node can run this job right now. Reduce its resources or try again later.\n")
+	}
+	if limit := t.client.Preferences().MaxJobCost; limit > 0 && estimate.CreditCost > limit {
+		result.WriteString(fmt.Sprintf("\n⚠️  This exceeds your maximum of %.2f credits per job; submitting it will be refused.\n", limit))
+	}
+
+	result.WriteString("\nNothing was submitted. Use 'deparrow_submit_job' with the same parameters to run it.")
+	return tools.UserResult(result.String())
+}
+
+// formatSpecResources renders a spec's resource requests.
+func formatSpecResources(spec *JobSpec) string {
+	r := spec.Resources
+	if r == nil {
+		return "defaults"
+	}
+	var parts []string
+	if r.CPU != "" {
+		parts = append(parts, r.CPU+" CPU")
+	}
+	if r.Memory != "" {
+		parts = append(parts, r.Memory+" memory")
+	}
+	if r.GPU != "" && r.GPU != "0" {
+		parts = append(parts, r.GPU+" GPU")
+	}
+	if r.Storage != "" {
+		parts = append(parts, r.Storage+" storage")
+	}
+	if len(parts) == 0 {
+		return "defaults"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatQueueTime renders an expected queue time.
+func formatQueueTime(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "unknown"
+	case d < time.Minute:
+		return "starts right away"
+	default:
+		return "about " + formatDuration(d.Round(time.Minute))
+	}
+}
+
+// Ensure tool implements the Tool interface
+var _ tools.Tool = (*EstimateTool)(nil)
@@ -0,0 +1,476 @@
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventsPath is the WebSocket pushing real-time events.
+	eventsPath = "/api/v1/ws"
+
+	// eventsPollPath is the long-poll fallback for eventsPath.
+	eventsPollPath = "/api/v1/events/poll"
+
+	// eventsBuffer is how many events are held for a slow consumer.
+	eventsBuffer = 64
+
+	// eventsPollWait is how long the server holds an event poll while
+	// nothing is new.
+	eventsPollWait = 25 * time.Second
+
+	// eventsWebSocketFailures is how many WebSocket connections in a row
+	// may fail without delivering anything before the stream settles for
+	// long-polling, as on networks that drop held connections.
+	eventsWebSocketFailures = 2
+)
+
+// WithLongPolling makes real-time features long-poll instead of holding a
+// WebSocket open, for devices and networks that cannot keep one.
+func WithLongPolling() ClientOption {
+	return func(c *Client) {
+		c.longPoll = true
+	}
+}
+
+// Event channels to subscribe to.
+const (
+	EventChannelJobs    = "jobs"
+	EventChannelNodes   = "nodes"
+	EventChannelAgents  = "agents"
+	EventChannelMetrics = "metrics"
+)
+
+// EventReset is the type of the event sent in place of events that were
+// missed for good, e.g. while the client was away longer than the server
+// keeps events. Whatever state the consumer built from events should be
+// refetched.
+const EventReset = "reset"
+
+// Event is a real-time update from the Meta-OS.
+type Event struct {
+	// ID orders the events; resume after it with EventOptions.After.
+	// Zero for EventReset
+	ID uint64
+	// Type of the update, e.g. "job_submitted"
+	Type    string
+	Channel string
+	// Data is the whole update as sent, for decoding its type's fields
+	Data json.RawMessage
+}
+
+// eventMessage is the part of an update the stream reads itself.
+type eventMessage struct {
+	EventID uint64 `json:"event_id"`
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	// Set on the WebSocket's connected message: the latest event ID
+	Cursor *uint64 `json:"cursor"`
+}
+
+// eventPollResponse is one answer of the long-poll endpoint.
+type eventPollResponse struct {
+	Events []json.RawMessage `json:"events"`
+	Cursor uint64            `json:"cursor"`
+	Reset  bool              `json:"reset"`
+}
+
+// EventOptions selects the events a subscription receives.
+type EventOptions struct {
+	// Channels to follow; empty follows jobs, nodes and agents
+	Channels []string
+	// After resumes after the event with this ID, as from a previous
+	// stream's Cursor; zero starts with the events from now on
+	After uint64
+}
+
+// EventStream follows the Meta-OS's real-time events.
+type EventStream struct {
+	client   *Client
+	channels []string
+	events   chan Event
+	done     chan struct{}
+	cancel   context.CancelFunc
+	closed   atomic.Bool
+	// ID of the last event delivered or skipped
+	cursor atomic.Uint64
+	// Set once the cursor marks a point in the server's events; until
+	// then the stream starts with whatever happens next
+	positioned bool
+	err        error
+}
+
+// SubscribeEvents follows real-time events until ctx is cancelled or the
+// stream is closed. Events are pushed over a WebSocket; servers without
+// one, networks that keep dropping it and clients set up WithLongPolling
+// long-poll instead. Dropped connections are retried with exponential
+// backoff and resume after the last event seen, so none are missed while
+// the server still keeps them.
+//
+// Example:
+//
+//	stream, err := client.SubscribeEvents(ctx, deparrow.EventOptions{
+//	    Channels: []string{deparrow.EventChannelJobs},
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	defer stream.Close()
+//	for event := range stream.Events() {
+//	    fmt.Println(event.Type)
+//	}
+func (c *Client) SubscribeEvents(ctx context.Context, opts EventOptions) (*EventStream, error) {
+	channels := opts.Channels
+	if len(channels) == 0 {
+		channels = []string{EventChannelJobs, EventChannelNodes, EventChannelAgents}
+	}
+	for _, channel := range channels {
+		if channel == "" || strings.Contains(channel, ",") {
+			return nil, fmt.Errorf("invalid event channel %q", channel)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &EventStream{
+		client:   c,
+		channels: channels,
+		events:   make(chan Event, eventsBuffer),
+		done:     make(chan struct{}),
+		cancel:   cancel,
+	}
+	s.cursor.Store(opts.After)
+	s.positioned = opts.After > 0
+	go s.run(ctx)
+	return s, nil
+}
+
+// Events returns the events in order. It is closed when the stream ends.
+func (s *EventStream) Events() <-chan Event {
+	return s.events
+}
+
+// Cursor returns the ID of the last event the stream has seen, to resume
+// after with EventOptions.After. It is zero until then, unless the stream
+// was resumed.
+func (s *EventStream) Cursor() uint64 {
+	return s.cursor.Load()
+}
+
+// Err returns why the stream ended, once Events is closed. It is nil when
+// the stream was closed.
+func (s *EventStream) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close stops following events and releases the connection.
+func (s *EventStream) Close() error {
+	s.closed.Store(true)
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// run follows the events, reconnecting after failures, then closes Events.
+func (s *EventStream) run(ctx context.Context) {
+	useWebSocket := !s.client.IsSandbox() && !s.client.longPoll && s.client.offers(FeatureWebSocket)
+	backoff := watchBackoffMin
+	failures := 0
+	socketFailures := 0
+
+	var err error
+	for {
+		cursor := s.cursor.Load()
+		if useWebSocket {
+			err = s.webSocket(ctx)
+			if errors.Is(err, errWebSocketUnsupported) {
+				useWebSocket = false
+				continue
+			}
+		} else {
+			err = s.longPoll(ctx)
+		}
+		if ctx.Err() != nil || !retryableError(err) {
+			break
+		}
+
+		// A connection that delivered news was healthy for a while
+		if s.cursor.Load() != cursor {
+			failures, socketFailures, backoff = 0, 0, watchBackoffMin
+		}
+		failures++
+		if useWebSocket {
+			socketFailures++
+			if socketFailures >= eventsWebSocketFailures {
+				useWebSocket = false
+			}
+		}
+		if failures > watchMaxRetries {
+			err = fmt.Errorf("gave up following events after %d attempts: %w", failures, err)
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		backoff = min(backoff*2, watchBackoffMax)
+	}
+
+	if ctx.Err() != nil {
+		err = ctx.Err()
+		if s.closed.Load() {
+			err = nil
+		}
+	}
+	s.cancel()
+	s.err = err
+	close(s.done)
+	close(s.events)
+}
+
+// eventControlTypes are WebSocket messages about the connection itself
+// rather than events.
+var eventControlTypes = map[string]bool{
+	"connected": true, "subscribed": true, "unsubscribed": true, "pong": true, "error": true,
+}
+
+// deliver hands an event to the consumer. Events at or before the cursor,
+// as repeated after a catch-up poll, are dropped. Servers that predate
+// event IDs send events without one, which are passed on as they come.
+func (s *EventStream) deliver(ctx context.Context, raw json.RawMessage) error {
+	var msg eventMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return &malformedResponseError{err}
+	}
+	event := Event{ID: msg.EventID, Type: msg.Type, Channel: msg.Channel, Data: raw}
+	if msg.EventID == 0 {
+		if eventControlTypes[msg.Type] {
+			return nil
+		}
+		return s.send(ctx, event, s.cursor.Load())
+	}
+	if msg.EventID <= s.cursor.Load() {
+		return nil
+	}
+	return s.send(ctx, event, msg.EventID)
+}
+
+// reset tells the consumer events were missed and moves the cursor on.
+func (s *EventStream) reset(ctx context.Context, cursor uint64) error {
+	return s.send(ctx, Event{Type: EventReset, Data: json.RawMessage(`{}`)}, cursor)
+}
+
+// send queues an event and moves the cursor to cursor.
+func (s *EventStream) send(ctx context.Context, event Event, cursor uint64) error {
+	select {
+	case s.events <- event:
+		s.cursor.Store(cursor)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// webSocket follows the events over one WebSocket connection.
+func (s *EventStream) webSocket(ctx context.Context) error {
+	u, err := url.Parse(s.client.baseURL + eventsPath)
+	if err != nil {
+		return fmt.Errorf("invalid API URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.RawQuery = url.Values{"channels": {strings.Join(s.channels, ",")}}.Encode()
+
+	// Reuse the regular request headers for authentication and metadata
+	req, err := s.client.newRequest(ctx, http.MethodGet, "", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Del("Content-Type")
+	req.Header.Del("Accept-Encoding")
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: s.client.httpClient.Timeout,
+	}
+	conn, resp, err := dialer.DialContext(ctx, u.String(), req.Header)
+	if err != nil {
+		if resp == nil {
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+		defer closeBody(resp.Body)
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return responseError(resp)
+		case resp.StatusCode == http.StatusSwitchingProtocols:
+			return fmt.Errorf("failed to connect: %w", err)
+		case resp.StatusCode >= 500:
+			return responseError(resp)
+		}
+		return errWebSocketUnsupported
+	}
+	defer conn.Close()
+
+	// Unblock the read below when the stream is cancelled
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(watchIdleTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(watchIdleTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("event connection lost: %w", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(watchIdleTimeout))
+
+		var msg eventMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return &malformedResponseError{err}
+		}
+		if msg.Cursor != nil {
+			// The WebSocket only pushes what happens from now on, so fetch
+			// what happened while the stream was away
+			if err := s.catchUp(ctx, *msg.Cursor); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.deliver(ctx, raw); err != nil {
+			return err
+		}
+	}
+}
+
+// catchUp delivers the events between the cursor and latest, the newest
+// event when the WebSocket connected. A fresh stream has nothing to catch
+// up on and starts from latest.
+func (s *EventStream) catchUp(ctx context.Context, latest uint64) error {
+	if !s.positioned {
+		s.positioned = true
+		s.cursor.Store(latest)
+		return nil
+	}
+	if s.cursor.Load() == latest {
+		return nil
+	}
+
+	result, err := s.poll(ctx, s.client.httpClient, 0)
+	if endpointUnavailable(err) {
+		return s.reset(ctx, latest)
+	}
+	if err != nil {
+		return err
+	}
+	return s.deliverPoll(ctx, result)
+}
+
+// longPoll follows the events by asking for those after the cursor, which
+// the server holds back for up to eventsPollWait until there are some.
+func (s *EventStream) longPoll(ctx context.Context) error {
+	// The request is held open on purpose, so the client timeout must
+	// not cut it short
+	polling := *s.client.httpClient
+	polling.Timeout = 0
+
+	for {
+		pollCtx, cancel := context.WithTimeout(ctx, eventsPollWait+s.client.httpClient.Timeout)
+		result, err := s.poll(pollCtx, &polling, eventsPollWait)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if err := s.deliverPoll(ctx, result); err != nil {
+			return err
+		}
+
+		if len(result.Events) == 0 {
+			select {
+			case <-time.After(watchPollInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// deliverPoll delivers the events of a poll and moves the cursor past
+// them.
+func (s *EventStream) deliverPoll(ctx context.Context, result *eventPollResponse) error {
+	if result.Reset {
+		if err := s.reset(ctx, s.cursor.Load()); err != nil {
+			return err
+		}
+	}
+	for _, raw := range result.Events {
+		if err := s.deliver(ctx, raw); err != nil {
+			return err
+		}
+	}
+	// Skip the events on other channels
+	if result.Cursor > s.cursor.Load() || result.Reset {
+		s.cursor.Store(result.Cursor)
+	}
+	s.positioned = true
+	return nil
+}
+
+// poll asks once for the events after the cursor, held for up to wait.
+func (s *EventStream) poll(ctx context.Context, httpClient *http.Client, wait time.Duration) (*eventPollResponse, error) {
+	query := url.Values{
+		"channels": {strings.Join(s.channels, ",")},
+		"wait":     {strconv.Itoa(int(wait / time.Second))},
+	}
+	if s.positioned {
+		query.Set("cursor", strconv.FormatUint(s.cursor.Load(), 10))
+	}
+
+	req, err := s.client.newRequest(ctx, http.MethodGet, eventsPollPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.do(httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp.Body)
+
+	var result eventPollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, &malformedResponseError{err}
+	}
+	return &result, nil
+}
@@ -0,0 +1,202 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// pollAnswer is what the fake events server answers a poll with, given
+// the cursor the poll sent ("" for none).
+type pollAnswer func(cursor string) eventPollResponse
+
+// newEventsServer serves the event WebSocket with serve, or 404 when serve
+// is nil, and the long-poll endpoint with poll.
+func newEventsServer(t *testing.T, serve func(conn *websocket.Conn, n int), poll pollAnswer) *httptest.Server {
+	t.Helper()
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-token" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		switch r.URL.Path {
+		case eventsPath:
+			if serve == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if got := r.URL.Query().Get("channels"); got != "jobs" {
+				t.Errorf("channels = %q", got)
+			}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("Upgrade() error = %v", err)
+				return
+			}
+			defer conn.Close()
+			serve(conn, int(connections.Add(1)))
+		case eventsPollPath:
+			cursor := ""
+			if r.URL.Query().Has("cursor") {
+				cursor = r.URL.Query().Get("cursor")
+			}
+			json.NewEncoder(w).Encode(poll(cursor))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// rawEvent encodes an event as the server sends it.
+func rawEvent(id uint64, eventType string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"event_id": %d, "type": %q, "channel": "jobs"}`, id, eventType))
+}
+
+// nextEvents reads n events from the stream.
+func nextEvents(t *testing.T, stream *EventStream, n int) []string {
+	t.Helper()
+	var got []string
+	for len(got) < n {
+		select {
+		case event, ok := <-stream.Events():
+			if !ok {
+				t.Fatalf("stream ended after %v: %v", got, stream.Err())
+			}
+			got = append(got, fmt.Sprintf("%d:%s", event.ID, event.Type))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out after %v", got)
+		}
+	}
+	return got
+}
+
+func TestSubscribeEvents_LongPollResumes(t *testing.T) {
+	var mu sync.Mutex
+	var cursors []string
+	server := newEventsServer(t, nil, func(cursor string) eventPollResponse {
+		mu.Lock()
+		cursors = append(cursors, cursor)
+		mu.Unlock()
+		switch cursor {
+		case "":
+			// Nothing new yet: the stream learns where it is
+			return eventPollResponse{Cursor: 4}
+		case "4":
+			return eventPollResponse{Events: []json.RawMessage{rawEvent(5, "job_submitted")}, Cursor: 6}
+		case "6":
+			// The server restarted and lost the events after 6
+			return eventPollResponse{Cursor: 2, Reset: true}
+		case "2":
+			return eventPollResponse{Events: []json.RawMessage{rawEvent(3, "job_update")}, Cursor: 3}
+		default:
+			return eventPollResponse{Cursor: 3}
+		}
+	})
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithLongPolling())
+	stream, err := client.SubscribeEvents(context.Background(), EventOptions{Channels: []string{EventChannelJobs}})
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+	defer stream.Close()
+
+	got := strings.Join(nextEvents(t, stream, 3), ",")
+	if got != "5:job_submitted,0:reset,3:job_update" {
+		t.Errorf("events = %s", got)
+	}
+	mu.Lock()
+	if strings.Join(cursors[:4], ",") != ",4,6,2" {
+		t.Errorf("cursors = %q", cursors)
+	}
+	mu.Unlock()
+	stream.Close()
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() after Close = %v", err)
+	}
+}
+
+func TestSubscribeEvents_FallsBackWithoutWebSocket(t *testing.T) {
+	server := newEventsServer(t, nil, func(cursor string) eventPollResponse {
+		switch cursor {
+		case "8":
+			return eventPollResponse{Cursor: 8}
+		case "7":
+		default:
+			t.Errorf("resumed poll sent cursor %q, want 7", cursor)
+		}
+		return eventPollResponse{Events: []json.RawMessage{rawEvent(8, "job_submitted")}, Cursor: 8}
+	})
+	defer server.Close()
+
+	stream, err := NewClient(server.URL, "test-token").SubscribeEvents(context.Background(),
+		EventOptions{Channels: []string{EventChannelJobs}, After: 7})
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+	defer stream.Close()
+
+	if got := nextEvents(t, stream, 1); got[0] != "8:job_submitted" {
+		t.Errorf("events = %v", got)
+	}
+	if stream.Cursor() != 8 {
+		t.Errorf("Cursor() = %d, want 8", stream.Cursor())
+	}
+}
+
+func TestSubscribeEvents_WebSocketCatchesUpAfterReconnect(t *testing.T) {
+	server := newEventsServer(t, func(conn *websocket.Conn, n int) {
+		switch n {
+		case 1:
+			conn.WriteJSON(map[string]interface{}{"type": "connected", "cursor": 0})
+			conn.WriteJSON(map[string]interface{}{"type": "state_sync"})
+			conn.WriteMessage(websocket.TextMessage, rawEvent(1, "job_submitted"))
+			// Dropped without a close frame
+		default:
+			conn.WriteJSON(map[string]interface{}{"type": "connected", "cursor": 2})
+			conn.WriteMessage(websocket.TextMessage, rawEvent(2, "job_update"))
+			conn.WriteMessage(websocket.TextMessage, rawEvent(3, "job_update"))
+			conn.ReadMessage()
+		}
+	}, func(cursor string) eventPollResponse {
+		if cursor != "1" {
+			t.Errorf("catch-up poll sent cursor %q, want 1", cursor)
+		}
+		return eventPollResponse{Events: []json.RawMessage{rawEvent(2, "job_update")}, Cursor: 2}
+	})
+	defer server.Close()
+
+	stream, err := NewClient(server.URL, "test-token").SubscribeEvents(context.Background(),
+		EventOptions{Channels: []string{EventChannelJobs}})
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+	defer stream.Close()
+
+	// The event missed while reconnecting comes from the catch-up poll,
+	// and its repeat on the new connection is dropped
+	got := strings.Join(nextEvents(t, stream, 4), ",")
+	if got != "0:state_sync,1:job_submitted,2:job_update,3:job_update" {
+		t.Errorf("events = %s", got)
+	}
+}
+
+func TestSubscribeEvents_InvalidChannel(t *testing.T) {
+	if _, err := NewClient("http://localhost:8080", "test-token").SubscribeEvents(context.Background(),
+		EventOptions{Channels: []string{"jobs,nodes"}}); err == nil {
+		t.Error("a channel list in one channel should fail")
+	}
+}
@@ -0,0 +1,77 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// agentStep is one tool call of the scripted agent.
+type agentStep struct {
+	tool string
+	args map[string]interface{}
+}
+
+// budgetAgentSession is the agent session the scripted calls belong to.
+const budgetAgentSession = "example-budget-agent"
+
+// BudgetedAgent stands in for an agent loop: it calls the DEparrow tools
+// the way a model would, through a ToolsProvider whose BudgetGuard caps
+// the session's spend at Ceiling credits. The second GPU job would go over
+// the ceiling and is refused, after which the agent settles for a cheaper
+// CPU job.
+type BudgetedAgent struct {
+	// Most credits the session may spend
+	Ceiling float64
+}
+
+// Run makes the scripted calls and prints what the agent would read. It
+// fails when a call is missing or the session spent more than its ceiling.
+func (a BudgetedAgent) Run(ctx context.Context, client *deparrow.Client, out io.Writer) error {
+	guard := deparrow.NewBudgetGuard(deparrow.BudgetConfig{SessionCeiling: a.Ceiling})
+	provider := deparrow.NewToolsProvider(client)
+	provider.SetBudgetGuard(guard)
+
+	byName := make(map[string]tools.Tool)
+	for _, tool := range provider.GetAllTools() {
+		byName[tool.Name()] = tool
+	}
+
+	gpuJob := map[string]interface{}{"image": "pytorch/pytorch:2.3.0-cuda12.1-cudnn8-runtime", "command": "python train.py", "gpu": "1", "timeout": 3600}
+	steps := []agentStep{
+		{tool: "deparrow_credits", args: map[string]interface{}{}},
+		{tool: "deparrow_submit_job", args: gpuJob},
+		{tool: "deparrow_submit_job", args: gpuJob},
+		{tool: "deparrow_submit_job", args: map[string]interface{}{"image": "python:3.11-slim", "command": "python evaluate.py"}},
+	}
+
+	ctx = deparrow.WithSessionID(ctx, budgetAgentSession)
+	for i, step := range steps {
+		tool, ok := byName[step.tool]
+		if !ok {
+			return fmt.Errorf("no %s tool", step.tool)
+		}
+		result := tool.Execute(ctx, step.args)
+		mark := "✅"
+		if result.IsError {
+			mark = "🛑"
+		}
+		fmt.Fprintf(out, "%s Step %d: %s\n%s\n\n", mark, i+1, step.tool, indent(result.ForLLM))
+	}
+
+	spent := guard.Spent(budgetAgentSession)
+	fmt.Fprintf(out, "💰 The session spent %.2f of its %.2f credits\n", spent, a.Ceiling)
+	if spent > a.Ceiling {
+		return fmt.Errorf("the session spent %.2f credits, over its ceiling of %.2f", spent, a.Ceiling)
+	}
+	return nil
+}
+
+// indent indents every line of s for printing under a heading.
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n    ")
+}
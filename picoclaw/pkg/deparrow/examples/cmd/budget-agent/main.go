@@ -0,0 +1,8 @@
+// Command budget-agent runs the budget-agent example: drive the agent tools under a session budget that refuses overspending.
+package main
+
+import "github.com/sipeed/picoclaw/pkg/deparrow/examples"
+
+func main() {
+	examples.Main("budget-agent")
+}
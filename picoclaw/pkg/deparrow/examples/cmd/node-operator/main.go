@@ -0,0 +1,8 @@
+// Command node-operator runs the node-operator example: watch your nodes' status, earnings and updates until interrupted.
+package main
+
+import "github.com/sipeed/picoclaw/pkg/deparrow/examples"
+
+func main() {
+	examples.Main("node-operator")
+}
@@ -0,0 +1,8 @@
+// Command submit-and-wait runs the submit-and-wait example: estimate a job, submit it, wait for it and print its output.
+package main
+
+import "github.com/sipeed/picoclaw/pkg/deparrow/examples"
+
+func main() {
+	examples.Main("submit-and-wait")
+}
@@ -0,0 +1,8 @@
+// Command sweep runs the sweep example: submit a parameter sweep as one batch and aggregate the results.
+package main
+
+import "github.com/sipeed/picoclaw/pkg/deparrow/examples"
+
+func main() {
+	examples.Main("sweep")
+}
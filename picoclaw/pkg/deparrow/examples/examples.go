@@ -0,0 +1,107 @@
+// Package examples holds end-to-end scenarios built on the public deparrow
+// API. Each one runs against a real Meta-OS endpoint or, when no endpoint
+// is given, against the sandbox, and the package tests run them all against
+// the sandbox so they double as integration tests of the client.
+//
+// The programs under cmd/ run one scenario each:
+//
+//	go run ./pkg/deparrow/examples/cmd/submit-and-wait
+//	go run ./pkg/deparrow/examples/cmd/sweep -api-url http://localhost:8080 -token $TOKEN
+package examples
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+// Scenario is a runnable example.
+type Scenario struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, client *deparrow.Client, out io.Writer) error
+}
+
+// Scenarios returns every example, in the order they are best read.
+func Scenarios() []Scenario {
+	return []Scenario{
+		{
+			Name:        "submit-and-wait",
+			Description: "Estimate a job, submit it, wait for it and print its output",
+			Run:         SubmitAndWait,
+		},
+		{
+			Name:        "node-operator",
+			Description: "Watch your nodes' status, earnings and updates until interrupted",
+			Run:         NodeOperator{Interval: time.Minute}.Run,
+		},
+		{
+			Name:        "budget-agent",
+			Description: "Drive the agent tools under a session budget that refuses overspending",
+			Run:         BudgetedAgent{Ceiling: 5}.Run,
+		},
+		{
+			Name:        "sweep",
+			Description: "Submit a parameter sweep as one batch and aggregate the results",
+			Run:         Sweep{Values: []string{"0.1", "0.01", "0.001"}}.Run,
+		},
+	}
+}
+
+// Lookup returns the scenario with the given name.
+func Lookup(name string) (Scenario, bool) {
+	for _, s := range Scenarios() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// NewClient creates a client for apiURL, or a sandbox client when apiURL
+// is empty.
+func NewClient(apiURL, jwtToken string, opts ...deparrow.ClientOption) *deparrow.Client {
+	if apiURL == "" {
+		return deparrow.NewSandboxClient(opts...)
+	}
+	return deparrow.NewClient(apiURL, jwtToken, opts...)
+}
+
+// Main runs the named scenario as a program. The endpoint and token come
+// from the -api-url and -token flags, or from the same environment
+// variables the agent's configuration reads. It exits non-zero when the
+// scenario fails.
+func Main(name string) {
+	scenario, ok := Lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown example %q\n", name)
+		os.Exit(2)
+	}
+
+	apiURL := flag.String("api-url", os.Getenv("PICOCLAW_DEPARROW_API_URL"), "DEparrow Meta-OS API URL; the sandbox when empty")
+	token := flag.String("token", os.Getenv("PICOCLAW_DEPARROW_JWT_TOKEN"), "DEparrow API token")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s: %s\n\nUsage:\n", scenario.Name, scenario.Description)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := NewClient(*apiURL, *token)
+	if client.IsSandbox() {
+		fmt.Println("🧪 Running against the sandbox; pass -api-url to use a real endpoint")
+	}
+	if err := scenario.Run(ctx, client, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", scenario.Name, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,122 @@
+//go:build unit
+
+package examples
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+func TestScenarios_RunAgainstSandbox(t *testing.T) {
+	for _, scenario := range Scenarios() {
+		if scenario.Name == "node-operator" {
+			// Runs until interrupted; see TestNodeOperator
+			continue
+		}
+		t.Run(scenario.Name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := scenario.Run(context.Background(), NewClient("", ""), &out); err != nil {
+				t.Fatalf("Run() error = %v\n%s", err, out.String())
+			}
+			if out.Len() == 0 {
+				t.Error("the scenario printed nothing")
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, ok := Lookup("sweep"); !ok {
+		t.Error("Lookup(sweep) should find the sweep")
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("Lookup(nope) should find nothing")
+	}
+}
+
+func TestSubmitAndWait(t *testing.T) {
+	var out bytes.Buffer
+	client := NewClient("", "")
+	if err := SubmitAndWait(context.Background(), client, &out); err != nil {
+		t.Fatalf("SubmitAndWait() error = %v", err)
+	}
+	for _, want := range []string{"Estimated cost", "completed on ", "alpine:3.20 finished successfully", " left"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output should contain %q:\n%s", want, out.String())
+		}
+	}
+	jobs, err := client.ListJobs(context.Background())
+	if err != nil || len(jobs) != 1 || jobs[0].Spec.Labels["example"] != "submit-and-wait" {
+		t.Errorf("ListJobs() = %+v, %v", jobs, err)
+	}
+}
+
+func TestNodeOperator(t *testing.T) {
+	var out bytes.Buffer
+	operator := NodeOperator{NodeIDs: []string{"node-aps-rtx-01", "node-aps-cpu-02", "node-gone"}, Interval: time.Millisecond, Rounds: 2}
+	if err := operator.Run(context.Background(), NewClient("", ""), &out); err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"Round 1", "Round 2",
+		"1 of the watched nodes are not listed",
+		"node-aps-rtx-01 online, 7480.00 credits earned",
+		"(+0.00)",
+		"node-aps-cpu-02 is maintenance",
+		"node-aps-rtx-01 runs 1.4.0",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output should contain %q:\n%s", want, out.String())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (NodeOperator{Interval: time.Hour}).Run(ctx, NewClient("", ""), &out); err != context.Canceled {
+		t.Errorf("Run() after cancel = %v", err)
+	}
+}
+
+func TestBudgetedAgent(t *testing.T) {
+	var out bytes.Buffer
+	if err := (BudgetedAgent{Ceiling: 5}).Run(context.Background(), NewClient("", ""), &out); err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, out.String())
+	}
+	got := out.String()
+	for _, want := range []string{"✅ Step 2", "🛑 Step 3", "exceeds the", "✅ Step 4", "The session spent 3.28 of its 5.00 credits"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output should contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	var out bytes.Buffer
+	client := NewClient("", "")
+	summary := Sweep{Values: []string{"0.1", "0.01", ""}}.Collect(context.Background(), client, &out)
+	if summary.Completed != 3 || summary.Failed != 0 || summary.Fastest == nil || summary.TotalCost <= 0 {
+		t.Fatalf("summary = %+v\n%s", summary, out.String())
+	}
+	if err := summary.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+	jobs, err := client.ListJobs(context.Background())
+	if err != nil {
+		t.Fatalf("ListJobs() error = %v", err)
+	}
+	for _, job := range jobs {
+		if job.ID == summary.Points[1].Job.ID && job.Spec.Env["LEARNING_RATE"] != "0.01" {
+			t.Errorf("point 1 ran with %v", job.Spec.Env)
+		}
+	}
+
+	summary.Points[2].Err = deparrow.ErrWaitTimeout
+	if err := summary.Err(); err == nil || !strings.Contains(err.Error(), "wait") {
+		t.Errorf("Err() = %v", err)
+	}
+}
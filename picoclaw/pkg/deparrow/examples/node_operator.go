@@ -0,0 +1,90 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+// NodeOperator is the loop a node operator leaves running: every
+// Interval it reports the status, earnings and rank of each node and warns
+// about nodes that are offline or run an outdated node agent.
+type NodeOperator struct {
+	// Nodes to watch; empty watches every node the API lists
+	NodeIDs []string
+	// Time between rounds
+	Interval time.Duration
+	// Rounds to run before returning; zero runs until ctx is done
+	Rounds int
+}
+
+// Run watches the nodes until ctx is done or the rounds are over. A round
+// that fails is reported and the next one tried; the error of the last
+// round is returned.
+func (o NodeOperator) Run(ctx context.Context, client *deparrow.Client, out io.Writer) error {
+	earned := make(map[string]float64)
+	for round := 1; ; round++ {
+		err := o.round(ctx, client, out, round, earned)
+		if err != nil {
+			fmt.Fprintf(out, "⚠️  Round %d: %v\n", round, err)
+		}
+		if o.Rounds > 0 && round >= o.Rounds {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(o.Interval):
+		}
+	}
+}
+
+// round reports on the nodes once. earned carries each node's credits from
+// the round before, to show what it earned since.
+func (o NodeOperator) round(ctx context.Context, client *deparrow.Client, out io.Writer, round int, earned map[string]float64) error {
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("list nodes: %w", err)
+	}
+	if len(o.NodeIDs) > 0 {
+		nodes = slices.DeleteFunc(nodes, func(n deparrow.Node) bool { return !slices.Contains(o.NodeIDs, n.ID) })
+		if len(nodes) < len(o.NodeIDs) {
+			fmt.Fprintf(out, "⚠️  %d of the watched nodes are not listed\n", len(o.NodeIDs)-len(nodes))
+		}
+	}
+
+	fmt.Fprintf(out, "🖥️  Round %d at %s: %d nodes\n", round, time.Now().Format(time.TimeOnly), len(nodes))
+	var errs []error
+	for _, node := range nodes {
+		line := fmt.Sprintf("  %s %s, %.2f credits earned", node.ID, node.Status, node.CreditsEarned)
+		if before, ok := earned[node.ID]; ok {
+			line += fmt.Sprintf(" (+%.2f)", node.CreditsEarned-before)
+		}
+		earned[node.ID] = node.CreditsEarned
+
+		if contribution, err := client.GetNodeContribution(ctx, node.ID); err != nil {
+			errs = append(errs, fmt.Errorf("contribution of %s: %w", node.ID, err))
+		} else if contribution.TotalNodes > 0 {
+			line += fmt.Sprintf(", rank %d of %d", contribution.Rank, contribution.TotalNodes)
+		}
+		fmt.Fprintln(out, line)
+
+		if node.Status != deparrow.NodeStatusOnline {
+			fmt.Fprintf(out, "    ⚠️  %s is %s and earns nothing\n", node.ID, node.Status)
+		}
+		advisory, err := client.GetUpdateAdvisory(ctx, node.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("update advisory of %s: %w", node.ID, err))
+			continue
+		}
+		if advisory.Outdated {
+			fmt.Fprintf(out, "    ⬆️  %s runs %s; %s is out (%s)\n", node.ID, advisory.CurrentVersion, advisory.Latest.Version, advisory.Severity)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+// helloSpec is the job the submit-and-wait example runs.
+func helloSpec() *deparrow.JobSpec {
+	return &deparrow.JobSpec{
+		Image:     "alpine:3.20",
+		Command:   []string{"echo", "hello from DEparrow"},
+		Resources: &deparrow.ResourceSpec{CPU: "500m", Memory: "256Mi"},
+		Timeout:   300,
+		Labels:    map[string]string{"example": "submit-and-wait"},
+	}
+}
+
+// SubmitAndWait estimates a small job, checks the balance covers it,
+// submits it and waits for it to finish, printing its output and what it
+// cost. A job that fails is reported as an error.
+func SubmitAndWait(ctx context.Context, client *deparrow.Client, out io.Writer) error {
+	spec := helloSpec()
+
+	estimate, err := client.EstimateJob(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("estimate: %w", err)
+	}
+	fmt.Fprintf(out, "💰 Estimated cost: %.4f credits\n", estimate.CreditCost)
+
+	enough, err := client.CheckCredits(ctx, estimate.CreditCost)
+	if err != nil {
+		return fmt.Errorf("check credits: %w", err)
+	}
+	if !enough {
+		return fmt.Errorf("the balance does not cover %.4f credits", estimate.CreditCost)
+	}
+
+	job, err := client.SubmitJob(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("submit: %w", err)
+	}
+	fmt.Fprintf(out, "🚀 Submitted %s\n", job.ID)
+
+	job, err = client.WaitForJobCompletion(ctx, job.ID, deparrow.WaitOptions{
+		PollInterval: 100 * time.Millisecond,
+		MaxWait:      10 * time.Minute,
+	})
+	if err != nil {
+		return fmt.Errorf("wait for %s: %w", job.ID, err)
+	}
+	fmt.Fprintf(out, "🏁 %s %s", job.ID, job.Status)
+	if job.Results != nil {
+		fmt.Fprintf(out, " on %s (exit code %d)\n%s", job.Results.NodeID, job.Results.ExitCode, job.Results.Stdout)
+	} else {
+		fmt.Fprintln(out)
+	}
+	if job.Status != deparrow.JobStatusCompleted {
+		return fmt.Errorf("job %s %s: %s", job.ID, job.Status, job.Error)
+	}
+
+	balance, err := client.GetCredits(ctx)
+	if err != nil {
+		return fmt.Errorf("get credits: %w", err)
+	}
+	fmt.Fprintf(out, "💳 Charged %.4f credits, %.2f left\n", job.CreditCost, balance.Balance)
+	return nil
+}
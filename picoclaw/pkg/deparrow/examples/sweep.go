@@ -0,0 +1,129 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow"
+)
+
+// Sweep runs one job per value of a parameter, submitted together as a
+// batch, waits for them all and sums up what they cost and how they went.
+type Sweep struct {
+	// Values of the LEARNING_RATE environment variable, one job each
+	Values []string
+}
+
+// SweepPoint is the outcome of one value of a sweep.
+type SweepPoint struct {
+	Value string
+	// Finished job; nil when it was not submitted or waited for
+	Job *deparrow.Job
+	Err error
+}
+
+// SweepSummary aggregates the points of a sweep.
+type SweepSummary struct {
+	Points    []SweepPoint
+	Completed int
+	Failed    int
+	// Credits all the jobs cost
+	TotalCost float64
+	// Completed point that ran the shortest, nil when none completed
+	Fastest *SweepPoint
+}
+
+// Err joins the errors of the points that did not complete, or returns nil.
+func (s *SweepSummary) Err() error {
+	var errs []error
+	for _, p := range s.Points {
+		switch {
+		case p.Err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", p.Value, p.Err))
+		case p.Job.Status != deparrow.JobStatusCompleted:
+			errs = append(errs, fmt.Errorf("%s: job %s %s", p.Value, p.Job.ID, p.Job.Status))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run submits the sweep, prints each point as it finishes and a summary at
+// the end. It fails when any point did not complete.
+func (s Sweep) Run(ctx context.Context, client *deparrow.Client, out io.Writer) error {
+	summary := s.Collect(ctx, client, out)
+	fmt.Fprintf(out, "📊 %d completed, %d failed, %.4f credits in total\n", summary.Completed, summary.Failed, summary.TotalCost)
+	if summary.Fastest != nil {
+		fmt.Fprintf(out, "⚡ Fastest: LEARNING_RATE=%s in %s\n", summary.Fastest.Value, summary.Fastest.Job.Duration(time.Now()).Round(time.Millisecond))
+	}
+	return summary.Err()
+}
+
+// Collect submits the sweep and waits for every point, printing each as it
+// finishes. Points that could not be submitted are in the summary with
+// their error.
+func (s Sweep) Collect(ctx context.Context, client *deparrow.Client, out io.Writer) *SweepSummary {
+	specs := make([]*deparrow.JobSpec, len(s.Values))
+	for i, value := range s.Values {
+		specs[i] = &deparrow.JobSpec{
+			Image:     "python:3.11-slim",
+			Command:   []string{"python", "train.py"},
+			Env:       map[string]string{"LEARNING_RATE": value},
+			Resources: &deparrow.ResourceSpec{CPU: "1", Memory: "1Gi"},
+			Timeout:   1800,
+			Labels:    map[string]string{"example": "sweep", "learning_rate": value},
+		}
+	}
+
+	// The per-point errors are kept in the summary
+	batch, _ := client.SubmitJobs(ctx, specs)
+	fmt.Fprintf(out, "🚀 Submitted %d of %d points for %.4f credits\n", batch.Submitted, len(specs), batch.TotalCost)
+
+	summary := &SweepSummary{Points: make([]SweepPoint, len(specs))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, result := range batch.Results {
+		point := &summary.Points[i]
+		point.Value = s.Values[i]
+		if result.Err != nil {
+			point.Err = result.Err
+			continue
+		}
+		wg.Add(1)
+		go func(jobID string) {
+			defer wg.Done()
+			point.Job, point.Err = client.WaitForJobCompletion(ctx, jobID, deparrow.WaitOptions{PollInterval: 100 * time.Millisecond})
+			if point.Err != nil {
+				point.Job = nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if point.Job != nil {
+				fmt.Fprintf(out, "  LEARNING_RATE=%s: %s %s\n", point.Value, point.Job.ID, point.Job.Status)
+			}
+		}(result.Job.ID)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	for i := range summary.Points {
+		point := &summary.Points[i]
+		if point.Job == nil {
+			summary.Failed++
+			continue
+		}
+		summary.TotalCost += point.Job.CreditCost
+		if point.Job.Status != deparrow.JobStatusCompleted {
+			summary.Failed++
+			continue
+		}
+		summary.Completed++
+		if summary.Fastest == nil || point.Job.Duration(now) < summary.Fastest.Job.Duration(now) {
+			summary.Fastest = point
+		}
+	}
+	return summary
+}
@@ -0,0 +1,91 @@
+package deparrow
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FixtureNode is a node of the fixture network, the network the sandbox
+// simulates. Test servers seed themselves from the same fixtures, so every
+// fake of the Meta-OS API serves the same nodes.
+type FixtureNode struct {
+	Node
+	Region   string
+	MemoryGB float64
+	DiskGB   float64
+}
+
+// FixtureNodes returns the fixture network: seven nodes across three
+// regions, one of them in maintenance, last seen at lastSeen. Nodes are
+// ranked by credits earned, as the leaderboard does.
+func FixtureNodes(lastSeen time.Time) []FixtureNode {
+	fixtures := []struct {
+		id, region, city, country string
+		lat, lng                  float64
+		arch                      Architecture
+		status                    NodeStatus
+		cpu, gpu                  int
+		gpuModel                  string
+		memoryGB, diskGB          float64
+		tier                      ContributionTier
+		credits, cpuHours         float64
+		gpuHours, gflops          float64
+		agentVersion              string
+	}{
+		{"node-use-a100-01", "us-east", "Ashburn", "US", 39.04, -77.49, ArchX86_64, NodeStatusOnline, 96, 8, "A100", 768, 8192, TierLegendary, 48210, 91200, 38400, 2480, "1.6.0"},
+		{"node-use-a100-02", "us-east", "Ashburn", "US", 39.04, -77.49, ArchX86_64, NodeStatusOnline, 96, 8, "A100", 768, 8192, TierDiamond, 31875, 74100, 22950, 2310, "1.5.2"},
+		{"node-use-cpu-01", "us-east", "New York", "US", 40.71, -74.01, ArchX86_64, NodeStatusOnline, 64, 0, "", 256, 2048, TierGold, 9120, 42300, 0, 410, "1.5.0"},
+		{"node-euw-h100-01", "eu-west", "Amsterdam", "NL", 52.37, 4.90, ArchX86_64, NodeStatusOnline, 64, 4, "H100", 512, 4096, TierDiamond, 27640, 51800, 19700, 3120, "1.6.0"},
+		{"node-euw-arm-01", "eu-west", "Dublin", "IE", 53.35, -6.26, ArchARM64, NodeStatusOnline, 32, 0, "", 128, 1024, TierSilver, 2310, 15400, 0, 180, "1.5.0"},
+		{"node-aps-rtx-01", "ap-south", "Mumbai", "IN", 19.08, 72.88, ArchX86_64, NodeStatusOnline, 32, 2, "RTX4090", 128, 2048, TierGold, 7480, 20100, 6200, 860, "1.4.0"},
+		{"node-aps-cpu-02", "ap-south", "Singapore", "SG", 1.35, 103.82, ArchX86_64, NodeStatusMaintenance, 16, 0, "", 64, 512, TierBronze, 410, 2900, 0, 0, "1.6.0"},
+	}
+
+	totalGFlops := 0.0
+	for _, f := range fixtures {
+		totalGFlops += f.gflops
+	}
+
+	nodes := make([]FixtureNode, 0, len(fixtures))
+	for _, f := range fixtures {
+		nodes = append(nodes, FixtureNode{
+			Region:   f.region,
+			MemoryGB: f.memoryGB,
+			DiskGB:   f.diskGB,
+			Node: Node{
+				ID:     f.id,
+				Arch:   f.arch,
+				Status: f.status,
+				Resources: &NodeResources{
+					CPU:      f.cpu,
+					Memory:   fmt.Sprintf("%.0fGi", f.memoryGB),
+					GPU:      f.gpu,
+					GPUModel: f.gpuModel,
+					Storage:  fmt.Sprintf("%.0fGi", f.diskGB),
+				},
+				Labels:        map[string]string{"region": f.region},
+				LastSeen:      lastSeen,
+				CreditsEarned: f.credits,
+				Location:      &Location{Latitude: f.lat, Longitude: f.lng, City: f.city, Country: f.country},
+				Tier:          f.tier,
+				AgentVersion:  f.agentVersion,
+				Contribution: &NodeContribution{
+					CPUUsageHours:  f.cpuHours,
+					GPUUsageHours:  f.gpuHours,
+					LiveGFlops:     f.gflops,
+					NetworkPercent: f.gflops / totalGFlops * 100,
+				},
+			},
+		})
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].CreditsEarned > nodes[j].CreditsEarned
+	})
+	for i := range nodes {
+		nodes[i].Contribution.Rank = i + 1
+		nodes[i].Contribution.TotalNodes = len(nodes)
+	}
+	return nodes
+}
@@ -0,0 +1,189 @@
+package deparrow
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gpuDetectTimeout bounds how long DetectResources waits for the vendor
+// tools, which can hang while a driver initializes.
+const gpuDetectTimeout = 10 * time.Second
+
+// GPUDevice is a GPU found on this machine.
+type GPUDevice struct {
+	Vendor    string
+	Model     string
+	VRAMBytes uint64
+}
+
+// GPUDetector finds the GPUs of one vendor. A machine without the
+// vendor's GPUs or tools has none, which is not an error.
+type GPUDetector interface {
+	DetectGPUs(ctx context.Context) ([]GPUDevice, error)
+}
+
+// CommandRunner runs a command and returns its standard output. Detectors
+// take one so tests can stand in for the vendor tools.
+type CommandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCommand runs a command on this machine.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// NvidiaSMI detects NVIDIA GPUs with nvidia-smi.
+type NvidiaSMI struct {
+	// Run runs nvidia-smi; nil runs it on this machine
+	Run CommandRunner
+}
+
+// DetectGPUs lists the GPUs nvidia-smi reports.
+func (d NvidiaSMI) DetectGPUs(ctx context.Context) ([]GPUDevice, error) {
+	out, err := detectorRun(ctx, d.Run, "nvidia-smi", "--query-gpu=name,memory.total", "--format=csv,noheader,nounits")
+	if err != nil || out == nil {
+		return nil, err
+	}
+	return parseNvidiaSMI(out)
+}
+
+// parseNvidiaSMI parses one "name, memory in MiB" line per GPU, e.g.
+// "NVIDIA GeForce RTX 4090, 24564".
+func parseNvidiaSMI(out []byte) ([]GPUDevice, error) {
+	var gpus []GPUDevice
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// Model names don't contain commas, but take the last field as
+		// the memory in case one does
+		i := strings.LastIndex(line, ",")
+		if i < 0 {
+			return nil, fmt.Errorf("unexpected nvidia-smi output: %q", line)
+		}
+		gpu := GPUDevice{Vendor: "nvidia", Model: strings.TrimSpace(line[:i])}
+		// Some boards, such as Jetson, report "[N/A]"
+		if mib, err := strconv.ParseUint(strings.TrimSpace(line[i+1:]), 10, 64); err == nil {
+			gpu.VRAMBytes = mib << 20
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, scanner.Err()
+}
+
+// ROCmSMI detects AMD GPUs with rocm-smi.
+type ROCmSMI struct {
+	// Run runs rocm-smi; nil runs it on this machine
+	Run CommandRunner
+}
+
+// DetectGPUs lists the GPUs rocm-smi reports.
+func (d ROCmSMI) DetectGPUs(ctx context.Context) ([]GPUDevice, error) {
+	out, err := detectorRun(ctx, d.Run, "rocm-smi", "--showproductname", "--showmeminfo", "vram", "--json")
+	if err != nil || out == nil {
+		return nil, err
+	}
+	return parseROCmSMI(out)
+}
+
+// parseROCmSMI parses rocm-smi's JSON, an object of cards such as
+// {"card0": {"Card series": "...", "VRAM Total Memory (B)": "17163091968"}}.
+// Releases differ in which name keys they report.
+func parseROCmSMI(out []byte) ([]GPUDevice, error) {
+	var cards map[string]map[string]string
+	if err := json.Unmarshal(out, &cards); err != nil {
+		return nil, fmt.Errorf("unexpected rocm-smi output: %w", err)
+	}
+	names := make([]string, 0, len(cards))
+	for name := range cards {
+		if strings.HasPrefix(name, "card") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	gpus := make([]GPUDevice, 0, len(names))
+	for _, name := range names {
+		card := cards[name]
+		gpu := GPUDevice{Vendor: "amd"}
+		for _, key := range []string{"Card Series", "Card series", "Device Name", "Card model"} {
+			if model := strings.TrimSpace(card[key]); model != "" {
+				gpu.Model = model
+				break
+			}
+		}
+		gpu.VRAMBytes, _ = strconv.ParseUint(strings.TrimSpace(card["VRAM Total Memory (B)"]), 10, 64)
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
+
+// detectorRun runs a vendor tool. A tool that isn't installed returns no
+// output and no error.
+func detectorRun(ctx context.Context, run CommandRunner, name string, args ...string) ([]byte, error) {
+	if run == nil {
+		run = runCommand
+	}
+	out, err := run(ctx, name, args...)
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
+
+// DefaultGPUDetectors are the detectors DetectResources uses when given none.
+func DefaultGPUDetectors() []GPUDetector {
+	return []GPUDetector{NvidiaSMI{}, ROCmSMI{}}
+}
+
+// DetectGPUs asks each detector in turn and returns the GPUs of the first
+// that finds any, along with the errors of those that failed before it.
+func DetectGPUs(ctx context.Context, detectors ...GPUDetector) ([]GPUDevice, error) {
+	var errs []error
+	for _, detector := range detectors {
+		gpus, err := detector.DetectGPUs(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(gpus) > 0 {
+			return gpus, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// applyGPUs records the GPUs in resources. The model is the first GPU's
+// and VRAM the smallest, since a job may land on any of them.
+func applyGPUs(resources *LocalResources, gpus []GPUDevice) {
+	if len(gpus) == 0 {
+		return
+	}
+	resources.GPU = len(gpus)
+	resources.GPUModel = gpus[0].Model
+	vram := gpus[0].VRAMBytes
+	for _, gpu := range gpus[1:] {
+		vram = min(vram, gpu.VRAMBytes)
+	}
+	if vram > 0 {
+		resources.VRAMBytes = vram
+		// Tools report a little less than the board's size, so round to
+		// the nearest Gi rather than down
+		if vram >= 1<<30 {
+			vram += 1 << 29
+		}
+		resources.VRAM = formatMemory(vram)
+	}
+}
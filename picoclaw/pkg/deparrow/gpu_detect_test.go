@@ -0,0 +1,130 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeGPUDetector reports fixed GPUs, or fails.
+type fakeGPUDetector struct {
+	gpus []GPUDevice
+	err  error
+}
+
+func (f fakeGPUDetector) DetectGPUs(context.Context) ([]GPUDevice, error) {
+	return f.gpus, f.err
+}
+
+// fakeCommand stands in for a vendor tool, checking it is the one expected.
+func fakeCommand(t *testing.T, want, out string, err error) CommandRunner {
+	return func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if name != want {
+			t.Errorf("ran %s %v, want %s", name, args, want)
+		}
+		return []byte(out), err
+	}
+}
+
+func TestNvidiaSMI_DetectGPUs(t *testing.T) {
+	out := "NVIDIA GeForce RTX 4090, 24564\nNVIDIA A100-SXM4-80GB, 81920\n\n"
+	gpus, err := NvidiaSMI{Run: fakeCommand(t, "nvidia-smi", out, nil)}.DetectGPUs(context.Background())
+	if err != nil {
+		t.Fatalf("DetectGPUs() error = %v", err)
+	}
+	if len(gpus) != 2 || gpus[0].Model != "NVIDIA GeForce RTX 4090" || gpus[0].VRAMBytes != 24564<<20 || gpus[1].Vendor != "nvidia" {
+		t.Errorf("DetectGPUs() = %+v", gpus)
+	}
+
+	// Jetson boards share system memory and report none
+	gpus, err = parseNvidiaSMI([]byte("Orin, [N/A]\n"))
+	if err != nil || len(gpus) != 1 || gpus[0].Model != "Orin" || gpus[0].VRAMBytes != 0 {
+		t.Errorf("parseNvidiaSMI(Jetson) = %+v, %v", gpus, err)
+	}
+	if _, err := parseNvidiaSMI([]byte("No devices were found\n")); err == nil {
+		t.Error("unexpected output should fail")
+	}
+}
+
+func TestROCmSMI_DetectGPUs(t *testing.T) {
+	out := `{
+		"card1": {"Device Name": "Radeon RX 7900 XTX", "VRAM Total Memory (B)": "25753026560"},
+		"card0": {"Card series": "Instinct MI210", "Card model": "0x740f", "VRAM Total Memory (B)": "68702699520"},
+		"system": {"Driver version": "6.7.0"}
+	}`
+	gpus, err := ROCmSMI{Run: fakeCommand(t, "rocm-smi", out, nil)}.DetectGPUs(context.Background())
+	if err != nil {
+		t.Fatalf("DetectGPUs() error = %v", err)
+	}
+	if len(gpus) != 2 || gpus[0].Model != "Instinct MI210" || gpus[1].Model != "Radeon RX 7900 XTX" || gpus[1].VRAMBytes != 25753026560 {
+		t.Errorf("DetectGPUs() = %+v", gpus)
+	}
+	if _, err := parseROCmSMI([]byte("not json")); err == nil {
+		t.Error("unexpected output should fail")
+	}
+}
+
+func TestDetectGPUs(t *testing.T) {
+	ctx := context.Background()
+	missing := NvidiaSMI{Run: fakeCommand(t, "nvidia-smi", "", fmt.Errorf("exec: %w", exec.ErrNotFound))}
+	amd := fakeGPUDetector{gpus: []GPUDevice{{Vendor: "amd", Model: "MI210"}}}
+
+	gpus, err := DetectGPUs(ctx, missing, amd)
+	if err != nil || len(gpus) != 1 || gpus[0].Model != "MI210" {
+		t.Errorf("DetectGPUs() = %+v, %v; a missing tool is not an error", gpus, err)
+	}
+
+	broken := fakeGPUDetector{err: errors.New("driver not loaded")}
+	gpus, err = DetectGPUs(ctx, broken, missing)
+	if len(gpus) != 0 || err == nil || !strings.Contains(err.Error(), "driver not loaded") {
+		t.Errorf("DetectGPUs() = %+v, %v", gpus, err)
+	}
+	if gpus, err := DetectGPUs(ctx, broken, amd); err != nil || len(gpus) != 1 {
+		t.Errorf("a later detector's GPUs should win over an earlier failure: %+v, %v", gpus, err)
+	}
+}
+
+func TestApplyGPUs(t *testing.T) {
+	var resources LocalResources
+	applyGPUs(&resources, []GPUDevice{
+		{Model: "NVIDIA GeForce RTX 4090", VRAMBytes: 24564 << 20},
+		{Model: "NVIDIA GeForce RTX 3060", VRAMBytes: 12288 << 20},
+	})
+	if resources.GPU != 2 || resources.GPUModel != "NVIDIA GeForce RTX 4090" || resources.VRAM != "12Gi" || resources.VRAMBytes != 12288<<20 {
+		t.Errorf("applyGPUs() = %+v", resources)
+	}
+
+	resources = LocalResources{}
+	applyGPUs(&resources, []GPUDevice{{Model: "RTX 4090", VRAMBytes: 24564 << 20}})
+	if resources.VRAM != "24Gi" {
+		t.Errorf("VRAM = %q, want 24Gi rounded from 24564Mi", resources.VRAM)
+	}
+	applyGPUs(&resources, nil)
+	if resources.GPU != 1 {
+		t.Error("no GPUs should leave resources as they are")
+	}
+}
+
+func TestNodeAgent_RegistersDetectedGPUs(t *testing.T) {
+	client := NewSandboxClient()
+	agent, err := NewNodeAgent(client, NodeAgentConfig{
+		NodeID:       "node-gpu",
+		GPUDetectors: []GPUDetector{fakeGPUDetector{gpus: []GPUDevice{{Model: "RTX 4090", VRAMBytes: 24 << 30}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := agent.register(ctx); err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+	node, err := client.GetNode(ctx, "node-gpu")
+	if err != nil || node.Resources.GPU != 1 || node.Resources.GPUModel != "RTX 4090" || node.Resources.VRAM != "24Gi" {
+		t.Errorf("GetNode() = %+v, %v", node, err)
+	}
+}
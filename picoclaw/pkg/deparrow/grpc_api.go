@@ -0,0 +1,275 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow/deparrowpb"
+)
+
+// grpcSubmitJob submits a job over the gRPC API.
+func (c *Client) grpcSubmitJob(ctx context.Context, spec *JobSpec, creditCost float64, orchestratorID string) (*deparrowpb.SubmitJobResponse, error) {
+	var resp *deparrowpb.SubmitJobResponse
+	err := c.grpcCall(ctx, http.MethodPost, "/api/v1/jobs/submit", func(ctx context.Context, api deparrowpb.DeparrowClient) error {
+		var err error
+		resp, err = api.SubmitJob(ctx, &deparrowpb.SubmitJobRequest{
+			Spec:         specToProto(spec),
+			CreditCost:   creditCost,
+			Orchestrator: orchestratorID,
+		})
+		return err
+	})
+	return resp, err
+}
+
+// grpcGetJob gets a job over the gRPC API.
+func (c *Client) grpcGetJob(ctx context.Context, jobID string) (jobResponse, error) {
+	var job *deparrowpb.Job
+	err := c.grpcCall(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(jobID), func(ctx context.Context, api deparrowpb.DeparrowClient) error {
+		var err error
+		job, err = api.GetJob(ctx, &deparrowpb.GetJobRequest{JobId: jobID})
+		return err
+	})
+	if err != nil {
+		return jobResponse{}, err
+	}
+	return jobResponse{
+		JobID:       job.GetJobId(),
+		Status:      JobStatus(job.GetStatus()),
+		UserID:      job.GetUserId(),
+		CreditCost:  job.GetCreditCost(),
+		SubmittedAt: timeFromProto(job.GetSubmittedAt()),
+		Results:     resultsFromProto(job.GetResults()),
+		Evictions:   evictionsFromProto(job.GetEvictions()),
+	}, nil
+}
+
+// grpcCancelJob cancels a job over the gRPC API, returning the refund.
+func (c *Client) grpcCancelJob(ctx context.Context, jobID string) (float64, error) {
+	var resp *deparrowpb.CancelJobResponse
+	err := c.grpcCall(ctx, http.MethodPost, "/api/v1/jobs/"+url.PathEscape(jobID)+"/cancel", func(ctx context.Context, api deparrowpb.DeparrowClient) error {
+		var err error
+		resp, err = api.CancelJob(ctx, &deparrowpb.CancelJobRequest{JobId: jobID})
+		return err
+	})
+	return resp.GetRefundAmount(), err
+}
+
+// grpcGetCredits gets the balance of the authenticated user over the gRPC
+// API; path is the REST endpoint it stands in for.
+func (c *Client) grpcGetCredits(ctx context.Context, path string) (*CreditBalance, error) {
+	var balance *deparrowpb.CreditBalance
+	err := c.grpcCall(ctx, http.MethodGet, path, func(ctx context.Context, api deparrowpb.DeparrowClient) error {
+		var err error
+		balance, err = api.GetCredits(ctx, &deparrowpb.GetCreditsRequest{UserId: c.userID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []CreditBucket
+	for _, b := range balance.GetBuckets() {
+		bucket := CreditBucket{
+			ID:          b.GetBucketId(),
+			Source:      CreditSource(b.GetSource()),
+			Amount:      b.GetAmount(),
+			Description: b.GetDescription(),
+			GrantedAt:   timeFromProto(b.GetGrantedAt()),
+		}
+		if b.GetExpiresAt() != nil {
+			expires := timeFromProto(b.GetExpiresAt())
+			bucket.ExpiresAt = &expires
+		}
+		buckets = append(buckets, bucket)
+	}
+	return &CreditBalance{
+		Balance:     balance.GetCreditBalance(),
+		LastUpdated: timeFromProto(balance.GetLastActive()),
+		Buckets:     buckets,
+	}, nil
+}
+
+// grpcGetNetworkStats gets the network statistics over the gRPC API.
+func (c *Client) grpcGetNetworkStats(ctx context.Context) (*NetworkStats, error) {
+	var stats *deparrowpb.NetworkStats
+	err := c.grpcCall(ctx, http.MethodGet, "/api/v1/network/contribution", func(ctx context.Context, api deparrowpb.DeparrowClient) error {
+		var err error
+		stats, err = api.GetNetworkStats(ctx, &deparrowpb.GetNetworkStatsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tiers map[string]int
+	if len(stats.GetTiers()) > 0 {
+		tiers = make(map[string]int, len(stats.GetTiers()))
+		for tier, n := range stats.GetTiers() {
+			tiers[tier] = int(n)
+		}
+	}
+	return &NetworkStats{
+		TotalNodes:       int(stats.GetTotalNodes()),
+		OnlineNodes:      int(stats.GetOnlineNodes()),
+		TotalCPU:         int(stats.GetTotalCpuCores()),
+		TotalGPU:         int(stats.GetTotalGpuCount()),
+		TotalMemory:      stats.GetTotalMemoryGb(),
+		LiveGFlops:       stats.GetLiveGflops(),
+		LiveTFlops:       stats.GetLiveTflops(),
+		TierDistribution: tiers,
+		Timestamp:        timeFromProto(stats.GetTimestamp()),
+	}, nil
+}
+
+// watchGRPC follows the job over one gRPC stream.
+func (w *JobWatch) watchGRPC(ctx context.Context) error {
+	path := "/api/v1/jobs/" + url.PathEscape(w.jobID) + "/watch"
+	return w.client.grpcAuthenticated(ctx, http.MethodGet, path, true, func(ctx context.Context, api deparrowpb.DeparrowClient) error {
+		stream, err := api.WatchJob(ctx, &deparrowpb.WatchJobRequest{JobId: w.jobID})
+		if err != nil {
+			return err
+		}
+		for {
+			update, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return errors.New("server closed the watch before the job finished")
+			}
+			if err != nil {
+				return err
+			}
+			if update.GetStatus() == "" {
+				continue
+			}
+			finished, err := w.deliver(ctx, JobStatus(update.GetStatus()))
+			if err != nil || finished {
+				return err
+			}
+		}
+	})
+}
+
+// specToProto converts a job spec to its gRPC message.
+func specToProto(spec *JobSpec) *deparrowpb.JobSpec {
+	pb := &deparrowpb.JobSpec{
+		Image:          spec.Image,
+		Command:        spec.Command,
+		Env:            spec.Env,
+		Timeout:        int32(spec.Timeout),
+		Priority:       int32(spec.Priority),
+		Labels:         spec.Labels,
+		Verified:       spec.Verified,
+		NodeSelector:   spec.NodeSelector,
+		Regions:        spec.Regions,
+		ExcludeNodeIds: spec.ExcludeNodeIDs,
+		PricingClass:   string(spec.PricingClass),
+		MaxCreditBid:   spec.MaxCreditBid,
+	}
+	if r := spec.Resources; r != nil {
+		pb.Resources = &deparrowpb.ResourceSpec{Cpu: r.CPU, Memory: r.Memory, Gpu: r.GPU, Storage: r.Storage}
+	}
+	for _, in := range spec.Inputs {
+		pb.Inputs = append(pb.Inputs, &deparrowpb.InputSpec{
+			StorageSource: in.StorageSource,
+			Source:        in.Source,
+			Path:          in.Path,
+			S3Config:      s3ToProto(in.S3Config),
+		})
+	}
+	for _, out := range spec.Outputs {
+		pb.Outputs = append(pb.Outputs, &deparrowpb.OutputSpec{
+			Path:               out.Path,
+			StorageDestination: out.StorageDestination,
+			S3Config:           s3ToProto(out.S3Config),
+		})
+	}
+	if v := spec.Verification; v != nil {
+		pb.Verification = &deparrowpb.VerificationSpec{Mode: string(v.Mode), Replicas: int32(v.Replicas)}
+	}
+	for _, arch := range spec.Architectures {
+		pb.Architectures = append(pb.Architectures, string(arch))
+	}
+	if r := spec.Retry; r != nil {
+		pb.Retry = &deparrowpb.RetrySpec{
+			MaxAttempts:       int32(r.MaxAttempts),
+			BackoffSeconds:    int32(r.BackoffSeconds),
+			MaxBackoffSeconds: int32(r.MaxBackoffSeconds),
+			MaxCredits:        r.MaxCredits,
+		}
+		for _, code := range r.RetryOnExitCodes {
+			pb.Retry.RetryOnExitCodes = append(pb.Retry.RetryOnExitCodes, int32(code))
+		}
+	}
+	return pb
+}
+
+// s3ToProto converts an S3 configuration to its gRPC message.
+func s3ToProto(s3 *S3Config) *deparrowpb.S3Config {
+	if s3 == nil {
+		return nil
+	}
+	return &deparrowpb.S3Config{
+		Bucket:    s3.Bucket,
+		Key:       s3.Key,
+		Region:    s3.Region,
+		Endpoint:  s3.Endpoint,
+		AccessKey: s3.AccessKey,
+		SecretKey: s3.SecretKey,
+	}
+}
+
+// resultsFromProto converts the results of a job from their gRPC message.
+func resultsFromProto(pb *deparrowpb.JobResults) *JobResults {
+	if pb == nil {
+		return nil
+	}
+	results := &JobResults{
+		OutputCID:    pb.GetOutputCid(),
+		Stdout:       pb.GetStdout(),
+		Stderr:       pb.GetStderr(),
+		ExitCode:     int(pb.GetExitCode()),
+		Duration:     pb.GetDurationSeconds(),
+		NodeID:       pb.GetNodeId(),
+		DownloadURLs: pb.GetDownloadUrls(),
+		Checksums:    pb.GetChecksums(),
+	}
+	if v := pb.GetVerification(); v != nil {
+		results.Verification = &VerificationResult{
+			Mode:        VerificationMode(v.GetMode()),
+			Status:      VerificationStatus(v.GetStatus()),
+			Replicas:    int(v.GetReplicas()),
+			Agreeing:    int(v.GetAgreeing()),
+			ResultHash:  v.GetResultHash(),
+			Attestation: v.GetAttestation(),
+			Message:     v.GetMessage(),
+		}
+	}
+	return results
+}
+
+// evictionsFromProto converts a job's evictions from their gRPC messages.
+func evictionsFromProto(pbs []*deparrowpb.JobEviction) []JobEviction {
+	var evictions []JobEviction
+	for _, pb := range pbs {
+		evictions = append(evictions, JobEviction{
+			At:     timeFromProto(pb.GetEvictedAt()),
+			NodeID: pb.GetNodeId(),
+			Reason: EvictionReason(pb.GetReason()),
+		})
+	}
+	return evictions
+}
+
+// timeFromProto converts a timestamp, the zero time when it is unset.
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
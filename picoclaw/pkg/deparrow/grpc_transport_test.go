@@ -0,0 +1,266 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow/deparrowpb"
+)
+
+// fakeDeparrowServer serves job-1 and remembers what it was sent.
+type fakeDeparrowServer struct {
+	deparrowpb.UnimplementedDeparrowServer
+	noWatch bool
+
+	mu        sync.Mutex
+	submitted *deparrowpb.SubmitJobRequest
+	md        metadata.MD
+}
+
+func (s *fakeDeparrowServer) record(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.md, _ = metadata.FromIncomingContext(ctx)
+}
+
+func (s *fakeDeparrowServer) SubmitJob(ctx context.Context, req *deparrowpb.SubmitJobRequest) (*deparrowpb.SubmitJobResponse, error) {
+	s.record(ctx)
+	s.mu.Lock()
+	s.submitted = req
+	s.mu.Unlock()
+	return &deparrowpb.SubmitJobResponse{JobId: "job-1", Status: "submitted", CreditDeducted: req.CreditCost, Orchestrator: "orch-1"}, nil
+}
+
+func (s *fakeDeparrowServer) GetJob(ctx context.Context, req *deparrowpb.GetJobRequest) (*deparrowpb.Job, error) {
+	s.record(ctx)
+	if req.JobId != "job-1" {
+		return nil, status.Error(codes.NotFound, "job not found")
+	}
+	return &deparrowpb.Job{
+		JobId:       "job-1",
+		Status:      string(JobStatusCompleted),
+		UserId:      "user-1",
+		CreditCost:  1.5,
+		SubmittedAt: timestamppb.New(time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)),
+		Results:     &deparrowpb.JobResults{Stdout: "hello", ExitCode: 0, NodeId: "node-1"},
+		Evictions:   []*deparrowpb.JobEviction{{NodeId: "node-0", Reason: string(EvictionReclaimed)}},
+	}, nil
+}
+
+func (s *fakeDeparrowServer) CancelJob(ctx context.Context, req *deparrowpb.CancelJobRequest) (*deparrowpb.CancelJobResponse, error) {
+	return &deparrowpb.CancelJobResponse{JobId: req.JobId, Status: "cancelled", RefundAmount: 0.75}, nil
+}
+
+func (s *fakeDeparrowServer) WatchJob(req *deparrowpb.WatchJobRequest, stream grpc.ServerStreamingServer[deparrowpb.JobStatusUpdate]) error {
+	if s.noWatch {
+		return status.Error(codes.Unimplemented, "method WatchJob not implemented")
+	}
+	s.record(stream.Context())
+	for _, st := range []JobStatus{JobStatusPending, JobStatusRunning, JobStatusRunning, JobStatusCompleted} {
+		if err := stream.Send(&deparrowpb.JobStatusUpdate{JobId: req.JobId, Status: string(st)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeDeparrowServer) GetCredits(ctx context.Context, _ *deparrowpb.GetCreditsRequest) (*deparrowpb.CreditBalance, error) {
+	return &deparrowpb.CreditBalance{
+		CreditBalance: 42,
+		Buckets: []*deparrowpb.CreditBucket{
+			{BucketId: "b-1", Source: string(CreditSourcePromo), Amount: 10, ExpiresAt: timestamppb.Now()},
+			{BucketId: "b-2", Source: string(CreditSourcePurchased), Amount: 32},
+		},
+	}, nil
+}
+
+func (s *fakeDeparrowServer) GetNetworkStats(ctx context.Context, _ *deparrowpb.GetNetworkStatsRequest) (*deparrowpb.NetworkStats, error) {
+	return &deparrowpb.NetworkStats{TotalNodes: 12, OnlineNodes: 9, LiveTflops: 3.5, Tiers: map[string]int32{"gold": 2}}, nil
+}
+
+// startGRPCServer serves srv over gRPC on a local port and returns its
+// address.
+func startGRPCServer(t *testing.T, srv deparrowpb.DeparrowServer) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := grpc.NewServer()
+	deparrowpb.RegisterDeparrowServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestGRPCTransport_Calls(t *testing.T) {
+	srv := &fakeDeparrowServer{}
+	client := NewClient("http://"+startGRPCServer(t, srv), "test-token", WithTransport(TransportGRPC))
+	defer client.Close()
+	ctx := context.Background()
+
+	job, err := client.SubmitJobTo(ctx, &JobSpec{
+		Image:     "alpine",
+		Command:   []string{"echo", "hi"},
+		Resources: &ResourceSpec{CPU: "500m", Memory: "256Mi"},
+		Retry:     &RetrySpec{MaxAttempts: 3, RetryOnExitCodes: []int{137}},
+	}, "orch-1")
+	if err != nil {
+		t.Fatalf("SubmitJobTo() error = %v", err)
+	}
+	if job.ID != "job-1" || job.Orchestrator != "orch-1" || job.CreditCost <= 0 {
+		t.Errorf("job = %+v", job)
+	}
+	spec := srv.submitted.GetSpec()
+	if srv.submitted.GetOrchestrator() != "orch-1" || spec.GetResources().GetCpu() != "500m" ||
+		len(spec.GetRetry().GetRetryOnExitCodes()) != 1 || strings.Join(spec.GetCommand(), " ") != "echo hi" {
+		t.Errorf("submitted = %v", srv.submitted)
+	}
+	if auth := srv.md.Get("authorization"); len(auth) != 1 || auth[0] != "Bearer test-token" {
+		t.Errorf("authorization metadata = %v", auth)
+	}
+	if len(srv.md.Get(strings.ToLower(HeaderRequestID))) != 1 {
+		t.Errorf("metadata %v carries no request ID", srv.md)
+	}
+
+	job, err = client.GetJob(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != JobStatusCompleted || job.Results.Stdout != "hello" || job.SubmittedAt.Day() != 10 ||
+		len(job.Evictions) != 1 || job.Evictions[0].Reason != EvictionReclaimed {
+		t.Errorf("job = %+v", job)
+	}
+
+	_, err = client.GetJob(ctx, "job-2")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound || !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("GetJob(unknown) error = %v, want a 404 job_not_found", err)
+	}
+
+	if refund, err := client.CancelJob(ctx, "job-1"); err != nil || refund != 0.75 {
+		t.Errorf("CancelJob() = %v, %v", refund, err)
+	}
+
+	balance, err := client.GetCredits(ctx)
+	if err != nil || balance.Balance != 42 || len(balance.Buckets) != 2 ||
+		balance.Buckets[0].ExpiresAt == nil || balance.Buckets[1].ExpiresAt != nil {
+		t.Errorf("GetCredits() = %+v, %v", balance, err)
+	}
+
+	stats, err := client.GetNetworkStats(ctx)
+	if err != nil || stats.TotalNodes != 12 || stats.LiveTFlops != 3.5 || stats.TierDistribution["gold"] != 2 {
+		t.Errorf("GetNetworkStats() = %+v, %v", stats, err)
+	}
+
+	// Endpoints the gRPC API does not mirror stay on HTTP
+	if _, err := client.GetLeaderboardPage(ctx, LeaderboardOptions{}); err == nil {
+		t.Error("GetLeaderboardPage() reached the gRPC port over HTTP")
+	}
+}
+
+func TestGRPCTransport_WatchJob(t *testing.T) {
+	srv := &fakeDeparrowServer{}
+	client := NewClient("http://"+startGRPCServer(t, srv), "test-token", WithTransport(TransportGRPC))
+	defer client.Close()
+
+	watch, err := client.WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	if got := joinStatuses(collectUpdates(watch)); got != "pending,running,completed" {
+		t.Errorf("updates = %s, want pending,running,completed", got)
+	}
+	if err := watch.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+	if auth := srv.md.Get("authorization"); len(auth) != 1 {
+		t.Errorf("stream sent without authorization: %v", srv.md)
+	}
+}
+
+func TestGRPCTransport_WatchFallsBackToREST(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/watch") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "status": JobStatusCompleted})
+	}))
+	defer server.Close()
+
+	addr := startGRPCServer(t, &fakeDeparrowServer{noWatch: true})
+	client := NewClient(server.URL, "test-token", WithTransport(TransportGRPC), WithGRPCAddress(addr))
+	defer client.Close()
+
+	watch, err := client.WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	if got := joinStatuses(collectUpdates(watch)); got != "completed" {
+		t.Errorf("updates = %s, want completed", got)
+	}
+	if err := watch.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestGRPCError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/job-1", nil)
+	req.Header.Set(HeaderRequestID, "req-1")
+
+	st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(
+		&errdetails.ErrorInfo{Reason: "rate_limited"},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)},
+	)
+	if err != nil {
+		t.Fatalf("WithDetails() error = %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(grpcError(req, st.Err()), &apiErr) {
+		t.Fatal("grpcError() is not an *APIError")
+	}
+	if apiErr.Code != http.StatusTooManyRequests || apiErr.ErrorCode != "rate_limited" ||
+		apiErr.RetryAfter != 3*time.Second || apiErr.RequestID != "req-1" || apiErr.Message != "slow down" {
+		t.Errorf("grpcError() = %+v", apiErr)
+	}
+
+	for code, want := range map[codes.Code]int{
+		codes.Unauthenticated: http.StatusUnauthorized,
+		codes.Unimplemented:   http.StatusNotImplemented,
+		codes.Unavailable:     http.StatusServiceUnavailable,
+		codes.DataLoss:        http.StatusInternalServerError,
+	} {
+		if !errors.As(grpcError(req, status.Error(code, "")), &apiErr) || apiErr.Code != want {
+			t.Errorf("grpcError(%s) = %v, want status %d", code, apiErr, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := grpcError(req.WithContext(ctx), status.Error(codes.Canceled, "")); !errors.Is(err, context.Canceled) {
+		t.Errorf("grpcError(cancelled) = %v, want context.Canceled", err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
@@ -25,9 +27,12 @@ func (t *JobTool) Name() string {
 	return "deparrow_submit_job"
 }
 
-// Description returns the tool description.
+// Description returns the tool description. Verification, placement
+// constraints and spot pricing are only described when the server
+// supports them.
 func (t *JobTool) Description() string {
-	return `Submit a compute job to the DEparrow network.
+	var b strings.Builder
+	b.WriteString(`Submit a compute job to the DEparrow network.
 
 This tool allows you to run containerized workloads on the distributed
 DEparrow compute network. Jobs can be Docker containers with custom commands,
@@ -38,19 +43,57 @@ Use this when you need to:
 - Run distributed data processing
 - Perform machine learning inference
 - Process large datasets in parallel
-
+`)
+	if t.client.offers(FeatureVerifiedJobs) {
+		b.WriteString(`
+Set verified=true when the result must be trustworthy. The job then runs
+redundantly on several nodes whose results are compared, or in a trusted
+execution environment with verification_mode='tee'. Verification costs
+extra: each additional replica is charged in full and TEE placement adds
+50%. The premium is included in the cost shown after submission.
+`)
+	}
+	if t.client.offers(FeatureSchedulingConstraints) {
+		b.WriteString(`
+To control where the job runs, set architectures (e.g. ["arm64"]),
+regions, node_selector (node labels that must match) or exclude_node_ids.
+The job then only runs on nodes that meet all of them; use
+'deparrow_nodes' to see which nodes have which architecture, region and
+labels.
+`)
+	}
+	if t.client.offers(FeatureSpotPricing) {
+		b.WriteString(`
+Set pricing_class='spot' to run cheaply on idle capacity, at around 40%
+of the list price. A spot job can be evicted when the node is needed for
+guaranteed work; it then goes back in the queue and starts over, so only
+use it for jobs that can be restarted. max_credit_bid caps what a spot job
+pays. Evictions are listed by 'deparrow_job_status'.
+`)
+	}
+	b.WriteString(`
 Example usage:
   image: "python:3.11-slim"
   command: "python -c 'print(2+2)'"
   resources:
     cpu: "500m"
     memory: "256Mi"
-`
+`)
+	return b.String()
 }
 
-// Parameters returns the JSON schema for tool parameters.
+// featureParams are the job parameters that need an optional feature.
+var featureParams = map[string][]string{
+	FeatureVerifiedJobs:          {"verified", "verification_mode", "replicas"},
+	FeatureSpotPricing:           {"pricing_class", "max_credit_bid"},
+	FeatureOrchestratorRouting:   {"orchestrator"},
+	FeatureSchedulingConstraints: {"architectures", "regions", "node_selector", "exclude_node_ids"},
+}
+
+// Parameters returns the JSON schema for tool parameters, leaving out those
+// that need a feature the server doesn't support.
 func (t *JobTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
+	params := map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
 			"image": map[string]interface{}{
@@ -110,6 +153,58 @@ func (t *JobTool) Parameters() map[string]interface{} {
 					},
 				},
 			},
+			"verified": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Request a verified result, at a premium",
+				"default":     false,
+			},
+			"verification_mode": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{string(VerifyRedundant), string(VerifyTEE)},
+				"description": "How a verified job is checked: 'redundant' compares results from several nodes, 'tee' runs in a trusted execution environment",
+				"default":     string(VerifyRedundant),
+			},
+			"replicas": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of nodes a redundant verified job runs on",
+				"default":     DefaultVerificationReplicas,
+				"minimum":     2,
+				"maximum":     MaxVerificationReplicas,
+			},
+			"pricing_class": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{string(PricingGuaranteed), string(PricingSpot)},
+				"description": "'guaranteed' runs to completion at the list price; 'spot' runs on idle capacity at a discount but may be evicted and requeued",
+				"default":     string(PricingGuaranteed),
+			},
+			"max_credit_bid": map[string]interface{}{
+				"type":        "number",
+				"description": "Most credits a spot job will pay; it waits while the spot price is higher",
+			},
+			"orchestrator": map[string]interface{}{
+				"type":        "string",
+				"description": "Orchestrator ID to submit through (see 'deparrow_orchestrators'); the least loaded one is used if omitted",
+			},
+			"architectures": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string", "enum": []string{string(ArchX86_64), string(ArchARM64)}},
+				"description": "CPU architectures the job may run on (any of them)",
+			},
+			"regions": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]string{"type": "string"},
+				"description": "Regions the job may run in (any of them), e.g. ['eu-west']",
+			},
+			"node_selector": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]string{"type": "string"},
+				"description":          "Node labels that must all match, e.g. {\"gpu_vendor\": \"nvidia\"}",
+			},
+			"exclude_node_ids": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]string{"type": "string"},
+				"description": "Node IDs the job must not run on",
+			},
 			"wait": map[string]interface{}{
 				"type":        "boolean",
 				"description": "Wait for job completion and return results",
@@ -118,16 +213,76 @@ func (t *JobTool) Parameters() map[string]interface{} {
 		},
 		"required": []string{"image"},
 	}
+	properties := params["properties"].(map[string]interface{})
+	for feature, names := range featureParams {
+		if !t.client.offers(feature) {
+			for _, name := range names {
+				delete(properties, name)
+			}
+		}
+	}
+	return params
 }
 
 // Execute runs the job submission tool.
 func (t *JobTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	spec, err := buildJobSpec(t.client, args)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	verification, _ := spec.verification()
+
+	if result := checkJobSpend(t.client, spec); result != nil {
+		return result
+	}
+
+	// Submit job
+	orchestrator, _ := args["orchestrator"].(string)
+	job, err := t.client.SubmitJobTo(ctx, spec, orchestrator)
+	if err != nil {
+		return failureResult("submit job", err)
+	}
+
+	// Check if we should wait for completion
+	if wait, _ := args["wait"].(bool); wait {
+		return t.waitForJob(ctx, job.ID)
+	}
+
+	// Return immediate response with job ID
+	result := fmt.Sprintf(
+		"Job submitted successfully!\n\nJob ID: %s\nStatus: %s\nCredit Cost: %.2f\n",
+		job.ID, job.Status, job.CreditCost,
+	)
+	if verification != nil {
+		base := baseCreditCost(spec)
+		result += fmt.Sprintf("Verification: %s (estimated %.2f + %.2f credits premium)\n",
+			describeVerification(verification), base, verificationPremium(spec, base))
+	}
+	if job.Orchestrator != "" {
+		result += fmt.Sprintf("Orchestrator: %s\n", job.Orchestrator)
+	}
+	if pricing := describePricing(spec); pricing != "" {
+		result += fmt.Sprintf("Pricing: %s\n", pricing)
+	}
+	if placement := describeConstraints(spec); placement != "" {
+		result += fmt.Sprintf("Placement: %s\n", placement)
+	}
+	result += fmt.Sprintf("\nUse 'deparrow_job_status' with job_id='%s' to check progress.", job.ID)
+
+	return tools.UserResult(result)
+}
+
+// buildJobSpec builds a job spec from the job submission tool's
+// arguments, filling in what they leave out from the user's preferences.
+func buildJobSpec(client *Client, args map[string]interface{}) (*JobSpec, error) {
 	// Extract image (required)
 	image, ok := args["image"].(string)
 	if !ok || image == "" {
-		return tools.ErrorResult("image parameter is required")
+		return nil, fmt.Errorf("image parameter is required")
 	}
 
+	prefs := client.Preferences()
+
 	// Build job spec
 	spec := &JobSpec{
 		Image: image,
@@ -139,6 +294,19 @@ func (t *JobTool) Execute(ctx context.Context, args map[string]interface{}) *too
 		Labels:  make(map[string]string),
 	}
 
+	// Saved preferences replace the built-in defaults
+	if res := prefs.DefaultResources; res != nil {
+		if res.CPU != "" {
+			spec.Resources.CPU = res.CPU
+		}
+		if res.Memory != "" {
+			spec.Resources.Memory = res.Memory
+		}
+		if res.GPU != "" {
+			spec.Resources.GPU = res.GPU
+		}
+	}
+
 	// Parse command
 	if cmd, ok := args["command"].(string); ok && cmd != "" {
 		// Split command into parts for proper execution
@@ -208,70 +376,154 @@ func (t *JobTool) Execute(ctx context.Context, args map[string]interface{}) *too
 		}
 	}
 
-	// Submit job
-	job, err := t.client.SubmitJob(ctx, spec)
-	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to submit job: %v", err))
+	// Parse verification
+	if verified, _ := args["verified"].(bool); verified {
+		spec.Verified = true
+		mode, _ := args["verification_mode"].(string)
+		replicas, _ := args["replicas"].(float64)
+		if mode != "" || replicas != 0 {
+			spec.Verification = &VerificationSpec{Mode: VerificationMode(mode), Replicas: int(replicas)}
+		}
+	}
+	if _, err := spec.verification(); err != nil {
+		return nil, err
 	}
 
-	// Check if we should wait for completion
-	if wait, _ := args["wait"].(bool); wait {
-		return t.waitForJob(ctx, job.ID)
+	// Parse pricing
+	if class, ok := args["pricing_class"].(string); ok {
+		spec.PricingClass = PricingClass(class)
+	}
+	if bid, ok := args["max_credit_bid"].(float64); ok {
+		spec.MaxCreditBid = bid
 	}
 
-	// Return immediate response with job ID
-	result := fmt.Sprintf(
-		"Job submitted successfully!\n\nJob ID: %s\nStatus: %s\nCredit Cost: %.2f\n\n"+
-			"Use 'deparrow_job_status' with job_id='%s' to check progress.",
-		job.ID, job.Status, job.CreditCost, job.ID,
-	)
+	// Parse scheduling constraints
+	for _, arch := range stringList(args["architectures"]) {
+		spec.Architectures = append(spec.Architectures, Architecture(arch))
+	}
+	spec.Regions = stringList(args["regions"])
+	spec.ExcludeNodeIDs = stringList(args["exclude_node_ids"])
+	if selector, ok := args["node_selector"].(map[string]interface{}); ok && len(selector) > 0 {
+		spec.NodeSelector = make(map[string]string)
+		for k, v := range selector {
+			if vStr, ok := v.(string); ok {
+				spec.NodeSelector[k] = vStr
+			}
+		}
+	}
 
-	return tools.UserResult(result)
+	// Apply placement preferences as scheduling constraints, on servers
+	// known to honour them; explicit regions and selectors win over them
+	if client.advertises(FeatureSchedulingConstraints) {
+		if prefs.DefaultRegion != "" && len(spec.Regions) == 0 {
+			spec.Regions = []string{prefs.DefaultRegion}
+		}
+		if prefs.PreferredGPUVendor != "" && spec.Resources.GPU != "" && spec.Resources.GPU != "0" {
+			if _, ok := spec.NodeSelector[NodeGPUVendorLabel]; !ok {
+				if spec.NodeSelector == nil {
+					spec.NodeSelector = make(map[string]string)
+				}
+				spec.NodeSelector[NodeGPUVendorLabel] = prefs.PreferredGPUVendor
+			}
+		}
+	}
+
+	return spec, nil
+}
+
+// stringList returns the strings of a tool argument that is a JSON array,
+// skipping anything that isn't a non-empty string.
+func stringList(arg interface{}) []string {
+	items, _ := arg.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// checkJobSpend returns an error result when the job must not be
+// submitted: while spending is paused, or when its estimated cost exceeds
+// the user's per-job maximum.
+func checkJobSpend(client *Client, spec *JobSpec) *tools.ToolResult {
+	// Nothing is spent while spending is paused
+	if pause := client.SpendingPaused(); pause != nil {
+		return tools.ErrorResult(spendingPausedMessage(pause))
+	}
+
+	// Enforce the user's cost ceiling before spending anything
+	if limit := client.Preferences().MaxJobCost; limit > 0 {
+		if cost := calculateCreditCost(spec); cost > limit {
+			return tools.ErrorResult(fmt.Sprintf(
+				"Estimated cost %.2f credits exceeds your maximum of %.2f credits per job. "+
+					"Reduce the job's resources or raise the limit with 'deparrow_preferences'.",
+				cost, limit,
+			))
+		}
+	}
+	return nil
 }
 
-// waitForJob polls for job completion and returns the results.
+// waitForJob watches the job until it finishes and returns the results.
 func (t *JobTool) waitForJob(ctx context.Context, jobID string) *tools.ToolResult {
-	for {
-		job, err := t.client.GetJob(ctx, jobID)
-		if err != nil {
-			return tools.ErrorResult(fmt.Sprintf("Failed to get job status: %v", err))
+	watch, err := t.client.WatchJob(ctx, jobID)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to watch job: %v", err))
+	}
+	defer watch.Close()
+
+	for range watch.Updates() {
+	}
+	if err := watch.Err(); err != nil {
+		if ctx.Err() != nil {
+			return tools.ErrorResult("Job wait cancelled by context")
 		}
+		return failureResult("get job status", err)
+	}
+
+	job, err := t.client.GetJob(ctx, jobID)
+	if err != nil {
+		return failureResult("get job status", err)
+	}
 
-		switch job.Status {
-		case JobStatusCompleted:
-			result := fmt.Sprintf(
-				"Job completed successfully!\n\nJob ID: %s\nDuration: %.1fs\n\n",
-				job.ID, job.Results.Duration,
-			)
+	switch job.Status {
+	case JobStatusCompleted:
+		result := fmt.Sprintf(
+			"Job completed successfully!\n\nJob ID: %s\n",
+			job.ID,
+		)
+		if job.Results != nil {
+			result += fmt.Sprintf("Duration: %.1fs\n", job.Results.Duration)
+			if v := job.Results.Verification; v != nil {
+				result += "Verification: " + v.Summary() + "\n"
+				if !v.Trusted() {
+					result += "Warning: the result could not be verified, do not rely on it.\n"
+				}
+			}
+			result += "\n"
 			if job.Results.Stdout != "" {
-				result += "Output:\n" + job.Results.Stdout
+				result += "Output:\n" + t.client.CaptureOutput(job.ID, LogStreamStdout, job.Results.Stdout).String()
 			}
 			if job.Results.OutputCID != "" {
 				result += fmt.Sprintf("\n\nOutput CID: %s", job.Results.OutputCID)
 			}
-			return tools.UserResult(result)
+		}
+		return tools.UserResult(result)
 
-		case JobStatusFailed:
-			errMsg := job.Error
-			if job.Results != nil && job.Results.Stderr != "" {
-				errMsg = job.Results.Stderr
-			}
-			return tools.ErrorResult(fmt.Sprintf("Job failed: %s", errMsg))
-
-		case JobStatusCancelled:
-			return tools.ErrorResult("Job was cancelled")
-
-		case JobStatusPending, JobStatusRunning:
-			// Continue waiting
-			select {
-			case <-ctx.Done():
-				return tools.ErrorResult("Job wait cancelled by context")
-			default:
-				// Wait a bit before polling again
-				// In a real implementation, use proper sleep
-				continue
-			}
+	case JobStatusFailed:
+		errMsg := job.Error
+		if job.Results != nil && job.Results.Stderr != "" {
+			errMsg = t.client.CaptureOutput(job.ID, LogStreamStderr, job.Results.Stderr).String()
 		}
+		return tools.ErrorResult(fmt.Sprintf("Job failed: %s", errMsg))
+
+	case JobStatusCancelled:
+		return tools.ErrorResult("Job was cancelled")
+
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Job is still %s", job.Status))
 	}
 }
 
@@ -318,14 +570,23 @@ func (t *JobStatusTool) Execute(ctx context.Context, args map[string]interface{}
 
 	job, err := t.client.GetJob(ctx, jobID)
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to get job: %v", err))
+		return failureResult("get job", err)
 	}
 
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Job ID: %s\n", job.ID))
 	result.WriteString(fmt.Sprintf("Status: %s\n", job.Status))
+	if !job.Status.IsTerminal() {
+		result.WriteString(fmt.Sprintf("Progress: %d%%\n", job.Status.Progress()))
+	}
 	result.WriteString(fmt.Sprintf("Credit Cost: %.2f\n", job.CreditCost))
 	result.WriteString(fmt.Sprintf("Submitted: %s\n", job.SubmittedAt.Format("2006-01-02 15:04:05")))
+	if len(job.Evictions) > 0 {
+		result.WriteString(fmt.Sprintf("\nEvicted %d time(s); this spot job was requeued after each:\n", len(job.Evictions)))
+		for _, e := range job.Evictions {
+			result.WriteString(fmt.Sprintf("  • %s\n", e.Describe()))
+		}
+	}
 
 	if job.Results != nil {
 		result.WriteString(fmt.Sprintf("\nDuration: %.1f seconds\n", job.Results.Duration))
@@ -333,8 +594,11 @@ func (t *JobStatusTool) Execute(ctx context.Context, args map[string]interface{}
 		if job.Results.OutputCID != "" {
 			result.WriteString(fmt.Sprintf("Output CID: %s\n", job.Results.OutputCID))
 		}
+		if v := job.Results.Verification; v != nil {
+			result.WriteString(fmt.Sprintf("Verification: %s\n", v.Summary()))
+		}
 		if job.Results.Stdout != "" {
-			result.WriteString(fmt.Sprintf("\nOutput:\n%s", job.Results.Stdout))
+			result.WriteString(fmt.Sprintf("\nOutput:\n%s", t.client.CaptureOutput(job.ID, LogStreamStdout, job.Results.Stdout)))
 		}
 	}
 
@@ -358,41 +622,178 @@ func (t *JobListTool) Name() string {
 
 // Description returns the tool description.
 func (t *JobListTool) Description() string {
-	return "List all jobs submitted by the authenticated user."
+	return "List jobs submitted by the authenticated user, with counts by status. " +
+		"Jobs can be sorted by submission time, cost or duration and are shown a page at a time."
 }
 
 // Parameters returns the JSON schema for tool parameters.
 func (t *JobListTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
-		"type":       "object",
-		"properties": map[string]interface{}{},
+		"type": "object",
+		"properties": map[string]interface{}{
+			"sort_by": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{string(JobSortSubmittedAt), string(JobSortCost), string(JobSortDuration)},
+				"description": "Field to sort jobs by",
+				"default":     string(JobSortSubmittedAt),
+			},
+			"order": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"desc", "asc"},
+				"description": "desc for newest, most expensive or longest first; asc for the reverse",
+				"default":     "desc",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of jobs to show",
+				"default":     defaultJobListLimit,
+			},
+			"page": map[string]interface{}{
+				"type":        "integer",
+				"description": "Page of limit jobs to show, starting at 1",
+				"default":     1,
+			},
+		},
 	}
 }
 
+// defaultJobListLimit is how many jobs the list tool shows by default.
+const defaultJobListLimit = 10
+
+// jobStatusOrder is the order statuses appear in the list header.
+var jobStatusOrder = []JobStatus{
+	JobStatusCompleted, JobStatusRunning, JobStatusPending, JobStatusFailed, JobStatusCancelled,
+}
+
 // Execute runs the job list tool.
 func (t *JobListTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	jobs, err := t.client.ListJobs(ctx)
+	opts := JobPageOptions{SortBy: JobSortSubmittedAt}
+	if sortBy, ok := args["sort_by"].(string); ok && sortBy != "" {
+		opts.SortBy = JobSortField(sortBy)
+		if !opts.SortBy.Valid() {
+			return tools.ErrorResult(fmt.Sprintf("Unknown sort_by: %s (use submitted_at, cost or duration)", sortBy))
+		}
+	}
+	switch order, _ := args["order"].(string); order {
+	case "", "desc":
+	case "asc":
+		opts.Ascending = true
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown order: %s (use asc or desc)", order))
+	}
+	opts.Limit = defaultJobListLimit
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		opts.Limit = int(l)
+	}
+	if p, ok := args["page"].(float64); ok && p > 1 {
+		opts.Offset = (int(p) - 1) * opts.Limit
+	}
+
+	page, err := t.client.ListJobsPage(ctx, opts)
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to list jobs: %v", err))
+		return failureResult("list jobs", err)
+	}
+
+	jobs, total := page.Jobs, page.Total
+	_, more := page.Next()
+	// A server that does not page returns the whole list
+	if len(jobs) > opts.Limit {
+		total = len(jobs)
+		jobs = jobs[min(opts.Offset, total):min(opts.Offset+opts.Limit, total)]
+		more = opts.Offset+opts.Limit < total
+	}
+	if total < 0 && !more {
+		total = opts.Offset + len(jobs)
+	}
+	counts := page.Counts
+	if counts == nil && opts.Offset == 0 && !more {
+		counts = make(map[JobStatus]int)
+		for _, job := range jobs {
+			counts[job.Status]++
+		}
 	}
 
 	if len(jobs) == 0 {
+		if opts.Offset > 0 && total > 0 {
+			return tools.UserResult(fmt.Sprintf("No jobs on this page; there are %d jobs.", total))
+		}
 		return tools.UserResult("No jobs found.")
 	}
 
+	return tools.UserResult(formatJobList(jobs, counts, total, more, opts))
+}
+
+// formatJobList renders the status counts followed by one page of jobs.
+// total is the number of jobs in the whole list, or -1 when unknown.
+func formatJobList(jobs []Job, counts map[JobStatus]int, total int, more bool, opts JobPageOptions) string {
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Found %d jobs:\n\n", len(jobs)))
+	if total >= 0 {
+		result.WriteString(fmt.Sprintf("%d jobs", total))
+	} else {
+		result.WriteString("Jobs")
+	}
+	if len(counts) > 0 {
+		result.WriteString(": " + formatJobCounts(counts))
+	}
+	result.WriteString("\n")
+
+	order := "newest first"
+	switch {
+	case opts.SortBy == JobSortCost && opts.Ascending:
+		order = "cheapest first"
+	case opts.SortBy == JobSortCost:
+		order = "most expensive first"
+	case opts.SortBy == JobSortDuration && opts.Ascending:
+		order = "shortest first"
+	case opts.SortBy == JobSortDuration:
+		order = "longest first"
+	case opts.Ascending:
+		order = "oldest first"
+	}
+	result.WriteString(fmt.Sprintf("Sorted %s\n\n", order))
 
+	now := time.Now()
 	for i, job := range jobs {
-		result.WriteString(fmt.Sprintf("%d. %s [%s]\n", i+1, job.ID, job.Status))
+		result.WriteString(fmt.Sprintf("%d. %s [%s]", opts.Offset+i+1, job.ID, job.Status))
 		if job.Spec != nil && job.Spec.Image != "" {
-			result.WriteString(fmt.Sprintf("   Image: %s\n", job.Spec.Image))
+			result.WriteString(" " + job.Spec.Image)
 		}
-		result.WriteString(fmt.Sprintf("   Credits: %.2f\n", job.CreditCost))
-		result.WriteString(fmt.Sprintf("   Submitted: %s\n\n", job.SubmittedAt.Format("2006-01-02 15:04:05")))
+		result.WriteString(fmt.Sprintf(" · %.2f credits · %s · submitted %s\n",
+			job.CreditCost, job.Duration(now).Round(time.Second), job.SubmittedAt.Format("2006-01-02 15:04")))
 	}
 
-	return tools.UserResult(result.String())
+	if more {
+		next := opts.Offset/opts.Limit + 2
+		if rest := total - opts.Offset - len(jobs); total >= 0 && rest > 0 {
+			result.WriteString(fmt.Sprintf("... and %d more (page=%d shows the next ones)\n", rest, next))
+		} else {
+			result.WriteString(fmt.Sprintf("... and more (page=%d shows the next ones)\n", next))
+		}
+	}
+
+	return result.String()
+}
+
+// formatJobCounts renders counts like "34 completed, 2 running, 1 failed".
+func formatJobCounts(counts map[JobStatus]int) string {
+	var parts []string
+	seen := make(map[JobStatus]bool)
+	for _, status := range jobStatusOrder {
+		seen[status] = true
+		if n := counts[status]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+
+	// Statuses this client does not know about yet
+	var other []string
+	for status, n := range counts {
+		if !seen[status] && n > 0 {
+			other = append(other, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	sort.Strings(other)
+	return strings.Join(append(parts, other...), ", ")
 }
 
 // JobCancelTool provides the ability to cancel a running job.
@@ -438,7 +839,7 @@ func (t *JobCancelTool) Execute(ctx context.Context, args map[string]interface{}
 
 	refund, err := t.client.CancelJob(ctx, jobID)
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to cancel job: %v", err))
+		return failureResult("cancel job", err)
 	}
 
 	return tools.UserResult(fmt.Sprintf(
@@ -347,6 +347,19 @@ func TestJobStatusTool_Execute_Success(t *testing.T) {
 	}
 }
 
+func TestJobStatusTool_Execute_Progress(t *testing.T) {
+	client := NewSandboxClient()
+	job, err := client.SubmitJob(context.Background(), &JobSpec{Image: "alpine"})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+
+	result := NewJobStatusTool(client).Execute(context.Background(), map[string]interface{}{"job_id": job.ID})
+	if result.IsError || !contains(result.ForLLM, "Progress: ") {
+		t.Errorf("Result should show the progress of an unfinished job: %s", result.ForLLM)
+	}
+}
+
 func TestJobStatusTool_Execute_MissingJobID(t *testing.T) {
 	client := NewClient("http://localhost:8080", "test-token")
 	tool := NewJobStatusTool(client)
@@ -438,6 +451,65 @@ func TestJobListTool_Execute_Success(t *testing.T) {
 	}
 }
 
+func TestJobListTool_Execute_CountsAndSort(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+	for _, image := range []string{"alpine", "python:3.11"} {
+		if _, err := client.SubmitJob(ctx, &JobSpec{Image: image}); err != nil {
+			t.Fatalf("SubmitJob() error = %v", err)
+		}
+	}
+	tool := NewJobListTool(client)
+
+	result := tool.Execute(ctx, map[string]interface{}{"sort_by": "cost", "order": "asc", "limit": 1.0})
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	for _, want := range []string{"2 jobs: 2 pending", "cheapest first", "and 1 more (page=2"} {
+		if !contains(result.ForLLM, want) {
+			t.Errorf("Result should contain %q: %s", want, result.ForLLM)
+		}
+	}
+
+	if result := tool.Execute(ctx, map[string]interface{}{"sort_by": "name"}); !result.IsError {
+		t.Error("expected error for an unknown sort_by")
+	}
+}
+
+func TestJobListTool_Execute_Pages(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if _, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"}); err != nil {
+			t.Fatalf("SubmitJob() error = %v", err)
+		}
+	}
+	tool := NewJobListTool(client)
+
+	result := tool.Execute(ctx, map[string]interface{}{"order": "asc", "limit": 2.0, "page": 2.0})
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	for _, want := range []string{"5 jobs: 5 pending", "3. sandbox-job-0003", "4. sandbox-job-0004", "and 1 more (page=3"} {
+		if !contains(result.ForLLM, want) {
+			t.Errorf("Result should contain %q: %s", want, result.ForLLM)
+		}
+	}
+	if contains(result.ForLLM, "sandbox-job-0002") {
+		t.Errorf("Result should not contain the first page: %s", result.ForLLM)
+	}
+
+	// The last page of a server that does not page its list
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"jobs": []Job{{ID: "job-1"}, {ID: "job-2"}, {ID: "job-3"}}})
+	}))
+	defer server.Close()
+	result = NewJobListTool(NewClient(server.URL, "test-token")).Execute(ctx, map[string]interface{}{"limit": 2.0, "page": 2.0})
+	if !contains(result.ForLLM, "3 jobs") || !contains(result.ForLLM, "3. job-3") || contains(result.ForLLM, "more") {
+		t.Errorf("Result should show job-3 of 3 jobs: %s", result.ForLLM)
+	}
+}
+
 func TestJobListTool_Execute_Empty(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
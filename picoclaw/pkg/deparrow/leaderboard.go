@@ -0,0 +1,118 @@
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// leaderboardPath is the contribution leaderboard.
+const leaderboardPath = "/api/v1/network/leaderboard"
+
+// LeaderboardWindow is the period a leaderboard ranks contributions over.
+type LeaderboardWindow string
+
+// Leaderboard windows.
+const (
+	LeaderboardDay     LeaderboardWindow = "24h"
+	LeaderboardWeek    LeaderboardWindow = "7d"
+	LeaderboardMonth   LeaderboardWindow = "30d"
+	LeaderboardAllTime LeaderboardWindow = "all"
+)
+
+// Valid reports whether the window is known.
+func (w LeaderboardWindow) Valid() bool {
+	switch w {
+	case LeaderboardDay, LeaderboardWeek, LeaderboardMonth, LeaderboardAllTime:
+		return true
+	}
+	return false
+}
+
+// RankChange returns how many places the entry rose since the previous
+// period, negative when it fell. It reports false when the server gave no
+// previous rank, or the node was not ranked then.
+func (e LeaderboardEntry) RankChange() (int, bool) {
+	if e.PreviousRank == nil || *e.PreviousRank <= 0 {
+		return 0, false
+	}
+	return *e.PreviousRank - e.Rank, true
+}
+
+// LeaderboardOptions selects a page of the leaderboard.
+type LeaderboardOptions struct {
+	PageOptions
+	// Window to rank over; empty keeps the server's default, all time
+	Window LeaderboardWindow
+	// Region ranks only the nodes of one region; empty ranks them all
+	Region string
+}
+
+// query encodes the options as list parameters.
+func (o LeaderboardOptions) query() url.Values {
+	query := url.Values{}
+	o.encode(query)
+	if o.Window != "" {
+		query.Set("window", string(o.Window))
+	}
+	if o.Region != "" {
+		query.Set("region", o.Region)
+	}
+	return query
+}
+
+// LeaderboardPage is one page of the leaderboard.
+type LeaderboardPage struct {
+	Entries []LeaderboardEntry
+	// Window the server ranked over, when it reports it
+	Window LeaderboardWindow
+	// Total ranked nodes, or -1 when the server does not report it
+	Total int
+
+	opts    LeaderboardOptions
+	next    PageOptions
+	hasNext bool
+}
+
+// Next returns the options for the page after this one, or false on the
+// last page.
+func (p *LeaderboardPage) Next() (LeaderboardOptions, bool) {
+	opts := p.opts
+	opts.PageOptions = p.next
+	return opts, p.hasNext
+}
+
+// GetLeaderboardPage fetches one page of the leaderboard matching opts.
+//
+// Example:
+//
+//	// This week's top ten in Europe
+//	page, err := client.GetLeaderboardPage(ctx, deparrow.LeaderboardOptions{
+//	    PageOptions: deparrow.PageOptions{Limit: 10},
+//	    Window:      deparrow.LeaderboardWeek,
+//	    Region:      "eu-west",
+//	})
+func (c *Client) GetLeaderboardPage(ctx context.Context, opts LeaderboardOptions) (*LeaderboardPage, error) {
+	if opts.Window != "" && !opts.Window.Valid() {
+		return nil, fmt.Errorf("unsupported leaderboard window: %s", opts.Window)
+	}
+
+	page := &LeaderboardPage{opts: opts}
+	others := map[string]interface{}{"window": &page.Window}
+	state, err := c.streamPage(ctx, leaderboardPath, "leaderboard", opts.query(), others, func(dec *json.Decoder) error {
+		var entry LeaderboardEntry
+		if err := dec.Decode(&entry); err != nil {
+			return &malformedResponseError{err}
+		}
+		page.Entries = append(page.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = state.Total
+	page.next, page.hasNext = opts.PageOptions.next(state)
+	return page, nil
+}
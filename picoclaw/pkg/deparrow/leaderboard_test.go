@@ -0,0 +1,94 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSandbox_LeaderboardWindowsAndRegions(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	page, err := client.GetLeaderboardPage(ctx, LeaderboardOptions{
+		PageOptions: PageOptions{Limit: 2},
+		Window:      LeaderboardWeek,
+	})
+	if err != nil {
+		t.Fatalf("GetLeaderboardPage() error = %v", err)
+	}
+	if page.Window != LeaderboardWeek || page.Total != 7 || len(page.Entries) != 2 {
+		t.Fatalf("page = %+v", page)
+	}
+	// The H100 node leads the week from third all time
+	top := page.Entries[0]
+	if change, ok := top.RankChange(); top.NodeID != "node-euw-h100-01" || !ok || change != 2 {
+		t.Errorf("top = %s, change %d, %v; want node-euw-h100-01 up 2", top.NodeID, change, ok)
+	}
+
+	next, ok := page.Next()
+	if !ok || next.Window != LeaderboardWeek {
+		t.Fatalf("Next() = %+v, %v", next, ok)
+	}
+	page, err = client.GetLeaderboardPage(ctx, next)
+	if err != nil || page.Entries[0].Rank != 3 {
+		t.Errorf("second page = %+v, %v; want it to start at rank 3", page, err)
+	}
+
+	page, err = client.GetLeaderboardPage(ctx, LeaderboardOptions{Region: "eu-west"})
+	if err != nil || len(page.Entries) != 2 || page.Entries[1].NodeID != "node-euw-arm-01" || page.Entries[1].Rank != 2 {
+		t.Errorf("eu-west page = %+v, %v", page, err)
+	}
+	if page.Entries[0].PreviousRank != nil {
+		t.Error("all-time entries report a previous rank")
+	}
+
+	if _, err := client.GetLeaderboardPage(ctx, LeaderboardOptions{Window: "1y"}); err == nil {
+		t.Error("expected error for an unsupported window")
+	}
+}
+
+func TestLeaderboardTool_RankDeltas(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rank := func(n int) *int { return &n }
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"window": "24h",
+			"total":  40,
+			"leaderboard": []LeaderboardEntry{
+				{Rank: 11, NodeID: "node-riser", Tier: TierGold, PreviousRank: rank(14)},
+				{Rank: 12, NodeID: "node-faller", Tier: TierGold, PreviousRank: rank(9)},
+				{Rank: 13, NodeID: "node-steady", Tier: TierSilver, PreviousRank: rank(13)},
+				{Rank: 14, NodeID: "node-newcomer", Tier: TierBronze, PreviousRank: rank(0)},
+			},
+		})
+	}))
+	defer server.Close()
+
+	tool := NewLeaderboardTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"limit": 4.0, "offset": 10.0, "window": "24h", "region": "eu-west",
+	})
+	if result.IsError {
+		t.Fatalf("Execute() error = %s", result.ForLLM)
+	}
+	for _, param := range []string{"window=24h", "region=eu-west", "offset=10", "limit=4"} {
+		if !strings.Contains(query, param) {
+			t.Errorf("query %s missing %s", query, param)
+		}
+	}
+	for _, want := range []string{
+		"Last 24 hours · eu-west", "node-riser  ▲3", "node-faller  ▼3", "node-steady  =",
+		"node-newcome...  🆕 new", "Showing 4 of 40", "offset=14",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+}
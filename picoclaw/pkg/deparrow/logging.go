@@ -0,0 +1,271 @@
+package deparrow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// DefaultDebugBodyLimit is the number of body bytes debug logging shows
+// when WithDebugLogging is given no limit.
+const DefaultDebugBodyLimit = 4096
+
+// redacted replaces secrets in logged headers and bodies.
+const redacted = "[REDACTED]"
+
+// Logger receives an event for every request the client sends and every
+// response it gets, with credentials redacted. Implementations must be
+// safe for concurrent use and should return quickly, as they are called
+// on the request path.
+type Logger interface {
+	// LogRequest is called once per attempt, just before it is sent.
+	LogRequest(RequestLog)
+
+	// LogResponse is called once per attempt, after the response headers
+	// arrive or the attempt fails.
+	LogResponse(ResponseLog)
+}
+
+// RequestLog describes a request attempt about to be sent.
+type RequestLog struct {
+	Method    string
+	URL       string
+	RequestID string
+	// Headers with Authorization, API keys and cookies redacted
+	Header http.Header
+	// Body is only set in debug mode, with secrets redacted and cut at
+	// the debug body limit
+	Body string
+}
+
+// ResponseLog describes the outcome of a request attempt.
+type ResponseLog struct {
+	Method    string
+	URL       string
+	RequestID string
+	// StatusCode is zero when no response arrived
+	StatusCode int
+	Duration   time.Duration
+	// Header with cookies redacted
+	Header http.Header
+	// Body is only set in debug mode, like RequestLog.Body; streamed
+	// responses are never read ahead
+	Body string
+	// Err is set when no response arrived
+	Err error
+}
+
+// WithLogger reports every request and response to l.
+func WithLogger(l Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithDebugLogging makes the logger also receive request and response
+// bodies, cut after limit bytes (DefaultDebugBodyLimit when zero or less).
+// It has no effect without WithLogger.
+func WithDebugLogging(limit int) ClientOption {
+	return func(c *Client) {
+		if limit <= 0 {
+			limit = DefaultDebugBodyLimit
+		}
+		c.debugBodyLimit = limit
+	}
+}
+
+// logRequest reports a request attempt to the logger, if any.
+func (c *Client) logRequest(req *http.Request) {
+	if c.logger == nil {
+		return
+	}
+	entry := RequestLog{
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		RequestID: req.Header.Get(HeaderRequestID),
+		Header:    redactHeader(req.Header),
+	}
+	if c.debugBodyLimit > 0 && req.GetBody != nil && loggableBody(req.Header) {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, int64(c.debugBodyLimit)+1))
+			body.Close()
+			entry.Body = formatLogBody(data, c.debugBodyLimit)
+		}
+	}
+	c.logger.LogRequest(entry)
+}
+
+// logResponse reports the outcome of a request attempt to the logger, if
+// any. In debug mode the start of the body is read ahead and put back, so
+// the caller still reads the whole body.
+func (c *Client) logResponse(req *http.Request, resp *http.Response, start time.Time, err error) {
+	if c.logger == nil {
+		return
+	}
+	entry := ResponseLog{
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		RequestID: req.Header.Get(HeaderRequestID),
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+		entry.Header = redactHeader(resp.Header)
+		if c.debugBodyLimit > 0 && loggableBody(resp.Header) {
+			data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(c.debugBodyLimit)+1))
+			resp.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), body: resp.Body}
+			entry.Body = formatLogBody(data, c.debugBodyLimit)
+		}
+	}
+	c.logger.LogResponse(entry)
+}
+
+// peekedBody puts bytes read ahead back in front of a body.
+type peekedBody struct {
+	io.Reader
+	body io.Closer
+}
+
+func (b *peekedBody) Close() error {
+	return b.body.Close()
+}
+
+// sensitiveHeaders are the headers whose values are never logged.
+var sensitiveHeaders = []string{"Authorization", HeaderAPIKey, "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// redactHeader returns a copy of h with credentials replaced.
+func redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// loggableBody reports whether a body is text that can be logged without
+// blocking: streamed responses only end when the stream does.
+func loggableBody(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch {
+	case mediaType == "text/event-stream", mediaType == "application/x-ndjson":
+		return false
+	case mediaType == "", mediaType == "application/json", strings.HasPrefix(mediaType, "text/"):
+		return true
+	default:
+		return false
+	}
+}
+
+// sensitiveFields are the JSON fields whose values are never logged, such
+// as the secret key of an S3Config.
+var sensitiveFields = map[string]bool{
+	"secret_key":    true,
+	"access_key":    true,
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"api_key":       true,
+	"secret":        true,
+}
+
+// formatLogBody redacts the secrets in a body and cuts it at limit bytes.
+// Bodies that aren't complete JSON can't be redacted field by field, so
+// they are only logged when they contain none of the sensitive field names.
+func formatLogBody(data []byte, limit int) string {
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
+	if len(data) == 0 {
+		return ""
+	}
+
+	var value interface{}
+	if !truncated && json.Unmarshal(data, &value) == nil {
+		redactJSON(value)
+		if out, err := json.Marshal(value); err == nil {
+			return string(out)
+		}
+	}
+	for field := range sensitiveFields {
+		if bytes.Contains(data, []byte(`"`+field+`"`)) {
+			return fmt.Sprintf("[%d bytes withheld: may contain secrets]", len(data))
+		}
+	}
+	if truncated {
+		return string(data) + "…(truncated)"
+	}
+	return string(data)
+}
+
+// redactJSON replaces the values of sensitive fields in a decoded JSON
+// value, at any depth.
+func redactJSON(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[strings.ToLower(key)] {
+				if field != nil && field != "" {
+					v[key] = redacted
+				}
+				continue
+			}
+			redactJSON(field)
+		}
+	case []interface{}:
+		for _, item := range v {
+			redactJSON(item)
+		}
+	}
+}
+
+// ComponentLogger is a Logger that writes to picoclaw's logger under the
+// "deparrow" component: requests and successful responses at debug level,
+// failed ones at warn level.
+type ComponentLogger struct{}
+
+// LogRequest logs a request attempt.
+func (ComponentLogger) LogRequest(r RequestLog) {
+	fields := map[string]interface{}{
+		"method":     r.Method,
+		"url":        r.URL,
+		"request_id": r.RequestID,
+	}
+	if r.Body != "" {
+		fields["body"] = r.Body
+	}
+	logger.DebugCF("deparrow", "API request", fields)
+}
+
+// LogResponse logs the outcome of a request attempt.
+func (ComponentLogger) LogResponse(r ResponseLog) {
+	fields := map[string]interface{}{
+		"method":      r.Method,
+		"url":         r.URL,
+		"request_id":  r.RequestID,
+		"status":      r.StatusCode,
+		"duration_ms": r.Duration.Milliseconds(),
+	}
+	if r.Body != "" {
+		fields["body"] = r.Body
+	}
+	if r.Err != nil {
+		fields["error"] = r.Err.Error()
+	}
+	if r.Err != nil || r.StatusCode >= 400 {
+		logger.WarnCF("deparrow", "API request failed", fields)
+		return
+	}
+	logger.DebugCF("deparrow", "API response", fields)
+}
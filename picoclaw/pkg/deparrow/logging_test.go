@@ -0,0 +1,138 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingLogger keeps every event it receives.
+type recordingLogger struct {
+	mu        sync.Mutex
+	requests  []RequestLog
+	responses []ResponseLog
+}
+
+func (l *recordingLogger) LogRequest(r RequestLog) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, r)
+}
+
+func (l *recordingLogger) LogResponse(r ResponseLog) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.responses = append(l.responses, r)
+}
+
+func TestLogger_RedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "credit_deducted": 1.0})
+	}))
+	defer server.Close()
+
+	logs := &recordingLogger{}
+	client := NewClient(server.URL, "jwt-secret-token", WithLogger(logs), WithDebugLogging(0))
+	spec := &JobSpec{
+		Image:   "alpine",
+		Outputs: []OutputSpec{{Path: "/out", StorageDestination: "s3", S3Config: &S3Config{Bucket: "results", AccessKey: "AKIAEXAMPLE", SecretKey: "wJalrXUtnFEMI"}}},
+	}
+	job, err := client.SubmitJob(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+	if job.ID != "job-1" {
+		t.Errorf("job ID = %q, the peeked body was not put back", job.ID)
+	}
+
+	if len(logs.requests) != 1 || len(logs.responses) != 1 {
+		t.Fatalf("got %d requests and %d responses, want 1 each", len(logs.requests), len(logs.responses))
+	}
+	req := logs.requests[0]
+	if got := req.Header.Get("Authorization"); got != redacted {
+		t.Errorf("Authorization = %q, want redacted", got)
+	}
+	for _, secret := range []string{"jwt-secret-token", "AKIAEXAMPLE", "wJalrXUtnFEMI"} {
+		if strings.Contains(req.Body, secret) || strings.Contains(req.Header.Get("Authorization"), secret) {
+			t.Errorf("request log leaks %q: %s", secret, req.Body)
+		}
+	}
+	if !strings.Contains(req.Body, `"bucket":"results"`) {
+		t.Errorf("request body = %s, want the rest of the spec", req.Body)
+	}
+
+	resp := logs.responses[0]
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Body, `"job_id":"job-1"`) {
+		t.Errorf("response log = %+v", resp)
+	}
+}
+
+func TestLogger_BodiesOnlyInDebugMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"status":"healthy","notes":"`+strings.Repeat("x", 100)+`"}`)
+	}))
+	defer server.Close()
+
+	logs := &recordingLogger{}
+	client := NewClient(server.URL, "", WithLogger(logs))
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if logs.responses[0].Body != "" {
+		t.Errorf("body logged without debug mode: %s", logs.responses[0].Body)
+	}
+
+	logs = &recordingLogger{}
+	client = NewClient(server.URL, "", WithLogger(logs), WithDebugLogging(32))
+	health, err := client.Health(context.Background())
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if health["status"] != "healthy" {
+		t.Errorf("health = %v, the peeked body was not put back", health)
+	}
+	if body := logs.responses[0].Body; !strings.HasSuffix(body, "…(truncated)") || len(body) > 32+len("…(truncated)") {
+		t.Errorf("body = %q, want it cut at 32 bytes", body)
+	}
+}
+
+func TestLogger_FailedRequest(t *testing.T) {
+	logs := &recordingLogger{}
+	client := NewClient("http://127.0.0.1:1", "", WithLogger(logs), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	client.Health(context.Background())
+
+	if len(logs.responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(logs.responses))
+	}
+	if r := logs.responses[0]; r.Err == nil || r.StatusCode != 0 {
+		t.Errorf("response log = %+v, want an error and no status", r)
+	}
+}
+
+func TestFormatLogBody(t *testing.T) {
+	tests := []struct {
+		body  string
+		limit int
+		want  string
+	}{
+		{`{"user":"a","password":"hunter2"}`, 100, `{"password":"[REDACTED]","user":"a"}`},
+		{`[{"token":""}]`, 100, `[{"token":""}]`},
+		{`{"secret_key":"abc","padding":"xxxxxxxx"}`, 20, `[20 bytes withheld: may contain secrets]`},
+		{`plain text`, 5, `plain…(truncated)`},
+		{``, 10, ``},
+	}
+	for _, tt := range tests {
+		if got := formatLogBody([]byte(tt.body), tt.limit); got != tt.want {
+			t.Errorf("formatLogBody(%q, %d) = %q, want %q", tt.body, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package deparrow
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// LogStreamStdout and LogStreamStderr name the output a log line came from.
+	LogStreamStdout = "stdout"
+	LogStreamStderr = "stderr"
+
+	// logLineBuffer is how many lines are decoded ahead of the consumer.
+	// Once it is full the stream stops reading the response, so a slow
+	// consumer throttles the server through the connection instead of
+	// buffering without bound.
+	logLineBuffer = 64
+
+	// maxLogLineSize bounds a single log line.
+	maxLogLineSize = 1 << 20
+)
+
+// LogLine is one line of a job's output.
+type LogLine struct {
+	Stream    string    `json:"stream"`
+	Text      string    `json:"line"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JobLogEnd is sent when the job has finished and its log is complete.
+type JobLogEnd struct {
+	Status   JobStatus `json:"status"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// JobLogStream follows a job's log as it is written.
+type JobLogStream struct {
+	lines  chan LogLine
+	done   chan struct{}
+	cancel context.CancelFunc
+	closed atomic.Bool
+	err    error
+	end    *JobLogEnd
+}
+
+// StreamJobLogs follows the log of a job until it finishes, ctx is
+// cancelled or the stream is closed. The API sends Server-Sent Events;
+// newline-delimited JSON is accepted from servers that do not.
+func (c *Client) StreamJobLogs(ctx context.Context, jobID string) (*JobLogStream, error) {
+	if jobID == "" {
+		return nil, fmt.Errorf("job ID is required")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// The stream lasts as long as the job, so the client timeout must not
+	// cut it short; ctx bounds it instead
+	streaming := *c.httpClient
+	streaming.Timeout = 0
+	resp, err := c.doAuthenticated(&streaming, func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(jobID)+"/logs?follow=true", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "text/event-stream, application/x-ndjson")
+		return req, nil
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &JobLogStream{
+		lines:  make(chan LogLine, logLineBuffer),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	go s.run(ctx, resp)
+	return s, nil
+}
+
+// Lines returns the log lines in order. It is closed when the stream ends.
+func (s *JobLogStream) Lines() <-chan LogLine {
+	return s.lines
+}
+
+// Err returns why the stream ended, once Lines is closed. It is nil when
+// the job finished or the stream was closed.
+func (s *JobLogStream) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// End returns how the job finished, once Lines is closed. It is nil when
+// the stream ended before the job did.
+func (s *JobLogStream) End() *JobLogEnd {
+	select {
+	case <-s.done:
+		return s.end
+	default:
+		return nil
+	}
+}
+
+// Close stops following the log and releases the connection.
+func (s *JobLogStream) Close() error {
+	s.closed.Store(true)
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// run reads the response until it ends, then closes Lines.
+func (s *JobLogStream) run(ctx context.Context, resp *http.Response) {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	var err error
+	if mediaType == "application/x-ndjson" {
+		err = s.readNDJSON(ctx, resp.Body)
+	} else {
+		err = s.readSSE(ctx, resp.Body)
+	}
+	resp.Body.Close()
+
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+		if s.closed.Load() {
+			err = nil
+		}
+	}
+	s.cancel()
+	s.err = err
+	close(s.done)
+	close(s.lines)
+}
+
+// deliver hands a line to the consumer, waiting while it catches up.
+func (s *JobLogStream) deliver(ctx context.Context, line LogLine) error {
+	select {
+	case s.lines <- line:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newLogScanner splits a stream into lines of up to maxLogLineSize.
+func newLogScanner(body io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxLogLineSize)
+	return scanner
+}
+
+// readSSE decodes a Server-Sent Events stream. Comment lines are
+// heartbeats and are skipped.
+func (s *JobLogStream) readSSE(ctx context.Context, body io.Reader) error {
+	scanner := newLogScanner(body)
+	var event string
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				finished, err := s.dispatch(ctx, event, strings.Join(data, "\n"))
+				if err != nil || finished {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// Heartbeat
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// dispatch handles one event, reporting whether it ended the stream.
+func (s *JobLogStream) dispatch(ctx context.Context, event, data string) (bool, error) {
+	switch event {
+	case "", "log":
+		var line LogLine
+		if err := json.Unmarshal([]byte(data), &line); err != nil {
+			return false, fmt.Errorf("failed to parse log line: %w", err)
+		}
+		return false, s.deliver(ctx, line)
+	case "end":
+		var end JobLogEnd
+		if err := json.Unmarshal([]byte(data), &end); err != nil {
+			return false, fmt.Errorf("failed to parse log end: %w", err)
+		}
+		s.end = &end
+		return true, nil
+	case "error":
+		var apiErr APIError
+		if err := json.Unmarshal([]byte(data), &apiErr); err != nil || apiErr.Message == "" {
+			return false, errors.New("log stream failed: " + data)
+		}
+		return false, &apiErr
+	default:
+		// Unknown events are for newer clients
+		return false, nil
+	}
+}
+
+// logRecord is one line of a newline-delimited JSON log stream; the last
+// record carries End instead of a line.
+type logRecord struct {
+	LogLine
+	End *JobLogEnd `json:"end,omitempty"`
+}
+
+// readNDJSON decodes a newline-delimited JSON stream.
+func (s *JobLogStream) readNDJSON(ctx context.Context, body io.Reader) error {
+	scanner := newLogScanner(body)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var record logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("failed to parse log line: %w", err)
+		}
+		if record.End != nil {
+			s.end = record.End
+			return nil
+		}
+		if err := s.deliver(ctx, record.LogLine); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
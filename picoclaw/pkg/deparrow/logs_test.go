@@ -0,0 +1,227 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamJobLogs_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	job, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine", Command: []string{"echo", "hi"}})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+
+	stream, err := client.StreamJobLogs(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("StreamJobLogs() error = %v", err)
+	}
+	defer stream.Close()
+
+	var lines []LogLine
+	for line := range stream.Lines() {
+		lines = append(lines, line)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %+v", len(lines), lines)
+	}
+	if lines[1].Stream != LogStreamStderr {
+		t.Errorf("lines[1].Stream = %s, want stderr", lines[1].Stream)
+	}
+	if want := "[sandbox] alpine finished successfully"; lines[2].Text != want {
+		t.Errorf("lines[2].Text = %q, want %q", lines[2].Text, want)
+	}
+
+	end := stream.End()
+	if end == nil || end.Status != JobStatusCompleted || end.ExitCode != 0 {
+		t.Errorf("End() = %+v, want completed with exit code 0", end)
+	}
+
+	got, _ := client.GetJob(ctx, job.ID)
+	if got.Status != JobStatusCompleted {
+		t.Errorf("Status = %s after following the log, want completed", got.Status)
+	}
+}
+
+func TestStreamJobLogs_NotFound(t *testing.T) {
+	client := NewSandboxClient()
+
+	_, err := client.StreamJobLogs(context.Background(), "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		t.Fatalf("StreamJobLogs() error = %v, want 404", err)
+	}
+}
+
+func TestStreamJobLogs_NDJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/jobs/job-1/logs" || r.URL.Query().Get("follow") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"stream":"stdout","line":"step 1"}`)
+		fmt.Fprintln(w, `{"stream":"stderr","line":"warning"}`)
+		fmt.Fprintln(w, `{"end":{"status":"failed","exit_code":2,"error":"boom"}}`)
+	}))
+	defer server.Close()
+
+	stream, err := NewClient(server.URL, "test-token").StreamJobLogs(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("StreamJobLogs() error = %v", err)
+	}
+	defer stream.Close()
+
+	var texts []string
+	for line := range stream.Lines() {
+		texts = append(texts, line.Stream+":"+line.Text)
+	}
+	if strings.Join(texts, ",") != "stdout:step 1,stderr:warning" {
+		t.Errorf("lines = %v", texts)
+	}
+	end := stream.End()
+	if end == nil || end.Status != JobStatusFailed || end.ExitCode != 2 || end.Error != "boom" {
+		t.Errorf("End() = %+v", end)
+	}
+}
+
+// newEndlessLogServer streams log lines and heartbeats until the client
+// goes away, then closes gone.
+func newEndlessLogServer(t *testing.T, gone chan<- struct{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(gone)
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; ; i++ {
+			if i%10 == 0 {
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+			if _, err := fmt.Fprintf(w, "event: log\ndata: {\"stream\":\"stdout\",\"line\":\"line %d\"}\n\n", i); err != nil {
+				return
+			}
+			flusher.Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+}
+
+func TestStreamJobLogs_Close(t *testing.T) {
+	gone := make(chan struct{})
+	server := newEndlessLogServer(t, gone)
+	defer server.Close()
+
+	stream, err := NewClient(server.URL, "test-token").StreamJobLogs(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("StreamJobLogs() error = %v", err)
+	}
+
+	// A consumer that reads slowly only ever sees lines in order
+	for i := 0; i < 3; i++ {
+		line := <-stream.Lines()
+		if want := fmt.Sprintf("line %d", i); line.Text != want {
+			t.Fatalf("line = %q, want %q", line.Text, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stream.Close()
+	for range stream.Lines() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() = %v after Close, want nil", err)
+	}
+	if stream.End() != nil {
+		t.Error("End() should be nil when the job did not finish")
+	}
+
+	select {
+	case <-gone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server still streaming after Close")
+	}
+}
+
+func TestStreamJobLogs_ContextCancel(t *testing.T) {
+	gone := make(chan struct{})
+	server := newEndlessLogServer(t, gone)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := NewClient(server.URL, "test-token").StreamJobLogs(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("StreamJobLogs() error = %v", err)
+	}
+	defer stream.Close()
+
+	<-stream.Lines()
+	cancel()
+	for range stream.Lines() {
+	}
+	if err := stream.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", err)
+	}
+	<-gone
+}
+
+func TestJobLogsTool_Execute(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	job, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+
+	tool := NewJobLogsTool(client)
+	result := tool.Execute(ctx, map[string]interface{}{"job_id": job.ID, "tail": float64(1)})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	for _, want := range []string{
+		"last 1 of 3 lines",
+		"[sandbox] alpine finished successfully",
+		"Job completed with exit code 0",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{})
+	if !result.IsError {
+		t.Error("expected error without job_id")
+	}
+}
+
+func TestJobLogsTool_StillRunning(t *testing.T) {
+	gone := make(chan struct{})
+	server := newEndlessLogServer(t, gone)
+	defer server.Close()
+
+	tool := NewJobLogsTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{"job_id": "job-1", "tail": float64(2), "wait_seconds": 0.1})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "still running") {
+		t.Errorf("result should say the job is still running:\n%s", result.ForLLM)
+	}
+}
@@ -0,0 +1,144 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+const (
+	// defaultJobLogsTail is how many of the latest lines the logs tool shows.
+	defaultJobLogsTail = 50
+
+	// defaultJobLogsWait and maxJobLogsWait bound how long the logs tool
+	// follows a job that is still running.
+	defaultJobLogsWait = 30 * time.Second
+	maxJobLogsWait     = 2 * time.Minute
+)
+
+// JobLogsTool follows a job's log so the agent can show its output live.
+type JobLogsTool struct {
+	client *Client
+}
+
+// NewJobLogsTool creates a new job logs tool.
+func NewJobLogsTool(client *Client) *JobLogsTool {
+	return &JobLogsTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *JobLogsTool) Name() string {
+	return "deparrow_job_logs"
+}
+
+// Description returns the tool description.
+func (t *JobLogsTool) Description() string {
+	return "Show the live log of a DEparrow job. Follows the job until it finishes " +
+		"or the wait runs out, and returns the latest lines."
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *JobLogsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"job_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The ID of the job to show logs for",
+			},
+			"tail": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of latest lines to show",
+				"default":     defaultJobLogsTail,
+			},
+			"wait_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("How long to follow a running job, up to %d seconds", int(maxJobLogsWait.Seconds())),
+				"default":     int(defaultJobLogsWait.Seconds()),
+			},
+		},
+		"required": []string{"job_id"},
+	}
+}
+
+// Execute runs the job logs tool.
+func (t *JobLogsTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	jobID, ok := args["job_id"].(string)
+	if !ok || jobID == "" {
+		return tools.ErrorResult("job_id parameter is required")
+	}
+
+	tail := defaultJobLogsTail
+	if n, ok := args["tail"].(float64); ok && n > 0 {
+		tail = int(n)
+	}
+	wait := defaultJobLogsWait
+	if s, ok := args["wait_seconds"].(float64); ok && s > 0 {
+		wait = time.Duration(s * float64(time.Second))
+	}
+	if wait > maxJobLogsWait {
+		wait = maxJobLogsWait
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	stream, err := t.client.StreamJobLogs(ctx, jobID)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to stream job logs: %v", err))
+	}
+	defer stream.Close()
+
+	// Keep only the latest lines; the stream is drained as it arrives so
+	// long logs never pile up
+	var lines []LogLine
+	total := 0
+	for line := range stream.Lines() {
+		total++
+		lines = append(lines, line)
+		if len(lines) > tail {
+			lines = lines[1:]
+		}
+	}
+
+	end := stream.End()
+	if err := stream.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return tools.ErrorResult(fmt.Sprintf("Job log stream failed: %v", err))
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Logs for job %s", jobID))
+	if total > len(lines) {
+		result.WriteString(fmt.Sprintf(" (last %d of %d lines)", len(lines), total))
+	}
+	result.WriteString(":\n\n")
+
+	if total == 0 {
+		result.WriteString("(no output yet)\n")
+	}
+	for _, line := range lines {
+		if line.Stream == LogStreamStderr {
+			result.WriteString("[stderr] ")
+		}
+		result.WriteString(line.Text)
+		result.WriteString("\n")
+	}
+
+	result.WriteString("\n")
+	if end == nil {
+		result.WriteString(fmt.Sprintf("Job is still running after %s. Ask again to keep following it.\n", wait))
+	} else {
+		result.WriteString(fmt.Sprintf("Job %s with exit code %d.\n", end.Status, end.ExitCode))
+		if end.Error != "" {
+			result.WriteString(fmt.Sprintf("Error: %s\n", end.Error))
+		}
+	}
+
+	return tools.UserResult(result.String())
+}
+
+var _ tools.Tool = (*JobLogsTool)(nil)
@@ -0,0 +1,114 @@
+package deparrow
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// Headers used to correlate client and server logs.
+const (
+	HeaderRequestID = "X-Request-ID"
+	HeaderSessionID = "X-Session-ID"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	sessionIDKey
+	retryPolicyKey
+	primaryReadKey
+	creditTallyKey
+)
+
+// WithRequestID returns a context carrying the request ID to send with API calls.
+// When no request ID is set, a new one is generated for every call.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithSessionID returns a context carrying the agent session the API calls belong to.
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// SessionIDFromContext returns the session ID carried by ctx, if any.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey).(string)
+	return id
+}
+
+// WithUserAgent overrides the User-Agent sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// DefaultUserAgent returns the User-Agent used when none is configured.
+// It includes the version of the agent binary the client is built into.
+func DefaultUserAgent() string {
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	return "picoclaw-deparrow/" + version
+}
+
+// setMetadataHeaders adds the User-Agent, request ID and session ID headers.
+// A request ID is generated when the context does not carry one.
+func (c *Client) setMetadataHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.userAgent)
+
+	requestID := RequestIDFromContext(req.Context())
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	req.Header.Set(HeaderRequestID, requestID)
+
+	if sessionID := SessionIDFromContext(req.Context()); sessionID != "" {
+		req.Header.Set(HeaderSessionID, sessionID)
+	}
+}
+
+// sessionTool forwards the conversation a tool is called from as the
+// session ID of its API calls.
+type sessionTool struct {
+	tools.Tool
+	mu      sync.Mutex
+	session string
+}
+
+// SetContext records the channel and chat the next call comes from.
+func (t *sessionTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session = channel + ":" + chatID
+}
+
+// Execute runs the wrapped tool with the session ID attached to ctx.
+func (t *sessionTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	t.mu.Lock()
+	session := t.session
+	t.mu.Unlock()
+
+	if session != "" && SessionIDFromContext(ctx) == "" {
+		ctx = WithSessionID(ctx, session)
+	}
+	return t.Tool.Execute(ctx, args)
+}
+
+// Ensure the wrapper receives the tool context
+var _ tools.ContextualTool = (*sessionTool)(nil)
@@ -0,0 +1,121 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// newHeaderServer records the headers of every request it receives.
+func newHeaderServer(t *testing.T, status int) (*httptest.Server, *[]http.Header) {
+	t.Helper()
+	var seen []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Clone())
+		w.Header().Set(HeaderRequestID, r.Header.Get(HeaderRequestID))
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":"boom"}`))
+	}))
+	return server, &seen
+}
+
+func TestClient_MetadataHeaders_Generated(t *testing.T) {
+	server, seen := newHeaderServer(t, http.StatusOK)
+	defer server.Close()
+
+	client := NewClient(server.URL, "")
+	client.Health(context.Background())
+	client.Health(context.Background())
+
+	first, second := (*seen)[0], (*seen)[1]
+	if first.Get(HeaderRequestID) == "" {
+		t.Fatal("X-Request-ID was not generated")
+	}
+	if first.Get(HeaderRequestID) == second.Get(HeaderRequestID) {
+		t.Error("each request should get its own X-Request-ID")
+	}
+	if first.Get(HeaderSessionID) != "" {
+		t.Error("X-Session-ID should be absent without a session")
+	}
+	if !strings.HasPrefix(first.Get("User-Agent"), "picoclaw-deparrow/") {
+		t.Errorf("User-Agent = %s", first.Get("User-Agent"))
+	}
+}
+
+func TestClient_MetadataHeaders_FromContext(t *testing.T) {
+	server, seen := newHeaderServer(t, http.StatusOK)
+	defer server.Close()
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	ctx = WithSessionID(ctx, "telegram:123")
+	NewClient(server.URL, "", WithUserAgent("my-agent/1.0")).Health(ctx)
+
+	h := (*seen)[0]
+	if h.Get(HeaderRequestID) != "req-42" {
+		t.Errorf("X-Request-ID = %s, want req-42", h.Get(HeaderRequestID))
+	}
+	if h.Get(HeaderSessionID) != "telegram:123" {
+		t.Errorf("X-Session-ID = %s, want telegram:123", h.Get(HeaderSessionID))
+	}
+	if h.Get("User-Agent") != "my-agent/1.0" {
+		t.Errorf("User-Agent = %s, want my-agent/1.0", h.Get("User-Agent"))
+	}
+}
+
+func TestClient_APIError_RequestID(t *testing.T) {
+	server, _ := newHeaderServer(t, http.StatusInternalServerError)
+	defer server.Close()
+
+	ctx := WithRequestID(context.Background(), "req-err")
+	_, err := NewClient(server.URL, "").Health(ctx)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.RequestID != "req-err" {
+		t.Errorf("RequestID = %s, want req-err", apiErr.RequestID)
+	}
+}
+
+func TestToolsProvider_SessionPropagation(t *testing.T) {
+	server, seen := newHeaderServer(t, http.StatusOK)
+	defer server.Close()
+
+	registry := tools.NewToolRegistry()
+	NewToolsProvider(NewClient(server.URL, "")).RegisterAll(registry)
+
+	registry.ExecuteWithContext(context.Background(), "deparrow_health", map[string]interface{}{},
+		"telegram", "123", nil)
+
+	if len(*seen) != 1 {
+		t.Fatalf("requests = %d, want 1", len(*seen))
+	}
+	if got := (*seen)[0].Get(HeaderSessionID); got != "telegram:123" {
+		t.Errorf("X-Session-ID = %s, want telegram:123", got)
+	}
+}
+
+func TestSandbox_EchoesMetadata(t *testing.T) {
+	client := NewSandboxClient()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, sandboxBaseURL+"/api/v1/health", nil)
+	req.Header.Set(HeaderRequestID, "req-sbx")
+	req.Header.Set(HeaderSessionID, "cli:direct")
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get(HeaderRequestID) != "req-sbx" || resp.Header.Get(HeaderSessionID) != "cli:direct" {
+		t.Errorf("headers not echoed: %v", resp.Header)
+	}
+}
@@ -0,0 +1,370 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsHook is told about every request the client sends and every
+// credit it spends, so operators can watch latency, error rates and spend.
+// Implementations must be safe for concurrent use and should return
+// quickly, as they are called on the request path.
+type MetricsHook interface {
+	// ObserveRequest is called once per attempt, after the response
+	// headers arrive or the attempt fails.
+	ObserveRequest(RequestMetric)
+
+	// ObserveCreditsDeducted is called with the credits a successful job
+	// submission deducted.
+	ObserveCreditsDeducted(credits float64)
+}
+
+// RequestMetric describes one request attempt.
+type RequestMetric struct {
+	Method string
+	// Endpoint is the request path with resource IDs replaced by {id},
+	// e.g. "/api/v1/jobs/{id}/logs"
+	Endpoint string
+	// StatusCode is zero when no response arrived
+	StatusCode int
+	Duration   time.Duration
+	// Err is set when no response arrived
+	Err error
+}
+
+// WithMetrics reports the client's requests and spend to hook.
+func WithMetrics(hook MetricsHook) ClientOption {
+	return func(c *Client) {
+		c.metrics = hook
+	}
+}
+
+// observeRequest reports a request attempt to the metrics hook, if any.
+func (c *Client) observeRequest(req *http.Request, statusCode int, start time.Time, err error) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.ObserveRequest(RequestMetric{
+		Method:     req.Method,
+		Endpoint:   endpointTemplate(req.URL.Path),
+		StatusCode: statusCode,
+		Duration:   time.Since(start),
+		Err:        err,
+	})
+}
+
+// observeCredits reports credits deducted to the metrics hook, if any,
+// and to the tally of the tool call ctx belongs to.
+func (c *Client) observeCredits(ctx context.Context, credits float64) {
+	if credits <= 0 {
+		return
+	}
+	if tally, ok := ctx.Value(creditTallyKey).(*creditTally); ok {
+		tally.add(credits)
+	}
+	if c.metrics != nil {
+		c.metrics.ObserveCreditsDeducted(credits)
+	}
+}
+
+// idCollections are the paths whose next segment is a resource ID, with
+// the segments under them that are endpoints rather than IDs.
+var idCollections = map[string]map[string]bool{
+	"/api/v1/jobs":                    {"submit": true, "estimate": true, "archive": true},
+	"/api/v1/nodes":                   {"register": true},
+	"/api/v1/bookings":                nil,
+	"/api/v1/calendars":               nil,
+	"/api/v1/credits/balance":         nil,
+	"/api/v1/credits/standing-orders": nil,
+	"/api/v1/credits/top-ups":         nil,
+	"/api/v1/auth/api-keys":           nil,
+}
+
+// endpointTemplate replaces the resource IDs in a request path with {id},
+// so requests for different jobs or nodes are counted together. Anything
+// before /api/ is dropped.
+func endpointTemplate(path string) string {
+	if i := strings.Index(path, "/api/"); i > 0 {
+		path = path[i:]
+	}
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments)-1; i++ {
+		static, ok := idCollections[strings.Join(segments[:i+1], "/")]
+		if ok && segments[i+1] != "" && !static[segments[i+1]] {
+			segments[i+1] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// DefaultDurationBuckets are the upper bounds, in seconds, of the request
+// duration histogram.
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// PrometheusMetrics is a MetricsHook that serves what it records in the
+// Prometheus text format. Mount it on the agent's HTTP server:
+//
+//	metrics := deparrow.NewPrometheusMetrics("")
+//	client := deparrow.NewClient(url, token, deparrow.WithMetrics(metrics))
+//	http.Handle("/metrics", metrics)
+//
+// It exposes <namespace>_requests_total by method, endpoint and status
+// code ("error" when no response arrived), the
+// <namespace>_request_duration_seconds histogram by method and endpoint,
+// and <namespace>_credits_deducted_total.
+//
+// Passed to ToolsProvider.SetMetrics it also counts tool executions:
+// <namespace>_tool_invocations_total, <namespace>_tool_errors_total,
+// <namespace>_tool_credits_spent_total and the
+// <namespace>_tool_duration_seconds histogram, all by tool.
+type PrometheusMetrics struct {
+	namespace string
+	buckets   []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[durationKey]*histogram
+	credits   float64
+	tools     map[string]*toolSeries
+}
+
+// toolSeries holds the observations of one tool.
+type toolSeries struct {
+	invocations uint64
+	errors      uint64
+	credits     float64
+	duration    *histogram
+}
+
+type requestKey struct {
+	method, endpoint, code string
+}
+
+type durationKey struct {
+	method, endpoint string
+}
+
+// histogram holds the observations of one duration series.
+type histogram struct {
+	// Observations in each bucket, not cumulative
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusMetrics creates a Prometheus metrics hook whose metric names
+// start with namespace, "deparrow_client" when empty.
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	if namespace == "" {
+		namespace = "deparrow_client"
+	}
+	return &PrometheusMetrics{
+		namespace: namespace,
+		buckets:   DefaultDurationBuckets,
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[durationKey]*histogram),
+		tools:     make(map[string]*toolSeries),
+	}
+}
+
+// ObserveRequest records a request attempt.
+func (m *PrometheusMetrics) ObserveRequest(r RequestMetric) {
+	code := "error"
+	if r.StatusCode != 0 {
+		code = strconv.Itoa(r.StatusCode)
+	}
+	seconds := r.Duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{r.Method, r.Endpoint, code}]++
+
+	key := durationKey{r.Method, r.Endpoint}
+	h := m.durations[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	h.observe(m.buckets, seconds)
+}
+
+// observe adds an observation to the histogram.
+func (h *histogram) observe(buckets []float64, seconds float64) {
+	if i := sort.SearchFloat64s(buckets, seconds); i < len(buckets) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// ObserveTool records a tool execution.
+func (m *PrometheusMetrics) ObserveTool(t ToolMetric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.tools[t.Tool]
+	if s == nil {
+		s = &toolSeries{duration: &histogram{counts: make([]uint64, len(m.buckets))}}
+		m.tools[t.Tool] = s
+	}
+	s.invocations++
+	if t.Error {
+		s.errors++
+	}
+	s.credits += t.Credits
+	s.duration.observe(m.buckets, t.Duration.Seconds())
+}
+
+// ObserveCreditsDeducted adds to the credits spent.
+func (m *PrometheusMetrics) ObserveCreditsDeducted(credits float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credits += credits
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	name := m.namespace + "_requests_total"
+	fmt.Fprintf(&b, "# HELP %s Requests sent to the DEparrow API.\n# TYPE %s counter\n", name, name)
+	requests := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		requests = append(requests, k)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	for _, k := range requests {
+		fmt.Fprintf(&b, "%s{method=%s,endpoint=%s,code=%s} %d\n",
+			name, labelValue(k.method), labelValue(k.endpoint), labelValue(k.code), m.requests[k])
+	}
+
+	name = m.namespace + "_request_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Time until the DEparrow API responded.\n# TYPE %s histogram\n", name, name)
+	durations := make([]durationKey, 0, len(m.durations))
+	for k := range m.durations {
+		durations = append(durations, k)
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		a, b := durations[i], durations[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		return a.method < b.method
+	})
+	for _, k := range durations {
+		labels := fmt.Sprintf("method=%s,endpoint=%s", labelValue(k.method), labelValue(k.endpoint))
+		m.writeHistogram(&b, name, labels, m.durations[k])
+	}
+
+	name = m.namespace + "_credits_deducted_total"
+	fmt.Fprintf(&b, "# HELP %s Credits deducted for submitted jobs.\n# TYPE %s counter\n", name, name)
+	fmt.Fprintf(&b, "%s %s\n", name, strconv.FormatFloat(m.credits, 'g', -1, 64))
+
+	if len(m.tools) > 0 {
+		m.writeTools(&b)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeTools writes the tool execution metrics; m.mu must be held.
+func (m *PrometheusMetrics) writeTools(b *strings.Builder) {
+	names := make([]string, 0, len(m.tools))
+	for tool := range m.tools {
+		names = append(names, tool)
+	}
+	sort.Strings(names)
+
+	for _, counter := range []struct {
+		suffix, help string
+		value        func(*toolSeries) string
+	}{
+		{"_tool_invocations_total", "Tool executions.", func(s *toolSeries) string { return strconv.FormatUint(s.invocations, 10) }},
+		{"_tool_errors_total", "Tool executions that returned an error.", func(s *toolSeries) string { return strconv.FormatUint(s.errors, 10) }},
+		{"_tool_credits_spent_total", "Credits deducted for jobs the tools submitted.", func(s *toolSeries) string { return strconv.FormatFloat(s.credits, 'g', -1, 64) }},
+	} {
+		name := m.namespace + counter.suffix
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, counter.help, name)
+		for _, tool := range names {
+			fmt.Fprintf(b, "%s{tool=%s} %s\n", name, labelValue(tool), counter.value(m.tools[tool]))
+		}
+	}
+
+	name := m.namespace + "_tool_duration_seconds"
+	fmt.Fprintf(b, "# HELP %s Time tool executions took.\n# TYPE %s histogram\n", name, name)
+	for _, tool := range names {
+		m.writeHistogram(b, name, "tool="+labelValue(tool), m.tools[tool].duration)
+	}
+}
+
+// writeHistogram writes the series of one histogram.
+func (m *PrometheusMetrics) writeHistogram(b *strings.Builder, name, labels string, h *histogram) {
+	var cumulative uint64
+	for i, bound := range m.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// ListenAndServe serves the metrics at /metrics on addr until ctx is done.
+func (m *PrometheusMetrics) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return m.Serve(ctx, ln)
+}
+
+// Serve serves the metrics at /metrics on ln until ctx is done, closing ln.
+// Callers that need to know the address is bound listen first themselves.
+func (m *PrometheusMetrics) Serve(ctx context.Context, ln net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ln) }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+		server.Close()
+		if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// labelValue quotes a Prometheus label value.
+func labelValue(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
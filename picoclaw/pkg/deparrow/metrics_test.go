@@ -0,0 +1,192 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/jobs":                        "/api/v1/jobs",
+		"/api/v1/jobs/submit":                 "/api/v1/jobs/submit",
+		"/api/v1/jobs/submit/batch":           "/api/v1/jobs/submit/batch",
+		"/api/v1/jobs/job-42":                 "/api/v1/jobs/{id}",
+		"/api/v1/jobs/job-42/logs":            "/api/v1/jobs/{id}/logs",
+		"/api/v1/nodes/node-1":                "/api/v1/nodes/{id}",
+		"/api/v1/credits/balance/user-1":      "/api/v1/credits/balance/{id}",
+		"/api/v1/credits/standing-orders/o-1": "/api/v1/credits/standing-orders/{id}",
+		"/metaos/api/v1/bookings/b-1":         "/api/v1/bookings/{id}",
+		"/api/v1/health":                      "/api/v1/health",
+	}
+	for path, want := range tests {
+		if got := endpointTemplate(path); got != want {
+			t.Errorf("endpointTemplate(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/jobs/submit":
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "credit_deducted": 2.5})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Not found"})
+		}
+	}))
+	defer server.Close()
+
+	metrics := NewPrometheusMetrics("")
+	client := NewClient(server.URL, "test-token", WithMetrics(metrics))
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"}); err != nil {
+			t.Fatalf("SubmitJob() error = %v", err)
+		}
+	}
+	client.GetJob(ctx, "job-1")
+	client.GetJob(ctx, "job-2")
+
+	// A request that gets no response is counted as an error
+	closed := NewClient("http://127.0.0.1:1", "test-token", WithMetrics(metrics), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	closed.Health(ctx)
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		`deparrow_client_requests_total{method="POST",endpoint="/api/v1/jobs/submit",code="200"} 2`,
+		`deparrow_client_requests_total{method="GET",endpoint="/api/v1/jobs/{id}",code="404"} 2`,
+		`deparrow_client_requests_total{method="GET",endpoint="/api/v1/health",code="error"} 1`,
+		`deparrow_client_request_duration_seconds_bucket{method="GET",endpoint="/api/v1/jobs/{id}",le="+Inf"} 2`,
+		`deparrow_client_request_duration_seconds_count{method="POST",endpoint="/api/v1/jobs/submit"} 2`,
+		"# TYPE deparrow_client_request_duration_seconds histogram",
+		"deparrow_client_credits_deducted_total 5",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics are missing %q:\n%s", line, body)
+		}
+	}
+}
+
+func TestPrometheusMetrics_Buckets(t *testing.T) {
+	metrics := NewPrometheusMetrics("agent")
+	for _, d := range []time.Duration{3 * time.Millisecond, 40 * time.Millisecond, time.Minute} {
+		metrics.ObserveRequest(RequestMetric{Method: "GET", Endpoint: "/api/v1/health", StatusCode: 200, Duration: d})
+	}
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	for _, line := range []string{
+		`agent_request_duration_seconds_bucket{method="GET",endpoint="/api/v1/health",le="0.005"} 1`,
+		`agent_request_duration_seconds_bucket{method="GET",endpoint="/api/v1/health",le="0.05"} 2`,
+		`agent_request_duration_seconds_bucket{method="GET",endpoint="/api/v1/health",le="30"} 2`,
+		`agent_request_duration_seconds_bucket{method="GET",endpoint="/api/v1/health",le="+Inf"} 3`,
+		`agent_request_duration_seconds_sum{method="GET",endpoint="/api/v1/health"} 60.043`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("metrics are missing %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestToolsProvider_Metrics(t *testing.T) {
+	metrics := NewPrometheusMetrics("")
+	client := NewSandboxClient(WithMetrics(metrics))
+	provider := NewToolsProvider(client)
+	provider.SetMetrics(metrics)
+
+	byName := make(map[string]tools.Tool)
+	for _, tool := range provider.GetAllTools() {
+		byName[tool.Name()] = tool
+	}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if result := byName["deparrow_submit_job"].Execute(ctx, map[string]interface{}{"image": "alpine"}); result.IsError {
+			t.Fatalf("submit failed: %s", result.ForLLM)
+		}
+	}
+	byName["deparrow_job_status"].Execute(ctx, map[string]interface{}{"job_id": "missing"})
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	body := b.String()
+	for _, line := range []string{
+		`deparrow_client_tool_invocations_total{tool="deparrow_submit_job"} 2`,
+		`deparrow_client_tool_errors_total{tool="deparrow_submit_job"} 0`,
+		`deparrow_client_tool_invocations_total{tool="deparrow_job_status"} 1`,
+		`deparrow_client_tool_errors_total{tool="deparrow_job_status"} 1`,
+		`deparrow_client_tool_credits_spent_total{tool="deparrow_job_status"} 0`,
+		`deparrow_client_tool_duration_seconds_count{tool="deparrow_submit_job"} 2`,
+		"# TYPE deparrow_client_tool_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics are missing %q:\n%s", line, body)
+		}
+	}
+	if !strings.Contains(body, `deparrow_client_tool_credits_spent_total{tool="deparrow_submit_job"} `) ||
+		strings.Contains(body, `deparrow_client_tool_credits_spent_total{tool="deparrow_submit_job"} 0`+"\n") {
+		t.Errorf("credits spent by the submit tool were not counted:\n%s", body)
+	}
+}
+
+func TestPrometheusMetrics_ListenAndServe(t *testing.T) {
+	metrics := NewPrometheusMetrics("")
+	metrics.ObserveTool(ToolMetric{Tool: "deparrow_credits", Duration: time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- metrics.ListenAndServe(ctx, "127.0.0.1:0") }()
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("ListenAndServe() after cancel = %v", err)
+	}
+
+	if err := metrics.ListenAndServe(context.Background(), "not-an-address"); err == nil {
+		t.Error("ListenAndServe() on an invalid address should fail")
+	}
+}
+
+func TestPrometheusMetrics_Serve(t *testing.T) {
+	metrics := NewPrometheusMetrics("")
+	metrics.ObserveTool(ToolMetric{Tool: "deparrow_credits", Duration: time.Millisecond})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- metrics.Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `tool="deparrow_credits"`) {
+		t.Errorf("served metrics are missing the observed tool:\n%s", body)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("Serve() after cancel = %v", err)
+	}
+	if _, err := http.Get("http://" + ln.Addr().String() + "/metrics"); err == nil {
+		t.Error("the listener is still open after Serve() returned")
+	}
+}
@@ -0,0 +1,252 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// mockAPIKeysAlias is another path for the API key collection.
+const mockAPIKeysAlias = "/api/v1/auth/apikeys"
+
+// MockAPIKey is an API key issued by the mock server and the user it
+// authenticates as.
+type MockAPIKey struct {
+	APIKey
+	UserID string `json:"user_id"`
+	secret string
+}
+
+// newAPIKeySecret generates a secret and the prefix shown in listings.
+func newAPIKeySecret() (secret, prefix string) {
+	secret = "dpk_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	return secret, secret[:12]
+}
+
+// AddTestAPIKey issues an API key for a user and returns its secret.
+func (m *MockMetaOSServer) AddTestAPIKey(userID, name string, scope APIKeyScope) (*MockAPIKey, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.issueAPIKey(userID, name, scope)
+}
+
+// APIKey returns a copy of an API key.
+func (m *MockMetaOSServer) APIKey(id string) (MockAPIKey, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key, ok := m.apiKeys[id]; ok {
+		return *key, true
+	}
+	return MockAPIKey{}, false
+}
+
+// issueAPIKey creates an API key. Must be called with the lock held.
+func (m *MockMetaOSServer) issueAPIKey(userID, name string, scope APIKeyScope) (*MockAPIKey, string) {
+	secret, prefix := newAPIKeySecret()
+	key := &MockAPIKey{
+		APIKey: APIKey{
+			ID:        m.newID("key", len(m.apiKeys)+1),
+			Name:      name,
+			Scope:     scope,
+			Prefix:    prefix,
+			CreatedAt: time.Now(),
+		},
+		UserID: userID,
+		secret: secret,
+	}
+	m.apiKeys[key.ID] = key
+	return key, secret
+}
+
+// apiKeyAllows reports whether a key with scope may make the request.
+// Read-only keys may only read; submit-only keys may submit jobs and
+// follow them. No key may manage API keys.
+func apiKeyAllows(scope APIKeyScope, method, path string) bool {
+	if strings.HasPrefix(path, apiKeysPath) {
+		return false
+	}
+	read := method == http.MethodGet || method == http.MethodHead
+	switch scope {
+	case APIKeyScopeFull:
+		return true
+	case APIKeyScopeReadOnly:
+		return read
+	case APIKeyScopeSubmitOnly:
+		if path == "/api/v1/jobs/submit" {
+			return method == http.MethodPost
+		}
+		return read && (path == "/api/v1/jobs" || strings.HasPrefix(path, "/api/v1/jobs/") || path == "/api/v1/health")
+	default:
+		return false
+	}
+}
+
+// authorizeAPIKey checks the X-API-Key of a request, returning
+// http.StatusOK when the key may make it and the error response when it
+// is unknown, revoked or out of scope. Must be called with the lock held.
+func (m *MockMetaOSServer) authorizeAPIKey(method, path, secret string) (int, interface{}) {
+	var key *MockAPIKey
+	for _, k := range m.apiKeys {
+		if k.secret == secret {
+			key = k
+			break
+		}
+	}
+	if key == nil || key.Revoked() {
+		return mockError(http.StatusUnauthorized, "Invalid API key")
+	}
+	if !apiKeyAllows(key.Scope, method, path) {
+		return mockError(http.StatusForbidden, fmt.Sprintf("API key scope %s does not allow this request", key.Scope))
+	}
+
+	now := time.Now()
+	key.LastUsedAt = &now
+	return http.StatusOK, nil
+}
+
+// tokenUser returns the user whose bearer token authenticates the request.
+// Must be called with the lock held.
+func (m *MockMetaOSServer) tokenUser(r *http.Request) (*MockUser, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return nil, false
+	}
+	for _, user := range m.users {
+		if user.Token == token {
+			return user, true
+		}
+	}
+	return nil, false
+}
+
+// handleAPIKeys issues API keys and lists the caller's keys. Managing keys
+// needs a user token, so a leaked key cannot mint more.
+func (m *MockMetaOSServer) handleAPIKeys(r *http.Request, body []byte) (int, interface{}) {
+	user, ok := m.tokenUser(r)
+	if !ok {
+		return mockError(http.StatusUnauthorized, "Missing or invalid authorization token")
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var req APIKeyRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return mockError(http.StatusBadRequest, "Invalid JSON")
+		}
+		if req.Scope == "" {
+			req.Scope = APIKeyScopeFull
+		}
+		if req.Name == "" {
+			return mockError(http.StatusBadRequest, "Name is required")
+		}
+		if !req.Scope.Valid() {
+			return mockError(http.StatusBadRequest, "Invalid scope")
+		}
+
+		key, secret := m.issueAPIKey(user.ID, req.Name, req.Scope)
+		return http.StatusCreated, apiKeyWithSecret(key, secret)
+	case http.MethodGet:
+		keys := make([]MockAPIKey, 0)
+		for _, key := range m.apiKeys {
+			if key.UserID == user.ID {
+				keys = append(keys, *key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+		return http.StatusOK, map[string]interface{}{"api_keys": keys}
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleAPIKey rotates or revokes one of the caller's API keys.
+func (m *MockMetaOSServer) handleAPIKey(r *http.Request, rest string) (int, interface{}) {
+	user, ok := m.tokenUser(r)
+	if !ok {
+		return mockError(http.StatusUnauthorized, "Missing or invalid authorization token")
+	}
+
+	id, action, _ := strings.Cut(rest, "/")
+	key, exists := m.apiKeys[id]
+	if !exists || key.UserID != user.ID {
+		return mockError(http.StatusNotFound, "API key not found")
+	}
+
+	now := time.Now()
+	switch {
+	case action == "rotate" && r.Method == http.MethodPost:
+		if key.Revoked() {
+			return mockError(http.StatusBadRequest, "API key is revoked")
+		}
+		secret, prefix := newAPIKeySecret()
+		key.secret = secret
+		key.Prefix = prefix
+		key.RotatedAt = &now
+		return http.StatusOK, apiKeyWithSecret(key, secret)
+	case action == "" && r.Method == http.MethodDelete:
+		if !key.Revoked() {
+			key.RevokedAt = &now
+		}
+		return http.StatusNoContent, nil
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// apiKeyWithSecret is the response to issuing or rotating a key, the only
+// time its secret is shown.
+func apiKeyWithSecret(key *MockAPIKey, secret string) MockAPIKey {
+	shown := *key
+	shown.Secret = secret
+	return shown
+}
+
+func (m *MockMetaOSServer) handleLogin(body []byte) (int, interface{}) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+
+	for _, user := range m.users {
+		if user.Email == req.Email && user.Password == req.Password {
+			return http.StatusOK, mockSession(user, m.balance(user.ID))
+		}
+	}
+	return mockError(http.StatusUnauthorized, "Invalid credentials")
+}
+
+func (m *MockMetaOSServer) handleRegister(body []byte) (int, interface{}) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Name     string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+
+	user := m.addUser(uuid.New().String(), req.Email, req.Password)
+	user.Name = req.Name
+	return http.StatusOK, mockSession(user, 0)
+}
+
+// mockSession is the response to logging in or registering.
+func mockSession(user *MockUser, credits float64) map[string]interface{} {
+	return map[string]interface{}{
+		"token": user.Token,
+		"user": map[string]interface{}{
+			"id":      user.ID,
+			"email":   user.Email,
+			"name":    user.Name,
+			"credits": credits,
+		},
+	}
+}
@@ -0,0 +1,244 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// mockCalendarHorizon is how far ahead calendars reach when no range is asked for.
+const mockCalendarHorizon = 14 * 24 * time.Hour
+
+// mockCalendarWindows returns the windows, overlapping [from, to), in which a
+// node with the given GPU model is offered for booking. H100 rigs
+// are free every night from midnight to 08:00 UTC; the others are free
+// over the weekend.
+func mockCalendarWindows(gpuModel string, from, to time.Time) []CalendarSlot {
+	var windows []CalendarSlot
+	day := from.UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		var window CalendarSlot
+		switch {
+		case strings.EqualFold(gpuModel, "H100"):
+			window = CalendarSlot{Start: day, End: day.Add(8 * time.Hour)}
+		case day.Weekday() == time.Saturday:
+			window = CalendarSlot{Start: day, End: day.AddDate(0, 0, 2)}
+		default:
+			continue
+		}
+		if window.End.After(from) && window.Start.Before(to) {
+			window.CreditsPerGPUHour = calculateCreditCost(&JobSpec{Resources: &ResourceSpec{GPU: "1"}, Timeout: 3600})
+			windows = append(windows, window)
+		}
+	}
+	return windows
+}
+
+// bookedGPUs returns the GPUs of a node held by bookings overlapping [start, end).
+func (m *MockMetaOSServer) bookedGPUs(nodeID string, start, end time.Time) int {
+	booked := 0
+	for _, b := range m.bookings {
+		if b.NodeID == nodeID && b.Status == BookingHeld && b.Start.Before(end) && b.End.After(start) {
+			booked += b.GPUs
+		}
+	}
+	return booked
+}
+
+// calendar returns a node's slots overlapping [from, to) that have not ended.
+func (m *MockMetaOSServer) calendar(n *MockNode, from, to, now time.Time) CapacityCalendar {
+	res := n.Resources
+	cal := CapacityCalendar{NodeID: n.ID, Region: n.region(), GPUModel: res.GPUModel, GPUCount: res.GPU, Slots: []CalendarSlot{}}
+	for _, slot := range mockCalendarWindows(res.GPUModel, from, to) {
+		if !slot.End.After(now) {
+			continue
+		}
+		slot.AvailableGPUs = res.GPU - m.bookedGPUs(n.ID, slot.Start, slot.End)
+		cal.Slots = append(cal.Slots, slot)
+	}
+	return cal
+}
+
+// mockCalendarRange reads the from and to parameters of a calendar
+// request, defaulting to the next two weeks.
+func mockCalendarRange(query url.Values, now time.Time) (from, to time.Time, problem string) {
+	from, to = now, now.Add(mockCalendarHorizon)
+	if v := query.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, "Invalid from time"
+		}
+		from, to = t, t.Add(mockCalendarHorizon)
+	}
+	if v := query.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, "Invalid to time"
+		}
+		to = t
+	}
+	return from, to, ""
+}
+
+func (m *MockMetaOSServer) handleListCalendars(query url.Values) (int, interface{}) {
+	now := time.Now()
+	from, to, problem := mockCalendarRange(query, now)
+	if problem != "" {
+		return mockError(http.StatusBadRequest, problem)
+	}
+	model, region := query.Get("gpu_model"), query.Get("region")
+
+	calendars := []CapacityCalendar{}
+	for _, n := range m.nodes {
+		if n.Status != NodeStatusOnline || n.Resources == nil || n.Resources.GPU == 0 {
+			continue
+		}
+		if model != "" && !strings.EqualFold(n.Resources.GPUModel, model) {
+			continue
+		}
+		if region != "" && !strings.EqualFold(n.region(), region) {
+			continue
+		}
+		calendars = append(calendars, m.calendar(n, from, to, now))
+	}
+	return http.StatusOK, map[string]interface{}{"calendars": calendars}
+}
+
+func (m *MockMetaOSServer) handleNodeCalendar(id string, query url.Values) (int, interface{}) {
+	n := m.findNode(id)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+	if n.Resources == nil || n.Resources.GPU == 0 {
+		return mockError(http.StatusNotFound, "Node publishes no calendar")
+	}
+	now := time.Now()
+	from, to, problem := mockCalendarRange(query, now)
+	if problem != "" {
+		return mockError(http.StatusBadRequest, problem)
+	}
+	return http.StatusOK, m.calendar(n, from, to, now)
+}
+
+func (m *MockMetaOSServer) handleCreateBooking(body []byte) (int, interface{}) {
+	var req BookingRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+	if req.GPUs <= 0 || !req.End.After(req.Start) {
+		return mockError(http.StatusBadRequest, "A positive number of GPUs and a window ending after it starts are required")
+	}
+	now := time.Now()
+	if req.Start.Before(now) {
+		return mockError(http.StatusBadRequest, "Bookings must start in the future")
+	}
+	n := m.findNode(req.NodeID)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+	if n.Resources == nil || n.Resources.GPU == 0 {
+		return mockError(http.StatusConflict, "The node offers no GPUs for booking")
+	}
+
+	var slot *CalendarSlot
+	for _, window := range mockCalendarWindows(n.Resources.GPUModel, req.Start, req.End) {
+		if window.Covers(req.Start, req.End, 0) {
+			slot = &window
+			break
+		}
+	}
+	if slot == nil {
+		return mockError(http.StatusConflict, "The node is not offered for the whole window")
+	}
+	if free := n.Resources.GPU - m.bookedGPUs(n.ID, req.Start, req.End); req.GPUs > free {
+		return mockError(http.StatusConflict, fmt.Sprintf("Only %d GPUs are free in that window", free))
+	}
+
+	hold := slot.Cost(req.Start, req.End, req.GPUs)
+	if !m.spend(m.user, hold) {
+		return mockInsufficientCredits()
+	}
+	booking := &Booking{
+		ID:         m.newID("booking", len(m.bookings)+1),
+		NodeID:     n.ID,
+		GPUModel:   n.Resources.GPUModel,
+		GPUs:       req.GPUs,
+		Start:      req.Start,
+		End:        req.End,
+		Status:     BookingHeld,
+		HoldAmount: hold,
+		CreatedAt:  now,
+	}
+	m.bookings = append(m.bookings, booking)
+	m.record(Transaction{
+		Type:        TransactionSpend,
+		Amount:      hold,
+		Description: "Credit hold for booking " + booking.ID,
+		Timestamp:   now,
+		FromUser:    m.user,
+	})
+
+	return http.StatusOK, booking
+}
+
+func (m *MockMetaOSServer) handleListBookings() (int, interface{}) {
+	bookings := make([]Booking, 0, len(m.bookings))
+	for _, b := range m.bookings {
+		bookings = append(bookings, *b)
+	}
+	return http.StatusOK, map[string]interface{}{"bookings": bookings}
+}
+
+func (m *MockMetaOSServer) handleBooking(method, id string) (int, interface{}) {
+	var booking *Booking
+	for _, b := range m.bookings {
+		if b.ID == id {
+			booking = b
+			break
+		}
+	}
+	if booking == nil {
+		return mockError(http.StatusNotFound, "Booking not found")
+	}
+
+	switch method {
+	case http.MethodGet:
+		return http.StatusOK, booking
+	case http.MethodDelete:
+		if booking.Status != BookingHeld {
+			return mockError(http.StatusBadRequest, fmt.Sprintf("Booking is already %s", booking.Status))
+		}
+		now := time.Now()
+		if !booking.Start.After(now) {
+			return mockError(http.StatusBadRequest, "Booking has already started")
+		}
+		booking.Status = BookingCancelled
+		m.deposit(m.user, booking.HoldAmount)
+		m.record(Transaction{
+			Type:        TransactionEarn,
+			Amount:      booking.HoldAmount,
+			Description: "Released hold for cancelled booking " + booking.ID,
+			Timestamp:   now,
+			ToUser:      m.user,
+		})
+		return http.StatusOK, map[string]interface{}{
+			"status":     "cancelled",
+			"booking_id": booking.ID,
+			"released":   booking.HoldAmount,
+		}
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// completeBookings marks held bookings whose window has passed as completed.
+func (m *MockMetaOSServer) completeBookings(now time.Time) {
+	for _, b := range m.bookings {
+		if b.Status == BookingHeld && !b.End.After(now) {
+			b.Status = BookingCompleted
+		}
+	}
+}
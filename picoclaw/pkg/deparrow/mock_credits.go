@@ -0,0 +1,618 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// balance returns the credits a user can currently spend.
+func (m *MockMetaOSServer) balance(userID string) float64 {
+	return AvailableCredits(m.buckets[userID], time.Now())
+}
+
+// spend takes credits from a user's buckets, expiring ones first. It
+// reports false, leaving the buckets unchanged, when the balance is too low.
+func (m *MockMetaOSServer) spend(userID string, amount float64) bool {
+	buckets, err := SpendCredits(m.buckets[userID], amount, time.Now())
+	if err != nil {
+		return false
+	}
+	m.buckets[userID] = buckets
+	return true
+}
+
+// deposit adds credits to a user's wallet. Refunds don't expire, whichever
+// bucket the credits were originally spent from.
+func (m *MockMetaOSServer) deposit(userID string, amount float64) {
+	buckets := m.buckets[userID]
+	for i := range buckets {
+		if buckets[i].Source == CreditSourcePurchased && buckets[i].ExpiresAt == nil {
+			buckets[i].Amount += amount
+			return
+		}
+	}
+	m.buckets[userID] = append(buckets, CreditBucket{
+		ID:        m.ledgerID("bucket", len(buckets)+1),
+		Source:    CreditSourcePurchased,
+		Amount:    amount,
+		GrantedAt: time.Now(),
+	})
+}
+
+func (m *MockMetaOSServer) handleBalance() (int, interface{}) {
+	return http.StatusOK, map[string]interface{}{
+		"user_id":        m.user,
+		"credit_balance": m.balance(m.user),
+		"last_active":    time.Now(),
+		"buckets":        m.buckets[m.user],
+	}
+}
+
+// mockSnapshotInterval is how often the mock server records the balance,
+// shorter than the network's so a session has a trend to show.
+const mockSnapshotInterval = time.Minute
+
+// snapshotBalance records the balance when the last snapshot is older
+// than mockSnapshotInterval.
+func (m *MockMetaOSServer) snapshotBalance(now time.Time) {
+	if n := len(m.snapshots); n > 0 && now.Sub(m.snapshots[n-1].At) < mockSnapshotInterval {
+		return
+	}
+	m.snapshots = append(m.snapshots, BalanceSnapshot{At: now, Balance: m.balance(m.user)})
+}
+
+func (m *MockMetaOSServer) handleBalanceHistory(query url.Values) (int, interface{}) {
+	period, _ := strconv.Atoi(query.Get("period"))
+	resolution, _ := strconv.Atoi(query.Get("resolution"))
+	if err := validateBalanceHistory(time.Duration(period)*time.Second, time.Duration(resolution)*time.Second); err != nil {
+		return mockError(http.StatusBadRequest, err.Error())
+	}
+	history := balanceHistory(m.snapshots, time.Now(), time.Duration(period)*time.Second, time.Duration(resolution)*time.Second)
+	history.UserID = m.user
+	return http.StatusOK, history
+}
+
+func (m *MockMetaOSServer) handleCheckCredits(body []byte) (int, interface{}) {
+	var req struct {
+		Required float64 `json:"required"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+	available := m.balance(m.user)
+	return http.StatusOK, map[string]interface{}{
+		"has_sufficient": available >= req.Required,
+		"required":       req.Required,
+		"available":      available,
+		"difference":     available - req.Required,
+	}
+}
+
+func (m *MockMetaOSServer) handleTransfer(body []byte) (int, interface{}) {
+	var req struct {
+		FromUser        string  `json:"from_user"`
+		ToUserID        string  `json:"to_user_id"`
+		ToUser          string  `json:"to_user"`
+		Amount          float64 `json:"amount"`
+		RequireApproval bool    `json:"require_approval"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+	if req.FromUser == "" {
+		req.FromUser = m.user
+	}
+	if req.ToUserID == "" {
+		req.ToUserID = req.ToUser
+	}
+	if req.ToUserID == "" || req.Amount <= 0 {
+		return mockError(http.StatusBadRequest, "Recipient and a positive amount are required")
+	}
+	if req.RequireApproval {
+		transfer := &PendingTransfer{
+			ID:          m.newID("transfer", len(m.approvals)+1),
+			FromUserID:  req.FromUser,
+			ToUserID:    req.ToUserID,
+			Amount:      req.Amount,
+			Status:      TransferPendingApproval,
+			RequestedAt: time.Now(),
+		}
+		m.approvals = append(m.approvals, transfer)
+		return http.StatusAccepted, transfer
+	}
+	txn, ok := m.transfer(req.FromUser, req.ToUserID, req.Amount)
+	if !ok {
+		return mockInsufficientCredits()
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"success":           true,
+		"transaction_id":    txn.ID,
+		"from_user":         req.FromUser,
+		"to_user":           req.ToUserID,
+		"amount":            req.Amount,
+		"message":           "Transfer successful",
+		"status":            "completed",
+		"remaining_balance": m.balance(req.FromUser),
+	}
+}
+
+// transfer moves amount between users and records it. It reports false
+// when the sender's balance is too low.
+func (m *MockMetaOSServer) transfer(fromUser, toUser string, amount float64) (Transaction, bool) {
+	if !m.spend(fromUser, amount) {
+		return Transaction{}, false
+	}
+	m.deposit(toUser, amount)
+	txn := Transaction{
+		ID:          m.ledgerID("txn", len(m.transactions)+1),
+		Type:        TransactionTransfer,
+		Amount:      amount,
+		Description: "Transfer to " + toUser,
+		Timestamp:   time.Now(),
+		FromUser:    fromUser,
+		ToUser:      toUser,
+	}
+	m.record(txn)
+	return txn, true
+}
+
+func (m *MockMetaOSServer) handleListPendingTransfers() (int, interface{}) {
+	pending := make([]PendingTransfer, 0, len(m.approvals))
+	for _, transfer := range m.approvals {
+		if transfer.Status == TransferPendingApproval {
+			pending = append(pending, *transfer)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"pending_transfers": pending}
+}
+
+// handleApproveTransfer makes a transfer waiting for approval. Every
+// request acts for the same user, so requesters can approve their own
+// transfers to see what happens.
+func (m *MockMetaOSServer) handleApproveTransfer(id string) (int, interface{}) {
+	for _, transfer := range m.approvals {
+		if transfer.ID != id {
+			continue
+		}
+		if transfer.Status != TransferPendingApproval {
+			return mockError(http.StatusConflict, "Transfer is already "+string(transfer.Status))
+		}
+		if _, ok := m.transfer(transfer.FromUserID, transfer.ToUserID, transfer.Amount); !ok {
+			return mockInsufficientCredits()
+		}
+		now := time.Now()
+		transfer.Status = TransferApproved
+		transfer.ApprovedBy = m.user
+		transfer.ApprovedAt = &now
+		return http.StatusOK, transfer
+	}
+	return mockError(http.StatusNotFound, "Pending transfer not found")
+}
+
+func (m *MockMetaOSServer) handleCreateStandingOrder(body []byte) (int, interface{}) {
+	var req StandingOrderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+	if req.ToUserID == "" || req.Amount <= 0 {
+		return mockError(http.StatusBadRequest, "Recipient and a positive amount are required")
+	}
+	if !req.Interval.Valid() {
+		return mockError(http.StatusBadRequest, "Interval must be daily, weekly or monthly")
+	}
+
+	now := time.Now()
+	order := &MockStandingOrder{
+		StandingOrder: StandingOrder{
+			ID:          m.newID("order", len(m.standingOrders)+1),
+			ToUserID:    req.ToUserID,
+			Amount:      req.Amount,
+			Interval:    req.Interval,
+			Description: req.Description,
+			Status:      StandingOrderActive,
+			CreatedAt:   now,
+			NextRun:     now,
+		},
+		FromUser: m.user,
+	}
+	if req.StartAt != nil {
+		order.NextRun = *req.StartAt
+	}
+	m.standingOrders = append(m.standingOrders, order)
+
+	// An order starting now makes its first transfer straight away
+	m.runStandingOrders(now)
+
+	return http.StatusOK, order
+}
+
+func (m *MockMetaOSServer) handleListStandingOrders() (int, interface{}) {
+	orders := make([]MockStandingOrder, 0, len(m.standingOrders))
+	for _, order := range m.standingOrders {
+		if order.FromUser == m.user {
+			orders = append(orders, *order)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"standing_orders": orders}
+}
+
+func (m *MockMetaOSServer) handleStandingOrder(method, id string, body []byte) (int, interface{}) {
+	var order *MockStandingOrder
+	for _, o := range m.standingOrders {
+		if o.ID == id {
+			order = o
+			break
+		}
+	}
+	if order == nil {
+		return mockError(http.StatusNotFound, "Standing order not found")
+	}
+
+	switch method {
+	case http.MethodGet:
+		return http.StatusOK, order
+	case http.MethodPut:
+		if order.Status != StandingOrderActive {
+			return mockError(http.StatusBadRequest, "Standing order is cancelled")
+		}
+		var req StandingOrderRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return mockError(http.StatusBadRequest, "Invalid JSON")
+		}
+		if req.Interval != "" && !req.Interval.Valid() {
+			return mockError(http.StatusBadRequest, "Interval must be daily, weekly or monthly")
+		}
+		if req.Amount > 0 {
+			order.Amount = req.Amount
+		}
+		if req.Interval != "" {
+			order.Interval = req.Interval
+		}
+		if req.Description != "" {
+			order.Description = req.Description
+		}
+		if req.StartAt != nil {
+			order.NextRun = *req.StartAt
+		}
+		return http.StatusOK, order
+	case http.MethodDelete:
+		if order.Status == StandingOrderCancelled {
+			return mockError(http.StatusBadRequest, "Standing order is already cancelled")
+		}
+		order.Status = StandingOrderCancelled
+		return http.StatusOK, order
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// runStandingOrders makes every transfer that has fallen due by now and
+// returns the number made. A run the sender can't cover is skipped, not
+// retried, and recorded on the order.
+func (m *MockMetaOSServer) runStandingOrders(now time.Time) int {
+	executed := 0
+	for _, order := range m.standingOrders {
+		for order.Status == StandingOrderActive && !order.NextRun.After(now) {
+			run := order.NextRun
+			order.NextRun = order.Interval.Next(run)
+
+			if !m.spend(order.FromUser, order.Amount) {
+				order.LastError = fmt.Sprintf("Insufficient credits on %s", run.Format("2006-01-02"))
+				continue
+			}
+			m.deposit(order.ToUserID, order.Amount)
+
+			m.record(Transaction{
+				Type:        TransactionTransfer,
+				Amount:      order.Amount,
+				Description: fmt.Sprintf("Standing order %s to %s", order.ID, order.ToUserID),
+				Timestamp:   run,
+				FromUser:    order.FromUser,
+				ToUser:      order.ToUserID,
+			})
+			order.Runs++
+			order.LastRun = &run
+			order.LastError = ""
+			executed++
+		}
+	}
+	return executed
+}
+
+// RunStandingOrders makes every standing order transfer that has fallen
+// due by now and returns the number of transfers made.
+func (m *MockMetaOSServer) RunStandingOrders(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.runStandingOrders(now)
+}
+
+// StandingOrder returns a copy of a standing order.
+func (m *MockMetaOSServer) StandingOrder(id string) (MockStandingOrder, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, order := range m.standingOrders {
+		if order.ID == id {
+			return *order, true
+		}
+	}
+	return MockStandingOrder{}, false
+}
+
+// StartStandingOrderScheduler executes due standing orders every interval
+// until the scheduler is stopped or the server is closed.
+func (m *MockMetaOSServer) StartStandingOrderScheduler(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopScheduler != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	m.stopScheduler = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				m.RunStandingOrders(now)
+			}
+		}
+	}()
+}
+
+// StopStandingOrderScheduler stops the scheduler started by StartStandingOrderScheduler.
+func (m *MockMetaOSServer) StopStandingOrderScheduler() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopScheduler != nil {
+		close(m.stopScheduler)
+		m.stopScheduler = nil
+	}
+}
+
+func (m *MockMetaOSServer) handleCreateTransferOffer(body []byte) (int, interface{}) {
+	var req struct {
+		ToUserID   string  `json:"to_user_id"`
+		Amount     float64 `json:"amount"`
+		Memo       string  `json:"memo"`
+		TTLSeconds int     `json:"ttl_seconds"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+	if req.ToUserID == "" || req.Amount <= 0 {
+		return mockError(http.StatusBadRequest, "Recipient and a positive amount are required")
+	}
+	if req.ToUserID == m.user {
+		return mockError(http.StatusBadRequest, "Cannot offer credits to yourself")
+	}
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	if ttl < MinTransferOfferTTL || ttl > MaxTransferOfferTTL {
+		return mockError(http.StatusBadRequest, "Offer TTL is out of range")
+	}
+	if !m.spend(m.user, req.Amount) {
+		return mockInsufficientCredits()
+	}
+
+	now := time.Now()
+	offer := &TransferOffer{
+		ID:         m.newID("offer", len(m.transferOffers)+1),
+		FromUserID: m.user,
+		ToUserID:   req.ToUserID,
+		Amount:     req.Amount,
+		Memo:       req.Memo,
+		Status:     TransferOfferPending,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	m.transferOffers = append(m.transferOffers, offer)
+	m.record(Transaction{
+		Type:        TransactionTransfer,
+		Amount:      req.Amount,
+		Description: "Escrow for offer " + offer.ID + " to " + req.ToUserID,
+		Timestamp:   now,
+		FromUser:    m.user,
+		ToUser:      req.ToUserID,
+	})
+	return http.StatusOK, offer
+}
+
+func (m *MockMetaOSServer) handleListTransferOffers() (int, interface{}) {
+	offers := make([]TransferOffer, 0, len(m.transferOffers))
+	for _, offer := range m.transferOffers {
+		offers = append(offers, *offer)
+	}
+	return http.StatusOK, map[string]interface{}{"transfer_offers": offers}
+}
+
+// handleTransferOffer serves an offer and its accept and decline actions.
+// Every request acts for the same user, so it answers for the recipient
+// too: any offer can be accepted or declined to see what happens.
+func (m *MockMetaOSServer) handleTransferOffer(method, rest string) (int, interface{}) {
+	id, action, _ := strings.Cut(rest, "/")
+	var offer *TransferOffer
+	for _, o := range m.transferOffers {
+		if o.ID == id {
+			offer = o
+			break
+		}
+	}
+	if offer == nil {
+		return mockError(http.StatusNotFound, "Transfer offer not found")
+	}
+
+	switch {
+	case action == "" && method == http.MethodGet:
+		return http.StatusOK, offer
+	case (action == "accept" || action == "decline") && method == http.MethodPost:
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+	if offer.Status != TransferOfferPending {
+		return mockError(http.StatusConflict, "Transfer offer is already "+string(offer.Status))
+	}
+
+	now := time.Now()
+	if action == "accept" {
+		offer.Status = TransferOfferAccepted
+		offer.ResolvedAt = &now
+		m.deposit(offer.ToUserID, offer.Amount)
+		return http.StatusOK, offer
+	}
+	m.refundTransferOffer(offer, TransferOfferDeclined, now)
+	return http.StatusOK, offer
+}
+
+// expireTransferOffers returns the credits of every pending offer that has
+// passed its expiry by now.
+func (m *MockMetaOSServer) expireTransferOffers(now time.Time) {
+	for _, offer := range m.transferOffers {
+		if offer.Status == TransferOfferPending && !now.Before(offer.ExpiresAt) {
+			m.refundTransferOffer(offer, TransferOfferExpired, offer.ExpiresAt)
+		}
+	}
+}
+
+// refundTransferOffer resolves an offer with status and returns its
+// escrowed credits to the sender.
+func (m *MockMetaOSServer) refundTransferOffer(offer *TransferOffer, status TransferOfferStatus, at time.Time) {
+	offer.Status = status
+	offer.ResolvedAt = &at
+	m.deposit(offer.FromUserID, offer.Amount)
+	m.record(Transaction{
+		Type:        TransactionTransfer,
+		Amount:      offer.Amount,
+		Description: fmt.Sprintf("Refund of %s offer %s", status, offer.ID),
+		Timestamp:   at,
+		ToUser:      offer.FromUserID,
+	})
+}
+
+func (m *MockMetaOSServer) handleTransactions(query url.Values) (int, interface{}) {
+	var since, until time.Time
+	for name, bound := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return mockError(http.StatusBadRequest, "Invalid "+name+": "+v)
+			}
+			*bound = t
+		}
+	}
+	types := make(map[string]bool)
+	for _, kind := range strings.Split(query.Get("type"), ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			types[kind] = true
+		}
+	}
+
+	transactions := make([]Transaction, 0, len(m.transactions))
+	for _, tx := range m.transactions {
+		if tx.Timestamp.Before(since) || !until.IsZero() && !tx.Timestamp.Before(until) {
+			continue
+		}
+		if len(types) > 0 && !types[tx.Type] {
+			continue
+		}
+		transactions = append(transactions, tx)
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Timestamp.Before(transactions[j].Timestamp)
+	})
+
+	start, end, paging, problem := mockPage(query, len(transactions))
+	if problem != "" {
+		return mockError(http.StatusBadRequest, problem)
+	}
+	paging["transactions"] = transactions[start:end]
+	return http.StatusOK, paging
+}
+
+// handleActivity builds the feed from finished jobs, transfers from other
+// users and tier changes. The user is the whole organization, so both
+// scopes show the same feed.
+func (m *MockMetaOSServer) handleActivity(query url.Values) (int, interface{}) {
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return mockError(http.StatusBadRequest, "Invalid since: "+v)
+		}
+		since = t
+	}
+	if scope := query.Get("scope"); scope != "" && scope != "user" && scope != "org" {
+		return mockError(http.StatusBadRequest, "Invalid scope: "+scope)
+	}
+	kinds := make(map[ActivityKind]bool)
+	for _, kind := range strings.Split(query.Get("kind"), ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds[ActivityKind(kind)] = true
+		}
+	}
+
+	activities := append([]Activity(nil), m.tierChanges...)
+	for _, id := range m.jobOrder {
+		job := m.jobs[id]
+		if job.CompletedAt == nil {
+			continue
+		}
+		activity := Activity{
+			ID:         "act-" + job.ID,
+			Kind:       ActivityJobCompleted,
+			Timestamp:  *job.CompletedAt,
+			UserID:     m.user,
+			JobID:      job.ID,
+			CreditCost: job.CreditCost,
+		}
+		switch job.Status {
+		case JobStatusCompleted:
+		case JobStatusFailed:
+			activity.Kind = ActivityJobFailed
+		default:
+			continue
+		}
+		activities = append(activities, activity)
+	}
+	for _, tx := range m.transactions {
+		if tx.Type != TransactionTransfer || tx.ToUser != m.user || tx.FromUser == "" {
+			continue
+		}
+		activities = append(activities, Activity{
+			ID:        "act-" + tx.ID,
+			Kind:      ActivityTransferReceived,
+			Timestamp: tx.Timestamp,
+			UserID:    m.user,
+			FromUser:  tx.FromUser,
+			Amount:    tx.Amount,
+		})
+	}
+
+	feed := activities[:0]
+	for _, activity := range activities {
+		if activity.Timestamp.Before(since) || len(kinds) > 0 && !kinds[activity.Kind] {
+			continue
+		}
+		feed = append(feed, activity)
+	}
+	sort.SliceStable(feed, func(i, j int) bool {
+		return feed[i].Timestamp.Before(feed[j].Timestamp)
+	})
+
+	start, end, paging, problem := mockPage(query, len(feed))
+	if problem != "" {
+		return mockError(http.StatusBadRequest, problem)
+	}
+	paging["activities"] = feed[start:end]
+	return http.StatusOK, paging
+}
@@ -0,0 +1,280 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// mockEventsPath streams the mock server's events to browsers.
+	mockEventsPath = "/api/v1/events"
+
+	// DefaultSSEHeartbeat is how often an idle event stream sends a heartbeat.
+	DefaultSSEHeartbeat = 15 * time.Second
+
+	// mockEventRetention is how many past events are kept for resuming streams.
+	mockEventRetention = 256
+
+	// mockSubscriberBuffer is how many events a slow subscriber may fall
+	// behind before it is disconnected and has to resume.
+	mockSubscriberBuffer = 64
+
+	// sseRetry is the reconnect delay suggested to browsers, in milliseconds.
+	sseRetry = 3000
+)
+
+// MockEvent is a real-time update sent to GUI subscribers. Types match the
+// GUI's WebSocket messages: job_created, job_update, transaction and
+// balance_update.
+type MockEvent struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// mockEventSubscriber is an open event stream.
+type mockEventSubscriber struct {
+	events chan MockEvent
+	types  map[string]bool
+}
+
+// wants reports whether the subscriber asked for events of type t.
+func (s *mockEventSubscriber) wants(t string) bool {
+	return len(s.types) == 0 || s.types[t]
+}
+
+// publishEvent records an event and fans it out to subscribers. Streams
+// encode data after the lock is released, so it must not be changed later:
+// publish copies of mutable records.
+// Must be called with the lock held.
+func (m *MockMetaOSServer) publishEvent(eventType string, data interface{}) {
+	m.nextEventID++
+	event := MockEvent{ID: m.nextEventID, Type: eventType, Data: data}
+	m.events = append(m.events, event)
+	if len(m.events) > mockEventRetention {
+		m.events = m.events[len(m.events)-mockEventRetention:]
+	}
+
+	for sub := range m.subscribers {
+		if !sub.wants(eventType) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Too far behind; the client reconnects with its last event ID
+			close(sub.events)
+			delete(m.subscribers, sub)
+		}
+	}
+}
+
+// publishBalance publishes a user's current balance.
+// Must be called with the lock held.
+func (m *MockMetaOSServer) publishBalance(userID string) {
+	m.publishEvent("balance_update", map[string]interface{}{
+		"user_id":        userID,
+		"credit_balance": m.balance(userID),
+	})
+}
+
+// record adds a transaction to the ledger, numbering it unless it has an
+// ID, and publishes it with the balances it changed.
+// Must be called with the lock held.
+func (m *MockMetaOSServer) record(txn Transaction) {
+	if txn.ID == "" {
+		txn.ID = m.ledgerID("txn", len(m.transactions)+1)
+	}
+	m.transactions = append(m.transactions, txn)
+
+	m.publishEvent("transaction", map[string]interface{}{"transaction": txn})
+	if txn.FromUser != "" {
+		m.publishBalance(txn.FromUser)
+	}
+	if txn.ToUser != "" {
+		m.publishBalance(txn.ToUser)
+	}
+}
+
+// jobStages orders job statuses through their lifecycle: pending,
+// running, then completed, failed or cancelled.
+var jobStages = map[JobStatus]int{
+	JobStatusPending:   0,
+	JobStatusRunning:   1,
+	JobStatusCompleted: 2,
+	JobStatusFailed:    2,
+	JobStatusCancelled: 2,
+}
+
+// canTransitionJob reports whether a job in status from can later be in
+// status to.
+func canTransitionJob(from, to JobStatus) bool {
+	fromStage, fromOK := jobStages[from]
+	toStage, toOK := jobStages[to]
+	return fromOK && toOK && fromStage < 2 && toStage > fromStage
+}
+
+// UpdateJobStatus moves a job to a later status and publishes a job_update
+// event. It reports false for unknown jobs and for moves a job cannot make,
+// such as leaving a terminal status.
+func (m *MockMetaOSServer) UpdateJobStatus(jobID string, status JobStatus) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists || !canTransitionJob(job.Status, status) {
+		return false
+	}
+	m.setJobStatus(job, status, time.Now())
+	return true
+}
+
+// setJobStatus moves a job to status and publishes a job_update event.
+// Must be called with the lock held.
+func (m *MockMetaOSServer) setJobStatus(job *MockJob, status JobStatus, now time.Time) {
+	job.Status = status
+	job.Progress = jobStages[status] * 50
+	if status.IsTerminal() {
+		job.CompletedAt = &now
+	}
+	m.publishEvent("job_update", map[string]interface{}{"job": *job})
+}
+
+// Events returns the retained events, oldest first.
+func (m *MockMetaOSServer) Events() []MockEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockEvent(nil), m.events...)
+}
+
+// closeEventStreams ends every open event stream, so that closing the
+// server does not wait on them.
+func (m *MockMetaOSServer) closeEventStreams() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for sub := range m.subscribers {
+		close(sub.events)
+		delete(m.subscribers, sub)
+	}
+}
+
+// subscribeEvents opens a subscription and returns the retained events
+// after lastID. reset is set when events after lastID are no longer
+// retained, so the client must refetch its state.
+func (m *MockMetaOSServer) subscribeEvents(
+	types map[string]bool, lastID uint64, resume bool,
+) (sub *mockEventSubscriber, backlog []MockEvent, reset bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub = &mockEventSubscriber{events: make(chan MockEvent, mockSubscriberBuffer), types: types}
+	m.subscribers[sub] = struct{}{}
+
+	if !resume {
+		return sub, nil, false
+	}
+	if len(m.events) > 0 && m.events[0].ID > lastID+1 {
+		reset = true
+	}
+	for _, event := range m.events {
+		if event.ID > lastID && sub.wants(event.Type) {
+			backlog = append(backlog, event)
+		}
+	}
+	return sub, backlog, reset
+}
+
+// unsubscribeEvents closes a subscription that is still open.
+func (m *MockMetaOSServer) unsubscribeEvents(sub *mockEventSubscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, open := m.subscribers[sub]; open {
+		close(sub.events)
+		delete(m.subscribers, sub)
+	}
+}
+
+// handleEvents bridges event subscriptions to a Server-Sent Events stream
+// for browsers. The types query parameter filters events by type. Streams
+// resume after the Last-Event-ID header, or the last_event_id query
+// parameter for clients that cannot set headers.
+func (m *MockMetaOSServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeMockResponse(w, http.StatusInternalServerError, mockErrorPayload("Streaming unsupported"))
+		return
+	}
+
+	types := make(map[string]bool)
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types[t] = true
+		}
+	}
+
+	token := r.Header.Get("Last-Event-ID")
+	if token == "" {
+		token = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if token != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(token, 10, 64); err != nil {
+			writeMockResponse(w, http.StatusBadRequest, mockErrorPayload("Invalid resume token"))
+			return
+		}
+	}
+
+	sub, backlog, reset := m.subscribeEvents(types, lastID, token != "")
+	defer m.unsubscribeEvents(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+	if reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range backlog {
+		writeSSEEvent(w, event)
+	}
+	flusher.Flush()
+
+	heartbeat := m.SSEHeartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultSSEHeartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-sub.events:
+			if !open {
+				return
+			}
+			writeSSEEvent(w, event)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSEEvent writes one event frame. Its ID is the resume token.
+func writeSSEEvent(w http.ResponseWriter, event MockEvent) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		data = []byte(`{}`)
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -0,0 +1,548 @@
+package deparrow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mockOrchestratorCapacity is the number of active jobs a mock
+// orchestrator can coordinate before it reports full load.
+const mockOrchestratorCapacity = 20
+
+// mockJobLogsID matches the streaming job log route.
+func mockJobLogsID(method, path string) (string, bool) {
+	if method != http.MethodGet || !strings.HasPrefix(path, "/api/v1/jobs/") || !strings.HasSuffix(path, "/logs") {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/jobs/"), "/logs"), true
+}
+
+// handleJobLogs streams a job's log as Server-Sent Events, one frame at a
+// time, so a reader that falls behind or goes away holds up the server
+// like a real connection.
+func (m *MockMetaOSServer) handleJobLogs(w http.ResponseWriter, r *http.Request, id string) {
+	frames, found := m.jobLogFrames(id)
+	if !found {
+		status, payload := mockJobNotFound()
+		writeMockResponse(w, status, payload)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	for _, frame := range frames {
+		if r.Context().Err() != nil {
+			return
+		}
+		if _, err := io.WriteString(w, frame); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// jobLogFrames renders a job's log as Server-Sent Events. When simulating,
+// the job advances to completion as a client following it would see.
+func (m *MockMetaOSServer) jobLogFrames(id string) ([]string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, false
+	}
+
+	var frames []string
+	logf := func(stream, text string) {
+		data, _ := json.Marshal(LogLine{Stream: stream, Text: text, Timestamp: time.Now()})
+		frames = append(frames, fmt.Sprintf("event: log\ndata: %s\n\n", data))
+	}
+
+	image := ""
+	if job.Spec != nil {
+		image = job.Spec.Image
+	}
+	if job.Status == JobStatusPending && m.Simulate {
+		m.advance(job)
+		logf(LogStreamStdout, "[sandbox] scheduled on "+job.NodeID)
+	}
+	if job.Status == JobStatusRunning && m.Simulate {
+		logf(LogStreamStderr, "[sandbox] pulling image "+image)
+		frames = append(frames, ": heartbeat\n\n")
+		m.advance(job)
+	}
+	end := JobLogEnd{Status: job.Status, Error: job.Error}
+	if job.Results != nil {
+		for _, output := range []struct{ stream, text string }{
+			{LogStreamStdout, job.Results.Stdout},
+			{LogStreamStderr, job.Results.Stderr},
+		} {
+			if output.text == "" {
+				continue
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(output.text, "\n"), "\n") {
+				logf(output.stream, line)
+			}
+		}
+		end.ExitCode = job.Results.ExitCode
+	}
+
+	data, _ := json.Marshal(end)
+	frames = append(frames, fmt.Sprintf("event: end\ndata: %s\n\n", data))
+	return frames, true
+}
+
+// mockJobNotFound is the response to a request for an unknown job.
+func mockJobNotFound() (int, interface{}) {
+	return http.StatusNotFound, map[string]string{"error": "Job not found", "error_code": ErrorCodeJobNotFound}
+}
+
+func (m *MockMetaOSServer) handleSubmitJob(body []byte) (int, interface{}) {
+	var req struct {
+		Spec         *JobSpec `json:"spec"`
+		CreditCost   float64  `json:"credit_cost"`
+		Orchestrator string   `json:"orchestrator"`
+		NodeID       string   `json:"node_id"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Spec == nil {
+		return mockError(http.StatusBadRequest, "Invalid job request")
+	}
+	if req.Spec.Image == "" {
+		return mockError(http.StatusBadRequest, "Job image is required")
+	}
+	orchestrator := req.Orchestrator
+	if orchestrator == "" {
+		orchestrator = m.leastLoadedOrchestrator()
+	} else if _, ok := m.orchestrator(orchestrator); !ok {
+		return mockError(http.StatusNotFound, "Orchestrator not found: "+orchestrator)
+	}
+	if req.Spec.HasConstraints() && !m.anyNodeAllows(req.Spec) {
+		return mockError(http.StatusUnprocessableEntity, "No node meets the job's scheduling constraints")
+	}
+	cost := req.CreditCost
+	if cost <= 0 {
+		cost = calculateCreditCost(req.Spec)
+	}
+	if !m.spend(m.user, cost) {
+		return mockInsufficientCredits()
+	}
+
+	job := m.createJob(req.Spec, cost, orchestrator, req.NodeID, time.Now())
+
+	return http.StatusOK, map[string]interface{}{
+		"status":            "submitted",
+		"job_id":            job.ID,
+		"credit_deducted":   cost,
+		"remaining_balance": m.balance(m.user),
+		"orchestrator":      orchestrator,
+		"message":           "Job submitted successfully",
+	}
+}
+
+// createJob queues a job whose credits have already been spent. When
+// simulating, a job submitted to no node in particular is placed on the
+// first one that can take it.
+func (m *MockMetaOSServer) createJob(spec *JobSpec, cost float64, orchestrator, nodeID string, now time.Time) *MockJob {
+	m.nextJobID++
+	id := m.newID("job", m.nextJobID)
+
+	job := &MockJob{
+		Job: Job{
+			ID:           id,
+			UserID:       m.user,
+			Status:       JobStatusPending,
+			Spec:         spec,
+			CreditCost:   cost,
+			SubmittedAt:  now,
+			Orchestrator: orchestrator,
+		},
+		NodeID: nodeID,
+	}
+	if nodeID == "" && m.Simulate {
+		job.NodeID = m.placeJob(spec)
+	}
+	m.jobs[id] = job
+	m.jobOrder = append(m.jobOrder, id)
+	m.publishEvent("job_created", map[string]interface{}{"job": *job})
+
+	m.record(Transaction{
+		ID:          fmt.Sprintf("txn-%s", id),
+		Type:        TransactionSpend,
+		Amount:      cost,
+		Description: "Job " + id,
+		Timestamp:   now,
+		FromUser:    m.user,
+	})
+	return job
+}
+
+// placeJob returns the first online node that meets the spec's scheduling
+// constraints, or "" when there is none.
+func (m *MockMetaOSServer) placeJob(spec *JobSpec) string {
+	for _, n := range m.nodes {
+		if n.Status == NodeStatusOnline && spec.AllowsNode(&n.Node) {
+			return n.ID
+		}
+	}
+	return ""
+}
+
+func (m *MockMetaOSServer) handleSubmitJobBatch(body []byte) (int, interface{}) {
+	var req struct {
+		Jobs []json.RawMessage `json:"jobs"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid batch request")
+	}
+
+	results := make([]interface{}, len(req.Jobs))
+	for i, job := range req.Jobs {
+		status, result := m.handleSubmitJob(job)
+		if status != http.StatusOK {
+			failure := result.(map[string]string)
+			results[i] = map[string]interface{}{"error": failure["error"], "error_code": failure["error_code"], "code": status}
+			continue
+		}
+		results[i] = result
+	}
+	return http.StatusOK, map[string]interface{}{"results": results, "remaining_balance": m.balance(m.user)}
+}
+
+// mockQueueTimePerJob is how long each job already waiting delays a new one.
+const mockQueueTimePerJob = 30 * time.Second
+
+// handleEstimateJob answers what a job would cost, how many nodes could
+// run it and how long it would queue, without submitting it.
+func (m *MockMetaOSServer) handleEstimateJob(body []byte) (int, interface{}) {
+	var req struct {
+		Spec       *JobSpec `json:"spec"`
+		CreditCost float64  `json:"credit_cost"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Spec == nil {
+		return mockError(http.StatusBadRequest, "Invalid estimate request")
+	}
+	cost := req.CreditCost
+	if cost <= 0 {
+		cost = calculateCreditCost(req.Spec)
+	}
+
+	var cores, memoryGB float64
+	var gpus int
+	if r := req.Spec.Resources; r != nil {
+		cores, memoryGB = cpuCores(r.CPU), quantityGB(r.Memory)
+		gpus, _ = strconv.Atoi(r.GPU)
+	}
+	eligible := 0
+	for _, n := range m.nodes {
+		if n.Status == NodeStatusOnline && n.Resources != nil && float64(n.Resources.CPU) >= cores &&
+			n.Resources.GPU >= gpus && mockSizeGB(n.Resources.Memory) >= memoryGB && req.Spec.AllowsNode(&n.Node) {
+			eligible++
+		}
+	}
+
+	pending := 0
+	for _, job := range m.jobs {
+		if job.Status == JobStatusPending {
+			pending++
+		}
+	}
+	queue := 0.0
+	if eligible > 0 {
+		queue = (time.Duration(pending) * mockQueueTimePerJob / time.Duration(eligible)).Seconds()
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"credit_cost":            cost,
+		"eligible_nodes":         eligible,
+		"expected_queue_seconds": queue,
+	}
+}
+
+// anyNodeAllows reports whether some node that hasn't been retired meets
+// the spec's scheduling constraints.
+func (m *MockMetaOSServer) anyNodeAllows(spec *JobSpec) bool {
+	for _, n := range m.nodes {
+		if n.Status != NodeStatusRetired && spec.AllowsNode(&n.Node) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockMetaOSServer) handleListOrchestrators() (int, interface{}) {
+	orchestrators := make([]Orchestrator, len(m.orchestrators))
+	for i, o := range m.orchestrators {
+		o.Load = m.orchestratorLoad(o.ID)
+		orchestrators[i] = o
+	}
+	return http.StatusOK, map[string]interface{}{"orchestrators": orchestrators, "total": len(orchestrators)}
+}
+
+func (m *MockMetaOSServer) orchestrator(id string) (Orchestrator, bool) {
+	for _, o := range m.orchestrators {
+		if o.ID == id {
+			return o, true
+		}
+	}
+	return Orchestrator{}, false
+}
+
+// orchestratorLoad is the share of the orchestrator's capacity taken by active jobs.
+func (m *MockMetaOSServer) orchestratorLoad(id string) float64 {
+	active := 0
+	for _, job := range m.jobs {
+		if job.Orchestrator == id && !job.Status.IsTerminal() {
+			active++
+		}
+	}
+	return math.Min(float64(active)/mockOrchestratorCapacity, 1)
+}
+
+func (m *MockMetaOSServer) leastLoadedOrchestrator() string {
+	best, bestLoad := "", math.Inf(1)
+	for _, o := range m.orchestrators {
+		if load := m.orchestratorLoad(o.ID); o.Status == NodeStatusOnline && load < bestLoad {
+			best, bestLoad = o.ID, load
+		}
+	}
+	return best
+}
+
+func (m *MockMetaOSServer) handleListJobs(query url.Values) (int, interface{}) {
+	statuses := mockStatusFilter(query)
+	jobs := make([]MockJob, 0, len(m.jobOrder))
+	counts := make(map[JobStatus]int)
+	for _, id := range m.jobOrder {
+		job := m.jobs[id]
+		if statuses != nil && !statuses[string(job.Status)] {
+			continue
+		}
+		jobs = append(jobs, *job)
+		counts[job.Status]++
+	}
+
+	sortBy := JobSortField(query.Get("sort"))
+	if sortBy != "" {
+		if !sortBy.Valid() {
+			return mockError(http.StatusBadRequest, "Unsupported sort field: "+string(sortBy))
+		}
+		now := time.Now()
+		less := func(a, b MockJob) bool {
+			switch sortBy {
+			case JobSortCost:
+				return a.CreditCost < b.CreditCost
+			case JobSortDuration:
+				return a.Duration(now) < b.Duration(now)
+			default:
+				return a.SubmittedAt.Before(b.SubmittedAt)
+			}
+		}
+		ascending := query.Get("order") == "asc"
+		sort.SliceStable(jobs, func(i, j int) bool {
+			if ascending {
+				return less(jobs[i], jobs[j])
+			}
+			return less(jobs[j], jobs[i])
+		})
+	}
+
+	start, end, paging, problem := mockPage(query, len(jobs))
+	if problem != "" {
+		return mockError(http.StatusBadRequest, problem)
+	}
+	paging["jobs"] = jobs[start:end]
+	paging["counts"] = counts
+	return http.StatusOK, paging
+}
+
+// mockPage picks the page a list request asks for out of n items. It
+// returns the page's index range and the paging fields of the response.
+// Requests without a limit get the whole list.
+// An invalid request is reported by a non-empty problem.
+func mockPage(query url.Values, n int) (start, end int, fields map[string]interface{}, problem string) {
+	fields = map[string]interface{}{"total": n}
+	if query.Get("limit") == "" {
+		return 0, n, fields, ""
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		return 0, 0, nil, "Invalid limit: " + query.Get("limit")
+	}
+	position := query.Get("cursor")
+	if position == "" {
+		position = query.Get("offset")
+	}
+	if position != "" {
+		if start, err = strconv.Atoi(position); err != nil || start < 0 {
+			return 0, 0, nil, "Invalid cursor: " + position
+		}
+	}
+
+	start = min(start, n)
+	end = min(start+limit, n)
+	if end < n {
+		fields["next_cursor"] = strconv.Itoa(end)
+		fields["has_more"] = true
+	}
+	return start, end, fields, ""
+}
+
+func (m *MockMetaOSServer) handleGetJob(id string) (int, interface{}) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return mockJobNotFound()
+	}
+	if m.Simulate {
+		m.advance(job)
+	}
+	return http.StatusOK, job
+}
+
+func (m *MockMetaOSServer) handleArchiveJob(id string) (int, interface{}) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return mockJobNotFound()
+	}
+	if !job.Status.IsTerminal() {
+		return mockError(http.StatusConflict, fmt.Sprintf("Job is still %s", job.Status))
+	}
+
+	now := time.Now()
+	record := JobRecord{Job: job.Job, ArchivedAt: &now}
+	m.archive = append(m.archive, record)
+	delete(m.jobs, id)
+	for i, jobID := range m.jobOrder {
+		if jobID == id {
+			m.jobOrder = append(m.jobOrder[:i], m.jobOrder[i+1:]...)
+			break
+		}
+	}
+	return http.StatusOK, record
+}
+
+func (m *MockMetaOSServer) handleListArchive(sinceParam string) (int, interface{}) {
+	var since time.Time
+	if sinceParam != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, sinceParam); err != nil {
+			return mockError(http.StatusBadRequest, "Invalid since: "+sinceParam)
+		}
+	}
+
+	jobs := make([]JobRecord, 0, len(m.archive))
+	for _, record := range m.archive {
+		if !record.SubmittedAt.Before(since) {
+			jobs = append(jobs, record)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"jobs": jobs}
+}
+
+// advance moves a job one step through its lifecycle, as the network
+// would. A job rescheduled off its node is placed again as it starts. A
+// spot job is evicted once, the first time it would complete, and requeued.
+func (m *MockMetaOSServer) advance(job *MockJob) {
+	now := time.Now()
+	switch job.Status {
+	case JobStatusPending:
+		if job.NodeID == "" {
+			job.NodeID = m.placeJob(job.Spec)
+		}
+		m.setJobStatus(job, JobStatusRunning, now)
+	case JobStatusRunning:
+		if job.Spec.IsSpot() && len(job.Evictions) == 0 {
+			job.Evictions = append(job.Evictions, JobEviction{
+				At:     now,
+				NodeID: job.NodeID,
+				Reason: EvictionReclaimed,
+			})
+			m.setJobStatus(job, JobStatusPending, now)
+			return
+		}
+		job.Results = &JobResults{
+			Stdout:    fmt.Sprintf("[sandbox] %s finished successfully\n", job.Spec.Image),
+			ExitCode:  0,
+			Duration:  now.Sub(job.SubmittedAt).Seconds(),
+			NodeID:    job.NodeID,
+			OutputCID: "bafysandbox" + m.jobNumber(job.ID),
+		}
+		job.Results.Verification = m.verification(job)
+		m.setJobStatus(job, JobStatusCompleted, now)
+	}
+}
+
+// jobNumber returns the number a job ID was given.
+func (m *MockMetaOSServer) jobNumber(id string) string {
+	return strings.TrimPrefix(id, m.idPrefix+"job-")
+}
+
+// verification reports a verified job's simulated replicas or
+// attestation as all agreeing.
+func (m *MockMetaOSServer) verification(job *MockJob) *VerificationResult {
+	v, err := job.Spec.verification()
+	if err != nil || v == nil {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(job.Results.Stdout))
+	result := &VerificationResult{
+		Mode:       v.Mode,
+		Status:     VerificationVerified,
+		ResultHash: hex.EncodeToString(sum[:]),
+	}
+	if v.Mode == VerifyTEE {
+		result.Attestation = m.idPrefix + "attestation-" + m.jobNumber(job.ID)
+	} else {
+		result.Replicas = v.Replicas
+		result.Agreeing = v.Replicas
+	}
+	return result
+}
+
+func (m *MockMetaOSServer) handleCancelJob(id string) (int, interface{}) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return mockJobNotFound()
+	}
+
+	var refund float64
+	switch job.Status {
+	case JobStatusPending:
+		refund = job.CreditCost
+	case JobStatusRunning:
+		refund = job.CreditCost / 2
+	default:
+		return mockError(http.StatusBadRequest, fmt.Sprintf("Job is already %s", job.Status))
+	}
+
+	now := time.Now()
+	m.setJobStatus(job, JobStatusCancelled, now)
+	m.deposit(job.UserID, refund)
+	m.record(Transaction{
+		ID:          fmt.Sprintf("txn-%s-refund", id),
+		Type:        TransactionEarn,
+		Amount:      refund,
+		Description: "Refund for cancelled job " + id,
+		Timestamp:   now,
+		ToUser:      job.UserID,
+	})
+
+	return http.StatusOK, map[string]interface{}{
+		"status":            "cancelled",
+		"job_id":            id,
+		"refund_amount":     refund,
+		"remaining_balance": m.balance(m.user),
+	}
+}
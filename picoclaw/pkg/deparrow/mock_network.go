@@ -0,0 +1,365 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// handleHealth advertises the optional features the mock server serves. It
+// pushes no events over WebSockets and runs no workflows itself.
+func (m *MockMetaOSServer) handleHealth() (int, interface{}) {
+	return http.StatusOK, map[string]interface{}{
+		"status":      "healthy",
+		"version":     m.version,
+		"api_version": sandboxAPIVersion,
+		"features": []string{
+			FeatureVerifiedJobs, FeatureOrchestratorRouting, FeatureSchedulingConstraints, FeatureSpotPricing,
+			FeatureEscrow, FeatureEstimates,
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+		"services": map[string]string{
+			"bootstrap": "healthy",
+			"registry":  "healthy",
+			"credits":   "healthy",
+		},
+		"components": map[string]interface{}{
+			"nodes": len(m.nodes),
+			"jobs":  len(m.jobs),
+		},
+	}
+}
+
+func (m *MockMetaOSServer) handleMetrics() (int, interface{}) {
+	return http.StatusOK, map[string]interface{}{
+		"uptime_seconds": time.Since(m.started).Seconds(),
+		"jobs_total":     len(m.jobs),
+		"nodes_total":    len(m.nodes),
+	}
+}
+
+// liveGFlops is the throughput a node currently sustains.
+func (n *MockNode) liveGFlops() float64 {
+	if n.Contribution == nil {
+		return 0
+	}
+	return n.Contribution.LiveGFlops
+}
+
+func (m *MockMetaOSServer) handleNetworkStats() (int, interface{}) {
+	var online, cpu, gpu int
+	var memory, gflops float64
+	tiers := make(map[string]int)
+	for _, n := range m.nodes {
+		tiers[string(n.Tier)]++
+		if n.Status != NodeStatusOnline || n.Resources == nil {
+			continue
+		}
+		online++
+		cpu += n.Resources.CPU
+		gpu += n.Resources.GPU
+		memory += mockSizeGB(n.Resources.Memory)
+		gflops += n.liveGFlops()
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"network": map[string]interface{}{
+			"total_nodes":     len(m.nodes),
+			"online_nodes":    online,
+			"total_cpu_cores": cpu,
+			"total_gpu_count": gpu,
+			"total_memory_gb": memory,
+			"live_gflops":     gflops,
+			"live_tflops":     gflops / 1000,
+		},
+		"tiers":     tiers,
+		"timestamp": time.Now(),
+	}
+}
+
+// mockNetworkGrowth is how much the mock network grows a day, as a
+// fraction of its size, so that its history has a trend to show.
+const mockNetworkGrowth = 0.03
+
+// handleNetworkHistory reports the network growing steadily into its
+// current size. Each point earns what its live throughput makes over the
+// interval.
+func (m *MockMetaOSServer) handleNetworkHistory(query url.Values) (int, interface{}) {
+	window, _ := strconv.Atoi(query.Get("window"))
+	resolution, _ := strconv.Atoi(query.Get("resolution"))
+	span, step := time.Duration(window)*time.Second, time.Duration(resolution)*time.Second
+	if err := validateNetworkStatsHistory(span, step); err != nil {
+		return mockError(http.StatusBadRequest, err.Error())
+	}
+
+	var online int
+	var gflops float64
+	for _, n := range m.nodes {
+		if n.Status == NodeStatusOnline {
+			online++
+			gflops += n.liveGFlops()
+		}
+	}
+
+	end := time.Now()
+	history := NetworkStatsHistory{Points: []NetworkStatsPoint{}}
+	for t := end.Add(-span).Truncate(step); t.Before(end); t = t.Add(step) {
+		age := max(end.Sub(t.Add(step)), 0)
+		scale := 1 / (1 + mockNetworkGrowth*age.Hours()/24)
+		history.Points = append(history.Points, NetworkStatsPoint{
+			Time:          t,
+			TotalNodes:    int(math.Round(float64(len(m.nodes)) * scale)),
+			OnlineNodes:   int(math.Round(float64(online) * scale)),
+			LiveGFlops:    gflops * scale,
+			CreditsEarned: gflops * scale * step.Hours() * mockGFlopCredits,
+		})
+	}
+	return http.StatusOK, history
+}
+
+func (m *MockMetaOSServer) handleCapacity() (int, interface{}) {
+	byRegion := make(map[string]*RegionCapacity)
+	var order []string
+	for _, n := range m.nodes {
+		if n.Status != NodeStatusOnline || n.Resources == nil {
+			continue
+		}
+		region := n.region()
+		rc, ok := byRegion[region]
+		if !ok {
+			rc = &RegionCapacity{Region: region}
+			byRegion[region] = rc
+			order = append(order, region)
+		}
+		rc.OnlineNodes++
+		rc.AvailableCPU += n.Resources.CPU
+		rc.AvailableMemoryGB += mockSizeGB(n.Resources.Memory)
+		rc.AvailableStorageGB += mockSizeGB(n.Resources.Storage)
+
+		if n.Resources.GPU == 0 {
+			continue
+		}
+		model := n.Resources.GPUModel
+		if model == "" {
+			model = n.Labels["gpu_model"]
+		}
+		found := false
+		for i := range rc.GPUs {
+			if rc.GPUs[i].Model == model {
+				rc.GPUs[i].Available += n.Resources.GPU
+				rc.GPUs[i].MaxPerNode = max(rc.GPUs[i].MaxPerNode, n.Resources.GPU)
+				found = true
+			}
+		}
+		if !found {
+			rc.GPUs = append(rc.GPUs, GPUCapacity{
+				Model:      model,
+				Available:  n.Resources.GPU,
+				MaxPerNode: n.Resources.GPU,
+			})
+		}
+	}
+
+	capacity := NetworkCapacity{Regions: []RegionCapacity{}, Timestamp: time.Now()}
+	for _, region := range order {
+		capacity.Regions = append(capacity.Regions, *byRegion[region])
+	}
+	return http.StatusOK, capacity
+}
+
+// mockWindowHours is how long each leaderboard window is, in hours.
+var mockWindowHours = map[LeaderboardWindow]float64{
+	LeaderboardDay:   24,
+	LeaderboardWeek:  7 * 24,
+	LeaderboardMonth: 30 * 24,
+}
+
+// mockGFlopCredits is what a node earns per hour for each GFLOP/s it
+// sustains, for the windowed leaderboards.
+const mockGFlopCredits = 0.01
+
+// handleLeaderboard ranks the nodes, or one region's, over a window. All
+// time ranks lifetime credits. Shorter windows rank what the nodes' live
+// throughput earns over the window and report the all-time rank as the
+// previous period's, so that they show movement.
+func (m *MockMetaOSServer) handleLeaderboard(query url.Values) (int, interface{}) {
+	window := LeaderboardWindow(query.Get("window"))
+	if window == "" {
+		window = LeaderboardAllTime
+	}
+	if !window.Valid() {
+		return mockError(http.StatusBadRequest, "Invalid window: "+string(window))
+	}
+	region := query.Get("region")
+
+	lifetime := make([]*MockNode, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		if region == "" || n.region() == region {
+			lifetime = append(lifetime, n)
+		}
+	}
+	sort.SliceStable(lifetime, func(i, j int) bool {
+		return lifetime[i].CreditsEarned > lifetime[j].CreditsEarned
+	})
+
+	entries := make([]LeaderboardEntry, 0, len(lifetime))
+	for _, n := range lifetime {
+		// Nodes registered with the mock server have contributed nothing yet
+		var contribution NodeContribution
+		if n.Contribution != nil {
+			contribution = *n.Contribution
+		}
+		entry := LeaderboardEntry{
+			NodeID:        n.ID,
+			Tier:          n.Tier,
+			CreditsEarned: n.CreditsEarned,
+			CPUHours:      contribution.CPUUsageHours,
+			GPUHours:      contribution.GPUUsageHours,
+			TotalHours:    contribution.CPUUsageHours + contribution.GPUUsageHours,
+			Location:      n.Location,
+		}
+		if hours, ok := mockWindowHours[window]; ok {
+			previous := len(entries) + 1
+			entry.PreviousRank = &previous
+			entry.CreditsEarned = contribution.LiveGFlops * hours * mockGFlopCredits
+			if n.Status != NodeStatusOnline {
+				entry.CreditsEarned = 0
+			}
+			entry.CPUHours = min(entry.CPUHours, hours)
+			entry.GPUHours = min(entry.GPUHours, hours)
+			entry.TotalHours = entry.CPUHours + entry.GPUHours
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreditsEarned > entries[j].CreditsEarned
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	start, end, paging, problem := mockPage(query, len(entries))
+	if problem != "" {
+		return mockError(http.StatusBadRequest, problem)
+	}
+	paging["leaderboard"] = entries[start:end]
+	paging["window"] = window
+	return http.StatusOK, paging
+}
+
+func (m *MockMetaOSServer) handlePreferences(method string, body []byte) (int, interface{}) {
+	switch method {
+	case http.MethodGet:
+		return http.StatusOK, m.prefs
+	case http.MethodPut:
+		var prefs Preferences
+		if err := json.Unmarshal(body, &prefs); err != nil {
+			return mockError(http.StatusBadRequest, "Invalid JSON")
+		}
+		m.prefs = prefs
+		return http.StatusOK, m.prefs
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (m *MockMetaOSServer) handleListProviders() (int, interface{}) {
+	providers := make([]map[string]interface{}, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		if n.Status != NodeStatusOnline || n.Resources == nil {
+			continue
+		}
+		name := n.ID
+		if len(name) > 8 {
+			name = name[:8]
+		}
+		providers = append(providers, map[string]interface{}{
+			"id":     n.ID,
+			"name":   fmt.Sprintf("Provider %s", name),
+			"status": n.Status,
+			"location": map[string]interface{}{
+				"region":  n.region(),
+				"country": "USA",
+				"city":    "San Francisco",
+			},
+			"resources": map[string]interface{}{
+				"cpu_cores":        n.Resources.CPU,
+				"cpu_available":    n.Resources.CPU,
+				"memory_total":     n.Resources.Memory,
+				"memory_available": n.Resources.Memory,
+				"gpu_count":        n.Resources.GPU,
+				"gpu_available":    n.Resources.GPU,
+			},
+			"pricing": map[string]interface{}{
+				"cpu_per_hour":       1.0,
+				"memory_per_gb_hour": 0.1,
+				"gpu_per_hour":       5.0,
+			},
+			"stats": map[string]interface{}{
+				"jobs_completed":       50,
+				"success_rate":         0.98,
+				"avg_response_time":    2.5,
+				"uptime_30d":           0.99,
+				"total_credits_earned": n.CreditsEarned,
+			},
+		})
+	}
+	return http.StatusOK, map[string]interface{}{"providers": providers}
+}
+
+func (m *MockMetaOSServer) handleAgentStatus() (int, interface{}) {
+	return http.StatusOK, map[string]interface{}{
+		"id":              "agent-test-001",
+		"name":            "Test Agent",
+		"status":          "running",
+		"uptime":          "1h30m",
+		"credits_earned":  150.5,
+		"credits_spent":   45.0,
+		"tasks_completed": 12,
+		"tools": []map[string]interface{}{
+			{
+				"name":        "job_submit",
+				"description": "Submit compute jobs",
+				"enabled":     true,
+				"calls":       8,
+			},
+			{
+				"name":        "credit_check",
+				"description": "Check credit balance",
+				"enabled":     true,
+				"calls":       15,
+			},
+		},
+		"resources": map[string]interface{}{
+			"cpu_usage":    25.5,
+			"memory_usage": 512.0,
+			"disk_usage":   2.1,
+		},
+		"last_heartbeat": time.Now(),
+	}
+}
+
+func (m *MockMetaOSServer) handleAgentChat(body []byte) (int, interface{}) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"message": map[string]interface{}{
+			"id":        fmt.Sprintf("msg-%s", uuid.New().String()[:8]),
+			"role":      "assistant",
+			"content":   fmt.Sprintf("I received your message: '%s'. How can I help you with the DEparrow network?", req.Message),
+			"timestamp": time.Now(),
+		},
+	}
+}
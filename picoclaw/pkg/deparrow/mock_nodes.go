@@ -0,0 +1,407 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mockNodeRegion is the region of nodes without a "region" label.
+const mockNodeRegion = "default"
+
+// region returns the region a node is labeled with.
+func (n *MockNode) region() string {
+	if region := n.Labels["region"]; region != "" {
+		return region
+	}
+	return mockNodeRegion
+}
+
+// mockSizeGB parses a size such as "8Gi" or "512Mi" in GiB.
+func mockSizeGB(size string) float64 {
+	for suffix, scale := range map[string]float64{"Ti": 1024, "Gi": 1, "Mi": 1.0 / 1024} {
+		if n, err := strconv.ParseFloat(strings.TrimSuffix(size, suffix), 64); err == nil && strings.HasSuffix(size, suffix) {
+			return n * scale
+		}
+	}
+	return 0
+}
+
+func (m *MockMetaOSServer) handleListNodes(query url.Values) (int, interface{}) {
+	statuses := mockStatusFilter(query)
+	nodes := make([]MockNode, 0, len(m.nodes))
+	online := 0
+	for _, n := range m.nodes {
+		if statuses != nil && !statuses[string(n.Status)] {
+			continue
+		}
+		nodes = append(nodes, *n)
+		if n.Status == NodeStatusOnline {
+			online++
+		}
+	}
+
+	start, end, paging, problem := mockPage(query, len(nodes))
+	if problem != "" {
+		return mockError(http.StatusBadRequest, problem)
+	}
+	paging["nodes"] = nodes[start:end]
+	paging["online"] = online
+	return http.StatusOK, paging
+}
+
+// findNode returns the node with the given ID, or nil.
+func (m *MockMetaOSServer) findNode(id string) *MockNode {
+	for _, n := range m.nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// withRunningJobs returns a copy of a node reporting its running jobs.
+func (m *MockMetaOSServer) withRunningJobs(n *MockNode) MockNode {
+	node := *n
+	node.RunningJobs = len(m.runningJobs(n))
+	return node
+}
+
+func (m *MockMetaOSServer) handleGetNode(id string) (int, interface{}) {
+	n := m.findNode(id)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+	if n.Status == NodeStatusDraining && m.Simulate {
+		if running := m.runningJobs(n); len(running) > 0 {
+			m.advance(running[0])
+		}
+	}
+	return http.StatusOK, m.withRunningJobs(n)
+}
+
+// runningJobs returns the jobs running on a node, in submission order.
+func (m *MockMetaOSServer) runningJobs(n *MockNode) []*MockJob {
+	var running []*MockJob
+	for _, id := range m.jobOrder {
+		if job := m.jobs[id]; job.NodeID == n.ID && job.Status == JobStatusRunning {
+			running = append(running, job)
+		}
+	}
+	return running
+}
+
+// handleSetNodeStatus takes a node out of service or puts it back.
+func (m *MockMetaOSServer) handleSetNodeStatus(id string, body []byte) (int, interface{}) {
+	n := m.findNode(id)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+	var req struct {
+		Status NodeStatus `json:"status"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid request body")
+	}
+	switch req.Status {
+	case NodeStatusOnline, NodeStatusMaintenance, NodeStatusDraining:
+	default:
+		return mockError(http.StatusBadRequest, fmt.Sprintf("Node status %q can't be set", req.Status))
+	}
+	if n.Status == NodeStatusRetired {
+		return mockError(http.StatusConflict, "Node has been decommissioned")
+	}
+	if m.decommissions[id] != nil {
+		return mockError(http.StatusConflict, "Node is being decommissioned; cancel that first")
+	}
+
+	n.Status = req.Status
+	return http.StatusOK, m.withRunningJobs(n)
+}
+
+// Decommission returns a copy of a node's decommissioning.
+func (m *MockMetaOSServer) Decommission(nodeID string) (Decommission, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.decommissions[nodeID]; ok {
+		return *d, true
+	}
+	return Decommission{}, false
+}
+
+// handleDecommission starts (POST), reports (GET) or cancels (DELETE) a
+// node's decommissioning. Starting it moves the node's pending jobs back
+// to the queue; the node then stays draining until its running jobs
+// finish, after which its pending earnings are paid out, its key is
+// revoked and it is retired. When simulating, each read moves it one
+// stage further; otherwise it completes on the first request after the
+// drain.
+func (m *MockMetaOSServer) handleDecommission(method, id string) (int, interface{}) {
+	n := m.findNode(id)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+	d := m.decommissions[id]
+
+	switch method {
+	case http.MethodPost:
+		if d != nil {
+			return mockError(http.StatusConflict, fmt.Sprintf("Node is already %s", d.Stage))
+		}
+		d = &Decommission{NodeID: id, Stage: DecommissionDraining, RequestedAt: time.Now()}
+		for _, jobID := range m.jobOrder {
+			if job := m.jobs[jobID]; job.NodeID == id && job.Status == JobStatusPending {
+				job.NodeID = ""
+				d.JobsRescheduled++
+				m.publishEvent("job_update", map[string]interface{}{"job": *job})
+			}
+		}
+		d.JobsRemaining = len(m.runningJobs(n))
+		m.decommissions[id] = d
+		n.Status = NodeStatusDraining
+		if !m.Simulate {
+			m.finishDecommission(n, d)
+		}
+		return http.StatusOK, d
+	case http.MethodGet:
+		if d == nil {
+			return mockError(http.StatusNotFound, "Node is not being decommissioned")
+		}
+		if m.Simulate {
+			m.advanceDecommission(n, d)
+		} else {
+			m.finishDecommission(n, d)
+		}
+		return http.StatusOK, d
+	case http.MethodDelete:
+		if d == nil {
+			return mockError(http.StatusNotFound, "Node is not being decommissioned")
+		}
+		if d.Stage != DecommissionDraining {
+			return mockError(http.StatusConflict, "Node is past draining; the decommissioning can no longer be cancelled")
+		}
+		delete(m.decommissions, id)
+		n.Status = NodeStatusOnline
+		return http.StatusOK, map[string]interface{}{"status": "cancelled", "node_id": id}
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// finishDecommission takes a decommissioning through all its stages once
+// the node has no running jobs left.
+func (m *MockMetaOSServer) finishDecommission(n *MockNode, d *Decommission) {
+	for d.Stage != DecommissionRetired {
+		stage := d.Stage
+		m.advanceDecommission(n, d)
+		if d.Stage == stage {
+			return
+		}
+	}
+}
+
+// advanceDecommission moves a decommissioning on to its next stage. A
+// draining node stays draining while it has running jobs; when
+// simulating, it finishes one of them each time.
+func (m *MockMetaOSServer) advanceDecommission(n *MockNode, d *Decommission) {
+	now := time.Now()
+	switch d.Stage {
+	case DecommissionDraining:
+		running := m.runningJobs(n)
+		if len(running) > 0 && m.Simulate {
+			m.advance(running[0])
+			running = m.runningJobs(n)
+		}
+		if d.JobsRemaining = len(running); d.JobsRemaining > 0 {
+			return
+		}
+		d.Stage = DecommissionFinalizing
+	case DecommissionFinalizing:
+		d.FinalEarnings = n.PendingEarnings
+		if n.PendingEarnings > 0 {
+			n.CreditsEarned += n.PendingEarnings
+			m.deposit(m.user, n.PendingEarnings)
+			m.record(Transaction{
+				Type:        TransactionEarn,
+				Amount:      n.PendingEarnings,
+				Description: "Final earnings of decommissioned node " + n.ID,
+				Timestamp:   now,
+				ToUser:      m.user,
+				NodeID:      n.ID,
+			})
+			n.PendingEarnings = 0
+		}
+		d.Stage = DecommissionRevokingKey
+	case DecommissionRevokingKey:
+		n.PublicKey = ""
+		d.KeyRevokedAt = &now
+		d.Stage = DecommissionRetired
+		d.CompletedAt = &now
+		n.Status = NodeStatusRetired
+	}
+}
+
+// mockHeartbeatEarnings is what a node earns for each heartbeat.
+const mockHeartbeatEarnings = 0.1
+
+// handleRegisterNode adds a node to the network, or brings a known one
+// back online with its new resources.
+func (m *MockMetaOSServer) handleRegisterNode(body []byte) (int, interface{}) {
+	var req struct {
+		NodeRegistration
+		// Older agents report their disk alongside the other resources
+		Resources struct {
+			LocalResources
+			Disk string `json:"disk"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.NodeID == "" {
+		return mockError(http.StatusBadRequest, "Registration failed: node_id is required")
+	}
+	n := m.findNode(req.NodeID)
+	if n == nil {
+		n = &MockNode{Node: Node{ID: req.NodeID, Arch: ArchX86_64, Labels: make(map[string]string)}}
+		m.nodes = append(m.nodes, n)
+	} else if n.Status == NodeStatusRetired {
+		return mockError(http.StatusConflict, "Node has been decommissioned")
+	}
+
+	n.PublicKey = req.PublicKey
+	if req.Arch != "" {
+		n.Arch = req.Arch
+	}
+	// A node taken out of service stays out when its agent restarts
+	if n.Status != NodeStatusMaintenance && n.Status != NodeStatusDraining {
+		n.Status = NodeStatusOnline
+	}
+	n.LastSeen = time.Now()
+	r := req.Resources
+	n.Resources = &NodeResources{CPU: r.CPU, Memory: r.Memory, GPU: r.GPU, GPUModel: r.GPUModel, VRAM: r.VRAM, Storage: r.Disk}
+	if n.Labels == nil {
+		n.Labels = make(map[string]string)
+	}
+	for k, v := range req.Labels {
+		n.Labels[k] = v
+	}
+	if req.AgentVersion != "" {
+		n.AgentVersion = req.AgentVersion
+	}
+	return http.StatusOK, struct {
+		NodeRegistrationResult
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}{
+		NodeRegistrationResult: NodeRegistrationResult{
+			Status:            string(n.Status),
+			NodeID:            req.NodeID,
+			Token:             m.idPrefix + "node-token-" + req.NodeID,
+			Orchestrators:     m.orchestrators,
+			CreditEarningRate: mockHeartbeatEarnings,
+		},
+		Success: true,
+		Message: "Node registered successfully",
+	}
+}
+
+// handleHeartbeat keeps a node online and credits it for the time.
+func (m *MockMetaOSServer) handleHeartbeat(id string) (int, interface{}) {
+	n := m.findNode(id)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+	if n.Status == NodeStatusRetired {
+		return mockError(http.StatusConflict, "Node has been decommissioned")
+	}
+	n.LastSeen = time.Now()
+	n.CreditsEarned += mockHeartbeatEarnings
+	return http.StatusOK, HeartbeatAck{Status: "ok", LastSeen: n.LastSeen, CreditsEarned: n.CreditsEarned}
+}
+
+func (m *MockMetaOSServer) handleReleases() (int, interface{}) {
+	// History is newest first, so the first release per architecture is the latest
+	seen := make(map[Architecture]bool)
+	var latest []NodeAgentRelease
+	for _, r := range m.releases {
+		if !seen[r.Arch] {
+			seen[r.Arch] = true
+			latest = append(latest, r)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{
+		"channel":  "stable",
+		"releases": latest,
+	}
+}
+
+func (m *MockMetaOSServer) handleUpdateAdvisory(id string) (int, interface{}) {
+	n := m.findNode(id)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+
+	advisory := UpdateAdvisory{
+		NodeID:         id,
+		Arch:           n.Arch,
+		CurrentVersion: n.AgentVersion,
+		Severity:       UpdateCurrent,
+	}
+	found := false
+	for _, r := range m.releases {
+		if r.Arch != n.Arch {
+			continue
+		}
+		if !found {
+			advisory.Latest = r
+			found = true
+		}
+		switch cmp := compareVersions(r.Version, advisory.CurrentVersion); {
+		case cmp > 0:
+			advisory.Missing = append(advisory.Missing, r)
+		case cmp == 0:
+			advisory.CurrentMultiplier = r.EarningsMultiplier
+		}
+	}
+	if !found {
+		return mockError(http.StatusNotFound, "No releases for architecture "+string(n.Arch))
+	}
+
+	switch {
+	case advisory.CurrentVersion == "":
+		advisory.Severity = UpdateUnknown
+		advisory.Missing = nil
+	case compareVersions(advisory.CurrentVersion, advisory.Latest.MinSupported) < 0:
+		advisory.Severity = UpdateUnsupported
+	case len(advisory.SecurityFixes()) > 0:
+		advisory.Severity = UpdateSecurity
+	case len(advisory.Missing) > 0:
+		advisory.Severity = UpdateRecommended
+	}
+	advisory.Outdated = len(advisory.Missing) > 0
+
+	return http.StatusOK, advisory
+}
+
+func (m *MockMetaOSServer) handleNodeContribution(id string) (int, interface{}) {
+	n := m.findNode(id)
+	if n == nil {
+		return mockError(http.StatusNotFound, "Node not found")
+	}
+	// Nodes registered with the mock server have contributed nothing yet
+	var contribution NodeContribution
+	if n.Contribution != nil {
+		contribution = *n.Contribution
+	}
+	return http.StatusOK, map[string]interface{}{
+		"node_id":      id,
+		"contribution": contribution,
+		"ranking": map[string]interface{}{
+			"rank":        contribution.Rank,
+			"total_nodes": contribution.TotalNodes,
+			"tier":        n.Tier,
+		},
+	}
+}
@@ -0,0 +1,258 @@
+package deparrow
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+const (
+	// MockPaymentWebhookPath receives payment provider events.
+	MockPaymentWebhookPath = "/api/v1/payments/webhook"
+
+	// PaymentSignatureHeader carries the HMAC-SHA256 of a webhook body.
+	PaymentSignatureHeader = "X-Payment-Signature"
+)
+
+// Payment webhook event types.
+const (
+	PaymentEventSucceeded = "payment.succeeded"
+	PaymentEventFailed    = "payment.failed"
+	PaymentEventExpired   = "payment.expired"
+)
+
+// MockTopUp is a purchase of credits and the user who made it. A top-up
+// that needs a checkout stays pending until a payment webhook settles it.
+type MockTopUp struct {
+	TopUp
+	UserID string `json:"user_id"`
+}
+
+// MockPaymentEvent is the body of a payment webhook.
+type MockPaymentEvent struct {
+	ID      string `json:"event_id"`
+	Type    string `json:"type"`
+	TopUpID string `json:"top_up_id"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// mockFundingSources are the funding sources every user starts with.
+func mockFundingSources() []FundingSource {
+	return []FundingSource{
+		{
+			ID:               "card-4242",
+			Type:             FundingSourceCard,
+			Label:            "Visa ending 4242",
+			Currency:         "USD",
+			CreditsPerUnit:   10,
+			MinAmount:        10,
+			MaxAmount:        10000,
+			Default:          true,
+			RequiresCheckout: true,
+		},
+		{
+			ID:               "bank-transfer",
+			Type:             FundingSourceBank,
+			Label:            "Bank transfer",
+			Currency:         "EUR",
+			CreditsPerUnit:   11,
+			MinAmount:        100,
+			RequiresCheckout: true,
+		},
+	}
+}
+
+// SetFundingSources replaces a user's funding sources.
+func (m *MockMetaOSServer) SetFundingSources(userID string, sources []FundingSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fundingSources[userID] = sources
+}
+
+// TopUp returns a copy of a top-up.
+func (m *MockMetaOSServer) TopUp(id string) (MockTopUp, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if topUp := m.topUp(id); topUp != nil {
+		return *topUp, true
+	}
+	return MockTopUp{}, false
+}
+
+// topUp returns a top-up, or nil. Must be called with the lock held.
+func (m *MockMetaOSServer) topUp(id string) *MockTopUp {
+	for _, topUp := range m.topUps {
+		if topUp.ID == id {
+			return topUp
+		}
+	}
+	return nil
+}
+
+// SignPaymentWebhook returns the signature header value for a webhook body.
+func (m *MockMetaOSServer) SignPaymentWebhook(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(m.PaymentWebhookSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// userFundingSources returns a user's funding sources, giving them the
+// defaults on first use. Must be called with the lock held.
+func (m *MockMetaOSServer) userFundingSources(userID string) []FundingSource {
+	sources, ok := m.fundingSources[userID]
+	if !ok {
+		sources = mockFundingSources()
+		m.fundingSources[userID] = sources
+	}
+	return sources
+}
+
+func (m *MockMetaOSServer) handleListFundingSources() (int, interface{}) {
+	return http.StatusOK, map[string]interface{}{"funding_sources": m.userFundingSources(m.user)}
+}
+
+func (m *MockMetaOSServer) handleCreateTopUp(body []byte) (int, interface{}) {
+	var req struct {
+		Amount   float64 `json:"amount"`
+		SourceID string  `json:"source_id"`
+		Provider string  `json:"provider"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+	if req.Amount <= 0 {
+		return mockError(http.StatusBadRequest, "A positive amount is required")
+	}
+
+	var source *FundingSource
+	sources := m.userFundingSources(m.user)
+	for i := range sources {
+		candidate := &sources[i]
+		if req.Provider != "" {
+			if candidate.Provider == req.Provider {
+				source = candidate
+				break
+			}
+			continue
+		}
+		if candidate.ID == req.SourceID || (req.SourceID == "" && candidate.Default) {
+			source = candidate
+			break
+		}
+	}
+	if source == nil && req.Provider != "" {
+		return mockError(http.StatusBadRequest, fmt.Sprintf("Payment provider %q is not supported", req.Provider))
+	}
+	if source == nil {
+		return mockError(http.StatusNotFound, "Funding source not found")
+	}
+	if req.Amount < source.MinAmount {
+		return mockError(http.StatusBadRequest, fmt.Sprintf("%s top-ups must be at least %.0f credits", source.Label, source.MinAmount))
+	}
+	if source.MaxAmount > 0 && req.Amount > source.MaxAmount {
+		return mockError(http.StatusBadRequest, fmt.Sprintf("%s top-ups must be at most %.0f credits", source.Label, source.MaxAmount))
+	}
+
+	now := time.Now()
+	topUp := &MockTopUp{
+		TopUp: TopUp{
+			ID:        m.newID("topup", len(m.topUps)+1),
+			SourceID:  source.ID,
+			Provider:  req.Provider,
+			Amount:    req.Amount,
+			Price:     math.Round(req.Amount/source.CreditsPerUnit*100) / 100,
+			Currency:  source.Currency,
+			Status:    TopUpPending,
+			CreatedAt: now,
+		},
+		UserID: m.user,
+	}
+	m.topUps = append(m.topUps, topUp)
+
+	switch {
+	case req.Provider != "":
+		// A provider intent is always paid on the provider's checkout
+		expires := now.Add(time.Hour)
+		topUp.CheckoutURL = m.CheckoutBaseURL + "/" + req.Provider + "/" + topUp.ID
+		topUp.ExpiresAt = &expires
+	case source.RequiresCheckout:
+		expires := now.Add(time.Hour)
+		topUp.CheckoutURL = m.CheckoutBaseURL + "/pay/" + topUp.ID
+		topUp.ExpiresAt = &expires
+	default:
+		m.completeTopUp(topUp, now)
+	}
+	return http.StatusOK, topUp
+}
+
+// handleGetTopUp reports a top-up. When simulating there is no one to pay
+// the checkout, so a pending top-up is completed the first time it is
+// checked.
+func (m *MockMetaOSServer) handleGetTopUp(id string) (int, interface{}) {
+	topUp := m.topUp(id)
+	if topUp == nil {
+		return mockError(http.StatusNotFound, "Top-up not found")
+	}
+	if topUp.Status == TopUpPending && m.Simulate {
+		m.completeTopUp(topUp, time.Now())
+	}
+	return http.StatusOK, topUp
+}
+
+// completeTopUp adds the credits of a paid top-up to the wallet.
+func (m *MockMetaOSServer) completeTopUp(topUp *MockTopUp, now time.Time) {
+	topUp.Status = TopUpCompleted
+	topUp.CheckoutURL = ""
+	topUp.CompletedAt = &now
+	m.deposit(topUp.UserID, topUp.Amount)
+	m.record(Transaction{
+		ID:          fmt.Sprintf("txn-%s", topUp.ID),
+		Type:        TransactionTopUp,
+		Amount:      topUp.Amount,
+		Description: "Top-up from " + topUp.SourceID,
+		Timestamp:   now,
+		ToUser:      topUp.UserID,
+	})
+}
+
+// handlePaymentWebhook settles a top-up from a payment provider event.
+// Events must be signed with PaymentWebhookSecret. Redelivered events
+// leave a settled top-up unchanged, so credits are added only once.
+func (m *MockMetaOSServer) handlePaymentWebhook(signature string, body []byte) (int, interface{}) {
+	if !hmac.Equal([]byte(signature), []byte(m.SignPaymentWebhook(body))) {
+		return mockError(http.StatusUnauthorized, "Invalid signature")
+	}
+
+	var event MockPaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+
+	topUp := m.topUp(event.TopUpID)
+	if topUp == nil {
+		return mockError(http.StatusNotFound, "Top-up not found")
+	}
+	if topUp.Status != TopUpPending {
+		return http.StatusOK, map[string]interface{}{"received": true, "duplicate": true}
+	}
+
+	switch event.Type {
+	case PaymentEventSucceeded:
+		m.completeTopUp(topUp, time.Now())
+	case PaymentEventFailed:
+		topUp.Status = TopUpFailed
+		topUp.CheckoutURL = ""
+		topUp.FailureReason = event.Reason
+	case PaymentEventExpired:
+		topUp.Status = TopUpExpired
+		topUp.CheckoutURL = ""
+	default:
+		return mockError(http.StatusBadRequest, "Unknown event type")
+	}
+	return http.StatusOK, map[string]interface{}{"received": true}
+}
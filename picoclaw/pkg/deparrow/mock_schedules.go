@@ -0,0 +1,142 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func (m *MockMetaOSServer) handleCreateSchedule(body []byte) (int, interface{}) {
+	var req JobScheduleRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return mockError(http.StatusBadRequest, "Invalid JSON")
+	}
+	if req.Spec == nil || req.Spec.Image == "" {
+		return mockError(http.StatusBadRequest, "Job image is required")
+	}
+	if req.BudgetPerWindow > 0 && !req.BudgetWindow.Valid() {
+		return mockError(http.StatusBadRequest, "Budget window must be daily, weekly or monthly")
+	}
+	if req.Spec.HasConstraints() && !m.anyNodeAllows(req.Spec) {
+		return mockError(http.StatusUnprocessableEntity, "No node meets the job's scheduling constraints")
+	}
+
+	now := time.Now()
+	runs, err := NextScheduleRuns(req.Cron, req.Timezone, now, 1)
+	if err != nil {
+		return mockError(http.StatusBadRequest, err.Error())
+	}
+	schedule := &JobSchedule{
+		ID:                m.newID("schedule", len(m.schedules)+1),
+		Name:              req.Name,
+		Cron:              req.Cron,
+		Timezone:          req.Timezone,
+		Spec:              req.Spec,
+		MaxConcurrentRuns: req.MaxConcurrentRuns,
+		BudgetPerWindow:   req.BudgetPerWindow,
+		BudgetWindow:      req.BudgetWindow,
+		NextRunAt:         runs[0],
+		CreatedAt:         now,
+	}
+	if schedule.MaxConcurrentRuns == 0 {
+		schedule.MaxConcurrentRuns = DefaultMaxConcurrentRuns
+	}
+	m.schedules = append(m.schedules, schedule)
+	return http.StatusOK, schedule
+}
+
+func (m *MockMetaOSServer) handleListSchedules() (int, interface{}) {
+	schedules := make([]JobSchedule, 0, len(m.schedules))
+	for _, schedule := range m.schedules {
+		schedule.ActiveRuns = m.activeRuns(schedule.ID)
+		schedules = append(schedules, *schedule)
+	}
+	return http.StatusOK, map[string]interface{}{"schedules": schedules}
+}
+
+func (m *MockMetaOSServer) handleSchedule(method, id string) (int, interface{}) {
+	index := -1
+	for i, schedule := range m.schedules {
+		if schedule.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return mockError(http.StatusNotFound, "Schedule not found")
+	}
+	schedule := m.schedules[index]
+
+	switch method {
+	case http.MethodGet:
+		schedule.ActiveRuns = m.activeRuns(schedule.ID)
+		return http.StatusOK, schedule
+	case http.MethodDelete:
+		m.schedules = append(m.schedules[:index], m.schedules[index+1:]...)
+		return http.StatusOK, map[string]interface{}{
+			"status":      "deleted",
+			"schedule_id": schedule.ID,
+		}
+	default:
+		return mockError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// activeRuns counts the jobs a schedule submitted that haven't finished.
+func (m *MockMetaOSServer) activeRuns(scheduleID string) int {
+	active := 0
+	for _, job := range m.jobs {
+		if job.Spec.Labels[ScheduleLabel] == scheduleID && !job.Status.IsTerminal() {
+			active++
+		}
+	}
+	return active
+}
+
+// runSchedules submits the run of every schedule that has fallen due by
+// now. Runs missed in between are not caught up; a schedule fires at most
+// once per check and then waits for its next tick after now.
+func (m *MockMetaOSServer) runSchedules(now time.Time) {
+	for _, schedule := range m.schedules {
+		if schedule.NextRunAt.After(now) {
+			continue
+		}
+		run := schedule.NextRunAt
+		if next, err := NextScheduleRuns(schedule.Cron, schedule.Timezone, now, 1); err == nil {
+			schedule.NextRunAt = next[0]
+		}
+
+		if schedule.BudgetPerWindow > 0 && (schedule.WindowResetsAt == nil || !schedule.WindowResetsAt.After(now)) {
+			resets := schedule.BudgetWindow.Next(now)
+			schedule.SpentInWindow = 0
+			schedule.WindowResetsAt = &resets
+		}
+
+		cost := calculateCreditCost(schedule.Spec)
+		switch {
+		case m.activeRuns(schedule.ID) >= schedule.MaxConcurrentRuns:
+			schedule.LastSkipReason = fmt.Sprintf("%d run(s) still active on %s", schedule.MaxConcurrentRuns, run.Format(time.RFC3339))
+		case schedule.BudgetPerWindow > 0 && schedule.SpentInWindow+cost > schedule.BudgetPerWindow:
+			schedule.LastSkipReason = fmt.Sprintf("%s budget of %.2f credits used up on %s", schedule.BudgetWindow, schedule.BudgetPerWindow, run.Format(time.RFC3339))
+		case !m.spend(m.user, cost):
+			schedule.LastSkipReason = fmt.Sprintf("Insufficient credits on %s", run.Format(time.RFC3339))
+		default:
+			spec := *schedule.Spec
+			spec.Labels = make(map[string]string, len(schedule.Spec.Labels)+1)
+			for k, v := range schedule.Spec.Labels {
+				spec.Labels[k] = v
+			}
+			spec.Labels[ScheduleLabel] = schedule.ID
+
+			job := m.createJob(&spec, cost, m.leastLoadedOrchestrator(), "", now)
+			schedule.SpentInWindow += cost
+			schedule.Runs++
+			schedule.LastRunAt = &run
+			schedule.LastJobID = job.ID
+			schedule.LastSkipReason = ""
+			continue
+		}
+		schedule.SkippedRuns++
+	}
+}
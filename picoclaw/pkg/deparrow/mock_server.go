@@ -0,0 +1,517 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MockUserID is the user a mock server acts for, whoever authenticates.
+const MockUserID = "test-user"
+
+// MockMetaOSServer is an in-memory implementation of the Meta-OS API. It
+// is an http.Handler: integration tests serve it over a socket, and the
+// sandbox serves it in-process as a client's transport.
+//
+// Every request acts for one user, whatever token it carries. Jobs and
+// nodes only change state when a test moves them, unless Simulate is set.
+// Standing orders and job schedules that have fallen due run before each
+// request, and the balance is snapshotted at most once per
+// mockSnapshotInterval.
+type MockMetaOSServer struct {
+	// SSEHeartbeat is how often idle event streams send a heartbeat.
+	// Zero uses DefaultSSEHeartbeat.
+	SSEHeartbeat time.Duration
+
+	// PaymentWebhookSecret signs payment webhooks.
+	PaymentWebhookSecret string
+
+	// CheckoutBaseURL is where the checkout pages of top-ups are served.
+	CheckoutBaseURL string
+
+	// Simulate makes the server play the network's part: a job advances
+	// one state each time it is read (pending → running → completed), a
+	// draining node finishes one of its running jobs per read, and a
+	// decommissioning moves one stage further per read. Pending top-ups
+	// are paid the first time they are checked, as no one visits the
+	// checkout.
+	Simulate bool
+
+	mu       sync.Mutex
+	user     string
+	idPrefix string
+	version  string
+	started  time.Time
+	requests []MockRequest
+	users    map[string]*MockUser
+	apiKeys  map[string]*MockAPIKey
+
+	buckets        map[string][]CreditBucket
+	transactions   []Transaction
+	snapshots      []BalanceSnapshot
+	standingOrders []*MockStandingOrder
+	stopScheduler  chan struct{}
+	transferOffers []*TransferOffer
+	approvals      []*PendingTransfer
+	fundingSources map[string][]FundingSource
+	topUps         []*MockTopUp
+
+	jobs      map[string]*MockJob
+	jobOrder  []string
+	archive   []JobRecord
+	nextJobID int
+	schedules []*JobSchedule
+	bookings  []*Booking
+
+	nodes         []*MockNode
+	orchestrators []Orchestrator
+	decommissions map[string]*Decommission
+	releases      []NodeAgentRelease
+	tierChanges   []Activity
+	prefs         Preferences
+
+	events      []MockEvent
+	nextEventID uint64
+	subscribers map[*mockEventSubscriber]struct{}
+}
+
+// MockRequest records the correlation metadata of a request received by the mock server.
+type MockRequest struct {
+	Method    string
+	Path      string
+	RequestID string
+	SessionID string
+	UserAgent string
+}
+
+// MockUser is an account that can log in to the mock server.
+type MockUser struct {
+	ID       string `json:"user_id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"-"`
+	Token    string `json:"token"`
+}
+
+// MockNode is a node of the mock network.
+type MockNode struct {
+	Node
+	// PendingEarnings are credits earned but not yet paid out
+	PendingEarnings float64 `json:"pending_earnings,omitempty"`
+}
+
+// MockJob is a job submitted to the mock server.
+type MockJob struct {
+	Job
+	// Node the job was placed on, if any
+	NodeID   string `json:"node_id,omitempty"`
+	Progress int    `json:"progress"`
+}
+
+// MockStandingOrder is a standing order and the user who pays it.
+type MockStandingOrder struct {
+	StandingOrder
+	FromUser string `json:"from_user"`
+}
+
+// NewMockMetaOSServer creates a mock server acting for MockUserID, who
+// starts with 1000 credits and no network.
+func NewMockMetaOSServer() *MockMetaOSServer {
+	m := newMockServer(MockUserID, "", "1.0.0-test")
+	m.addUser(MockUserID, "test@example.com", "password123")
+	m.setCredits(MockUserID, 1000.0)
+	return m
+}
+
+// newMockServer creates an empty mock server acting for userID. Record IDs
+// start with idPrefix.
+func newMockServer(userID, idPrefix, version string) *MockMetaOSServer {
+	return &MockMetaOSServer{
+		PaymentWebhookSecret: "test-payment-webhook-secret",
+		user:                 userID,
+		idPrefix:             idPrefix,
+		version:              version,
+		started:              time.Now(),
+		users:                make(map[string]*MockUser),
+		apiKeys:              make(map[string]*MockAPIKey),
+		buckets:              make(map[string][]CreditBucket),
+		fundingSources:       make(map[string][]FundingSource),
+		jobs:                 make(map[string]*MockJob),
+		decommissions:        make(map[string]*Decommission),
+		subscribers:          make(map[*mockEventSubscriber]struct{}),
+	}
+}
+
+// newID numbers the nth record of a kind, e.g. "sandbox-job-0001".
+func (m *MockMetaOSServer) newID(kind string, n int) string {
+	return fmt.Sprintf("%s%s-%04d", m.idPrefix, kind, n)
+}
+
+// ledgerID numbers the nth transaction or credit bucket, e.g. "txn-sandbox-0001".
+func (m *MockMetaOSServer) ledgerID(kind string, n int) string {
+	return fmt.Sprintf("%s-%s%04d", kind, m.idPrefix, n)
+}
+
+// Close ends the standing order scheduler and every open event stream.
+func (m *MockMetaOSServer) Close() {
+	m.StopStandingOrderScheduler()
+	m.closeEventStreams()
+}
+
+// AddTestUser adds a user with no credits.
+func (m *MockMetaOSServer) AddTestUser(id, email, password string) *MockUser {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addUser(id, email, password)
+}
+
+// addUser adds a user. Must be called with the lock held.
+func (m *MockMetaOSServer) addUser(id, email, password string) *MockUser {
+	user := &MockUser{
+		ID:       id,
+		Email:    email,
+		Name:     fmt.Sprintf("Test User %s", id),
+		Password: password,
+		Token:    fmt.Sprintf("test-jwt-token-%s", id),
+	}
+	m.users[id] = user
+	m.buckets[id] = nil
+	return user
+}
+
+// AddTestNode adds an online node with 4 CPU cores, 8Gi of memory and no GPU.
+func (m *MockMetaOSServer) AddTestNode(id string) *MockNode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node := &MockNode{Node: Node{
+		ID:        id,
+		PublicKey: fmt.Sprintf("pubkey-%s", id),
+		Arch:      ArchX86_64,
+		Status:    NodeStatusOnline,
+		LastSeen:  time.Now(),
+		Resources: &NodeResources{
+			CPU:     4,
+			Memory:  "8Gi",
+			Storage: "100Gi",
+		},
+		Labels: make(map[string]string),
+	}}
+	m.nodes = append(m.nodes, node)
+	return node
+}
+
+// LoadFixtures seeds the server with the fixture network the sandbox
+// simulates and gives the user the sandbox's starting balance, so tests
+// can expect the same state from either.
+func (m *MockMetaOSServer) LoadFixtures() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, f := range FixtureNodes(time.Now()) {
+		node := &MockNode{Node: f.Node}
+		node.PublicKey = fmt.Sprintf("pubkey-%s", f.ID)
+		m.nodes = append(m.nodes, node)
+	}
+	m.setCredits(m.user, SandboxStartingBalance)
+}
+
+// UpdateNode changes a node under the server's lock, reporting whether
+// the node exists.
+func (m *MockMetaOSServer) UpdateNode(id string, update func(*MockNode)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node := m.findNode(id)
+	if node == nil {
+		return false
+	}
+	update(node)
+	return true
+}
+
+// GetCredits returns the credit balance for a user.
+func (m *MockMetaOSServer) GetCredits(userID string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.balance(userID)
+}
+
+// SetCredits replaces a user's credits with a single purchased bucket.
+func (m *MockMetaOSServer) SetCredits(userID string, amount float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setCredits(userID, amount)
+	m.publishBalance(userID)
+}
+
+// setCredits replaces a user's credits. Must be called with the lock held.
+func (m *MockMetaOSServer) setCredits(userID string, amount float64) {
+	m.buckets[userID] = []CreditBucket{{
+		ID:        m.ledgerID("bucket", 1),
+		Source:    CreditSourcePurchased,
+		Amount:    amount,
+		GrantedAt: time.Now(),
+	}}
+}
+
+// Requests returns the requests received so far, in order.
+func (m *MockMetaOSServer) Requests() []MockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockRequest(nil), m.requests...)
+}
+
+// ServeHTTP answers a request from the mock state.
+func (m *MockMetaOSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Echo correlation headers so client and server logs line up
+	requestID := r.Header.Get(HeaderRequestID)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	w.Header().Set(HeaderRequestID, requestID)
+	if sessionID := r.Header.Get(HeaderSessionID); sessionID != "" {
+		w.Header().Set(HeaderSessionID, sessionID)
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, MockRequest{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		RequestID: requestID,
+		SessionID: r.Header.Get(HeaderSessionID),
+		UserAgent: r.Header.Get("User-Agent"),
+	})
+	m.mu.Unlock()
+
+	// Handle CORS preflight
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Serve the API key alias as the collection itself, scope checks included
+	if rest, ok := strings.CutPrefix(r.URL.Path, mockAPIKeysAlias); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		r.URL.Path = apiKeysPath + rest
+	}
+
+	// Requests made with an API key are limited to its scope
+	if secret := r.Header.Get(HeaderAPIKey); secret != "" && r.URL.Path != "/api/v1/health" {
+		m.mu.Lock()
+		status, payload := m.authorizeAPIKey(r.Method, r.URL.Path, secret)
+		m.mu.Unlock()
+		if status != http.StatusOK {
+			writeMockResponse(w, status, payload)
+			return
+		}
+	}
+
+	// Streams are written as they go, outside the lock
+	if r.URL.Path == mockEventsPath {
+		m.handleEvents(w, r)
+		return
+	}
+	if id, ok := mockJobLogsID(r.Method, r.URL.Path); ok {
+		m.handleJobLogs(w, r, id)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeMockResponse(w, http.StatusBadRequest, mockErrorPayload("Invalid body"))
+		return
+	}
+
+	m.mu.Lock()
+	now := time.Now()
+	m.runStandingOrders(now)
+	m.expireTransferOffers(now)
+	m.runSchedules(now)
+	m.completeBookings(now)
+	m.snapshotBalance(now)
+	status, payload := m.route(r, body)
+	// Encode before unlocking, the payload may point into the state
+	var data []byte
+	if payload != nil {
+		data, err = json.Marshal(payload)
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		writeMockResponse(w, http.StatusInternalServerError, mockErrorPayload("Failed to encode response"))
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// writeMockResponse writes a response that is not part of the mock state.
+func writeMockResponse(w http.ResponseWriter, status int, payload interface{}) {
+	data, _ := json.Marshal(payload)
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// mockError builds an error response in the API's format.
+func mockError(status int, message string) (int, interface{}) {
+	return status, mockErrorPayload(message)
+}
+
+// mockErrorPayload is the body of an error response.
+func mockErrorPayload(message string) map[string]string {
+	return map[string]string{"error": message}
+}
+
+// mockInsufficientCredits is the response to a request the user can't pay for.
+func mockInsufficientCredits() (int, interface{}) {
+	return http.StatusBadRequest, map[string]string{"error": "Insufficient credits", "error_code": ErrorCodeInsufficientCredits}
+}
+
+// route dispatches a request to the matching handler.
+// Must be called with the lock held.
+func (m *MockMetaOSServer) route(r *http.Request, body []byte) (int, interface{}) {
+	method, path, query := r.Method, r.URL.Path, r.URL.Query()
+
+	switch {
+	case path == "/api/v1/health":
+		return m.handleHealth()
+	case path == "/api/v1/metrics":
+		return m.handleMetrics()
+	case path == "/api/v1/auth/login":
+		return m.handleLogin(body)
+	case path == "/api/v1/auth/register":
+		return m.handleRegister(body)
+	case path == apiKeysPath:
+		return m.handleAPIKeys(r, body)
+	case strings.HasPrefix(path, apiKeysPath+"/"):
+		return m.handleAPIKey(r, strings.TrimPrefix(path, apiKeysPath+"/"))
+	case path == "/api/v1/jobs/submit" && method == http.MethodPost:
+		return m.handleSubmitJob(body)
+	case path == batchSubmitPath && method == http.MethodPost:
+		return m.handleSubmitJobBatch(body)
+	case path == jobEstimatePath && method == http.MethodPost:
+		return m.handleEstimateJob(body)
+	case path == "/api/v1/jobs":
+		return m.handleListJobs(query)
+	case path == jobArchivePath:
+		return m.handleListArchive(query.Get("since"))
+	case strings.HasPrefix(path, "/api/v1/jobs/") && strings.HasSuffix(path, "/archive") && method == http.MethodPost:
+		return m.handleArchiveJob(strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/jobs/"), "/archive"))
+	case strings.HasPrefix(path, "/api/v1/jobs/") && strings.HasSuffix(path, "/cancel") && method == http.MethodPost:
+		return m.handleCancelJob(strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/jobs/"), "/cancel"))
+	case strings.HasPrefix(path, "/api/v1/jobs/"):
+		return m.handleGetJob(strings.TrimPrefix(path, "/api/v1/jobs/"))
+	case path == "/api/v1/credits/history" || strings.HasPrefix(path, "/api/v1/credits/balance/") && strings.HasSuffix(path, "/history"):
+		return m.handleBalanceHistory(query)
+	case path == "/api/v1/credits" || strings.HasPrefix(path, "/api/v1/credits/balance"):
+		return m.handleBalance()
+	case path == "/api/v1/credits/check" && method == http.MethodPost:
+		return m.handleCheckCredits(body)
+	case path == transfersPath && method == http.MethodPost:
+		return m.handleTransfer(body)
+	case path == pendingTransfersPath:
+		return m.handleListPendingTransfers()
+	case strings.HasPrefix(path, pendingTransfersPath+"/") && strings.HasSuffix(path, "/approve") && method == http.MethodPost:
+		return m.handleApproveTransfer(strings.TrimSuffix(strings.TrimPrefix(path, pendingTransfersPath+"/"), "/approve"))
+	case path == transactionsPath:
+		return m.handleTransactions(query)
+	case path == activityPath:
+		return m.handleActivity(query)
+	case path == standingOrdersPath && method == http.MethodPost:
+		return m.handleCreateStandingOrder(body)
+	case path == standingOrdersPath:
+		return m.handleListStandingOrders()
+	case strings.HasPrefix(path, standingOrdersPath+"/"):
+		return m.handleStandingOrder(method, strings.TrimPrefix(path, standingOrdersPath+"/"), body)
+	case path == transferOffersPath && method == http.MethodPost:
+		return m.handleCreateTransferOffer(body)
+	case path == transferOffersPath:
+		return m.handleListTransferOffers()
+	case strings.HasPrefix(path, transferOffersPath+"/"):
+		return m.handleTransferOffer(method, strings.TrimPrefix(path, transferOffersPath+"/"))
+	case path == schedulesPath && method == http.MethodPost:
+		return m.handleCreateSchedule(body)
+	case path == schedulesPath:
+		return m.handleListSchedules()
+	case strings.HasPrefix(path, schedulesPath+"/"):
+		return m.handleSchedule(method, strings.TrimPrefix(path, schedulesPath+"/"))
+	case path == fundingSourcesPath:
+		return m.handleListFundingSources()
+	case path == topUpsPath && method == http.MethodPost:
+		return m.handleCreateTopUp(body)
+	case strings.HasPrefix(path, topUpsPath+"/"):
+		return m.handleGetTopUp(strings.TrimPrefix(path, topUpsPath+"/"))
+	case path == MockPaymentWebhookPath && method == http.MethodPost:
+		return m.handlePaymentWebhook(r.Header.Get(PaymentSignatureHeader), body)
+	case path == calendarsPath:
+		return m.handleListCalendars(query)
+	case strings.HasPrefix(path, calendarsPath+"/"):
+		return m.handleNodeCalendar(strings.TrimPrefix(path, calendarsPath+"/"), query)
+	case path == bookingsPath && method == http.MethodPost:
+		return m.handleCreateBooking(body)
+	case path == bookingsPath:
+		return m.handleListBookings()
+	case strings.HasPrefix(path, bookingsPath+"/"):
+		return m.handleBooking(method, strings.TrimPrefix(path, bookingsPath+"/"))
+	case path == "/api/v1/orchestrators":
+		return m.handleListOrchestrators()
+	case path == "/api/v1/nodes":
+		return m.handleListNodes(query)
+	case path == nodeRegisterPath && method == http.MethodPost:
+		return m.handleRegisterNode(body)
+	case strings.HasPrefix(path, "/api/v1/nodes/") && strings.HasSuffix(path, "/heartbeat") && method == http.MethodPost:
+		return m.handleHeartbeat(strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/nodes/"), "/heartbeat"))
+	case path == "/api/v1/node-agent/releases":
+		return m.handleReleases()
+	case strings.HasPrefix(path, "/api/v1/nodes/") && strings.HasSuffix(path, "/update-advisory"):
+		return m.handleUpdateAdvisory(strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/nodes/"), "/update-advisory"))
+	case strings.HasPrefix(path, "/api/v1/nodes/") && strings.HasSuffix(path, "/status") && method == http.MethodPut:
+		return m.handleSetNodeStatus(strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/nodes/"), "/status"), body)
+	case strings.HasPrefix(path, "/api/v1/nodes/") && strings.HasSuffix(path, "/decommission"):
+		return m.handleDecommission(method, strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/nodes/"), "/decommission"))
+	case strings.HasPrefix(path, "/api/v1/nodes/") && strings.HasSuffix(path, "/contribution"):
+		return m.handleNodeContribution(strings.TrimSuffix(strings.TrimPrefix(path, "/api/v1/nodes/"), "/contribution"))
+	case strings.HasPrefix(path, "/api/v1/nodes/"):
+		return m.handleGetNode(strings.TrimPrefix(path, "/api/v1/nodes/"))
+	case path == "/api/v1/providers":
+		return m.handleListProviders()
+	case path == "/api/v1/network/contribution":
+		return m.handleNetworkStats()
+	case path == networkHistoryPath:
+		return m.handleNetworkHistory(query)
+	case path == "/api/v1/network/capacity":
+		return m.handleCapacity()
+	case path == leaderboardPath:
+		return m.handleLeaderboard(query)
+	case path == "/api/v1/users/preferences":
+		return m.handlePreferences(method, body)
+	case path == "/api/v1/agent/status":
+		return m.handleAgentStatus()
+	case path == "/api/v1/agent/chat":
+		return m.handleAgentChat(body)
+	default:
+		return mockError(http.StatusNotFound, "Not found: "+path)
+	}
+}
+
+// mockStatusFilter returns the statuses a list is filtered to, or nil.
+func mockStatusFilter(query url.Values) map[string]bool {
+	param := query.Get("status")
+	if param == "" {
+		return nil
+	}
+	statuses := make(map[string]bool)
+	for _, status := range strings.Split(param, ",") {
+		statuses[strings.TrimSpace(status)] = true
+	}
+	return statuses
+}
@@ -0,0 +1,123 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// networkHistoryPath is the network statistics over time.
+const networkHistoryPath = "/api/v1/network/contribution/history"
+
+// MaxNetworkHistoryPoints is the most intervals a network history request
+// may ask for.
+const MaxNetworkHistoryPoints = 1000
+
+// NetworkStatsPoint is the network at the end of one interval of a
+// NetworkStatsHistory.
+type NetworkStatsPoint struct {
+	// Start of the interval
+	Time        time.Time `json:"timestamp"`
+	TotalNodes  int       `json:"total_nodes"`
+	OnlineNodes int       `json:"online_nodes"`
+	LiveGFlops  float64   `json:"live_gflops"`
+	// Credits the network's nodes earned during the interval
+	CreditsEarned float64 `json:"credits_earned"`
+}
+
+// NetworkStatsHistory is the network over a window, one point per interval
+// of the requested resolution, oldest first.
+type NetworkStatsHistory struct {
+	Window     time.Duration       `json:"-"`
+	Resolution time.Duration       `json:"-"`
+	Points     []NetworkStatsPoint `json:"points"`
+}
+
+// NetworkGrowth is how much the network changed over a history, as a
+// fraction of where it started: 0.1 is 10% growth and -0.1 a 10% decline.
+// A measure that started at zero reports no change.
+type NetworkGrowth struct {
+	TotalNodes  float64
+	OnlineNodes float64
+	LiveGFlops  float64
+}
+
+// Growth compares the last point of the history with the first. It
+// reports false when there are fewer than two points to compare.
+func (h *NetworkStatsHistory) Growth() (NetworkGrowth, bool) {
+	if len(h.Points) < 2 {
+		return NetworkGrowth{}, false
+	}
+	first, last := h.Points[0], h.Points[len(h.Points)-1]
+	return NetworkGrowth{
+		TotalNodes:  relativeChange(float64(first.TotalNodes), float64(last.TotalNodes)),
+		OnlineNodes: relativeChange(float64(first.OnlineNodes), float64(last.OnlineNodes)),
+		LiveGFlops:  relativeChange(first.LiveGFlops, last.LiveGFlops),
+	}, true
+}
+
+// CreditsEarned returns the credits the network earned over the history.
+func (h *NetworkStatsHistory) CreditsEarned() float64 {
+	var total float64
+	for _, p := range h.Points {
+		total += p.CreditsEarned
+	}
+	return total
+}
+
+// relativeChange returns the change from one value to another as a
+// fraction of the first, or 0 when the first is not positive.
+func relativeChange(from, to float64) float64 {
+	if from <= 0 {
+		return 0
+	}
+	return (to - from) / from
+}
+
+// GetNetworkStatsHistory returns the network's size, compute power and
+// earnings over the window ending now, one point per resolution. It
+// answers whether the network is growing, where GetNetworkStats only
+// gives the current snapshot.
+//
+// Example:
+//
+//	// Daily network statistics over the last two weeks
+//	history, err := client.GetNetworkStatsHistory(ctx, 14*24*time.Hour, 24*time.Hour)
+//	if growth, ok := history.Growth(); ok && growth.LiveGFlops > 0 {
+//	    fmt.Println("compute power is up")
+//	}
+func (c *Client) GetNetworkStatsHistory(ctx context.Context, window, resolution time.Duration) (*NetworkStatsHistory, error) {
+	if err := validateNetworkStatsHistory(window, resolution); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"window":     {strconv.FormatInt(int64(window/time.Second), 10)},
+		"resolution": {strconv.FormatInt(int64(resolution/time.Second), 10)},
+	}
+
+	var history NetworkStatsHistory
+	if err := c.doRequest(ctx, http.MethodGet, networkHistoryPath+"?"+query.Encode(), nil, &history); err != nil {
+		return nil, err
+	}
+	history.Window = window
+	history.Resolution = resolution
+	return &history, nil
+}
+
+// validateNetworkStatsHistory checks the window and resolution of a
+// network history request.
+func validateNetworkStatsHistory(window, resolution time.Duration) error {
+	switch {
+	case resolution < time.Second:
+		return fmt.Errorf("resolution must be at least a second, got %s", resolution)
+	case window < resolution:
+		return fmt.Errorf("window %s is shorter than the resolution %s", window, resolution)
+	case window/resolution > MaxNetworkHistoryPoints:
+		return fmt.Errorf("window %s at resolution %s is more than %d points", window, resolution, MaxNetworkHistoryPoints)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandbox_NetworkStatsHistory(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	history, err := client.GetNetworkStatsHistory(ctx, 7*24*time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetNetworkStatsHistory() error = %v", err)
+	}
+	if history.Window != 7*24*time.Hour || len(history.Points) < 7 {
+		t.Fatalf("history = %+v", history)
+	}
+	stats, err := client.GetNetworkStats(ctx)
+	if err != nil {
+		t.Fatalf("GetNetworkStats() error = %v", err)
+	}
+	last := history.Points[len(history.Points)-1]
+	if last.OnlineNodes != stats.OnlineNodes || last.LiveGFlops != stats.LiveGFlops {
+		t.Errorf("last point = %+v, want the current network %+v", last, stats)
+	}
+	growth, ok := history.Growth()
+	if !ok || growth.LiveGFlops <= 0 {
+		t.Errorf("Growth() = %+v, %v; want the sandbox network growing", growth, ok)
+	}
+	if history.CreditsEarned() <= 0 {
+		t.Error("CreditsEarned() = 0")
+	}
+
+	for _, tt := range []struct {
+		window, resolution time.Duration
+	}{
+		{time.Hour, 0},
+		{time.Hour, 2 * time.Hour},
+		{2000 * time.Hour, time.Hour},
+	} {
+		if _, err := client.GetNetworkStatsHistory(ctx, tt.window, tt.resolution); err == nil {
+			t.Errorf("GetNetworkStatsHistory(%s, %s) expected error", tt.window, tt.resolution)
+		}
+	}
+}
+
+func TestNetworkStatsHistory_Growth(t *testing.T) {
+	history := &NetworkStatsHistory{Points: []NetworkStatsPoint{
+		{TotalNodes: 10, OnlineNodes: 8, LiveGFlops: 100, CreditsEarned: 2},
+		{TotalNodes: 12, OnlineNodes: 0, LiveGFlops: 150, CreditsEarned: 3},
+		{TotalNodes: 15, OnlineNodes: 6, LiveGFlops: 50, CreditsEarned: 1},
+	}}
+	growth, ok := history.Growth()
+	if !ok || growth.TotalNodes != 0.5 || growth.OnlineNodes != -0.25 || growth.LiveGFlops != -0.5 {
+		t.Errorf("Growth() = %+v, %v", growth, ok)
+	}
+	if got := history.CreditsEarned(); got != 6 {
+		t.Errorf("CreditsEarned() = %v, want 6", got)
+	}
+
+	history.Points = history.Points[:1]
+	if _, ok := history.Growth(); ok {
+		t.Error("Growth() = true for a single point")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{1, 2, 3, 4, 5, 6, 7, 8}); got != "▁▂▃▄▅▆▇█" {
+		t.Errorf("sparkline(rising) = %s", got)
+	}
+	if got := sparkline([]float64{3, 3, 3}); got != "▅▅▅" {
+		t.Errorf("sparkline(flat) = %s", got)
+	}
+	if got := sparkline(nil); got != "" {
+		t.Errorf("sparkline(nil) = %q", got)
+	}
+}
+
+func TestNetworkVerdict(t *testing.T) {
+	tests := []struct {
+		growth NetworkGrowth
+		want   string
+	}{
+		{NetworkGrowth{OnlineNodes: 0.002, LiveGFlops: -0.005}, "holding steady"},
+		{NetworkGrowth{OnlineNodes: 0.2, LiveGFlops: 0}, "growing"},
+		{NetworkGrowth{OnlineNodes: -0.1, LiveGFlops: -0.3}, "shrinking"},
+		{NetworkGrowth{OnlineNodes: -0.1, LiveGFlops: 0.3}, "fewer nodes are online, but compute power grew"},
+		{NetworkGrowth{OnlineNodes: 0.1, LiveGFlops: -0.3}, "more nodes are online, but compute power fell"},
+	}
+	for _, tt := range tests {
+		if got := networkVerdict(tt.growth); !strings.Contains(got, tt.want) {
+			t.Errorf("networkVerdict(%+v) = %s, want %q", tt.growth, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkStatsTool_Trend(t *testing.T) {
+	result := NewNetworkStatsTool(NewSandboxClient()).Execute(context.Background(), map[string]interface{}{"trend_days": 7.0})
+	if result.IsError {
+		t.Fatalf("Execute() error = %s", result.ForLLM)
+	}
+	for _, want := range []string{"Trend (last 7 days)", "Online nodes", "Credits/day", "The network is growing"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = NewNetworkStatsTool(NewSandboxClient()).Execute(context.Background(), map[string]interface{}{"trend_days": 0.0})
+	if !result.IsError {
+		t.Error("expected error for trend_days 0")
+	}
+}
+
+func TestNetworkStatsTool_TrendUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == networkHistoryPath {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(APIError{Code: 404, Message: "not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"network": map[string]interface{}{"total_nodes": 4, "online_nodes": 3},
+		})
+	}))
+	defer server.Close()
+
+	result := NewNetworkStatsTool(NewClient(server.URL, "test-token")).Execute(context.Background(), map[string]interface{}{"trend_days": 1.0})
+	if result.IsError {
+		t.Fatalf("Execute() error = %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Total Nodes:  4") || !strings.Contains(result.ForLLM, "does not record network history") {
+		t.Errorf("output = %s, want the snapshot and a note", result.ForLLM)
+	}
+}
@@ -0,0 +1,430 @@
+package deparrow
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const nodeRegisterPath = "/api/v1/nodes/register"
+
+// DefaultHeartbeatInterval is how often a NodeAgent reports to the network.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+const (
+	// nodeAgentBackoffMin and nodeAgentBackoffMax bound the delay before
+	// retrying a failed registration or heartbeat, which doubles after
+	// each failure in a row.
+	nodeAgentBackoffMin = time.Second
+	nodeAgentBackoffMax = 2 * time.Minute
+)
+
+// LocalResources are the resources of the machine a node runs on.
+type LocalResources struct {
+	Arch Architecture `json:"-"`
+	CPU  int          `json:"cpu"`
+	// Total memory, e.g. "16Gi"
+	Memory      string `json:"memory"`
+	MemoryBytes uint64 `json:"memory_bytes,omitempty"`
+	GPU         int    `json:"gpu"`
+	GPUModel    string `json:"gpu_model,omitempty"`
+	// Memory of each GPU, e.g. "24Gi"
+	VRAM      string `json:"vram,omitempty"`
+	VRAMBytes uint64 `json:"vram_bytes,omitempty"`
+}
+
+// NodeRegistration is what a node tells the network about itself when it
+// joins.
+type NodeRegistration struct {
+	NodeID       string            `json:"node_id"`
+	PublicKey    string            `json:"public_key"`
+	Arch         Architecture      `json:"arch"`
+	Resources    LocalResources    `json:"resources"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	AgentVersion string            `json:"agent_version,omitempty"`
+}
+
+// NodeRegistrationResult is the network's answer to a registration.
+type NodeRegistrationResult struct {
+	Status string `json:"status"`
+	NodeID string `json:"node_id"`
+	// Token the node authenticates with from now on, when the server issues one
+	Token string `json:"token,omitempty"`
+	// Orchestrators the node may connect to
+	Orchestrators []Orchestrator `json:"orchestrators,omitempty"`
+	// Credits earned per hour of contributed compute
+	CreditEarningRate float64 `json:"credit_earning_rate,omitempty"`
+}
+
+// NodeHeartbeat reports a node's live utilization.
+type NodeHeartbeat struct {
+	CPUPercent    float64 `json:"cpu_usage_percent"`
+	MemoryPercent float64 `json:"memory_usage_percent"`
+	GPUPercent    float64 `json:"gpu_usage_percent,omitempty"`
+	RunningJobs   int     `json:"running_jobs"`
+}
+
+// HeartbeatAck is the network's answer to a heartbeat.
+type HeartbeatAck struct {
+	Status        string    `json:"status"`
+	LastSeen      time.Time `json:"last_seen"`
+	CreditsEarned float64   `json:"credits_earned"`
+}
+
+// RegisterNode registers a node with the network, or registers it again
+// after it went away, which brings it back online. A retired node can't
+// register again.
+func (c *Client) RegisterNode(ctx context.Context, reg *NodeRegistration) (*NodeRegistrationResult, error) {
+	if reg.NodeID == "" {
+		return nil, fmt.Errorf("node ID is required")
+	}
+	var result NodeRegistrationResult
+	if err := c.doRequest(ctx, http.MethodPost, nodeRegisterPath, reg, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SendHeartbeat keeps a registered node alive and reports its
+// utilization. It fails with ErrNodeNotFound when the network has
+// forgotten the node, which then has to register again.
+func (c *Client) SendHeartbeat(ctx context.Context, nodeID string, hb *NodeHeartbeat) (*HeartbeatAck, error) {
+	var result HeartbeatAck
+	if err := c.doRequest(ctx, http.MethodPost, "/api/v1/nodes/"+url.PathEscape(nodeID)+"/heartbeat", hb, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// NodeAgentConfig configures a NodeAgent.
+type NodeAgentConfig struct {
+	NodeID       string
+	PublicKey    string
+	Labels       map[string]string
+	AgentVersion string
+	// Time between heartbeats; zero uses DefaultHeartbeatInterval
+	HeartbeatInterval time.Duration
+	// Resources to register instead of the detected ones
+	Resources *LocalResources
+	// GPUDetectors find the GPUs to register; nil uses DefaultGPUDetectors
+	GPUDetectors []GPUDetector
+	// Sample measures the live utilization each heartbeat reports; nil
+	// reads it from /proc
+	Sample func(ctx context.Context) (*NodeHeartbeat, error)
+}
+
+// NodeAgentStatus is where a NodeAgent stands.
+type NodeAgentStatus struct {
+	Registered bool
+	// Times the node registered, the first time included
+	Registrations int
+	LastHeartbeat time.Time
+	// Credits the node has earned, as of the last heartbeat
+	CreditsEarned float64
+	// Registrations and heartbeats that failed in a row
+	ConsecutiveFailures int
+	LastError           error
+}
+
+// NodeAgent keeps a node joined to the network: it registers the machine's
+// resources, then sends a heartbeat with its live utilization every
+// interval. When the network forgets the node, or heartbeats fail because
+// the network can't be reached, the node registers again once it can.
+type NodeAgent struct {
+	client *Client
+	config NodeAgentConfig
+
+	mu     sync.Mutex
+	status NodeAgentStatus
+}
+
+// NewNodeAgent creates an agent for the node described by config.
+func NewNodeAgent(client *Client, config NodeAgentConfig) (*NodeAgent, error) {
+	if config.NodeID == "" {
+		return nil, fmt.Errorf("node ID is required")
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if config.Sample == nil {
+		config.Sample = newProcSampler().sample
+	}
+	return &NodeAgent{client: client, config: config}, nil
+}
+
+// Status returns where the agent stands.
+func (a *NodeAgent) Status() NodeAgentStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
+
+// Run registers the node and keeps it alive until ctx is done, when it
+// returns nil. Failures are retried with exponential backoff, except those
+// retrying can't fix: a retired node or credentials the network refuses.
+//
+// Example:
+//
+//	agent, err := deparrow.NewNodeAgent(client, deparrow.NodeAgentConfig{
+//	    NodeID:    "node-home-01",
+//	    PublicKey: publicKey,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	return agent.Run(ctx)
+func (a *NodeAgent) Run(ctx context.Context) error {
+	backoff := nodeAgentBackoffMin
+	for {
+		registered := a.Status().Registered
+		var err error
+		if registered {
+			err = a.heartbeat(ctx)
+		} else {
+			err = a.register(ctx)
+		}
+
+		wait := a.config.HeartbeatInterval
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err == nil:
+			backoff = nodeAgentBackoffMin
+			if !registered {
+				// Report utilization straight after joining
+				continue
+			}
+		case nodeAgentFatal(err):
+			return err
+		case registered && errors.Is(err, ErrNodeNotFound):
+			// The network forgot the node; register again at once
+			continue
+		default:
+			wait = backoff
+			backoff = min(backoff*2, nodeAgentBackoffMax)
+		}
+		if sleepContext(ctx, wait) != nil {
+			return nil
+		}
+	}
+}
+
+// register registers the node with the resources it has now.
+func (a *NodeAgent) register(ctx context.Context) error {
+	resources := a.config.Resources
+	if resources == nil {
+		detected := DetectResources(ctx, a.config.GPUDetectors...)
+		resources = &detected
+	}
+	_, err := a.client.RegisterNode(ctx, &NodeRegistration{
+		NodeID:       a.config.NodeID,
+		PublicKey:    a.config.PublicKey,
+		Arch:         resources.Arch,
+		Resources:    *resources,
+		Labels:       a.config.Labels,
+		AgentVersion: a.config.AgentVersion,
+	})
+
+	if ctx.Err() != nil {
+		// Stopped mid-way; nothing went wrong
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.fail(fmt.Errorf("register: %w", err))
+		return err
+	}
+	a.status.Registered = true
+	a.status.Registrations++
+	a.status.ConsecutiveFailures = 0
+	a.status.LastError = nil
+	return nil
+}
+
+// heartbeat samples the node's utilization and reports it. A heartbeat
+// that fails leaves the node to register again, since the network may
+// have marked it offline meanwhile.
+func (a *NodeAgent) heartbeat(ctx context.Context) error {
+	hb, err := a.config.Sample(ctx)
+	if err != nil {
+		// Better a heartbeat without utilization than none
+		hb = &NodeHeartbeat{}
+	}
+	ack, err := a.client.SendHeartbeat(ctx, a.config.NodeID, hb)
+	if ctx.Err() != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.status.Registered = false
+		a.fail(fmt.Errorf("heartbeat: %w", err))
+		return err
+	}
+	a.status.LastHeartbeat = time.Now()
+	a.status.CreditsEarned = ack.CreditsEarned
+	a.status.ConsecutiveFailures = 0
+	a.status.LastError = nil
+	return nil
+}
+
+// fail records a failed registration or heartbeat. The caller holds a.mu.
+func (a *NodeAgent) fail(err error) {
+	a.status.ConsecutiveFailures++
+	a.status.LastError = err
+}
+
+// nodeAgentFatal reports whether err is a refusal retrying won't change:
+// the node was retired, or the network does not accept the credentials.
+func nodeAgentFatal(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusConflict || apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusForbidden
+}
+
+// DetectResources reports the CPU cores, memory, GPUs and architecture of
+// this machine. Memory is only known on Linux. GPUs are found by the
+// detectors, DefaultGPUDetectors when none are given; a machine whose
+// tools fail is reported without GPUs.
+func DetectResources(ctx context.Context, detectors ...GPUDetector) LocalResources {
+	resources := LocalResources{Arch: localArch(), CPU: runtime.NumCPU()}
+	if meminfo, err := readMeminfo(); err == nil {
+		resources.MemoryBytes = meminfo["MemTotal"]
+		resources.Memory = formatMemory(resources.MemoryBytes)
+	}
+
+	if len(detectors) == 0 {
+		detectors = DefaultGPUDetectors()
+	}
+	ctx, cancel := context.WithTimeout(ctx, gpuDetectTimeout)
+	defer cancel()
+	gpus, _ := DetectGPUs(ctx, detectors...)
+	applyGPUs(&resources, gpus)
+	return resources
+}
+
+// localArch returns the network's name for this machine's architecture.
+func localArch() Architecture {
+	switch runtime.GOARCH {
+	case "amd64":
+		return ArchX86_64
+	case "arm64":
+		return ArchARM64
+	default:
+		return Architecture(runtime.GOARCH)
+	}
+}
+
+// formatMemory renders bytes the way resource specs do, e.g. "16Gi".
+func formatMemory(bytes uint64) string {
+	if bytes >= 1<<30 {
+		return fmt.Sprintf("%dGi", bytes>>30)
+	}
+	return fmt.Sprintf("%dMi", bytes>>20)
+}
+
+// readMeminfo reads /proc/meminfo, in bytes.
+func readMeminfo() (map[string]uint64, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	meminfo := make(map[string]uint64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// e.g. "MemTotal:       16310068 kB"
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		meminfo[name] = n
+	}
+	return meminfo, nil
+}
+
+// procSampler measures utilization from /proc. CPU usage is the share of
+// busy time since the previous sample, so the first one reports none.
+type procSampler struct {
+	mu                sync.Mutex
+	lastBusy, lastAll uint64
+}
+
+func newProcSampler() *procSampler {
+	return &procSampler{}
+}
+
+// sample measures the CPU and memory in use.
+func (p *procSampler) sample(context.Context) (*NodeHeartbeat, error) {
+	hb := &NodeHeartbeat{}
+	meminfo, err := readMeminfo()
+	if err != nil {
+		return nil, err
+	}
+	if total := meminfo["MemTotal"]; total > 0 {
+		hb.MemoryPercent = float64(total-min(meminfo["MemAvailable"], total)) / float64(total) * 100
+	}
+
+	busy, all, err := readCPUTimes()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.lastAll > 0 && all > p.lastAll {
+		hb.CPUPercent = float64(busy-p.lastBusy) / float64(all-p.lastAll) * 100
+	}
+	p.lastBusy, p.lastAll = busy, all
+	return hb, nil
+}
+
+// readCPUTimes returns the busy and total CPU time from /proc/stat.
+func readCPUTimes() (busy, all uint64, err error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	// e.g. "cpu  user nice system idle iowait irq softirq steal guest guest_nice";
+	// guest time is already part of user and nice
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("unexpected /proc/stat: %q", line)
+	}
+	for i, field := range fields[1:min(len(fields), 9)] {
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unexpected /proc/stat: %q", line)
+		}
+		all += n
+		// idle and iowait are not busy
+		if i != 3 && i != 4 {
+			busy += n
+		}
+	}
+	return busy, all, nil
+}
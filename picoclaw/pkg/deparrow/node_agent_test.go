@@ -0,0 +1,202 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nodeAgentServer is a fake network that records what a node agent sends.
+// respond picks the status of the nth heartbeat (counting from 1), with
+// 200 answering normally.
+type nodeAgentServer struct {
+	mu            sync.Mutex
+	registrations []NodeRegistration
+	heartbeats    []NodeHeartbeat
+	respond       func(n int) int
+}
+
+func (s *nodeAgentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.URL.Path == nodeRegisterPath:
+		var reg NodeRegistration
+		json.NewDecoder(r.Body).Decode(&reg)
+		s.registrations = append(s.registrations, reg)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "registered", "node_id": reg.NodeID, "token": "node-token"})
+	case r.URL.Path == "/api/v1/nodes/node-home-01/heartbeat":
+		var hb NodeHeartbeat
+		json.NewDecoder(r.Body).Decode(&hb)
+		s.heartbeats = append(s.heartbeats, hb)
+		if status := s.respond(len(s.heartbeats)); status != http.StatusOK {
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"error": http.StatusText(status)})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "credits_earned": float64(len(s.heartbeats))})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *nodeAgentServer) counts() (registrations, heartbeats int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.registrations), len(s.heartbeats)
+}
+
+// runNodeAgent runs an agent against fake until done reports true or
+// the agent stops, and returns the agent and what Run returned.
+func runNodeAgent(t *testing.T, fake *nodeAgentServer, done func(registrations, heartbeats int) bool) (*NodeAgent, error) {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	agent, err := NewNodeAgent(NewClient(server.URL, "test-token"), NodeAgentConfig{
+		NodeID:            "node-home-01",
+		PublicKey:         "node-key",
+		Labels:            map[string]string{"region": "eu-west"},
+		HeartbeatInterval: 5 * time.Millisecond,
+		Resources:         &LocalResources{Arch: ArchARM64, CPU: 8, Memory: "16Gi"},
+		Sample: func(context.Context) (*NodeHeartbeat, error) {
+			return &NodeHeartbeat{CPUPercent: 42, MemoryPercent: 50, RunningJobs: 1}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewNodeAgent() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() { result <- agent.Run(ctx) }()
+	for {
+		select {
+		case err := <-result:
+			return agent, err
+		case <-time.After(5 * time.Millisecond):
+		}
+		if done(fake.counts()) {
+			cancel()
+			return agent, <-result
+		}
+	}
+}
+
+func TestNodeAgent_RegistersAndHeartbeats(t *testing.T) {
+	fake := &nodeAgentServer{respond: func(int) int { return http.StatusOK }}
+	agent, err := runNodeAgent(t, fake, func(_, heartbeats int) bool { return heartbeats >= 3 })
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fake.registrations) != 1 {
+		t.Fatalf("registrations = %d, want 1", len(fake.registrations))
+	}
+	reg := fake.registrations[0]
+	if reg.Arch != ArchARM64 || reg.Resources.CPU != 8 || reg.Resources.Memory != "16Gi" || reg.PublicKey != "node-key" || reg.Labels["region"] != "eu-west" {
+		t.Errorf("registration = %+v", reg)
+	}
+	if hb := fake.heartbeats[0]; hb.CPUPercent != 42 || hb.RunningJobs != 1 {
+		t.Errorf("heartbeat = %+v", hb)
+	}
+
+	status := agent.Status()
+	if !status.Registered || status.Registrations != 1 || status.CreditsEarned < 3 || status.LastHeartbeat.IsZero() || status.LastError != nil {
+		t.Errorf("Status() = %+v", status)
+	}
+}
+
+func TestNodeAgent_ReregistersWhenForgotten(t *testing.T) {
+	fake := &nodeAgentServer{respond: func(n int) int {
+		if n == 2 {
+			return http.StatusNotFound
+		}
+		return http.StatusOK
+	}}
+	agent, err := runNodeAgent(t, fake, func(_, heartbeats int) bool { return heartbeats >= 3 })
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if status := agent.Status(); status.Registrations != 2 || !status.Registered {
+		t.Errorf("Status() = %+v, want a second registration", status)
+	}
+}
+
+func TestNodeAgent_ReregistersAfterDisconnect(t *testing.T) {
+	fake := &nodeAgentServer{respond: func(n int) int {
+		if n == 2 {
+			return http.StatusBadGateway
+		}
+		return http.StatusOK
+	}}
+	agent, err := runNodeAgent(t, fake, func(registrations, _ int) bool { return registrations >= 2 })
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	status := agent.Status()
+	if status.Registrations != 2 || status.ConsecutiveFailures != 0 {
+		t.Errorf("Status() = %+v", status)
+	}
+}
+
+func TestNodeAgent_StopsWhenRetired(t *testing.T) {
+	fake := &nodeAgentServer{respond: func(int) int { return http.StatusConflict }}
+	agent, err := runNodeAgent(t, fake, func(int, int) bool { return false })
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusConflict {
+		t.Fatalf("Run() error = %v, want the conflict", err)
+	}
+	if status := agent.Status(); status.Registered || status.ConsecutiveFailures != 1 || !strings.Contains(status.LastError.Error(), "heartbeat") {
+		t.Errorf("Status() = %+v", status)
+	}
+}
+
+func TestSandbox_NodeRegistration(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	if _, err := client.SendHeartbeat(ctx, "node-new", &NodeHeartbeat{}); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("SendHeartbeat() before registering = %v", err)
+	}
+	result, err := client.RegisterNode(ctx, &NodeRegistration{
+		NodeID: "node-new", Arch: ArchX86_64, Resources: LocalResources{CPU: 4, Memory: "8Gi"}, AgentVersion: "1.6.0",
+	})
+	if err != nil || result.Status != string(NodeStatusOnline) || result.Token == "" || len(result.Orchestrators) == 0 {
+		t.Fatalf("RegisterNode() = %+v, %v", result, err)
+	}
+	ack, err := client.SendHeartbeat(ctx, "node-new", &NodeHeartbeat{CPUPercent: 10})
+	if err != nil || ack.CreditsEarned <= 0 {
+		t.Fatalf("SendHeartbeat() = %+v, %v", ack, err)
+	}
+	node, err := client.GetNode(ctx, "node-new")
+	if err != nil || node.Status != NodeStatusOnline || node.Resources.CPU != 4 {
+		t.Errorf("GetNode() = %+v, %v", node, err)
+	}
+
+	if _, err := client.RegisterNode(ctx, &NodeRegistration{}); err == nil {
+		t.Error("registering without a node ID should fail")
+	}
+}
+
+func TestDetectResources(t *testing.T) {
+	resources := DetectResources(context.Background(), fakeGPUDetector{gpus: []GPUDevice{{Model: "A100", VRAMBytes: 80 << 30}}})
+	if resources.CPU < 1 || resources.Arch == "" || resources.GPU != 1 || resources.VRAM != "80Gi" {
+		t.Errorf("DetectResources() = %+v", resources)
+	}
+	if got := formatMemory(16 << 30); got != "16Gi" {
+		t.Errorf("formatMemory(16Gi) = %q", got)
+	}
+	if got := formatMemory(512 << 20); got != "512Mi" {
+		t.Errorf("formatMemory(512Mi) = %q", got)
+	}
+}
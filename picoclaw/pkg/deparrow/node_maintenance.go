@@ -0,0 +1,97 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrDrainTimeout is returned by DrainNode when the node still runs jobs
+// once WaitOptions.MaxWait has passed.
+var ErrDrainTimeout = errors.New("timed out waiting for node to drain")
+
+// nodeStatusPath is the endpoint of a node's operator-set status.
+func nodeStatusPath(nodeID string) string {
+	return "/api/v1/nodes/" + url.PathEscape(nodeID) + "/status"
+}
+
+// SetNodeStatus takes a node out of service or puts it back. Operators may
+// set NodeStatusMaintenance to take a node offline for work,
+// NodeStatusDraining to stop it taking new jobs while its running ones
+// finish, and NodeStatusOnline to uncordon it. The network sets the other
+// statuses itself.
+func (c *Client) SetNodeStatus(ctx context.Context, nodeID string, status NodeStatus) (*Node, error) {
+	if nodeID == "" {
+		return nil, fmt.Errorf("node ID is required")
+	}
+	switch status {
+	case NodeStatusOnline, NodeStatusMaintenance, NodeStatusDraining:
+	default:
+		return nil, fmt.Errorf("node status %q can't be set; use %s, %s or %s",
+			status, NodeStatusOnline, NodeStatusMaintenance, NodeStatusDraining)
+	}
+
+	var result Node
+	req := map[string]NodeStatus{"status": status}
+	if err := c.doRequest(ctx, http.MethodPut, nodeStatusPath(nodeID), req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DrainNode stops a node taking new jobs and waits for the ones it runs
+// to finish, polling the node the way WaitForJobCompletion polls a job.
+// The drained node is left draining; set it to NodeStatusMaintenance to
+// work on it, or NodeStatusOnline to return it to service.
+//
+// When MaxWait runs out the last node seen is returned along with an
+// error wrapping ErrDrainTimeout; the node keeps draining.
+//
+// Example:
+//
+//	node, err := client.DrainNode(ctx, "node-home-01", deparrow.WaitOptions{
+//	    MaxWait: time.Hour,
+//	})
+func (c *Client) DrainNode(ctx context.Context, nodeID string, opts WaitOptions) (*Node, error) {
+	node, err := c.SetNodeStatus(ctx, nodeID, NodeStatusDraining)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+
+	var deadline <-chan time.Time
+	if opts.MaxWait > 0 {
+		timer := time.NewTimer(opts.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	interval := opts.PollInterval
+	for node.RunningJobs > 0 {
+		select {
+		case <-time.After(interval):
+		case <-deadline:
+			return node, fmt.Errorf("%w after %s: %d jobs still running", ErrDrainTimeout, opts.MaxWait, node.RunningJobs)
+		case <-ctx.Done():
+			return node, ctx.Err()
+		}
+		interval = min(time.Duration(float64(interval)*opts.Backoff), opts.MaxPollInterval)
+
+		latest, err := c.GetNode(ctx, nodeID)
+		switch {
+		case err == nil:
+			if latest.Status != NodeStatusDraining {
+				return latest, fmt.Errorf("node %s stopped draining: it is %s", nodeID, latest.Status)
+			}
+			node = latest
+		case ctx.Err() != nil:
+			return node, ctx.Err()
+		case !retryableError(err):
+			return node, err
+		}
+	}
+	return node, nil
+}
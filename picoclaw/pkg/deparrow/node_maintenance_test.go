@@ -0,0 +1,136 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sandboxRunningJobs starts jobs on the sandbox's first node, which runs them all.
+func sandboxRunningJobs(t *testing.T, client *Client, n int) string {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		job, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+		if err != nil {
+			t.Fatalf("SubmitJob() error = %v", err)
+		}
+		// The first read starts it
+		if _, err := client.GetJob(ctx, job.ID); err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+	}
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		t.Fatalf("ListNodes() error = %v", err)
+	}
+	return nodes[0].ID
+}
+
+func TestClient_SetNodeStatus(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+	nodeID := sandboxRunningJobs(t, client, 2)
+
+	node, err := client.SetNodeStatus(ctx, nodeID, NodeStatusMaintenance)
+	if err != nil || node.Status != NodeStatusMaintenance || node.RunningJobs != 2 {
+		t.Fatalf("SetNodeStatus(maintenance) = %+v, %v", node, err)
+	}
+	if node, _ := client.GetNode(ctx, nodeID); node.Status != NodeStatusMaintenance {
+		t.Errorf("Status = %s, want maintenance", node.Status)
+	}
+	if node, err := client.SetNodeStatus(ctx, nodeID, NodeStatusOnline); err != nil || node.Status != NodeStatusOnline {
+		t.Errorf("SetNodeStatus(online) = %+v, %v", node, err)
+	}
+
+	if _, err := client.SetNodeStatus(ctx, nodeID, NodeStatusRetired); err == nil {
+		t.Error("only the network retires nodes")
+	}
+	if _, err := client.SetNodeStatus(ctx, "", NodeStatusOnline); err == nil {
+		t.Error("a node ID is required")
+	}
+	if _, err := client.SetNodeStatus(ctx, "node-missing", NodeStatusOnline); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("SetNodeStatus(missing) error = %v, want ErrNodeNotFound", err)
+	}
+
+	// A decommissioning is cancelled through its own endpoint
+	if _, err := client.RequestDecommission(ctx, nodeID); err != nil {
+		t.Fatalf("RequestDecommission() error = %v", err)
+	}
+	if _, err := client.SetNodeStatus(ctx, nodeID, NodeStatusOnline); err == nil {
+		t.Error("a decommissioning node should not be uncordoned")
+	}
+}
+
+func TestClient_DrainNode(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+	nodeID := sandboxRunningJobs(t, client, 3)
+	fast := WaitOptions{PollInterval: time.Millisecond, Backoff: 1}
+
+	node, err := client.DrainNode(ctx, nodeID, fast)
+	if err != nil {
+		t.Fatalf("DrainNode() error = %v", err)
+	}
+	if node.Status != NodeStatusDraining || node.RunningJobs != 0 {
+		t.Errorf("DrainNode() = %+v", node)
+	}
+
+	// An idle node is drained straight away
+	nodes, _ := client.ListNodes(ctx)
+	if node, err := client.DrainNode(ctx, nodes[1].ID, WaitOptions{MaxWait: time.Millisecond}); err != nil || node.RunningJobs != 0 {
+		t.Errorf("DrainNode(idle) = %+v, %v", node, err)
+	}
+}
+
+func TestClient_DrainNode_Timeout(t *testing.T) {
+	client := NewSandboxClient()
+	nodeID := sandboxRunningJobs(t, client, 5)
+
+	fast := WaitOptions{PollInterval: 20 * time.Millisecond, Backoff: 1, MaxWait: 30 * time.Millisecond}
+	node, err := client.DrainNode(context.Background(), nodeID, fast)
+	if !errors.Is(err, ErrDrainTimeout) {
+		t.Fatalf("DrainNode() error = %v, want ErrDrainTimeout", err)
+	}
+	if node == nil || node.RunningJobs == 0 {
+		t.Errorf("DrainNode() = %+v, want the last node seen with jobs left", node)
+	}
+}
+
+func TestNodeTool_DrainAndUncordon(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewNodeTool(client)
+	ctx := context.Background()
+	nodeID := sandboxRunningJobs(t, client, 1)
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "drain", "node_id": nodeID})
+	if result.IsError || !strings.Contains(result.ForLLM, "1 running jobs are finishing") {
+		t.Fatalf("drain = %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"node_id": nodeID})
+	if !strings.Contains(result.ForLLM, "0 jobs left to finish") {
+		t.Errorf("inspecting a draining node should show its jobs:\n%s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"status": "draining"})
+	if !strings.Contains(result.ForLLM, nodeID[:16]) {
+		t.Errorf("draining filter should list the node:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "uncordon", "node_id": nodeID})
+	if result.IsError || !strings.Contains(result.ForLLM, "back online") {
+		t.Fatalf("uncordon = %s", result.ForLLM)
+	}
+	if node, _ := client.GetNode(ctx, nodeID); node.Status != NodeStatusOnline {
+		t.Errorf("Status = %s, want online", node.Status)
+	}
+
+	for _, action := range []string{"drain", "uncordon"} {
+		if result := tool.Execute(ctx, map[string]interface{}{"action": action}); !result.IsError {
+			t.Errorf("%s without a node_id should fail", action)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package deparrow
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/sipeed/picoclaw/pkg/tools"
@@ -38,6 +39,16 @@ Use this tool to:
 - Discover available compute resources
 - Check node health and status
 - View node contributions and tiers
+
+To retire one of the user's nodes for good, use action 'decommission'
+with its node_id. The first call only shows what will happen; call again
+with confirm=true once the user has agreed. Follow the progress with
+'decommission_status', or stop it while the node is still draining with
+'cancel_decommission'.
+
+To take one of the user's nodes out of service for a while, use action
+'drain' with its node_id: it stops taking new jobs and lets its running
+ones finish. Use 'uncordon' to return it to service.
 `
 }
 
@@ -46,13 +57,24 @@ func (t *NodeTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"inspect", "drain", "uncordon", "decommission", "decommission_status", "cancel_decommission"},
+				"description": "Action to perform: 'inspect' to list or view nodes, 'drain' to stop a node taking new jobs, 'uncordon' to return it to service, 'decommission' to retire a node, 'decommission_status' to follow it, 'cancel_decommission' to stop it",
+				"default":     "inspect",
+			},
 			"node_id": map[string]interface{}{
 				"type":        "string",
-				"description": "Specific node ID to inspect (optional, lists all if not provided)",
+				"description": "Specific node ID to inspect (optional, lists all if not provided); required for the other actions",
+			},
+			"confirm": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Set to true, after the user agreed, to start decommissioning (for decommission)",
+				"default":     false,
 			},
 			"status": map[string]interface{}{
 				"type":        "string",
-				"enum":        []string{"online", "offline", "maintenance", "all"},
+				"enum":        []string{"online", "offline", "maintenance", "draining", "retired", "all"},
 				"description": "Filter by node status",
 				"default":     "online",
 			},
@@ -67,8 +89,27 @@ func (t *NodeTool) Parameters() map[string]interface{} {
 
 // Execute runs the node tool.
 func (t *NodeTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	nodeID, _ := args["node_id"].(string)
+	action, _ := args["action"].(string)
+	switch action {
+	case "", "inspect":
+	case "drain":
+		return t.drain(ctx, nodeID)
+	case "uncordon":
+		return t.uncordon(ctx, nodeID)
+	case "decommission":
+		confirm, _ := args["confirm"].(bool)
+		return t.decommission(ctx, nodeID, confirm)
+	case "decommission_status":
+		return t.decommissionStatus(ctx, nodeID)
+	case "cancel_decommission":
+		return t.cancelDecommission(ctx, nodeID)
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
+	}
+
 	// Check if specific node is requested
-	if nodeID, ok := args["node_id"].(string); ok && nodeID != "" {
+	if nodeID != "" {
 		return t.getNode(ctx, nodeID, args)
 	}
 
@@ -104,6 +145,14 @@ func (t *NodeTool) listNodes(ctx context.Context, args map[string]interface{}) *
 			if node.Status == NodeStatusMaintenance {
 				filtered = append(filtered, node)
 			}
+		case "draining":
+			if node.Status == NodeStatusDraining {
+				filtered = append(filtered, node)
+			}
+		case "retired":
+			if node.Status == NodeStatusRetired {
+				filtered = append(filtered, node)
+			}
 		default:
 			filtered = append(filtered, node)
 		}
@@ -119,6 +168,7 @@ func (t *NodeTool) listNodes(ctx context.Context, args map[string]interface{}) *
 
 	includeContribution, _ := args["contribution"].(bool)
 
+	var partial partialResult
 	for i, node := range filtered {
 		result.WriteString(fmt.Sprintf("%d. Node: %s\n", i+1, node.ID[:16]+"..."))
 		result.WriteString(fmt.Sprintf("   Status: %s | Arch: %s\n", node.Status, node.Arch))
@@ -139,7 +189,9 @@ func (t *NodeTool) listNodes(ctx context.Context, args map[string]interface{}) *
 
 		if includeContribution {
 			contrib, err := t.client.GetNodeContribution(ctx, node.ID)
-			if err == nil {
+			if err != nil {
+				partial.miss("contribution of "+node.ID, err)
+			} else {
 				result.WriteString(fmt.Sprintf("   Contribution: %.1f%% of network | Rank #%d\n",
 					contrib.NetworkPercent, contrib.Rank))
 			}
@@ -148,20 +200,23 @@ func (t *NodeTool) listNodes(ctx context.Context, args map[string]interface{}) *
 		result.WriteString("\n")
 	}
 
-	return tools.UserResult(result.String())
+	return partial.result(result.String())
 }
 
 // getNode retrieves details for a specific node.
 func (t *NodeTool) getNode(ctx context.Context, nodeID string, args map[string]interface{}) *tools.ToolResult {
 	node, err := t.client.GetNode(ctx, nodeID)
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to get node: %v", err))
+		return failureResult("get node", err)
 	}
 
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("🖥️  Node: %s\n", node.ID))
 	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 	result.WriteString(fmt.Sprintf("Status:     %s\n", node.Status))
+	if node.Status == NodeStatusDraining {
+		result.WriteString(fmt.Sprintf("Running:    %d jobs left to finish\n", node.RunningJobs))
+	}
 	result.WriteString(fmt.Sprintf("Arch:       %s\n", node.Arch))
 	result.WriteString(fmt.Sprintf("Last Seen:  %s\n", node.LastSeen.Format("2006-01-02 15:04:05")))
 
@@ -173,6 +228,9 @@ func (t *NodeTool) getNode(ctx context.Context, nodeID string, args map[string]i
 			if node.Resources.GPUModel != "" {
 				result.WriteString(fmt.Sprintf(" (%s)", node.Resources.GPUModel))
 			}
+			if node.Resources.VRAM != "" {
+				result.WriteString(fmt.Sprintf(", %s VRAM each", node.Resources.VRAM))
+			}
 			result.WriteString("\n")
 		}
 		if node.Resources.Memory != "" {
@@ -183,8 +241,11 @@ func (t *NodeTool) getNode(ctx context.Context, nodeID string, args map[string]i
 	result.WriteString(fmt.Sprintf("\n💰 Credits Earned: %.2f\n", node.CreditsEarned))
 
 	// Get contribution details
+	var partial partialResult
 	contrib, err := t.client.GetNodeContribution(ctx, nodeID)
-	if err == nil {
+	if err != nil {
+		partial.miss("contribution", err)
+	} else {
 		result.WriteString("\n📈 Contribution:\n")
 		result.WriteString(fmt.Sprintf("  CPU Hours:    %.1f\n", contrib.CPUUsageHours))
 		result.WriteString(fmt.Sprintf("  GPU Hours:    %.1f\n", contrib.GPUUsageHours))
@@ -201,7 +262,168 @@ func (t *NodeTool) getNode(ctx context.Context, nodeID string, args map[string]i
 		}
 	}
 
-	return tools.UserResult(result.String())
+	return partial.result(result.String())
+}
+
+// drain stops a node taking new jobs. It doesn't wait for the running
+// ones to finish; inspecting the node shows how many are left.
+func (t *NodeTool) drain(ctx context.Context, nodeID string) *tools.ToolResult {
+	if nodeID == "" {
+		return tools.ErrorResult("node_id is required to drain a node")
+	}
+
+	node, err := t.client.SetNodeStatus(ctx, nodeID, NodeStatusDraining)
+	if err != nil {
+		return failureResult("drain node", err)
+	}
+	if node.RunningJobs == 0 {
+		return tools.UserResult(fmt.Sprintf("✅ Node %s is drained: it takes no new jobs and runs none.\n💡 Use action 'uncordon' to return it to service.", nodeID))
+	}
+	return tools.UserResult(fmt.Sprintf("⏳ Node %s is draining: it takes no new jobs, and %d running jobs are finishing.\n💡 Inspect the node to follow them, or use action 'uncordon' to return it to service.", nodeID, node.RunningJobs))
+}
+
+// uncordon returns a drained or maintained node to service.
+func (t *NodeTool) uncordon(ctx context.Context, nodeID string) *tools.ToolResult {
+	if nodeID == "" {
+		return tools.ErrorResult("node_id is required to uncordon a node")
+	}
+
+	if _, err := t.client.SetNodeStatus(ctx, nodeID, NodeStatusOnline); err != nil {
+		return failureResult("uncordon node", err)
+	}
+	return tools.UserResult(fmt.Sprintf("✅ Node %s is back online and taking new jobs.", nodeID))
+}
+
+// decommission shows what retiring a node involves and, once confirmed,
+// starts it.
+func (t *NodeTool) decommission(ctx context.Context, nodeID string, confirm bool) *tools.ToolResult {
+	if nodeID == "" {
+		return tools.ErrorResult("node_id is required to decommission a node")
+	}
+
+	node, err := t.client.GetNode(ctx, nodeID)
+	if err != nil {
+		return failureResult("get node", err)
+	}
+	if node.Status == NodeStatusRetired {
+		return tools.ErrorResult(fmt.Sprintf("Node %s is already retired", nodeID))
+	}
+
+	if !confirm {
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("⚠️  Decommission Node %s?\n", node.ID))
+		result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+		result.WriteString(fmt.Sprintf("  Status:          %s\n", node.Status))
+		result.WriteString(fmt.Sprintf("  Credits Earned:  %.2f\n\n", node.CreditsEarned))
+		result.WriteString("Decommissioning will:\n")
+		result.WriteString("  1. Stop new jobs and move queued ones to other nodes;\n")
+		result.WriteString("     running jobs are allowed to finish\n")
+		result.WriteString("  2. Pay the node's unpaid earnings into your wallet\n")
+		result.WriteString("  3. Revoke the node's key, so it can't rejoin the network\n")
+		result.WriteString("  4. Mark the node retired\n\n")
+		result.WriteString("This cannot be undone once the node is drained.\n")
+		result.WriteString("💡 Ask the user to confirm, then call again with confirm=true.")
+		return tools.UserResult(result.String())
+	}
+
+	d, err := t.client.RequestDecommission(ctx, nodeID)
+	if err != nil {
+		return failureResult("decommission node", err)
+	}
+	return tools.UserResult(formatDecommission(d))
+}
+
+// decommissionStatus reports how far a node's decommissioning has got.
+func (t *NodeTool) decommissionStatus(ctx context.Context, nodeID string) *tools.ToolResult {
+	if nodeID == "" {
+		return tools.ErrorResult("node_id is required to check a decommissioning")
+	}
+
+	d, err := t.client.GetDecommission(ctx, nodeID)
+	if err != nil {
+		return failureResult("get decommission status", err)
+	}
+	return tools.UserResult(formatDecommission(d))
+}
+
+// cancelDecommission puts a draining node back online.
+func (t *NodeTool) cancelDecommission(ctx context.Context, nodeID string) *tools.ToolResult {
+	if nodeID == "" {
+		return tools.ErrorResult("node_id is required to cancel a decommissioning")
+	}
+
+	if err := t.client.CancelDecommission(ctx, nodeID); err != nil {
+		return failureResult("cancel decommission", err)
+	}
+	return tools.UserResult(fmt.Sprintf("✅ Decommissioning of node %s cancelled; the node is back online.", nodeID))
+}
+
+// decommissionSteps are the stages of a decommissioning, in order, with
+// how they are shown.
+var decommissionSteps = []struct {
+	stage DecommissionStage
+	label string
+}{
+	{DecommissionDraining, "Drain jobs"},
+	{DecommissionFinalizing, "Finalize earnings"},
+	{DecommissionRevokingKey, "Revoke node key"},
+	{DecommissionRetired, "Retire node"},
+}
+
+// formatDecommission renders the progress of a decommissioning.
+func formatDecommission(d *Decommission) string {
+	current := -1
+	for i, step := range decommissionSteps {
+		if step.stage == d.Stage {
+			current = i
+		}
+	}
+
+	var result strings.Builder
+	if d.Done() {
+		result.WriteString(fmt.Sprintf("🏁 Node %s Retired\n", d.NodeID))
+	} else {
+		result.WriteString(fmt.Sprintf("🛠️  Decommissioning Node %s\n", d.NodeID))
+	}
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	for i, step := range decommissionSteps {
+		icon := "⬜"
+		switch {
+		case i < current || d.Done():
+			icon = "✅"
+		case i == current:
+			icon = "⏳"
+		}
+		result.WriteString(fmt.Sprintf("  %s %s", icon, step.label))
+		switch {
+		case step.stage == DecommissionDraining:
+			result.WriteString(fmt.Sprintf(" — %d rescheduled", d.JobsRescheduled))
+			if d.JobsRemaining > 0 {
+				result.WriteString(fmt.Sprintf(", %d still running", d.JobsRemaining))
+			}
+		case step.stage == DecommissionFinalizing && i < current:
+			result.WriteString(fmt.Sprintf(" — %.2f credits paid out", d.FinalEarnings))
+		case step.stage == DecommissionRevokingKey && d.KeyRevokedAt != nil:
+			result.WriteString(fmt.Sprintf(" — %s", d.KeyRevokedAt.Format("2006-01-02 15:04")))
+		}
+		result.WriteString("\n")
+	}
+
+	result.WriteString(fmt.Sprintf("\n  Requested: %s\n", d.RequestedAt.Format("2006-01-02 15:04")))
+	if d.CompletedAt != nil {
+		result.WriteString(fmt.Sprintf("  Completed: %s\n", d.CompletedAt.Format("2006-01-02 15:04")))
+	}
+	switch d.Stage {
+	case DecommissionDraining:
+		result.WriteString("\n💡 Check progress with action 'decommission_status', or stop it with\n   'cancel_decommission' while the node is still draining.")
+	case DecommissionRetired:
+		result.WriteString("\n💡 The node can be shut down and uninstalled.")
+	default:
+		result.WriteString("\n💡 Check progress with action 'decommission_status'.")
+	}
+
+	return result.String()
 }
 
 // NodeContributionTool provides detailed contribution statistics.
@@ -248,12 +470,15 @@ func (t *NodeContributionTool) Execute(ctx context.Context, args map[string]inte
 
 	contrib, err := t.client.GetNodeContribution(ctx, nodeID)
 	if err != nil {
-		return tools.ErrorResult(fmt.Sprintf("Failed to get contribution: %v", err))
+		return failureResult("get contribution", err)
 	}
 
+	// The report stands without the node's details, minus its tier and credits
+	var partial partialResult
 	node, err := t.client.GetNode(ctx, nodeID)
 	if err != nil {
-		node = &Node{ID: nodeID}
+		partial.miss("tier and credits of the node", err)
+		node = nil
 	}
 
 	var result strings.Builder
@@ -274,34 +499,35 @@ func (t *NodeContributionTool) Execute(ctx context.Context, args map[string]inte
 	// Ranking
 	result.WriteString("\n🏆 Ranking:\n")
 	result.WriteString(fmt.Sprintf("  Position:      #%d of %d nodes\n", contrib.Rank, contrib.TotalNodes))
-	result.WriteString(fmt.Sprintf("  Tier:          %s ", node.Tier))
-
-	tierIcons := map[ContributionTier]string{
-		TierBronze:    "🥉",
-		TierSilver:    "🥈",
-		TierGold:      "🥇",
-		TierDiamond:   "💎",
-		TierLegendary: "🔥",
-	}
-	if icon, ok := tierIcons[node.Tier]; ok {
-		result.WriteString(icon)
-	}
-	result.WriteString("\n")
-
-	// Credits
-	result.WriteString(fmt.Sprintf("\n💰 Credits Earned: %.2f\n", node.CreditsEarned))
+	if node != nil {
+		result.WriteString(fmt.Sprintf("  Tier:          %s ", node.Tier))
+		tierIcons := map[ContributionTier]string{
+			TierBronze:    "🥉",
+			TierSilver:    "🥈",
+			TierGold:      "🥇",
+			TierDiamond:   "💎",
+			TierLegendary: "🔥",
+		}
+		if icon, ok := tierIcons[node.Tier]; ok {
+			result.WriteString(icon)
+		}
+		result.WriteString("\n")
 
-	// Progress to next tier
-	result.WriteString("\n📈 Progress:\n")
-	totalHours := contrib.CPUUsageHours + contrib.GPUUsageHours
-	nextTier, hoursNeeded := getNextTier(node.Tier, totalHours)
-	if hoursNeeded > 0 {
-		result.WriteString(fmt.Sprintf("  Next Tier:     %s (%.0f more hours needed)\n", nextTier, hoursNeeded))
-	} else {
-		result.WriteString("  Max Tier:      Legendary! 🎉\n")
+		// Credits
+		result.WriteString(fmt.Sprintf("\n💰 Credits Earned: %.2f\n", node.CreditsEarned))
+
+		// Progress to next tier
+		result.WriteString("\n📈 Progress:\n")
+		totalHours := contrib.CPUUsageHours + contrib.GPUUsageHours
+		nextTier, hoursNeeded := getNextTier(node.Tier, totalHours)
+		if hoursNeeded > 0 {
+			result.WriteString(fmt.Sprintf("  Next Tier:     %s (%.0f more hours needed)\n", nextTier, hoursNeeded))
+		} else {
+			result.WriteString("  Max Tier:      Legendary! 🎉\n")
+		}
 	}
 
-	return tools.UserResult(result.String())
+	return partial.result(result.String())
 }
 
 // getNextTier calculates the next tier and hours needed.
@@ -342,26 +568,86 @@ func (t *OrchestratorTool) Name() string {
 
 // Description returns the tool description.
 func (t *OrchestratorTool) Description() string {
-	return "List orchestrator nodes in the DEparrow network."
+	return `List orchestrator nodes in the DEparrow network.
+
+Orchestrators accept jobs and distribute them to compute nodes. Shows each
+orchestrator's region, current load, version and supported features, least
+loaded first. Pass an orchestrator ID to 'deparrow_submit_job' to route a job
+through it.`
 }
 
 // Parameters returns the JSON schema for tool parameters.
 func (t *OrchestratorTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
-		"type":       "object",
-		"properties": map[string]interface{}{},
+		"type": "object",
+		"properties": map[string]interface{}{
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show orchestrators in this region (e.g., 'us-east')",
+			},
+			"feature": map[string]interface{}{
+				"type":        "string",
+				"description": "Only show orchestrators supporting this feature (e.g., 'gpu', 'wasm')",
+			},
+		},
 	}
 }
 
 // Execute runs the orchestrator tool.
 func (t *OrchestratorTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
-	// Note: This would call a real orchestrator list endpoint
-	// For now, return a placeholder
-	return tools.UserResult(`🎛️  DEparrow Orchestrators
+	orchestrators, err := t.client.ListOrchestrators(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to list orchestrators: %v", err))
+	}
 
-Active orchestrators coordinate job distribution across the network.
+	region, _ := args["region"].(string)
+	feature, _ := args["feature"].(string)
 
-Use 'deparrow_network' to see network statistics including orchestrator count.`)
+	var filtered []Orchestrator
+	for _, o := range orchestrators {
+		if region != "" && o.Region != region {
+			continue
+		}
+		if feature != "" && !o.Supports(feature) {
+			continue
+		}
+		filtered = append(filtered, o)
+	}
+
+	if len(filtered) == 0 {
+		return tools.UserResult("No orchestrators match the given filters.")
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Load < filtered[j].Load
+	})
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🎛️  DEparrow Orchestrators (%d)\n", len(filtered)))
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	for _, o := range filtered {
+		icon := "🟢"
+		if o.Status != NodeStatusOnline {
+			icon = "🔴"
+		}
+		result.WriteString(fmt.Sprintf("%s %s", icon, o.ID))
+		if o.Region != "" {
+			result.WriteString(fmt.Sprintf(" (%s)", o.Region))
+		}
+		result.WriteString("\n")
+		result.WriteString(fmt.Sprintf("   Status: %s | Load: %.0f%% | Nodes: %d\n", o.Status, o.Load*100, o.NodeCount))
+		if o.Version != "" {
+			result.WriteString(fmt.Sprintf("   Version: %s\n", o.Version))
+		}
+		if len(o.Features) > 0 {
+			result.WriteString(fmt.Sprintf("   Features: %s\n", strings.Join(o.Features, ", ")))
+		}
+		result.WriteString("\n")
+	}
+
+	result.WriteString("💡 Pass orchestrator='<id>' to 'deparrow_submit_job' to route a job through a specific orchestrator.")
+	return tools.UserResult(result.String())
 }
 
 // Ensure tools implement the Tool interface
@@ -437,17 +437,38 @@ func TestOrchestratorTool_Name(t *testing.T) {
 }
 
 func TestOrchestratorTool_Execute(t *testing.T) {
-	client := NewClient("http://localhost:8080", "test-token")
+	client := NewSandboxClient()
 	tool := NewOrchestratorTool(client)
 	ctx := context.Background()
 
+	// Load the US orchestrator so it sorts last
+	if _, err := client.SubmitJobTo(ctx, &JobSpec{Image: "alpine"}, "orch-us-east"); err != nil {
+		t.Fatalf("SubmitJobTo() error = %v", err)
+	}
+
 	result := tool.Execute(ctx, nil)
 
 	if result.IsError {
 		t.Errorf("Execute() returned error: %s", result.ForLLM)
 	}
-	if !strings.Contains(result.ForLLM, "Orchestrator") {
-		t.Errorf("Result should mention orchestrators: %s", result.ForLLM)
+	if !strings.Contains(result.ForLLM, "Orchestrators (3)") {
+		t.Errorf("Result should list the sandbox orchestrators: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Load: 5%") {
+		t.Errorf("Result should show live load: %s", result.ForLLM)
+	}
+	if strings.Index(result.ForLLM, "orch-us-east") < strings.Index(result.ForLLM, "orch-eu-west") {
+		t.Errorf("Least loaded orchestrators should be listed first: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"feature": "wasm", "region": "ap-south"})
+	if !strings.Contains(result.ForLLM, "Orchestrators (1)") || !strings.Contains(result.ForLLM, "orch-ap-south") {
+		t.Errorf("Filtered result should only list orch-ap-south: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"region": "mars"})
+	if !strings.Contains(result.ForLLM, "No orchestrators") {
+		t.Errorf("Result should report no matches: %s", result.ForLLM)
 	}
 }
 
@@ -0,0 +1,122 @@
+package deparrow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultOutputLimit is the most bytes of a job's stdout or stderr the
+// tools put in front of the agent.
+const DefaultOutputLimit = 8 << 10
+
+// WithOutputLimit caps the bytes of a job's stdout or stderr the tools
+// show. Longer output is cut down to its beginning and end, and the full
+// stream is saved as an artifact. A limit of zero or less shows everything.
+func WithOutputLimit(bytes int) ClientOption {
+	return func(c *Client) {
+		c.outputLimit = bytes
+	}
+}
+
+// WithArtifactDir sets the directory the full output of truncated streams
+// is saved in. It defaults to deparrow-artifacts in the temporary directory.
+func WithArtifactDir(dir string) ClientOption {
+	return func(c *Client) {
+		c.artifactDir = dir
+	}
+}
+
+// CapturedOutput is a job's stdout or stderr cut down to the output limit.
+type CapturedOutput struct {
+	// Name of the stream, e.g. "stdout"
+	Name string
+	// The output to show: all of it, or its beginning and end
+	Text string
+	// Size of the full stream in bytes
+	TotalBytes int
+	// Bytes of the stream in Text
+	ShownBytes int
+	// Where the full stream was saved when it was truncated
+	ArtifactPath string
+	// Why the full stream could not be saved
+	SaveErr error
+}
+
+// Truncated reports whether part of the stream was left out.
+func (o CapturedOutput) Truncated() bool {
+	return o.ShownBytes < o.TotalBytes
+}
+
+// Note describes what was left out and where to find it, or is empty
+// when nothing was.
+func (o CapturedOutput) Note() string {
+	if !o.Truncated() {
+		return ""
+	}
+	note := fmt.Sprintf("[%s truncated: showing %d of %d bytes", o.Name, o.ShownBytes, o.TotalBytes)
+	if o.ArtifactPath != "" {
+		return note + "; full output saved to " + o.ArtifactPath + "]"
+	}
+	return note + fmt.Sprintf("; the full output could not be saved: %v]", o.SaveErr)
+}
+
+// String returns the output followed by the truncation note, if any.
+func (o CapturedOutput) String() string {
+	if note := o.Note(); note != "" {
+		return strings.TrimRight(o.Text, "\n") + "\n" + note + "\n"
+	}
+	return o.Text
+}
+
+// CaptureOutput cuts a job's stream down to the client's output limit,
+// keeping its beginning and end, and saves the full stream to the
+// artifact directory when anything was left out.
+func (c *Client) CaptureOutput(jobID, name, output string) CapturedOutput {
+	captured := CapturedOutput{Name: name, Text: output, TotalBytes: len(output), ShownBytes: len(output)}
+	limit := c.outputLimit
+	if limit <= 0 || len(output) <= limit {
+		return captured
+	}
+
+	head := runeBoundary(output, limit/2)
+	tail := runeBoundary(output, len(output)-(limit-head))
+	captured.ShownBytes = head + len(output) - tail
+	captured.Text = output[:head] + fmt.Sprintf("\n… %d bytes omitted …\n", tail-head) + output[tail:]
+
+	dir := filepath.Join(c.artifactDir, artifactName(jobID))
+	path := filepath.Join(dir, artifactName(name)+".log")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		captured.SaveErr = err
+	} else if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+		captured.SaveErr = err
+	} else {
+		captured.ArtifactPath = path
+	}
+	return captured
+}
+
+// runeBoundary moves i back to the start of the UTF-8 character it falls in.
+func runeBoundary(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// artifactName makes an ID safe to use as a file name.
+func artifactName(id string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, id)
+	if strings.Trim(name, ".") == "" {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,78 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestClient_CaptureOutput(t *testing.T) {
+	dir := t.TempDir()
+	client := NewClient("http://localhost:8080", "test-token", WithOutputLimit(100), WithArtifactDir(dir))
+
+	short := client.CaptureOutput("job-1", LogStreamStdout, "hello\n")
+	if short.Truncated() || short.String() != "hello\n" || short.ArtifactPath != "" {
+		t.Errorf("short output = %+v", short)
+	}
+
+	full := "BEGIN" + strings.Repeat("é", 500) + "END"
+	out := client.CaptureOutput("job/../1", LogStreamStdout, full)
+	if !out.Truncated() || out.TotalBytes != len(full) || out.ShownBytes > 100 {
+		t.Fatalf("captured = %+v", out)
+	}
+	if !strings.HasPrefix(out.Text, "BEGIN") || !strings.HasSuffix(out.Text, "END") || !utf8.ValidString(out.Text) {
+		t.Errorf("Text = %q, want the valid beginning and end", out.Text)
+	}
+	if want := filepath.Join(dir, "job_.._1", "stdout.log"); out.ArtifactPath != want {
+		t.Errorf("ArtifactPath = %q, want %q", out.ArtifactPath, want)
+	}
+	if saved, err := os.ReadFile(out.ArtifactPath); err != nil || string(saved) != full {
+		t.Errorf("artifact holds %d bytes, %v; want the full stream", len(saved), err)
+	}
+	note := out.Note()
+	if !strings.Contains(note, "stdout truncated") || !strings.Contains(note, "of 1008 bytes") || !strings.Contains(note, out.ArtifactPath) {
+		t.Errorf("Note() = %q", note)
+	}
+
+	// The stream is still shown when it can't be saved
+	blocked := filepath.Join(dir, "file")
+	os.WriteFile(blocked, nil, 0o644)
+	client = NewClient("http://localhost:8080", "test-token", WithOutputLimit(100), WithArtifactDir(blocked))
+	out = client.CaptureOutput("job-1", LogStreamStderr, full)
+	if out.ArtifactPath != "" || out.SaveErr == nil || !strings.Contains(out.Note(), "could not be saved") {
+		t.Errorf("captured = %+v, note %q", out, out.Note())
+	}
+
+	unlimited := NewClient("http://localhost:8080", "test-token", WithOutputLimit(0))
+	if out := unlimited.CaptureOutput("job-1", LogStreamStdout, full); out.Truncated() || out.Text != full {
+		t.Error("a zero limit should show the whole stream")
+	}
+}
+
+func TestJobStatusTool_TruncatesOutput(t *testing.T) {
+	stdout := strings.Repeat("line of output\n", 10000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Job{ID: "job-1", Status: JobStatusCompleted, Results: &JobResults{Stdout: stdout}})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithArtifactDir(t.TempDir()))
+	result := NewJobStatusTool(client).Execute(context.Background(), map[string]interface{}{"job_id": "job-1"})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if len(result.ForLLM) > DefaultOutputLimit+1024 {
+		t.Errorf("ForLLM is %d bytes, want it near the %d byte limit", len(result.ForLLM), DefaultOutputLimit)
+	}
+	if !strings.Contains(result.ForLLM, "showing 8192 of 150000 bytes") || !strings.Contains(result.ForLLM, "stdout.log") {
+		t.Errorf("ForLLM is missing the truncation note:\n%s", result.ForLLM[len(result.ForLLM)-300:])
+	}
+}
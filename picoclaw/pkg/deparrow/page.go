@@ -0,0 +1,318 @@
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the page size used when PageOptions.Limit is zero.
+const DefaultPageSize = 100
+
+// PageOptions selects one page of a list. Servers that hand out cursors
+// are paged by cursor, others by offset.
+type PageOptions struct {
+	// Limit is the maximum number of items on the page; 0 uses DefaultPageSize
+	Limit int
+	// Cursor continues the list from the page that returned it
+	Cursor string
+	// Offset skips this many items; ignored when Cursor is set
+	Offset int
+}
+
+// encode adds the paging parameters to query.
+func (o PageOptions) encode(query url.Values) {
+	limit := o.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	if o.Cursor != "" {
+		query.Set("cursor", o.Cursor)
+	} else if o.Offset > 0 {
+		query.Set("offset", strconv.Itoa(o.Offset))
+	}
+}
+
+// pageState is the paging information a list response reports.
+type pageState struct {
+	NextCursor string
+	HasMore    bool
+	// Total matching items, or -1 when the server does not report it
+	Total int
+	// Items on the page
+	Count int
+}
+
+// next returns the options for the page after the one fetched with o,
+// reporting false on the last page. A server that reports neither a next
+// cursor nor has_more is taken to have returned the whole list.
+func (o PageOptions) next(state pageState) (PageOptions, bool) {
+	switch {
+	case state.NextCursor != "" && state.NextCursor != o.Cursor:
+		return PageOptions{Limit: o.Limit, Cursor: state.NextCursor}, true
+	case state.NextCursor == "" && o.Cursor == "" && state.HasMore && state.Count > 0:
+		return PageOptions{Limit: o.Limit, Offset: o.Offset + state.Count}, true
+	}
+	return PageOptions{}, false
+}
+
+// JobPageOptions selects a page of jobs.
+type JobPageOptions struct {
+	PageOptions
+	// Status keeps only jobs in one of these statuses; empty keeps all
+	Status []JobStatus
+	// Sort order; empty keeps the server's default order
+	SortBy    JobSortField
+	Ascending bool
+}
+
+// query encodes the options as list parameters.
+func (o JobPageOptions) query() url.Values {
+	query := url.Values{}
+	o.encode(query)
+	if len(o.Status) > 0 {
+		statuses := make([]string, len(o.Status))
+		for i, status := range o.Status {
+			statuses[i] = string(status)
+		}
+		query.Set("status", strings.Join(statuses, ","))
+	}
+	if o.SortBy != "" {
+		query.Set("sort", string(o.SortBy))
+		query.Set("order", "desc")
+		if o.Ascending {
+			query.Set("order", "asc")
+		}
+	}
+	return query
+}
+
+// JobPage is one page of the job list.
+type JobPage struct {
+	Jobs []Job
+	// Jobs per status over the whole list, when the server reports them
+	Counts map[JobStatus]int
+	// Total matching jobs, or -1 when the server does not report it
+	Total int
+
+	opts    JobPageOptions
+	next    PageOptions
+	hasNext bool
+}
+
+// Next returns the options for the page after this one, or false on the
+// last page.
+func (p *JobPage) Next() (JobPageOptions, bool) {
+	opts := p.opts
+	opts.PageOptions = p.next
+	return opts, p.hasNext
+}
+
+// ListJobsPage fetches one page of the jobs matching opts.
+//
+// Example:
+//
+//	page, err := client.ListJobsPage(ctx, deparrow.JobPageOptions{
+//	    PageOptions: deparrow.PageOptions{Limit: 50},
+//	    Status:      []deparrow.JobStatus{deparrow.JobStatusFailed},
+//	})
+func (c *Client) ListJobsPage(ctx context.Context, opts JobPageOptions) (*JobPage, error) {
+	if opts.SortBy != "" && !opts.SortBy.Valid() {
+		return nil, fmt.Errorf("unsupported job sort field: %s", opts.SortBy)
+	}
+
+	page := &JobPage{opts: opts}
+	others := map[string]interface{}{"counts": &page.Counts}
+	state, err := c.streamPage(ctx, "/api/v1/jobs", "jobs", opts.query(), others, func(dec *json.Decoder) error {
+		var job Job
+		if err := dec.Decode(&job); err != nil {
+			return &malformedResponseError{err}
+		}
+		page.Jobs = append(page.Jobs, job)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = state.Total
+	page.next, page.hasNext = opts.PageOptions.next(state)
+	return page, nil
+}
+
+// AllJobs iterates over every job matching opts, fetching the following
+// pages as the loop reaches them. An error ends the iteration.
+//
+// Example:
+//
+//	for job, err := range client.AllJobs(ctx, deparrow.JobPageOptions{}) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(job.ID)
+//	}
+func (c *Client) AllJobs(ctx context.Context, opts JobPageOptions) iter.Seq2[Job, error] {
+	return func(yield func(Job, error) bool) {
+		for {
+			page, err := c.ListJobsPage(ctx, opts)
+			if err != nil {
+				yield(Job{}, err)
+				return
+			}
+			for _, job := range page.Jobs {
+				if !yield(job, nil) {
+					return
+				}
+			}
+			next, ok := page.Next()
+			if !ok {
+				return
+			}
+			opts = next
+		}
+	}
+}
+
+// NodePageOptions selects a page of nodes.
+type NodePageOptions struct {
+	PageOptions
+	// Status keeps only nodes in one of these statuses; empty keeps all
+	Status []NodeStatus
+}
+
+// query encodes the options as list parameters.
+func (o NodePageOptions) query() url.Values {
+	query := url.Values{}
+	o.encode(query)
+	if len(o.Status) > 0 {
+		statuses := make([]string, len(o.Status))
+		for i, status := range o.Status {
+			statuses[i] = string(status)
+		}
+		query.Set("status", strings.Join(statuses, ","))
+	}
+	return query
+}
+
+// NodePage is one page of the node list.
+type NodePage struct {
+	Nodes []Node
+	// Total matching nodes, or -1 when the server does not report it
+	Total int
+
+	opts    NodePageOptions
+	next    PageOptions
+	hasNext bool
+}
+
+// Next returns the options for the page after this one, or false on the
+// last page.
+func (p *NodePage) Next() (NodePageOptions, bool) {
+	opts := p.opts
+	opts.PageOptions = p.next
+	return opts, p.hasNext
+}
+
+// ListNodesPage fetches one page of the nodes matching opts.
+func (c *Client) ListNodesPage(ctx context.Context, opts NodePageOptions) (*NodePage, error) {
+	page := &NodePage{opts: opts}
+	state, err := c.streamPage(ctx, "/api/v1/nodes", "nodes", opts.query(), nil, func(dec *json.Decoder) error {
+		var node Node
+		if err := dec.Decode(&node); err != nil {
+			return &malformedResponseError{err}
+		}
+		page.Nodes = append(page.Nodes, node)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = state.Total
+	page.next, page.hasNext = opts.PageOptions.next(state)
+	return page, nil
+}
+
+// AllNodes iterates over every node matching opts, fetching the following
+// pages as the loop reaches them. An error ends the iteration.
+func (c *Client) AllNodes(ctx context.Context, opts NodePageOptions) iter.Seq2[Node, error] {
+	return func(yield func(Node, error) bool) {
+		for {
+			page, err := c.ListNodesPage(ctx, opts)
+			if err != nil {
+				yield(Node{}, err)
+				return
+			}
+			for _, node := range page.Nodes {
+				if !yield(node, nil) {
+					return
+				}
+			}
+			next, ok := page.Next()
+			if !ok {
+				return
+			}
+			opts = next
+		}
+	}
+}
+
+// streamPage streams one page of the list at path, like streamList, and
+// returns the paging information reported alongside it.
+func (c *Client) streamPage(
+	ctx context.Context, path, field string, query url.Values,
+	others map[string]interface{}, each func(*json.Decoder) error,
+) (pageState, error) {
+	var (
+		state pageState
+		total *int
+	)
+	fields := map[string]interface{}{
+		"next_cursor": &state.NextCursor,
+		"has_more":    &state.HasMore,
+		"total":       &total,
+	}
+	for key, target := range others {
+		fields[key] = target
+	}
+
+	err := c.streamList(ctx, path+"?"+query.Encode(), field, fields, func(dec *json.Decoder) error {
+		state.Count++
+		return each(dec)
+	})
+	if err != nil {
+		return pageState{}, err
+	}
+
+	state.Total = -1
+	if total != nil {
+		state.Total = *total
+	}
+	return state, nil
+}
+
+// eachPage streams every page of the list at path, starting from opts.
+// query holds the list's filters and is updated with each page's position.
+func (c *Client) eachPage(
+	ctx context.Context, path, field string, query url.Values, opts PageOptions, each func(*json.Decoder) error,
+) error {
+	for {
+		query.Del("cursor")
+		query.Del("offset")
+		opts.encode(query)
+		state, err := c.streamPage(ctx, path, field, query, nil, each)
+		if err != nil {
+			return err
+		}
+		next, ok := opts.next(state)
+		if !ok {
+			return nil
+		}
+		opts = next
+	}
+}
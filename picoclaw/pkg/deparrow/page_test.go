@@ -0,0 +1,160 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// offsetPagedServer serves n jobs paged by limit and offset, reporting
+// has_more but no cursor.
+func offsetPagedServer(t *testing.T, n int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := min(offset+limit, n)
+
+		jobs := make([]Job, 0, end-offset)
+		for i := offset; i < end; i++ {
+			jobs = append(jobs, Job{ID: fmt.Sprintf("job-%03d", i), Status: JobStatusCompleted})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jobs": jobs, "has_more": end < n})
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func TestListJobs_PagesByOffset(t *testing.T) {
+	server, queries := offsetPagedServer(t, 250)
+	client := NewClient(server.URL, "test-token")
+
+	jobs, err := client.ListJobs(context.Background())
+	if err != nil {
+		t.Fatalf("ListJobs() error = %v", err)
+	}
+	if len(jobs) != 250 || jobs[249].ID != "job-249" {
+		t.Fatalf("ListJobs() returned %d jobs, want 250", len(jobs))
+	}
+	want := []string{"limit=100", "limit=100&offset=100", "limit=100&offset=200"}
+	if fmt.Sprint(*queries) != fmt.Sprint(want) {
+		t.Errorf("queries = %v, want %v", *queries, want)
+	}
+}
+
+func TestListJobsPage_UnpagedServer(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jobs": []Job{{ID: "job-1"}, {ID: "job-2"}}})
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "test-token")
+
+	page, err := client.ListJobsPage(context.Background(), JobPageOptions{PageOptions: PageOptions{Limit: 2}})
+	if err != nil {
+		t.Fatalf("ListJobsPage() error = %v", err)
+	}
+	if _, ok := page.Next(); ok {
+		t.Error("a server without paging fields returns the whole list")
+	}
+	if page.Total != -1 {
+		t.Errorf("Total = %d, want -1 when not reported", page.Total)
+	}
+
+	calls.Store(0)
+	var ids []string
+	for job, err := range client.AllJobs(context.Background(), JobPageOptions{}) {
+		if err != nil {
+			t.Fatalf("AllJobs() error = %v", err)
+		}
+		ids = append(ids, job.ID)
+	}
+	if len(ids) != 2 || calls.Load() != 1 {
+		t.Errorf("AllJobs() = %v in %d calls, want 2 jobs in 1 call", ids, calls.Load())
+	}
+}
+
+func TestSandbox_JobPagesAndFilters(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if _, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"}); err != nil {
+			t.Fatalf("SubmitJob() error = %v", err)
+		}
+	}
+	if _, err := client.CancelJob(ctx, "sandbox-job-0003"); err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+
+	page, err := client.ListJobsPage(ctx, JobPageOptions{PageOptions: PageOptions{Limit: 2}})
+	if err != nil {
+		t.Fatalf("ListJobsPage() error = %v", err)
+	}
+	next, ok := page.Next()
+	if len(page.Jobs) != 2 || page.Total != 5 || !ok || next.Cursor == "" {
+		t.Fatalf("page = %d jobs of %d, next = %+v, %v; want 2 of 5 and a cursor", len(page.Jobs), page.Total, next, ok)
+	}
+
+	var ids []string
+	for job, err := range client.AllJobs(ctx, JobPageOptions{PageOptions: PageOptions{Limit: 2}}) {
+		if err != nil {
+			t.Fatalf("AllJobs() error = %v", err)
+		}
+		ids = append(ids, job.ID)
+	}
+	if len(ids) != 5 || ids[4] != "sandbox-job-0005" {
+		t.Errorf("AllJobs() = %v, want all 5 jobs in order", ids)
+	}
+
+	page, err = client.ListJobsPage(ctx, JobPageOptions{Status: []JobStatus{JobStatusCancelled}})
+	if err != nil {
+		t.Fatalf("ListJobsPage() error = %v", err)
+	}
+	if len(page.Jobs) != 1 || page.Jobs[0].ID != "sandbox-job-0003" {
+		t.Errorf("cancelled jobs = %+v, want sandbox-job-0003", page.Jobs)
+	}
+}
+
+func TestSandbox_AllNodesStopsEarly(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	all, err := client.ListNodes(ctx)
+	if err != nil || len(all) < 2 {
+		t.Fatalf("ListNodes() = %d nodes, %v; want at least 2", len(all), err)
+	}
+
+	var seen int
+	for _, err := range client.AllNodes(ctx, NodePageOptions{PageOptions: PageOptions{Limit: 1}}) {
+		if err != nil {
+			t.Fatalf("AllNodes() error = %v", err)
+		}
+		seen++
+		if seen == 2 {
+			break
+		}
+	}
+	if seen != 2 {
+		t.Errorf("seen = %d, want the loop to stop after 2 nodes", seen)
+	}
+
+	page, err := client.ListNodesPage(ctx, NodePageOptions{Status: []NodeStatus{NodeStatusOnline}})
+	if err != nil {
+		t.Fatalf("ListNodesPage() error = %v", err)
+	}
+	for _, node := range page.Nodes {
+		if node.Status != NodeStatusOnline {
+			t.Errorf("node %s is %s, want only online nodes", node.ID, node.Status)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package deparrow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// ErrPartialResult is matched by a *PartialResultError.
+var ErrPartialResult = errors.New("partial result")
+
+// MissingData is a part of a tool result that could not be fetched.
+type MissingData struct {
+	// What is missing, e.g. "contribution of node-1"
+	What string
+	Err  error
+}
+
+// PartialResultError lists what a tool result is missing. Tools that show
+// what they could fetch carry it in the result's Err while IsError stays
+// false, so callers can tell a partial answer from a complete one.
+type PartialResultError struct {
+	Missing []MissingData
+}
+
+// Error implements the error interface.
+func (e *PartialResultError) Error() string {
+	return "partial result, missing " + describeMissing(e.Missing)
+}
+
+// describeMissing lists missing parts with their errors.
+func describeMissing(missing []MissingData) string {
+	parts := make([]string, len(missing))
+	for i, m := range missing {
+		parts[i] = fmt.Sprintf("%s: %v", m.What, m.Err)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Is makes errors.Is(err, ErrPartialResult) match.
+func (e *PartialResultError) Is(target error) bool {
+	return target == ErrPartialResult
+}
+
+// IsPartialResult reports whether a tool result shows only part of what
+// was asked for.
+func IsPartialResult(result *tools.ToolResult) bool {
+	return result != nil && !result.IsError && errors.Is(result.Err, ErrPartialResult)
+}
+
+// partialResult collects the sub-calls of a tool that failed without
+// failing the tool, so its result can say what is missing and why.
+type partialResult struct {
+	missing []MissingData
+}
+
+// miss records that what could not be fetched.
+func (p *partialResult) miss(what string, err error) {
+	p.missing = append(p.missing, MissingData{What: what, Err: err})
+}
+
+// count returns how many parts are missing.
+func (p *partialResult) count() int {
+	return len(p.missing)
+}
+
+// result returns content as the tool's result. When parts are missing it
+// lists them after the content and marks the result partial.
+func (p *partialResult) result(content string) *tools.ToolResult {
+	if len(p.missing) == 0 {
+		return tools.UserResult(content)
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	b.WriteString("\n\n⚠️  Partial result, some data could not be fetched:\n")
+	retryable := false
+	for _, m := range p.missing {
+		b.WriteString(fmt.Sprintf("   ❌ %s: %s\n", m.What, missingReason(m.Err)))
+		retryable = retryable || retryableError(m.Err)
+	}
+	if retryable {
+		b.WriteString("\n💡 Some of it failed for temporary reasons; try again shortly for the full picture.")
+	}
+
+	result := tools.UserResult(b.String())
+	result.Err = &PartialResultError{Missing: p.missing}
+	return result
+}
+
+// missingReason says briefly why a part is missing.
+func missingReason(err error) string {
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, ErrNodeNotFound):
+		return "node not found"
+	case endpointUnavailable(err):
+		return "not available on this DEparrow server"
+	case isServerError(err) && errors.As(err, &apiErr):
+		return fmt.Sprintf("the DEparrow API is failing (HTTP %d)", apiErr.Code)
+	case isTimeout(err):
+		return "the DEparrow API did not answer in time"
+	}
+	return err.Error()
+}
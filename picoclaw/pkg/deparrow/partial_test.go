@@ -0,0 +1,103 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPartialResult(t *testing.T) {
+	var complete partialResult
+	result := complete.result("all here\n")
+	if IsPartialResult(result) || result.Err != nil || result.ForLLM != "all here\n" {
+		t.Errorf("complete result = %+v", result)
+	}
+
+	var partial partialResult
+	partial.miss("contribution of node-1", &APIError{Code: http.StatusNotFound, Message: "Not found"})
+	partial.miss("node-2", &APIError{Code: http.StatusBadGateway, Message: "Bad gateway"})
+	result = partial.result("some here\n")
+	if result.IsError || !IsPartialResult(result) {
+		t.Fatalf("partial result = %+v", result)
+	}
+	for _, want := range []string{
+		"some here\n\n⚠️  Partial result",
+		"❌ contribution of node-1: not available on this DEparrow server",
+		"❌ node-2: the DEparrow API is failing (HTTP 502)",
+		"try again shortly",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result should contain %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	var partialErr *PartialResultError
+	if !errors.As(result.Err, &partialErr) || len(partialErr.Missing) != 2 || !errors.Is(result.Err, ErrPartialResult) {
+		t.Errorf("Err = %v", result.Err)
+	}
+	if IsPartialResult(failureResult("get node", result.Err)) {
+		t.Error("an error result is not partial")
+	}
+}
+
+func TestNodeTool_GetNode_PartialWithoutContribution(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/contribution") {
+			w.WriteHeader(http.StatusNotImplemented)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": "Not implemented"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"node_id":        "node-specific-abc123",
+			"status":         "online",
+			"credits_earned": 500.0,
+		})
+	}))
+	defer server.Close()
+
+	tool := NewNodeTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "inspect", "node_id": "node-specific-abc123"})
+	if result.IsError || !IsPartialResult(result) {
+		t.Fatalf("result should be partial, not an error:\n%s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Credits Earned: 500.00") || strings.Contains(result.ForLLM, "📈 Contribution") {
+		t.Errorf("result should show the node without its contribution:\n%s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "❌ contribution: not available on this DEparrow server") {
+		t.Errorf("result should say what is missing:\n%s", result.ForLLM)
+	}
+}
+
+func TestNodeContributionTool_PartialWithoutNode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/contribution") {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": "Node not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"node_id":      "node-specific-abc123",
+			"contribution": map[string]interface{}{"cpu_usage_hours": 100.0},
+			"ranking":      map[string]interface{}{"rank": 10, "total_nodes": 100},
+		})
+	}))
+	defer server.Close()
+
+	tool := NewNodeContributionTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{"node_id": "node-specific-abc123"})
+	if result.IsError || !IsPartialResult(result) {
+		t.Fatalf("result should be partial, not an error:\n%s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "CPU Time:      100.0 hours") || strings.Contains(result.ForLLM, "Tier:") {
+		t.Errorf("result should show the contribution without the node's tier:\n%s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "❌ tier and credits of the node: node not found") {
+		t.Errorf("result should say what is missing:\n%s", result.ForLLM)
+	}
+}
@@ -0,0 +1,144 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// fundingSourcesPath is the collection endpoint for funding sources.
+	fundingSourcesPath = "/api/v1/credits/funding-sources"
+
+	// topUpsPath is the collection endpoint for top-ups.
+	topUpsPath = "/api/v1/credits/top-ups"
+)
+
+// DefaultLowBalance is the balance below which the tools suggest adding
+// credits, unless the low balance notification sets another threshold.
+const DefaultLowBalance = 10.0
+
+// ListFundingSources retrieves the ways the authenticated user can buy
+// credits.
+func (c *Client) ListFundingSources(ctx context.Context) ([]FundingSource, error) {
+	var result struct {
+		Sources []FundingSource `json:"funding_sources"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, fundingSourcesPath, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Sources == nil {
+		result.Sources = []FundingSource{}
+	}
+	return result.Sources, nil
+}
+
+// InitiateTopUp starts buying amount credits from a funding source, the
+// default one when sourceID is empty. A top-up that needs a checkout is
+// returned pending with the CheckoutURL the user must open to pay; the
+// credits arrive once the payment is confirmed, which GetTopUp reports.
+func (c *Client) InitiateTopUp(ctx context.Context, amount float64, sourceID string) (*TopUp, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	req := map[string]interface{}{"amount": amount}
+	if sourceID != "" {
+		req["source_id"] = sourceID
+	}
+
+	var result TopUp
+	if err := c.doRequest(ctx, http.MethodPost, topUpsPath, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateTopUpIntent starts buying amount credits through a payment
+// provider's hosted checkout, e.g. "stripe", without a saved funding
+// source. The top-up is returned pending with the CheckoutURL the user
+// opens to pay; WaitForTopUp follows it until the provider confirms the
+// payment.
+func (c *Client) CreateTopUpIntent(ctx context.Context, amount float64, provider string) (*TopUp, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if provider == "" {
+		return nil, fmt.Errorf("payment provider is required")
+	}
+
+	req := map[string]interface{}{"amount": amount, "provider": provider}
+	var result TopUp
+	if err := c.doRequest(ctx, http.MethodPost, topUpsPath, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTopUp retrieves a top-up, e.g. to see whether its payment went through.
+func (c *Client) GetTopUp(ctx context.Context, topUpID string) (*TopUp, error) {
+	var result TopUp
+	if err := c.doRequest(ctx, http.MethodGet, topUpsPath+"/"+url.PathEscape(topUpID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// WaitForTopUp polls a top-up until its payment completes, fails or
+// expires, on the same schedule as WaitForJobCompletion. When MaxWait runs
+// out the top-up is returned still pending and without an error: the user
+// may simply not have paid yet.
+func (c *Client) WaitForTopUp(ctx context.Context, topUpID string, opts WaitOptions) (*TopUp, error) {
+	if topUpID == "" {
+		return nil, fmt.Errorf("top-up ID is required")
+	}
+	opts = opts.withDefaults()
+
+	var deadline <-chan time.Time
+	if opts.MaxWait > 0 {
+		timer := time.NewTimer(opts.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var last *TopUp
+	interval := opts.PollInterval
+	for {
+		topUp, err := c.GetTopUp(ctx, topUpID)
+		switch {
+		case err == nil:
+			if topUp.Status != TopUpPending {
+				return topUp, nil
+			}
+			last = topUp
+		case ctx.Err() != nil:
+			return last, ctx.Err()
+		case !retryableError(err):
+			return last, err
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-deadline:
+			if last == nil {
+				return nil, fmt.Errorf("top-up %s not reached after %s: %w", topUpID, opts.MaxWait, err)
+			}
+			return last, nil
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+		interval = min(time.Duration(float64(interval)*opts.Backoff), opts.MaxPollInterval)
+	}
+}
+
+// LowBalanceThreshold returns the balance below which the user should add
+// credits: the low balance notification threshold when one is set,
+// DefaultLowBalance otherwise.
+func (c *Client) LowBalanceThreshold() float64 {
+	if notify := c.Preferences().Notifications; notify.LowBalance && notify.LowBalanceThreshold > 0 {
+		return notify.LowBalanceThreshold
+	}
+	return DefaultLowBalance
+}
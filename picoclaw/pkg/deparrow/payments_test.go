@@ -0,0 +1,251 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_InitiateTopUp(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == topUpsPath:
+			json.NewDecoder(r.Body).Decode(&got)
+			json.NewEncoder(w).Encode(TopUp{
+				ID:          "topup-1",
+				SourceID:    "card-1",
+				Amount:      50,
+				Status:      TopUpPending,
+				CheckoutURL: "https://pay.example.com/topup-1",
+			})
+		case r.Method == http.MethodGet && r.URL.Path == topUpsPath+"/topup-1":
+			json.NewEncoder(w).Encode(TopUp{ID: "topup-1", Amount: 50, Status: TopUpCompleted})
+		case r.URL.Path == fundingSourcesPath:
+			json.NewEncoder(w).Encode(map[string]interface{}{"funding_sources": nil})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	ctx := context.Background()
+
+	if _, err := client.InitiateTopUp(ctx, 0, ""); err == nil {
+		t.Error("InitiateTopUp() with no amount should fail")
+	}
+
+	topUp, err := client.InitiateTopUp(ctx, 50, "card-1")
+	if err != nil {
+		t.Fatalf("InitiateTopUp() error = %v", err)
+	}
+	if got["amount"] != 50.0 || got["source_id"] != "card-1" {
+		t.Errorf("request = %v", got)
+	}
+	if topUp.Status != TopUpPending || topUp.CheckoutURL == "" {
+		t.Errorf("top-up = %+v, want pending with a checkout URL", topUp)
+	}
+
+	topUp, err = client.GetTopUp(ctx, "topup-1")
+	if err != nil {
+		t.Fatalf("GetTopUp() error = %v", err)
+	}
+	if topUp.Status != TopUpCompleted {
+		t.Errorf("Status = %s, want completed", topUp.Status)
+	}
+
+	sources, err := client.ListFundingSources(ctx)
+	if err != nil {
+		t.Fatalf("ListFundingSources() error = %v", err)
+	}
+	if sources == nil || len(sources) != 0 {
+		t.Errorf("ListFundingSources() = %v, want empty", sources)
+	}
+}
+
+func TestLowBalanceThreshold(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	if got := client.LowBalanceThreshold(); got != DefaultLowBalance {
+		t.Errorf("LowBalanceThreshold() = %v, want %v", got, DefaultLowBalance)
+	}
+
+	store, err := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), nil)
+	if err != nil {
+		t.Fatalf("NewPreferencesStore() error = %v", err)
+	}
+	client.SetPreferences(store)
+	err = store.Update(context.Background(), func(p *Preferences) {
+		p.Notifications.LowBalance = true
+		p.Notifications.LowBalanceThreshold = 75
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := client.LowBalanceThreshold(); got != 75 {
+		t.Errorf("LowBalanceThreshold() = %v, want 75", got)
+	}
+}
+
+func TestWalletTool_TopUp_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewWalletTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "funding_sources"})
+	if result.IsError {
+		t.Fatalf("funding_sources failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{"sandbox-card", "default", "checkout page"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("funding sources missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	// The default card pays straight away
+	result = tool.Execute(ctx, map[string]interface{}{"action": "top_up", "amount": 40.0})
+	if result.IsError {
+		t.Fatalf("top_up failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Credits Added") {
+		t.Errorf("card top-up should complete:\n%s", result.ForLLM)
+	}
+	wallet, err := client.GetWallet(ctx)
+	if err != nil {
+		t.Fatalf("GetWallet() error = %v", err)
+	}
+	if wallet.Balance != SandboxStartingBalance+40 {
+		t.Errorf("Balance = %.2f, want %.2f", wallet.Balance, SandboxStartingBalance+40)
+	}
+
+	// A bank transfer waits for the checkout
+	result = tool.Execute(ctx, map[string]interface{}{"action": "top_up", "amount": 200.0, "source_id": "sandbox-bank"})
+	if result.IsError {
+		t.Fatalf("top_up failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{"Awaiting Payment", "https://checkout.sandbox.deparrow.local/pay/sandbox-topup-0002", "top_up_status"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("pending top-up missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "top_up_status", "top_up_id": "sandbox-topup-0002"})
+	if !strings.Contains(result.ForLLM, "Credits Added") {
+		t.Errorf("sandbox checkout should complete once checked:\n%s", result.ForLLM)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "top_up"},
+		{"action": "top_up", "amount": 5.0},
+		{"action": "top_up", "amount": 50.0, "source_id": "missing"},
+		{"action": "top_up_status"},
+	} {
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("Execute(%v) should fail:\n%s", args, result.ForLLM)
+		}
+	}
+}
+
+func TestWalletTool_Balance_SuggestsTopUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"user_id":        "user-1",
+			"credit_balance": 4.0,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	client.SetUserID("user-1234567890abcdef")
+	result := NewWalletTool(client).Execute(context.Background(), map[string]interface{}{"action": "balance"})
+	if result.IsError {
+		t.Fatalf("balance failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Balance is low") || !strings.Contains(result.ForLLM, "'top_up'") {
+		t.Errorf("low balance should suggest a top-up:\n%s", result.ForLLM)
+	}
+}
+
+func TestWaitForTopUp(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := TopUpPending
+		switch polls.Add(1) {
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case 4:
+			status = TopUpCompleted
+		}
+		json.NewEncoder(w).Encode(TopUp{ID: "topup-1", Amount: 50, Status: status})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	topUp, err := client.WaitForTopUp(context.Background(), "topup-1", WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForTopUp() error = %v", err)
+	}
+	if topUp.Status != TopUpCompleted || polls.Load() != 4 {
+		t.Errorf("status %s after %d polls, want completed after 4", topUp.Status, polls.Load())
+	}
+
+	// An unpaid checkout is still pending when the wait runs out
+	polls.Store(-100)
+	topUp, err = client.WaitForTopUp(context.Background(), "topup-1",
+		WaitOptions{PollInterval: time.Millisecond, MaxWait: 20 * time.Millisecond})
+	if err != nil || topUp.Status != TopUpPending {
+		t.Errorf("WaitForTopUp() = %+v, %v, want pending without an error", topUp, err)
+	}
+}
+
+func TestTopUpTool_ProviderCheckout_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewTopUpTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "start", "amount": 30.0, "provider": "stripe"})
+	if result.IsError {
+		t.Fatalf("start failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{
+		"Awaiting Payment", "Provider: stripe",
+		"https://checkout.sandbox.deparrow.local/stripe/sandbox-topup-0001", "deparrow_topup action 'watch'",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("started top-up missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "watch", "top_up_id": "sandbox-topup-0001", "wait_seconds": 5.0})
+	if result.IsError || !strings.Contains(result.ForLLM, "Credits Added") {
+		t.Errorf("watch should see the payment:\n%s", result.ForLLM)
+	}
+	wallet, err := client.GetWallet(ctx)
+	if err != nil {
+		t.Fatalf("GetWallet() error = %v", err)
+	}
+	if wallet.Balance != SandboxStartingBalance+30 {
+		t.Errorf("Balance = %.2f, want %.2f", wallet.Balance, SandboxStartingBalance+30)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "refund"},
+		{"action": "start", "provider": "stripe"},
+		{"action": "start", "amount": 30.0, "provider": "paypal"},
+		{"action": "start", "amount": 30.0, "provider": "stripe", "source_id": "sandbox-card"},
+		{"action": "watch"},
+		{"action": "watch", "top_up_id": "missing"},
+	} {
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("Execute(%v) should fail:\n%s", args, result.ForLLM)
+		}
+	}
+}
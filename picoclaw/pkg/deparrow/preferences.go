@@ -0,0 +1,163 @@
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// GetPreferences retrieves the preferences stored on the server for the authenticated user.
+func (c *Client) GetPreferences(ctx context.Context) (*Preferences, error) {
+	var result Preferences
+	err := c.doRequest(ctx, http.MethodGet, "/api/v1/users/preferences", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdatePreferences replaces the preferences stored on the server.
+func (c *Client) UpdatePreferences(ctx context.Context, prefs *Preferences) error {
+	return c.doRequest(ctx, http.MethodPut, "/api/v1/users/preferences", prefs, nil)
+}
+
+// SetPreferences attaches a preferences store to the client.
+// Tools sharing the client read their defaults from it.
+func (c *Client) SetPreferences(store *PreferencesStore) {
+	c.preferences = store
+}
+
+// Preferences returns the user's current preferences.
+// A zero value is returned when no store is attached.
+func (c *Client) Preferences() Preferences {
+	if c.preferences == nil {
+		return Preferences{}
+	}
+	return c.preferences.Get()
+}
+
+// PreferencesStore keeps user preferences in a local JSON file and
+// synchronizes them with the server. The most recently updated copy wins.
+type PreferencesStore struct {
+	client *Client
+	path   string
+	mu     sync.RWMutex
+	prefs  Preferences
+}
+
+// NewPreferencesStore creates a store backed by the file at path.
+// Existing preferences are loaded from disk; a missing file is not an error.
+// The client may be nil for a purely local store.
+func NewPreferencesStore(path string, client *Client) (*PreferencesStore, error) {
+	s := &PreferencesStore{
+		client: client,
+		path:   path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read preferences: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.prefs); err != nil {
+		return nil, fmt.Errorf("failed to parse preferences: %w", err)
+	}
+
+	return s, nil
+}
+
+// Get returns a copy of the current preferences.
+func (s *PreferencesStore) Get() Preferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefs := s.prefs
+	if s.prefs.DefaultResources != nil {
+		res := *s.prefs.DefaultResources
+		prefs.DefaultResources = &res
+	}
+	return prefs
+}
+
+// Update applies fn to the preferences, saves them locally and pushes them to the server.
+// The local save always happens first, so a failed sync never loses the change.
+func (s *PreferencesStore) Update(ctx context.Context, fn func(*Preferences)) error {
+	s.mu.Lock()
+	fn(&s.prefs)
+	s.prefs.UpdatedAt = time.Now()
+	prefs := s.prefs
+	err := s.saveLocked()
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if s.client != nil {
+		if err := s.client.UpdatePreferences(ctx, &prefs); err != nil {
+			return fmt.Errorf("preferences saved locally but failed to sync: %w", err)
+		}
+	}
+	return nil
+}
+
+// Sync reconciles the local preferences with the server copy.
+// Whichever side was updated most recently is kept and written to the other.
+func (s *PreferencesStore) Sync(ctx context.Context) error {
+	if s.client == nil {
+		return nil
+	}
+
+	remote, err := s.client.GetPreferences(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch preferences: %w", err)
+	}
+
+	s.mu.Lock()
+	if remote.UpdatedAt.After(s.prefs.UpdatedAt) {
+		s.prefs = *remote
+		err := s.saveLocked()
+		s.mu.Unlock()
+		return err
+	}
+	local := s.prefs
+	s.mu.Unlock()
+
+	if local.UpdatedAt.After(remote.UpdatedAt) {
+		if err := s.client.UpdatePreferences(ctx, &local); err != nil {
+			return fmt.Errorf("failed to push preferences: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveLocked writes the preferences to disk using a temp file + rename.
+// Must be called with the lock held.
+func (s *PreferencesStore) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create preferences directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.prefs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newPreferencesServer serves GET/PUT /api/v1/users/preferences from memory.
+func newPreferencesServer(t *testing.T, initial Preferences) (*httptest.Server, func() Preferences) {
+	t.Helper()
+	var mu sync.Mutex
+	stored := initial
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/users/preferences" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(stored)
+		case http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&stored)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	return server, func() Preferences {
+		mu.Lock()
+		defer mu.Unlock()
+		return stored
+	}
+}
+
+func TestPreferencesStore_LocalRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "prefs.json")
+
+	store, err := NewPreferencesStore(path, nil)
+	if err != nil {
+		t.Fatalf("NewPreferencesStore() error = %v", err)
+	}
+
+	err = store.Update(context.Background(), func(p *Preferences) {
+		p.DefaultRegion = "eu-west"
+		p.MaxJobCost = 25
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	reloaded, err := NewPreferencesStore(path, nil)
+	if err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	got := reloaded.Get()
+	if got.DefaultRegion != "eu-west" || got.MaxJobCost != 25 {
+		t.Errorf("reloaded preferences = %+v", got)
+	}
+	if got.UpdatedAt.IsZero() {
+		t.Error("UpdatedAt should be set on update")
+	}
+}
+
+func TestPreferencesStore_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prefs.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+
+	if _, err := NewPreferencesStore(path, nil); err == nil {
+		t.Error("expected error for invalid preferences file")
+	}
+}
+
+func TestPreferencesStore_Get_ReturnsCopy(t *testing.T) {
+	store, _ := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), nil)
+	store.Update(context.Background(), func(p *Preferences) {
+		p.DefaultResources = &ResourceSpec{CPU: "2"}
+	})
+
+	prefs := store.Get()
+	prefs.DefaultResources.CPU = "64"
+
+	if store.Get().DefaultResources.CPU != "2" {
+		t.Error("Get() should return a copy of default resources")
+	}
+}
+
+func TestPreferencesStore_Update_PushesToServer(t *testing.T) {
+	server, stored := newPreferencesServer(t, Preferences{})
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	store, _ := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), client)
+
+	store.Update(context.Background(), func(p *Preferences) {
+		p.PreferredGPUVendor = "amd"
+	})
+
+	if stored().PreferredGPUVendor != "amd" {
+		t.Errorf("server preferences = %+v, want vendor amd", stored())
+	}
+}
+
+func TestPreferencesStore_Sync_RemoteNewer(t *testing.T) {
+	server, _ := newPreferencesServer(t, Preferences{
+		DefaultRegion: "ap-south",
+		UpdatedAt:     time.Now(),
+	})
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	store, _ := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), client)
+
+	if err := store.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if store.Get().DefaultRegion != "ap-south" {
+		t.Errorf("DefaultRegion = %s, want ap-south", store.Get().DefaultRegion)
+	}
+}
+
+func TestPreferencesStore_Sync_LocalNewer(t *testing.T) {
+	server, stored := newPreferencesServer(t, Preferences{
+		DefaultRegion: "ap-south",
+		UpdatedAt:     time.Now().Add(-time.Hour),
+	})
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "prefs.json")
+	local, _ := NewPreferencesStore(path, nil)
+	local.Update(context.Background(), func(p *Preferences) {
+		p.DefaultRegion = "us-east"
+	})
+
+	store, _ := NewPreferencesStore(path, NewClient(server.URL, "test-token"))
+	if err := store.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if stored().DefaultRegion != "us-east" {
+		t.Errorf("server DefaultRegion = %s, want us-east", stored().DefaultRegion)
+	}
+}
+
+func TestPreferencesTool_SetAndGet(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	store, _ := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), nil)
+	client.SetPreferences(store)
+	tool := NewPreferencesTool(client)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"action":                "set",
+		"default_region":        "eu-west",
+		"memory":                "2Gi",
+		"max_job_cost":          10.0,
+		"low_balance_threshold": 50.0,
+	})
+	if result.IsError {
+		t.Fatalf("set returned error: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{})
+	for _, want := range []string{"eu-west", "2Gi", "10.00 credits", "below 50.00"} {
+		if !contains(result.ForLLM, want) {
+			t.Errorf("get output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestPreferencesTool_NotEnabled(t *testing.T) {
+	tool := NewPreferencesTool(NewClient("http://localhost:8080", "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{})
+
+	if !result.IsError {
+		t.Error("expected error when no preferences store is attached")
+	}
+}
+
+func TestJobTool_Execute_UsesPreferences(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" {
+			json.NewEncoder(w).Encode(ServerInfo{Status: "healthy", APIVersion: "1.2", Features: []string{FeatureSchedulingConstraints}})
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&captured)
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "credit_deducted": 1.0})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	store, _ := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), nil)
+	store.Update(context.Background(), func(p *Preferences) {
+		p.DefaultRegion = "eu-west"
+		p.PreferredGPUVendor = "nvidia"
+		p.DefaultResources = &ResourceSpec{Memory: "4Gi"}
+	})
+	client.SetPreferences(store)
+	if err := NewToolsProvider(client).DiscoverCapabilities(context.Background()); err != nil {
+		t.Fatalf("DiscoverCapabilities() error = %v", err)
+	}
+
+	result := NewJobTool(client).Execute(context.Background(), map[string]interface{}{
+		"image": "alpine",
+		"gpu":   "1",
+	})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+
+	spec := captured["spec"].(map[string]interface{})
+	if mem := spec["resources"].(map[string]interface{})["memory"]; mem != "4Gi" {
+		t.Errorf("memory = %v, want 4Gi", mem)
+	}
+	if regions, _ := spec["regions"].([]interface{}); len(regions) != 1 || regions[0] != "eu-west" {
+		t.Errorf("regions = %v, want [eu-west]", spec["regions"])
+	}
+	if vendor := spec["node_selector"].(map[string]interface{})[NodeGPUVendorLabel]; vendor != "nvidia" {
+		t.Errorf("node_selector gpu_vendor = %v, want nvidia", vendor)
+	}
+	if labels, _ := spec["labels"].(map[string]interface{}); labels["region"] != nil {
+		t.Errorf("labels = %v, preferences should not be job labels", labels)
+	}
+}
+
+func TestJobTool_Execute_MaxJobCost(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	store, _ := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), nil)
+	store.Update(context.Background(), func(p *Preferences) {
+		p.MaxJobCost = 0.5
+	})
+	client.SetPreferences(store)
+
+	result := NewJobTool(client).Execute(context.Background(), map[string]interface{}{
+		"image":   "alpine",
+		"gpu":     "1",
+		"timeout": 7200.0,
+	})
+
+	if !result.IsError {
+		t.Fatal("expected job above the cost ceiling to be rejected")
+	}
+	if !contains(result.ForLLM, "exceeds your maximum") {
+		t.Errorf("unexpected message: %s", result.ForLLM)
+	}
+}
@@ -0,0 +1,215 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// PreferencesTool lets the agent read and remember the user's default choices.
+type PreferencesTool struct {
+	client *Client
+}
+
+// NewPreferencesTool creates a new preferences tool.
+func NewPreferencesTool(client *Client) *PreferencesTool {
+	return &PreferencesTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *PreferencesTool) Name() string {
+	return "deparrow_preferences"
+}
+
+// Description returns the tool description.
+func (t *PreferencesTool) Description() string {
+	return `View or update your saved DEparrow preferences.
+
+Saved preferences are used as defaults by the other DEparrow tools, so
+you don't need to be asked the same questions every session:
+- Default region for jobs and capacity checks
+- Default CPU, memory, and GPU for submitted jobs
+- Maximum credit cost per job
+- Preferred GPU vendor
+- Notification settings
+
+Use action 'set' with only the fields you want to change.
+`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *PreferencesTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"get", "set"},
+				"description": "Action to perform: 'get' to show preferences, 'set' to update them",
+				"default":     "get",
+			},
+			"default_region": map[string]interface{}{
+				"type":        "string",
+				"description": "Default region (e.g., 'us-east')",
+			},
+			"cpu": map[string]interface{}{
+				"type":        "string",
+				"description": "Default CPU requirement (e.g., '500m')",
+			},
+			"memory": map[string]interface{}{
+				"type":        "string",
+				"description": "Default memory requirement (e.g., '1Gi')",
+			},
+			"gpu": map[string]interface{}{
+				"type":        "string",
+				"description": "Default GPU requirement (e.g., '1')",
+			},
+			"max_job_cost": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum estimated credits per job (0 to remove the limit)",
+			},
+			"preferred_gpu_vendor": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"nvidia", "amd", "intel", ""},
+				"description": "Preferred GPU vendor",
+			},
+			"notify_job_completed": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Notify when a job completes",
+			},
+			"notify_job_failed": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Notify when a job fails",
+			},
+			"low_balance_threshold": map[string]interface{}{
+				"type":        "number",
+				"description": "Warn when the balance drops below this amount (0 to disable)",
+			},
+		},
+	}
+}
+
+// Execute runs the preferences tool.
+func (t *PreferencesTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	if t.client.preferences == nil {
+		return tools.ErrorResult("Preferences are not enabled for this agent")
+	}
+
+	action, _ := args["action"].(string)
+	if action == "" {
+		action = "get"
+	}
+
+	switch action {
+	case "get":
+		return tools.UserResult(formatPreferences(t.client.Preferences()))
+	case "set":
+		return t.setPreferences(ctx, args)
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
+	}
+}
+
+// setPreferences applies the supplied fields to the stored preferences.
+func (t *PreferencesTool) setPreferences(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	err := t.client.preferences.Update(ctx, func(p *Preferences) {
+		if v, ok := args["default_region"].(string); ok {
+			p.DefaultRegion = v
+		}
+		for _, field := range []string{"cpu", "memory", "gpu"} {
+			v, ok := args[field].(string)
+			if !ok {
+				continue
+			}
+			if p.DefaultResources == nil {
+				p.DefaultResources = &ResourceSpec{}
+			}
+			switch field {
+			case "cpu":
+				p.DefaultResources.CPU = v
+			case "memory":
+				p.DefaultResources.Memory = v
+			case "gpu":
+				p.DefaultResources.GPU = v
+			}
+		}
+		if v, ok := args["max_job_cost"].(float64); ok {
+			p.MaxJobCost = v
+		}
+		if v, ok := args["preferred_gpu_vendor"].(string); ok {
+			p.PreferredGPUVendor = v
+		}
+		if v, ok := args["notify_job_completed"].(bool); ok {
+			p.Notifications.JobCompleted = v
+		}
+		if v, ok := args["notify_job_failed"].(bool); ok {
+			p.Notifications.JobFailed = v
+		}
+		if v, ok := args["low_balance_threshold"].(float64); ok {
+			p.Notifications.LowBalance = v > 0
+			p.Notifications.LowBalanceThreshold = v
+		}
+	})
+
+	output := formatPreferences(t.client.Preferences())
+	if err != nil {
+		// The local copy is saved even when the server sync fails
+		return tools.UserResult(fmt.Sprintf("⚠️  %v\n\n%s", err, output))
+	}
+	return tools.UserResult("✅ Preferences saved.\n\n" + output)
+}
+
+// formatPreferences renders preferences for display.
+func formatPreferences(p Preferences) string {
+	var result strings.Builder
+	result.WriteString("⚙️  DEparrow Preferences\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	result.WriteString(fmt.Sprintf("  Default Region:   %s\n", orNotSet(p.DefaultRegion)))
+	if p.DefaultResources != nil {
+		res := p.DefaultResources
+		result.WriteString(fmt.Sprintf("  Default CPU:      %s\n", orNotSet(res.CPU)))
+		result.WriteString(fmt.Sprintf("  Default Memory:   %s\n", orNotSet(res.Memory)))
+		result.WriteString(fmt.Sprintf("  Default GPU:      %s\n", orNotSet(res.GPU)))
+	} else {
+		result.WriteString("  Default Resources: not set\n")
+	}
+	if p.MaxJobCost > 0 {
+		result.WriteString(fmt.Sprintf("  Max Job Cost:     %.2f credits\n", p.MaxJobCost))
+	} else {
+		result.WriteString("  Max Job Cost:     no limit\n")
+	}
+	result.WriteString(fmt.Sprintf("  GPU Vendor:       %s\n", orNotSet(p.PreferredGPUVendor)))
+
+	result.WriteString("\n🔔 Notifications:\n")
+	result.WriteString(fmt.Sprintf("  Job completed:    %s\n", onOff(p.Notifications.JobCompleted)))
+	result.WriteString(fmt.Sprintf("  Job failed:       %s\n", onOff(p.Notifications.JobFailed)))
+	if p.Notifications.LowBalance {
+		result.WriteString(fmt.Sprintf("  Low balance:      below %.2f credits\n", p.Notifications.LowBalanceThreshold))
+	} else {
+		result.WriteString("  Low balance:      off\n")
+	}
+
+	return result.String()
+}
+
+// orNotSet returns v or "not set" when v is empty.
+func orNotSet(v string) string {
+	if v == "" {
+		return "not set"
+	}
+	return v
+}
+
+// onOff renders a boolean setting.
+func onOff(v bool) string {
+	if v {
+		return "on"
+	}
+	return "off"
+}
+
+// Ensure tools implement the Tool interface
+var _ tools.Tool = (*PreferencesTool)(nil)
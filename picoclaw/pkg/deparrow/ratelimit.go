@@ -0,0 +1,175 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRateLimitWait is the longest the client waits out a 429 on its
+	// own. A server asking for a longer wait gets the error passed on.
+	maxRateLimitWait = 10 * time.Second
+
+	// maxRateLimitRetries is how often a request is resent after 429s.
+	maxRateLimitRetries = 3
+
+	// defaultRateLimitPause is how long requests are held back after a
+	// 429 without a Retry-After header.
+	defaultRateLimitPause = time.Second
+)
+
+// WithRateLimit smooths requests to at most perSecond on average, with
+// bursts of up to burst requests. Requests over the limit wait for their
+// turn rather than fail.
+func WithRateLimit(perSecond float64, burst int) ClientOption {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newTokenBucket(perSecond, max(burst, 1))
+	}
+}
+
+// WithEndpointConcurrency allows at most n requests at a time to paths
+// starting with pathPrefix, e.g. "/api/v1/jobs/submit". When prefixes
+// overlap the longest one applies.
+func WithEndpointConcurrency(pathPrefix string, n int) ClientOption {
+	return func(c *Client) {
+		if c.endpointSlots == nil {
+			c.endpointSlots = make(map[string]chan struct{})
+		}
+		if n <= 0 {
+			delete(c.endpointSlots, pathPrefix)
+			return
+		}
+		c.endpointSlots[pathPrefix] = make(chan struct{}, n)
+	}
+}
+
+// tokenBucket is a token-bucket rate limiter. Waiters reserve tokens in
+// turn, so the balance may go negative while they sleep.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perSecond float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token that was not used.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// throttle waits until req may be sent: for the rate limit, for the end
+// of a pause the server asked for, and for a free slot on its endpoint.
+// The returned function frees the slot.
+func (c *Client) throttle(req *http.Request) (func(), error) {
+	ctx := req.Context()
+	now := time.Now()
+	if pause := time.Duration(c.pausedUntil.Load() - now.UnixNano()); pause > 0 {
+		if pause > maxRateLimitWait {
+			return nil, &APIError{Code: http.StatusTooManyRequests, Message: "rate limited by the server", RetryAfter: pause}
+		}
+		if err := sleepContext(ctx, pause); err != nil {
+			return nil, err
+		}
+	}
+	if c.limiter != nil {
+		if err := sleepContext(ctx, c.limiter.reserve(time.Now())); err != nil {
+			c.limiter.cancel()
+			return nil, err
+		}
+	}
+
+	slots := c.endpointSlotsFor(req.URL.Path)
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// endpointSlotsFor returns the concurrency slots of the longest prefix
+// matching path, or nil when the endpoint is not capped.
+func (c *Client) endpointSlotsFor(path string) chan struct{} {
+	var slots chan struct{}
+	longest := -1
+	for prefix, s := range c.endpointSlots {
+		if len(prefix) > longest && strings.Contains(path, prefix) {
+			slots, longest = s, len(prefix)
+		}
+	}
+	return slots
+}
+
+// noteRateLimit holds back every request for as long as a 429 asked.
+func (c *Client) noteRateLimit(err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return
+	}
+	pause := apiErr.RetryAfter
+	if pause <= 0 {
+		pause = defaultRateLimitPause
+	}
+	until := time.Now().Add(pause).UnixNano()
+	for {
+		current := c.pausedUntil.Load()
+		if current >= until || c.pausedUntil.CompareAndSwap(current, until) {
+			return
+		}
+	}
+}
+
+// resendAfterRateLimit reports whether a request rejected with err is
+// sent again once the pause is over. A 429 means the server did not act
+// on the request, so this is safe for any method.
+func resendAfterRateLimit(err error, resent int) bool {
+	var apiErr *APIError
+	return resent < maxRateLimitRetries && errors.As(err, &apiErr) &&
+		apiErr.Code == http.StatusTooManyRequests && apiErr.RetryAfter <= maxRateLimitWait
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
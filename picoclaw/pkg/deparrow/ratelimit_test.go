@@ -0,0 +1,139 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	start := time.Now()
+	b := &tokenBucket{rate: 10, burst: 2, tokens: 2, last: start}
+
+	for i, want := range []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		if got := b.reserve(start); got != want {
+			t.Errorf("reserve #%d = %s, want %s", i+1, got, want)
+		}
+	}
+	// Tokens refill at the rate but never beyond the burst
+	if got := b.reserve(start.Add(time.Hour)); got != 0 {
+		t.Errorf("reserve after an hour = %s, want 0", got)
+	}
+	if b.tokens != 1 {
+		t.Errorf("tokens = %v, want the burst less one", b.tokens)
+	}
+}
+
+func TestClient_RateLimitSmoothsBursts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithRateLimit(20, 2))
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Health(context.Background()); err != nil {
+				t.Errorf("Health() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Two go at once, the other four wait 50ms each
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("6 requests took %s, want about 200ms at 20/s with a burst of 2", elapsed)
+	}
+	if calls.Load() != 6 {
+		t.Errorf("server saw %d requests, want 6", calls.Load())
+	}
+}
+
+func TestClient_EndpointConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		json.NewEncoder(w).Encode(Job{ID: "job-1", Status: JobStatusRunning})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token",
+		WithEndpointConcurrency("/api/v1/jobs", 4),
+		WithEndpointConcurrency("/api/v1/jobs/", 2))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetJob(context.Background(), "job-1"); err != nil {
+				t.Errorf("GetJob() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrency = %d, want the longest prefix's cap of 2", got)
+	}
+}
+
+func TestClient_RateLimitPause(t *testing.T) {
+	var calls atomic.Int32
+	var retryAfter atomic.Value
+	retryAfter.Store("1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter.Load().(string))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(Job{ID: "job-1", Status: JobStatusRunning})
+	}))
+	defer server.Close()
+
+	// A short pause is waited out, even for requests that are not retried
+	client := NewClient(server.URL, "test-token", WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	start := time.Now()
+	if _, err := client.SubmitJob(context.Background(), &JobSpec{Image: "ubuntu:22.04"}); err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || calls.Load() != 2 {
+		t.Errorf("resent after %s with %d calls, want one resend after the 1s asked", elapsed, calls.Load())
+	}
+
+	// A long one is passed on, and later requests fail without being sent
+	calls.Store(0)
+	retryAfter.Store("60")
+	if _, err := client.GetJob(context.Background(), "job-1"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("GetJob() error = %v, want ErrRateLimited", err)
+	}
+	_, err := client.Health(context.Background())
+	if wait, ok := RetryAfter(err); !errors.Is(err, ErrRateLimited) || !ok || wait <= 50*time.Second {
+		t.Errorf("Health() error = %v, RetryAfter %s; want ErrRateLimited for the rest of the pause", err, wait)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server saw %d requests, want only the rate-limited one", calls.Load())
+	}
+}
@@ -0,0 +1,212 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// reconcilePeriods maps the supported report periods to their length.
+var reconcilePeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// EarningsReconcileTool checks that the credits a node reports as earned
+// actually reached the operator's wallet.
+type EarningsReconcileTool struct {
+	client *Client
+	// local keeps readings for the session when no ledger is attached to the client
+	local *EarningsLedger
+}
+
+// NewEarningsReconcileTool creates a new earnings reconciliation tool.
+func NewEarningsReconcileTool(client *Client) *EarningsReconcileTool {
+	local, _ := NewEarningsLedger("")
+	return &EarningsReconcileTool{client: client, local: local}
+}
+
+// Name returns the tool name.
+func (t *EarningsReconcileTool) Name() string {
+	return "deparrow_reconcile_earnings"
+}
+
+// Description returns the tool description.
+func (t *EarningsReconcileTool) Description() string {
+	return `Check that the credits your nodes report as earned reached your wallet.
+
+Compares the increase of each node's credits_earned counter over the period
+with the wallet earn transactions attributed to that node, and highlights
+any discrepancies.
+
+Node readings are remembered between runs: the first run for a node records
+a baseline, later runs compare against it.`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *EarningsReconcileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"node_ids": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "IDs of the nodes you operate",
+			},
+			"period": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"24h", "7d", "30d"},
+				"description": "Period to reconcile",
+				"default":     "7d",
+			},
+		},
+		"required": []string{"node_ids"},
+	}
+}
+
+// Execute runs the reconciliation.
+func (t *EarningsReconcileTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	nodeIDs := parseNodeIDs(args["node_ids"])
+	if len(nodeIDs) == 0 {
+		return tools.ErrorResult("node_ids is required")
+	}
+
+	period, _ := args["period"].(string)
+	if period == "" {
+		period = "7d"
+	}
+	length, ok := reconcilePeriods[period]
+	if !ok {
+		return tools.ErrorResult(fmt.Sprintf("Unknown period: %s (use 24h, 7d or 30d)", period))
+	}
+
+	now := time.Now()
+	since := now.Add(-length)
+	ledger := t.ledger()
+
+	var nodes []Node
+	var partial partialResult
+	baselines := make(map[string]EarningsReading)
+	for _, id := range nodeIDs {
+		node, err := t.client.GetNode(ctx, id)
+		if err != nil {
+			partial.miss(id, err)
+			continue
+		}
+		nodes = append(nodes, *node)
+
+		if baseline, ok := ledger.Baseline(id, since); ok {
+			baselines[id] = baseline
+		}
+		if err := ledger.Record(id, EarningsReading{At: now, CreditsEarned: node.CreditsEarned}); err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Failed to record node earnings: %v", err))
+		}
+	}
+
+	// Fetch from the earliest baseline so every node's window is covered
+	from := since
+	for _, baseline := range baselines {
+		if baseline.At.Before(from) {
+			from = baseline.At
+		}
+	}
+	transactions, err := t.client.ListTransactions(ctx, from)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get wallet transactions: %v", err))
+	}
+
+	report := ReconcileEarnings(nodes, baselines, transactions, since, now)
+	return partial.result(formatReconciliation(report, period, partial.count()))
+}
+
+// ledger returns the ledger attached to the client, or the session ledger.
+func (t *EarningsReconcileTool) ledger() *EarningsLedger {
+	if t.client.earnings != nil {
+		return t.client.earnings
+	}
+	return t.local
+}
+
+// parseNodeIDs accepts a list of IDs or a comma-separated string.
+func parseNodeIDs(v interface{}) []string {
+	var raw []string
+	switch ids := v.(type) {
+	case []interface{}:
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	case []string:
+		raw = ids
+	case string:
+		raw = strings.Split(ids, ",")
+	}
+
+	var out []string
+	seen := make(map[string]bool)
+	for _, id := range raw {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// formatReconciliation renders the reconciliation report.
+func formatReconciliation(report *EarningsReconciliation, period string, unavailable int) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🧾 Earnings Reconciliation (last %s)\n", period))
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	matched, discrepancies, baselines := 0, 0, 0
+	for _, node := range report.Nodes {
+		switch {
+		case !node.HasBaseline:
+			baselines++
+			result.WriteString(fmt.Sprintf("🆕 %s\n", node.NodeID))
+			result.WriteString("   Baseline recorded, run again later to compare\n\n")
+		case node.Matched():
+			matched++
+			result.WriteString(fmt.Sprintf("✅ %s\n", node.NodeID))
+			result.WriteString(fmt.Sprintf("   Reported %.2f · Credited %.2f credits\n", node.Reported, node.Credited))
+			result.WriteString(fmt.Sprintf("   Since %s\n\n", node.Since.Format("2006-01-02 15:04")))
+		default:
+			discrepancies++
+			result.WriteString(fmt.Sprintf("⚠️  %s\n", node.NodeID))
+			result.WriteString(fmt.Sprintf("   Reported %.2f · Credited %.2f credits\n", node.Reported, node.Credited))
+			if diff := node.Difference(); diff > 0 {
+				result.WriteString(fmt.Sprintf("   Missing from wallet: %.2f credits\n", diff))
+			} else {
+				result.WriteString(fmt.Sprintf("   Credited beyond reported: %.2f credits\n", -diff))
+			}
+			result.WriteString(fmt.Sprintf("   Since %s\n\n", node.Since.Format("2006-01-02 15:04")))
+		}
+	}
+
+	if report.Unattributed > 0 {
+		result.WriteString(fmt.Sprintf("💳 Earnings not linked to a node: %.2f credits\n\n", report.Unattributed))
+	}
+
+	result.WriteString(fmt.Sprintf("📊 %d matched · %d discrepancies · %d new", matched, discrepancies, baselines))
+	if unavailable > 0 {
+		result.WriteString(fmt.Sprintf(" · %d unavailable", unavailable))
+	}
+	result.WriteString("\n")
+
+	if discrepancies > 0 {
+		result.WriteString("\n💡 Earnings can take a few minutes to settle. If a gap persists, ")
+		result.WriteString("contact support with the node ID and period above.")
+	}
+
+	return result.String()
+}
+
+// Ensure tool implements the Tool interface
+var _ tools.Tool = (*EarningsReconcileTool)(nil)
@@ -0,0 +1,105 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newReconcileServer(t *testing.T, credits float64, transactions []Transaction) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/credits/transactions":
+			if _, err := time.Parse(time.RFC3339, r.URL.Query().Get("since")); err != nil {
+				t.Errorf("invalid since parameter: %v", err)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"transactions": transactions})
+		case strings.HasPrefix(r.URL.Path, "/api/v1/nodes/"):
+			id := strings.TrimPrefix(r.URL.Path, "/api/v1/nodes/")
+			if id == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"error": "node not found"})
+				return
+			}
+			json.NewEncoder(w).Encode(Node{ID: id, CreditsEarned: credits})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestEarningsReconcileTool_FirstRunRecordsBaseline(t *testing.T) {
+	server := newReconcileServer(t, 100, nil)
+	defer server.Close()
+
+	tool := NewEarningsReconcileTool(NewClient(server.URL, "test-token"))
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"node_ids": []interface{}{"node-1", "missing"},
+	})
+
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Baseline recorded") {
+		t.Errorf("Result should report the baseline: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "1 unavailable") {
+		t.Errorf("Result should report the unavailable node: %s", result.ForLLM)
+	}
+	if _, ok := tool.local.Baseline("node-1", time.Now()); !ok {
+		t.Error("reading was not recorded")
+	}
+}
+
+func TestEarningsReconcileTool_ReportsDiscrepancy(t *testing.T) {
+	transactions := []Transaction{
+		{ID: "tx-1", Type: "earn", NodeID: "node-1", Amount: 40, Timestamp: time.Now().Add(-time.Hour)},
+	}
+	server := newReconcileServer(t, 150, transactions)
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	ledger, _ := NewEarningsLedger("")
+	ledger.Record("node-1", EarningsReading{At: time.Now().Add(-2 * time.Hour), CreditsEarned: 100})
+	client.SetEarningsLedger(ledger)
+
+	tool := NewEarningsReconcileTool(client)
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"node_ids": "node-1",
+		"period":   "24h",
+	})
+
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Missing from wallet: 10.00") {
+		t.Errorf("Result should report the missing credits: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "1 discrepancies") {
+		t.Errorf("Result should count the discrepancy: %s", result.ForLLM)
+	}
+}
+
+func TestEarningsReconcileTool_InvalidArgs(t *testing.T) {
+	tool := NewEarningsReconcileTool(NewClient("http://localhost:8080", "test-token"))
+
+	if result := tool.Execute(context.Background(), nil); !result.IsError {
+		t.Error("Execute() without node_ids should fail")
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"node_ids": []interface{}{"node-1"},
+		"period":   "1y",
+	})
+	if !result.IsError {
+		t.Error("Execute() with unknown period should fail")
+	}
+}
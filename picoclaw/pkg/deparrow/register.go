@@ -1,13 +1,17 @@
 package deparrow
 
 import (
+	"context"
+
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
 // ToolsProvider creates and provides all DEparrow tools.
 // Use this to easily register all DEparrow tools with an agent.
 type ToolsProvider struct {
-	client *Client
+	client  *Client
+	budget  *BudgetGuard
+	metrics ToolMetricsHook
 }
 
 // NewToolsProvider creates a new tools provider with the given client.
@@ -22,15 +26,40 @@ func NewToolsProviderFromConfig(apiURL, jwtToken string, userID string) *ToolsPr
 	return NewToolsProvider(client)
 }
 
+// SetBudgetGuard enforces the guard's budget on the tools that spend
+// credits. Set it before getting or registering the tools.
+func (p *ToolsProvider) SetBudgetGuard(guard *BudgetGuard) {
+	p.budget = guard
+}
+
+// SetMetrics reports every execution of the tools to hook: which tool ran,
+// how long it took, whether it failed and the credits the jobs it
+// submitted were charged. Set it before getting or registering the tools.
+func (p *ToolsProvider) SetMetrics(hook ToolMetricsHook) {
+	p.metrics = hook
+}
+
+// DiscoverCapabilities asks the server which optional features it
+// supports, so that from then on the tools only offer the parameters and
+// actions it can serve. Until it succeeds they offer everything.
+func (p *ToolsProvider) DiscoverCapabilities(ctx context.Context) error {
+	_, err := p.client.ServerInfo(ctx)
+	return err
+}
+
 // GetAllTools returns all DEparrow tools.
 // This is the recommended way to get all tools for registration.
 func (p *ToolsProvider) GetAllTools() []tools.Tool {
-	return []tools.Tool{
+	return p.wrap([]tools.Tool{
 		// Job management
 		NewJobTool(p.client),
 		NewJobStatusTool(p.client),
 		NewJobListTool(p.client),
 		NewJobCancelTool(p.client),
+		NewJobLogsTool(p.client),
+		NewJobTemplateTool(p.client),
+		NewScheduleTool(p.client),
+		NewWorkerPoolTool(p.client),
 
 		// Credit management
 		NewCreditTool(p.client),
@@ -42,50 +71,112 @@ func (p *ToolsProvider) GetAllTools() []tools.Tool {
 		NewNodeTool(p.client),
 		NewNodeContributionTool(p.client),
 		NewOrchestratorTool(p.client),
+		NewEarningsReconcileTool(p.client),
+		NewNodeUpdateTool(p.client),
 
 		// Wallet management
 		NewWalletTool(p.client),
+		NewTopUpTool(p.client),
 		NewTransferTool(p.client),
 		NewHealthTool(p.client),
-	}
+		NewSpendCheckTool(p.client),
+
+		// Capacity planning
+		NewCanRunTool(p.client),
+		NewTradeoffTool(p.client),
+		NewEstimateTool(p.client),
+		NewBookingTool(p.client),
+
+		// Account settings and activity
+		NewPreferencesTool(p.client),
+		NewActivityTool(p.client),
+	})
 }
 
 // GetJobTools returns tools for job management.
 func (p *ToolsProvider) GetJobTools() []tools.Tool {
-	return []tools.Tool{
+	return p.wrap([]tools.Tool{
 		NewJobTool(p.client),
 		NewJobStatusTool(p.client),
 		NewJobListTool(p.client),
 		NewJobCancelTool(p.client),
-	}
+		NewJobLogsTool(p.client),
+		NewJobTemplateTool(p.client),
+		NewScheduleTool(p.client),
+		NewWorkerPoolTool(p.client),
+	})
 }
 
 // GetCreditTools returns tools for credit management.
 func (p *ToolsProvider) GetCreditTools() []tools.Tool {
-	return []tools.Tool{
+	return p.wrap([]tools.Tool{
 		NewCreditTool(p.client),
 		NewCreditEarnTool(p.client),
 		NewNetworkStatsTool(p.client),
 		NewLeaderboardTool(p.client),
-	}
+	})
 }
 
 // GetNodeTools returns tools for node management.
 func (p *ToolsProvider) GetNodeTools() []tools.Tool {
-	return []tools.Tool{
+	return p.wrap([]tools.Tool{
 		NewNodeTool(p.client),
 		NewNodeContributionTool(p.client),
 		NewOrchestratorTool(p.client),
-	}
+		NewEarningsReconcileTool(p.client),
+		NewNodeUpdateTool(p.client),
+	})
 }
 
 // GetWalletTools returns tools for wallet management.
 func (p *ToolsProvider) GetWalletTools() []tools.Tool {
-	return []tools.Tool{
+	return p.wrap([]tools.Tool{
 		NewWalletTool(p.client),
+		NewTopUpTool(p.client),
 		NewTransferTool(p.client),
 		NewHealthTool(p.client),
+		NewSpendCheckTool(p.client),
+	})
+}
+
+// GetPlanningTools returns tools for capacity planning.
+func (p *ToolsProvider) GetPlanningTools() []tools.Tool {
+	return p.wrap([]tools.Tool{
+		NewCanRunTool(p.client),
+		NewTradeoffTool(p.client),
+		NewEstimateTool(p.client),
+		NewBookingTool(p.client),
+	})
+}
+
+// GetAccountTools returns tools for account settings and activity.
+func (p *ToolsProvider) GetAccountTools() []tools.Tool {
+	return p.wrap([]tools.Tool{
+		NewPreferencesTool(p.client),
+		NewActivityTool(p.client),
+	})
+}
+
+// wrap forwards the calling conversation as a session ID on every tool,
+// runs each execution in a trace span, enforces the budget on the tools
+// that spend credits, reports executions to the metrics hook and, in
+// sandbox mode, watermarks the tool output.
+func (p *ToolsProvider) wrap(list []tools.Tool) []tools.Tool {
+	for i, tool := range list {
+		spender, spends := tool.(budgetedTool)
+		if p.client.IsSandbox() {
+			tool = &sandboxTool{Tool: tool}
+		}
+		tool = &tracedTool{Tool: tool, client: p.client}
+		if p.budget != nil && spends {
+			tool = p.budget.wrap(tool, spender)
+		}
+		if p.metrics != nil {
+			tool = &meteredTool{Tool: tool, hook: p.metrics}
+		}
+		list[i] = &sessionTool{Tool: tool}
 	}
+	return list
 }
 
 // RegisterAll registers all DEparrow tools with the provided registry.
@@ -123,6 +214,20 @@ func (p *ToolsProvider) RegisterWallet(registry *tools.ToolRegistry) {
 	}
 }
 
+// RegisterPlanning registers capacity planning tools.
+func (p *ToolsProvider) RegisterPlanning(registry *tools.ToolRegistry) {
+	for _, tool := range p.GetPlanningTools() {
+		registry.Register(tool)
+	}
+}
+
+// RegisterAccount registers account settings and activity tools.
+func (p *ToolsProvider) RegisterAccount(registry *tools.ToolRegistry) {
+	for _, tool := range p.GetAccountTools() {
+		registry.Register(tool)
+	}
+}
+
 // ToolNames returns the names of all available DEparrow tools.
 func ToolNames() []string {
 	return []string{
@@ -131,6 +236,10 @@ func ToolNames() []string {
 		"deparrow_job_status",
 		"deparrow_list_jobs",
 		"deparrow_cancel_job",
+		"deparrow_job_logs",
+		"deparrow_job_template",
+		"deparrow_schedule",
+		"deparrow_worker_pool",
 
 		// Credit management
 		"deparrow_credits",
@@ -142,11 +251,25 @@ func ToolNames() []string {
 		"deparrow_nodes",
 		"deparrow_contribution",
 		"deparrow_orchestrators",
+		"deparrow_reconcile_earnings",
+		"deparrow_node_updates",
 
 		// Wallet management
 		"deparrow_wallet",
+		"deparrow_topup",
 		"deparrow_transfer",
 		"deparrow_health",
+		"deparrow_spend_check",
+
+		// Capacity planning
+		"deparrow_can_run",
+		"deparrow_tradeoffs",
+		"deparrow_estimate",
+		"deparrow_book_capacity",
+
+		// Account settings and activity
+		"deparrow_preferences",
+		"deparrow_activity",
 	}
 }
 
@@ -156,8 +279,12 @@ func ToolDescriptions() map[string]string {
 		// Job management
 		"deparrow_submit_job":   "Submit a compute job to the DEparrow network",
 		"deparrow_job_status":   "Check the status of a submitted DEparrow job",
-		"deparrow_list_jobs":    "List all jobs submitted by the authenticated user",
+		"deparrow_list_jobs":    "List your jobs with counts by status, sorted by submission time, cost or duration",
 		"deparrow_cancel_job":   "Cancel a running job and receive partial credit refund",
+		"deparrow_job_logs":     "Follow the live log of a running job and show its latest output",
+		"deparrow_job_template": "Save job specs as templates with ${VAR} placeholders and submit them with new values",
+		"deparrow_schedule":     "Run jobs on a cron schedule with limits on concurrent runs and credits per day, week or month",
+		"deparrow_worker_pool":  "Keep a pool of worker jobs running, replacing failed workers within a credit budget",
 
 		// Credit management
 		"deparrow_credits":      "Check your DEparrow credit balance and transaction history",
@@ -168,11 +295,25 @@ func ToolDescriptions() map[string]string {
 		// Node management
 		"deparrow_nodes":         "List and inspect compute nodes on the DEparrow network",
 		"deparrow_contribution":  "View detailed contribution statistics for a specific node",
-		"deparrow_orchestrators": "List orchestrator nodes in the DEparrow network",
+		"deparrow_orchestrators": "List orchestrators with their region, load, version and supported features",
+		"deparrow_reconcile_earnings": "Check that your nodes' reported earnings reached your wallet",
+		"deparrow_node_updates":       "Check which of your nodes run outdated node-agent software and what updating brings",
 
 		// Wallet management
-		"deparrow_wallet":  "View your DEparrow wallet balance and transaction history",
-		"deparrow_transfer": "Transfer credits to another DEparrow user",
+		"deparrow_wallet":  "View your DEparrow wallet balance, transaction history and standing orders",
+		"deparrow_topup":    "Buy credits through a payment provider's checkout and watch for the payment",
+		"deparrow_transfer": "Transfer credits to another DEparrow user, at once, as an escrowed offer or with approval",
 		"deparrow_health":   "Check the health of your DEparrow connection and the network",
+		"deparrow_spend_check": "Flag unusual credit spending and optionally pause job submissions and transfers",
+
+		// Capacity planning
+		"deparrow_can_run":   "Check whether the network can run a workload now, where, and at what cost",
+		"deparrow_tradeoffs": "Compare cheaper, slower and faster ways to run a workload",
+		"deparrow_estimate":  "Estimate a job's cost, eligible nodes and queue time without submitting it",
+		"deparrow_book_capacity": "View provider availability calendars and book future GPU capacity with a credit hold",
+
+		// Account settings and activity
+		"deparrow_preferences": "View or update saved defaults for region, resources, cost limits, and notifications",
+		"deparrow_activity":    "Summarize recent job completions, transfers received and node tier changes for a daily briefing",
 	}
 }
@@ -41,9 +41,9 @@ func TestToolsProvider_GetAllTools(t *testing.T) {
 
 	tools := provider.GetAllTools()
 
-	// Should have 14 tools
-	if len(tools) != 14 {
-		t.Errorf("GetAllTools() returned %d tools, want 14", len(tools))
+	// Should have 28 tools
+	if len(tools) != 28 {
+		t.Errorf("GetAllTools() returned %d tools, want 28", len(tools))
 	}
 
 	// Verify tool names
@@ -64,9 +64,18 @@ func TestToolsProvider_GetAllTools(t *testing.T) {
 		"deparrow_nodes",
 		"deparrow_contribution",
 		"deparrow_orchestrators",
+		"deparrow_reconcile_earnings",
+		"deparrow_node_updates",
 		"deparrow_wallet",
+		"deparrow_topup",
 		"deparrow_transfer",
 		"deparrow_health",
+		"deparrow_spend_check",
+		"deparrow_can_run",
+		"deparrow_tradeoffs",
+		"deparrow_book_capacity",
+		"deparrow_preferences",
+		"deparrow_activity",
 	}
 
 	for _, name := range expectedTools {
@@ -82,8 +91,8 @@ func TestToolsProvider_GetJobTools(t *testing.T) {
 
 	tools := provider.GetJobTools()
 
-	if len(tools) != 4 {
-		t.Errorf("GetJobTools() returned %d tools, want 4", len(tools))
+	if len(tools) != 8 {
+		t.Errorf("GetJobTools() returned %d tools, want 8", len(tools))
 	}
 
 	expectedNames := []string{
@@ -91,6 +100,10 @@ func TestToolsProvider_GetJobTools(t *testing.T) {
 		"deparrow_job_status",
 		"deparrow_list_jobs",
 		"deparrow_cancel_job",
+		"deparrow_job_logs",
+		"deparrow_job_template",
+		"deparrow_schedule",
+		"deparrow_worker_pool",
 	}
 
 	for i, tool := range tools {
@@ -130,14 +143,16 @@ func TestToolsProvider_GetNodeTools(t *testing.T) {
 
 	tools := provider.GetNodeTools()
 
-	if len(tools) != 3 {
-		t.Errorf("GetNodeTools() returned %d tools, want 3", len(tools))
+	if len(tools) != 5 {
+		t.Errorf("GetNodeTools() returned %d tools, want 5", len(tools))
 	}
 
 	expectedNames := []string{
 		"deparrow_nodes",
 		"deparrow_contribution",
 		"deparrow_orchestrators",
+		"deparrow_reconcile_earnings",
+		"deparrow_node_updates",
 	}
 
 	for i, tool := range tools {
@@ -153,14 +168,40 @@ func TestToolsProvider_GetWalletTools(t *testing.T) {
 
 	tools := provider.GetWalletTools()
 
-	if len(tools) != 3 {
-		t.Errorf("GetWalletTools() returned %d tools, want 3", len(tools))
+	if len(tools) != 5 {
+		t.Errorf("GetWalletTools() returned %d tools, want 5", len(tools))
 	}
 
 	expectedNames := []string{
 		"deparrow_wallet",
+		"deparrow_topup",
 		"deparrow_transfer",
 		"deparrow_health",
+		"deparrow_spend_check",
+	}
+
+	for i, tool := range tools {
+		if tool.Name() != expectedNames[i] {
+			t.Errorf("Tool %d: name = %s, want %s", i, tool.Name(), expectedNames[i])
+		}
+	}
+}
+
+func TestToolsProvider_GetPlanningTools(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	provider := NewToolsProvider(client)
+
+	tools := provider.GetPlanningTools()
+
+	if len(tools) != 4 {
+		t.Errorf("GetPlanningTools() returned %d tools, want 4", len(tools))
+	}
+
+	expectedNames := []string{
+		"deparrow_can_run",
+		"deparrow_tradeoffs",
+		"deparrow_estimate",
+		"deparrow_book_capacity",
 	}
 
 	for i, tool := range tools {
@@ -170,6 +211,20 @@ func TestToolsProvider_GetWalletTools(t *testing.T) {
 	}
 }
 
+func TestToolsProvider_GetAccountTools(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	provider := NewToolsProvider(client)
+
+	tools := provider.GetAccountTools()
+
+	if len(tools) != 2 {
+		t.Errorf("GetAccountTools() returned %d tools, want 2", len(tools))
+	}
+	if tools[0].Name() != "deparrow_preferences" {
+		t.Errorf("Tool 0: name = %s, want deparrow_preferences", tools[0].Name())
+	}
+}
+
 func TestToolsProvider_RegisterAll(t *testing.T) {
 	client := NewClient("http://localhost:8080", "test-token")
 	provider := NewToolsProvider(client)
@@ -177,9 +232,9 @@ func TestToolsProvider_RegisterAll(t *testing.T) {
 
 	provider.RegisterAll(registry)
 
-	// Verify all 14 tools are registered
-	if registry.Count() != 14 {
-		t.Errorf("Registry count = %d, want 14", registry.Count())
+	// Verify all 28 tools are registered
+	if registry.Count() != 28 {
+		t.Errorf("Registry count = %d, want 28", registry.Count())
 	}
 
 	// Verify each tool is accessible
@@ -195,9 +250,17 @@ func TestToolsProvider_RegisterAll(t *testing.T) {
 		"deparrow_nodes",
 		"deparrow_contribution",
 		"deparrow_orchestrators",
+		"deparrow_reconcile_earnings",
+		"deparrow_node_updates",
 		"deparrow_wallet",
+		"deparrow_topup",
 		"deparrow_transfer",
 		"deparrow_health",
+		"deparrow_spend_check",
+		"deparrow_can_run",
+		"deparrow_tradeoffs",
+		"deparrow_preferences",
+		"deparrow_activity",
 	}
 
 	for _, name := range expectedTools {
@@ -214,8 +277,8 @@ func TestToolsProvider_RegisterJobs(t *testing.T) {
 
 	provider.RegisterJobs(registry)
 
-	if registry.Count() != 4 {
-		t.Errorf("Registry count = %d, want 4", registry.Count())
+	if registry.Count() != 8 {
+		t.Errorf("Registry count = %d, want 8", registry.Count())
 	}
 }
 
@@ -238,8 +301,8 @@ func TestToolsProvider_RegisterNodes(t *testing.T) {
 
 	provider.RegisterNodes(registry)
 
-	if registry.Count() != 3 {
-		t.Errorf("Registry count = %d, want 3", registry.Count())
+	if registry.Count() != 5 {
+		t.Errorf("Registry count = %d, want 5", registry.Count())
 	}
 }
 
@@ -250,8 +313,8 @@ func TestToolsProvider_RegisterWallet(t *testing.T) {
 
 	provider.RegisterWallet(registry)
 
-	if registry.Count() != 3 {
-		t.Errorf("Registry count = %d, want 3", registry.Count())
+	if registry.Count() != 5 {
+		t.Errorf("Registry count = %d, want 5", registry.Count())
 	}
 }
 
@@ -259,8 +322,8 @@ func TestToolsProvider_RegisterWallet(t *testing.T) {
 func TestToolNames(t *testing.T) {
 	names := ToolNames()
 
-	if len(names) != 14 {
-		t.Errorf("ToolNames() returned %d names, want 14", len(names))
+	if len(names) != 28 {
+		t.Errorf("ToolNames() returned %d names, want 28", len(names))
 	}
 
 	// Verify all expected names are present
@@ -281,9 +344,17 @@ func TestToolNames(t *testing.T) {
 		"deparrow_nodes",
 		"deparrow_contribution",
 		"deparrow_orchestrators",
+		"deparrow_reconcile_earnings",
+		"deparrow_node_updates",
 		"deparrow_wallet",
+		"deparrow_topup",
 		"deparrow_transfer",
 		"deparrow_health",
+		"deparrow_spend_check",
+		"deparrow_can_run",
+		"deparrow_tradeoffs",
+		"deparrow_preferences",
+		"deparrow_activity",
 	}
 
 	for _, name := range expectedNames {
@@ -297,8 +368,8 @@ func TestToolNames(t *testing.T) {
 func TestToolDescriptions(t *testing.T) {
 	descs := ToolDescriptions()
 
-	if len(descs) != 14 {
-		t.Errorf("ToolDescriptions() returned %d descriptions, want 14", len(descs))
+	if len(descs) != 28 {
+		t.Errorf("ToolDescriptions() returned %d descriptions, want 28", len(descs))
 	}
 
 	// Verify each description is non-empty
@@ -329,8 +400,8 @@ func TestToolCategories(t *testing.T) {
 	jobTools := provider.GetJobTools()
 	for _, tool := range jobTools {
 		name := tool.Name()
-		if !containsStr(name, "job") {
-			t.Errorf("Job tool %s should contain 'job' in name", name)
+		if !containsStr(name, "job") && !containsStr(name, "schedule") && !containsStr(name, "worker") {
+			t.Errorf("Job tool %s should contain 'job', 'schedule' or 'worker' in name", name)
 		}
 	}
 
@@ -350,7 +421,7 @@ func TestToolCategories(t *testing.T) {
 	for _, tool := range nodeTools {
 		name := tool.Name()
 		valid := containsStr(name, "node") || containsStr(name, "contribution") || 
-		         containsStr(name, "orchestrator")
+		         containsStr(name, "orchestrator") || containsStr(name, "earnings")
 		if !valid {
 			t.Errorf("Node tool %s has unexpected name", name)
 		}
@@ -361,7 +432,8 @@ func TestToolCategories(t *testing.T) {
 	for _, tool := range walletTools {
 		name := tool.Name()
 		valid := containsStr(name, "wallet") || containsStr(name, "transfer") || 
-		         containsStr(name, "health")
+		         containsStr(name, "health") || containsStr(name, "spend") ||
+		         containsStr(name, "topup")
 		if !valid {
 			t.Errorf("Wallet tool %s has unexpected name", name)
 		}
@@ -377,6 +449,7 @@ func TestTools_InterfaceCompliance(t *testing.T) {
 	var _ tools.Tool = NewJobStatusTool(client)
 	var _ tools.Tool = NewJobListTool(client)
 	var _ tools.Tool = NewJobCancelTool(client)
+	var _ tools.Tool = NewJobLogsTool(client)
 	var _ tools.Tool = NewCreditTool(client)
 	var _ tools.Tool = NewCreditEarnTool(client)
 	var _ tools.Tool = NewNetworkStatsTool(client)
@@ -387,6 +460,11 @@ func TestTools_InterfaceCompliance(t *testing.T) {
 	var _ tools.Tool = NewWalletTool(client)
 	var _ tools.Tool = NewTransferTool(client)
 	var _ tools.Tool = NewHealthTool(client)
+	var _ tools.Tool = NewSpendCheckTool(client)
+	var _ tools.Tool = NewCanRunTool(client)
+	var _ tools.Tool = NewTradeoffTool(client)
+	var _ tools.Tool = NewJobTemplateTool(client)
+	var _ tools.Tool = NewPreferencesTool(client)
 }
 
 // Test tool registration is idempotent
@@ -491,8 +569,8 @@ func TestToolsProvider_DifferentConfigs(t *testing.T) {
 			}
 
 			tools := provider.GetAllTools()
-			if len(tools) != 14 {
-				t.Errorf("GetAllTools returned %d tools, want 14", len(tools))
+			if len(tools) != 28 {
+				t.Errorf("GetAllTools returned %d tools, want 28", len(tools))
 			}
 		})
 	}
@@ -0,0 +1,86 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// replicaPaths are the read-only endpoints served from read replicas:
+// network statistics, the leaderboard, metrics and listings. Their readers
+// tolerate data a few seconds old. Single jobs, credits and everything
+// else a caller may read back right after changing it stay on the primary.
+var replicaPaths = map[string]bool{
+	"/api/v1/jobs":          true,
+	"/api/v1/jobs/archive":  true,
+	"/api/v1/nodes":         true,
+	"/api/v1/orchestrators": true,
+	"/api/v1/metrics":       true,
+}
+
+// replicaPrefixes are path prefixes served from read replicas.
+var replicaPrefixes = []string{"/api/v1/network/"}
+
+// WithReadReplicas serves heavy read-only requests, such as stats,
+// the leaderboard and job and node lists, from the given base URLs instead
+// of the primary API. Replicas take turns; a read a replica fails with a
+// network error or server error is repeated against the primary.
+func WithReadReplicas(baseURLs ...string) ClientOption {
+	return func(c *Client) {
+		c.readURLs = nil
+		for _, u := range baseURLs {
+			if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+				c.readURLs = append(c.readURLs, u)
+			}
+		}
+	}
+}
+
+// WithPrimaryRead returns a context whose API calls all go to the primary,
+// for reads that must see a change made just before.
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey, true)
+}
+
+// isReplicaPath reports whether a replica may serve a request for path.
+func isReplicaPath(path string) bool {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if replicaPaths[path] {
+		return true
+	}
+	for _, prefix := range replicaPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// baseURLFor returns the base URL a request is sent to: the next read
+// replica for replica-served reads, the primary otherwise.
+func (c *Client) baseURLFor(ctx context.Context, method, path string) string {
+	if len(c.readURLs) == 0 || c.sandbox != nil || (method != http.MethodGet && method != http.MethodHead) {
+		return c.baseURL
+	}
+	if primary, _ := ctx.Value(primaryReadKey).(bool); primary || !isReplicaPath(path) {
+		return c.baseURL
+	}
+	n := c.nextReplica.Add(1) - 1
+	return c.readURLs[n%uint64(len(c.readURLs))]
+}
+
+// replicaFailed reports whether a replica's error is worth repeating the
+// read against the primary for: the replica was unreachable or broken,
+// rather than the request being wrong.
+func replicaFailed(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
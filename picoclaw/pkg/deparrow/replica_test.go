@@ -0,0 +1,111 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordingServer answers every request with an empty job list and a
+// leaderboard, recording the paths it was asked for.
+func recordingServer(t *testing.T, status int) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error": "replica unavailable"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jobs":        []Job{},
+			"leaderboard": []LeaderboardEntry{{NodeID: "node-1"}},
+			"status":      "ok",
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+}
+
+func TestReadReplicas_Routing(t *testing.T) {
+	primary, primaryPaths := recordingServer(t, http.StatusOK)
+	replicaA, replicaAPaths := recordingServer(t, http.StatusOK)
+	replicaB, replicaBPaths := recordingServer(t, http.StatusOK)
+	client := NewClient(primary.URL, "test-token", WithReadReplicas(replicaA.URL+"/", " ", replicaB.URL))
+	ctx := context.Background()
+
+	if _, err := client.GetLeaderboard(ctx, 10); err != nil {
+		t.Fatalf("GetLeaderboard() error = %v", err)
+	}
+	if _, err := client.ListJobs(ctx); err != nil {
+		t.Fatalf("ListJobs() error = %v", err)
+	}
+	if _, err := client.GetJob(ctx, "job-1"); err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if _, err := client.CancelJob(ctx, "job-1"); err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if _, err := client.ListJobs(WithPrimaryRead(ctx)); err != nil {
+		t.Fatalf("ListJobs() error = %v", err)
+	}
+
+	if got := replicaAPaths(); len(got) != 1 || got[0] != "GET /api/v1/network/leaderboard" {
+		t.Errorf("replica A served %v", got)
+	}
+	if got := replicaBPaths(); len(got) != 1 || got[0] != "GET /api/v1/jobs" {
+		t.Errorf("replica B served %v", got)
+	}
+	want := []string{"GET /api/v1/jobs/job-1", "POST /api/v1/jobs/job-1/cancel", "GET /api/v1/jobs"}
+	if got := primaryPaths(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("primary served %v, want %v", got, want)
+	}
+}
+
+func TestReadReplicas_FallBackToPrimary(t *testing.T) {
+	primary, primaryPaths := recordingServer(t, http.StatusOK)
+	broken, brokenPaths := recordingServer(t, http.StatusServiceUnavailable)
+	client := NewClient(primary.URL, "test-token", WithReadReplicas(broken.URL))
+
+	entries, err := client.GetLeaderboard(context.Background(), 0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetLeaderboard() = %v, %v; want the primary's answer", entries, err)
+	}
+	if len(brokenPaths()) != 1 || len(primaryPaths()) != 1 {
+		t.Errorf("replica calls = %d, primary calls = %d; want 1 each", len(brokenPaths()), len(primaryPaths()))
+	}
+
+	// An unreachable replica falls back too
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	client = NewClient(primary.URL, "test-token", WithReadReplicas(down.URL))
+	if _, err := client.GetNetworkCapacity(context.Background()); err != nil {
+		t.Errorf("GetNetworkCapacity() error = %v, want the primary's answer", err)
+	}
+}
+
+func TestReadReplicas_ClientErrorsAreFinal(t *testing.T) {
+	primary, primaryPaths := recordingServer(t, http.StatusOK)
+	replica, _ := recordingServer(t, http.StatusForbidden)
+	client := NewClient(primary.URL, "test-token", WithReadReplicas(replica.URL))
+
+	if _, err := client.GetMetrics(context.Background()); err == nil {
+		t.Error("GetMetrics() should return the replica's 403")
+	}
+	if len(primaryPaths()) != 0 {
+		t.Errorf("primary served %v, want nothing", primaryPaths())
+	}
+}
@@ -0,0 +1,154 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Defaults for RetryPolicy fields left at zero.
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryInitialBackoff = 250 * time.Millisecond
+	DefaultRetryMaxBackoff     = 5 * time.Second
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryJitter         = 0.2
+)
+
+// DefaultRetryStatuses are the response statuses retried when a policy
+// does not list its own: the gateway errors a restarting or overloaded
+// Meta-OS answers with.
+var DefaultRetryStatuses = []int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy controls how API requests are retried after network errors
+// and transient error statuses. Streaming log follows and job watches
+// reconnect on their own and are not affected.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first;
+	// 1 or less disables retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each retry.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction of it, so
+	// clients failing together do not retry together.
+	Jitter float64
+	// RetryOnStatus lists the response statuses that are retried; nil
+	// uses DefaultRetryStatuses. Network errors are always retried. A
+	// Retry-After header on the response lengthens the wait before the
+	// next attempt.
+	RetryOnStatus []int
+	// RetryNonIdempotent also retries POST and PATCH requests. A failed
+	// submit or transfer may still have been applied by the server, so
+	// retrying it can charge twice.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy retries idempotent requests up to three times on
+// network errors and gateway errors.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: DefaultRetryMaxAttempts, Jitter: DefaultRetryJitter}.withDefaults()
+}
+
+// withDefaults fills in unset fields.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryMultiplier
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	if p.RetryOnStatus == nil {
+		p.RetryOnStatus = DefaultRetryStatuses
+	}
+	return p
+}
+
+// WithRetryPolicy retries failed requests according to policy. Without it
+// every request is attempted once.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy.withDefaults()
+	}
+}
+
+// WithRequestRetryPolicy returns a context whose API calls are retried
+// according to policy instead of the client's policy. Use
+// RetryPolicy{MaxAttempts: 1} to disable retries for a call.
+func WithRequestRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey, policy.withDefaults())
+}
+
+// retryPolicyFor returns the policy for a request made with ctx.
+func (c *Client) retryPolicyFor(ctx context.Context) RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey).(RetryPolicy); ok {
+		return policy
+	}
+	return c.retryPolicy
+}
+
+// attempts returns how many times a request with method may be attempted.
+func (p RetryPolicy) attempts(method string) int {
+	if p.MaxAttempts <= 1 || (!idempotent(method) && !p.RetryNonIdempotent) {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retries reports whether a request that failed with err is retried. A
+// response asking to wait longer than MaxBackoff is not.
+func (p RetryPolicy) retries(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return slices.Contains(p.RetryOnStatus, apiErr.Code) && apiErr.RetryAfter <= p.MaxBackoff
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// delay returns the wait after the given failed attempt: the backoff, or
+// longer when the server asked for it with Retry-After.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	wait, _ := RetryAfter(err)
+	return max(p.backoff(attempt), wait)
+}
+
+// backoff returns the delay after the given failed attempt, counting from 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	delay = min(delay, float64(p.MaxBackoff))
+	delay += (rand.Float64()*2 - 1) * p.Jitter * delay
+	return time.Duration(delay)
+}
+
+// idempotent reports whether repeating a request with method has the same
+// effect as sending it once.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetries retries quickly enough for tests.
+var fastRetries = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+// flakyServer fails the first failures requests with status and then
+// answers every request with an empty job list.
+func flakyServer(t *testing.T, failures int32, status int, requestIDs *[]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestIDs != nil {
+			*requestIDs = append(*requestIDs, r.Header.Get(HeaderRequestID))
+		}
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error": "upstream unavailable"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jobs": []Job{}, "status": "ok"})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetryPolicy_RetriesTransientStatus(t *testing.T) {
+	var requestIDs []string
+	server, calls := flakyServer(t, 2, http.StatusServiceUnavailable, &requestIDs)
+	client := NewClient(server.URL, "test-token", WithRetryPolicy(fastRetries))
+
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+	for _, id := range requestIDs {
+		if id == "" || id != requestIDs[0] {
+			t.Errorf("request IDs = %v, want the same ID on every attempt", requestIDs)
+			break
+		}
+	}
+}
+
+func TestRetryPolicy_GivesUp(t *testing.T) {
+	server, calls := flakyServer(t, 10, http.StatusBadGateway, nil)
+	client := NewClient(server.URL, "test-token", WithRetryPolicy(fastRetries))
+
+	_, err := client.Health(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadGateway {
+		t.Fatalf("Health() error = %v, want the last 502", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestRetryPolicy_StatusNotListed(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusInternalServerError, nil)
+	client := NewClient(server.URL, "test-token", WithRetryPolicy(fastRetries))
+
+	if _, err := client.Health(context.Background()); err == nil {
+		t.Fatal("a 500 should not be retried by default")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+
+	policy := fastRetries
+	policy.RetryOnStatus = []int{http.StatusInternalServerError}
+	client = NewClient(server.URL, "test-token", WithRetryPolicy(policy))
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Errorf("Health() error = %v with 500 listed", err)
+	}
+}
+
+func TestRetryPolicy_NonIdempotent(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	client := NewClient(server.URL, "test-token", WithRetryPolicy(fastRetries))
+
+	if _, err := client.CancelJob(context.Background(), "job-1"); err == nil {
+		t.Fatal("a POST should not be retried by default")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+
+	// A per-request override opts the call in
+	calls.Store(0)
+	policy := fastRetries
+	policy.RetryNonIdempotent = true
+	ctx := WithRequestRetryPolicy(context.Background(), policy)
+	if _, err := client.CancelJob(ctx, "job-1"); err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestRetryPolicy_RequestOverrideDisables(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	client := NewClient(server.URL, "test-token", WithRetryPolicy(fastRetries))
+
+	ctx := WithRequestRetryPolicy(context.Background(), RetryPolicy{MaxAttempts: 1})
+	if _, err := client.ListJobs(ctx); err == nil {
+		t.Fatal("retries should be disabled for the call")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestRetryPolicy_NetworkError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	if _, err := client.Health(context.Background()); err == nil {
+		t.Fatal("without a policy the dropped connection should surface")
+	}
+
+	calls.Store(0)
+	client = NewClient(server.URL, "test-token", WithRetryPolicy(fastRetries))
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Errorf("Health() error = %v, want the dropped connection retried", err)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.withDefaults()
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 50; i++ {
+		if got := policy.backoff(2); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("backoff(2) = %v, want within 50%% of 200ms", got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MaxRetryAttempts is the most attempts a RetrySpec may ask for.
+const MaxRetryAttempts = 10
+
+// RetryOfLabel is the label carrying the ID of a logical job's first
+// attempt on each of its resubmissions.
+const RetryOfLabel = "retry_of"
+
+// RetrySpec asks for a failed job to be submitted again. It is carried out
+// by a JobRunner on the client, not by the network. Cancelled jobs are
+// never resubmitted.
+type RetrySpec struct {
+	// Total attempts including the first
+	MaxAttempts int `json:"max_attempts"`
+	// Delay before the first resubmission, doubled for each one after
+	BackoffSeconds int `json:"backoff_seconds,omitempty"`
+	// Caps the delay; 0 leaves it uncapped
+	MaxBackoffSeconds int `json:"max_backoff_seconds,omitempty"`
+	// Exit codes that are worth retrying; empty retries any failure
+	RetryOnExitCodes []int `json:"retry_on_exit_codes,omitempty"`
+	// Credits all attempts together may cost; 0 for no ceiling
+	MaxCredits float64 `json:"max_credits,omitempty"`
+}
+
+// backoff returns the delay before the given resubmission, counting from 1.
+func (r *RetrySpec) backoff(retry int) time.Duration {
+	delay := time.Duration(r.BackoffSeconds) * time.Second
+	limit := time.Duration(r.MaxBackoffSeconds) * time.Second
+	for i := 1; i < retry; i++ {
+		if limit > 0 && delay >= limit {
+			break
+		}
+		delay *= 2
+	}
+	if limit > 0 {
+		delay = min(delay, limit)
+	}
+	return delay
+}
+
+// retries reports whether a failure with the exit code is worth retrying.
+func (r *RetrySpec) retries(exitCode int) bool {
+	return len(r.RetryOnExitCodes) == 0 || slices.Contains(r.RetryOnExitCodes, exitCode)
+}
+
+// JobAttempt is one submission of a logical job.
+type JobAttempt struct {
+	// Number counts from 1
+	Number      int       `json:"number"`
+	JobID       string    `json:"job_id"`
+	Status      JobStatus `json:"status"`
+	ExitCode    int       `json:"exit_code"`
+	CreditCost  float64   `json:"credit_cost"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// JobRun is a logical job: the attempts a JobRunner made to complete a spec.
+type JobRun struct {
+	// ID is the job ID of the first attempt
+	ID       string       `json:"id"`
+	Attempts []JobAttempt `json:"attempts"`
+	// Credits all attempts cost together
+	TotalCost float64 `json:"total_cost"`
+	// Job is the last attempt with its results
+	Job *Job `json:"job,omitempty"`
+	// Why no further attempt was made, when the last one did not complete
+	StopReason string `json:"stop_reason,omitempty"`
+}
+
+// Succeeded reports whether the last attempt completed.
+func (r *JobRun) Succeeded() bool {
+	return r.Job != nil && r.Job.Status == JobStatusCompleted
+}
+
+// JobRunner submits jobs and resubmits the ones that fail as their
+// RetrySpec allows, waiting for each attempt to finish. It keeps the
+// history of every logical job it has run.
+type JobRunner struct {
+	client *Client
+	wait   WaitOptions
+
+	mu   sync.Mutex
+	runs map[string]*JobRun
+}
+
+// NewJobRunner creates a runner that waits for each attempt with opts.
+func NewJobRunner(client *Client, opts WaitOptions) *JobRunner {
+	return &JobRunner{client: client, wait: opts, runs: make(map[string]*JobRun)}
+}
+
+// Run submits the spec and waits for it, resubmitting it after a failure
+// while its RetrySpec allows. A spec without one is submitted once.
+//
+// A job that still fails after its last attempt is returned without an
+// error; check Succeeded and StopReason. The error is set when an attempt
+// could not be submitted or waited for, along with the run so far.
+//
+// Example:
+//
+//	spec.Retry = &deparrow.RetrySpec{MaxAttempts: 3, BackoffSeconds: 30, MaxCredits: 20}
+//	run, err := deparrow.NewJobRunner(client, deparrow.WaitOptions{}).Run(ctx, spec)
+func (r *JobRunner) Run(ctx context.Context, spec *JobSpec) (*JobRun, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	policy := spec.Retry
+	if policy == nil {
+		policy = &RetrySpec{MaxAttempts: 1}
+	}
+
+	run := &JobRun{}
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if cost := calculateCreditCost(spec); policy.MaxCredits > 0 && run.TotalCost+cost > policy.MaxCredits {
+				r.stop(run, fmt.Sprintf("another attempt would cost %.2f credits, over the ceiling of %.2f", run.TotalCost+cost, policy.MaxCredits))
+				return run, nil
+			}
+			if err := sleepContext(ctx, policy.backoff(attempt-1)); err != nil {
+				return run, err
+			}
+		}
+
+		job, err := r.client.SubmitJob(ctx, r.attemptSpec(spec, run.ID))
+		if err != nil {
+			return run, fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+		record := JobAttempt{Number: attempt, JobID: job.ID, Status: job.Status, CreditCost: job.CreditCost, SubmittedAt: job.SubmittedAt}
+
+		finished, err := r.client.WaitForJobCompletion(ctx, job.ID, r.wait)
+		if finished != nil {
+			record.Status = finished.Status
+			if finished.Results != nil {
+				record.ExitCode = finished.Results.ExitCode
+			}
+		}
+		r.record(run, record, finished)
+		if err != nil {
+			return run, fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+
+		switch {
+		case record.Status == JobStatusCompleted:
+			return run, nil
+		case record.Status == JobStatusCancelled:
+			r.stop(run, "the job was cancelled")
+			return run, nil
+		case !policy.retries(record.ExitCode):
+			r.stop(run, fmt.Sprintf("exit code %d is not retried", record.ExitCode))
+			return run, nil
+		case attempt >= policy.MaxAttempts:
+			r.stop(run, fmt.Sprintf("failed all %d attempts", attempt))
+			return run, nil
+		}
+	}
+}
+
+// record adds an attempt to a run, registering the run on its first.
+func (r *JobRunner) record(run *JobRun, attempt JobAttempt, job *Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if run.ID == "" {
+		run.ID = attempt.JobID
+		r.runs[run.ID] = run
+	}
+	run.Attempts = append(run.Attempts, attempt)
+	run.TotalCost += attempt.CreditCost
+	if job != nil {
+		run.Job = job
+	}
+}
+
+// stop records why a run made no further attempt.
+func (r *JobRunner) stop(run *JobRun, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.StopReason = reason
+}
+
+// attemptSpec returns the spec to submit for an attempt: resubmissions
+// carry the first attempt's job ID in RetryOfLabel.
+func (r *JobRunner) attemptSpec(spec *JobSpec, firstJobID string) *JobSpec {
+	if firstJobID == "" {
+		return spec
+	}
+	attempt := *spec
+	attempt.Labels = make(map[string]string, len(spec.Labels)+1)
+	for k, v := range spec.Labels {
+		attempt.Labels[k] = v
+	}
+	attempt.Labels[RetryOfLabel] = firstJobID
+	return &attempt
+}
+
+// History returns a copy of a logical job's run by the job ID of its first
+// attempt.
+func (r *JobRunner) History(id string) (JobRun, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return JobRun{}, false
+	}
+	copied := *run
+	copied.Attempts = slices.Clone(run.Attempts)
+	return copied, true
+}
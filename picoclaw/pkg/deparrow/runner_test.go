@@ -0,0 +1,177 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingJobServer serves jobs that finish with the given exit codes in
+// turn, completing once they run out. It records the labels of each
+// submission.
+type failingJobServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	exitCodes []int
+	labels    []map[string]string
+}
+
+func newFailingJobServer(exitCodes ...int) *failingJobServer {
+	s := &failingJobServer{exitCodes: exitCodes}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch {
+		case r.URL.Path == "/api/v1/jobs/submit":
+			var req struct {
+				Spec JobSpec `json:"spec"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			s.labels = append(s.labels, req.Spec.Labels)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"job_id": fmt.Sprintf("job-%d", len(s.labels)), "credit_deducted": 2.0,
+			})
+		case strings.HasPrefix(r.URL.Path, "/api/v1/jobs/job-"):
+			var n int
+			fmt.Sscanf(r.URL.Path, "/api/v1/jobs/job-%d", &n)
+			status, code := "completed", 0
+			if n <= len(s.exitCodes) {
+				status, code = "failed", s.exitCodes[n-1]
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"job_id": fmt.Sprintf("job-%d", n), "status": status,
+				"results": map[string]interface{}{"exit_code": code},
+			})
+		}
+	}))
+	return s
+}
+
+func (s *failingJobServer) submissions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.labels)
+}
+
+var fastWait = WaitOptions{PollInterval: time.Millisecond}
+
+func TestJobRunner_RetriesUntilSuccess(t *testing.T) {
+	server := newFailingJobServer(137, 137)
+	defer server.Close()
+	runner := NewJobRunner(NewClient(server.URL, "test-token"), fastWait)
+
+	spec := &JobSpec{Image: "alpine", Labels: map[string]string{"team": "ml"}, Retry: &RetrySpec{MaxAttempts: 3}}
+	run, err := runner.Run(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !run.Succeeded() || len(run.Attempts) != 3 || run.TotalCost != 6 || run.StopReason != "" {
+		t.Fatalf("run = %+v", run)
+	}
+	if a := run.Attempts[0]; a.Number != 1 || a.Status != JobStatusFailed || a.ExitCode != 137 {
+		t.Errorf("first attempt = %+v", a)
+	}
+
+	// Resubmissions point back at the first attempt and keep the spec's labels
+	if _, ok := server.labels[0][RetryOfLabel]; ok {
+		t.Errorf("first attempt labels = %v", server.labels[0])
+	}
+	for _, labels := range server.labels[1:] {
+		if labels[RetryOfLabel] != "job-1" || labels["team"] != "ml" {
+			t.Errorf("resubmission labels = %v", labels)
+		}
+	}
+	if len(spec.Labels) != 1 {
+		t.Errorf("Run() should not modify the spec's labels: %v", spec.Labels)
+	}
+
+	history, ok := runner.History(run.ID)
+	if !ok || len(history.Attempts) != 3 || history.ID != "job-1" {
+		t.Errorf("History() = %+v, %v", history, ok)
+	}
+}
+
+func TestJobRunner_Stops(t *testing.T) {
+	tests := []struct {
+		name      string
+		exitCodes []int
+		retry     *RetrySpec
+		attempts  int
+		reason    string
+	}{
+		{"no retry spec", []int{1}, nil, 1, "failed all 1 attempts"},
+		{"out of attempts", []int{1, 1, 1}, &RetrySpec{MaxAttempts: 2}, 2, "failed all 2 attempts"},
+		{"exit code not retried", []int{137, 2}, &RetrySpec{MaxAttempts: 5, RetryOnExitCodes: []int{137}}, 2, "exit code 2 is not retried"},
+		{"credit ceiling", []int{1, 1, 1}, &RetrySpec{MaxAttempts: 5, MaxCredits: 4.5}, 2, "over the ceiling of 4.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFailingJobServer(tt.exitCodes...)
+			defer server.Close()
+
+			run, err := NewJobRunner(NewClient(server.URL, "test-token"), fastWait).
+				Run(context.Background(), &JobSpec{Image: "alpine", Retry: tt.retry})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if run.Succeeded() || len(run.Attempts) != tt.attempts || !strings.Contains(run.StopReason, tt.reason) {
+				t.Errorf("run = %+v, want %d attempts stopped with %q", run, tt.attempts, tt.reason)
+			}
+			if server.submissions() != tt.attempts {
+				t.Errorf("%d submissions, want %d", server.submissions(), tt.attempts)
+			}
+		})
+	}
+}
+
+func TestJobRunner_BackoffHonorsContext(t *testing.T) {
+	server := newFailingJobServer(1, 1)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	run, err := NewJobRunner(NewClient(server.URL, "test-token"), fastWait).
+		Run(ctx, &JobSpec{Image: "alpine", Retry: &RetrySpec{MaxAttempts: 3, BackoffSeconds: 60}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run() error = %v, want the deadline", err)
+	}
+	if len(run.Attempts) != 1 {
+		t.Errorf("run = %+v, want only the first attempt", run)
+	}
+}
+
+func TestRetrySpec_Backoff(t *testing.T) {
+	r := &RetrySpec{BackoffSeconds: 10, MaxBackoffSeconds: 30}
+	for retry, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 30 * time.Second, 8: 30 * time.Second} {
+		if got := r.backoff(retry); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", retry, got, want)
+		}
+	}
+	if got := (&RetrySpec{BackoffSeconds: 5}).backoff(3); got != 20*time.Second {
+		t.Errorf("uncapped backoff(3) = %s, want 20s", got)
+	}
+}
+
+func TestJobSpec_Validate_Retry(t *testing.T) {
+	spec := &JobSpec{Image: "alpine", Retry: &RetrySpec{
+		MaxAttempts: MaxRetryAttempts + 1, BackoffSeconds: -1, RetryOnExitCodes: []int{1, 256}, MaxCredits: -1,
+	}}
+	var verr *ValidationError
+	if err := spec.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want a *ValidationError", err)
+	}
+	for _, field := range []string{"retry.max_attempts", "retry.backoff_seconds", "retry.retry_on_exit_codes[1]", "retry.max_credits"} {
+		if verr.Field(field) == nil {
+			t.Errorf("no error for %s in %v", field, verr)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+const (
+	// SandboxUserID is the user a sandbox client acts as.
+	SandboxUserID = "sandbox-user"
+
+	// SandboxStartingBalance is the number of practice credits a sandbox starts with.
+	SandboxStartingBalance = 1000.0
+
+	// sandboxPromoCredits is the part of the starting balance given as an
+	// expiring promotion, so expiry warnings can be tried out.
+	sandboxPromoCredits = 150.0
+
+	// SandboxWatermark is prepended to every tool result produced in sandbox mode.
+	SandboxWatermark = "🧪 SANDBOX MODE — simulated network, no real credits are spent"
+
+	// sandboxBaseURL is never dialed; requests are answered in-process.
+	sandboxBaseURL = "http://sandbox.deparrow.local"
+
+	// sandboxAPIVersion is the API version the sandbox advertises.
+	sandboxAPIVersion = "1.2"
+
+	// sandboxUnpaidEarnings is what every sandbox node is owed when its
+	// earnings are finalized.
+	sandboxUnpaidEarnings = 12.5
+)
+
+// WithSandbox routes every request to an in-process simulation of the
+// Meta-OS API seeded with realistic fixtures. Nothing leaves the process
+// and no real credits are spent.
+func WithSandbox() ClientOption {
+	return func(c *Client) {
+		c.sandbox = newSandboxServer()
+		// Copy rather than mutate, the http.Client may be shared
+		c.httpClient = &http.Client{
+			Timeout:   c.httpClient.Timeout,
+			Transport: handlerTransport{handler: c.sandbox},
+		}
+	}
+}
+
+// sandboxFundingSources are the ways of paying a sandbox offers: a saved
+// card that pays at once, or through its provider's checkout, and a bank
+// transfer that needs a checkout.
+var sandboxFundingSources = []FundingSource{
+	{
+		ID:             "sandbox-card",
+		Type:           FundingSourceCard,
+		Label:          "Visa ending 4242",
+		Currency:       "USD",
+		CreditsPerUnit: 10,
+		MinAmount:      10,
+		MaxAmount:      10000,
+		Default:        true,
+		Provider:       "stripe",
+	},
+	{
+		ID:               "sandbox-bank",
+		Type:             FundingSourceBank,
+		Label:            "Bank transfer",
+		Currency:         "EUR",
+		CreditsPerUnit:   11,
+		MinAmount:        100,
+		RequiresCheckout: true,
+	},
+}
+
+// newSandboxServer creates a mock server that simulates the fixture
+// network for SandboxUserID.
+func newSandboxServer() *MockMetaOSServer {
+	now := time.Now()
+	m := newMockServer(SandboxUserID, "sandbox-", "sandbox")
+	m.Simulate = true
+	m.CheckoutBaseURL = "https://checkout.sandbox.deparrow.local"
+
+	promoExpiry := now.Add(5 * 24 * time.Hour)
+	m.buckets[SandboxUserID] = []CreditBucket{
+		{
+			ID:          "bucket-sandbox-starter",
+			Source:      CreditSourcePurchased,
+			Amount:      SandboxStartingBalance - sandboxPromoCredits,
+			Description: "Sandbox starting credits",
+			GrantedAt:   now,
+		},
+		{
+			ID:          "bucket-sandbox-promo",
+			Source:      CreditSourcePromo,
+			Amount:      sandboxPromoCredits,
+			Description: "Sandbox welcome promotion",
+			GrantedAt:   now,
+			ExpiresAt:   &promoExpiry,
+		},
+	}
+	m.fundingSources[SandboxUserID] = sandboxFundingSources
+
+	for _, f := range FixtureNodes(now.Add(-30 * time.Second)) {
+		m.nodes = append(m.nodes, &MockNode{Node: f.Node, PendingEarnings: sandboxUnpaidEarnings})
+	}
+
+	// One orchestrator per region
+	m.orchestrators = []Orchestrator{
+		{ID: "orch-us-east", Host: "orch-us-east.sandbox.deparrow.net", Port: 4222, Region: "us-east",
+			Status: NodeStatusOnline, Version: "1.6.0", Features: []string{"docker", "gpu", "wasm"}},
+		{ID: "orch-eu-west", Host: "orch-eu-west.sandbox.deparrow.net", Port: 4222, Region: "eu-west",
+			Status: NodeStatusOnline, Version: "1.6.0", Features: []string{"docker", "gpu"}},
+		{ID: "orch-ap-south", Host: "orch-ap-south.sandbox.deparrow.net", Port: 4222, Region: "ap-south",
+			Status: NodeStatusOnline, Version: "1.5.2", Features: []string{"docker", "wasm"}},
+	}
+	for i := range m.orchestrators {
+		for _, n := range m.nodes {
+			if n.region() == m.orchestrators[i].Region {
+				m.orchestrators[i].NodeCount++
+			}
+		}
+	}
+
+	// Node-agent release history, newest first
+	day := 24 * time.Hour
+	for _, arch := range []Architecture{ArchX86_64, ArchARM64} {
+		m.releases = append(m.releases,
+			NodeAgentRelease{Version: "1.6.0", Arch: arch, Channel: "stable", ReleasedAt: now.Add(-10 * day),
+				EarningsMultiplier: 1.10, MinSupported: "1.5.0",
+				Notes: "10% earnings bonus for nodes with verified uptime"},
+			NodeAgentRelease{Version: "1.5.2", Arch: arch, Channel: "stable", ReleasedAt: now.Add(-40 * day),
+				SecurityFix: true, EarningsMultiplier: 1.0, MinSupported: "1.5.0",
+				Notes: "Fixes a container escape in job isolation"},
+			NodeAgentRelease{Version: "1.5.0", Arch: arch, Channel: "stable", ReleasedAt: now.Add(-90 * day),
+				EarningsMultiplier: 1.0, MinSupported: "1.4.0",
+				Notes: "Faster job start-up and GPU telemetry"},
+			NodeAgentRelease{Version: "1.4.0", Arch: arch, Channel: "stable", ReleasedAt: now.Add(-180 * day),
+				EarningsMultiplier: 1.0, MinSupported: "1.3.0"},
+		)
+	}
+
+	// A recent promotion, for the activity feed
+	m.tierChanges = []Activity{{
+		ID:        "act-sandbox-tier-1",
+		Kind:      ActivityNodeTierChanged,
+		Timestamp: now.Add(-6 * time.Hour),
+		Summary:   "Node node-euw-arm-01 moved up from bronze to silver",
+		UserID:    SandboxUserID,
+		NodeID:    "node-euw-arm-01",
+		FromTier:  TierBronze,
+		ToTier:    TierSilver,
+	}}
+
+	m.transactions = append(m.transactions, Transaction{
+		ID:          "txn-sandbox-0001",
+		Type:        TransactionEarn,
+		Amount:      SandboxStartingBalance,
+		Description: "Sandbox starting credits",
+		Timestamp:   now,
+		ToUser:      SandboxUserID,
+	})
+
+	return m
+}
+
+// handlerTransport serves requests with an http.Handler in-process, so
+// the regular client code path is exercised end to end without a socket.
+// The body is piped from the handler as it writes, so streams arrive
+// frame by frame and a reader that falls behind or goes away holds up
+// the handler like a real connection.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The handler's context ends with the request's or when the body is
+	// closed, as a server's does when the connection goes away
+	ctx, cancel := context.WithCancel(req.Context())
+	r := req.Clone(ctx)
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+
+	pr, pw := io.Pipe()
+	stop := context.AfterFunc(ctx, func() {
+		pw.CloseWithError(ctx.Err())
+	})
+	w := &pipeResponseWriter{header: make(http.Header), body: pw, ready: make(chan struct{})}
+	go func() {
+		defer cancel()
+		t.handler.ServeHTTP(w, r)
+		w.WriteHeader(http.StatusOK)
+		if stop() {
+			pw.Close()
+		}
+	}()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	length := int64(-1)
+	if v := w.sent.Get("Content-Length"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			length = n
+		}
+	}
+	return &http.Response{
+		StatusCode:    w.status,
+		Status:        fmt.Sprintf("%d %s", w.status, http.StatusText(w.status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.sent,
+		Body:          &handlerBody{PipeReader: pr, cancel: cancel},
+		ContentLength: length,
+		Request:       req,
+	}, nil
+}
+
+// handlerBody is the body of a handlerTransport response. Closing it ends
+// the handler's context.
+type handlerBody struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (b *handlerBody) Close() error {
+	b.cancel()
+	return b.PipeReader.Close()
+}
+
+// pipeResponseWriter is the http.ResponseWriter of a handlerTransport. The
+// status and header are fixed by the first write or flush, as they are on
+// the wire.
+type pipeResponseWriter struct {
+	header http.Header
+	sent   http.Header
+	status int
+	body   *io.PipeWriter
+	ready  chan struct{}
+}
+
+func (w *pipeResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pipeResponseWriter) WriteHeader(status int) {
+	if w.sent != nil {
+		return
+	}
+	w.status = status
+	w.sent = w.header.Clone()
+	close(w.ready)
+}
+
+func (w *pipeResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// Flush sends the header; the body is unbuffered.
+func (w *pipeResponseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
+
+// NewSandboxClient creates a client wired to a fresh sandbox.
+// Use it to practice with the tools or to demo them without an account.
+func NewSandboxClient(opts ...ClientOption) *Client {
+	c := NewClient(sandboxBaseURL, "", append(opts, WithSandbox())...)
+	c.SetUserID(SandboxUserID)
+	return c
+}
+
+// IsSandbox reports whether the client talks to the simulated network.
+func (c *Client) IsSandbox() bool {
+	return c.sandbox != nil
+}
+
+// sandboxTool wraps a tool so its output is clearly marked as simulated.
+type sandboxTool struct {
+	tools.Tool
+}
+
+// Description returns the wrapped description with a sandbox notice.
+func (t *sandboxTool) Description() string {
+	return "[SANDBOX] " + t.Tool.Description()
+}
+
+// Execute runs the wrapped tool and watermarks its result.
+func (t *sandboxTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	result := t.Tool.Execute(ctx, args)
+	if result == nil {
+		return result
+	}
+	result.ForLLM = SandboxWatermark + "\n\n" + result.ForLLM
+	if result.ForUser != "" {
+		result.ForUser = SandboxWatermark + "\n\n" + result.ForUser
+	}
+	return result
+}
+
+// Ensure the sandbox can serve as an HTTP transport
+var _ http.RoundTripper = handlerTransport{}
+
+// Ensure streaming handlers can flush through the transport
+var _ http.Flusher = (*pipeResponseWriter)(nil)
+
+// Ensure the wrapper implements the Tool interface
+var _ tools.Tool = (*sandboxTool)(nil)
@@ -0,0 +1,232 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewSandboxClient(t *testing.T) {
+	client := NewSandboxClient()
+
+	if !client.IsSandbox() {
+		t.Error("IsSandbox() = false, want true")
+	}
+	if client.userID != SandboxUserID {
+		t.Errorf("userID = %s, want %s", client.userID, SandboxUserID)
+	}
+	if NewClient("http://localhost:8080", "test-token").IsSandbox() {
+		t.Error("regular client should not be in sandbox mode")
+	}
+}
+
+func TestSandbox_ServesFixtureNetwork(t *testing.T) {
+	nodes, err := NewSandboxClient().ListNodes(context.Background())
+	if err != nil {
+		t.Fatalf("ListNodes() error = %v", err)
+	}
+	fixtures := FixtureNodes(time.Now())
+	if len(nodes) != len(fixtures) {
+		t.Fatalf("ListNodes() returned %d nodes, want the %d fixtures", len(nodes), len(fixtures))
+	}
+	for i, f := range fixtures {
+		if nodes[i].ID != f.ID || nodes[i].Resources.CPU != f.Resources.CPU || nodes[i].Contribution.Rank != i+1 {
+			t.Errorf("node %d = %s with %d CPUs ranked %d, want fixture %s with %d CPUs",
+				i, nodes[i].ID, nodes[i].Resources.CPU, nodes[i].Contribution.Rank, f.ID, f.Resources.CPU)
+		}
+	}
+}
+
+func TestSandbox_JobLifecycle(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	job, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine", Command: []string{"echo", "hi"}})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+
+	balance, _ := client.GetCredits(ctx)
+	if balance.Balance != SandboxStartingBalance-job.CreditCost {
+		t.Errorf("Balance = %.2f, want %.2f", balance.Balance, SandboxStartingBalance-job.CreditCost)
+	}
+
+	for _, want := range []JobStatus{JobStatusRunning, JobStatusCompleted, JobStatusCompleted} {
+		got, err := client.GetJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+		if got.Status != want {
+			t.Fatalf("Status = %s, want %s", got.Status, want)
+		}
+	}
+
+	jobs, _ := client.ListJobs(ctx)
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("ListJobs() = %+v, want the submitted job", jobs)
+	}
+
+	if _, err := client.CancelJob(ctx, job.ID); err == nil {
+		t.Error("expected error cancelling a completed job")
+	}
+}
+
+func TestSandbox_CancelRefund(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	job, _ := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	refund, err := client.CancelJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if refund != job.CreditCost {
+		t.Errorf("refund = %.2f, want full refund %.2f for a pending job", refund, job.CreditCost)
+	}
+
+	balance, _ := client.GetCredits(ctx)
+	if balance.Balance != SandboxStartingBalance {
+		t.Errorf("Balance = %.2f, want %.2f", balance.Balance, SandboxStartingBalance)
+	}
+}
+
+func TestSandbox_ListJobsSortedWithCounts(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	gpu, _ := client.SubmitJob(ctx, &JobSpec{Image: "pytorch", Resources: &ResourceSpec{GPU: "1"}})
+	cheap, _ := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	cancelled, _ := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	if _, err := client.CancelJob(ctx, cancelled.ID); err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+
+	list, err := client.ListJobsPage(ctx, JobPageOptions{SortBy: JobSortCost})
+	if err != nil {
+		t.Fatalf("ListJobsPage() error = %v", err)
+	}
+	if len(list.Jobs) != 3 || list.Jobs[0].ID != gpu.ID {
+		t.Errorf("most expensive first = %+v, want %s", list.Jobs, gpu.ID)
+	}
+	if list.Counts[JobStatusPending] != 2 || list.Counts[JobStatusCancelled] != 1 {
+		t.Errorf("Counts = %v, want 2 pending and 1 cancelled", list.Counts)
+	}
+
+	list, _ = client.ListJobsPage(ctx, JobPageOptions{SortBy: JobSortCost, Ascending: true})
+	if list.Jobs[0].ID != cheap.ID {
+		t.Errorf("cheapest first = %s, want %s", list.Jobs[0].ID, cheap.ID)
+	}
+
+	if _, err := client.ListJobsPage(ctx, JobPageOptions{SortBy: "name"}); err == nil {
+		t.Error("expected error for an unsupported sort field")
+	}
+}
+
+func TestSandbox_OrchestratorRouting(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	job, err := client.SubmitJobTo(ctx, &JobSpec{Image: "alpine"}, "orch-eu-west")
+	if err != nil {
+		t.Fatalf("SubmitJobTo() error = %v", err)
+	}
+	if job.Orchestrator != "orch-eu-west" {
+		t.Errorf("Orchestrator = %s, want orch-eu-west", job.Orchestrator)
+	}
+
+	// Without a target the least loaded orchestrator is picked
+	job, _ = client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	if job.Orchestrator == "" || job.Orchestrator == "orch-eu-west" {
+		t.Errorf("Orchestrator = %q, want an idle orchestrator", job.Orchestrator)
+	}
+
+	if _, err := client.SubmitJobTo(ctx, &JobSpec{Image: "alpine"}, "orch-unknown"); err == nil {
+		t.Error("expected error for an unknown orchestrator")
+	}
+	balance, _ := client.GetCredits(ctx)
+	if want := SandboxStartingBalance - 2; balance.Balance != want {
+		t.Errorf("Balance = %.2f, want %.2f (rejected submission is not charged)", balance.Balance, want)
+	}
+}
+
+func TestSandbox_InsufficientCredits(t *testing.T) {
+	client := NewSandboxClient()
+
+	err := client.TransferCredits(context.Background(), "friend", SandboxStartingBalance+1)
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.Message != "Insufficient credits" {
+		t.Errorf("Message = %s", apiErr.Message)
+	}
+}
+
+func TestSandbox_Fixtures(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	nodes, err := client.ListNodes(ctx)
+	if err != nil || len(nodes) == 0 {
+		t.Fatalf("ListNodes() = %d nodes, err %v", len(nodes), err)
+	}
+
+	contrib, err := client.GetNodeContribution(ctx, nodes[0].ID)
+	if err != nil {
+		t.Fatalf("GetNodeContribution() error = %v", err)
+	}
+	if contrib.Rank != 1 {
+		t.Errorf("Rank = %d, want 1 for the top node", contrib.Rank)
+	}
+
+	entries, _ := client.GetLeaderboard(ctx, 3)
+	if len(entries) != 3 {
+		t.Errorf("leaderboard entries = %d, want 3", len(entries))
+	}
+
+	stats, _ := client.GetNetworkStats(ctx)
+	if stats.OnlineNodes >= stats.TotalNodes {
+		t.Error("fixtures should include an offline node")
+	}
+}
+
+func TestSandbox_ToolsAreWatermarked(t *testing.T) {
+	provider := NewToolsProvider(NewSandboxClient())
+
+	all := provider.GetAllTools()
+	if len(all) != len(ToolNames()) {
+		t.Fatalf("GetAllTools() = %d tools, want %d", len(all), len(ToolNames()))
+	}
+
+	for _, tool := range all {
+		if !contains(tool.Description(), "[SANDBOX]") {
+			t.Errorf("%s description is not marked as sandbox", tool.Name())
+		}
+	}
+
+	canRun := provider.GetPlanningTools()[0]
+	result := canRun.Execute(context.Background(), map[string]interface{}{
+		"resources": "8×A100, 1 day",
+	})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if !contains(result.ForLLM, SandboxWatermark) {
+		t.Errorf("result is not watermarked:\n%s", result.ForLLM)
+	}
+	if !contains(result.ForLLM, "us-east") {
+		t.Errorf("expected fixture region us-east:\n%s", result.ForLLM)
+	}
+}
+
+func TestToolsProvider_NotWatermarkedOutsideSandbox(t *testing.T) {
+	provider := NewToolsProvider(NewClient("http://localhost:8080", "test-token"))
+
+	for _, tool := range provider.GetAllTools() {
+		if contains(tool.Description(), "[SANDBOX]") {
+			t.Errorf("%s should not be marked as sandbox", tool.Name())
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// ScheduleTool lets the agent set up jobs that run on a recurring schedule.
+type ScheduleTool struct {
+	client *Client
+}
+
+// NewScheduleTool creates a new job schedule tool.
+func NewScheduleTool(client *Client) *ScheduleTool {
+	return &ScheduleTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *ScheduleTool) Name() string {
+	return "deparrow_schedule"
+}
+
+// Description returns the tool description.
+func (t *ScheduleTool) Description() string {
+	return `Run DEparrow jobs on a recurring schedule, e.g. a nightly batch or an hourly scrape.
+
+Actions:
+- create: schedule a job (same job parameters as 'deparrow_submit_job'
+  plus cron, and optionally name, timezone, max_concurrent_runs, budget
+  and budget_window)
+- list: show schedules, their next run and how much of their budget is used
+- delete: stop a schedule (schedule_id); runs already submitted carry on
+
+cron is a five-field expression (minute hour day-of-month month
+day-of-week), e.g. "0 2 * * *" for every night at 02:00 or "*/15 * * * *"
+for every 15 minutes. It is evaluated in timezone (UTC by default).
+
+A run is skipped, not queued, when max_concurrent_runs runs (1 by
+default) are still pending or running, or when it would take the
+schedule over its budget of credits per budget_window. Each run is
+charged like a normal job submission.
+`
+}
+
+// Parameters returns the JSON schema for tool parameters: those of the job
+// submission tool plus the schedule's own.
+func (t *ScheduleTool) Parameters() map[string]interface{} {
+	params := NewJobTool(t.client).Parameters()
+	delete(params, "required")
+	properties := params["properties"].(map[string]interface{})
+	delete(properties, "orchestrator")
+	delete(properties, "wait")
+
+	properties["action"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{"create", "list", "delete"},
+		"description": "Action to perform",
+		"default":     "list",
+	}
+	properties["schedule_id"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Schedule to delete (for delete)",
+	}
+	properties["cron"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Five-field cron expression, e.g. '0 2 * * *' (for create)",
+	}
+	properties["name"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Name to recognize the schedule by (for create)",
+	}
+	properties["timezone"] = map[string]interface{}{
+		"type":        "string",
+		"description": "IANA timezone the cron expression is evaluated in, e.g. 'Europe/Berlin' (default: UTC)",
+	}
+	properties["max_concurrent_runs"] = map[string]interface{}{
+		"type":        "integer",
+		"description": "Runs that may be active at once; further runs are skipped",
+		"default":     DefaultMaxConcurrentRuns,
+		"minimum":     1,
+	}
+	properties["budget"] = map[string]interface{}{
+		"type":        "number",
+		"description": "Credits the schedule may spend per budget_window (default: no limit)",
+	}
+	properties["budget_window"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{string(IntervalDaily), string(IntervalWeekly), string(IntervalMonthly)},
+		"description": "Period the budget applies to",
+		"default":     string(IntervalDaily),
+	}
+	return params
+}
+
+// Execute runs the job schedule tool.
+func (t *ScheduleTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	action, _ := args["action"].(string)
+	switch action {
+	case "", "list":
+		return t.list(ctx)
+	case "create":
+		return t.create(ctx, args)
+	case "delete":
+		scheduleID, _ := args["schedule_id"].(string)
+		return t.delete(ctx, scheduleID)
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
+	}
+}
+
+// create schedules a job from the tool's arguments.
+func (t *ScheduleTool) create(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	cron, _ := args["cron"].(string)
+	if strings.TrimSpace(cron) == "" {
+		return tools.ErrorResult("cron parameter is required to create a schedule")
+	}
+	spec, err := buildJobSpec(t.client, args)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	if result := checkJobSpend(t.client, spec); result != nil {
+		return result
+	}
+
+	req := &JobScheduleRequest{Cron: cron, Spec: spec}
+	req.Name, _ = args["name"].(string)
+	req.Timezone, _ = args["timezone"].(string)
+	if runs, ok := args["max_concurrent_runs"].(float64); ok {
+		req.MaxConcurrentRuns = int(runs)
+	} else if runs, ok := args["max_concurrent_runs"].(int); ok {
+		req.MaxConcurrentRuns = runs
+	}
+	if budget, ok := args["budget"].(float64); ok && budget > 0 {
+		req.BudgetPerWindow = budget
+		req.BudgetWindow = IntervalDaily
+		if window, _ := args["budget_window"].(string); window != "" {
+			req.BudgetWindow = StandingOrderInterval(window)
+		}
+	}
+
+	schedule, err := t.client.CreateSchedule(ctx, req)
+	if err != nil {
+		return failureResult("create schedule", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("🗓️  Job Scheduled\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(formatSchedule(schedule))
+	if runs, err := NextScheduleRuns(schedule.Cron, schedule.Timezone, time.Now(), 3); err == nil {
+		result.WriteString("\nNext runs:\n")
+		for _, run := range runs {
+			result.WriteString(fmt.Sprintf("  • %s\n", run.Format("Mon 2006-01-02 15:04 MST")))
+		}
+	}
+	result.WriteString(fmt.Sprintf("\nEach run costs about %.2f credits.", calculateCreditCost(spec)))
+	result.WriteString(fmt.Sprintf("\nUse 'deparrow_schedule' with action='delete' and schedule_id='%s' to stop it.", schedule.ID))
+	return tools.UserResult(result.String())
+}
+
+// list displays the user's job schedules.
+func (t *ScheduleTool) list(ctx context.Context) *tools.ToolResult {
+	schedules, err := t.client.ListSchedules(ctx)
+	if err != nil {
+		return failureResult("get schedules", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("🗓️  Job Schedules\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	if len(schedules) == 0 {
+		result.WriteString("No schedules.\n\n")
+		result.WriteString("💡 Schedules submit a job on a cron expression, e.g. a nightly\n")
+		result.WriteString("   batch. Create one with action='create'.")
+		return tools.UserResult(result.String())
+	}
+
+	for i := range schedules {
+		result.WriteString(formatSchedule(&schedules[i]))
+		result.WriteString("\n")
+	}
+	result.WriteString(fmt.Sprintf("📊 %d schedules", len(schedules)))
+	return tools.UserResult(result.String())
+}
+
+// delete stops a schedule.
+func (t *ScheduleTool) delete(ctx context.Context, scheduleID string) *tools.ToolResult {
+	if scheduleID == "" {
+		return tools.ErrorResult("schedule_id is required to delete a schedule")
+	}
+
+	schedule, err := t.client.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return failureResult("get schedule", err)
+	}
+	if err := t.client.DeleteSchedule(ctx, scheduleID); err != nil {
+		return failureResult("delete schedule", err)
+	}
+
+	result := fmt.Sprintf("🗑️  Schedule %s deleted after %d runs.", schedule.ID, schedule.Runs)
+	if schedule.ActiveRuns > 0 {
+		result += fmt.Sprintf("\n%d run(s) still active carry on; cancel them with 'deparrow_cancel_job'.", schedule.ActiveRuns)
+	}
+	return tools.UserResult(result)
+}
+
+// formatSchedule renders a schedule's settings and state.
+func formatSchedule(s *JobSchedule) string {
+	var b strings.Builder
+	name := s.ID
+	if s.Name != "" {
+		name = fmt.Sprintf("%s (%s)", s.Name, s.ID)
+	}
+	timezone := s.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	b.WriteString(fmt.Sprintf("🔁 %s — %s %s\n", name, s.Cron, timezone))
+	if s.Spec != nil {
+		b.WriteString(fmt.Sprintf("   Image: %s\n", s.Spec.Image))
+	}
+	b.WriteString(fmt.Sprintf("   Next: %s · %d runs, %d skipped · %d/%d active\n",
+		s.NextRunAt.Format("2006-01-02 15:04"), s.Runs, s.SkippedRuns, s.ActiveRuns, s.MaxConcurrentRuns))
+	if s.BudgetPerWindow > 0 {
+		b.WriteString(fmt.Sprintf("   Budget: %.2f of %.2f credits %s\n", s.SpentInWindow, s.BudgetPerWindow, s.BudgetWindow))
+	}
+	if s.LastJobID != "" {
+		b.WriteString(fmt.Sprintf("   Last job: %s\n", s.LastJobID))
+	}
+	if s.LastSkipReason != "" {
+		b.WriteString(fmt.Sprintf("   ⚠️  Last run skipped: %s\n", s.LastSkipReason))
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/adhocore/gronx"
+)
+
+// schedulesPath is the collection endpoint for job schedules.
+const schedulesPath = "/api/v1/schedules"
+
+// ScheduleLabel is the label carrying the schedule ID on every job a
+// schedule submits, so its runs can be told apart from one-off jobs.
+const ScheduleLabel = "schedule_id"
+
+// CreateSchedule sets up a job to be submitted on a cron schedule.
+func (c *Client) CreateSchedule(ctx context.Context, req *JobScheduleRequest) (*JobSchedule, error) {
+	if !gronx.IsValid(req.Cron) {
+		return nil, fmt.Errorf("invalid cron expression %q", req.Cron)
+	}
+	if _, err := scheduleLocation(req.Timezone); err != nil {
+		return nil, err
+	}
+	if req.Spec == nil {
+		return nil, fmt.Errorf("job spec is required")
+	}
+	if err := req.Spec.Validate(); err != nil {
+		return nil, err
+	}
+	if req.MaxConcurrentRuns < 0 {
+		return nil, fmt.Errorf("max concurrent runs must not be negative")
+	}
+	if req.BudgetPerWindow < 0 {
+		return nil, fmt.Errorf("budget must not be negative")
+	}
+	if req.BudgetPerWindow > 0 && !req.BudgetWindow.Valid() {
+		return nil, fmt.Errorf("unsupported budget window %q", req.BudgetWindow)
+	}
+	if err := c.requireFeatures(ctx, req.Spec.features()); err != nil {
+		return nil, err
+	}
+
+	var result JobSchedule
+	if err := c.doRequest(ctx, http.MethodPost, schedulesPath, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListSchedules retrieves the authenticated user's job schedules.
+func (c *Client) ListSchedules(ctx context.Context) ([]JobSchedule, error) {
+	var result struct {
+		Schedules []JobSchedule `json:"schedules"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, schedulesPath, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Schedules == nil {
+		result.Schedules = []JobSchedule{}
+	}
+	return result.Schedules, nil
+}
+
+// GetSchedule retrieves a single job schedule.
+func (c *Client) GetSchedule(ctx context.Context, scheduleID string) (*JobSchedule, error) {
+	var result JobSchedule
+	if err := c.doRequest(ctx, http.MethodGet, schedulePath(scheduleID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteSchedule stops a schedule. Runs already submitted carry on.
+func (c *Client) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	return c.doRequest(ctx, http.MethodDelete, schedulePath(scheduleID), nil, nil)
+}
+
+func schedulePath(scheduleID string) string {
+	return schedulesPath + "/" + url.PathEscape(scheduleID)
+}
+
+// NextScheduleRuns returns the next n times a cron expression fires after
+// the given time, in the timezone (UTC when empty).
+func NextScheduleRuns(cron, timezone string, after time.Time, n int) ([]time.Time, error) {
+	loc, err := scheduleLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]time.Time, 0, n)
+	t := after.In(loc)
+	for len(runs) < n {
+		next, err := gronx.NextTickAfter(cron, t, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", cron, err)
+		}
+		runs = append(runs, next)
+		t = next
+	}
+	return runs, nil
+}
+
+func scheduleLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", timezone)
+	}
+	return loc, nil
+}
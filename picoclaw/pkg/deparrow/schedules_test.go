@@ -0,0 +1,171 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextScheduleRuns(t *testing.T) {
+	after := time.Date(2026, time.March, 1, 1, 30, 0, 0, time.UTC)
+	runs, err := NextScheduleRuns("0 2 * * *", "", after, 3)
+	if err != nil {
+		t.Fatalf("NextScheduleRuns() error = %v", err)
+	}
+	for i, run := range runs {
+		want := time.Date(2026, time.March, 1+i, 2, 0, 0, 0, time.UTC)
+		if !run.Equal(want) {
+			t.Errorf("run %d = %s, want %s", i, run, want)
+		}
+	}
+
+	if _, err := NextScheduleRuns("0 2 * *", "", after, 1); err == nil {
+		t.Error("NextScheduleRuns() with four fields should fail")
+	}
+	if _, err := NextScheduleRuns("0 2 * * *", "Mars/Olympus", after, 1); err == nil {
+		t.Error("NextScheduleRuns() with an unknown timezone should fail")
+	}
+}
+
+func TestCreateSchedule_Validation(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "test-token")
+	spec := &JobSpec{Image: "alpine"}
+
+	for name, req := range map[string]*JobScheduleRequest{
+		"bad cron":         {Cron: "every night", Spec: spec},
+		"no spec":          {Cron: "0 2 * * *"},
+		"negative runs":    {Cron: "0 2 * * *", Spec: spec, MaxConcurrentRuns: -1},
+		"no budget window": {Cron: "0 2 * * *", Spec: spec, BudgetPerWindow: 10},
+		"bad timezone":     {Cron: "0 2 * * *", Spec: spec, Timezone: "Nowhere"},
+	} {
+		if _, err := client.CreateSchedule(context.Background(), req); err == nil {
+			t.Errorf("%s: CreateSchedule() should fail", name)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("%d requests sent for invalid schedules", requests)
+	}
+}
+
+// runSandboxSchedules runs the sandbox's schedules as if the clock read now.
+func runSandboxSchedules(client *Client, now time.Time) {
+	client.sandbox.mu.Lock()
+	defer client.sandbox.mu.Unlock()
+	client.sandbox.runSchedules(now)
+}
+
+func TestScheduleTool_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewScheduleTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{
+		"action": "create",
+		"name":   "nightly",
+		"cron":   "0 2 * * *",
+		"image":  "python:3.11-slim",
+	})
+	if result.IsError {
+		t.Fatalf("create failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Next runs:") || !strings.Contains(result.ForLLM, "02:00") {
+		t.Errorf("result should list the next runs:\n%s", result.ForLLM)
+	}
+
+	schedules, err := client.ListSchedules(ctx)
+	if err != nil || len(schedules) != 1 {
+		t.Fatalf("ListSchedules() = %v, %v", schedules, err)
+	}
+	schedule := schedules[0]
+	if schedule.MaxConcurrentRuns != DefaultMaxConcurrentRuns || schedule.NextRunAt.Hour() != 2 {
+		t.Errorf("schedule = %+v", schedule)
+	}
+
+	// The first run submits a job labelled with the schedule
+	runSandboxSchedules(client, schedule.NextRunAt)
+	got, err := client.GetSchedule(ctx, schedule.ID)
+	if err != nil {
+		t.Fatalf("GetSchedule() error = %v", err)
+	}
+	if got.Runs != 1 || got.ActiveRuns != 1 || got.LastJobID == "" {
+		t.Fatalf("schedule after first run = %+v", got)
+	}
+	if job := client.sandbox.jobs[got.LastJobID]; job.Spec.Labels[ScheduleLabel] != schedule.ID {
+		t.Errorf("Labels = %v, want the schedule ID", job.Spec.Labels)
+	}
+
+	// The next night the first run is still going, so the second is skipped
+	runSandboxSchedules(client, got.NextRunAt)
+	got, _ = client.GetSchedule(ctx, schedule.ID)
+	if got.Runs != 1 || got.SkippedRuns != 1 || got.LastSkipReason == "" {
+		t.Errorf("schedule after overlapping run = %+v", got)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	if !strings.Contains(result.ForLLM, "nightly ("+schedule.ID+")") || !strings.Contains(result.ForLLM, "Last run skipped") {
+		t.Errorf("list should show the skipped run:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "delete", "schedule_id": schedule.ID})
+	if result.IsError {
+		t.Fatalf("delete failed: %s", result.ForLLM)
+	}
+	if schedules, _ := client.ListSchedules(ctx); len(schedules) != 0 {
+		t.Errorf("ListSchedules() after delete = %v", schedules)
+	}
+}
+
+func TestScheduleTool_Budget_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+	spec := &JobSpec{Image: "alpine"}
+	cost := calculateCreditCost(spec)
+
+	schedule, err := client.CreateSchedule(ctx, &JobScheduleRequest{
+		Cron:              "*/10 * * * *",
+		Spec:              spec,
+		MaxConcurrentRuns: 5,
+		BudgetPerWindow:   cost * 1.5,
+		BudgetWindow:      IntervalDaily,
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+
+	first := schedule.NextRunAt
+	runSandboxSchedules(client, first)
+	runSandboxSchedules(client, first.Add(10*time.Minute))
+	got, _ := client.GetSchedule(ctx, schedule.ID)
+	if got.Runs != 1 || got.SkippedRuns != 1 || !strings.Contains(got.LastSkipReason, "budget") {
+		t.Errorf("schedule within the day = %+v", got)
+	}
+
+	// A new day brings a fresh budget
+	runSandboxSchedules(client, first.Add(25*time.Hour))
+	got, _ = client.GetSchedule(ctx, schedule.ID)
+	if got.Runs != 2 || got.SpentInWindow != cost {
+		t.Errorf("schedule the next day = %+v", got)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "create", "image": "alpine"},
+		{"action": "create", "cron": "0 2 * * *"},
+		{"action": "delete"},
+		{"action": "delete", "schedule_id": "missing"},
+		{"action": "pause"},
+	} {
+		if result := NewScheduleTool(client).Execute(ctx, args); !result.IsError {
+			t.Errorf("Execute(%v) should fail:\n%s", args, result.ForLLM)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package deparrow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NodeRegionLabel is the node label that names the node's region.
+const NodeRegionLabel = "region"
+
+// NodeGPUVendorLabel is the node label that names the vendor of the
+// node's GPUs, e.g. "nvidia".
+const NodeGPUVendorLabel = "gpu_vendor"
+
+// constraintField returns the JSON name of the first scheduling
+// constraint the spec sets, or "" when it sets none.
+func (s *JobSpec) constraintField() string {
+	switch {
+	case len(s.NodeSelector) > 0:
+		return "spec.node_selector"
+	case len(s.Regions) > 0:
+		return "spec.regions"
+	case len(s.Architectures) > 0:
+		return "spec.architectures"
+	case len(s.ExcludeNodeIDs) > 0:
+		return "spec.exclude_node_ids"
+	}
+	return ""
+}
+
+// HasConstraints reports whether the spec limits which nodes may run it.
+func (s *JobSpec) HasConstraints() bool {
+	return s.constraintField() != ""
+}
+
+// AllowsNode reports whether the spec's scheduling constraints let the
+// node run it. Regions are compared case-insensitively against the
+// node's region label; resources are not considered.
+func (s *JobSpec) AllowsNode(n *Node) bool {
+	for _, id := range s.ExcludeNodeIDs {
+		if id == n.ID {
+			return false
+		}
+	}
+	for key, value := range s.NodeSelector {
+		if n.Labels[key] != value {
+			return false
+		}
+	}
+	if len(s.Regions) > 0 {
+		found := false
+		for _, region := range s.Regions {
+			if strings.EqualFold(region, n.Labels[NodeRegionLabel]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(s.Architectures) > 0 {
+		found := false
+		for _, arch := range s.Architectures {
+			if arch == n.Arch {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// describeConstraints renders the spec's scheduling constraints on one
+// line, such as "arm64 · eu-west or us-east · gpu=a100 · not node-3",
+// or "" when it has none.
+func describeConstraints(s *JobSpec) string {
+	var parts []string
+	if len(s.Architectures) > 0 {
+		archs := make([]string, len(s.Architectures))
+		for i, arch := range s.Architectures {
+			archs[i] = string(arch)
+		}
+		parts = append(parts, strings.Join(archs, " or "))
+	}
+	if len(s.Regions) > 0 {
+		parts = append(parts, strings.Join(s.Regions, " or "))
+	}
+	keys := make([]string, 0, len(s.NodeSelector))
+	for key := range s.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, s.NodeSelector[key]))
+	}
+	if len(s.ExcludeNodeIDs) > 0 {
+		parts = append(parts, "not "+strings.Join(s.ExcludeNodeIDs, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
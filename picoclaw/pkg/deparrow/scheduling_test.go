@@ -0,0 +1,200 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJobSpec_AllowsNode(t *testing.T) {
+	node := &Node{
+		ID:     "node-1",
+		Arch:   ArchARM64,
+		Labels: map[string]string{"region": "eu-west", "gpu_vendor": "nvidia"},
+	}
+	tests := []struct {
+		name string
+		spec JobSpec
+		want bool
+	}{
+		{"no constraints", JobSpec{}, true},
+		{"architecture", JobSpec{Architectures: []Architecture{ArchX86_64, ArchARM64}}, true},
+		{"wrong architecture", JobSpec{Architectures: []Architecture{ArchX86_64}}, false},
+		{"region", JobSpec{Regions: []string{"us-east", "EU-West"}}, true},
+		{"wrong region", JobSpec{Regions: []string{"us-east"}}, false},
+		{"selector", JobSpec{NodeSelector: map[string]string{"gpu_vendor": "nvidia"}}, true},
+		{"selector mismatch", JobSpec{NodeSelector: map[string]string{"gpu_vendor": "amd"}}, false},
+		{"missing label", JobSpec{NodeSelector: map[string]string{"tee": "sgx"}}, false},
+		{"excluded", JobSpec{ExcludeNodeIDs: []string{"node-1"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.AllowsNode(node); got != tt.want {
+			t.Errorf("%s: AllowsNode() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJobSpec_Validate_Constraints(t *testing.T) {
+	spec := &JobSpec{
+		Image:          "alpine",
+		Regions:        []string{"eu-west", " "},
+		Architectures:  []Architecture{ArchARM64, "riscv64"},
+		ExcludeNodeIDs: []string{""},
+	}
+	var verr *ValidationError
+	if err := spec.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want a *ValidationError", err)
+	}
+	for _, field := range []string{"regions[1]", "architectures[1]", "exclude_node_ids[0]"} {
+		if verr.Field(field) == nil {
+			t.Errorf("no error for %s in %v", field, verr)
+		}
+	}
+	if len(verr.Errors) != 3 {
+		t.Errorf("got %d errors, want 3: %v", len(verr.Errors), verr)
+	}
+}
+
+func TestSubmitJob_ConstraintsNeedServerSupport(t *testing.T) {
+	var spec map[string]interface{}
+	features := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/health":
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy", "api_version": "1.0", "features": features})
+		case "/api/v1/jobs/submit":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			spec, _ = req["spec"].(map[string]interface{})
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1"})
+		}
+	}))
+	defer server.Close()
+
+	job := &JobSpec{Image: "alpine", Architectures: []Architecture{ArchARM64}, Regions: []string{"eu-west"}}
+	_, err := NewClient(server.URL, "test-token").SubmitJob(context.Background(), job)
+	var unsupported *UnsupportedFeatureError
+	if !errors.As(err, &unsupported) || unsupported.Field != "spec.regions" {
+		t.Fatalf("SubmitJob() error = %v, want an unsupported spec.regions", err)
+	}
+
+	features = []string{FeatureSchedulingConstraints}
+	if _, err := NewClient(server.URL, "test-token").SubmitJob(context.Background(), job); err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+	if archs, _ := spec["architectures"].([]interface{}); len(archs) != 1 || archs[0] != "arm64" {
+		t.Errorf("spec = %v, want the architectures sent", spec)
+	}
+	if regions, _ := spec["regions"].([]interface{}); len(regions) != 1 || regions[0] != "eu-west" {
+		t.Errorf("spec = %v, want the regions sent", spec)
+	}
+}
+
+func TestJobTool_Constraints_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewJobTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{
+		"image":            "alpine",
+		"architectures":    []interface{}{"arm64"},
+		"regions":          []interface{}{"eu-west"},
+		"node_selector":    map[string]interface{}{"region": "eu-west"},
+		"exclude_node_ids": []interface{}{"node-euw-h100-01"},
+	})
+	if result.IsError {
+		t.Fatalf("submit failed: %s", result.ForLLM)
+	}
+	if want := "Placement: arm64 · eu-west · region=eu-west · not node-euw-h100-01"; !strings.Contains(result.ForLLM, want) {
+		t.Errorf("result missing %q:\n%s", want, result.ForLLM)
+	}
+
+	estimate, err := client.EstimateJob(ctx, &JobSpec{Image: "alpine", Architectures: []Architecture{ArchARM64}})
+	if err != nil {
+		t.Fatalf("EstimateJob() error = %v", err)
+	}
+	if estimate.EligibleNodes != 1 {
+		t.Errorf("EligibleNodes = %d, want only the arm64 node", estimate.EligibleNodes)
+	}
+
+	// Nothing in the sandbox is both arm64 and in us-east
+	result = tool.Execute(ctx, map[string]interface{}{
+		"image":         "alpine",
+		"architectures": []interface{}{"arm64"},
+		"regions":       []interface{}{"us-east"},
+	})
+	if !result.IsError {
+		t.Errorf("unsatisfiable constraints should fail:\n%s", result.ForLLM)
+	}
+}
+
+func TestBuildJobSpec_PlacementPreferences(t *testing.T) {
+	client := NewSandboxClient()
+	store, err := NewPreferencesStore(filepath.Join(t.TempDir(), "prefs.json"), nil)
+	if err != nil {
+		t.Fatalf("NewPreferencesStore() error = %v", err)
+	}
+	client.SetPreferences(store)
+	if err := store.Update(context.Background(), func(p *Preferences) {
+		p.DefaultRegion = "eu-west"
+		p.PreferredGPUVendor = "amd"
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// Nothing is added until the server is known to honour constraints
+	spec, err := buildJobSpec(client, map[string]interface{}{"image": "alpine", "gpu": "1"})
+	if err != nil {
+		t.Fatalf("buildJobSpec() error = %v", err)
+	}
+	if spec.HasConstraints() {
+		t.Errorf("spec = %+v, want no constraints before discovery", spec)
+	}
+	if _, err := client.ServerInfo(context.Background()); err != nil {
+		t.Fatalf("ServerInfo() error = %v", err)
+	}
+
+	// The preferences become scheduling constraints
+	spec, err = buildJobSpec(client, map[string]interface{}{"image": "alpine", "gpu": "1"})
+	if err != nil {
+		t.Fatalf("buildJobSpec() error = %v", err)
+	}
+	if len(spec.Regions) != 1 || spec.Regions[0] != "eu-west" {
+		t.Errorf("Regions = %v, want the default region", spec.Regions)
+	}
+	if spec.NodeSelector[NodeGPUVendorLabel] != "amd" {
+		t.Errorf("NodeSelector = %v, want the preferred GPU vendor", spec.NodeSelector)
+	}
+	if _, ok := spec.Labels["region"]; ok {
+		t.Errorf("Labels = %v, preferences should not be job labels", spec.Labels)
+	}
+
+	// Explicit regions and selectors win over them
+	spec, err = buildJobSpec(client, map[string]interface{}{
+		"image": "alpine", "gpu": "1",
+		"regions":       []interface{}{"ap-south"},
+		"node_selector": map[string]interface{}{NodeGPUVendorLabel: "nvidia"},
+	})
+	if err != nil {
+		t.Fatalf("buildJobSpec() error = %v", err)
+	}
+	if len(spec.Regions) != 1 || spec.Regions[0] != "ap-south" {
+		t.Errorf("Regions = %v", spec.Regions)
+	}
+	if spec.NodeSelector[NodeGPUVendorLabel] != "nvidia" {
+		t.Errorf("NodeSelector = %v", spec.NodeSelector)
+	}
+
+	// The GPU vendor only matters to jobs that use a GPU
+	spec, _ = buildJobSpec(client, map[string]interface{}{"image": "alpine"})
+	if len(spec.NodeSelector) != 0 {
+		t.Errorf("NodeSelector = %v for a CPU job", spec.NodeSelector)
+	}
+}
@@ -0,0 +1,88 @@
+package deparrow
+
+import (
+	"fmt"
+	"time"
+)
+
+// PricingClass is how a job is priced and whether it may be evicted.
+type PricingClass string
+
+const (
+	// PricingGuaranteed runs the job to completion at the list price; it
+	// is the default.
+	PricingGuaranteed PricingClass = "guaranteed"
+	// PricingSpot runs the job on idle capacity at a discount. The job is
+	// evicted when guaranteed work needs its node or the spot price rises
+	// above its bid, and goes back in the queue to run again from the
+	// start.
+	PricingSpot PricingClass = "spot"
+)
+
+// spotPriceRate is the share of the list price a spot job is estimated to
+// cost.
+const spotPriceRate = 0.4
+
+// EvictionReason is why a spot job was taken off its node.
+type EvictionReason string
+
+const (
+	// EvictionReclaimed is an eviction to make room for guaranteed work.
+	EvictionReclaimed EvictionReason = "reclaimed"
+	// EvictionOutbid is an eviction because the spot price rose above the
+	// job's bid.
+	EvictionOutbid EvictionReason = "outbid"
+)
+
+// JobEviction is a time a spot job was evicted and requeued.
+type JobEviction struct {
+	At     time.Time      `json:"evicted_at"`
+	NodeID string         `json:"node_id,omitempty"`
+	Reason EvictionReason `json:"reason"`
+}
+
+// Describe renders the eviction for a person.
+func (e JobEviction) Describe() string {
+	why := string(e.Reason)
+	switch e.Reason {
+	case EvictionReclaimed:
+		why = "node reclaimed for guaranteed work"
+	case EvictionOutbid:
+		why = "spot price rose above the bid"
+	}
+	if e.NodeID != "" {
+		return fmt.Sprintf("%s on %s: %s", e.At.Format("2006-01-02 15:04:05"), e.NodeID, why)
+	}
+	return fmt.Sprintf("%s: %s", e.At.Format("2006-01-02 15:04:05"), why)
+}
+
+// IsSpot reports whether the job runs in the spot pricing class.
+func (s *JobSpec) IsSpot() bool {
+	return s.PricingClass == PricingSpot
+}
+
+// spotPrice returns what a job costing cost at the list price is
+// estimated to cost in its pricing class. A spot job never costs more than
+// its bid.
+func spotPrice(spec *JobSpec, cost float64) float64 {
+	if !spec.IsSpot() {
+		return cost
+	}
+	cost *= spotPriceRate
+	if spec.MaxCreditBid > 0 {
+		cost = min(cost, spec.MaxCreditBid)
+	}
+	return cost
+}
+
+// describePricing renders a spot job's pricing for the submission result,
+// or "" for a guaranteed one.
+func describePricing(spec *JobSpec) string {
+	if !spec.IsSpot() {
+		return ""
+	}
+	if spec.MaxCreditBid > 0 {
+		return fmt.Sprintf("spot, bidding at most %.2f credits; it may be evicted and requeued", spec.MaxCreditBid)
+	}
+	return "spot; it may be evicted and requeued"
+}
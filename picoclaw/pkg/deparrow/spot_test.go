@@ -0,0 +1,108 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCalculateCreditCost_Spot(t *testing.T) {
+	spec := &JobSpec{Image: "alpine", Resources: &ResourceSpec{GPU: "1"}}
+	list := calculateCreditCost(spec)
+
+	spec.PricingClass = PricingSpot
+	if got, want := calculateCreditCost(spec), list*spotPriceRate; got != want {
+		t.Errorf("spot cost = %v, want %v", got, want)
+	}
+	spec.MaxCreditBid = 0.5
+	if got := calculateCreditCost(spec); got != 0.5 {
+		t.Errorf("spot cost with a lower bid = %v, want the bid", got)
+	}
+}
+
+func TestJobSpec_Validate_Pricing(t *testing.T) {
+	tests := map[string]struct {
+		spec  JobSpec
+		field string
+	}{
+		"unknown class":    {JobSpec{Image: "alpine", PricingClass: "cheap"}, "pricing_class"},
+		"negative bid":     {JobSpec{Image: "alpine", PricingClass: PricingSpot, MaxCreditBid: -1}, "max_credit_bid"},
+		"guaranteed bid":   {JobSpec{Image: "alpine", MaxCreditBid: 2}, "max_credit_bid"},
+		"spot with a bid":  {JobSpec{Image: "alpine", PricingClass: PricingSpot, MaxCreditBid: 2}, ""},
+		"explicit default": {JobSpec{Image: "alpine", PricingClass: PricingGuaranteed}, ""},
+	}
+	for name, tt := range tests {
+		err := tt.spec.Validate()
+		if tt.field == "" {
+			if err != nil {
+				t.Errorf("%s: Validate() = %v", name, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) || verr.Field(tt.field) == nil {
+			t.Errorf("%s: Validate() = %v, want an error for %s", name, err, tt.field)
+		}
+	}
+}
+
+func TestSubmitJob_SpotNeedsFeature(t *testing.T) {
+	submitted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy", "api_version": "1.1"})
+			return
+		}
+		submitted = true
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "test-token").SubmitJob(context.Background(), &JobSpec{Image: "alpine", PricingClass: PricingSpot})
+	var unsupported *UnsupportedFeatureError
+	if !errors.As(err, &unsupported) || unsupported.Feature != FeatureSpotPricing || unsupported.Field != "spec.pricing_class" {
+		t.Fatalf("SubmitJob() error = %v, want spot pricing unsupported", err)
+	}
+	if submitted {
+		t.Error("the job should not have been sent")
+	}
+}
+
+func TestJobTools_SpotEviction(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	result := NewJobTool(client).Execute(ctx, map[string]interface{}{
+		"image": "alpine", "pricing_class": "spot", "max_credit_bid": 5.0,
+	})
+	if result.IsError || !strings.Contains(result.ForLLM, "Pricing: spot, bidding at most 5.00 credits") {
+		t.Fatalf("submit result:\n%s", result.ForLLM)
+	}
+	var jobID string
+	for id := range client.sandbox.jobs {
+		jobID = id
+	}
+	if job := client.sandbox.jobs[jobID]; !job.Spec.IsSpot() || job.Spec.MaxCreditBid != 5 {
+		t.Errorf("spec = %+v", job.Spec)
+	}
+
+	status := NewJobStatusTool(client)
+	args := map[string]interface{}{"job_id": jobID}
+	status.Execute(ctx, args) // running
+	result = status.Execute(ctx, args)
+	if !strings.Contains(result.ForLLM, "Status: pending") || !strings.Contains(result.ForLLM, "Evicted 1 time(s)") ||
+		!strings.Contains(result.ForLLM, "reclaimed for guaranteed work") {
+		t.Errorf("evicted status:\n%s", result.ForLLM)
+	}
+
+	status.Execute(ctx, args) // running again
+	result = status.Execute(ctx, args)
+	if !strings.Contains(result.ForLLM, "Status: completed") || !strings.Contains(result.ForLLM, "Evicted 1 time(s)") {
+		t.Errorf("completed status:\n%s", result.ForLLM)
+	}
+}
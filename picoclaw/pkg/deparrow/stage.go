@@ -0,0 +1,433 @@
+package deparrow
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultIPFSAPI is the IPFS RPC API inputs are added through: that of an
+// IPFS daemon on the local machine.
+const DefaultIPFSAPI = "http://127.0.0.1:5001"
+
+// defaultS3Region is the region of S3 buckets whose config names none.
+const defaultS3Region = "us-east-1"
+
+// WithIPFSAPI sets the IPFS RPC API (a Kubo daemon or compatible pinning
+// service) StageInput adds content through.
+func WithIPFSAPI(apiURL string) ClientOption {
+	return func(c *Client) {
+		c.ipfsAPI = strings.TrimRight(strings.TrimSpace(apiURL), "/")
+	}
+}
+
+// StageOptions says where StageInput stores local data.
+type StageOptions struct {
+	// StorageSource is "ipfs" (the default) or "s3"
+	StorageSource string
+	// Path the input is mounted at in the container; defaults to
+	// /inputs/<name of the local file or directory>
+	Path string
+	// S3 names the bucket and the key, or key prefix for a directory, to
+	// upload to; the key defaults to the local name. Credentials left empty
+	// are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+	// AWS_SESSION_TOKEN, and are not copied into the returned input.
+	S3 *S3Config
+}
+
+// stagedFile is a file or directory under the path being staged.
+type stagedFile struct {
+	// Slash-separated path from the staged path's parent
+	rel string
+	abs string
+	dir bool
+}
+
+// StageInput uploads a local file or directory so jobs can read it, and
+// returns the input to put in a JobSpec. Content goes to IPFS through the
+// client's IPFS API, or to S3. Nothing is uploaded in sandbox mode.
+//
+// Example:
+//
+//	input, err := client.StageInput(ctx, "./dataset", deparrow.StageOptions{})
+//	if err != nil {
+//	    return err
+//	}
+//	spec.Inputs = append(spec.Inputs, *input)
+func (c *Client) StageInput(ctx context.Context, localPath string, opts StageOptions) (*InputSpec, error) {
+	files, err := listStagedFiles(localPath)
+	if err != nil {
+		return nil, err
+	}
+	name := files[0].rel
+
+	input := &InputSpec{StorageSource: opts.StorageSource, Path: opts.Path}
+	if input.Path == "" {
+		input.Path = "/inputs/" + name
+	}
+	if !path.IsAbs(input.Path) {
+		return nil, fmt.Errorf("mount path %q must be absolute", input.Path)
+	}
+
+	switch opts.StorageSource {
+	case "", "ipfs":
+		input.StorageSource = "ipfs"
+		if c.sandbox != nil {
+			input.Source, err = sandboxCID(files)
+		} else {
+			input.Source, err = c.addToIPFS(ctx, name, files)
+		}
+	case "s3":
+		if opts.S3 == nil || opts.S3.Bucket == "" {
+			return nil, fmt.Errorf("an S3 bucket is required to stage to S3")
+		}
+		input.S3Config, input.Source, err = c.uploadToS3(ctx, *opts.S3, files)
+	default:
+		return nil, fmt.Errorf("cannot stage inputs to %q, only to ipfs or s3", opts.StorageSource)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage %s: %w", localPath, err)
+	}
+	return input, nil
+}
+
+// listStagedFiles lists localPath and, for a directory, everything under
+// it, parents before their contents.
+func listStagedFiles(localPath string) ([]stagedFile, error) {
+	root, err := filepath.Abs(localPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []stagedFile{{rel: filepath.Base(root), abs: root}}, nil
+	}
+
+	parent := filepath.Dir(root)
+	var files []stagedFile
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			// Links and devices have no content to upload
+			return nil
+		}
+		rel, err := filepath.Rel(parent, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, stagedFile{rel: filepath.ToSlash(rel), abs: p, dir: d.IsDir()})
+		return nil
+	})
+	return files, err
+}
+
+// addToIPFS adds files through the IPFS RPC API, pinned, and returns the
+// CID of the first one: the staged file or directory.
+func (c *Client) addToIPFS(ctx context.Context, name string, files []stagedFile) (string, error) {
+	if c.ipfsAPI == "" {
+		return "", fmt.Errorf("no IPFS API is configured")
+	}
+
+	body, writer := io.Pipe()
+	mw := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(writeIPFSParts(mw, files))
+	}()
+	defer body.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.ipfsAPI+"/api/v0/add?pin=true&cid-version=1&progress=false", body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := c.transferClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer closeBody(resp.Body)
+	if resp.StatusCode >= 400 {
+		return "", transferError("IPFS API", resp)
+	}
+
+	// One line per added file and directory
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var added struct {
+			Name string `json:"Name"`
+			Hash string `json:"Hash"`
+		}
+		if err := dec.Decode(&added); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to parse IPFS response: %w", err)
+		}
+		if added.Name == name && added.Hash != "" {
+			return added.Hash, nil
+		}
+	}
+	return "", fmt.Errorf("IPFS API did not report the CID of %s", name)
+}
+
+// writeIPFSParts writes files as the multipart form the IPFS add call
+// takes: a part per file and directory, named by path.
+func writeIPFSParts(mw *multipart.Writer, files []stagedFile) error {
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition",
+			fmt.Sprintf(`form-data; name="file"; filename="%s"`, url.QueryEscape(file.rel)))
+		if file.dir {
+			header.Set("Content-Type", "application/x-directory")
+		} else {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if file.dir {
+			continue
+		}
+		if err := copyFile(part, file.abs); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// copyFile copies the file at p to w.
+func copyFile(w io.Writer, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// sandboxCID derives a stand-in CID from the staged content.
+func sandboxCID(files []stagedFile) (string, error) {
+	h := sha256.New()
+	for _, file := range files {
+		io.WriteString(h, file.rel+"\n")
+		if !file.dir {
+			if err := copyFile(h, file.abs); err != nil {
+				return "", err
+			}
+		}
+	}
+	return "bafysandbox" + hex.EncodeToString(h.Sum(nil))[:32], nil
+}
+
+// uploadToS3 uploads files to the bucket in cfg and returns the config and
+// source the input reads them from.
+func (c *Client) uploadToS3(ctx context.Context, cfg S3Config, files []stagedFile) (*S3Config, string, error) {
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+	creds := s3Credentials{accessKey: cfg.AccessKey, secretKey: cfg.SecretKey}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		creds = s3Credentials{
+			accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+	if (creds.accessKey == "" || creds.secretKey == "") && c.sandbox == nil {
+		return nil, "", fmt.Errorf("no S3 credentials: set them in the S3 config or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	// A file is stored at the key, a directory's files under it
+	key := strings.Trim(cfg.Key, "/")
+	if key == "" {
+		key = files[0].rel
+	}
+	name := files[0].rel
+	if files[0].dir {
+		key += "/"
+	}
+	cfg.Key = key
+
+	if c.sandbox == nil {
+		for _, file := range files {
+			if file.dir {
+				continue
+			}
+			objectKey := key
+			if files[0].dir {
+				objectKey = key + strings.TrimPrefix(file.rel, name+"/")
+			}
+			if err := c.putS3Object(ctx, cfg, creds, objectKey, file.abs); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	return &cfg, "s3://" + cfg.Bucket + "/" + key, nil
+}
+
+// s3Credentials sign S3 requests.
+type s3Credentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// putS3Object uploads the file at p to key in the bucket.
+func (c *Client) putS3Object(ctx context.Context, cfg S3Config, creds s3Credentials, key, p string) error {
+	// Signing needs the payload hash before the payload is sent
+	h := sha256.New()
+	if err := copyFile(h, p); err != nil {
+		return err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s3ObjectURL(cfg, key), f)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = info.Size()
+	if info.Size() == 0 {
+		req.Body = http.NoBody
+	}
+	signS3Request(req, creds, cfg.Region, hex.EncodeToString(h.Sum(nil)), time.Now())
+
+	resp, err := c.transferClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer closeBody(resp.Body)
+	if resp.StatusCode >= 400 {
+		return transferError("S3", resp)
+	}
+	return nil
+}
+
+// s3ObjectURL returns the URL of key in the bucket: path-style on a custom
+// endpoint such as MinIO, virtual-hosted on AWS.
+func s3ObjectURL(cfg S3Config, key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = s3Escape(s)
+	}
+	escaped := strings.Join(segments, "/")
+
+	if cfg.Endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", cfg.Bucket, cfg.Region, escaped)
+	}
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return endpoint + "/" + s3Escape(cfg.Bucket) + "/" + escaped
+}
+
+// s3Escape percent-encodes everything but unreserved characters, as AWS
+// signatures require.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// signS3Request signs req for S3 with AWS Signature Version 4, covering
+// the host and every header set on req.
+func signS3Request(req *http.Request, creds s3Credentials, region, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + creds.secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		creds.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// transferError describes an error response from a storage service, whose
+// error bodies are not shaped like the API's.
+func transferError(service string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Errorf("%s returned %s: %s", service, resp.Status, msg)
+	}
+	return fmt.Errorf("%s returned %s", service, resp.Status)
+}
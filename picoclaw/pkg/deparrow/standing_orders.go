@@ -0,0 +1,80 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// standingOrdersPath is the collection endpoint for standing orders.
+const standingOrdersPath = "/api/v1/credits/standing-orders"
+
+// CreateStandingOrder sets up a recurring credit transfer.
+func (c *Client) CreateStandingOrder(ctx context.Context, req *StandingOrderRequest) (*StandingOrder, error) {
+	if req.ToUserID == "" {
+		return nil, fmt.Errorf("recipient is required")
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if !req.Interval.Valid() {
+		return nil, fmt.Errorf("unsupported interval %q", req.Interval)
+	}
+
+	var result StandingOrder
+	if err := c.doRequest(ctx, http.MethodPost, standingOrdersPath, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListStandingOrders retrieves the authenticated user's standing orders,
+// including cancelled ones.
+func (c *Client) ListStandingOrders(ctx context.Context) ([]StandingOrder, error) {
+	var result struct {
+		Orders []StandingOrder `json:"standing_orders"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, standingOrdersPath, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Orders == nil {
+		result.Orders = []StandingOrder{}
+	}
+	return result.Orders, nil
+}
+
+// GetStandingOrder retrieves a single standing order.
+func (c *Client) GetStandingOrder(ctx context.Context, orderID string) (*StandingOrder, error) {
+	var result StandingOrder
+	if err := c.doRequest(ctx, http.MethodGet, standingOrderPath(orderID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateStandingOrder changes the amount, interval or description of a
+// standing order. Zero fields are left unchanged.
+func (c *Client) UpdateStandingOrder(ctx context.Context, orderID string, req *StandingOrderRequest) (*StandingOrder, error) {
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if req.Interval != "" && !req.Interval.Valid() {
+		return nil, fmt.Errorf("unsupported interval %q", req.Interval)
+	}
+
+	var result StandingOrder
+	if err := c.doRequest(ctx, http.MethodPut, standingOrderPath(orderID), req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelStandingOrder stops a standing order. Transfers already made are not reversed.
+func (c *Client) CancelStandingOrder(ctx context.Context, orderID string) error {
+	return c.doRequest(ctx, http.MethodDelete, standingOrderPath(orderID), nil, nil)
+}
+
+func standingOrderPath(orderID string) string {
+	return standingOrdersPath + "/" + url.PathEscape(orderID)
+}
@@ -0,0 +1,155 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStandingOrderInterval_Next(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		interval StandingOrderInterval
+		want     time.Time
+	}{
+		{IntervalDaily, time.Date(2026, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{IntervalWeekly, time.Date(2026, time.February, 7, 9, 0, 0, 0, time.UTC)},
+		{IntervalMonthly, time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tt.interval.Next(start); !got.Equal(tt.want) {
+			t.Errorf("%s.Next() = %s, want %s", tt.interval, got, tt.want)
+		}
+	}
+
+	if StandingOrderInterval("yearly").Valid() {
+		t.Error("yearly should not be a valid interval")
+	}
+}
+
+func TestClient_StandingOrderCRUD(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/credits/standing-orders":
+			var req StandingOrderRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.ToUserID != "teammate" || req.Amount != 25 || req.Interval != IntervalWeekly {
+				t.Errorf("unexpected create request %+v", req)
+			}
+			json.NewEncoder(w).Encode(StandingOrder{ID: "order-1", ToUserID: req.ToUserID, Amount: req.Amount,
+				Interval: req.Interval, Status: StandingOrderActive})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/credits/standing-orders":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"standing_orders": []StandingOrder{{ID: "order-1", Status: StandingOrderActive}},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/credits/standing-orders/order-1":
+			json.NewEncoder(w).Encode(StandingOrder{ID: "order-1", Amount: 25})
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/credits/standing-orders/order-1":
+			var req StandingOrderRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(StandingOrder{ID: "order-1", Amount: req.Amount})
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/credits/standing-orders/order-1":
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	ctx := context.Background()
+
+	order, err := client.CreateStandingOrder(ctx, &StandingOrderRequest{ToUserID: "teammate", Amount: 25, Interval: IntervalWeekly})
+	if err != nil {
+		t.Fatalf("CreateStandingOrder() error = %v", err)
+	}
+	if order.ID != "order-1" || order.Status != StandingOrderActive {
+		t.Errorf("CreateStandingOrder() = %+v", order)
+	}
+
+	orders, err := client.ListStandingOrders(ctx)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("ListStandingOrders() = %+v, %v", orders, err)
+	}
+
+	if order, err = client.GetStandingOrder(ctx, "order-1"); err != nil || order.Amount != 25 {
+		t.Errorf("GetStandingOrder() = %+v, %v", order, err)
+	}
+
+	if order, err = client.UpdateStandingOrder(ctx, "order-1", &StandingOrderRequest{Amount: 40}); err != nil || order.Amount != 40 {
+		t.Errorf("UpdateStandingOrder() = %+v, %v", order, err)
+	}
+
+	if err := client.CancelStandingOrder(ctx, "order-1"); err != nil {
+		t.Errorf("CancelStandingOrder() error = %v", err)
+	}
+}
+
+func TestClient_CreateStandingOrderValidation(t *testing.T) {
+	client := NewClient("http://localhost:8080", "test-token")
+	ctx := context.Background()
+
+	invalid := []*StandingOrderRequest{
+		{Amount: 10, Interval: IntervalWeekly},
+		{ToUserID: "teammate", Amount: 0, Interval: IntervalWeekly},
+		{ToUserID: "teammate", Amount: 10, Interval: "yearly"},
+	}
+	for _, req := range invalid {
+		if _, err := client.CreateStandingOrder(ctx, req); err == nil {
+			t.Errorf("CreateStandingOrder(%+v) expected error", req)
+		}
+	}
+}
+
+func TestSandbox_StandingOrders(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	// Backdated so three weekly runs have already fallen due
+	start := time.Now().Add(-15 * 24 * time.Hour)
+	order, err := client.CreateStandingOrder(ctx, &StandingOrderRequest{
+		ToUserID: "teammate",
+		Amount:   50,
+		Interval: IntervalWeekly,
+		StartAt:  &start,
+	})
+	if err != nil {
+		t.Fatalf("CreateStandingOrder() error = %v", err)
+	}
+	if order.Runs != 3 || !order.NextRun.After(time.Now()) {
+		t.Errorf("Runs = %d, NextRun = %s, want 3 runs and a future next run", order.Runs, order.NextRun)
+	}
+
+	balance, _ := client.GetCredits(ctx)
+	if balance.Balance != SandboxStartingBalance-150 {
+		t.Errorf("Balance = %.2f, want %.2f", balance.Balance, SandboxStartingBalance-150)
+	}
+
+	// Unaffordable runs are skipped and reported
+	huge := time.Now().Add(-time.Hour)
+	big, _ := client.CreateStandingOrder(ctx, &StandingOrderRequest{
+		ToUserID: "project", Amount: SandboxStartingBalance, Interval: IntervalMonthly, StartAt: &huge,
+	})
+	if big.Runs != 0 || big.LastError == "" {
+		t.Errorf("unaffordable order = %+v, want a failed run", big)
+	}
+
+	if err := client.CancelStandingOrder(ctx, order.ID); err != nil {
+		t.Fatalf("CancelStandingOrder() error = %v", err)
+	}
+	if err := client.CancelStandingOrder(ctx, order.ID); err == nil {
+		t.Error("expected error cancelling twice")
+	}
+
+	orders, _ := client.ListStandingOrders(ctx)
+	if len(orders) != 2 || orders[0].Status != StandingOrderCancelled {
+		t.Errorf("ListStandingOrders() = %+v", orders)
+	}
+}
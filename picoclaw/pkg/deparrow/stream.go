@@ -0,0 +1,174 @@
+package deparrow
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// acceptEncoding lists the response encodings the client can decode.
+	acceptEncoding = "br, gzip"
+
+	// maxErrorBodySize bounds how much of an error response is read.
+	maxErrorBodySize = 1 << 20
+)
+
+// decodeBody wraps the response body with a decompressor matching its
+// Content-Encoding. Because the client sets Accept-Encoding itself, the
+// transport no longer decompresses gzip transparently.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			if err == io.EOF {
+				// Empty body, nothing to decompress
+				return resp.Body, nil
+			}
+			return nil, fmt.Errorf("failed to read gzip response: %w", err)
+		}
+		return &decodedBody{Reader: zr, decoder: zr, body: resp.Body}, nil
+	case "br":
+		return &decodedBody{Reader: brotli.NewReader(resp.Body), body: resp.Body}, nil
+	default:
+		return nil, fmt.Errorf("unsupported response encoding: %s", encoding)
+	}
+}
+
+// decodedBody closes both the decompressor and the underlying body.
+type decodedBody struct {
+	io.Reader
+	decoder io.Closer
+	body    io.ReadCloser
+}
+
+// Close releases the decompressor and the underlying body.
+func (d *decodedBody) Close() error {
+	if d.decoder != nil {
+		d.decoder.Close()
+	}
+	return d.body.Close()
+}
+
+// closeBody drains what is left of a body so the connection can be reused,
+// then closes it.
+func closeBody(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxErrorBodySize))
+	body.Close()
+}
+
+// EachNode streams the node list, calling fn for every node as it is decoded.
+// Only one node is held in memory at a time, so even very large networks can
+// be scanned cheaply; the list is fetched a page at a time. Returning an
+// error from fn stops the scan.
+func (c *Client) EachNode(ctx context.Context, fn func(Node) error) error {
+	return c.eachPage(ctx, "/api/v1/nodes", "nodes", url.Values{}, PageOptions{}, func(dec *json.Decoder) error {
+		var node Node
+		if err := dec.Decode(&node); err != nil {
+			return &malformedResponseError{err}
+		}
+		return fn(node)
+	})
+}
+
+// EachJob streams the job list a page at a time, calling fn for every job
+// as it is decoded. Returning an error from fn stops the scan.
+func (c *Client) EachJob(ctx context.Context, fn func(Job) error) error {
+	return c.eachPage(ctx, "/api/v1/jobs", "jobs", url.Values{}, PageOptions{}, func(dec *json.Decoder) error {
+		var job Job
+		if err := dec.Decode(&job); err != nil {
+			return &malformedResponseError{err}
+		}
+		return fn(job)
+	})
+}
+
+// streamList fetches path and invokes each for every element of the array
+// stored under field in the top-level response object. Other fields are
+// decoded into the matching entry of others, or skipped.
+func (c *Client) streamList(
+	ctx context.Context, path, field string, others map[string]interface{}, each func(*json.Decoder) error,
+) error {
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+
+	dec := json.NewDecoder(resp.Body)
+	if err := expectDelim(dec, '{'); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return &malformedResponseError{err}
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return &malformedResponseError{err}
+		}
+		key, _ := tok.(string)
+
+		if target, ok := others[key]; ok && key != field {
+			if err := dec.Decode(target); err != nil {
+				return &malformedResponseError{err}
+			}
+			continue
+		}
+		if key != field {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return &malformedResponseError{err}
+			}
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return &malformedResponseError{err}
+		}
+		// A null list is the same as an empty one
+		if tok == nil {
+			continue
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return &malformedResponseError{fmt.Errorf("%s is not a list", field)}
+		}
+
+		for dec.More() {
+			if err := each(dec); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return &malformedResponseError{err}
+		}
+	}
+
+	return nil
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// nodeListPayload builds a /api/v1/nodes response with n nodes.
+func nodeListPayload(tb testing.TB, n int) []byte {
+	tb.Helper()
+	nodes := make([]Node, n)
+	for i := range nodes {
+		nodes[i] = Node{
+			ID:            fmt.Sprintf("node-%05d", i),
+			Arch:          ArchX86_64,
+			Status:        NodeStatusOnline,
+			LastSeen:      time.Unix(1700000000, 0).UTC(),
+			CreditsEarned: float64(i),
+			Resources:     &NodeResources{CPU: 16, Memory: "64Gi", GPU: 1, GPUModel: "A100"},
+			Labels:        map[string]string{"region": "us-east", "zone": "a"},
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"total":  n,
+		"nodes":  nodes,
+		"online": n,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+// compress encodes data with the given content encoding.
+func compress(tb testing.TB, encoding string, data []byte) []byte {
+	tb.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		return data
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// newEncodedServer serves body with the given Content-Encoding.
+func newEncodedServer(tb testing.TB, encoding string, body []byte) *httptest.Server {
+	tb.Helper()
+	payload := compress(tb, encoding, body)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		w.Write(payload)
+	}))
+}
+
+func TestClient_AcceptEncodingHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Encoding"); got != acceptEncoding {
+			t.Errorf("Accept-Encoding = %q, want %q", got, acceptEncoding)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "").Health(context.Background()); err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+}
+
+func TestClient_CompressedResponses(t *testing.T) {
+	for _, encoding := range []string{"", "gzip", "br"} {
+		t.Run("encoding="+encoding, func(t *testing.T) {
+			server := newEncodedServer(t, encoding, nodeListPayload(t, 50))
+			defer server.Close()
+
+			nodes, err := NewClient(server.URL, "").ListNodes(context.Background())
+			if err != nil {
+				t.Fatalf("ListNodes() error = %v", err)
+			}
+			if len(nodes) != 50 {
+				t.Fatalf("len(nodes) = %d, want 50", len(nodes))
+			}
+			if nodes[49].ID != "node-00049" || nodes[49].Labels["region"] != "us-east" {
+				t.Errorf("last node = %+v", nodes[49])
+			}
+		})
+	}
+}
+
+func TestClient_CompressedErrorResponse(t *testing.T) {
+	payload := compress(t, "gzip", []byte(`{"error":"Insufficient credits"}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "").GetCredits(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.Code != http.StatusPaymentRequired || apiErr.Message != "Insufficient credits" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+}
+
+func TestClient_UnsupportedEncoding(t *testing.T) {
+	server := newEncodedServer(t, "zstd", []byte(`{}`))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "").Health(context.Background()); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
+
+func TestClient_EachNode_StopsEarly(t *testing.T) {
+	server := newEncodedServer(t, "gzip", nodeListPayload(t, 100))
+	defer server.Close()
+
+	stop := errors.New("stop")
+	seen := 0
+	err := NewClient(server.URL, "").EachNode(context.Background(), func(Node) error {
+		seen++
+		if seen == 3 {
+			return stop
+		}
+		return nil
+	})
+
+	if err != stop {
+		t.Errorf("EachNode() error = %v, want stop", err)
+	}
+	if seen != 3 {
+		t.Errorf("seen = %d, want 3", seen)
+	}
+}
+
+func TestClient_EachJob_NullAndEmpty(t *testing.T) {
+	for _, body := range []string{`{"jobs":null}`, `{"jobs":[]}`, `{}`, ``} {
+		server := newEncodedServer(t, "", []byte(body))
+
+		jobs, err := NewClient(server.URL, "").ListJobs(context.Background())
+		if err != nil {
+			t.Errorf("ListJobs(%q) error = %v", body, err)
+		}
+		if len(jobs) != 0 {
+			t.Errorf("ListJobs(%q) = %d jobs, want 0", body, len(jobs))
+		}
+		server.Close()
+	}
+}
+
+func TestClient_EachJob_InvalidList(t *testing.T) {
+	server := newEncodedServer(t, "", []byte(`{"jobs":{"job_id":"x"}}`))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "").ListJobs(context.Background()); err == nil {
+		t.Error("expected error when jobs is not a list")
+	}
+}
+
+// benchmarkNodes10k measures decoding a 10k-node response.
+// Compare with BenchmarkListNodes_Buffered10k to see the allocation savings
+// of decoding straight from the stream.
+func benchmarkNodes10k(b *testing.B, encoding string) {
+	server := newEncodedServer(b, encoding, nodeListPayload(b, 10000))
+	defer server.Close()
+	client := NewClient(server.URL, "")
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		err := client.EachNode(ctx, func(Node) error {
+			count++
+			return nil
+		})
+		if err != nil || count != 10000 {
+			b.Fatalf("EachNode() = %d nodes, err %v", count, err)
+		}
+	}
+}
+
+func BenchmarkEachNode_Streaming10k(b *testing.B)     { benchmarkNodes10k(b, "") }
+func BenchmarkEachNode_StreamingGzip10k(b *testing.B) { benchmarkNodes10k(b, "gzip") }
+func BenchmarkEachNode_StreamingBr10k(b *testing.B)   { benchmarkNodes10k(b, "br") }
+
+// BenchmarkListNodes_Buffered10k reproduces the previous approach of reading
+// the whole body and unmarshalling it in one go, as a baseline.
+func BenchmarkListNodes_Buffered10k(b *testing.B) {
+	server := newEncodedServer(b, "", nodeListPayload(b, 10000))
+	defer server.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			b.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		var result struct {
+			Nodes []Node `json:"nodes"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil || len(result.Nodes) != 10000 {
+			b.Fatalf("unmarshal = %d nodes, err %v", len(result.Nodes), err)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package deparrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// templatePlaceholder matches ${NAME} placeholders, and $${NAME} escapes
+// that render as a literal ${NAME}.
+var templatePlaceholder = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// templateVarName is the form of a placeholder name.
+var templateVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// JobTemplate is a reusable job spec with ${VAR} placeholders in its
+// string fields, such as the image, command, environment and inputs.
+// Write $${VAR} for a literal ${VAR}, e.g. a variable the container's
+// shell expands.
+type JobTemplate struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Spec        *JobSpec `json:"spec"`
+	// Values used for variables a render does not set
+	Defaults  map[string]string `json:"defaults,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// UnresolvedVariablesError reports template variables a render left without a value.
+type UnresolvedVariablesError struct {
+	Template string
+	Names    []string
+}
+
+func (e *UnresolvedVariablesError) Error() string {
+	return fmt.Sprintf("template %s has no value for %s", e.Template, strings.Join(e.Names, ", "))
+}
+
+// Validate checks that the template has a name and an image, and that its
+// placeholders are well formed.
+func (t *JobTemplate) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if t.Spec == nil || strings.TrimSpace(t.Spec.Image) == "" {
+		return fmt.Errorf("template %s needs a spec with an image", t.Name)
+	}
+	for name := range t.Defaults {
+		if !templateVarName.MatchString(name) {
+			return fmt.Errorf("template %s: invalid default variable name %q", t.Name, name)
+		}
+	}
+	_, err := t.walk(func(s string) (string, error) {
+		for _, m := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			if !strings.HasPrefix(m[0], "$$") && !templateVarName.MatchString(m[1]) {
+				return "", fmt.Errorf("template %s: invalid placeholder %q", t.Name, m[0])
+			}
+		}
+		return s, nil
+	})
+	return err
+}
+
+// Variables returns the names of the variables the template uses, sorted.
+func (t *JobTemplate) Variables() []string {
+	seen := make(map[string]bool)
+	t.walk(func(s string) (string, error) {
+		for _, m := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			if !strings.HasPrefix(m[0], "$$") && templateVarName.MatchString(m[1]) {
+				seen[m[1]] = true
+			}
+		}
+		return s, nil
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render returns the template's spec with every placeholder replaced by its
+// value in params, or failing that in the template's defaults. It returns
+// an *UnresolvedVariablesError naming every variable left without a value.
+func (t *JobTemplate) Render(params map[string]string) (*JobSpec, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	missing := make(map[string]bool)
+	rendered, err := t.walk(func(s string) (string, error) {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+			if strings.HasPrefix(match, "$$") {
+				return match[1:]
+			}
+			name := match[2 : len(match)-1]
+			if v, ok := params[name]; ok {
+				return v
+			}
+			if v, ok := t.Defaults[name]; ok {
+				return v
+			}
+			missing[name] = true
+			return match
+		}), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, &UnresolvedVariablesError{Template: t.Name, Names: names}
+	}
+	return rendered, nil
+}
+
+// walk applies fn to every string in a copy of the spec, map keys
+// included, and returns the copy.
+func (t *JobTemplate) walk(fn func(string) (string, error)) (*JobSpec, error) {
+	if t.Spec == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(t.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template %s: %w", t.Name, err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode template %s: %w", t.Name, err)
+	}
+
+	doc, err = walkStrings(doc, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template %s: %w", t.Name, err)
+	}
+	var spec JobSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to decode template %s: %w", t.Name, err)
+	}
+	return &spec, nil
+}
+
+// walkStrings applies fn to every string of a decoded JSON value.
+func walkStrings(v interface{}, fn func(string) (string, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case []interface{}:
+		for i := range v {
+			item, err := walkStrings(v[i], fn)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = item
+		}
+		return v, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			key, err := fn(key)
+			if err != nil {
+				return nil, err
+			}
+			if out[key], err = walkStrings(item, fn); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// SetTemplates attaches the store the job template tool saves templates in.
+func (c *Client) SetTemplates(store *TemplateStore) {
+	c.templates = store
+}
+
+// TemplateStore keeps job templates by name in a local JSON file.
+type TemplateStore struct {
+	path      string
+	mu        sync.Mutex
+	templates map[string]*JobTemplate
+}
+
+// NewTemplateStore creates a store backed by the file at path.
+// A missing file is not an error. An empty path keeps templates in memory only.
+func NewTemplateStore(path string) (*TemplateStore, error) {
+	s := &TemplateStore{
+		path:      path,
+		templates: make(map[string]*JobTemplate),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read job templates: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.templates); err != nil {
+		return nil, fmt.Errorf("failed to parse job templates: %w", err)
+	}
+	return s, nil
+}
+
+// Save validates a template and stores it under its name, replacing any
+// template of the same name.
+func (s *TemplateStore) Save(t JobTemplate) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	t.CreatedAt, t.UpdatedAt = now, now
+	if existing, ok := s.templates[t.Name]; ok {
+		t.CreatedAt = existing.CreatedAt
+	}
+	s.templates[t.Name] = &t
+	return s.saveLocked()
+}
+
+// Get returns a copy of the template with the given name.
+func (s *TemplateStore) Get(name string) (*JobTemplate, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.templates[name]
+	if !ok {
+		return nil, false
+	}
+	copied := *t
+	return &copied, true
+}
+
+// List returns copies of all templates, sorted by name.
+func (s *TemplateStore) List() []JobTemplate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]JobTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Delete removes a template, reporting whether it existed.
+func (s *TemplateStore) Delete(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[name]; !ok {
+		return false, nil
+	}
+	delete(s.templates, name)
+	return true, s.saveLocked()
+}
+
+// saveLocked writes the templates to disk. The caller must hold the lock.
+func (s *TemplateStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create job templates directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.templates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal job templates: %w", err)
+	}
+
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
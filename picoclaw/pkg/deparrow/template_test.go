@@ -0,0 +1,171 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func trainTemplate() JobTemplate {
+	return JobTemplate{
+		Name: "train",
+		Spec: &JobSpec{
+			Image:   "python:${PY}-slim",
+			Command: []string{"sh", "-c", "python train.py --lr ${LR} --out $${HOME}/out"},
+			Env:     map[string]string{"DATASET": "${DATASET}", "${FLAG}": "1"},
+			Inputs:  []InputSpec{{StorageSource: "s3", Source: "s3://data/${DATASET}", Path: "/data"}},
+			Timeout: 600,
+		},
+		Defaults: map[string]string{"PY": "3.11", "FLAG": "FAST"},
+	}
+}
+
+func TestJobTemplate_Render(t *testing.T) {
+	tmpl := trainTemplate()
+
+	if got, want := tmpl.Variables(), []string{"DATASET", "FLAG", "LR", "PY"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+
+	spec, err := tmpl.Render(map[string]string{"LR": "0.01", "DATASET": "mnist", "PY": "3.12"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if spec.Image != "python:3.12-slim" {
+		t.Errorf("Image = %s, params should override defaults", spec.Image)
+	}
+	if spec.Command[2] != "python train.py --lr 0.01 --out ${HOME}/out" {
+		t.Errorf("Command = %q, want the escape kept as a literal placeholder", spec.Command[2])
+	}
+	if spec.Env["DATASET"] != "mnist" || spec.Env["FAST"] != "1" {
+		t.Errorf("Env = %v, want values and keys rendered", spec.Env)
+	}
+	if spec.Inputs[0].Source != "s3://data/mnist" || spec.Timeout != 600 {
+		t.Errorf("spec = %+v, want inputs rendered and numbers kept", spec)
+	}
+	if tmpl.Spec.Image != "python:${PY}-slim" {
+		t.Error("Render() must not change the template")
+	}
+}
+
+func TestJobTemplate_RenderUnresolved(t *testing.T) {
+	tmpl := trainTemplate()
+
+	_, err := tmpl.Render(map[string]string{"LR": "0.1"})
+	var unresolved *UnresolvedVariablesError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("Render() error = %v, want UnresolvedVariablesError", err)
+	}
+	if !reflect.DeepEqual(unresolved.Names, []string{"DATASET"}) {
+		t.Errorf("Names = %v, want [DATASET]", unresolved.Names)
+	}
+}
+
+func TestJobTemplate_Validate(t *testing.T) {
+	tests := map[string]JobTemplate{
+		"no name":          {Spec: &JobSpec{Image: "alpine"}},
+		"no image":         {Name: "t", Spec: &JobSpec{}},
+		"bad placeholder":  {Name: "t", Spec: &JobSpec{Image: "alpine", Command: []string{"echo", "${1X}"}}},
+		"bad default name": {Name: "t", Spec: &JobSpec{Image: "alpine"}, Defaults: map[string]string{"A-B": "x"}},
+	}
+	for name, tmpl := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := tmpl.Validate(); err == nil {
+				t.Error("Validate() should fail")
+			}
+		})
+	}
+}
+
+func TestTemplateStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	store, err := NewTemplateStore(path)
+	if err != nil {
+		t.Fatalf("NewTemplateStore() error = %v", err)
+	}
+	if err := store.Save(trainTemplate()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	first, _ := store.Get("train")
+
+	updated := trainTemplate()
+	updated.Description = "Train a model"
+	if err := store.Save(updated); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := NewTemplateStore(path)
+	if err != nil {
+		t.Fatalf("NewTemplateStore() error = %v", err)
+	}
+	got, ok := reloaded.Get("train")
+	if !ok || got.Description != "Train a model" || !got.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("reloaded = %+v, want the update with the original creation time", got)
+	}
+
+	if deleted, err := reloaded.Delete("train"); !deleted || err != nil {
+		t.Errorf("Delete() = %v, %v", deleted, err)
+	}
+	if len(reloaded.List()) != 0 {
+		t.Error("List() should be empty after delete")
+	}
+}
+
+func TestJobTemplateTool_SaveAndSubmit(t *testing.T) {
+	client := NewSandboxClient()
+	store, _ := NewTemplateStore("")
+	client.SetTemplates(store)
+	tool := NewJobTemplateTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{
+		"action": "save",
+		"name":   "sweep",
+		"spec": map[string]interface{}{
+			"image":   "python:3.11-slim",
+			"command": []interface{}{"python", "train.py", "--lr", "${LR}"},
+		},
+	})
+	if result.IsError || !strings.Contains(result.ForLLM, "Variables: LR") {
+		t.Fatalf("save = %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "submit", "name": "sweep"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "Missing values for LR") {
+		t.Errorf("submit without params = %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{
+		"action": "submit",
+		"name":   "sweep",
+		"params": map[string]interface{}{"LR": 0.001},
+	})
+	if result.IsError || !strings.Contains(result.ForLLM, "Job submitted from template sweep") {
+		t.Fatalf("submit = %s", result.ForLLM)
+	}
+	jobs, _ := client.ListJobs(ctx)
+	if len(jobs) != 1 || strings.Join(jobs[0].Spec.Command, " ") != "python train.py --lr 0.001" {
+		t.Errorf("jobs = %+v, want one job with the rendered command", jobs)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{
+		"action": "save",
+		"name":   "typo",
+		"spec":   map[string]interface{}{"image": "alpine", "comand": []interface{}{"ls"}},
+	})
+	if !result.IsError {
+		t.Error("a spec with unknown fields should be rejected")
+	}
+}
+
+func TestJobTemplateTool_NotEnabled(t *testing.T) {
+	tool := NewJobTemplateTool(NewClient("http://localhost:8080", "test-token"))
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError {
+		t.Error("the tool should report that templates are not enabled")
+	}
+}
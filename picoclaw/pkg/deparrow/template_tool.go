@@ -0,0 +1,317 @@
+package deparrow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// JobTemplateTool lets the agent save job specs as templates and reuse them.
+type JobTemplateTool struct {
+	client *Client
+}
+
+// NewJobTemplateTool creates a new job template tool.
+func NewJobTemplateTool(client *Client) *JobTemplateTool {
+	return &JobTemplateTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *JobTemplateTool) Name() string {
+	return "deparrow_job_template"
+}
+
+// Description returns the tool description.
+func (t *JobTemplateTool) Description() string {
+	return `Save DEparrow job specs as reusable templates and run them with different values.
+
+Templates are job specs with ${VAR} placeholders in any text field, for example:
+  {"image": "python:3.11", "command": ["python", "train.py", "--lr", "${LR}"],
+   "env": {"DATASET": "${DATASET}"}}
+Write $${VAR} to keep a literal ${VAR} for the container's shell.
+
+Actions:
+- list: show saved templates and their variables
+- show: show one template
+- save: save a template (name, spec, optional description and defaults)
+- render: fill in a template with params and show the resulting spec
+- submit: fill in a template with params and submit it as a job
+- delete: remove a template
+
+Rendering fails and names the missing variables when a placeholder has
+neither a param nor a default value.
+`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *JobTemplateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"list", "show", "save", "render", "submit", "delete"},
+				"description": "Action to perform",
+				"default":     "list",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Template name (required except for list)",
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": "What the template runs (for save)",
+			},
+			"spec": map[string]interface{}{
+				"type":        "object",
+				"description": "Job spec with ${VAR} placeholders: " + t.specFields() + " (for save)",
+			},
+			"defaults": map[string]interface{}{
+				"type":        "object",
+				"description": "Default values for template variables (for save)",
+			},
+			"params": map[string]interface{}{
+				"type":        "object",
+				"description": "Values for template variables (for render and submit)",
+			},
+		},
+	}
+}
+
+// specFields lists the fields a template's spec may set, the optional ones
+// only when the server supports them.
+func (t *JobTemplateTool) specFields() string {
+	fields := "image, command, env, resources, inputs, outputs, timeout, priority, labels"
+	if t.client.offers(FeatureVerifiedJobs) {
+		fields += ", verified, verification"
+	}
+	if t.client.offers(FeatureSchedulingConstraints) {
+		fields += ", architectures, regions, node_selector, exclude_node_ids"
+	}
+	if t.client.offers(FeatureSpotPricing) {
+		fields += ", pricing_class, max_credit_bid"
+	}
+	return fields
+}
+
+// Execute runs the job template tool.
+func (t *JobTemplateTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	store := t.client.templates
+	if store == nil {
+		return tools.ErrorResult("Job templates are not enabled for this agent")
+	}
+
+	action, _ := args["action"].(string)
+	if action == "" {
+		action = "list"
+	}
+	if action == "list" {
+		return tools.UserResult(formatTemplateList(store.List()))
+	}
+
+	name, _ := args["name"].(string)
+	if strings.TrimSpace(name) == "" {
+		return tools.ErrorResult("name parameter is required")
+	}
+
+	switch action {
+	case "save":
+		return t.save(store, name, args)
+	case "delete":
+		deleted, err := store.Delete(name)
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("Failed to delete template: %v", err))
+		}
+		if !deleted {
+			return tools.ErrorResult(fmt.Sprintf("Template %s not found", name))
+		}
+		return tools.UserResult(fmt.Sprintf("🗑️  Template %s deleted.", name))
+	case "show", "render", "submit":
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
+	}
+
+	tmpl, ok := store.Get(name)
+	if !ok {
+		return tools.ErrorResult(fmt.Sprintf("Template %s not found. Use action 'list' to see saved templates.", name))
+	}
+	if action == "show" {
+		return tools.UserResult(formatTemplate(tmpl))
+	}
+
+	params, err := stringMap(args["params"])
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Invalid params: %v", err))
+	}
+	spec, err := tmpl.Render(params)
+	if err != nil {
+		var unresolved *UnresolvedVariablesError
+		if errors.As(err, &unresolved) {
+			return tools.ErrorResult(fmt.Sprintf("Missing values for %s. Pass them in params.",
+				strings.Join(unresolved.Names, ", ")))
+		}
+		return tools.ErrorResult(err.Error())
+	}
+
+	if action == "render" {
+		data, _ := json.MarshalIndent(spec, "", "  ")
+		return tools.UserResult(fmt.Sprintf("📄 Rendered template %s:\n\n%s", name, data))
+	}
+	return t.submit(ctx, name, spec)
+}
+
+// save stores the template described by args.
+func (t *JobTemplateTool) save(store *TemplateStore, name string, args map[string]interface{}) *tools.ToolResult {
+	spec, err := decodeJobSpec(args["spec"])
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Invalid spec: %v", err))
+	}
+	if err := t.client.offersFeatures(spec.features()); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Invalid spec: %v", err))
+	}
+	defaults, err := stringMap(args["defaults"])
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Invalid defaults: %v", err))
+	}
+	description, _ := args["description"].(string)
+
+	tmpl := JobTemplate{Name: name, Description: description, Spec: spec, Defaults: defaults}
+	if err := store.Save(tmpl); err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to save template: %v", err))
+	}
+
+	result := fmt.Sprintf("✅ Template %s saved.", name)
+	if vars := tmpl.Variables(); len(vars) > 0 {
+		result += fmt.Sprintf("\n\nVariables: %s", strings.Join(vars, ", "))
+	}
+	return tools.UserResult(result)
+}
+
+// submit submits a rendered template.
+func (t *JobTemplateTool) submit(ctx context.Context, name string, spec *JobSpec) *tools.ToolResult {
+	if _, err := spec.verification(); err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	if result := checkJobSpend(t.client, spec); result != nil {
+		return result
+	}
+
+	job, err := t.client.SubmitJob(ctx, spec)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to submit job: %v", err))
+	}
+
+	result := fmt.Sprintf(
+		"Job submitted from template %s!\n\nJob ID: %s\nStatus: %s\nCredit Cost: %.2f\n",
+		name, job.ID, job.Status, job.CreditCost,
+	)
+	if job.Orchestrator != "" {
+		result += fmt.Sprintf("Orchestrator: %s\n", job.Orchestrator)
+	}
+	result += fmt.Sprintf("\nUse 'deparrow_job_status' with job_id='%s' to check progress.", job.ID)
+	return tools.UserResult(result)
+}
+
+// decodeJobSpec decodes a job spec given as an object or a JSON string,
+// rejecting unknown fields.
+func decodeJobSpec(v interface{}) (*JobSpec, error) {
+	var data []byte
+	switch v := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("spec is required")
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var spec JobSpec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// stringMap converts an object of scalar values to a string map.
+func stringMap(v interface{}) (map[string]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object")
+	}
+
+	m := make(map[string]string, len(obj))
+	for key, value := range obj {
+		switch value := value.(type) {
+		case string:
+			m[key] = value
+		case float64:
+			m[key] = strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			m[key] = strconv.FormatBool(value)
+		default:
+			return nil, fmt.Errorf("value of %s must be a string, number or boolean", key)
+		}
+	}
+	return m, nil
+}
+
+// formatTemplateList renders the saved templates.
+func formatTemplateList(list []JobTemplate) string {
+	if len(list) == 0 {
+		return "No job templates saved yet. Use action 'save' to create one."
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📋 Job Templates (%d)\n", len(list)))
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	for _, tmpl := range list {
+		result.WriteString(fmt.Sprintf("\n• %s — %s\n", tmpl.Name, tmpl.Spec.Image))
+		if tmpl.Description != "" {
+			result.WriteString(fmt.Sprintf("  %s\n", tmpl.Description))
+		}
+		if vars := tmpl.Variables(); len(vars) > 0 {
+			result.WriteString(fmt.Sprintf("  Variables: %s\n", strings.Join(vars, ", ")))
+		}
+	}
+	return result.String()
+}
+
+// formatTemplate renders one template with its spec.
+func formatTemplate(tmpl *JobTemplate) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📋 Job Template %s\n", tmpl.Name))
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	if tmpl.Description != "" {
+		result.WriteString(tmpl.Description + "\n\n")
+	}
+
+	for _, name := range tmpl.Variables() {
+		if value, ok := tmpl.Defaults[name]; ok {
+			result.WriteString(fmt.Sprintf("  ${%s} (default %q)\n", name, value))
+		} else {
+			result.WriteString(fmt.Sprintf("  ${%s} (required)\n", name))
+		}
+	}
+
+	data, _ := json.MarshalIndent(tmpl.Spec, "", "  ")
+	result.WriteString(fmt.Sprintf("\nSpec:\n%s\n", data))
+	result.WriteString(fmt.Sprintf("\nUpdated: %s\n", tmpl.UpdatedAt.Format("2006-01-02 15:04")))
+	return result.String()
+}
+
+// Ensure tool implements the Tool interface
+var _ tools.Tool = (*JobTemplateTool)(nil)
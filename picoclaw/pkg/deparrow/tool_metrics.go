@@ -0,0 +1,78 @@
+package deparrow
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// ToolMetricsHook is told about every execution of the tools a
+// ToolsProvider with metrics hands out. PrometheusMetrics implements it.
+// Implementations must be safe for concurrent use.
+type ToolMetricsHook interface {
+	ObserveTool(ToolMetric)
+}
+
+// ToolMetric describes one tool execution.
+type ToolMetric struct {
+	Tool     string
+	Duration time.Duration
+	// Error is set when the tool returned an error result
+	Error bool
+	// Credits deducted for the jobs the execution submitted
+	Credits float64
+}
+
+// creditTally adds up the credits deducted during one tool execution.
+// Credits added to a tally are added to the one it was started within too.
+type creditTally struct {
+	mu      sync.Mutex
+	credits float64
+	parent  *creditTally
+}
+
+// withCreditTally returns a context that tallies the credits deducted
+// under it, within the tally ctx already carries.
+func withCreditTally(ctx context.Context) (context.Context, *creditTally) {
+	parent, _ := ctx.Value(creditTallyKey).(*creditTally)
+	tally := &creditTally{parent: parent}
+	return context.WithValue(ctx, creditTallyKey, tally), tally
+}
+
+func (t *creditTally) add(credits float64) {
+	t.mu.Lock()
+	t.credits += credits
+	t.mu.Unlock()
+	if t.parent != nil {
+		t.parent.add(credits)
+	}
+}
+
+func (t *creditTally) total() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.credits
+}
+
+// meteredTool reports each execution of a tool to the metrics hook.
+type meteredTool struct {
+	tools.Tool
+	hook ToolMetricsHook
+}
+
+// Execute runs the wrapped tool, timing it and tallying the credits its
+// job submissions are charged.
+func (t *meteredTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	ctx, tally := withCreditTally(ctx)
+	start := time.Now()
+	result := t.Tool.Execute(ctx, args)
+	t.hook.ObserveTool(ToolMetric{
+		Tool:     t.Name(),
+		Duration: time.Since(start),
+		Error:    result == nil || result.IsError,
+		Credits:  tally.total(),
+	})
+	return result
+}
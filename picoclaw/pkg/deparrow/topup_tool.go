@@ -0,0 +1,139 @@
+package deparrow
+
+import (
+	"context"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// Limits on how long the top-up tool watches a checkout.
+const (
+	defaultTopUpWatch = 2 * time.Minute
+	maxTopUpWatch     = 10 * time.Minute
+)
+
+// TopUpTool buys credits through a payment provider's checkout and watches
+// for the payment to arrive.
+type TopUpTool struct {
+	client *Client
+}
+
+// NewTopUpTool creates a new credit top-up tool.
+func NewTopUpTool(client *Client) *TopUpTool {
+	return &TopUpTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *TopUpTool) Name() string {
+	return "deparrow_topup"
+}
+
+// Description returns the tool description.
+func (t *TopUpTool) Description() string {
+	return `Buy DEparrow credits through a payment provider's checkout.
+
+Actions:
+- start: begin a top-up of amount credits, paid through a provider such as
+  "stripe" or a saved funding source, and get the checkout URL to give the user
+- watch: wait for the user to finish paying and report when the credits arrive
+
+Always show the checkout URL to the user before watching.`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *TopUpTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"start", "watch"},
+				"description": "Start a top-up or watch one for completion",
+			},
+			"amount": map[string]interface{}{
+				"type":        "number",
+				"description": "Credits to buy (for start)",
+			},
+			"provider": map[string]interface{}{
+				"type":        "string",
+				"description": "Payment provider hosting the checkout, e.g. stripe (for start)",
+			},
+			"source_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Saved funding source to pay with instead of a provider (for start)",
+			},
+			"top_up_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Top-up to watch (for watch)",
+			},
+			"wait_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "How long to watch for the payment, up to 600 seconds (default: 120)",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+// Execute starts or watches a top-up.
+func (t *TopUpTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	action, _ := args["action"].(string)
+	switch action {
+	case "start":
+		return t.start(ctx, args)
+	case "watch":
+		return t.watch(ctx, args)
+	default:
+		return tools.ErrorResult("action must be 'start' or 'watch'")
+	}
+}
+
+// start begins a top-up and returns where the user pays for it.
+func (t *TopUpTool) start(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	amount, ok := args["amount"].(float64)
+	if !ok {
+		if amountInt, ok := args["amount"].(int); ok {
+			amount = float64(amountInt)
+		}
+	}
+	if amount <= 0 {
+		return tools.ErrorResult("amount must be a positive number of credits to buy")
+	}
+	provider, _ := args["provider"].(string)
+	sourceID, _ := args["source_id"].(string)
+	if provider != "" && sourceID != "" {
+		return tools.ErrorResult("give either a provider or a source_id, not both")
+	}
+
+	var topUp *TopUp
+	var err error
+	if provider != "" {
+		topUp, err = t.client.CreateTopUpIntent(ctx, amount, provider)
+	} else {
+		topUp, err = t.client.InitiateTopUp(ctx, amount, sourceID)
+	}
+	if err != nil {
+		return failureResult("start top-up", err)
+	}
+	return tools.UserResult(formatTopUp(topUp, "deparrow_topup action 'watch'"))
+}
+
+// watch waits for a top-up's payment to complete, fail or expire.
+func (t *TopUpTool) watch(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	topUpID, _ := args["top_up_id"].(string)
+	if topUpID == "" {
+		return tools.ErrorResult("top_up_id is required to watch a top-up")
+	}
+
+	wait := defaultTopUpWatch
+	if seconds, ok := args["wait_seconds"].(float64); ok && seconds > 0 {
+		wait = min(time.Duration(seconds)*time.Second, maxTopUpWatch)
+	}
+
+	topUp, err := t.client.WaitForTopUp(ctx, topUpID, WaitOptions{MaxWait: wait})
+	if err != nil {
+		return failureResult("watch top-up", err)
+	}
+	return tools.UserResult(formatTopUp(topUp, "deparrow_topup action 'watch'"))
+}
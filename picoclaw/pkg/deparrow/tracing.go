@@ -0,0 +1,156 @@
+package deparrow
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans the client creates.
+const tracerName = "github.com/sipeed/picoclaw/pkg/deparrow"
+
+// Span attributes the client records, so a job can be followed from the
+// tool call that submitted it to the orchestrator that ran it.
+const (
+	AttrJobID      = attribute.Key("deparrow.job_id")
+	AttrNodeID     = attribute.Key("deparrow.node_id")
+	AttrCreditCost = attribute.Key("deparrow.credit_cost")
+	AttrTool       = attribute.Key("deparrow.tool")
+)
+
+// traceContext injects the W3C traceparent and tracestate headers. It is
+// used whatever the global propagator is, as the orchestrator only reads
+// W3C trace context.
+var traceContext = propagation.TraceContext{}
+
+// WithTracerProvider traces requests and tool executions with tp. Without
+// it the global tracer provider is used, which records nothing unless the
+// application installed one.
+func WithTracerProvider(tp trace.TracerProvider) ClientOption {
+	return func(c *Client) {
+		c.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// tracerFor returns the tracer spans are started with.
+func (c *Client) tracerFor() trace.Tracer {
+	if c.tracer != nil {
+		return c.tracer
+	}
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// startRequestSpan starts a client span for one request attempt and
+// injects its trace context into the request headers. The span is named
+// after the endpoint, not the path, so spans for different jobs group
+// together; the IDs in the path become attributes.
+func (c *Client) startRequestSpan(req *http.Request) trace.Span {
+	endpoint := endpointTemplate(req.URL.Path)
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.path", endpoint),
+		attribute.String("server.address", req.URL.Host),
+	}
+	attrs = append(attrs, pathAttributes(req.URL.Path)...)
+
+	ctx, span := c.tracerFor().Start(req.Context(), req.Method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	traceContext.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return span
+}
+
+// endRequestSpan records how a request attempt went and ends its span.
+// statusCode is zero when no response arrived.
+func endRequestSpan(span trace.Span, statusCode int, err error) {
+	if statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case statusCode >= 400:
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+	span.End()
+}
+
+// pathAttributes returns the job and node IDs in a request path.
+func pathAttributes(path string) []attribute.KeyValue {
+	if i := strings.Index(path, "/api/"); i > 0 {
+		path = path[i:]
+	}
+	template := strings.Split(endpointTemplate(path), "/")
+	segments := strings.Split(path, "/")
+	if len(template) != len(segments) {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	for i := 1; i < len(segments); i++ {
+		if template[i] != "{id}" {
+			continue
+		}
+		switch segments[i-1] {
+		case "jobs":
+			attrs = append(attrs, AttrJobID.String(segments[i]))
+		case "nodes":
+			attrs = append(attrs, AttrNodeID.String(segments[i]))
+		}
+	}
+	return attrs
+}
+
+// annotateSpan adds attributes to the span in ctx, typically the span of
+// the tool execution the client was called from.
+func annotateSpan(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// tracedTool runs each execution of a tool in its own span, so the
+// requests the tool sends become its children.
+type tracedTool struct {
+	tools.Tool
+	client *Client
+}
+
+// Execute runs the wrapped tool in a span named after it.
+func (t *tracedTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	ctx, span := t.client.tracerFor().Start(ctx, "tool "+t.Name(),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(AttrTool.String(t.Name())),
+	)
+	defer span.End()
+
+	if session := SessionIDFromContext(ctx); session != "" {
+		span.SetAttributes(attribute.String("deparrow.session_id", session))
+	}
+	result := t.Tool.Execute(ctx, args)
+	if result != nil && result.IsError {
+		if result.Err != nil {
+			span.RecordError(result.Err)
+		}
+		span.SetStatus(codes.Error, firstLine(result.ForLLM))
+	}
+	if IsPartialResult(result) {
+		span.SetAttributes(attribute.Bool("deparrow.partial", true))
+	}
+	return result
+}
+
+// firstLine returns s up to its first newline.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
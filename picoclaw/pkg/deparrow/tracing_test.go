@@ -0,0 +1,162 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracing_SubmitJob(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/jobs/submit":
+			traceparent = r.Header.Get("traceparent")
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "credit_deducted": 2.5})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Not found"})
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := NewClient(server.URL, "test-token", WithTracerProvider(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "submit")
+	if _, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"}); err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+	parent.End()
+	client.GetJob(context.Background(), "job-404")
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(spans))
+	}
+	submit, root, get := spans[0], spans[1], spans[2]
+
+	if submit.Name() != "POST /api/v1/jobs/submit" {
+		t.Errorf("span name = %q", submit.Name())
+	}
+	if submit.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Error("request span is not a child of the caller's span")
+	}
+	want := "00-" + submit.SpanContext().TraceID().String() + "-" + submit.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+
+	if v, _ := spanAttr(root, AttrJobID); v.AsString() != "job-1" {
+		t.Errorf("job_id = %q, want job-1", v.AsString())
+	}
+	if v, _ := spanAttr(root, AttrCreditCost); v.AsFloat64() != 2.5 {
+		t.Errorf("credit_cost = %v, want 2.5", v.AsFloat64())
+	}
+
+	if get.Name() != "GET /api/v1/jobs/{id}" {
+		t.Errorf("span name = %q", get.Name())
+	}
+	if v, _ := spanAttr(get, AttrJobID); v.AsString() != "job-404" {
+		t.Errorf("job_id = %q, want job-404", v.AsString())
+	}
+	if v, _ := spanAttr(get, "http.response.status_code"); v.AsInt64() != http.StatusNotFound {
+		t.Errorf("status code = %d", v.AsInt64())
+	}
+	if get.Status().Code != codes.Error {
+		t.Errorf("status = %v, want Error", get.Status().Code)
+	}
+}
+
+func TestPathAttributes(t *testing.T) {
+	tests := map[string][]attribute.KeyValue{
+		"/api/v1/jobs/submit":                 nil,
+		"/api/v1/jobs/job-42/logs":            {AttrJobID.String("job-42")},
+		"/metaos/api/v1/nodes/node-1":         {AttrNodeID.String("node-1")},
+		"/api/v1/credits/balance/user-1":      nil,
+		"/api/v1/credits/standing-orders/o-1": nil,
+	}
+	for path, want := range tests {
+		got := pathAttributes(path)
+		if len(got) != len(want) {
+			t.Errorf("pathAttributes(%q) = %v, want %v", path, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("pathAttributes(%q) = %v, want %v", path, got, want)
+			}
+		}
+	}
+}
+
+func TestTracing_ToolSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := NewSandboxClient(WithTracerProvider(tp))
+
+	var submit, status *tracedTool
+	for _, tool := range NewToolsProvider(client).GetJobTools() {
+		traced := tool.(*sessionTool).Tool.(*tracedTool)
+		switch tool.Name() {
+		case "deparrow_submit_job":
+			submit = traced
+		case "deparrow_job_status":
+			status = traced
+		}
+	}
+
+	ctx := context.Background()
+	result := submit.Execute(ctx, map[string]interface{}{"image": "alpine", "command": "echo hi"})
+	if result.IsError {
+		t.Fatalf("submit failed: %s", result.ForLLM)
+	}
+	if result := status.Execute(ctx, map[string]interface{}{}); !result.IsError {
+		t.Fatal("status without a job ID succeeded")
+	}
+
+	var toolSpans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if _, ok := spanAttr(span, AttrTool); ok {
+			toolSpans = append(toolSpans, span)
+		}
+	}
+	if len(toolSpans) != 2 {
+		t.Fatalf("recorded %d tool spans, want 2", len(toolSpans))
+	}
+	if toolSpans[0].Name() != "tool deparrow_submit_job" {
+		t.Errorf("span name = %q", toolSpans[0].Name())
+	}
+	if v, _ := spanAttr(toolSpans[0], AttrJobID); v.AsString() == "" {
+		t.Error("submit span has no job ID")
+	}
+	if toolSpans[1].Status().Code != codes.Error {
+		t.Errorf("failed tool span status = %v, want Error", toolSpans[1].Status().Code)
+	}
+
+	// Every request span belongs to the trace of the tool that sent it
+	for _, span := range recorder.Ended() {
+		if span.SpanKind().String() == "client" && span.Parent().TraceID() != toolSpans[0].SpanContext().TraceID() {
+			t.Errorf("request span %q is not part of the submit trace", span.Name())
+		}
+	}
+}
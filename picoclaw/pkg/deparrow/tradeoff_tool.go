@@ -0,0 +1,342 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// gpuClass is the relative speed of a GPU model.
+type gpuClass struct {
+	Model string
+	// Throughput relative to an A100
+	Speed float64
+}
+
+// gpuClasses lists the GPU models the explorer can trade between, fastest
+// first. Models not listed are timed like an A100.
+var gpuClasses = []gpuClass{
+	{"H200", 2.4},
+	{"H100", 2.0},
+	{"A100", 1.0},
+	{"L40", 0.9},
+	{"RTX4090", 0.8},
+	{"A40", 0.6},
+	{"A10", 0.5},
+	{"V100", 0.5},
+	{"L4", 0.4},
+	{"T4", 0.2},
+}
+
+// gpuClassOf returns the class of a GPU model.
+func gpuClassOf(model string) gpuClass {
+	for _, class := range gpuClasses {
+		if strings.EqualFold(class.Model, model) {
+			return class
+		}
+	}
+	return gpuClass{Model: model, Speed: 1}
+}
+
+// parallelFraction is the share of a workload assumed to speed up with more
+// CPUs or GPUs; the rest runs at the same speed however many there are.
+const parallelFraction = 0.9
+
+// scaleRuntime estimates the runtime on to units of compute of a workload
+// that takes d on from units.
+func scaleRuntime(d time.Duration, from, to int) time.Duration {
+	if from <= 0 || to <= 0 || from == to {
+		return d
+	}
+	at := func(n int) float64 { return (1 - parallelFraction) + parallelFraction/float64(n) }
+	return time.Duration(float64(d) * at(to) / at(from))
+}
+
+// TradeoffOption is one way to run a workload, with its estimated cost and
+// runtime.
+type TradeoffOption struct {
+	Label   string
+	Request *CapacityRequest
+	Cost    float64
+	// Regions that can run the option now
+	Regions []string
+}
+
+// Runtime returns the option's estimated runtime.
+func (o *TradeoffOption) Runtime() time.Duration {
+	return o.Request.Duration
+}
+
+// Compare describes the option relative to base, such as
+// "2.0x slower, 60% cheaper".
+func (o *TradeoffOption) Compare(base *TradeoffOption) string {
+	var parts []string
+	speed := float64(o.Runtime()) / float64(base.Runtime())
+	switch {
+	case speed > 1.05:
+		parts = append(parts, fmt.Sprintf("%.1fx slower", speed))
+	case speed < 0.95:
+		parts = append(parts, fmt.Sprintf("%.1fx faster", 1/speed))
+	default:
+		parts = append(parts, "same speed")
+	}
+
+	if base.Cost > 0 {
+		change := (o.Cost - base.Cost) / base.Cost * 100
+		switch {
+		case change <= -1:
+			parts = append(parts, fmt.Sprintf("%.0f%% cheaper", -change))
+		case change >= 1:
+			parts = append(parts, fmt.Sprintf("%.0f%% more expensive", change))
+		default:
+			parts = append(parts, "same cost")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExploreTradeoffs lists alternatives to a workload: fewer or more CPUs,
+// fewer GPUs and other GPU classes available on the network, each with its
+// estimated cost and runtime and the regions with a node that can run it
+// now. The
+// request's duration is taken as its runtime as requested. The first
+// option is the request itself; the others are sorted cheapest first, and
+// alternatives both slower and dearer than another are left out.
+func ExploreTradeoffs(req *CapacityRequest, capacity *NetworkCapacity, nodes []Node) []TradeoffOption {
+	base := *req
+	var alternatives []TradeoffOption
+	add := func(label string, alt CapacityRequest) {
+		alternatives = append(alternatives, TradeoffOption{Label: label, Request: &alt})
+	}
+
+	if req.GPUCount == 0 && req.CPU > 1 {
+		for _, cpus := range []int{req.CPU / 4, req.CPU / 2, req.CPU * 2} {
+			if cpus < 1 || cpus == req.CPU {
+				continue
+			}
+			alt := base
+			alt.CPU = cpus
+			alt.Duration = scaleRuntime(req.Duration, req.CPU, cpus)
+			add(fmt.Sprintf("%d CPUs", cpus), alt)
+		}
+	}
+
+	if req.GPUCount > 0 {
+		if req.GPUCount > 1 {
+			alt := base
+			alt.GPUCount = req.GPUCount / 2
+			alt.Duration = scaleRuntime(req.Duration, req.GPUCount, alt.GPUCount)
+			add(fmt.Sprintf("%d GPUs", alt.GPUCount), alt)
+		}
+
+		current := gpuClassOf(req.GPUModel)
+		for _, model := range networkGPUModels(capacity) {
+			class := gpuClassOf(model)
+			if strings.EqualFold(class.Model, current.Model) {
+				continue
+			}
+			alt := base
+			alt.GPUModel = class.Model
+			alt.Duration = time.Duration(float64(req.Duration) * current.Speed / class.Speed)
+			add(fmt.Sprintf("%d×%s", req.GPUCount, class.Model), alt)
+		}
+	}
+
+	options := append([]TradeoffOption{{Label: "As requested", Request: &base}}, alternatives...)
+	for i := range options {
+		options[i].Cost = options[i].Request.EstimateCost()
+		options[i].Regions = feasibleRegions(options[i].Request, capacity, nodes)
+	}
+
+	alternatives = options[1:]
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return alternatives[i].Cost < alternatives[j].Cost
+	})
+	kept := options[:1]
+	for i, alt := range alternatives {
+		if !dominated(alt, options[0]) && !dominatedByAny(alt, alternatives[:i]) {
+			kept = append(kept, alt)
+		}
+	}
+	return kept
+}
+
+// dominated reports whether other is at least as fast and as cheap as
+// option and better on one of the two.
+func dominated(option, other TradeoffOption) bool {
+	return other.Runtime() <= option.Runtime() && other.Cost <= option.Cost &&
+		(other.Runtime() < option.Runtime() || other.Cost < option.Cost)
+}
+
+// dominatedByAny reports whether any of others dominates option.
+func dominatedByAny(option TradeoffOption, others []TradeoffOption) bool {
+	for _, other := range others {
+		if dominated(option, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// networkGPUModels lists the GPU models with free capacity, fastest first.
+func networkGPUModels(capacity *NetworkCapacity) []string {
+	seen := make(map[string]bool)
+	var models []string
+	for _, region := range capacity.Regions {
+		for _, gpu := range region.GPUs {
+			model := strings.ToUpper(gpu.Model)
+			if gpu.MaxPerNode > 0 && model != "" && !seen[model] {
+				seen[model] = true
+				models = append(models, model)
+			}
+		}
+	}
+	sort.SliceStable(models, func(i, j int) bool {
+		return gpuClassOf(models[i]).Speed > gpuClassOf(models[j]).Speed
+	})
+	return models
+}
+
+// feasibleRegions lists the regions with a node that can run the request now.
+func feasibleRegions(req *CapacityRequest, capacity *NetworkCapacity, nodes []Node) []string {
+	var regions []string
+	for _, region := range capacity.Regions {
+		if fitRegion(req, region, nodes).feasible {
+			regions = append(regions, region.Region)
+		}
+	}
+	return regions
+}
+
+// TradeoffTool lays out cheaper, faster and more available ways to run a
+// workload.
+type TradeoffTool struct {
+	client *Client
+}
+
+// NewTradeoffTool creates a new cost and runtime tradeoff tool.
+func NewTradeoffTool(client *Client) *TradeoffTool {
+	return &TradeoffTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *TradeoffTool) Name() string {
+	return "deparrow_tradeoffs"
+}
+
+// Description returns the tool description.
+func (t *TradeoffTool) Description() string {
+	return `Compare the cost and runtime of alternative ways to run a workload.
+
+Describe the workload in plain language with its expected runtime, for example:
+  "2×A100, 64GB RAM, 6 hours"
+  "32 cpu, 128GB RAM, 2 hours"
+
+The answer lists alternatives such as fewer CPUs, fewer GPUs or another GPU
+class, each with its estimated cost and runtime relative to the request
+(e.g. "2.0x slower, 60% cheaper") and the regions that can run it now.
+Estimates assume the workload scales with compute the way typical batch
+jobs do; present them to the user as options, not guarantees.
+`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *TradeoffTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"resources": map[string]interface{}{
+				"type":        "string",
+				"description": "Plain resource description with runtime (e.g., '2×A100, 64GB RAM, 6 hours')",
+			},
+			"region": map[string]interface{}{
+				"type":        "string",
+				"description": "Only consider capacity in this region (optional)",
+			},
+		},
+		"required": []string{"resources"},
+	}
+}
+
+// Execute runs the tradeoff tool.
+func (t *TradeoffTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	desc, ok := args["resources"].(string)
+	if !ok || strings.TrimSpace(desc) == "" {
+		return tools.ErrorResult("resources parameter is required")
+	}
+
+	req, err := ParseCapacityRequest(desc)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to understand resources: %v", err))
+	}
+
+	capacity, err := t.client.GetNetworkCapacity(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get network capacity: %v", err))
+	}
+	if region, _ := args["region"].(string); region != "" {
+		filtered := &NetworkCapacity{Timestamp: capacity.Timestamp}
+		for _, rc := range capacity.Regions {
+			if strings.EqualFold(rc.Region, region) {
+				filtered.Regions = append(filtered.Regions, rc)
+			}
+		}
+		if len(filtered.Regions) == 0 {
+			return tools.UserResult(fmt.Sprintf("No capacity data for region %s.", region))
+		}
+		capacity = filtered
+	}
+	nodes, err := t.client.ListNodes(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to list nodes: %v", err))
+	}
+
+	options := ExploreTradeoffs(req, capacity, nodes)
+	base := &options[0]
+
+	var result strings.Builder
+	result.WriteString("⚖️  DEparrow Cost/Runtime Tradeoffs\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(fmt.Sprintf("Requested: %s\n", req))
+	result.WriteString(fmt.Sprintf("  %.2f credits, %s\n", base.Cost, formatRuntime(base.Runtime())))
+	result.WriteString(fmt.Sprintf("  %s\n", formatRegions(base.Regions)))
+
+	if len(options) == 1 {
+		result.WriteString("\nNo alternative is cheaper or faster for this workload.")
+		return tools.UserResult(result.String())
+	}
+
+	result.WriteString("\nAlternatives (cheapest first):\n")
+	for i := range options[1:] {
+		option := &options[i+1]
+		result.WriteString(fmt.Sprintf("\n  %d. %s: %s\n", i+1, option.Label, option.Compare(base)))
+		result.WriteString(fmt.Sprintf("     %.2f credits, %s\n", option.Cost, formatRuntime(option.Runtime())))
+		result.WriteString(fmt.Sprintf("     %s\n", formatRegions(option.Regions)))
+	}
+
+	return tools.UserResult(result.String())
+}
+
+// formatRuntime renders an estimated runtime rounded to a readable precision.
+func formatRuntime(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("about %d minutes", int(math.Round(d.Minutes())))
+	}
+	return fmt.Sprintf("about %.1f hours", d.Hours())
+}
+
+// formatRegions renders where an option can run now.
+func formatRegions(regions []string) string {
+	if len(regions) == 0 {
+		return "not available right now"
+	}
+	return "available now in " + strings.Join(regions, ", ")
+}
+
+// Ensure tool implements the Tool interface
+var _ tools.Tool = (*TradeoffTool)(nil)
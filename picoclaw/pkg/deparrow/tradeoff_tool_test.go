@@ -0,0 +1,139 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tradeoffCapacity() *NetworkCapacity {
+	return &NetworkCapacity{Regions: []RegionCapacity{
+		{
+			Region: "us-east", OnlineNodes: 4, AvailableCPU: 64, AvailableMemoryGB: 256, AvailableStorageGB: 1000,
+			GPUs: []GPUCapacity{{Model: "A100", Available: 4, MaxPerNode: 4}},
+		},
+		{
+			Region: "ap-south", OnlineNodes: 2, AvailableCPU: 16, AvailableMemoryGB: 64, AvailableStorageGB: 500,
+			GPUs: []GPUCapacity{{Model: "T4", Available: 4, MaxPerNode: 2}, {Model: "H100", Available: 1, MaxPerNode: 1}},
+		},
+	}}
+}
+
+// tradeoffNodes returns the nodes behind tradeoffCapacity's regions.
+func tradeoffNodes() []Node {
+	node := func(id, region string, cpu int, memory string, gpus int, model string) Node {
+		return Node{
+			ID:        id,
+			Status:    NodeStatusOnline,
+			Resources: &NodeResources{CPU: cpu, Memory: memory, Storage: "250Gi", GPU: gpus, GPUModel: model},
+			Labels:    map[string]string{NodeRegionLabel: region},
+		}
+	}
+	return []Node{
+		node("use-1", "us-east", 64, "256Gi", 4, "A100"),
+		node("aps-1", "ap-south", 8, "32Gi", 2, "T4"),
+		node("aps-2", "ap-south", 8, "32Gi", 1, "H100"),
+	}
+}
+
+func findOption(options []TradeoffOption, label string) *TradeoffOption {
+	for i := range options {
+		if options[i].Label == label {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+func TestExploreTradeoffs_GPUClasses(t *testing.T) {
+	req := &CapacityRequest{GPUCount: 2, GPUModel: "A100", MemoryGB: 32, Duration: 4 * time.Hour}
+	options := ExploreTradeoffs(req, tradeoffCapacity(), tradeoffNodes())
+
+	base := &options[0]
+	if base.Label != "As requested" || base.Cost != req.EstimateCost() {
+		t.Fatalf("options[0] = %+v, want the request itself", base)
+	}
+	if len(base.Regions) != 1 || base.Regions[0] != "us-east" {
+		t.Errorf("base regions = %v, want [us-east]", base.Regions)
+	}
+
+	h100 := findOption(options, "2×H100")
+	if h100 == nil || h100.Runtime() != 2*time.Hour {
+		t.Fatalf("2×H100 = %+v, want half the runtime", h100)
+	}
+	// Jobs are priced by the hour, so the faster GPUs cost less
+	if got := h100.Compare(base); got != "2.0x faster, 50% cheaper" {
+		t.Errorf("Compare() = %q", got)
+	}
+	if len(h100.Regions) != 0 {
+		t.Errorf("2×H100 regions = %v, want none with one H100 per node", h100.Regions)
+	}
+
+	// Running 5x longer makes the slower GPUs dearer too
+	if t4 := findOption(options, "2×T4"); t4 != nil {
+		t.Errorf("2×T4 = %+v, want it left out as slower and dearer", t4)
+	}
+
+	options = ExploreTradeoffs(&CapacityRequest{GPUCount: 1, GPUModel: "A100", Duration: time.Hour}, tradeoffCapacity(), tradeoffNodes())
+	h100 = findOption(options, "1×H100")
+	if h100 == nil || len(h100.Regions) != 1 || h100.Regions[0] != "ap-south" {
+		t.Fatalf("1×H100 = %+v, want it to run in ap-south", h100)
+	}
+
+	for i := 2; i < len(options); i++ {
+		if options[i].Cost < options[i-1].Cost {
+			t.Errorf("alternatives not sorted by cost: %v", options)
+		}
+	}
+}
+
+func TestExploreTradeoffs_CPUsDropsDominated(t *testing.T) {
+	req := &CapacityRequest{CPU: 16, MemoryGB: 64, Duration: 2 * time.Hour}
+	options := ExploreTradeoffs(req, tradeoffCapacity(), tradeoffNodes())
+
+	// Fewer CPUs run longer and, priced by the hour, cost more too
+	for _, label := range []string{"8 CPUs", "4 CPUs"} {
+		if option := findOption(options, label); option != nil {
+			t.Errorf("%s = %+v, want it left out", label, option)
+		}
+	}
+	// Doubling the CPUs finishes sooner for less
+	double := findOption(options, "32 CPUs")
+	if double == nil || double.Runtime() >= req.Duration || double.Cost >= options[0].Cost {
+		t.Fatalf("32 CPUs = %+v, want faster and cheaper", double)
+	}
+	if len(double.Regions) != 1 || double.Regions[0] != "us-east" {
+		t.Errorf("32 CPUs regions = %v, want [us-east]", double.Regions)
+	}
+	for _, option := range options[1:] {
+		if dominated(option, options[0]) {
+			t.Errorf("%s is dominated by the request and should be left out", option.Label)
+		}
+	}
+}
+
+func TestTradeoffTool_Sandbox(t *testing.T) {
+	tool := NewTradeoffTool(NewSandboxClient())
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"resources": "1×A100, 16GB RAM, 3 hours"})
+	if result.IsError {
+		t.Fatalf("Execute() error = %s", result.ForLLM)
+	}
+	for _, want := range []string{"Requested:", "Alternatives (cheapest first):", "1×H100", "cheaper"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("output missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"resources": "1×A100, 3 hours", "region": "mars"})
+	if result.IsError || !strings.Contains(result.ForLLM, "No capacity data for region mars") {
+		t.Errorf("unknown region output = %s", result.ForLLM)
+	}
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError {
+		t.Error("missing resources should be an error")
+	}
+}
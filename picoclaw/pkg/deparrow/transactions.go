@@ -0,0 +1,346 @@
+package deparrow
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// transactionsPath is the collection endpoint for wallet transactions.
+const transactionsPath = "/api/v1/credits/transactions"
+
+// Transaction types.
+const (
+	TransactionEarn     = "earn"
+	TransactionSpend    = "spend"
+	TransactionTransfer = "transfer"
+	TransactionTopUp    = "top_up"
+)
+
+// validTransactionType reports whether the transaction type is known.
+func validTransactionType(kind string) bool {
+	switch kind {
+	case TransactionEarn, TransactionSpend, TransactionTransfer, TransactionTopUp:
+		return true
+	}
+	return false
+}
+
+// Outgoing reports whether the transaction took credits out of the wallet.
+// Transfers with a sender are transfers out.
+func (tx Transaction) Outgoing() bool {
+	switch tx.Type {
+	case TransactionSpend:
+		return true
+	case TransactionTransfer:
+		return tx.FromUser != ""
+	}
+	return false
+}
+
+// TransactionFilter selects wallet transactions.
+type TransactionFilter struct {
+	PageOptions
+	// Since and Until bound when the transactions happened, Since included
+	// and Until excluded; a zero time leaves that end open
+	Since time.Time
+	Until time.Time
+	// Types keeps only transactions of these types; empty keeps all
+	Types []string
+}
+
+// validate checks the filter's time range and types.
+func (f TransactionFilter) validate() error {
+	if !f.Since.IsZero() && !f.Until.IsZero() && !f.Until.After(f.Since) {
+		return fmt.Errorf("transaction filter ends at %s, before it starts at %s",
+			f.Until.UTC().Format(time.RFC3339), f.Since.UTC().Format(time.RFC3339))
+	}
+	for _, kind := range f.Types {
+		if !validTransactionType(kind) {
+			return fmt.Errorf("unknown transaction type %q", kind)
+		}
+	}
+	return nil
+}
+
+// query encodes the filter as list parameters.
+func (f TransactionFilter) query() url.Values {
+	query := url.Values{}
+	f.encode(query)
+	if !f.Since.IsZero() {
+		query.Set("since", f.Since.UTC().Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		query.Set("until", f.Until.UTC().Format(time.RFC3339))
+	}
+	if len(f.Types) > 0 {
+		query.Set("type", strings.Join(f.Types, ","))
+	}
+	return query
+}
+
+// TransactionPage is one page of the transactions matching a filter.
+type TransactionPage struct {
+	Transactions []Transaction
+	// Total matching transactions, or -1 when the server does not report it
+	Total int
+
+	filter  TransactionFilter
+	next    PageOptions
+	hasNext bool
+}
+
+// Next returns the filter for the page after this one, or false on the
+// last page.
+func (p *TransactionPage) Next() (TransactionFilter, bool) {
+	filter := p.filter
+	filter.PageOptions = p.next
+	return filter, p.hasNext
+}
+
+// GetTransactions fetches one page of the wallet transactions matching
+// filter, oldest first.
+//
+// Example:
+//
+//	// What the agent spent last week
+//	page, err := client.GetTransactions(ctx, deparrow.TransactionFilter{
+//	    Since: time.Now().AddDate(0, 0, -7),
+//	    Types: []string{deparrow.TransactionSpend},
+//	})
+func (c *Client) GetTransactions(ctx context.Context, filter TransactionFilter) (*TransactionPage, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	page := &TransactionPage{filter: filter}
+	state, err := c.streamPage(ctx, transactionsPath, "transactions", filter.query(), nil, func(dec *json.Decoder) error {
+		var tx Transaction
+		if err := dec.Decode(&tx); err != nil {
+			return &malformedResponseError{err}
+		}
+		page.Transactions = append(page.Transactions, tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page.Total = state.Total
+	page.next, page.hasNext = filter.PageOptions.next(state)
+	return page, nil
+}
+
+// AllTransactions iterates over every transaction matching filter,
+// fetching the following pages as the loop reaches them. An error ends the
+// iteration.
+func (c *Client) AllTransactions(ctx context.Context, filter TransactionFilter) iter.Seq2[Transaction, error] {
+	return func(yield func(Transaction, error) bool) {
+		for {
+			page, err := c.GetTransactions(ctx, filter)
+			if err != nil {
+				yield(Transaction{}, err)
+				return
+			}
+			for _, tx := range page.Transactions {
+				if !yield(tx, nil) {
+					return
+				}
+			}
+			next, ok := page.Next()
+			if !ok {
+				return
+			}
+			filter = next
+		}
+	}
+}
+
+// TransactionExport is a portable record of the wallet transactions
+// matching a filter.
+type TransactionExport struct {
+	ExportedAt   time.Time          `json:"exported_at"`
+	Since        *time.Time         `json:"since,omitempty"`
+	Until        *time.Time         `json:"until,omitempty"`
+	Types        []string           `json:"types,omitempty"`
+	Summary      TransactionSummary `json:"summary"`
+	Transactions []Transaction      `json:"transactions"`
+}
+
+// transactionExportColumns is the header row of the CSV export.
+var transactionExportColumns = []string{
+	"transaction_id", "timestamp", "type", "amount", "signed_amount", "description", "from_user", "to_user", "node_id",
+}
+
+// ExportTransactions collects every transaction matching filter, starting
+// from its first page, and encodes them as JSON or CSV.
+func (c *Client) ExportTransactions(ctx context.Context, filter TransactionFilter, format ExportFormat) ([]byte, error) {
+	if format != ExportFormatJSON && format != ExportFormatCSV {
+		return nil, fmt.Errorf("unsupported transaction export format %q", format)
+	}
+
+	filter.PageOptions = PageOptions{Limit: filter.Limit}
+	export := TransactionExport{ExportedAt: time.Now(), Types: filter.Types, Transactions: []Transaction{}}
+	if !filter.Since.IsZero() {
+		export.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		export.Until = &filter.Until
+	}
+	for tx, err := range c.AllTransactions(ctx, filter) {
+		if err != nil {
+			return nil, err
+		}
+		export.Transactions = append(export.Transactions, tx)
+	}
+	if summaries := SummarizeTransactions(export.Transactions, ""); len(summaries) > 0 {
+		export.Summary = summaries[0]
+	}
+
+	if format == ExportFormatCSV {
+		return encodeTransactionsCSV(export.Transactions)
+	}
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// encodeTransactionsCSV writes one row per transaction. The signed amount
+// is negative for credits leaving the wallet.
+func encodeTransactionsCSV(transactions []Transaction) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(transactionExportColumns); err != nil {
+		return nil, err
+	}
+
+	for _, tx := range transactions {
+		signed := tx.Amount
+		if tx.Outgoing() {
+			signed = -signed
+		}
+		err := w.Write([]string{
+			tx.ID,
+			tx.Timestamp.UTC().Format(time.RFC3339),
+			tx.Type,
+			strconv.FormatFloat(tx.Amount, 'f', 2, 64),
+			strconv.FormatFloat(signed, 'f', 2, 64),
+			tx.Description,
+			tx.FromUser,
+			tx.ToUser,
+			tx.NodeID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SummaryPeriod is the length of the periods transactions are summed over.
+type SummaryPeriod string
+
+const (
+	SummaryDay   SummaryPeriod = "day"
+	SummaryWeek  SummaryPeriod = "week"
+	SummaryMonth SummaryPeriod = "month"
+)
+
+// Valid reports whether the period is known. The empty period, which sums
+// everything into one summary, is valid.
+func (p SummaryPeriod) Valid() bool {
+	switch p {
+	case "", SummaryDay, SummaryWeek, SummaryMonth:
+		return true
+	}
+	return false
+}
+
+// start returns the start of the period holding t, in UTC. Weeks start on
+// Monday.
+func (p SummaryPeriod) start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case SummaryDay:
+		return day
+	case SummaryWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case SummaryMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Time{}
+}
+
+// TransactionSummary totals the transactions of one period by kind.
+type TransactionSummary struct {
+	// Start of the period; zero for a summary of everything
+	Start          time.Time `json:"start,omitzero"`
+	Count          int       `json:"count"`
+	Earned         float64   `json:"earned"`
+	Spent          float64   `json:"spent"`
+	ToppedUp       float64   `json:"topped_up"`
+	TransferredIn  float64   `json:"transferred_in"`
+	TransferredOut float64   `json:"transferred_out"`
+}
+
+// Net returns how much the wallet grew over the period; it is negative
+// when more went out than came in.
+func (s TransactionSummary) Net() float64 {
+	return s.Earned + s.ToppedUp + s.TransferredIn - s.Spent - s.TransferredOut
+}
+
+// add counts the transaction into the summary.
+func (s *TransactionSummary) add(tx Transaction) {
+	s.Count++
+	switch tx.Type {
+	case TransactionEarn:
+		s.Earned += tx.Amount
+	case TransactionSpend:
+		s.Spent += tx.Amount
+	case TransactionTopUp:
+		s.ToppedUp += tx.Amount
+	case TransactionTransfer:
+		if tx.Outgoing() {
+			s.TransferredOut += tx.Amount
+		} else {
+			s.TransferredIn += tx.Amount
+		}
+	}
+}
+
+// SummarizeTransactions totals the transactions per period, oldest period
+// first. Periods without transactions are left out. The empty period sums
+// everything into one summary, and no transactions give no summaries.
+func SummarizeTransactions(transactions []Transaction, period SummaryPeriod) []TransactionSummary {
+	byStart := make(map[time.Time]*TransactionSummary)
+	for _, tx := range transactions {
+		start := period.start(tx.Timestamp)
+		summary, ok := byStart[start]
+		if !ok {
+			summary = &TransactionSummary{Start: start}
+			byStart[start] = summary
+		}
+		summary.add(tx)
+	}
+
+	summaries := make([]TransactionSummary, 0, len(byStart))
+	for _, summary := range byStart {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Start.Before(summaries[j].Start)
+	})
+	return summaries
+}
@@ -0,0 +1,174 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sandboxLedger replaces the sandbox's transactions with a fixed ledger
+// over the first days of March 2026.
+func sandboxLedger(client *Client) {
+	day := func(d, hour int) time.Time { return time.Date(2026, 3, d, hour, 0, 0, 0, time.UTC) }
+	client.sandbox.transactions = []Transaction{
+		{ID: "tx-1", Type: TransactionEarn, Amount: 100, Timestamp: day(2, 9), NodeID: "node-1"},
+		{ID: "tx-2", Type: TransactionSpend, Amount: 30, Timestamp: day(3, 10), Description: "Job job-1"},
+		{ID: "tx-3", Type: TransactionTransfer, Amount: 20, Timestamp: day(4, 11), FromUser: SandboxUserID, ToUser: "user-2"},
+		{ID: "tx-4", Type: TransactionTopUp, Amount: 50, Timestamp: day(9, 12)},
+		{ID: "tx-5", Type: TransactionSpend, Amount: 5, Timestamp: day(10, 13), Description: "Job, with a comma"},
+	}
+}
+
+func TestGetTransactions_FilterAndPages(t *testing.T) {
+	client := NewSandboxClient()
+	sandboxLedger(client)
+	ctx := context.Background()
+
+	page, err := client.GetTransactions(ctx, TransactionFilter{
+		PageOptions: PageOptions{Limit: 1},
+		Since:       time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC),
+		Until:       time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		Types:       []string{TransactionSpend, TransactionTransfer},
+	})
+	if err != nil {
+		t.Fatalf("GetTransactions() error = %v", err)
+	}
+	if len(page.Transactions) != 1 || page.Transactions[0].ID != "tx-2" || page.Total != 2 {
+		t.Fatalf("page = %+v", page)
+	}
+	next, ok := page.Next()
+	if !ok || next.Types == nil || next.Cursor == "" {
+		t.Fatalf("Next() = %+v, %v", next, ok)
+	}
+
+	var ids []string
+	for tx, err := range client.AllTransactions(ctx, TransactionFilter{PageOptions: PageOptions{Limit: 2}}) {
+		if err != nil {
+			t.Fatalf("AllTransactions() error = %v", err)
+		}
+		ids = append(ids, tx.ID)
+	}
+	if strings.Join(ids, ",") != "tx-1,tx-2,tx-3,tx-4,tx-5" {
+		t.Errorf("AllTransactions() = %v", ids)
+	}
+}
+
+func TestTransactionFilter_Validate(t *testing.T) {
+	now := time.Now()
+	for name, filter := range map[string]TransactionFilter{
+		"reversed range": {Since: now, Until: now.Add(-time.Hour)},
+		"unknown type":   {Types: []string{"refund"}},
+	} {
+		if _, err := NewSandboxClient().GetTransactions(context.Background(), filter); err == nil {
+			t.Errorf("%s: GetTransactions() should fail", name)
+		}
+	}
+}
+
+func TestSummarizeTransactions(t *testing.T) {
+	client := NewSandboxClient()
+	sandboxLedger(client)
+	ledger := client.sandbox.transactions
+
+	total := SummarizeTransactions(ledger, "")
+	if len(total) != 1 || total[0].Count != 5 || total[0].Net() != 95 || total[0].TransferredOut != 20 {
+		t.Errorf("total = %+v", total)
+	}
+
+	// 2026-03-02 is a Monday
+	weeks := SummarizeTransactions(ledger, SummaryWeek)
+	if len(weeks) != 2 || !weeks[0].Start.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) ||
+		weeks[0].Net() != 50 || weeks[1].Net() != 45 {
+		t.Errorf("weeks = %+v", weeks)
+	}
+	if days := SummarizeTransactions(ledger, SummaryDay); len(days) != 5 {
+		t.Errorf("%d days, want 5", len(days))
+	}
+	if months := SummarizeTransactions(ledger, SummaryMonth); len(months) != 1 || months[0].Start.Day() != 1 {
+		t.Errorf("months = %+v", months)
+	}
+	if none := SummarizeTransactions(nil, ""); len(none) != 0 {
+		t.Errorf("no transactions = %+v", none)
+	}
+}
+
+func TestExportTransactions(t *testing.T) {
+	client := NewSandboxClient()
+	sandboxLedger(client)
+	ctx := context.Background()
+	filter := TransactionFilter{Types: []string{TransactionSpend}}
+
+	data, err := client.ExportTransactions(ctx, filter, ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("ExportTransactions(csv) error = %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[2][4] != "-5.00" || rows[2][5] != "Job, with a comma" {
+		t.Errorf("rows = %v", rows)
+	}
+
+	data, err = client.ExportTransactions(ctx, filter, ExportFormatJSON)
+	if err != nil {
+		t.Fatalf("ExportTransactions(json) error = %v", err)
+	}
+	var export TransactionExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(export.Transactions) != 2 || export.Summary.Spent != 35 || export.Since != nil {
+		t.Errorf("export = %+v", export)
+	}
+
+	if _, err := client.ExportTransactions(ctx, filter, ExportFormatTar); err == nil {
+		t.Error("tar export should fail")
+	}
+}
+
+func TestWalletTool_HistoryFilters(t *testing.T) {
+	client := NewSandboxClient()
+	sandboxLedger(client)
+	tool := NewWalletTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{
+		"action": "history", "since": "2026-03-03", "until": "2026-03-09", "summarize_by": "week",
+	})
+	if result.IsError {
+		t.Fatalf("history failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{
+		"Showing: 2026-03-03 to 2026-03-09", "Spent:         -30.00", "Topped up:     +50.00",
+		"Net:           +0.00", "Per week:", "2026-03-02  (2 transactions)", "2026-03-09  (1 transactions)",
+		"Latest 3 of 3",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("history should contain %q:\n%s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "tx-5") || strings.Contains(result.ForLLM, "with a comma") {
+		t.Errorf("history includes a transaction after until:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "history", "transaction_type": "earn", "since": "2026-03-05"})
+	if result.IsError || !strings.Contains(result.ForLLM, "No transactions match") {
+		t.Errorf("empty filtered history:\n%s", result.ForLLM)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "history", "since": "March 3"},
+		{"action": "history", "since": "2026-03-05", "until": "2026-03-01"},
+		{"action": "history", "summarize_by": "year"},
+	} {
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("%v should fail:\n%s", args, result.ForLLM)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+const (
+	// transfersPath is the endpoint credit transfers are made through.
+	transfersPath = "/api/v1/credits/transfer"
+
+	// pendingTransfersPath is the collection endpoint for transfers
+	// awaiting approval.
+	pendingTransfersPath = "/api/v1/credits/transfers/pending"
+)
+
+// WithTransferApprovalThreshold makes transfers of more than amount credits
+// wait for a second approver instead of going through at once, on top of
+// any threshold the organization sets on the server. Zero leaves it to the
+// server.
+func WithTransferApprovalThreshold(amount float64) ClientOption {
+	return func(c *Client) {
+		c.approvalThreshold = amount
+	}
+}
+
+// ApprovalThreshold returns the amount above which the client has
+// transfers approved, or 0 when it leaves that to the server.
+func (c *Client) ApprovalThreshold() float64 {
+	return c.approvalThreshold
+}
+
+// ErrApprovalRequired is matched by an *ApprovalRequiredError.
+var ErrApprovalRequired = errors.New("approval required")
+
+// ApprovalRequiredError is returned by TransferCredits for a transfer that
+// was accepted but not made: it waits for a second approver to call
+// ApproveTransfer.
+type ApprovalRequiredError struct {
+	Transfer PendingTransfer
+}
+
+// Error implements the error interface.
+func (e *ApprovalRequiredError) Error() string {
+	return fmt.Sprintf("transfer %s of %.2f credits to %s is waiting for approval",
+		e.Transfer.ID, e.Transfer.Amount, e.Transfer.ToUserID)
+}
+
+// Is makes errors.Is(err, ErrApprovalRequired) match.
+func (e *ApprovalRequiredError) Is(target error) bool {
+	return target == ErrApprovalRequired
+}
+
+// IsApprovalRequired reports whether a tool result is a transfer waiting
+// for approval rather than a completed one.
+func IsApprovalRequired(result *tools.ToolResult) bool {
+	return result != nil && !result.IsError && errors.Is(result.Err, ErrApprovalRequired)
+}
+
+// transferResult is the response to a transfer, made or waiting for
+// approval.
+type transferResult struct {
+	PendingTransfer
+	RemainingBalance float64 `json:"remaining_balance"`
+}
+
+// ListPendingTransfers retrieves the transfers of the authenticated user's
+// organization that are waiting for approval.
+func (c *Client) ListPendingTransfers(ctx context.Context) ([]PendingTransfer, error) {
+	var result struct {
+		Transfers []PendingTransfer `json:"pending_transfers"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, pendingTransfersPath, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Transfers == nil {
+		result.Transfers = []PendingTransfer{}
+	}
+	return result.Transfers, nil
+}
+
+// ApproveTransfer approves a transfer waiting for approval, which makes
+// it. The server refuses approval by the user who requested the transfer.
+func (c *Client) ApproveTransfer(ctx context.Context, transferID string) (*PendingTransfer, error) {
+	if transferID == "" {
+		return nil, fmt.Errorf("transfer ID is required")
+	}
+	var result PendingTransfer
+	path := pendingTransfersPath + "/" + url.PathEscape(transferID) + "/approve"
+	if err := c.doRequest(ctx, http.MethodPost, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// approvalRequiredResult tells the agent that a transfer is waiting for
+// approval. The result is not an error, but carries the
+// *ApprovalRequiredError so callers can tell it from a completed transfer.
+func approvalRequiredResult(pending *ApprovalRequiredError) *tools.ToolResult {
+	var b strings.Builder
+	b.WriteString("⏳ Approval Required\n")
+	b.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	b.WriteString(formatPendingTransfer(&pending.Transfer))
+	b.WriteString("\n💡 No credits have moved yet. The transfer is above the approval threshold, so another ")
+	b.WriteString("user of the organization must approve it with 'deparrow_transfer' action 'approve'.")
+
+	result := tools.UserResult(b.String())
+	result.Err = pending
+	return result
+}
+
+// formatPendingTransfer renders a transfer that needs approval.
+func formatPendingTransfer(transfer *PendingTransfer) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("  Transfer:  %s (%s)\n", transfer.ID, transfer.Status))
+	b.WriteString(fmt.Sprintf("  From:      %s\n", transfer.FromUserID))
+	b.WriteString(fmt.Sprintf("  To:        %s\n", transfer.ToUserID))
+	b.WriteString(fmt.Sprintf("  Amount:    %.2f credits\n", transfer.Amount))
+	if !transfer.RequestedAt.IsZero() {
+		b.WriteString(fmt.Sprintf("  Requested: %s\n", transfer.RequestedAt.Format("2006-01-02 15:04")))
+	}
+	if transfer.ApprovedBy != "" {
+		b.WriteString(fmt.Sprintf("  Approved:  by %s\n", transfer.ApprovedBy))
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_TransferCredits_ApprovalRequired(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if req["amount"].(float64) > 100 {
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transfer_id": "transfer-1", "to_user_id": req["to_user_id"], "amount": req["amount"], "status": "pending_approval",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "completed", "remaining_balance": 50.0})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token", WithTransferApprovalThreshold(100))
+	if err := client.TransferCredits(context.Background(), "teammate", 100); err != nil {
+		t.Fatalf("TransferCredits() at the threshold error = %v", err)
+	}
+	err := client.TransferCredits(context.Background(), "teammate", 250)
+	var pending *ApprovalRequiredError
+	if !errors.As(err, &pending) || !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("TransferCredits() above the threshold error = %v", err)
+	}
+	if pending.Transfer.ID != "transfer-1" || pending.Transfer.Amount != 250 {
+		t.Errorf("pending transfer = %+v", pending.Transfer)
+	}
+
+	if _, ok := requests[0]["require_approval"]; ok {
+		t.Error("a transfer at the threshold should not ask for approval")
+	}
+	if requests[1]["require_approval"] != true {
+		t.Errorf("a transfer above the threshold should ask for approval: %v", requests[1])
+	}
+}
+
+func TestSandbox_TransferApproval(t *testing.T) {
+	client := NewSandboxClient(WithTransferApprovalThreshold(50))
+	ctx := context.Background()
+
+	var pending *ApprovalRequiredError
+	if err := client.TransferCredits(ctx, "friend", 80); !errors.As(err, &pending) {
+		t.Fatalf("TransferCredits() error = %v, want approval required", err)
+	}
+	if balance := sandboxBalance(t, client); balance != SandboxStartingBalance {
+		t.Errorf("balance before approval = %.2f, no credits should move", balance)
+	}
+
+	transfers, err := client.ListPendingTransfers(ctx)
+	if err != nil || len(transfers) != 1 || transfers[0].ID != pending.Transfer.ID {
+		t.Fatalf("ListPendingTransfers() = %+v, %v", transfers, err)
+	}
+
+	approved, err := client.ApproveTransfer(ctx, pending.Transfer.ID)
+	if err != nil || approved.Status != TransferApproved || approved.ApprovedAt == nil {
+		t.Fatalf("ApproveTransfer() = %+v, %v", approved, err)
+	}
+	if balance := sandboxBalance(t, client); balance != SandboxStartingBalance-80 {
+		t.Errorf("balance after approval = %.2f", balance)
+	}
+	if _, err := client.ApproveTransfer(ctx, pending.Transfer.ID); !isConflict(err) {
+		t.Errorf("approving twice: %v", err)
+	}
+	if transfers, _ := client.ListPendingTransfers(ctx); len(transfers) != 0 {
+		t.Errorf("approved transfers should no longer be pending: %+v", transfers)
+	}
+}
+
+func TestTransferTool_ApprovalRequired(t *testing.T) {
+	client := NewSandboxClient(WithTransferApprovalThreshold(50))
+	tool := NewTransferTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"to_user_id": "user-0123456789abcdef", "amount": 75.0})
+	if result.IsError || !IsApprovalRequired(result) {
+		t.Fatalf("transfer above the threshold:\n%s", result.ForLLM)
+	}
+	for _, want := range []string{"Approval Required", "sandbox-transfer-0001 (pending_approval)", "No credits have moved"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result should contain %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "pending"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Transfers Awaiting Approval (1)") {
+		t.Errorf("pending:\n%s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "approve", "transfer_id": "sandbox-transfer-0001"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Transfer Approved") || IsApprovalRequired(result) {
+		t.Errorf("approve:\n%s", result.ForLLM)
+	}
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "approve"}); !result.IsError {
+		t.Error("approve without a transfer_id should fail")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"to_user_id": "user-0123456789abcdef", "amount": 20.0})
+	if result.IsError || IsApprovalRequired(result) {
+		t.Errorf("transfer below the threshold:\n%s", result.ForLLM)
+	}
+}
@@ -0,0 +1,106 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// transferOffersPath is the collection endpoint for escrowed transfers.
+const transferOffersPath = "/api/v1/credits/transfer-offers"
+
+// Limits on how long a recipient has to accept a transfer offer.
+const (
+	DefaultTransferOfferTTL = 24 * time.Hour
+	MinTransferOfferTTL     = time.Minute
+	MaxTransferOfferTTL     = 30 * 24 * time.Hour
+)
+
+// CreateTransferOffer moves credits into escrow for another user, who must
+// accept the offer before it expires to receive them. Unlike
+// TransferCredits the transfer can still be undone: a declined or expired
+// offer returns the credits to the sender.
+func (c *Client) CreateTransferOffer(ctx context.Context, req *TransferOfferRequest) (*TransferOffer, error) {
+	if req.ToUserID == "" {
+		return nil, fmt.Errorf("recipient is required")
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = DefaultTransferOfferTTL
+	}
+	if ttl < MinTransferOfferTTL || ttl > MaxTransferOfferTTL {
+		return nil, fmt.Errorf("offer must stay open between %s and %s, got %s", MinTransferOfferTTL, MaxTransferOfferTTL, ttl)
+	}
+
+	body := map[string]interface{}{
+		"to_user_id":  req.ToUserID,
+		"amount":      req.Amount,
+		"ttl_seconds": int(ttl / time.Second),
+	}
+	if req.Memo != "" {
+		body["memo"] = req.Memo
+	}
+
+	var result TransferOffer
+	if err := c.doRequest(ctx, http.MethodPost, transferOffersPath, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListTransferOffers retrieves the offers the authenticated user has made
+// or received, including resolved ones.
+func (c *Client) ListTransferOffers(ctx context.Context) ([]TransferOffer, error) {
+	var result struct {
+		Offers []TransferOffer `json:"transfer_offers"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, transferOffersPath, nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Offers == nil {
+		result.Offers = []TransferOffer{}
+	}
+	return result.Offers, nil
+}
+
+// GetTransferOffer retrieves a single transfer offer.
+func (c *Client) GetTransferOffer(ctx context.Context, offerID string) (*TransferOffer, error) {
+	var result TransferOffer
+	if err := c.doRequest(ctx, http.MethodGet, transferOfferPath(offerID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AcceptTransfer accepts a pending offer made to the authenticated user,
+// releasing the escrowed credits into their wallet.
+func (c *Client) AcceptTransfer(ctx context.Context, offerID string) (*TransferOffer, error) {
+	return c.resolveTransferOffer(ctx, offerID, "accept")
+}
+
+// DeclineTransfer turns down a pending offer made to the authenticated
+// user, or withdraws one they made, returning the escrowed credits to the
+// sender.
+func (c *Client) DeclineTransfer(ctx context.Context, offerID string) (*TransferOffer, error) {
+	return c.resolveTransferOffer(ctx, offerID, "decline")
+}
+
+func (c *Client) resolveTransferOffer(ctx context.Context, offerID, action string) (*TransferOffer, error) {
+	if offerID == "" {
+		return nil, fmt.Errorf("offer ID is required")
+	}
+	var result TransferOffer
+	if err := c.doRequest(ctx, http.MethodPost, transferOfferPath(offerID)+"/"+action, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func transferOfferPath(offerID string) string {
+	return transferOffersPath + "/" + url.PathEscape(offerID)
+}
@@ -0,0 +1,179 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_CreateTransferOffer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/credits/transfer-offers" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["to_user_id"] != "teammate" || req["amount"] != 25.0 || req["ttl_seconds"] != 7200.0 || req["memo"] != "thanks" {
+			t.Errorf("unexpected offer request %v", req)
+		}
+		json.NewEncoder(w).Encode(TransferOffer{ID: "offer-1", ToUserID: "teammate", Amount: 25, Status: TransferOfferPending})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	offer, err := client.CreateTransferOffer(context.Background(),
+		&TransferOfferRequest{ToUserID: "teammate", Amount: 25, Memo: "thanks", TTL: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("CreateTransferOffer() error = %v", err)
+	}
+	if offer.ID != "offer-1" || offer.Status != TransferOfferPending {
+		t.Errorf("offer = %+v", offer)
+	}
+
+	for _, req := range []*TransferOfferRequest{
+		{Amount: 25},
+		{ToUserID: "teammate"},
+		{ToUserID: "teammate", Amount: 25, TTL: time.Second},
+		{ToUserID: "teammate", Amount: 25, TTL: 365 * 24 * time.Hour},
+	} {
+		if _, err := client.CreateTransferOffer(context.Background(), req); err == nil {
+			t.Errorf("CreateTransferOffer(%+v) should fail", req)
+		}
+	}
+}
+
+func TestSandbox_TransferOfferEscrow(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	offer, err := client.CreateTransferOffer(ctx, &TransferOfferRequest{ToUserID: "friend", Amount: 100})
+	if err != nil {
+		t.Fatalf("CreateTransferOffer() error = %v", err)
+	}
+	if time.Until(offer.ExpiresAt) < 23*time.Hour {
+		t.Errorf("offer should default to a day, expires at %s", offer.ExpiresAt)
+	}
+	if balance := sandboxBalance(t, client); balance != SandboxStartingBalance-100 {
+		t.Errorf("balance with the offer in escrow = %.2f", balance)
+	}
+
+	declined, err := client.DeclineTransfer(ctx, offer.ID)
+	if err != nil || declined.Status != TransferOfferDeclined || declined.ResolvedAt == nil {
+		t.Fatalf("DeclineTransfer() = %+v, %v", declined, err)
+	}
+	if balance := sandboxBalance(t, client); balance != SandboxStartingBalance {
+		t.Errorf("balance after decline = %.2f, want the credits back", balance)
+	}
+	if _, err := client.AcceptTransfer(ctx, offer.ID); !isConflict(err) {
+		t.Errorf("accepting a declined offer: %v", err)
+	}
+
+	accepted, err := client.CreateTransferOffer(ctx, &TransferOfferRequest{ToUserID: "friend", Amount: 40})
+	if err != nil {
+		t.Fatalf("CreateTransferOffer() error = %v", err)
+	}
+	if accepted, err = client.AcceptTransfer(ctx, accepted.ID); err != nil || accepted.Status != TransferOfferAccepted {
+		t.Fatalf("AcceptTransfer() = %+v, %v", accepted, err)
+	}
+	if balance := sandboxBalance(t, client); balance != SandboxStartingBalance-40 {
+		t.Errorf("balance after accept = %.2f", balance)
+	}
+
+	if _, err := client.CreateTransferOffer(ctx, &TransferOfferRequest{ToUserID: "friend", Amount: SandboxStartingBalance}); !errors.Is(err, ErrInsufficientCredits) {
+		t.Errorf("offering more than the balance: %v", err)
+	}
+}
+
+func TestSandbox_TransferOfferExpires(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	offer, err := client.CreateTransferOffer(ctx, &TransferOfferRequest{ToUserID: "friend", Amount: 30, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("CreateTransferOffer() error = %v", err)
+	}
+	client.sandbox.mu.Lock()
+	client.sandbox.expireTransferOffers(time.Now().Add(2 * time.Hour))
+	client.sandbox.mu.Unlock()
+
+	offers, err := client.ListTransferOffers(ctx)
+	if err != nil || len(offers) != 1 || offers[0].ID != offer.ID || offers[0].Status != TransferOfferExpired {
+		t.Fatalf("ListTransferOffers() = %+v, %v", offers, err)
+	}
+	if balance := sandboxBalance(t, client); balance != SandboxStartingBalance {
+		t.Errorf("balance after expiry = %.2f, want the credits back", balance)
+	}
+}
+
+// sandboxBalance returns the sandbox wallet's balance.
+func sandboxBalance(t *testing.T, client *Client) float64 {
+	t.Helper()
+	credits, err := client.GetCredits(context.Background())
+	if err != nil {
+		t.Fatalf("GetCredits() error = %v", err)
+	}
+	return credits.Balance
+}
+
+// isConflict reports whether err is an HTTP 409 from the API.
+func isConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
+
+func TestTransferTool_Offers(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewTransferTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{
+		"action": "offer", "to_user_id": "friend", "amount": 12.5, "memo": "lunch", "expires_in_hours": 2.0,
+	})
+	if result.IsError {
+		t.Fatalf("offer failed:\n%s", result.ForLLM)
+	}
+	for _, want := range []string{"Transfer Offered", "sandbox-offer-0001 (pending)", "12.50 credits", "lunch", "escrow"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("offer result should contain %q:\n%s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "accept", "offer_id": "sandbox-offer-0001"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Accepted") {
+		t.Errorf("accept:\n%s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"action": "decline", "offer_id": "sandbox-offer-0001"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "already accepted") {
+		t.Errorf("declining an accepted offer:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Transfer Offers (1)") || !strings.Contains(result.ForLLM, "Settled:") {
+		t.Errorf("list:\n%s", result.ForLLM)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"action": "accept"},
+		{"action": "offer", "to_user_id": "friend"},
+		{"action": "offer", "to_user_id": "friend", "amount": 5.0, "expires_in_hours": -1.0},
+		{"action": "refund"},
+	} {
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("Execute(%v) should fail:\n%s", args, result.ForLLM)
+		}
+	}
+
+	if _, ok := tool.estimateSpend(map[string]interface{}{"action": "accept", "amount": 5.0}); ok {
+		t.Error("answering an offer should spend nothing")
+	}
+	if cost, ok := tool.estimateSpend(map[string]interface{}{"action": "offer", "amount": 5.0}); !ok || cost != 5 {
+		t.Errorf("an offer should count its escrow, got %.2f", cost)
+	}
+}
@@ -0,0 +1,279 @@
+package deparrow
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/sipeed/picoclaw/pkg/deparrow/deparrowpb"
+)
+
+// Transport is how the client reaches the endpoints the gRPC API mirrors.
+type Transport string
+
+const (
+	// TransportHTTP calls every endpoint over the REST API. It is the
+	// default.
+	TransportHTTP Transport = "http"
+
+	// TransportGRPC calls job submission, job status, cancellation,
+	// credits and network statistics over the gRPC API, and watches jobs
+	// over a gRPC stream. Everything else still goes over HTTP.
+	TransportGRPC Transport = "grpc"
+)
+
+// WithTransport picks the transport for the endpoints the gRPC API
+// mirrors. Sandbox clients ignore it.
+func WithTransport(t Transport) ClientOption {
+	return func(c *Client) {
+		c.transport = t
+	}
+}
+
+// WithGRPCAddress sets the host:port of the gRPC API. By default it is the
+// host of the base URL, on port 443 for https and 80 otherwise.
+func WithGRPCAddress(addr string) ClientOption {
+	return func(c *Client) {
+		c.grpcAddress = addr
+	}
+}
+
+// WithGRPCDialOptions adds options for dialling the gRPC API, applied after
+// the client's own, e.g. to supply other transport credentials.
+func WithGRPCDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *Client) {
+		c.grpcDialOptions = append(c.grpcDialOptions, opts...)
+	}
+}
+
+// Close releases the connection to the gRPC API, if one was made. The
+// client must not be used afterwards.
+func (c *Client) Close() error {
+	c.grpcOnce.Do(func() {
+		c.grpcErr = errors.New("client is closed")
+	})
+	if c.grpcConn == nil {
+		return nil
+	}
+	return c.grpcConn.Close()
+}
+
+// usesGRPC reports whether the mirrored endpoints go over gRPC.
+func (c *Client) usesGRPC() bool {
+	return c.transport == TransportGRPC && !c.IsSandbox()
+}
+
+// grpcAPI returns the gRPC API, connecting to it on first use. The
+// connection is TLS-secured when the base URL is https.
+func (c *Client) grpcAPI() (deparrowpb.DeparrowClient, error) {
+	c.grpcOnce.Do(func() {
+		u, err := url.Parse(c.baseURL)
+		if err != nil {
+			c.grpcErr = fmt.Errorf("invalid API URL: %w", err)
+			return
+		}
+		target := c.grpcAddress
+		if target == "" {
+			port := u.Port()
+			if port == "" {
+				port = "80"
+				if u.Scheme == "https" {
+					port = "443"
+				}
+			}
+			target = net.JoinHostPort(u.Hostname(), port)
+		}
+		creds := insecure.NewCredentials()
+		if u.Scheme == "https" {
+			creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+		}
+
+		opts := append([]grpc.DialOption{
+			grpc.WithTransportCredentials(creds),
+			grpc.WithUserAgent(c.userAgent),
+		}, c.grpcDialOptions...)
+		c.grpcConn, c.grpcErr = grpc.NewClient(target, opts...)
+	})
+	if c.grpcErr != nil {
+		return nil, fmt.Errorf("failed to connect to the gRPC API: %w", c.grpcErr)
+	}
+	return deparrowpb.NewDeparrowClient(c.grpcConn), nil
+}
+
+// grpcCall makes a unary call to the gRPC API standing in for the REST
+// request method path, with the same retry policy, rate limits, tracing,
+// metrics and token renewal. Errors are converted to *APIError with the
+// status the REST API would have answered.
+func (c *Client) grpcCall(ctx context.Context, method, path string,
+	call func(context.Context, deparrowpb.DeparrowClient) error,
+) error {
+	policy := c.retryPolicyFor(ctx)
+	attempts := policy.attempts(method)
+	if attempts > 1 && RequestIDFromContext(ctx) == "" {
+		ctx = WithRequestID(ctx, uuid.New().String())
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.grpcAuthenticated(ctx, method, path, false, call)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !policy.retries(err) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// grpcAuthenticated makes call like grpcInvoke. When the server rejects
+// the token and the token provider renews it, call is made once more.
+func (c *Client) grpcAuthenticated(ctx context.Context, method, path string, stream bool,
+	call func(context.Context, deparrowpb.DeparrowClient) error,
+) error {
+	req, err := c.newRequest(ctx, method, path, nil)
+	if err != nil {
+		return err
+	}
+	err = c.grpcInvoke(req, stream, call)
+	var apiErr *APIError
+	if err == nil || !errors.As(err, &apiErr) || !c.reauthenticate(req, apiErr.Code) {
+		return err
+	}
+	if req, err = c.newRequest(ctx, method, path, nil); err != nil {
+		return err
+	}
+	return c.grpcInvoke(req, stream, call)
+}
+
+// grpcInvoke makes call with the headers of req, the REST request it
+// stands in for, as metadata. Unary calls are throttled and bounded by the
+// client timeout like requests; streams, like the watch WebSocket, are not.
+func (c *Client) grpcInvoke(req *http.Request, stream bool,
+	call func(context.Context, deparrowpb.DeparrowClient) error,
+) error {
+	api, err := c.grpcAPI()
+	if err != nil {
+		return err
+	}
+	if !stream {
+		release, err := c.throttle(req)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	span := c.startRequestSpan(req)
+	ctx := metadata.NewOutgoingContext(req.Context(), grpcMetadata(req.Header))
+	if !stream && c.httpClient.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.httpClient.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err = grpcError(req, call(ctx, api))
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		c.observeRequest(req, http.StatusOK, start, nil)
+		endRequestSpan(span, http.StatusOK, nil)
+	case errors.As(err, &apiErr):
+		c.observeRequest(req, apiErr.Code, start, nil)
+		endRequestSpan(span, apiErr.Code, nil)
+		c.noteRateLimit(err)
+	default:
+		c.observeRequest(req, 0, start, err)
+		endRequestSpan(span, 0, err)
+	}
+	return err
+}
+
+// grpcMetadata carries request headers over as gRPC metadata, leaving out
+// those gRPC sets itself.
+func grpcMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for name, values := range header {
+		switch name {
+		case "Content-Type", "Accept-Encoding", "User-Agent":
+			continue
+		}
+		md.Append(strings.ToLower(name), values...)
+	}
+	return md
+}
+
+// grpcStatusCodes are the HTTP statuses the REST API answers with where the
+// gRPC API fails with a code.
+var grpcStatusCodes = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.Aborted:            http.StatusConflict,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+}
+
+// grpcError converts an error of a call standing in for req to *APIError,
+// so callers handle it like the REST API's. The reason of an ErrorInfo
+// detail is the error code, and a RetryInfo detail the Retry-After. Calls
+// ended by the caller's context are reported like failed requests.
+func grpcError(req *http.Request, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := req.Context().Err(); ctxErr != nil {
+		return fmt.Errorf("request failed: %w", ctxErr)
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	code, ok := grpcStatusCodes[st.Code()]
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+	apiErr := &APIError{
+		Code:      code,
+		Message:   st.Message(),
+		RequestID: req.Header.Get(HeaderRequestID),
+	}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			apiErr.ErrorCode = d.GetReason()
+		case *errdetails.RetryInfo:
+			apiErr.RetryAfter = d.GetRetryDelay().AsDuration()
+		}
+	}
+	if apiErr.ErrorCode == "" {
+		apiErr.ErrorCode = inferErrorCode(code, req.URL.Path)
+	}
+	return apiErr
+}
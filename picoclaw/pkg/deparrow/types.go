@@ -16,6 +16,40 @@ const (
 	JobStatusCancelled JobStatus = "cancelled"
 )
 
+// jobStage orders statuses through a job's lifecycle: it is scheduled,
+// runs, and ends completed, failed or cancelled. A job can fail or be
+// cancelled before it runs.
+var jobStage = map[JobStatus]int{
+	JobStatusPending:   0,
+	JobStatusRunning:   1,
+	JobStatusCompleted: 2,
+	JobStatusFailed:    2,
+	JobStatusCancelled: 2,
+}
+
+// IsTerminal reports whether the job has reached a final state.
+func (s JobStatus) IsTerminal() bool {
+	return s == JobStatusCompleted || s == JobStatusFailed || s == JobStatusCancelled
+}
+
+// Progress returns how far through its lifecycle a job in this status is,
+// as a percentage: 0 while pending, 50 while running and 100 once
+// terminal. Unknown statuses report 0.
+func (s JobStatus) Progress() int {
+	return jobStage[s] * 50
+}
+
+// CanTransition reports whether a job in status from can later be in status
+// to. Jobs only move forward, possibly past statuses an observer never saw,
+// such as from pending straight to completed. Staying in the same status
+// is not a transition, nothing leaves a terminal status, and unknown
+// statuses cannot be reached or left.
+func CanTransition(from, to JobStatus) bool {
+	fromStage, fromOK := jobStage[from]
+	toStage, toOK := jobStage[to]
+	return fromOK && toOK && !from.IsTerminal() && toStage > fromStage
+}
+
 // NodeStatus represents the current state of a compute node.
 type NodeStatus string
 
@@ -24,6 +58,11 @@ const (
 	NodeStatusOffline    NodeStatus = "offline"
 	NodeStatusMaintenance NodeStatus = "maintenance"
 	NodeStatusSuspended  NodeStatus = "suspended"
+	// Takes no new jobs while its running ones finish, as when being
+	// decommissioned
+	NodeStatusDraining NodeStatus = "draining"
+	// Decommissioned for good
+	NodeStatusRetired NodeStatus = "retired"
 )
 
 // ContributionTier represents a node's contribution level.
@@ -57,6 +96,39 @@ type Job struct {
 	CompletedAt  *time.Time             `json:"completed_at,omitempty"`
 	Error        string                 `json:"error,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Orchestrator string                 `json:"orchestrator,omitempty"`
+	// Times a spot job was evicted and requeued, oldest first
+	Evictions []JobEviction `json:"evictions,omitempty"`
+}
+
+// Duration returns how long the job ran, or has been running until now.
+func (j Job) Duration(now time.Time) time.Duration {
+	end := now
+	if j.CompletedAt != nil {
+		end = *j.CompletedAt
+	}
+	if end.Before(j.SubmittedAt) {
+		return 0
+	}
+	return end.Sub(j.SubmittedAt)
+}
+
+// JobSortField selects the order of the job list.
+type JobSortField string
+
+const (
+	JobSortSubmittedAt JobSortField = "submitted_at"
+	JobSortCost        JobSortField = "cost"
+	JobSortDuration    JobSortField = "duration"
+)
+
+// Valid reports whether the field is a supported sort order.
+func (f JobSortField) Valid() bool {
+	switch f {
+	case JobSortSubmittedAt, JobSortCost, JobSortDuration:
+		return true
+	}
+	return false
 }
 
 // JobSpec defines the specification for a compute job.
@@ -79,6 +151,25 @@ type JobSpec struct {
 	Priority int `json:"priority,omitempty"`
 	// Labels for job categorization
 	Labels map[string]string `json:"labels,omitempty"`
+	// Request a verified result, at a premium; Verification picks how
+	Verified     bool              `json:"verified,omitempty"`
+	Verification *VerificationSpec `json:"verification,omitempty"`
+	// Scheduling constraints; the job only runs on a node that meets all
+	// of them. Node labels that must match exactly
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Regions the node may be in (any of them)
+	Regions []string `json:"regions,omitempty"`
+	// CPU architectures the node may have (any of them)
+	Architectures []Architecture `json:"architectures,omitempty"`
+	// Nodes the job must not run on
+	ExcludeNodeIDs []string `json:"exclude_node_ids,omitempty"`
+	// Resubmit the job when it fails; carried out by a JobRunner
+	Retry *RetrySpec `json:"retry,omitempty"`
+	// Pricing class; empty is PricingGuaranteed
+	PricingClass PricingClass `json:"pricing_class,omitempty"`
+	// Most credits a spot job will pay; it waits in the queue while the
+	// spot price is higher. 0 accepts the going spot price.
+	MaxCreditBid float64 `json:"max_credit_bid,omitempty"`
 }
 
 // ResourceSpec defines resource requirements for a job.
@@ -135,8 +226,12 @@ type JobResults struct {
 	Duration float64 `json:"duration_seconds"`
 	// Node that executed the job
 	NodeID string `json:"node_id,omitempty"`
-	// Download URLs for outputs
+	// Download URLs for outputs, by name; paths are relative to the API
 	DownloadURLs map[string]string `json:"download_urls,omitempty"`
+	// SHA-256 checksums of the downloads, by name, hex-encoded
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// How the result was verified (verified jobs only)
+	Verification *VerificationResult `json:"verification,omitempty"`
 }
 
 // Node represents a compute node on the DEparrow network.
@@ -153,6 +248,10 @@ type Node struct {
 	Tier           ContributionTier       `json:"tier"`
 	Contribution   *NodeContribution      `json:"contribution,omitempty"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	// Version of the node-agent software the node runs
+	AgentVersion string `json:"agent_version,omitempty"`
+	// Jobs running on the node
+	RunningJobs int `json:"running_jobs,omitempty"`
 }
 
 // NodeResources describes a node's available resources.
@@ -162,6 +261,33 @@ type NodeResources struct {
 	GPU     int    `json:"gpu_count"`
 	GPUModel string `json:"gpu_model,omitempty"`
 	Storage string `json:"storage,omitempty"`
+	// Memory of each GPU, e.g. "24Gi"
+	VRAM string `json:"vram,omitempty"`
+}
+
+// Orchestrator is a requester node that accepts jobs and distributes them
+// to compute nodes.
+type Orchestrator struct {
+	ID        string     `json:"orchestrator_id"`
+	Host      string     `json:"host"`
+	Port      int        `json:"port"`
+	Region    string     `json:"region,omitempty"`
+	Status    NodeStatus `json:"status"`
+	NodeCount int        `json:"node_count"`
+	// Fraction of the orchestrator's job capacity in use (0-1)
+	Load     float64  `json:"load"`
+	Version  string   `json:"version,omitempty"`
+	Features []string `json:"features,omitempty"`
+}
+
+// Supports reports whether the orchestrator advertises a feature, e.g. "gpu".
+func (o Orchestrator) Supports(feature string) bool {
+	for _, f := range o.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
 }
 
 // Location represents geographical location.
@@ -190,6 +316,31 @@ type CreditBalance struct {
 	LastUpdated time.Time `json:"last_updated"`
 	// Minimum balance required for job submission
 	MinBalance float64 `json:"min_balance,omitempty"`
+	// Breakdown of the balance by origin and expiry
+	Buckets []CreditBucket `json:"buckets,omitempty"`
+}
+
+// CreditSource is where the credits in a bucket came from.
+type CreditSource string
+
+const (
+	CreditSourcePurchased CreditSource = "purchased"
+	CreditSourceEarned    CreditSource = "earned"
+	CreditSourcePromo     CreditSource = "promo"
+	CreditSourceGrant     CreditSource = "grant"
+)
+
+// CreditBucket is a portion of a balance with a common origin and expiry.
+// Promotional credits and grants usually expire; purchased and earned
+// credits usually don't.
+type CreditBucket struct {
+	ID          string       `json:"bucket_id"`
+	Source      CreditSource `json:"source"`
+	Amount      float64      `json:"amount"`
+	Description string       `json:"description,omitempty"`
+	GrantedAt   time.Time    `json:"granted_at"`
+	// Nil for credits that never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // Wallet represents a DEparrow wallet with transaction history.
@@ -198,6 +349,7 @@ type Wallet struct {
 	Balance     float64       `json:"balance"`
 	Transactions []Transaction `json:"transactions,omitempty"`
 	CreatedAt   time.Time     `json:"created_at"`
+	Buckets     []CreditBucket `json:"buckets,omitempty"`
 }
 
 // Transaction represents a credit transaction.
@@ -210,6 +362,8 @@ type Transaction struct {
 	// For transfers
 	FromUser string `json:"from_user,omitempty"`
 	ToUser   string `json:"to_user,omitempty"`
+	// For earnings, the node that earned the credits
+	NodeID string `json:"node_id,omitempty"`
 }
 
 // NetworkStats represents overall network statistics.
@@ -235,6 +389,9 @@ type LeaderboardEntry struct {
 	GPUHours        float64          `json:"gpu_usage_hours"`
 	TotalHours      float64          `json:"total_hours"`
 	Location        *Location        `json:"location,omitempty"`
+	// Rank in the previous period of the same window, 0 when the node
+	// wasn't ranked then; nil when the server doesn't report it
+	PreviousRank *int `json:"previous_rank,omitempty"`
 }
 
 // APIError represents an error response from the API.
@@ -242,6 +399,12 @@ type APIError struct {
 	Code    int    `json:"code"`
 	Message string `json:"error"`
 	Details string `json:"details,omitempty"`
+	// ErrorCode names the kind of failure, e.g. insufficient_credits; see Is
+	ErrorCode string `json:"error_code,omitempty"`
+	// RequestID is the X-Request-ID of the failed call, for matching server logs
+	RequestID string `json:"request_id,omitempty"`
+	// RetryAfter is how long the server asked to wait before trying again
+	RetryAfter time.Duration `json:"-"`
 }
 
 // Error implements the error interface.
@@ -251,3 +414,481 @@ func (e *APIError) Error() string {
 	}
 	return e.Message
 }
+
+// NetworkCapacity is the regional breakdown of currently free capacity.
+type NetworkCapacity struct {
+	Regions   []RegionCapacity `json:"regions"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// RegionCapacity describes the free capacity available in a single region.
+type RegionCapacity struct {
+	Region             string        `json:"region"`
+	OnlineNodes        int           `json:"online_nodes"`
+	AvailableCPU       int           `json:"available_cpu_cores"`
+	AvailableMemoryGB  float64       `json:"available_memory_gb"`
+	AvailableStorageGB float64       `json:"available_storage_gb"`
+	GPUs               []GPUCapacity `json:"gpus,omitempty"`
+}
+
+// GPUCapacity describes the free GPUs of one model within a region.
+type GPUCapacity struct {
+	Model     string `json:"model"`
+	Available int    `json:"available"`
+	// Largest number of free GPUs of this model on a single node
+	MaxPerNode int     `json:"max_per_node"`
+	MemoryGB   float64 `json:"memory_gb,omitempty"`
+}
+
+// Preferences holds the user's default choices for DEparrow tools.
+// Tools fall back to these values when a parameter is not supplied.
+type Preferences struct {
+	// Region to prefer for job placement and capacity checks
+	DefaultRegion string `json:"default_region,omitempty"`
+	// Resources applied to jobs that don't specify their own
+	DefaultResources *ResourceSpec `json:"default_resources,omitempty"`
+	// Maximum estimated credit cost for a single job (0 = no limit)
+	MaxJobCost float64 `json:"max_job_cost,omitempty"`
+	// GPU vendor to request when a job needs a GPU (e.g., "nvidia", "amd")
+	PreferredGPUVendor string               `json:"preferred_gpu_vendor,omitempty"`
+	Notifications      NotificationSettings `json:"notifications"`
+	UpdatedAt          time.Time            `json:"updated_at"`
+}
+
+// NotificationSettings controls which events the agent reports to the user.
+type NotificationSettings struct {
+	JobCompleted bool `json:"job_completed"`
+	JobFailed    bool `json:"job_failed"`
+	LowBalance   bool `json:"low_balance"`
+	// Balance below which a low balance warning is shown
+	LowBalanceThreshold float64 `json:"low_balance_threshold,omitempty"`
+}
+
+// StandingOrderInterval is how often a standing order transfers credits.
+type StandingOrderInterval string
+
+const (
+	IntervalDaily   StandingOrderInterval = "daily"
+	IntervalWeekly  StandingOrderInterval = "weekly"
+	IntervalMonthly StandingOrderInterval = "monthly"
+)
+
+// Valid reports whether the interval is supported.
+func (i StandingOrderInterval) Valid() bool {
+	switch i {
+	case IntervalDaily, IntervalWeekly, IntervalMonthly:
+		return true
+	}
+	return false
+}
+
+// Next returns the run following t.
+func (i StandingOrderInterval) Next(t time.Time) time.Time {
+	switch i {
+	case IntervalDaily:
+		return t.AddDate(0, 0, 1)
+	case IntervalWeekly:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 1, 0)
+	}
+}
+
+// StandingOrderStatus represents the state of a standing order.
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "active"
+	StandingOrderCancelled StandingOrderStatus = "cancelled"
+)
+
+// StandingOrder is a recurring credit transfer, e.g. a weekly allowance to
+// a teammate or the monthly funding of a project wallet.
+type StandingOrder struct {
+	ID          string                `json:"order_id"`
+	ToUserID    string                `json:"to_user_id"`
+	Amount      float64               `json:"amount"`
+	Interval    StandingOrderInterval `json:"interval"`
+	Description string                `json:"description,omitempty"`
+	Status      StandingOrderStatus   `json:"status"`
+	CreatedAt   time.Time             `json:"created_at"`
+	NextRun     time.Time             `json:"next_run"`
+	LastRun     *time.Time            `json:"last_run,omitempty"`
+	// Number of transfers made so far
+	Runs int `json:"runs"`
+	// Reason the most recent run failed, cleared on the next success
+	LastError string `json:"last_error,omitempty"`
+}
+
+// StandingOrderRequest creates or updates a standing order.
+type StandingOrderRequest struct {
+	ToUserID    string                `json:"to_user_id,omitempty"`
+	Amount      float64               `json:"amount,omitempty"`
+	Interval    StandingOrderInterval `json:"interval,omitempty"`
+	Description string                `json:"description,omitempty"`
+	// First transfer; defaults to now when creating
+	StartAt *time.Time `json:"start_at,omitempty"`
+}
+
+// TransferApprovalStatus represents the state of a transfer that needs a
+// second approver.
+type TransferApprovalStatus string
+
+const (
+	TransferPendingApproval TransferApprovalStatus = "pending_approval"
+	TransferApproved        TransferApprovalStatus = "approved"
+)
+
+// PendingTransfer is a transfer above the approval threshold. It is made
+// once a second user of the organization approves it; until then no
+// credits move.
+type PendingTransfer struct {
+	ID          string                 `json:"transfer_id"`
+	FromUserID  string                 `json:"from_user_id"`
+	ToUserID    string                 `json:"to_user_id"`
+	Amount      float64                `json:"amount"`
+	Status      TransferApprovalStatus `json:"status"`
+	RequestedAt time.Time              `json:"requested_at"`
+	ApprovedBy  string                 `json:"approved_by,omitempty"`
+	ApprovedAt  *time.Time             `json:"approved_at,omitempty"`
+}
+
+// TransferOfferStatus represents the state of an escrowed transfer.
+type TransferOfferStatus string
+
+const (
+	TransferOfferPending  TransferOfferStatus = "pending"
+	TransferOfferAccepted TransferOfferStatus = "accepted"
+	TransferOfferDeclined TransferOfferStatus = "declined"
+	TransferOfferExpired  TransferOfferStatus = "expired"
+)
+
+// TransferOffer is a credit transfer held in escrow until the recipient
+// accepts it. The credits leave the sender's wallet when the offer is made
+// and return to it if the offer is declined or expires.
+type TransferOffer struct {
+	ID         string              `json:"offer_id"`
+	FromUserID string              `json:"from_user_id"`
+	ToUserID   string              `json:"to_user_id"`
+	Amount     float64             `json:"amount"`
+	Memo       string              `json:"memo,omitempty"`
+	Status     TransferOfferStatus `json:"status"`
+	CreatedAt  time.Time           `json:"created_at"`
+	// When a pending offer expires and its credits return to the sender
+	ExpiresAt time.Time `json:"expires_at"`
+	// When the offer was accepted, declined or expired
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// TransferOfferRequest makes an escrowed transfer.
+type TransferOfferRequest struct {
+	ToUserID string  `json:"to_user_id"`
+	Amount   float64 `json:"amount"`
+	Memo     string  `json:"memo,omitempty"`
+	// How long the recipient has to accept; DefaultTransferOfferTTL when zero
+	TTL time.Duration `json:"-"`
+}
+
+// DefaultMaxConcurrentRuns is how many runs of a schedule may be active at
+// once when the schedule doesn't say.
+const DefaultMaxConcurrentRuns = 1
+
+// JobSchedule submits a job spec on a cron schedule, e.g. a nightly
+// training run or an hourly scrape. A run that falls due while the
+// schedule is at its concurrency limit or out of budget is skipped.
+type JobSchedule struct {
+	ID   string `json:"schedule_id"`
+	Name string `json:"name,omitempty"`
+	// Five-field cron expression, evaluated in Timezone
+	Cron     string   `json:"cron"`
+	Timezone string   `json:"timezone,omitempty"`
+	Spec     *JobSpec `json:"spec"`
+	// Runs that may be pending or running at the same time
+	MaxConcurrentRuns int `json:"max_concurrent_runs"`
+	// Credits the runs may spend per BudgetWindow, 0 for no limit
+	BudgetPerWindow float64               `json:"budget_per_window,omitempty"`
+	BudgetWindow    StandingOrderInterval `json:"budget_window,omitempty"`
+	SpentInWindow   float64               `json:"spent_in_window"`
+	WindowResetsAt  *time.Time            `json:"window_resets_at,omitempty"`
+	ActiveRuns      int                   `json:"active_runs"`
+	// Number of jobs submitted and of runs skipped so far
+	Runs        int        `json:"runs"`
+	SkippedRuns int        `json:"skipped_runs"`
+	NextRunAt   time.Time  `json:"next_run_at"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	LastJobID   string     `json:"last_job_id,omitempty"`
+	// Why the most recent run was skipped, cleared on the next submission
+	LastSkipReason string    `json:"last_skip_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// JobScheduleRequest creates a job schedule.
+type JobScheduleRequest struct {
+	Name              string                `json:"name,omitempty"`
+	Cron              string                `json:"cron"`
+	Timezone          string                `json:"timezone,omitempty"`
+	Spec              *JobSpec              `json:"spec"`
+	MaxConcurrentRuns int                   `json:"max_concurrent_runs,omitempty"`
+	BudgetPerWindow   float64               `json:"budget_per_window,omitempty"`
+	BudgetWindow      StandingOrderInterval `json:"budget_window,omitempty"`
+}
+
+// CalendarSlot is a window in which a provider offers a node's GPUs for
+// booking, e.g. a rig that is free over the weekend.
+type CalendarSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// GPUs not yet booked for the whole window
+	AvailableGPUs int `json:"available_gpus"`
+	// Price of one GPU for one hour, in credits
+	CreditsPerGPUHour float64 `json:"credits_per_gpu_hour"`
+}
+
+// CapacityCalendar is the availability a provider publishes for a node.
+type CapacityCalendar struct {
+	NodeID   string         `json:"node_id"`
+	Region   string         `json:"region,omitempty"`
+	GPUModel string         `json:"gpu_model,omitempty"`
+	GPUCount int            `json:"gpu_count"`
+	Slots    []CalendarSlot `json:"slots"`
+}
+
+// BookingStatus represents the state of a capacity booking.
+type BookingStatus string
+
+const (
+	BookingHeld      BookingStatus = "held"
+	BookingCancelled BookingStatus = "cancelled"
+	BookingCompleted BookingStatus = "completed"
+)
+
+// Booking reserves GPUs on a node for a future window. Its cost is held
+// from the wallet when it is made and released if it is cancelled.
+type Booking struct {
+	ID       string        `json:"booking_id"`
+	NodeID   string        `json:"node_id"`
+	GPUModel string        `json:"gpu_model,omitempty"`
+	GPUs     int           `json:"gpus"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Status   BookingStatus `json:"status"`
+	// Credits held for the booking
+	HoldAmount float64   `json:"hold_amount"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BookingRequest books GPUs on a node for a window inside one of its
+// calendar slots.
+type BookingRequest struct {
+	NodeID string    `json:"node_id"`
+	GPUs   int       `json:"gpus"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// NodeAgentRelease is a published version of the node-agent software.
+type NodeAgentRelease struct {
+	Version    string       `json:"version"`
+	Arch       Architecture `json:"arch"`
+	Channel    string       `json:"channel"`
+	ReleasedAt time.Time    `json:"released_at"`
+	// Whether the release fixes a security issue
+	SecurityFix bool `json:"security_fix"`
+	// Earnings multiplier applied to nodes running this version
+	EarningsMultiplier float64 `json:"earnings_multiplier"`
+	Notes              string  `json:"notes,omitempty"`
+	// Oldest version still allowed to join the network
+	MinSupported string `json:"min_supported,omitempty"`
+}
+
+// UpdateSeverity ranks how urgently a node should be updated.
+type UpdateSeverity string
+
+const (
+	UpdateCurrent     UpdateSeverity = "current"
+	UpdateRecommended UpdateSeverity = "recommended"
+	UpdateSecurity    UpdateSeverity = "security"
+	UpdateUnsupported UpdateSeverity = "unsupported"
+	UpdateUnknown     UpdateSeverity = "unknown"
+)
+
+// UpdateAdvisory tells an operator whether a node runs outdated software
+// and what the newer releases bring.
+type UpdateAdvisory struct {
+	NodeID         string           `json:"node_id"`
+	Arch           Architecture     `json:"arch"`
+	CurrentVersion string           `json:"current_version"`
+	Latest         NodeAgentRelease `json:"latest"`
+	Outdated       bool             `json:"outdated"`
+	Severity       UpdateSeverity   `json:"severity"`
+	// Releases newer than the current version, newest first
+	Missing []NodeAgentRelease `json:"missing,omitempty"`
+	// Earnings multiplier of the version the node runs
+	CurrentMultiplier float64 `json:"current_multiplier"`
+}
+
+// SecurityFixes returns the missing releases that fix security issues.
+func (a *UpdateAdvisory) SecurityFixes() []NodeAgentRelease {
+	var out []NodeAgentRelease
+	for _, r := range a.Missing {
+		if r.SecurityFix {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// EarningsGain returns the relative earnings increase from updating,
+// e.g. 0.1 for 10% more credits. It is 0 when nothing is gained.
+func (a *UpdateAdvisory) EarningsGain() float64 {
+	if a.CurrentMultiplier <= 0 || a.Latest.EarningsMultiplier <= a.CurrentMultiplier {
+		return 0
+	}
+	return a.Latest.EarningsMultiplier/a.CurrentMultiplier - 1
+}
+
+// APIKeyScope limits what requests an API key may make.
+type APIKeyScope string
+
+const (
+	// APIKeyScopeFull allows everything the owner can do except managing API keys.
+	APIKeyScopeFull APIKeyScope = "full"
+	// APIKeyScopeReadOnly allows only reads.
+	APIKeyScopeReadOnly APIKeyScope = "read-only"
+	// APIKeyScopeSubmitOnly allows submitting jobs and checking on them.
+	APIKeyScopeSubmitOnly APIKeyScope = "submit-only"
+)
+
+// Valid reports whether the scope is supported.
+func (s APIKeyScope) Valid() bool {
+	switch s {
+	case APIKeyScopeFull, APIKeyScopeReadOnly, APIKeyScopeSubmitOnly:
+		return true
+	}
+	return false
+}
+
+// APIKey is a credential for programmatic access, e.g. from CI, that works
+// without a user session.
+type APIKey struct {
+	ID    string      `json:"key_id"`
+	Name  string      `json:"name"`
+	Scope APIKeyScope `json:"scope"`
+	// First characters of the secret, to tell keys apart
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// Secret to send as X-API-Key. Only returned when the key is
+	// created or rotated; store it then, it cannot be retrieved later.
+	Secret string `json:"secret,omitempty"`
+}
+
+// Revoked reports whether the key has been revoked.
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// APIKeyRequest issues an API key.
+type APIKeyRequest struct {
+	Name  string      `json:"name"`
+	Scope APIKeyScope `json:"scope"`
+}
+
+// FundingSourceType is how a funding source pays for credits.
+type FundingSourceType string
+
+const (
+	FundingSourceCard   FundingSourceType = "card"
+	FundingSourceBank   FundingSourceType = "bank_transfer"
+	FundingSourceCrypto FundingSourceType = "crypto"
+)
+
+// FundingSource is a way of buying credits, e.g. a saved card.
+type FundingSource struct {
+	ID   string            `json:"source_id"`
+	Type FundingSourceType `json:"type"`
+	// Human-readable name, e.g. "Visa ending 4242"
+	Label    string `json:"label"`
+	Currency string `json:"currency"`
+	// Credits one unit of the currency buys
+	CreditsPerUnit float64 `json:"credits_per_unit"`
+	// Limits on the credits a single top-up may buy (0 for none)
+	MinAmount float64 `json:"min_amount,omitempty"`
+	MaxAmount float64 `json:"max_amount,omitempty"`
+	// Used when a top-up names no source
+	Default bool `json:"default"`
+	// Whether the user must finish paying on a checkout page
+	RequiresCheckout bool `json:"requires_checkout"`
+	// Payment provider that hosts the checkout, e.g. "stripe"
+	Provider string `json:"provider,omitempty"`
+}
+
+// TopUpStatus represents the state of a top-up.
+type TopUpStatus string
+
+const (
+	TopUpPending   TopUpStatus = "pending"
+	TopUpCompleted TopUpStatus = "completed"
+	TopUpFailed    TopUpStatus = "failed"
+	TopUpExpired   TopUpStatus = "expired"
+)
+
+// TopUp is a purchase of credits from a funding source. Top-ups that need
+// a checkout stay pending until the payment provider confirms the payment;
+// the credits are added to the wallet then.
+type TopUp struct {
+	ID       string `json:"top_up_id"`
+	SourceID string `json:"source_id"`
+	// Payment provider handling the checkout, if any
+	Provider string `json:"provider,omitempty"`
+	// Credits bought
+	Amount float64 `json:"amount"`
+	// What the credits cost, in Currency
+	Price    float64     `json:"price"`
+	Currency string      `json:"currency"`
+	Status   TopUpStatus `json:"status"`
+	// Page the user completes the payment on, while pending
+	CheckoutURL string `json:"checkout_url,omitempty"`
+	// When the checkout page stops accepting the payment
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+}
+
+// DecommissionStage is how far a node's decommissioning has got.
+type DecommissionStage string
+
+const (
+	// The node takes no new jobs; queued ones are moved to other nodes and
+	// running ones are allowed to finish
+	DecommissionDraining DecommissionStage = "draining"
+	// The node's unpaid earnings are being settled to its owner's wallet
+	DecommissionFinalizing DecommissionStage = "finalizing_earnings"
+	// The node's key is being revoked so it can no longer join the network
+	DecommissionRevokingKey DecommissionStage = "revoking_key"
+	// The node is retired; this is final
+	DecommissionRetired DecommissionStage = "retired"
+)
+
+// Decommission tracks the graceful removal of a node from the network.
+type Decommission struct {
+	NodeID      string            `json:"node_id"`
+	Stage       DecommissionStage `json:"stage"`
+	RequestedAt time.Time         `json:"requested_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	// Queued jobs moved to other nodes
+	JobsRescheduled int `json:"jobs_rescheduled"`
+	// Jobs still running on the node
+	JobsRemaining int `json:"jobs_remaining"`
+	// Credits paid out when the earnings were finalized
+	FinalEarnings float64    `json:"final_earnings"`
+	KeyRevokedAt  *time.Time `json:"key_revoked_at,omitempty"`
+}
+
+// Done reports whether the node has been retired.
+func (d *Decommission) Done() bool {
+	return d.Stage == DecommissionRetired
+}
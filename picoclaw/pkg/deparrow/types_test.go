@@ -29,6 +29,56 @@ func TestJobStatus_Constants(t *testing.T) {
 	}
 }
 
+func TestJobStatus_Lifecycle(t *testing.T) {
+	tests := []struct {
+		status   JobStatus
+		terminal bool
+		progress int
+	}{
+		{JobStatusPending, false, 0},
+		{JobStatusRunning, false, 50},
+		{JobStatusCompleted, true, 100},
+		{JobStatusFailed, true, 100},
+		{JobStatusCancelled, true, 100},
+		{"queued", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsTerminal(); got != tt.terminal {
+				t.Errorf("IsTerminal() = %v, want %v", got, tt.terminal)
+			}
+			if got := tt.status.Progress(); got != tt.progress {
+				t.Errorf("Progress() = %d, want %d", got, tt.progress)
+			}
+		})
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to JobStatus
+		want     bool
+	}{
+		{JobStatusPending, JobStatusRunning, true},
+		{JobStatusPending, JobStatusCompleted, true},
+		{JobStatusPending, JobStatusCancelled, true},
+		{JobStatusRunning, JobStatusFailed, true},
+		{JobStatusRunning, JobStatusPending, false},
+		{JobStatusRunning, JobStatusRunning, false},
+		{JobStatusCompleted, JobStatusFailed, false},
+		{JobStatusCancelled, JobStatusRunning, false},
+		{JobStatusPending, "queued", false},
+		{"queued", JobStatusRunning, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
 func TestNodeStatus_Constants(t *testing.T) {
 	tests := []struct {
 		name     string
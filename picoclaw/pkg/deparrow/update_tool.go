@@ -0,0 +1,149 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// updateSeverityRank orders advisories from most to least urgent.
+var updateSeverityRank = map[UpdateSeverity]int{
+	UpdateUnsupported: 0,
+	UpdateSecurity:    1,
+	UpdateRecommended: 2,
+	UpdateUnknown:     3,
+	UpdateCurrent:     4,
+}
+
+// NodeUpdateTool tells operators which of their nodes run outdated
+// node-agent software and what the newer releases bring.
+type NodeUpdateTool struct {
+	client *Client
+}
+
+// NewNodeUpdateTool creates a new node update advisory tool.
+func NewNodeUpdateTool(client *Client) *NodeUpdateTool {
+	return &NodeUpdateTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *NodeUpdateTool) Name() string {
+	return "deparrow_node_updates"
+}
+
+// Description returns the tool description.
+func (t *NodeUpdateTool) Description() string {
+	return `Check which of your nodes run outdated node-agent software.
+
+For each node, shows the installed and recommended versions and what an
+update brings: security fixes and earnings multipliers. Nodes below the
+minimum supported version can no longer join the network.`
+}
+
+// Parameters returns the JSON schema for tool parameters.
+func (t *NodeUpdateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"node_ids": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "IDs of the nodes you operate",
+			},
+		},
+		"required": []string{"node_ids"},
+	}
+}
+
+// Execute checks each node against the update channel.
+func (t *NodeUpdateTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	nodeIDs := parseNodeIDs(args["node_ids"])
+	if len(nodeIDs) == 0 {
+		return tools.ErrorResult("node_ids is required")
+	}
+
+	var advisories []*UpdateAdvisory
+	var partial partialResult
+	for _, id := range nodeIDs {
+		advisory, err := t.client.GetUpdateAdvisory(ctx, id)
+		if err != nil {
+			partial.miss(id, err)
+			continue
+		}
+		advisories = append(advisories, advisory)
+	}
+	if len(advisories) == 0 {
+		return tools.ErrorResult("Failed to check nodes: " + describeMissing(partial.missing))
+	}
+
+	sort.SliceStable(advisories, func(i, j int) bool {
+		return updateSeverityRank[advisories[i].Severity] < updateSeverityRank[advisories[j].Severity]
+	})
+
+	return partial.result(formatUpdateAdvisories(advisories))
+}
+
+// formatUpdateAdvisories renders the advisories, most urgent first.
+func formatUpdateAdvisories(advisories []*UpdateAdvisory) string {
+	var result strings.Builder
+	result.WriteString("🛠️  Node Software Updates\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	current, outdated, security := 0, 0, 0
+	for _, a := range advisories {
+		switch a.Severity {
+		case UpdateCurrent:
+			current++
+			result.WriteString(fmt.Sprintf("✅ %s — up to date (%s)\n\n", a.NodeID, a.CurrentVersion))
+			continue
+		case UpdateUnknown:
+			result.WriteString(fmt.Sprintf("❓ %s — version unknown, latest is %s\n", a.NodeID, a.Latest.Version))
+			result.WriteString("   The node did not report its node-agent version\n\n")
+			continue
+		case UpdateUnsupported:
+			result.WriteString(fmt.Sprintf("🚨 %s — %s → %s (unsupported)\n", a.NodeID, a.CurrentVersion, a.Latest.Version))
+			result.WriteString(fmt.Sprintf("   Below the minimum supported %s, the node can no longer join the network\n", a.Latest.MinSupported))
+		case UpdateSecurity:
+			result.WriteString(fmt.Sprintf("🔒 %s — %s → %s (security update)\n", a.NodeID, a.CurrentVersion, a.Latest.Version))
+		default:
+			result.WriteString(fmt.Sprintf("⚠️  %s — %s → %s (update recommended)\n", a.NodeID, a.CurrentVersion, a.Latest.Version))
+		}
+		outdated++
+
+		fixes := a.SecurityFixes()
+		if len(fixes) > 0 {
+			security++
+		}
+		for _, r := range fixes {
+			result.WriteString(fmt.Sprintf("   🔒 %s: %s\n", r.Version, r.Notes))
+		}
+		if gain := a.EarningsGain(); gain > 0 {
+			result.WriteString(fmt.Sprintf("   💰 +%.0f%% earnings with %s\n", gain*100, a.Latest.Version))
+		}
+		for _, r := range a.Missing {
+			if !r.SecurityFix && r.Notes != "" {
+				result.WriteString(fmt.Sprintf("   • %s: %s\n", r.Version, r.Notes))
+			}
+		}
+		result.WriteString("\n")
+	}
+
+	result.WriteString(fmt.Sprintf("📊 %d up to date · %d outdated", current, outdated))
+	if security > 0 {
+		result.WriteString(fmt.Sprintf(" (%d missing security fixes)", security))
+	}
+	result.WriteString("\n")
+
+	if outdated > 0 {
+		result.WriteString("\n💡 Update with your node's package manager or re-run the node installer, ")
+		result.WriteString("then restart the node agent.")
+	}
+
+	return result.String()
+}
+
+// Ensure tool implements the Tool interface
+var _ tools.Tool = (*NodeUpdateTool)(nil)
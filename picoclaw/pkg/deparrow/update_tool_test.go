@@ -0,0 +1,106 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.6.0", "1.6.0", 0},
+		{"v1.6", "1.6.0", 0},
+		{"1.5.2", "1.6.0", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0.0-rc1", "1.9.0", 1},
+		{"", "1.0.0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClient_GetUpdateAdvisory(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	releases, err := client.GetNodeAgentReleases(ctx)
+	if err != nil {
+		t.Fatalf("GetNodeAgentReleases() error = %v", err)
+	}
+	if len(releases) != 2 {
+		t.Errorf("GetNodeAgentReleases() = %d releases, want one per architecture", len(releases))
+	}
+
+	tests := []struct {
+		nodeID   string
+		severity UpdateSeverity
+		missing  int
+	}{
+		{"node-use-a100-01", UpdateCurrent, 0},
+		{"node-use-a100-02", UpdateRecommended, 1},
+		{"node-use-cpu-01", UpdateSecurity, 2},
+		{"node-aps-rtx-01", UpdateUnsupported, 3},
+	}
+	for _, tt := range tests {
+		advisory, err := client.GetUpdateAdvisory(ctx, tt.nodeID)
+		if err != nil {
+			t.Fatalf("GetUpdateAdvisory(%s) error = %v", tt.nodeID, err)
+		}
+		if advisory.Severity != tt.severity || len(advisory.Missing) != tt.missing {
+			t.Errorf("%s: severity %s with %d missing, want %s with %d",
+				tt.nodeID, advisory.Severity, len(advisory.Missing), tt.severity, tt.missing)
+		}
+		if advisory.Outdated != (tt.missing > 0) {
+			t.Errorf("%s: Outdated = %v", tt.nodeID, advisory.Outdated)
+		}
+	}
+
+	advisory, _ := client.GetUpdateAdvisory(ctx, "node-use-cpu-01")
+	if gain := advisory.EarningsGain(); gain < 0.099 || gain > 0.101 {
+		t.Errorf("EarningsGain() = %v, want 0.10", gain)
+	}
+	if _, err := client.GetUpdateAdvisory(ctx, "missing-node"); err == nil {
+		t.Error("expected error for unknown node")
+	}
+}
+
+func TestNodeUpdateTool_Execute(t *testing.T) {
+	tool := NewNodeUpdateTool(NewSandboxClient())
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"node_ids": []interface{}{"node-use-a100-01", "node-aps-rtx-01", "node-use-cpu-01", "missing-node"},
+	})
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+
+	for _, want := range []string{
+		"node-use-a100-01 — up to date",
+		"1.4.0 → 1.6.0 (unsupported)",
+		"container escape",
+		"+10% earnings with 1.6.0",
+		"1 up to date · 2 outdated (2 missing security fixes)",
+		"❌ missing-node",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("Result should contain %q: %s", want, result.ForLLM)
+		}
+	}
+
+	// Most urgent first
+	if strings.Index(result.ForLLM, "node-aps-rtx-01") > strings.Index(result.ForLLM, "node-use-a100-01") {
+		t.Error("unsupported node should be listed before up-to-date nodes")
+	}
+
+	if result := tool.Execute(context.Background(), nil); !result.IsError {
+		t.Error("Execute() without node_ids should fail")
+	}
+}
@@ -0,0 +1,68 @@
+package deparrow
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GetNodeAgentReleases retrieves the latest recommended node-agent release
+// for each architecture.
+func (c *Client) GetNodeAgentReleases(ctx context.Context) ([]NodeAgentRelease, error) {
+	var result struct {
+		Releases []NodeAgentRelease `json:"releases"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, "/api/v1/node-agent/releases", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Releases, nil
+}
+
+// GetUpdateAdvisory checks whether a node runs the recommended node-agent
+// version and lists the releases it is missing.
+func (c *Client) GetUpdateAdvisory(ctx context.Context, nodeID string) (*UpdateAdvisory, error) {
+	var result UpdateAdvisory
+	err := c.doRequest(ctx, http.MethodGet, "/api/v1/nodes/"+url.PathEscape(nodeID)+"/update-advisory", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// compareVersions compares dotted numeric versions such as "v1.4.2",
+// returning -1, 0 or 1. Missing components count as zero and any
+// pre-release suffix is ignored.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(p)
+		parts = append(parts, n)
+	}
+	return parts
+}
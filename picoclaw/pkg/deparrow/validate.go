@@ -0,0 +1,332 @@
+package deparrow
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxJobTimeout is the longest timeout, in seconds, a job may ask for.
+const MaxJobTimeout = 7 * 24 * 3600
+
+// FieldError is a problem with one field of a job spec.
+type FieldError struct {
+	// Field is the path of the field, such as "resources.cpu" or "inputs[1].path"
+	Field   string
+	Value   string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	if e.Value == "" {
+		return e.Field + ": " + e.Message
+	}
+	return fmt.Sprintf("%s %q: %s", e.Field, e.Value, e.Message)
+}
+
+// ValidationError lists every problem found in a job spec.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "invalid job spec: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors, for errors.As.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Field returns the error for a field, or nil when the field is valid.
+func (e *ValidationError) Field(field string) *FieldError {
+	for _, err := range e.Errors {
+		if err.Field == field {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	// imageComponent is one path component of an image name.
+	imageComponent = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	// imageRegistry is a registry host with an optional port.
+	imageRegistry = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9.-]*[A-Za-z0-9])?(?::[0-9]+)?$`)
+	imageTag      = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	imageDigest   = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+	cpuQuantity  = regexp.MustCompile(`^(?:[0-9]+(?:\.[0-9]+)?|[0-9]+m)$`)
+	byteQuantity = regexp.MustCompile(`^[0-9]+(?:\.[0-9]+)?(?:[KMGTP]i?[Bb]?|[kmgtp][Bb]?|[Bb])?$`)
+	envName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// storageSources are the input sources the network can fetch.
+var storageSources = map[string]bool{"ipfs": true, "s3": true, "url": true, "inline": true}
+
+// storageDestinations are where the network can publish outputs.
+var storageDestinations = map[string]bool{"ipfs": true, "s3": true}
+
+// Validate checks the spec before it is submitted: the image reference,
+// the CPU, memory, GPU and storage quantities, the timeout and priority
+// bounds, environment variable names, that no two inputs or outputs
+// share or nest inside each other's path, the scheduling constraints, the
+// retry policy and the pricing class and bid. It returns nil or a *ValidationError listing every
+// problem found.
+func (s *JobSpec) Validate() error {
+	v := &ValidationError{}
+	add := func(field, value, message string) {
+		v.Errors = append(v.Errors, &FieldError{Field: field, Value: value, Message: message})
+	}
+
+	if s == nil {
+		add("spec", "", "is nil")
+		return v
+	}
+
+	if s.Image == "" {
+		add("image", "", "is required")
+	} else if msg := checkImageRef(s.Image); msg != "" {
+		add("image", s.Image, msg)
+	}
+
+	if r := s.Resources; r != nil {
+		if r.CPU != "" && (!cpuQuantity.MatchString(r.CPU) || isZeroQuantity(r.CPU)) {
+			add("resources.cpu", r.CPU, "must be a positive number of cores, such as 2, 0.5 or 500m")
+		}
+		if r.Memory != "" && (!byteQuantity.MatchString(r.Memory) || isZeroQuantity(r.Memory)) {
+			add("resources.memory", r.Memory, "must be a positive size, such as 512Mi or 4Gi")
+		}
+		if r.Storage != "" && (!byteQuantity.MatchString(r.Storage) || isZeroQuantity(r.Storage)) {
+			add("resources.storage", r.Storage, "must be a positive size, such as 10Gi")
+		}
+		if r.GPU != "" {
+			if n, err := strconv.Atoi(r.GPU); err != nil || n < 0 {
+				add("resources.gpu", r.GPU, "must be a whole number of GPUs")
+			}
+		}
+	}
+
+	if s.Timeout < 0 || s.Timeout > MaxJobTimeout {
+		add("timeout", strconv.Itoa(s.Timeout), fmt.Sprintf("must be between 0 and %d seconds", MaxJobTimeout))
+	}
+	if s.Priority < 0 || s.Priority > 100 {
+		add("priority", strconv.Itoa(s.Priority), "must be between 0 and 100")
+	}
+
+	for name := range s.Env {
+		if !envName.MatchString(name) {
+			add("env", name, "is not a valid variable name")
+		}
+	}
+
+	// Mount paths, each with the field that claims it
+	var mounts []struct{ field, path string }
+	claim := func(field, p string) {
+		if p == "" {
+			add(field, "", "is required")
+			return
+		}
+		if !path.IsAbs(p) {
+			add(field, p, "must be an absolute path")
+			return
+		}
+		p = path.Clean(p)
+		for _, m := range mounts {
+			if pathsOverlap(p, m.path) {
+				add(field, p, fmt.Sprintf("collides with %s %s", m.field, m.path))
+				return
+			}
+		}
+		mounts = append(mounts, struct{ field, path string }{field, p})
+	}
+
+	for i, in := range s.Inputs {
+		field := fmt.Sprintf("inputs[%d]", i)
+		if !storageSources[in.StorageSource] {
+			add(field+".storage_source", in.StorageSource, "must be ipfs, s3, url or inline")
+		}
+		if in.Source == "" {
+			add(field+".source", "", "is required")
+		}
+		claim(field+".path", in.Path)
+	}
+	for i, out := range s.Outputs {
+		field := fmt.Sprintf("outputs[%d]", i)
+		if out.StorageDestination != "" && !storageDestinations[out.StorageDestination] {
+			add(field+".storage_destination", out.StorageDestination, "must be ipfs or s3")
+		}
+		claim(field+".path", out.Path)
+	}
+
+	if _, err := s.verification(); err != nil {
+		add("verification", "", err.Error())
+	}
+
+	for key := range s.NodeSelector {
+		if key == "" {
+			add("node_selector", "", "label names must not be empty")
+		}
+	}
+	for i, region := range s.Regions {
+		if strings.TrimSpace(region) == "" {
+			add(fmt.Sprintf("regions[%d]", i), "", "must not be empty")
+		}
+	}
+	for i, arch := range s.Architectures {
+		if arch != ArchX86_64 && arch != ArchARM64 {
+			add(fmt.Sprintf("architectures[%d]", i), string(arch), "must be x86_64 or arm64")
+		}
+	}
+	for i, id := range s.ExcludeNodeIDs {
+		if id == "" {
+			add(fmt.Sprintf("exclude_node_ids[%d]", i), "", "must not be empty")
+		}
+	}
+
+	if r := s.Retry; r != nil {
+		if r.MaxAttempts < 1 || r.MaxAttempts > MaxRetryAttempts {
+			add("retry.max_attempts", strconv.Itoa(r.MaxAttempts), fmt.Sprintf("must be between 1 and %d", MaxRetryAttempts))
+		}
+		if r.BackoffSeconds < 0 {
+			add("retry.backoff_seconds", strconv.Itoa(r.BackoffSeconds), "must not be negative")
+		}
+		if r.MaxBackoffSeconds < 0 {
+			add("retry.max_backoff_seconds", strconv.Itoa(r.MaxBackoffSeconds), "must not be negative")
+		}
+		for i, code := range r.RetryOnExitCodes {
+			if code < 0 || code > 255 {
+				add(fmt.Sprintf("retry.retry_on_exit_codes[%d]", i), strconv.Itoa(code), "must be between 0 and 255")
+			}
+		}
+		if r.MaxCredits < 0 {
+			add("retry.max_credits", strconv.FormatFloat(r.MaxCredits, 'f', -1, 64), "must not be negative")
+		}
+	}
+
+	switch s.PricingClass {
+	case "", PricingGuaranteed, PricingSpot:
+	default:
+		add("pricing_class", string(s.PricingClass), "must be guaranteed or spot")
+	}
+	bid := strconv.FormatFloat(s.MaxCreditBid, 'f', -1, 64)
+	switch {
+	case s.MaxCreditBid < 0:
+		add("max_credit_bid", bid, "must not be negative")
+	case s.MaxCreditBid > 0 && !s.IsSpot():
+		add("max_credit_bid", bid, "only applies to spot jobs; set pricing_class to spot")
+	}
+
+	if len(v.Errors) == 0 {
+		return nil
+	}
+	return v
+}
+
+// checkImageRef checks a reference such as "python:3.11",
+// "ghcr.io/org/app:v1" or "alpine@sha256:…", returning what is wrong or "".
+func checkImageRef(ref string) string {
+	name := ref
+	if at := strings.Index(name, "@"); at >= 0 {
+		if !imageDigest.MatchString(name[at+1:]) {
+			return "has an invalid digest"
+		}
+		name = name[:at]
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		if !imageTag.MatchString(name[colon+1:]) {
+			return "has an invalid tag"
+		}
+		name = name[:colon]
+	}
+
+	components := strings.Split(name, "/")
+	// The first component is a registry when it looks like a host
+	if first := components[0]; len(components) > 1 &&
+		(strings.ContainsAny(first, ".:") || first == "localhost") {
+		if !imageRegistry.MatchString(first) {
+			return "has an invalid registry"
+		}
+		components = components[1:]
+	}
+	for _, c := range components {
+		if !imageComponent.MatchString(c) {
+			return "is not a valid image name; use lowercase letters, digits and separators, such as python:3.11"
+		}
+	}
+	return ""
+}
+
+// pathsOverlap reports whether two clean absolute paths are the same or
+// one lies inside the other.
+func pathsOverlap(a, b string) bool {
+	within := func(p, dir string) bool {
+		return p == dir || dir == "/" || strings.HasPrefix(p, dir+"/")
+	}
+	return within(a, b) || within(b, a)
+}
+
+// isZeroQuantity reports whether a quantity's number is zero.
+func isZeroQuantity(q string) bool {
+	end := strings.IndexFunc(q, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end < 0 {
+		end = len(q)
+	}
+	n, err := strconv.ParseFloat(q[:end], 64)
+	return err == nil && n == 0
+}
+
+// cpuCores returns the number of cores a CPU quantity such as "2" or
+// "500m" asks for, or 0 when it is empty or invalid.
+func cpuCores(q string) float64 {
+	if !cpuQuantity.MatchString(q) {
+		return 0
+	}
+	if milli, ok := strings.CutSuffix(q, "m"); ok {
+		n, _ := strconv.ParseFloat(milli, 64)
+		return n / 1000
+	}
+	n, _ := strconv.ParseFloat(q, 64)
+	return n
+}
+
+// quantityGB returns a size quantity such as "512Mi" or "4G" in gigabytes,
+// treating decimal and binary units alike, or 0 when it is empty or invalid.
+func quantityGB(q string) float64 {
+	if !byteQuantity.MatchString(q) {
+		return 0
+	}
+	end := strings.IndexFunc(q, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end < 0 {
+		end = len(q)
+	}
+	n, _ := strconv.ParseFloat(q[:end], 64)
+
+	unit := strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(q[end:]), "b"), "i")
+	switch unit {
+	case "k":
+		return n / (1 << 20)
+	case "m":
+		return n / (1 << 10)
+	case "g":
+		return n
+	case "t":
+		return n * (1 << 10)
+	case "p":
+		return n * (1 << 20)
+	default:
+		return n / (1 << 30)
+	}
+}
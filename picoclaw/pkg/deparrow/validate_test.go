@@ -0,0 +1,115 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestJobSpec_Validate_Valid(t *testing.T) {
+	specs := []*JobSpec{
+		{Image: "alpine"},
+		{Image: "python:3.11-slim", Resources: &ResourceSpec{CPU: "500m", Memory: "1Gi", GPU: "1", Storage: "10GB"}},
+		{Image: "ghcr.io/org/app:v1.2", Timeout: 3600, Priority: 100},
+		{Image: "localhost:5000/team/tool", Env: map[string]string{"_DEBUG": "1", "LR_2": "0.1"}},
+		{Image: "alpine@sha256:" + strings.Repeat("a", 64)},
+		{
+			Image:   "alpine",
+			Inputs:  []InputSpec{{StorageSource: "ipfs", Source: "Qm123", Path: "/inputs/a"}, {StorageSource: "url", Source: "https://x", Path: "/inputs/b"}},
+			Outputs: []OutputSpec{{Path: "/outputs", StorageDestination: "ipfs"}},
+		},
+	}
+	for _, spec := range specs {
+		if err := spec.Validate(); err != nil {
+			t.Errorf("Validate(%s) error = %v", spec.Image, err)
+		}
+	}
+}
+
+func TestJobSpec_Validate_ReportsEveryProblem(t *testing.T) {
+	spec := &JobSpec{
+		Image:     "Python:3.11",
+		Resources: &ResourceSpec{CPU: "two", Memory: "0Gi", GPU: "1.5", Storage: "10 GB"},
+		Timeout:   MaxJobTimeout + 1,
+		Priority:  -1,
+		Env:       map[string]string{"1BAD": "x"},
+		Inputs: []InputSpec{
+			{StorageSource: "ftp", Source: "ftp://x", Path: "/data"},
+			{StorageSource: "s3", Path: "relative"},
+		},
+		Outputs: []OutputSpec{{Path: "/data/out/"}},
+	}
+
+	err := spec.Validate()
+	var v *ValidationError
+	if !errors.As(err, &v) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+
+	want := []string{
+		"image", "resources.cpu", "resources.memory", "resources.storage", "resources.gpu",
+		"timeout", "priority", "env", "inputs[0].storage_source", "inputs[1].source",
+		"inputs[1].path", "outputs[0].path",
+	}
+	for _, field := range want {
+		if v.Field(field) == nil {
+			t.Errorf("no error for %s in %v", field, err)
+		}
+	}
+	if len(v.Errors) != len(want) {
+		t.Errorf("%d errors, want %d: %v", len(v.Errors), len(want), err)
+	}
+	if msg := v.Field("outputs[0].path").Message; msg != "collides with inputs[0].path /data" {
+		t.Errorf("collision message = %q", msg)
+	}
+
+	var field *FieldError
+	if !errors.As(err, &field) || field.Field != "image" {
+		t.Errorf("errors.As(*FieldError) = %v, want the first field error", field)
+	}
+}
+
+func TestJobSpec_Validate_Images(t *testing.T) {
+	tests := map[string]bool{
+		"ubuntu:22.04":             true,
+		"my_org/my-app":            true,
+		"registry.example.com/a/b": true,
+		"UPPER/case":               false,
+		"alpine:":                  false,
+		"alpine@sha256:abc":        false,
+		"-leading/dash":            false,
+		"double//slash":            false,
+	}
+	for image, valid := range tests {
+		if got := checkImageRef(image) == ""; got != valid {
+			t.Errorf("checkImageRef(%q) valid = %v, want %v", image, got, valid)
+		}
+	}
+}
+
+func TestSubmitJob_ValidatesBeforeSending(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "test-token")
+
+	_, err := client.SubmitJob(context.Background(), &JobSpec{Image: "alpine", Resources: &ResourceSpec{Memory: "lots"}})
+	var v *ValidationError
+	if !errors.As(err, &v) || v.Field("resources.memory") == nil {
+		t.Errorf("SubmitJob() error = %v, want a memory validation error", err)
+	}
+	if _, err := client.SubmitJob(context.Background(), nil); err == nil {
+		t.Error("SubmitJob(nil) should fail")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("%d requests sent for invalid specs, want none", calls.Load())
+	}
+}
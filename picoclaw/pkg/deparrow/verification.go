@@ -0,0 +1,147 @@
+package deparrow
+
+import (
+	"fmt"
+)
+
+// VerificationMode is how the result of a verified job is checked.
+type VerificationMode string
+
+const (
+	// VerifyTEE runs the job on a node with a trusted execution environment
+	// and checks the node's attestation.
+	VerifyTEE VerificationMode = "tee"
+	// VerifyRedundant runs the job on several nodes and compares their results.
+	VerifyRedundant VerificationMode = "redundant"
+)
+
+// Verification defaults and premiums.
+const (
+	// DefaultVerificationReplicas is how many nodes run a redundant job
+	DefaultVerificationReplicas = 3
+	// MaxVerificationReplicas caps the nodes a redundant job runs on
+	MaxVerificationReplicas = 7
+	// teePremiumRate is the share of the job's cost added for TEE placement
+	teePremiumRate = 0.5
+)
+
+// VerificationSpec picks how a verified job's result is checked. Without
+// one, a verified job runs redundantly on DefaultVerificationReplicas nodes.
+type VerificationSpec struct {
+	Mode VerificationMode `json:"mode,omitempty"`
+	// Number of nodes a redundant job runs on
+	Replicas int `json:"replicas,omitempty"`
+}
+
+// VerificationStatus is the outcome of checking a verified job's result.
+type VerificationStatus string
+
+const (
+	VerificationPending  VerificationStatus = "pending"
+	VerificationVerified VerificationStatus = "verified"
+	// VerificationMismatch means the replicas of a redundant job disagreed
+	VerificationMismatch VerificationStatus = "mismatch"
+	// VerificationFailed means the TEE attestation could not be checked
+	VerificationFailed VerificationStatus = "failed"
+)
+
+// VerificationResult reports how a verified job's result was checked.
+type VerificationResult struct {
+	Mode   VerificationMode   `json:"mode"`
+	Status VerificationStatus `json:"status"`
+	// Nodes the job ran on and how many returned the reported result
+	Replicas int `json:"replicas,omitempty"`
+	Agreeing int `json:"agreeing,omitempty"`
+	// SHA-256 of the reported result
+	ResultHash string `json:"result_hash,omitempty"`
+	// Reference to the TEE attestation report
+	Attestation string `json:"attestation,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// Trusted reports whether the result was verified.
+func (v *VerificationResult) Trusted() bool {
+	return v != nil && v.Status == VerificationVerified
+}
+
+// Summary describes the verification outcome in one line.
+func (v *VerificationResult) Summary() string {
+	var detail string
+	switch {
+	case v.Mode == VerifyRedundant && v.Replicas > 0:
+		detail = fmt.Sprintf("%d of %d nodes agree", v.Agreeing, v.Replicas)
+	case v.Mode == VerifyTEE && v.Attestation != "":
+		detail = "attestation " + v.Attestation
+	}
+	if v.Message != "" {
+		if detail != "" {
+			detail += ", "
+		}
+		detail += v.Message
+	}
+
+	summary := fmt.Sprintf("%s (%s)", v.Status, v.Mode)
+	if detail != "" {
+		summary += ": " + detail
+	}
+	return summary
+}
+
+// verification returns the spec's verification settings with defaults
+// filled in, or nil when the job is not verified.
+func (s *JobSpec) verification() (*VerificationSpec, error) {
+	if !s.Verified {
+		if s.Verification != nil {
+			return nil, fmt.Errorf("verification settings require verified to be set")
+		}
+		return nil, nil
+	}
+
+	v := VerificationSpec{Mode: VerifyRedundant}
+	if s.Verification != nil {
+		v = *s.Verification
+		if v.Mode == "" {
+			v.Mode = VerifyRedundant
+		}
+	}
+
+	switch v.Mode {
+	case VerifyTEE:
+		if v.Replicas > 1 {
+			return nil, fmt.Errorf("TEE verification runs on a single node, got %d replicas", v.Replicas)
+		}
+	case VerifyRedundant:
+		if v.Replicas == 0 {
+			v.Replicas = DefaultVerificationReplicas
+		}
+		if v.Replicas < 2 || v.Replicas > MaxVerificationReplicas {
+			return nil, fmt.Errorf("redundant verification needs 2 to %d replicas, got %d",
+				MaxVerificationReplicas, v.Replicas)
+		}
+	default:
+		return nil, fmt.Errorf("unknown verification mode: %s (use tee or redundant)", v.Mode)
+	}
+	return &v, nil
+}
+
+// verificationPremium is the credits a verified job costs on top of base:
+// every extra replica of a redundant job is charged in full, and TEE
+// placement adds teePremiumRate.
+func verificationPremium(spec *JobSpec, base float64) float64 {
+	v, err := spec.verification()
+	if err != nil || v == nil {
+		return 0
+	}
+	if v.Mode == VerifyTEE {
+		return base * teePremiumRate
+	}
+	return base * float64(v.Replicas-1)
+}
+
+// describeVerification renders a verified job's settings for cost estimates.
+func describeVerification(v *VerificationSpec) string {
+	if v.Mode == VerifyTEE {
+		return "TEE placement"
+	}
+	return fmt.Sprintf("redundant on %d nodes", v.Replicas)
+}
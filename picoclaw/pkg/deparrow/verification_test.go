@@ -0,0 +1,111 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestJobSpec_Verification(t *testing.T) {
+	v, err := (&JobSpec{Image: "alpine"}).verification()
+	if err != nil || v != nil {
+		t.Fatalf("unverified spec: verification() = %+v, %v", v, err)
+	}
+
+	v, err = (&JobSpec{Image: "alpine", Verified: true}).verification()
+	if err != nil {
+		t.Fatalf("verification() error = %v", err)
+	}
+	if v.Mode != VerifyRedundant || v.Replicas != DefaultVerificationReplicas {
+		t.Errorf("verification() = %+v, want redundant on %d nodes", v, DefaultVerificationReplicas)
+	}
+
+	for _, spec := range []JobSpec{
+		{Verification: &VerificationSpec{Mode: VerifyTEE}},
+		{Verified: true, Verification: &VerificationSpec{Mode: "zk"}},
+		{Verified: true, Verification: &VerificationSpec{Replicas: 1}},
+		{Verified: true, Verification: &VerificationSpec{Replicas: MaxVerificationReplicas + 1}},
+		{Verified: true, Verification: &VerificationSpec{Mode: VerifyTEE, Replicas: 2}},
+	} {
+		if _, err := spec.verification(); err == nil {
+			t.Errorf("verification() accepted %+v", spec.Verification)
+		}
+	}
+}
+
+func TestCalculateCreditCost_VerificationPremium(t *testing.T) {
+	spec := &JobSpec{Image: "alpine", Resources: &ResourceSpec{GPU: "1"}, Timeout: 3600}
+	base := calculateCreditCost(spec)
+
+	spec.Verified = true
+	if got := calculateCreditCost(spec); math.Abs(got-3*base) > 1e-9 {
+		t.Errorf("redundant cost = %.2f, want 3x %.2f", got, base)
+	}
+
+	spec.Verification = &VerificationSpec{Mode: VerifyTEE}
+	if got := calculateCreditCost(spec); math.Abs(got-1.5*base) > 1e-9 {
+		t.Errorf("TEE cost = %.2f, want 1.5x %.2f", got, base)
+	}
+}
+
+func TestVerificationResult_Summary(t *testing.T) {
+	tests := []struct {
+		result VerificationResult
+		want   string
+	}{
+		{
+			VerificationResult{Mode: VerifyRedundant, Status: VerificationVerified, Replicas: 3, Agreeing: 3},
+			"verified (redundant): 3 of 3 nodes agree",
+		},
+		{
+			VerificationResult{Mode: VerifyRedundant, Status: VerificationMismatch, Replicas: 3, Agreeing: 1, Message: "no majority"},
+			"mismatch (redundant): 1 of 3 nodes agree, no majority",
+		},
+		{
+			VerificationResult{Mode: VerifyTEE, Status: VerificationVerified, Attestation: "att-1"},
+			"verified (tee): attestation att-1",
+		},
+		{
+			VerificationResult{Mode: VerifyTEE, Status: VerificationPending},
+			"pending (tee)",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Summary(); got != tt.want {
+			t.Errorf("Summary() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestJobTool_Verified(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewJobTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"image": "alpine", "verified": true})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Verification: redundant on 3 nodes") ||
+		!strings.Contains(result.ForLLM, "credits premium") {
+		t.Errorf("result should show the verification premium:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{
+		"image": "alpine", "verified": true, "verification_mode": "tee", "wait": true,
+	})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Verification: verified (tee): attestation sandbox-attestation-") {
+		t.Errorf("result should show the verification status:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"image": "alpine", "verified": true, "replicas": 1.0})
+	if !result.IsError || !strings.Contains(result.ForLLM, "replicas") {
+		t.Errorf("1 replica should be refused:\n%s", result.ForLLM)
+	}
+}
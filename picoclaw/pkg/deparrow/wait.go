@@ -0,0 +1,109 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Defaults for WaitOptions fields left at zero.
+const (
+	DefaultWaitPollInterval    = time.Second
+	DefaultWaitMaxPollInterval = 30 * time.Second
+	DefaultWaitBackoff         = 2.0
+)
+
+// ErrWaitTimeout is returned by WaitForJobCompletion when the job has not
+// finished within WaitOptions.MaxWait.
+var ErrWaitTimeout = errors.New("timed out waiting for job to finish")
+
+// WaitOptions controls how WaitForJobCompletion polls.
+type WaitOptions struct {
+	// PollInterval is the delay before the second poll.
+	PollInterval time.Duration
+	// MaxPollInterval caps the delay between polls.
+	MaxPollInterval time.Duration
+	// Backoff multiplies the delay after each poll that finds the job
+	// unfinished; 1 polls at a fixed interval.
+	Backoff float64
+	// MaxWait gives up after this long; zero waits until ctx is done.
+	MaxWait time.Duration
+}
+
+// withDefaults fills in unset fields.
+func (o WaitOptions) withDefaults() WaitOptions {
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultWaitPollInterval
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = DefaultWaitMaxPollInterval
+	}
+	if o.MaxPollInterval < o.PollInterval {
+		o.MaxPollInterval = o.PollInterval
+	}
+	if o.Backoff < 1 {
+		o.Backoff = DefaultWaitBackoff
+	}
+	return o
+}
+
+// WaitForJobCompletion polls a job until it completes, fails or is
+// cancelled and returns it with its results. The delay between polls
+// starts at PollInterval and grows by Backoff up to MaxPollInterval.
+// Transient errors are retried on the same schedule.
+//
+// A failed or cancelled job is returned without an error; check its
+// Status. When MaxWait runs out the last job seen is returned along with
+// an error wrapping ErrWaitTimeout.
+//
+// Example:
+//
+//	job, err := client.WaitForJobCompletion(ctx, job.ID, deparrow.WaitOptions{
+//	    MaxWait: 10 * time.Minute,
+//	})
+func (c *Client) WaitForJobCompletion(ctx context.Context, jobID string, opts WaitOptions) (*Job, error) {
+	if jobID == "" {
+		return nil, fmt.Errorf("job ID is required")
+	}
+	opts = opts.withDefaults()
+
+	var deadline <-chan time.Time
+	if opts.MaxWait > 0 {
+		timer := time.NewTimer(opts.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var last *Job
+	var lastErr error
+	interval := opts.PollInterval
+	for {
+		job, err := c.GetJob(ctx, jobID)
+		switch {
+		case err == nil:
+			if job.Status.IsTerminal() {
+				return job, nil
+			}
+			last, lastErr = job, nil
+		case ctx.Err() != nil:
+			return last, ctx.Err()
+		case !retryableError(err):
+			return last, err
+		default:
+			lastErr = err
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-deadline:
+			if lastErr != nil {
+				return last, fmt.Errorf("%w after %s: %v", ErrWaitTimeout, opts.MaxWait, lastErr)
+			}
+			return last, fmt.Errorf("%w after %s", ErrWaitTimeout, opts.MaxWait)
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+		interval = min(time.Duration(float64(interval)*opts.Backoff), opts.MaxPollInterval)
+	}
+}
@@ -0,0 +1,130 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newStatusServer answers job polls with statuses in turn, repeating the
+// last one. A zero status answers 503.
+func newStatusServer(t *testing.T, polls *atomic.Int32, statuses ...JobStatus) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/jobs/job-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := int(polls.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		if status == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		resp := map[string]interface{}{"job_id": "job-1", "status": status}
+		if status == JobStatusCompleted {
+			resp["results"] = JobResults{Stdout: "done", Duration: 1.5}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestWaitForJobCompletion(t *testing.T) {
+	var polls atomic.Int32
+	server := newStatusServer(t, &polls, JobStatusPending, "", JobStatusRunning, JobStatusCompleted)
+	defer server.Close()
+
+	job, err := NewClient(server.URL, "test-token").WaitForJobCompletion(context.Background(), "job-1",
+		WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForJobCompletion() error = %v", err)
+	}
+	if job.Status != JobStatusCompleted || job.Results == nil || job.Results.Stdout != "done" {
+		t.Errorf("job = %+v, want completed with results", job)
+	}
+	if polls.Load() != 4 {
+		t.Errorf("polls = %d, want 4 with the 503 retried", polls.Load())
+	}
+}
+
+func TestWaitForJobCompletion_Timeout(t *testing.T) {
+	var polls atomic.Int32
+	server := newStatusServer(t, &polls, JobStatusRunning)
+	defer server.Close()
+
+	job, err := NewClient(server.URL, "test-token").WaitForJobCompletion(context.Background(), "job-1",
+		WaitOptions{PollInterval: 5 * time.Millisecond, Backoff: 1, MaxWait: 60 * time.Millisecond})
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("error = %v, want ErrWaitTimeout", err)
+	}
+	if job == nil || job.Status != JobStatusRunning {
+		t.Errorf("job = %+v, want the last running job", job)
+	}
+	if polls.Load() < 5 {
+		t.Errorf("polls = %d, a fixed 5ms interval should poll more often", polls.Load())
+	}
+}
+
+func TestWaitForJobCompletion_Backoff(t *testing.T) {
+	var polls atomic.Int32
+	server := newStatusServer(t, &polls, JobStatusRunning)
+	defer server.Close()
+
+	// 10ms, 20ms, 40ms, 80ms... leaves room for only a handful of polls
+	_, err := NewClient(server.URL, "test-token").WaitForJobCompletion(context.Background(), "job-1",
+		WaitOptions{PollInterval: 10 * time.Millisecond, MaxWait: 100 * time.Millisecond})
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("error = %v, want ErrWaitTimeout", err)
+	}
+	if n := polls.Load(); n < 2 || n > 5 {
+		t.Errorf("polls = %d, want between 2 and 5 with exponential backoff", n)
+	}
+}
+
+func TestWaitForJobCompletion_NotFound(t *testing.T) {
+	var polls atomic.Int32
+	server := newStatusServer(t, &polls, JobStatusRunning)
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "test-token").WaitForJobCompletion(context.Background(), "missing",
+		WaitOptions{PollInterval: time.Millisecond})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		t.Errorf("error = %v, want 404 without retrying", err)
+	}
+}
+
+func TestWaitForJobCompletion_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	submitted, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine", Command: []string{"echo", "hi"}})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+
+	job, err := client.WaitForJobCompletion(ctx, submitted.ID, WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForJobCompletion() error = %v", err)
+	}
+	if job.Status != JobStatusCompleted || job.Results == nil {
+		t.Errorf("job = %+v, want completed with results", job)
+	}
+}
+
+func TestWaitOptions_Defaults(t *testing.T) {
+	opts := WaitOptions{PollInterval: time.Minute}.withDefaults()
+	if opts.MaxPollInterval != time.Minute {
+		t.Errorf("MaxPollInterval = %s, want raised to PollInterval", opts.MaxPollInterval)
+	}
+	if opts.Backoff != DefaultWaitBackoff {
+		t.Errorf("Backoff = %v, want %v", opts.Backoff, DefaultWaitBackoff)
+	}
+}
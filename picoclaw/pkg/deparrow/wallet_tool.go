@@ -2,7 +2,9 @@ package deparrow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -38,6 +40,22 @@ AI agents use wallets to:
 - Pay for compute jobs
 - Receive credits for contributions
 - Transfer credits to other agents
+
+Standing orders are recurring transfers (e.g. a weekly allowance to a
+teammate). Use 'standing_orders' to list them and 'cancel_standing_order'
+with an order_id to stop one.
+
+Use 'history' with since and until dates (YYYY-MM-DD) and a
+transaction_type to narrow the transactions; it totals what was earned and
+spent, per day, week or month with summarize_by.
+
+Use 'trend' to see the daily balance over the last 'days' (30 by default)
+and whether the user is earning more than they spend.
+
+To buy credits, use 'funding_sources' to see how the user can pay, then
+'top_up' with an amount (and optionally a source_id). Some payments need
+the user to open a checkout link; check them with 'top_up_status' and the
+top_up_id afterwards.
 `
 }
 
@@ -48,10 +66,48 @@ func (t *WalletTool) Parameters() map[string]interface{} {
 		"properties": map[string]interface{}{
 			"action": map[string]interface{}{
 				"type":        "string",
-				"enum":        []string{"balance", "history", "info"},
-				"description": "Action to perform: 'balance' to check balance, 'history' for transactions, 'info' for wallet details",
+				"enum":        []string{"balance", "history", "trend", "info", "standing_orders", "cancel_standing_order", "funding_sources", "top_up", "top_up_status"},
+				"description": "Action to perform: 'balance' to check balance, 'history' for transactions, 'trend' for the daily balance, 'info' for wallet details, 'standing_orders' to list recurring transfers, 'cancel_standing_order' to stop one, 'funding_sources' to list ways to pay, 'top_up' to buy credits, 'top_up_status' to check a purchase",
 				"default":     "balance",
 			},
+			"order_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Standing order to cancel (for cancel_standing_order)",
+			},
+			"days": map[string]interface{}{
+				"type":        "integer",
+				"description": "Days of balance to show (for trend, default 30)",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "First day of transactions to show, as YYYY-MM-DD (for history)",
+			},
+			"until": map[string]interface{}{
+				"type":        "string",
+				"description": "Last day of transactions to show, as YYYY-MM-DD (for history)",
+			},
+			"transaction_type": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{TransactionEarn, TransactionSpend, TransactionTransfer, TransactionTopUp},
+				"description": "Show only transactions of this type (for history)",
+			},
+			"summarize_by": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{string(SummaryDay), string(SummaryWeek), string(SummaryMonth)},
+				"description": "Also total the transactions per day, week or month (for history)",
+			},
+			"amount": map[string]interface{}{
+				"type":        "number",
+				"description": "Credits to buy (for top_up)",
+			},
+			"source_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Funding source to pay with (for top_up, default source if omitted)",
+			},
+			"top_up_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Top-up to check (for top_up_status)",
+			},
 		},
 	}
 }
@@ -67,9 +123,23 @@ func (t *WalletTool) Execute(ctx context.Context, args map[string]interface{}) *
 	case "balance":
 		return t.getBalance(ctx)
 	case "history":
-		return t.getHistory(ctx)
+		return t.getHistory(ctx, args)
+	case "trend":
+		return t.getTrend(ctx, args)
 	case "info":
 		return t.getInfo(ctx)
+	case "standing_orders":
+		return t.listStandingOrders(ctx)
+	case "cancel_standing_order":
+		orderID, _ := args["order_id"].(string)
+		return t.cancelStandingOrder(ctx, orderID)
+	case "funding_sources":
+		return t.listFundingSources(ctx)
+	case "top_up":
+		return t.topUp(ctx, args)
+	case "top_up_status":
+		topUpID, _ := args["top_up_id"].(string)
+		return t.topUpStatus(ctx, topUpID)
 	default:
 		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
 	}
@@ -89,27 +159,136 @@ func (t *WalletTool) getBalance(ctx context.Context) *tools.ToolResult {
 	result.WriteString(fmt.Sprintf("  Balance:  %.2f credits 💰\n", wallet.Balance))
 	result.WriteString(fmt.Sprintf("  Created:  %s\n", wallet.CreatedAt.Format("2006-01-02")))
 
+	if len(wallet.Buckets) > 1 || len(ExpiringCredits(wallet.Buckets, time.Now(), CreditExpiryWarning)) > 0 {
+		result.WriteString(formatCreditBuckets(wallet.Buckets, time.Now()))
+	}
+
 	// Calculate spending power
 	result.WriteString("\n💡 Spending Power:\n")
 	avgJobCost := 5.0 // Average job cost
 	jobsCanRun := int(wallet.Balance / avgJobCost)
 	result.WriteString(fmt.Sprintf("  Can run ~%d standard jobs (%.0f credits each)\n", jobsCanRun, avgJobCost))
 
+	if wallet.Balance < t.client.LowBalanceThreshold() {
+		result.WriteString("\n⚠️  Balance is low. Use action 'funding_sources' to see how to pay,\n")
+		result.WriteString("   then 'top_up' with an amount to buy credits.\n")
+	}
+
 	return tools.UserResult(result.String())
 }
 
-// getHistory displays transaction history.
-func (t *WalletTool) getHistory(ctx context.Context) *tools.ToolResult {
+// formatCreditBuckets renders the balance breakdown and warns about
+// credits that expire soon.
+func formatCreditBuckets(buckets []CreditBucket, now time.Time) string {
+	var result strings.Builder
+	result.WriteString("\n🪣 Credit Buckets:\n")
+	for _, b := range buckets {
+		if b.Expired(now) || b.Amount <= 0 {
+			continue
+		}
+		expiry := "never expires"
+		if b.ExpiresAt != nil {
+			expiry = "expires " + b.ExpiresAt.Format("2006-01-02")
+		}
+		label := string(b.Source)
+		if b.Description != "" {
+			label = b.Description
+		}
+		result.WriteString(fmt.Sprintf("  %-28s %10.2f  %s\n", label, b.Amount, expiry))
+	}
+
+	expiring := ExpiringCredits(buckets, now, CreditExpiryWarning)
+	if len(expiring) > 0 {
+		total := 0.0
+		for _, b := range expiring {
+			total += b.Amount
+		}
+		result.WriteString(fmt.Sprintf("\n⏳ %.2f credits expire within %d days:\n", total, int(CreditExpiryWarning.Hours()/24)))
+		for _, b := range expiring {
+			days := int(b.ExpiresAt.Sub(now).Hours() / 24)
+			when := fmt.Sprintf("in %d days", days)
+			if days == 0 {
+				when = "today"
+			} else if days == 1 {
+				when = "tomorrow"
+			}
+			result.WriteString(fmt.Sprintf("  • %.2f %s credits %s (%s)\n",
+				b.Amount, b.Source, when, b.ExpiresAt.Format("2006-01-02 15:04")))
+		}
+		result.WriteString("  Expiring credits are spent first, use them before they lapse.\n")
+	}
+
+	return result.String()
+}
+
+// historyShown is how many of the latest transactions the history lists.
+const historyShown = 10
+
+// maxHistoryTransactions bounds how many transactions the history sums up.
+const maxHistoryTransactions = 5000
+
+// getHistory displays the transactions in a date range with totals by
+// kind, optionally per day, week or month.
+func (t *WalletTool) getHistory(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	var filter TransactionFilter
+	for name, bound := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		v, _ := args[name].(string)
+		if v == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", strings.TrimSpace(v))
+		if err != nil {
+			return tools.ErrorResult(fmt.Sprintf("%s must be a date as YYYY-MM-DD, got %q", name, v))
+		}
+		*bound = day
+	}
+	if !filter.Until.IsZero() {
+		// The until date is included
+		filter.Until = filter.Until.AddDate(0, 0, 1)
+	}
+	if kind, _ := args["transaction_type"].(string); kind != "" {
+		filter.Types = []string{kind}
+	}
+	if err := filter.validate(); err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	by, _ := args["summarize_by"].(string)
+	period := SummaryPeriod(by)
+	if !period.Valid() {
+		return tools.ErrorResult(fmt.Sprintf("summarize_by must be day, week or month, got %q", period))
+	}
+
 	wallet, err := t.client.GetWallet(ctx)
 	if err != nil {
 		return tools.ErrorResult(fmt.Sprintf("Failed to get wallet: %v", err))
 	}
+	var transactions []Transaction
+	truncated := false
+	for tx, err := range t.client.AllTransactions(ctx, filter) {
+		if err != nil {
+			return failureResult("get transactions", err)
+		}
+		if len(transactions) == maxHistoryTransactions {
+			truncated = true
+			break
+		}
+		transactions = append(transactions, tx)
+	}
 
 	var result strings.Builder
 	result.WriteString("📜 Transaction History\n")
 	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	filtered := !filter.Since.IsZero() || !filter.Until.IsZero() || len(filter.Types) > 0
+	if filtered {
+		result.WriteString(fmt.Sprintf("  Showing: %s\n\n", describeTransactionFilter(filter)))
+	}
 
-	if len(wallet.Transactions) == 0 {
+	if len(transactions) == 0 {
+		if filtered {
+			result.WriteString("No transactions match. Widen the dates or drop the type filter.\n")
+			result.WriteString(fmt.Sprintf("\nCurrent Balance: %.2f credits\n", wallet.Balance))
+			return tools.UserResult(result.String())
+		}
 		result.WriteString("No transactions yet.\n\n")
 		result.WriteString("💡 Transactions will appear here when you:\n")
 		result.WriteString("  • Submit compute jobs (spend)\n")
@@ -118,46 +297,46 @@ func (t *WalletTool) getHistory(ctx context.Context) *tools.ToolResult {
 		return tools.UserResult(result.String())
 	}
 
-	// Display last 10 transactions
-	displayCount := len(wallet.Transactions)
-	if displayCount > 10 {
-		displayCount = 10
+	total := SummarizeTransactions(transactions, "")[0]
+	result.WriteString(formatTransactionSummary(total, "  "))
+	if truncated {
+		result.WriteString(fmt.Sprintf("  Only the first %d transactions are counted; narrow the dates for exact totals.\n", maxHistoryTransactions))
+	}
+	if period != "" {
+		result.WriteString(fmt.Sprintf("\nPer %s:\n", period))
+		for _, summary := range SummarizeTransactions(transactions, period) {
+			result.WriteString(fmt.Sprintf("  %s  (%d transactions)\n", summary.Start.Format("2006-01-02"), summary.Count))
+			result.WriteString(formatTransactionSummary(summary, "    "))
+		}
 	}
 
-	runningBalance := wallet.Balance
-	for i := len(wallet.Transactions) - 1; i >= 0 && i >= len(wallet.Transactions)-displayCount; i-- {
-		tx := wallet.Transactions[i]
-
+	shown := min(len(transactions), historyShown)
+	result.WriteString(fmt.Sprintf("\nLatest %d of %d:\n\n", shown, len(transactions)))
+	for i := len(transactions) - 1; i >= len(transactions)-shown; i-- {
+		tx := transactions[i]
 		var icon string
-		var amountStr string
 		switch tx.Type {
-		case "earn":
+		case TransactionEarn:
 			icon = "📈"
-			amountStr = fmt.Sprintf("+%.2f", tx.Amount)
-			runningBalance -= tx.Amount // Go back in time
-		case "spend":
+		case TransactionSpend:
 			icon = "📉"
-			amountStr = fmt.Sprintf("-%.2f", tx.Amount)
-			runningBalance += tx.Amount
-		case "transfer":
-			if tx.FromUser != "" {
+		case TransactionTransfer:
+			icon = "📥"
+			if tx.Outgoing() {
 				icon = "📤"
-				amountStr = fmt.Sprintf("-%.2f", tx.Amount)
-				runningBalance += tx.Amount
-			} else {
-				icon = "📥"
-				amountStr = fmt.Sprintf("+%.2f", tx.Amount)
-				runningBalance -= tx.Amount
 			}
 		default:
 			icon = "💳"
-			amountStr = fmt.Sprintf("%.2f", tx.Amount)
+		}
+		sign := "+"
+		if tx.Outgoing() {
+			sign = "-"
 		}
 
-		result.WriteString(fmt.Sprintf("%s %s %s credits\n",
+		result.WriteString(fmt.Sprintf("%s %s %s%.2f credits\n",
 			tx.Timestamp.Format("2006-01-02 15:04"),
 			icon,
-			amountStr,
+			sign, tx.Amount,
 		))
 		result.WriteString(fmt.Sprintf("   %s\n\n", tx.Description))
 	}
@@ -167,6 +346,126 @@ func (t *WalletTool) getHistory(ctx context.Context) *tools.ToolResult {
 	return tools.UserResult(result.String())
 }
 
+// describeTransactionFilter renders the date range and type a history is
+// limited to.
+func describeTransactionFilter(filter TransactionFilter) string {
+	var parts []string
+	switch {
+	case !filter.Since.IsZero() && !filter.Until.IsZero():
+		parts = append(parts, fmt.Sprintf("%s to %s", filter.Since.Format("2006-01-02"), filter.Until.AddDate(0, 0, -1).Format("2006-01-02")))
+	case !filter.Since.IsZero():
+		parts = append(parts, "since "+filter.Since.Format("2006-01-02"))
+	case !filter.Until.IsZero():
+		parts = append(parts, "until "+filter.Until.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	if len(filter.Types) > 0 {
+		parts = append(parts, strings.Join(filter.Types, ", ")+" only")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatTransactionSummary renders the non-zero totals of a summary and
+// its net change, one per line.
+func formatTransactionSummary(summary TransactionSummary, indent string) string {
+	var result strings.Builder
+	for _, total := range []struct {
+		label  string
+		amount float64
+		sign   string
+	}{
+		{"Earned", summary.Earned, "+"},
+		{"Topped up", summary.ToppedUp, "+"},
+		{"Transfers in", summary.TransferredIn, "+"},
+		{"Spent", summary.Spent, "-"},
+		{"Transfers out", summary.TransferredOut, "-"},
+	} {
+		if total.amount != 0 {
+			result.WriteString(fmt.Sprintf("%s%-14s %s%.2f\n", indent, total.label+":", total.sign, total.amount))
+		}
+	}
+	result.WriteString(fmt.Sprintf("%s%-14s %+.2f\n", indent, "Net:", summary.Net()))
+	return result.String()
+}
+
+// defaultTrendDays is how many days of balance the trend shows by default.
+const defaultTrendDays = 30
+
+// trendBarWidth is the width of the longest bar of the balance trend.
+const trendBarWidth = 20
+
+// getTrend displays the daily balance trend.
+func (t *WalletTool) getTrend(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	days := defaultTrendDays
+	if d, ok := args["days"].(float64); ok {
+		days = int(d)
+	}
+	if days < 1 || days > MaxBalanceHistoryPoints {
+		return tools.ErrorResult(fmt.Sprintf("days must be between 1 and %d", MaxBalanceHistoryPoints))
+	}
+
+	history, err := t.client.GetBalanceHistory(ctx, time.Duration(days)*24*time.Hour, 24*time.Hour)
+	if err != nil {
+		if endpointUnavailable(err) {
+			return tools.ErrorResult("This DEparrow server does not record balance history. Use action 'history' to see recent transactions instead.")
+		}
+		return failureResult("get balance history", err)
+	}
+	return tools.UserResult(formatBalanceTrend(history, days))
+}
+
+// formatBalanceTrend renders a daily balance history as a bar per day and
+// tells whether the user earned or spent more over it.
+func formatBalanceTrend(history *BalanceHistory, days int) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📊 Balance Trend (last %d days)\n", days))
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	if len(history.Points) == 0 {
+		result.WriteString("No balance history yet. The network records balances\n")
+		result.WriteString("periodically; check back later.\n")
+		return result.String()
+	}
+
+	peak := 0.0
+	for _, p := range history.Points {
+		peak = max(peak, p.Balance)
+	}
+	up, down := 0, 0
+	for _, p := range history.Points {
+		bar := 0
+		if peak > 0 {
+			bar = int(math.Round(max(p.Balance, 0) / peak * trendBarWidth))
+		}
+		change := ""
+		switch {
+		case p.Change > 0:
+			change = fmt.Sprintf("+%.2f", p.Change)
+			up++
+		case p.Change < 0:
+			change = fmt.Sprintf("%.2f", p.Change)
+			down++
+		}
+		line := fmt.Sprintf("  %s  %s%s %10.2f  %s", p.Time.Format("2006-01-02"),
+			strings.Repeat("█", bar), strings.Repeat(" ", trendBarWidth-bar), p.Balance, change)
+		result.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	net := history.NetChange()
+	result.WriteString(fmt.Sprintf("\n  Start:  %.2f credits\n", history.StartBalance))
+	result.WriteString(fmt.Sprintf("  Now:    %.2f credits\n", history.EndBalance))
+	result.WriteString(fmt.Sprintf("  Days up: %d, down: %d\n\n", up, down))
+	switch {
+	case net > 0:
+		result.WriteString(fmt.Sprintf("📈 Net earner: +%.2f credits over the period.\n", net))
+	case net < 0:
+		result.WriteString(fmt.Sprintf("📉 Net spender: %.2f credits over the period.\n", net))
+		result.WriteString("   Earn more with 'deparrow_how_to_earn' or buy credits with action 'top_up'.\n")
+	default:
+		result.WriteString("➖ Your balance is where it started.\n")
+	}
+	return result.String()
+}
+
 // getInfo displays wallet information.
 func (t *WalletTool) getInfo(ctx context.Context) *tools.ToolResult {
 	wallet, err := t.client.GetWallet(ctx)
@@ -191,6 +490,222 @@ func (t *WalletTool) getInfo(ctx context.Context) *tools.ToolResult {
 	return tools.UserResult(result.String())
 }
 
+// listStandingOrders displays the user's recurring transfers.
+func (t *WalletTool) listStandingOrders(ctx context.Context) *tools.ToolResult {
+	orders, err := t.client.ListStandingOrders(ctx)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get standing orders: %v", err))
+	}
+
+	var result strings.Builder
+	result.WriteString("🔁 Standing Orders\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	if len(orders) == 0 {
+		result.WriteString("No standing orders.\n\n")
+		result.WriteString("💡 Standing orders send credits on a schedule, e.g. a weekly\n")
+		result.WriteString("   allowance to a teammate or monthly funding of a project wallet.")
+		return tools.UserResult(result.String())
+	}
+
+	active := 0
+	var monthly float64
+	for _, order := range orders {
+		icon := "✅"
+		if order.Status != StandingOrderActive {
+			icon = "⏹️ "
+		} else {
+			active++
+			switch order.Interval {
+			case IntervalDaily:
+				monthly += order.Amount * 30
+			case IntervalWeekly:
+				monthly += order.Amount * 52 / 12
+			default:
+				monthly += order.Amount
+			}
+		}
+
+		result.WriteString(fmt.Sprintf("%s %s — %.2f credits %s to %s\n",
+			icon, order.ID, order.Amount, order.Interval, order.ToUserID))
+		if order.Description != "" {
+			result.WriteString(fmt.Sprintf("   %s\n", order.Description))
+		}
+		if order.Status == StandingOrderActive {
+			result.WriteString(fmt.Sprintf("   Next: %s", order.NextRun.Format("2006-01-02 15:04")))
+		} else {
+			result.WriteString(fmt.Sprintf("   Status: %s", order.Status))
+		}
+		result.WriteString(fmt.Sprintf(" · %d transfers made\n", order.Runs))
+		if order.LastError != "" {
+			result.WriteString(fmt.Sprintf("   ⚠️  Last run failed: %s\n", order.LastError))
+		}
+		result.WriteString("\n")
+	}
+
+	result.WriteString(fmt.Sprintf("📊 %d active · ~%.2f credits committed per month", active, monthly))
+
+	return tools.UserResult(result.String())
+}
+
+// cancelStandingOrder stops a recurring transfer.
+func (t *WalletTool) cancelStandingOrder(ctx context.Context, orderID string) *tools.ToolResult {
+	if orderID == "" {
+		return tools.ErrorResult("order_id is required to cancel a standing order")
+	}
+
+	order, err := t.client.GetStandingOrder(ctx, orderID)
+	if err != nil {
+		return tools.ErrorResult(fmt.Sprintf("Failed to get standing order: %v", err))
+	}
+	if order.Status == StandingOrderCancelled {
+		return tools.ErrorResult(fmt.Sprintf("Standing order %s is already cancelled", orderID))
+	}
+
+	if err := t.client.CancelStandingOrder(ctx, orderID); err != nil {
+		return failureResult("cancel standing order", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("⏹️  Standing Order Cancelled\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(fmt.Sprintf("  Order:   %s\n", order.ID))
+	result.WriteString(fmt.Sprintf("  To:      %s\n", order.ToUserID))
+	result.WriteString(fmt.Sprintf("  Amount:  %.2f credits %s\n", order.Amount, order.Interval))
+	result.WriteString(fmt.Sprintf("\n  %d transfers were made and are not reversed.", order.Runs))
+
+	return tools.UserResult(result.String())
+}
+
+// listFundingSources displays the ways the user can buy credits.
+func (t *WalletTool) listFundingSources(ctx context.Context) *tools.ToolResult {
+	sources, err := t.client.ListFundingSources(ctx)
+	if err != nil {
+		return failureResult("get funding sources", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("💳 Funding Sources\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	if len(sources) == 0 {
+		result.WriteString("No funding sources yet.\n\n")
+		result.WriteString("💡 Ask the user to add a card or bank account in the DEparrow\n")
+		result.WriteString("   dashboard, or earn credits with 'deparrow_how_to_earn'.")
+		return tools.UserResult(result.String())
+	}
+
+	for _, source := range sources {
+		result.WriteString(fmt.Sprintf("• %s (%s)", source.Label, source.ID))
+		if source.Default {
+			result.WriteString(" — default")
+		}
+		result.WriteString("\n")
+		if source.CreditsPerUnit > 0 {
+			result.WriteString(fmt.Sprintf("   %.2f credits per %s", source.CreditsPerUnit, source.Currency))
+		} else {
+			result.WriteString(fmt.Sprintf("   Pays in %s", source.Currency))
+		}
+		switch {
+		case source.MinAmount > 0 && source.MaxAmount > 0:
+			result.WriteString(fmt.Sprintf(" · %.0f–%.0f credits per top-up", source.MinAmount, source.MaxAmount))
+		case source.MinAmount > 0:
+			result.WriteString(fmt.Sprintf(" · at least %.0f credits per top-up", source.MinAmount))
+		case source.MaxAmount > 0:
+			result.WriteString(fmt.Sprintf(" · up to %.0f credits per top-up", source.MaxAmount))
+		}
+		result.WriteString("\n")
+		if source.RequiresCheckout {
+			result.WriteString("   Payment is confirmed on a checkout page\n")
+		}
+		if source.Provider != "" {
+			result.WriteString(fmt.Sprintf("   Also payable through %s checkout with deparrow_topup\n", source.Provider))
+		}
+		result.WriteString("\n")
+	}
+
+	result.WriteString("💡 Use action 'top_up' with an amount and source_id to buy credits.")
+
+	return tools.UserResult(result.String())
+}
+
+// topUp starts buying credits and tells the user how to finish paying.
+func (t *WalletTool) topUp(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	amount, ok := args["amount"].(float64)
+	if !ok {
+		if amountInt, ok := args["amount"].(int); ok {
+			amount = float64(amountInt)
+		}
+	}
+	if amount <= 0 {
+		return tools.ErrorResult("amount must be a positive number of credits to buy")
+	}
+	sourceID, _ := args["source_id"].(string)
+
+	topUp, err := t.client.InitiateTopUp(ctx, amount, sourceID)
+	if err != nil {
+		return failureResult("start top-up", err)
+	}
+	return tools.UserResult(formatTopUp(topUp, "action 'top_up_status'"))
+}
+
+// topUpStatus reports whether a top-up's payment went through.
+func (t *WalletTool) topUpStatus(ctx context.Context, topUpID string) *tools.ToolResult {
+	if topUpID == "" {
+		return tools.ErrorResult("top_up_id is required to check a top-up")
+	}
+
+	topUp, err := t.client.GetTopUp(ctx, topUpID)
+	if err != nil {
+		return failureResult("get top-up", err)
+	}
+	return tools.UserResult(formatTopUp(topUp, "action 'top_up_status'"))
+}
+
+// formatTopUp renders a top-up and what the user has to do next. check
+// names how to follow a pending top-up, e.g. "action 'top_up_status'".
+func formatTopUp(topUp *TopUp, check string) string {
+	var result strings.Builder
+	switch topUp.Status {
+	case TopUpCompleted:
+		result.WriteString("✅ Credits Added\n")
+	case TopUpPending:
+		result.WriteString("⏳ Top-Up Awaiting Payment\n")
+	default:
+		result.WriteString("❌ Top-Up Not Completed\n")
+	}
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(fmt.Sprintf("  Top-up:   %s\n", topUp.ID))
+	if topUp.Provider != "" {
+		result.WriteString(fmt.Sprintf("  Provider: %s\n", topUp.Provider))
+	}
+	result.WriteString(fmt.Sprintf("  Credits:  %.2f\n", topUp.Amount))
+	if topUp.Price > 0 {
+		result.WriteString(fmt.Sprintf("  Price:    %.2f %s\n", topUp.Price, topUp.Currency))
+	}
+	result.WriteString(fmt.Sprintf("  Status:   %s\n", topUp.Status))
+
+	switch topUp.Status {
+	case TopUpPending:
+		if topUp.CheckoutURL != "" {
+			result.WriteString(fmt.Sprintf("\n👉 Ask the user to complete the payment at:\n   %s\n", topUp.CheckoutURL))
+			if topUp.ExpiresAt != nil {
+				result.WriteString(fmt.Sprintf("   The link expires %s.\n", topUp.ExpiresAt.Format("2006-01-02 15:04")))
+			}
+		}
+		result.WriteString(fmt.Sprintf("\n💡 The credits arrive once the payment is confirmed; check with\n   %s and top_up_id %s.", check, topUp.ID))
+	case TopUpCompleted:
+		result.WriteString("\n💡 The credits are in your wallet and ready to spend.")
+	default:
+		if topUp.FailureReason != "" {
+			result.WriteString(fmt.Sprintf("  Reason:   %s\n", topUp.FailureReason))
+		}
+		result.WriteString("\n💡 No credits were added. Start a new top-up, perhaps with another funding source.")
+	}
+
+	return result.String()
+}
+
 // TransferTool provides credit transfer functionality.
 type TransferTool struct {
 	client *Client
@@ -206,9 +721,52 @@ func (t *TransferTool) Name() string {
 	return "deparrow_transfer"
 }
 
-// Description returns the tool description.
+// Description returns the tool description. Offers are only described
+// when the server supports escrow.
 func (t *TransferTool) Description() string {
-	return "Transfer credits to another DEparrow user."
+	if !t.client.offers(FeatureEscrow) {
+		return `Transfer credits to another DEparrow user.
+
+Actions:
+- send (default): transfer the credits at once; this cannot be undone
+- pending / approve: show the transfers waiting for a second approver, and
+  approve one by transfer_id
+
+Transfers above the organization's approval threshold are not made at once:
+they wait for another user to approve them.`
+	}
+	return `Transfer credits to another DEparrow user.
+
+Actions:
+- send (default): transfer the credits at once; this cannot be undone
+- offer: hold the credits in escrow until the recipient accepts, within
+  expires_in_hours (default 24); declined and expired offers are refunded
+- accept / decline: answer an offer by offer_id; declining your own offer
+  withdraws it
+- list: show the offers you made and received
+- pending / approve: show the transfers waiting for a second approver, and
+  approve one by transfer_id
+
+Transfers above the organization's approval threshold are not made at once:
+they wait for another user to approve them.
+
+Prefer an offer when the recipient might not expect the credits or the
+user ID could be wrong.`
+}
+
+// escrowActions are the transfer actions that need FeatureEscrow.
+var escrowActions = map[string]bool{"offer": true, "accept": true, "decline": true, "list": true}
+
+// actions returns the actions the server supports.
+func (t *TransferTool) actions() []string {
+	var actions []string
+	escrow := t.client.offers(FeatureEscrow)
+	for _, action := range []string{"send", "offer", "accept", "decline", "list", "pending", "approve"} {
+		if escrow || !escrowActions[action] {
+			actions = append(actions, action)
+		}
+	}
+	return actions
 }
 
 // Parameters returns the JSON schema for tool parameters.
@@ -216,29 +774,70 @@ func (t *TransferTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        t.actions(),
+				"description": "Send at once, make an escrowed offer, answer or list offers, or review transfers awaiting approval (default: send)",
+			},
 			"to_user_id": map[string]interface{}{
 				"type":        "string",
-				"description": "The user ID to transfer credits to",
+				"description": "The user ID to transfer credits to (for send and offer)",
 			},
 			"amount": map[string]interface{}{
 				"type":        "number",
-				"description": "Amount of credits to transfer",
+				"description": "Amount of credits to transfer (for send and offer)",
 				"minimum":     0.01,
 			},
 			"memo": map[string]interface{}{
 				"type":        "string",
 				"description": "Optional memo for the transfer",
 			},
+			"expires_in_hours": map[string]interface{}{
+				"type":        "number",
+				"description": "How long the recipient has to accept an offer, up to 720 hours (default: 24)",
+			},
+			"offer_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The offer to accept or decline",
+			},
+			"transfer_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The transfer awaiting approval to approve",
+			},
 		},
-		"required": []string{"to_user_id", "amount"},
+		"required": []string{},
 	}
 }
 
 // Execute runs the transfer tool.
 func (t *TransferTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	action, _ := args["action"].(string)
+	if escrowActions[action] && !t.client.offers(FeatureEscrow) {
+		return tools.ErrorResult(fmt.Sprintf("The DEparrow server doesn't support escrowed transfer offers, so '%s' is unavailable; use action 'send'", action))
+	}
+	switch action {
+	case "", "send":
+		return t.send(ctx, args)
+	case "offer":
+		return t.offer(ctx, args)
+	case "accept", "decline":
+		return t.resolve(ctx, action, args)
+	case "list":
+		return t.list(ctx)
+	case "pending":
+		return t.pending(ctx)
+	case "approve":
+		return t.approve(ctx, args)
+	default:
+		return tools.ErrorResult("action must be 'send', 'offer', 'accept', 'decline', 'list', 'pending' or 'approve'")
+	}
+}
+
+// transferArgs reads the recipient and amount of a send or offer.
+func transferArgs(args map[string]interface{}) (string, float64, *tools.ToolResult) {
 	toUserID, ok := args["to_user_id"].(string)
 	if !ok || toUserID == "" {
-		return tools.ErrorResult("to_user_id is required")
+		return "", 0, tools.ErrorResult("to_user_id is required")
 	}
 
 	amount, ok := args["amount"].(float64)
@@ -247,12 +846,25 @@ func (t *TransferTool) Execute(ctx context.Context, args map[string]interface{})
 		if amountInt, ok := args["amount"].(int); ok {
 			amount = float64(amountInt)
 		} else {
-			return tools.ErrorResult("amount is required and must be a number")
+			return "", 0, tools.ErrorResult("amount is required and must be a number")
 		}
 	}
 
 	if amount <= 0 {
-		return tools.ErrorResult("amount must be positive")
+		return "", 0, tools.ErrorResult("amount must be positive")
+	}
+	return toUserID, amount, nil
+}
+
+// send transfers credits at once.
+func (t *TransferTool) send(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	toUserID, amount, errResult := transferArgs(args)
+	if errResult != nil {
+		return errResult
+	}
+
+	if pause := t.client.SpendingPaused(); pause != nil {
+		return tools.ErrorResult(spendingPausedMessage(pause))
 	}
 
 	// Check if we have enough credits first
@@ -267,6 +879,10 @@ func (t *TransferTool) Execute(ctx context.Context, args map[string]interface{})
 
 	// Perform transfer
 	err = t.client.TransferCredits(ctx, toUserID, amount)
+	var pending *ApprovalRequiredError
+	if errors.As(err, &pending) {
+		return approvalRequiredResult(pending)
+	}
 	if err != nil {
 		return tools.ErrorResult(fmt.Sprintf("Transfer failed: %v", err))
 	}
@@ -286,6 +902,150 @@ func (t *TransferTool) Execute(ctx context.Context, args map[string]interface{})
 	return tools.UserResult(result.String())
 }
 
+// offer puts credits in escrow for the recipient to accept.
+func (t *TransferTool) offer(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	toUserID, amount, errResult := transferArgs(args)
+	if errResult != nil {
+		return errResult
+	}
+
+	req := &TransferOfferRequest{ToUserID: toUserID, Amount: amount}
+	req.Memo, _ = args["memo"].(string)
+	if hours, ok := args["expires_in_hours"].(float64); ok {
+		if hours <= 0 {
+			return tools.ErrorResult("expires_in_hours must be positive")
+		}
+		req.TTL = time.Duration(hours * float64(time.Hour))
+	}
+
+	if pause := t.client.SpendingPaused(); pause != nil {
+		return tools.ErrorResult(spendingPausedMessage(pause))
+	}
+
+	offer, err := t.client.CreateTransferOffer(ctx, req)
+	if err != nil {
+		return failureResult("offer transfer", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("📨 Transfer Offered\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(formatTransferOffer(offer))
+	result.WriteString("\n💡 The credits are held in escrow until the recipient accepts. ")
+	result.WriteString("If they decline or the offer expires, the credits come back to you.")
+	return tools.UserResult(result.String())
+}
+
+// resolve accepts or declines an offer.
+func (t *TransferTool) resolve(ctx context.Context, action string, args map[string]interface{}) *tools.ToolResult {
+	offerID, _ := args["offer_id"].(string)
+	if offerID == "" {
+		return tools.ErrorResult(fmt.Sprintf("offer_id is required to %s an offer", action))
+	}
+
+	var offer *TransferOffer
+	var err error
+	if action == "accept" {
+		offer, err = t.client.AcceptTransfer(ctx, offerID)
+	} else {
+		offer, err = t.client.DeclineTransfer(ctx, offerID)
+	}
+	if err != nil {
+		return failureResult(action+" transfer offer", err)
+	}
+
+	var result strings.Builder
+	if offer.Status == TransferOfferAccepted {
+		result.WriteString("✅ Transfer Offer Accepted\n")
+	} else {
+		result.WriteString("↩️  Transfer Offer Declined\n")
+	}
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(formatTransferOffer(offer))
+	return tools.UserResult(result.String())
+}
+
+// list shows the offers made and received.
+func (t *TransferTool) list(ctx context.Context) *tools.ToolResult {
+	offers, err := t.client.ListTransferOffers(ctx)
+	if err != nil {
+		return failureResult("list transfer offers", err)
+	}
+	if len(offers) == 0 {
+		return tools.UserResult("No transfer offers. Make one with action 'offer'.")
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📨 Transfer Offers (%d)\n", len(offers)))
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	for i := range offers {
+		result.WriteString("\n")
+		result.WriteString(formatTransferOffer(&offers[i]))
+	}
+	return tools.UserResult(result.String())
+}
+
+// pending shows the transfers waiting for approval.
+func (t *TransferTool) pending(ctx context.Context) *tools.ToolResult {
+	transfers, err := t.client.ListPendingTransfers(ctx)
+	if err != nil {
+		return failureResult("list transfers awaiting approval", err)
+	}
+	if len(transfers) == 0 {
+		return tools.UserResult("No transfers are waiting for approval.")
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("⏳ Transfers Awaiting Approval (%d)\n", len(transfers)))
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	for i := range transfers {
+		result.WriteString("\n")
+		result.WriteString(formatPendingTransfer(&transfers[i]))
+	}
+	result.WriteString("\n💡 Approve one with action 'approve' and its transfer_id, once the user confirms it.")
+	return tools.UserResult(result.String())
+}
+
+// approve approves a transfer waiting for approval.
+func (t *TransferTool) approve(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	transferID, _ := args["transfer_id"].(string)
+	if transferID == "" {
+		return tools.ErrorResult("transfer_id is required to approve a transfer")
+	}
+
+	transfer, err := t.client.ApproveTransfer(ctx, transferID)
+	if err != nil {
+		return failureResult("approve transfer", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("✅ Transfer Approved\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(formatPendingTransfer(transfer))
+	return tools.UserResult(result.String())
+}
+
+// formatTransferOffer renders an offer and, while it is pending, when it
+// expires.
+func formatTransferOffer(offer *TransferOffer) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("  Offer:   %s (%s)\n", offer.ID, offer.Status))
+	result.WriteString(fmt.Sprintf("  From:    %s\n", offer.FromUserID))
+	result.WriteString(fmt.Sprintf("  To:      %s\n", offer.ToUserID))
+	result.WriteString(fmt.Sprintf("  Amount:  %.2f credits\n", offer.Amount))
+	if offer.Memo != "" {
+		result.WriteString(fmt.Sprintf("  Memo:    %s\n", offer.Memo))
+	}
+	switch {
+	case offer.Status == TransferOfferPending:
+		result.WriteString(fmt.Sprintf("  Expires: %s (in %s)\n",
+			offer.ExpiresAt.Format("2006-01-02 15:04"), formatDuration(time.Until(offer.ExpiresAt).Round(time.Minute))))
+	case offer.ResolvedAt != nil:
+		result.WriteString(fmt.Sprintf("  Settled: %s\n", offer.ResolvedAt.Format("2006-01-02 15:04")))
+	}
+	return result.String()
+}
+
 // HealthTool provides health check for the DEparrow connection.
 type HealthTool struct {
 	client *Client
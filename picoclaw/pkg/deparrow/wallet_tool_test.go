@@ -304,8 +304,9 @@ func TestTransferTool_Parameters(t *testing.T) {
 	if !ok {
 		t.Fatal("required not found")
 	}
-	if len(required) != 2 {
-		t.Errorf("required count = %d, want 2", len(required))
+	// Accepting, declining and listing offers need neither
+	if len(required) != 0 {
+		t.Errorf("required count = %d, want 0", len(required))
 	}
 }
 
@@ -798,3 +799,46 @@ func TestWalletTool_Execute_Info_WithTimestamp(t *testing.T) {
 	// Just verify it doesn't panic
 	_ = result
 }
+
+func TestWalletTool_Execute_StandingOrders(t *testing.T) {
+	client := NewSandboxClient()
+	tool := NewWalletTool(client)
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]interface{}{"action": "standing_orders"})
+	if result.IsError || !strings.Contains(result.ForLLM, "No standing orders") {
+		t.Errorf("empty list result = %s", result.ForLLM)
+	}
+
+	order, err := client.CreateStandingOrder(ctx, &StandingOrderRequest{
+		ToUserID: "teammate", Amount: 20, Interval: IntervalWeekly, Description: "Weekly allowance",
+	})
+	if err != nil {
+		t.Fatalf("CreateStandingOrder() error = %v", err)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "standing_orders"})
+	if result.IsError {
+		t.Fatalf("Execute() returned error: %s", result.ForLLM)
+	}
+	for _, want := range []string{order.ID, "Weekly allowance", "1 transfers made", "1 active"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("Result should contain %q: %s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "cancel_standing_order", "order_id": order.ID})
+	if result.IsError || !strings.Contains(result.ForLLM, "Cancelled") {
+		t.Errorf("cancel result = %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "cancel_standing_order", "order_id": order.ID})
+	if !result.IsError {
+		t.Error("cancelling twice should fail")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "cancel_standing_order"})
+	if !result.IsError {
+		t.Error("cancel without order_id should fail")
+	}
+}
@@ -0,0 +1,369 @@
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// watchBuffer is how many status updates are held for a slow consumer.
+	watchBuffer = 16
+
+	// watchPollWait is how long the server holds a long-poll request
+	// before answering with an unchanged status.
+	watchPollWait = 30 * time.Second
+
+	// watchPollInterval spaces out long-polls against servers that answer
+	// straight away instead of holding the request.
+	watchPollInterval = 2 * time.Second
+
+	// watchIdleTimeout drops a WebSocket that has gone quiet, including
+	// the server's pings, so a dead connection is noticed and replaced.
+	watchIdleTimeout = 90 * time.Second
+
+	// watchBackoffMin and watchBackoffMax bound the delay between
+	// reconnection attempts, which doubles after each failure.
+	watchBackoffMin = 500 * time.Millisecond
+	watchBackoffMax = 30 * time.Second
+
+	// watchMaxRetries is how many consecutive failed connections are
+	// tolerated before the watch gives up.
+	watchMaxRetries = 5
+)
+
+// errWebSocketUnsupported reports a server without the watch endpoint.
+var errWebSocketUnsupported = errors.New("server does not support watching jobs over WebSocket")
+
+// jobStatusMessage is a status update pushed over the watch WebSocket.
+type jobStatusMessage struct {
+	JobID  string    `json:"job_id"`
+	Status JobStatus `json:"status"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// JobWatch follows a job's status as it changes.
+type JobWatch struct {
+	client  *Client
+	jobID   string
+	updates chan JobStatus
+	done    chan struct{}
+	cancel  context.CancelFunc
+	closed  atomic.Bool
+	last    JobStatus
+	err     error
+}
+
+// WatchJob follows the status of a job until it finishes, ctx is
+// cancelled or the watch is closed. Updates are pushed over a WebSocket,
+// or a gRPC stream for clients set up WithTransport(TransportGRPC);
+// servers without one, and clients set up WithLongPolling, long-poll. Dropped connections are retried
+// with exponential backoff.
+//
+// Example:
+//
+//	watch, err := client.WatchJob(ctx, job.ID)
+//	if err != nil {
+//	    return err
+//	}
+//	defer watch.Close()
+//	for status := range watch.Updates() {
+//	    fmt.Println("job is", status)
+//	}
+//	if err := watch.Err(); err != nil {
+//	    return err
+//	}
+func (c *Client) WatchJob(ctx context.Context, jobID string) (*JobWatch, error) {
+	if jobID == "" {
+		return nil, fmt.Errorf("job ID is required")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &JobWatch{
+		client:  c,
+		jobID:   jobID,
+		updates: make(chan JobStatus, watchBuffer),
+		done:    make(chan struct{}),
+		cancel:  cancel,
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+// Updates returns each status the job passes through, starting with its
+// current one. It is closed when the job finishes or the watch ends.
+func (w *JobWatch) Updates() <-chan JobStatus {
+	return w.updates
+}
+
+// Err returns why the watch ended, once Updates is closed. It is nil when
+// the job finished or the watch was closed.
+func (w *JobWatch) Err() error {
+	select {
+	case <-w.done:
+		return w.err
+	default:
+		return nil
+	}
+}
+
+// Close stops watching and releases the connection.
+func (w *JobWatch) Close() error {
+	w.closed.Store(true)
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+// run follows the job, reconnecting after failures, then closes Updates.
+func (w *JobWatch) run(ctx context.Context) {
+	useGRPC := w.client.usesGRPC()
+	useWebSocket := !w.client.IsSandbox() && !w.client.longPoll
+	backoff := watchBackoffMin
+	failures := 0
+
+	var err error
+	for {
+		last := w.last
+		switch {
+		case useGRPC:
+			err = w.watchGRPC(ctx)
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotImplemented {
+				// A gRPC API without the stream still has the REST watch
+				useGRPC = false
+				continue
+			}
+		case useWebSocket:
+			err = w.watchWebSocket(ctx)
+			if errors.Is(err, errWebSocketUnsupported) {
+				useWebSocket = false
+				continue
+			}
+		default:
+			err = w.longPoll(ctx)
+		}
+		if err == nil || ctx.Err() != nil || !retryableError(err) {
+			break
+		}
+
+		// A connection that delivered news was healthy for a while
+		if w.last != last {
+			failures, backoff = 0, watchBackoffMin
+		}
+		failures++
+		if failures > watchMaxRetries {
+			err = fmt.Errorf("gave up watching job after %d attempts: %w", failures, err)
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		backoff = min(backoff*2, watchBackoffMax)
+	}
+
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+		if w.closed.Load() {
+			err = nil
+		}
+	}
+	w.cancel()
+	w.err = err
+	close(w.done)
+	close(w.updates)
+}
+
+// retryableError reports whether trying again might get past err.
+// Client errors such as a bad token or an unknown job will not go away.
+func retryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500 || apiErr.Code == http.StatusRequestTimeout ||
+			apiErr.Code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// deliver hands a new status to the consumer, reporting whether the job
+// has finished. Repeats of the last status and stale ones the job has
+// already moved past, as from a lagging poll, are dropped. Statuses this
+// client does not know are passed on as they come.
+func (w *JobWatch) deliver(ctx context.Context, status JobStatus) (bool, error) {
+	_, lastKnown := jobStage[w.last]
+	_, known := jobStage[status]
+	if status == w.last || (lastKnown && known && !CanTransition(w.last, status)) {
+		return w.last.IsTerminal(), nil
+	}
+	select {
+	case w.updates <- status:
+		w.last = status
+		return status.IsTerminal(), nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// watchURL returns the WebSocket URL of the job's watch endpoint.
+func (w *JobWatch) watchURL() (string, error) {
+	u, err := url.Parse(w.client.baseURL + "/api/v1/jobs/" + url.PathEscape(w.jobID) + "/watch")
+	if err != nil {
+		return "", fmt.Errorf("invalid API URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	return u.String(), nil
+}
+
+// watchWebSocket follows the job over one WebSocket connection.
+func (w *JobWatch) watchWebSocket(ctx context.Context) error {
+	wsURL, err := w.watchURL()
+	if err != nil {
+		return err
+	}
+
+	// Reuse the regular request headers for authentication and metadata
+	req, err := w.client.newRequest(ctx, http.MethodGet, "", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Del("Content-Type")
+	req.Header.Del("Accept-Encoding")
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: w.client.httpClient.Timeout,
+	}
+	conn, resp, err := dialer.DialContext(ctx, wsURL, req.Header)
+	if err != nil {
+		if resp == nil {
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+		defer closeBody(resp.Body)
+		switch resp.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return responseError(resp)
+		case http.StatusSwitchingProtocols:
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+		if resp.StatusCode >= 500 {
+			return responseError(resp)
+		}
+		return errWebSocketUnsupported
+	}
+	defer conn.Close()
+
+	// Unblock the read below when the watch is cancelled
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(watchIdleTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(watchIdleTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	for {
+		var msg jobStatusMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return errors.New("server closed the watch before the job finished")
+			}
+			return fmt.Errorf("watch connection lost: %w", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(watchIdleTimeout))
+
+		if msg.Status == "" {
+			if msg.Error != "" {
+				return &APIError{Code: http.StatusBadRequest, Message: msg.Error}
+			}
+			continue
+		}
+		finished, err := w.deliver(ctx, msg.Status)
+		if err != nil {
+			return err
+		}
+		if finished {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			return nil
+		}
+	}
+}
+
+// longPoll follows the job by asking for its status, which the server
+// holds back for up to watchPollWait until it differs from the last one
+// seen.
+func (w *JobWatch) longPoll(ctx context.Context) error {
+	// The request is held open on purpose, so the client timeout must
+	// not cut it short
+	polling := *w.client.httpClient
+	polling.Timeout = 0
+
+	for {
+		query := url.Values{"wait": {watchPollWait.String()}}
+		if w.last != "" {
+			query.Set("since", string(w.last))
+		}
+		path := "/api/v1/jobs/" + url.PathEscape(w.jobID) + "?" + query.Encode()
+
+		pollCtx, cancel := context.WithTimeout(ctx, watchPollWait+w.client.httpClient.Timeout)
+		job, err := w.poll(pollCtx, &polling, path)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		last := w.last
+		finished, err := w.deliver(ctx, job.Status)
+		if err != nil || finished {
+			return err
+		}
+		if job.Status == last {
+			select {
+			case <-time.After(watchPollInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// poll fetches the job once.
+func (w *JobWatch) poll(ctx context.Context, httpClient *http.Client, path string) (*Job, error) {
+	req, err := w.client.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.client.do(httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp.Body)
+
+	var result jobResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, &malformedResponseError{err}
+	}
+	return result.job(), nil
+}
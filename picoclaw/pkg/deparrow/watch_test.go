@@ -0,0 +1,231 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// collectUpdates drains a watch and returns the statuses it reported.
+func collectUpdates(w *JobWatch) []JobStatus {
+	var statuses []JobStatus
+	for status := range w.Updates() {
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func joinStatuses(statuses []JobStatus) string {
+	parts := make([]string, len(statuses))
+	for i, s := range statuses {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+// newWatchServer serves the watch WebSocket, handing each connection to
+// serve along with its sequence number.
+func newWatchServer(t *testing.T, serve func(conn *websocket.Conn, n int)) *httptest.Server {
+	t.Helper()
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/jobs/job-1/watch" {
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-token" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade() error = %v", err)
+			return
+		}
+		defer conn.Close()
+		serve(conn, int(connections.Add(1)))
+	}))
+}
+
+func TestWatchJob_WebSocket(t *testing.T) {
+	server := newWatchServer(t, func(conn *websocket.Conn, _ int) {
+		for _, status := range []JobStatus{JobStatusPending, JobStatusRunning, JobStatusRunning, JobStatusCompleted} {
+			conn.WriteJSON(jobStatusMessage{JobID: "job-1", Status: status})
+		}
+		conn.ReadMessage()
+	})
+	defer server.Close()
+
+	watch, err := NewClient(server.URL, "test-token").WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	if got := joinStatuses(collectUpdates(watch)); got != "pending,running,completed" {
+		t.Errorf("updates = %s, want pending,running,completed", got)
+	}
+	if err := watch.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestWatchJob_StaleStatus(t *testing.T) {
+	server := newWatchServer(t, func(conn *websocket.Conn, _ int) {
+		// A lagging update reports the job as pending after it started
+		for _, status := range []JobStatus{JobStatusRunning, JobStatusPending, "unpacking", JobStatusCompleted, JobStatusRunning} {
+			conn.WriteJSON(jobStatusMessage{JobID: "job-1", Status: status})
+		}
+		conn.ReadMessage()
+	})
+	defer server.Close()
+
+	watch, err := NewClient(server.URL, "test-token").WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	if got := joinStatuses(collectUpdates(watch)); got != "running,unpacking,completed" {
+		t.Errorf("updates = %s, want running,unpacking,completed", got)
+	}
+}
+
+func TestWatchJob_Reconnect(t *testing.T) {
+	server := newWatchServer(t, func(conn *websocket.Conn, n int) {
+		if n == 1 {
+			// Drop the connection mid-job
+			conn.WriteJSON(jobStatusMessage{JobID: "job-1", Status: JobStatusRunning})
+			return
+		}
+		conn.WriteJSON(jobStatusMessage{JobID: "job-1", Status: JobStatusRunning})
+		conn.WriteJSON(jobStatusMessage{JobID: "job-1", Status: JobStatusFailed})
+		conn.ReadMessage()
+	})
+	defer server.Close()
+
+	watch, err := NewClient(server.URL, "test-token").WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	if got := joinStatuses(collectUpdates(watch)); got != "running,failed" {
+		t.Errorf("updates = %s, want running,failed", got)
+	}
+	if err := watch.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestWatchJob_LongPollFallback(t *testing.T) {
+	statuses := []JobStatus{JobStatusPending, JobStatusRunning, JobStatusCompleted}
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/watch") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("wait") == "" {
+			t.Errorf("long-poll without wait: %s", r.URL)
+		}
+		n := int(polls.Add(1)) - 1
+		if n > 0 && r.URL.Query().Get("since") != string(statuses[n-1]) {
+			t.Errorf("since = %q, want %s", r.URL.Query().Get("since"), statuses[n-1])
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "status": statuses[n]})
+	}))
+	defer server.Close()
+
+	watch, err := NewClient(server.URL, "test-token").WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	if got := joinStatuses(collectUpdates(watch)); got != "pending,running,completed" {
+		t.Errorf("updates = %s, want pending,running,completed", got)
+	}
+	if err := watch.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestWatchJob_Unauthorized(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "Invalid token"}`))
+	}))
+	defer server.Close()
+
+	watch, err := NewClient(server.URL, "bad-token").WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	collectUpdates(watch)
+	var apiErr *APIError
+	if !errors.As(watch.Err(), &apiErr) || apiErr.Code != http.StatusUnauthorized {
+		t.Fatalf("Err() = %v, want 401", watch.Err())
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("attempts = %d, an auth failure should not be retried", attempts.Load())
+	}
+}
+
+func TestWatchJob_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	ctx := context.Background()
+
+	job, err := client.SubmitJob(ctx, &JobSpec{Image: "alpine"})
+	if err != nil {
+		t.Fatalf("SubmitJob() error = %v", err)
+	}
+
+	watch, err := client.WatchJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+	defer watch.Close()
+
+	statuses := collectUpdates(watch)
+	if len(statuses) == 0 || statuses[len(statuses)-1] != JobStatusCompleted {
+		t.Errorf("updates = %v, want to end with completed", statuses)
+	}
+}
+
+func TestWatchJob_Close(t *testing.T) {
+	server := newWatchServer(t, func(conn *websocket.Conn, _ int) {
+		conn.WriteJSON(jobStatusMessage{JobID: "job-1", Status: JobStatusRunning})
+		conn.ReadMessage()
+	})
+	defer server.Close()
+
+	watch, err := NewClient(server.URL, "test-token").WatchJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("WatchJob() error = %v", err)
+	}
+
+	if status := <-watch.Updates(); status != JobStatusRunning {
+		t.Fatalf("first update = %s, want running", status)
+	}
+	watch.Close()
+	for range watch.Updates() {
+	}
+	if err := watch.Err(); err != nil {
+		t.Errorf("Err() = %v after Close, want nil", err)
+	}
+}
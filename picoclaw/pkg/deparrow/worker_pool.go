@@ -0,0 +1,479 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WorkerPoolLabel labels each worker job with the name of its pool.
+const WorkerPoolLabel = "worker_pool"
+
+// Defaults and limits for WorkerPoolConfig.
+const (
+	// DefaultWorkerStartupTimeout is how long a worker may stay pending
+	// before the default health check gives up on it.
+	DefaultWorkerStartupTimeout = 10 * time.Minute
+
+	// DefaultWorkerMaxFailures is how many workers in a row may fail
+	// before the pool stops replacing them.
+	DefaultWorkerMaxFailures = 3
+
+	// DefaultWorkerPoolInterval is how often a started pool reconciles.
+	DefaultWorkerPoolInterval = 30 * time.Second
+
+	// MaxPoolWorkers caps the desired count of a pool, so a mistaken
+	// scale-up can't submit a flood of jobs.
+	MaxPoolWorkers = 50
+
+	// workerPoolEvents is how many of its latest events a pool keeps.
+	workerPoolEvents = 50
+)
+
+// WorkerHealthCheck reports why a live worker job is unhealthy, or nil
+// when it is healthy. Unhealthy workers are cancelled and replaced.
+type WorkerHealthCheck func(ctx context.Context, job *Job, startedAt time.Time) error
+
+// WorkerPoolConfig describes a pool of identical worker jobs.
+type WorkerPoolConfig struct {
+	// Name identifies the pool and labels its workers
+	Name string
+	// Spec every worker runs
+	Spec *JobSpec
+	// Desired number of live workers, at most MaxPoolWorkers
+	Desired int
+	// Budget is the most credits the pool may spend on workers, replacements
+	// included. Once the next worker would go over it the pool halts.
+	Budget float64
+	// MaxFailures is how many workers in a row may fail or be found
+	// unhealthy before the pool halts; 0 uses DefaultWorkerMaxFailures
+	MaxFailures int
+	// StartupTimeout bounds how long the default health check lets a
+	// worker stay pending; 0 uses DefaultWorkerStartupTimeout
+	StartupTimeout time.Duration
+	// HealthCheck replaces the default check, which only catches workers
+	// stuck pending past StartupTimeout
+	HealthCheck WorkerHealthCheck
+}
+
+// Worker is one worker job of a pool.
+type Worker struct {
+	JobID      string    `json:"job_id"`
+	Status     JobStatus `json:"status"`
+	CreditCost float64   `json:"credit_cost"`
+	StartedAt  time.Time `json:"started_at"`
+	// Replaces is the job ID of the worker this one replaced
+	Replaces string `json:"replaces,omitempty"`
+}
+
+// WorkerPoolEvent is something the pool did or noticed.
+type WorkerPoolEvent struct {
+	Time    time.Time `json:"time"`
+	JobID   string    `json:"job_id,omitempty"`
+	Message string    `json:"message"`
+}
+
+// WorkerPoolStatus is a snapshot of a pool.
+type WorkerPoolStatus struct {
+	Name    string   `json:"name"`
+	Desired int      `json:"desired"`
+	Workers []Worker `json:"workers"`
+	// Credits spent on workers so far, and the pool's budget
+	Spent  float64 `json:"spent"`
+	Budget float64 `json:"budget"`
+	// Workers submitted in place of failed or unhealthy ones
+	Replacements int `json:"replacements"`
+	// Failed or unhealthy workers since the last healthy one
+	FailuresInRow int `json:"failures_in_row"`
+	// Why the pool stopped submitting workers, empty while it runs
+	Halted string            `json:"halted,omitempty"`
+	Events []WorkerPoolEvent `json:"events,omitempty"`
+}
+
+// Live returns the workers that are pending or running.
+func (s WorkerPoolStatus) Live() int {
+	live := 0
+	for _, w := range s.Workers {
+		if !w.Status.IsTerminal() {
+			live++
+		}
+	}
+	return live
+}
+
+// WorkerPool keeps a desired number of worker jobs alive: it replaces
+// workers that fail, finish or turn unhealthy, within a credit budget, and
+// halts instead of spending further when the budget runs out or workers
+// keep failing. Reconcile makes one pass; Start makes them in the
+// background.
+type WorkerPool struct {
+	client *Client
+	cfg    WorkerPoolConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	workers  []Worker
+	spent    float64
+	lastCost float64
+	replaced int
+	failures int
+	halted   string
+	events   []WorkerPoolEvent
+	// Job IDs of workers that went away and still wait for a replacement
+	vacated []string
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+// NewWorkerPool creates a pool; no worker is submitted until it reconciles.
+func NewWorkerPool(client *Client, cfg WorkerPoolConfig) (*WorkerPool, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("worker pool name is required")
+	}
+	if cfg.Spec == nil {
+		return nil, fmt.Errorf("worker job spec is required")
+	}
+	if err := cfg.Spec.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateDesired(cfg.Desired); err != nil {
+		return nil, err
+	}
+	if cfg.Budget <= 0 {
+		return nil, fmt.Errorf("a worker pool needs a positive credit budget")
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = DefaultWorkerMaxFailures
+	}
+	if cfg.StartupTimeout <= 0 {
+		cfg.StartupTimeout = DefaultWorkerStartupTimeout
+	}
+
+	spec := *cfg.Spec
+	spec.Labels = make(map[string]string, len(cfg.Spec.Labels)+1)
+	for k, v := range cfg.Spec.Labels {
+		spec.Labels[k] = v
+	}
+	spec.Labels[WorkerPoolLabel] = cfg.Name
+	cfg.Spec = &spec
+
+	return &WorkerPool{client: client, cfg: cfg, now: time.Now}, nil
+}
+
+// validateDesired checks a desired worker count.
+func validateDesired(n int) error {
+	if n < 0 || n > MaxPoolWorkers {
+		return fmt.Errorf("desired workers must be between 0 and %d", MaxPoolWorkers)
+	}
+	return nil
+}
+
+// WorkerCost returns the credits one worker is expected to cost: the
+// estimate for its spec, or what the last worker was charged when that
+// was more.
+func (p *WorkerPool) WorkerCost() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return max(calculateCreditCost(p.cfg.Spec), p.lastCost)
+}
+
+// Scale changes the desired number of workers. Extra workers are cancelled
+// and missing ones submitted on the next pass. Scaling a halted pool up
+// does not resume it.
+func (p *WorkerPool) Scale(desired int) error {
+	if err := validateDesired(desired); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cfg.Desired = desired
+	p.event("", fmt.Sprintf("scaled to %d workers", desired))
+	return nil
+}
+
+// Resume lets a halted pool submit workers again, with the failure count
+// reset and, when budget is positive, a new budget.
+func (p *WorkerPool) Resume(budget float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if budget > 0 {
+		p.cfg.Budget = budget
+	}
+	p.halted = ""
+	p.failures = 0
+	p.event("", fmt.Sprintf("resumed with a budget of %.2f credits", p.cfg.Budget))
+}
+
+// Status returns a snapshot of the pool.
+func (p *WorkerPool) Status() WorkerPoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return WorkerPoolStatus{
+		Name:          p.cfg.Name,
+		Desired:       p.cfg.Desired,
+		Workers:       slices.Clone(p.workers),
+		Spent:         p.spent,
+		Budget:        p.cfg.Budget,
+		Replacements:  p.replaced,
+		FailuresInRow: p.failures,
+		Halted:        p.halted,
+		Events:        slices.Clone(p.events),
+	}
+}
+
+// Reconcile makes one pass: it checks every live worker, drops the ones
+// that finished, failed or are unhealthy, cancels workers beyond the
+// desired count and submits missing ones while the budget and failure
+// guardrails allow. Nothing is submitted while the client's spending is
+// paused. The error reports the first call that failed; the pass carries
+// on past it.
+func (p *WorkerPool) Reconcile(ctx context.Context) error {
+	var errs []error
+
+	p.mu.Lock()
+	live := slices.Clone(p.workers)
+	p.mu.Unlock()
+	for _, w := range live {
+		if err := p.check(ctx, w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := p.scaleDown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := p.fill(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// check refreshes one worker and drops it when it is gone or unhealthy.
+func (p *WorkerPool) check(ctx context.Context, w Worker) error {
+	job, err := p.client.GetJob(ctx, w.JobID)
+	if errors.Is(err, ErrJobNotFound) {
+		p.drop(w.JobID, false, "the job disappeared")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check worker %s: %w", w.JobID, err)
+	}
+
+	switch job.Status {
+	case JobStatusFailed:
+		reason := "the job failed"
+		if job.Results != nil {
+			reason = fmt.Sprintf("the job failed with exit code %d", job.Results.ExitCode)
+		}
+		p.drop(w.JobID, true, reason)
+		return nil
+	case JobStatusCompleted, JobStatusCancelled:
+		p.drop(w.JobID, false, "the job "+string(job.Status))
+		return nil
+	}
+
+	health := p.cfg.HealthCheck
+	if health == nil {
+		health = p.startupCheck
+	}
+	if unhealthy := health(ctx, job, w.StartedAt); unhealthy != nil {
+		if _, err := p.client.CancelJob(ctx, w.JobID); err != nil && !errors.Is(err, ErrJobNotFound) {
+			return fmt.Errorf("cancel unhealthy worker %s: %w", w.JobID, err)
+		}
+		p.drop(w.JobID, true, "unhealthy: "+unhealthy.Error())
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.workers {
+		if p.workers[i].JobID == w.JobID {
+			p.workers[i].Status = job.Status
+		}
+	}
+	if job.Status == JobStatusRunning {
+		p.failures = 0
+	}
+	return nil
+}
+
+// startupCheck is the default health check: a worker may stay pending for
+// the startup timeout.
+func (p *WorkerPool) startupCheck(_ context.Context, job *Job, startedAt time.Time) error {
+	if job.Status == JobStatusPending && p.now().Sub(startedAt) > p.cfg.StartupTimeout {
+		return fmt.Errorf("still pending after %s", formatDuration(p.cfg.StartupTimeout))
+	}
+	return nil
+}
+
+// drop removes a worker that went away. Failed and unhealthy workers count
+// against the failure guardrail; one that completed or was cancelled from
+// outside does not.
+func (p *WorkerPool) drop(jobID string, failed bool, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.workers = slices.DeleteFunc(p.workers, func(w Worker) bool { return w.JobID == jobID })
+	p.vacated = append(p.vacated, jobID)
+	if failed {
+		p.failures++
+	}
+	p.event(jobID, "worker removed: "+reason)
+	if p.failures >= p.cfg.MaxFailures && p.halted == "" {
+		p.halted = fmt.Sprintf("%d workers in a row failed", p.failures)
+		p.event("", "halted: "+p.halted)
+	}
+}
+
+// scaleDown cancels the newest workers beyond the desired count.
+func (p *WorkerPool) scaleDown(ctx context.Context) error {
+	p.mu.Lock()
+	extra := len(p.workers) - p.cfg.Desired
+	var surplus []Worker
+	if extra > 0 {
+		surplus = slices.Clone(p.workers[len(p.workers)-extra:])
+	}
+	p.mu.Unlock()
+
+	for i := len(surplus) - 1; i >= 0; i-- {
+		w := surplus[i]
+		if _, err := p.client.CancelJob(ctx, w.JobID); err != nil && !errors.Is(err, ErrJobNotFound) {
+			return fmt.Errorf("cancel surplus worker %s: %w", w.JobID, err)
+		}
+		p.mu.Lock()
+		p.workers = slices.DeleteFunc(p.workers, func(x Worker) bool { return x.JobID == w.JobID })
+		p.event(w.JobID, "worker cancelled: scaled down")
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+// fill submits workers until the desired count is live, stopping at the
+// guardrails.
+func (p *WorkerPool) fill(ctx context.Context) error {
+	for {
+		cost := p.WorkerCost()
+		p.mu.Lock()
+		missing := p.cfg.Desired - len(p.workers)
+		switch {
+		case missing <= 0 || p.halted != "":
+			p.mu.Unlock()
+			return nil
+		case p.spent+cost > p.cfg.Budget:
+			p.halted = fmt.Sprintf("another worker would cost %.2f credits, over the budget of %.2f (%.2f spent)",
+				cost, p.cfg.Budget, p.spent)
+			p.event("", "halted: "+p.halted)
+			p.mu.Unlock()
+			return nil
+		}
+		var replaces string
+		if len(p.vacated) > 0 {
+			replaces = p.vacated[0]
+		}
+		p.mu.Unlock()
+
+		if pause := p.client.SpendingPaused(); pause != nil {
+			return nil
+		}
+		job, err := p.client.SubmitJob(ctx, p.cfg.Spec)
+		if err != nil {
+			return fmt.Errorf("submit worker: %w", err)
+		}
+
+		p.mu.Lock()
+		p.workers = append(p.workers, Worker{
+			JobID:      job.ID,
+			Status:     job.Status,
+			CreditCost: job.CreditCost,
+			StartedAt:  p.now(),
+			Replaces:   replaces,
+		})
+		p.spent += job.CreditCost
+		p.lastCost = job.CreditCost
+		if replaces != "" {
+			p.vacated = p.vacated[1:]
+			p.replaced++
+			p.event(job.ID, "worker submitted to replace "+replaces)
+		} else {
+			p.event(job.ID, "worker submitted")
+		}
+		p.mu.Unlock()
+	}
+}
+
+// event records an event; p.mu must be held.
+func (p *WorkerPool) event(jobID, message string) {
+	p.events = append(p.events, WorkerPoolEvent{Time: p.now(), JobID: jobID, Message: message})
+	if len(p.events) > workerPoolEvents {
+		p.events = p.events[len(p.events)-workerPoolEvents:]
+	}
+}
+
+// Start reconciles the pool every interval in the background until Stop
+// is called or ctx is done; 0 uses DefaultWorkerPoolInterval. Starting a
+// started pool does nothing.
+func (p *WorkerPool) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWorkerPoolInterval
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	ctx, p.stop = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		for {
+			if err := p.Reconcile(ctx); err != nil && ctx.Err() == nil {
+				p.mu.Lock()
+				p.event("", "reconcile: "+err.Error())
+				p.mu.Unlock()
+			}
+			if sleepContext(ctx, interval) != nil {
+				return
+			}
+		}
+	}(p.done)
+}
+
+// Stop ends the background reconciliation and, when cancelWorkers is
+// set, cancels every live worker. It returns the credits refunded.
+func (p *WorkerPool) Stop(ctx context.Context, cancelWorkers bool) (float64, error) {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.stop, p.done = nil, nil
+	p.mu.Unlock()
+	if stop != nil {
+		stop()
+		<-done
+	}
+	if !cancelWorkers {
+		return 0, nil
+	}
+
+	p.mu.Lock()
+	workers := slices.Clone(p.workers)
+	p.cfg.Desired = 0
+	p.mu.Unlock()
+
+	var (
+		refunded float64
+		errs     []error
+	)
+	for _, w := range workers {
+		refund, err := p.client.CancelJob(ctx, w.JobID)
+		if err != nil && !errors.Is(err, ErrJobNotFound) {
+			errs = append(errs, fmt.Errorf("cancel worker %s: %w", w.JobID, err))
+			continue
+		}
+		refunded += refund
+		p.mu.Lock()
+		p.workers = slices.DeleteFunc(p.workers, func(x Worker) bool { return x.JobID == w.JobID })
+		p.event(w.JobID, "worker cancelled: pool stopped")
+		p.mu.Unlock()
+	}
+	return refunded, errors.Join(errs...)
+}
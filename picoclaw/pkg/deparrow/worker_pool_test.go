@@ -0,0 +1,237 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// poolServer is a fake API whose job statuses the test sets.
+type poolServer struct {
+	mu        sync.Mutex
+	next      int
+	status    map[string]JobStatus
+	labels    map[string]string
+	cancelled []string
+}
+
+func newPoolServer(t *testing.T) (*poolServer, *Client) {
+	s := &poolServer{status: make(map[string]JobStatus), labels: make(map[string]string)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/")
+		switch {
+		case path == "submit":
+			var req struct {
+				Spec JobSpec `json:"spec"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			s.next++
+			id := fmt.Sprintf("worker-%d", s.next)
+			s.status[id] = JobStatusPending
+			s.labels[id] = req.Spec.Labels[WorkerPoolLabel]
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": id, "credit_deducted": 2.0})
+		case strings.HasSuffix(path, "/cancel"):
+			id := strings.TrimSuffix(path, "/cancel")
+			s.status[id] = JobStatusCancelled
+			s.cancelled = append(s.cancelled, id)
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": id, "refund_amount": 1.0})
+		default:
+			status, ok := s.status[path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": path, "status": status})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return s, NewClient(server.URL, "test-token")
+}
+
+func (s *poolServer) set(id string, status JobStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[id] = status
+}
+
+func (s *poolServer) submitted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+func newTestPool(t *testing.T, client *Client, cfg WorkerPoolConfig) *WorkerPool {
+	t.Helper()
+	cfg.Name = "crawlers"
+	cfg.Spec = &JobSpec{Image: "crawler:latest"}
+	pool, err := NewWorkerPool(client, cfg)
+	if err != nil {
+		t.Fatalf("NewWorkerPool() error = %v", err)
+	}
+	return pool
+}
+
+func TestWorkerPool_ReplacesFailedWorkers(t *testing.T) {
+	server, client := newPoolServer(t)
+	pool := newTestPool(t, client, WorkerPoolConfig{Desired: 2, Budget: 100})
+	ctx := context.Background()
+
+	if err := pool.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if server.submitted() != 2 || server.labels["worker-1"] != "crawlers" {
+		t.Fatalf("submitted %d workers labelled %v, want 2 labelled with the pool", server.submitted(), server.labels)
+	}
+
+	server.set("worker-1", JobStatusRunning)
+	server.set("worker-2", JobStatusFailed)
+	if err := pool.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	status := pool.Status()
+	if len(status.Workers) != 2 || status.Workers[1].JobID != "worker-3" || status.Workers[1].Replaces != "worker-2" {
+		t.Errorf("workers = %+v, want worker-3 replacing worker-2", status.Workers)
+	}
+	if status.Replacements != 1 || status.FailuresInRow != 1 || status.Spent != 6 {
+		t.Errorf("status = %+v", status)
+	}
+
+	// A worker that disappears is replaced without counting as a failure.
+	server.mu.Lock()
+	delete(server.status, "worker-3")
+	server.mu.Unlock()
+	server.set("worker-1", JobStatusRunning)
+	pool.Reconcile(ctx)
+	if status := pool.Status(); status.FailuresInRow != 0 || status.Live() != 2 || status.Replacements != 2 {
+		t.Errorf("after a lost worker: %+v", status)
+	}
+}
+
+func TestWorkerPool_Guardrails(t *testing.T) {
+	t.Run("budget", func(t *testing.T) {
+		server, client := newPoolServer(t)
+		pool := newTestPool(t, client, WorkerPoolConfig{Desired: 2, Budget: 3})
+		pool.Reconcile(context.Background())
+
+		status := pool.Status()
+		if server.submitted() != 1 || !strings.Contains(status.Halted, "over the budget") {
+			t.Errorf("submitted %d, halted %q; want one worker and a budget halt", server.submitted(), status.Halted)
+		}
+	})
+
+	t.Run("failures in a row", func(t *testing.T) {
+		server, client := newPoolServer(t)
+		pool := newTestPool(t, client, WorkerPoolConfig{Desired: 1, Budget: 100, MaxFailures: 2})
+		ctx := context.Background()
+		pool.Reconcile(ctx)
+		server.set("worker-1", JobStatusFailed)
+		pool.Reconcile(ctx)
+		server.set("worker-2", JobStatusFailed)
+		pool.Reconcile(ctx)
+
+		status := pool.Status()
+		if server.submitted() != 2 || !strings.Contains(status.Halted, "2 workers in a row failed") {
+			t.Errorf("submitted %d, halted %q", server.submitted(), status.Halted)
+		}
+
+		pool.Resume(0)
+		pool.Reconcile(ctx)
+		if status := pool.Status(); status.Halted != "" || server.submitted() != 3 {
+			t.Errorf("after resume: submitted %d, status %+v", server.submitted(), status)
+		}
+	})
+
+	t.Run("spending paused", func(t *testing.T) {
+		server, client := newPoolServer(t)
+		client.PauseSpending("test")
+		pool := newTestPool(t, client, WorkerPoolConfig{Desired: 1, Budget: 100})
+		pool.Reconcile(context.Background())
+		if server.submitted() != 0 {
+			t.Errorf("submitted %d workers while spending is paused", server.submitted())
+		}
+	})
+}
+
+func TestWorkerPool_HealthAndScaling(t *testing.T) {
+	server, client := newPoolServer(t)
+	pool := newTestPool(t, client, WorkerPoolConfig{Desired: 3, Budget: 100, StartupTimeout: time.Minute})
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	ctx := context.Background()
+	pool.Reconcile(ctx)
+
+	server.set("worker-1", JobStatusRunning)
+	server.set("worker-2", JobStatusRunning)
+	now = now.Add(2 * time.Minute)
+	pool.Reconcile(ctx)
+	if len(server.cancelled) != 1 || server.cancelled[0] != "worker-3" {
+		t.Errorf("cancelled %v, want the worker stuck pending", server.cancelled)
+	}
+	if status := pool.Status(); status.Live() != 3 || status.FailuresInRow != 1 {
+		t.Errorf("after the health check: %+v", status)
+	}
+
+	if err := pool.Scale(1); err != nil {
+		t.Fatalf("Scale() error = %v", err)
+	}
+	pool.Reconcile(ctx)
+	if status := pool.Status(); len(status.Workers) != 1 || status.Workers[0].JobID != "worker-1" {
+		t.Errorf("after scaling down: %+v", status.Workers)
+	}
+	if err := pool.Scale(MaxPoolWorkers + 1); err == nil {
+		t.Error("Scale() above MaxPoolWorkers should fail")
+	}
+
+	refunded, err := pool.Stop(ctx, true)
+	if err != nil || refunded != 1 || pool.Status().Live() != 0 {
+		t.Errorf("Stop() = %v, %v; status %+v", refunded, err, pool.Status())
+	}
+}
+
+func TestWorkerPoolTool(t *testing.T) {
+	server, client := newPoolServer(t)
+	tool := NewWorkerPoolTool(client)
+	ctx := context.Background()
+
+	if result := tool.Execute(ctx, map[string]interface{}{
+		"action": "create", "name": "crawlers", "image": "crawler:latest", "workers": float64(2),
+	}); !result.IsError || !strings.Contains(result.ForLLM, "budget") {
+		t.Errorf("create without a budget = %s", result.ForLLM)
+	}
+
+	result := tool.Execute(ctx, map[string]interface{}{
+		"action": "create", "name": "crawlers", "image": "crawler:latest", "workers": float64(2), "budget": 10.0,
+	})
+	if result.IsError || !strings.Contains(result.ForLLM, "2/2 workers live") {
+		t.Fatalf("create = %s", result.ForLLM)
+	}
+	if spend, ok := tool.estimateSpend(map[string]interface{}{"action": "create", "budget": 10.0}); !ok || spend != 10 {
+		t.Errorf("estimateSpend() = %v, %v; want the budget", spend, ok)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{})
+	if !strings.Contains(result.ForLLM, "crawlers") || !strings.Contains(result.ForLLM, "1 pool") {
+		t.Errorf("status = %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"action": "stop", "name": "crawlers", "cancel_workers": true})
+	if result.IsError || !strings.Contains(result.ForLLM, "Cancelled 2 workers") {
+		t.Errorf("stop = %s", result.ForLLM)
+	}
+	if server.submitted() != 2 {
+		t.Errorf("submitted %d workers, want 2", server.submitted())
+	}
+	if result := tool.Execute(ctx, map[string]interface{}{"action": "status", "name": "crawlers"}); !result.IsError {
+		t.Errorf("status of a stopped pool = %s", result.ForLLM)
+	}
+}
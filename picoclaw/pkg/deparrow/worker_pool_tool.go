@@ -0,0 +1,328 @@
+package deparrow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// WorkerPoolTool lets the agent keep a pool of worker jobs running, with
+// failed workers replaced within a credit budget.
+type WorkerPoolTool struct {
+	client *Client
+}
+
+// NewWorkerPoolTool creates a new worker pool tool.
+func NewWorkerPoolTool(client *Client) *WorkerPoolTool {
+	return &WorkerPoolTool{client: client}
+}
+
+// Name returns the tool name.
+func (t *WorkerPoolTool) Name() string {
+	return "deparrow_worker_pool"
+}
+
+// Description returns the tool description.
+func (t *WorkerPoolTool) Description() string {
+	return fmt.Sprintf(`Keep a pool of identical worker jobs running on DEparrow, e.g. queue consumers or crawlers.
+
+Actions:
+- create: start a pool (same job parameters as 'deparrow_submit_job' plus
+  name, workers and budget)
+- status: show a pool's workers, spending and recent events, or all pools
+  without name
+- scale: change how many workers a pool keeps (name, workers)
+- resume: let a halted pool submit workers again, optionally with a new budget
+- stop: stop maintaining a pool; cancel_workers=true also cancels its workers
+
+The pool checks its workers every %s. Workers that fail, finish or stay
+pending longer than startup_timeout_minutes are replaced. To stay safe
+the pool halts, and submits nothing more, when the next worker would take
+it over its budget of credits or when %d workers in a row have failed.
+Workers are labelled %s=<name>, so 'deparrow_list_jobs' can find them.
+`, formatDuration(DefaultWorkerPoolInterval), DefaultWorkerMaxFailures, WorkerPoolLabel)
+}
+
+// Parameters returns the JSON schema for tool parameters: those of the job
+// submission tool plus the pool's own.
+func (t *WorkerPoolTool) Parameters() map[string]interface{} {
+	params := NewJobTool(t.client).Parameters()
+	delete(params, "required")
+	properties := params["properties"].(map[string]interface{})
+	delete(properties, "orchestrator")
+	delete(properties, "wait")
+
+	properties["action"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{"create", "status", "scale", "resume", "stop"},
+		"description": "Action to perform",
+		"default":     "status",
+	}
+	properties["name"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Name of the pool",
+	}
+	properties["workers"] = map[string]interface{}{
+		"type":        "integer",
+		"description": "Number of workers to keep running (for create and scale)",
+		"minimum":     0,
+		"maximum":     MaxPoolWorkers,
+	}
+	properties["budget"] = map[string]interface{}{
+		"type":        "number",
+		"description": "Most credits the pool may spend on workers, replacements included (required for create; for resume, a new budget)",
+	}
+	properties["startup_timeout_minutes"] = map[string]interface{}{
+		"type":        "integer",
+		"description": "Minutes a worker may stay pending before it is replaced",
+		"default":     int(DefaultWorkerStartupTimeout / time.Minute),
+	}
+	properties["cancel_workers"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Cancel the pool's workers when stopping it",
+		"default":     false,
+	}
+	return params
+}
+
+// Execute runs the worker pool tool.
+func (t *WorkerPoolTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	action, _ := args["action"].(string)
+	name, _ := args["name"].(string)
+	switch action {
+	case "", "status":
+		return t.status(name)
+	case "create":
+		return t.create(ctx, name, args)
+	case "scale", "resume", "stop":
+	default:
+		return tools.ErrorResult(fmt.Sprintf("Unknown action: %s", action))
+	}
+
+	if name == "" {
+		return tools.ErrorResult(fmt.Sprintf("name is required to %s a worker pool", action))
+	}
+	pool := t.client.workerPool(name)
+	if pool == nil {
+		return tools.ErrorResult(fmt.Sprintf("No worker pool named %q. Use action='status' to list pools.", name))
+	}
+	switch action {
+	case "scale":
+		workers, ok := intArg(args, "workers")
+		if !ok {
+			return tools.ErrorResult("workers is required to scale a worker pool")
+		}
+		if err := pool.Scale(workers); err != nil {
+			return tools.ErrorResult(err.Error())
+		}
+		if err := pool.Reconcile(ctx); err != nil {
+			return failureResult("scale worker pool", err)
+		}
+		return tools.UserResult("⚖️  Worker pool scaled\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n" + formatWorkerPool(pool.Status()))
+	case "resume":
+		budget, _ := args["budget"].(float64)
+		if result := checkJobSpend(t.client, pool.cfg.Spec); result != nil {
+			return result
+		}
+		pool.Resume(budget)
+		if err := pool.Reconcile(ctx); err != nil {
+			return failureResult("resume worker pool", err)
+		}
+		return tools.UserResult("▶️  Worker pool resumed\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n" + formatWorkerPool(pool.Status()))
+	default:
+		return t.stop(ctx, pool, args)
+	}
+}
+
+// create starts a pool from the tool's arguments.
+func (t *WorkerPoolTool) create(ctx context.Context, name string, args map[string]interface{}) *tools.ToolResult {
+	if name == "" {
+		return tools.ErrorResult("name is required to create a worker pool")
+	}
+	if t.client.workerPool(name) != nil {
+		return tools.ErrorResult(fmt.Sprintf("A worker pool named %q already exists; scale or stop it instead.", name))
+	}
+	workers, ok := intArg(args, "workers")
+	if !ok || workers < 1 {
+		return tools.ErrorResult("workers must be at least 1 to create a worker pool")
+	}
+	budget, _ := args["budget"].(float64)
+	if budget <= 0 {
+		return tools.ErrorResult("budget is required to create a worker pool: the most credits it may spend on workers")
+	}
+	spec, err := buildJobSpec(t.client, args)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	if result := checkJobSpend(t.client, spec); result != nil {
+		return result
+	}
+
+	cfg := WorkerPoolConfig{Name: name, Spec: spec, Desired: workers, Budget: budget}
+	if minutes, ok := intArg(args, "startup_timeout_minutes"); ok && minutes > 0 {
+		cfg.StartupTimeout = time.Duration(minutes) * time.Minute
+	}
+	pool, err := NewWorkerPool(t.client, cfg)
+	if err != nil {
+		return tools.ErrorResult(err.Error())
+	}
+	if cost := pool.WorkerCost(); cost > budget {
+		return tools.ErrorResult(fmt.Sprintf("A worker costs about %.2f credits, more than the budget of %.2f.", cost, budget))
+	}
+	if err := pool.Reconcile(ctx); err != nil && len(pool.Status().Workers) == 0 {
+		return failureResult("start worker pool", err)
+	}
+	t.client.addWorkerPool(pool)
+	pool.Start(context.WithoutCancel(ctx), DefaultWorkerPoolInterval)
+
+	var result strings.Builder
+	result.WriteString("👷 Worker Pool Started\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result.WriteString(formatWorkerPool(pool.Status()))
+	if cost := pool.WorkerCost(); cost > 0 {
+		result.WriteString(fmt.Sprintf("\nEach worker costs about %.2f credits; the budget covers %s.",
+			cost, plural(int(budget/cost), "worker")))
+	}
+	result.WriteString(fmt.Sprintf("\nUse 'deparrow_worker_pool' with action='stop' and name='%s' to stop it.", name))
+	return tools.UserResult(result.String())
+}
+
+// status displays one pool, or every pool when name is empty.
+func (t *WorkerPoolTool) status(name string) *tools.ToolResult {
+	if name != "" {
+		pool := t.client.workerPool(name)
+		if pool == nil {
+			return tools.ErrorResult(fmt.Sprintf("No worker pool named %q.", name))
+		}
+		var result strings.Builder
+		result.WriteString("👷 Worker Pool\n")
+		result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+		status := pool.Status()
+		result.WriteString(formatWorkerPool(status))
+		if len(status.Events) > 0 {
+			result.WriteString("\nRecent events:\n")
+			events := status.Events
+			if len(events) > 10 {
+				events = events[len(events)-10:]
+			}
+			for _, e := range events {
+				line := e.Message
+				if e.JobID != "" && !strings.Contains(line, e.JobID) {
+					line = e.JobID + ": " + line
+				}
+				result.WriteString(fmt.Sprintf("  • %s %s\n", e.Time.Format("15:04:05"), line))
+			}
+		}
+		return tools.UserResult(result.String())
+	}
+
+	pools := t.client.listWorkerPools()
+	var result strings.Builder
+	result.WriteString("👷 Worker Pools\n")
+	result.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	if len(pools) == 0 {
+		result.WriteString("No worker pools.\n\n")
+		result.WriteString("💡 A worker pool keeps a number of worker jobs running and replaces\n")
+		result.WriteString("   failed ones within a budget. Create one with action='create'.")
+		return tools.UserResult(result.String())
+	}
+	for _, pool := range pools {
+		result.WriteString(formatWorkerPool(pool.Status()))
+		result.WriteString("\n")
+	}
+	result.WriteString(fmt.Sprintf("📊 %s", plural(len(pools), "pool")))
+	return tools.UserResult(result.String())
+}
+
+// stop stops maintaining a pool and forgets it.
+func (t *WorkerPoolTool) stop(ctx context.Context, pool *WorkerPool, args map[string]interface{}) *tools.ToolResult {
+	cancelWorkers, _ := args["cancel_workers"].(bool)
+	live := pool.Status().Live()
+	refunded, err := pool.Stop(ctx, cancelWorkers)
+	if err != nil {
+		return failureResult("cancel workers", err)
+	}
+	t.client.removeWorkerPool(pool.cfg.Name)
+
+	result := fmt.Sprintf("🛑 Worker pool %s stopped.", pool.cfg.Name)
+	switch {
+	case cancelWorkers:
+		result += fmt.Sprintf("\nCancelled %s; %.2f credits refunded.", plural(live, "worker"), refunded)
+	case live > 0:
+		result += fmt.Sprintf("\n%s still running carry on; cancel them with 'deparrow_cancel_job'.", plural(live, "worker"))
+	}
+	return tools.UserResult(result)
+}
+
+// formatWorkerPool renders a pool's state.
+func formatWorkerPool(s WorkerPoolStatus) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("👷 %s — %d/%d workers live\n", s.Name, s.Live(), s.Desired))
+	b.WriteString(fmt.Sprintf("   Budget: %.2f of %.2f credits · %s\n",
+		s.Spent, s.Budget, plural(s.Replacements, "replacement")))
+	for _, w := range s.Workers {
+		line := fmt.Sprintf("   • %s %s", w.JobID, w.Status)
+		if w.Replaces != "" {
+			line += " (replaced " + w.Replaces + ")"
+		}
+		b.WriteString(line + "\n")
+	}
+	if s.FailuresInRow > 0 {
+		b.WriteString(fmt.Sprintf("   ⚠️  %d failed in a row\n", s.FailuresInRow))
+	}
+	if s.Halted != "" {
+		b.WriteString(fmt.Sprintf("   ⛔ Halted: %s. Fix the cause and use action='resume'.\n", s.Halted))
+	}
+	return b.String()
+}
+
+// intArg reads an integer argument, which JSON decodes as a float64.
+func intArg(args map[string]interface{}, key string) (int, bool) {
+	switch v := args[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+// workerPool returns the pool named name, or nil.
+func (c *Client) workerPool(name string) *WorkerPool {
+	c.workerPoolsMu.Lock()
+	defer c.workerPoolsMu.Unlock()
+	return c.workerPools[name]
+}
+
+// addWorkerPool keeps pool for the worker pool tool.
+func (c *Client) addWorkerPool(pool *WorkerPool) {
+	c.workerPoolsMu.Lock()
+	defer c.workerPoolsMu.Unlock()
+	if c.workerPools == nil {
+		c.workerPools = make(map[string]*WorkerPool)
+	}
+	c.workerPools[pool.cfg.Name] = pool
+}
+
+// removeWorkerPool forgets the pool named name.
+func (c *Client) removeWorkerPool(name string) {
+	c.workerPoolsMu.Lock()
+	defer c.workerPoolsMu.Unlock()
+	delete(c.workerPools, name)
+}
+
+// listWorkerPools returns the pools sorted by name.
+func (c *Client) listWorkerPools() []*WorkerPool {
+	c.workerPoolsMu.Lock()
+	defer c.workerPoolsMu.Unlock()
+	pools := make([]*WorkerPool, 0, len(c.workerPools))
+	for _, pool := range c.workerPools {
+		pools = append(pools, pool)
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].cfg.Name < pools[j].cfg.Name })
+	return pools
+}
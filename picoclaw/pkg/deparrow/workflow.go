@@ -0,0 +1,292 @@
+package deparrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// workflowsPath is the endpoint for submitting workflows to a server that
+// runs them itself.
+const workflowsPath = "/api/v1/workflows"
+
+// ErrWorkflowCycle is returned for a workflow whose steps depend on each
+// other in a loop.
+var ErrWorkflowCycle = errors.New("workflow has a dependency cycle")
+
+// WorkflowStep is one job of a workflow.
+type WorkflowStep struct {
+	// Name identifies the step within the workflow
+	Name string   `json:"name"`
+	Spec *JobSpec `json:"spec"`
+	// Steps that must complete first. Each one's output is mounted as an
+	// IPFS input of this step.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Where a dependency's output is mounted, by step name; defaults to
+	// /inputs/<step name>
+	InputPaths map[string]string `json:"input_paths,omitempty"`
+}
+
+// inputPath returns where the output of the dependency is mounted.
+func (s *WorkflowStep) inputPath(dependency string) string {
+	if p := s.InputPaths[dependency]; p != "" {
+		return p
+	}
+	return "/inputs/" + dependency
+}
+
+// wiredSpec returns a copy of the step's spec with the outputs of its
+// dependencies, by step name, added as inputs.
+func (s *WorkflowStep) wiredSpec(outputs map[string]string) *JobSpec {
+	spec := *s.Spec
+	spec.Inputs = append([]InputSpec(nil), s.Spec.Inputs...)
+	for _, dep := range s.DependsOn {
+		spec.Inputs = append(spec.Inputs, InputSpec{
+			StorageSource: "ipfs",
+			Source:        outputs[dep],
+			Path:          s.inputPath(dep),
+		})
+	}
+	return &spec
+}
+
+// Workflow is a set of jobs where some consume the outputs of others, such
+// as a preprocessing step feeding a training step.
+//
+// Example:
+//
+//	wf := &deparrow.Workflow{
+//	    Name: "train",
+//	    Steps: []deparrow.WorkflowStep{
+//	        {Name: "prepare", Spec: prepareSpec},
+//	        {Name: "train", Spec: trainSpec, DependsOn: []string{"prepare"}},
+//	    },
+//	}
+//	run, err := client.SubmitWorkflow(ctx, wf, deparrow.WaitOptions{})
+type Workflow struct {
+	Name  string         `json:"name"`
+	Steps []WorkflowStep `json:"steps"`
+}
+
+// Validate checks the steps have unique names and valid specs, that every
+// dependency names another step and that there is no cycle.
+func (w *Workflow) Validate() error {
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("workflow has no steps")
+	}
+
+	names := make(map[string]bool, len(w.Steps))
+	for i, step := range w.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("step %d has no name", i)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("step name %q is used twice", step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	for _, step := range w.Steps {
+		if step.Spec == nil {
+			return fmt.Errorf("step %s has no job spec", step.Name)
+		}
+		for _, dep := range step.DependsOn {
+			if dep == step.Name {
+				return fmt.Errorf("step %s depends on itself", step.Name)
+			}
+			if !names[dep] {
+				return fmt.Errorf("step %s depends on unknown step %q", step.Name, dep)
+			}
+		}
+		// Wire placeholder outputs so clashing input paths are caught now
+		placeholders := make(map[string]string, len(step.DependsOn))
+		for _, dep := range step.DependsOn {
+			placeholders[dep] = "pending-" + dep
+		}
+		if err := step.wiredSpec(placeholders).Validate(); err != nil {
+			return fmt.Errorf("step %s: %w", step.Name, err)
+		}
+	}
+
+	_, err := w.Order()
+	return err
+}
+
+// Order returns the steps in an order where each comes after the steps it
+// depends on. Steps that don't depend on each other keep the order they
+// were given in. The error wraps ErrWorkflowCycle when there is none.
+func (w *Workflow) Order() ([]WorkflowStep, error) {
+	index := make(map[string]int, len(w.Steps))
+	for i, step := range w.Steps {
+		index[step.Name] = i
+	}
+
+	waiting := make([]int, len(w.Steps))
+	dependents := make([][]int, len(w.Steps))
+	for i, step := range w.Steps {
+		for _, dep := range step.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("step %s depends on unknown step %q", step.Name, dep)
+			}
+			waiting[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	order := make([]WorkflowStep, 0, len(w.Steps))
+	done := make([]bool, len(w.Steps))
+	for len(order) < len(w.Steps) {
+		next := -1
+		for i := range w.Steps {
+			if !done[i] && waiting[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var stuck []string
+			for i, step := range w.Steps {
+				if !done[i] {
+					stuck = append(stuck, step.Name)
+				}
+			}
+			return nil, fmt.Errorf("%w between %s", ErrWorkflowCycle, strings.Join(stuck, ", "))
+		}
+		done[next] = true
+		order = append(order, w.Steps[next])
+		for _, i := range dependents[next] {
+			waiting[i]--
+		}
+	}
+	return order, nil
+}
+
+// WorkflowStepRun is the outcome of one step of a submitted workflow.
+type WorkflowStepRun struct {
+	Name   string    `json:"name"`
+	JobID  string    `json:"job_id,omitempty"`
+	Status JobStatus `json:"status,omitempty"`
+	// Output of the step's job, wired into the steps that depend on it
+	OutputCID string `json:"output_cid,omitempty"`
+	// Skipped is set when a dependency did not complete or produced no
+	// output, so the step's job was never submitted
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// WorkflowRun is a submitted workflow.
+type WorkflowRun struct {
+	// ID is set by servers that run workflows themselves
+	ID   string `json:"workflow_id,omitempty"`
+	Name string `json:"name"`
+	// Steps in the order they run
+	Steps []WorkflowStepRun `json:"steps"`
+	// Native is set when the server runs the workflow; the steps then only
+	// reflect its state at submission
+	Native bool `json:"-"`
+}
+
+// Step returns the run of a step by name.
+func (r *WorkflowRun) Step(name string) (*WorkflowStepRun, bool) {
+	for i := range r.Steps {
+		if r.Steps[i].Name == name {
+			return &r.Steps[i], true
+		}
+	}
+	return nil, false
+}
+
+// SubmitWorkflow validates a workflow and submits it. A server that
+// advertises FeatureWorkflows receives the whole workflow and wires the
+// outputs itself. Otherwise the client runs it: it submits each step once
+// the steps it depends on have completed, with their output CIDs added as
+// inputs, and waits for it with opts. Steps whose dependencies failed are
+// skipped; the others still run.
+//
+// The run is returned with a result per step. The error is non-nil when
+// any step failed or was skipped and joins the individual errors.
+func (c *Client) SubmitWorkflow(ctx context.Context, wf *Workflow, opts WaitOptions) (*WorkflowRun, error) {
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	order, _ := wf.Order()
+	for _, step := range order {
+		if err := c.requireFeatures(ctx, step.Spec.features()); err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+	}
+
+	if info, err := c.ServerInfo(ctx); err == nil && info.Supports(FeatureWorkflows) {
+		var run WorkflowRun
+		if err := c.doRequest(ctx, http.MethodPost, workflowsPath, wf, &run); err != nil {
+			return nil, err
+		}
+		run.Native = true
+		return &run, nil
+	}
+
+	run := &WorkflowRun{Name: wf.Name, Steps: make([]WorkflowStepRun, len(order))}
+	outputs := make(map[string]string, len(order))
+	var errs []error
+	for i, step := range order {
+		result := &run.Steps[i]
+		result.Name = step.Name
+
+		for _, dep := range step.DependsOn {
+			cid, ok := outputs[dep]
+			if !ok || cid == "" {
+				result.Skipped = true
+				result.Error = fmt.Sprintf("dependency %s did not complete", dep)
+				if ok {
+					result.Error = fmt.Sprintf("dependency %s produced no output", dep)
+				}
+				break
+			}
+		}
+		if result.Skipped {
+			errs = append(errs, fmt.Errorf("step %s: %s", step.Name, result.Error))
+			continue
+		}
+
+		err := c.runWorkflowStep(ctx, step.wiredSpec(outputs), result, opts)
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, fmt.Errorf("step %s: %w", step.Name, err))
+			if ctx.Err() != nil {
+				return run, errors.Join(errs...)
+			}
+			continue
+		}
+		outputs[step.Name] = result.OutputCID
+	}
+	return run, errors.Join(errs...)
+}
+
+// runWorkflowStep submits one step's job, waits for it and records the
+// outcome in result.
+func (c *Client) runWorkflowStep(ctx context.Context, spec *JobSpec, result *WorkflowStepRun, opts WaitOptions) error {
+	job, err := c.SubmitJob(ctx, spec)
+	if err != nil {
+		return err
+	}
+	result.JobID = job.ID
+	result.Status = job.Status
+
+	job, err = c.WaitForJobCompletion(ctx, job.ID, opts)
+	if job != nil {
+		result.Status = job.Status
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status != JobStatusCompleted {
+		return fmt.Errorf("job %s %s", job.ID, job.Status)
+	}
+	if job.Results != nil {
+		result.OutputCID = job.Results.OutputCID
+	}
+	return nil
+}
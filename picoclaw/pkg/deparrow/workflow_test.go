@@ -0,0 +1,188 @@
+//go:build unit
+
+package deparrow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func workflowStepNames(steps []WorkflowStep) []string {
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+	}
+	return names
+}
+
+func TestWorkflow_Order(t *testing.T) {
+	spec := &JobSpec{Image: "alpine"}
+	wf := &Workflow{Steps: []WorkflowStep{
+		{Name: "report", Spec: spec, DependsOn: []string{"train", "evaluate"}},
+		{Name: "train", Spec: spec, DependsOn: []string{"prepare"}},
+		{Name: "evaluate", Spec: spec, DependsOn: []string{"train"}},
+		{Name: "prepare", Spec: spec},
+		{Name: "lint", Spec: spec},
+	}}
+	order, err := wf.Order()
+	if err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	want := "prepare train evaluate report lint"
+	if got := strings.Join(workflowStepNames(order), " "); got != want {
+		t.Errorf("Order() = %s, want %s", got, want)
+	}
+
+	wf.Steps[3].DependsOn = []string{"report"}
+	if _, err := wf.Order(); !errors.Is(err, ErrWorkflowCycle) {
+		t.Fatalf("Order() error = %v, want ErrWorkflowCycle", err)
+	} else if strings.Contains(err.Error(), "lint") {
+		t.Errorf("cycle error %q should only name the steps in or behind the cycle", err)
+	}
+}
+
+func TestWorkflow_Validate(t *testing.T) {
+	spec := &JobSpec{Image: "alpine"}
+	tests := map[string]*Workflow{
+		"no steps":       {},
+		"unnamed step":   {Steps: []WorkflowStep{{Spec: spec}}},
+		"duplicate name": {Steps: []WorkflowStep{{Name: "a", Spec: spec}, {Name: "a", Spec: spec}}},
+		"no spec":        {Steps: []WorkflowStep{{Name: "a"}}},
+		"self":           {Steps: []WorkflowStep{{Name: "a", Spec: spec, DependsOn: []string{"a"}}}},
+		"unknown":        {Steps: []WorkflowStep{{Name: "a", Spec: spec, DependsOn: []string{"b"}}}},
+		"invalid spec":   {Steps: []WorkflowStep{{Name: "a", Spec: &JobSpec{}}}},
+		"cycle": {Steps: []WorkflowStep{
+			{Name: "a", Spec: spec, DependsOn: []string{"b"}},
+			{Name: "b", Spec: spec, DependsOn: []string{"a"}},
+		}},
+		"input path clash": {Steps: []WorkflowStep{
+			{Name: "a", Spec: spec},
+			{Name: "b", Spec: &JobSpec{Image: "alpine", Inputs: []InputSpec{{StorageSource: "url", Source: "https://example.com/x", Path: "/inputs/a"}}}, DependsOn: []string{"a"}},
+		}},
+	}
+	for name, wf := range tests {
+		if err := wf.Validate(); err == nil {
+			t.Errorf("%s: Validate() should fail", name)
+		}
+	}
+}
+
+func TestSubmitWorkflow_Sandbox(t *testing.T) {
+	client := NewSandboxClient()
+	wf := &Workflow{Name: "pipeline", Steps: []WorkflowStep{
+		{Name: "train", Spec: &JobSpec{Image: "pytorch/pytorch"}, DependsOn: []string{"prepare"}, InputPaths: map[string]string{"prepare": "/data"}},
+		{Name: "prepare", Spec: &JobSpec{Image: "alpine"}},
+	}}
+
+	run, err := client.SubmitWorkflow(context.Background(), wf, WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("SubmitWorkflow() error = %v", err)
+	}
+	if run.Native || len(run.Steps) != 2 || run.Steps[0].Name != "prepare" {
+		t.Fatalf("run = %+v", run)
+	}
+	prepare, _ := run.Step("prepare")
+	train, _ := run.Step("train")
+	if prepare.Status != JobStatusCompleted || train.Status != JobStatusCompleted || prepare.OutputCID == "" {
+		t.Errorf("steps = %+v", run.Steps)
+	}
+
+	// The training job was given the preparation's output
+	job := client.sandbox.jobs[train.JobID]
+	inputs := job.Spec.Inputs
+	if len(inputs) != 1 || inputs[0].Source != prepare.OutputCID || inputs[0].Path != "/data" || inputs[0].StorageSource != "ipfs" {
+		t.Errorf("Inputs = %+v, want the output of %s", inputs, prepare.JobID)
+	}
+	if len(wf.Steps[0].Spec.Inputs) != 0 {
+		t.Error("SubmitWorkflow() should not modify the step's spec")
+	}
+}
+
+func TestSubmitWorkflow_SkipsDependentsOfFailedStep(t *testing.T) {
+	jobs := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/health":
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy"})
+		case r.URL.Path == "/api/v1/jobs/submit":
+			jobs++
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-" + string(rune('0'+jobs))})
+		case r.URL.Path == "/api/v1/jobs/job-1":
+			json.NewEncoder(w).Encode(map[string]interface{}{"job_id": "job-1", "status": "failed"})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"job_id": strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/"), "status": "completed",
+				"results": map[string]interface{}{"output_cid": "bafyother"},
+			})
+		}
+	}))
+	defer server.Close()
+
+	spec := &JobSpec{Image: "alpine"}
+	wf := &Workflow{Steps: []WorkflowStep{
+		{Name: "prepare", Spec: spec},
+		{Name: "train", Spec: spec, DependsOn: []string{"prepare"}},
+		{Name: "lint", Spec: spec},
+	}}
+	run, err := NewClient(server.URL, "test-token").SubmitWorkflow(context.Background(), wf, WaitOptions{PollInterval: time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "step prepare") || !strings.Contains(err.Error(), "step train") {
+		t.Fatalf("SubmitWorkflow() error = %v", err)
+	}
+	if train, _ := run.Step("train"); !train.Skipped || train.JobID != "" {
+		t.Errorf("train = %+v, want it skipped", train)
+	}
+	if lint, _ := run.Step("lint"); lint.Status != JobStatusCompleted {
+		t.Errorf("lint = %+v, want independent steps still run", lint)
+	}
+	if jobs != 2 {
+		t.Errorf("%d jobs submitted, want 2", jobs)
+	}
+}
+
+func TestSubmitWorkflow_Native(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/health":
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "healthy", "features": []string{FeatureWorkflows}})
+		case "/api/v1/workflows":
+			json.NewDecoder(r.Body).Decode(&body)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"workflow_id": "wf-1",
+				"name":        "pipeline",
+				"steps": []map[string]interface{}{
+					{"name": "prepare", "job_id": "job-1", "status": "pending"},
+					{"name": "train"},
+				},
+			})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	wf := &Workflow{Name: "pipeline", Steps: []WorkflowStep{
+		{Name: "prepare", Spec: &JobSpec{Image: "alpine"}},
+		{Name: "train", Spec: &JobSpec{Image: "alpine"}, DependsOn: []string{"prepare"}},
+	}}
+	run, err := NewClient(server.URL, "test-token").SubmitWorkflow(context.Background(), wf, WaitOptions{})
+	if err != nil {
+		t.Fatalf("SubmitWorkflow() error = %v", err)
+	}
+	if !run.Native || run.ID != "wf-1" || len(run.Steps) != 2 {
+		t.Errorf("run = %+v", run)
+	}
+	steps, _ := body["steps"].([]interface{})
+	if len(steps) != 2 {
+		t.Fatalf("body = %v, want the whole workflow sent", body)
+	}
+	if deps := steps[1].(map[string]interface{})["depends_on"]; deps == nil {
+		t.Errorf("step = %v, want its dependencies sent", steps[1])
+	}
+}
@@ -0,0 +1,275 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultPolicyTimeout bounds a single admission decision.
+	DefaultPolicyTimeout = 100 * time.Millisecond
+
+	// DefaultPolicyMemoryLimit is the most memory a policy module may grow to.
+	DefaultPolicyMemoryLimit = 16 << 20
+
+	// Exports a WASM admission policy must provide besides its memory.
+	policyAllocExport = "alloc"
+	policyAdmitExport = "admit"
+
+	wasmPageSize = 64 << 10
+)
+
+// ErrAdmissionDenied is returned by SubmitJob when the admission policy
+// denies a job.
+var ErrAdmissionDenied = errors.New("admission denied")
+
+// AdmissionDecision is what an admission policy decides for a job.
+type AdmissionDecision string
+
+const (
+	// AdmissionAdmit schedules the job as usual.
+	AdmissionAdmit AdmissionDecision = "admit"
+
+	// AdmissionDeny rejects the job.
+	AdmissionDeny AdmissionDecision = "deny"
+
+	// AdmissionQueue queues the job without scheduling it.
+	AdmissionQueue AdmissionDecision = "queue"
+)
+
+// Valid reports whether d is a known decision.
+func (d AdmissionDecision) Valid() bool {
+	switch d {
+	case AdmissionAdmit, AdmissionDeny, AdmissionQueue:
+		return true
+	}
+	return false
+}
+
+// AdmissionInput is what an admission policy decides on.
+type AdmissionInput struct {
+	// Request is the job submission.
+	Request GlobalJobRequest `json:"Request"`
+
+	// Capacity is the aggregated capacity available across the Global VM.
+	Capacity *GlobalResources `json:"Capacity"`
+
+	// Time is when the job was submitted, for time-of-day rules.
+	Time time.Time `json:"Time"`
+}
+
+// AdmissionResult is an admission policy's decision and why it was made.
+type AdmissionResult struct {
+	Decision AdmissionDecision `json:"Decision"`
+	Reason   string            `json:"Reason,omitempty"`
+}
+
+// AdmissionPolicy decides whether a submitted job is admitted, denied or
+// queued, before any nodes are selected for it.
+type AdmissionPolicy interface {
+	Admit(ctx context.Context, input AdmissionInput) (AdmissionResult, error)
+}
+
+// WithAdmissionPolicy runs every submission past the policy. A policy that
+// fails to decide fails the submission.
+func WithAdmissionPolicy(policy AdmissionPolicy) EndpointOption {
+	return func(e *Endpoint) {
+		e.admission = policy
+	}
+}
+
+// WASMPolicy is an AdmissionPolicy implemented by a WebAssembly module, so
+// operators can supply custom admission logic, such as business hours or
+// customer tiers, without rebuilding the orchestrator.
+//
+// The module exports its memory and two functions:
+//
+//	alloc(size i32) -> i32           returns the address of size free bytes
+//	admit(ptr i32, len i32) -> i64   decides on the AdmissionInput JSON at ptr
+//
+// admit returns the address of the AdmissionResult JSON in the upper 32
+// bits of its result and the length in the lower 32 bits. Each decision
+// runs in a fresh instance of the module, so no state carries over between
+// jobs. WASI is available, but the module has no wall clock; rules that
+// depend on the time use the input's Time.
+type WASMPolicy struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	timeout  time.Duration
+}
+
+// WASMPolicyOption configures a WASM admission policy.
+type WASMPolicyOption func(*wasmPolicyConfig)
+
+type wasmPolicyConfig struct {
+	timeout     time.Duration
+	memoryLimit uint64
+}
+
+// WithPolicyTimeout bounds how long a single decision may run.
+func WithPolicyTimeout(d time.Duration) WASMPolicyOption {
+	return func(c *wasmPolicyConfig) {
+		c.timeout = d
+	}
+}
+
+// WithPolicyMemoryLimit limits the memory a policy module may grow to, in bytes.
+func WithPolicyMemoryLimit(bytes uint64) WASMPolicyOption {
+	return func(c *wasmPolicyConfig) {
+		c.memoryLimit = bytes
+	}
+}
+
+// NewWASMPolicy compiles a WASM admission policy module.
+func NewWASMPolicy(ctx context.Context, wasm []byte, opts ...WASMPolicyOption) (*WASMPolicy, error) {
+	cfg := wasmPolicyConfig{timeout: DefaultPolicyTimeout, memoryLimit: DefaultPolicyMemoryLimit}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	pages := max(cfg.memoryLimit/wasmPageSize, 1)
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(uint32(min(pages, 65536))))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to set up WASI: %w", err)
+	}
+	compiled, err := runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to compile admission policy: %w", err)
+	}
+	if err := checkPolicyExports(compiled); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	return &WASMPolicy{runtime: runtime, compiled: compiled, timeout: cfg.timeout}, nil
+}
+
+// LoadWASMPolicy compiles the WASM admission policy module at path.
+func LoadWASMPolicy(ctx context.Context, path string, opts ...WASMPolicyOption) (*WASMPolicy, error) {
+	wasm, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admission policy: %w", err)
+	}
+	return NewWASMPolicy(ctx, wasm, opts...)
+}
+
+// checkPolicyExports checks that the module implements the policy ABI.
+func checkPolicyExports(compiled wazero.CompiledModule) error {
+	if len(compiled.ExportedMemories()) == 0 {
+		return fmt.Errorf("admission policy does not export its memory")
+	}
+	funcs := compiled.ExportedFunctions()
+	for name, want := range map[string]struct{ params, results []api.ValueType }{
+		policyAllocExport: {[]api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}},
+		policyAdmitExport: {[]api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}},
+	} {
+		fn, ok := funcs[name]
+		if !ok {
+			return fmt.Errorf("admission policy does not export %s", name)
+		}
+		if string(fn.ParamTypes()) != string(want.params) || string(fn.ResultTypes()) != string(want.results) {
+			return fmt.Errorf("admission policy export %s has the wrong signature", name)
+		}
+	}
+	return nil
+}
+
+// Admit runs the policy module on the input.
+func (p *WASMPolicy) Admit(ctx context.Context, input AdmissionInput) (AdmissionResult, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return AdmissionResult{}, fmt.Errorf("failed to encode admission input: %w", err)
+	}
+	out, err := p.invoke(ctx, payload)
+	if err != nil {
+		return AdmissionResult{}, err
+	}
+
+	var result AdmissionResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return AdmissionResult{}, fmt.Errorf("admission policy returned invalid JSON: %w", err)
+	}
+	if !result.Decision.Valid() {
+		return AdmissionResult{}, fmt.Errorf("admission policy returned unknown decision %q", result.Decision)
+	}
+	return result, nil
+}
+
+// invoke passes payload to a fresh instance of the module and returns the
+// bytes its admit function points at.
+func (p *WASMPolicy) invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	mod, err := p.runtime.InstantiateModule(ctx, p.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate admission policy: %w", policyError(ctx, err))
+	}
+	defer mod.Close(context.Background())
+
+	size := uint64(len(payload))
+	results, err := mod.ExportedFunction(policyAllocExport).Call(ctx, size)
+	if err != nil {
+		return nil, fmt.Errorf("admission policy alloc failed: %w", policyError(ctx, err))
+	}
+	ptr := uint32(results[0])
+	if !mod.Memory().Write(ptr, payload) {
+		return nil, fmt.Errorf("admission policy alloc returned %d bytes out of bounds", size)
+	}
+
+	results, err = mod.ExportedFunction(policyAdmitExport).Call(ctx, uint64(ptr), size)
+	if err != nil {
+		return nil, fmt.Errorf("admission policy failed: %w", policyError(ctx, err))
+	}
+	outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+	out, ok := mod.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("admission policy result is out of bounds")
+	}
+	// The memory goes away with the instance
+	return append([]byte(nil), out...), nil
+}
+
+// policyError reports a decision cut short by its deadline as such.
+func policyError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out: %w", context.DeadlineExceeded)
+	}
+	return err
+}
+
+// Close releases the compiled module.
+func (p *WASMPolicy) Close(ctx context.Context) error {
+	return p.runtime.Close(ctx)
+}
+
+// admit runs the submission past the admission policy.
+func (e *Endpoint) admit(ctx context.Context, req GlobalJobRequest, capacity *GlobalResources) (AdmissionResult, error) {
+	result, err := e.admission.Admit(ctx, AdmissionInput{Request: req, Capacity: capacity, Time: time.Now()})
+	if err != nil {
+		admissionDecisions.Add(ctx, 1, metric.WithAttributes(AttrDecisionKey.String(AttrDecisionError)))
+		return result, fmt.Errorf("admission policy failed: %w", err)
+	}
+	admissionDecisions.Add(ctx, 1, metric.WithAttributes(AttrDecisionKey.String(string(result.Decision))))
+	componentLogger(ctx, ComponentAdmission).Debug().
+		Str("jobID", req.Job.ID).
+		Str("decision", string(result.Decision)).
+		Str("reason", result.Reason).
+		Msg("Admission decision")
+	return result, nil
+}
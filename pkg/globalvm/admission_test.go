@@ -0,0 +1,227 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+)
+
+// policyInputOffset is where the test modules' alloc places the input.
+const policyInputOffset = 1024
+
+// policyModule assembles a WASM admission policy whose admit function has
+// the given body. data is placed at address 0, and alloc always returns
+// policyInputOffset.
+func policyModule(data string, admitBody ...byte) []byte {
+	section := func(id byte, content ...byte) []byte {
+		return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+	}
+	name := func(s string) []byte { return append(uleb(uint64(len(s))), s...) }
+	body := func(code ...byte) []byte { return append(uleb(uint64(len(code)+1)), append([]byte{0}, code...)...) }
+	const i32, i64 = 0x7f, 0x7e
+
+	module := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, 2, 0x60, 1, i32, 1, i32, 0x60, 2, i32, i32, 1, i64)...)
+	module = append(module, section(3, 2, 0, 1)...)
+	module = append(module, section(5, 1, 0x00, 1)...)
+
+	exports := []byte{3}
+	exports = append(append(exports, name("memory")...), 0x02, 0)
+	exports = append(append(exports, name(policyAllocExport)...), 0x00, 0)
+	exports = append(append(exports, name(policyAdmitExport)...), 0x00, 1)
+	module = append(module, section(7, exports...)...)
+
+	code := []byte{2}
+	code = append(code, body(append(append([]byte{0x41}, sleb(policyInputOffset)...), 0x0b)...)...)
+	code = append(code, body(admitBody...)...)
+	module = append(module, section(10, code...)...)
+
+	segment := append([]byte{1, 0x00, 0x41, 0x00, 0x0b}, name(data)...)
+	return append(module, section(11, segment...)...)
+}
+
+// returnSlice is an admit body returning the bytes at [ptr, ptr+n).
+func returnSlice(ptr, n int) []byte {
+	return append(append([]byte{0x42}, sleb(int64(ptr)<<32|int64(n))...), 0x0b)
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// constantPolicy compiles a policy that always returns result.
+func constantPolicy(t *testing.T, result string) *WASMPolicy {
+	t.Helper()
+	policy, err := NewWASMPolicy(context.Background(), policyModule(result, returnSlice(0, len(result))...))
+	require.NoError(t, err)
+	t.Cleanup(func() { policy.Close(context.Background()) })
+	return policy
+}
+
+func TestWASMPolicy_Admit(t *testing.T) {
+	ctx := context.Background()
+	input := AdmissionInput{
+		Request:  GlobalJobRequest{Job: createTestJob("job-1", models.JobTypeBatch, 1), ClientID: "tenant-a"},
+		Capacity: &GlobalResources{AvailableCPU: 8, TotalNodes: 2},
+		Time:     time.Date(2026, 3, 2, 22, 0, 0, 0, time.UTC),
+	}
+
+	result, err := constantPolicy(t, `{"Decision":"deny","Reason":"outside business hours"}`).Admit(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, AdmissionResult{Decision: AdmissionDeny, Reason: "outside business hours"}, result)
+
+	// The module receives the input as JSON at the address alloc returned
+	echo, err := NewWASMPolicy(ctx, policyModule("",
+		0x20, 0x00, 0xad, 0x42, 32, 0x86, // i64(ptr) << 32
+		0x20, 0x01, 0xad, 0x84, 0x0b, // | i64(len)
+	))
+	require.NoError(t, err)
+	defer echo.Close(ctx)
+	payload, err := json.Marshal(input)
+	require.NoError(t, err)
+	got, err := echo.invoke(ctx, payload)
+	require.NoError(t, err)
+	var decoded AdmissionInput
+	require.NoError(t, json.Unmarshal(got, &decoded))
+	assert.Equal(t, "tenant-a", decoded.Request.ClientID)
+	assert.Equal(t, 8.0, decoded.Capacity.AvailableCPU)
+	assert.True(t, input.Time.Equal(decoded.Time))
+}
+
+func TestWASMPolicy_Errors(t *testing.T) {
+	ctx := context.Background()
+	input := AdmissionInput{Request: GlobalJobRequest{Job: createTestJob("job-1", models.JobTypeBatch, 1)}}
+
+	for name, module := range map[string][]byte{
+		"unknown decision": policyModule(`{"Decision":"maybe"}`, returnSlice(0, 20)...),
+		"invalid JSON":     policyModule(`not json`, returnSlice(0, 8)...),
+		"out of bounds":    policyModule(`{}`, returnSlice(1<<20, 2)...),
+		"trap":             policyModule(`{}`, 0x00, 0x0b),
+	} {
+		policy, err := NewWASMPolicy(ctx, module)
+		require.NoError(t, err, name)
+		_, err = policy.Admit(ctx, input)
+		assert.Error(t, err, name)
+		policy.Close(ctx)
+	}
+
+	// A policy that never returns is stopped at the timeout
+	loop, err := NewWASMPolicy(ctx, policyModule(`{}`, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b),
+		WithPolicyTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+	defer loop.Close(ctx)
+	start := time.Now()
+	_, err = loop.Admit(ctx, input)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	_, err = NewWASMPolicy(ctx, []byte("not wasm"))
+	assert.Error(t, err)
+	_, err = NewWASMPolicy(ctx, []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00})
+	assert.ErrorContains(t, err, "does not export", "a module without the policy exports")
+}
+
+func TestLoadWASMPolicy(t *testing.T) {
+	result := `{"Decision":"admit"}`
+	path := filepath.Join(t.TempDir(), "policy.wasm")
+	require.NoError(t, os.WriteFile(path, policyModule(result, returnSlice(0, len(result))...), 0o644))
+
+	policy, err := LoadWASMPolicy(context.Background(), path)
+	require.NoError(t, err)
+	defer policy.Close(context.Background())
+	decision, err := policy.Admit(context.Background(), AdmissionInput{})
+	require.NoError(t, err)
+	assert.Equal(t, AdmissionAdmit, decision.Decision)
+
+	_, err = LoadWASMPolicy(context.Background(), filepath.Join(t.TempDir(), "missing.wasm"))
+	assert.Error(t, err)
+}
+
+// recordingPolicy returns a fixed result and keeps the inputs it saw.
+type recordingPolicy struct {
+	result AdmissionResult
+	err    error
+	inputs []AdmissionInput
+}
+
+func (p *recordingPolicy) Admit(_ context.Context, input AdmissionInput) (AdmissionResult, error) {
+	p.inputs = append(p.inputs, input)
+	return p.result, p.err
+}
+
+func TestEndpoint_SubmitJobAdmission(t *testing.T) {
+	newEndpoint := func(policy AdmissionPolicy) *Endpoint {
+		selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+			{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+		}}
+		capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+		return NewEndpoint(NewScheduler(selector, capacity), capacity, WithAdmissionPolicy(policy))
+	}
+	ctx := context.Background()
+	submit := func(e *Endpoint) (*GlobalJobResponse, error) {
+		return e.SubmitJob(ctx, GlobalJobRequest{Job: createTestJob("job-1", models.JobTypeBatch, 1), ClientID: "tenant-a"})
+	}
+
+	admit := &recordingPolicy{result: AdmissionResult{Decision: AdmissionAdmit}}
+	resp, err := submit(newEndpoint(admit))
+	require.NoError(t, err)
+	assert.Len(t, resp.AllocatedNodes, 1)
+	require.Len(t, admit.inputs, 1)
+	assert.Equal(t, "tenant-a", admit.inputs[0].Request.ClientID)
+	assert.Equal(t, 10.0, admit.inputs[0].Capacity.AvailableCPU)
+
+	_, err = submit(newEndpoint(&recordingPolicy{result: AdmissionResult{Decision: AdmissionDeny, Reason: "free tier limit"}}))
+	assert.ErrorIs(t, err, ErrAdmissionDenied)
+	assert.ErrorContains(t, err, "free tier limit")
+
+	endpoint := newEndpoint(&recordingPolicy{result: AdmissionResult{Decision: AdmissionQueue, Reason: "outside business hours"}})
+	resp, err = submit(endpoint)
+	require.NoError(t, err)
+	assert.Empty(t, resp.AllocatedNodes)
+	assert.Equal(t, 1, resp.QueuePosition)
+	assert.Contains(t, resp.Warnings, "Queued by admission policy: outside business hours")
+	placement, ok := endpoint.GetJobPlacement("job-1")
+	require.True(t, ok)
+	assert.True(t, placement.Queued)
+
+	failing := errors.New("policy crashed")
+	_, err = submit(newEndpoint(&recordingPolicy{err: failing}))
+	assert.ErrorIs(t, err, failing, "a failing policy fails the submission")
+
+	// End to end with a WASM policy
+	resp, err = submit(newEndpoint(constantPolicy(t, `{"Decision":"queue","Reason":"tier quota"}`)))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Warnings, "Queued by admission policy: tier quota")
+}
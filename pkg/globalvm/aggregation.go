@@ -0,0 +1,170 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultAggregationResultSize is the result size assumed per worker when
+// AggregationOptions.ResultSize is not set.
+const DefaultAggregationResultSize = 100 << 20
+
+// NodeRole is the part a selected node plays in a job.
+type NodeRole string
+
+const (
+	// NodeRoleWorker runs the job's task.
+	NodeRoleWorker NodeRole = "worker"
+
+	// NodeRoleAggregator collects and combines the workers' results.
+	NodeRoleAggregator NodeRole = "aggregator"
+
+	// NodeRoleStandby holds a replica ready to take over from the workers.
+	NodeRoleStandby NodeRole = "standby"
+
+	// NodeRoleCoordinator runs the job's task and coordinates the other
+	// workers.
+	NodeRoleCoordinator NodeRole = "coordinator"
+)
+
+// AggregationOptions requests an aggregation node for map-reduce style jobs.
+type AggregationOptions struct {
+	// ResultSize is the expected size in bytes of each worker's result.
+	// Zero uses DefaultAggregationResultSize.
+	ResultSize uint64 `json:"ResultSize,omitempty"`
+}
+
+// Aggregator returns the aggregation node of a selection set, if any.
+func Aggregator(selections []NodeSelection) (NodeSelection, bool) {
+	for _, sel := range selections {
+		if sel.Role == NodeRoleAggregator {
+			return sel, true
+		}
+	}
+	return NodeSelection{}, false
+}
+
+// WithLatencyMatrix sets the latency and bandwidth matrix used to place
+// aggregation nodes. Without one, region estimates are used.
+func WithLatencyMatrix(matrix LatencyMatrix) SchedulerOption {
+	return func(s *Scheduler) {
+		s.latencyMatrix = matrix
+	}
+}
+
+// TransferTime estimates how long moving size bytes between two nodes takes.
+// Measurements between the nodes themselves are preferred over measurements
+// between their regions.
+func (s *Scheduler) TransferTime(from, to NodeSelection, size uint64) time.Duration {
+	return s.TenantTransferTime("", from, to, size)
+}
+
+// TenantTransferTime is TransferTime for a tenant's job: the tenant's
+// latency overrides take precedence over the latency matrix, as
+// TenantLatencyOverrides describes.
+func (s *Scheduler) TenantTransferTime(tenantID string, from, to NodeSelection, size uint64) time.Duration {
+	if from.NodeID == to.NodeID {
+		return 0
+	}
+
+	var latency time.Duration
+	var bandwidth float64
+	if s.latencyMatrix != nil {
+		if measured, ok := s.latencyMatrix.GetAllLatencies(from.NodeID)[to.NodeID]; ok {
+			latency = measured
+			bandwidth = s.latencyMatrix.GetBandwidth(from.NodeID, to.NodeID)
+		} else {
+			latency = s.latencyMatrix.GetLatency(from.Region, to.Region)
+			bandwidth = s.latencyMatrix.GetBandwidth(from.Region, to.Region)
+		}
+	} else {
+		latency = EstimatedLatency(from.Region, to.Region)
+		bandwidth = EstimatedBandwidth(from.Region, to.Region)
+	}
+	if override, ok := s.tenantLatencies.Latency(tenantID, from, to); ok {
+		latency = override
+	}
+
+	if bandwidth <= 0 {
+		return latency
+	}
+	return latency + time.Duration(float64(size)/bandwidth*float64(time.Second))
+}
+
+// aggregationCost is the total time to move every worker's result to the candidate.
+func (s *Scheduler) aggregationCost(tenantID string, candidate NodeSelection, workers []NodeSelection, size uint64) time.Duration {
+	var total time.Duration
+	for _, worker := range workers {
+		total += s.TenantTransferTime(tenantID, worker, candidate, size)
+	}
+	return total
+}
+
+// selectAggregator picks the node outside the workers that minimizes the
+// total transfer of worker results, and appends it to the selection set.
+// With a leaser, candidates whose capacity cannot be leased are skipped.
+func (s *Scheduler) selectAggregator(
+	ctx context.Context, req GlobalSchedulingRequest, leaser CapacityLeaser,
+	workers []NodeSelection, candidates []NodeSelection,
+) ([]NodeSelection, error) {
+	size := req.Scheduling.Aggregation.ResultSize
+	if size == 0 {
+		size = DefaultAggregationResultSize
+	}
+
+	workers = append([]NodeSelection(nil), workers...)
+	used := make(map[string]bool, len(workers))
+	for i := range workers {
+		if workers[i].Role == "" {
+			workers[i].Role = NodeRoleWorker
+		}
+		used[workers[i].NodeID] = true
+	}
+
+	type scored struct {
+		sel  NodeSelection
+		cost time.Duration
+	}
+	var spare []scored
+	for _, sel := range candidates {
+		if !used[sel.NodeID] {
+			spare = append(spare, scored{sel: sel, cost: s.aggregationCost(req.TenantID, sel, workers, size)})
+		}
+	}
+	sort.SliceStable(spare, func(i, j int) bool {
+		return spare[i].cost < spare[j].cost
+	})
+
+	for _, cand := range spare {
+		sel := cand.sel
+		if leaser != nil {
+			resources, err := jobResources(req.Job)
+			if err != nil {
+				return workers, err
+			}
+			lease, err := leaser.AcquireLease(ctx, req.Job.ID, sel.NodeID, resources)
+			if err != nil {
+				if ctx.Err() != nil {
+					return workers, ctx.Err()
+				}
+				continue
+			}
+			sel.LeaseID = lease.ID
+		}
+
+		sel.Role = NodeRoleAggregator
+		sel.addReason(ReasonAggregation)
+		sel.Reason = fmt.Sprintf("aggregator: %s total result transfer from %d workers", cand.cost, len(workers))
+		return append(workers, sel), nil
+	}
+
+	componentLogger(ctx, ComponentScheduler).Debug().
+		Str("jobID", req.Job.ID).
+		Int("workers", len(workers)).
+		Msg("No spare node available for result aggregation")
+	return workers, nil
+}
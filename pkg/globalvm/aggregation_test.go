@@ -0,0 +1,125 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_TransferTime(t *testing.T) {
+	east1 := NodeSelection{NodeID: "east-1", Region: "us-east"}
+	east2 := NodeSelection{NodeID: "east-2", Region: "us-east"}
+	asia := NodeSelection{NodeID: "asia-1", Region: "asia-east"}
+
+	t.Run("uses region estimates without a matrix", func(t *testing.T) {
+		s := NewScheduler(nil, nil)
+		assert.Zero(t, s.TransferTime(east1, east1, 1<<30))
+		assert.Less(t, s.TransferTime(east1, east2, 100<<20), s.TransferTime(east1, asia, 100<<20))
+	})
+
+	t.Run("prefers node measurements over region measurements", func(t *testing.T) {
+		matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+		matrix.UpdateLatency("us-east", "asia-east", 200*time.Millisecond)
+		matrix.UpdateBandwidth("us-east", "asia-east", 10e6)
+		matrix.UpdateLatency("east-1", "asia-1", 50*time.Millisecond)
+		matrix.UpdateBandwidth("east-1", "asia-1", 100e6)
+		s := NewScheduler(nil, nil, WithLatencyMatrix(matrix))
+
+		assert.Equal(t, 50*time.Millisecond+time.Second, s.TransferTime(east1, asia, 100e6))
+		assert.Equal(t, 200*time.Millisecond+10*time.Second, s.TransferTime(east2, asia, 100e6))
+	})
+}
+
+func TestScheduler_SelectAggregator(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("worker-1", "eu-west"), Rank: 50},
+		{NodeInfo: createTestNodeInfo("worker-2", "eu-west"), Rank: 40},
+		{NodeInfo: createTestNodeInfo("worker-3", "eu-central"), Rank: 30},
+		{NodeInfo: createTestNodeInfo("asia-1", "asia-east"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("eu-1", "eu-west"), Rank: 10},
+	}}
+	job := createTestJob("mapreduce", models.JobTypeBatch, 3)
+	req := GlobalSchedulingRequest{
+		Job:         job,
+		TargetCount: 3,
+		Scheduling:  SchedulingOptions{Aggregation: &AggregationOptions{ResultSize: 1 << 30}},
+	}
+
+	t.Run("picks the node closest to the workers", func(t *testing.T) {
+		selections, err := NewScheduler(selector, nil).SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, selections, 4)
+
+		for _, sel := range selections[:3] {
+			assert.Equal(t, NodeRoleWorker, sel.Role)
+		}
+		aggregator, ok := Aggregator(selections)
+		require.True(t, ok)
+		assert.Equal(t, "eu-1", aggregator.NodeID, "lower ranked but next to the workers")
+	})
+
+	t.Run("measured bandwidth changes the placement", func(t *testing.T) {
+		matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+		for _, worker := range []string{"worker-1", "worker-2", "worker-3"} {
+			matrix.UpdateLatency(worker, "asia-1", time.Millisecond)
+			matrix.UpdateBandwidth(worker, "asia-1", 10e9)
+		}
+		scheduler := NewScheduler(selector, nil, WithLatencyMatrix(matrix))
+
+		selections, err := scheduler.SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		aggregator, ok := Aggregator(selections)
+		require.True(t, ok)
+		assert.Equal(t, "asia-1", aggregator.NodeID)
+	})
+
+	t.Run("no spare node", func(t *testing.T) {
+		small := &mockNodeSelector{nodes: selector.nodes[:3]}
+		selections, err := NewScheduler(small, nil).SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		assert.Len(t, selections, 3)
+		_, ok := Aggregator(selections)
+		assert.False(t, ok)
+	})
+
+	t.Run("without aggregation no roles are set", func(t *testing.T) {
+		plain := req
+		plain.Scheduling = SchedulingOptions{}
+		selections, err := NewScheduler(selector, nil).SelectNodes(context.Background(), plain)
+		require.NoError(t, err)
+		require.Len(t, selections, 3)
+		assert.Empty(t, selections[0].Role)
+	})
+}
+
+func TestScheduler_SelectAggregatorWithLeases(t *testing.T) {
+	a := newLeaseTestAggregator()
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("node-2", "us-east"), Rank: 10},
+	}}
+	job := createTestJob("job-1", models.JobTypeBatch, 1)
+	job.Tasks[0].ResourcesConfig = &models.ResourcesConfig{CPU: "4", Memory: "8GiB"}
+
+	selections, err := NewScheduler(selector, a).SelectNodes(context.Background(), GlobalSchedulingRequest{
+		Job:           job,
+		TargetCount:   1,
+		LeaseCapacity: true,
+		Scheduling:    SchedulingOptions{Aggregation: &AggregationOptions{}},
+	})
+	require.NoError(t, err)
+	require.Len(t, selections, 2)
+
+	aggregator, ok := Aggregator(selections)
+	require.True(t, ok)
+	assert.Equal(t, "node-2", aggregator.NodeID)
+	assert.NotEmpty(t, aggregator.LeaseID)
+	assert.Len(t, a.ActiveLeases(context.Background()), 2)
+}
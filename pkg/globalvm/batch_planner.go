@@ -0,0 +1,528 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+const (
+	// DefaultBatchSlot is the granularity of a batch plan: deferred jobs
+	// are admitted at the start of a slot.
+	DefaultBatchSlot = 15 * time.Minute
+
+	// DefaultBatchHorizon is how far ahead a batch is planned. Jobs that
+	// do not fit within it are rejected.
+	DefaultBatchHorizon = 24 * time.Hour
+
+	// MaxBatchHorizon bounds the horizon a batch may ask for.
+	MaxBatchHorizon = 7 * 24 * time.Hour
+
+	// DefaultBatchJobDuration is how long a job is assumed to hold its
+	// resources when its task sets no execution timeout.
+	DefaultBatchJobDuration = time.Hour
+
+	// DefaultAdmissionQueueInterval is how often RunAdmissionQueue looks
+	// for deferred jobs that have fallen due.
+	DefaultAdmissionQueueInterval = 30 * time.Second
+)
+
+// BatchAction is what a batch plan does with one of its jobs.
+type BatchAction string
+
+const (
+	// BatchAdmitNow submits the job with the batch.
+	BatchAdmitNow BatchAction = "admit-now"
+
+	// BatchAdmitLater defers the job to the admission queue until its
+	// AdmitAt time.
+	BatchAdmitLater BatchAction = "admit-later"
+
+	// BatchReject turns the job down.
+	BatchReject BatchAction = "reject"
+)
+
+// BatchJob is one job of a batch submission.
+type BatchJob struct {
+	// Request is the job submission. Its ClientID is replaced by the batch's.
+	Request GlobalJobRequest `json:"Request"`
+
+	// Deadline is the latest time the job may be admitted. Zero lets it
+	// wait until the end of the batch's horizon.
+	Deadline time.Time `json:"Deadline,omitempty"`
+}
+
+// BatchRequest submits many jobs at once. Instead of admitting them
+// greedily, the endpoint plans when each is admitted so that the batch
+// stays within the tenant's quota and the forecast capacity, and the jobs
+// with the nearest deadlines go first.
+type BatchRequest struct {
+	// ClientID identifies the tenant, whose quota the batch is planned
+	// against.
+	ClientID string `json:"ClientID,omitempty"`
+
+	// Jobs are the jobs to admit.
+	Jobs []BatchJob `json:"Jobs"`
+
+	// Horizon is how far ahead to plan. Zero uses DefaultBatchHorizon.
+	Horizon time.Duration `json:"Horizon,omitempty"`
+}
+
+// BatchPlanEntry is the plan for one job of a batch.
+type BatchPlanEntry struct {
+	// JobID is the job the entry is for.
+	JobID string `json:"JobID"`
+
+	// Action is what the plan does with the job.
+	Action BatchAction `json:"Action"`
+
+	// AdmitAt is when the job is admitted, unless it is rejected.
+	AdmitAt time.Time `json:"AdmitAt,omitempty"`
+
+	// Reason explains a deferral or rejection.
+	Reason string `json:"Reason,omitempty"`
+
+	// Response is the submission result of a job admitted now.
+	Response *GlobalJobResponse `json:"Response,omitempty"`
+
+	// Error is why a job admitted now or deferred failed to be submitted
+	// or queued.
+	Error string `json:"Error,omitempty"`
+}
+
+// BatchResponse is the schedule a batch was planned into, with one entry
+// per job in the order they were submitted.
+type BatchResponse struct {
+	// BatchID identifies the batch in the admission queue.
+	BatchID string `json:"BatchID"`
+
+	// Plan holds the plan for each job.
+	Plan []BatchPlanEntry `json:"Plan"`
+
+	// AdmittedNow, Deferred and Rejected count the plan's actions.
+	AdmittedNow int `json:"AdmittedNow"`
+	Deferred    int `json:"Deferred"`
+	Rejected    int `json:"Rejected"`
+}
+
+// TenantQuota limits what a tenant's batch may admit. Zero fields are
+// unlimited. CPU and GPU limits bound what the jobs of one batch hold at
+// once.
+type TenantQuota struct {
+	// MaxJobsPerHour caps how many jobs are admitted in each hour of the plan.
+	MaxJobsPerHour int `json:"MaxJobsPerHour,omitempty"`
+
+	// MaxCPU caps the CPU cores the batch's jobs hold at once.
+	MaxCPU float64 `json:"MaxCPU,omitempty"`
+
+	// MaxGPU caps the GPUs the batch's jobs hold at once.
+	MaxGPU int `json:"MaxGPU,omitempty"`
+}
+
+// QuotaProvider returns the quota of a tenant.
+type QuotaProvider interface {
+	Quota(tenantID string) TenantQuota
+}
+
+// StaticQuotas is a fixed quota per tenant. The quota under the empty
+// tenant ID applies to tenants without one of their own.
+type StaticQuotas map[string]TenantQuota
+
+// Quota returns the tenant's quota.
+func (q StaticQuotas) Quota(tenantID string) TenantQuota {
+	if quota, ok := q[tenantID]; ok {
+		return quota
+	}
+	return q[""]
+}
+
+// WithBatchQuotas plans batches against the tenants' quotas. Without it
+// batches are only bound by capacity.
+func WithBatchQuotas(quotas QuotaProvider) EndpointOption {
+	return func(e *Endpoint) {
+		e.quotas = quotas
+	}
+}
+
+// SubmitBatch plans the admission of a batch of jobs over time and
+// carries the plan out: jobs admitted now are submitted as by SubmitJob,
+// deferred ones are put in the admission queue for AdmitDueJobs, and
+// rejected ones are left out. Jobs that fail validation are rejected
+// without failing the batch.
+func (e *Endpoint) SubmitBatch(ctx context.Context, req BatchRequest) (*BatchResponse, error) {
+	if e.replicator != nil && !e.replicator.IsLeader() {
+		return nil, ErrNotLeader
+	}
+	if len(req.Jobs) == 0 {
+		return nil, fmt.Errorf("batch has no jobs")
+	}
+	horizon := req.Horizon
+	if horizon == 0 {
+		horizon = DefaultBatchHorizon
+	}
+	if horizon < DefaultBatchSlot || horizon > MaxBatchHorizon {
+		return nil, fmt.Errorf("batch horizon must be between %s and %s, got %s", DefaultBatchSlot, MaxBatchHorizon, horizon)
+	}
+
+	now := time.Now()
+	forecasts, err := e.forecastCapacity(ctx, horizon)
+	if err != nil {
+		return nil, err
+	}
+	var quota TenantQuota
+	if e.quotas != nil {
+		quota = e.quotas.Quota(req.ClientID)
+	}
+
+	jobs := make([]BatchJob, len(req.Jobs))
+	for i, job := range req.Jobs {
+		job.Request.ClientID = req.ClientID
+		jobs[i] = job
+	}
+	plan := planBatch(jobs, forecasts, quota, now)
+
+	resp := &BatchResponse{BatchID: uuid.NewString(), Plan: plan}
+	for i := range plan {
+		entry := &plan[i]
+		switch entry.Action {
+		case BatchAdmitNow:
+			resp.AdmittedNow++
+			submitted, err := e.SubmitJob(ctx, jobs[i].Request)
+			if err != nil {
+				entry.Error = err.Error()
+				continue
+			}
+			entry.Response = submitted
+		case BatchAdmitLater:
+			resp.Deferred++
+			err := e.deferJob(ctx, QueuedJob{
+				JobID: entry.JobID, Request: jobs[i].Request, BatchID: resp.BatchID, AdmitAt: entry.AdmitAt,
+			})
+			if err != nil {
+				entry.Error = err.Error()
+			}
+		case BatchReject:
+			resp.Rejected++
+		}
+	}
+
+	componentLogger(ctx, ComponentAdmission).Info().
+		Str("batchID", resp.BatchID).
+		Str("clientID", req.ClientID).
+		Int("admittedNow", resp.AdmittedNow).
+		Int("deferred", resp.Deferred).
+		Int("rejected", resp.Rejected).
+		Msg("Planned batch admission")
+	return resp, nil
+}
+
+// forecastCapacity returns the capacity forecast for each slot of the
+// horizon, the first being the capacity available now.
+func (e *Endpoint) forecastCapacity(ctx context.Context, horizon time.Duration) ([]GlobalResources, error) {
+	slots := int((horizon + DefaultBatchSlot - 1) / DefaultBatchSlot)
+	forecasts := make([]GlobalResources, slots)
+
+	current, err := e.capacityProvider.GetAvailableCapacity(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check capacity: %w", err)
+	}
+	forecasts[0] = *current
+	for i := 1; i < slots; i++ {
+		predicted, err := e.capacityProvider.PredictCapacity(ctx, time.Duration(i)*DefaultBatchSlot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to forecast capacity: %w", err)
+		}
+		forecasts[i] = *predicted
+	}
+	return forecasts, nil
+}
+
+// batchDemand is what a job of a batch holds while it runs.
+type batchDemand struct {
+	cpu    float64
+	memory uint64
+	gpu    int
+	// Slots the job runs for
+	slots int
+}
+
+// batchJobDemand returns what the job holds, across all its nodes, and
+// for how many slots.
+func batchJobDemand(job *models.Job) (batchDemand, error) {
+	resources, err := jobResources(job)
+	if err != nil {
+		return batchDemand{}, err
+	}
+	count := max(job.Count, 1)
+	duration := DefaultBatchJobDuration
+	if task := job.Task(); task != nil && task.Timeouts != nil && task.Timeouts.ExecutionTimeout > 0 {
+		duration = time.Duration(task.Timeouts.ExecutionTimeout) * time.Second
+	}
+	return batchDemand{
+		cpu:    resources.CPU * float64(count),
+		memory: resources.Memory * uint64(count),
+		gpu:    int(resources.GPU) * count,
+		slots:  int((duration + DefaultBatchSlot - 1) / DefaultBatchSlot),
+	}, nil
+}
+
+// batchLedger tracks what the planned jobs hold in each slot of the plan.
+type batchLedger struct {
+	forecasts []GlobalResources
+	quota     TenantQuota
+	cpu       []float64
+	memory    []uint64
+	gpu       []int
+	// Jobs admitted in each hour of the plan
+	admitted map[int]int
+}
+
+func newBatchLedger(forecasts []GlobalResources, quota TenantQuota) *batchLedger {
+	return &batchLedger{
+		forecasts: forecasts,
+		quota:     quota,
+		cpu:       make([]float64, len(forecasts)),
+		memory:    make([]uint64, len(forecasts)),
+		gpu:       make([]int, len(forecasts)),
+		admitted:  make(map[int]int),
+	}
+}
+
+// hour returns the hour of the plan a slot starts in.
+func (l *batchLedger) hour(slot int) int {
+	return int(time.Duration(slot) * DefaultBatchSlot / time.Hour)
+}
+
+// fits reports whether a job admitted at slot stays within the forecast
+// capacity and the quota for as long as it runs, or until the horizon.
+func (l *batchLedger) fits(slot int, d batchDemand) bool {
+	if limit := l.quota.MaxJobsPerHour; limit > 0 && l.admitted[l.hour(slot)] >= limit {
+		return false
+	}
+	for i := slot; i < min(slot+d.slots, len(l.forecasts)); i++ {
+		forecast := l.forecasts[i]
+		switch {
+		case l.cpu[i]+d.cpu > forecast.AvailableCPU,
+			l.memory[i]+d.memory > forecast.AvailableMemory,
+			l.gpu[i]+d.gpu > forecast.AvailableGPU,
+			l.quota.MaxCPU > 0 && l.cpu[i]+d.cpu > l.quota.MaxCPU,
+			l.quota.MaxGPU > 0 && l.gpu[i]+d.gpu > l.quota.MaxGPU:
+			return false
+		}
+	}
+	return true
+}
+
+// take records a job admitted at slot.
+func (l *batchLedger) take(slot int, d batchDemand) {
+	l.admitted[l.hour(slot)]++
+	for i := slot; i < min(slot+d.slots, len(l.forecasts)); i++ {
+		l.cpu[i] += d.cpu
+		l.memory[i] += d.memory
+		l.gpu[i] += d.gpu
+	}
+}
+
+// overQuota explains why a job can never fit the quota, or returns "".
+func (l *batchLedger) overQuota(d batchDemand) string {
+	switch {
+	case l.quota.MaxCPU > 0 && d.cpu > l.quota.MaxCPU:
+		return fmt.Sprintf("needs %g CPU, more than the tenant quota of %g", d.cpu, l.quota.MaxCPU)
+	case l.quota.MaxGPU > 0 && d.gpu > l.quota.MaxGPU:
+		return fmt.Sprintf("needs %d GPUs, more than the tenant quota of %d", d.gpu, l.quota.MaxGPU)
+	}
+	return ""
+}
+
+// planBatch decides when each job of a batch is admitted, given the
+// capacity forecast for each slot from now. Jobs are planned earliest
+// deadline first, then by priority boost, then in submission order, each
+// into the first slot where it fits. The plan is returned in submission
+// order.
+func planBatch(jobs []BatchJob, forecasts []GlobalResources, quota TenantQuota, now time.Time) []BatchPlanEntry {
+	plan := make([]BatchPlanEntry, len(jobs))
+	order := make([]int, 0, len(jobs))
+	seen := make(map[string]bool, len(jobs))
+	for i, job := range jobs {
+		plan[i] = BatchPlanEntry{Action: BatchReject}
+		if job.Request.Job == nil {
+			plan[i].Reason = "job is missing"
+			continue
+		}
+		plan[i].JobID = job.Request.Job.ID
+		if err := job.Request.Job.Validate(); err != nil {
+			plan[i].Reason = fmt.Sprintf("job validation failed: %v", err)
+			continue
+		}
+		if seen[job.Request.Job.ID] {
+			plan[i].Reason = "job ID appears earlier in the batch"
+			continue
+		}
+		seen[job.Request.Job.ID] = true
+		order = append(order, i)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ja, jb := jobs[order[a]], jobs[order[b]]
+		switch {
+		case !ja.Deadline.Equal(jb.Deadline):
+			// Jobs without a deadline go last
+			return !ja.Deadline.IsZero() && (jb.Deadline.IsZero() || ja.Deadline.Before(jb.Deadline))
+		default:
+			return ja.Request.PriorityBoost > jb.Request.PriorityBoost
+		}
+	})
+
+	ledger := newBatchLedger(forecasts, quota)
+	for _, i := range order {
+		job, entry := jobs[i], &plan[i]
+		demand, err := batchJobDemand(job.Request.Job)
+		if err != nil {
+			entry.Reason = err.Error()
+			continue
+		}
+		if reason := ledger.overQuota(demand); reason != "" {
+			entry.Reason = reason
+			continue
+		}
+
+		last := len(forecasts) - 1
+		if !job.Deadline.IsZero() {
+			if job.Deadline.Before(now) {
+				entry.Reason = "deadline has passed"
+				continue
+			}
+			last = min(last, int(job.Deadline.Sub(now)/DefaultBatchSlot))
+		}
+		slot := -1
+		for s := 0; s <= last; s++ {
+			if ledger.fits(s, demand) {
+				slot = s
+				break
+			}
+		}
+		switch {
+		case slot < 0 && last < len(forecasts)-1:
+			entry.Reason = "no capacity or quota left before its deadline"
+		case slot < 0:
+			entry.Reason = fmt.Sprintf("no capacity or quota left within the %s horizon", time.Duration(len(forecasts))*DefaultBatchSlot)
+		case slot == 0:
+			ledger.take(slot, demand)
+			entry.Action = BatchAdmitNow
+			entry.AdmitAt = now
+		default:
+			ledger.take(slot, demand)
+			entry.Action = BatchAdmitLater
+			entry.AdmitAt = now.Add(time.Duration(slot) * DefaultBatchSlot)
+			entry.Reason = "capacity or quota is taken by earlier jobs until then"
+		}
+	}
+	return plan
+}
+
+// deferJob puts a job in the admission queue until its AdmitAt time. With
+// a replicator the queue survives failover.
+func (e *Endpoint) deferJob(ctx context.Context, job QueuedJob) error {
+	if e.replicator != nil {
+		if _, err := e.replicator.EnqueueJob(ctx, job); err != nil {
+			return fmt.Errorf("failed to queue job: %w", err)
+		}
+		return nil
+	}
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+	e.deferredMu.Lock()
+	defer e.deferredMu.Unlock()
+	e.deferred = append(e.deferred, job)
+	return nil
+}
+
+// takeDueJobs removes the deferred jobs whose AdmitAt time has come from
+// the admission queue. Jobs queued for capacity, without an AdmitAt time,
+// are left alone.
+func (e *Endpoint) takeDueJobs(ctx context.Context, now time.Time) ([]QueuedJob, error) {
+	due := func(job QueuedJob) bool {
+		return !job.AdmitAt.IsZero() && !job.AdmitAt.After(now)
+	}
+
+	if e.replicator != nil {
+		var taken []QueuedJob
+		for _, job := range e.replicator.State().Snapshot().Queue {
+			if !due(job) {
+				continue
+			}
+			if err := e.replicator.DequeueJob(ctx, job.JobID); err != nil {
+				return taken, fmt.Errorf("failed to dequeue job %s: %w", job.JobID, err)
+			}
+			taken = append(taken, job)
+		}
+		return taken, nil
+	}
+
+	e.deferredMu.Lock()
+	defer e.deferredMu.Unlock()
+	var taken []QueuedJob
+	kept := e.deferred[:0]
+	for _, job := range e.deferred {
+		if due(job) {
+			taken = append(taken, job)
+		} else {
+			kept = append(kept, job)
+		}
+	}
+	e.deferred = kept
+	return taken, nil
+}
+
+// AdmitDueJobs submits the deferred batch jobs whose time has come and
+// returns how many were submitted. A job that fails to submit is logged
+// and dropped rather than retried. A standby admits nothing.
+func (e *Endpoint) AdmitDueJobs(ctx context.Context) (int, error) {
+	if e.replicator != nil && !e.replicator.IsLeader() {
+		return 0, nil
+	}
+
+	jobs, err := e.takeDueJobs(ctx, time.Now())
+	submitted := 0
+	for _, job := range jobs {
+		if _, err := e.SubmitJob(ctx, job.Request); err != nil {
+			componentLogger(ctx, ComponentAdmission).Warn().
+				Err(err).
+				Str("jobID", job.JobID).
+				Str("batchID", job.BatchID).
+				Msg("Failed to admit deferred batch job")
+			continue
+		}
+		submitted++
+	}
+	return submitted, err
+}
+
+// RunAdmissionQueue admits deferred batch jobs as they fall due, checking
+// every interval until ctx is done. Zero uses
+// DefaultAdmissionQueueInterval.
+func (e *Endpoint) RunAdmissionQueue(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultAdmissionQueueInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := e.AdmitDueJobs(ctx); err != nil {
+				componentLogger(ctx, ComponentAdmission).Warn().Err(err).Msg("Failed to take due jobs from the admission queue")
+			}
+		}
+	}
+}
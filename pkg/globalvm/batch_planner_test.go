@@ -0,0 +1,193 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+)
+
+// batchTestJob is a job asking for cpu cores that runs for one slot.
+func batchTestJob(id string, cpu string) BatchJob {
+	job := createTestJob(id, models.JobTypeBatch, 1)
+	job.Tasks[0].ResourcesConfig = &models.ResourcesConfig{CPU: cpu}
+	job.Tasks[0].Timeouts = &models.TimeoutConfig{ExecutionTimeout: int64(DefaultBatchSlot / time.Second)}
+	return BatchJob{Request: GlobalJobRequest{Job: job}}
+}
+
+// flatForecast is a forecast of the same capacity for each of slots slots.
+func flatForecast(slots int, cpu float64) []GlobalResources {
+	forecasts := make([]GlobalResources, slots)
+	for i := range forecasts {
+		forecasts[i] = GlobalResources{AvailableCPU: cpu, AvailableMemory: 1 << 40}
+	}
+	return forecasts
+}
+
+func planSummary(plan []BatchPlanEntry, now time.Time) map[string]string {
+	summary := make(map[string]string, len(plan))
+	for _, entry := range plan {
+		switch entry.Action {
+		case BatchReject:
+			summary[entry.JobID] = "reject"
+		default:
+			summary[entry.JobID] = entry.AdmitAt.Sub(now).String()
+		}
+	}
+	return summary
+}
+
+func TestPlanBatch_SpreadsOverForecast(t *testing.T) {
+	now := time.Now()
+	urgent := batchTestJob("urgent", "4")
+	urgent.Deadline = now.Add(10 * time.Minute)
+	late := batchTestJob("late", "4")
+	late.Deadline = now.Add(20 * time.Minute)
+	boosted := batchTestJob("boosted", "4")
+	boosted.Request.PriorityBoost = 5
+	jobs := []BatchJob{batchTestJob("first", "4"), late, batchTestJob("second", "4"), urgent, boosted}
+
+	// Slot 1 is forecast to have less room
+	forecasts := flatForecast(3, 8)
+	forecasts[1].AvailableCPU = 4
+
+	plan := planBatch(jobs, forecasts, TenantQuota{}, now)
+	require.Len(t, plan, len(jobs))
+	assert.Equal(t, map[string]string{
+		"urgent":  "0s",
+		"late":    "0s",
+		"boosted": "15m0s",
+		"first":   "30m0s",
+		"second":  "30m0s",
+	}, planSummary(plan, now))
+	assert.Equal(t, BatchAdmitNow, plan[3].Action)
+	assert.Equal(t, BatchAdmitLater, plan[4].Action)
+	assert.NotEmpty(t, plan[4].Reason)
+}
+
+func TestPlanBatch_Rejections(t *testing.T) {
+	now := time.Now()
+	missed := batchTestJob("missed", "1")
+	missed.Deadline = now.Add(-time.Minute)
+	tight := batchTestJob("tight", "4")
+	tight.Deadline = now.Add(5 * time.Minute)
+	jobs := []BatchJob{
+		batchTestJob("big", "4"),
+		tight,
+		missed,
+		batchTestJob("big", "1"),
+		batchTestJob("huge", "16"),
+		{Request: GlobalJobRequest{}},
+	}
+
+	plan := planBatch(jobs, flatForecast(2, 4), TenantQuota{MaxCPU: 8}, now)
+	assert.Equal(t, BatchAdmitLater, plan[0].Action, "the deadline job takes slot 0")
+	assert.Equal(t, BatchAdmitNow, plan[1].Action)
+	assert.Equal(t, "deadline has passed", plan[2].Reason)
+	assert.Equal(t, "job ID appears earlier in the batch", plan[3].Reason)
+	assert.Contains(t, plan[4].Reason, "more than the tenant quota")
+	assert.Equal(t, "job is missing", plan[5].Reason)
+	for _, entry := range plan[2:] {
+		assert.Equal(t, BatchReject, entry.Action)
+	}
+
+	// Nothing fits before the deadline once capacity runs out
+	other := batchTestJob("other", "4")
+	other.Deadline = now.Add(5 * time.Minute)
+	plan = planBatch([]BatchJob{tight, other}, flatForecast(2, 4), TenantQuota{}, now)
+	assert.Equal(t, BatchAdmitNow, plan[0].Action)
+	assert.Equal(t, "no capacity or quota left before its deadline", plan[1].Reason)
+}
+
+func TestPlanBatch_Quota(t *testing.T) {
+	now := time.Now()
+	var jobs []BatchJob
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		jobs = append(jobs, batchTestJob(id, "1"))
+	}
+
+	// Two jobs an hour: the rest wait for the next hour or miss the horizon
+	plan := planBatch(jobs, flatForecast(8, 100), TenantQuota{MaxJobsPerHour: 2}, now)
+	assert.Equal(t, map[string]string{"a": "0s", "b": "0s", "c": "1h0m0s", "d": "1h0m0s", "e": "reject"}, planSummary(plan, now))
+	assert.Contains(t, plan[4].Reason, "2h0m0s horizon")
+
+	// CPU quota holds the jobs back like capacity does
+	plan = planBatch(jobs[:3], flatForecast(2, 100), TenantQuota{MaxCPU: 2}, now)
+	assert.Equal(t, map[string]string{"a": "0s", "b": "0s", "c": "15m0s"}, planSummary(plan, now))
+}
+
+func newBatchTestEndpoint(opts ...EndpointOption) *Endpoint {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{
+		AvailableCPU: 8, AvailableMemory: 1 << 40, HealthyNodes: 2,
+	}}
+	return NewEndpoint(NewScheduler(selector, capacity), capacity, opts...)
+}
+
+func TestEndpoint_SubmitBatch(t *testing.T) {
+	ctx := context.Background()
+	endpoint := newBatchTestEndpoint(WithBatchQuotas(StaticQuotas{"": {MaxGPU: 1}, "tenant-a": {MaxCPU: 4}}))
+
+	resp, err := endpoint.SubmitBatch(ctx, BatchRequest{
+		ClientID: "tenant-a",
+		Jobs:     []BatchJob{batchTestJob("job-1", "4"), batchTestJob("job-2", "4")},
+		Horizon:  time.Hour,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.BatchID)
+	assert.Equal(t, 1, resp.AdmittedNow)
+	assert.Equal(t, 1, resp.Deferred)
+	require.NotNil(t, resp.Plan[0].Response, resp.Plan[0].Error)
+	assert.Equal(t, "job-1", resp.Plan[0].Response.JobID)
+	assert.Equal(t, BatchAdmitLater, resp.Plan[1].Action)
+
+	// The deferred job waits in the admission queue until it is due
+	submitted, err := endpoint.AdmitDueJobs(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, submitted)
+	require.Len(t, endpoint.deferred, 1)
+	assert.Equal(t, resp.BatchID, endpoint.deferred[0].BatchID)
+	assert.Equal(t, "tenant-a", endpoint.deferred[0].Request.ClientID)
+
+	endpoint.deferred[0].AdmitAt = time.Now().Add(-time.Second)
+	submitted, err = endpoint.AdmitDueJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, submitted)
+	assert.Empty(t, endpoint.deferred)
+	_, ok := endpoint.GetJobPlacement("job-2")
+	assert.True(t, ok)
+
+	_, err = endpoint.SubmitBatch(ctx, BatchRequest{Jobs: []BatchJob{batchTestJob("job-3", "1")}, Horizon: time.Minute})
+	assert.Error(t, err, "a horizon shorter than a slot is rejected")
+	_, err = endpoint.SubmitBatch(ctx, BatchRequest{})
+	assert.Error(t, err)
+}
+
+func TestEndpoint_SubmitBatch_ReplicatedQueue(t *testing.T) {
+	ctx := context.Background()
+	leader := NewReplicator(NewReplicatedState(), NewInMemoryTransport())
+	endpoint := newBatchTestEndpoint(WithReplicator(leader))
+
+	jobs := []BatchJob{batchTestJob("job-1", "8"), batchTestJob("job-2", "8")}
+	_, err := endpoint.SubmitBatch(ctx, BatchRequest{Jobs: jobs})
+	assert.ErrorIs(t, err, ErrNotLeader)
+
+	leader.Promote(ctx, 1)
+	resp, err := endpoint.SubmitBatch(ctx, BatchRequest{Jobs: jobs})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Deferred)
+
+	queue := leader.State().Snapshot().Queue
+	require.Len(t, queue, 1)
+	assert.Equal(t, "job-2", queue[0].JobID)
+	assert.WithinDuration(t, time.Now().Add(DefaultBatchSlot), queue[0].AdmitAt, time.Minute)
+}
@@ -0,0 +1,67 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSubmissionAborted is returned by SubmitJob when its context is
+// cancelled before the orchestrator accepts the job. The error also wraps
+// the context's error.
+var ErrSubmissionAborted = errors.New("job submission aborted")
+
+// Stages of a submission, as reported when it is aborted.
+const (
+	stageCapacity   = "capacity check"
+	stageAdmission  = "admission"
+	stageScheduling = "scheduling"
+	stageSubmission = "submission"
+)
+
+// abandonSelection undoes what a failed scheduling pass allocated: the
+// leases of the nodes it selected and the job's GPU time slices. It runs
+// to completion even when ctx is cancelled, since the allocations outlive
+// the request.
+func (s *Scheduler) abandonSelection(ctx context.Context, req GlobalSchedulingRequest, selections []NodeSelection) {
+	ctx = context.WithoutCancel(ctx)
+	releaseLeases(ctx, s.capacityProvider, selections)
+	if req.Scheduling.GPUTimeSlice != nil && req.LeaseCapacity {
+		if n := s.ReleaseTimeSlices(req.Job.ID); n > 0 {
+			componentLogger(ctx, ComponentScheduler).Debug().
+				Str("jobID", req.Job.ID).
+				Int("released", n).
+				Msg("Released GPU time slices of an abandoned pass")
+		}
+	}
+}
+
+// abortSubmission undoes what a submission cancelled at stage had
+// allocated, the leases of its selections and the job's GPU time slices,
+// and audits the abort. The cleanup runs to completion despite ctx.
+func (e *Endpoint) abortSubmission(
+	ctx context.Context, req GlobalJobRequest, stage string, selections []NodeSelection,
+) error {
+	cause := ctx.Err()
+	cleanup := context.WithoutCancel(ctx)
+	releaseLeases(cleanup, e.capacityProvider, selections)
+	e.releaseTimeSlices(cleanup, req.Job.ID)
+
+	e.history.recordAudit(AuditEntry{
+		JobID:    req.Job.ID,
+		Action:   AuditActionAbort,
+		ClientID: req.ClientID,
+		Detail:   fmt.Sprintf("cancelled during %s: %v", stage, cause),
+		Nodes:    len(selections),
+	})
+	componentLogger(ctx, ComponentEndpoint).Warn().
+		Err(cause).
+		Str("jobID", req.Job.ID).
+		Str("stage", stage).
+		Int("released", len(selections)).
+		Msg("Job submission aborted")
+
+	return fmt.Errorf("%w during %s: %w", ErrSubmissionAborted, stage, cause)
+}
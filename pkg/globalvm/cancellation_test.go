@@ -0,0 +1,268 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Stages a submission is cancelled at by the cancelling test doubles.
+const (
+	cancelAtCapacity   = "capacity"
+	cancelAtAdmission  = "admission"
+	cancelAtMatching   = "matching"
+	cancelAtRanking    = "ranking"
+	cancelAtLeasing    = "leasing"
+	cancelAtCompletion = "completion"
+	cancelAtSubmission = "submission"
+	cancelAtAccepted   = "accepted"
+)
+
+// cancellingLeaser leases from a CapacityAggregator and cancels the
+// submission when it reaches its stage. Like a remote leaser, it refuses
+// to release or confirm leases under a cancelled context.
+type cancellingLeaser struct {
+	*CapacityAggregator
+	stage     string
+	cancel    context.CancelFunc
+	released  int
+	confirmed int
+}
+
+func (l *cancellingLeaser) GetAvailableCapacity(ctx context.Context) (*GlobalResources, error) {
+	capacity, err := l.CapacityAggregator.GetAvailableCapacity(ctx)
+	if l.stage == cancelAtCapacity {
+		l.cancel()
+		return nil, ctx.Err()
+	}
+	return capacity, err
+}
+
+func (l *cancellingLeaser) AcquireLease(
+	ctx context.Context, jobID, nodeID string, resources models.Resources,
+) (*SchedulingLease, error) {
+	lease, err := l.CapacityAggregator.AcquireLease(ctx, jobID, nodeID, resources)
+	if l.stage == cancelAtLeasing {
+		l.cancel()
+	}
+	return lease, err
+}
+
+func (l *cancellingLeaser) ReleaseLease(ctx context.Context, leaseID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.released++
+	return l.CapacityAggregator.ReleaseLease(ctx, leaseID)
+}
+
+func (l *cancellingLeaser) ConfirmLease(ctx context.Context, leaseID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.confirmed++
+	return l.CapacityAggregator.ConfirmLease(ctx, leaseID)
+}
+
+// cancellingSelector cancels while matching nodes.
+type cancellingSelector struct {
+	mockNodeSelector
+	stage  string
+	cancel context.CancelFunc
+}
+
+func (s *cancellingSelector) MatchingNodes(
+	ctx context.Context, job *models.Job,
+) (matched, rejected []orchestrator.NodeRank, err error) {
+	if s.stage == cancelAtMatching {
+		s.cancel()
+	}
+	return s.mockNodeSelector.MatchingNodes(ctx, job)
+}
+
+// cancellingProfile keeps the ranks as they are and adds a standby, and
+// cancels while ranking or completing.
+type cancellingProfile struct {
+	stage  string
+	cancel context.CancelFunc
+}
+
+func (p *cancellingProfile) Name() string {
+	return "cancelling"
+}
+
+func (p *cancellingProfile) Rank(ctx context.Context, pc *PlacementContext, candidates []NodeSelection) []NodeSelection {
+	if p.stage == cancelAtRanking {
+		p.cancel()
+	}
+	return candidates
+}
+
+func (p *cancellingProfile) Complete(
+	ctx context.Context, pc *PlacementContext, workers, spare []NodeSelection,
+) ([]NodeSelection, error) {
+	if len(spare) > 0 {
+		standby, ok, err := pc.Lease(ctx, spare[0])
+		if err != nil {
+			return workers, err
+		}
+		if ok {
+			standby.Role = NodeRoleStandby
+			workers = append(workers, standby)
+		}
+	}
+	if p.stage == cancelAtCompletion {
+		p.cancel()
+	}
+	return workers, nil
+}
+
+// cancellingPolicy admits every job, cancelling while it decides.
+type cancellingPolicy struct {
+	stage  string
+	cancel context.CancelFunc
+}
+
+func (p *cancellingPolicy) Admit(ctx context.Context, _ AdmissionInput) (AdmissionResult, error) {
+	if p.stage == cancelAtAdmission {
+		p.cancel()
+		return AdmissionResult{}, ctx.Err()
+	}
+	return AdmissionResult{Decision: AdmissionAdmit}, nil
+}
+
+// cancellingSubmitter cancels while the orchestrator takes the job, which
+// it accepts at cancelAtAccepted and fails to get at cancelAtSubmission.
+type cancellingSubmitter struct {
+	stage  string
+	cancel context.CancelFunc
+}
+
+func (s *cancellingSubmitter) SubmitJob(
+	ctx context.Context, req orchestrator.SubmitJobRequest,
+) (*orchestrator.SubmitJobResponse, error) {
+	switch s.stage {
+	case cancelAtSubmission:
+		s.cancel()
+		return nil, ctx.Err()
+	case cancelAtAccepted:
+		s.cancel()
+	}
+	return &orchestrator.SubmitJobResponse{JobID: req.Job.ID, EvaluationID: "eval-1"}, nil
+}
+
+func TestEndpoint_SubmitJobCancellation(t *testing.T) {
+	tests := []struct {
+		stage    string
+		abortAt  string
+		released int
+	}{
+		{stage: cancelAtCapacity, abortAt: stageCapacity},
+		{stage: cancelAtAdmission, abortAt: stageAdmission},
+		{stage: cancelAtMatching, abortAt: stageScheduling},
+		{stage: cancelAtRanking, abortAt: stageScheduling},
+		{stage: cancelAtLeasing, abortAt: stageScheduling, released: 1},
+		{stage: cancelAtCompletion, abortAt: stageScheduling, released: 2},
+		{stage: cancelAtSubmission, abortAt: stageSubmission, released: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stage, func(t *testing.T) {
+			endpoint, leaser, ctx := cancellationTestEndpoint(tt.stage)
+			job := createTestJob("job-1", models.JobTypeService, 1)
+
+			_, err := endpoint.SubmitJob(ctx, GlobalJobRequest{
+				Job:        job,
+				ClientID:   "client-1",
+				Scheduling: SchedulingOptions{Profile: "cancelling"},
+			})
+			require.ErrorIs(t, err, ErrSubmissionAborted)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.ErrorContains(t, err, tt.abortAt)
+
+			assert.Empty(t, leaser.ActiveLeases(context.Background()), "leases left behind")
+			assert.Equal(t, tt.released, leaser.released)
+			assert.Zero(t, leaser.confirmed)
+
+			audit := endpoint.GetAuditLog(job.ID)
+			require.Len(t, audit, 1)
+			assert.Equal(t, AuditActionAbort, audit[0].Action)
+			assert.Equal(t, "client-1", audit[0].ClientID)
+			assert.Contains(t, audit[0].Detail, "cancelled during "+tt.abortAt)
+		})
+	}
+
+	t.Run("after the orchestrator accepted the job", func(t *testing.T) {
+		endpoint, leaser, ctx := cancellationTestEndpoint(cancelAtAccepted)
+		job := createTestJob("job-1", models.JobTypeService, 1)
+
+		resp, err := endpoint.SubmitJob(ctx, GlobalJobRequest{
+			Job:        job,
+			Scheduling: SchedulingOptions{Profile: "cancelling"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "eval-1", resp.EvaluationID)
+		assert.Equal(t, 2, leaser.confirmed, "the job keeps its worker and standby")
+		assert.Zero(t, leaser.released)
+		assert.Equal(t, AuditActionSubmit, endpoint.GetAuditLog(job.ID)[0].Action)
+	})
+}
+
+// cancellationTestEndpoint builds an endpoint over two nodes in different
+// regions whose parts cancel the returned context at stage.
+func cancellationTestEndpoint(stage string) (*Endpoint, *cancellingLeaser, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	leaser := &cancellingLeaser{CapacityAggregator: newLeaseTestAggregator(), stage: stage, cancel: cancel}
+	selector := &cancellingSelector{
+		mockNodeSelector: mockNodeSelector{nodes: []orchestrator.NodeRank{
+			{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 20},
+			{NodeInfo: createTestNodeInfo("node-2", "eu-west"), Rank: 10},
+		}},
+		stage:  stage,
+		cancel: cancel,
+	}
+	scheduler := NewScheduler(selector, leaser, WithPlacementProfile(&cancellingProfile{stage: stage, cancel: cancel}))
+	endpoint := NewEndpoint(scheduler, leaser,
+		WithAdmissionPolicy(&cancellingPolicy{stage: stage, cancel: cancel}),
+		WithJobSubmitter(&cancellingSubmitter{stage: stage, cancel: cancel}),
+	)
+	return endpoint, leaser, ctx
+}
+
+func TestScheduler_CancelledPassReleasesTimeSlices(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	slicer := NewGPUTimeSlicer()
+	leaser := &cancellingLeaser{CapacityAggregator: newLeaseTestAggregator(), stage: cancelAtLeasing, cancel: cancel}
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: gpuNodeInfo("node-1", "us-east", 1), Rank: 10},
+	}}
+	scheduler := NewScheduler(selector, leaser, WithGPUTimeSlicer(slicer))
+
+	_, err := scheduler.Schedule(ctx, GlobalSchedulingRequest{
+		Job:           createTestJob("job-1", models.JobTypeService, 1),
+		Scheduling:    SchedulingOptions{GPUTimeSlice: &GPUTimeSliceOptions{DutyCycle: 0.5}},
+		TargetCount:   1,
+		LeaseCapacity: true,
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, leaser.released)
+	assert.Empty(t, leaser.ActiveLeases(context.Background()))
+	assert.Empty(t, slicer.Utilization(), "time slice left committed")
+}
+
+func TestGeoRanker_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ranker := NewGeoRanker(GeoRankerConfig{}, nil)
+
+	_, err := ranker.RankNodes(ctx, *createTestJob("job-1", models.JobTypeBatch, 1), []models.NodeInfo{
+		createTestNodeInfo("node-1", "us-east"),
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -38,6 +38,9 @@ type NodeCapabilities struct {
 	// Network contains network capabilities.
 	Network NetworkCapability `json:"Network"`
 
+	// Energy is the operator's electricity tariff (optional).
+	Energy *EnergyTariff `json:"Energy,omitempty"`
+
 	// Benchmarks contains performance benchmarks (optional).
 	Benchmarks *CapabilityBenchmarks `json:"Benchmarks,omitempty"`
 
@@ -202,6 +205,7 @@ type CapabilityBenchmarks struct {
 type Detector struct {
 	gpuDetector    GPUDetector
 	engineDetector EngineDetector
+	energyTariff   *EnergyTariff
 
 	mu           sync.RWMutex
 	lastDetect   *NodeCapabilities
@@ -240,6 +244,14 @@ func WithEngineDetector(detector EngineDetector) DetectorOption {
 	}
 }
 
+// WithEnergyTariff publishes the operator's electricity tariff with the
+// detected capabilities.
+func WithEnergyTariff(tariff *EnergyTariff) DetectorOption {
+	return func(d *Detector) {
+		d.energyTariff = tariff
+	}
+}
+
 // WithCacheExpiry sets the cache expiry duration.
 func WithCacheExpiry(dur time.Duration) DetectorOption {
 	return func(d *Detector) {
@@ -263,6 +275,7 @@ func (d *Detector) DetectAll(ctx context.Context) (*NodeCapabilities, error) {
 		DetectionTime: time.Now(),
 		OS:            runtime.GOOS,
 		Architecture:  runtime.GOARCH,
+		Energy:        d.energyTariff,
 	}
 
 	// Detect hostname
@@ -0,0 +1,180 @@
+package capability
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Node labels publishing an operator's electricity tariff.
+const (
+	// LabelEnergyRate is the off-peak electricity price per kWh.
+	LabelEnergyRate = "energy-rate"
+
+	// LabelEnergyPeaks lists daily peak windows with their price per kWh,
+	// e.g. "07:00-09:00=0.25;17:00-21:00=0.32".
+	LabelEnergyPeaks = "energy-peaks"
+
+	// LabelEnergyTimezone is the IANA time zone the peak windows are in.
+	// Defaults to UTC.
+	LabelEnergyTimezone = "energy-timezone"
+
+	// LabelPowerDraw is the node's power draw under full load, in watts.
+	LabelPowerDraw = "power-draw-watts"
+)
+
+// TariffWindow is a daily period priced differently from the base rate.
+// A window whose end is before its start wraps past midnight.
+type TariffWindow struct {
+	// Start and End are offsets from local midnight.
+	Start time.Duration `json:"Start"`
+	End   time.Duration `json:"End"`
+
+	// Rate is the price per kWh within the window.
+	Rate float64 `json:"Rate"`
+}
+
+// contains reports whether the offset from midnight falls in the window.
+func (w TariffWindow) contains(offset time.Duration) bool {
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// EnergyTariff is the electricity cost a node operator pays, published so
+// the scheduler can keep operators profitable.
+type EnergyTariff struct {
+	// Rate is the base price per kWh, in the currency credits settle in.
+	Rate float64 `json:"Rate"`
+
+	// Peaks are windows priced above the base rate.
+	Peaks []TariffWindow `json:"Peaks,omitempty"`
+
+	// Timezone is the IANA time zone of the peak windows.
+	Timezone string `json:"Timezone,omitempty"`
+
+	// PowerDraw is the node's power draw under full load, in watts.
+	// Zero means unknown.
+	PowerDraw float64 `json:"PowerDraw,omitempty"`
+}
+
+// RateAt returns the price per kWh at t and whether t is in a peak window.
+func (t *EnergyTariff) RateAt(at time.Time) (float64, bool) {
+	if loc, err := time.LoadLocation(t.Timezone); err == nil {
+		at = at.In(loc)
+	}
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	offset := at.Sub(midnight)
+
+	for _, w := range t.Peaks {
+		if w.contains(offset) {
+			return w.Rate, w.Rate > t.Rate
+		}
+	}
+	return t.Rate, false
+}
+
+// Labels encodes the tariff as node labels.
+func (t *EnergyTariff) Labels() map[string]string {
+	labels := map[string]string{
+		LabelEnergyRate: strconv.FormatFloat(t.Rate, 'f', -1, 64),
+	}
+	if len(t.Peaks) > 0 {
+		windows := make([]string, len(t.Peaks))
+		for i, w := range t.Peaks {
+			windows[i] = fmt.Sprintf("%s-%s=%s", formatClock(w.Start), formatClock(w.End),
+				strconv.FormatFloat(w.Rate, 'f', -1, 64))
+		}
+		labels[LabelEnergyPeaks] = strings.Join(windows, ";")
+	}
+	if t.Timezone != "" {
+		labels[LabelEnergyTimezone] = t.Timezone
+	}
+	if t.PowerDraw > 0 {
+		labels[LabelPowerDraw] = strconv.FormatFloat(t.PowerDraw, 'f', -1, 64)
+	}
+	return labels
+}
+
+// ParseEnergyTariff decodes a tariff from node labels. It returns nil
+// without an error when the node publishes no tariff.
+func ParseEnergyTariff(labels map[string]string) (*EnergyTariff, error) {
+	rateLabel, ok := labels[LabelEnergyRate]
+	if !ok {
+		return nil, nil
+	}
+	rate, err := strconv.ParseFloat(rateLabel, 64)
+	if err != nil || rate < 0 {
+		return nil, fmt.Errorf("invalid %s %q", LabelEnergyRate, rateLabel)
+	}
+	tariff := &EnergyTariff{Rate: rate, Timezone: labels[LabelEnergyTimezone]}
+
+	if tariff.Timezone != "" {
+		if _, err := time.LoadLocation(tariff.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", LabelEnergyTimezone, tariff.Timezone, err)
+		}
+	}
+
+	if peaks := labels[LabelEnergyPeaks]; peaks != "" {
+		for _, spec := range strings.Split(peaks, ";") {
+			window, err := parseTariffWindow(strings.TrimSpace(spec))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", LabelEnergyPeaks, spec, err)
+			}
+			tariff.Peaks = append(tariff.Peaks, window)
+		}
+		sort.Slice(tariff.Peaks, func(i, j int) bool { return tariff.Peaks[i].Start < tariff.Peaks[j].Start })
+	}
+
+	if draw, ok := labels[LabelPowerDraw]; ok {
+		tariff.PowerDraw, err = strconv.ParseFloat(draw, 64)
+		if err != nil || tariff.PowerDraw < 0 {
+			return nil, fmt.Errorf("invalid %s %q", LabelPowerDraw, draw)
+		}
+	}
+
+	return tariff, nil
+}
+
+// parseTariffWindow parses "HH:MM-HH:MM=rate".
+func parseTariffWindow(spec string) (TariffWindow, error) {
+	period, rateStr, ok := strings.Cut(spec, "=")
+	if !ok {
+		return TariffWindow{}, fmt.Errorf("missing rate")
+	}
+	startStr, endStr, ok := strings.Cut(period, "-")
+	if !ok {
+		return TariffWindow{}, fmt.Errorf("missing end time")
+	}
+
+	start, err := parseClock(startStr)
+	if err != nil {
+		return TariffWindow{}, err
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return TariffWindow{}, err
+	}
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil || rate < 0 {
+		return TariffWindow{}, fmt.Errorf("invalid rate %q", rateStr)
+	}
+	return TariffWindow{Start: start, End: end, Rate: rate}, nil
+}
+
+// parseClock parses "HH:MM" as an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// formatClock formats an offset from midnight as "HH:MM".
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours())%24, int(d.Minutes())%60)
+}
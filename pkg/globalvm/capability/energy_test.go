@@ -0,0 +1,76 @@
+//go:build unit
+
+package capability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnergyTariff(t *testing.T) {
+	tariff, err := ParseEnergyTariff(map[string]string{
+		LabelEnergyRate:     "0.12",
+		LabelEnergyPeaks:    "17:00-21:00=0.35; 07:00-09:00=0.25",
+		LabelEnergyTimezone: "Europe/Berlin",
+		LabelPowerDraw:      "450",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, tariff)
+
+	assert.Equal(t, 0.12, tariff.Rate)
+	assert.Equal(t, 450.0, tariff.PowerDraw)
+	require.Len(t, tariff.Peaks, 2)
+	assert.Equal(t, TariffWindow{Start: 7 * time.Hour, End: 9 * time.Hour, Rate: 0.25}, tariff.Peaks[0])
+
+	// Round-trips through labels
+	again, err := ParseEnergyTariff(tariff.Labels())
+	require.NoError(t, err)
+	assert.Equal(t, tariff, again)
+}
+
+func TestParseEnergyTariff_NoTariff(t *testing.T) {
+	tariff, err := ParseEnergyTariff(map[string]string{"region": "eu-west"})
+	assert.NoError(t, err)
+	assert.Nil(t, tariff)
+}
+
+func TestParseEnergyTariff_Invalid(t *testing.T) {
+	for _, labels := range []map[string]string{
+		{LabelEnergyRate: "cheap"},
+		{LabelEnergyRate: "0.1", LabelEnergyPeaks: "17:00=0.3"},
+		{LabelEnergyRate: "0.1", LabelEnergyPeaks: "5pm-9pm=0.3"},
+		{LabelEnergyRate: "0.1", LabelEnergyTimezone: "Mars/Olympus"},
+		{LabelEnergyRate: "0.1", LabelPowerDraw: "-5"},
+	} {
+		_, err := ParseEnergyTariff(labels)
+		assert.Error(t, err, "labels %v", labels)
+	}
+}
+
+func TestEnergyTariff_RateAt(t *testing.T) {
+	tariff := &EnergyTariff{
+		Rate:     0.12,
+		Timezone: "Europe/Berlin",
+		Peaks: []TariffWindow{
+			{Start: 17 * time.Hour, End: 21 * time.Hour, Rate: 0.35},
+			{Start: 23 * time.Hour, End: time.Hour, Rate: 0.05}, // cheaper overnight
+		},
+	}
+
+	// Berlin is UTC+1 in January
+	rate, peak := tariff.RateAt(time.Date(2026, 1, 15, 16, 30, 0, 0, time.UTC))
+	assert.Equal(t, 0.35, rate)
+	assert.True(t, peak)
+
+	rate, peak = tariff.RateAt(time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, 0.12, rate)
+	assert.False(t, peak)
+
+	// The overnight window wraps past midnight and is not a peak
+	rate, peak = tariff.RateAt(time.Date(2026, 1, 15, 23, 30, 0, 0, time.UTC))
+	assert.Equal(t, 0.05, rate)
+	assert.False(t, peak)
+}
@@ -10,7 +10,6 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/models"
 	"github.com/bacalhau-project/bacalhau/pkg/orchestrator/nodes"
-	"github.com/rs/zerolog/log"
 )
 
 // GlobalResources represents the aggregated resources across all nodes.
@@ -92,6 +91,11 @@ type CapacityAggregator struct {
 	lastSnapshot *CapacitySnapshot
 	updateChan   chan GlobalResources
 	snapshotInterval time.Duration
+
+	leaseMu    sync.Mutex
+	leases     map[string]SchedulingLease
+	leaseTTL   time.Duration
+	leaseStore LeaseStore
 }
 
 // NewCapacityAggregator creates a new capacity aggregator.
@@ -100,6 +104,8 @@ func NewCapacityAggregator(nodeLookup nodes.Lookup, opts ...AggregatorOption) *C
 		nodeLookup:       nodeLookup,
 		updateChan:       make(chan GlobalResources, 100),
 		snapshotInterval: 10 * time.Second,
+		leases:           make(map[string]SchedulingLease),
+		leaseTTL:         DefaultLeaseTTL,
 	}
 	for _, opt := range opts {
 		opt(a)
@@ -185,7 +191,7 @@ func (a *CapacityAggregator) Subscribe(ctx context.Context) (<-chan GlobalResour
 			case <-ticker.C:
 				resources, err := a.GetGlobalCapacity(ctx)
 				if err != nil {
-					log.Warn().Err(err).Msg("failed to get global capacity")
+					componentLogger(ctx, ComponentAggregator).Warn().Err(err).Msg("Failed to get global capacity")
 					continue
 				}
 				select {
@@ -249,6 +255,13 @@ func (a *CapacityAggregator) computeSnapshot(ctx context.Context) (*CapacitySnap
 		}
 	}
 
+	// Capacity held by scheduling leases is not available to other passes
+	leased := a.leasedTotal(ctx, snapshot.NodeDetails)
+	snapshot.Resources.AvailableCPU = max(snapshot.Resources.AvailableCPU-leased.CPU, 0)
+	snapshot.Resources.AvailableMemory -= min(leased.Memory, snapshot.Resources.AvailableMemory)
+	snapshot.Resources.AvailableDisk -= min(leased.Disk, snapshot.Resources.AvailableDisk)
+	snapshot.Resources.AvailableGPU = max(snapshot.Resources.AvailableGPU-int(leased.GPU), 0)
+
 	snapshot.Resources.SnapshotTime = snapshot.Timestamp
 	return snapshot, nil
 }
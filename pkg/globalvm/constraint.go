@@ -0,0 +1,608 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+// Constraint is a compiled node constraint expression, such as
+//
+//	region in ["us-east", "eu-west"] && gpu.vendor == "nvidia" && node.reliability > 0.9
+//
+// The region.* attributes place nodes with the region hierarchy (see
+// RegionHierarchy), so `region.continent == "europe"` matches any node in
+// Europe.
+//
+// Comparisons take a node attribute on the left and a literal on the right.
+// They are combined with &&, || and !, and grouped with parentheses.
+// String comparisons are case-insensitive. Multi-valued attributes such as
+// gpu.vendor match when any of their values matches.
+//
+// Supported attributes:
+//
+//	region            node region label
+//	region.continent  continent of the node's region, such as "europe"
+//	region.country    country code of the node's region, such as "de"
+//	region.metro      metro area of the node's region, such as "frankfurt"
+//	node.id           node ID
+//	node.reliability  node "reliability" label, 0 when absent
+//	cpu               available CPU cores
+//	memory.gb         available memory in GiB
+//	disk.gb           available disk in GiB
+//	gpu.count         number of GPUs
+//	gpu.vendor        GPU vendors: "nvidia", "amd" or "intel"
+//	gpu.model         GPU model names
+//	labels.<key>      value of any node label
+type Constraint struct {
+	expr string
+	root constraintNode
+}
+
+// ConstraintError reports an invalid constraint expression.
+type ConstraintError struct {
+	// Expr is the full constraint expression.
+	Expr string
+
+	// Offset is the byte offset of the offending fragment in Expr.
+	Offset int
+
+	// Fragment is the part of Expr the error refers to.
+	Fragment string
+
+	// Reason describes what is wrong with the fragment.
+	Reason string
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Fragment == "" {
+		return fmt.Sprintf("invalid constraint %q: %s at offset %d", e.Expr, e.Reason, e.Offset)
+	}
+	return fmt.Sprintf("invalid constraint %q: %s in %q at offset %d", e.Expr, e.Reason, e.Fragment, e.Offset)
+}
+
+// ParseConstraint parses and validates a constraint expression.
+// An empty expression matches every node.
+func ParseConstraint(expr string) (*Constraint, error) {
+	if strings.TrimSpace(expr) == "" {
+		return &Constraint{expr: expr}, nil
+	}
+
+	tokens, err := lexConstraint(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &constraintParser{expr: expr, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok.pos, tok.text, "unexpected "+strconv.Quote(tok.text))
+	}
+
+	return &Constraint{expr: expr, root: root}, nil
+}
+
+// String returns the source expression.
+func (c *Constraint) String() string {
+	return c.expr
+}
+
+// Matches reports whether a node satisfies the constraint.
+func (c *Constraint) Matches(info models.NodeInfo) bool {
+	if c.root == nil {
+		return true
+	}
+	return c.root.eval(info)
+}
+
+// Node attributes
+
+type attrKind int
+
+const (
+	kindString attrKind = iota
+	kindNumber
+	kindList
+)
+
+func (k attrKind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindList:
+		return "list"
+	default:
+		return "string"
+	}
+}
+
+// attrValue holds a resolved attribute; which field is set depends on the kind.
+type attrValue struct {
+	str  string
+	num  float64
+	list []string
+}
+
+type constraintAttr struct {
+	kind    attrKind
+	resolve func(info models.NodeInfo) attrValue
+}
+
+const labelsPrefix = "labels."
+
+var constraintAttrs = map[string]constraintAttr{
+	"region": {kindString, func(info models.NodeInfo) attrValue {
+		return attrValue{str: nodeRegion(info)}
+	}},
+	"region.continent": {kindString, func(info models.NodeInfo) attrValue {
+		return attrValue{str: regions().NodePath(info).Continent}
+	}},
+	"region.country": {kindString, func(info models.NodeInfo) attrValue {
+		return attrValue{str: regions().NodePath(info).Country}
+	}},
+	"region.metro": {kindString, func(info models.NodeInfo) attrValue {
+		return attrValue{str: regions().NodePath(info).Metro}
+	}},
+	"node.id": {kindString, func(info models.NodeInfo) attrValue {
+		return attrValue{str: info.ID()}
+	}},
+	"node.reliability": {kindNumber, func(info models.NodeInfo) attrValue {
+		reliability, _ := strconv.ParseFloat(info.Labels["reliability"], 64)
+		return attrValue{num: reliability}
+	}},
+	"cpu": {kindNumber, func(info models.NodeInfo) attrValue {
+		return attrValue{num: info.ComputeNodeInfo.AvailableCapacity.CPU}
+	}},
+	"memory.gb": {kindNumber, func(info models.NodeInfo) attrValue {
+		return attrValue{num: float64(info.ComputeNodeInfo.AvailableCapacity.Memory) / (1 << 30)}
+	}},
+	"disk.gb": {kindNumber, func(info models.NodeInfo) attrValue {
+		return attrValue{num: float64(info.ComputeNodeInfo.AvailableCapacity.Disk) / (1 << 30)}
+	}},
+	"gpu.count": {kindNumber, func(info models.NodeInfo) attrValue {
+		return attrValue{num: float64(len(nodeGPUs(info)))}
+	}},
+	"gpu.vendor": {kindList, func(info models.NodeInfo) attrValue {
+		var vendors []string
+		for _, gpu := range nodeGPUs(info) {
+			vendors = append(vendors, gpuVendorName(gpu.Vendor))
+		}
+		return attrValue{list: vendors}
+	}},
+	"gpu.model": {kindList, func(info models.NodeInfo) attrValue {
+		var names []string
+		for _, gpu := range nodeGPUs(info) {
+			names = append(names, gpu.Name)
+		}
+		return attrValue{list: names}
+	}},
+}
+
+// lookupAttr returns the attribute with the given name.
+func lookupAttr(name string) (constraintAttr, bool) {
+	if key, ok := strings.CutPrefix(name, labelsPrefix); ok && key != "" {
+		return constraintAttr{kindString, func(info models.NodeInfo) attrValue {
+			return attrValue{str: info.Labels[key]}
+		}}, true
+	}
+	attr, ok := constraintAttrs[name]
+	return attr, ok
+}
+
+// nodeGPUs returns the GPUs a node advertises.
+func nodeGPUs(info models.NodeInfo) []models.GPU {
+	if gpus := info.ComputeNodeInfo.MaxCapacity.GPUs; len(gpus) > 0 {
+		return gpus
+	}
+	return info.ComputeNodeInfo.AvailableCapacity.GPUs
+}
+
+// gpuVendorName maps a GPU vendor to the name used in constraints.
+func gpuVendorName(vendor models.GPUVendor) string {
+	switch vendor {
+	case models.GPUVendorNvidia:
+		return "nvidia"
+	case models.GPUVendorAMDATI:
+		return "amd"
+	case models.GPUVendorIntel:
+		return "intel"
+	default:
+		return strings.ToLower(string(vendor))
+	}
+}
+
+// Expression tree
+
+type constraintNode interface {
+	eval(info models.NodeInfo) bool
+}
+
+type andNode struct{ left, right constraintNode }
+
+func (n andNode) eval(info models.NodeInfo) bool { return n.left.eval(info) && n.right.eval(info) }
+
+type orNode struct{ left, right constraintNode }
+
+func (n orNode) eval(info models.NodeInfo) bool { return n.left.eval(info) || n.right.eval(info) }
+
+type notNode struct{ inner constraintNode }
+
+func (n notNode) eval(info models.NodeInfo) bool { return !n.inner.eval(info) }
+
+// literal is a constant on the right-hand side of a comparison.
+type literal struct {
+	kind attrKind
+	str  string
+	num  float64
+	list []literal
+}
+
+type compareNode struct {
+	attr  constraintAttr
+	op    string
+	value literal
+}
+
+func (n compareNode) eval(info models.NodeInfo) bool {
+	got := n.attr.resolve(info)
+
+	switch n.op {
+	case "in":
+		return n.in(got)
+	case "not in":
+		return !n.in(got)
+	case "==":
+		return n.equal(got, n.value)
+	case "!=":
+		return !n.equal(got, n.value)
+	case "<":
+		return got.num < n.value.num
+	case "<=":
+		return got.num <= n.value.num
+	case ">":
+		return got.num > n.value.num
+	case ">=":
+		return got.num >= n.value.num
+	}
+	return false
+}
+
+// equal reports whether the attribute equals want. A list attribute is
+// equal when any of its values is.
+func (n compareNode) equal(got attrValue, want literal) bool {
+	switch n.attr.kind {
+	case kindNumber:
+		return got.num == want.num
+	case kindList:
+		for _, v := range got.list {
+			if strings.EqualFold(v, want.str) {
+				return true
+			}
+		}
+		return false
+	default:
+		return strings.EqualFold(got.str, want.str)
+	}
+}
+
+// in reports whether the attribute equals any value of the literal list.
+func (n compareNode) in(got attrValue) bool {
+	for _, want := range n.value.list {
+		if n.equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// twoCharPuncts are matched before single character punctuation.
+var twoCharPuncts = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+func lexConstraint(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, &ConstraintError{Expr: expr, Offset: i, Fragment: expr[i:], Reason: "unterminated string"}
+			}
+			tokens = append(tokens, token{tokString, expr[i+1 : i+1+end], i})
+			i += end + 2
+
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && isDigit(expr[i+1])):
+			start := i
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, expr[start:i], start})
+
+		case isIdentStart(c):
+			start := i
+			for i < len(expr) && (isIdentStart(expr[i]) || isDigit(expr[i]) || expr[i] == '.' || expr[i] == '-' || expr[i] == '/') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, expr[start:i], start})
+
+		default:
+			matched := false
+			for _, p := range twoCharPuncts {
+				if strings.HasPrefix(expr[i:], p) {
+					tokens = append(tokens, token{tokPunct, p, i})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.IndexByte("()[],!<>", c) < 0 {
+				return nil, &ConstraintError{Expr: expr, Offset: i, Fragment: string(c), Reason: "unexpected character"}
+			}
+			tokens = append(tokens, token{tokPunct, string(c), i})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(expr)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Parser
+
+type constraintParser struct {
+	expr   string
+	tokens []token
+	i      int
+}
+
+func (p *constraintParser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *constraintParser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *constraintParser) is(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+func (p *constraintParser) errorAt(pos int, fragment, reason string) error {
+	return &ConstraintError{Expr: p.expr, Offset: pos, Fragment: fragment, Reason: reason}
+}
+
+// span returns the source text between two offsets.
+func (p *constraintParser) span(start, end int) string {
+	return strings.TrimSpace(p.expr[start:end])
+}
+
+func (p *constraintParser) parseOr() (constraintNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *constraintParser) parseAnd() (constraintNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *constraintParser) parseUnary() (constraintNode, error) {
+	if p.is("!") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+
+	if p.is("(") {
+		open := p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(")") {
+			return nil, p.errorAt(open.pos, p.span(open.pos, p.peek().pos), "missing closing parenthesis")
+		}
+		p.next()
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *constraintParser) parseComparison() (constraintNode, error) {
+	name := p.next()
+	if name.kind != tokIdent {
+		if name.kind == tokEOF {
+			return nil, p.errorAt(name.pos, "", "expected a node attribute")
+		}
+		return nil, p.errorAt(name.pos, name.text, "expected a node attribute")
+	}
+	attr, ok := lookupAttr(name.text)
+	if !ok {
+		return nil, p.errorAt(name.pos, name.text, "unknown attribute")
+	}
+
+	op, err := p.parseOperator(name)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	fragment := p.span(name.pos, p.peek().pos)
+	if reason := checkComparison(name.text, attr.kind, op, value); reason != "" {
+		return nil, p.errorAt(name.pos, fragment, reason)
+	}
+
+	return compareNode{attr: attr, op: op, value: value}, nil
+}
+
+var comparisonOps = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *constraintParser) parseOperator(name token) (string, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokPunct && comparisonOps[tok.text]:
+		return tok.text, nil
+	case tok.kind == tokIdent && tok.text == "in":
+		return "in", nil
+	case tok.kind == tokIdent && tok.text == "not" && p.peek().kind == tokIdent && p.peek().text == "in":
+		p.next()
+		return "not in", nil
+	case tok.kind == tokEOF:
+		return "", p.errorAt(name.pos, name.text, "missing comparison operator after attribute")
+	default:
+		return "", p.errorAt(tok.pos, tok.text, "expected a comparison operator")
+	}
+}
+
+func (p *constraintParser) parseLiteral() (literal, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokString:
+		return literal{kind: kindString, str: tok.text}, nil
+
+	case tok.kind == tokNumber:
+		num, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return literal{}, p.errorAt(tok.pos, tok.text, "invalid number")
+		}
+		return literal{kind: kindNumber, num: num}, nil
+
+	case tok.kind == tokPunct && tok.text == "[":
+		list := literal{kind: kindList}
+		for !p.is("]") {
+			if len(list.list) > 0 {
+				if !p.is(",") {
+					return literal{}, p.errorAt(tok.pos, p.span(tok.pos, p.peek().pos), "expected , or ] in list")
+				}
+				p.next()
+			}
+			item, err := p.parseLiteral()
+			if err != nil {
+				return literal{}, err
+			}
+			if item.kind == kindList {
+				return literal{}, p.errorAt(tok.pos, p.span(tok.pos, p.peek().pos), "lists cannot be nested")
+			}
+			list.list = append(list.list, item)
+		}
+		p.next()
+		return list, nil
+
+	case tok.kind == tokIdent:
+		return literal{}, p.errorAt(tok.pos, tok.text, "expected a value, strings must be quoted")
+	case tok.kind == tokEOF:
+		return literal{}, p.errorAt(tok.pos, "", "expected a value")
+	default:
+		return literal{}, p.errorAt(tok.pos, tok.text, "expected a value")
+	}
+}
+
+// checkComparison returns why a comparison is invalid, or "" if it is valid.
+func checkComparison(name string, kind attrKind, op string, value literal) string {
+	// Values are compared against the attribute's element type
+	want := kind
+	if kind == kindList {
+		want = kindString
+	}
+
+	switch op {
+	case "in", "not in":
+		if value.kind != kindList {
+			return fmt.Sprintf("%s expects a list", op)
+		}
+		if len(value.list) == 0 {
+			return fmt.Sprintf("%s expects a non-empty list", op)
+		}
+		for _, item := range value.list {
+			if item.kind != want {
+				return fmt.Sprintf("%s is a %s attribute but the list contains a %s", name, kind, item.kind)
+			}
+		}
+	case "<", "<=", ">", ">=":
+		if kind != kindNumber {
+			return fmt.Sprintf("%s is not defined for %s attribute %s", op, kind, name)
+		}
+		if value.kind != kindNumber {
+			return fmt.Sprintf("%s must be compared with a number", name)
+		}
+	default:
+		if value.kind != want {
+			return fmt.Sprintf("%s is a %s attribute but is compared with a %s", name, kind, value.kind)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createConstraintTestNode(id, region, reliability string, vendors ...models.GPUVendor) models.NodeInfo {
+	info := createTestNodeInfo(id, region)
+	info.Labels["reliability"] = reliability
+	for i, vendor := range vendors {
+		info.ComputeNodeInfo.MaxCapacity.GPUs = append(info.ComputeNodeInfo.MaxCapacity.GPUs,
+			models.GPU{Index: uint64(i), Vendor: vendor, Name: "Tesla T4"})
+	}
+	return info
+}
+
+func TestConstraint_Matches(t *testing.T) {
+	nvidiaEast := createConstraintTestNode("node-1", "us-east", "0.95", models.GPUVendorNvidia)
+	amdWest := createConstraintTestNode("node-2", "eu-west", "0.85", models.GPUVendorAMDATI)
+	cpuOnly := createConstraintTestNode("node-3", "ap-south", "0.99")
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected []bool // nvidiaEast, amdWest, cpuOnly
+	}{
+		{"empty", "", []bool{true, true, true}},
+		{"region equality", `region == "us-east"`, []bool{true, false, false}},
+		{"case insensitive", `region == "US-EAST"`, []bool{true, false, false}},
+		{"region in list", `region in ["us-east", "eu-west"]`, []bool{true, true, false}},
+		{"region not in list", `region not in ["us-east", "eu-west"]`, []bool{false, false, true}},
+		{"gpu vendor", `gpu.vendor == "nvidia"`, []bool{true, false, false}},
+		{"amd vendor", `gpu.vendor == "amd"`, []bool{false, true, false}},
+		{"gpu count", `gpu.count >= 1`, []bool{true, true, false}},
+		{"reliability", `node.reliability > 0.9`, []bool{true, false, true}},
+		{"numbers", `cpu >= 4 && memory.gb == 16 && disk.gb < 200`, []bool{true, true, true}},
+		{"labels", `labels.region != "eu-west"`, []bool{true, false, true}},
+		{"node id", `node.id in ['node-2', 'node-3']`, []bool{false, true, true}},
+		{
+			"composite",
+			`region in ["us-east","eu-west"] && gpu.vendor == "nvidia" && node.reliability > 0.9`,
+			[]bool{true, false, false},
+		},
+		{"or", `gpu.vendor == "amd" || node.reliability >= 0.99`, []bool{false, true, true}},
+		{"not", `!(gpu.count > 0)`, []bool{false, false, true}},
+		{"precedence", `region == "ap-south" || region == "us-east" && gpu.count == 0`, []bool{false, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConstraint(tt.expr)
+			require.NoError(t, err)
+
+			got := []bool{c.Matches(nvidiaEast), c.Matches(amdWest), c.Matches(cpuOnly)}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestConstraint_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		offset   int
+		fragment string
+		reason   string
+	}{
+		{"unknown attribute", `regoin == "us-east"`, 0, "regoin", "unknown attribute"},
+		{"unknown in conjunction", `region == "us-east" && gpu.vendr == "nvidia"`, 23, "gpu.vendr", "unknown attribute"},
+		{"ordering on string", `region > "a"`, 0, `region > "a"`, "not defined for string"},
+		{"type mismatch", `cpu == "four"`, 0, `cpu == "four"`, "compared with a string"},
+		{"mixed list", `region in ["us-east", 3]`, 0, `region in ["us-east", 3]`, "list contains a number"},
+		{"in without list", `region in "us-east"`, 0, `region in "us-east"`, "expects a list"},
+		{"unquoted string", `region == us-east`, 10, "us-east", "strings must be quoted"},
+		{"missing operator", `cpu`, 0, "cpu", "missing comparison operator"},
+		{"missing value", `cpu >`, 5, "", "expected a value"},
+		{"unterminated string", `region == "us-east`, 10, `"us-east`, "unterminated string"},
+		{"unbalanced parenthesis", `(cpu > 1 && gpu.count > 0`, 0, "(cpu > 1 && gpu.count > 0", "missing closing parenthesis"},
+		{"trailing tokens", `cpu > 1 gpu.count > 0`, 8, "gpu.count", "unexpected"},
+		{"bad character", `cpu > 1 & gpu.count > 0`, 8, "&", "unexpected character"},
+		{"literal on left", `"us-east" == region`, 0, "us-east", "expected a node attribute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConstraint(tt.expr)
+			require.Error(t, err)
+
+			var cerr *ConstraintError
+			require.True(t, errors.As(err, &cerr), "expected ConstraintError, got %T", err)
+			assert.Equal(t, tt.expr, cerr.Expr)
+			assert.Equal(t, tt.offset, cerr.Offset)
+			assert.Equal(t, tt.fragment, cerr.Fragment)
+			assert.Contains(t, cerr.Reason, tt.reason)
+			assert.Contains(t, err.Error(), tt.reason)
+		})
+	}
+}
+
+func TestScheduler_SelectNodesWithConstraint(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createConstraintTestNode("node-1", "us-east", "0.95", models.GPUVendorNvidia), Rank: 10},
+		{NodeInfo: createConstraintTestNode("node-2", "eu-west", "0.95", models.GPUVendorAMDATI), Rank: 20},
+		{NodeInfo: createConstraintTestNode("node-3", "us-east", "0.50", models.GPUVendorNvidia), Rank: 30},
+	}}
+	scheduler := NewScheduler(selector, &mockCapacityProvider{})
+
+	selections, err := scheduler.SelectNodes(context.Background(), GlobalSchedulingRequest{
+		Job: createTestJob("job-1", models.JobTypeBatch, 3),
+		Scheduling: SchedulingOptions{
+			Constraint: `region in ["us-east","eu-west"] && gpu.vendor == "nvidia" && node.reliability > 0.9`,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-1", selections[0].NodeID)
+
+	_, err = scheduler.SelectNodes(context.Background(), GlobalSchedulingRequest{
+		Job:        createTestJob("job-1", models.JobTypeBatch, 3),
+		Scheduling: SchedulingOptions{Constraint: `gpu.vendor ==`},
+	})
+	var cerr *ConstraintError
+	assert.ErrorAs(t, err, &cerr)
+}
+
+func TestEndpoint_SubmitJobRejectsInvalidConstraint(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity)
+
+	_, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:        createTestJob("job-1", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Constraint: `node.reliability > "high"`},
+	})
+	var cerr *ConstraintError
+	require.ErrorAs(t, err, &cerr)
+	assert.Equal(t, `node.reliability > "high"`, cerr.Fragment)
+}
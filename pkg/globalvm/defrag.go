@@ -0,0 +1,391 @@
+//go:build unit
+
+// Package globalvm provides global scheduling capabilities for the distributed compute network.
+// This file implements a planner that consolidates fragmented GPU allocations.
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoDefragPlan is returned when no set of migrations frees enough GPUs on one node.
+	ErrNoDefragPlan = errors.New("no defragmentation plan frees enough GPUs")
+
+	// ErrDefragAdvisory is returned when applying a plan while the planner is in advisory mode.
+	ErrDefragAdvisory = errors.New("GPU defragmentation is in advisory mode")
+
+	// ErrDefragNotConfigured is returned by the endpoint when it has no defragmentation planner.
+	ErrDefragNotConfigured = errors.New("GPU defragmentation is not configured")
+)
+
+// gpuShareEpsilon absorbs rounding when summing fractional GPU shares.
+const gpuShareEpsilon = 1e-9
+
+// DefragMode decides whether the planner only proposes migrations or also runs them.
+type DefragMode string
+
+const (
+	// DefragModeAdvisory proposes plans without moving any workload.
+	DefragModeAdvisory DefragMode = "advisory"
+
+	// DefragModeEnforce runs plans through the checkpoint/restore hooks.
+	DefragModeEnforce DefragMode = "enforce"
+)
+
+// GPUAllocation is a workload's share of one GPU device.
+type GPUAllocation struct {
+	JobID       string `json:"JobID"`
+	ExecutionID string `json:"ExecutionID"`
+
+	// GPU is the device index on the node.
+	GPU int `json:"GPU"`
+
+	// Share is the fraction of the device held, 1 for a whole GPU and
+	// less for MIG slices or time-sliced partial GPUs.
+	Share float64 `json:"Share"`
+
+	// Migratable is set when the workload can be checkpointed and restored elsewhere.
+	Migratable bool `json:"Migratable"`
+}
+
+// NodeGPUState is the GPU inventory of a node and its current allocations.
+type NodeGPUState struct {
+	NodeID      string          `json:"NodeID"`
+	Region      string          `json:"Region,omitempty"`
+	GPUs        int             `json:"GPUs"`
+	Allocations []GPUAllocation `json:"Allocations,omitempty"`
+}
+
+// GPUInventory reports the GPU allocations across the cluster.
+type GPUInventory interface {
+	GPUState(ctx context.Context) ([]NodeGPUState, error)
+}
+
+// CheckpointRestorer moves a running execution between nodes.
+type CheckpointRestorer interface {
+	// Checkpoint suspends the execution on its node and returns a checkpoint ID.
+	Checkpoint(ctx context.Context, executionID, nodeID string) (string, error)
+
+	// Restore resumes a checkpoint on the node, pinned to the GPU device.
+	Restore(ctx context.Context, checkpointID, nodeID string, gpu int) error
+}
+
+// GPUMigration moves one allocation to another device.
+type GPUMigration struct {
+	JobID       string  `json:"JobID"`
+	ExecutionID string  `json:"ExecutionID"`
+	FromNode    string  `json:"FromNode"`
+	FromGPU     int     `json:"FromGPU"`
+	ToNode      string  `json:"ToNode"`
+	ToGPU       int     `json:"ToGPU"`
+	Share       float64 `json:"Share"`
+}
+
+// DefragPlan frees TargetGPUs whole GPUs on NodeID. A plan without
+// migrations means the GPUs are already free.
+type DefragPlan struct {
+	TargetGPUs int            `json:"TargetGPUs"`
+	NodeID     string         `json:"NodeID"`
+	FreeGPUs   int            `json:"FreeGPUs"`
+	Migrations []GPUMigration `json:"Migrations,omitempty"`
+	Mode       DefragMode     `json:"Mode"`
+	CreatedAt  time.Time      `json:"CreatedAt"`
+}
+
+// GPUDefragmenter plans migrations that consolidate partial GPU allocations
+// so that large multi-GPU jobs fit on a single node.
+type GPUDefragmenter struct {
+	inventory GPUInventory
+	restorer  CheckpointRestorer
+	mode      DefragMode
+
+	mu   sync.Mutex
+	last *DefragPlan
+}
+
+// DefragOption configures the defragmentation planner.
+type DefragOption func(*GPUDefragmenter)
+
+// WithDefragMode sets whether plans are only proposed or also applied.
+func WithDefragMode(mode DefragMode) DefragOption {
+	return func(d *GPUDefragmenter) {
+		d.mode = mode
+	}
+}
+
+// WithCheckpointRestorer sets the hooks used to migrate executions.
+func WithCheckpointRestorer(restorer CheckpointRestorer) DefragOption {
+	return func(d *GPUDefragmenter) {
+		d.restorer = restorer
+	}
+}
+
+// NewGPUDefragmenter creates a planner in advisory mode.
+func NewGPUDefragmenter(inventory GPUInventory, opts ...DefragOption) *GPUDefragmenter {
+	d := &GPUDefragmenter{
+		inventory: inventory,
+		mode:      DefragModeAdvisory,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Mode returns the planner's mode.
+func (d *GPUDefragmenter) Mode() DefragMode {
+	return d.mode
+}
+
+// LastPlan returns the most recent plan.
+func (d *GPUDefragmenter) LastPlan() (*DefragPlan, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.last, d.last != nil
+}
+
+// Plan proposes the fewest migrations that leave targetGPUs whole GPUs free
+// on a single node. Only migratable allocations are moved, and they are
+// packed onto the partially used GPUs of other nodes before any free GPU.
+func (d *GPUDefragmenter) Plan(ctx context.Context, targetGPUs int) (*DefragPlan, error) {
+	if targetGPUs <= 0 {
+		return nil, fmt.Errorf("target GPUs must be positive, got %d", targetGPUs)
+	}
+	nodes, err := d.inventory.GPUState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GPU inventory: %w", err)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+
+	var best *DefragPlan
+	var bestShare float64
+	for _, node := range nodes {
+		if node.GPUs < targetGPUs {
+			continue
+		}
+		plan, moved, ok := planNode(nodes, node, targetGPUs)
+		if !ok {
+			continue
+		}
+		if best == nil || len(plan.Migrations) < len(best.Migrations) ||
+			(len(plan.Migrations) == len(best.Migrations) && moved < bestShare) {
+			best, bestShare = plan, moved
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: %d GPUs", ErrNoDefragPlan, targetGPUs)
+	}
+
+	best.Mode = d.mode
+	best.CreatedAt = time.Now()
+	d.mu.Lock()
+	d.last = best
+	d.mu.Unlock()
+
+	componentLogger(ctx, ComponentDefrag).Info().
+		Int("targetGPUs", targetGPUs).
+		Str("nodeID", best.NodeID).
+		Int("migrations", len(best.Migrations)).
+		Str("mode", string(d.mode)).
+		Msg("GPU defragmentation plan proposed")
+	return best, nil
+}
+
+// Apply runs a plan's migrations in order through the checkpoint/restore
+// hooks. It stops at the first failure; earlier migrations are kept.
+func (d *GPUDefragmenter) Apply(ctx context.Context, plan *DefragPlan) error {
+	if d.mode != DefragModeEnforce {
+		return ErrDefragAdvisory
+	}
+	if d.restorer == nil {
+		return errors.New("no checkpoint/restore hooks configured")
+	}
+
+	for _, m := range plan.Migrations {
+		checkpointID, err := d.restorer.Checkpoint(ctx, m.ExecutionID, m.FromNode)
+		if err != nil {
+			return fmt.Errorf("failed to checkpoint execution %s of job %s: %w", m.ExecutionID, m.JobID, err)
+		}
+		if err := d.restorer.Restore(ctx, checkpointID, m.ToNode, m.ToGPU); err != nil {
+			return fmt.Errorf("failed to restore execution %s of job %s on %s: %w", m.ExecutionID, m.JobID, m.ToNode, err)
+		}
+		defragMigrations.Add(ctx, 1)
+		componentLogger(ctx, ComponentDefrag).Info().
+			Str("jobID", m.JobID).
+			Str("from", m.FromNode).
+			Str("to", m.ToNode).
+			Msg("Migrated execution for GPU defragmentation")
+	}
+	return nil
+}
+
+// gpuUsage tracks the allocated share of every device in the cluster.
+type gpuUsage map[string][]float64
+
+func newGPUUsage(nodes []NodeGPUState) gpuUsage {
+	usage := make(gpuUsage, len(nodes))
+	for _, node := range nodes {
+		shares := make([]float64, node.GPUs)
+		for _, alloc := range node.Allocations {
+			if alloc.GPU >= 0 && alloc.GPU < node.GPUs {
+				shares[alloc.GPU] += alloc.Share
+			}
+		}
+		usage[node.NodeID] = shares
+	}
+	return usage
+}
+
+// freeGPUs counts the devices on a node with nothing allocated.
+func (u gpuUsage) freeGPUs(nodeID string) int {
+	free := 0
+	for _, share := range u[nodeID] {
+		if share <= gpuShareEpsilon {
+			free++
+		}
+	}
+	return free
+}
+
+// planNode plans the migrations that free targetGPUs devices on target,
+// evicting the least used devices first. It returns the total share moved.
+func planNode(nodes []NodeGPUState, target NodeGPUState, targetGPUs int) (*DefragPlan, float64, bool) {
+	usage := newGPUUsage(nodes)
+	plan := &DefragPlan{TargetGPUs: targetGPUs, NodeID: target.NodeID, FreeGPUs: usage.freeGPUs(target.NodeID)}
+	need := targetGPUs - plan.FreeGPUs
+	if need <= 0 {
+		return plan, 0, true
+	}
+
+	// Devices holding an allocation that cannot move stay where they are
+	pinned := make(map[int]bool)
+	for _, alloc := range target.Allocations {
+		if !alloc.Migratable {
+			pinned[alloc.GPU] = true
+		}
+	}
+	var evictable []int
+	for gpu, share := range usage[target.NodeID] {
+		if share > gpuShareEpsilon && !pinned[gpu] {
+			evictable = append(evictable, gpu)
+		}
+	}
+	if len(evictable) < need {
+		return nil, 0, false
+	}
+	sort.SliceStable(evictable, func(i, j int) bool {
+		return usage[target.NodeID][evictable[i]] < usage[target.NodeID][evictable[j]]
+	})
+	evict := make(map[int]bool, need)
+	for _, gpu := range evictable[:need] {
+		evict[gpu] = true
+	}
+
+	var moves []GPUAllocation
+	for _, alloc := range target.Allocations {
+		if evict[alloc.GPU] {
+			moves = append(moves, alloc)
+		}
+	}
+	// Place the largest shares first, they are the hardest to fit
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].Share > moves[j].Share })
+
+	var moved float64
+	for _, alloc := range moves {
+		nodeID, gpu, ok := usage.place(nodes, target.NodeID, alloc.Share)
+		if !ok {
+			return nil, 0, false
+		}
+		usage[nodeID][gpu] += alloc.Share
+		moved += alloc.Share
+		plan.Migrations = append(plan.Migrations, GPUMigration{
+			JobID:       alloc.JobID,
+			ExecutionID: alloc.ExecutionID,
+			FromNode:    target.NodeID,
+			FromGPU:     alloc.GPU,
+			ToNode:      nodeID,
+			ToGPU:       gpu,
+			Share:       alloc.Share,
+		})
+	}
+	return plan, moved, true
+}
+
+// place finds a device outside exclude for share. It prefers the partially
+// used device left fullest by the move, then a free device on the node with
+// the fewest free devices, so large free blocks stay intact.
+func (u gpuUsage) place(nodes []NodeGPUState, exclude string, share float64) (string, int, bool) {
+	bestNode, bestGPU, bestLeft := "", -1, 2.0
+	freeNode, freeGPU, freeCount := "", -1, 0
+	for _, node := range nodes {
+		if node.NodeID == exclude {
+			continue
+		}
+		free := u.freeGPUs(node.NodeID)
+		for gpu, used := range u[node.NodeID] {
+			if used <= gpuShareEpsilon {
+				if freeGPU < 0 || free < freeCount {
+					freeNode, freeGPU, freeCount = node.NodeID, gpu, free
+				}
+				continue
+			}
+			if left := 1 - used - share; left >= -gpuShareEpsilon && left < bestLeft {
+				bestNode, bestGPU, bestLeft = node.NodeID, gpu, left
+			}
+		}
+	}
+	if bestGPU >= 0 {
+		return bestNode, bestGPU, true
+	}
+	if freeGPU >= 0 {
+		return freeNode, freeGPU, true
+	}
+	return "", 0, false
+}
+
+// WithGPUDefragmenter exposes a GPU defragmentation planner to operators.
+func WithGPUDefragmenter(defragmenter *GPUDefragmenter) EndpointOption {
+	return func(e *Endpoint) {
+		e.defragmenter = defragmenter
+	}
+}
+
+// PlanGPUDefragmentation proposes migrations that free targetGPUs whole GPUs
+// on one node, without moving anything.
+func (e *Endpoint) PlanGPUDefragmentation(ctx context.Context, targetGPUs int) (*DefragPlan, error) {
+	if e.defragmenter == nil {
+		return nil, ErrDefragNotConfigured
+	}
+	return e.defragmenter.Plan(ctx, targetGPUs)
+}
+
+// GetGPUDefragmentationPlan returns the most recently proposed plan.
+func (e *Endpoint) GetGPUDefragmentationPlan() (*DefragPlan, bool) {
+	if e.defragmenter == nil {
+		return nil, false
+	}
+	return e.defragmenter.LastPlan()
+}
+
+// ApplyGPUDefragmentation re-plans for targetGPUs against the current
+// allocations and runs the migrations. It fails with ErrDefragAdvisory
+// unless the planner is in enforce mode.
+func (e *Endpoint) ApplyGPUDefragmentation(ctx context.Context, targetGPUs int) (*DefragPlan, error) {
+	if e.defragmenter == nil {
+		return nil, ErrDefragNotConfigured
+	}
+	if e.defragmenter.Mode() != DefragModeEnforce {
+		return nil, ErrDefragAdvisory
+	}
+	plan, err := e.defragmenter.Plan(ctx, targetGPUs)
+	if err != nil {
+		return nil, err
+	}
+	return plan, e.defragmenter.Apply(ctx, plan)
+}
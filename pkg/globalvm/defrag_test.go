@@ -0,0 +1,143 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticGPUInventory []NodeGPUState
+
+func (i staticGPUInventory) GPUState(ctx context.Context) ([]NodeGPUState, error) {
+	return append([]NodeGPUState(nil), i...), nil
+}
+
+// recordingRestorer records the migrations it is asked to run.
+type recordingRestorer struct {
+	err      error
+	restored []string
+}
+
+func (r *recordingRestorer) Checkpoint(_ context.Context, executionID, _ string) (string, error) {
+	return "ckpt-" + executionID, nil
+}
+
+func (r *recordingRestorer) Restore(_ context.Context, checkpointID, nodeID string, _ int) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.restored = append(r.restored, checkpointID+"@"+nodeID)
+	return nil
+}
+
+// fragmentedCluster has 11 free GPUs in total, but no node with 8.
+func fragmentedCluster() staticGPUInventory {
+	return staticGPUInventory{
+		{NodeID: "node-a", GPUs: 8, Allocations: []GPUAllocation{
+			{JobID: "job-1", ExecutionID: "exec-1", GPU: 0, Share: 0.25, Migratable: true},
+			{JobID: "job-2", ExecutionID: "exec-2", GPU: 1, Share: 0.5, Migratable: true},
+		}},
+		{NodeID: "node-b", GPUs: 8, Allocations: []GPUAllocation{
+			{JobID: "job-3", ExecutionID: "exec-3", GPU: 0, Share: 1},
+			{JobID: "job-4", ExecutionID: "exec-4", GPU: 1, Share: 1, Migratable: true},
+			{JobID: "job-5", ExecutionID: "exec-5", GPU: 2, Share: 0.5, Migratable: true},
+			{JobID: "job-6", ExecutionID: "exec-6", GPU: 3, Share: 0.5},
+		}},
+	}
+}
+
+func TestGPUDefragmenter_Plan(t *testing.T) {
+	d := NewGPUDefragmenter(fragmentedCluster())
+
+	plan, err := d.Plan(context.Background(), 8)
+	require.NoError(t, err)
+
+	assert.Equal(t, DefragModeAdvisory, plan.Mode)
+	assert.Equal(t, "node-a", plan.NodeID)
+	assert.Equal(t, 6, plan.FreeGPUs)
+	require.Len(t, plan.Migrations, 2)
+
+	// Both slices are packed onto node-b's partially used GPUs
+	assert.Equal(t, GPUMigration{
+		JobID: "job-2", ExecutionID: "exec-2", FromNode: "node-a", FromGPU: 1, ToNode: "node-b", ToGPU: 2, Share: 0.5,
+	}, plan.Migrations[0])
+	assert.Equal(t, "node-b", plan.Migrations[1].ToNode)
+	assert.Equal(t, 3, plan.Migrations[1].ToGPU)
+
+	last, ok := d.LastPlan()
+	require.True(t, ok)
+	assert.Equal(t, plan, last)
+}
+
+func TestGPUDefragmenter_Plan_AlreadyFree(t *testing.T) {
+	d := NewGPUDefragmenter(fragmentedCluster())
+
+	plan, err := d.Plan(context.Background(), 4)
+	require.NoError(t, err)
+	assert.Equal(t, "node-a", plan.NodeID)
+	assert.Empty(t, plan.Migrations)
+}
+
+func TestGPUDefragmenter_Plan_NoPlan(t *testing.T) {
+	inventory := staticGPUInventory{
+		{NodeID: "node-a", GPUs: 2, Allocations: []GPUAllocation{
+			{JobID: "job-1", ExecutionID: "exec-1", GPU: 0, Share: 0.5, Migratable: true},
+		}},
+		{NodeID: "node-b", GPUs: 2, Allocations: []GPUAllocation{
+			{JobID: "job-2", ExecutionID: "exec-2", GPU: 0, Share: 1},
+			{JobID: "job-3", ExecutionID: "exec-3", GPU: 1, Share: 1},
+		}},
+	}
+	d := NewGPUDefragmenter(inventory)
+
+	_, err := d.Plan(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrNoDefragPlan, "node-b is full, so job-1 has nowhere to go")
+
+	_, err = d.Plan(context.Background(), 4)
+	assert.ErrorIs(t, err, ErrNoDefragPlan)
+
+	_, ok := d.LastPlan()
+	assert.False(t, ok)
+}
+
+func TestGPUDefragmenter_Apply(t *testing.T) {
+	ctx := context.Background()
+
+	advisory := NewGPUDefragmenter(fragmentedCluster())
+	plan, err := advisory.Plan(ctx, 8)
+	require.NoError(t, err)
+	assert.ErrorIs(t, advisory.Apply(ctx, plan), ErrDefragAdvisory)
+
+	restorer := &recordingRestorer{}
+	enforce := NewGPUDefragmenter(fragmentedCluster(), WithDefragMode(DefragModeEnforce), WithCheckpointRestorer(restorer))
+	plan, err = enforce.Plan(ctx, 8)
+	require.NoError(t, err)
+	require.NoError(t, enforce.Apply(ctx, plan))
+	assert.Equal(t, []string{"ckpt-exec-2@node-b", "ckpt-exec-1@node-b"}, restorer.restored)
+
+	restorer.err = errors.New("restore failed")
+	assert.ErrorContains(t, enforce.Apply(ctx, plan), "exec-2")
+}
+
+func TestEndpoint_GPUDefragmentation(t *testing.T) {
+	ctx := context.Background()
+
+	endpoint := NewEndpoint(nil, &mockCapacityProvider{})
+	_, err := endpoint.PlanGPUDefragmentation(ctx, 8)
+	assert.ErrorIs(t, err, ErrDefragNotConfigured)
+
+	endpoint = NewEndpoint(nil, &mockCapacityProvider{}, WithGPUDefragmenter(NewGPUDefragmenter(fragmentedCluster())))
+	plan, err := endpoint.PlanGPUDefragmentation(ctx, 8)
+	require.NoError(t, err)
+	last, ok := endpoint.GetGPUDefragmentationPlan()
+	require.True(t, ok)
+	assert.Equal(t, plan, last)
+
+	_, err = endpoint.ApplyGPUDefragmentation(ctx, 8)
+	assert.ErrorIs(t, err, ErrDefragAdvisory)
+}
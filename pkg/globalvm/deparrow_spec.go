@@ -0,0 +1,525 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"k8s.io/apimachinery/pkg/selection"
+
+	dockermodels "github.com/bacalhau-project/bacalhau/pkg/executor/docker/models"
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/s3"
+)
+
+// DEparrow storage types used by job inputs and outputs.
+const (
+	DeparrowStorageIPFS   = "ipfs"
+	DeparrowStorageS3     = "s3"
+	DeparrowStorageURL    = "url"
+	DeparrowStorageInline = "inline"
+)
+
+// Verification modes of a verified DEparrow job.
+const (
+	// DeparrowVerifyTEE places the job on a node with a trusted execution
+	// environment.
+	DeparrowVerifyTEE = "tee"
+
+	// DeparrowVerifyRedundant runs the job on several nodes so their
+	// results can be compared.
+	DeparrowVerifyRedundant = "redundant"
+)
+
+// DefaultDeparrowVerifyReplicas is how many nodes run a redundant job that
+// does not ask for a number.
+const DefaultDeparrowVerifyReplicas = 3
+
+const (
+	// LabelNodeTEE is the node label set to "true" on nodes that run jobs in
+	// a trusted execution environment.
+	LabelNodeTEE = "tee"
+
+	// LabelJobVerification is the job label holding the verification mode
+	// of a verified DEparrow job.
+	LabelJobVerification = "verification"
+)
+
+// DeparrowJobSpec is the job specification accepted by the DEparrow gateway.
+// It mirrors the JSON wire format of deparrow.JobSpec so gateway requests can
+// be decoded into it directly.
+type DeparrowJobSpec struct {
+	Image     string                `json:"image"`
+	Command   []string              `json:"command,omitempty"`
+	Env       map[string]string     `json:"env,omitempty"`
+	Resources *DeparrowResourceSpec `json:"resources,omitempty"`
+	Inputs    []DeparrowInputSpec   `json:"inputs,omitempty"`
+	Outputs   []DeparrowOutputSpec  `json:"outputs,omitempty"`
+	// Timeout in seconds (0 = default)
+	Timeout int `json:"timeout,omitempty"`
+	// Priority level (0-100, higher = more priority)
+	Priority int               `json:"priority,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	// Verified requests a verified result; Verification picks how
+	Verified     bool                      `json:"verified,omitempty"`
+	Verification *DeparrowVerificationSpec `json:"verification,omitempty"`
+}
+
+// DeparrowVerificationSpec picks how the result of a verified DEparrow job
+// is checked. The mode defaults to redundant execution.
+type DeparrowVerificationSpec struct {
+	Mode string `json:"mode,omitempty"`
+	// Replicas is the number of nodes a redundant job runs on
+	Replicas int `json:"replicas,omitempty"`
+}
+
+// DeparrowResourceSpec defines the resource requirements of a DEparrow job.
+type DeparrowResourceSpec struct {
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	GPU     string `json:"gpu,omitempty"`
+	Storage string `json:"storage,omitempty"`
+}
+
+// DeparrowInputSpec defines an input data source of a DEparrow job.
+type DeparrowInputSpec struct {
+	StorageSource string            `json:"storage_source"`
+	Source        string            `json:"source"`
+	Path          string            `json:"path"`
+	S3Config      *DeparrowS3Config `json:"s3_config,omitempty"`
+}
+
+// DeparrowOutputSpec defines an output of a DEparrow job.
+type DeparrowOutputSpec struct {
+	Path               string            `json:"path"`
+	StorageDestination string            `json:"storage_destination"`
+	S3Config           *DeparrowS3Config `json:"s3_config,omitempty"`
+}
+
+// DeparrowS3Config holds the S3 location of a DEparrow input or output.
+type DeparrowS3Config struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	Region    string `json:"region,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+// JobFromDeparrowSpec converts a DEparrow job spec into a single-task batch
+// job that can be submitted to the Endpoint. The returned warnings list the
+// parts of the spec that have no equivalent in the job model and were dropped.
+func JobFromDeparrowSpec(jobID string, spec DeparrowJobSpec) (*models.Job, []string, error) {
+	var warnings []string
+
+	engine := dockermodels.NewDockerEngineBuilder(spec.Image)
+	if len(spec.Command) > 0 {
+		engine = engine.WithEntrypoint(spec.Command...)
+	}
+	engineSpec, err := engine.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task := &models.Task{
+		Name:   "main",
+		Engine: engineSpec,
+		Env:    models.EnvVarsFromStringsMap(spec.Env),
+	}
+	if spec.Timeout > 0 {
+		task.Timeouts = &models.TimeoutConfig{ExecutionTimeout: int64(spec.Timeout)}
+	}
+	if r := spec.Resources; r != nil {
+		task.ResourcesConfig = &models.ResourcesConfig{
+			CPU:    r.CPU,
+			Memory: r.Memory,
+			GPU:    r.GPU,
+			Disk:   r.Storage,
+		}
+	}
+
+	for i, input := range spec.Inputs {
+		source, warning, err := inputSourceFromDeparrow(input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		if warning != "" {
+			warnings = append(warnings, fmt.Sprintf("Input %s: %s", input.Path, warning))
+		}
+		task.InputSources = append(task.InputSources, &models.InputSource{Source: source, Target: input.Path})
+	}
+
+	publisher, outputWarnings, err := publisherFromDeparrow(spec.Outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, outputWarnings...)
+	task.Publisher = publisher
+	task.ResultPaths = resultPathsFromDeparrow(spec.Outputs)
+
+	job := &models.Job{
+		ID:        jobID,
+		Name:      jobID,
+		Namespace: models.DefaultNamespace,
+		Type:      models.JobTypeBatch,
+		Count:     1,
+		Priority:  spec.Priority,
+		Labels:    spec.Labels,
+		Tasks:     []*models.Task{task},
+	}
+	if err := applyDeparrowVerification(job, spec); err != nil {
+		return nil, nil, err
+	}
+	job.Normalize()
+	return job, warnings, nil
+}
+
+// applyDeparrowVerification turns a verified spec into placement: TEE jobs
+// are constrained to TEE nodes and redundant jobs run once per replica. The
+// mode is kept in a job label so results can be compared on completion.
+func applyDeparrowVerification(job *models.Job, spec DeparrowJobSpec) error {
+	if !spec.Verified {
+		if spec.Verification != nil {
+			return fmt.Errorf("verification settings require verified to be set")
+		}
+		return nil
+	}
+
+	verification := DeparrowVerificationSpec{}
+	if spec.Verification != nil {
+		verification = *spec.Verification
+	}
+	if verification.Mode == "" {
+		verification.Mode = DeparrowVerifyRedundant
+	}
+
+	switch verification.Mode {
+	case DeparrowVerifyTEE:
+		if verification.Replicas > 1 {
+			return fmt.Errorf("TEE verification runs on a single node, got %d replicas", verification.Replicas)
+		}
+		job.Constraints = append(job.Constraints, &models.LabelSelectorRequirement{
+			Key:      LabelNodeTEE,
+			Operator: selection.Equals,
+			Values:   []string{"true"},
+		})
+	case DeparrowVerifyRedundant:
+		if verification.Replicas == 0 {
+			verification.Replicas = DefaultDeparrowVerifyReplicas
+		}
+		if verification.Replicas < 2 {
+			return fmt.Errorf("redundant verification needs at least 2 replicas, got %d", verification.Replicas)
+		}
+		job.Count = verification.Replicas
+	default:
+		return fmt.Errorf("unsupported verification mode: %s", verification.Mode)
+	}
+
+	labels := make(map[string]string, len(job.Labels)+1)
+	for k, v := range job.Labels {
+		labels[k] = v
+	}
+	labels[LabelJobVerification] = verification.Mode
+	job.Labels = labels
+	return nil
+}
+
+// inputSourceFromDeparrow maps a DEparrow input onto a storage source spec.
+func inputSourceFromDeparrow(input DeparrowInputSpec) (*models.SpecConfig, string, error) {
+	switch input.StorageSource {
+	case DeparrowStorageIPFS:
+		return models.NewSpecConfig(models.StorageSourceIPFS).WithParam("CID", input.Source), "", nil
+	case DeparrowStorageURL:
+		return models.NewSpecConfig(models.StorageSourceURL).WithParam("URL", input.Source), "", nil
+	case DeparrowStorageInline:
+		return models.NewSpecConfig(models.StorageSourceInline).WithParam("URL", input.Source), "", nil
+	case DeparrowStorageS3:
+		cfg := input.S3Config
+		if cfg == nil {
+			bucket, key, _ := strings.Cut(strings.TrimPrefix(input.Source, "s3://"), "/")
+			cfg = &DeparrowS3Config{Bucket: bucket, Key: key}
+		}
+		source := s3.SourceSpec{Bucket: cfg.Bucket, Key: cfg.Key, Region: cfg.Region, Endpoint: cfg.Endpoint}
+		if err := source.Validate(); err != nil {
+			return nil, "", err
+		}
+		return &models.SpecConfig{Type: models.StorageSourceS3, Params: source.ToMap()}, s3CredentialsWarning(cfg), nil
+	default:
+		return nil, "", fmt.Errorf("unsupported storage source %q", input.StorageSource)
+	}
+}
+
+// publisherFromDeparrow picks the task publisher. A task has a single
+// publisher, so outputs with other destinations are published to the first.
+func publisherFromDeparrow(outputs []DeparrowOutputSpec) (*models.SpecConfig, []string, error) {
+	if len(outputs) == 0 {
+		return nil, nil, nil
+	}
+
+	var warnings []string
+	first := outputs[0]
+	for _, output := range outputs[1:] {
+		if output.StorageDestination != first.StorageDestination {
+			warnings = append(warnings, fmt.Sprintf(
+				"Output %s: published to %s with the other outputs instead of %s",
+				output.Path, first.StorageDestination, output.StorageDestination))
+		}
+	}
+
+	switch first.StorageDestination {
+	case DeparrowStorageIPFS:
+		return models.NewSpecConfig(models.PublisherIPFS), warnings, nil
+	case DeparrowStorageS3:
+		if first.S3Config == nil {
+			return nil, nil, fmt.Errorf("output %s: s3_config is required for s3 outputs", first.Path)
+		}
+		publisher := s3.PublisherSpec{
+			Bucket:   first.S3Config.Bucket,
+			Key:      first.S3Config.Key,
+			Region:   first.S3Config.Region,
+			Endpoint: first.S3Config.Endpoint,
+		}
+		if err := publisher.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if warning := s3CredentialsWarning(first.S3Config); warning != "" {
+			warnings = append(warnings, fmt.Sprintf("Output %s: %s", first.Path, warning))
+		}
+		return &models.SpecConfig{Type: models.PublisherS3, Params: publisher.ToMap()}, warnings, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported storage destination %q", first.StorageDestination)
+	}
+}
+
+// resultPathsFromDeparrow names each output after the last element of its path.
+func resultPathsFromDeparrow(outputs []DeparrowOutputSpec) []*models.ResultPath {
+	var paths []*models.ResultPath
+	used := make(map[string]bool)
+	for i, output := range outputs {
+		name := path.Base(output.Path)
+		if name == "/" || name == "." || used[name] {
+			name = fmt.Sprintf("output-%d", i)
+		}
+		used[name] = true
+		paths = append(paths, &models.ResultPath{Name: name, Path: output.Path})
+	}
+	return paths
+}
+
+func s3CredentialsWarning(cfg *DeparrowS3Config) string {
+	if cfg.AccessKey != "" || cfg.SecretKey != "" {
+		return "S3 credentials are not carried over, the compute node's own credentials are used"
+	}
+	return ""
+}
+
+// DeparrowSpecFromJob converts the first task of a job back into a DEparrow
+// job spec. Only Docker tasks can be converted. The returned warnings list
+// the job settings that have no equivalent in the DEparrow spec.
+func DeparrowSpecFromJob(job *models.Job) (DeparrowJobSpec, []string, error) {
+	if job == nil || len(job.Tasks) == 0 {
+		return DeparrowJobSpec{}, nil, fmt.Errorf("job has no tasks")
+	}
+
+	var warnings []string
+	if len(job.Tasks) > 1 {
+		warnings = append(warnings, fmt.Sprintf("Only the first of %d tasks is converted", len(job.Tasks)))
+	}
+	verified, verification, labels, constraints := deparrowVerificationFromJob(job)
+	if job.Count > 1 && verification.Mode != DeparrowVerifyRedundant {
+		warnings = append(warnings, fmt.Sprintf("Count of %d dropped, DEparrow jobs run once", job.Count))
+	}
+	if len(constraints) > 0 {
+		warnings = append(warnings, "Node constraints dropped")
+	}
+
+	task := job.Tasks[0]
+	engine, err := dockermodels.DecodeSpec(task.Engine)
+	if err != nil {
+		return DeparrowJobSpec{}, nil, err
+	}
+	if engine.WorkingDirectory != "" {
+		warnings = append(warnings, "Working directory dropped")
+	}
+
+	spec := DeparrowJobSpec{
+		Image:    engine.Image,
+		Command:  append(append([]string{}, engine.Entrypoint...), engine.Parameters...),
+		Priority: job.Priority,
+		Labels:   labels,
+		Verified: verified,
+	}
+	if verified {
+		spec.Verification = &verification
+	}
+
+	env := models.EnvVarsToStringMap(task.Env)
+	for _, kv := range engine.EnvironmentVariables {
+		name, value, _ := strings.Cut(kv, "=")
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env[name] = value
+	}
+	if len(env) > 0 {
+		spec.Env = env
+	}
+
+	if r := task.ResourcesConfig; r != nil && (r.CPU != "" || r.Memory != "" || r.GPU != "" || r.Disk != "") {
+		spec.Resources = &DeparrowResourceSpec{CPU: r.CPU, Memory: r.Memory, GPU: r.GPU, Storage: r.Disk}
+	}
+	if t := task.Timeouts; t != nil {
+		spec.Timeout = int(t.ExecutionTimeout)
+		if t.QueueTimeout > 0 || t.TotalTimeout > 0 {
+			warnings = append(warnings, "Queue and total timeouts dropped")
+		}
+	}
+	if task.Network != nil && task.Network.Type != models.NetworkDefault && task.Network.Type != models.NetworkNone {
+		warnings = append(warnings, fmt.Sprintf("Network mode %s dropped", task.Network.Type))
+	}
+
+	for _, source := range task.InputSources {
+		input, err := deparrowInputFromSource(source)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Input %s dropped: %v", source.Target, err))
+			continue
+		}
+		if source.Alias != "" {
+			warnings = append(warnings, fmt.Sprintf("Input %s: alias %s dropped", source.Target, source.Alias))
+		}
+		spec.Inputs = append(spec.Inputs, input)
+	}
+
+	outputs, outputWarnings := deparrowOutputsFromTask(task)
+	spec.Outputs = outputs
+	warnings = append(warnings, outputWarnings...)
+
+	return spec, warnings, nil
+}
+
+// deparrowVerificationFromJob recovers the verification settings of a job
+// created from a verified spec. It returns the job's labels and constraints
+// without the ones the verification added.
+func deparrowVerificationFromJob(job *models.Job) (
+	bool, DeparrowVerificationSpec, map[string]string, []*models.LabelSelectorRequirement,
+) {
+	mode, ok := job.Labels[LabelJobVerification]
+	if !ok {
+		return false, DeparrowVerificationSpec{}, job.Labels, job.Constraints
+	}
+
+	var labels map[string]string
+	for k, v := range job.Labels {
+		if k == LabelJobVerification {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string, len(job.Labels)-1)
+		}
+		labels[k] = v
+	}
+
+	verification := DeparrowVerificationSpec{Mode: mode}
+	constraints := job.Constraints
+	switch mode {
+	case DeparrowVerifyRedundant:
+		verification.Replicas = job.Count
+	case DeparrowVerifyTEE:
+		constraints = nil
+		for _, c := range job.Constraints {
+			if c.Key != LabelNodeTEE {
+				constraints = append(constraints, c)
+			}
+		}
+	}
+	return true, verification, labels, constraints
+}
+
+// deparrowInputFromSource maps a storage source spec back onto a DEparrow input.
+func deparrowInputFromSource(source *models.InputSource) (DeparrowInputSpec, error) {
+	input := DeparrowInputSpec{Path: source.Target}
+	if source.Source == nil {
+		return input, fmt.Errorf("no source")
+	}
+
+	var params struct {
+		CID string
+		URL string
+	}
+	switch source.Source.Type {
+	case models.StorageSourceIPFS, models.StorageSourceURL, models.StorageSourceInline:
+		if err := mapstructure.Decode(source.Source.Params, &params); err != nil {
+			return input, err
+		}
+	}
+
+	switch source.Source.Type {
+	case models.StorageSourceIPFS:
+		input.StorageSource, input.Source = DeparrowStorageIPFS, params.CID
+	case models.StorageSourceURL:
+		input.StorageSource, input.Source = DeparrowStorageURL, params.URL
+	case models.StorageSourceInline:
+		input.StorageSource, input.Source = DeparrowStorageInline, params.URL
+	case models.StorageSourceS3:
+		var s3Source s3.SourceSpec
+		if err := mapstructure.Decode(source.Source.Params, &s3Source); err != nil {
+			return input, err
+		}
+		input.StorageSource = DeparrowStorageS3
+		input.Source = fmt.Sprintf("s3://%s/%s", s3Source.Bucket, s3Source.Key)
+		input.S3Config = &DeparrowS3Config{
+			Bucket:   s3Source.Bucket,
+			Key:      s3Source.Key,
+			Region:   s3Source.Region,
+			Endpoint: s3Source.Endpoint,
+		}
+	default:
+		return input, fmt.Errorf("storage source %s is not supported by DEparrow", source.Source.Type)
+	}
+	return input, nil
+}
+
+// deparrowOutputsFromTask maps the task's result paths onto DEparrow outputs
+// published to the task's publisher.
+func deparrowOutputsFromTask(task *models.Task) ([]DeparrowOutputSpec, []string) {
+	if len(task.ResultPaths) == 0 {
+		return nil, nil
+	}
+
+	var destination string
+	var s3Config *DeparrowS3Config
+	publisherType := ""
+	if task.Publisher != nil {
+		publisherType = task.Publisher.Type
+	}
+	switch publisherType {
+	case models.PublisherIPFS:
+		destination = DeparrowStorageIPFS
+	case models.PublisherS3:
+		var publisher s3.PublisherSpec
+		if err := mapstructure.Decode(task.Publisher.Params, &publisher); err != nil {
+			return nil, []string{fmt.Sprintf("Outputs dropped: %v", err)}
+		}
+		destination = DeparrowStorageS3
+		s3Config = &DeparrowS3Config{
+			Bucket:   publisher.Bucket,
+			Key:      publisher.Key,
+			Region:   publisher.Region,
+			Endpoint: publisher.Endpoint,
+		}
+	default:
+		if publisherType == "" {
+			publisherType = "no"
+		}
+		return nil, []string{fmt.Sprintf("Outputs dropped: %s publisher is not supported by DEparrow", publisherType)}
+	}
+
+	outputs := make([]DeparrowOutputSpec, 0, len(task.ResultPaths))
+	for _, p := range task.ResultPaths {
+		outputs = append(outputs, DeparrowOutputSpec{Path: p.Path, StorageDestination: destination, S3Config: s3Config})
+	}
+	return outputs, nil
+}
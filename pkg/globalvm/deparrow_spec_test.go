@@ -0,0 +1,195 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dockermodels "github.com/bacalhau-project/bacalhau/pkg/executor/docker/models"
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+func TestJobFromDeparrowSpec(t *testing.T) {
+	var spec DeparrowJobSpec
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"image": "python:3.11",
+		"command": ["python", "train.py"],
+		"env": {"EPOCHS": "10"},
+		"resources": {"cpu": "2", "memory": "4Gi", "gpu": "1", "storage": "10Gi"},
+		"inputs": [
+			{"storage_source": "ipfs", "source": "QmData", "path": "/inputs/data"},
+			{"storage_source": "s3", "source": "s3://datasets/train.csv", "path": "/inputs/train.csv"}
+		],
+		"outputs": [
+			{"path": "/outputs/model", "storage_destination": "ipfs"},
+			{"path": "/outputs/logs", "storage_destination": "s3", "s3_config": {"bucket": "b", "key": "k"}}
+		],
+		"timeout": 3600,
+		"priority": 50,
+		"labels": {"team": "ml"}
+	}`), &spec))
+
+	job, warnings, err := JobFromDeparrowSpec("job-1", spec)
+	require.NoError(t, err)
+	require.NoError(t, job.Validate())
+
+	assert.Equal(t, models.JobTypeBatch, job.Type)
+	assert.Equal(t, 50, job.Priority)
+	assert.Equal(t, "ml", job.Labels["team"])
+
+	task := job.Task()
+	engine, err := dockermodels.DecodeSpec(task.Engine)
+	require.NoError(t, err)
+	assert.Equal(t, "python:3.11", engine.Image)
+	assert.Equal(t, []string{"python", "train.py"}, engine.Entrypoint)
+	assert.Equal(t, models.EnvVarValue("10"), task.Env["EPOCHS"])
+	// Resource quantities are normalized to lower case
+	assert.Equal(t, &models.ResourcesConfig{CPU: "2", Memory: "4gi", GPU: "1", Disk: "10gi"}, task.ResourcesConfig)
+	assert.Equal(t, int64(3600), task.Timeouts.ExecutionTimeout)
+
+	require.Len(t, task.InputSources, 2)
+	assert.Equal(t, models.StorageSourceIPFS, task.InputSources[0].Source.Type)
+	assert.Equal(t, "QmData", task.InputSources[0].Source.Params["CID"])
+	assert.Equal(t, models.StorageSourceS3, task.InputSources[1].Source.Type)
+	assert.Equal(t, "datasets", task.InputSources[1].Source.Params["Bucket"])
+
+	assert.Equal(t, models.PublisherIPFS, task.Publisher.Type)
+	require.Len(t, task.ResultPaths, 2)
+	assert.Equal(t, "model", task.ResultPaths[0].Name)
+
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "/outputs/logs")
+}
+
+func TestJobFromDeparrowSpec_Errors(t *testing.T) {
+	_, _, err := JobFromDeparrowSpec("job-1", DeparrowJobSpec{})
+	assert.Error(t, err, "image is required")
+
+	_, _, err = JobFromDeparrowSpec("job-1", DeparrowJobSpec{
+		Image:  "alpine",
+		Inputs: []DeparrowInputSpec{{StorageSource: "ftp", Source: "ftp://host/file", Path: "/in"}},
+	})
+	assert.ErrorContains(t, err, "unsupported storage source")
+
+	_, _, err = JobFromDeparrowSpec("job-1", DeparrowJobSpec{
+		Image:   "alpine",
+		Outputs: []DeparrowOutputSpec{{Path: "/out", StorageDestination: "s3"}},
+	})
+	assert.ErrorContains(t, err, "s3_config")
+}
+
+func TestJobFromDeparrowSpec_Verified(t *testing.T) {
+	var spec DeparrowJobSpec
+	require.NoError(t, json.Unmarshal([]byte(`{"image": "alpine", "verified": true}`), &spec))
+
+	job, _, err := JobFromDeparrowSpec("job-1", spec)
+	require.NoError(t, err)
+	require.NoError(t, job.Validate())
+	assert.Equal(t, DefaultDeparrowVerifyReplicas, job.Count)
+	assert.Equal(t, DeparrowVerifyRedundant, job.Labels[LabelJobVerification])
+	assert.Empty(t, job.Constraints)
+
+	spec.Labels = map[string]string{"team": "ml"}
+	spec.Verification = &DeparrowVerificationSpec{Mode: DeparrowVerifyTEE}
+	job, _, err = JobFromDeparrowSpec("job-2", spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Count)
+	require.Len(t, job.Constraints, 1)
+	assert.Equal(t, LabelNodeTEE, job.Constraints[0].Key)
+	assert.Equal(t, []string{"true"}, job.Constraints[0].Values)
+	assert.NotContains(t, spec.Labels, LabelJobVerification, "the spec's labels must not be modified")
+
+	for _, bad := range []DeparrowJobSpec{
+		{Image: "alpine", Verification: &DeparrowVerificationSpec{Mode: DeparrowVerifyTEE}},
+		{Image: "alpine", Verified: true, Verification: &DeparrowVerificationSpec{Mode: "zk"}},
+		{Image: "alpine", Verified: true, Verification: &DeparrowVerificationSpec{Mode: DeparrowVerifyRedundant, Replicas: 1}},
+		{Image: "alpine", Verified: true, Verification: &DeparrowVerificationSpec{Mode: DeparrowVerifyTEE, Replicas: 3}},
+	} {
+		_, _, err := JobFromDeparrowSpec("job-3", bad)
+		assert.Error(t, err, "%+v", bad.Verification)
+	}
+}
+
+func TestDeparrowSpec_RoundTripVerified(t *testing.T) {
+	for _, verification := range []*DeparrowVerificationSpec{
+		{Mode: DeparrowVerifyRedundant, Replicas: 5},
+		{Mode: DeparrowVerifyTEE},
+	} {
+		spec := DeparrowJobSpec{
+			Image:        "alpine",
+			Command:      []string{"sha256sum", "/inputs/data"},
+			Labels:       map[string]string{"env": "test"},
+			Verified:     true,
+			Verification: verification,
+		}
+
+		job, _, err := JobFromDeparrowSpec("job-1", spec)
+		require.NoError(t, err)
+
+		back, warnings, err := DeparrowSpecFromJob(job)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		assert.Equal(t, spec, back)
+	}
+}
+
+func TestDeparrowSpec_RoundTrip(t *testing.T) {
+	spec := DeparrowJobSpec{
+		Image:     "alpine",
+		Command:   []string{"sh", "-c", "echo hi"},
+		Env:       map[string]string{"A": "1"},
+		Resources: &DeparrowResourceSpec{CPU: "500m", Memory: "1gi"},
+		Inputs: []DeparrowInputSpec{
+			{StorageSource: DeparrowStorageURL, Source: "https://example.com/data.csv", Path: "/inputs/data.csv"},
+			{StorageSource: DeparrowStorageS3, Source: "s3://bucket/key", Path: "/inputs/s3",
+				S3Config: &DeparrowS3Config{Bucket: "bucket", Key: "key", Region: "us-east-1"}},
+		},
+		Outputs:  []DeparrowOutputSpec{{Path: "/outputs", StorageDestination: DeparrowStorageIPFS}},
+		Timeout:  60,
+		Priority: 10,
+		Labels:   map[string]string{"env": "test"},
+	}
+
+	job, warnings, err := JobFromDeparrowSpec("job-1", spec)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	back, warnings, err := DeparrowSpecFromJob(job)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, spec, back)
+}
+
+func TestDeparrowSpecFromJob_Lossiness(t *testing.T) {
+	job := createTestJob("job-1", models.JobTypeBatch, 3)
+	job.Tasks[0].Engine.Params["WorkingDirectory"] = "/work"
+	job.Tasks[0].InputSources = []*models.InputSource{
+		{Source: models.NewSpecConfig(models.StorageSourceLocalDirectory).WithParam("SourcePath", "/data"), Target: "/data"},
+		{Source: models.NewSpecConfig(models.StorageSourceIPFS).WithParam("CID", "QmX"), Target: "/ipfs", Alias: "weights"},
+	}
+	job.Tasks[0].ResultPaths = []*models.ResultPath{{Name: "out", Path: "/outputs"}}
+	job.Tasks[0].Publisher = models.NewSpecConfig(models.PublisherLocal)
+
+	spec, warnings, err := DeparrowSpecFromJob(job)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ubuntu:latest", spec.Image)
+	assert.Equal(t, []string{"echo", "hello"}, spec.Command)
+	require.Len(t, spec.Inputs, 1)
+	assert.Equal(t, "QmX", spec.Inputs[0].Source)
+	assert.Empty(t, spec.Outputs)
+
+	assert.Len(t, warnings, 5)
+	assert.Contains(t, warnings, "Count of 3 dropped, DEparrow jobs run once")
+	assert.Contains(t, warnings, "Working directory dropped")
+	assert.Contains(t, warnings, "Input /ipfs: alias weights dropped")
+	assert.Contains(t, warnings, "Outputs dropped: local publisher is not supported by DEparrow")
+
+	job.Tasks[0].Engine = models.NewSpecConfig(models.EngineWasm)
+	_, _, err = DeparrowSpecFromJob(job)
+	assert.Error(t, err, "only Docker tasks convert")
+}
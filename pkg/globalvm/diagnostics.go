@@ -0,0 +1,328 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator/nodes"
+)
+
+// Names of the self-test checks.
+const (
+	// CheckNodeLookup lists the nodes known to the orchestrator.
+	CheckNodeLookup = "node_lookup"
+
+	// CheckLatencyMatrix checks that latencies are still being reported.
+	CheckLatencyMatrix = "latency_matrix"
+
+	// CheckScheduler schedules a synthetic job, without leasing capacity.
+	CheckScheduler = "scheduler"
+)
+
+const (
+	// DefaultLatencyMaxAge is how old the newest latency may be before the
+	// matrix counts as stale.
+	DefaultLatencyMaxAge = 15 * time.Minute
+
+	// DefaultSelfTestTimeout bounds each self-test check.
+	DefaultSelfTestTimeout = 5 * time.Second
+
+	// DefaultReadinessTTL is how long a self-test result answers readiness
+	// probes before the checks run again.
+	DefaultReadinessTTL = 10 * time.Second
+)
+
+// CheckStatus is the outcome of a self-test check.
+type CheckStatus string
+
+const (
+	CheckPassed CheckStatus = "passed"
+	CheckFailed CheckStatus = "failed"
+
+	// CheckSkipped means the component the check needs is not configured.
+	CheckSkipped CheckStatus = "skipped"
+)
+
+// CheckResult is the outcome of one self-test check.
+type CheckResult struct {
+	Name     string        `json:"Name"`
+	Status   CheckStatus   `json:"Status"`
+	Message  string        `json:"Message,omitempty"`
+	Duration time.Duration `json:"Duration"`
+}
+
+// SelfTestReport is the outcome of a self-test run.
+type SelfTestReport struct {
+	// Ready is set when no check failed.
+	Ready bool `json:"Ready"`
+
+	// Checks holds the result of each check, in the order they ran.
+	Checks []CheckResult `json:"Checks"`
+
+	// RanAt is when the self-test started.
+	RanAt time.Time `json:"RanAt"`
+}
+
+// Check returns the result of the named check.
+func (r *SelfTestReport) Check(name string) (CheckResult, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return CheckResult{}, false
+}
+
+// Diagnostics runs end-to-end self-tests of the Global VM layer and serves
+// liveness and readiness probes for deployments. Checks whose component
+// is not configured are skipped.
+type Diagnostics struct {
+	nodeLookup    nodes.Lookup
+	latencyMatrix LatencyMatrix
+	latencyMaxAge time.Duration
+	scheduler     GlobalScheduler
+	syntheticJob  *models.Job
+	checkTimeout  time.Duration
+	readinessTTL  time.Duration
+	now           func() time.Time
+	started       time.Time
+
+	mu   sync.Mutex
+	last *SelfTestReport
+}
+
+// DiagnosticsOption configures the diagnostics.
+type DiagnosticsOption func(*Diagnostics)
+
+// WithDiagnosticNodeLookup checks that the node lookup can list nodes.
+func WithDiagnosticNodeLookup(lookup nodes.Lookup) DiagnosticsOption {
+	return func(d *Diagnostics) {
+		d.nodeLookup = lookup
+	}
+}
+
+// WithDiagnosticLatencyMatrix checks that the newest latency in the matrix
+// is no older than maxAge. Zero uses DefaultLatencyMaxAge.
+func WithDiagnosticLatencyMatrix(matrix LatencyMatrix, maxAge time.Duration) DiagnosticsOption {
+	return func(d *Diagnostics) {
+		d.latencyMatrix = matrix
+		if maxAge > 0 {
+			d.latencyMaxAge = maxAge
+		}
+	}
+}
+
+// WithDiagnosticScheduler checks that the scheduler can select a node for
+// a synthetic job.
+func WithDiagnosticScheduler(scheduler GlobalScheduler) DiagnosticsOption {
+	return func(d *Diagnostics) {
+		d.scheduler = scheduler
+	}
+}
+
+// WithSyntheticJob sets the job the scheduler check schedules, for
+// networks where the default small docker job can't run.
+func WithSyntheticJob(job *models.Job) DiagnosticsOption {
+	return func(d *Diagnostics) {
+		d.syntheticJob = job
+	}
+}
+
+// WithSelfTestTimeout sets how long each check may take.
+func WithSelfTestTimeout(timeout time.Duration) DiagnosticsOption {
+	return func(d *Diagnostics) {
+		d.checkTimeout = timeout
+	}
+}
+
+// WithReadinessTTL sets how long a self-test result answers readiness
+// probes. Zero runs the checks on every probe.
+func WithReadinessTTL(ttl time.Duration) DiagnosticsOption {
+	return func(d *Diagnostics) {
+		d.readinessTTL = ttl
+	}
+}
+
+// NewDiagnostics creates the diagnostics of the Global VM components given
+// by opts.
+func NewDiagnostics(opts ...DiagnosticsOption) *Diagnostics {
+	d := &Diagnostics{
+		latencyMaxAge: DefaultLatencyMaxAge,
+		syntheticJob:  defaultSyntheticJob(),
+		checkTimeout:  DefaultSelfTestTimeout,
+		readinessTTL:  DefaultReadinessTTL,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.started = d.now()
+	return d
+}
+
+// defaultSyntheticJob is a small batch job any compute node can run.
+func defaultSyntheticJob() *models.Job {
+	job := &models.Job{
+		ID:        "globalvm-self-test",
+		Name:      "globalvm-self-test",
+		Type:      models.JobTypeBatch,
+		Count:     1,
+		Namespace: "default",
+		Tasks: []*models.Task{
+			{
+				Name: "main",
+				Engine: &models.SpecConfig{
+					Type: "docker",
+					Params: map[string]interface{}{
+						"Image":      "busybox:latest",
+						"Entrypoint": []string{"true"},
+					},
+				},
+				Publisher: &models.SpecConfig{},
+				ResourcesConfig: &models.ResourcesConfig{
+					CPU:    "100m",
+					Memory: "64Mi",
+				},
+			},
+		},
+	}
+	job.Normalize()
+	return job
+}
+
+// RunSelfTest runs every check and records the report for readiness probes.
+func (d *Diagnostics) RunSelfTest(ctx context.Context) *SelfTestReport {
+	report := &SelfTestReport{Ready: true, RanAt: d.now()}
+	for _, check := range []struct {
+		name string
+		run  func(context.Context) (CheckStatus, string)
+	}{
+		{CheckNodeLookup, d.checkNodeLookup},
+		{CheckLatencyMatrix, d.checkLatencyMatrix},
+		{CheckScheduler, d.checkScheduler},
+	} {
+		checkCtx, cancel := context.WithTimeout(ctx, d.checkTimeout)
+		start := time.Now()
+		status, message := check.run(checkCtx)
+		cancel()
+
+		result := CheckResult{Name: check.name, Status: status, Message: message, Duration: time.Since(start)}
+		if status == CheckFailed {
+			report.Ready = false
+			componentLogger(ctx, ComponentDiagnostics).Warn().
+				Str("check", check.name).
+				Str("reason", message).
+				Msg("Global VM self-test check failed")
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	d.mu.Lock()
+	d.last = report
+	d.mu.Unlock()
+	return report
+}
+
+// Readiness returns the latest self-test report, running the checks again
+// when it is older than the readiness TTL.
+func (d *Diagnostics) Readiness(ctx context.Context) *SelfTestReport {
+	d.mu.Lock()
+	last := d.last
+	d.mu.Unlock()
+
+	if last != nil && d.now().Sub(last.RanAt) < d.readinessTTL {
+		return last
+	}
+	return d.RunSelfTest(ctx)
+}
+
+// checkNodeLookup lists the known nodes.
+func (d *Diagnostics) checkNodeLookup(ctx context.Context) (CheckStatus, string) {
+	if d.nodeLookup == nil {
+		return CheckSkipped, "no node lookup configured"
+	}
+	states, err := d.nodeLookup.List(ctx)
+	if err != nil {
+		return CheckFailed, fmt.Sprintf("listing nodes: %v", err)
+	}
+	connected := 0
+	for _, state := range states {
+		if state.IsConnected() {
+			connected++
+		}
+	}
+	return CheckPassed, fmt.Sprintf("%d nodes known, %d connected", len(states), connected)
+}
+
+// checkLatencyMatrix checks that the newest latency is recent enough.
+func (d *Diagnostics) checkLatencyMatrix(ctx context.Context) (CheckStatus, string) {
+	if d.latencyMatrix == nil {
+		return CheckSkipped, "no latency matrix configured"
+	}
+	updated := d.latencyMatrix.LastUpdated()
+	if updated.IsZero() {
+		return CheckFailed, "no latencies have been reported"
+	}
+	age := d.now().Sub(updated)
+	if age > d.latencyMaxAge {
+		return CheckFailed, fmt.Sprintf("newest latency is %s old, more than %s", age.Round(time.Second), d.latencyMaxAge)
+	}
+	return CheckPassed, fmt.Sprintf("newest latency is %s old", age.Round(time.Second))
+}
+
+// checkScheduler selects a node for the synthetic job. No capacity is
+// leased, so the check leaves nothing behind.
+func (d *Diagnostics) checkScheduler(ctx context.Context) (CheckStatus, string) {
+	if d.scheduler == nil {
+		return CheckSkipped, "no scheduler configured"
+	}
+	selections, err := d.scheduler.SelectNodes(ctx, GlobalSchedulingRequest{Job: d.syntheticJob, TargetCount: 1})
+	if err != nil {
+		return CheckFailed, fmt.Sprintf("scheduling synthetic job: %v", err)
+	}
+	if len(selections) == 0 {
+		return CheckFailed, "no node can run the synthetic job"
+	}
+	sel := selections[0]
+	return CheckPassed, fmt.Sprintf("selected node %s in %s", sel.NodeID, sel.Region)
+}
+
+// LivenessHandler answers liveness probes. It reports the process alive
+// without checking dependencies, so a failing node lookup or scheduler
+// fails readiness rather than getting the process restarted.
+func (d *Diagnostics) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeDiagnosticsJSON(w, http.StatusOK, map[string]interface{}{
+			"Status": "alive",
+			"Uptime": d.now().Sub(d.started).Round(time.Second).String(),
+		})
+	})
+}
+
+// ReadinessHandler answers readiness probes with the self-test report:
+// 200 when every check passed or was skipped, 503 otherwise.
+func (d *Diagnostics) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := d.Readiness(r.Context())
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeDiagnosticsJSON(w, status, report)
+	})
+}
+
+// writeDiagnosticsJSON writes v as the JSON body of a probe response.
+func writeDiagnosticsJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
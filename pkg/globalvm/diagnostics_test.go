@@ -0,0 +1,123 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiagnostics(lookupErr error, selector *mockNodeSelector, matrix LatencyMatrix) *Diagnostics {
+	lookup := &mockNodeLookup{
+		states: []models.NodeState{
+			createMockNodeState("node-1", true, 4.0, 16<<30, 100<<30, nil),
+			createMockNodeState("node-2", false, 4.0, 16<<30, 100<<30, nil),
+		},
+		err: lookupErr,
+	}
+	scheduler := NewScheduler(selector, &mockCapacityProvider{capacity: &GlobalResources{HealthyNodes: 1}})
+	return NewDiagnostics(
+		WithDiagnosticNodeLookup(lookup),
+		WithDiagnosticLatencyMatrix(matrix, time.Minute),
+		WithDiagnosticScheduler(scheduler),
+	)
+}
+
+func TestDiagnostics_RunSelfTest(t *testing.T) {
+	healthySelector := &mockNodeSelector{
+		nodes: []orchestrator.NodeRank{{NodeInfo: createTestNodeInfo("node-1", "eu-west"), Rank: 10}},
+	}
+
+	t.Run("all checks pass", func(t *testing.T) {
+		matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+		matrix.UpdateLatency("eu-west", "us-east", 80*time.Millisecond)
+
+		report := newTestDiagnostics(nil, healthySelector, matrix).RunSelfTest(context.Background())
+		assert.True(t, report.Ready)
+		require.Len(t, report.Checks, 3)
+		for _, check := range report.Checks {
+			assert.Equal(t, CheckPassed, check.Status, check.Name)
+		}
+		lookup, _ := report.Check(CheckNodeLookup)
+		assert.Equal(t, "2 nodes known, 1 connected", lookup.Message)
+		scheduler, _ := report.Check(CheckScheduler)
+		assert.Contains(t, scheduler.Message, "node-1")
+	})
+
+	t.Run("each failure is reported", func(t *testing.T) {
+		matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+		d := newTestDiagnostics(errors.New("store unavailable"), &mockNodeSelector{}, matrix)
+
+		report := d.RunSelfTest(context.Background())
+		assert.False(t, report.Ready)
+		for _, name := range []string{CheckNodeLookup, CheckLatencyMatrix, CheckScheduler} {
+			check, ok := report.Check(name)
+			require.True(t, ok, name)
+			assert.Equal(t, CheckFailed, check.Status, name)
+		}
+		check, _ := report.Check(CheckScheduler)
+		assert.Equal(t, "no node can run the synthetic job", check.Message)
+	})
+
+	t.Run("stale latency matrix", func(t *testing.T) {
+		matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+		matrix.UpdateLatency("eu-west", "us-east", 80*time.Millisecond)
+		d := newTestDiagnostics(nil, healthySelector, matrix)
+		d.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+		check, _ := d.RunSelfTest(context.Background()).Check(CheckLatencyMatrix)
+		assert.Equal(t, CheckFailed, check.Status)
+		assert.Contains(t, check.Message, "more than 1m0s")
+	})
+
+	t.Run("unconfigured checks are skipped", func(t *testing.T) {
+		report := NewDiagnostics().RunSelfTest(context.Background())
+		assert.True(t, report.Ready)
+		for _, check := range report.Checks {
+			assert.Equal(t, CheckSkipped, check.Status, check.Name)
+		}
+	})
+}
+
+func TestDiagnostics_Handlers(t *testing.T) {
+	selector := &mockNodeSelector{
+		nodes: []orchestrator.NodeRank{{NodeInfo: createTestNodeInfo("node-1", "eu-west"), Rank: 10}},
+	}
+	matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+	matrix.UpdateLatency("eu-west", "us-east", 80*time.Millisecond)
+	d := newTestDiagnostics(nil, selector, matrix)
+
+	probe := func(h http.Handler) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec
+	}
+
+	rec := probe(d.ReadinessHandler())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var report SelfTestReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.True(t, report.Ready)
+	assert.Len(t, report.Checks, 3)
+
+	// The cached report answers until the TTL runs out
+	selector.nodes = nil
+	assert.Equal(t, http.StatusOK, probe(d.ReadinessHandler()).Code)
+	d.now = func() time.Time { return time.Now().Add(DefaultReadinessTTL) }
+	assert.Equal(t, http.StatusServiceUnavailable, probe(d.ReadinessHandler()).Code)
+
+	// Liveness doesn't depend on the checks
+	rec = probe(d.LivenessHandler())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"Status":"alive"`)
+}
@@ -0,0 +1,119 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+)
+
+// LabelNodeEgress is the node label an operator sets to NodeEgressDenied
+// to keep jobs that need internet egress off the node. Nodes without it
+// allow egress.
+const LabelNodeEgress = "network.egress"
+
+// Values of LabelNodeEgress.
+const (
+	NodeEgressAllowed = "allowed"
+	NodeEgressDenied  = "denied"
+)
+
+// EgressPolicy is a job's requirement on outbound internet access.
+type EgressPolicy string
+
+const (
+	// EgressUnspecified leaves egress to the node's executor defaults.
+	EgressUnspecified EgressPolicy = ""
+
+	// EgressRequired places the job only on nodes that allow egress.
+	EgressRequired EgressPolicy = "required"
+
+	// EgressDenied runs the job without egress. Any node can run it; the
+	// node cuts the job's networking off as its allocation says.
+	EgressDenied EgressPolicy = "denied"
+)
+
+// Valid reports whether the policy is known.
+func (p EgressPolicy) Valid() bool {
+	switch p {
+	case EgressUnspecified, EgressRequired, EgressDenied:
+		return true
+	}
+	return false
+}
+
+// taskEgress returns the egress policy implied by the network of the job's
+// task: networked tasks need egress and tasks without a network must not
+// have it. Tasks on the default network leave it to the node.
+func taskEgress(job *models.Job) EgressPolicy {
+	task := job.Task()
+	if task == nil || task.Network == nil {
+		return EgressUnspecified
+	}
+	switch task.Network.Type {
+	case models.NetworkNone:
+		return EgressDenied
+	case models.NetworkHost, models.NetworkFull, models.NetworkHTTP, models.NetworkBridge:
+		return EgressRequired
+	}
+	return EgressUnspecified
+}
+
+// jobEgress returns the egress policy a scheduling request asks for,
+// falling back to the one implied by the task's network.
+func jobEgress(req GlobalSchedulingRequest) EgressPolicy {
+	if req.Scheduling.Egress != EgressUnspecified {
+		return req.Scheduling.Egress
+	}
+	return taskEgress(req.Job)
+}
+
+// validateEgress checks the request's egress policy is known and does not
+// contradict the network of the job's task.
+func validateEgress(req GlobalSchedulingRequest) error {
+	policy := req.Scheduling.Egress
+	if !policy.Valid() {
+		return fmt.Errorf("unknown egress policy: %s", policy)
+	}
+	if implied := taskEgress(req.Job); policy != EgressUnspecified && implied != EgressUnspecified && implied != policy {
+		return fmt.Errorf("egress policy %s contradicts the task's %s network", policy, req.Job.Task().Network.Type)
+	}
+	return nil
+}
+
+// nodeAllowsEgress reports whether a node lets jobs reach the internet.
+func nodeAllowsEgress(info models.NodeInfo) bool {
+	return !strings.EqualFold(info.Labels[LabelNodeEgress], NodeEgressDenied)
+}
+
+// applyEgress drops the nodes that forbid egress when the job requires it.
+func (s *Scheduler) applyEgress(
+	ctx context.Context, req GlobalSchedulingRequest, policy EgressPolicy, ranks []orchestrator.NodeRank,
+) []orchestrator.NodeRank {
+	if policy != EgressRequired {
+		return ranks
+	}
+
+	trace := traceFrom(ctx)
+	filtered := make([]orchestrator.NodeRank, 0, len(ranks))
+	for _, rank := range ranks {
+		if nodeAllowsEgress(rank.NodeInfo) {
+			filtered = append(filtered, rank)
+		} else {
+			trace.reject(rank.NodeInfo.ID(), ReasonEgressDenied, "")
+		}
+	}
+	trace.match(ConstraintEgress)
+
+	componentLogger(ctx, ComponentScheduler).Debug().
+		Str("jobID", req.Job.ID).
+		Int("matched", len(filtered)).
+		Int("rejected", len(ranks)-len(filtered)).
+		Msg("Applied egress requirement")
+
+	return filtered
+}
@@ -0,0 +1,123 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func egressNodeInfo(id, egress string) models.NodeInfo {
+	info := createTestNodeInfo(id, "us-east")
+	if egress != "" {
+		info.Labels[LabelNodeEgress] = egress
+	}
+	return info
+}
+
+func TestScheduler_Egress(t *testing.T) {
+	ctx := context.Background()
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: egressNodeInfo("node-open", ""), Rank: 10},
+		{NodeInfo: egressNodeInfo("node-closed", NodeEgressDenied), Rank: 20},
+		{NodeInfo: egressNodeInfo("node-allowed", NodeEgressAllowed), Rank: 5},
+	}}
+	scheduler := NewScheduler(selector, &mockCapacityProvider{capacity: &GlobalResources{}})
+
+	nodeIDs := func(selections []NodeSelection) []string {
+		ids := make([]string, len(selections))
+		for i, sel := range selections {
+			ids[i] = sel.NodeID
+		}
+		return ids
+	}
+
+	selections, err := scheduler.SelectNodes(ctx, GlobalSchedulingRequest{
+		Job:        createTestJob("needs-internet", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Egress: EgressRequired},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-open", "node-allowed"}, nodeIDs(selections))
+	for _, sel := range selections {
+		assert.Equal(t, EgressRequired, sel.Egress)
+	}
+
+	// Jobs without egress may run anywhere, and say so in their allocation
+	selections, err = scheduler.SelectNodes(ctx, GlobalSchedulingRequest{
+		Job:        createTestJob("offline", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Egress: EgressDenied},
+	})
+	require.NoError(t, err)
+	require.Len(t, selections, 3)
+	assert.Equal(t, "node-closed", selections[0].NodeID)
+	assert.Equal(t, EgressDenied, selections[0].Egress)
+
+	// The task's network implies the policy when none is given
+	job := createTestJob("http", models.JobTypeBatch, 1)
+	job.Tasks[0].Network = &models.NetworkConfig{Type: models.NetworkHTTP, Domains: []string{"example.com"}}
+	selections, err = scheduler.SelectNodes(ctx, GlobalSchedulingRequest{Job: job})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-open", "node-allowed"}, nodeIDs(selections))
+	assert.Equal(t, EgressRequired, selections[0].Egress)
+
+	_, err = scheduler.SelectNodes(ctx, GlobalSchedulingRequest{Job: job, Scheduling: SchedulingOptions{Egress: EgressDenied}})
+	assert.ErrorContains(t, err, "contradicts")
+
+	_, err = scheduler.SelectNodes(ctx, GlobalSchedulingRequest{Job: job, Scheduling: SchedulingOptions{Egress: "sometimes"}})
+	assert.ErrorContains(t, err, "unknown egress policy")
+}
+
+func TestTaskEgress(t *testing.T) {
+	tests := map[models.Network]EgressPolicy{
+		models.NetworkDefault: EgressUnspecified,
+		models.NetworkNone:    EgressDenied,
+		models.NetworkHost:    EgressRequired,
+		models.NetworkBridge:  EgressRequired,
+		models.NetworkHTTP:    EgressRequired,
+	}
+	for network, want := range tests {
+		job := createTestJob("job", models.JobTypeBatch, 1)
+		job.Tasks[0].Network = &models.NetworkConfig{Type: network}
+		assert.Equal(t, want, taskEgress(job), network.String())
+	}
+
+	job := createTestJob("job", models.JobTypeBatch, 1)
+	job.Tasks[0].Network = nil
+	assert.Equal(t, EgressUnspecified, taskEgress(job))
+}
+
+func TestEndpoint_Egress(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: egressNodeInfo("node-closed", NodeEgressDenied), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 100, AvailableMemory: 1024 << 30, HealthyNodes: 1}}
+	submitter := &mockJobSubmitter{response: &orchestrator.SubmitJobResponse{JobID: "job-1"}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity, WithJobSubmitter(submitter))
+
+	resp, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:        createTestJob("job-1", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Egress: EgressDenied},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.AllocatedNodes, 1)
+	assert.Equal(t, EgressDenied, resp.AllocatedNodes[0].Egress)
+
+	_, err = endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:        createTestJob("job-2", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Egress: "open"},
+	})
+	assert.ErrorContains(t, err, "unknown egress policy")
+
+	// No node allows egress, so none is allocated
+	resp, err = endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:        createTestJob("job-3", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Egress: EgressRequired},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.AllocatedNodes)
+}
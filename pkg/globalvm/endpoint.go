@@ -5,11 +5,13 @@ package globalvm
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/dustin/go-humanize"
+
 	"github.com/bacalhau-project/bacalhau/pkg/models"
 	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
-	"github.com/rs/zerolog/log"
 )
 
 // GlobalJobRequest represents a job submission request to the Global VM.
@@ -26,6 +28,10 @@ type GlobalJobRequest struct {
 
 	// PriorityBoost allows increasing job priority for urgent workloads.
 	PriorityBoost int `json:"PriorityBoost,omitempty"`
+
+	// OriginRegion is the region the client submits from and fetches
+	// results to. Empty falls back to the job's origin-region label.
+	OriginRegion string `json:"OriginRegion,omitempty"`
 }
 
 // SchedulingOptions controls how jobs are distributed across the Global VM.
@@ -36,12 +42,18 @@ type SchedulingOptions struct {
 	// Set to N > 1 for multi-region distribution.
 	SpreadAcrossRegions int `json:"SpreadAcrossRegions,omitempty"`
 
+	// SpreadLevel is the level of the region hierarchy SpreadAcrossRegions
+	// counts at, so SpreadAcrossRegions 2 with RegionLevelContinent spans
+	// two continents. Empty counts flat regions.
+	SpreadLevel RegionLevel `json:"SpreadLevel,omitempty"`
+
 	// MaxLatency specifies the maximum acceptable network latency to nodes.
 	// Nodes with higher latency will be deprioritized or excluded.
 	MaxLatency time.Duration `json:"MaxLatency,omitempty"`
 
 	// PreferredRegions is a list of regions to prefer for job placement.
-	// Nodes in these regions will be ranked higher.
+	// Nodes in these regions will be ranked higher. Entries may also name
+	// an area of the region hierarchy, such as "eu" or "europe/de".
 	PreferredRegions []string `json:"PreferredRegions,omitempty"`
 
 	// PreferLowCost when true, prioritizes nodes with lower cost.
@@ -61,6 +73,56 @@ type SchedulingOptions struct {
 
 	// Exclusive when true, requests dedicated nodes without other workloads.
 	Exclusive bool `json:"Exclusive,omitempty"`
+
+	// Timeout bounds how long node selection may take. Zero uses the
+	// scheduler's default.
+	Timeout time.Duration `json:"Timeout,omitempty"`
+
+	// TimeoutPolicy decides whether an expired Timeout returns the nodes
+	// selected so far or fails. Empty uses the scheduler's default.
+	TimeoutPolicy TimeoutPolicy `json:"TimeoutPolicy,omitempty"`
+
+	// Constraint is an expression nodes must satisfy, for example
+	// `region in ["us-east","eu-west"] && gpu.vendor == "nvidia"`.
+	// See Constraint for the supported attributes and operators.
+	Constraint string `json:"Constraint,omitempty"`
+
+	// AutoRightSize shrinks CPU and memory requests that past runs of the
+	// same image show to be over-provisioned. Without it the endpoint only
+	// warns. Requires an endpoint configured WithRightSizeAdvisor.
+	AutoRightSize bool `json:"AutoRightSize,omitempty"`
+
+	// Aggregation requests an extra node, besides the job's workers, to
+	// collect their results. It is placed to minimize the total transfer
+	// from the workers and returned with NodeRoleAggregator.
+	Aggregation *AggregationOptions `json:"Aggregation,omitempty"`
+
+	// Coordinator makes the best-ranked worker the job's coordinator,
+	// such as rank 0 of a distributed training job, returned with
+	// NodeRoleCoordinator. Needs at least two workers.
+	Coordinator bool `json:"Coordinator,omitempty"`
+
+	// Lineage lists the upstream pipeline stages whose intermediates the
+	// job consumes. Nodes holding them, or close to them, rank higher.
+	Lineage []StageLineage `json:"Lineage,omitempty"`
+
+	// GPUTimeSlice shares one GPU with other jobs instead of taking a
+	// whole one, for bursty workloads such as inference. The job is only
+	// placed on a GPU whose committed duty cycles leave room for its own.
+	// Requires a scheduler configured WithGPUTimeSlicer.
+	GPUTimeSlice *GPUTimeSliceOptions `json:"GPUTimeSlice,omitempty"`
+
+	// Egress says whether the job needs outbound internet access. With
+	// EgressRequired it is kept off nodes labelled to deny egress; with
+	// EgressDenied its nodes are told to cut its networking off. Empty
+	// follows the network of the job's task.
+	Egress EgressPolicy `json:"Egress,omitempty"`
+
+	// Profile names the placement profile tailoring node selection to the
+	// workload: ProfileInference for model serving or ProfileTraining for
+	// distributed training. Empty uses none. Custom profiles are
+	// registered WithPlacementProfile.
+	Profile string `json:"Profile,omitempty"`
 }
 
 // GlobalJobResponse is returned after a successful job submission.
@@ -82,6 +144,26 @@ type GlobalJobResponse struct {
 
 	// QueuePosition indicates the job's position if queued (0 if running).
 	QueuePosition int `json:"QueuePosition,omitempty"`
+
+	// Partial is set when the scheduling deadline expired before all
+	// requested nodes were selected.
+	Partial bool `json:"Partial,omitempty"`
+
+	// SchedulingID correlates the log lines of this scheduling decision
+	// across the endpoint, scheduler, ranker and capacity aggregator.
+	SchedulingID string `json:"SchedulingID,omitempty"`
+
+	// RightSizing compares the request with past runs of the same image.
+	// It is set only when the request looks over-provisioned.
+	RightSizing *RightSizeAdvice `json:"RightSizing,omitempty"`
+
+	// RetrievalHints lists where the job's results can be fetched, the
+	// closest to the submitting origin first.
+	RetrievalHints []RetrievalHint `json:"RetrievalHints,omitempty"`
+
+	// Rejections lists the nodes scheduling dropped and why, which for a
+	// queued job explains why no node was selected.
+	Rejections []NodeRejection `json:"Rejections,omitempty"`
 }
 
 // GlobalJobStatus represents the current state of a job in the Global VM.
@@ -196,6 +278,18 @@ type Endpoint struct {
 	jobSubmitter       JobSubmitter
 	statusProvider     JobStatusProvider
 	nodeSelector       orchestrator.NodeSelector
+	replicator         *Replicator
+	rightSizer         *RightSizeAdvisor
+	defragmenter       *GPUDefragmenter
+	admission          AdmissionPolicy
+	history            *jobHistory
+	retrievalLatencies LatencyMatrix
+	logRelay           LogRelay
+	quotas             QuotaProvider
+
+	// Deferred batch jobs, when there is no replicator to queue them
+	deferredMu sync.Mutex
+	deferred   []QueuedJob
 }
 
 // JobSubmitter is an interface for submitting jobs to the orchestrator.
@@ -221,6 +315,7 @@ func NewEndpoint(
 	e := &Endpoint{
 		scheduler:        scheduler,
 		capacityProvider: capacityProvider,
+		history:          newJobHistory(),
 	}
 	for _, opt := range opts {
 		opt(e)
@@ -249,9 +344,35 @@ func WithNodeSelector(selector orchestrator.NodeSelector) EndpointOption {
 	}
 }
 
-// SubmitJob submits a job to the Global VM.
+// WithReplicator makes the endpoint part of a leader/standby pair.
+// Queued jobs are kept in the replicated state, and a standby rejects
+// submissions with ErrNotLeader until it is promoted.
+func WithReplicator(replicator *Replicator) EndpointOption {
+	return func(e *Endpoint) {
+		e.replicator = replicator
+	}
+}
+
+// WithRightSizeAdvisor checks submitted jobs against the usage history of
+// their image and warns about, or with AutoRightSize shrinks, requests
+// that are larger than similar runs needed.
+func WithRightSizeAdvisor(advisor *RightSizeAdvisor) EndpointOption {
+	return func(e *Endpoint) {
+		e.rightSizer = advisor
+	}
+}
+
+// SubmitJob submits a job to the Global VM. Cancelling ctx before the
+// orchestrator accepts the job aborts the submission with
+// ErrSubmissionAborted, releasing the capacity it had leased.
 func (e *Endpoint) SubmitJob(ctx context.Context, req GlobalJobRequest) (*GlobalJobResponse, error) {
-	log.Ctx(ctx).Info().
+	if e.replicator != nil && !e.replicator.IsLeader() {
+		return nil, ErrNotLeader
+	}
+
+	ctx, schedulingID := WithSchedulingID(ctx, "")
+
+	componentLogger(ctx, ComponentEndpoint).Info().
 		Str("jobName", req.Job.Name).
 		Str("clientID", req.ClientID).
 		Msg("Submitting job to Global VM")
@@ -260,13 +381,60 @@ func (e *Endpoint) SubmitJob(ctx context.Context, req GlobalJobRequest) (*Global
 	if err := req.Job.Validate(); err != nil {
 		return nil, fmt.Errorf("job validation failed: %w", err)
 	}
+	if _, err := ParseConstraint(req.Scheduling.Constraint); err != nil {
+		return nil, err
+	}
+	if !req.Scheduling.SpreadLevel.Valid() {
+		return nil, fmt.Errorf("unknown spread level: %s", req.Scheduling.SpreadLevel)
+	}
+	if slice := req.Scheduling.GPUTimeSlice; slice != nil {
+		if err := slice.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateEgress(GlobalSchedulingRequest{Job: req.Job, Scheduling: req.Scheduling}); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	advice := e.adviseRightSize(&req)
+	if advice != nil {
+		for _, msg := range advice.Messages {
+			warnings = append(warnings, "Over-provisioned: "+msg)
+		}
+		if advice.Applied {
+			warnings = append(warnings, fmt.Sprintf(
+				"Request right-sized to %g CPU and %s memory", advice.Suggested.CPU, humanize.IBytes(advice.Suggested.Memory)))
+		}
+	}
 
 	// Check global capacity
 	capacity, err := e.capacityProvider.GetAvailableCapacity(ctx)
+	if ctx.Err() != nil {
+		return nil, e.abortSubmission(ctx, req, stageCapacity, nil)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to check capacity: %w", err)
 	}
 
+	// The operator's admission policy may deny or queue the job
+	if e.admission != nil {
+		decision, err := e.admit(ctx, req, capacity)
+		if ctx.Err() != nil {
+			return nil, e.abortSubmission(ctx, req, stageAdmission, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch decision.Decision {
+		case AdmissionDeny:
+			return nil, fmt.Errorf("%w: %s", ErrAdmissionDenied, decision.Reason)
+		case AdmissionQueue:
+			warnings = append(warnings, "Queued by admission policy: "+decision.Reason)
+			return e.queueJob(ctx, req, schedulingID, warnings, advice, nil)
+		}
+	}
+
 	// Validate capacity requirements
 	if err := e.validateCapacity(ctx, req.Job, capacity); err != nil {
 		return nil, fmt.Errorf("insufficient capacity: %w", err)
@@ -278,20 +446,38 @@ func (e *Endpoint) SubmitJob(ctx context.Context, req GlobalJobRequest) (*Global
 		Scheduling:        req.Scheduling,
 		TargetCount:       req.Job.Count,
 		AvailableCapacity: capacity,
+		LeaseCapacity:     true,
+		TenantID:          req.ClientID,
+		OriginRegion:      requestOrigin(req),
 	}
 
-	selections, err := e.scheduler.SelectNodes(ctx, schedulingReq)
+	// The scheduler releases what it allocated when it fails. A pass that
+	// finished as the context was cancelled is undone here
+	result, err := e.scheduler.Schedule(ctx, schedulingReq)
+	if ctx.Err() != nil {
+		var selections []NodeSelection
+		if err == nil {
+			selections = result.Selections
+		}
+		return nil, e.abortSubmission(ctx, req, stageScheduling, selections)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to select nodes: %w", err)
 	}
+	selections := result.Selections
+
+	if result.Partial {
+		warnings = append(warnings, fmt.Sprintf(
+			"Scheduling deadline reached, %d of %d nodes selected", len(selections), req.Job.Count))
+	}
+	if _, ok := Aggregator(selections); req.Scheduling.Aggregation != nil && len(selections) > 0 && !ok {
+		warnings = append(warnings, "No spare node available for result aggregation")
+	}
 
 	// If no nodes selected, queue the job
 	if len(selections) == 0 {
-		return &GlobalJobResponse{
-			JobID:          req.Job.ID,
-			Warnings:       []string{"No suitable nodes available, job queued"},
-			QueuePosition:  1, // Would be calculated from actual queue
-		}, nil
+		warnings = append(warnings, "No suitable nodes available, job queued")
+		return e.queueJob(ctx, req, schedulingID, warnings, advice, result)
 	}
 
 	// Estimate cost
@@ -305,17 +491,67 @@ func (e *Endpoint) SubmitJob(ctx context.Context, req GlobalJobRequest) (*Global
 			ClientInstanceID: req.ClientID,
 		}
 		resp, err := e.jobSubmitter.SubmitJob(ctx, submitReq)
+		if err != nil && ctx.Err() != nil {
+			return nil, e.abortSubmission(ctx, req, stageSubmission, selections)
+		}
 		if err != nil {
+			releaseLeases(ctx, e.capacityProvider, selections)
+			e.releaseTimeSlices(ctx, req.Job.ID)
 			return nil, fmt.Errorf("failed to submit job: %w", err)
 		}
 		evalID = resp.EvaluationID
 	}
+	// Once the orchestrator has accepted the job cancellation no longer
+	// aborts it: its leases are confirmed regardless
+	e.confirmLeases(context.WithoutCancel(ctx), selections)
+	e.recordSubmission(req, JobPlacement{
+		SchedulingID: schedulingID, Selections: selections, Partial: result.Partial, Warnings: warnings,
+	}, result)
 
 	return &GlobalJobResponse{
 		JobID:          req.Job.ID,
 		EvaluationID:   evalID,
 		AllocatedNodes: selections,
 		EstimatedCost:  estimatedCost,
+		Warnings:       warnings,
+		Partial:        result.Partial,
+		RightSizing:    advice,
+		SchedulingID:   schedulingID,
+		RetrievalHints: e.retrievalHints(requestOrigin(req), selections),
+		Rejections:     result.Rejections,
+	}, nil
+}
+
+// queueJob queues a job that is not scheduled now. result is the
+// scheduling pass that found no nodes for it, if there was one.
+func (e *Endpoint) queueJob(
+	ctx context.Context, req GlobalJobRequest, schedulingID string, warnings []string,
+	advice *RightSizeAdvice, result *SchedulingResult,
+) (*GlobalJobResponse, error) {
+	position := 1
+	if e.replicator != nil {
+		var err error
+		position, err = e.replicator.EnqueueJob(ctx, QueuedJob{JobID: req.Job.ID, Request: req})
+		if err != nil {
+			return nil, fmt.Errorf("failed to queue job: %w", err)
+		}
+	}
+
+	placement := JobPlacement{SchedulingID: schedulingID, Queued: true, Warnings: warnings}
+	var rejections []NodeRejection
+	if result != nil {
+		placement.Partial = result.Partial
+		rejections = result.Rejections
+	}
+	e.recordSubmission(req, placement, result)
+	return &GlobalJobResponse{
+		JobID:          req.Job.ID,
+		Warnings:       warnings,
+		QueuePosition:  position,
+		Partial:        placement.Partial,
+		RightSizing:    advice,
+		SchedulingID:   schedulingID,
+		Rejections:     rejections,
 	}, nil
 }
 
@@ -393,8 +629,14 @@ func (e *Endpoint) ScaleJob(ctx context.Context, jobID string, targetCount int)
 		return fmt.Errorf("cannot scale job in terminal state %s", status.State)
 	}
 
+	e.history.recordAudit(AuditEntry{
+		JobID:  jobID,
+		Action: AuditActionScale,
+		Detail: fmt.Sprintf("%d -> %d nodes", status.TotalNodes, targetCount),
+	})
+
 	// TODO: Implement actual scaling via job update
-	log.Ctx(ctx).Info().
+	componentLogger(ctx, ComponentEndpoint).Info().
 		Str("jobID", jobID).
 		Int("current", status.TotalNodes).
 		Int("target", targetCount).
@@ -405,11 +647,21 @@ func (e *Endpoint) ScaleJob(ctx context.Context, jobID string, targetCount int)
 
 // CancelJob stops a running job.
 func (e *Endpoint) CancelJob(ctx context.Context, jobID string, reason string) error {
-	log.Ctx(ctx).Info().
+	componentLogger(ctx, ComponentEndpoint).Info().
 		Str("jobID", jobID).
 		Str("reason", reason).
 		Msg("Canceling job")
 
+	// A queued job only needs to leave the queue
+	if e.replicator != nil {
+		if err := e.replicator.DequeueJob(ctx, jobID); err != nil {
+			return fmt.Errorf("failed to dequeue job: %w", err)
+		}
+	}
+
+	e.history.recordAudit(AuditEntry{JobID: jobID, Action: AuditActionCancel, Detail: reason})
+	e.releaseTimeSlices(ctx, jobID)
+
 	// TODO: Implement via orchestrator StopJob
 	return nil
 }
@@ -423,6 +675,138 @@ func (e *Endpoint) GetJobLogs(ctx context.Context, jobID string, opts LogOptions
 	}, nil
 }
 
+// GetJobPlacement returns the scheduling metadata recorded when the job was
+// submitted. It is removed by garbage collection once the job has completed.
+func (e *Endpoint) GetJobPlacement(jobID string) (*JobPlacement, bool) {
+	p, ok := e.history.placement(jobID)
+	if !ok {
+		return nil, false
+	}
+	return &p, true
+}
+
+// GetAuditLog returns the changes made to a job through the endpoint, oldest first.
+func (e *Endpoint) GetAuditLog(jobID string) []AuditEntry {
+	return e.history.auditLog(jobID)
+}
+
+// ReplayDecision re-runs the scheduling decision recorded in the job's
+// audit log against the cluster state it was made on, with the scheduler's
+// current policy adjusted by the candidate options, and diffs the outcome.
+// Decisions are only recorded when the scheduler keeps decision snapshots.
+func (e *Endpoint) ReplayDecision(ctx context.Context, jobID string, candidate ...SchedulerOption) (*ReplayResult, error) {
+	replayer, ok := e.scheduler.(DecisionReplayer)
+	if !ok {
+		return nil, fmt.Errorf("scheduler does not support replaying decisions")
+	}
+
+	entries := e.history.auditLog(jobID)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Action == AuditActionSubmit && entries[i].Decision != nil {
+			return replayer.Replay(ctx, entries[i].Decision, candidate...)
+		}
+	}
+	return nil, fmt.Errorf("no scheduling decision recorded for job %s", jobID)
+}
+
+// RetentionTargets returns the endpoint's per-job state for garbage collection.
+func (e *Endpoint) RetentionTargets() []RetentionTarget {
+	return []RetentionTarget{placementTarget{e.history}, auditTarget{e.history}}
+}
+
+// recordSubmission keeps the placement and an audit entry for a submitted
+// job. The entry carries the scheduling decision when a snapshot was taken.
+func (e *Endpoint) recordSubmission(req GlobalJobRequest, placement JobPlacement, result *SchedulingResult) {
+	placement.JobID = req.Job.ID
+	placement.SubmittedAt = time.Now()
+	e.history.recordPlacement(placement)
+
+	entry := AuditEntry{
+		JobID:    req.Job.ID,
+		Action:   AuditActionSubmit,
+		ClientID: req.ClientID,
+		At:       placement.SubmittedAt,
+		Regions:  selectionRegions(placement.Selections),
+		Nodes:    len(placement.Selections),
+		Selected: selectionRecords(placement.Selections),
+	}
+	if resources, err := jobResources(req.Job); err == nil {
+		entry.GPUsPerNode = int(resources.GPU)
+	}
+	if result != nil {
+		entry.Rejected = rejectionCounts(result.Rejections)
+	}
+	if result != nil && result.Snapshot != nil {
+		entry.Decision = &SchedulingDecision{
+			SchedulingID: placement.SchedulingID,
+			Snapshot:     result.Snapshot,
+			Selections:   placement.Selections,
+			Rejections:   result.Rejections,
+			Partial:      placement.Partial,
+		}
+	}
+	e.history.recordAudit(entry)
+}
+
+// RecordJobStart records that a queued job was placed on its nodes.
+func (e *Endpoint) RecordJobStart(jobID string, selections []NodeSelection) {
+	e.history.recordAudit(AuditEntry{
+		JobID:   jobID,
+		Action:   AuditActionStart,
+		Regions:  selectionRegions(selections),
+		Nodes:    len(selections),
+		Selected: selectionRecords(selections),
+	})
+}
+
+// RecordPreemption records that a job lost its nodes to other work. It
+// holds no nodes until RecordJobStart places it again.
+func (e *Endpoint) RecordPreemption(jobID, reason string) {
+	e.history.recordAudit(AuditEntry{JobID: jobID, Action: AuditActionPreempt, Detail: reason})
+	e.releaseTimeSlices(context.Background(), jobID)
+}
+
+// RecordJobFinish records that a job stopped running and released its
+// nodes, along with any GPU time slices it held.
+func (e *Endpoint) RecordJobFinish(jobID string) {
+	e.history.recordAudit(AuditEntry{JobID: jobID, Action: AuditActionFinish})
+	e.releaseTimeSlices(context.Background(), jobID)
+}
+
+// adviseRightSize runs the right-size advisor on the request. With
+// AutoRightSize it shrinks the request on a copy of the job, so the
+// caller's job is left untouched.
+func (e *Endpoint) adviseRightSize(req *GlobalJobRequest) *RightSizeAdvice {
+	if e.rightSizer == nil {
+		return nil
+	}
+	advice := e.rightSizer.Advise(req.Job)
+	if advice == nil || !advice.OverProvisioned() {
+		return nil
+	}
+	if req.Scheduling.AutoRightSize {
+		req.Job = req.Job.Copy()
+		advice.Apply(req.Job)
+	}
+	return advice
+}
+
+// confirmLeases confirms the allocation leases of a dispatched job.
+func (e *Endpoint) confirmLeases(ctx context.Context, selections []NodeSelection) {
+	leaser, ok := e.capacityProvider.(CapacityLeaser)
+	if !ok {
+		return
+	}
+	for _, sel := range selections {
+		if sel.LeaseID == "" {
+			continue
+		}
+		if err := leaser.ConfirmLease(ctx, sel.LeaseID); err != nil {
+			componentLogger(ctx, ComponentEndpoint).Warn().Err(err).Str("leaseID", sel.LeaseID).Msg("Failed to confirm lease")
+		}
+	}
+}
+
 // validateCapacity checks if the job can fit in available capacity.
 func (e *Endpoint) validateCapacity(ctx context.Context, job *models.Job, capacity *GlobalResources) error {
 	task := job.Task()
@@ -526,3 +526,23 @@ func createTestNodeInfo(id string, region string) models.NodeInfo {
 		},
 	}
 }
+
+func TestEndpoint_SubmitJobPartialSchedule(t *testing.T) {
+	selector := &stalledNodeSelector{
+		mockNodeSelector: mockNodeSelector{nodes: []orchestrator.NodeRank{
+			{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+		}},
+		delay: time.Second,
+	}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity)
+
+	resp, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:        createTestJob("job-1", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Timeout: 20 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Partial)
+	assert.Equal(t, 1, resp.QueuePosition)
+	assert.Contains(t, resp.Warnings[0], "Scheduling deadline reached")
+}
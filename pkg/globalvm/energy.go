@@ -0,0 +1,214 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+
+	"github.com/bacalhau-project/bacalhau/pkg/globalvm/capability"
+)
+
+// LabelJobPayRate is the job label holding what the job pays its node
+// operator, in credits per hour.
+const LabelJobPayRate = "pay-rate"
+
+// ProfitabilityConfig configures the profitability ranker.
+type ProfitabilityConfig struct {
+	// CreditValue is what one credit is worth in the currency operators
+	// publish their tariffs in.
+	CreditValue float64
+
+	// WattsPerCPU and WattsPerGPU estimate a job's power draw from the
+	// resources it requests.
+	WattsPerCPU float64
+	WattsPerGPU float64
+
+	// PeakPenalty is how far below other nodes a node in a peak tariff
+	// ranks when the job does not cover its energy cost there.
+	PeakPenalty int
+
+	// ProfitBoost is the rank boost for the most profitable placement;
+	// less profitable nodes get a proportional share.
+	ProfitBoost int
+
+	// ExcludeUnprofitable marks nodes unsuitable instead of penalizing them
+	// when the job would not cover their energy cost at any time of day.
+	ExcludeUnprofitable bool
+}
+
+// DefaultProfitabilityConfig returns the default configuration.
+func DefaultProfitabilityConfig() ProfitabilityConfig {
+	return ProfitabilityConfig{
+		CreditValue: 0.01,
+		WattsPerCPU: 10,
+		WattsPerGPU: 300,
+		PeakPenalty: 100,
+		ProfitBoost: 30,
+	}
+}
+
+// ProfitabilityRanker implements the NodeRanker interface for operator
+// profitability. It compares what a job pays with the energy it costs the
+// operator at the node's current tariff, steering low-paying jobs away from
+// nodes in a peak tariff so operators are not running them at a loss.
+// Nodes without a published tariff and jobs without a pay rate are ranked
+// neutrally.
+//
+// Ranks are relative: nodes the ranker has no objection to start at
+// PeakPenalty and penalized nodes at RankPossible, so a penalty lowers a
+// node without marking it unsuitable, which would exclude it from a
+// ranker chain.
+type ProfitabilityRanker struct {
+	config ProfitabilityConfig
+	now    func() time.Time
+}
+
+// NewProfitabilityRanker creates a new profitability ranker.
+func NewProfitabilityRanker(config ProfitabilityConfig) *ProfitabilityRanker {
+	return &ProfitabilityRanker{config: config, now: time.Now}
+}
+
+// WithProfitabilityRanker adjusts node ranks for operator profitability
+// before the global optimizations run.
+func WithProfitabilityRanker(ranker *ProfitabilityRanker) SchedulerOption {
+	return func(s *Scheduler) {
+		s.profitability = ranker
+	}
+}
+
+// RankNodes ranks nodes by the operator's margin on the job.
+func (r *ProfitabilityRanker) RankNodes(ctx context.Context, job models.Job, nodes []models.NodeInfo) ([]orchestrator.NodeRank, error) {
+	ranks := make([]orchestrator.NodeRank, len(nodes))
+	for i, node := range nodes {
+		ranks[i] = orchestrator.NodeRank{NodeInfo: node, Rank: orchestrator.RankPossible, Retryable: true}
+	}
+
+	payRate, ok := jobPayRate(job)
+	if !ok {
+		return ranks, nil
+	}
+	for i := range ranks {
+		ranks[i].Rank = r.config.PeakPenalty
+	}
+	revenue := payRate * r.config.CreditValue
+	now := r.now()
+
+	margins := make([]float64, len(nodes))
+	tariffs := make([]*capability.EnergyTariff, len(nodes))
+	best := 0.0
+	for i, node := range nodes {
+		tariff, err := capability.ParseEnergyTariff(node.Labels)
+		if err != nil {
+			componentLogger(ctx, ComponentRanker).Debug().
+				Err(err).
+				Str("nodeID", node.ID()).
+				Msg("Ignoring invalid energy tariff")
+			continue
+		}
+		if tariff == nil {
+			continue
+		}
+		tariffs[i] = tariff
+
+		rate, _ := tariff.RateAt(now)
+		margins[i] = revenue - r.energyCost(job, tariff, rate)
+		if margins[i] > best {
+			best = margins[i]
+		}
+	}
+
+	for i, tariff := range tariffs {
+		if tariff == nil {
+			continue
+		}
+		rate, peak := tariff.RateAt(now)
+		margin := margins[i]
+
+		switch {
+		case margin < 0 && r.config.ExcludeUnprofitable && revenue < r.energyCost(job, tariff, tariff.Rate):
+			ranks[i].Rank = orchestrator.RankUnsuitable
+			ranks[i].Reason = fmt.Sprintf("job pays %.3f/h, below energy cost even off-peak", revenue)
+		case margin < 0 && peak:
+			ranks[i].Rank = orchestrator.RankPossible
+			ranks[i].Reason = fmt.Sprintf("peak energy tariff %.3f/kWh exceeds what the job pays", rate)
+			energyPeakPenalties.Add(ctx, 1)
+		case margin > 0 && best > 0:
+			ranks[i].Rank += int(float64(r.config.ProfitBoost) * margin / best)
+			ranks[i].Reason = fmt.Sprintf("operator margin %.3f/h", margin)
+		}
+
+		componentLogger(ctx, ComponentRanker).Trace().Object("Rank", ranks[i]).Msg("Profitability-ranked node")
+	}
+
+	return ranks, nil
+}
+
+// energyCost is the hourly cost of the energy the job draws at rate per kWh.
+func (r *ProfitabilityRanker) energyCost(job models.Job, tariff *capability.EnergyTariff, rate float64) float64 {
+	return r.powerDraw(job, tariff) / 1000 * rate
+}
+
+// powerDraw estimates the job's power draw in watts from the resources it
+// requests, falling back to the node's full-load draw.
+func (r *ProfitabilityRanker) powerDraw(job models.Job, tariff *capability.EnergyTariff) float64 {
+	resources, err := jobResources(&job)
+	if err == nil && (resources.CPU > 0 || resources.GPU > 0) {
+		watts := resources.CPU*r.config.WattsPerCPU + float64(resources.GPU)*r.config.WattsPerGPU
+		if tariff.PowerDraw > 0 && watts > tariff.PowerDraw {
+			return tariff.PowerDraw
+		}
+		return watts
+	}
+	if tariff.PowerDraw > 0 {
+		return tariff.PowerDraw
+	}
+	return r.config.WattsPerCPU
+}
+
+// jobPayRate returns the credits per hour the job pays, if it says.
+func jobPayRate(job models.Job) (float64, bool) {
+	value, ok := job.Labels[LabelJobPayRate]
+	if !ok {
+		return 0, false
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 {
+		return 0, false
+	}
+	return rate, true
+}
+
+// applyProfitability adds the profitability ranker's adjustments to the
+// selector's ranks and drops nodes it finds unsuitable.
+func (s *Scheduler) applyProfitability(
+	ctx context.Context, job *models.Job, ranks []orchestrator.NodeRank,
+) ([]orchestrator.NodeRank, error) {
+	profits, err := s.profitability.RankNodes(ctx, *job, extractNodeInfos(ranks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank nodes for profitability: %w", err)
+	}
+
+	trace := traceFrom(ctx)
+	result := make([]orchestrator.NodeRank, 0, len(ranks))
+	for i, rank := range ranks {
+		profit := profits[i]
+		if !profit.MeetsRequirement() {
+			trace.reject(rank.NodeInfo.ID(), ReasonUnprofitable, profit.Reason)
+			continue
+		}
+		rank.Rank += profit.Rank
+		trace.score(rank.NodeInfo.ID(), ReasonProfitable, profit.Rank)
+		if profit.Reason != "" {
+			rank.Reason = profit.Reason
+		}
+		result = append(result, rank)
+	}
+	trace.match(ConstraintProfitability)
+	return result, nil
+}
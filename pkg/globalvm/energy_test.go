@@ -0,0 +1,106 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/globalvm/capability"
+)
+
+// energyTestNodes returns a node with a flat cheap tariff, one with an
+// evening peak and one that publishes no tariff.
+func energyTestNodes() []models.NodeInfo {
+	flat := createTestNodeInfo("node-flat", "us-east")
+	for k, v := range (&capability.EnergyTariff{Rate: 0.05}).Labels() {
+		flat.Labels[k] = v
+	}
+
+	peak := createTestNodeInfo("node-peak", "eu-west")
+	for k, v := range (&capability.EnergyTariff{
+		Rate:  0.12,
+		Peaks: []capability.TariffWindow{{Start: 17 * time.Hour, End: 21 * time.Hour, Rate: 0.35}},
+	}).Labels() {
+		peak.Labels[k] = v
+	}
+
+	return []models.NodeInfo{flat, peak, createTestNodeInfo("node-none", "us-west")}
+}
+
+// newTestProfitabilityRanker returns a ranker with its clock fixed at hour:00 UTC.
+func newTestProfitabilityRanker(config ProfitabilityConfig, hour int) *ProfitabilityRanker {
+	r := NewProfitabilityRanker(config)
+	r.now = func() time.Time { return time.Date(2026, 10, 16, hour, 0, 0, 0, time.UTC) }
+	return r
+}
+
+// gpuJob returns a single-GPU job paying payRate credits per hour.
+func gpuJob(payRate string) models.Job {
+	job := createTestJobWithGPU("gpu-job", "nvidia")
+	job.Labels = map[string]string{LabelJobPayRate: payRate}
+	return *job
+}
+
+func TestProfitabilityRanker_PeakTariff(t *testing.T) {
+	// A 300W GPU job paying 5 credits/h earns 0.05/h: enough for the peak
+	// node off-peak (0.036/h of energy), not during its peak (0.105/h)
+	job := gpuJob("5")
+
+	ranks, err := newTestProfitabilityRanker(DefaultProfitabilityConfig(), 18).RankNodes(context.Background(), job, energyTestNodes())
+	require.NoError(t, err)
+	assert.Equal(t, 130, ranks[0].Rank, "most profitable node gets the full boost")
+	assert.Equal(t, orchestrator.RankPossible, ranks[1].Rank)
+	assert.Contains(t, ranks[1].Reason, "peak energy tariff")
+	assert.True(t, ranks[1].MeetsRequirement(), "a penalty must not exclude the node")
+	assert.Equal(t, 100, ranks[2].Rank, "nodes without a tariff are neutral")
+
+	ranks, err = newTestProfitabilityRanker(DefaultProfitabilityConfig(), 12).RankNodes(context.Background(), job, energyTestNodes())
+	require.NoError(t, err)
+	assert.Greater(t, ranks[1].Rank, ranks[2].Rank, "off-peak the job is profitable")
+	assert.Less(t, ranks[1].Rank, ranks[0].Rank)
+}
+
+func TestProfitabilityRanker_NoPayRate(t *testing.T) {
+	job := *createTestJobWithGPU("gpu-job", "nvidia")
+
+	ranks, err := newTestProfitabilityRanker(DefaultProfitabilityConfig(), 18).RankNodes(context.Background(), job, energyTestNodes())
+	require.NoError(t, err)
+	for _, rank := range ranks {
+		assert.Equal(t, orchestrator.RankPossible, rank.Rank)
+		assert.Empty(t, rank.Reason)
+	}
+}
+
+func TestProfitabilityRanker_ExcludeUnprofitable(t *testing.T) {
+	config := DefaultProfitabilityConfig()
+	config.ExcludeUnprofitable = true
+
+	ranks, err := newTestProfitabilityRanker(config, 12).RankNodes(context.Background(), gpuJob("2"), energyTestNodes())
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.RankUnsuitable, ranks[1].Rank, "0.036/h of energy against 0.02/h paid")
+	assert.True(t, ranks[0].MeetsRequirement())
+}
+
+func TestScheduler_SelectNodes_Profitability(t *testing.T) {
+	var matched []orchestrator.NodeRank
+	for _, node := range energyTestNodes() {
+		matched = append(matched, orchestrator.NodeRank{NodeInfo: node, Rank: 10})
+	}
+	scheduler := NewScheduler(&mockNodeSelector{nodes: matched}, &mockCapacityProvider{},
+		WithProfitabilityRanker(newTestProfitabilityRanker(DefaultProfitabilityConfig(), 18)))
+
+	job := gpuJob("5")
+	selections, err := scheduler.SelectNodes(context.Background(), GlobalSchedulingRequest{Job: &job})
+	require.NoError(t, err)
+	require.Len(t, selections, 3)
+	assert.Equal(t, "node-flat", selections[0].NodeID)
+	assert.Equal(t, "node-peak", selections[2].NodeID)
+	assert.Contains(t, selections[2].Reason, "peak energy tariff")
+}
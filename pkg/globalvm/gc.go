@@ -0,0 +1,191 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultGCInterval is how often the garbage collector runs.
+const DefaultGCInterval = 10 * time.Minute
+
+// DataClass is a kind of per-job state with its own retention window.
+type DataClass string
+
+const (
+	// DataClassScheduling is the placement metadata recorded on submission.
+	DataClassScheduling DataClass = "scheduling"
+
+	// DataClassAudit is the audit trail of changes made to jobs.
+	DataClassAudit DataClass = "audit"
+
+	// DataClassReservations are replicated reservations tied to a job.
+	DataClassReservations DataClass = "reservations"
+
+	// DataClassAllocations are replicated capacity leases held for a job.
+	DataClassAllocations DataClass = "allocations"
+)
+
+// DefaultRetention returns how long each data class is kept after its job completes.
+func DefaultRetention() map[DataClass]time.Duration {
+	return map[DataClass]time.Duration{
+		DataClassScheduling:   24 * time.Hour,
+		DataClassAudit:        30 * 24 * time.Hour,
+		DataClassReservations: time.Hour,
+		DataClassAllocations:  time.Hour,
+	}
+}
+
+// ExpiredFunc reports whether an entry for jobID, recorded at recordedAt,
+// is past its retention window.
+type ExpiredFunc func(jobID string, recordedAt time.Time) bool
+
+// RetentionTarget is a store of per-job state the garbage collector prunes.
+type RetentionTarget interface {
+	// DataClass selects the retention window applied to the store.
+	DataClass() DataClass
+
+	// Collect removes the entries for which expired returns true and
+	// reports how many were removed.
+	Collect(ctx context.Context, expired ExpiredFunc) (int, error)
+}
+
+// GCReport summarizes one garbage collection pass.
+type GCReport struct {
+	// Reclaimed is the number of entries removed per data class.
+	Reclaimed map[DataClass]int `json:"Reclaimed"`
+
+	// JobsChecked is the number of distinct jobs looked up.
+	JobsChecked int `json:"JobsChecked"`
+}
+
+// Total returns the number of entries removed across all data classes.
+func (r GCReport) Total() int {
+	total := 0
+	for _, n := range r.Reclaimed {
+		total += n
+	}
+	return total
+}
+
+// GarbageCollector removes state kept for completed jobs once it is older
+// than the retention window of its data class.
+type GarbageCollector struct {
+	status    JobStatusProvider
+	targets   []RetentionTarget
+	retention map[DataClass]time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// GCOption configures the garbage collector.
+type GCOption func(*GarbageCollector)
+
+// WithRetention sets the retention window of a data class. Zero keeps the
+// class forever.
+func WithRetention(class DataClass, d time.Duration) GCOption {
+	return func(gc *GarbageCollector) {
+		gc.retention[class] = d
+	}
+}
+
+// WithGCInterval sets how often Run collects.
+func WithGCInterval(d time.Duration) GCOption {
+	return func(gc *GarbageCollector) {
+		gc.interval = d
+	}
+}
+
+// NewGarbageCollector creates a collector that looks up job completion
+// through status and prunes the given targets.
+func NewGarbageCollector(status JobStatusProvider, targets []RetentionTarget, opts ...GCOption) *GarbageCollector {
+	gc := &GarbageCollector{
+		status:    status,
+		targets:   targets,
+		retention: DefaultRetention(),
+		interval:  DefaultGCInterval,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(gc)
+	}
+	return gc
+}
+
+// Run collects every interval until ctx is done.
+func (gc *GarbageCollector) Run(ctx context.Context) {
+	ticker := time.NewTicker(gc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := gc.Collect(ctx); err != nil {
+				componentLogger(ctx, ComponentGC).Warn().Err(err).Msg("Global VM garbage collection failed")
+			}
+		}
+	}
+}
+
+// Collect runs one pass over all targets. An entry is removed once its job
+// is in a terminal state and both the job's completion and the entry itself
+// are older than the retention window. Entries of jobs that cannot be looked
+// up are kept, so a failing status provider never drops live state.
+func (gc *GarbageCollector) Collect(ctx context.Context) (GCReport, error) {
+	now := gc.now()
+	completed := make(map[string]*time.Time)
+	report := GCReport{Reclaimed: make(map[DataClass]int)}
+
+	// completedAt returns when the job completed, or nil if it has not
+	completedAt := func(jobID string) *time.Time {
+		if at, ok := completed[jobID]; ok {
+			return at
+		}
+		var at *time.Time
+		job, err := gc.status.GetJob(ctx, jobID)
+		if err != nil {
+			componentLogger(ctx, ComponentGC).Debug().Err(err).Str("jobID", jobID).Msg("Keeping state of job that cannot be looked up")
+		} else if job != nil && job.IsTerminal() {
+			t := job.GetModifyTime()
+			at = &t
+		}
+		completed[jobID] = at
+		return at
+	}
+
+	for _, target := range gc.targets {
+		class := target.DataClass()
+		retention := gc.retention[class]
+		if retention <= 0 {
+			continue
+		}
+		cutoff := now.Add(-retention)
+
+		removed, err := target.Collect(ctx, func(jobID string, recordedAt time.Time) bool {
+			if jobID == "" || recordedAt.After(cutoff) {
+				return false
+			}
+			at := completedAt(jobID)
+			return at != nil && at.Before(cutoff)
+		})
+		if err != nil {
+			return report, err
+		}
+		if removed > 0 {
+			report.Reclaimed[class] += removed
+			gcReclaimed.Add(ctx, int64(removed), metric.WithAttributes(AttrDataClassKey.String(string(class))))
+		}
+	}
+	report.JobsChecked = len(completed)
+
+	componentLogger(ctx, ComponentGC).Debug().
+		Int("reclaimed", report.Total()).
+		Int("jobsChecked", report.JobsChecked).
+		Msg("Global VM garbage collection complete")
+	return report, nil
+}
@@ -0,0 +1,155 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jobsStatusProvider returns jobs by ID; unknown IDs fail the lookup.
+type jobsStatusProvider struct {
+	jobs map[string]*models.Job
+}
+
+func (p *jobsStatusProvider) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	if job, ok := p.jobs[jobID]; ok {
+		return job, nil
+	}
+	return nil, fmt.Errorf("job %s not found", jobID)
+}
+
+func (p *jobsStatusProvider) GetExecutions(ctx context.Context, jobID string) ([]models.Execution, error) {
+	return nil, nil
+}
+
+func jobInState(id string, state models.JobStateType, modified time.Time) *models.Job {
+	return &models.Job{
+		ID:         id,
+		State:      models.NewJobState(state),
+		ModifyTime: modified.UnixNano(),
+	}
+}
+
+func TestGarbageCollector_Collect(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+
+	status := &jobsStatusProvider{jobs: map[string]*models.Job{
+		"done-old":    jobInState("done-old", models.JobStateTypeCompleted, old),
+		"done-recent": jobInState("done-recent", models.JobStateTypeCompleted, now.Add(-time.Minute)),
+		"running":     jobInState("running", models.JobStateTypeRunning, old),
+	}}
+
+	history := newJobHistory()
+	for _, id := range []string{"done-old", "done-recent", "running", "unknown"} {
+		history.recordPlacement(JobPlacement{JobID: id, SubmittedAt: old})
+		history.recordAudit(AuditEntry{JobID: id, Action: AuditActionSubmit, At: old})
+	}
+
+	transport := NewInMemoryTransport()
+	replicator := NewReplicator(NewReplicatedState(), transport)
+	replicator.Promote(ctx, 1)
+	require.NoError(t, replicator.PutReservation(ctx, Reservation{ID: "res-old", NodeID: "n1", JobID: "done-old", ExpiresAt: old}))
+	require.NoError(t, replicator.PutReservation(ctx, Reservation{ID: "res-run", NodeID: "n1", JobID: "running", ExpiresAt: old}))
+	require.NoError(t, replicator.PutReservation(ctx, Reservation{ID: "res-tenant", NodeID: "n1", ExpiresAt: old}))
+	require.NoError(t, replicator.PutLease(ctx, SchedulingLease{ID: "lease-old", JobID: "done-old", NodeID: "n1", ExpiresAt: old}))
+
+	targets := append([]RetentionTarget{placementTarget{history}, auditTarget{history}}, replicator.RetentionTargets()...)
+	gc := NewGarbageCollector(status, targets, WithRetention(DataClassAudit, 0))
+
+	report, err := gc.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[DataClass]int{
+		DataClassScheduling:   1,
+		DataClassReservations: 1,
+		DataClassAllocations:  1,
+	}, report.Reclaimed)
+	assert.Equal(t, 3, report.Total())
+
+	_, ok := history.placement("done-old")
+	assert.False(t, ok, "completed beyond retention")
+	for _, id := range []string{"done-recent", "running", "unknown"} {
+		_, ok := history.placement(id)
+		assert.True(t, ok, "%s is kept", id)
+	}
+	assert.Len(t, history.auditLog("done-old"), 1, "audit retention disabled")
+
+	snap := replicator.State().Snapshot()
+	require.Len(t, snap.Reservations, 2)
+	assert.Equal(t, "res-run", snap.Reservations[0].ID)
+	assert.Equal(t, "res-tenant", snap.Reservations[1].ID)
+	assert.Empty(t, snap.Leases)
+
+	// A second pass has nothing left to reclaim
+	report, err = gc.Collect(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total())
+}
+
+func TestGarbageCollector_StandbyKeepsReplicatedState(t *testing.T) {
+	ctx := context.Background()
+	old := time.Now().Add(-48 * time.Hour)
+	status := &jobsStatusProvider{jobs: map[string]*models.Job{
+		"done": jobInState("done", models.JobStateTypeCompleted, old),
+	}}
+
+	state := NewReplicatedState()
+	require.NoError(t, state.Apply(ReplicationEntry{
+		Term: 1, Index: 1, Op: OpPutLease, Key: "lease-1",
+		Data: []byte(`{"ID":"lease-1","JobID":"done","NodeID":"n1"}`),
+	}))
+	standby := NewReplicator(state, NewInMemoryTransport())
+
+	report, err := NewGarbageCollector(status, standby.RetentionTargets()).Collect(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total())
+	assert.Len(t, state.Snapshot().Leases, 1)
+}
+
+func TestEndpoint_JobHistory(t *testing.T) {
+	ctx := context.Background()
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity)
+
+	_, err := endpoint.SubmitJob(ctx, GlobalJobRequest{Job: createTestJob("job-1", models.JobTypeBatch, 1), ClientID: "client-1"})
+	require.NoError(t, err)
+	require.NoError(t, endpoint.CancelJob(ctx, "job-1", "no longer needed"))
+
+	placement, ok := endpoint.GetJobPlacement("job-1")
+	require.True(t, ok)
+	require.Len(t, placement.Selections, 1)
+	assert.Equal(t, "node-1", placement.Selections[0].NodeID)
+
+	audit := endpoint.GetAuditLog("job-1")
+	require.Len(t, audit, 2)
+	assert.Equal(t, AuditActionSubmit, audit[0].Action)
+	assert.Equal(t, "client-1", audit[0].ClientID)
+	assert.Equal(t, AuditActionCancel, audit[1].Action)
+
+	// Once the job has completed and retention passed, the state is reclaimed
+	status := &jobsStatusProvider{jobs: map[string]*models.Job{
+		"job-1": jobInState("job-1", models.JobStateTypeStopped, time.Now().Add(-time.Hour)),
+	}}
+	gc := NewGarbageCollector(status, endpoint.RetentionTargets(),
+		WithRetention(DataClassScheduling, time.Nanosecond), WithRetention(DataClassAudit, time.Nanosecond))
+	report, err := gc.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Reclaimed[DataClassScheduling])
+	assert.Equal(t, 2, report.Reclaimed[DataClassAudit])
+
+	_, ok = endpoint.GetJobPlacement("job-1")
+	assert.False(t, ok)
+	assert.Empty(t, endpoint.GetAuditLog("job-1"))
+}
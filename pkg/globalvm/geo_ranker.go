@@ -12,7 +12,6 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/models"
 	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
-	"github.com/rs/zerolog/log"
 )
 
 // GeoRankerConfig configures the geographic ranker.
@@ -91,7 +90,7 @@ func (r *GeoRanker) RankNodes(ctx context.Context, job models.Job, nodes []model
 		if region, ok := job.Labels["region"]; ok {
 			originRegion = region
 		}
-		if region, ok := job.Labels["origin-region"]; ok {
+		if region, ok := job.Labels[LabelJobOriginRegion]; ok {
 			originRegion = region
 		}
 	}
@@ -102,6 +101,10 @@ func (r *GeoRanker) RankNodes(ctx context.Context, job models.Job, nodes []model
 	maxLatency := r.getMaxLatency(job)
 
 	for i, node := range nodes {
+		// Stop ranking once nobody waits for the ranks
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		rank, reason := r.rankNode(ctx, node, originRegion, preferredRegions, excludedRegions, maxLatency)
 		ranks[i] = orchestrator.NodeRank{
 			NodeInfo:  node,
@@ -109,7 +112,7 @@ func (r *GeoRanker) RankNodes(ctx context.Context, job models.Job, nodes []model
 			Reason:    reason,
 			Retryable: true,
 		}
-		log.Ctx(ctx).Trace().Object("Rank", ranks[i]).Msg("Geo-ranked node")
+		componentLogger(ctx, ComponentRanker).Trace().Object("Rank", ranks[i]).Msg("Geo-ranked node")
 	}
 
 	return ranks, nil
@@ -305,7 +308,7 @@ func (s *GeoAwareNodeSelector) SelectNodes(
 
 	// Log rejected nodes
 	if len(rejected) > 0 {
-		log.Ctx(ctx).Debug().
+		componentLogger(ctx, ComponentRanker).Debug().
 			Int("rejected", len(rejected)).
 			Msg("Nodes rejected by selector")
 	}
@@ -331,7 +334,7 @@ func (s *GeoAwareNodeSelector) SelectNodes(
 
 	// Ensure minimum nodes
 	if len(combinedRanks) < minNodes {
-		log.Ctx(ctx).Warn().
+		componentLogger(ctx, ComponentRanker).Warn().
 			Int("available", len(combinedRanks)).
 			Int("required", minNodes).
 			Msg("Not enough nodes after geographic filtering")
@@ -0,0 +1,418 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+)
+
+// Job labels holding vendor-specific GPU requirements. Versions are
+// minimums, compared part by part, so "12.10" is newer than "12.9".
+const (
+	// LabelJobCUDAVersion is the CUDA version the job's kernels need.
+	LabelJobCUDAVersion = "cuda-version"
+
+	// LabelJobCUDACapability is the compute capability the job's kernels
+	// are built for, such as "8.0".
+	LabelJobCUDACapability = "cuda-compute-capability"
+
+	// LabelJobROCmVersion is the ROCm version the job's kernels need.
+	LabelJobROCmVersion = "rocm-version"
+
+	// LabelJobROCmTargets lists, comma separated, the GPU architectures
+	// the job's kernels are compiled for, such as "gfx90a,gfx942".
+	LabelJobROCmTargets = "rocm-targets"
+
+	// LabelJobOneAPIVersion is the oneAPI version the job's kernels need.
+	LabelJobOneAPIVersion = "oneapi-version"
+
+	// LabelJobIntelGPUTier is the lowest Intel GPU tier the job runs on.
+	LabelJobIntelGPUTier = "intel-gpu-tier"
+)
+
+// Node labels describing the GPU software stack and hardware of a node.
+// Where a node has several GPUs of the vendor, they describe the least
+// capable one.
+const (
+	LabelNodeCUDAVersion    = "gpu.cuda.version"
+	LabelNodeCUDACapability = "gpu.cuda.capability"
+	LabelNodeROCmVersion    = "gpu.rocm.version"
+	LabelNodeROCmTarget     = "gpu.rocm.target"
+	LabelNodeOneAPIVersion  = "gpu.oneapi.version"
+	LabelNodeIntelGPUTier   = "gpu.intel.tier"
+)
+
+// Intel GPU tiers, lowest first.
+const (
+	IntelTierIntegrated = "integrated"
+	IntelTierArc        = "arc"
+	IntelTierFlex       = "flex"
+	IntelTierMax        = "max"
+)
+
+var intelTiers = []string{IntelTierIntegrated, IntelTierArc, IntelTierFlex, IntelTierMax}
+
+// GPUVendorPlugin understands the requirements a job places on one
+// vendor's GPUs, such as a CUDA compute capability or a ROCm version, so
+// that the scheduler itself stays vendor-agnostic. Every scheduler has the
+// built-in CUDA, ROCm and oneAPI plugins; WithGPUVendorPlugin replaces them.
+//
+// When a job has requirements for any vendor, it only runs on nodes with
+// a GPU of a vendor whose requirements the node meets. A job with
+// requirements for several vendors, such as one built for both CUDA and
+// ROCm, may run on either.
+type GPUVendorPlugin interface {
+	// Vendor is the GPU vendor the plugin handles.
+	Vendor() models.GPUVendor
+
+	// Requirements parses the job's requirements for the vendor. It
+	// returns nil when the job has none, and an error when they are
+	// malformed, which fails the scheduling pass.
+	Requirements(job *models.Job) (GPURequirements, error)
+}
+
+// GPURequirements are a job's parsed requirements for one GPU vendor.
+type GPURequirements interface {
+	// Score reports whether the node's GPUs of the vendor meet the
+	// requirements and, if they do, the rank to add and why.
+	Score(info models.NodeInfo) (rank int, reason string, ok bool)
+}
+
+// WithGPUVendorPlugin registers a plugin for its vendor, replacing any
+// plugin for the same vendor, built-in ones included.
+func WithGPUVendorPlugin(plugin GPUVendorPlugin) SchedulerOption {
+	return func(s *Scheduler) {
+		s.gpuVendors[plugin.Vendor()] = plugin
+	}
+}
+
+// builtinGPUVendorPlugins returns the vendor plugins every scheduler knows.
+func builtinGPUVendorPlugins() map[models.GPUVendor]GPUVendorPlugin {
+	plugins := make(map[models.GPUVendor]GPUVendorPlugin)
+	for _, plugin := range []GPUVendorPlugin{NewCUDAPlugin(), NewROCmPlugin(), NewOneAPIPlugin()} {
+		plugins[plugin.Vendor()] = plugin
+	}
+	return plugins
+}
+
+// gpuRequirements returns the job's requirements for each vendor that has any.
+func (s *Scheduler) gpuRequirements(job *models.Job) (map[models.GPUVendor]GPURequirements, error) {
+	var requirements map[models.GPUVendor]GPURequirements
+	for vendor, plugin := range s.gpuVendors {
+		reqs, err := plugin.Requirements(job)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s GPU requirements: %w", gpuVendorName(vendor), err)
+		}
+		if reqs == nil {
+			continue
+		}
+		if requirements == nil {
+			requirements = make(map[models.GPUVendor]GPURequirements)
+		}
+		requirements[vendor] = reqs
+	}
+	return requirements, nil
+}
+
+// applyGPUVendors drops the nodes whose GPUs miss the job's vendor
+// requirements and adds the plugins' rank to the rest. A node with GPUs
+// of several vendors takes the best score among them.
+func (s *Scheduler) applyGPUVendors(
+	ctx context.Context, job *models.Job, ranks []orchestrator.NodeRank,
+) ([]orchestrator.NodeRank, error) {
+	requirements, err := s.gpuRequirements(job)
+	if err != nil || len(requirements) == 0 {
+		return ranks, err
+	}
+
+	filtered := make([]orchestrator.NodeRank, 0, len(ranks))
+	for _, rank := range ranks {
+		best, reason, eligible := 0, "", false
+		for _, vendor := range nodeGPUVendors(rank.NodeInfo) {
+			reqs, ok := requirements[vendor]
+			if !ok {
+				continue
+			}
+			if score, why, ok := reqs.Score(rank.NodeInfo); ok && (!eligible || score > best) {
+				best, reason, eligible = score, why, true
+			}
+		}
+		if !eligible {
+			traceFrom(ctx).reject(rank.NodeInfo.ID(), ReasonGPUVendorMismatch, "")
+			continue
+		}
+		rank.Rank += best
+		traceFrom(ctx).score(rank.NodeInfo.ID(), ReasonGPUVendor, best)
+		if reason != "" {
+			rank.Reason = reason
+		}
+		filtered = append(filtered, rank)
+	}
+
+	traceFrom(ctx).match(ConstraintGPUVendor)
+
+	componentLogger(ctx, ComponentScheduler).Debug().
+		Str("jobID", job.ID).
+		Int("matched", len(filtered)).
+		Int("rejected", len(ranks)-len(filtered)).
+		Msg("Applied GPU vendor requirements")
+
+	return filtered, nil
+}
+
+// nodeGPUVendors returns the distinct vendors of a node's GPUs.
+func nodeGPUVendors(info models.NodeInfo) []models.GPUVendor {
+	var vendors []models.GPUVendor
+	for _, gpu := range nodeGPUs(info) {
+		if !slices.Contains(vendors, gpu.Vendor) {
+			vendors = append(vendors, gpu.Vendor)
+		}
+	}
+	return vendors
+}
+
+// CUDAPlugin handles NVIDIA GPUs. Jobs set LabelJobCUDAVersion and
+// LabelJobCUDACapability; nodes advertise LabelNodeCUDAVersion and
+// LabelNodeCUDACapability.
+type CUDAPlugin struct {
+	// GenerationWeight is the rank added for each major compute
+	// capability the node is ahead of the job's, favouring newer GPUs.
+	GenerationWeight int
+}
+
+// NewCUDAPlugin creates the CUDA plugin with its defaults.
+func NewCUDAPlugin() *CUDAPlugin {
+	return &CUDAPlugin{GenerationWeight: 10}
+}
+
+// Vendor returns models.GPUVendorNvidia.
+func (p *CUDAPlugin) Vendor() models.GPUVendor {
+	return models.GPUVendorNvidia
+}
+
+// Requirements parses the job's CUDA version and compute capability.
+func (p *CUDAPlugin) Requirements(job *models.Job) (GPURequirements, error) {
+	version, err := jobVersionLabel(job, LabelJobCUDAVersion)
+	if err != nil {
+		return nil, err
+	}
+	capability, err := jobVersionLabel(job, LabelJobCUDACapability)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil && capability == nil {
+		return nil, nil
+	}
+	return &cudaRequirements{plugin: p, version: version, capability: capability}, nil
+}
+
+type cudaRequirements struct {
+	plugin              *CUDAPlugin
+	version, capability []int
+}
+
+func (r *cudaRequirements) Score(info models.NodeInfo) (int, string, bool) {
+	if !meetsVersion(info, LabelNodeCUDAVersion, r.version) {
+		return 0, "", false
+	}
+	if r.capability == nil {
+		return 0, "cuda " + info.Labels[LabelNodeCUDAVersion], true
+	}
+	capability, ok := nodeVersionLabel(info, LabelNodeCUDACapability)
+	if !ok || compareVersions(capability, r.capability) < 0 {
+		return 0, "", false
+	}
+	rank := (capability[0] - r.capability[0]) * r.plugin.GenerationWeight
+	return rank, "cuda compute capability " + info.Labels[LabelNodeCUDACapability], true
+}
+
+// ROCmPlugin handles AMD GPUs. Jobs set LabelJobROCmVersion and
+// LabelJobROCmTargets; nodes advertise LabelNodeROCmVersion and
+// LabelNodeROCmTarget.
+type ROCmPlugin struct{}
+
+// NewROCmPlugin creates the ROCm plugin.
+func NewROCmPlugin() *ROCmPlugin {
+	return &ROCmPlugin{}
+}
+
+// Vendor returns models.GPUVendorAMDATI.
+func (p *ROCmPlugin) Vendor() models.GPUVendor {
+	return models.GPUVendorAMDATI
+}
+
+// Requirements parses the job's ROCm version and architecture targets.
+func (p *ROCmPlugin) Requirements(job *models.Job) (GPURequirements, error) {
+	version, err := jobVersionLabel(job, LabelJobROCmVersion)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, target := range strings.Split(job.Labels[LabelJobROCmTargets], ",") {
+		if target = strings.ToLower(strings.TrimSpace(target)); target != "" {
+			if !strings.HasPrefix(target, "gfx") {
+				return nil, fmt.Errorf("%s: %q is not a gfx architecture", LabelJobROCmTargets, target)
+			}
+			targets = append(targets, target)
+		}
+	}
+	if version == nil && targets == nil {
+		return nil, nil
+	}
+	return &rocmRequirements{version: version, targets: targets}, nil
+}
+
+type rocmRequirements struct {
+	version []int
+	targets []string
+}
+
+func (r *rocmRequirements) Score(info models.NodeInfo) (int, string, bool) {
+	if !meetsVersion(info, LabelNodeROCmVersion, r.version) {
+		return 0, "", false
+	}
+	target := strings.ToLower(info.Labels[LabelNodeROCmTarget])
+	if r.targets != nil && !slices.Contains(r.targets, target) {
+		return 0, "", false
+	}
+	reason := "rocm " + info.Labels[LabelNodeROCmVersion]
+	if target != "" {
+		reason += " on " + target
+	}
+	return 0, reason, true
+}
+
+// OneAPIPlugin handles Intel GPUs. Jobs set LabelJobOneAPIVersion and
+// LabelJobIntelGPUTier; nodes advertise LabelNodeOneAPIVersion and
+// LabelNodeIntelGPUTier.
+type OneAPIPlugin struct {
+	// TierWeight is the rank added for each tier the node is above the
+	// job's, favouring data center GPUs.
+	TierWeight int
+}
+
+// NewOneAPIPlugin creates the oneAPI plugin with its defaults.
+func NewOneAPIPlugin() *OneAPIPlugin {
+	return &OneAPIPlugin{TierWeight: 10}
+}
+
+// Vendor returns models.GPUVendorIntel.
+func (p *OneAPIPlugin) Vendor() models.GPUVendor {
+	return models.GPUVendorIntel
+}
+
+// Requirements parses the job's oneAPI version and GPU tier.
+func (p *OneAPIPlugin) Requirements(job *models.Job) (GPURequirements, error) {
+	version, err := jobVersionLabel(job, LabelJobOneAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	tier := -1
+	if value, ok := job.Labels[LabelJobIntelGPUTier]; ok {
+		if tier = slices.Index(intelTiers, strings.ToLower(value)); tier < 0 {
+			return nil, fmt.Errorf("%s: unknown tier %q, want one of %s", LabelJobIntelGPUTier, value, strings.Join(intelTiers, ", "))
+		}
+	}
+	if version == nil && tier < 0 {
+		return nil, nil
+	}
+	return &oneAPIRequirements{plugin: p, version: version, tier: tier}, nil
+}
+
+type oneAPIRequirements struct {
+	plugin  *OneAPIPlugin
+	version []int
+	// tier indexes intelTiers, -1 for any
+	tier int
+}
+
+func (r *oneAPIRequirements) Score(info models.NodeInfo) (int, string, bool) {
+	if !meetsVersion(info, LabelNodeOneAPIVersion, r.version) {
+		return 0, "", false
+	}
+	if r.tier < 0 {
+		return 0, "oneapi " + info.Labels[LabelNodeOneAPIVersion], true
+	}
+	tier := slices.Index(intelTiers, strings.ToLower(info.Labels[LabelNodeIntelGPUTier]))
+	if tier < r.tier {
+		return 0, "", false
+	}
+	return (tier - r.tier) * r.plugin.TierWeight, "intel " + intelTiers[tier] + " gpu", true
+}
+
+// jobVersionLabel parses a version from a job label, or returns nil when
+// the job does not set it.
+func jobVersionLabel(job *models.Job, label string) ([]int, error) {
+	value, ok := job.Labels[label]
+	if !ok {
+		return nil, nil
+	}
+	version, err := parseVersion(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", label, err)
+	}
+	return version, nil
+}
+
+// nodeVersionLabel parses a version from a node label. It reports false
+// when the node does not set it or sets it to something unparsable.
+func nodeVersionLabel(info models.NodeInfo, label string) ([]int, bool) {
+	version, err := parseVersion(info.Labels[label])
+	return version, err == nil
+}
+
+// meetsVersion reports whether the node's version label is at least
+// want. Any node meets a nil want.
+func meetsVersion(info models.NodeInfo, label string, want []int) bool {
+	if want == nil {
+		return true
+	}
+	version, ok := nodeVersionLabel(info, label)
+	return ok && compareVersions(version, want) >= 0
+}
+
+// parseVersion parses a dotted version such as "12.1" into its parts.
+func parseVersion(s string) ([]int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+	parts := strings.Split(s, ".")
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// compareVersions compares two versions part by part, missing parts
+// counting as zero, and returns -1, 0 or 1.
+func compareVersions(a, b []int) int {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
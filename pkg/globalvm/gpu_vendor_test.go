@@ -0,0 +1,159 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vendorNodeInfo returns a node with one GPU of the vendor and the labels.
+func vendorNodeInfo(id string, vendor models.GPUVendor, labels map[string]string) models.NodeInfo {
+	info := createTestNodeInfo(id, "us-east")
+	info.ComputeNodeInfo.MaxCapacity = models.Resources{GPU: 1, GPUs: []models.GPU{{Vendor: vendor}}}
+	for key, value := range labels {
+		info.Labels[key] = value
+	}
+	return info
+}
+
+func vendorTestScheduler(opts ...SchedulerOption) *Scheduler {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: vendorNodeInfo("a100", models.GPUVendorNvidia, map[string]string{
+			LabelNodeCUDAVersion: "12.2", LabelNodeCUDACapability: "8.0",
+		}), Rank: 10},
+		{NodeInfo: vendorNodeInfo("h100", models.GPUVendorNvidia, map[string]string{
+			LabelNodeCUDAVersion: "12.4", LabelNodeCUDACapability: "9.0",
+		}), Rank: 10},
+		{NodeInfo: vendorNodeInfo("t4", models.GPUVendorNvidia, map[string]string{
+			LabelNodeCUDAVersion: "11.8", LabelNodeCUDACapability: "7.5",
+		}), Rank: 10},
+		{NodeInfo: vendorNodeInfo("mi250", models.GPUVendorAMDATI, map[string]string{
+			LabelNodeROCmVersion: "6.0.2", LabelNodeROCmTarget: "gfx90a",
+		}), Rank: 10},
+		{NodeInfo: vendorNodeInfo("max1550", models.GPUVendorIntel, map[string]string{
+			LabelNodeOneAPIVersion: "2024.1", LabelNodeIntelGPUTier: IntelTierMax,
+		}), Rank: 10},
+		{NodeInfo: vendorNodeInfo("arc770", models.GPUVendorIntel, map[string]string{
+			LabelNodeOneAPIVersion: "2024.1", LabelNodeIntelGPUTier: IntelTierArc,
+		}), Rank: 10},
+		{NodeInfo: createTestNodeInfo("cpu-only", "us-east"), Rank: 10},
+	}}
+	return NewScheduler(selector, &mockCapacityProvider{}, opts...)
+}
+
+func TestScheduler_GPUVendorRequirements(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+		// best is the node expected first, when ranks tell them apart
+		best string
+	}{
+		{
+			name:   "no vendor requirements",
+			labels: nil,
+			want:   []string{"a100", "h100", "t4", "mi250", "max1550", "arc770", "cpu-only"},
+		},
+		{
+			name:   "cuda compute capability favours newer generations",
+			labels: map[string]string{LabelJobCUDACapability: "8.0", LabelJobCUDAVersion: "12.1"},
+			want:   []string{"h100", "a100"},
+			best:   "h100",
+		},
+		{
+			name:   "rocm targets",
+			labels: map[string]string{LabelJobROCmTargets: "gfx90a, gfx942", LabelJobROCmVersion: "6.0"},
+			want:   []string{"mi250"},
+		},
+		{
+			name:   "rocm version too new",
+			labels: map[string]string{LabelJobROCmVersion: "6.1"},
+			want:   []string{},
+		},
+		{
+			name:   "intel tier",
+			labels: map[string]string{LabelJobIntelGPUTier: "flex"},
+			want:   []string{"max1550"},
+			best:   "max1550",
+		},
+		{
+			name:   "built for both cuda and rocm",
+			labels: map[string]string{LabelJobCUDACapability: "9.0", LabelJobROCmTargets: "gfx90a"},
+			want:   []string{"h100", "mi250"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := createTestJobWithGPU("job-1", "")
+			job.Labels = tt.labels
+
+			selections, err := vendorTestScheduler().SelectNodes(context.Background(), GlobalSchedulingRequest{Job: job})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, selectedIDs(selections))
+			if tt.best != "" {
+				assert.Equal(t, tt.best, selections[0].NodeID)
+			}
+		})
+	}
+}
+
+func TestScheduler_InvalidGPUVendorRequirements(t *testing.T) {
+	for _, labels := range []map[string]string{
+		{LabelJobCUDACapability: "eight"},
+		{LabelJobROCmTargets: "mi250"},
+		{LabelJobIntelGPUTier: "ultra"},
+	} {
+		job := createTestJobWithGPU("job-1", "")
+		job.Labels = labels
+		_, err := vendorTestScheduler().SelectNodes(context.Background(), GlobalSchedulingRequest{Job: job})
+		assert.Error(t, err, "labels %v", labels)
+	}
+}
+
+// pinnedPlugin is an NVIDIA plugin that only accepts one node.
+type pinnedPlugin struct{ nodeID string }
+
+func (p pinnedPlugin) Vendor() models.GPUVendor { return models.GPUVendorNvidia }
+
+func (p pinnedPlugin) Requirements(job *models.Job) (GPURequirements, error) {
+	if _, ok := job.Labels[LabelJobCUDAVersion]; !ok {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (p pinnedPlugin) Score(info models.NodeInfo) (int, string, bool) {
+	return 0, "pinned", info.ID() == p.nodeID
+}
+
+func TestScheduler_CustomGPUVendorPlugin(t *testing.T) {
+	job := createTestJobWithGPU("job-1", "")
+	job.Labels = map[string]string{LabelJobCUDAVersion: "99"}
+
+	scheduler := vendorTestScheduler(WithGPUVendorPlugin(pinnedPlugin{nodeID: "t4"}))
+	selections, err := scheduler.SelectNodes(context.Background(), GlobalSchedulingRequest{Job: job})
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "t4", selections[0].NodeID)
+	assert.Equal(t, "pinned", selections[0].Reason)
+}
+
+func TestCompareVersions(t *testing.T) {
+	version := func(s string) []int {
+		v, err := parseVersion(s)
+		require.NoError(t, err)
+		return v
+	}
+	assert.Equal(t, 1, compareVersions(version("12.10"), version("12.9")))
+	assert.Equal(t, 0, compareVersions(version("6.0"), version("6")))
+	assert.Equal(t, -1, compareVersions(version("v5.7.1"), version("6.0")))
+
+	_, err := parseVersion("12.x")
+	assert.Error(t, err)
+}
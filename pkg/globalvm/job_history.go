@@ -0,0 +1,155 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AuditAction is a change made to a job through the Endpoint.
+type AuditAction string
+
+const (
+	AuditActionSubmit AuditAction = "submit"
+	AuditActionScale  AuditAction = "scale"
+	AuditActionCancel AuditAction = "cancel"
+
+	// The submission was cancelled before the orchestrator accepted the
+	// job, and whatever it had allocated was released.
+	AuditActionAbort AuditAction = "abort"
+
+	// The job got its nodes after waiting in the queue, lost them to a
+	// preemption, or finished running.
+	AuditActionStart   AuditAction = "start"
+	AuditActionPreempt AuditAction = "preempt"
+	AuditActionFinish  AuditAction = "finish"
+)
+
+// JobPlacement is the scheduling metadata kept for a submitted job.
+type JobPlacement struct {
+	JobID        string          `json:"JobID"`
+	SchedulingID string          `json:"SchedulingID,omitempty"`
+	SubmittedAt  time.Time       `json:"SubmittedAt"`
+	Selections   []NodeSelection `json:"Selections,omitempty"`
+	Queued       bool            `json:"Queued,omitempty"`
+	Partial      bool            `json:"Partial,omitempty"`
+	Warnings     []string        `json:"Warnings,omitempty"`
+}
+
+// AuditEntry records one change made to a job.
+type AuditEntry struct {
+	JobID    string      `json:"JobID"`
+	Action   AuditAction `json:"Action"`
+	ClientID string      `json:"ClientID,omitempty"`
+	Detail   string      `json:"Detail,omitempty"`
+	At       time.Time   `json:"At"`
+
+	// Where a submitted or started job runs: the regions and number of its
+	// nodes. GPUsPerNode is the job's GPU request, recorded on submission.
+	Regions     []string `json:"Regions,omitempty"`
+	Nodes       int      `json:"Nodes,omitempty"`
+	GPUsPerNode int      `json:"GPUsPerNode,omitempty"`
+
+	// Selected records each node's role, rank and selection reasons, and
+	// Rejected counts the nodes a submission's scheduling pass dropped by
+	// reason.
+	Selected []SelectionRecord  `json:"Selected,omitempty"`
+	Rejected map[ReasonCode]int `json:"Rejected,omitempty"`
+
+	// Decision is the scheduling decision behind a submission, kept so it
+	// can be replayed.
+	Decision *SchedulingDecision `json:"Decision,omitempty"`
+}
+
+// jobHistory holds the Endpoint's per-job scheduling metadata and audit trail.
+type jobHistory struct {
+	mu         sync.RWMutex
+	placements map[string]JobPlacement
+	audit      []AuditEntry
+}
+
+func newJobHistory() *jobHistory {
+	return &jobHistory{placements: make(map[string]JobPlacement)}
+}
+
+func (h *jobHistory) recordPlacement(p JobPlacement) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.placements[p.JobID] = p
+}
+
+func (h *jobHistory) placement(jobID string) (JobPlacement, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	p, ok := h.placements[jobID]
+	return p, ok
+}
+
+func (h *jobHistory) recordAudit(entry AuditEntry) {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.audit = append(h.audit, entry)
+}
+
+func (h *jobHistory) auditLog(jobID string) []AuditEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var entries []AuditEntry
+	for _, entry := range h.audit {
+		if entry.JobID == jobID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// auditEntries returns the whole audit trail, oldest first.
+func (h *jobHistory) auditEntries() []AuditEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]AuditEntry(nil), h.audit...)
+}
+
+// placementTarget exposes the scheduling metadata to the garbage collector.
+type placementTarget struct{ h *jobHistory }
+
+func (t placementTarget) DataClass() DataClass { return DataClassScheduling }
+
+func (t placementTarget) Collect(_ context.Context, expired ExpiredFunc) (int, error) {
+	t.h.mu.Lock()
+	defer t.h.mu.Unlock()
+
+	removed := 0
+	for jobID, p := range t.h.placements {
+		if expired(jobID, p.SubmittedAt) {
+			delete(t.h.placements, jobID)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// auditTarget exposes the audit trail to the garbage collector.
+type auditTarget struct{ h *jobHistory }
+
+func (t auditTarget) DataClass() DataClass { return DataClassAudit }
+
+func (t auditTarget) Collect(_ context.Context, expired ExpiredFunc) (int, error) {
+	t.h.mu.Lock()
+	defer t.h.mu.Unlock()
+
+	kept := t.h.audit[:0]
+	for _, entry := range t.h.audit {
+		if !expired(entry.JobID, entry.At) {
+			kept = append(kept, entry)
+		}
+	}
+	removed := len(t.h.audit) - len(kept)
+	t.h.audit = kept
+	return removed, nil
+}
@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/models"
-	"github.com/rs/zerolog/log"
 )
 
 // LatencyMatrix provides latency tracking between regions/nodes.
@@ -32,8 +31,18 @@ type LatencyMatrix interface {
 	// GetAllLatencies returns all known latencies from a region.
 	GetAllLatencies(from string) map[string]time.Duration
 
-	// ClearCache clears the latency cache.
+	// GetBandwidth returns the bandwidth between two regions or nodes in bytes per second.
+	GetBandwidth(from, to string) float64
+
+	// UpdateBandwidth updates the bandwidth between two regions or nodes.
+	UpdateBandwidth(from, to string, bytesPerSecond float64)
+
+	// ClearCache clears the latency and bandwidth cache.
 	ClearCache()
+
+	// LastUpdated returns when a latency was last reported, or the zero
+	// time if none has been since the cache was cleared.
+	LastUpdated() time.Time
 }
 
 // LatencyProbe defines the interface for latency probing.
@@ -58,6 +67,10 @@ type LatencyMatrixConfig struct {
 
 	// DefaultLatency is used when latency is unknown.
 	DefaultLatency time.Duration
+
+	// DefaultBandwidth is used when bandwidth is unknown, in bytes per second.
+	// Zero falls back to EstimatedBandwidth.
+	DefaultBandwidth float64
 }
 
 // DefaultLatencyMatrixConfig returns the default configuration.
@@ -77,30 +90,35 @@ type matrixEntry struct {
 	source     string // "probe", "reported", "estimated"
 }
 
+// bandwidthEntry represents a cached bandwidth entry.
+type bandwidthEntry struct {
+	bytesPerSecond float64
+	measuredAt     time.Time
+}
+
 // latencyMatrix implements the LatencyMatrix interface.
 type latencyMatrix struct {
 	config LatencyMatrixConfig
 	probe  LatencyProbe
 
-	mu     sync.RWMutex
-	matrix map[string]map[string]*matrixEntry // from -> to -> entry
+	mu        sync.RWMutex
+	matrix    map[string]map[string]*matrixEntry   // from -> to -> entry
+	bandwidth map[string]map[string]bandwidthEntry // from -> to -> entry
+	updatedAt time.Time
 }
 
 // NewLatencyMatrix creates a new latency matrix.
 func NewLatencyMatrix(config LatencyMatrixConfig) LatencyMatrix {
-	return &latencyMatrix{
-		config: config,
-		probe:  NewHTTPLatencyProbe(config.ProbeTimeout),
-		matrix: make(map[string]map[string]*matrixEntry),
-	}
+	return NewLatencyMatrixWithProbe(config, NewHTTPLatencyProbe(config.ProbeTimeout))
 }
 
 // NewLatencyMatrixWithProbe creates a latency matrix with a custom probe.
 func NewLatencyMatrixWithProbe(config LatencyMatrixConfig, probe LatencyProbe) LatencyMatrix {
 	return &latencyMatrix{
-		config: config,
-		probe:  probe,
-		matrix: make(map[string]map[string]*matrixEntry),
+		config:    config,
+		probe:     probe,
+		matrix:    make(map[string]map[string]*matrixEntry),
+		bandwidth: make(map[string]map[string]bandwidthEntry),
 	}
 }
 
@@ -150,6 +168,7 @@ func (m *latencyMatrix) UpdateLatency(from, to string, latency time.Duration) {
 		measuredAt: time.Now(),
 		source:     "reported",
 	}
+	m.updatedAt = time.Now()
 }
 
 // GetNearestNodes returns nodes sorted by proximity to a region.
@@ -212,12 +231,51 @@ func (m *latencyMatrix) GetAllLatencies(from string) map[string]time.Duration {
 	return result
 }
 
-// ClearCache clears the latency cache.
+// GetBandwidth returns the bandwidth between two regions or nodes.
+func (m *latencyMatrix) GetBandwidth(from, to string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if entry, ok := m.bandwidth[from][to]; ok && time.Since(entry.measuredAt) < m.config.CacheExpiry {
+		return entry.bytesPerSecond
+	}
+
+	if m.config.DefaultBandwidth > 0 {
+		return m.config.DefaultBandwidth
+	}
+	return EstimatedBandwidth(from, to)
+}
+
+// UpdateBandwidth updates the bandwidth between two regions or nodes.
+func (m *latencyMatrix) UpdateBandwidth(from, to string, bytesPerSecond float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := bandwidthEntry{bytesPerSecond: bytesPerSecond, measuredAt: time.Now()}
+	for _, pair := range [][2]string{{from, to}, {to, from}} {
+		if m.bandwidth[pair[0]] == nil {
+			m.bandwidth[pair[0]] = make(map[string]bandwidthEntry)
+		}
+		m.bandwidth[pair[0]][pair[1]] = entry
+	}
+}
+
+// ClearCache clears the latency and bandwidth cache.
 func (m *latencyMatrix) ClearCache() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.matrix = make(map[string]map[string]*matrixEntry)
+	m.bandwidth = make(map[string]map[string]bandwidthEntry)
+	m.updatedAt = time.Time{}
+}
+
+// LastUpdated returns when a latency was last reported.
+func (m *latencyMatrix) LastUpdated() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.updatedAt
 }
 
 // httpLatencyProbe implements LatencyProbe using HTTP.
@@ -257,7 +315,7 @@ func (p *httpLatencyProbe) Probe(ctx context.Context, target string) (time.Durat
 
 	latency := time.Since(start)
 
-	log.Ctx(ctx).Trace().
+	componentLogger(ctx, ComponentLatency).Trace().
 		Str("target", target).
 		Dur("latency", latency).
 		Int("status", resp.StatusCode).
@@ -333,8 +391,17 @@ func EstimatedLatency(fromRegion, toRegion string) time.Duration {
 		}
 	}
 
-	// Default latency for unknown region pairs
-	return 200 * time.Millisecond
+	// Otherwise inherit the default of the areas both regions share
+	return regions().Latency(fromRegion, toRegion)
+}
+
+// EstimatedBandwidth estimates bandwidth between regions in bytes per second.
+// This provides a fallback when actual measurements aren't available.
+func EstimatedBandwidth(fromRegion, toRegion string) float64 {
+	if fromRegion == toRegion {
+		return 125e6 // 1 Gbit/s within a region
+	}
+	return 12.5e6 // 100 Mbit/s across regions
 }
 
 // LatencyAwareNodeInfo wraps NodeInfo with latency information.
@@ -0,0 +1,291 @@
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+// DefaultLeaseTTL is how long a lease holds capacity before it is released
+// if the job is never dispatched.
+const DefaultLeaseTTL = 30 * time.Second
+
+var (
+	// ErrLeaseConflict is returned when the requested capacity is already leased.
+	ErrLeaseConflict = errors.New("capacity already leased")
+
+	// ErrLeaseNotFound is returned when a lease does not exist or has expired.
+	ErrLeaseNotFound = errors.New("lease not found")
+)
+
+// SchedulingLease is a short-lived claim on node capacity held by a scheduling pass.
+type SchedulingLease struct {
+	ID        string           `json:"ID"`
+	JobID     string           `json:"JobID"`
+	NodeID    string           `json:"NodeID"`
+	Resources models.Resources `json:"Resources"`
+	ExpiresAt time.Time        `json:"ExpiresAt"`
+
+	// ConfirmedAt is when the lease's job was dispatched. Until the node
+	// reports its capacity after that, the report does not account for
+	// the job, so the lease keeps holding the capacity.
+	ConfirmedAt time.Time `json:"ConfirmedAt,omitempty"`
+}
+
+// CapacityLeaser grants short-lived leases on node capacity so that
+// concurrent scheduling passes cannot allocate the same free capacity.
+// A lease is confirmed once its job is dispatched, or released if the
+// job is not placed. Leases that are neither expire after their TTL.
+type CapacityLeaser interface {
+	// AcquireLease claims resources on a node for a job.
+	AcquireLease(ctx context.Context, jobID, nodeID string, resources models.Resources) (*SchedulingLease, error)
+
+	// ConfirmLease marks the lease as used by a dispatched job.
+	ConfirmLease(ctx context.Context, leaseID string) error
+
+	// ReleaseLease returns the leased capacity to the pool.
+	ReleaseLease(ctx context.Context, leaseID string) error
+}
+
+// LeaseStore keeps scheduling leases where a standby Endpoint can see
+// them, so a standby promoted after failover does not grant capacity the
+// old leader had leased. A Replicator is a LeaseStore.
+type LeaseStore interface {
+	// PutLease records a lease.
+	PutLease(ctx context.Context, lease SchedulingLease) error
+
+	// DeleteLease removes a lease.
+	DeleteLease(ctx context.Context, id string) error
+
+	// Leases returns the leases recorded.
+	Leases() []SchedulingLease
+}
+
+// WithLeaseTTL sets how long allocation leases are held before they expire.
+func WithLeaseTTL(d time.Duration) AggregatorOption {
+	return func(a *CapacityAggregator) {
+		a.leaseTTL = d
+	}
+}
+
+// WithLeaseStore keeps leases in store rather than only in memory. Leases
+// are granted and removed only once the store has recorded the change.
+func WithLeaseStore(store LeaseStore) AggregatorOption {
+	return func(a *CapacityAggregator) {
+		a.leaseStore = store
+	}
+}
+
+// AcquireLease claims resources on a node for a job. It fails with
+// ErrLeaseConflict if the node's free capacity, less the capacity already
+// leased on it, cannot fit the request.
+func (a *CapacityAggregator) AcquireLease(
+	ctx context.Context, jobID, nodeID string, resources models.Resources,
+) (*SchedulingLease, error) {
+	snapshot, err := a.GetSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var node *NodeCapacity
+	for i := range snapshot.NodeDetails {
+		if snapshot.NodeDetails[i].NodeID == nodeID {
+			node = &snapshot.NodeDetails[i]
+			break
+		}
+	}
+	if node == nil || !node.IsHealthy {
+		return nil, fmt.Errorf("node %s is not available", nodeID)
+	}
+
+	a.leaseMu.Lock()
+	defer a.leaseMu.Unlock()
+
+	a.loadLeases(ctx)
+	a.expireLeases(ctx, time.Now())
+
+	leased := a.leasedOn(nodeID, node.LastSeen)
+	requested := leased.Add(resources)
+	if !requested.LessThanEq(node.Resources) {
+		leaseContention.Add(ctx, 1)
+		componentLogger(ctx, ComponentLease).Debug().
+			Str("jobID", jobID).
+			Str("nodeID", nodeID).
+			Str("leased", leased.String()).
+			Msg("Lease rejected, capacity already leased")
+		return nil, fmt.Errorf("%w on node %s", ErrLeaseConflict, nodeID)
+	}
+
+	lease := SchedulingLease{
+		ID:        uuid.NewString(),
+		JobID:     jobID,
+		NodeID:    nodeID,
+		Resources: resources,
+		ExpiresAt: time.Now().Add(a.leaseTTL),
+	}
+	if a.leaseStore != nil {
+		if err := a.leaseStore.PutLease(ctx, lease); err != nil {
+			return nil, fmt.Errorf("failed to record lease: %w", err)
+		}
+	}
+	a.leases[lease.ID] = lease
+	leasesGranted.Add(ctx, 1)
+	leasesActive.Record(ctx, int64(len(a.leases)))
+
+	return &lease, nil
+}
+
+// ConfirmLease marks the lease as used by a dispatched job. The lease holds
+// the capacity until the node's next report, which accounts for the job,
+// and is dropped when its TTL runs out from confirmation. It fails with
+// ErrLeaseNotFound if the lease is not pending.
+func (a *CapacityAggregator) ConfirmLease(ctx context.Context, leaseID string) error {
+	a.leaseMu.Lock()
+	defer a.leaseMu.Unlock()
+
+	a.loadLeases(ctx)
+	lease, ok := a.leases[leaseID]
+	if !ok || !lease.ConfirmedAt.IsZero() {
+		return ErrLeaseNotFound
+	}
+	now := time.Now()
+	lease.ConfirmedAt = now
+	lease.ExpiresAt = now.Add(a.leaseTTL)
+	if a.leaseStore != nil {
+		if err := a.leaseStore.PutLease(ctx, lease); err != nil {
+			return fmt.Errorf("failed to record lease: %w", err)
+		}
+	}
+	a.leases[leaseID] = lease
+	leasesConfirmed.Add(ctx, 1)
+	return nil
+}
+
+// ReleaseLease returns the leased capacity to the pool.
+func (a *CapacityAggregator) ReleaseLease(ctx context.Context, leaseID string) error {
+	a.leaseMu.Lock()
+	defer a.leaseMu.Unlock()
+
+	a.loadLeases(ctx)
+	if err := a.removeLease(ctx, leaseID); err != nil {
+		return err
+	}
+	leasesReleased.Add(ctx, 1, metric.WithAttributes(AttrReasonKey.String(AttrReasonReleased)))
+	return nil
+}
+
+// ActiveLeases returns the leases currently held.
+func (a *CapacityAggregator) ActiveLeases(ctx context.Context) []SchedulingLease {
+	a.leaseMu.Lock()
+	defer a.leaseMu.Unlock()
+
+	a.loadLeases(ctx)
+	a.expireLeases(ctx, time.Now())
+
+	leases := make([]SchedulingLease, 0, len(a.leases))
+	for _, lease := range a.leases {
+		leases = append(leases, lease)
+	}
+	return leases
+}
+
+// leasedTotal returns the resources held by unexpired leases across all
+// nodes, leaving out confirmed leases the nodes have since reported on.
+func (a *CapacityAggregator) leasedTotal(ctx context.Context, nodes []NodeCapacity) models.Resources {
+	a.leaseMu.Lock()
+	defer a.leaseMu.Unlock()
+
+	a.loadLeases(ctx)
+	a.expireLeases(ctx, time.Now())
+
+	reported := make(map[string]time.Time, len(nodes))
+	for _, node := range nodes {
+		reported[node.NodeID] = node.LastSeen
+	}
+	total := models.Resources{}
+	for _, lease := range a.leases {
+		if lease.holds(reported[lease.NodeID]) {
+			total = *total.Add(lease.Resources)
+		}
+	}
+	return total
+}
+
+// leasedOn returns the resources leased on a node whose capacity was
+// last reported at reported. The lease lock must be held.
+func (a *CapacityAggregator) leasedOn(nodeID string, reported time.Time) models.Resources {
+	total := models.Resources{}
+	for _, lease := range a.leases {
+		if lease.NodeID == nodeID && lease.holds(reported) {
+			total = *total.Add(lease.Resources)
+		}
+	}
+	return total
+}
+
+// holds reports whether the lease holds capacity a node report made at
+// reported does not already account for.
+func (l SchedulingLease) holds(reported time.Time) bool {
+	return l.ConfirmedAt.IsZero() || !reported.After(l.ConfirmedAt)
+}
+
+// expireLeases drops leases past their TTL. The lease lock must be held.
+func (a *CapacityAggregator) expireLeases(ctx context.Context, now time.Time) {
+	for id, lease := range a.leases {
+		if now.After(lease.ExpiresAt) {
+			if err := a.removeLease(ctx, id); err != nil {
+				componentLogger(ctx, ComponentLease).Warn().Err(err).Str("leaseID", id).Msg("Failed to remove expired lease")
+				continue
+			}
+			if !lease.ConfirmedAt.IsZero() {
+				continue
+			}
+			componentLogger(ctx, ComponentLease).Debug().
+				Str("leaseID", id).
+				Str("jobID", lease.JobID).
+				Str("nodeID", lease.NodeID).
+				Msg("Lease expired without dispatch")
+			leasesReleased.Add(ctx, 1, metric.WithAttributes(AttrReasonKey.String(AttrReasonExpired)))
+		}
+	}
+}
+
+// loadLeases replaces the leases held in memory with the lease store's,
+// which include those granted by an earlier leader. The lease lock must
+// be held.
+func (a *CapacityAggregator) loadLeases(ctx context.Context) {
+	if a.leaseStore == nil {
+		return
+	}
+	stored := a.leaseStore.Leases()
+	a.leases = make(map[string]SchedulingLease, len(stored))
+	for _, lease := range stored {
+		a.leases[lease.ID] = lease
+	}
+	leasesActive.Record(ctx, int64(len(a.leases)))
+}
+
+// removeLease deletes a lease, from the lease store first if there is one.
+// The lease lock must be held.
+func (a *CapacityAggregator) removeLease(ctx context.Context, leaseID string) error {
+	if _, ok := a.leases[leaseID]; !ok {
+		return ErrLeaseNotFound
+	}
+	if a.leaseStore != nil {
+		if err := a.leaseStore.DeleteLease(ctx, leaseID); err != nil {
+			return fmt.Errorf("failed to remove lease %s: %w", leaseID, err)
+		}
+	}
+	delete(a.leases, leaseID)
+	leasesActive.Record(ctx, int64(len(a.leases)))
+	return nil
+}
+
+// Ensure the aggregator grants leases
+var _ CapacityLeaser = (*CapacityAggregator)(nil)
@@ -0,0 +1,336 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newLeaseTestAggregator(opts ...AggregatorOption) *CapacityAggregator {
+	lookup := &mockNodeLookup{states: []models.NodeState{
+		createMockNodeState("node-1", true, 8.0, 32<<30, 100<<30, nil),
+		createMockNodeState("node-2", true, 8.0, 32<<30, 100<<30, nil),
+		createMockNodeState("node-down", false, 8.0, 32<<30, 100<<30, nil),
+	}}
+	return NewCapacityAggregator(lookup, opts...)
+}
+
+func TestCapacityAggregator_AcquireLease(t *testing.T) {
+	ctx := context.Background()
+	a := newLeaseTestAggregator()
+	half := models.Resources{CPU: 4, Memory: 16 << 30}
+
+	first, err := a.AcquireLease(ctx, "job-1", "node-1", half)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, "node-1", first.NodeID)
+
+	_, err = a.AcquireLease(ctx, "job-2", "node-1", half)
+	require.NoError(t, err)
+
+	// node-1 is now fully leased
+	_, err = a.AcquireLease(ctx, "job-3", "node-1", half)
+	assert.ErrorIs(t, err, ErrLeaseConflict)
+
+	// Other nodes are unaffected
+	_, err = a.AcquireLease(ctx, "job-3", "node-2", half)
+	require.NoError(t, err)
+
+	_, err = a.AcquireLease(ctx, "job-4", "node-down", half)
+	assert.Error(t, err)
+	_, err = a.AcquireLease(ctx, "job-4", "missing", half)
+	assert.Error(t, err)
+
+	assert.Len(t, a.ActiveLeases(ctx), 3)
+}
+
+func TestCapacityAggregator_ConfirmAndReleaseLease(t *testing.T) {
+	ctx := context.Background()
+	a := newLeaseTestAggregator()
+	full := models.Resources{CPU: 8, Memory: 32 << 30}
+
+	lease, err := a.AcquireLease(ctx, "job-1", "node-1", full)
+	require.NoError(t, err)
+
+	require.NoError(t, a.ReleaseLease(ctx, lease.ID))
+	assert.ErrorIs(t, a.ReleaseLease(ctx, lease.ID), ErrLeaseNotFound)
+
+	// Released capacity can be leased again
+	lease, err = a.AcquireLease(ctx, "job-2", "node-1", full)
+	require.NoError(t, err)
+
+	require.NoError(t, a.ConfirmLease(ctx, lease.ID))
+	assert.ErrorIs(t, a.ConfirmLease(ctx, lease.ID), ErrLeaseNotFound)
+	active := a.ActiveLeases(ctx)
+	require.Len(t, active, 1)
+	assert.False(t, active[0].ConfirmedAt.IsZero())
+}
+
+func TestCapacityAggregator_ConfirmedLeaseHoldsUntilNodeReports(t *testing.T) {
+	ctx := context.Background()
+	node := createMockNodeState("node-1", true, 8.0, 32<<30, 100<<30, []models.GPU{{Index: 0}, {Index: 1}})
+	node.Info.ComputeNodeInfo.AvailableCapacity.GPU = 2
+	lookup := &mockNodeLookup{states: []models.NodeState{node}}
+	a := NewCapacityAggregator(lookup)
+	full := models.Resources{CPU: 8, Memory: 32 << 30, GPU: 2}
+
+	lease, err := a.AcquireLease(ctx, "job-1", "node-1", full)
+	require.NoError(t, err)
+	capacity, err := a.GetAvailableCapacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, capacity.AvailableGPU, "leased GPUs are not available")
+
+	// Dispatched, but the node has not reported the job's allocation yet
+	require.NoError(t, a.ConfirmLease(ctx, lease.ID))
+	_, err = a.AcquireLease(ctx, "job-2", "node-1", full)
+	assert.ErrorIs(t, err, ErrLeaseConflict)
+	capacity, err = a.GetAvailableCapacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, capacity.AvailableCPU)
+	assert.Equal(t, 0, capacity.AvailableGPU)
+
+	// The node's next report accounts for the job, so the lease no longer
+	// counts against it
+	time.Sleep(time.Millisecond)
+	lookup.states[0].ConnectionState.LastHeartbeat = time.Now()
+	lookup.states[0].Info.ComputeNodeInfo.AvailableCapacity = models.Resources{CPU: 0.5, Memory: 1 << 30}
+	capacity, err = a.GetAvailableCapacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, capacity.AvailableCPU)
+}
+
+func TestCapacityAggregator_LeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	a := newLeaseTestAggregator(WithLeaseTTL(time.Millisecond))
+	full := models.Resources{CPU: 8, Memory: 32 << 30}
+
+	lease, err := a.AcquireLease(ctx, "job-1", "node-1", full)
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = a.AcquireLease(ctx, "job-2", "node-1", full)
+	require.NoError(t, err)
+	assert.ErrorIs(t, a.ConfirmLease(ctx, lease.ID), ErrLeaseNotFound)
+}
+
+func TestCapacityAggregator_LeasesReduceAvailableCapacity(t *testing.T) {
+	ctx := context.Background()
+	a := newLeaseTestAggregator()
+
+	_, err := a.AcquireLease(ctx, "job-1", "node-1", models.Resources{CPU: 3, Memory: 8 << 30})
+	require.NoError(t, err)
+
+	capacity, err := a.GetAvailableCapacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 16.0, capacity.TotalCPU)
+	assert.Equal(t, 13.0, capacity.AvailableCPU)
+	assert.Equal(t, uint64(56<<30), capacity.AvailableMemory)
+}
+
+func TestCapacityAggregator_ConcurrentLeases(t *testing.T) {
+	ctx := context.Background()
+	a := newLeaseTestAggregator()
+	quarter := models.Resources{CPU: 2, Memory: 8 << 30}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	granted, conflicts := 0, 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.AcquireLease(ctx, "job", "node-1", quarter)
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, ErrLeaseConflict) {
+				conflicts++
+			} else if err == nil {
+				granted++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 4, granted)
+	assert.Equal(t, 16, conflicts)
+}
+
+func TestScheduler_SelectNodesWithLeases(t *testing.T) {
+	ctx := context.Background()
+	a := newLeaseTestAggregator()
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("node-2", "us-east"), Rank: 10},
+	}}
+	scheduler := NewScheduler(selector, a)
+
+	job := createTestJob("job-1", models.JobTypeBatch, 1)
+	job.Tasks[0].ResourcesConfig = &models.ResourcesConfig{CPU: "8", Memory: "32GiB"}
+	req := GlobalSchedulingRequest{Job: job, TargetCount: 1, LeaseCapacity: true}
+
+	// Two concurrent passes for the same capacity land on different nodes
+	first, err := scheduler.SelectNodes(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, "node-1", first[0].NodeID)
+	assert.NotEmpty(t, first[0].LeaseID)
+
+	second, err := scheduler.SelectNodes(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, "node-2", second[0].NodeID)
+
+	third, err := scheduler.SelectNodes(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, third)
+
+	// Without leasing, selection is unchanged
+	req.LeaseCapacity = false
+	plain, err := scheduler.SelectNodes(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, plain, 1)
+	assert.Empty(t, plain[0].LeaseID)
+}
+
+func TestEndpoint_SubmitJobLeases(t *testing.T) {
+	ctx := context.Background()
+	a := newLeaseTestAggregator()
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+	}}
+	job := createTestJob("job-1", models.JobTypeBatch, 1)
+
+	// A failed dispatch releases the lease
+	failing := NewEndpoint(NewScheduler(selector, a), a,
+		WithJobSubmitter(&mockJobSubmitter{err: errors.New("orchestrator unavailable")}))
+	_, err := failing.SubmitJob(ctx, GlobalJobRequest{Job: job})
+	require.Error(t, err)
+	assert.Empty(t, a.ActiveLeases(ctx))
+
+	// A successful dispatch confirms it
+	endpoint := NewEndpoint(NewScheduler(selector, a), a,
+		WithJobSubmitter(&mockJobSubmitter{response: &orchestrator.SubmitJobResponse{JobID: "job-1"}}))
+	resp, err := endpoint.SubmitJob(ctx, GlobalJobRequest{Job: job})
+	require.NoError(t, err)
+	require.Len(t, resp.AllocatedNodes, 1)
+	assert.NotEmpty(t, resp.AllocatedNodes[0].LeaseID)
+	active := a.ActiveLeases(ctx)
+	require.Len(t, active, 1)
+	assert.False(t, active[0].ConfirmedAt.IsZero())
+}
+
+func TestScheduler_LeasesSurviveFailover(t *testing.T) {
+	ctx := context.Background()
+	transport := NewInMemoryTransport()
+	leader := NewReplicator(NewReplicatedState(), transport)
+	leader.Promote(ctx, 1)
+	standby := startStandby(t, transport)
+
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("node-2", "us-east"), Rank: 10},
+	}}
+	job := createTestJob("job-1", models.JobTypeBatch, 1)
+	job.Tasks[0].ResourcesConfig = &models.ResourcesConfig{CPU: "8", Memory: "32GiB"}
+	req := GlobalSchedulingRequest{Job: job, TargetCount: 1, LeaseCapacity: true}
+
+	first, err := NewScheduler(selector, newLeaseTestAggregator(WithLeaseStore(leader))).SelectNodes(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, "node-1", first[0].NodeID)
+	waitForIndex(t, standby, 1)
+
+	// The standby takes over before the lease is confirmed
+	standby.Promote(ctx, 2)
+	a := newLeaseTestAggregator(WithLeaseStore(standby))
+	scheduler := NewScheduler(selector, a)
+
+	second, err := scheduler.SelectNodes(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, "node-2", second[0].NodeID, "node-1 is leased by the old leader")
+
+	third, err := scheduler.SelectNodes(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, third)
+
+	// Releasing the old leader's lease frees its node on the new leader
+	require.NoError(t, a.ReleaseLease(ctx, first[0].LeaseID))
+	assert.Len(t, standby.Leases(), 1)
+	fourth, err := scheduler.SelectNodes(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, fourth, 1)
+	assert.Equal(t, "node-1", fourth[0].NodeID)
+}
+
+var (
+	leaseMetricsOnce   sync.Once
+	leaseMetricsReader *sdkmetric.ManualReader
+)
+
+// readLeaseMetrics routes the package's metrics to a manual reader.
+func readLeaseMetrics() {
+	leaseMetricsOnce.Do(func() {
+		leaseMetricsReader = sdkmetric.NewManualReader()
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(leaseMetricsReader)))
+	})
+}
+
+// activeLeasesGauge returns the last value recorded on the active leases
+// gauge since readLeaseMetrics.
+func activeLeasesGauge(t *testing.T) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, leaseMetricsReader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok && m.Name == "globalvm.lease.active" {
+				require.Len(t, gauge.DataPoints, 1)
+				return gauge.DataPoints[0].Value
+			}
+		}
+	}
+	t.Fatal("globalvm.lease.active was not recorded")
+	return 0
+}
+
+func TestCapacityAggregator_ActiveLeasesGaugeAfterFailover(t *testing.T) {
+	ctx := context.Background()
+	readLeaseMetrics()
+
+	// A lease granted by the old leader is already in the store when the
+	// new leader's aggregator starts
+	store := NewReplicator(NewReplicatedState(), NewInMemoryTransport())
+	store.Promote(ctx, 1)
+	require.NoError(t, store.PutLease(ctx, SchedulingLease{
+		ID:        "lease-old",
+		JobID:     "job-1",
+		NodeID:    "node-1",
+		Resources: models.Resources{CPU: 4},
+		ExpiresAt: time.Now().Add(time.Minute),
+	}))
+	a := newLeaseTestAggregator(WithLeaseStore(store))
+
+	assert.Len(t, a.ActiveLeases(ctx), 1)
+	assert.Equal(t, int64(1), activeLeasesGauge(t))
+
+	_, err := a.AcquireLease(ctx, "job-2", "node-2", models.Resources{CPU: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), activeLeasesGauge(t))
+
+	require.NoError(t, a.ReleaseLease(ctx, "lease-old"))
+	assert.Equal(t, int64(1), activeLeasesGauge(t))
+}
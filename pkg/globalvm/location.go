@@ -609,33 +609,11 @@ func ExtractIP(addr string) string {
 	return host
 }
 
-// RegionToContinent maps regions to continents for broader grouping.
+// RegionToContinent maps regions to continents for broader grouping,
+// using the region hierarchy (see SetRegionHierarchy).
 func RegionToContinent(region string) string {
-	regionMap := map[string]string{
-		"us-east":       "north-america",
-		"us-west":       "north-america",
-		"us-central":    "north-america",
-		"eu-west":       "europe",
-		"eu-central":    "europe",
-		"eu-north":      "europe",
-		"asia-east":     "asia",
-		"asia-south":    "asia",
-		"asia-southeast": "asia",
-		"south-america": "south-america",
-		"africa":        "africa",
-		"australia":     "oceania",
-	}
-
-	if continent, ok := regionMap[region]; ok {
-		return continent
-	}
-
-	// Try to match prefix
-	for prefix, continent := range regionMap {
-		if strings.HasPrefix(region, prefix) {
-			return continent
-		}
+	if path, ok := regions().Resolve(region); ok {
+		return path.Continent
 	}
-
 	return "unknown"
 }
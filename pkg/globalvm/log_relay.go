@@ -0,0 +1,330 @@
+//go:build unit
+
+// Package globalvm provides global scheduling capabilities for the distributed compute network.
+// This file implements relaying execution output from compute nodes to readers.
+package globalvm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLogFinished is returned when publishing to an execution log that has been finished.
+	ErrLogFinished = errors.New("execution log is finished")
+
+	// ErrLogRelayNotConfigured is returned by the endpoint when it has no log relay.
+	ErrLogRelayNotConfigured = errors.New("execution log streaming is not configured")
+)
+
+// DefaultLogRelayBuffer is the number of lines an execution log buffers
+// when NewInMemoryLogRelay is given zero.
+const DefaultLogRelayBuffer = 1000
+
+// LogStream names the output a log line came from.
+type LogStream string
+
+const (
+	LogStreamStdout LogStream = "stdout"
+	LogStreamStderr LogStream = "stderr"
+)
+
+// ExecutionLogLine is one line of an execution's output.
+type ExecutionLogLine struct {
+	ExecutionID string    `json:"ExecutionID"`
+	Stream      LogStream `json:"Stream"`
+	Line        string    `json:"Line"`
+	Timestamp   time.Time `json:"Timestamp"`
+}
+
+// LogRelay carries execution output from compute nodes through the
+// orchestrator to the readers following it. Implementations may be backed
+// by NATS, a stream store, or memory.
+type LogRelay interface {
+	// Publish appends a line to its execution's log. It blocks while a
+	// reader is a full buffer behind, so slow readers throttle the node
+	// rather than lose lines, and returns ctx.Err() if ctx is done first.
+	Publish(ctx context.Context, line ExecutionLogLine) error
+
+	// Finish marks an execution's log complete. Readers receive the lines
+	// still buffered and then see their channel closed.
+	Finish(executionID string) error
+
+	// Subscribe streams an execution's log from the oldest buffered line
+	// until the log is finished or ctx is done. Subscribing before the
+	// execution publishes anything is allowed.
+	Subscribe(ctx context.Context, executionID string) (<-chan ExecutionLogLine, error)
+}
+
+// executionLog is the buffered output of one execution.
+type executionLog struct {
+	lines    []ExecutionLogLine
+	first    uint64 // sequence number of lines[0]
+	finished bool
+	readers  map[*uint64]struct{} // cursors: sequence number of each reader's next line
+	changed  chan struct{}        // closed and replaced on every change
+}
+
+// next returns the sequence number the next published line gets.
+func (l *executionLog) next() uint64 {
+	return l.first + uint64(len(l.lines))
+}
+
+// notify wakes everyone waiting for the log to change.
+func (l *executionLog) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// InMemoryLogRelay is a LogRelay for tests and single-process deployments.
+// Each execution keeps its last buffer lines for late readers; once a
+// reader is that far behind, publishers wait for it. Finished logs are
+// kept until Remove is called.
+type InMemoryLogRelay struct {
+	buffer int
+
+	mu   sync.Mutex
+	logs map[string]*executionLog
+}
+
+// NewInMemoryLogRelay creates an in-memory relay buffering the given
+// number of lines per execution.
+func NewInMemoryLogRelay(buffer int) *InMemoryLogRelay {
+	if buffer <= 0 {
+		buffer = DefaultLogRelayBuffer
+	}
+	return &InMemoryLogRelay{buffer: buffer, logs: make(map[string]*executionLog)}
+}
+
+// log returns the execution's log, creating it if needed. Callers must
+// hold r.mu.
+func (r *InMemoryLogRelay) log(executionID string) *executionLog {
+	l, ok := r.logs[executionID]
+	if !ok {
+		l = &executionLog{readers: make(map[*uint64]struct{}), changed: make(chan struct{})}
+		r.logs[executionID] = l
+	}
+	return l
+}
+
+// Publish appends a line, waiting while a reader is a full buffer behind.
+func (r *InMemoryLogRelay) Publish(ctx context.Context, line ExecutionLogLine) error {
+	if line.ExecutionID == "" {
+		return fmt.Errorf("log line has no execution ID")
+	}
+	if line.Timestamp.IsZero() {
+		line.Timestamp = time.Now()
+	}
+
+	r.mu.Lock()
+	for {
+		l := r.log(line.ExecutionID)
+		if l.finished {
+			r.mu.Unlock()
+			return fmt.Errorf("execution %s: %w", line.ExecutionID, ErrLogFinished)
+		}
+		if !r.readerBehind(l) {
+			l.lines = append(l.lines, line)
+			if len(l.lines) > r.buffer {
+				// Nobody still needs the oldest line
+				l.lines = l.lines[1:]
+				l.first++
+			}
+			l.notify()
+			r.mu.Unlock()
+			return nil
+		}
+
+		changed := l.changed
+		r.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		r.mu.Lock()
+	}
+}
+
+// readerBehind reports whether a reader has a full buffer of lines still
+// to read, so publishing another would drop one it has not seen.
+func (r *InMemoryLogRelay) readerBehind(l *executionLog) bool {
+	if len(l.lines) < r.buffer {
+		return false
+	}
+	for cursor := range l.readers {
+		if *cursor <= l.first {
+			return true
+		}
+	}
+	return false
+}
+
+// Finish marks the execution's log complete.
+func (r *InMemoryLogRelay) Finish(executionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l := r.log(executionID)
+	if !l.finished {
+		l.finished = true
+		l.notify()
+	}
+	return nil
+}
+
+// Remove drops a finished execution's log. Readers still following it
+// are ended.
+func (r *InMemoryLogRelay) Remove(executionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.logs[executionID]; ok {
+		l.lines = nil
+		l.finished = true
+		l.notify()
+		delete(r.logs, executionID)
+	}
+}
+
+// Subscribe streams the execution's log from the oldest buffered line.
+func (r *InMemoryLogRelay) Subscribe(ctx context.Context, executionID string) (<-chan ExecutionLogLine, error) {
+	r.mu.Lock()
+	l := r.log(executionID)
+	cursor := new(uint64)
+	*cursor = l.first
+	l.readers[cursor] = struct{}{}
+	r.mu.Unlock()
+
+	ch := make(chan ExecutionLogLine)
+	go func() {
+		defer close(ch)
+		defer func() {
+			r.mu.Lock()
+			delete(l.readers, cursor)
+			l.notify()
+			r.mu.Unlock()
+		}()
+
+		for {
+			r.mu.Lock()
+			if *cursor < l.first {
+				*cursor = l.first
+			}
+			if *cursor < l.next() {
+				line := l.lines[*cursor-l.first]
+				r.mu.Unlock()
+				select {
+				case ch <- line:
+				case <-ctx.Done():
+					return
+				}
+				r.mu.Lock()
+				*cursor++
+				l.notify()
+				r.mu.Unlock()
+				continue
+			}
+			if l.finished {
+				r.mu.Unlock()
+				return
+			}
+			changed := l.changed
+			r.mu.Unlock()
+
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Ensure the in-memory relay implements the interface
+var _ LogRelay = (*InMemoryLogRelay)(nil)
+
+// ExecutionLogWriter turns an execution's output stream into log lines
+// published to a relay, so a node can hand it to its executor as stdout
+// or stderr. Writes block while the relay applies backpressure.
+type ExecutionLogWriter struct {
+	ctx         context.Context
+	relay       LogRelay
+	executionID string
+	stream      LogStream
+	partial     []byte
+}
+
+// NewExecutionLogWriter creates a writer publishing one stream of an
+// execution's output.
+func NewExecutionLogWriter(ctx context.Context, relay LogRelay, executionID string, stream LogStream) *ExecutionLogWriter {
+	return &ExecutionLogWriter{ctx: ctx, relay: relay, executionID: executionID, stream: stream}
+}
+
+// Write publishes every complete line in p and keeps the rest for the
+// next write.
+func (w *ExecutionLogWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(bytes.TrimSuffix(w.partial[:i], []byte("\r")))
+		w.partial = w.partial[i+1:]
+		if err := w.publish(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Close publishes a trailing line without a newline. It does not finish
+// the execution's log, since the other stream may still be writing.
+func (w *ExecutionLogWriter) Close() error {
+	if len(w.partial) == 0 {
+		return nil
+	}
+	line := string(w.partial)
+	w.partial = nil
+	return w.publish(line)
+}
+
+// publish sends one line to the relay.
+func (w *ExecutionLogWriter) publish(line string) error {
+	return w.relay.Publish(w.ctx, ExecutionLogLine{
+		ExecutionID: w.executionID,
+		Stream:      w.stream,
+		Line:        line,
+		Timestamp:   time.Now(),
+	})
+}
+
+// Ensure the writer can stand in for an executor's output
+var _ io.WriteCloser = (*ExecutionLogWriter)(nil)
+
+// WithLogRelay sets the relay execution logs are streamed from.
+func WithLogRelay(relay LogRelay) EndpointOption {
+	return func(e *Endpoint) {
+		e.logRelay = relay
+	}
+}
+
+// StreamExecutionLogs follows an execution's stdout and stderr as the
+// node relays them, from the oldest buffered line until the execution's
+// log is finished or ctx is done. The returned channel is closed at the
+// end; not reading from it holds the node's output back.
+func (e *Endpoint) StreamExecutionLogs(ctx context.Context, executionID string) (<-chan ExecutionLogLine, error) {
+	if e.logRelay == nil {
+		return nil, ErrLogRelayNotConfigured
+	}
+	if executionID == "" {
+		return nil, fmt.Errorf("execution ID is required")
+	}
+	return e.logRelay.Subscribe(ctx, executionID)
+}
@@ -0,0 +1,109 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectLines reads a log channel until it is closed.
+func collectLines(t *testing.T, ch <-chan ExecutionLogLine) []string {
+	t.Helper()
+	var lines []string
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				return lines
+			}
+			lines = append(lines, string(line.Stream)+": "+line.Line)
+		case <-timeout:
+			t.Fatalf("log not finished, got %v", lines)
+		}
+	}
+}
+
+func TestEndpoint_StreamExecutionLogs(t *testing.T) {
+	ctx := context.Background()
+	relay := NewInMemoryLogRelay(0)
+	endpoint := NewEndpoint(nil, nil, WithLogRelay(relay))
+
+	lines, err := endpoint.StreamExecutionLogs(ctx, "exec-1")
+	require.NoError(t, err)
+
+	stdout := NewExecutionLogWriter(ctx, relay, "exec-1", LogStreamStdout)
+	stderr := NewExecutionLogWriter(ctx, relay, "exec-1", LogStreamStderr)
+	_, err = io.WriteString(stdout, "starting\r\nstep 1")
+	require.NoError(t, err)
+	_, err = io.WriteString(stderr, "warning\n")
+	require.NoError(t, err)
+	_, err = io.WriteString(stdout, " done\nexit")
+	require.NoError(t, err)
+	require.NoError(t, stdout.Close())
+	require.NoError(t, relay.Finish("exec-1"))
+
+	assert.Equal(t, []string{"stdout: starting", "stderr: warning", "stdout: step 1 done", "stdout: exit"}, collectLines(t, lines))
+
+	// A late reader gets the buffered log, and nothing more can be published
+	late, err := endpoint.StreamExecutionLogs(ctx, "exec-1")
+	require.NoError(t, err)
+	assert.Len(t, collectLines(t, late), 4)
+	assert.ErrorIs(t, relay.Publish(ctx, ExecutionLogLine{ExecutionID: "exec-1", Line: "late"}), ErrLogFinished)
+}
+
+func TestEndpoint_StreamExecutionLogs_NotConfigured(t *testing.T) {
+	_, err := NewEndpoint(nil, nil).StreamExecutionLogs(context.Background(), "exec-1")
+	assert.ErrorIs(t, err, ErrLogRelayNotConfigured)
+}
+
+func TestInMemoryLogRelay_Backpressure(t *testing.T) {
+	ctx := context.Background()
+	relay := NewInMemoryLogRelay(2)
+
+	readerCtx, stopReading := context.WithCancel(ctx)
+	lines, err := relay.Subscribe(readerCtx, "exec-1")
+	require.NoError(t, err)
+
+	// The reader reads nothing, so the third line waits for it
+	publish := func(i int) error {
+		return relay.Publish(ctx, ExecutionLogLine{ExecutionID: "exec-1", Stream: LogStreamStdout, Line: fmt.Sprint(i)})
+	}
+	require.NoError(t, publish(1))
+	require.NoError(t, publish(2))
+	blocked, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, relay.Publish(blocked, ExecutionLogLine{ExecutionID: "exec-1", Line: "3"}), context.DeadlineExceeded)
+
+	// Reading makes room
+	first := <-lines
+	assert.Equal(t, "1", first.Line)
+	done := make(chan error, 1)
+	go func() { done <- publish(3) }()
+	assert.Equal(t, "2", (<-lines).Line)
+	assert.Equal(t, "3", (<-lines).Line)
+	require.NoError(t, <-done)
+
+	// A reader that leaves no longer holds the node back, and the buffer
+	// keeps only the latest lines
+	stopReading()
+	for range lines {
+	}
+	for i := 4; i <= 10; i++ {
+		require.NoError(t, publish(i))
+	}
+	require.NoError(t, relay.Finish("exec-1"))
+	late, err := relay.Subscribe(ctx, "exec-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stdout: 9", "stdout: 10"}, collectLines(t, late))
+
+	relay.Remove("exec-1")
+	assert.NoError(t, publish(1), "a removed log starts over")
+}
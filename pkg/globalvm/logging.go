@@ -0,0 +1,122 @@
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Log field names shared by all Global VM components.
+const (
+	LogFieldComponent    = "component"
+	LogFieldSchedulingID = "schedulingID"
+)
+
+// Component identifies the Global VM component that emitted a log line.
+type Component string
+
+const (
+	ComponentEndpoint    Component = "endpoint"
+	ComponentScheduler   Component = "scheduler"
+	ComponentRanker      Component = "ranker"
+	ComponentAggregator  Component = "aggregator"
+	ComponentLease       Component = "lease"
+	ComponentLatency     Component = "latency"
+	ComponentReplication Component = "replication"
+	ComponentGC          Component = "gc"
+	ComponentMarket      Component = "market"
+	ComponentDefrag      Component = "defrag"
+	ComponentPricing     Component = "pricing"
+	ComponentUsage       Component = "usage"
+	ComponentAdmission   Component = "admission"
+	ComponentDiagnostics Component = "diagnostics"
+)
+
+var (
+	componentLevelsMu sync.RWMutex
+	componentLevels   = make(map[Component]zerolog.Level)
+)
+
+// SetComponentLogLevel sets the minimum level logged by a component. The
+// global zerolog level still applies, so a component cannot log below it.
+func SetComponentLogLevel(component Component, level zerolog.Level) {
+	componentLevelsMu.Lock()
+	defer componentLevelsMu.Unlock()
+	componentLevels[component] = level
+}
+
+// ResetComponentLogLevels removes all per-component levels.
+func ResetComponentLogLevels() {
+	componentLevelsMu.Lock()
+	defer componentLevelsMu.Unlock()
+	componentLevels = make(map[Component]zerolog.Level)
+}
+
+// ConfigureComponentLogLevels applies a comma-separated list of
+// component=level pairs, e.g. "scheduler=debug,lease=warn".
+func ConfigureComponentLogLevels(spec string) error {
+	levels := make(map[Component]zerolog.Level)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid component log level %q, expected component=level", pair)
+		}
+		level, err := zerolog.ParseLevel(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid log level for component %s: %w", name, err)
+		}
+		levels[Component(strings.TrimSpace(name))] = level
+	}
+	for component, level := range levels {
+		SetComponentLogLevel(component, level)
+	}
+	return nil
+}
+
+// componentLogger returns the context logger tagged with the component,
+// at the component's configured level. The context logger carries the
+// scheduling ID when the call is part of a scheduling decision.
+func componentLogger(ctx context.Context, component Component) *zerolog.Logger {
+	l := log.Ctx(ctx).With().Str(LogFieldComponent, string(component)).Logger()
+
+	componentLevelsMu.RLock()
+	level, ok := componentLevels[component]
+	componentLevelsMu.RUnlock()
+	if ok {
+		l = l.Level(level)
+	}
+	return &l
+}
+
+type schedulingIDKey struct{}
+
+// WithSchedulingID returns a context whose logger tags every line with a
+// scheduling correlation ID, so the endpoint, scheduler, ranker and
+// capacity aggregator logs of one decision can be followed together.
+// An empty id generates a new one; a context that already has an ID keeps it.
+func WithSchedulingID(ctx context.Context, id string) (context.Context, string) {
+	if existing := SchedulingID(ctx); existing != "" {
+		return ctx, existing
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	ctx = context.WithValue(ctx, schedulingIDKey{}, id)
+	l := log.Ctx(ctx).With().Str(LogFieldSchedulingID, id).Logger()
+	return l.WithContext(ctx), id
+}
+
+// SchedulingID returns the scheduling correlation ID of the context, if any.
+func SchedulingID(ctx context.Context) string {
+	id, _ := ctx.Value(schedulingIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,98 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs returns a context whose logger writes JSON lines to the buffer.
+func captureLogs() (context.Context, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Level(zerolog.TraceLevel)
+	return logger.WithContext(context.Background()), &buf
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestWithSchedulingID(t *testing.T) {
+	ctx, id := WithSchedulingID(context.Background(), "")
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id, SchedulingID(ctx))
+
+	// A nested call keeps the decision's ID
+	nested, nestedID := WithSchedulingID(ctx, "other")
+	assert.Equal(t, id, nestedID)
+	assert.Equal(t, id, SchedulingID(nested))
+
+	assert.Empty(t, SchedulingID(context.Background()))
+}
+
+func TestEndpoint_SubmitJobCorrelatesLogs(t *testing.T) {
+	t.Cleanup(ResetComponentLogLevels)
+	ctx, buf := captureLogs()
+
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity)
+
+	resp, err := endpoint.SubmitJob(ctx, GlobalJobRequest{Job: createTestJob("job-1", models.JobTypeBatch, 1)})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.SchedulingID)
+
+	placement, ok := endpoint.GetJobPlacement("job-1")
+	require.True(t, ok)
+	assert.Equal(t, resp.SchedulingID, placement.SchedulingID)
+
+	components := make(map[string]bool)
+	for _, line := range logLines(t, buf) {
+		assert.Equal(t, resp.SchedulingID, line[LogFieldSchedulingID], "line %v", line)
+		components[line[LogFieldComponent].(string)] = true
+	}
+	assert.True(t, components[string(ComponentEndpoint)])
+	assert.True(t, components[string(ComponentScheduler)])
+}
+
+func TestComponentLogLevels(t *testing.T) {
+	t.Cleanup(ResetComponentLogLevels)
+	ctx, buf := captureLogs()
+
+	require.NoError(t, ConfigureComponentLogLevels("scheduler=warn, endpoint=debug"))
+	componentLogger(ctx, ComponentScheduler).Debug().Msg("hidden")
+	componentLogger(ctx, ComponentScheduler).Warn().Msg("shown")
+	componentLogger(ctx, ComponentEndpoint).Debug().Msg("shown")
+	componentLogger(ctx, ComponentLease).Info().Msg("shown")
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.Equal(t, "shown", line["message"])
+	}
+
+	assert.Error(t, ConfigureComponentLogLevels("scheduler"))
+	assert.Error(t, ConfigureComponentLogLevels("scheduler=loud"))
+}
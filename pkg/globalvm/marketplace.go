@@ -0,0 +1,334 @@
+//go:build unit
+
+// Package globalvm provides global scheduling capabilities for the distributed compute network.
+// This file implements a marketplace where tenants sell unused reservations to each other.
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// ErrReservationNotListed is returned for bids and purchases on a reservation that is not for sale.
+	ErrReservationNotListed = errors.New("reservation is not listed for sale")
+
+	// ErrReservationInUse is returned when listing a reservation that is bound to a job or expired.
+	ErrReservationInUse = errors.New("reservation is bound to a job or expired")
+
+	// ErrBidNotFound is returned when accepting a bid that was never placed or was withdrawn.
+	ErrBidNotFound = errors.New("bid not found")
+)
+
+// ReservationListing offers a reservation for sale at an asking price in credits.
+type ReservationListing struct {
+	ReservationID string    `json:"ReservationID"`
+	SellerID      string    `json:"SellerID"`
+	AskPrice      float64   `json:"AskPrice"`
+	ListedAt      time.Time `json:"ListedAt"`
+}
+
+// ReservationBid is a standing offer from a tenant for a listed reservation.
+type ReservationBid struct {
+	ID            string    `json:"ID"`
+	ReservationID string    `json:"ReservationID"`
+	BidderID      string    `json:"BidderID"`
+	Price         float64   `json:"Price"`
+	PlacedAt      time.Time `json:"PlacedAt"`
+}
+
+// ReservationTransfer records a completed sale.
+type ReservationTransfer struct {
+	Reservation   Reservation `json:"Reservation"`
+	SellerID      string      `json:"SellerID"`
+	BuyerID       string      `json:"BuyerID"`
+	Price         float64     `json:"Price"`
+	TransferredAt time.Time   `json:"TransferredAt"`
+}
+
+// CreditSettlement moves credits between tenants for a reservation sale.
+type CreditSettlement interface {
+	// Settle charges the buyer and pays the seller. An error aborts the sale.
+	Settle(ctx context.Context, transfer ReservationTransfer) error
+
+	// Refund reverses a settled sale whose ownership change could not be recorded.
+	Refund(ctx context.Context, transfer ReservationTransfer) error
+}
+
+// ReservationMarket matches sellers and buyers of unused reservations.
+// Ownership changes are replicated writes, so sales only complete on the
+// leader. Listings and bids are kept in memory and are dropped on failover.
+type ReservationMarket struct {
+	replicator *Replicator
+	settlement CreditSettlement
+
+	mu       sync.Mutex
+	listings map[string]ReservationListing
+	bids     map[string][]ReservationBid
+}
+
+// ReservationMarketOption configures the reservation market.
+type ReservationMarketOption func(*ReservationMarket)
+
+// WithCreditSettlement sets the hook that settles sales in the credit system.
+// Without one, reservations change hands without moving credits.
+func WithCreditSettlement(settlement CreditSettlement) ReservationMarketOption {
+	return func(m *ReservationMarket) {
+		m.settlement = settlement
+	}
+}
+
+// NewReservationMarket creates a market over the replicator's reservations.
+func NewReservationMarket(replicator *Replicator, opts ...ReservationMarketOption) *ReservationMarket {
+	m := &ReservationMarket{
+		replicator: replicator,
+		listings:   make(map[string]ReservationListing),
+		bids:       make(map[string][]ReservationBid),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// List offers a reservation held by sellerID for sale. Listing it again
+// updates the asking price. Reservations bound to a job cannot be sold.
+func (m *ReservationMarket) List(reservationID, sellerID string, askPrice float64) (ReservationListing, error) {
+	if askPrice < 0 {
+		return ReservationListing{}, fmt.Errorf("asking price must not be negative, got %.2f", askPrice)
+	}
+	if _, err := m.sellable(reservationID, sellerID); err != nil {
+		return ReservationListing{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listing := ReservationListing{
+		ReservationID: reservationID,
+		SellerID:      sellerID,
+		AskPrice:      askPrice,
+		ListedAt:      time.Now(),
+	}
+	m.listings[reservationID] = listing
+	return listing, nil
+}
+
+// Withdraw takes a reservation off the market and discards its bids.
+func (m *ReservationMarket) Withdraw(reservationID, sellerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listing, ok := m.listings[reservationID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrReservationNotListed, reservationID)
+	}
+	if listing.SellerID != sellerID {
+		return fmt.Errorf("%w: %s", ErrNotReservationOwner, reservationID)
+	}
+	m.unlist(reservationID)
+	return nil
+}
+
+// Bid places an offer on a listed reservation. The seller decides whether
+// to accept it; bids at or above the asking price are not filled automatically.
+func (m *ReservationMarket) Bid(reservationID, bidderID string, price float64) (ReservationBid, error) {
+	if price <= 0 {
+		return ReservationBid{}, fmt.Errorf("bid price must be positive, got %.2f", price)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listing, ok := m.listings[reservationID]
+	if !ok {
+		return ReservationBid{}, fmt.Errorf("%w: %s", ErrReservationNotListed, reservationID)
+	}
+	if listing.SellerID == bidderID {
+		return ReservationBid{}, fmt.Errorf("tenant %s cannot bid on its own reservation", bidderID)
+	}
+
+	bid := ReservationBid{
+		ID:            uuid.NewString(),
+		ReservationID: reservationID,
+		BidderID:      bidderID,
+		Price:         price,
+		PlacedAt:      time.Now(),
+	}
+	m.bids[reservationID] = append(m.bids[reservationID], bid)
+	return bid, nil
+}
+
+// Buy purchases a listed reservation at its asking price.
+func (m *ReservationMarket) Buy(ctx context.Context, reservationID, buyerID string) (ReservationTransfer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listing, ok := m.listings[reservationID]
+	if !ok {
+		return ReservationTransfer{}, fmt.Errorf("%w: %s", ErrReservationNotListed, reservationID)
+	}
+	if listing.SellerID == buyerID {
+		return ReservationTransfer{}, fmt.Errorf("tenant %s cannot buy its own reservation", buyerID)
+	}
+	return m.transfer(ctx, listing, buyerID, listing.AskPrice, AttrSaleAsk)
+}
+
+// AcceptBid sells a listed reservation to the bidder at the bid price.
+func (m *ReservationMarket) AcceptBid(ctx context.Context, reservationID, sellerID, bidID string) (ReservationTransfer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listing, ok := m.listings[reservationID]
+	if !ok {
+		return ReservationTransfer{}, fmt.Errorf("%w: %s", ErrReservationNotListed, reservationID)
+	}
+	if listing.SellerID != sellerID {
+		return ReservationTransfer{}, fmt.Errorf("%w: %s", ErrNotReservationOwner, reservationID)
+	}
+	for _, bid := range m.bids[reservationID] {
+		if bid.ID == bidID {
+			return m.transfer(ctx, listing, bid.BidderID, bid.Price, AttrSaleBid)
+		}
+	}
+	return ReservationTransfer{}, fmt.Errorf("%w: %s", ErrBidNotFound, bidID)
+}
+
+// Listings returns the reservations for sale, oldest listing first.
+func (m *ReservationMarket) Listings() []ReservationListing {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listings := make([]ReservationListing, 0, len(m.listings))
+	for _, listing := range m.listings {
+		listings = append(listings, listing)
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		if !listings[i].ListedAt.Equal(listings[j].ListedAt) {
+			return listings[i].ListedAt.Before(listings[j].ListedAt)
+		}
+		return listings[i].ReservationID < listings[j].ReservationID
+	})
+	return listings
+}
+
+// Bids returns the bids on a reservation, highest price first.
+func (m *ReservationMarket) Bids(reservationID string) []ReservationBid {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bids := append([]ReservationBid(nil), m.bids[reservationID]...)
+	sort.SliceStable(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+	return bids
+}
+
+// sellable returns the reservation if sellerID holds it and it is free to sell.
+func (m *ReservationMarket) sellable(reservationID, sellerID string) (Reservation, error) {
+	res, ok := m.replicator.State().reservation(reservationID)
+	if !ok {
+		return Reservation{}, fmt.Errorf("%w: %s", ErrReservationNotFound, reservationID)
+	}
+	if res.TenantID != sellerID {
+		return Reservation{}, fmt.Errorf("%w: %s", ErrNotReservationOwner, reservationID)
+	}
+	if res.JobID != "" || (!res.ExpiresAt.IsZero() && !res.ExpiresAt.After(time.Now())) {
+		return Reservation{}, fmt.Errorf("%w: %s", ErrReservationInUse, reservationID)
+	}
+	return res, nil
+}
+
+// transfer settles the sale and records the new owner. The listing is
+// removed once the sale completes. Must be called with the lock held.
+func (m *ReservationMarket) transfer(
+	ctx context.Context, listing ReservationListing, buyerID string, price float64, sale string,
+) (ReservationTransfer, error) {
+	res, err := m.sellable(listing.ReservationID, listing.SellerID)
+	if err != nil {
+		// The reservation was bound, released or sold elsewhere since listing
+		m.unlist(listing.ReservationID)
+		return ReservationTransfer{}, err
+	}
+	if !m.replicator.IsLeader() {
+		return ReservationTransfer{}, ErrNotLeader
+	}
+
+	transfer := ReservationTransfer{
+		Reservation:   res,
+		SellerID:      listing.SellerID,
+		BuyerID:       buyerID,
+		Price:         price,
+		TransferredAt: time.Now(),
+	}
+	if m.settlement != nil {
+		if err := m.settlement.Settle(ctx, transfer); err != nil {
+			return ReservationTransfer{}, fmt.Errorf("failed to settle reservation %s: %w", res.ID, err)
+		}
+	}
+
+	transferred, err := m.replicator.TransferReservation(ctx, res.ID, listing.SellerID, buyerID)
+	if err != nil {
+		if m.settlement != nil {
+			if refundErr := m.settlement.Refund(ctx, transfer); refundErr != nil {
+				componentLogger(ctx, ComponentMarket).Error().
+					Err(refundErr).
+					Str("reservationID", res.ID).
+					Str("buyerID", buyerID).
+					Msg("Failed to refund reservation sale")
+			}
+		}
+		return ReservationTransfer{}, fmt.Errorf("failed to transfer reservation %s: %w", res.ID, err)
+	}
+
+	transfer.Reservation = transferred
+	m.unlist(res.ID)
+	reservationTransfers.Add(ctx, 1, metric.WithAttributes(AttrSaleKey.String(sale)))
+	componentLogger(ctx, ComponentMarket).Info().
+		Str("reservationID", res.ID).
+		Str("sellerID", listing.SellerID).
+		Str("buyerID", buyerID).
+		Float64("price", price).
+		Msg("Reservation transferred")
+	return transfer, nil
+}
+
+// unlist removes a listing and its bids. Must be called with the lock held.
+func (m *ReservationMarket) unlist(reservationID string) {
+	delete(m.listings, reservationID)
+	delete(m.bids, reservationID)
+}
+
+// WithReservations makes the scheduler prefer nodes where the requesting
+// tenant holds reservations, including ones bought from other tenants.
+func WithReservations(state *ReplicatedState) SchedulerOption {
+	return func(s *Scheduler) {
+		s.reservations = state
+	}
+}
+
+// applyReservations boosts nodes where the tenant holds an unexpired
+// reservation that is free or already bound to this job.
+func (s *Scheduler) applyReservations(req GlobalSchedulingRequest, selections []NodeSelection) []NodeSelection {
+	reserved := make(map[string]Reservation)
+	for _, res := range s.reservations.tenantReservations(req.TenantID, time.Now()) {
+		if res.JobID != "" && res.JobID != req.Job.ID {
+			continue
+		}
+		if _, seen := reserved[res.NodeID]; !seen {
+			reserved[res.NodeID] = res
+		}
+	}
+
+	for i := range selections {
+		res, ok := reserved[selections[i].NodeID]
+		if !ok {
+			continue
+		}
+		selections[i].addScore(ReasonReservedCapacity, 150) // Reserved capacity outranks region preference
+		selections[i].Reason = "reserved capacity: " + res.ID
+		if res.TransferredFrom != "" {
+			selections[i].Reason += " (transferred from " + res.TransferredFrom + ")"
+		}
+	}
+
+	return selections
+}
@@ -0,0 +1,208 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSettlement records settled and refunded sales.
+type recordingSettlement struct {
+	err      error
+	onSettle func()
+	settled  []ReservationTransfer
+	refunded []ReservationTransfer
+}
+
+func (s *recordingSettlement) Settle(_ context.Context, transfer ReservationTransfer) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.settled = append(s.settled, transfer)
+	if s.onSettle != nil {
+		s.onSettle()
+	}
+	return nil
+}
+
+func (s *recordingSettlement) Refund(_ context.Context, transfer ReservationTransfer) error {
+	s.refunded = append(s.refunded, transfer)
+	return nil
+}
+
+// newTestMarket creates a market on a leader holding res-1 for tenant-a.
+func newTestMarket(t *testing.T, opts ...ReservationMarketOption) (*ReservationMarket, *Replicator) {
+	t.Helper()
+	ctx := context.Background()
+	leader := NewReplicator(NewReplicatedState(), NewInMemoryTransport())
+	leader.Promote(ctx, 1)
+	require.NoError(t, leader.PutReservation(ctx, Reservation{
+		ID:        "res-1",
+		TenantID:  "tenant-a",
+		NodeID:    "node-2",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	return NewReservationMarket(leader, opts...), leader
+}
+
+func TestReservationMarket_Buy(t *testing.T) {
+	ctx := context.Background()
+	settlement := &recordingSettlement{}
+	market, leader := newTestMarket(t, WithCreditSettlement(settlement))
+
+	_, err := market.List("res-1", "tenant-b", 10)
+	assert.ErrorIs(t, err, ErrNotReservationOwner)
+
+	listing, err := market.List("res-1", "tenant-a", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []ReservationListing{listing}, market.Listings())
+
+	_, err = market.Buy(ctx, "res-1", "tenant-a")
+	assert.Error(t, err, "sellers cannot buy their own reservation")
+
+	transfer, err := market.Buy(ctx, "res-1", "tenant-b")
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, transfer.Price)
+	assert.Equal(t, "tenant-b", transfer.Reservation.TenantID)
+	assert.Equal(t, "tenant-a", transfer.Reservation.TransferredFrom)
+	require.Len(t, settlement.settled, 1)
+	assert.Equal(t, "tenant-b", settlement.settled[0].BuyerID)
+
+	res, ok := leader.State().reservation("res-1")
+	require.True(t, ok)
+	assert.Equal(t, "tenant-b", res.TenantID)
+	assert.Empty(t, market.Listings())
+
+	_, err = market.Buy(ctx, "res-1", "tenant-c")
+	assert.ErrorIs(t, err, ErrReservationNotListed)
+}
+
+func TestReservationMarket_AcceptBid(t *testing.T) {
+	ctx := context.Background()
+	market, leader := newTestMarket(t)
+
+	_, err := market.Bid("res-1", "tenant-b", 5)
+	assert.ErrorIs(t, err, ErrReservationNotListed)
+
+	_, err = market.List("res-1", "tenant-a", 10)
+	require.NoError(t, err)
+
+	low, err := market.Bid("res-1", "tenant-b", 5)
+	require.NoError(t, err)
+	high, err := market.Bid("res-1", "tenant-c", 8)
+	require.NoError(t, err)
+	_, err = market.Bid("res-1", "tenant-a", 8)
+	assert.Error(t, err, "sellers cannot bid on their own reservation")
+
+	bids := market.Bids("res-1")
+	require.Len(t, bids, 2)
+	assert.Equal(t, high.ID, bids[0].ID)
+
+	_, err = market.AcceptBid(ctx, "res-1", "tenant-b", low.ID)
+	assert.ErrorIs(t, err, ErrNotReservationOwner)
+	_, err = market.AcceptBid(ctx, "res-1", "tenant-a", "missing")
+	assert.ErrorIs(t, err, ErrBidNotFound)
+
+	transfer, err := market.AcceptBid(ctx, "res-1", "tenant-a", low.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, transfer.Price)
+	assert.Equal(t, "tenant-b", transfer.BuyerID)
+	assert.Empty(t, market.Bids("res-1"))
+
+	res, _ := leader.State().reservation("res-1")
+	assert.Equal(t, "tenant-b", res.TenantID)
+}
+
+func TestReservationMarket_SettlementFailureAbortsSale(t *testing.T) {
+	ctx := context.Background()
+	settlement := &recordingSettlement{err: errors.New("insufficient credits")}
+	market, leader := newTestMarket(t, WithCreditSettlement(settlement))
+
+	_, err := market.List("res-1", "tenant-a", 10)
+	require.NoError(t, err)
+
+	_, err = market.Buy(ctx, "res-1", "tenant-b")
+	assert.ErrorContains(t, err, "insufficient credits")
+
+	res, _ := leader.State().reservation("res-1")
+	assert.Equal(t, "tenant-a", res.TenantID)
+	assert.Len(t, market.Listings(), 1, "a failed sale keeps the listing")
+}
+
+func TestReservationMarket_RefundsWhenTransferFails(t *testing.T) {
+	ctx := context.Background()
+	settlement := &recordingSettlement{}
+	market, leader := newTestMarket(t, WithCreditSettlement(settlement))
+
+	_, err := market.List("res-1", "tenant-a", 10)
+	require.NoError(t, err)
+
+	// Losing leadership after settlement leaves the ownership unchanged
+	settlement.onSettle = func() { leader.Demote(ctx) }
+	_, err = market.Buy(ctx, "res-1", "tenant-b")
+	assert.ErrorIs(t, err, ErrNotLeader)
+	require.Len(t, settlement.refunded, 1)
+	assert.Equal(t, settlement.settled, settlement.refunded)
+
+	// A sale on a standby does not move credits at all
+	_, err = market.Buy(ctx, "res-1", "tenant-c")
+	assert.ErrorIs(t, err, ErrNotLeader)
+	assert.Len(t, settlement.settled, 1)
+}
+
+func TestReservationMarket_RejectsBoundReservations(t *testing.T) {
+	ctx := context.Background()
+	market, leader := newTestMarket(t)
+
+	require.NoError(t, leader.PutReservation(ctx, Reservation{ID: "res-2", TenantID: "tenant-a", NodeID: "node-1", JobID: "job-1"}))
+	_, err := market.List("res-2", "tenant-a", 10)
+	assert.ErrorIs(t, err, ErrReservationInUse)
+
+	_, err = market.List("missing", "tenant-a", 10)
+	assert.ErrorIs(t, err, ErrReservationNotFound)
+
+	// Reservations bound after listing are pulled from the market on sale
+	_, err = market.List("res-1", "tenant-a", 10)
+	require.NoError(t, err)
+	require.NoError(t, leader.DeleteReservation(ctx, "res-1"))
+	_, err = market.Buy(ctx, "res-1", "tenant-b")
+	assert.ErrorIs(t, err, ErrReservationNotFound)
+	assert.Empty(t, market.Listings())
+}
+
+func TestScheduler_PrefersTransferredReservations(t *testing.T) {
+	ctx := context.Background()
+	market, leader := newTestMarket(t)
+	_, err := market.List("res-1", "tenant-a", 10)
+	require.NoError(t, err)
+	_, err = market.Buy(ctx, "res-1", "tenant-b")
+	require.NoError(t, err)
+
+	nodeSelector := &mockNodeSelector{
+		nodes: []orchestrator.NodeRank{
+			{NodeInfo: createTestNodeInfo("node-1", "us-west"), Rank: 10},
+			{NodeInfo: createTestNodeInfo("node-2", "us-east"), Rank: 8},
+		},
+	}
+	scheduler := NewScheduler(nodeSelector, &mockCapacityProvider{}, WithReservations(leader.State()))
+	job := createTestJob("job-1", models.JobTypeBatch, 1)
+
+	selections, err := scheduler.SelectNodes(ctx, GlobalSchedulingRequest{Job: job, TargetCount: 1, TenantID: "tenant-b"})
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-2", selections[0].NodeID)
+	assert.Equal(t, "reserved capacity: res-1 (transferred from tenant-a)", selections[0].Reason)
+
+	// The seller no longer gets the boost
+	selections, err = scheduler.SelectNodes(ctx, GlobalSchedulingRequest{Job: job, TargetCount: 1, TenantID: "tenant-a"})
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", selections[0].NodeID)
+}
@@ -0,0 +1,111 @@
+package globalvm
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/bacalhau-project/bacalhau/pkg/telemetry"
+)
+
+var (
+	Meter = otel.GetMeterProvider().Meter("globalvm")
+
+	// Lease metrics
+	leasesGranted = telemetry.Must(Meter.Int64Counter(
+		"globalvm.lease.granted",
+		metric.WithDescription("Number of allocation leases granted to scheduling passes"),
+		metric.WithUnit("1"),
+	))
+
+	leaseContention = telemetry.Must(Meter.Int64Counter(
+		"globalvm.lease.contention",
+		metric.WithDescription("Number of lease requests rejected because the capacity was already leased"),
+		metric.WithUnit("1"),
+	))
+
+	leasesConfirmed = telemetry.Must(Meter.Int64Counter(
+		"globalvm.lease.confirmed",
+		metric.WithDescription("Number of leases confirmed on job dispatch"),
+		metric.WithUnit("1"),
+	))
+
+	leasesReleased = telemetry.Must(Meter.Int64Counter(
+		"globalvm.lease.released",
+		metric.WithDescription("Number of leases released without being confirmed"),
+		metric.WithUnit("1"),
+	))
+
+	leasesActive = telemetry.Must(Meter.Int64Gauge(
+		"globalvm.lease.active",
+		metric.WithDescription("Number of leases currently held"),
+		metric.WithUnit("1"),
+	))
+
+	// Scheduling metrics
+	schedulingTimeouts = telemetry.Must(Meter.Int64Counter(
+		"globalvm.scheduling.timeout",
+		metric.WithDescription("Number of scheduling passes that hit their deadline"),
+		metric.WithUnit("1"),
+	))
+
+	// Reservation marketplace metrics
+	reservationTransfers = telemetry.Must(Meter.Int64Counter(
+		"globalvm.reservation.transferred",
+		metric.WithDescription("Number of reservations sold between tenants"),
+		metric.WithUnit("1"),
+	))
+
+	// GPU defragmentation metrics
+	defragMigrations = telemetry.Must(Meter.Int64Counter(
+		"globalvm.defrag.migrations",
+		metric.WithDescription("Number of executions migrated to consolidate free GPUs"),
+		metric.WithUnit("1"),
+	))
+
+	// Energy-aware scheduling metrics
+	energyPeakPenalties = telemetry.Must(Meter.Int64Counter(
+		"globalvm.energy.peak_penalized",
+		metric.WithDescription("Number of candidate nodes ranked down because a job would not cover their peak energy cost"),
+		metric.WithUnit("1"),
+	))
+
+	// Pricing oracle metrics
+	pricingRefreshFailures = telemetry.Must(Meter.Int64Counter(
+		"globalvm.pricing.refresh_failed",
+		metric.WithDescription("Number of failed attempts to pull prices from the pricing oracle"),
+		metric.WithUnit("1"),
+	))
+
+	// Admission policy metrics
+	admissionDecisions = telemetry.Must(Meter.Int64Counter(
+		"globalvm.admission.decisions",
+		metric.WithDescription("Number of admission policy decisions, by decision"),
+		metric.WithUnit("1"),
+	))
+
+	// Garbage collection metrics
+	gcReclaimed = telemetry.Must(Meter.Int64Counter(
+		"globalvm.gc.reclaimed",
+		metric.WithDescription("Number of completed-job state entries removed by garbage collection"),
+		metric.WithUnit("1"),
+	))
+)
+
+// Common attribute keys
+const (
+	AttrReasonKey      = attribute.Key("reason")
+	AttrReasonReleased = "released"
+	AttrReasonExpired  = "expired"
+
+	AttrPolicyKey = attribute.Key("policy")
+
+	AttrDataClassKey = attribute.Key("data_class")
+
+	AttrDecisionKey   = attribute.Key("decision")
+	AttrDecisionError = "error"
+
+	AttrSaleKey = attribute.Key("sale")
+	AttrSaleAsk = "ask"
+	AttrSaleBid = "bid"
+)
@@ -0,0 +1,109 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultStageIntermediateSize is the intermediate size assumed per upstream
+// node when StageLineage.IntermediateSize is not set.
+const DefaultStageIntermediateSize = 1 << 30
+
+const (
+	// stageColocationBonus is added to nodes that ran an upstream stage,
+	// so its intermediates never leave the node.
+	stageColocationBonus = 200
+
+	// stageProximityBonus is added to the off-node candidate with the
+	// cheapest transfer from the upstream stage, and scaled down for the rest.
+	stageProximityBonus = 100
+)
+
+// StageLineage describes an upstream pipeline stage whose intermediates the
+// job consumes. A workflow engine passes it with each stage after the first
+// so the scheduler places the stage on or near the nodes holding its input.
+type StageLineage struct {
+	// Stage names the upstream stage.
+	Stage string `json:"Stage"`
+
+	// Nodes are the nodes holding the upstream stage's intermediates.
+	Nodes []NodeSelection `json:"Nodes"`
+
+	// IntermediateSize is the expected size in bytes of the intermediates
+	// on each upstream node. Zero uses DefaultStageIntermediateSize.
+	IntermediateSize uint64 `json:"IntermediateSize,omitempty"`
+}
+
+// Lineage describes the submitted job as the upstream stage of the next
+// pipeline stage. When the job has an aggregation node, only it is kept,
+// since it holds the combined results.
+func (r *GlobalJobResponse) Lineage(stage string, size uint64) StageLineage {
+	lineage := StageLineage{Stage: stage, IntermediateSize: size}
+	if aggregator, ok := Aggregator(r.AllocatedNodes); ok {
+		lineage.Nodes = []NodeSelection{{NodeID: aggregator.NodeID, Region: aggregator.Region}}
+		return lineage
+	}
+	for _, sel := range r.AllocatedNodes {
+		lineage.Nodes = append(lineage.Nodes, NodeSelection{NodeID: sel.NodeID, Region: sel.Region})
+	}
+	return lineage
+}
+
+// stageTransferCost is the total time to move every upstream stage's
+// intermediates to the candidate.
+func (s *Scheduler) stageTransferCost(tenantID string, candidate NodeSelection, lineage []StageLineage) time.Duration {
+	var total time.Duration
+	for _, stage := range lineage {
+		size := stage.IntermediateSize
+		if size == 0 {
+			size = DefaultStageIntermediateSize
+		}
+		total += s.aggregationCost(tenantID, candidate, stage.Nodes, size)
+	}
+	return total
+}
+
+// applyStageLocality boosts nodes close to the upstream stages' intermediates.
+// Nodes that ran an upstream stage get the full colocation bonus; the
+// others get a proximity bonus inversely proportional to their transfer cost.
+func (s *Scheduler) applyStageLocality(tenantID string, selections []NodeSelection, lineage []StageLineage) []NodeSelection {
+	upstream := make(map[string]string)
+	for _, stage := range lineage {
+		for _, node := range stage.Nodes {
+			upstream[node.NodeID] = stage.Stage
+		}
+	}
+
+	costs := make([]time.Duration, len(selections))
+	var cheapest time.Duration = -1
+	for i, sel := range selections {
+		if _, ok := upstream[sel.NodeID]; ok {
+			continue
+		}
+		costs[i] = s.stageTransferCost(tenantID, sel, lineage)
+		if cheapest < 0 || costs[i] < cheapest {
+			cheapest = costs[i]
+		}
+	}
+
+	for i := range selections {
+		if stage, ok := upstream[selections[i].NodeID]; ok {
+			selections[i].addScore(ReasonStageColocation, stageColocationBonus)
+			selections[i].Reason = "co-located with stage " + stage
+			continue
+		}
+		if costs[i] <= 0 {
+			// Nothing to move, e.g. lineage without nodes
+			continue
+		}
+		bonus := int(float64(stageProximityBonus) * float64(cheapest) / float64(costs[i]))
+		selections[i].addScore(ReasonStageProximity, bonus)
+		if bonus == stageProximityBonus {
+			selections[i].Reason = fmt.Sprintf("near upstream stages: %s intermediate transfer", costs[i])
+		}
+	}
+
+	return selections
+}
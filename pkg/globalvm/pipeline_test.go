@@ -0,0 +1,101 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_ApplyStageLocality(t *testing.T) {
+	scheduler := &Scheduler{}
+
+	selections := []NodeSelection{
+		{NodeID: "node-1", Region: "eu-west", Rank: 10},
+		{NodeID: "node-2", Region: "us-west", Rank: 10},
+		{NodeID: "node-3", Region: "us-east", Rank: 10},
+	}
+	lineage := []StageLineage{{
+		Stage: "extract",
+		Nodes: []NodeSelection{{NodeID: "node-3", Region: "us-east"}, {NodeID: "node-4", Region: "us-east"}},
+	}}
+
+	result := scheduler.applyStageLocality("", selections, lineage)
+
+	assert.Equal(t, 210, result[2].Rank) // ran the upstream stage
+	assert.Equal(t, "co-located with stage extract", result[2].Reason)
+	// us-west is the closest region to us-east, so it gets the full
+	// proximity bonus; eu-west has higher latency and slightly less
+	assert.Equal(t, 110, result[1].Rank)
+	assert.Greater(t, result[0].Rank, 100)
+	assert.Less(t, result[0].Rank, result[1].Rank)
+}
+
+func TestScheduler_ApplyStageLocality_ScalesWithTransferCost(t *testing.T) {
+	scheduler := &Scheduler{}
+
+	selections := []NodeSelection{
+		{NodeID: "node-1", Region: "us-east", Rank: 10},
+		{NodeID: "node-2", Region: "eu-west", Rank: 10},
+	}
+	lineage := []StageLineage{{Stage: "extract", Nodes: []NodeSelection{{NodeID: "node-3", Region: "us-east"}}}}
+
+	result := scheduler.applyStageLocality("", selections, lineage)
+
+	assert.Equal(t, 110, result[0].Rank)
+	assert.Contains(t, result[0].Reason, "near upstream stages")
+	assert.Less(t, result[1].Rank, 30, "cross-region transfer is an order of magnitude slower")
+	assert.Empty(t, result[1].Reason)
+}
+
+func TestScheduler_SelectNodes_PipelineStage(t *testing.T) {
+	nodeSelector := &mockNodeSelector{
+		nodes: []orchestrator.NodeRank{
+			{NodeInfo: createTestNodeInfo("node-1", "us-west"), Rank: 50},
+			{NodeInfo: createTestNodeInfo("node-2", "eu-west"), Rank: 10},
+		},
+	}
+	scheduler := NewScheduler(nodeSelector, &mockCapacityProvider{})
+
+	upstream := &GlobalJobResponse{
+		JobID: "extract-job",
+		AllocatedNodes: []NodeSelection{
+			{NodeID: "node-1", Region: "us-west", Role: NodeRoleWorker},
+			{NodeID: "node-2", Region: "eu-west", Role: NodeRoleAggregator},
+		},
+	}
+	lineage := upstream.Lineage("extract", 0)
+	require.Len(t, lineage.Nodes, 1, "only the aggregator holds the combined results")
+
+	req := GlobalSchedulingRequest{
+		Job:         createTestJob("transform-job", models.JobTypeBatch, 1),
+		TargetCount: 1,
+		Scheduling:  SchedulingOptions{Lineage: []StageLineage{lineage}},
+	}
+	selections, err := scheduler.SelectNodes(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-2", selections[0].NodeID)
+	assert.Equal(t, "co-located with stage extract", selections[0].Reason)
+}
+
+func TestGlobalJobResponse_Lineage(t *testing.T) {
+	resp := &GlobalJobResponse{
+		AllocatedNodes: []NodeSelection{
+			{NodeID: "node-1", Region: "us-east", Rank: 50, LeaseID: "lease-1"},
+			{NodeID: "node-2", Region: "eu-west", Rank: 40},
+		},
+	}
+
+	lineage := resp.Lineage("map", 1<<20)
+	assert.Equal(t, StageLineage{
+		Stage:            "map",
+		Nodes:            []NodeSelection{{NodeID: "node-1", Region: "us-east"}, {NodeID: "node-2", Region: "eu-west"}},
+		IntermediateSize: 1 << 20,
+	}, lineage)
+}
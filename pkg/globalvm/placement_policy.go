@@ -0,0 +1,102 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// PlacementPolicy tunes the built-in placement profiles. Read with
+// LoadPlacementPolicy, fields a policy leaves out keep their defaults.
+type PlacementPolicy struct {
+	Inference InferencePolicy `json:"Inference"`
+	Training  TrainingPolicy  `json:"Training"`
+}
+
+// InferencePolicy tunes the InferenceProfile; see it for the fields.
+type InferencePolicy struct {
+	LatencyBudget   string `json:"LatencyBudget"`
+	ProximityWeight int    `json:"ProximityWeight"`
+	SharedGPUWeight int    `json:"SharedGPUWeight"`
+	Standby         bool   `json:"Standby"`
+}
+
+// TrainingPolicy tunes the TrainingProfile; see it for the fields.
+type TrainingPolicy struct {
+	ColocationWeight   int     `json:"ColocationWeight"`
+	BandwidthWeight    int     `json:"BandwidthWeight"`
+	ReferenceBandwidth float64 `json:"ReferenceBandwidth"`
+	LatencyTolerance   string  `json:"LatencyTolerance"`
+	LatencyPenalty     int     `json:"LatencyPenalty"`
+	SpotWeight         int     `json:"SpotWeight"`
+}
+
+// DefaultPlacementPolicy returns the policy of the built-in profiles.
+func DefaultPlacementPolicy() PlacementPolicy {
+	inference := NewInferenceProfile()
+	training := NewTrainingProfile()
+	return PlacementPolicy{
+		Inference: InferencePolicy{
+			LatencyBudget:   inference.LatencyBudget.String(),
+			ProximityWeight: inference.ProximityWeight,
+			SharedGPUWeight: inference.SharedGPUWeight,
+			Standby:         inference.Standby,
+		},
+		Training: TrainingPolicy{
+			ColocationWeight:   training.ColocationWeight,
+			BandwidthWeight:    training.BandwidthWeight,
+			ReferenceBandwidth: training.ReferenceBandwidth,
+			LatencyTolerance:   training.LatencyTolerance.String(),
+			LatencyPenalty:     training.LatencyPenalty,
+			SpotWeight:         training.SpotWeight,
+		},
+	}
+}
+
+// Profiles builds the profiles the policy tunes.
+func (p PlacementPolicy) Profiles() ([]PlacementProfile, error) {
+	budget, err := time.ParseDuration(p.Inference.LatencyBudget)
+	if err != nil || budget <= 0 {
+		return nil, fmt.Errorf("inference: invalid latency budget %q", p.Inference.LatencyBudget)
+	}
+	tolerance, err := time.ParseDuration(p.Training.LatencyTolerance)
+	if err != nil || tolerance < 0 {
+		return nil, fmt.Errorf("training: invalid latency tolerance %q", p.Training.LatencyTolerance)
+	}
+	if p.Training.ReferenceBandwidth < 0 {
+		return nil, fmt.Errorf("training: invalid reference bandwidth %v", p.Training.ReferenceBandwidth)
+	}
+
+	return []PlacementProfile{
+		&InferenceProfile{
+			LatencyBudget:   budget,
+			ProximityWeight: p.Inference.ProximityWeight,
+			SharedGPUWeight: p.Inference.SharedGPUWeight,
+			Standby:         p.Inference.Standby,
+		},
+		&TrainingProfile{
+			ColocationWeight:   p.Training.ColocationWeight,
+			BandwidthWeight:    p.Training.BandwidthWeight,
+			ReferenceBandwidth: p.Training.ReferenceBandwidth,
+			LatencyTolerance:   tolerance,
+			LatencyPenalty:     p.Training.LatencyPenalty,
+			SpotWeight:         p.Training.SpotWeight,
+		},
+	}, nil
+}
+
+// LoadPlacementPolicy reads a JSON PlacementPolicy over the defaults and
+// builds its profiles, for WithPlacementProfiles. Unknown fields are
+// rejected so a misspelt tunable does not silently keep its default.
+func LoadPlacementPolicy(r io.Reader) ([]PlacementProfile, error) {
+	policy := DefaultPlacementPolicy()
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to decode placement policy: %w", err)
+	}
+	return policy.Profiles()
+}
@@ -0,0 +1,357 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+)
+
+// ProfileInference is the name of the built-in InferenceProfile.
+const ProfileInference = "inference"
+
+// LabelNodeWarmImages is the node label listing, comma separated, the
+// container images a node has already pulled and can start without delay.
+const LabelNodeWarmImages = "images.warm"
+
+// LabelNodeGPUSpareMemory is the node label holding the GPU memory, in
+// MiB, left free on the GPUs the node shares between jobs.
+const LabelNodeGPUSpareMemory = "gpu.memory.spare"
+
+// LabelJobGPUMemory is the job label holding the GPU memory, in MiB, a job
+// needs when it can share a GPU, such as a model being served.
+const LabelJobGPUMemory = "gpu-memory"
+
+// PlacementProfile tailors node selection to a kind of workload, chosen
+// per job with SchedulingOptions.Profile.
+//
+// Rank sees every candidate after the scheduling options have been
+// applied and may drop or re-rank them; the scheduler then picks the best
+// TargetCount as workers. Complete sees the workers and the remaining
+// candidates and may add nodes in other roles, such as standbys.
+type PlacementProfile interface {
+	// Name is what SchedulingOptions.Profile selects the profile by.
+	Name() string
+
+	// Rank returns the candidates the workload may use, with their ranks
+	// adjusted. The order does not matter.
+	Rank(ctx context.Context, pc *PlacementContext, candidates []NodeSelection) []NodeSelection
+
+	// Complete returns the final selection given the chosen workers and
+	// the candidates that were not chosen, best first.
+	Complete(ctx context.Context, pc *PlacementContext, workers, spare []NodeSelection) ([]NodeSelection, error)
+}
+
+// PlacementContext is what a PlacementProfile can see of a scheduling pass.
+type PlacementContext struct {
+	// Request is the request being scheduled.
+	Request GlobalSchedulingRequest
+
+	scheduler *Scheduler
+	nodes     map[string]models.NodeInfo
+	leaser    CapacityLeaser
+}
+
+// Node returns the information of a candidate node.
+func (pc *PlacementContext) Node(nodeID string) (models.NodeInfo, bool) {
+	info, ok := pc.nodes[nodeID]
+	return info, ok
+}
+
+// OriginLatency returns the latency from the request's origin to a
+// region, measured when the scheduler has a latency matrix and estimated
+// otherwise. It reports false when the origin is unknown.
+func (pc *PlacementContext) OriginLatency(region string) (time.Duration, bool) {
+	origin := pc.Request.OriginRegion
+	if origin == "" {
+		return 0, false
+	}
+	if pc.scheduler.latencyMatrix != nil {
+		return pc.scheduler.latencyMatrix.GetLatency(origin, region), true
+	}
+	return EstimatedLatency(origin, region), true
+}
+
+// Bandwidth returns the bandwidth, in bytes per second, between two
+// regions or nodes, measured when the scheduler has a latency matrix and
+// estimated otherwise.
+func (pc *PlacementContext) Bandwidth(from, to string) float64 {
+	if pc.scheduler.latencyMatrix != nil {
+		return pc.scheduler.latencyMatrix.GetBandwidth(from, to)
+	}
+	return EstimatedBandwidth(from, to)
+}
+
+// Lease acquires a lease on the node for the job when the pass leases
+// capacity. It reports false when the lease is not granted.
+func (pc *PlacementContext) Lease(ctx context.Context, sel NodeSelection) (NodeSelection, bool, error) {
+	if pc.leaser == nil {
+		return sel, true, nil
+	}
+	resources, err := jobResources(pc.Request.Job)
+	if err != nil {
+		return sel, false, err
+	}
+	lease, err := pc.leaser.AcquireLease(ctx, pc.Request.Job.ID, sel.NodeID, resources)
+	if err != nil {
+		// A node already leased is skipped; only cancellation is an error
+		return sel, false, ctx.Err()
+	}
+	sel.LeaseID = lease.ID
+	return sel, true, nil
+}
+
+// newPlacementContext creates the context a profile sees of a pass over
+// the matched nodes.
+func newPlacementContext(
+	s *Scheduler, req GlobalSchedulingRequest, leaser CapacityLeaser, matched []orchestrator.NodeRank,
+) *PlacementContext {
+	nodes := make(map[string]models.NodeInfo, len(matched))
+	for _, rank := range matched {
+		nodes[rank.NodeInfo.ID()] = rank.NodeInfo
+	}
+	return &PlacementContext{Request: req, scheduler: s, nodes: nodes, leaser: leaser}
+}
+
+// unselected returns the candidates that are not among the selected nodes.
+func unselected(candidates, selected []NodeSelection) []NodeSelection {
+	used := make(map[string]bool, len(selected))
+	for _, sel := range selected {
+		used[sel.NodeID] = true
+	}
+	var rest []NodeSelection
+	for _, sel := range candidates {
+		if !used[sel.NodeID] {
+			rest = append(rest, sel)
+		}
+	}
+	return rest
+}
+
+// WithPlacementProfile makes a profile selectable by its name, replacing
+// any profile of the same name, built-in ones included.
+func WithPlacementProfile(profile PlacementProfile) SchedulerOption {
+	return WithPlacementProfiles([]PlacementProfile{profile})
+}
+
+// WithPlacementProfiles is WithPlacementProfile for several profiles, such
+// as those LoadPlacementPolicy tunes.
+func WithPlacementProfiles(profiles []PlacementProfile) SchedulerOption {
+	return func(s *Scheduler) {
+		for _, profile := range profiles {
+			s.profiles[profile.Name()] = profile
+		}
+	}
+}
+
+// builtinProfiles returns the profiles every scheduler knows.
+func builtinProfiles() map[string]PlacementProfile {
+	profiles := make(map[string]PlacementProfile)
+	for _, profile := range []PlacementProfile{NewInferenceProfile(), NewTrainingProfile()} {
+		profiles[profile.Name()] = profile
+	}
+	return profiles
+}
+
+// placementProfile returns the profile the request selects, or nil for none.
+func (s *Scheduler) placementProfile(req GlobalSchedulingRequest) (PlacementProfile, error) {
+	name := req.Scheduling.Profile
+	if name == "" {
+		return nil, nil
+	}
+	profile, ok := s.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown placement profile: %s", name)
+	}
+	return profile, nil
+}
+
+// applyProfile lets the profile drop and re-rank the candidates, then
+// sorts them by their new rank.
+func (s *Scheduler) applyProfile(
+	ctx context.Context, profile PlacementProfile, pc *PlacementContext, selections []NodeSelection,
+) []NodeSelection {
+	ranked := profile.Rank(ctx, pc, selections)
+	rejectDroppedSelections(ctx, ReasonProfileMismatch, profile.Name(), selections, ranked)
+	traceFrom(ctx).match(ConstraintProfile)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Rank > ranked[j].Rank
+	})
+
+	componentLogger(ctx, ComponentScheduler).Debug().
+		Str("jobID", pc.Request.Job.ID).
+		Str("profile", profile.Name()).
+		Int("matched", len(ranked)).
+		Int("rejected", len(selections)-len(ranked)).
+		Msg("Applied placement profile")
+
+	return ranked
+}
+
+// InferenceProfile places model-serving jobs, which answer small requests
+// and care about response time. It
+//
+//   - keeps the job on nodes that already have its image, so replicas
+//     start without a pull
+//   - ranks nodes closer to the request's origin higher
+//   - prefers nodes with spare memory on a shared GPU over taking a
+//     whole GPU, for jobs that need one
+//   - adds a standby replica in a region none of the workers are in, so
+//     a regional outage does not take the model offline
+type InferenceProfile struct {
+	// LatencyBudget is the origin latency beyond which proximity adds no
+	// rank; a node at the origin gets ProximityWeight.
+	LatencyBudget time.Duration
+
+	// ProximityWeight is the rank added for a node at the origin.
+	ProximityWeight int
+
+	// SharedGPUWeight is the rank added for a node with enough spare
+	// memory on a shared GPU.
+	SharedGPUWeight int
+
+	// Standby adds a standby replica in another region.
+	Standby bool
+}
+
+// NewInferenceProfile creates the inference profile with its defaults.
+func NewInferenceProfile() *InferenceProfile {
+	return &InferenceProfile{
+		LatencyBudget:   200 * time.Millisecond,
+		ProximityWeight: 100,
+		SharedGPUWeight: 50,
+		Standby:         true,
+	}
+}
+
+// Name returns ProfileInference.
+func (p *InferenceProfile) Name() string {
+	return ProfileInference
+}
+
+// Rank drops the nodes without the job's image and ranks the rest by
+// proximity to the origin and spare shared GPU memory.
+func (p *InferenceProfile) Rank(ctx context.Context, pc *PlacementContext, candidates []NodeSelection) []NodeSelection {
+	image := jobImage(pc.Request.Job)
+	gpuMemory, sharesGPU := jobGPUMemory(pc.Request.Job)
+
+	ranked := make([]NodeSelection, 0, len(candidates))
+	for _, sel := range candidates {
+		info, _ := pc.Node(sel.NodeID)
+		if image != "" && !hasWarmImage(info, image) {
+			continue
+		}
+
+		var reasons []string
+		if latency, ok := pc.OriginLatency(sel.Region); ok {
+			sel.EstimatedLatency = latency
+			if latency < p.LatencyBudget {
+				sel.addScore(ReasonOriginProximity, int(float64(p.ProximityWeight)*float64(p.LatencyBudget-latency)/float64(p.LatencyBudget)))
+			}
+			reasons = append(reasons, fmt.Sprintf("%s from origin", latency))
+		}
+		if spare := gpuSpareMemory(info); sharesGPU && spare >= gpuMemory {
+			sel.addScore(ReasonSharedGPU, p.SharedGPUWeight)
+			reasons = append(reasons, fmt.Sprintf("%d MiB spare on a shared GPU", spare))
+		}
+		if image != "" {
+			sel.addReason(ReasonWarmImage)
+			reasons = append(reasons, "image warm")
+		}
+		sel.Reason = "inference: " + strings.Join(reasons, ", ")
+		ranked = append(ranked, sel)
+	}
+	return ranked
+}
+
+// Complete adds a standby replica on the best spare node outside the
+// workers' regions. Without one the workers are returned as they are.
+func (p *InferenceProfile) Complete(
+	ctx context.Context, pc *PlacementContext, workers, spare []NodeSelection,
+) ([]NodeSelection, error) {
+	if !p.Standby || len(workers) == 0 {
+		return workers, nil
+	}
+
+	covered := make(map[string]bool, len(workers))
+	for _, worker := range workers {
+		covered[worker.Region] = true
+	}
+	for _, sel := range spare {
+		if covered[sel.Region] {
+			continue
+		}
+		sel, ok, err := pc.Lease(ctx, sel)
+		if err != nil {
+			return workers, err
+		}
+		if !ok {
+			continue
+		}
+
+		workers = append([]NodeSelection(nil), workers...)
+		for i := range workers {
+			if workers[i].Role == "" {
+				workers[i].Role = NodeRoleWorker
+			}
+		}
+		sel.Role = NodeRoleStandby
+		sel.addReason(ReasonStandby)
+		sel.Reason = "standby in " + sel.Region
+		return append(workers, sel), nil
+	}
+
+	componentLogger(ctx, ComponentScheduler).Debug().
+		Str("jobID", pc.Request.Job.ID).
+		Msg("No node outside the workers' regions for a standby replica")
+	return workers, nil
+}
+
+// hasWarmImage reports whether the node lists image among its warm
+// images. An image without a tag matches the latest tag.
+func hasWarmImage(info models.NodeInfo, image string) bool {
+	for _, warm := range strings.Split(info.Labels[LabelNodeWarmImages], ",") {
+		warm = strings.TrimSpace(warm)
+		if warm != "" && normalizeImage(warm) == normalizeImage(image) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeImage adds the latest tag to an image reference without one.
+func normalizeImage(image string) string {
+	if strings.Contains(image, "@") {
+		return image
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if !strings.Contains(name, ":") {
+		return image + ":latest"
+	}
+	return image
+}
+
+// jobGPUMemory returns the GPU memory the job needs in MiB, and whether
+// it needs a GPU at all.
+func jobGPUMemory(job *models.Job) (uint64, bool) {
+	if value, ok := job.Labels[LabelJobGPUMemory]; ok {
+		if mib, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return mib, true
+		}
+	}
+	resources, err := jobResources(job)
+	return 0, err == nil && resources.GPU > 0
+}
+
+// gpuSpareMemory returns the spare memory, in MiB, the node has on shared GPUs.
+func gpuSpareMemory(info models.NodeInfo) uint64 {
+	spare, _ := strconv.ParseUint(info.Labels[LabelNodeGPUSpareMemory], 10, 64)
+	return spare
+}
@@ -0,0 +1,138 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// servingNode is a node with the given images warm.
+func servingNode(id, region, warm string, rank int) orchestrator.NodeRank {
+	info := createTestNodeInfo(id, region)
+	info.Labels[LabelNodeWarmImages] = warm
+	return orchestrator.NodeRank{NodeInfo: info, Rank: rank}
+}
+
+func selectedIDs(selections []NodeSelection) []string {
+	ids := make([]string, len(selections))
+	for i, sel := range selections {
+		ids[i] = sel.NodeID
+	}
+	return ids
+}
+
+func TestInferenceProfile_SelectNodes(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		servingNode("cold-east", "us-east", "", 90),
+		servingNode("warm-asia", "asia-east", "ubuntu", 80),
+		servingNode("warm-east", "us-east", "nginx, ubuntu:latest", 10),
+		servingNode("warm-eu", "eu-west", "ubuntu:latest", 10),
+	}}
+	req := GlobalSchedulingRequest{
+		Job:          createTestJob("serve", models.JobTypeService, 1),
+		TargetCount:  1,
+		OriginRegion: "us-east",
+		Scheduling:   SchedulingOptions{Profile: ProfileInference},
+	}
+
+	t.Run("warm nodes near the origin with a standby elsewhere", func(t *testing.T) {
+		selections, err := NewScheduler(selector, nil).SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, selections, 2)
+
+		assert.Equal(t, "warm-east", selections[0].NodeID, "closest warm node despite its rank")
+		assert.Equal(t, NodeRoleWorker, selections[0].Role)
+		assert.Contains(t, selections[0].Reason, "image warm")
+		assert.Equal(t, NodeRoleStandby, selections[1].Role)
+		assert.NotEqual(t, "us-east", selections[1].Region)
+		assert.NotContains(t, selectedIDs(selections), "cold-east")
+	})
+
+	t.Run("without a standby", func(t *testing.T) {
+		profile := NewInferenceProfile()
+		profile.Standby = false
+		scheduler := NewScheduler(selector, nil, WithPlacementProfile(profile))
+
+		selections, err := scheduler.SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"warm-east"}, selectedIDs(selections))
+		assert.Empty(t, selections[0].Role)
+	})
+
+	t.Run("no standby when every candidate shares the workers' region", func(t *testing.T) {
+		east := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+			servingNode("east-1", "us-east", "ubuntu", 10),
+			servingNode("east-2", "us-east", "ubuntu", 10),
+		}}
+		selections, err := NewScheduler(east, nil).SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		assert.Len(t, selections, 1)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		unknown := req
+		unknown.Scheduling.Profile = "missing"
+		_, err := NewScheduler(selector, nil).SelectNodes(context.Background(), unknown)
+		assert.ErrorContains(t, err, "unknown placement profile")
+	})
+}
+
+func TestInferenceProfile_PrefersSpareGPUMemory(t *testing.T) {
+	shared := servingNode("shared", "us-east", "ubuntu", 10)
+	shared.NodeInfo.Labels[LabelNodeGPUSpareMemory] = "24576"
+	small := servingNode("small", "us-east", "ubuntu", 20)
+	small.NodeInfo.Labels[LabelNodeGPUSpareMemory] = "4096"
+	whole := servingNode("whole", "us-east", "ubuntu", 30)
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{shared, small, whole}}
+
+	job := createTestJob("serve", models.JobTypeService, 1)
+	job.Labels = map[string]string{LabelJobGPUMemory: "16384"}
+	selections, err := NewScheduler(selector, nil).SelectNodes(context.Background(), GlobalSchedulingRequest{
+		Job:         job,
+		TargetCount: 1,
+		Scheduling:  SchedulingOptions{Profile: ProfileInference},
+	})
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "shared", selections[0].NodeID)
+	assert.Contains(t, selections[0].Reason, "spare on a shared GPU")
+}
+
+func TestInferenceProfile_LeasesStandby(t *testing.T) {
+	a := newLeaseTestAggregator()
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		servingNode("node-1", "us-east", "ubuntu", 20),
+		servingNode("node-2", "eu-west", "ubuntu", 10),
+	}}
+	job := createTestJob("serve", models.JobTypeService, 1)
+	job.Tasks[0].ResourcesConfig = &models.ResourcesConfig{CPU: "4", Memory: "8GiB"}
+
+	selections, err := NewScheduler(selector, a).SelectNodes(context.Background(), GlobalSchedulingRequest{
+		Job:           job,
+		TargetCount:   1,
+		LeaseCapacity: true,
+		Scheduling:    SchedulingOptions{Profile: ProfileInference},
+	})
+	require.NoError(t, err)
+	require.Len(t, selections, 2)
+	assert.Equal(t, NodeRoleStandby, selections[1].Role)
+	assert.NotEmpty(t, selections[1].LeaseID)
+	assert.Len(t, a.ActiveLeases(context.Background()), 2)
+}
+
+func TestHasWarmImage(t *testing.T) {
+	info := createTestNodeInfo("node-1", "us-east")
+	info.Labels[LabelNodeWarmImages] = "ubuntu, registry.io:5000/model, nginx:1.25"
+
+	assert.True(t, hasWarmImage(info, "ubuntu:latest"))
+	assert.True(t, hasWarmImage(info, "registry.io:5000/model:latest"))
+	assert.True(t, hasWarmImage(info, "nginx:1.25"))
+	assert.False(t, hasWarmImage(info, "nginx"))
+	assert.False(t, hasWarmImage(createTestNodeInfo("node-2", "us-east"), "ubuntu"))
+}
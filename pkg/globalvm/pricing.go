@@ -0,0 +1,355 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+const (
+	// DefaultPricingInterval is how often prices are pulled from the oracle.
+	DefaultPricingInterval = 5 * time.Minute
+
+	// DefaultPricingMaxAge is how old prices may get before the cost
+	// calculator falls back to its static table.
+	DefaultPricingMaxAge = 30 * time.Minute
+)
+
+// ResourcePrices are the prices of a node's resources, in credits per hour.
+type ResourcePrices struct {
+	// Base is charged per node regardless of its resources.
+	Base float64 `json:"Base"`
+
+	// CPU is the price per core and MemoryGB the price per GiB of memory.
+	CPU      float64 `json:"CPU"`
+	MemoryGB float64 `json:"MemoryGB"`
+
+	// GPU is the price per GPU by class. A class matches GPUs whose model
+	// name contains it, ignoring case, so "A100" prices an
+	// "NVIDIA A100-SXM4-80GB". The "" class prices GPUs of no listed class.
+	GPU map[string]float64 `json:"GPU,omitempty"`
+}
+
+// gpuPrice returns the price of a GPU by its model name, if the prices
+// list its class or a default. The longest matching class wins, so "A100"
+// does not price an "A10".
+func (p ResourcePrices) gpuPrice(model string) (float64, bool) {
+	model = strings.ToLower(model)
+	best, found := "", false
+	for class := range p.GPU {
+		if class != "" && strings.Contains(model, strings.ToLower(class)) && len(class) > len(best) {
+			best, found = class, true
+		}
+	}
+	if found {
+		return p.GPU[best], true
+	}
+	price, ok := p.GPU[""]
+	return price, ok
+}
+
+// validate checks that no price is negative.
+func (p ResourcePrices) validate() error {
+	if p.Base < 0 || p.CPU < 0 || p.MemoryGB < 0 {
+		return fmt.Errorf("prices must not be negative")
+	}
+	for class, price := range p.GPU {
+		if price < 0 {
+			return fmt.Errorf("GPU class %q: price must not be negative", class)
+		}
+	}
+	return nil
+}
+
+// PriceTable is a set of per-region resource prices.
+//
+//	{
+//	  "UpdatedAt": "2026-10-16T12:00:00Z",
+//	  "Default": {"Base": 1, "CPU": 0.1, "MemoryGB": 0.01, "GPU": {"": 0.5}},
+//	  "Regions": {
+//	    "europe": {"Base": 1, "CPU": 0.12, "MemoryGB": 0.01, "GPU": {"A100": 2.5, "H100": 4}},
+//	    "us-east": {"Base": 0.8, "CPU": 0.08, "MemoryGB": 0.008, "GPU": {"A100": 2}}
+//	  }
+//	}
+type PriceTable struct {
+	// UpdatedAt is when the prices were published. Zero means unknown, in
+	// which case they are as old as the last pull.
+	UpdatedAt time.Time `json:"UpdatedAt,omitempty"`
+
+	// Default prices nodes in regions the table does not list.
+	Default ResourcePrices `json:"Default"`
+
+	// Regions maps flat region labels, aliases and areas of the region
+	// hierarchy such as "europe" or "europe/de" to their prices.
+	Regions map[string]ResourcePrices `json:"Regions,omitempty"`
+}
+
+// DefaultPriceTable returns the static prices of the default cost calculator.
+func DefaultPriceTable() *PriceTable {
+	return &PriceTable{
+		Default: ResourcePrices{
+			Base:     1.0,
+			CPU:      0.1,
+			MemoryGB: 0.01,
+			GPU:      map[string]float64{"": 0.5},
+		},
+	}
+}
+
+// Validate checks that no price in the table is negative.
+func (t *PriceTable) Validate() error {
+	if err := t.Default.validate(); err != nil {
+		return fmt.Errorf("default prices: %w", err)
+	}
+	for region, prices := range t.Regions {
+		if err := prices.validate(); err != nil {
+			return fmt.Errorf("prices of %s: %w", region, err)
+		}
+	}
+	return nil
+}
+
+// Prices returns the prices for a node: those of its flat region label
+// when listed, else those of the innermost listed area of the region
+// hierarchy containing it, else the default prices.
+func (t *PriceTable) Prices(info models.NodeInfo) ResourcePrices {
+	if prices, ok := t.Regions[nodeRegion(info)]; ok {
+		return prices
+	}
+
+	hierarchy := regions()
+	path := hierarchy.NodePath(info)
+	if path.IsZero() {
+		return t.Default
+	}
+	best, depth := "", 0
+	for key := range t.Regions {
+		area, ok := hierarchy.Resolve(key)
+		if !ok || !path.Within(area) {
+			continue
+		}
+		// Deeper areas win, then the first key in order, for stable results
+		d := len(area.ancestors())
+		if d > depth || (d == depth && key < best) {
+			best, depth = key, d
+		}
+	}
+	if depth > 0 {
+		return t.Regions[best]
+	}
+	return t.Default
+}
+
+// NodeCost returns the hourly cost of a node's available resources. GPUs
+// of a class the node's region does not price are priced by the default
+// prices.
+func (t *PriceTable) NodeCost(info models.NodeInfo) float64 {
+	prices := t.Prices(info)
+	resources := info.ComputeNodeInfo.AvailableCapacity
+
+	cost := prices.Base
+	cost += resources.CPU * prices.CPU
+	cost += float64(resources.Memory>>30) * prices.MemoryGB
+	for _, gpu := range resources.GPUs {
+		price, ok := prices.gpuPrice(gpu.Name)
+		if !ok {
+			price, _ = t.Default.gpuPrice(gpu.Name)
+		}
+		cost += price
+	}
+	return cost
+}
+
+// PricingOracle supplies current resource prices.
+type PricingOracle interface {
+	// Prices returns the current price table.
+	Prices(ctx context.Context) (*PriceTable, error)
+}
+
+// StaticPricingOracle always returns the same table.
+type StaticPricingOracle struct {
+	Table *PriceTable
+}
+
+// Prices returns the static table.
+func (o StaticPricingOracle) Prices(ctx context.Context) (*PriceTable, error) {
+	if o.Table == nil {
+		return nil, fmt.Errorf("static pricing oracle has no table")
+	}
+	return o.Table, nil
+}
+
+// httpPricingOracle implements PricingOracle by fetching a JSON price table.
+type httpPricingOracle struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPricingOracle creates an oracle that fetches a PriceTable as JSON
+// from url.
+func NewHTTPPricingOracle(url string, timeout time.Duration) PricingOracle {
+	return &httpPricingOracle{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Prices fetches and validates the price table.
+func (o *httpPricingOracle) Prices(ctx context.Context) (*PriceTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pricing oracle returned %s", resp.Status)
+	}
+
+	var table PriceTable
+	if err := json.NewDecoder(resp.Body).Decode(&table); err != nil {
+		return nil, fmt.Errorf("failed to decode prices: %w", err)
+	}
+	if err := table.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid prices: %w", err)
+	}
+	return &table, nil
+}
+
+// OracleCostCalculator implements CostCalculator with prices pulled from a
+// pricing oracle. Prices are cached between pulls, so costing a node never
+// waits on the oracle. When the oracle cannot be reached the last prices
+// are kept until they are older than the maximum age; from then on, and
+// before the first successful pull, nodes are costed from a static
+// fallback table.
+type OracleCostCalculator struct {
+	oracle   PricingOracle
+	fallback *PriceTable
+	interval time.Duration
+	maxAge   time.Duration
+	now      func() time.Time
+
+	mu        sync.RWMutex
+	table     *PriceTable
+	fetchedAt time.Time
+}
+
+// PricingOption configures the oracle cost calculator.
+type PricingOption func(*OracleCostCalculator)
+
+// WithPricingFallback sets the prices used while the oracle's are missing
+// or stale.
+func WithPricingFallback(table *PriceTable) PricingOption {
+	return func(c *OracleCostCalculator) {
+		c.fallback = table
+	}
+}
+
+// WithPricingInterval sets how often Run pulls prices.
+func WithPricingInterval(d time.Duration) PricingOption {
+	return func(c *OracleCostCalculator) {
+		c.interval = d
+	}
+}
+
+// WithPricingMaxAge sets how old prices may get before they are replaced
+// by the fallback. Zero never considers prices stale.
+func WithPricingMaxAge(d time.Duration) PricingOption {
+	return func(c *OracleCostCalculator) {
+		c.maxAge = d
+	}
+}
+
+// NewOracleCostCalculator creates a cost calculator fed by oracle. Prices
+// are not pulled until Refresh or Run is called.
+func NewOracleCostCalculator(oracle PricingOracle, opts ...PricingOption) *OracleCostCalculator {
+	c := &OracleCostCalculator{
+		oracle:   oracle,
+		fallback: DefaultPriceTable(),
+		interval: DefaultPricingInterval,
+		maxAge:   DefaultPricingMaxAge,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Refresh pulls prices from the oracle. On failure the cached prices are
+// kept.
+func (c *OracleCostCalculator) Refresh(ctx context.Context) error {
+	table, err := c.oracle.Prices(ctx)
+	if err != nil {
+		pricingRefreshFailures.Add(ctx, 1)
+		return err
+	}
+
+	c.mu.Lock()
+	c.table = table
+	c.fetchedAt = c.now()
+	c.mu.Unlock()
+	return nil
+}
+
+// Run pulls prices now and then every interval until ctx is done.
+func (c *OracleCostCalculator) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Refresh(ctx); err != nil {
+			componentLogger(ctx, ComponentPricing).Warn().Err(err).Msg("Failed to refresh prices")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Table returns the prices nodes are costed with, and whether they are the
+// fallback because the oracle's are missing or stale.
+func (c *OracleCostCalculator) Table() (*PriceTable, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.table == nil {
+		return c.fallback, true
+	}
+	if c.maxAge > 0 {
+		published := c.fetchedAt
+		if !c.table.UpdatedAt.IsZero() && c.table.UpdatedAt.Before(published) {
+			published = c.table.UpdatedAt
+		}
+		if c.now().Sub(published) > c.maxAge {
+			return c.fallback, true
+		}
+	}
+	return c.table, false
+}
+
+// CalculateCost returns the hourly cost of the node's resources at the
+// current prices.
+func (c *OracleCostCalculator) CalculateCost(info models.NodeInfo) float64 {
+	table, _ := c.Table()
+	return table.NodeCost(info)
+}
+
+// Ensure the calculator implements the CostCalculator interface
+var _ CostCalculator = (*OracleCostCalculator)(nil)
@@ -0,0 +1,172 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPriceTable prices Europe by area, us-east by label and leaves the
+// rest of the world at the default prices.
+func testPriceTable() *PriceTable {
+	return &PriceTable{
+		Default: ResourcePrices{Base: 1, CPU: 0.1, MemoryGB: 0.01, GPU: map[string]float64{"": 0.5}},
+		Regions: map[string]ResourcePrices{
+			"eu":          {Base: 2, CPU: 0.2, GPU: map[string]float64{"A100": 3, "A10": 1}},
+			"europe/de":   {Base: 3},
+			"us-east":     {Base: 0.5, CPU: 0.05},
+			"nowhere/xx":  {Base: 100},
+			"unknown-lab": {Base: 100},
+		},
+	}
+}
+
+// pricedNode returns a 4-core, 16GiB node in region with the given GPUs.
+func pricedNode(region string, gpus ...string) models.NodeInfo {
+	info := createTestNodeInfo("node-"+region, region)
+	for i, name := range gpus {
+		info.ComputeNodeInfo.AvailableCapacity.GPUs = append(info.ComputeNodeInfo.AvailableCapacity.GPUs,
+			models.GPU{Index: uint64(i), Name: name, Vendor: models.GPUVendorNvidia})
+	}
+	return info
+}
+
+// staleOracle is a pricing oracle whose table and error can be swapped.
+type staleOracle struct {
+	table *PriceTable
+	err   error
+}
+
+func (o *staleOracle) Prices(ctx context.Context) (*PriceTable, error) {
+	return o.table, o.err
+}
+
+func TestPriceTable_Prices(t *testing.T) {
+	table := testPriceTable()
+
+	assert.Equal(t, 0.5, table.Prices(pricedNode("us-east")).Base, "flat label")
+	assert.Equal(t, 3.0, table.Prices(pricedNode("eu-central")).Base, "innermost area wins")
+	assert.Equal(t, 2.0, table.Prices(pricedNode("eu-west")).Base, "area listed by alias")
+	assert.Equal(t, 1.0, table.Prices(pricedNode("asia-east")).Base, "default")
+	assert.Equal(t, 1.0, table.Prices(pricedNode("mars")).Base, "unplaceable region")
+}
+
+func TestPriceTable_NodeCost(t *testing.T) {
+	table := testPriceTable()
+
+	// Default prices match the default cost calculator
+	node := pricedNode("asia-east", "Tesla T4")
+	assert.InDelta(t, (&DefaultCostCalculator{}).CalculateCost(node), DefaultPriceTable().NodeCost(node), 1e-9)
+
+	// 2 + 4*0.2 + A100 at 3 + A10 at 1 + an unlisted class at the default 0.5
+	node = pricedNode("eu-west", "NVIDIA A100-SXM4-80GB", "NVIDIA A10G", "Tesla T4")
+	assert.InDelta(t, 7.3, table.NodeCost(node), 1e-9)
+}
+
+func TestPriceTable_Validate(t *testing.T) {
+	table := testPriceTable()
+	require.NoError(t, table.Validate())
+
+	table.Regions["eu"].GPU["A100"] = -1
+	assert.ErrorContains(t, table.Validate(), "prices of eu")
+}
+
+func TestOracleCostCalculator_Staleness(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	oracle := &staleOracle{table: testPriceTable()}
+	calc := NewOracleCostCalculator(oracle, WithPricingMaxAge(time.Hour))
+	calc.now = func() time.Time { return now }
+	node := pricedNode("us-east")
+
+	_, fallback := calc.Table()
+	assert.True(t, fallback, "fallback before the first pull")
+	assert.InDelta(t, 1.56, calc.CalculateCost(node), 1e-9)
+
+	require.NoError(t, calc.Refresh(context.Background()))
+	_, fallback = calc.Table()
+	assert.False(t, fallback)
+	assert.InDelta(t, 0.7, calc.CalculateCost(node), 1e-9)
+
+	// A failed pull keeps the cached prices until they go stale
+	oracle.err = errors.New("oracle down")
+	now = now.Add(30 * time.Minute)
+	assert.Error(t, calc.Refresh(context.Background()))
+	assert.InDelta(t, 0.7, calc.CalculateCost(node), 1e-9)
+
+	now = now.Add(31 * time.Minute)
+	_, fallback = calc.Table()
+	assert.True(t, fallback, "stale prices fall back")
+	assert.InDelta(t, 1.56, calc.CalculateCost(node), 1e-9)
+
+	// Prices published long before the pull are stale on arrival
+	oracle.err = nil
+	oracle.table = testPriceTable()
+	oracle.table.UpdatedAt = now.Add(-2 * time.Hour)
+	require.NoError(t, calc.Refresh(context.Background()))
+	_, fallback = calc.Table()
+	assert.True(t, fallback)
+}
+
+func TestHTTPPricingOracle(t *testing.T) {
+	t.Run("fetches prices", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewEncoder(w).Encode(testPriceTable()))
+		}))
+		defer server.Close()
+
+		table, err := NewHTTPPricingOracle(server.URL, time.Second).Prices(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3.0, table.Regions["eu"].GPU["A100"])
+	})
+
+	t.Run("rejects errors and invalid prices", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/down" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"Default": {"CPU": -1}}`))
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPPricingOracle(server.URL+"/down", time.Second).Prices(context.Background())
+		assert.ErrorContains(t, err, "503")
+		_, err = NewHTTPPricingOracle(server.URL, time.Second).Prices(context.Background())
+		assert.ErrorContains(t, err, "invalid prices")
+	})
+}
+
+func TestOracleCostCalculator_Scheduler(t *testing.T) {
+	selector := &mockNodeSelector{
+		nodes: []orchestrator.NodeRank{
+			{NodeInfo: createTestNodeInfo("node-eu", "eu-central"), Rank: 10},
+			{NodeInfo: createTestNodeInfo("node-us", "us-east"), Rank: 10},
+			{NodeInfo: createTestNodeInfo("node-asia", "asia-east"), Rank: 10},
+		},
+	}
+	calc := NewOracleCostCalculator(StaticPricingOracle{Table: testPriceTable()})
+	require.NoError(t, calc.Refresh(context.Background()))
+	scheduler := NewScheduler(selector, &mockCapacityProvider{capacity: &GlobalResources{HealthyNodes: 3}},
+		WithCostCalculator(calc))
+
+	selections, err := scheduler.SelectNodes(context.Background(), GlobalSchedulingRequest{
+		Job:         createTestJob("job-1", models.JobTypeBatch, 1),
+		Scheduling:  SchedulingOptions{PreferLowCost: true},
+		TargetCount: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-us", selections[0].NodeID)
+	assert.InDelta(t, 0.7, selections[0].Cost, 1e-9)
+}
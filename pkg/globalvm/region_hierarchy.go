@@ -0,0 +1,429 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+// RegionLevel is a level of the region hierarchy.
+type RegionLevel string
+
+const (
+	// RegionLevelContinent groups regions by continent, such as "europe".
+	RegionLevelContinent RegionLevel = "continent"
+
+	// RegionLevelCountry groups regions by country, such as "europe/de".
+	RegionLevelCountry RegionLevel = "country"
+
+	// RegionLevelMetro groups regions by metro area, such as "europe/de/frankfurt".
+	RegionLevelMetro RegionLevel = "metro"
+)
+
+// Valid reports whether the level is known. The empty level is valid and
+// stands for flat region labels.
+func (l RegionLevel) Valid() bool {
+	switch l {
+	case "", RegionLevelContinent, RegionLevelCountry, RegionLevelMetro:
+		return true
+	}
+	return false
+}
+
+// Node labels holding a node's place in the region hierarchy. Nodes that
+// carry them are placed by their labels instead of their flat region.
+const (
+	LabelRegionContinent = "region.continent"
+	LabelRegionCountry   = "region.country"
+	LabelRegionMetro     = "region.metro"
+)
+
+// DefaultGlobalLatency is the estimated latency between regions that share
+// no area of the hierarchy.
+const DefaultGlobalLatency = 200 * time.Millisecond
+
+// RegionPath is a place in the region hierarchy, from continent down to
+// metro. Lower levels are empty when unknown.
+type RegionPath struct {
+	Continent string `json:"Continent,omitempty"`
+	Country   string `json:"Country,omitempty"`
+	Metro     string `json:"Metro,omitempty"`
+}
+
+// ParseRegionPath parses a path such as "europe/de/frankfurt" or "europe".
+func ParseRegionPath(s string) (RegionPath, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "/")
+	if len(parts) > 3 {
+		return RegionPath{}, fmt.Errorf("region path %q has more than 3 levels", s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return RegionPath{}, fmt.Errorf("region path %q has an empty level", s)
+		}
+	}
+
+	var path RegionPath
+	path.Continent = parts[0]
+	if len(parts) > 1 {
+		path.Country = parts[1]
+	}
+	if len(parts) > 2 {
+		path.Metro = parts[2]
+	}
+	return path, nil
+}
+
+// String renders the path as "continent/country/metro", leaving out
+// unknown levels.
+func (p RegionPath) String() string {
+	var parts []string
+	for _, part := range []string{p.Continent, p.Country, p.Metro} {
+		if part == "" {
+			break
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// IsZero reports whether the path is unknown.
+func (p RegionPath) IsZero() bool {
+	return p.Continent == ""
+}
+
+// Key returns the path cut to the given level, or "" when the path does not
+// reach that level. Regions with the same key share that area.
+func (p RegionPath) Key(level RegionLevel) string {
+	switch {
+	case level == RegionLevelContinent && p.Continent != "":
+		return p.Continent
+	case level == RegionLevelCountry && p.Country != "":
+		return p.Continent + "/" + p.Country
+	case level == RegionLevelMetro && p.Country != "" && p.Metro != "":
+		return p.Continent + "/" + p.Country + "/" + p.Metro
+	}
+	return ""
+}
+
+// Within reports whether the path lies inside area.
+func (p RegionPath) Within(area RegionPath) bool {
+	if area.IsZero() || p.Continent != area.Continent {
+		return false
+	}
+	if area.Country != "" && p.Country != area.Country {
+		return false
+	}
+	return area.Metro == "" || p.Metro == area.Metro
+}
+
+// ancestors returns the keys of the areas containing the path, innermost first.
+func (p RegionPath) ancestors() []string {
+	var keys []string
+	for _, level := range []RegionLevel{RegionLevelMetro, RegionLevelCountry, RegionLevelContinent} {
+		if key := p.Key(level); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// RegionMapping is the file format of a region hierarchy.
+//
+//	{
+//	  "Regions": {"eu-central": "europe/de/frankfurt", "eu-west": "europe/ie/dublin"},
+//	  "Aliases": {"eu": "europe"},
+//	  "Latency": {"europe": "30ms", "europe/de": "10ms"},
+//	  "GlobalLatency": "200ms"
+//	}
+type RegionMapping struct {
+	// Regions maps flat region labels to their hierarchy path.
+	Regions map[string]string `json:"Regions"`
+
+	// Aliases names areas by other names, such as "eu" for "europe".
+	Aliases map[string]string `json:"Aliases,omitempty"`
+
+	// Latency is the default latency between regions of an area, keyed by
+	// the area's path. Areas without one inherit their parent's.
+	Latency map[string]string `json:"Latency,omitempty"`
+
+	// GlobalLatency is the latency between regions sharing no area.
+	// Empty uses DefaultGlobalLatency.
+	GlobalLatency string `json:"GlobalLatency,omitempty"`
+}
+
+// RegionHierarchy places flat region labels, such as "eu-central", in a
+// continent → country → metro hierarchy, so that constraints, preferences
+// and spread can target any level and latency defaults are inherited from
+// the enclosing areas.
+type RegionHierarchy struct {
+	regions map[string]RegionPath
+	// Flat labels, longest first, for prefix matching
+	labels        []string
+	aliases       map[string]RegionPath
+	latency       map[string]time.Duration
+	globalLatency time.Duration
+}
+
+// NewRegionHierarchy builds a hierarchy from a mapping.
+func NewRegionHierarchy(mapping RegionMapping) (*RegionHierarchy, error) {
+	h := &RegionHierarchy{
+		regions:       make(map[string]RegionPath, len(mapping.Regions)),
+		aliases:       make(map[string]RegionPath, len(mapping.Aliases)),
+		latency:       make(map[string]time.Duration, len(mapping.Latency)),
+		globalLatency: DefaultGlobalLatency,
+	}
+
+	for label, s := range mapping.Regions {
+		path, err := ParseRegionPath(s)
+		if err != nil {
+			return nil, fmt.Errorf("region %s: %w", label, err)
+		}
+		label = strings.ToLower(label)
+		h.regions[label] = path
+		h.labels = append(h.labels, label)
+	}
+	sort.Slice(h.labels, func(i, j int) bool {
+		if len(h.labels[i]) != len(h.labels[j]) {
+			return len(h.labels[i]) > len(h.labels[j])
+		}
+		return h.labels[i] < h.labels[j]
+	})
+
+	for alias, s := range mapping.Aliases {
+		path, err := ParseRegionPath(s)
+		if err != nil {
+			return nil, fmt.Errorf("alias %s: %w", alias, err)
+		}
+		h.aliases[strings.ToLower(alias)] = path
+	}
+
+	for area, s := range mapping.Latency {
+		path, err := ParseRegionPath(area)
+		if err != nil {
+			return nil, fmt.Errorf("latency of %s: %w", area, err)
+		}
+		latency, err := time.ParseDuration(s)
+		if err != nil || latency < 0 {
+			return nil, fmt.Errorf("latency of %s: invalid duration %q", area, s)
+		}
+		h.latency[path.String()] = latency
+	}
+
+	if mapping.GlobalLatency != "" {
+		latency, err := time.ParseDuration(mapping.GlobalLatency)
+		if err != nil || latency < 0 {
+			return nil, fmt.Errorf("invalid global latency %q", mapping.GlobalLatency)
+		}
+		h.globalLatency = latency
+	}
+
+	return h, nil
+}
+
+// LoadRegionHierarchy reads a JSON RegionMapping and builds its hierarchy.
+func LoadRegionHierarchy(r io.Reader) (*RegionHierarchy, error) {
+	var mapping RegionMapping
+	if err := json.NewDecoder(r).Decode(&mapping); err != nil {
+		return nil, fmt.Errorf("failed to decode region mapping: %w", err)
+	}
+	return NewRegionHierarchy(mapping)
+}
+
+// DefaultRegionMapping returns the mapping of the flat region labels the
+// location detector assigns.
+func DefaultRegionMapping() RegionMapping {
+	return RegionMapping{
+		Regions: map[string]string{
+			"us-east":        "north-america/us/ashburn",
+			"us-central":     "north-america/us/chicago",
+			"us-west":        "north-america/us/san-jose",
+			"south-america":  "south-america/br/sao-paulo",
+			"eu-west":        "europe/ie/dublin",
+			"eu-central":     "europe/de/frankfurt",
+			"eu-north":       "europe/se/stockholm",
+			"asia-east":      "asia/jp/tokyo",
+			"asia-south":     "asia/in/mumbai",
+			"asia-southeast": "asia/sg/singapore",
+			"africa":         "africa/za/johannesburg",
+			"australia":      "oceania/au/sydney",
+		},
+		Aliases: map[string]string{
+			"eu":   "europe",
+			"na":   "north-america",
+			"sa":   "south-america",
+			"us":   "north-america/us",
+			"apac": "asia",
+		},
+		Latency: map[string]string{
+			"north-america":    "70ms",
+			"north-america/us": "60ms",
+			"south-america":    "60ms",
+			"europe":           "30ms",
+			"asia":             "90ms",
+			"africa":           "80ms",
+			"oceania":          "40ms",
+		},
+	}
+}
+
+// DefaultRegionHierarchy returns the hierarchy of DefaultRegionMapping.
+func DefaultRegionHierarchy() *RegionHierarchy {
+	h, err := NewRegionHierarchy(DefaultRegionMapping())
+	if err != nil {
+		panic(fmt.Sprintf("invalid default region mapping: %v", err))
+	}
+	return h
+}
+
+var regionHierarchy atomic.Pointer[RegionHierarchy]
+
+// SetRegionHierarchy replaces the hierarchy used to place flat region
+// labels, such as one read with LoadRegionHierarchy. Nil restores the
+// default hierarchy.
+func SetRegionHierarchy(h *RegionHierarchy) {
+	regionHierarchy.Store(h)
+}
+
+// regions returns the hierarchy in use.
+func regions() *RegionHierarchy {
+	if h := regionHierarchy.Load(); h != nil {
+		return h
+	}
+	h := DefaultRegionHierarchy()
+	regionHierarchy.CompareAndSwap(nil, h)
+	return regionHierarchy.Load()
+}
+
+// Resolve returns the hierarchy path of a flat region label, an alias, or
+// a path such as "europe/de". Labels extending a known one, such as
+// "eu-central-1", resolve like it.
+func (h *RegionHierarchy) Resolve(region string) (RegionPath, bool) {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if region == "" {
+		return RegionPath{}, false
+	}
+	if path, ok := h.regions[region]; ok {
+		return path, true
+	}
+	if path, ok := h.aliases[region]; ok {
+		return path, true
+	}
+	if strings.Contains(region, "/") {
+		path, err := ParseRegionPath(region)
+		return path, err == nil
+	}
+	if h.isArea(region) {
+		return RegionPath{Continent: region}, true
+	}
+	for _, label := range h.labels {
+		if strings.HasPrefix(region, label) {
+			return h.regions[label], true
+		}
+	}
+	return RegionPath{}, false
+}
+
+// isArea reports whether name is a continent of the hierarchy.
+func (h *RegionHierarchy) isArea(name string) bool {
+	for _, path := range h.regions {
+		if path.Continent == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Within reports whether region lies inside area. Both may be flat labels,
+// aliases or paths, so Within("eu-central", "eu") holds.
+func (h *RegionHierarchy) Within(region, area string) bool {
+	path, ok := h.Resolve(region)
+	if !ok {
+		return false
+	}
+	areaPath, ok := h.Resolve(area)
+	return ok && path.Within(areaPath)
+}
+
+// Key returns the key of the area at level containing region, or the region
+// itself when level is empty or the region cannot be placed.
+func (h *RegionHierarchy) Key(region string, level RegionLevel) string {
+	if level == "" {
+		return region
+	}
+	path, ok := h.Resolve(region)
+	if !ok {
+		return region
+	}
+	if key := path.Key(level); key != "" {
+		return key
+	}
+	return region
+}
+
+// Latency returns the default latency between two regions: the latency of
+// the innermost area containing both, inherited from the enclosing areas
+// when that one has none, or the global latency when they share no area.
+func (h *RegionHierarchy) Latency(from, to string) time.Duration {
+	fromPath, ok := h.Resolve(from)
+	if !ok {
+		return h.globalLatency
+	}
+	toPath, ok := h.Resolve(to)
+	if !ok {
+		return h.globalLatency
+	}
+
+	shared := make(map[string]bool)
+	for _, key := range toPath.ancestors() {
+		shared[key] = true
+	}
+	for _, key := range fromPath.ancestors() {
+		if latency, ok := h.latency[key]; ok && shared[key] {
+			return latency
+		}
+	}
+	return h.globalLatency
+}
+
+// NodePath returns where a node sits in the hierarchy, from its hierarchy
+// labels when it has them and from its flat region otherwise.
+func (h *RegionHierarchy) NodePath(info models.NodeInfo) RegionPath {
+	if continent := strings.ToLower(info.Labels[LabelRegionContinent]); continent != "" {
+		return RegionPath{
+			Continent: continent,
+			Country:   strings.ToLower(info.Labels[LabelRegionCountry]),
+			Metro:     strings.ToLower(info.Labels[LabelRegionMetro]),
+		}
+	}
+	path, _ := h.Resolve(nodeRegion(info))
+	return path
+}
+
+// MigrateLabels adds the hierarchy labels of the flat region in labels,
+// leaving the flat label in place for older schedulers. Labels already
+// carrying a continent are left alone. It reports whether labels changed.
+func (h *RegionHierarchy) MigrateLabels(labels map[string]string) bool {
+	if labels == nil || labels[LabelRegionContinent] != "" {
+		return false
+	}
+	path, ok := h.Resolve(nodeRegion(models.NodeInfo{Labels: labels}))
+	if !ok {
+		return false
+	}
+	labels[LabelRegionContinent] = path.Continent
+	if path.Country != "" {
+		labels[LabelRegionCountry] = path.Country
+	}
+	if path.Metro != "" {
+		labels[LabelRegionMetro] = path.Metro
+	}
+	return true
+}
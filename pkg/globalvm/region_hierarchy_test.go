@@ -0,0 +1,172 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionHierarchy_Resolve(t *testing.T) {
+	h := DefaultRegionHierarchy()
+
+	tests := []struct {
+		region string
+		want   string
+	}{
+		{"eu-central", "europe/de/frankfurt"},
+		{"EU-Central", "europe/de/frankfurt"},
+		{"eu-central-1", "europe/de/frankfurt"},
+		{"eu", "europe"},
+		{"europe", "europe"},
+		{"us", "north-america/us"},
+		{"europe/fr/paris", "europe/fr/paris"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			path, ok := h.Resolve(tt.region)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, path.String())
+		})
+	}
+
+	_, ok := h.Resolve("mars")
+	assert.False(t, ok)
+}
+
+func TestRegionHierarchy_Within(t *testing.T) {
+	h := DefaultRegionHierarchy()
+
+	assert.True(t, h.Within("eu-central", "eu"))
+	assert.True(t, h.Within("eu-west", "europe/ie"))
+	assert.False(t, h.Within("eu-west", "europe/de"))
+	assert.True(t, h.Within("us-west", "us"))
+	assert.False(t, h.Within("asia-east", "eu"))
+	assert.False(t, h.Within("unknown", "eu"))
+}
+
+func TestRegionHierarchy_LatencyInheritance(t *testing.T) {
+	h, err := LoadRegionHierarchy(strings.NewReader(`{
+		"Regions": {
+			"fra-1": "europe/de/frankfurt",
+			"ber-1": "europe/de/berlin",
+			"par-1": "europe/fr/paris",
+			"nyc-1": "north-america/us/new-york"
+		},
+		"Latency": {"europe": "30ms", "europe/de": "12ms"},
+		"GlobalLatency": "150ms"
+	}`))
+	require.NoError(t, err)
+
+	// The country sets its own default
+	assert.Equal(t, 12*time.Millisecond, h.Latency("fra-1", "ber-1"))
+	// The metro has none and inherits the country's
+	assert.Equal(t, 12*time.Millisecond, h.Latency("fra-1", "fra-1"))
+	// France has none and inherits Europe's
+	assert.Equal(t, 30*time.Millisecond, h.Latency("fra-1", "par-1"))
+	// No shared area
+	assert.Equal(t, 150*time.Millisecond, h.Latency("fra-1", "nyc-1"))
+	assert.Equal(t, 150*time.Millisecond, h.Latency("fra-1", "unknown"))
+}
+
+func TestLoadRegionHierarchy_Errors(t *testing.T) {
+	tests := map[string]string{
+		"bad json":     `{`,
+		"deep path":    `{"Regions": {"r": "a/b/c/d"}}`,
+		"empty level":  `{"Regions": {"r": "europe//berlin"}}`,
+		"bad latency":  `{"Regions": {}, "Latency": {"europe": "fast"}}`,
+		"bad global":   `{"Regions": {}, "GlobalLatency": "-1s"}`,
+		"bad alias to": `{"Regions": {}, "Aliases": {"eu": ""}}`,
+	}
+	for name, mapping := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadRegionHierarchy(strings.NewReader(mapping))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRegionHierarchy_MigrateLabels(t *testing.T) {
+	h := DefaultRegionHierarchy()
+
+	labels := map[string]string{"region": "eu-central"}
+	assert.True(t, h.MigrateLabels(labels))
+	assert.Equal(t, map[string]string{
+		"region":             "eu-central",
+		LabelRegionContinent: "europe",
+		LabelRegionCountry:   "de",
+		LabelRegionMetro:     "frankfurt",
+	}, labels)
+
+	// Already migrated, or no region to migrate
+	assert.False(t, h.MigrateLabels(labels))
+	assert.False(t, h.MigrateLabels(map[string]string{"region": "mars"}))
+
+	// Hierarchy labels take precedence over the flat region
+	info := createTestNodeInfo("node-1", "us-east")
+	info.Labels[LabelRegionContinent] = "Europe"
+	info.Labels[LabelRegionCountry] = "fr"
+	assert.Equal(t, RegionPath{Continent: "europe", Country: "fr"}, h.NodePath(info))
+}
+
+func TestConstraint_RegionHierarchy(t *testing.T) {
+	frankfurt := createTestNodeInfo("node-1", "eu-central")
+	dublin := createTestNodeInfo("node-2", "eu-west")
+	tokyo := createTestNodeInfo("node-3", "asia-east")
+
+	tests := []struct {
+		expr     string
+		expected []bool // frankfurt, dublin, tokyo
+	}{
+		{`region.continent == "europe"`, []bool{true, true, false}},
+		{`region.country in ["de", "jp"]`, []bool{true, false, true}},
+		{`region.metro == "dublin"`, []bool{false, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseConstraint(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected[0], c.Matches(frankfurt))
+			assert.Equal(t, tt.expected[1], c.Matches(dublin))
+			assert.Equal(t, tt.expected[2], c.Matches(tokyo))
+		})
+	}
+}
+
+func TestScheduler_SpreadAndPreferAcrossLevels(t *testing.T) {
+	scheduler := &Scheduler{}
+
+	selections := []NodeSelection{
+		{NodeID: "node-1", Region: "eu-central", Rank: 10},
+		{NodeID: "node-2", Region: "eu-west", Rank: 9},
+		{NodeID: "node-3", Region: "us-east", Rank: 8},
+	}
+
+	// Two flat regions are both in Europe; two continents need the US node
+	result := scheduler.applyRegionSpread(selections, 2, RegionLevelContinent)
+	require.Len(t, result, 2)
+	continents := map[string]bool{}
+	for _, sel := range result {
+		continents[RegionToContinent(sel.Region)] = true
+	}
+	assert.Equal(t, map[string]bool{"europe": true, "north-america": true}, continents)
+
+	// Three continents cannot be reached, so nothing is dropped
+	assert.Len(t, scheduler.applyRegionSpread(selections, 3, RegionLevelContinent), 3)
+
+	result = scheduler.applyPreferredRegions(selections, []string{"eu"})
+	assert.Equal(t, 110, result[0].Rank)
+	assert.Equal(t, 109, result[1].Rank)
+	assert.Equal(t, 8, result[2].Rank)
+}
+
+func TestEstimatedLatency_InheritsFromHierarchy(t *testing.T) {
+	// Not in the measured table, but both in the US
+	assert.Equal(t, 60*time.Millisecond, EstimatedLatency("us-central", "us-west"))
+	// Both in Asia
+	assert.Equal(t, 90*time.Millisecond, EstimatedLatency("asia-southeast", "asia-east"))
+}
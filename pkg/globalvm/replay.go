@@ -0,0 +1,236 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+)
+
+// SchedulingSnapshot is the cluster state a scheduling pass decided on: the
+// request and the nodes the selector matched and rejected.
+type SchedulingSnapshot struct {
+	Request  GlobalSchedulingRequest `json:"Request"`
+	Matched  []orchestrator.NodeRank `json:"Matched"`
+	Rejected []orchestrator.NodeRank `json:"Rejected,omitempty"`
+	TakenAt  time.Time               `json:"TakenAt"`
+}
+
+// SchedulingDecision is a scheduling pass's snapshot and outcome, kept in the
+// audit log so the decision can be replayed.
+type SchedulingDecision struct {
+	SchedulingID string              `json:"SchedulingID,omitempty"`
+	Snapshot     *SchedulingSnapshot `json:"Snapshot"`
+	Selections   []NodeSelection     `json:"Selections,omitempty"`
+	Rejections   []NodeRejection     `json:"Rejections,omitempty"`
+	Partial      bool                `json:"Partial,omitempty"`
+}
+
+// SelectionChange is how a node's selection differs in a replay.
+type SelectionChange string
+
+const (
+	// SelectionAdded is a node the replay selects that the decision did not.
+	SelectionAdded SelectionChange = "added"
+
+	// SelectionRemoved is a node the decision selected that the replay does not.
+	SelectionRemoved SelectionChange = "removed"
+
+	// SelectionMoved is a node selected by both at a different position.
+	SelectionMoved SelectionChange = "moved"
+
+	// SelectionReranked is a node at the same position with a different
+	// rank or reason.
+	SelectionReranked SelectionChange = "reranked"
+)
+
+// SelectionDiff is one node whose selection differs in a replay.
+type SelectionDiff struct {
+	NodeID string          `json:"NodeID"`
+	Change SelectionChange `json:"Change"`
+
+	// Recorded and Replayed are the node's selections, nil where it was
+	// not selected.
+	Recorded *NodeSelection `json:"Recorded,omitempty"`
+	Replayed *NodeSelection `json:"Replayed,omitempty"`
+
+	// RecordedPosition and ReplayedPosition are the node's positions in
+	// the selections, best first, or -1 where it was not selected.
+	RecordedPosition int `json:"RecordedPosition"`
+	ReplayedPosition int `json:"ReplayedPosition"`
+}
+
+// ReplayResult compares a recorded scheduling decision with a replay of it.
+type ReplayResult struct {
+	JobID        string          `json:"JobID"`
+	SchedulingID string          `json:"SchedulingID,omitempty"`
+	DecidedAt    time.Time       `json:"DecidedAt"`
+	Recorded     []NodeSelection `json:"Recorded"`
+	Replayed     []NodeSelection `json:"Replayed"`
+	Diff         []SelectionDiff `json:"Diff,omitempty"`
+}
+
+// Changed reports whether the replay selected differently.
+func (r *ReplayResult) Changed() bool {
+	return len(r.Diff) > 0
+}
+
+// DecisionReplayer is implemented by schedulers that can replay recorded
+// scheduling decisions.
+type DecisionReplayer interface {
+	Replay(ctx context.Context, decision *SchedulingDecision, candidate ...SchedulerOption) (*ReplayResult, error)
+}
+
+// WithDecisionSnapshots keeps the cluster state each scheduling pass saw in
+// its SchedulingResult, so the endpoint can record the decision in the
+// audit log for replay.
+func WithDecisionSnapshots() SchedulerOption {
+	return func(s *Scheduler) {
+		s.snapshots = true
+	}
+}
+
+type snapshotKey struct{}
+
+// withSnapshot returns a context in which selectNodes records the cluster
+// state it sees into the returned snapshot.
+func withSnapshot(ctx context.Context, req GlobalSchedulingRequest) (context.Context, *SchedulingSnapshot) {
+	if req.Job != nil {
+		req.Job = req.Job.Copy()
+	}
+	snapshot := &SchedulingSnapshot{Request: req}
+	return context.WithValue(ctx, snapshotKey{}, snapshot), snapshot
+}
+
+// recordSnapshot records the selector's output if ctx carries a snapshot.
+func recordSnapshot(ctx context.Context, matched, rejected []orchestrator.NodeRank) {
+	snapshot, ok := ctx.Value(snapshotKey{}).(*SchedulingSnapshot)
+	if !ok {
+		return
+	}
+	snapshot.Matched = append([]orchestrator.NodeRank(nil), matched...)
+	snapshot.Rejected = append([]orchestrator.NodeRank(nil), rejected...)
+	snapshot.TakenAt = time.Now()
+}
+
+// snapshotSelector serves a snapshot's nodes in place of the live network.
+type snapshotSelector struct {
+	snapshot *SchedulingSnapshot
+}
+
+func (s snapshotSelector) AllNodes(context.Context) ([]models.NodeInfo, error) {
+	nodes := extractNodeInfos(s.snapshot.Matched)
+	return append(nodes, extractNodeInfos(s.snapshot.Rejected)...), nil
+}
+
+func (s snapshotSelector) MatchingNodes(context.Context, *models.Job) ([]orchestrator.NodeRank, []orchestrator.NodeRank, error) {
+	matched := append([]orchestrator.NodeRank(nil), s.snapshot.Matched...)
+	rejected := append([]orchestrator.NodeRank(nil), s.snapshot.Rejected...)
+	return matched, rejected, nil
+}
+
+// Replay re-runs a recorded decision against its snapshot with the
+// scheduler's current policy, adjusted by the candidate options, and diffs
+// the outcome with the recorded selections. Nothing is leased, and rankers
+// that depend on the time of day see the time of the decision. Reservations
+// and latency measurements are read as they are now.
+func (s *Scheduler) Replay(
+	ctx context.Context, decision *SchedulingDecision, candidate ...SchedulerOption,
+) (*ReplayResult, error) {
+	if decision == nil || decision.Snapshot == nil || decision.Snapshot.Request.Job == nil {
+		return nil, fmt.Errorf("decision has no snapshot to replay")
+	}
+	snapshot := decision.Snapshot
+
+	replayer := *s
+	replayer.nodeSelector = snapshotSelector{snapshot}
+	replayer.snapshots = false
+	for _, opt := range candidate {
+		opt(&replayer)
+	}
+	if replayer.profitability != nil {
+		profitability := *replayer.profitability
+		profitability.now = func() time.Time { return snapshot.TakenAt }
+		replayer.profitability = &profitability
+	}
+
+	req := snapshot.Request
+	req.Job = req.Job.Copy()
+	req.LeaseCapacity = false
+
+	ctx, _ = WithSchedulingID(ctx, "")
+	ctx, trace := withSelectionTrace(ctx)
+	componentLogger(ctx, ComponentScheduler).Debug().
+		Str("jobID", req.Job.ID).
+		Str("replayOf", decision.SchedulingID).
+		Msg("Replaying scheduling decision")
+
+	replayed, err := replayer.selectNodes(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay scheduling decision: %w", err)
+	}
+	trace.annotate(replayed)
+
+	return &ReplayResult{
+		JobID:        req.Job.ID,
+		SchedulingID: decision.SchedulingID,
+		DecidedAt:    snapshot.TakenAt,
+		Recorded:     decision.Selections,
+		Replayed:     replayed,
+		Diff:         diffSelections(decision.Selections, replayed),
+	}, nil
+}
+
+// diffSelections lists the nodes whose selection differs, in the order of
+// the recorded selections followed by nodes only the replay selected.
+func diffSelections(recorded, replayed []NodeSelection) []SelectionDiff {
+	replayedAt := make(map[string]int, len(replayed))
+	for i, sel := range replayed {
+		replayedAt[sel.NodeID] = i
+	}
+
+	var diff []SelectionDiff
+	seen := make(map[string]bool, len(recorded))
+	for i := range recorded {
+		rec := &recorded[i]
+		seen[rec.NodeID] = true
+
+		j, ok := replayedAt[rec.NodeID]
+		if !ok {
+			diff = append(diff, SelectionDiff{
+				NodeID: rec.NodeID, Change: SelectionRemoved, Recorded: rec,
+				RecordedPosition: i, ReplayedPosition: -1,
+			})
+			continue
+		}
+
+		rep := &replayed[j]
+		change := SelectionChange("")
+		switch {
+		case i != j:
+			change = SelectionMoved
+		case rec.Rank != rep.Rank || rec.Reason != rep.Reason:
+			change = SelectionReranked
+		}
+		if change != "" {
+			diff = append(diff, SelectionDiff{
+				NodeID: rec.NodeID, Change: change, Recorded: rec, Replayed: rep,
+				RecordedPosition: i, ReplayedPosition: j,
+			})
+		}
+	}
+
+	for j := range replayed {
+		if rep := &replayed[j]; !seen[rep.NodeID] {
+			diff = append(diff, SelectionDiff{
+				NodeID: rep.NodeID, Change: SelectionAdded, Replayed: rep,
+				RecordedPosition: -1, ReplayedPosition: j,
+			})
+		}
+	}
+	return diff
+}
@@ -0,0 +1,107 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_ReplayCandidatePolicy(t *testing.T) {
+	nodes := energyTestNodes()
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: nodes[1], Rank: 30},
+		{NodeInfo: nodes[0], Rank: 20},
+		{NodeInfo: nodes[2], Rank: 10},
+	}}
+	scheduler := NewScheduler(selector, &mockCapacityProvider{}, WithDecisionSnapshots())
+
+	job := gpuJob("5")
+	result, err := scheduler.Schedule(context.Background(), GlobalSchedulingRequest{Job: &job, TargetCount: 2})
+	require.NoError(t, err)
+	require.NotNil(t, result.Snapshot)
+	require.Len(t, result.Snapshot.Matched, 3)
+	assert.Equal(t, "node-peak", result.Selections[0].NodeID)
+
+	// The decision was taken during node-peak's evening peak
+	result.Snapshot.TakenAt = time.Date(2026, 10, 13, 18, 0, 0, 0, time.UTC)
+	decision := &SchedulingDecision{Snapshot: result.Snapshot, Selections: result.Selections}
+
+	// The network has changed since, which must not affect the replay
+	selector.nodes = nil
+
+	replay, err := scheduler.Replay(context.Background(), decision)
+	require.NoError(t, err)
+	assert.False(t, replay.Changed(), "same policy, same snapshot: %+v", replay.Diff)
+
+	replay, err = scheduler.Replay(context.Background(), decision,
+		WithProfitabilityRanker(NewProfitabilityRanker(DefaultProfitabilityConfig())))
+	require.NoError(t, err)
+	require.True(t, replay.Changed())
+
+	changes := make(map[string]SelectionChange)
+	for _, d := range replay.Diff {
+		changes[d.NodeID] = d.Change
+	}
+	assert.Equal(t, map[string]SelectionChange{
+		"node-peak": SelectionRemoved,
+		"node-flat": SelectionMoved,
+		"node-none": SelectionAdded,
+	}, changes)
+	assert.Equal(t, []string{"node-flat", "node-none"}, selectionIDs(replay.Replayed))
+}
+
+func TestDiffSelections(t *testing.T) {
+	recorded := []NodeSelection{{NodeID: "a", Rank: 20}, {NodeID: "b", Rank: 10}}
+	replayed := []NodeSelection{{NodeID: "a", Rank: 25, Reason: "preferred region: eu"}, {NodeID: "b", Rank: 10}}
+
+	diff := diffSelections(recorded, replayed)
+	require.Len(t, diff, 1)
+	assert.Equal(t, SelectionReranked, diff[0].Change)
+	assert.Equal(t, 20, diff[0].Recorded.Rank)
+	assert.Equal(t, 25, diff[0].Replayed.Rank)
+
+	assert.Empty(t, diffSelections(recorded, recorded))
+}
+
+func TestEndpoint_ReplayDecision(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("node-2", "eu-west"), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity, WithDecisionSnapshots()), capacity)
+
+	job := createTestJob("job-1", models.JobTypeBatch, 1)
+	_, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{Job: job})
+	require.NoError(t, err)
+
+	audit := endpoint.GetAuditLog("job-1")
+	require.Len(t, audit, 1)
+	require.NotNil(t, audit[0].Decision)
+	assert.Equal(t, []string{"node-1"}, selectionIDs(audit[0].Decision.Selections))
+
+	selector.nodes = selector.nodes[1:]
+	replay, err := endpoint.ReplayDecision(context.Background(), "job-1")
+	require.NoError(t, err)
+	assert.False(t, replay.Changed())
+	assert.Equal(t, "job-1", replay.JobID)
+	assert.Equal(t, audit[0].Decision.SchedulingID, replay.SchedulingID)
+
+	_, err = endpoint.ReplayDecision(context.Background(), "job-unknown")
+	assert.ErrorContains(t, err, "no scheduling decision recorded")
+}
+
+func selectionIDs(selections []NodeSelection) []string {
+	ids := make([]string, len(selections))
+	for i, sel := range selections {
+		ids[i] = sel.NodeID
+	}
+	return ids
+}
@@ -0,0 +1,664 @@
+//go:build unit
+
+// Package globalvm provides global scheduling capabilities for the distributed compute network.
+// This file implements state replication to a hot standby Endpoint.
+package globalvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+var (
+	// ErrNotLeader is returned for writes sent to a standby Endpoint.
+	ErrNotLeader = errors.New("endpoint is not the leader")
+
+	// ErrStaleTerm is returned when an entry from a deposed leader is applied.
+	// Rejecting these entries fences off the old leader after failover.
+	ErrStaleTerm = errors.New("replication entry from a stale leadership term")
+
+	// ErrReservationExists is returned when a reservation ID is already allocated.
+	ErrReservationExists = errors.New("reservation already exists")
+
+	// ErrReservationNotFound is returned when a reservation ID is not allocated.
+	ErrReservationNotFound = errors.New("reservation not found")
+
+	// ErrNotReservationOwner is returned when a tenant acts on another tenant's reservation.
+	ErrNotReservationOwner = errors.New("reservation is owned by another tenant")
+)
+
+// QueuedJob is a job waiting for capacity, or a batch job deferred until
+// its AdmitAt time.
+type QueuedJob struct {
+	JobID      string           `json:"JobID"`
+	Request    GlobalJobRequest `json:"Request"`
+	EnqueuedAt time.Time        `json:"EnqueuedAt"`
+	BatchID    string           `json:"BatchID,omitempty"`
+	AdmitAt    time.Time        `json:"AdmitAt,omitempty"`
+}
+
+// Reservation is capacity set aside on a node for a tenant.
+// TransferredFrom is set when the reservation was bought from another tenant.
+type Reservation struct {
+	ID              string           `json:"ID"`
+	TenantID        string           `json:"TenantID,omitempty"`
+	NodeID          string           `json:"NodeID"`
+	JobID           string           `json:"JobID,omitempty"`
+	Resources       models.Resources `json:"Resources"`
+	ExpiresAt       time.Time        `json:"ExpiresAt,omitempty"`
+	TransferredFrom string           `json:"TransferredFrom,omitempty"`
+}
+
+// ReplicationOp identifies the state change carried by a ReplicationEntry.
+type ReplicationOp string
+
+const (
+	OpEnqueueJob        ReplicationOp = "EnqueueJob"
+	OpDequeueJob        ReplicationOp = "DequeueJob"
+	OpPutReservation    ReplicationOp = "PutReservation"
+	OpDeleteReservation ReplicationOp = "DeleteReservation"
+	OpPutLease          ReplicationOp = "PutLease"
+	OpDeleteLease       ReplicationOp = "DeleteLease"
+)
+
+// ReplicationEntry is one change in the replicated log.
+// Entries are totally ordered by Index, which maps directly onto a raft log
+// index or a NATS JetStream sequence number.
+type ReplicationEntry struct {
+	// Term is the leadership term of the leader that produced the entry.
+	Term uint64 `json:"Term"`
+
+	// Index is the position of the entry in the log, starting at 1.
+	Index uint64 `json:"Index"`
+
+	// Op is the state change.
+	Op ReplicationOp `json:"Op"`
+
+	// Key identifies the affected job, reservation or lease.
+	Key string `json:"Key"`
+
+	// Data is the JSON-encoded value for put operations.
+	Data json.RawMessage `json:"Data,omitempty"`
+}
+
+// ReplicationTransport ships log entries from the leader to standbys.
+// Implementations may be backed by NATS JetStream, a raft log, or memory.
+type ReplicationTransport interface {
+	// Publish ships an entry to standbys. It returns once the transport has
+	// durably accepted the entry, so acknowledged writes survive failover.
+	Publish(ctx context.Context, entry ReplicationEntry) error
+
+	// Subscribe delivers entries in index order, starting from the first
+	// entry still retained by the transport, until ctx is done.
+	Subscribe(ctx context.Context) (<-chan ReplicationEntry, error)
+}
+
+// LeadershipHooks are invoked by a LeaderElector on leadership changes.
+type LeadershipHooks struct {
+	// OnElected is called when this instance becomes leader for term.
+	OnElected func(ctx context.Context, term uint64)
+
+	// OnDemoted is called when this instance loses leadership.
+	OnDemoted func(ctx context.Context)
+}
+
+// LeaderElector campaigns for leadership and reports changes through hooks.
+// Implementations can wrap raft, a NATS KV lock, or a Kubernetes lease.
+type LeaderElector interface {
+	// Run campaigns until ctx is done, invoking hooks on every change.
+	Run(ctx context.Context, hooks LeadershipHooks) error
+}
+
+// StateSnapshot is a point-in-time copy of the replicated state.
+type StateSnapshot struct {
+	Term         uint64            `json:"Term"`
+	Index        uint64            `json:"Index"`
+	Queue        []QueuedJob       `json:"Queue"`
+	Reservations []Reservation     `json:"Reservations"`
+	Leases       []SchedulingLease `json:"Leases"`
+}
+
+// ReplicatedState holds the Endpoint state that must survive failover:
+// the job queue, reservations and scheduling leases.
+// It is only changed by applying log entries, on leader and standby alike.
+type ReplicatedState struct {
+	mu           sync.RWMutex
+	term         uint64
+	index        uint64
+	queue        []QueuedJob
+	reservations map[string]Reservation
+	leases       map[string]SchedulingLease
+}
+
+// NewReplicatedState creates an empty state.
+func NewReplicatedState() *ReplicatedState {
+	return &ReplicatedState{
+		reservations: make(map[string]Reservation),
+		leases:       make(map[string]SchedulingLease),
+	}
+}
+
+// Apply applies an entry. Entries at or below the current index are ignored,
+// so redelivery is harmless; entries from an older term are rejected.
+func (s *ReplicatedState) Apply(entry ReplicationEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.Term < s.term {
+		return fmt.Errorf("%w: entry term %d, current term %d", ErrStaleTerm, entry.Term, s.term)
+	}
+	if entry.Index <= s.index {
+		return nil
+	}
+
+	switch entry.Op {
+	case OpEnqueueJob:
+		var job QueuedJob
+		if err := json.Unmarshal(entry.Data, &job); err != nil {
+			return fmt.Errorf("failed to decode queued job: %w", err)
+		}
+		s.queue = append(s.queue, job)
+	case OpDequeueJob:
+		for i, job := range s.queue {
+			if job.JobID == entry.Key {
+				s.queue = append(s.queue[:i], s.queue[i+1:]...)
+				break
+			}
+		}
+	case OpPutReservation:
+		var res Reservation
+		if err := json.Unmarshal(entry.Data, &res); err != nil {
+			return fmt.Errorf("failed to decode reservation: %w", err)
+		}
+		s.reservations[entry.Key] = res
+	case OpDeleteReservation:
+		delete(s.reservations, entry.Key)
+	case OpPutLease:
+		var lease SchedulingLease
+		if err := json.Unmarshal(entry.Data, &lease); err != nil {
+			return fmt.Errorf("failed to decode lease: %w", err)
+		}
+		s.leases[entry.Key] = lease
+	case OpDeleteLease:
+		delete(s.leases, entry.Key)
+	default:
+		return fmt.Errorf("unknown replication op %q", entry.Op)
+	}
+
+	s.term = entry.Term
+	s.index = entry.Index
+	return nil
+}
+
+// Snapshot returns a copy of the current state.
+func (s *ReplicatedState) Snapshot() StateSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := StateSnapshot{
+		Term:         s.term,
+		Index:        s.index,
+		Queue:        append([]QueuedJob(nil), s.queue...),
+		Reservations: make([]Reservation, 0, len(s.reservations)),
+		Leases:       make([]SchedulingLease, 0, len(s.leases)),
+	}
+	for _, res := range s.reservations {
+		snap.Reservations = append(snap.Reservations, res)
+	}
+	for _, lease := range s.leases {
+		snap.Leases = append(snap.Leases, lease)
+	}
+	sort.Slice(snap.Reservations, func(i, j int) bool { return snap.Reservations[i].ID < snap.Reservations[j].ID })
+	sort.Slice(snap.Leases, func(i, j int) bool { return snap.Leases[i].ID < snap.Leases[j].ID })
+	return snap
+}
+
+// Index returns the index of the last applied entry.
+func (s *ReplicatedState) Index() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index
+}
+
+// Term returns the highest leadership term seen.
+func (s *ReplicatedState) Term() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.term
+}
+
+// fence raises the current term so entries from earlier terms are rejected.
+func (s *ReplicatedState) fence(term uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if term > s.term {
+		s.term = term
+	}
+}
+
+// Restore replaces the state with a snapshot, e.g. when a standby joins
+// after the transport has compacted older entries.
+func (s *ReplicatedState) Restore(snap StateSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.term = snap.Term
+	s.index = snap.Index
+	s.queue = append([]QueuedJob(nil), snap.Queue...)
+	s.reservations = make(map[string]Reservation, len(snap.Reservations))
+	for _, res := range snap.Reservations {
+		s.reservations[res.ID] = res
+	}
+	s.leases = make(map[string]SchedulingLease, len(snap.Leases))
+	for _, lease := range snap.Leases {
+		s.leases[lease.ID] = lease
+	}
+}
+
+// position returns the 1-based queue position of a job, or 0 if not queued.
+// Must be called with the lock held.
+func (s *ReplicatedState) position(jobID string) int {
+	for i, job := range s.queue {
+		if job.JobID == jobID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// reservation returns the reservation with the given ID.
+func (s *ReplicatedState) reservation(id string) (Reservation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res, ok := s.reservations[id]
+	return res, ok
+}
+
+// tenantReservations returns the unexpired reservations held by tenantID,
+// sorted by ID.
+func (s *ReplicatedState) tenantReservations(tenantID string, now time.Time) []Reservation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var held []Reservation
+	for _, res := range s.reservations {
+		if res.TenantID != tenantID {
+			continue
+		}
+		if !res.ExpiresAt.IsZero() && !res.ExpiresAt.After(now) {
+			continue
+		}
+		held = append(held, res)
+	}
+	sort.Slice(held, func(i, j int) bool { return held[i].ID < held[j].ID })
+	return held
+}
+
+// Replicator makes an Endpoint's state highly available. On the leader it
+// turns writes into log entries that are published before being applied;
+// on a standby it follows the log so the state is ready at failover.
+type Replicator struct {
+	state     *ReplicatedState
+	transport ReplicationTransport
+	hooks     LeadershipHooks
+
+	mu     sync.Mutex
+	leader bool
+	term   uint64
+}
+
+// ReplicatorOption configures the replicator.
+type ReplicatorOption func(*Replicator)
+
+// WithLeadershipHooks registers additional hooks run after the replicator
+// has switched role, e.g. to start or stop the job dispatcher.
+func WithLeadershipHooks(hooks LeadershipHooks) ReplicatorOption {
+	return func(r *Replicator) {
+		r.hooks = hooks
+	}
+}
+
+// NewReplicator creates a replicator that starts as a standby.
+func NewReplicator(state *ReplicatedState, transport ReplicationTransport, opts ...ReplicatorOption) *Replicator {
+	r := &Replicator{
+		state:     state,
+		transport: transport,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// State returns the replicated state.
+func (r *Replicator) State() *ReplicatedState {
+	return r.state
+}
+
+// IsLeader reports whether this instance accepts writes.
+func (r *Replicator) IsLeader() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.leader
+}
+
+// Hooks returns the hooks to hand to a LeaderElector.
+func (r *Replicator) Hooks() LeadershipHooks {
+	return LeadershipHooks{
+		OnElected: r.Promote,
+		OnDemoted: r.Demote,
+	}
+}
+
+// Promote makes this instance the leader for term. Terms must increase
+// across failovers; entries from earlier terms are rejected from then on.
+func (r *Replicator) Promote(ctx context.Context, term uint64) {
+	r.mu.Lock()
+	r.leader = true
+	r.term = term
+	r.mu.Unlock()
+
+	// Entries still in flight from the previous leader must not be applied
+	r.state.fence(term)
+
+	snap := r.state.Snapshot()
+	componentLogger(ctx, ComponentReplication).Info().
+		Uint64("term", term).
+		Uint64("index", snap.Index).
+		Int("queued", len(snap.Queue)).
+		Int("reservations", len(snap.Reservations)).
+		Int("leases", len(snap.Leases)).
+		Msg("Promoted to Global VM leader")
+
+	if r.hooks.OnElected != nil {
+		r.hooks.OnElected(ctx, term)
+	}
+}
+
+// Demote makes this instance a standby.
+func (r *Replicator) Demote(ctx context.Context) {
+	r.mu.Lock()
+	r.leader = false
+	r.mu.Unlock()
+
+	componentLogger(ctx, ComponentReplication).Info().Msg("Demoted to Global VM standby")
+
+	if r.hooks.OnDemoted != nil {
+		r.hooks.OnDemoted(ctx)
+	}
+}
+
+// Follow applies entries from the transport until ctx is done.
+// Entries are applied while leader too, which makes redelivery of the
+// leader's own writes a no-op and fences off writes from stale terms.
+func (r *Replicator) Follow(ctx context.Context) error {
+	entries, err := r.transport.Subscribe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to replication log: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case entry, ok := <-entries:
+			if !ok {
+				return nil
+			}
+			if err := r.state.Apply(entry); err != nil {
+				componentLogger(ctx, ComponentReplication).Warn().
+					Err(err).
+					Uint64("index", entry.Index).
+					Str("op", string(entry.Op)).
+					Msg("Skipping replication entry")
+			}
+		}
+	}
+}
+
+// EnqueueJob adds a job to the replicated queue and returns its position.
+func (r *Replicator) EnqueueJob(ctx context.Context, job QueuedJob) (int, error) {
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+	if err := r.write(ctx, OpEnqueueJob, job.JobID, job, nil); err != nil {
+		return 0, err
+	}
+
+	r.state.mu.RLock()
+	defer r.state.mu.RUnlock()
+	return r.state.position(job.JobID), nil
+}
+
+// DequeueJob removes a job from the replicated queue.
+func (r *Replicator) DequeueJob(ctx context.Context, jobID string) error {
+	return r.write(ctx, OpDequeueJob, jobID, nil, nil)
+}
+
+// PutReservation records a new reservation.
+// It fails with ErrReservationExists if the ID is already allocated, so a
+// reservation is never handed out twice, even across failover.
+func (r *Replicator) PutReservation(ctx context.Context, res Reservation) error {
+	return r.write(ctx, OpPutReservation, res.ID, res, func() error {
+		if _, exists := r.state.reservation(res.ID); exists {
+			return fmt.Errorf("%w: %s", ErrReservationExists, res.ID)
+		}
+		return nil
+	})
+}
+
+// TransferReservation hands a reservation held by from over to another
+// tenant and returns the updated reservation. The ID is kept, so leases and
+// references to the reservation stay valid.
+func (r *Replicator) TransferReservation(ctx context.Context, id, from, to string) (Reservation, error) {
+	// res is filled in by the check, which runs before the value is encoded
+	var res Reservation
+	err := r.write(ctx, OpPutReservation, id, &res, func() error {
+		current, exists := r.state.reservation(id)
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrReservationNotFound, id)
+		}
+		if current.TenantID != from {
+			return fmt.Errorf("%w: %s", ErrNotReservationOwner, id)
+		}
+		res = current
+		res.TenantID = to
+		res.TransferredFrom = from
+		return nil
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+	return res, nil
+}
+
+// DeleteReservation releases a reservation.
+func (r *Replicator) DeleteReservation(ctx context.Context, id string) error {
+	return r.write(ctx, OpDeleteReservation, id, nil, nil)
+}
+
+// PutLease records a scheduling lease.
+func (r *Replicator) PutLease(ctx context.Context, lease SchedulingLease) error {
+	return r.write(ctx, OpPutLease, lease.ID, lease, nil)
+}
+
+// DeleteLease releases a scheduling lease.
+func (r *Replicator) DeleteLease(ctx context.Context, id string) error {
+	return r.write(ctx, OpDeleteLease, id, nil, nil)
+}
+
+// Leases returns the replicated scheduling leases, so the Replicator can
+// serve as a CapacityAggregator's LeaseStore.
+func (r *Replicator) Leases() []SchedulingLease {
+	return r.state.Snapshot().Leases
+}
+
+// Ensure the replicator can keep an aggregator's leases
+var _ LeaseStore = (*Replicator)(nil)
+
+// RetentionTargets returns the replicated reservations and leases for
+// garbage collection. Deletions are replicated writes, so only the leader
+// collects; on a standby the targets remove nothing.
+func (r *Replicator) RetentionTargets() []RetentionTarget {
+	return []RetentionTarget{reservationTarget{r}, allocationTarget{r}}
+}
+
+// reservationTarget collects reservations tied to completed jobs.
+type reservationTarget struct{ r *Replicator }
+
+func (t reservationTarget) DataClass() DataClass { return DataClassReservations }
+
+func (t reservationTarget) Collect(ctx context.Context, expired ExpiredFunc) (int, error) {
+	if !t.r.IsLeader() {
+		return 0, nil
+	}
+	removed := 0
+	for _, res := range t.r.state.Snapshot().Reservations {
+		// A reservation's ExpiresAt is its end, so it counts as recorded then
+		if res.JobID == "" || !expired(res.JobID, res.ExpiresAt) {
+			continue
+		}
+		if err := t.r.DeleteReservation(ctx, res.ID); err != nil {
+			return removed, fmt.Errorf("failed to delete reservation %s: %w", res.ID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// allocationTarget collects leases held for completed jobs.
+type allocationTarget struct{ r *Replicator }
+
+func (t allocationTarget) DataClass() DataClass { return DataClassAllocations }
+
+func (t allocationTarget) Collect(ctx context.Context, expired ExpiredFunc) (int, error) {
+	if !t.r.IsLeader() {
+		return 0, nil
+	}
+	removed := 0
+	for _, lease := range t.r.state.Snapshot().Leases {
+		if !expired(lease.JobID, lease.ExpiresAt) {
+			continue
+		}
+		if err := t.r.DeleteLease(ctx, lease.ID); err != nil {
+			return removed, fmt.Errorf("failed to delete lease %s: %w", lease.ID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// write publishes an entry and then applies it locally.
+// Writes are serialized so entries are published in index order; check,
+// if set, runs under the same lock to validate the write against the state.
+func (r *Replicator) write(ctx context.Context, op ReplicationOp, key string, value interface{}, check func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.leader {
+		return ErrNotLeader
+	}
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	entry := ReplicationEntry{
+		Term:  r.term,
+		Index: r.state.Index() + 1,
+		Op:    op,
+		Key:   key,
+	}
+	if value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", op, err)
+		}
+		entry.Data = data
+	}
+
+	// Publish first: an acknowledged write must already be on the standby
+	if err := r.transport.Publish(ctx, entry); err != nil {
+		return fmt.Errorf("failed to replicate %s: %w", op, err)
+	}
+	return r.state.Apply(entry)
+}
+
+// InMemoryTransport is a ReplicationTransport for tests and single-process
+// deployments. It retains the full log, so late subscribers catch up.
+//
+// Each subscriber follows the log from its own goroutine, so a standby that
+// stops reading never holds up the leader's writes or other standbys.
+type InMemoryTransport struct {
+	mu  sync.Mutex
+	log []ReplicationEntry
+	// appended is closed, and replaced, whenever an entry is appended
+	appended chan struct{}
+}
+
+// NewInMemoryTransport creates an in-memory transport.
+func NewInMemoryTransport() *InMemoryTransport {
+	return &InMemoryTransport{appended: make(chan struct{})}
+}
+
+// Publish appends an entry to the log the subscribers follow. It does not
+// wait for them to read it.
+func (t *InMemoryTransport) Publish(ctx context.Context, entry ReplicationEntry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.log = append(t.log, entry)
+	close(t.appended)
+	t.appended = make(chan struct{})
+	return nil
+}
+
+// Subscribe replays the retained log and then streams new entries. The
+// channel is closed once ctx is done.
+func (t *InMemoryTransport) Subscribe(ctx context.Context) (<-chan ReplicationEntry, error) {
+	ch := make(chan ReplicationEntry, 256)
+	go t.follow(ctx, ch)
+	return ch, nil
+}
+
+// follow sends the log to ch, from the start and then as it grows, until
+// ctx is done.
+func (t *InMemoryTransport) follow(ctx context.Context, ch chan<- ReplicationEntry) {
+	defer close(ch)
+
+	next := 0
+	for {
+		t.mu.Lock()
+		// Entries are only ever appended, so the slice stays valid unlocked
+		pending := t.log[next:]
+		appended := t.appended
+		t.mu.Unlock()
+
+		for _, entry := range pending {
+			select {
+			case ch <- entry:
+				next++
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(pending) > 0 {
+			continue
+		}
+		select {
+		case <-appended:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Ensure the in-memory transport implements the interface
+var _ ReplicationTransport = (*InMemoryTransport)(nil)
@@ -0,0 +1,223 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startStandby creates a replicator that follows transport in the background.
+func startStandby(t *testing.T, transport ReplicationTransport) *Replicator {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	standby := NewReplicator(NewReplicatedState(), transport)
+	go standby.Follow(ctx)
+	return standby
+}
+
+// waitForIndex waits until the replicator has applied index.
+func waitForIndex(t *testing.T, r *Replicator, index uint64) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return r.State().Index() >= index
+	}, time.Second, 5*time.Millisecond, "standby did not catch up to index %d", index)
+}
+
+func TestReplicator_StandbyRejectsWrites(t *testing.T) {
+	r := NewReplicator(NewReplicatedState(), NewInMemoryTransport())
+
+	_, err := r.EnqueueJob(context.Background(), QueuedJob{JobID: "job-1"})
+	assert.ErrorIs(t, err, ErrNotLeader)
+	assert.False(t, r.IsLeader())
+}
+
+func TestReplicator_ReplicatesToStandby(t *testing.T) {
+	ctx := context.Background()
+	transport := NewInMemoryTransport()
+
+	leader := NewReplicator(NewReplicatedState(), transport)
+	leader.Promote(ctx, 1)
+	standby := startStandby(t, transport)
+
+	pos, err := leader.EnqueueJob(ctx, QueuedJob{JobID: "job-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pos)
+
+	pos, err = leader.EnqueueJob(ctx, QueuedJob{JobID: "job-2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pos)
+
+	require.NoError(t, leader.PutReservation(ctx, Reservation{ID: "res-1", NodeID: "node-1"}))
+	require.NoError(t, leader.PutLease(ctx, SchedulingLease{ID: "lease-1", JobID: "job-3", NodeID: "node-2"}))
+	require.NoError(t, leader.DequeueJob(ctx, "job-1"))
+
+	waitForIndex(t, standby, 5)
+	assert.Equal(t, leader.State().Snapshot(), standby.State().Snapshot())
+
+	snap := standby.State().Snapshot()
+	require.Len(t, snap.Queue, 1)
+	assert.Equal(t, "job-2", snap.Queue[0].JobID)
+	assert.Len(t, snap.Reservations, 1)
+	assert.Len(t, snap.Leases, 1)
+}
+
+func TestReplicator_LateStandbyCatchesUp(t *testing.T) {
+	ctx := context.Background()
+	transport := NewInMemoryTransport()
+
+	leader := NewReplicator(NewReplicatedState(), transport)
+	leader.Promote(ctx, 1)
+	for _, id := range []string{"a", "b", "c"} {
+		_, err := leader.EnqueueJob(ctx, QueuedJob{JobID: id})
+		require.NoError(t, err)
+	}
+
+	standby := startStandby(t, transport)
+	waitForIndex(t, standby, 3)
+	assert.Len(t, standby.State().Snapshot().Queue, 3)
+}
+
+func TestInMemoryTransport_StalledSubscriber(t *testing.T) {
+	ctx := context.Background()
+	transport := NewInMemoryTransport()
+	leader := NewReplicator(NewReplicatedState(), transport)
+	leader.Promote(ctx, 1)
+	standby := startStandby(t, transport)
+
+	// A subscriber that never reads holds up neither the leader nor other standbys
+	stalledCtx, stop := context.WithCancel(ctx)
+	stalled, err := transport.Subscribe(stalledCtx)
+	require.NoError(t, err)
+
+	written := make(chan error, 1)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			if _, err := leader.EnqueueJob(ctx, QueuedJob{JobID: fmt.Sprintf("job-%d", i)}); err != nil {
+				written <- err
+				return
+			}
+		}
+		written <- nil
+	}()
+	select {
+	case err := <-written:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the leader's writes waited on a stalled subscriber")
+	}
+	waitForIndex(t, standby, 1000)
+
+	// Unsubscribing a full subscriber closes its channel
+	stop()
+	require.Eventually(t, func() bool {
+		for {
+			select {
+			case _, ok := <-stalled:
+				if !ok {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, time.Second, 5*time.Millisecond, "the stalled subscriber was not closed")
+}
+
+func TestReplicator_FailoverPreservesStateAndFencesOldLeader(t *testing.T) {
+	ctx := context.Background()
+	transport := NewInMemoryTransport()
+
+	oldLeader := NewReplicator(NewReplicatedState(), transport)
+	oldLeader.Promote(ctx, 1)
+	standby := startStandby(t, transport)
+
+	_, err := oldLeader.EnqueueJob(ctx, QueuedJob{JobID: "queued"})
+	require.NoError(t, err)
+	require.NoError(t, oldLeader.PutReservation(ctx, Reservation{ID: "res-1", NodeID: "node-1"}))
+	waitForIndex(t, standby, 2)
+
+	// Failover: the standby is elected for term 2
+	var electedTerm uint64
+	standby.hooks.OnElected = func(ctx context.Context, term uint64) { electedTerm = term }
+	standby.Hooks().OnElected(ctx, 2)
+	assert.True(t, standby.IsLeader())
+	assert.Equal(t, uint64(2), electedTerm)
+
+	// Nothing queued before the failover is lost
+	snap := standby.State().Snapshot()
+	require.Len(t, snap.Queue, 1)
+	assert.Equal(t, "queued", snap.Queue[0].JobID)
+
+	// The reservation cannot be allocated a second time
+	err = standby.PutReservation(ctx, Reservation{ID: "res-1", NodeID: "node-2"})
+	assert.ErrorIs(t, err, ErrReservationExists)
+
+	// A late write from the deposed leader is fenced off
+	stale := ReplicationEntry{Term: 1, Index: 3, Op: OpPutReservation, Key: "res-2", Data: []byte(`{"ID":"res-2"}`)}
+	assert.ErrorIs(t, standby.State().Apply(stale), ErrStaleTerm)
+}
+
+func TestReplicatedState_ApplyIsIdempotent(t *testing.T) {
+	state := NewReplicatedState()
+	entry := ReplicationEntry{Term: 1, Index: 1, Op: OpEnqueueJob, Key: "job-1", Data: []byte(`{"JobID":"job-1"}`)}
+
+	require.NoError(t, state.Apply(entry))
+	require.NoError(t, state.Apply(entry))
+	assert.Len(t, state.Snapshot().Queue, 1)
+
+	err := state.Apply(ReplicationEntry{Term: 1, Index: 2, Op: "Bogus"})
+	assert.Error(t, err)
+}
+
+func TestReplicatedState_SnapshotRestore(t *testing.T) {
+	state := NewReplicatedState()
+	require.NoError(t, state.Apply(ReplicationEntry{Term: 3, Index: 7, Op: OpPutLease, Key: "l1", Data: []byte(`{"ID":"l1","NodeID":"n1"}`)}))
+
+	restored := NewReplicatedState()
+	restored.Restore(state.Snapshot())
+
+	assert.Equal(t, state.Snapshot(), restored.Snapshot())
+	assert.Equal(t, uint64(3), restored.Term())
+	assert.Equal(t, uint64(7), restored.Index())
+}
+
+func TestEndpoint_WithReplicator(t *testing.T) {
+	ctx := context.Background()
+	transport := NewInMemoryTransport()
+	replicator := NewReplicator(NewReplicatedState(), transport)
+
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity, WithReplicator(replicator))
+
+	req := GlobalJobRequest{Job: createTestJob("job-1", models.JobTypeBatch, 1)}
+
+	// Standby refuses submissions
+	_, err := endpoint.SubmitJob(ctx, req)
+	assert.ErrorIs(t, err, ErrNotLeader)
+
+	replicator.Promote(ctx, 1)
+
+	resp, err := endpoint.SubmitJob(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.QueuePosition)
+
+	resp, err = endpoint.SubmitJob(ctx, GlobalJobRequest{Job: createTestJob("job-2", models.JobTypeBatch, 1)})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.QueuePosition)
+
+	require.NoError(t, endpoint.CancelJob(ctx, "job-1", "no longer needed"))
+	queue := replicator.State().Snapshot().Queue
+	require.Len(t, queue, 1)
+	assert.Equal(t, "job-2", queue[0].JobID)
+}
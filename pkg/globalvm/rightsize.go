@@ -0,0 +1,341 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+)
+
+const (
+	// DefaultRightSizeMinRuns is how many finished runs of an image are
+	// needed before the advisor makes a suggestion.
+	DefaultRightSizeMinRuns = 3
+
+	// DefaultRightSizeHistory is how many recent runs are kept per image.
+	DefaultRightSizeHistory = 50
+
+	// DefaultRightSizeHeadroom is added on top of observed peak usage
+	// when suggesting a smaller request.
+	DefaultRightSizeHeadroom = 1.25
+
+	// DefaultOverProvisionRatio flags a request as over-provisioned when
+	// observed peak usage is below this fraction of what was requested.
+	DefaultOverProvisionRatio = 0.5
+
+	// usageQuantile is the quantile of past usage used as the peak.
+	usageQuantile = 0.95
+
+	// Suggestions are rounded up to these steps.
+	cpuStep    = 0.1
+	memoryStep = 64 << 20
+)
+
+// WorkloadClass describes which resource dominates a workload.
+type WorkloadClass string
+
+const (
+	WorkloadClassUnknown     WorkloadClass = "unknown"
+	WorkloadClassCPUBound    WorkloadClass = "cpu-bound"
+	WorkloadClassMemoryBound WorkloadClass = "memory-bound"
+	WorkloadClassBalanced    WorkloadClass = "balanced"
+	WorkloadClassIdle        WorkloadClass = "idle"
+)
+
+// UsageRecord is the resource usage of one finished run of an image.
+type UsageRecord struct {
+	Image      string           `json:"Image"`
+	Requested  models.Resources `json:"Requested"`
+	Used       models.Resources `json:"Used"`
+	FinishedAt time.Time        `json:"FinishedAt"`
+}
+
+// UsageProfile summarizes the past runs of an image.
+type UsageProfile struct {
+	Image string `json:"Image"`
+
+	// Runs is the number of runs the profile is built from.
+	Runs int `json:"Runs"`
+
+	// PeakCPU and PeakMemory are the 95th percentile of observed usage.
+	PeakCPU    float64 `json:"PeakCPU"`
+	PeakMemory uint64  `json:"PeakMemory"`
+
+	// CPUUtilization and MemoryUtilization are the 95th percentile of
+	// usage as a fraction of what each run requested.
+	CPUUtilization    float64 `json:"CPUUtilization"`
+	MemoryUtilization float64 `json:"MemoryUtilization"`
+
+	Class WorkloadClass `json:"Class"`
+}
+
+// UsageHistory keeps recent resource usage per image.
+type UsageHistory struct {
+	mu      sync.RWMutex
+	limit   int
+	records map[string][]UsageRecord
+}
+
+// NewUsageHistory creates a history that keeps the last limit runs per
+// image. A limit of zero uses DefaultRightSizeHistory.
+func NewUsageHistory(limit int) *UsageHistory {
+	if limit <= 0 {
+		limit = DefaultRightSizeHistory
+	}
+	return &UsageHistory{
+		limit:   limit,
+		records: make(map[string][]UsageRecord),
+	}
+}
+
+// Record adds a finished run to the history.
+func (h *UsageHistory) Record(rec UsageRecord) {
+	if rec.Image == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := append(h.records[rec.Image], rec)
+	if len(records) > h.limit {
+		records = records[len(records)-h.limit:]
+	}
+	h.records[rec.Image] = records
+}
+
+// Profile summarizes the recorded runs of an image. It returns false if
+// the image has no history.
+func (h *UsageHistory) Profile(image string) (UsageProfile, bool) {
+	h.mu.RLock()
+	records := h.records[image]
+	h.mu.RUnlock()
+
+	if len(records) == 0 {
+		return UsageProfile{}, false
+	}
+
+	var cpu, mem, cpuUtil, memUtil []float64
+	for _, rec := range records {
+		cpu = append(cpu, rec.Used.CPU)
+		mem = append(mem, float64(rec.Used.Memory))
+		if rec.Requested.CPU > 0 {
+			cpuUtil = append(cpuUtil, rec.Used.CPU/rec.Requested.CPU)
+		}
+		if rec.Requested.Memory > 0 {
+			memUtil = append(memUtil, float64(rec.Used.Memory)/float64(rec.Requested.Memory))
+		}
+	}
+
+	profile := UsageProfile{
+		Image:             image,
+		Runs:              len(records),
+		PeakCPU:           quantile(cpu, usageQuantile),
+		PeakMemory:        uint64(quantile(mem, usageQuantile)),
+		CPUUtilization:    quantile(cpuUtil, usageQuantile),
+		MemoryUtilization: quantile(memUtil, usageQuantile),
+	}
+	profile.Class = classifyWorkload(profile.CPUUtilization, profile.MemoryUtilization)
+	return profile, true
+}
+
+// classifyWorkload derives the workload class from peak utilization.
+func classifyWorkload(cpuUtil, memUtil float64) WorkloadClass {
+	switch {
+	case cpuUtil == 0 && memUtil == 0:
+		return WorkloadClassUnknown
+	case cpuUtil < 0.2 && memUtil < 0.2:
+		return WorkloadClassIdle
+	case cpuUtil >= 0.7 && memUtil < 0.5:
+		return WorkloadClassCPUBound
+	case memUtil >= 0.7 && cpuUtil < 0.5:
+		return WorkloadClassMemoryBound
+	default:
+		return WorkloadClassBalanced
+	}
+}
+
+// quantile returns the q-th quantile of values using the nearest rank.
+func quantile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// RightSizeAdvice is the advisor's verdict on a job's resource request.
+type RightSizeAdvice struct {
+	Image string        `json:"Image"`
+	Runs  int           `json:"Runs"`
+	Class WorkloadClass `json:"Class"`
+
+	// Requested is what the job asked for, Suggested what past runs
+	// of the image needed plus headroom.
+	Requested models.Resources `json:"Requested"`
+	Suggested models.Resources `json:"Suggested"`
+
+	OverProvisionedCPU    bool `json:"OverProvisionedCPU,omitempty"`
+	OverProvisionedMemory bool `json:"OverProvisionedMemory,omitempty"`
+
+	// Applied is set when the job's request was shrunk to Suggested.
+	Applied bool `json:"Applied,omitempty"`
+
+	// Messages explain each finding, e.g.
+	// "similar runs of this image used 1.2 CPU of the 8 requested".
+	Messages []string `json:"Messages,omitempty"`
+}
+
+// OverProvisioned reports whether any resource was over-requested.
+func (a *RightSizeAdvice) OverProvisioned() bool {
+	return a.OverProvisionedCPU || a.OverProvisionedMemory
+}
+
+// RightSizeAdvisor compares job resource requests with the usage of
+// previous runs of the same image.
+type RightSizeAdvisor struct {
+	history   *UsageHistory
+	minRuns   int
+	headroom  float64
+	threshold float64
+}
+
+// RightSizeOption configures the advisor.
+type RightSizeOption func(*RightSizeAdvisor)
+
+// NewRightSizeAdvisor creates an advisor backed by the given usage history.
+func NewRightSizeAdvisor(history *UsageHistory, opts ...RightSizeOption) *RightSizeAdvisor {
+	a := &RightSizeAdvisor{
+		history:   history,
+		minRuns:   DefaultRightSizeMinRuns,
+		headroom:  DefaultRightSizeHeadroom,
+		threshold: DefaultOverProvisionRatio,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithRightSizeMinRuns sets how many runs are needed before advising.
+func WithRightSizeMinRuns(n int) RightSizeOption {
+	return func(a *RightSizeAdvisor) {
+		a.minRuns = n
+	}
+}
+
+// WithRightSizeHeadroom sets the factor applied to peak usage.
+func WithRightSizeHeadroom(factor float64) RightSizeOption {
+	return func(a *RightSizeAdvisor) {
+		a.headroom = factor
+	}
+}
+
+// WithOverProvisionRatio sets the usage ratio below which a request
+// is flagged as over-provisioned.
+func WithOverProvisionRatio(ratio float64) RightSizeOption {
+	return func(a *RightSizeAdvisor) {
+		a.threshold = ratio
+	}
+}
+
+// History returns the usage history the advisor reads from.
+func (a *RightSizeAdvisor) History() *UsageHistory {
+	return a.history
+}
+
+// Advise checks the job's request against past runs of its image. It
+// returns nil if the job has no image, no explicit CPU or memory request,
+// or too little history.
+func (a *RightSizeAdvisor) Advise(job *models.Job) *RightSizeAdvice {
+	image := jobImage(job)
+	if image == "" {
+		return nil
+	}
+	task := job.Task()
+	if task.ResourcesConfig == nil {
+		return nil
+	}
+	requested, err := task.ResourcesConfig.Copy().ToResources()
+	if err != nil || (requested.CPU == 0 && requested.Memory == 0) {
+		return nil
+	}
+
+	profile, ok := a.history.Profile(image)
+	if !ok || profile.Runs < a.minRuns {
+		return nil
+	}
+
+	advice := &RightSizeAdvice{
+		Image:     image,
+		Runs:      profile.Runs,
+		Class:     profile.Class,
+		Requested: *requested,
+		Suggested: *requested,
+	}
+
+	if requested.CPU > 0 && profile.PeakCPU < requested.CPU*a.threshold {
+		suggested := math.Ceil(profile.PeakCPU*a.headroom/cpuStep) * cpuStep
+		suggested = math.Max(suggested, cpuStep)
+		if suggested < requested.CPU {
+			advice.OverProvisionedCPU = true
+			advice.Suggested.CPU = suggested
+			advice.Messages = append(advice.Messages, fmt.Sprintf(
+				"similar runs of this image used %.1f CPU of the %g requested", profile.PeakCPU, requested.CPU))
+		}
+	}
+
+	if requested.Memory > 0 && float64(profile.PeakMemory) < float64(requested.Memory)*a.threshold {
+		steps := math.Ceil(float64(profile.PeakMemory) * a.headroom / memoryStep)
+		suggested := uint64(math.Max(steps, 1)) * memoryStep
+		if suggested < requested.Memory {
+			advice.OverProvisionedMemory = true
+			advice.Suggested.Memory = suggested
+			advice.Messages = append(advice.Messages, fmt.Sprintf(
+				"similar runs of this image used %s of the %s memory requested",
+				humanize.IBytes(profile.PeakMemory), humanize.IBytes(requested.Memory)))
+		}
+	}
+
+	return advice
+}
+
+// Apply shrinks the job's resource request to the advice's suggestion.
+func (a *RightSizeAdvice) Apply(job *models.Job) {
+	task := job.Task()
+	if task.ResourcesConfig == nil || !a.OverProvisioned() {
+		return
+	}
+	if a.OverProvisionedCPU {
+		task.ResourcesConfig.CPU = strconv.FormatFloat(a.Suggested.CPU, 'f', -1, 64)
+	}
+	if a.OverProvisionedMemory {
+		task.ResourcesConfig.Memory = fmt.Sprintf("%dMiB", a.Suggested.Memory>>20)
+	}
+	a.Applied = true
+}
+
+// jobImage returns the container image of the job's task, if any.
+func jobImage(job *models.Job) string {
+	if job == nil || len(job.Tasks) == 0 {
+		return ""
+	}
+	task := job.Task()
+	if task.Engine == nil || task.Engine.Params == nil {
+		return ""
+	}
+	image, _ := task.Engine.Params["Image"].(string)
+	return image
+}
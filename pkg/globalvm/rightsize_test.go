@@ -0,0 +1,125 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordRuns(h *UsageHistory, image string, n int, requested, used models.Resources) {
+	for i := 0; i < n; i++ {
+		h.Record(UsageRecord{Image: image, Requested: requested, Used: used})
+	}
+}
+
+func TestUsageHistory_Profile(t *testing.T) {
+	h := NewUsageHistory(3)
+	_, ok := h.Profile("ubuntu:latest")
+	assert.False(t, ok)
+
+	requested := models.Resources{CPU: 8, Memory: 16 << 30}
+	recordRuns(h, "ubuntu:latest", 5, requested, models.Resources{CPU: 7, Memory: 2 << 30})
+
+	profile, ok := h.Profile("ubuntu:latest")
+	require.True(t, ok)
+	assert.Equal(t, 3, profile.Runs, "history keeps only the last runs")
+	assert.InDelta(t, 7.0, profile.PeakCPU, 0.001)
+	assert.Equal(t, uint64(2<<30), profile.PeakMemory)
+	assert.Equal(t, WorkloadClassCPUBound, profile.Class)
+}
+
+func TestClassifyWorkload(t *testing.T) {
+	tests := []struct {
+		cpu, mem float64
+		want     WorkloadClass
+	}{
+		{0, 0, WorkloadClassUnknown},
+		{0.1, 0.1, WorkloadClassIdle},
+		{0.9, 0.2, WorkloadClassCPUBound},
+		{0.3, 0.8, WorkloadClassMemoryBound},
+		{0.8, 0.8, WorkloadClassBalanced},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, classifyWorkload(tt.cpu, tt.mem), "cpu=%v mem=%v", tt.cpu, tt.mem)
+	}
+}
+
+func TestRightSizeAdvisor_Advise(t *testing.T) {
+	h := NewUsageHistory(0)
+	advisor := NewRightSizeAdvisor(h)
+	job := createTestJobWithResources("job-1", 4, 16<<30)
+
+	assert.Nil(t, advisor.Advise(job), "no history")
+
+	requested := models.Resources{CPU: 4, Memory: 16 << 30}
+	recordRuns(h, "ubuntu:latest", 2, requested, models.Resources{CPU: 1.2, Memory: 1 << 30})
+	assert.Nil(t, advisor.Advise(job), "too few runs")
+
+	recordRuns(h, "ubuntu:latest", 1, requested, models.Resources{CPU: 1.2, Memory: 1 << 30})
+	advice := advisor.Advise(job)
+	require.NotNil(t, advice)
+	assert.True(t, advice.OverProvisionedCPU)
+	assert.True(t, advice.OverProvisionedMemory)
+	assert.InDelta(t, 1.5, advice.Suggested.CPU, 0.001)
+	assert.Equal(t, uint64(1280<<20), advice.Suggested.Memory)
+	assert.Contains(t, advice.Messages, "similar runs of this image used 1.2 CPU of the 4 requested")
+
+	advice.Apply(job)
+	assert.True(t, advice.Applied)
+	applied, err := job.Task().ResourcesConfig.ToResources()
+	require.NoError(t, err)
+	assert.InDelta(t, 1.5, applied.CPU, 0.001)
+	assert.Equal(t, uint64(1280<<20), applied.Memory)
+}
+
+func TestRightSizeAdvisor_WellSized(t *testing.T) {
+	h := NewUsageHistory(0)
+	requested := models.Resources{CPU: 4, Memory: 16 << 30}
+	recordRuns(h, "ubuntu:latest", 3, requested, models.Resources{CPU: 3.5, Memory: 12 << 30})
+
+	advice := NewRightSizeAdvisor(h).Advise(createTestJobWithResources("job-1", 4, 16<<30))
+	require.NotNil(t, advice)
+	assert.False(t, advice.OverProvisioned())
+	assert.Empty(t, advice.Messages)
+}
+
+func TestEndpoint_SubmitJobRightSizing(t *testing.T) {
+	h := NewUsageHistory(0)
+	requested := models.Resources{CPU: 4, Memory: 16 << 30}
+	recordRuns(h, "ubuntu:latest", 3, requested, models.Resources{CPU: 1.2, Memory: 1 << 30})
+
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 40}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity,
+		WithRightSizeAdvisor(NewRightSizeAdvisor(h)))
+
+	t.Run("warns only", func(t *testing.T) {
+		job := createTestJobWithResources("job-1", 4, 16<<30)
+		resp, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{Job: job})
+		require.NoError(t, err)
+		require.NotNil(t, resp.RightSizing)
+		assert.False(t, resp.RightSizing.Applied)
+		assert.Contains(t, resp.Warnings, "Over-provisioned: similar runs of this image used 1.2 CPU of the 4 requested")
+		assert.Equal(t, "4", job.Task().ResourcesConfig.CPU)
+	})
+
+	t.Run("auto right-size", func(t *testing.T) {
+		job := createTestJobWithResources("job-2", 4, 16<<30)
+		resp, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+			Job:        job,
+			Scheduling: SchedulingOptions{AutoRightSize: true},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp.RightSizing)
+		assert.True(t, resp.RightSizing.Applied)
+		assert.Equal(t, "4", job.Task().ResourcesConfig.CPU, "caller's job is not modified")
+	})
+}
@@ -0,0 +1,101 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"sort"
+	"time"
+)
+
+// LabelNodeRetrievalEndpoint is the node label holding the URL clients
+// fetch a job's results from directly. Nodes without it serve results
+// through the orchestrator only.
+const LabelNodeRetrievalEndpoint = "retrieval.endpoint"
+
+// LabelJobOriginRegion is the job label naming the region the job was
+// submitted from, used when the request does not give OriginRegion.
+const LabelJobOriginRegion = "origin-region"
+
+// RetrievalHint tells a client where to fetch the results of one of the
+// job's nodes and how far that node is from the client.
+type RetrievalHint struct {
+	// NodeID is the allocated node.
+	NodeID string `json:"NodeID"`
+
+	// Region is the node's region.
+	Region string `json:"Region,omitempty"`
+
+	// Endpoint is the URL to fetch the node's results from. Empty when the
+	// node only serves results through the orchestrator.
+	Endpoint string `json:"Endpoint,omitempty"`
+
+	// RTT is the expected round trip time from the submitting origin.
+	// Zero when the origin is unknown.
+	RTT time.Duration `json:"RTT,omitempty"`
+
+	// Measured is set when RTT comes from the latency matrix rather than
+	// a region estimate.
+	Measured bool `json:"Measured,omitempty"`
+}
+
+// WithRetrievalLatencies sets the latency matrix used to estimate the
+// round trip from the submitting origin to each allocated node. Without
+// one, region estimates are used.
+func WithRetrievalLatencies(matrix LatencyMatrix) EndpointOption {
+	return func(e *Endpoint) {
+		e.retrievalLatencies = matrix
+	}
+}
+
+// requestOrigin returns the region a job was submitted from, if known.
+func requestOrigin(req GlobalJobRequest) string {
+	if req.OriginRegion != "" {
+		return req.OriginRegion
+	}
+	return req.Job.Labels[LabelJobOriginRegion]
+}
+
+// retrievalHints lists where the job's results can be fetched, closest to
+// origin first. A job with an aggregator delivers its results there, so
+// only the aggregator is listed. Standbys produce no results and are left out.
+func (e *Endpoint) retrievalHints(origin string, selections []NodeSelection) []RetrievalHint {
+	if aggregator, ok := Aggregator(selections); ok {
+		selections = []NodeSelection{aggregator}
+	}
+
+	hints := make([]RetrievalHint, 0, len(selections))
+	for _, sel := range selections {
+		if sel.Role == NodeRoleStandby {
+			continue
+		}
+		hint := RetrievalHint{NodeID: sel.NodeID, Region: sel.Region, Endpoint: sel.RetrievalEndpoint}
+		if origin != "" {
+			hint.RTT, hint.Measured = e.retrievalRTT(origin, sel)
+		}
+		hints = append(hints, hint)
+	}
+
+	// Nodes with an endpoint first, as those are the ones clients can use
+	sort.SliceStable(hints, func(i, j int) bool {
+		if (hints[i].Endpoint != "") != (hints[j].Endpoint != "") {
+			return hints[i].Endpoint != ""
+		}
+		return hints[i].RTT < hints[j].RTT
+	})
+	return hints
+}
+
+// retrievalRTT estimates the round trip from origin to a node. Measurements
+// to the node itself are preferred over measurements to its region.
+func (e *Endpoint) retrievalRTT(origin string, sel NodeSelection) (time.Duration, bool) {
+	if e.retrievalLatencies != nil {
+		measured := e.retrievalLatencies.GetAllLatencies(origin)
+		if rtt, ok := measured[sel.NodeID]; ok {
+			return rtt, true
+		}
+		if rtt, ok := measured[sel.Region]; ok {
+			return rtt, true
+		}
+	}
+	return EstimatedLatency(origin, sel.Region), false
+}
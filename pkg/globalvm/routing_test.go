@@ -0,0 +1,109 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func retrievalNodeInfo(id, region, endpoint string) models.NodeInfo {
+	info := createTestNodeInfo(id, region)
+	if endpoint != "" {
+		info.Labels[LabelNodeRetrievalEndpoint] = endpoint
+	}
+	return info
+}
+
+func newRetrievalEndpoint(opts ...EndpointOption) *Endpoint {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: retrievalNodeInfo("node-us", "us-east", "https://us.example.com"), Rank: 30},
+		{NodeInfo: retrievalNodeInfo("node-eu", "eu-west", "https://eu.example.com"), Rank: 20},
+		{NodeInfo: retrievalNodeInfo("node-asia", "asia-east", ""), Rank: 10},
+	}}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 100, AvailableMemory: 1024 << 30, HealthyNodes: 3}}
+	return NewEndpoint(NewScheduler(selector, capacity), capacity, opts...)
+}
+
+func hintNodeIDs(hints []RetrievalHint) []string {
+	ids := make([]string, len(hints))
+	for i, hint := range hints {
+		ids[i] = hint.NodeID
+	}
+	return ids
+}
+
+func TestEndpoint_RetrievalHints(t *testing.T) {
+	endpoint := newRetrievalEndpoint()
+
+	resp, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:          createTestJob("job-1", models.JobTypeBatch, 3),
+		OriginRegion: "eu-west",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.RetrievalHints, 3)
+
+	// Closest endpoint first; the node without an endpoint comes last
+	assert.Equal(t, []string{"node-eu", "node-us", "node-asia"}, hintNodeIDs(resp.RetrievalHints))
+	closest := resp.RetrievalHints[0]
+	assert.Equal(t, "https://eu.example.com", closest.Endpoint)
+	assert.Equal(t, EstimatedLatency("eu-west", "eu-west"), closest.RTT)
+	assert.False(t, closest.Measured)
+	assert.Equal(t, EstimatedLatency("eu-west", "us-east"), resp.RetrievalHints[1].RTT)
+	assert.Empty(t, resp.RetrievalHints[2].Endpoint)
+
+	for _, sel := range resp.AllocatedNodes {
+		if sel.NodeID == "node-us" {
+			assert.Equal(t, "https://us.example.com", sel.RetrievalEndpoint)
+		}
+	}
+}
+
+func TestEndpoint_RetrievalHints_LatencyMatrix(t *testing.T) {
+	matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+	matrix.UpdateLatency("ap-south", "node-us", 40*time.Millisecond)
+	matrix.UpdateLatency("ap-south", "eu-west", 90*time.Millisecond)
+	endpoint := newRetrievalEndpoint(WithRetrievalLatencies(matrix))
+
+	// The origin comes from the job's label when the request has none
+	job := createTestJob("job-1", models.JobTypeBatch, 3)
+	job.Labels = map[string]string{LabelJobOriginRegion: "ap-south"}
+	resp, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{Job: job})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"node-us", "node-eu", "node-asia"}, hintNodeIDs(resp.RetrievalHints))
+	assert.Equal(t, 40*time.Millisecond, resp.RetrievalHints[0].RTT)
+	assert.True(t, resp.RetrievalHints[0].Measured)
+	assert.Equal(t, 90*time.Millisecond, resp.RetrievalHints[1].RTT)
+	assert.True(t, resp.RetrievalHints[1].Measured)
+	assert.False(t, resp.RetrievalHints[2].Measured)
+}
+
+func TestEndpoint_RetrievalHints_UnknownOrigin(t *testing.T) {
+	resp, err := newRetrievalEndpoint().SubmitJob(context.Background(), GlobalJobRequest{
+		Job: createTestJob("job-1", models.JobTypeBatch, 3),
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.RetrievalHints, 3)
+	for _, hint := range resp.RetrievalHints {
+		assert.Zero(t, hint.RTT, hint.NodeID)
+	}
+	assert.Equal(t, "node-asia", resp.RetrievalHints[2].NodeID)
+}
+
+func TestEndpoint_RetrievalHints_Aggregator(t *testing.T) {
+	endpoint := &Endpoint{}
+	hints := endpoint.retrievalHints("us-east", []NodeSelection{
+		{NodeID: "worker-1", Region: "us-east", RetrievalEndpoint: "https://w1.example.com"},
+		{NodeID: "agg", Region: "eu-west", Role: NodeRoleAggregator, RetrievalEndpoint: "https://agg.example.com"},
+	})
+	require.Len(t, hints, 1)
+	assert.Equal(t, "agg", hints[0].NodeID)
+	assert.Equal(t, EstimatedLatency("us-east", "eu-west"), hints[0].RTT)
+}
@@ -11,7 +11,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/models"
 	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
 	"github.com/bacalhau-project/bacalhau/pkg/orchestrator/nodes"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // GlobalSchedulingRequest contains all information needed for global scheduling.
@@ -30,6 +30,19 @@ type GlobalSchedulingRequest struct {
 
 	// ExistingExecutions are nodes already running this job (for scaling).
 	ExistingExecutions []string `json:"ExistingExecutions,omitempty"`
+
+	// LeaseCapacity requests an allocation lease on every selected node,
+	// when the capacity provider grants them, and commits the GPU time
+	// slices of a time-sliced job. The caller must confirm or release the
+	// leases once the job is dispatched or abandoned.
+	LeaseCapacity bool `json:"LeaseCapacity,omitempty"`
+
+	// TenantID identifies the tenant whose reservations the job may use.
+	TenantID string `json:"TenantID,omitempty"`
+
+	// OriginRegion is where the job's requests or data come from, for
+	// placement profiles that favour proximity to it.
+	OriginRegion string `json:"OriginRegion,omitempty"`
 }
 
 // NodeSelection represents a selected node for job execution.
@@ -54,6 +67,79 @@ type NodeSelection struct {
 
 	// Cost is the relative cost of using this node.
 	Cost float64 `json:"Cost,omitempty"`
+
+	// LeaseID identifies the allocation lease held on this node, if any.
+	LeaseID string `json:"LeaseID,omitempty"`
+
+	// Role is set when the job distinguishes node roles, such as the
+	// aggregation node of a map-reduce job.
+	Role NodeRole `json:"Role,omitempty"`
+
+	// Score breaks Rank down into its components, which add up to it.
+	Score []ScoreComponent `json:"Score,omitempty"`
+
+	// Reasons are the machine-readable reasons the node was selected.
+	Reasons []ReasonCode `json:"Reasons,omitempty"`
+
+	// MatchedConstraints are the job's constraints the node satisfies.
+	MatchedConstraints []ConstraintKind `json:"MatchedConstraints,omitempty"`
+
+	// TimeSlice is the GPU a time-sliced job shares on this node.
+	TimeSlice *TimeSliceAssignment `json:"TimeSlice,omitempty"`
+
+	// Egress is the egress policy the node must enforce for the job.
+	Egress EgressPolicy `json:"Egress,omitempty"`
+
+	// RetrievalEndpoint is the URL the node serves job results from,
+	// taken from its LabelNodeRetrievalEndpoint label.
+	RetrievalEndpoint string `json:"RetrievalEndpoint,omitempty"`
+}
+
+// TimeoutPolicy decides what happens when a scheduling deadline expires.
+type TimeoutPolicy string
+
+const (
+	// TimeoutPolicyPartial returns the nodes selected before the deadline.
+	TimeoutPolicyPartial TimeoutPolicy = "partial"
+
+	// TimeoutPolicyFail returns a SchedulingTimeoutError.
+	TimeoutPolicyFail TimeoutPolicy = "fail"
+)
+
+// SchedulingResult is the outcome of a scheduling pass.
+type SchedulingResult struct {
+	// Selections are the selected nodes, best first.
+	Selections []NodeSelection `json:"Selections"`
+
+	// Partial is set when the scheduling deadline expired before the
+	// selection completed, so Selections may hold fewer nodes than requested.
+	Partial bool `json:"Partial,omitempty"`
+
+	// Snapshot is the cluster state the pass saw, kept when the scheduler
+	// has decision snapshots enabled.
+	Snapshot *SchedulingSnapshot `json:"Snapshot,omitempty"`
+
+	// Rejections are the nodes the pass dropped and why.
+	Rejections []NodeRejection `json:"Rejections,omitempty"`
+}
+
+// SchedulingTimeoutError is returned when the scheduling deadline expires
+// and the timeout policy does not accept partial results.
+type SchedulingTimeoutError struct {
+	JobID   string
+	Timeout time.Duration
+
+	// Selected is the number of nodes selected before the deadline.
+	Selected int
+}
+
+func (e *SchedulingTimeoutError) Error() string {
+	return fmt.Sprintf("scheduling job %s timed out after %s with %d nodes selected", e.JobID, e.Timeout, e.Selected)
+}
+
+// Unwrap allows errors.Is(err, context.DeadlineExceeded).
+func (e *SchedulingTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
 }
 
 // GlobalScheduler provides intelligent scheduling across the global compute network.
@@ -62,6 +148,10 @@ type GlobalScheduler interface {
 	// SelectNodes selects the best nodes for a job based on global scheduling rules.
 	SelectNodes(ctx context.Context, req GlobalSchedulingRequest) ([]NodeSelection, error)
 
+	// Schedule selects nodes like SelectNodes, within the request's scheduling
+	// deadline. It reports whether the result is partial because the deadline expired.
+	Schedule(ctx context.Context, req GlobalSchedulingRequest) (*SchedulingResult, error)
+
 	// GetBestNodeForJob returns a single best node for a job.
 	// This is useful for single-node jobs or picking a leader.
 	GetBestNodeForJob(ctx context.Context, job *models.Job) (*NodeSelection, error)
@@ -80,6 +170,16 @@ type Scheduler struct {
 	nodeLookup         nodes.Lookup
 	regionRanker       *RegionRanker
 	costCalculator     CostCalculator
+	timeout            time.Duration
+	timeoutPolicy      TimeoutPolicy
+	latencyMatrix      LatencyMatrix
+	tenantLatencies    *TenantLatencyOverrides
+	reservations       *ReplicatedState
+	profitability      *ProfitabilityRanker
+	timeSlicer         *GPUTimeSlicer
+	snapshots          bool
+	profiles           map[string]PlacementProfile
+	gpuVendors         map[models.GPUVendor]GPUVendorPlugin
 }
 
 // SchedulerOption configures the scheduler.
@@ -96,6 +196,9 @@ func NewScheduler(
 		capacityProvider: capacityProvider,
 		regionRanker:     NewRegionRanker(),
 		costCalculator:   &DefaultCostCalculator{},
+		timeoutPolicy:    TimeoutPolicyPartial,
+		profiles:         builtinProfiles(),
+		gpuVendors:       builtinGPUVendorPlugins(),
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -131,38 +234,235 @@ func WithCostCalculator(calc CostCalculator) SchedulerOption {
 	}
 }
 
+// WithSchedulingTimeout sets the default scheduling deadline for requests
+// that do not set their own. Zero means no deadline.
+func WithSchedulingTimeout(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.timeout = d
+	}
+}
+
+// WithTimeoutPolicy sets the default policy applied when a scheduling deadline expires.
+func WithTimeoutPolicy(policy TimeoutPolicy) SchedulerOption {
+	return func(s *Scheduler) {
+		s.timeoutPolicy = policy
+	}
+}
+
 // SelectNodes selects the best nodes for a job based on global scheduling rules.
 func (s *Scheduler) SelectNodes(ctx context.Context, req GlobalSchedulingRequest) ([]NodeSelection, error) {
-	log.Ctx(ctx).Debug().
+	result, err := s.Schedule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return result.Selections, nil
+}
+
+// Schedule selects nodes within the request's scheduling deadline. When the
+// deadline expires, the timeout policy either returns the nodes selected so
+// far as a partial result or fails with a SchedulingTimeoutError.
+func (s *Scheduler) Schedule(ctx context.Context, req GlobalSchedulingRequest) (*SchedulingResult, error) {
+	ctx, _ = WithSchedulingID(ctx, "")
+	ctx, trace := withSelectionTrace(ctx)
+
+	var snapshot *SchedulingSnapshot
+	if s.snapshots {
+		ctx, snapshot = withSnapshot(ctx, req)
+	}
+	result, err := s.schedule(ctx, req)
+	if result != nil {
+		result.Snapshot = snapshot
+		result.Rejections = trace.rejected()
+		trace.annotate(result.Selections)
+	}
+	return result, err
+}
+
+// schedule runs a scheduling pass under the deadline and timeout policy.
+func (s *Scheduler) schedule(ctx context.Context, req GlobalSchedulingRequest) (*SchedulingResult, error) {
+	timeout := req.Scheduling.Timeout
+	if timeout == 0 {
+		timeout = s.timeout
+	}
+	if timeout <= 0 {
+		selections, err := s.selectNodes(ctx, req)
+		if err != nil {
+			s.abandonSelection(ctx, req, selections)
+			return nil, err
+		}
+		return &SchedulingResult{Selections: selections}, nil
+	}
+
+	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	selections, err := s.selectNodes(deadlineCtx, req)
+	if err == nil {
+		return &SchedulingResult{Selections: selections}, nil
+	}
+
+	// Only our own deadline yields a partial result
+	if deadlineCtx.Err() != context.DeadlineExceeded || ctx.Err() != nil {
+		s.abandonSelection(ctx, req, selections)
+		return nil, err
+	}
+
+	policy := req.Scheduling.TimeoutPolicy
+	if policy == "" {
+		policy = s.timeoutPolicy
+	}
+	schedulingTimeouts.Add(ctx, 1, metric.WithAttributes(AttrPolicyKey.String(string(policy))))
+
+	componentLogger(ctx, ComponentScheduler).Warn().
+		Str("jobID", req.Job.ID).
+		Dur("timeout", timeout).
+		Int("selected", len(selections)).
+		Str("policy", string(policy)).
+		Msg("Scheduling deadline expired")
+
+	if policy == TimeoutPolicyFail {
+		s.abandonSelection(ctx, req, selections)
+		return nil, &SchedulingTimeoutError{JobID: req.Job.ID, Timeout: timeout, Selected: len(selections)}
+	}
+	return &SchedulingResult{Selections: selections, Partial: true}, nil
+}
+
+// selectNodes runs a scheduling pass. If ctx expires part way, it returns
+// the nodes selected so far along with the context error.
+func (s *Scheduler) selectNodes(ctx context.Context, req GlobalSchedulingRequest) ([]NodeSelection, error) {
+	componentLogger(ctx, ComponentScheduler).Debug().
 		Str("jobID", req.Job.ID).
 		Int("targetCount", req.TargetCount).
 		Msg("Selecting nodes for global scheduling")
 
+	constraint, err := ParseConstraint(req.Scheduling.Constraint)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEgress(req); err != nil {
+		return nil, err
+	}
+	egress := jobEgress(req)
+	profile, err := s.placementProfile(req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get ranked nodes from the existing selector
-	matched, rejected, err := s.nodeSelector.MatchingNodes(ctx, req.Job)
+	matched, rejected, err := s.matchingNodes(ctx, req.Job)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get matching nodes: %w", err)
 	}
+	recordSnapshot(ctx, matched, rejected)
+	for _, rank := range rejected {
+		traceFrom(ctx).reject(rank.NodeInfo.ID(), ReasonSelectorRejected, rank.Reason)
+	}
+
+	// Drop nodes that do not satisfy the constraint expression
+	matched = s.applyConstraint(ctx, matched, constraint)
+
+	// Drop nodes whose GPUs miss the job's vendor-specific requirements
+	matched, err = s.applyGPUVendors(ctx, req.Job, matched)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep jobs that need the internet off nodes that deny egress
+	matched = s.applyEgress(ctx, req, egress, matched)
+
+	// Keep low-paying jobs off nodes in a peak energy tariff
+	if s.profitability != nil {
+		matched, err = s.applyProfitability(ctx, req.Job, matched)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep time-sliced jobs off GPUs without room for their duty cycle
+	var nodeGPUs map[string]int
+	if req.Scheduling.GPUTimeSlice != nil {
+		matched, nodeGPUs, err = s.applyTimeSlicing(ctx, req, matched)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Log rejected nodes for debugging
 	if len(rejected) > 0 {
-		log.Ctx(ctx).Debug().
+		componentLogger(ctx, ComponentScheduler).Debug().
 			Int("rejected", len(rejected)).
 			Msg("Nodes rejected by selector")
 	}
 
 	// Convert to selections
-	selections := s.convertToSelections(ctx, matched)
+	selections := s.convertToSelections(ctx, matched, egress)
 
 	// Apply global scheduling optimizations
 	selections = s.applyGlobalOptimizations(ctx, req, selections)
 
-	// Limit to target count
-	if req.TargetCount > 0 && len(selections) > req.TargetCount {
-		selections = selections[:req.TargetCount]
+	// Lease capacity so concurrent passes cannot pick the same nodes
+	var leaser CapacityLeaser
+	if l, ok := s.capacityProvider.(CapacityLeaser); ok && req.LeaseCapacity {
+		leaser = l
+	}
+
+	// Let the workload's profile filter and re-rank the candidates
+	var pc *PlacementContext
+	if profile != nil {
+		pc = newPlacementContext(s, req, leaser, matched)
+		selections = s.applyProfile(ctx, profile, pc, selections)
+	}
+
+	// Nothing is allocated before this point, so a cancelled pass stops
+	// here rather than leasing for a request nobody waits for
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var workers []NodeSelection
+	if leaser != nil {
+		workers, err = s.leaseSelections(ctx, leaser, req, selections)
+		if err != nil {
+			return workers, err
+		}
+	} else {
+		// Limit to target count
+		workers = selections
+		if req.TargetCount > 0 && len(workers) > req.TargetCount {
+			workers = workers[:req.TargetCount]
+		}
+	}
+
+	// Time slices are committed along with leases, so dry runs leave none
+	if req.Scheduling.GPUTimeSlice != nil && req.LeaseCapacity {
+		workers = s.commitTimeSlices(ctx, req, workers, nodeGPUs)
+	}
+
+	// From here on the workers hold leases, which the caller releases
+	// when the pass fails
+	if err := ctx.Err(); err != nil {
+		return workers, err
+	}
+
+	// Put the best-ranked worker in charge of the others
+	if req.Scheduling.Coordinator && len(workers) > 1 {
+		workers = assignCoordinator(workers)
+	}
+
+	// Add the nodes the profile wants besides the workers, such as standbys
+	if profile != nil && len(workers) > 0 {
+		workers, err = profile.Complete(ctx, pc, workers, unselected(selections, workers))
+		if err != nil {
+			return workers, err
+		}
 	}
 
-	return selections, nil
+	// Place the aggregation node close to the workers' results
+	if req.Scheduling.Aggregation != nil && len(workers) > 0 {
+		return s.selectAggregator(ctx, req, leaser, workers, selections)
+	}
+
+	return workers, nil
 }
 
 // GetBestNodeForJob returns a single best node for a job.
@@ -209,9 +509,131 @@ func (s *Scheduler) GetNodesByRegion(ctx context.Context, job *models.Job) (map[
 	return regions, nil
 }
 
-// convertToSelections converts orchestrator node ranks to global node selections.
-func (s *Scheduler) convertToSelections(ctx context.Context, ranks []orchestrator.NodeRank) []NodeSelection {
+// matchingNodes queries the node selector, giving up when ctx is done
+// even if the selector does not honour cancellation.
+func (s *Scheduler) matchingNodes(ctx context.Context, job *models.Job) (matched, rejected []orchestrator.NodeRank, err error) {
+	type result struct {
+		matched, rejected []orchestrator.NodeRank
+		err               error
+	}
+	done := make(chan result, 1)
+	go func() {
+		matched, rejected, err := s.nodeSelector.MatchingNodes(ctx, job)
+		done <- result{matched, rejected, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.matched, res.rejected, res.err
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// releaseLeases releases the leases held by selections that will not be used.
+func releaseLeases(ctx context.Context, provider GlobalCapacityProvider, selections []NodeSelection) {
+	leaser, ok := provider.(CapacityLeaser)
+	if !ok {
+		return
+	}
+	for _, sel := range selections {
+		if sel.LeaseID == "" {
+			continue
+		}
+		if err := leaser.ReleaseLease(ctx, sel.LeaseID); err != nil {
+			componentLogger(ctx, ComponentScheduler).Warn().Err(err).Str("leaseID", sel.LeaseID).Msg("Failed to release lease")
+		}
+	}
+}
+
+// leaseSelections acquires a lease on each selected node in rank order until
+// the target count is reached. Nodes whose capacity is already leased are skipped.
+func (s *Scheduler) leaseSelections(
+	ctx context.Context, leaser CapacityLeaser, req GlobalSchedulingRequest, selections []NodeSelection,
+) ([]NodeSelection, error) {
+	resources, err := jobResources(req.Job)
+	if err != nil {
+		return nil, err
+	}
+
+	leased := make([]NodeSelection, 0, len(selections))
+	for _, sel := range selections {
+		if req.TargetCount > 0 && len(leased) >= req.TargetCount {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return leased, err
+		}
+
+		lease, err := leaser.AcquireLease(ctx, req.Job.ID, sel.NodeID, resources)
+		if err != nil {
+			if ctx.Err() != nil {
+				return leased, ctx.Err()
+			}
+			componentLogger(ctx, ComponentScheduler).Debug().
+				Err(err).
+				Str("jobID", req.Job.ID).
+				Str("nodeID", sel.NodeID).
+				Msg("Skipping node, lease not granted")
+			traceFrom(ctx).reject(sel.NodeID, ReasonLeaseDenied, err.Error())
+			continue
+		}
+
+		sel.LeaseID = lease.ID
+		leased = append(leased, sel)
+	}
+
+	return leased, nil
+}
+
+// jobResources returns the resources requested by the job's task.
+func jobResources(job *models.Job) (models.Resources, error) {
+	resources := models.Resources{}
+	if task := job.Task(); task != nil && task.ResourcesConfig != nil {
+		parsed, err := task.ResourcesConfig.ToResources()
+		if err != nil {
+			return resources, fmt.Errorf("failed to parse job resources: %w", err)
+		}
+		resources = *parsed
+	}
+	return resources, nil
+}
+
+// applyConstraint filters out nodes that do not satisfy the constraint.
+func (s *Scheduler) applyConstraint(
+	ctx context.Context, ranks []orchestrator.NodeRank, constraint *Constraint,
+) []orchestrator.NodeRank {
+	if constraint.String() == "" {
+		return ranks
+	}
+
+	trace := traceFrom(ctx)
+	filtered := make([]orchestrator.NodeRank, 0, len(ranks))
+	for _, rank := range ranks {
+		if constraint.Matches(rank.NodeInfo) {
+			filtered = append(filtered, rank)
+		} else {
+			trace.reject(rank.NodeInfo.ID(), ReasonConstraintMismatch, constraint.String())
+		}
+	}
+	trace.match(ConstraintExpression)
+
+	componentLogger(ctx, ComponentScheduler).Debug().
+		Str("constraint", constraint.String()).
+		Int("matched", len(filtered)).
+		Int("rejected", len(ranks)-len(filtered)).
+		Msg("Applied node constraint")
+
+	return filtered
+}
+
+// convertToSelections converts orchestrator node ranks to global node
+// selections, each recording the egress policy the node must enforce. A
+// selection's score starts with the ranker's part of its rank, followed
+// by the adjustments the filters before it traced.
+func (s *Scheduler) convertToSelections(ctx context.Context, ranks []orchestrator.NodeRank, egress EgressPolicy) []NodeSelection {
 	selections := make([]NodeSelection, 0, len(ranks))
+	trace := traceFrom(ctx)
 
 	for _, rank := range ranks {
 		selection := NodeSelection{
@@ -220,11 +642,27 @@ func (s *Scheduler) convertToSelections(ctx context.Context, ranks []orchestrato
 			Reason:     rank.Reason,
 			Region:     s.extractRegion(rank.NodeInfo),
 			Resources:  rank.NodeInfo.ComputeNodeInfo.AvailableCapacity,
+			Egress:     egress,
+		}
+
+		// Split the rank into the ranker's score and the adjustments
+		adjustments := trace.scoresOf(selection.NodeID)
+		base := rank.Rank
+		for _, adj := range adjustments {
+			base -= adj.Points
+		}
+		selection.Score = append([]ScoreComponent{{Reason: ReasonRanked, Points: base}}, adjustments...)
+		selection.Reasons = []ReasonCode{ReasonRanked}
+		for _, adj := range adjustments {
+			selection.addReason(adj.Reason)
 		}
 
 		// Calculate cost
 		selection.Cost = s.costCalculator.CalculateCost(rank.NodeInfo)
 
+		// Tell clients where to fetch results from
+		selection.RetrievalEndpoint = rank.NodeInfo.Labels[LabelNodeRetrievalEndpoint]
+
 		selections = append(selections, selection)
 	}
 
@@ -244,7 +682,10 @@ func (s *Scheduler) applyGlobalOptimizations(ctx context.Context, req GlobalSche
 
 	// Apply latency constraints
 	if req.Scheduling.MaxLatency > 0 {
-		selections = s.applyLatencyConstraints(selections, req.Scheduling.MaxLatency)
+		filtered := s.applyLatencyConstraints(selections, req.Scheduling.MaxLatency)
+		rejectDroppedSelections(ctx, ReasonLatencyExceeded, req.Scheduling.MaxLatency.String(), selections, filtered)
+		traceFrom(ctx).match(ConstraintMaxLatency)
+		selections = filtered
 	}
 
 	// Apply cost preference
@@ -252,14 +693,29 @@ func (s *Scheduler) applyGlobalOptimizations(ctx context.Context, req GlobalSche
 		selections = s.applyCostPreference(selections)
 	}
 
+	// Keep pipeline stages close to their upstream intermediates
+	if len(req.Scheduling.Lineage) > 0 {
+		selections = s.applyStageLocality(req.TenantID, selections, req.Scheduling.Lineage)
+	}
+
 	// Apply multi-region spread
 	if req.Scheduling.SpreadAcrossRegions > 1 {
-		selections = s.applyRegionSpread(selections, req.Scheduling.SpreadAcrossRegions)
+		spread := s.applyRegionSpread(selections, req.Scheduling.SpreadAcrossRegions, req.Scheduling.SpreadLevel)
+		rejectDroppedSelections(ctx, ReasonRegionSpread, "", selections, spread)
+		selections = spread
+	}
+
+	// Prefer capacity the tenant has reserved or bought
+	if s.reservations != nil && req.TenantID != "" {
+		selections = s.applyReservations(req, selections)
 	}
 
 	// Apply exclusions
 	if len(req.Scheduling.ExcludeNodeIDs) > 0 {
-		selections = s.applyExclusions(selections, req.Scheduling.ExcludeNodeIDs)
+		filtered := s.applyExclusions(selections, req.Scheduling.ExcludeNodeIDs)
+		rejectDroppedSelections(ctx, ReasonExcluded, "", selections, filtered)
+		traceFrom(ctx).match(ConstraintExclusions)
+		selections = filtered
 	}
 
 	// Sort by final rank
@@ -270,16 +726,29 @@ func (s *Scheduler) applyGlobalOptimizations(ctx context.Context, req GlobalSche
 	return selections
 }
 
-// applyPreferredRegions boosts ranking for preferred regions.
+// applyPreferredRegions boosts ranking for preferred regions, or for
+// regions inside preferred areas of the region hierarchy.
 func (s *Scheduler) applyPreferredRegions(selections []NodeSelection, preferred []string) []NodeSelection {
 	preferredSet := make(map[string]bool)
 	for _, r := range preferred {
 		preferredSet[r] = true
 	}
+	hierarchy := regions()
+	isPreferred := func(region string) bool {
+		if preferredSet[region] {
+			return true
+		}
+		for _, area := range preferred {
+			if hierarchy.Within(region, area) {
+				return true
+			}
+		}
+		return false
+	}
 
 	for i := range selections {
-		if preferredSet[selections[i].Region] {
-			selections[i].Rank += 100 // Boost preferred regions
+		if isPreferred(selections[i].Region) {
+			selections[i].addScore(ReasonPreferredRegion, 100) // Boost preferred regions
 			selections[i].Reason = "preferred region: " + selections[i].Region
 		}
 	}
@@ -315,18 +784,21 @@ func (s *Scheduler) applyCostPreference(selections []NodeSelection) []NodeSelect
 
 	// Give higher rank to lower cost nodes
 	for i := range selections {
-		selections[i].Rank += (len(selections) - i) * 10
+		selections[i].addScore(ReasonLowCost, (len(selections)-i)*10)
 	}
 
 	return selections
 }
 
-// applyRegionSpread ensures distribution across regions.
-func (s *Scheduler) applyRegionSpread(selections []NodeSelection, targetRegions int) []NodeSelection {
+// applyRegionSpread ensures distribution across regions, or across the
+// areas at level of the region hierarchy.
+func (s *Scheduler) applyRegionSpread(selections []NodeSelection, targetRegions int, level RegionLevel) []NodeSelection {
 	// Group by region
+	hierarchy := regions()
 	regions := make(map[string][]NodeSelection)
 	for _, sel := range selections {
-		regions[sel.Region] = append(regions[sel.Region], sel)
+		key := hierarchy.Key(sel.Region, level)
+		regions[key] = append(regions[key], sel)
 	}
 
 	// If we have enough regions, take one from each
@@ -366,6 +838,11 @@ func (s *Scheduler) applyExclusions(selections []NodeSelection, excludeIDs []str
 
 // extractRegion extracts region information from node info.
 func (s *Scheduler) extractRegion(info models.NodeInfo) string {
+	return nodeRegion(info)
+}
+
+// nodeRegion returns the region a node is labelled with.
+func nodeRegion(info models.NodeInfo) string {
 	// Try to get region from node labels
 	if info.Labels != nil {
 		if region, ok := info.Labels["region"]; ok {
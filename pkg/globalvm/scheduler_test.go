@@ -4,6 +4,7 @@ package globalvm
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -256,7 +257,7 @@ func TestScheduler_ApplyRegionSpread(t *testing.T) {
 	}
 
 	// Request 2 regions spread
-	result := scheduler.applyRegionSpread(selections, 2)
+	result := scheduler.applyRegionSpread(selections, 2, "")
 
 	// Should have 2 nodes from different regions
 	assert.Len(t, result, 2)
@@ -267,7 +268,7 @@ func TestScheduler_ApplyRegionSpread(t *testing.T) {
 	assert.Len(t, regions, 2)
 
 	// Request 3 regions spread
-	result = scheduler.applyRegionSpread(selections, 3)
+	result = scheduler.applyRegionSpread(selections, 3, "")
 	assert.Len(t, result, 3)
 }
 
@@ -469,3 +470,118 @@ func TestScheduler_Integration(t *testing.T) {
 		assert.NotEqual(t, "node-4", sel.NodeID)
 	}
 }
+
+// stalledNodeSelector blocks MatchingNodes without honouring cancellation.
+type stalledNodeSelector struct {
+	mockNodeSelector
+	delay time.Duration
+}
+
+func (m *stalledNodeSelector) MatchingNodes(ctx context.Context, job *models.Job) (matched, rejected []orchestrator.NodeRank, err error) {
+	time.Sleep(m.delay)
+	return m.mockNodeSelector.MatchingNodes(ctx, job)
+}
+
+// slowLeaser delays every lease it grants.
+type slowLeaser struct {
+	*CapacityAggregator
+	delay time.Duration
+}
+
+func (l *slowLeaser) AcquireLease(ctx context.Context, jobID, nodeID string, resources models.Resources) (*SchedulingLease, error) {
+	time.Sleep(l.delay)
+	return l.CapacityAggregator.AcquireLease(ctx, jobID, nodeID, resources)
+}
+
+func TestScheduler_ScheduleTimeout(t *testing.T) {
+	job := createTestJob("slow-job", models.JobTypeBatch, 1)
+	selector := &stalledNodeSelector{
+		mockNodeSelector: mockNodeSelector{nodes: []orchestrator.NodeRank{
+			{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 10},
+		}},
+		delay: time.Second,
+	}
+
+	t.Run("partial result on stalled lookup", func(t *testing.T) {
+		scheduler := NewScheduler(selector, &mockCapacityProvider{}, WithSchedulingTimeout(20*time.Millisecond))
+
+		start := time.Now()
+		result, err := scheduler.Schedule(context.Background(), GlobalSchedulingRequest{Job: job, TargetCount: 1})
+		require.NoError(t, err)
+		assert.True(t, result.Partial)
+		assert.Empty(t, result.Selections)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("typed error with fail policy", func(t *testing.T) {
+		scheduler := NewScheduler(selector, &mockCapacityProvider{})
+
+		_, err := scheduler.Schedule(context.Background(), GlobalSchedulingRequest{
+			Job:         job,
+			TargetCount: 1,
+			Scheduling:  SchedulingOptions{Timeout: 20 * time.Millisecond, TimeoutPolicy: TimeoutPolicyFail},
+		})
+		var timeoutErr *SchedulingTimeoutError
+		require.True(t, errors.As(err, &timeoutErr), "expected SchedulingTimeoutError, got %v", err)
+		assert.Equal(t, "slow-job", timeoutErr.JobID)
+		assert.Equal(t, 20*time.Millisecond, timeoutErr.Timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("request timeout overrides default", func(t *testing.T) {
+		fast := &stalledNodeSelector{mockNodeSelector: selector.mockNodeSelector, delay: 10 * time.Millisecond}
+		scheduler := NewScheduler(fast, &mockCapacityProvider{}, WithSchedulingTimeout(time.Millisecond))
+
+		result, err := scheduler.Schedule(context.Background(), GlobalSchedulingRequest{
+			Job:         job,
+			TargetCount: 1,
+			Scheduling:  SchedulingOptions{Timeout: time.Second},
+		})
+		require.NoError(t, err)
+		assert.False(t, result.Partial)
+		assert.Len(t, result.Selections, 1)
+	})
+
+	t.Run("parent cancellation is not a timeout", func(t *testing.T) {
+		scheduler := NewScheduler(selector, &mockCapacityProvider{}, WithSchedulingTimeout(time.Second))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := scheduler.Schedule(ctx, GlobalSchedulingRequest{Job: job, TargetCount: 1})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestScheduler_ScheduleTimeoutWithLeases(t *testing.T) {
+	job := createTestJob("lease-job", models.JobTypeBatch, 3)
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-1", "us-east"), Rank: 30},
+		{NodeInfo: createTestNodeInfo("node-2", "us-east"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("node-3", "us-east"), Rank: 10},
+	}}
+	req := GlobalSchedulingRequest{Job: job, TargetCount: 3, LeaseCapacity: true}
+
+	t.Run("partial keeps leases", func(t *testing.T) {
+		leaser := &slowLeaser{CapacityAggregator: newLeaseTestAggregator(), delay: 40 * time.Millisecond}
+		scheduler := NewScheduler(selector, leaser, WithSchedulingTimeout(60*time.Millisecond))
+
+		result, err := scheduler.Schedule(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, result.Partial)
+		assert.NotEmpty(t, result.Selections)
+		assert.Less(t, len(result.Selections), 3)
+		assert.Len(t, leaser.ActiveLeases(context.Background()), len(result.Selections))
+	})
+
+	t.Run("fail releases leases", func(t *testing.T) {
+		leaser := &slowLeaser{CapacityAggregator: newLeaseTestAggregator(), delay: 40 * time.Millisecond}
+		scheduler := NewScheduler(selector, leaser,
+			WithSchedulingTimeout(60*time.Millisecond), WithTimeoutPolicy(TimeoutPolicyFail))
+
+		_, err := scheduler.Schedule(context.Background(), req)
+		var timeoutErr *SchedulingTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Positive(t, timeoutErr.Selected)
+		assert.Empty(t, leaser.ActiveLeases(context.Background()))
+	})
+}
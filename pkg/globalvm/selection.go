@@ -0,0 +1,275 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"sync"
+)
+
+// ReasonCode is a machine-readable reason a node was selected, scored or
+// rejected. NodeSelection.Reason carries the human-readable account.
+type ReasonCode string
+
+// Reasons a node was selected or its score changed.
+const (
+	// ReasonRanked is the node ranker's score, the base of every selection.
+	ReasonRanked ReasonCode = "ranked"
+
+	// ReasonGPUVendor scores how well the node's GPUs meet the job's
+	// vendor-specific requirements.
+	ReasonGPUVendor ReasonCode = "gpu_vendor"
+
+	// ReasonProfitable scores the operator's margin under its energy tariff.
+	ReasonProfitable ReasonCode = "profitable"
+
+	ReasonPreferredRegion  ReasonCode = "preferred_region"
+	ReasonLowCost          ReasonCode = "low_cost"
+	ReasonReservedCapacity ReasonCode = "reserved_capacity"
+
+	// ReasonStageColocation and ReasonStageProximity favour nodes holding,
+	// or close to, the intermediates of upstream pipeline stages.
+	ReasonStageColocation ReasonCode = "stage_colocation"
+	ReasonStageProximity  ReasonCode = "stage_proximity"
+
+	// Placement profile scores: latency to the request's origin, spare
+	// shared GPU memory, the job's image already pulled, co-location with
+	// the training cluster, bandwidth between nodes, and spot capacity.
+	ReasonOriginProximity ReasonCode = "origin_proximity"
+	ReasonSharedGPU       ReasonCode = "shared_gpu"
+	ReasonWarmImage       ReasonCode = "warm_image"
+	ReasonColocation      ReasonCode = "colocation"
+	ReasonBandwidth       ReasonCode = "bandwidth"
+	ReasonSpot            ReasonCode = "spot"
+
+	// Roles other than worker
+	ReasonCoordinator ReasonCode = "coordinator"
+	ReasonAggregation ReasonCode = "aggregation"
+	ReasonStandby     ReasonCode = "standby"
+)
+
+// Reasons a node was rejected.
+const (
+	// ReasonSelectorRejected is a node the orchestrator's selector found
+	// unsuitable; the rejection's detail carries its explanation.
+	ReasonSelectorRejected ReasonCode = "selector_rejected"
+
+	ReasonConstraintMismatch ReasonCode = "constraint_mismatch"
+	ReasonGPUVendorMismatch  ReasonCode = "gpu_vendor_mismatch"
+	ReasonEgressDenied       ReasonCode = "egress_denied"
+	ReasonUnprofitable       ReasonCode = "unprofitable"
+	ReasonNoTimeSlice        ReasonCode = "no_time_slice"
+	ReasonProfileMismatch    ReasonCode = "profile_mismatch"
+	ReasonLatencyExceeded    ReasonCode = "latency_exceeded"
+	ReasonRegionSpread       ReasonCode = "region_spread"
+	ReasonExcluded           ReasonCode = "excluded"
+	ReasonLeaseDenied        ReasonCode = "lease_denied"
+)
+
+// ConstraintKind is a kind of job constraint a selected node satisfies.
+type ConstraintKind string
+
+const (
+	ConstraintExpression    ConstraintKind = "expression"
+	ConstraintGPUVendor     ConstraintKind = "gpu_vendor"
+	ConstraintEgress        ConstraintKind = "egress"
+	ConstraintProfitability ConstraintKind = "profitability"
+	ConstraintGPUTimeSlice  ConstraintKind = "gpu_time_slice"
+	ConstraintProfile       ConstraintKind = "placement_profile"
+	ConstraintMaxLatency    ConstraintKind = "max_latency"
+	ConstraintExclusions    ConstraintKind = "exclusions"
+)
+
+// ScoreComponent is one contribution to a selection's Rank.
+type ScoreComponent struct {
+	Reason ReasonCode `json:"Reason"`
+	Points int        `json:"Points"`
+}
+
+// NodeRejection records why a scheduling pass dropped a node.
+type NodeRejection struct {
+	NodeID string     `json:"NodeID"`
+	Reason ReasonCode `json:"Reason"`
+	Detail string     `json:"Detail,omitempty"`
+}
+
+// SelectionRecord is the part of a selection the audit log keeps.
+type SelectionRecord struct {
+	NodeID  string       `json:"NodeID"`
+	Role    NodeRole     `json:"Role,omitempty"`
+	Rank    int          `json:"Rank"`
+	Reasons []ReasonCode `json:"Reasons,omitempty"`
+}
+
+// addScore adds points to the selection's rank under reason.
+func (sel *NodeSelection) addScore(reason ReasonCode, points int) {
+	if points == 0 {
+		return
+	}
+	sel.Rank += points
+	sel.Score = append(sel.Score, ScoreComponent{Reason: reason, Points: points})
+	sel.addReason(reason)
+}
+
+// addReason records a reason the node was selected, once.
+func (sel *NodeSelection) addReason(reason ReasonCode) {
+	for _, r := range sel.Reasons {
+		if r == reason {
+			return
+		}
+	}
+	sel.Reasons = append(sel.Reasons, reason)
+}
+
+// selectionTrace collects, for one scheduling pass, the score adjustments
+// made to nodes before they became selections, the constraints checked
+// and the nodes rejected. Its methods do nothing on a nil trace.
+type selectionTrace struct {
+	mu         sync.Mutex
+	scores     map[string][]ScoreComponent
+	matched    []ConstraintKind
+	rejections []NodeRejection
+}
+
+type selectionTraceKey struct{}
+
+// withSelectionTrace returns a context that collects a selection trace.
+func withSelectionTrace(ctx context.Context) (context.Context, *selectionTrace) {
+	trace := &selectionTrace{scores: make(map[string][]ScoreComponent)}
+	return context.WithValue(ctx, selectionTraceKey{}, trace), trace
+}
+
+// traceFrom returns the selection trace ctx carries, if any.
+func traceFrom(ctx context.Context) *selectionTrace {
+	trace, _ := ctx.Value(selectionTraceKey{}).(*selectionTrace)
+	return trace
+}
+
+// score records an adjustment of a node's rank before it became a selection.
+func (t *selectionTrace) score(nodeID string, reason ReasonCode, points int) {
+	if t == nil || points == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.scores[nodeID] = append(t.scores[nodeID], ScoreComponent{Reason: reason, Points: points})
+}
+
+// scoresOf returns the adjustments recorded for a node.
+func (t *selectionTrace) scoresOf(nodeID string) []ScoreComponent {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.scores[nodeID]
+}
+
+// match records a constraint every remaining node satisfies.
+func (t *selectionTrace) match(kind ConstraintKind) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.matched = append(t.matched, kind)
+}
+
+// matchedConstraints returns the constraints checked so far.
+func (t *selectionTrace) matchedConstraints() []ConstraintKind {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ConstraintKind(nil), t.matched...)
+}
+
+// reject records a rejected node.
+func (t *selectionTrace) reject(nodeID string, reason ReasonCode, detail string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rejections = append(t.rejections, NodeRejection{NodeID: nodeID, Reason: reason, Detail: detail})
+}
+
+// rejected returns the rejections, in the order they happened.
+func (t *selectionTrace) rejected() []NodeRejection {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]NodeRejection(nil), t.rejections...)
+}
+
+// rejectDroppedSelections records the selections of before that a step
+// dropped from after as rejected for reason.
+func rejectDroppedSelections(ctx context.Context, reason ReasonCode, detail string, before, after []NodeSelection) {
+	trace := traceFrom(ctx)
+	if trace == nil {
+		return
+	}
+	kept := make(map[string]bool, len(after))
+	for _, sel := range after {
+		kept[sel.NodeID] = true
+	}
+	for _, sel := range before {
+		if !kept[sel.NodeID] {
+			trace.reject(sel.NodeID, reason, detail)
+		}
+	}
+}
+
+// annotate records the constraints checked on every selection.
+func (t *selectionTrace) annotate(selections []NodeSelection) {
+	matched := t.matchedConstraints()
+	if len(matched) == 0 {
+		return
+	}
+	for i := range selections {
+		selections[i].MatchedConstraints = matched
+	}
+}
+
+// assignCoordinator makes the best-ranked worker the coordinator and the
+// others workers.
+func assignCoordinator(workers []NodeSelection) []NodeSelection {
+	workers = append([]NodeSelection(nil), workers...)
+	best := 0
+	for i := range workers {
+		workers[i].Role = NodeRoleWorker
+		if workers[i].Rank > workers[best].Rank {
+			best = i
+		}
+	}
+	workers[best].Role = NodeRoleCoordinator
+	workers[best].addReason(ReasonCoordinator)
+	return workers
+}
+
+// selectionRecords summarizes selections for the audit log.
+func selectionRecords(selections []NodeSelection) []SelectionRecord {
+	if len(selections) == 0 {
+		return nil
+	}
+	records := make([]SelectionRecord, len(selections))
+	for i, sel := range selections {
+		records[i] = SelectionRecord{NodeID: sel.NodeID, Role: sel.Role, Rank: sel.Rank, Reasons: sel.Reasons}
+	}
+	return records
+}
+
+// rejectionCounts counts rejections by reason for the audit log.
+func rejectionCounts(rejections []NodeRejection) map[ReasonCode]int {
+	if len(rejections) == 0 {
+		return nil
+	}
+	counts := make(map[ReasonCode]int)
+	for _, r := range rejections {
+		counts[r.Reason]++
+	}
+	return counts
+}
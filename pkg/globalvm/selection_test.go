@@ -0,0 +1,118 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func selectionTestSelector() *mockNodeSelector {
+	return &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("node-east", "us-east"), Rank: 10},
+		{NodeInfo: createTestNodeInfo("node-west", "eu-west"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("node-south", "ap-south"), Rank: 30},
+		{NodeInfo: createTestNodeInfo("node-banned", "us-east"), Rank: 40},
+	}}
+}
+
+func TestScheduler_SelectionScoreAndRejections(t *testing.T) {
+	scheduler := NewScheduler(selectionTestSelector(), &mockCapacityProvider{})
+
+	result, err := scheduler.Schedule(context.Background(), GlobalSchedulingRequest{
+		Job: createTestJob("job-1", models.JobTypeBatch, 2),
+		Scheduling: SchedulingOptions{
+			Constraint:       `region in ["us-east","eu-west"]`,
+			PreferredRegions: []string{"us-east"},
+			ExcludeNodeIDs:   []string{"node-banned"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"node-east", "node-west"}, selectionIDs(result.Selections))
+
+	east := result.Selections[0]
+	assert.Equal(t, 110, east.Rank)
+	assert.Equal(t, []ScoreComponent{{ReasonRanked, 10}, {ReasonPreferredRegion, 100}}, east.Score)
+	assert.Equal(t, []ReasonCode{ReasonRanked, ReasonPreferredRegion}, east.Reasons)
+	assert.Equal(t, []ConstraintKind{ConstraintExpression, ConstraintExclusions}, east.MatchedConstraints)
+
+	for _, sel := range result.Selections {
+		total := 0
+		for _, c := range sel.Score {
+			total += c.Points
+		}
+		assert.Equal(t, sel.Rank, total, "score of %s", sel.NodeID)
+	}
+
+	assert.Equal(t, []NodeRejection{
+		{NodeID: "node-south", Reason: ReasonConstraintMismatch, Detail: `region in ["us-east","eu-west"]`},
+		{NodeID: "node-banned", Reason: ReasonExcluded},
+	}, result.Rejections)
+}
+
+func TestScheduler_Coordinator(t *testing.T) {
+	scheduler := NewScheduler(selectionTestSelector(), &mockCapacityProvider{})
+
+	result, err := scheduler.Schedule(context.Background(), GlobalSchedulingRequest{
+		Job:         createTestJob("job-1", models.JobTypeBatch, 3),
+		Scheduling:  SchedulingOptions{Coordinator: true},
+		TargetCount: 3,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Selections, 3)
+
+	roles := make(map[string]NodeRole)
+	for _, sel := range result.Selections {
+		roles[sel.NodeID] = sel.Role
+	}
+	assert.Equal(t, map[string]NodeRole{
+		"node-banned": NodeRoleCoordinator,
+		"node-south":  NodeRoleWorker,
+		"node-west":   NodeRoleWorker,
+	}, roles)
+	assert.Contains(t, result.Selections[0].Reasons, ReasonCoordinator)
+
+	// A single worker coordinates nothing
+	result, err = scheduler.Schedule(context.Background(), GlobalSchedulingRequest{
+		Job:         createTestJob("job-2", models.JobTypeBatch, 1),
+		Scheduling:  SchedulingOptions{Coordinator: true},
+		TargetCount: 1,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.Selections[0].Role)
+}
+
+func TestEndpoint_SelectionAudit(t *testing.T) {
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30}}
+	endpoint := NewEndpoint(NewScheduler(selectionTestSelector(), capacity), capacity)
+
+	resp, err := endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:        createTestJob("job-1", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Constraint: `region == "us-east"`, Coordinator: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.AllocatedNodes, 1)
+	assert.Len(t, resp.Rejections, 2)
+
+	audit := endpoint.GetAuditLog("job-1")
+	require.Len(t, audit, 1)
+	assert.Equal(t, []SelectionRecord{
+		{NodeID: "node-banned", Rank: 40, Reasons: []ReasonCode{ReasonRanked}},
+	}, audit[0].Selected)
+	assert.Equal(t, map[ReasonCode]int{ReasonConstraintMismatch: 2}, audit[0].Rejected)
+
+	// A queued job's response says why no node was selected
+	resp, err = endpoint.SubmitJob(context.Background(), GlobalJobRequest{
+		Job:        createTestJob("job-2", models.JobTypeBatch, 1),
+		Scheduling: SchedulingOptions{Constraint: `region == "sa-east"`},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.AllocatedNodes)
+	assert.Len(t, resp.Rejections, 4)
+	assert.Equal(t, map[ReasonCode]int{ReasonConstraintMismatch: 4}, endpoint.GetAuditLog("job-2")[0].Rejected)
+}
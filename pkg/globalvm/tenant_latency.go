@@ -0,0 +1,124 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TenantLatencyOverrides holds latencies that apply to a single tenant's
+// jobs in place of the global latency matrix, such as those of an
+// enterprise's private links between its sites.
+//
+// Overrides are keyed by node ID or region like the global matrix. When
+// estimating a transfer for a tenant's job, the scheduler uses the first
+// of:
+//
+//  1. the tenant's override between the two nodes
+//  2. the tenant's override between the nodes' regions
+//  3. the global measurement between the two nodes
+//  4. the global latency between the nodes' regions
+//
+// so a tenant's region override wins over a public measurement between
+// nodes in those regions. Overrides only replace latency; bandwidth still
+// comes from the global matrix. Overrides are configured rather than
+// measured and never expire.
+type TenantLatencyOverrides struct {
+	mu        sync.RWMutex
+	overrides map[string]map[string]map[string]time.Duration // tenant -> from -> to -> latency
+}
+
+// NewTenantLatencyOverrides creates an empty set of overrides.
+func NewTenantLatencyOverrides() *TenantLatencyOverrides {
+	return &TenantLatencyOverrides{
+		overrides: make(map[string]map[string]map[string]time.Duration),
+	}
+}
+
+// Set overrides the latency between two nodes or regions, in both
+// directions, for the tenant's jobs.
+func (o *TenantLatencyOverrides) Set(tenantID, from, to string, latency time.Duration) error {
+	switch {
+	case tenantID == "":
+		return fmt.Errorf("tenant latency override needs a tenant")
+	case from == "" || to == "":
+		return fmt.Errorf("tenant latency override for %s needs both ends", tenantID)
+	case from == to:
+		return fmt.Errorf("tenant latency override for %s is between %s and itself", tenantID, from)
+	case latency < 0:
+		return fmt.Errorf("tenant latency override for %s between %s and %s is negative: %s", tenantID, from, to, latency)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	tenant, ok := o.overrides[tenantID]
+	if !ok {
+		tenant = make(map[string]map[string]time.Duration)
+		o.overrides[tenantID] = tenant
+	}
+	for _, pair := range [][2]string{{from, to}, {to, from}} {
+		if tenant[pair[0]] == nil {
+			tenant[pair[0]] = make(map[string]time.Duration)
+		}
+		tenant[pair[0]][pair[1]] = latency
+	}
+	return nil
+}
+
+// Remove drops the tenant's override between two nodes or regions, so the
+// global matrix applies again.
+func (o *TenantLatencyOverrides) Remove(tenantID, from, to string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	tenant := o.overrides[tenantID]
+	for _, pair := range [][2]string{{from, to}, {to, from}} {
+		delete(tenant[pair[0]], pair[1])
+		if len(tenant[pair[0]]) == 0 {
+			delete(tenant, pair[0])
+		}
+	}
+	if len(tenant) == 0 {
+		delete(o.overrides, tenantID)
+	}
+}
+
+// ClearTenant drops all of the tenant's overrides.
+func (o *TenantLatencyOverrides) ClearTenant(tenantID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.overrides, tenantID)
+}
+
+// Latency returns the tenant's override between two nodes, falling back to
+// its override between their regions. It reports false when the tenant has
+// neither and the global matrix applies. It is safe on a nil receiver.
+func (o *TenantLatencyOverrides) Latency(tenantID string, from, to NodeSelection) (time.Duration, bool) {
+	if o == nil || tenantID == "" {
+		return 0, false
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	tenant, ok := o.overrides[tenantID]
+	if !ok {
+		return 0, false
+	}
+	if latency, ok := tenant[from.NodeID][to.NodeID]; ok {
+		return latency, true
+	}
+	latency, ok := tenant[from.Region][to.Region]
+	return latency, ok
+}
+
+// WithTenantLatencyOverrides sets per-tenant latencies layered over the
+// latency matrix when placing aggregation nodes for a tenant's jobs.
+func WithTenantLatencyOverrides(overrides *TenantLatencyOverrides) SchedulerOption {
+	return func(s *Scheduler) {
+		s.tenantLatencies = overrides
+	}
+}
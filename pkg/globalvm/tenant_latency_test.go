@@ -0,0 +1,85 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantLatencyOverrides_Precedence(t *testing.T) {
+	east1 := NodeSelection{NodeID: "east-1", Region: "us-east"}
+	east2 := NodeSelection{NodeID: "east-2", Region: "us-east"}
+	asia := NodeSelection{NodeID: "asia-1", Region: "asia-east"}
+
+	matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+	matrix.UpdateLatency("us-east", "asia-east", 200*time.Millisecond)
+	matrix.UpdateLatency("east-1", "asia-1", 150*time.Millisecond)
+
+	overrides := NewTenantLatencyOverrides()
+	require.NoError(t, overrides.Set("acme", "us-east", "asia-east", 40*time.Millisecond))
+	require.NoError(t, overrides.Set("acme", "asia-1", "east-2", 10*time.Millisecond))
+	s := NewScheduler(nil, nil, WithLatencyMatrix(matrix), WithTenantLatencyOverrides(overrides))
+
+	// Node override, then region override over a global node measurement
+	assert.Equal(t, 10*time.Millisecond, s.TenantTransferTime("acme", east2, asia, 0))
+	assert.Equal(t, 40*time.Millisecond, s.TenantTransferTime("acme", east1, asia, 0))
+	assert.Equal(t, 40*time.Millisecond, s.TenantTransferTime("acme", asia, east1, 0))
+
+	// Other tenants and untenanted jobs see the global matrix
+	assert.Equal(t, 150*time.Millisecond, s.TenantTransferTime("other", east1, asia, 0))
+	assert.Equal(t, 200*time.Millisecond, s.TransferTime(east2, asia, 0))
+
+	overrides.Remove("acme", "east-2", "asia-1")
+	assert.Equal(t, 40*time.Millisecond, s.TenantTransferTime("acme", east2, asia, 0))
+	overrides.ClearTenant("acme")
+	assert.Equal(t, 150*time.Millisecond, s.TenantTransferTime("acme", east1, asia, 0))
+}
+
+func TestTenantLatencyOverrides_Set(t *testing.T) {
+	overrides := NewTenantLatencyOverrides()
+	assert.Error(t, overrides.Set("", "a", "b", time.Millisecond))
+	assert.Error(t, overrides.Set("acme", "a", "", time.Millisecond))
+	assert.Error(t, overrides.Set("acme", "a", "a", time.Millisecond))
+	assert.Error(t, overrides.Set("acme", "a", "b", -time.Millisecond))
+
+	var none *TenantLatencyOverrides
+	_, ok := none.Latency("acme", NodeSelection{NodeID: "a"}, NodeSelection{NodeID: "b"})
+	assert.False(t, ok)
+}
+
+func TestScheduler_SelectAggregator_TenantOverrides(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		{NodeInfo: createTestNodeInfo("worker-1", "eu-west"), Rank: 50},
+		{NodeInfo: createTestNodeInfo("worker-2", "eu-west"), Rank: 40},
+		{NodeInfo: createTestNodeInfo("asia-1", "asia-east"), Rank: 20},
+		{NodeInfo: createTestNodeInfo("eu-1", "eu-central"), Rank: 10},
+	}}
+	overrides := NewTenantLatencyOverrides()
+	// A private link that makes the tenant's Asian site the closest
+	require.NoError(t, overrides.Set("acme", "eu-west", "asia-east", time.Millisecond))
+	scheduler := NewScheduler(selector, nil, WithTenantLatencyOverrides(overrides))
+
+	req := GlobalSchedulingRequest{
+		Job:         createTestJob("mapreduce", models.JobTypeBatch, 2),
+		TargetCount: 2,
+		Scheduling:  SchedulingOptions{Aggregation: &AggregationOptions{ResultSize: 1 << 10}},
+	}
+	aggregatorFor := func(tenantID string) string {
+		req.TenantID = tenantID
+		selections, err := scheduler.SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		aggregator, ok := Aggregator(selections)
+		require.True(t, ok)
+		return aggregator.NodeID
+	}
+
+	assert.Equal(t, "eu-1", aggregatorFor(""))
+	assert.Equal(t, "asia-1", aggregatorFor("acme"))
+}
@@ -0,0 +1,350 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+)
+
+var (
+	// ErrGPUOversubscribed is returned when no GPU on a node has room for
+	// a time slice without exceeding the oversubscription factor.
+	ErrGPUOversubscribed = errors.New("GPU time slices would exceed the oversubscription factor")
+
+	// ErrTimeSlicingNotConfigured is returned when a job asks for a GPU
+	// time slice from a scheduler without a time slicer.
+	ErrTimeSlicingNotConfigured = errors.New("GPU time slicing is not configured")
+
+	// ErrTimeSliceNotFound is returned when reporting utilization for a
+	// job that holds no time slice on the node.
+	ErrTimeSliceNotFound = errors.New("no GPU time slice for the job on the node")
+)
+
+// DefaultGPUOversubscription lets the duty cycles committed on a GPU add
+// up to the whole GPU, and no more.
+const DefaultGPUOversubscription = 1.0
+
+// GPUTimeSliceOptions asks for a share of one GPU instead of a whole one.
+type GPUTimeSliceOptions struct {
+	// DutyCycle is the fraction of the GPU's time the job uses on average,
+	// greater than 0 and at most 1.
+	DutyCycle float64 `json:"DutyCycle"`
+}
+
+// Validate checks the duty cycle.
+func (o *GPUTimeSliceOptions) Validate() error {
+	if o.DutyCycle <= 0 || o.DutyCycle > 1 {
+		return fmt.Errorf("GPU time slice duty cycle must be in (0, 1], got %g", o.DutyCycle)
+	}
+	return nil
+}
+
+// TimeSliceAssignment is the GPU a time-sliced job was placed on.
+type TimeSliceAssignment struct {
+	// GPU is the device index on the node.
+	GPU       int     `json:"GPU"`
+	DutyCycle float64 `json:"DutyCycle"`
+}
+
+// GPUTimeSliceShare is one job's committed time slice of a GPU.
+type GPUTimeSliceShare struct {
+	JobID     string  `json:"JobID"`
+	NodeID    string  `json:"NodeID"`
+	GPU       int     `json:"GPU"`
+	DutyCycle float64 `json:"DutyCycle"`
+
+	// Utilization is the fraction of the GPU's time the job was last
+	// reported to use, which may differ from its declared duty cycle.
+	Utilization float64   `json:"Utilization"`
+	ReportedAt  time.Time `json:"ReportedAt,omitempty"`
+
+	CommittedAt time.Time `json:"CommittedAt"`
+}
+
+// OverDutyCycle reports whether the job uses more GPU time than it declared.
+func (s GPUTimeSliceShare) OverDutyCycle() bool {
+	return s.Utilization > s.DutyCycle+gpuShareEpsilon
+}
+
+// GPUTimeSliceUtilization reports the time slices of one GPU.
+type GPUTimeSliceUtilization struct {
+	NodeID string `json:"NodeID"`
+	GPU    int    `json:"GPU"`
+
+	// Committed is the sum of the shares' duty cycles.
+	Committed float64 `json:"Committed"`
+
+	// Utilization is the sum of the shares' reported utilization.
+	Utilization float64 `json:"Utilization"`
+
+	Shares []GPUTimeSliceShare `json:"Shares"`
+}
+
+// GPUTimeSlicer tracks the time slices committed on each GPU so that
+// bursty jobs, such as inference servers, can share GPUs without the
+// duty cycles on any one GPU exceeding the oversubscription factor.
+type GPUTimeSlicer struct {
+	oversubscription float64
+	now              func() time.Time
+
+	mu     sync.Mutex
+	shares map[string][]*GPUTimeSliceShare // nodeID -> shares
+}
+
+// TimeSliceOption configures the time slicer.
+type TimeSliceOption func(*GPUTimeSlicer)
+
+// WithOversubscriptionFactor sets how far the duty cycles committed on a
+// GPU may add up, e.g. 1.5 to bet that bursty jobs rarely peak together.
+// Factors below 1 are raised to 1.
+func WithOversubscriptionFactor(factor float64) TimeSliceOption {
+	return func(t *GPUTimeSlicer) {
+		t.oversubscription = max(factor, 1)
+	}
+}
+
+// NewGPUTimeSlicer creates a time slicer without commitments.
+func NewGPUTimeSlicer(opts ...TimeSliceOption) *GPUTimeSlicer {
+	t := &GPUTimeSlicer{
+		oversubscription: DefaultGPUOversubscription,
+		now:              time.Now,
+		shares:           make(map[string][]*GPUTimeSliceShare),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// WithGPUTimeSlicer places jobs that ask for a GPU time slice on GPUs with
+// room for their duty cycle, and commits the slice when capacity is leased.
+func WithGPUTimeSlicer(slicer *GPUTimeSlicer) SchedulerOption {
+	return func(s *Scheduler) {
+		s.timeSlicer = slicer
+	}
+}
+
+// Fit returns the GPU of a node with gpus devices that a time slice of
+// dutyCycle would be placed on. The busiest GPU with room is chosen, so
+// that shares pack together and leave other GPUs whole.
+func (t *GPUTimeSlicer) Fit(nodeID string, gpus int, dutyCycle float64) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fit(nodeID, gpus, dutyCycle)
+}
+
+func (t *GPUTimeSlicer) fit(nodeID string, gpus int, dutyCycle float64) (int, bool) {
+	committed := make([]float64, gpus)
+	for _, share := range t.shares[nodeID] {
+		if share.GPU < gpus {
+			committed[share.GPU] += share.DutyCycle
+		}
+	}
+
+	best, bestCommitted := -1, -1.0
+	for gpu, c := range committed {
+		if c+dutyCycle > t.oversubscription+gpuShareEpsilon {
+			continue
+		}
+		if c > bestCommitted {
+			best, bestCommitted = gpu, c
+		}
+	}
+	return best, best >= 0
+}
+
+// Commit reserves a time slice for the job on a node with gpus devices.
+// It fails with ErrGPUOversubscribed when no GPU has room.
+func (t *GPUTimeSlicer) Commit(jobID, nodeID string, gpus int, dutyCycle float64) (*GPUTimeSliceShare, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gpu, ok := t.fit(nodeID, gpus, dutyCycle)
+	if !ok {
+		return nil, fmt.Errorf("%w: node %s, duty cycle %g", ErrGPUOversubscribed, nodeID, dutyCycle)
+	}
+	share := &GPUTimeSliceShare{
+		JobID:       jobID,
+		NodeID:      nodeID,
+		GPU:         gpu,
+		DutyCycle:   dutyCycle,
+		CommittedAt: t.now(),
+	}
+	t.shares[nodeID] = append(t.shares[nodeID], share)
+	copied := *share
+	return &copied, nil
+}
+
+// Release removes every time slice held by the job and reports how many
+// there were.
+func (t *GPUTimeSlicer) Release(jobID string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	released := 0
+	for nodeID, shares := range t.shares {
+		kept := shares[:0]
+		for _, share := range shares {
+			if share.JobID == jobID {
+				released++
+				continue
+			}
+			kept = append(kept, share)
+		}
+		if len(kept) == 0 {
+			delete(t.shares, nodeID)
+		} else {
+			t.shares[nodeID] = kept
+		}
+	}
+	return released
+}
+
+// ReportUtilization records the fraction of GPU time the job's slice on
+// the node was measured to use.
+func (t *GPUTimeSlicer) ReportUtilization(jobID, nodeID string, utilization float64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, share := range t.shares[nodeID] {
+		if share.JobID == jobID {
+			share.Utilization = utilization
+			share.ReportedAt = t.now()
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: job %s, node %s", ErrTimeSliceNotFound, jobID, nodeID)
+}
+
+// Utilization reports the time slices of every shared GPU, by node and device.
+func (t *GPUTimeSlicer) Utilization() []GPUTimeSliceUtilization {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	type gpuKey struct {
+		nodeID string
+		gpu    int
+	}
+	byGPU := make(map[gpuKey]*GPUTimeSliceUtilization)
+	for nodeID, shares := range t.shares {
+		for _, share := range shares {
+			key := gpuKey{nodeID, share.GPU}
+			u := byGPU[key]
+			if u == nil {
+				u = &GPUTimeSliceUtilization{NodeID: nodeID, GPU: share.GPU}
+				byGPU[key] = u
+			}
+			u.Committed += share.DutyCycle
+			u.Utilization += share.Utilization
+			u.Shares = append(u.Shares, *share)
+		}
+	}
+
+	report := make([]GPUTimeSliceUtilization, 0, len(byGPU))
+	for _, u := range byGPU {
+		report = append(report, *u)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].NodeID != report[j].NodeID {
+			return report[i].NodeID < report[j].NodeID
+		}
+		return report[i].GPU < report[j].GPU
+	})
+	return report
+}
+
+// nodeGPUCount returns the number of GPU devices on a node.
+func nodeGPUCount(info models.NodeInfo) int {
+	capacity := info.ComputeNodeInfo.MaxCapacity
+	return max(int(capacity.GPU), len(capacity.GPUs))
+}
+
+// applyTimeSlicing drops the nodes with no GPU that has room for the
+// job's time slice, and returns the GPU count of those kept.
+func (s *Scheduler) applyTimeSlicing(
+	ctx context.Context, req GlobalSchedulingRequest, ranks []orchestrator.NodeRank,
+) ([]orchestrator.NodeRank, map[string]int, error) {
+	if s.timeSlicer == nil {
+		return nil, nil, ErrTimeSlicingNotConfigured
+	}
+	dutyCycle := req.Scheduling.GPUTimeSlice.DutyCycle
+
+	gpus := make(map[string]int, len(ranks))
+	result := make([]orchestrator.NodeRank, 0, len(ranks))
+	for _, rank := range ranks {
+		nodeID := rank.NodeInfo.ID()
+		count := nodeGPUCount(rank.NodeInfo)
+		if _, ok := s.timeSlicer.Fit(nodeID, count, dutyCycle); !ok {
+			componentLogger(ctx, ComponentScheduler).Debug().
+				Str("jobID", req.Job.ID).
+				Str("nodeID", nodeID).
+				Float64("dutyCycle", dutyCycle).
+				Msg("Skipping node, no GPU has room for the time slice")
+			traceFrom(ctx).reject(nodeID, ReasonNoTimeSlice, "")
+			continue
+		}
+		gpus[nodeID] = count
+		result = append(result, rank)
+	}
+	traceFrom(ctx).match(ConstraintGPUTimeSlice)
+	return result, gpus, nil
+}
+
+// commitTimeSlices commits the job's time slice on each selected node.
+// A node whose GPUs filled up since it was ranked is dropped and its
+// lease released.
+func (s *Scheduler) commitTimeSlices(
+	ctx context.Context, req GlobalSchedulingRequest, selections []NodeSelection, gpus map[string]int,
+) []NodeSelection {
+	dutyCycle := req.Scheduling.GPUTimeSlice.DutyCycle
+	committed := make([]NodeSelection, 0, len(selections))
+	for _, sel := range selections {
+		share, err := s.timeSlicer.Commit(req.Job.ID, sel.NodeID, gpus[sel.NodeID], dutyCycle)
+		if err != nil {
+			componentLogger(ctx, ComponentScheduler).Debug().
+				Err(err).
+				Str("jobID", req.Job.ID).
+				Str("nodeID", sel.NodeID).
+				Msg("Dropping node, time slice not committed")
+			traceFrom(ctx).reject(sel.NodeID, ReasonNoTimeSlice, err.Error())
+			releaseLeases(ctx, s.capacityProvider, []NodeSelection{sel})
+			continue
+		}
+		sel.TimeSlice = &TimeSliceAssignment{GPU: share.GPU, DutyCycle: share.DutyCycle}
+		committed = append(committed, sel)
+	}
+	return committed
+}
+
+// ReleaseTimeSlices releases the GPU time slices committed for a job.
+func (s *Scheduler) ReleaseTimeSlices(jobID string) int {
+	if s.timeSlicer == nil {
+		return 0
+	}
+	return s.timeSlicer.Release(jobID)
+}
+
+// timeSliceReleaser is implemented by schedulers that commit GPU time slices.
+type timeSliceReleaser interface {
+	ReleaseTimeSlices(jobID string) int
+}
+
+// releaseTimeSlices releases a job's GPU time slices, when the scheduler
+// commits them.
+func (e *Endpoint) releaseTimeSlices(ctx context.Context, jobID string) {
+	releaser, ok := e.scheduler.(timeSliceReleaser)
+	if !ok {
+		return
+	}
+	if n := releaser.ReleaseTimeSlices(jobID); n > 0 {
+		componentLogger(ctx, ComponentEndpoint).Debug().Str("jobID", jobID).Int("released", n).Msg("Released GPU time slices")
+	}
+}
@@ -0,0 +1,179 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGPUTimeSlicer_Commit(t *testing.T) {
+	slicer := NewGPUTimeSlicer()
+
+	// Shares pack onto the busiest GPU with room
+	a, err := slicer.Commit("job-a", "node-1", 2, 0.5)
+	require.NoError(t, err)
+	b, err := slicer.Commit("job-b", "node-1", 2, 0.3)
+	require.NoError(t, err)
+	assert.Equal(t, 0, a.GPU)
+	assert.Equal(t, 0, b.GPU)
+
+	c, err := slicer.Commit("job-c", "node-1", 2, 0.4)
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.GPU, "GPU 0 has only 0.2 left")
+
+	_, err = slicer.Commit("job-d", "node-1", 2, 0.7)
+	assert.ErrorIs(t, err, ErrGPUOversubscribed)
+
+	assert.Equal(t, 1, slicer.Release("job-a"))
+	d, err := slicer.Commit("job-d", "node-1", 2, 0.7)
+	require.NoError(t, err)
+	assert.Equal(t, 0, d.GPU)
+	assert.Equal(t, 0, slicer.Release("job-a"))
+}
+
+func TestGPUTimeSlicer_OversubscriptionFactor(t *testing.T) {
+	slicer := NewGPUTimeSlicer(WithOversubscriptionFactor(1.5))
+	for _, job := range []string{"job-a", "job-b", "job-c"} {
+		_, err := slicer.Commit(job, "node-1", 1, 0.5)
+		require.NoError(t, err, job)
+	}
+	_, err := slicer.Commit("job-d", "node-1", 1, 0.1)
+	assert.ErrorIs(t, err, ErrGPUOversubscribed)
+
+	// Nodes without GPUs take no slices
+	_, ok := NewGPUTimeSlicer().Fit("node-2", 0, 0.1)
+	assert.False(t, ok)
+}
+
+func TestGPUTimeSlicer_Utilization(t *testing.T) {
+	slicer := NewGPUTimeSlicer()
+	_, err := slicer.Commit("job-a", "node-1", 1, 0.5)
+	require.NoError(t, err)
+	_, err = slicer.Commit("job-b", "node-1", 1, 0.25)
+	require.NoError(t, err)
+	_, err = slicer.Commit("job-c", "node-0", 1, 0.25)
+	require.NoError(t, err)
+
+	require.NoError(t, slicer.ReportUtilization("job-a", "node-1", 0.2))
+	require.NoError(t, slicer.ReportUtilization("job-b", "node-1", 0.4))
+	assert.ErrorIs(t, slicer.ReportUtilization("job-a", "node-0", 0.1), ErrTimeSliceNotFound)
+
+	report := slicer.Utilization()
+	require.Len(t, report, 2)
+	assert.Equal(t, "node-0", report[0].NodeID)
+	gpu := report[1]
+	assert.InDelta(t, 0.75, gpu.Committed, 1e-9)
+	assert.InDelta(t, 0.6, gpu.Utilization, 1e-9)
+	require.Len(t, gpu.Shares, 2)
+	for _, share := range gpu.Shares {
+		assert.Equal(t, share.JobID == "job-b", share.OverDutyCycle(), share.JobID)
+		assert.False(t, share.ReportedAt.IsZero())
+	}
+}
+
+func gpuNodeInfo(id, region string, gpus uint64) models.NodeInfo {
+	info := createTestNodeInfo(id, region)
+	info.ComputeNodeInfo.MaxCapacity = models.Resources{CPU: 4, Memory: 16 << 30, GPU: gpus}
+	return info
+}
+
+func TestScheduler_GPUTimeSlicing(t *testing.T) {
+	ctx := context.Background()
+	slicer := NewGPUTimeSlicer()
+	selector := &mockNodeSelector{
+		nodes: []orchestrator.NodeRank{
+			{NodeInfo: gpuNodeInfo("node-1", "us-west", 1), Rank: 10},
+			{NodeInfo: gpuNodeInfo("node-2", "us-east", 1), Rank: 8},
+			{NodeInfo: createTestNodeInfo("cpu-only", "us-east"), Rank: 20},
+		},
+	}
+	scheduler := NewScheduler(selector, &mockCapacityProvider{capacity: &GlobalResources{}}, WithGPUTimeSlicer(slicer))
+
+	request := func(jobID string, dutyCycle float64, commit bool) GlobalSchedulingRequest {
+		return GlobalSchedulingRequest{
+			Job:           createTestJob(jobID, models.JobTypeService, 1),
+			Scheduling:    SchedulingOptions{GPUTimeSlice: &GPUTimeSliceOptions{DutyCycle: dutyCycle}},
+			TargetCount:   1,
+			LeaseCapacity: commit,
+		}
+	}
+
+	// A dry run commits nothing
+	selections, err := scheduler.SelectNodes(ctx, request("dry-run", 0.6, false))
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-1", selections[0].NodeID)
+	assert.Nil(t, selections[0].TimeSlice)
+	assert.Empty(t, slicer.Utilization())
+
+	selections, err = scheduler.SelectNodes(ctx, request("job-a", 0.6, true))
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-1", selections[0].NodeID)
+	assert.Equal(t, &TimeSliceAssignment{GPU: 0, DutyCycle: 0.6}, selections[0].TimeSlice)
+
+	// node-1's GPU has no room left for another 0.6
+	selections, err = scheduler.SelectNodes(ctx, request("job-b", 0.6, true))
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-2", selections[0].NodeID)
+
+	selections, err = scheduler.SelectNodes(ctx, request("job-c", 0.6, true))
+	require.NoError(t, err)
+	assert.Empty(t, selections)
+
+	// but a smaller slice still fits
+	selections, err = scheduler.SelectNodes(ctx, request("job-d", 0.4, true))
+	require.NoError(t, err)
+	require.Len(t, selections, 1)
+	assert.Equal(t, "node-1", selections[0].NodeID)
+
+	assert.Equal(t, 1, scheduler.ReleaseTimeSlices("job-a"))
+
+	_, err = NewScheduler(selector, &mockCapacityProvider{capacity: &GlobalResources{}}).
+		SelectNodes(ctx, request("job-e", 0.5, true))
+	assert.ErrorIs(t, err, ErrTimeSlicingNotConfigured)
+}
+
+func TestEndpoint_GPUTimeSlicing(t *testing.T) {
+	ctx := context.Background()
+	slicer := NewGPUTimeSlicer()
+	selector := &mockNodeSelector{
+		nodes: []orchestrator.NodeRank{{NodeInfo: gpuNodeInfo("node-1", "us-west", 1), Rank: 10}},
+	}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 100, AvailableMemory: 1024 << 30, HealthyNodes: 1}}
+	submitter := &mockJobSubmitter{response: &orchestrator.SubmitJobResponse{JobID: "job-1"}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity, WithGPUTimeSlicer(slicer)), capacity, WithJobSubmitter(submitter))
+
+	submit := func(jobID string, dutyCycle float64) (*GlobalJobResponse, error) {
+		return endpoint.SubmitJob(ctx, GlobalJobRequest{
+			Job:        createTestJob(jobID, models.JobTypeService, 1),
+			Scheduling: SchedulingOptions{GPUTimeSlice: &GPUTimeSliceOptions{DutyCycle: dutyCycle}},
+		})
+	}
+
+	_, err := submit("bad", 1.5)
+	assert.ErrorContains(t, err, "duty cycle")
+
+	resp, err := submit("job-1", 0.5)
+	require.NoError(t, err)
+	require.Len(t, resp.AllocatedNodes, 1)
+	assert.Equal(t, 0, resp.AllocatedNodes[0].TimeSlice.GPU)
+
+	// A failed dispatch gives the slice back
+	submitter.err = errors.New("orchestrator unavailable")
+	_, err = submit("job-2", 0.5)
+	require.Error(t, err)
+	require.Len(t, slicer.Utilization(), 1)
+	assert.InDelta(t, 0.5, slicer.Utilization()[0].Committed, 1e-9)
+
+	endpoint.RecordJobFinish("job-1")
+	assert.Empty(t, slicer.Utilization())
+}
@@ -0,0 +1,184 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileTraining is the name of the built-in TrainingProfile.
+const ProfileTraining = "training"
+
+// LabelNodeSpot is the node label set to "true" on spot capacity, which
+// its operator may reclaim at short notice.
+const LabelNodeSpot = "capacity.spot"
+
+// LabelJobCheckpoint is the job label set to "true" on jobs that save
+// checkpoints and can resume from them after losing their node.
+const LabelJobCheckpoint = "checkpoint"
+
+// TrainingProfile places distributed training jobs, which exchange
+// gradients between workers for hours and care about throughput, not
+// response time. It
+//
+//   - keeps the workers together in the region that can hold the most of
+//     them, breaking ties by the bandwidth between its nodes
+//   - tolerates distance from the origin up to LatencyTolerance
+//   - prefers spot capacity for jobs that checkpoint, and avoids it for
+//     those that would lose their progress
+type TrainingProfile struct {
+	// ColocationWeight is the rank added to the nodes of the region the
+	// workers are kept together in.
+	ColocationWeight int
+
+	// BandwidthWeight is the rank added to the nodes of a region whose
+	// bandwidth between nodes reaches ReferenceBandwidth; slower regions
+	// get a proportional share.
+	BandwidthWeight int
+
+	// ReferenceBandwidth is the bandwidth, in bytes per second, that earns
+	// the whole BandwidthWeight.
+	ReferenceBandwidth float64
+
+	// LatencyTolerance is the origin latency below which nodes are not
+	// penalized for distance.
+	LatencyTolerance time.Duration
+
+	// LatencyPenalty is the rank removed per 100ms of origin latency beyond
+	// LatencyTolerance.
+	LatencyPenalty int
+
+	// SpotWeight is the rank added to spot nodes for jobs that
+	// checkpoint, and removed for jobs that don't.
+	SpotWeight int
+}
+
+// NewTrainingProfile creates the training profile with its defaults.
+func NewTrainingProfile() *TrainingProfile {
+	return &TrainingProfile{
+		ColocationWeight:   200,
+		BandwidthWeight:    50,
+		ReferenceBandwidth: 1.25e9, // 10 Gbit/s
+		LatencyTolerance:   500 * time.Millisecond,
+		LatencyPenalty:     10,
+		SpotWeight:         50,
+	}
+}
+
+// Name returns ProfileTraining.
+func (p *TrainingProfile) Name() string {
+	return ProfileTraining
+}
+
+// trainingRegion is what the training profile knows of a candidate region.
+type trainingRegion struct {
+	name      string
+	nodes     int
+	bandwidth float64
+	rank      int
+}
+
+// Rank favours the nodes of the region best able to hold the workers
+// together, then adjusts for origin latency and spot capacity.
+func (p *TrainingProfile) Rank(ctx context.Context, pc *PlacementContext, candidates []NodeSelection) []NodeSelection {
+	regions := p.regions(pc, candidates)
+	var cluster string
+	if len(regions) > 0 {
+		cluster = regions[0].name
+	}
+	bandwidths := make(map[string]float64, len(regions))
+	for _, region := range regions {
+		bandwidths[region.name] = region.bandwidth
+	}
+	checkpoints := pc.Request.Job.Labels[LabelJobCheckpoint] == "true"
+
+	ranked := make([]NodeSelection, 0, len(candidates))
+	for _, sel := range candidates {
+		info, _ := pc.Node(sel.NodeID)
+		var reasons []string
+
+		if sel.Region == cluster {
+			sel.addScore(ReasonColocation, p.ColocationWeight)
+			reasons = append(reasons, "co-located in "+cluster)
+		}
+		if p.ReferenceBandwidth > 0 {
+			share := min(bandwidths[sel.Region]/p.ReferenceBandwidth, 1)
+			sel.addScore(ReasonBandwidth, int(float64(p.BandwidthWeight)*share))
+			reasons = append(reasons, fmt.Sprintf("%.0f MB/s between nodes", bandwidths[sel.Region]/1e6))
+		}
+
+		if latency, ok := pc.OriginLatency(sel.Region); ok {
+			sel.EstimatedLatency = latency
+			if latency > p.LatencyTolerance {
+				sel.addScore(ReasonOriginProximity, -int(float64(p.LatencyPenalty)*float64(latency-p.LatencyTolerance)/float64(100*time.Millisecond)))
+				reasons = append(reasons, fmt.Sprintf("%s from origin", latency))
+			}
+		}
+
+		if info.Labels[LabelNodeSpot] == "true" {
+			if checkpoints {
+				sel.addScore(ReasonSpot, p.SpotWeight)
+				reasons = append(reasons, "spot with checkpoints")
+			} else {
+				sel.addScore(ReasonSpot, -p.SpotWeight)
+				reasons = append(reasons, "spot without checkpoints")
+			}
+		}
+
+		sel.Reason = "training: " + strings.Join(reasons, ", ")
+		ranked = append(ranked, sel)
+	}
+	return ranked
+}
+
+// regions summarizes the candidates by region, the best place to keep the
+// workers together first: the region holding the most of them, then the
+// one with the fastest links between its nodes, then the best ranked.
+func (p *TrainingProfile) regions(pc *PlacementContext, candidates []NodeSelection) []trainingRegion {
+	byName := make(map[string]*trainingRegion)
+	for _, sel := range candidates {
+		region, ok := byName[sel.Region]
+		if !ok {
+			region = &trainingRegion{name: sel.Region, bandwidth: pc.Bandwidth(sel.Region, sel.Region)}
+			byName[sel.Region] = region
+		}
+		region.nodes++
+		region.rank += sel.Rank
+	}
+
+	target := pc.Request.TargetCount
+	regions := make([]trainingRegion, 0, len(byName))
+	for _, region := range byName {
+		regions = append(regions, *region)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		a, b := regions[i], regions[j]
+		// Beyond the target count more nodes don't keep more workers together
+		holdsA, holdsB := a.nodes, b.nodes
+		if target > 0 {
+			holdsA, holdsB = min(holdsA, target), min(holdsB, target)
+		}
+		if holdsA != holdsB {
+			return holdsA > holdsB
+		}
+		if a.bandwidth != b.bandwidth {
+			return a.bandwidth > b.bandwidth
+		}
+		if a.rank != b.rank {
+			return a.rank > b.rank
+		}
+		return a.name < b.name
+	})
+	return regions
+}
+
+// Complete leaves the workers as they are; training needs no other roles.
+func (p *TrainingProfile) Complete(
+	ctx context.Context, pc *PlacementContext, workers, spare []NodeSelection,
+) ([]NodeSelection, error) {
+	return workers, nil
+}
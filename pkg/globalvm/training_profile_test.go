@@ -0,0 +1,139 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trainingNode is a node that may be spot capacity.
+func trainingNode(id, region string, spot bool, rank int) orchestrator.NodeRank {
+	info := createTestNodeInfo(id, region)
+	if spot {
+		info.Labels[LabelNodeSpot] = "true"
+	}
+	return orchestrator.NodeRank{NodeInfo: info, Rank: rank}
+}
+
+func trainingRequest(count int, checkpoint bool) GlobalSchedulingRequest {
+	job := createTestJob("train", models.JobTypeBatch, count)
+	if checkpoint {
+		job.Labels = map[string]string{LabelJobCheckpoint: "true"}
+	}
+	return GlobalSchedulingRequest{
+		Job:         job,
+		TargetCount: count,
+		Scheduling:  SchedulingOptions{Profile: ProfileTraining},
+	}
+}
+
+func TestTrainingProfile_KeepsWorkersTogether(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		trainingNode("west-1", "us-west", false, 90),
+		trainingNode("asia-1", "asia-east", false, 80),
+		trainingNode("east-1", "us-east", false, 10),
+		trainingNode("east-2", "us-east", false, 10),
+		trainingNode("east-3", "us-east", false, 10),
+		trainingNode("eu-1", "eu-west", false, 10),
+		trainingNode("eu-2", "eu-west", false, 10),
+	}}
+
+	t.Run("the region holding the most workers", func(t *testing.T) {
+		selections, err := NewScheduler(selector, nil).SelectNodes(context.Background(), trainingRequest(3, false))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"east-1", "east-2", "east-3"}, selectedIDs(selections))
+		assert.Contains(t, selections[0].Reason, "co-located in us-east")
+	})
+
+	t.Run("faster links break ties", func(t *testing.T) {
+		matrix := NewLatencyMatrix(DefaultLatencyMatrixConfig())
+		matrix.UpdateBandwidth("us-east", "us-east", 125e6)
+		matrix.UpdateBandwidth("eu-west", "eu-west", 1.25e9)
+		scheduler := NewScheduler(selector, nil, WithLatencyMatrix(matrix))
+
+		selections, err := scheduler.SelectNodes(context.Background(), trainingRequest(2, false))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"eu-1", "eu-2"}, selectedIDs(selections))
+	})
+
+	t.Run("distant origins are tolerated up to a point", func(t *testing.T) {
+		req := trainingRequest(2, false)
+		req.OriginRegion = "us-east"
+		selections, err := NewScheduler(selector, nil).SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"east-1", "east-2"}, selectedIDs(selections))
+
+		profile := NewTrainingProfile()
+		profile.LatencyTolerance = 0
+		profile.LatencyPenalty = 1000
+		scheduler := NewScheduler(selector, nil, WithPlacementProfile(profile))
+		req.OriginRegion = "eu-west"
+		selections, err = scheduler.SelectNodes(context.Background(), req)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"eu-1", "eu-2"}, selectedIDs(selections))
+	})
+}
+
+func TestTrainingProfile_Spot(t *testing.T) {
+	selector := &mockNodeSelector{nodes: []orchestrator.NodeRank{
+		trainingNode("spot-1", "us-east", true, 10),
+		trainingNode("ondemand-1", "us-east", false, 20),
+	}}
+
+	selections, err := NewScheduler(selector, nil).SelectNodes(context.Background(), trainingRequest(1, true))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spot-1"}, selectedIDs(selections))
+	assert.Contains(t, selections[0].Reason, "spot with checkpoints")
+
+	selector.nodes[1].Rank = 0
+	selections, err = NewScheduler(selector, nil).SelectNodes(context.Background(), trainingRequest(1, false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ondemand-1"}, selectedIDs(selections), "a job without checkpoints avoids spot")
+}
+
+func TestLoadPlacementPolicy(t *testing.T) {
+	profiles, err := LoadPlacementPolicy(strings.NewReader(`{
+		"Inference": {"LatencyBudget": "50ms", "Standby": false},
+		"Training": {"SpotWeight": 0, "LatencyTolerance": "1s"}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+
+	inference := profiles[0].(*InferenceProfile)
+	assert.Equal(t, 50*time.Millisecond, inference.LatencyBudget)
+	assert.False(t, inference.Standby)
+	assert.Equal(t, NewInferenceProfile().ProximityWeight, inference.ProximityWeight, "left out fields keep their defaults")
+
+	training := profiles[1].(*TrainingProfile)
+	assert.Zero(t, training.SpotWeight)
+	assert.Equal(t, time.Second, training.LatencyTolerance)
+	assert.Equal(t, NewTrainingProfile().ColocationWeight, training.ColocationWeight)
+
+	// The tuned profiles replace the built-in ones
+	scheduler := NewScheduler(nil, nil, WithPlacementProfiles(profiles))
+	assert.Same(t, profiles[1], scheduler.profiles[ProfileTraining])
+
+	defaults, err := DefaultPlacementPolicy().Profiles()
+	require.NoError(t, err)
+	assert.Equal(t, NewTrainingProfile(), defaults[1])
+
+	for name, policy := range map[string]string{
+		"unknown field":     `{"Training": {"SpotWieght": 10}}`,
+		"invalid duration":  `{"Inference": {"LatencyBudget": "soon"}}`,
+		"negative duration": `{"Training": {"LatencyTolerance": "-1s"}}`,
+		"not json":          `training`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadPlacementPolicy(strings.NewReader(policy))
+			assert.Error(t, err)
+		})
+	}
+}
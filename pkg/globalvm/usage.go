@@ -0,0 +1,344 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUsageReportInterval is how often the usage reporter produces a report.
+const DefaultUsageReportInterval = 24 * time.Hour
+
+// TenantUsage is one tenant's scheduling usage over a report period.
+type TenantUsage struct {
+	// TenantID is the client ID the tenant submits jobs with.
+	TenantID string `json:"TenantID"`
+
+	// JobsScheduled is the number of jobs submitted in the period, and
+	// JobsQueued how many of them had to wait for capacity.
+	JobsScheduled int `json:"JobsScheduled"`
+	JobsQueued    int `json:"JobsQueued"`
+
+	// Regions are the regions the tenant's jobs ran in, sorted.
+	Regions []string `json:"Regions,omitempty"`
+
+	// GPUHours is the GPU time the tenant's jobs held in the period.
+	GPUHours float64 `json:"GPUHours"`
+
+	// AverageWait is the mean time from submission to placement of the
+	// jobs placed in the period.
+	AverageWait time.Duration `json:"AverageWait"`
+
+	// Preemptions is the number of times the tenant's jobs lost their nodes.
+	Preemptions int `json:"Preemptions"`
+}
+
+// UsageReport is the per-tenant scheduling usage over [From, To), assembled
+// from the endpoint's audit log for chargeback.
+type UsageReport struct {
+	From        time.Time     `json:"From"`
+	To          time.Time     `json:"To"`
+	GeneratedAt time.Time     `json:"GeneratedAt"`
+	Tenants     []TenantUsage `json:"Tenants"`
+}
+
+// usageCSVHeader names the columns written by WriteCSV.
+var usageCSVHeader = []string{
+	"tenant_id", "period_start", "period_end", "jobs_scheduled", "jobs_queued",
+	"regions", "gpu_hours", "average_wait_seconds", "preemptions",
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *UsageReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the report as CSV, one row per tenant after a header.
+// Regions are separated by semicolons.
+func (r *UsageReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(usageCSVHeader); err != nil {
+		return err
+	}
+	for _, t := range r.Tenants {
+		row := []string{
+			t.TenantID,
+			r.From.UTC().Format(time.RFC3339),
+			r.To.UTC().Format(time.RFC3339),
+			strconv.Itoa(t.JobsScheduled),
+			strconv.Itoa(t.JobsQueued),
+			strings.Join(t.Regions, ";"),
+			strconv.FormatFloat(t.GPUHours, 'f', 4, 64),
+			strconv.FormatFloat(t.AverageWait.Seconds(), 'f', 3, 64),
+			strconv.Itoa(t.Preemptions),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// UsageReport assembles the per-tenant usage over [from, to) from the
+// audit log. Jobs are attributed to the client that submitted them; jobs
+// whose submission has left the log are not counted.
+func (e *Endpoint) UsageReport(from, to time.Time) *UsageReport {
+	return buildUsageReport(e.history.auditEntries(), from, to, time.Now())
+}
+
+// usageRun is a span of time a job held its nodes.
+type usageRun struct {
+	start, end time.Time
+	nodes      int
+	regions    []string
+}
+
+// jobUsage is a job's history as replayed from the audit log.
+type jobUsage struct {
+	tenant      string
+	submittedAt time.Time
+	queued      bool
+	gpusPerNode int
+	startedAt   time.Time
+	runs        []usageRun
+	running     bool
+	preemptions []time.Time
+}
+
+func (j *jobUsage) start(at time.Time, nodes int, regions []string) {
+	if j.running {
+		return
+	}
+	if j.startedAt.IsZero() {
+		j.startedAt = at
+	}
+	j.runs = append(j.runs, usageRun{start: at, nodes: nodes, regions: regions})
+	j.running = true
+}
+
+func (j *jobUsage) stop(at time.Time) {
+	if j.running {
+		j.runs[len(j.runs)-1].end = at
+		j.running = false
+	}
+}
+
+// buildUsageReport replays the audit entries into per-job runs and sums
+// them per tenant over [from, to). Runs still open end at the earlier of
+// to and now.
+func buildUsageReport(entries []AuditEntry, from, to, now time.Time) *UsageReport {
+	jobs := make(map[string]*jobUsage)
+	var order []string
+	for _, entry := range entries {
+		job := jobs[entry.JobID]
+		if entry.Action == AuditActionSubmit {
+			job = &jobUsage{
+				tenant:      entry.ClientID,
+				submittedAt: entry.At,
+				queued:      entry.Nodes == 0,
+				gpusPerNode: entry.GPUsPerNode,
+			}
+			jobs[entry.JobID] = job
+			order = append(order, entry.JobID)
+			if entry.Nodes > 0 {
+				job.start(entry.At, entry.Nodes, entry.Regions)
+			}
+			continue
+		}
+		if job == nil {
+			continue
+		}
+		switch entry.Action {
+		case AuditActionStart:
+			job.start(entry.At, entry.Nodes, entry.Regions)
+		case AuditActionPreempt:
+			job.preemptions = append(job.preemptions, entry.At)
+			job.stop(entry.At)
+		case AuditActionFinish, AuditActionCancel:
+			job.stop(entry.At)
+		}
+	}
+
+	end := to
+	if now.Before(end) {
+		end = now
+	}
+	inPeriod := func(at time.Time) bool {
+		return !at.Before(from) && at.Before(to)
+	}
+
+	type tenantTotals struct {
+		usage   TenantUsage
+		regions map[string]bool
+		waited  time.Duration
+		placed  int
+	}
+	tenants := make(map[string]*tenantTotals)
+	for _, jobID := range order {
+		job := jobs[jobID]
+		totals := tenants[job.tenant]
+		if totals == nil {
+			totals = &tenantTotals{usage: TenantUsage{TenantID: job.tenant}, regions: make(map[string]bool)}
+			tenants[job.tenant] = totals
+		}
+
+		if inPeriod(job.submittedAt) {
+			totals.usage.JobsScheduled++
+			if job.queued {
+				totals.usage.JobsQueued++
+			}
+		}
+		if !job.startedAt.IsZero() && inPeriod(job.startedAt) {
+			totals.waited += job.startedAt.Sub(job.submittedAt)
+			totals.placed++
+		}
+		for _, at := range job.preemptions {
+			if inPeriod(at) {
+				totals.usage.Preemptions++
+			}
+		}
+		for _, run := range job.runs {
+			runEnd := run.end
+			if runEnd.IsZero() || runEnd.After(end) {
+				runEnd = end
+			}
+			runStart := run.start
+			if runStart.Before(from) {
+				runStart = from
+			}
+			if !runEnd.After(runStart) {
+				continue
+			}
+			totals.usage.GPUHours += runEnd.Sub(runStart).Hours() * float64(job.gpusPerNode*run.nodes)
+			for _, region := range run.regions {
+				totals.regions[region] = true
+			}
+		}
+	}
+
+	report := &UsageReport{From: from, To: to, GeneratedAt: now, Tenants: []TenantUsage{}}
+	for _, totals := range tenants {
+		if totals.usage.JobsScheduled == 0 && totals.placed == 0 && totals.usage.Preemptions == 0 &&
+			totals.usage.GPUHours == 0 && len(totals.regions) == 0 {
+			continue
+		}
+		if totals.placed > 0 {
+			totals.usage.AverageWait = totals.waited / time.Duration(totals.placed)
+		}
+		for region := range totals.regions {
+			totals.usage.Regions = append(totals.usage.Regions, region)
+		}
+		sort.Strings(totals.usage.Regions)
+		report.Tenants = append(report.Tenants, totals.usage)
+	}
+	sort.Slice(report.Tenants, func(i, j int) bool {
+		return report.Tenants[i].TenantID < report.Tenants[j].TenantID
+	})
+	return report
+}
+
+// selectionRegions returns the distinct regions of the selected nodes, sorted.
+func selectionRegions(selections []NodeSelection) []string {
+	seen := make(map[string]bool)
+	var regions []string
+	for _, sel := range selections {
+		if sel.Region != "" && !seen[sel.Region] {
+			seen[sel.Region] = true
+			regions = append(regions, sel.Region)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// UsageReportSink receives each periodic usage report, e.g. to store it
+// for the billing system.
+type UsageReportSink func(ctx context.Context, report *UsageReport) error
+
+// UsageReporter produces a usage report every interval, covering the time
+// since the previous one.
+type UsageReporter struct {
+	endpoint *Endpoint
+	sink     UsageReportSink
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// UsageReporterOption configures the usage reporter.
+type UsageReporterOption func(*UsageReporter)
+
+// WithUsageReportInterval sets how often Run produces a report.
+func WithUsageReportInterval(d time.Duration) UsageReporterOption {
+	return func(r *UsageReporter) {
+		r.interval = d
+	}
+}
+
+// NewUsageReporter creates a reporter that hands the endpoint's usage
+// reports to sink. The first report starts when the reporter is created.
+func NewUsageReporter(endpoint *Endpoint, sink UsageReportSink, opts ...UsageReporterOption) *UsageReporter {
+	r := &UsageReporter{
+		endpoint: endpoint,
+		sink:     sink,
+		interval: DefaultUsageReportInterval,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.last = r.now()
+	return r
+}
+
+// Run reports every interval until ctx is done.
+func (r *UsageReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Report(ctx); err != nil {
+				componentLogger(ctx, ComponentUsage).Warn().Err(err).Msg("Failed to deliver usage report")
+			}
+		}
+	}
+}
+
+// Report produces the report for the time since the previous one and
+// hands it to the sink. A period whose report was not delivered is
+// included in the next one.
+func (r *UsageReporter) Report(ctx context.Context) (*UsageReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	report := buildUsageReport(r.endpoint.history.auditEntries(), r.last, now, now)
+	if err := r.sink(ctx, report); err != nil {
+		return report, err
+	}
+	r.last = now
+
+	componentLogger(ctx, ComponentUsage).Debug().
+		Int("tenants", len(report.Tenants)).
+		Time("from", report.From).
+		Time("to", report.To).
+		Msg("Usage report delivered")
+	return report, nil
+}
@@ -0,0 +1,177 @@
+//go:build unit
+
+package globalvm
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/models"
+	"github.com/bacalhau-project/bacalhau/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildUsageReport(t *testing.T) {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours float64) time.Time { return base.Add(time.Duration(hours * float64(time.Hour))) }
+
+	entries := []AuditEntry{
+		// Placed on submission on two nodes with 2 GPUs each, finished after 3h
+		{JobID: "a-1", Action: AuditActionSubmit, ClientID: "tenant-a", At: at(1), Nodes: 2, GPUsPerNode: 2, Regions: []string{"eu-west", "us-east"}},
+		{JobID: "a-1", Action: AuditActionFinish, At: at(4)},
+		// Queued for 2h, preempted after 1h, placed again 1h later and still running
+		{JobID: "a-2", Action: AuditActionSubmit, ClientID: "tenant-a", At: at(2), GPUsPerNode: 1},
+		{JobID: "a-2", Action: AuditActionStart, At: at(4), Nodes: 1, Regions: []string{"ap-south"}},
+		{JobID: "a-2", Action: AuditActionPreempt, At: at(5), Detail: "higher priority job"},
+		{JobID: "a-2", Action: AuditActionStart, At: at(6), Nodes: 1, Regions: []string{"ap-south"}},
+		// CPU-only job, queued for 4h then cancelled
+		{JobID: "b-1", Action: AuditActionSubmit, ClientID: "tenant-b", At: at(3)},
+		{JobID: "b-1", Action: AuditActionStart, At: at(7), Nodes: 1, Regions: []string{"eu-west"}},
+		{JobID: "b-1", Action: AuditActionCancel, At: at(8)},
+		// Submitted after the period
+		{JobID: "c-1", Action: AuditActionSubmit, ClientID: "tenant-c", At: at(30), Nodes: 1, GPUsPerNode: 8},
+		// Submission no longer in the log
+		{JobID: "gone", Action: AuditActionFinish, At: at(5)},
+	}
+
+	report := buildUsageReport(entries, base, at(24), at(10))
+	require.Len(t, report.Tenants, 2)
+	assert.Equal(t, at(10), report.GeneratedAt)
+
+	a := report.Tenants[0]
+	assert.Equal(t, "tenant-a", a.TenantID)
+	assert.Equal(t, 2, a.JobsScheduled)
+	assert.Equal(t, 1, a.JobsQueued)
+	assert.Equal(t, []string{"ap-south", "eu-west", "us-east"}, a.Regions)
+	// 3h*4 GPUs for a-1, 1h + 4h open run up to now for a-2
+	assert.InDelta(t, 17.0, a.GPUHours, 1e-9)
+	assert.Equal(t, time.Hour, a.AverageWait, "a-1 waited 0h, a-2 2h")
+	assert.Equal(t, 1, a.Preemptions)
+
+	b := report.Tenants[1]
+	assert.Equal(t, "tenant-b", b.TenantID)
+	assert.Equal(t, 1, b.JobsQueued)
+	assert.Equal(t, []string{"eu-west"}, b.Regions)
+	assert.Zero(t, b.GPUHours)
+	assert.Equal(t, 4*time.Hour, b.AverageWait)
+
+	// A later period only sees the time a job ran in it
+	report = buildUsageReport(entries, at(4.5), at(6.5), at(10))
+	require.Len(t, report.Tenants, 1)
+	assert.Zero(t, report.Tenants[0].JobsScheduled)
+	assert.InDelta(t, 1.0, report.Tenants[0].GPUHours, 1e-9)
+	assert.Equal(t, 1, report.Tenants[0].Preemptions)
+	assert.Zero(t, report.Tenants[0].AverageWait, "first placement of a-2 was before the period")
+}
+
+func TestEndpoint_UsageReport(t *testing.T) {
+	var ranks []orchestrator.NodeRank
+	for id, region := range map[string]string{"node-1": "eu-west", "node-2": "us-east"} {
+		node := createTestNodeInfo(id, region)
+		node.ComputeNodeInfo.AvailableCapacity.GPU = 4
+		ranks = append(ranks, orchestrator.NodeRank{NodeInfo: node, Rank: 10})
+	}
+	selector := &mockNodeSelector{nodes: ranks}
+	capacity := &mockCapacityProvider{capacity: &GlobalResources{AvailableCPU: 10, AvailableMemory: 1 << 30, AvailableGPU: 8}}
+	endpoint := NewEndpoint(NewScheduler(selector, capacity), capacity)
+	ctx := context.Background()
+
+	job := createTestJob("gpu-job", models.JobTypeBatch, 2)
+	job.Tasks[0].ResourcesConfig = &models.ResourcesConfig{GPU: "2"}
+	_, err := endpoint.SubmitJob(ctx, GlobalJobRequest{Job: job, ClientID: "tenant-a"})
+	require.NoError(t, err)
+
+	submit := endpoint.GetAuditLog("gpu-job")[0]
+	assert.Equal(t, 2, submit.GPUsPerNode)
+	assert.Equal(t, 2, submit.Nodes)
+	assert.Equal(t, []string{"eu-west", "us-east"}, submit.Regions)
+
+	endpoint.RecordPreemption("gpu-job", "maintenance")
+	endpoint.RecordJobStart("gpu-job", []NodeSelection{{NodeID: "node-1", Region: "eu-west"}})
+	endpoint.RecordJobFinish("gpu-job")
+	actions := []AuditAction{}
+	for _, entry := range endpoint.GetAuditLog("gpu-job") {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []AuditAction{AuditActionSubmit, AuditActionPreempt, AuditActionStart, AuditActionFinish}, actions)
+
+	report := endpoint.UsageReport(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.Len(t, report.Tenants, 1)
+	assert.Equal(t, "tenant-a", report.Tenants[0].TenantID)
+	assert.Equal(t, 1, report.Tenants[0].JobsScheduled)
+	assert.Equal(t, 1, report.Tenants[0].Preemptions)
+	assert.Equal(t, []string{"eu-west", "us-east"}, report.Tenants[0].Regions)
+}
+
+func TestUsageReport_Export(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	report := &UsageReport{
+		From: from,
+		To:   from.Add(24 * time.Hour),
+		Tenants: []TenantUsage{{
+			TenantID: "tenant-a", JobsScheduled: 3, JobsQueued: 1, Regions: []string{"eu-west", "us-east"},
+			GPUHours: 12.5, AverageWait: 90 * time.Second, Preemptions: 2,
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, usageCSVHeader, rows[0])
+	assert.Equal(t, []string{
+		"tenant-a", "2026-03-01T00:00:00Z", "2026-03-02T00:00:00Z", "3", "1", "eu-west;us-east", "12.5000", "90.000", "2",
+	}, rows[1])
+
+	buf.Reset()
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded UsageReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.Tenants, decoded.Tenants)
+	assert.True(t, report.To.Equal(decoded.To))
+}
+
+func TestUsageReporter_Report(t *testing.T) {
+	endpoint := NewEndpoint(nil, nil)
+	clock := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	sinkErr := errors.New("billing unavailable")
+
+	var delivered []*UsageReport
+	fail := true
+	reporter := NewUsageReporter(endpoint, func(_ context.Context, report *UsageReport) error {
+		if fail {
+			return sinkErr
+		}
+		delivered = append(delivered, report)
+		return nil
+	})
+	reporter.now = func() time.Time { return clock }
+	reporter.last = clock
+
+	endpoint.history.recordAudit(AuditEntry{JobID: "j", Action: AuditActionSubmit, ClientID: "tenant-a", At: clock.Add(time.Minute)})
+	clock = clock.Add(time.Hour)
+	_, err := reporter.Report(context.Background())
+	assert.ErrorIs(t, err, sinkErr)
+
+	// The undelivered period is covered by the next report
+	fail = false
+	clock = clock.Add(time.Hour)
+	report, err := reporter.Report(context.Background())
+	require.NoError(t, err)
+	require.Len(t, delivered, 1)
+	assert.Equal(t, clock.Add(-2*time.Hour), report.From)
+	assert.Equal(t, clock, report.To)
+	require.Len(t, report.Tenants, 1)
+	assert.Equal(t, 1, report.Tenants[0].JobsScheduled)
+
+	report, err = reporter.Report(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Tenants)
+}